@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/agents"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Agent flag names.
+const (
+	AgentCAFileFlag           = "ca-file"
+	AgentDataDirFlag          = "data-dir"
+	AgentDefaultTFVersionFlag = "default-tf-version"
+	AgentInsecureFlag         = "insecure"
+	AgentLogLevelFlag         = "log-level"
+	AgentNameFlag             = "name"
+	AgentPoolFlag             = "pool"
+	AgentServerFlag           = "server"
+	AgentTFChecksumsFileFlag  = "tf-checksums-file"
+	AgentTFDownloadURLFlag    = "tf-download-url"
+	AgentTokenFlagName        = "token" // nolint: gosec
+	DefaultAgentDataDir       = "~/.atlantis-agent"
+	agentJobsDirName          = "jobs"
+	agentDefaultLogLevelName  = "info"
+)
+
+// AgentCmd runs an agent that pulls the Terraform commands of a pool from
+// the server and runs them.
+type AgentCmd struct {
+	Viper  *viper.Viper
+	Logger logging.SimpleLogging
+}
+
+// agentConfig holds the agent's config, parsed from its flags.
+type agentConfig struct {
+	CAFile           string `mapstructure:"ca-file"`
+	DataDir          string `mapstructure:"data-dir"`
+	DefaultTFVersion string `mapstructure:"default-tf-version"`
+	Insecure         bool   `mapstructure:"insecure"`
+	LogLevel         string `mapstructure:"log-level"`
+	Name             string `mapstructure:"name"`
+	Pool             string `mapstructure:"pool"`
+	Server           string `mapstructure:"server"`
+	TFChecksumsFile  string `mapstructure:"tf-checksums-file"`
+	TFDownloadURL    string `mapstructure:"tf-download-url"`
+	Token            string `mapstructure:"token"`
+}
+
+// Init returns the runnable cobra command.
+func (a *AgentCmd) Init() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "agent",
+		Short: "Run Terraform commands for the atlantis server",
+		Long: `Connect to the atlantis server and run the Terraform commands of the repos
+whose agent_pool is --pool. Run agents close to the infrastructure that the
+server can't reach, ex. in a private network. Flags can also be set with
+environment variables prefixed with ATLANTIS_AGENT_, ex. ATLANTIS_AGENT_TOKEN.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := a.run()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "\033[31mError: %s\033[39m\n\n", err.Error())
+			}
+			return err
+		},
+	}
+
+	a.Viper.SetEnvPrefix("ATLANTIS_AGENT")
+	a.Viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	a.Viper.AutomaticEnv()
+
+	c.Flags().String(AgentServerFlag, "", "Host and port of the server's --"+AgentPortFlag+", ex. atlantis.example.com:9090. Required.")
+	c.Flags().String(AgentTokenFlagName, "", "The server's --"+AgentTokenFlag+". Required. Should be specified via the ATLANTIS_AGENT_TOKEN environment variable.")
+	c.Flags().String(AgentPoolFlag, "", "Pool to run the commands of. Repos select a pool with agent_pool in the server-side repo config. Required.")
+	c.Flags().String(AgentNameFlag, "", "Name of the agent in the server's logs. Defaults to the hostname.")
+	c.Flags().String(AgentDataDirFlag, DefaultAgentDataDir, "Path to the directory where Terraform binaries and the repos of running commands are stored.")
+	c.Flags().String(AgentDefaultTFVersionFlag, "", "Terraform version to run for projects that don't set one. Defaults to the terraform binary in the PATH.")
+	c.Flags().String(AgentTFDownloadURLFlag, DefaultTFDownloadURL, "Base URL to download Terraform versions from. Can be a file:// URL to install versions from a local dir.")
+	c.Flags().String(AgentTFChecksumsFileFlag, "", "Path to a file of pinned sha256 checksums of Terraform releases. If set, only releases with a checksum in the file are downloaded.")
+	c.Flags().String(AgentCAFileFlag, "", "Path to the PEM file of the CA that signed the server's --"+SSLCertFileFlag+". Defaults to the system's CAs.")
+	c.Flags().Bool(AgentInsecureFlag, false, "Connect to the server without TLS. Only use this if the server doesn't set --"+SSLCertFileFlag+".")
+	c.Flags().String(AgentLogLevelFlag, agentDefaultLogLevelName, fmt.Sprintf("Log level. Either %s.", strings.Join(ValidLogLevels, ", ")))
+	a.Viper.BindPFlags(c.Flags()) // nolint: errcheck
+	return c
+}
+
+func (a *AgentCmd) run() error {
+	var cfg agentConfig
+	if err := a.Viper.Unmarshal(&cfg); err != nil {
+		return err
+	}
+	if err := a.validate(&cfg); err != nil {
+		return err
+	}
+	a.Logger.SetLevel(server.UserConfig{LogLevel: cfg.LogLevel}.ToLogLevel())
+
+	dataDir, err := homedir.Expand(cfg.DataDir)
+	if err != nil {
+		return errors.Wrap(err, "determining data dir")
+	}
+	binDir := filepath.Join(dataDir, server.BinDirName)
+	jobsDir := filepath.Join(dataDir, agentJobsDirName)
+	for _, dir := range []string{binDir, jobsDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return errors.Wrapf(err, "creating %s", dir)
+		}
+	}
+	tfDownloader, err := terraform.NewDownloader(cfg.TFChecksumsFile)
+	if err != nil {
+		return errors.Wrapf(err, "loading --%s", AgentTFChecksumsFileFlag)
+	}
+	// The plugin cache isn't used because the providers are copied back to
+	// the server with the other files in the repo.
+	tfClient, err := terraform.NewClient(
+		a.Logger,
+		binDir,
+		"",
+		"",
+		"",
+		cfg.DefaultTFVersion,
+		AgentDefaultTFVersionFlag,
+		cfg.TFDownloadURL,
+		tfDownloader,
+		false,
+		&jobs.NoopProjectOutputHandler{})
+	if err != nil {
+		return errors.Wrap(err, "initializing terraform")
+	}
+
+	conn, err := a.dial(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close() // nolint: errcheck
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		a.Logger.Warn("Received interrupt. Stopping agent")
+		cancel()
+	}()
+
+	agent := &agents.Agent{
+		Client:    agents.NewAgentsClient(conn),
+		Name:      cfg.Name,
+		Pool:      cfg.Pool,
+		Terraform: tfClient,
+		DataDir:   jobsDir,
+		Log:       a.Logger,
+	}
+	return agent.Run(ctx)
+}
+
+func (a *AgentCmd) validate(cfg *agentConfig) error {
+	required := []struct {
+		flag  string
+		value string
+	}{
+		{AgentServerFlag, cfg.Server},
+		{AgentTokenFlagName, cfg.Token},
+		{AgentPoolFlag, cfg.Pool},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			return fmt.Errorf("--%s must be set", r.flag)
+		}
+	}
+	if !isValidLogLevel(strings.ToLower(cfg.LogLevel)) {
+		return fmt.Errorf("invalid log level: must be one of %v", ValidLogLevels)
+	}
+	if cfg.Insecure && cfg.CAFile != "" {
+		return fmt.Errorf("--%s and --%s can't both be set", AgentInsecureFlag, AgentCAFileFlag)
+	}
+	if cfg.Name == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return errors.Wrapf(err, "determining hostname, set --%s", AgentNameFlag)
+		}
+		cfg.Name = hostname
+	}
+	return nil
+}
+
+// dial connects to the server with the agent's token.
+func (a *AgentCmd) dial(cfg agentConfig) (*grpc.ClientConn, error) {
+	opts := []grpc.DialOption{grpc.WithPerRPCCredentials(agentToken{token: cfg.Token, insecure: cfg.Insecure})}
+	if cfg.Insecure {
+		opts = append(opts, grpc.WithInsecure())
+	} else {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if cfg.CAFile != "" {
+			pem, err := os.ReadFile(cfg.CAFile)
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading --%s", AgentCAFileFlag)
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("--%s %q has no PEM certificates", AgentCAFileFlag, cfg.CAFile)
+			}
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	}
+	conn, err := grpc.Dial(cfg.Server, opts...)
+	return conn, errors.Wrapf(err, "connecting to %s", cfg.Server)
+}
+
+// agentToken authenticates the agent's requests to the server.
+type agentToken struct {
+	token    string
+	insecure bool
+}
+
+func (t agentToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+func (t agentToken) RequireTransportSecurity() bool {
+	return !t.insecure
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/artifacts"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// DecryptStateSnapshotCmd prints a state snapshot that the server stored
+// before an apply, ex. to investigate or roll back a bad apply.
+type DecryptStateSnapshotCmd struct {
+	Viper *viper.Viper
+	// Out is where the state is printed. Defaults to stdout.
+	Out io.Writer
+}
+
+// Init returns the runnable cobra command.
+func (d *DecryptStateSnapshotCmd) Init() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "decrypt-state-snapshot <key>",
+		Short: "Print a state snapshot taken before an apply",
+		Long: `Download the state snapshot with <key> from --artifact-store, decrypt it with
+--state-snapshot-key and print it. The key is logged by the server when it takes
+the snapshot, ex. state-snapshots/owner/repo/project/default/20220102T150405Z-pull1-abc1234.tfstate.enc.
+The printed state can be pushed with terraform state push to roll back.
+Flags can also be set with environment variables prefixed with ATLANTIS_, ex.
+ATLANTIS_STATE_SNAPSHOT_KEY.`,
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := d.run(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "\033[31mError: %s\033[39m\n\n", err.Error())
+			}
+			return err
+		},
+	}
+
+	d.Viper.SetEnvPrefix("ATLANTIS")
+	d.Viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	d.Viper.AutomaticEnv()
+
+	c.Flags().String(ArtifactStoreFlag, "", "The server's --"+ArtifactStoreFlag+". Required.")
+	c.Flags().String(StateSnapshotKeyFlag, "", "The server's --"+StateSnapshotKeyFlag+". Required. Should be specified via the ATLANTIS_STATE_SNAPSHOT_KEY environment variable.")
+	d.Viper.BindPFlags(c.Flags()) // nolint: errcheck
+	return c
+}
+
+func (d *DecryptStateSnapshotCmd) run(key string) error {
+	storeURL := d.Viper.GetString(ArtifactStoreFlag)
+	encodedKey := d.Viper.GetString(StateSnapshotKeyFlag)
+	if storeURL == "" {
+		return fmt.Errorf("--%s must be set", ArtifactStoreFlag)
+	}
+	if encodedKey == "" {
+		return fmt.Errorf("--%s must be set", StateSnapshotKeyFlag)
+	}
+	encryptionKey, err := artifacts.ParseKey(encodedKey)
+	if err != nil {
+		return errors.Wrapf(err, "invalid --%s", StateSnapshotKeyFlag)
+	}
+	store, err := artifacts.NewStore(storeURL)
+	if err != nil {
+		return errors.Wrapf(err, "invalid --%s", ArtifactStoreFlag)
+	}
+	encrypted, err := store.Get(key)
+	if err != nil {
+		return err
+	}
+	state, err := artifacts.Decrypt(encryptionKey, encrypted)
+	if err != nil {
+		return err
+	}
+	out := d.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	_, err = out.Write(state)
+	return err
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Required contexts flag names. The server's --atlantis-url and --api-secret
+// flags are reused so their environment variables work for both.
+const (
+	RequiredContextsBranchFlag   = "branch"
+	RequiredContextsCommandsFlag = "commands"
+	RequiredContextsRepoFlag     = "repo"
+	RequiredContextsSyncFlag     = "sync"
+	RequiredContextsVCSTypeFlag  = "vcs-type"
+)
+
+// RequiredContextsCmd prints the commit status contexts that an Atlantis
+// server reports on a repo's pull requests, or syncs them to the required
+// status checks of a branch.
+type RequiredContextsCmd struct {
+	Viper *viper.Viper
+	// Out is where the contexts are printed. Defaults to stdout.
+	Out io.Writer
+}
+
+// requiredContextsConfig holds the command's config, parsed from its flags.
+type requiredContextsConfig struct {
+	AtlantisURL string `mapstructure:"atlantis-url"`
+	APISecret   string `mapstructure:"api-secret"`
+	Branch      string `mapstructure:"branch"`
+	Commands    string `mapstructure:"commands"`
+	Repo        string `mapstructure:"repo"`
+	Sync        bool   `mapstructure:"sync"`
+	VCSType     string `mapstructure:"vcs-type"`
+}
+
+// Init returns the runnable cobra command.
+func (r *RequiredContextsCmd) Init() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "required-contexts",
+		Short: "Print or sync the status checks that branch protection should require",
+		Long: `Print the contexts of the commit statuses that the atlantis server at
+--atlantis-url reports on every pull request of --repo, to require them in the
+repo's branch protection. With --sync, update the required status checks of
+--branch on GitHub to them instead, so they don't need to be maintained by hand.
+Flags can also be set with environment variables prefixed with ATLANTIS_, ex.
+ATLANTIS_API_SECRET.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := r.run()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "\033[31mError: %s\033[39m\n\n", err.Error())
+			}
+			return err
+		},
+	}
+
+	r.Viper.SetEnvPrefix("ATLANTIS")
+	r.Viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	r.Viper.AutomaticEnv()
+
+	c.Flags().String(AtlantisURLFlag, "", "URL of the atlantis server. Required.")
+	c.Flags().String(APISecretFlag, "", "The server's --"+APISecretFlag+". Required. Should be specified via the ATLANTIS_API_SECRET environment variable.")
+	c.Flags().String(RequiredContextsRepoFlag, "", "Full name of the repo, ex. owner/repo. Required.")
+	c.Flags().String(RequiredContextsVCSTypeFlag, "Github", "VCS host of the repo, ex. Github or Gitlab.")
+	c.Flags().String(RequiredContextsBranchFlag, "", "Branch to read the repo's atlantis.yaml from, and whose required status checks are synced. Required with --"+RequiredContextsSyncFlag+".")
+	c.Flags().String(RequiredContextsCommandsFlag, "", "Comma-separated commands whose statuses are required: plan, policy_check and apply. Defaults to plan, and policy_check if the server checks policies.")
+	c.Flags().Bool(RequiredContextsSyncFlag, false, "Update the required status checks of --"+RequiredContextsBranchFlag+" instead of printing them. Only supported for GitHub.")
+	r.Viper.BindPFlags(c.Flags()) // nolint: errcheck
+	return c
+}
+
+func (r *RequiredContextsCmd) run() error {
+	var cfg requiredContextsConfig
+	if err := r.Viper.Unmarshal(&cfg); err != nil {
+		return err
+	}
+	if err := r.validate(cfg); err != nil {
+		return err
+	}
+	out := r.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	request := controllers.RequiredContextsRequest{
+		Repository: cfg.Repo,
+		Type:       cfg.VCSType,
+		Branch:     cfg.Branch,
+	}
+	if cfg.Commands != "" {
+		request.Commands = strings.Split(cfg.Commands, ",")
+	}
+
+	if cfg.Sync {
+		var sync events.RequiredStatusChecksSync
+		if err := r.call(cfg, "POST", "/api/required-contexts/sync", request, &sync); err != nil {
+			return err
+		}
+		for _, c := range sync.Added {
+			fmt.Fprintf(out, "added %s\n", c)
+		}
+		for _, c := range sync.Removed {
+			fmt.Fprintf(out, "removed %s\n", c)
+		}
+		if len(sync.Added) == 0 && len(sync.Removed) == 0 {
+			fmt.Fprintf(out, "%s already requires %s\n", cfg.Branch, strings.Join(sync.Contexts, ", "))
+		}
+		return nil
+	}
+
+	var contexts controllers.RequiredContextsResponse
+	if err := r.call(cfg, "GET", "/api/required-contexts", request, &contexts); err != nil {
+		return err
+	}
+	for _, c := range contexts.Contexts {
+		fmt.Fprintln(out, c)
+	}
+	return nil
+}
+
+func (r *RequiredContextsCmd) validate(cfg requiredContextsConfig) error {
+	required := []struct {
+		flag  string
+		value string
+	}{
+		{AtlantisURLFlag, cfg.AtlantisURL},
+		{APISecretFlag, cfg.APISecret},
+		{RequiredContextsRepoFlag, cfg.Repo},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			return fmt.Errorf("--%s must be set", r.flag)
+		}
+	}
+	if cfg.Sync && cfg.Branch == "" {
+		return fmt.Errorf("--%s must be set with --%s", RequiredContextsBranchFlag, RequiredContextsSyncFlag)
+	}
+	return nil
+}
+
+// call sends request to the server's API at path and decodes the response
+// into resp. GET requests send request as query parameters.
+func (r *RequiredContextsCmd) call(cfg requiredContextsConfig, method string, path string, request controllers.RequiredContextsRequest, resp interface{}) error {
+	u := strings.TrimSuffix(cfg.AtlantisURL, "/") + path
+	var body io.Reader
+	if method == "GET" {
+		query := url.Values{}
+		query.Set("repository", request.Repository)
+		query.Set("type", request.Type)
+		query.Set("branch", request.Branch)
+		query.Set("commands", strings.Join(request.Commands, ","))
+		u += "?" + query.Encode()
+	} else {
+		data, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Atlantis-Token", cfg.APISecret)
+	req.Header.Set("Content-Type", "application/json")
+	httpResp, err := (&http.Client{Timeout: time.Minute}).Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "calling %s", cfg.AtlantisURL)
+	}
+	defer httpResp.Body.Close() // nolint: errcheck
+	if httpResp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(httpResp.Body).Decode(&errResp) // nolint: errcheck
+		return fmt.Errorf("got status %d from %s: %s", httpResp.StatusCode, cfg.AtlantisURL, errResp.Error)
+	}
+	return errors.Wrap(json.NewDecoder(httpResp.Body).Decode(resp), "parsing response")
+}
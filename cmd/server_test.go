@@ -35,6 +35,9 @@ import (
 // Used for testing.
 var passedConfig server.UserConfig
 
+// diagnosed is set to true if Diagnose was called instead of Start.
+var diagnosed bool
+
 type ServerCreatorMock struct{}
 
 func (s *ServerCreatorMock) NewServer(userConfig server.UserConfig, config server.Config) (ServerStarter, error) {
@@ -45,72 +48,166 @@ func (s *ServerCreatorMock) NewServer(userConfig server.UserConfig, config serve
 type ServerStarterMock struct{}
 
 func (s *ServerStarterMock) Start() error {
+	diagnosed = false
+	return nil
+}
+
+func (s *ServerStarterMock) Diagnose() error {
+	diagnosed = true
 	return nil
 }
 
 // Adding a new flag? Add it to this slice for testing in alphabetical
 // order.
 var testFlags = map[string]interface{}{
-	ADTokenFlag:                "ad-token",
-	ADUserFlag:                 "ad-user",
-	ADWebhookPasswordFlag:      "ad-wh-pass",
-	ADWebhookUserFlag:          "ad-wh-user",
-	AtlantisURLFlag:            "url",
-	AllowForkPRsFlag:           true,
-	AllowRepoConfigFlag:        true,
-	AutomergeFlag:              true,
-	AutoplanFileListFlag:       "**/*.tf,**/*.yml",
-	BitbucketBaseURLFlag:       "https://bitbucket-base-url.com",
-	BitbucketTokenFlag:         "bitbucket-token",
-	BitbucketUserFlag:          "bitbucket-user",
-	BitbucketWebhookSecretFlag: "bitbucket-secret",
-	CheckoutStrategyFlag:       "merge",
-	DataDirFlag:                "/path",
-	DefaultTFVersionFlag:       "v0.11.0",
-	DisableApplyAllFlag:        true,
-	DisableApplyFlag:           true,
-	DisableMarkdownFoldingFlag: true,
-	DisableRepoLockingFlag:     true,
-	GHHostnameFlag:             "ghhostname",
-	GHTokenFlag:                "token",
-	GHUserFlag:                 "user",
-	GHAppIDFlag:                int64(0),
-	GHAppKeyFlag:               "",
-	GHAppKeyFileFlag:           "",
-	GHAppSlugFlag:              "atlantis",
-	GHOrganizationFlag:         "",
-	GHWebhookSecretFlag:        "secret",
-	GitlabHostnameFlag:         "gitlab-hostname",
-	GitlabTokenFlag:            "gitlab-token",
-	GitlabUserFlag:             "gitlab-user",
-	GitlabWebhookSecretFlag:    "gitlab-secret",
-	LockingDBType:              "boltdb",
-	LogLevelFlag:               "debug",
-	StatsNamespace:             "atlantis",
-	AllowDraftPRs:              true,
-	PortFlag:                   8181,
-	ParallelPoolSize:           100,
-	RepoAllowlistFlag:          "github.com/runatlantis/atlantis",
-	RequireApprovalFlag:        true,
-	RequireMergeableFlag:       true,
-	SilenceNoProjectsFlag:      false,
-	SilenceForkPRErrorsFlag:    true,
-	SilenceAllowlistErrorsFlag: true,
-	SilenceVCSStatusNoPlans:    true,
-	SkipCloneNoChanges:         true,
-	SlackTokenFlag:             "slack-token",
-	SSLCertFileFlag:            "cert-file",
-	SSLKeyFileFlag:             "key-file",
-	TFDownloadURLFlag:          "https://my-hostname.com",
-	TFEHostnameFlag:            "my-hostname",
-	TFELocalExecutionModeFlag:  true,
-	TFETokenFlag:               "my-token",
-	VCSStatusName:              "my-status",
-	WriteGitCredsFlag:          true,
-	DisableAutoplanFlag:        true,
-	EnablePolicyChecksFlag:     false,
-	EnableRegExpCmdFlag:        false,
-	EnableDiffMarkdownFormat:   false,
+	ADTokenFlag:                    "ad-token",
+	ADUserFlag:                     "ad-user",
+	ADWebhookPasswordFlag:          "ad-wh-pass",
+	ADWebhookUserFlag:              "ad-wh-user",
+	AgentPortFlag:                  9090,
+	AgentTokenFlag:                 "agent-token",
+	AtlantisURLFlag:                "url",
+	AllowForkPRsFlag:               true,
+	ApplyCommentOutputsFlag:        "vpc_id,endpoint",
+	AllowRepoConfigFlag:            true,
+	ApplyHeartbeatIntervalFlag:     "5m",
+	ArtifactStoreFlag:              "/path/to/artifacts",
+	AnalyticsBatchSizeFlag:         100,
+	AnalyticsFlushIntervalFlag:     "30s",
+	AnalyticsSinkFlag:              "bigquery://project/atlantis/commands",
+	AnnotationEnvironmentRegexFlag: "(prod|staging)",
+	AnnotationWebhookURLFlag:       "https://annotations.example.com",
+	AutomergeFlag:                  true,
+	AutoplanFileListFlag:           "**/*.tf,**/*.yml",
+	AutoplanFmtFlag:                "check",
+	BitbucketBaseURLFlag:           "https://bitbucket-base-url.com",
+	BitbucketCABundleFlag:          "bitbucket-ca-bundle",
+	BitbucketOAuthClientIDFlag:     "",
+	BitbucketOAuthClientSecretFlag: "",
+	BitbucketProxyFlag:             "http://bitbucket-proxy:3128",
+	BitbucketTokenFlag:             "bitbucket-token",
+	BitbucketUserFlag:              "bitbucket-user",
+	BitbucketWebhookSecretFlag:     "bitbucket-secret",
+	BitbucketWorkspaceTokenFlag:    "",
+	CancelSupersededPlansFlag:      true,
+	CheckoutDepthFlag:              50,
+	CheckoutFilterFlag:             "blob:none",
+	CheckoutStrategyFlag:           "merge",
+	AlertApplyBranchRegexFlag:      "^main$",
+	AlertDriftProjectRegexFlag:     "^prod",
+	OpsgenieAPIKeyFlag:             "opsgenie-key",
+	OpsgenieURLFlag:                "https://api.eu.opsgenie.com",
+	PagerDutyRoutingKeyFlag:        "pagerduty-key",
+	CloudEventsSinksFlag:           "https://events.example.com,nats://nats:4222/atlantis.events",
+	CommitStatusGranularityFlag:    "command",
+	CommitStatusNameFlag:           "{{ .StatusName }}-{{ .Command }}",
+	DataDirFlag:                    "/path",
+	DebugAdminTeamsFlag:            "admin1,admin2",
+	DefaultTFVersionFlag:           "v0.11.0",
+	DependencyUpgradeIntervalFlag:  "168h",
+	DependencyUpgradeReposFlag:     "github.com/runatlantis/atlantis",
+	DiagnoseFlag:                   true,
+	DisableApplyAllFlag:            true,
+	DisableApplyFlag:               true,
+	DisableMarkdownFoldingFlag:     true,
+	DisableRepoLockingFlag:         true,
+	GerritBaseURLFlag:              "https://gerrit.example.com",
+	GerritTokenFlag:                "gerrit-token",
+	GerritUserFlag:                 "gerrit-user",
+	GerritWebhookSecretFlag:        "gerrit-secret",
+	GHCABundleFlag:                 "gh-ca-bundle",
+	GHHostnameFlag:                 "ghhostname",
+	GHProxyFlag:                    "http://gh-proxy:3128",
+	GHTokenFlag:                    "token",
+	GHUserFlag:                     "user",
+	GHAppIDFlag:                    int64(0),
+	GHAppKeyFlag:                   "",
+	GHAppKeyFileFlag:               "",
+	GHAppSlugFlag:                  "atlantis",
+	GHOrganizationFlag:             "",
+	GHWebhookSecretFlag:            "secret",
+	GitlabCABundleFlag:             "gitlab-ca-bundle",
+	GitlabHostnameFlag:             "gitlab-hostname",
+	GitlabProxyFlag:                "http://gitlab-proxy:3128",
+	GitlabTokenFlag:                "gitlab-token",
+	GitlabUserFlag:                 "gitlab-user",
+	GitlabWebhookGroupsFlag:        "infra,platform/terraform",
+	GitlabWebhookSecretFlag:        "gitlab-secret",
+	GrafanaAPIKeyFlag:              "grafana-key",
+	GrafanaURLFlag:                 "https://grafana.example.com",
+	GRPCAPIPortFlag:                9091,
+	APISecretFlag:                  "api-secret",
+	JobMaxLogBytesFlag:             4096,
+	JiraApprovedStatusFlag:         "Ready",
+	JiraCloseTransitionFlag:        "Close",
+	JiraIssueTypeFlag:              "Change",
+	JiraProjectFlag:                "CHG",
+	JiraTokenFlag:                  "jira-token",
+	JiraURLFlag:                    "https://example.atlassian.net",
+	JiraUserFlag:                   "jira-user",
+	JobRetentionFlag:               "720h",
+	LocaleFlag:                     "de",
+	LockingDBType:                  "boltdb",
+	LogLevelFlag:                   "debug",
+	MarkdownTemplateDirFlag:        "/path/to/templates",
+	MaxConcurrentAppliesFlag:       2,
+	MinFreeDiskMBFlag:              2048,
+	MaxPlanAgeFlag:                 "24h",
+	StatsNamespace:                 "atlantis",
+	AllowDraftPRs:                  true,
+	PortFlag:                       8181,
+	OIDCSigningKeyFileFlag:         "/path/to/oidc-key.pem",
+	ParallelPoolSize:               100,
+	PlanSigningKeyFlag:             "plan-signing-key",
+	ProjectStatusNameFlag:          "{{ .StatusName }}-{{ .Command }}-{{ .Project }}",
+	ProvidersLockPlatformsFlag:     "linux_amd64",
+	ProvisionWebhooksFlag:          true,
+	RepoAllowlistFlag:              "github.com/runatlantis/atlantis",
+	RepoConfigEnvAllowlistFlag:     "ENV",
+	RepoConfigReloadIntervalFlag:   "30s",
+	RequireApprovalFlag:            true,
+	RequireMergeableFlag:           true,
+	SecretsDirFlag:                 "/etc/atlantis/secrets",
+	ServiceNowPasswordFlag:         "servicenow-password",
+	ServiceNowURLFlag:              "",
+	ServiceNowUserFlag:             "servicenow-user",
+	SilenceNoProjectsFlag:          false,
+	SilenceForkPRErrorsFlag:        true,
+	SilenceAllowlistErrorsFlag:     true,
+	SilenceVCSStatusNoPlans:        true,
+	SkipCloneNoChanges:             true,
+	SlackTokenFlag:                 "slack-token",
+	SSHCloneFlag:                   false,
+	SSHKeyFileFlag:                 "/path/to/ssh-key",
+	SSHKnownHostsFileFlag:          "/path/to/known_hosts",
+	SSLCertFileFlag:                "cert-file",
+	SSLKeyFileFlag:                 "key-file",
+	StateLockAdminTeamsFlag:        "admin1,admin2",
+	StateSnapshotKeyFlag:           "a2tra2tra2tra2tra2tra2tra2tra2tra2tra2tra2s=",
+	StateLockRetriesFlag:           3,
+	TFChecksumsFileFlag:            "/path/to/checksums",
+	TFDownloadURLFlag:              "https://my-hostname.com",
+	TFEHostnameFlag:                "my-hostname",
+	TFELocalExecutionModeFlag:      true,
+	TFETokenFlag:                   "my-token",
+	ValidateConfigFlag:             false,
+	VCSStatusName:                  "my-status",
+	WriteGitCredsFlag:              true,
+	DisableAutoplanFlag:            true,
+	EnablePolicyChecksFlag:         false,
+	EnableCommentReactionsFlag:     true,
+	EnableFmtFixFlag:               true,
+	EnableRegExpCmdFlag:            false,
+	EnableStatusBadgesFlag:         true,
+	EnableStatusCommentFlag:        true,
+	EnableValidateStepFlag:         true,
+	EnablePlanSummaryCommentFlag:   true,
+	EnableGithubDeploymentsFlag:    true,
+	EnablePullStacksFlag:           true,
+	CheckRemoteStatesFlag:          true,
+	ScanSecretsFlag:                true,
+	EnableDiffMarkdownFormat:       false,
+	FilterPlanOutputFlag:           false,
 }
 
 func TestExecute_Defaults(t *testing.T) {
@@ -307,6 +404,169 @@ func TestExecute_ValidateCheckoutStrategy(t *testing.T) {
 	ErrEquals(t, "invalid checkout strategy: not one of branch or merge", err)
 }
 
+func TestExecute_ValidateAutoplanFmt(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		AutoplanFmtFlag: "write",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, `invalid --autoplan-fmt "write": not one of check or fix`, err)
+}
+
+func TestExecute_ValidateCheckoutDepth(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		CheckoutDepthFlag: -1,
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "invalid --checkout-depth -1: must be 0 or greater", err)
+}
+
+func TestExecute_ValidateCheckoutFilter(t *testing.T) {
+	cases := map[string]bool{
+		"blob:none":      true,
+		"blob:limit=1m":  true,
+		"tree:0":         true,
+		"blob:limit=":    false,
+		"tree:none":      false,
+		"sparse:oid=abc": false,
+	}
+	for filter, valid := range cases {
+		t.Run(filter, func(t *testing.T) {
+			c := setupWithDefaults(map[string]interface{}{
+				CheckoutFilterFlag: filter,
+			}, t)
+			err := c.Execute()
+			if valid {
+				Ok(t, err)
+			} else {
+				ErrEquals(t, fmt.Sprintf("invalid --checkout-filter %q: must be like blob:none, blob:limit=<n> or tree:<depth>", filter), err)
+			}
+		})
+	}
+}
+
+func TestExecute_ValidateCommitStatusGranularity(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		CommitStatusGranularityFlag: "invalid",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "invalid commit status granularity: not one of combined, command or project", err)
+}
+
+func TestExecute_ValidateMaxConcurrentApplies(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		MaxConcurrentAppliesFlag: -1,
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "invalid --max-concurrent-applies -1: must be 0 or greater", err)
+}
+
+func TestExecute_ValidateRepoConfigReloadInterval(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		RepoConfigReloadIntervalFlag: "-1m",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, `invalid --repo-config-reload-interval "-1m": must be a positive duration, ex. 30s`, err)
+}
+
+func TestExecute_ValidateApplyHeartbeatInterval(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		ApplyHeartbeatIntervalFlag: "5",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, `invalid --apply-heartbeat-interval "5": must be a positive duration, ex. 5m`, err)
+}
+
+func TestExecute_ValidateAnalytics(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		AnalyticsBatchSizeFlag: -1,
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "invalid --analytics-batch-size -1: must be 1 or greater", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		AnalyticsFlushIntervalFlag: "0s",
+	}, t)
+	err = c.Execute()
+	ErrEquals(t, `invalid --analytics-flush-interval "0s": must be a positive duration, ex. 5m`, err)
+}
+
+func TestExecute_ValidateGrafana(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		GrafanaURLFlag: "https://grafana.example.com",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "--grafana-url and --grafana-api-key must be set together", err)
+}
+
+func TestExecute_ValidateDependencyUpgrades(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		DependencyUpgradeIntervalFlag: "weekly",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, `invalid --dependency-upgrade-interval "weekly": must be a positive duration, ex. 168h`, err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		DependencyUpgradeReposFlag: "github.com/runatlantis/atlantis,runatlantis/atlantis",
+	}, t)
+	err = c.Execute()
+	ErrEquals(t, `invalid --dependency-upgrade-repos entry "runatlantis/atlantis": must be in the form {hostname}/{owner}/{repo}, ex. github.com/runatlantis/atlantis`, err)
+}
+
+func TestExecute_ValidateAgentPort(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		AgentPortFlag: 9090,
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "--agent-token must be set if --agent-port is set", err)
+}
+
+func TestExecute_ValidateGRPCAPIPort(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		GRPCAPIPortFlag: 9091,
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "--api-secret must be set if --grpc-api-port is set", err)
+}
+
+func TestExecute_ValidateJobRetention(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		JobRetentionFlag: "0s",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, `invalid --job-retention "0s": must be a positive duration, ex. 720h`, err)
+}
+
+func TestExecute_ValidateMaxPlanAge(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		MaxPlanAgeFlag: "two days",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, `invalid --max-plan-age "two days": must be a positive duration, ex. 24h`, err)
+}
+
+func TestExecute_ValidateStateSnapshotKey(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		StateSnapshotKeyFlag: "a2tra2tra2tra2tra2tra2tra2tra2tra2tra2tra2s=",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "if setting --state-snapshot-key, must set --artifact-store", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		StateSnapshotKeyFlag: "c2hvcnQ=",
+		ArtifactStoreFlag:    "/path/to/artifacts",
+	}, t)
+	err = c.Execute()
+	ErrEquals(t, "invalid --state-snapshot-key: key must be 32 bytes, got 5", err)
+}
+
+func TestExecute_ValidateLocale(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		LocaleFlag: "xx",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "unsupported locale \"xx\", supported locales are: de, en, es", err)
+}
+
 func TestExecute_ValidateSSLConfig(t *testing.T) {
 	expErr := "--ssl-key-file and --ssl-cert-file are both required for ssl"
 	cases := []struct {
@@ -356,7 +616,7 @@ func TestExecute_ValidateSSLConfig(t *testing.T) {
 }
 
 func TestExecute_ValidateVCSConfig(t *testing.T) {
-	expErr := "--gh-user/--gh-token or --gh-app-id/--gh-app-key-file or --gh-app-id/--gh-app-key or --gitlab-user/--gitlab-token or --bitbucket-user/--bitbucket-token or --azuredevops-user/--azuredevops-token must be set"
+	expErr := "--gh-user/--gh-token or --gh-app-id/--gh-app-key-file or --gh-app-id/--gh-app-key or --gitlab-user/--gitlab-token or --bitbucket-user/--bitbucket-token or --azuredevops-user/--azuredevops-token or --gerrit-user/--gerrit-token must be set"
 	cases := []struct {
 		description string
 		flags       map[string]interface{}
@@ -444,6 +704,13 @@ func TestExecute_ValidateVCSConfig(t *testing.T) {
 			},
 			true,
 		},
+		{
+			"just gerrit token set",
+			map[string]interface{}{
+				GerritTokenFlag: "token",
+			},
+			true,
+		},
 		{
 			"github user and gitlab token set",
 			map[string]interface{}{
@@ -516,6 +783,15 @@ func TestExecute_ValidateVCSConfig(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"gerrit user and gerrit token set and should be successful",
+			map[string]interface{}{
+				GerritUserFlag:    "user",
+				GerritTokenFlag:   "token",
+				GerritBaseURLFlag: "https://gerrit.mydomain.com",
+			},
+			false,
+		},
 		{
 			"all set should be successful",
 			map[string]interface{}{
@@ -642,7 +918,7 @@ func TestExecute_ADUser(t *testing.T) {
 	Equals(t, "user", passedConfig.AzureDevopsUser)
 }
 
-// If using bitbucket cloud, webhook secrets are not supported.
+// Bitbucket Cloud signs webhooks like Bitbucket Server.
 func TestExecute_BitbucketCloudWithWebhookSecret(t *testing.T) {
 	c := setup(map[string]interface{}{
 		BitbucketUserFlag:          "user",
@@ -650,8 +926,79 @@ func TestExecute_BitbucketCloudWithWebhookSecret(t *testing.T) {
 		RepoAllowlistFlag:          "*",
 		BitbucketWebhookSecretFlag: "my secret",
 	}, t)
-	err := c.Execute()
-	ErrEquals(t, "--bitbucket-webhook-secret cannot be specified for Bitbucket Cloud because it is not supported by Bitbucket", err)
+	Ok(t, c.Execute())
+	Equals(t, "my secret", passedConfig.BitbucketWebhookSecret)
+}
+
+func TestExecute_BitbucketCloudTokenAuth(t *testing.T) {
+	cases := []struct {
+		description string
+		flags       map[string]interface{}
+		expErr      string
+	}{
+		{
+			"oauth consumer",
+			map[string]interface{}{
+				BitbucketUserFlag:              "user",
+				BitbucketOAuthClientIDFlag:     "id",
+				BitbucketOAuthClientSecretFlag: "secret",
+			},
+			"",
+		},
+		{
+			"workspace token",
+			map[string]interface{}{
+				BitbucketUserFlag:           "user",
+				BitbucketWorkspaceTokenFlag: "token",
+			},
+			"",
+		},
+		{
+			"oauth consumer without secret",
+			map[string]interface{}{
+				BitbucketUserFlag:          "user",
+				BitbucketOAuthClientIDFlag: "id",
+			},
+			"--bitbucket-oauth-client-id and --bitbucket-oauth-client-secret must both be set",
+		},
+		{
+			"app password and workspace token",
+			map[string]interface{}{
+				BitbucketUserFlag:           "user",
+				BitbucketTokenFlag:          "token",
+				BitbucketWorkspaceTokenFlag: "token",
+			},
+			"only one of --bitbucket-token, --bitbucket-oauth-client-id/--bitbucket-oauth-client-secret or --bitbucket-workspace-token can be set",
+		},
+		{
+			"workspace token without user",
+			map[string]interface{}{
+				BitbucketWorkspaceTokenFlag: "token",
+			},
+			"--bitbucket-user must be set to the username that Atlantis comments as",
+		},
+		{
+			"workspace token with bitbucket server",
+			map[string]interface{}{
+				BitbucketUserFlag:           "user",
+				BitbucketWorkspaceTokenFlag: "token",
+				BitbucketBaseURLFlag:        "https://bitbucket.mydomain.com",
+			},
+			"--bitbucket-oauth-client-id/--bitbucket-oauth-client-secret and --bitbucket-workspace-token are only supported by Bitbucket Cloud",
+		},
+	}
+	for _, testCase := range cases {
+		t.Run(testCase.description, func(t *testing.T) {
+			testCase.flags[RepoAllowlistFlag] = "*"
+			c := setup(testCase.flags, t)
+			err := c.Execute()
+			if testCase.expErr != "" {
+				ErrEquals(t, testCase.expErr, err)
+			} else {
+				Ok(t, err)
+			}
+		})
+	}
 }
 
 // Base URL must have a scheme.
@@ -673,6 +1020,53 @@ func TestExecute_BitbucketServerBaseURLScheme(t *testing.T) {
 	ErrEquals(t, "error parsing --bitbucket-webhook-secret flag value \"://mydomain.com\": parse \"://mydomain.com\": missing protocol scheme", c.Execute())
 }
 
+func TestExecute_GitlabWebhookGroupsRequiresGitlab(t *testing.T) {
+	c := setup(map[string]interface{}{
+		GHUserFlag:              "user",
+		GHTokenFlag:             "token",
+		RepoAllowlistFlag:       "*",
+		GitlabWebhookGroupsFlag: "infra",
+	}, t)
+	ErrEquals(t, "--gitlab-webhook-groups requires --gitlab-user/--gitlab-token to be set", c.Execute())
+}
+
+func TestExecute_ProvisionWebhooksRequiresGithubUserOrGitlab(t *testing.T) {
+	c := setup(map[string]interface{}{
+		BitbucketUserFlag:     "user",
+		BitbucketTokenFlag:    "token",
+		RepoAllowlistFlag:     "*",
+		ProvisionWebhooksFlag: true,
+	}, t)
+	ErrEquals(t, "--provision-webhooks requires --gh-user/--gh-token or --gitlab-user/--gitlab-token to be set", c.Execute())
+}
+
+func TestExecute_PullStacksRequiresGithub(t *testing.T) {
+	c := setup(map[string]interface{}{
+		GitlabUserFlag:       "user",
+		GitlabTokenFlag:      "token",
+		RepoAllowlistFlag:    "*",
+		EnablePullStacksFlag: true,
+	}, t)
+	ErrEquals(t, "--enable-pull-stacks requires --gh-user/--gh-token or --gh-app-id to be set", c.Execute())
+}
+
+func TestExecute_GerritBaseURLScheme(t *testing.T) {
+	c := setup(map[string]interface{}{
+		GerritUserFlag:    "user",
+		GerritTokenFlag:   "token",
+		RepoAllowlistFlag: "*",
+	}, t)
+	ErrEquals(t, "--gerrit-base-url must be set and have http:// or https://, got \"\"", c.Execute())
+
+	c = setup(map[string]interface{}{
+		GerritUserFlag:    "user",
+		GerritTokenFlag:   "token",
+		RepoAllowlistFlag: "*",
+		GerritBaseURLFlag: "mydomain.com",
+	}, t)
+	ErrEquals(t, "--gerrit-base-url must be set and have http:// or https://, got \"mydomain.com\"", c.Execute())
+}
+
 // Port should be retained on base url.
 func TestExecute_BitbucketServerBaseURLPort(t *testing.T) {
 	c := setup(map[string]interface{}{
@@ -685,6 +1079,116 @@ func TestExecute_BitbucketServerBaseURLPort(t *testing.T) {
 	Equals(t, "http://mydomain.com:7990", passedConfig.BitbucketBaseURL)
 }
 
+// Proxies must be URLs.
+func TestExecute_InvalidProxy(t *testing.T) {
+	c := setup(map[string]interface{}{
+		GHUserFlag:        "user",
+		GHTokenFlag:       "token",
+		RepoAllowlistFlag: "*",
+		GHProxyFlag:       "proxy.example.com:3128",
+	}, t)
+	ErrEquals(t, "invalid --gh-proxy: proxy URL \"proxy.example.com:3128\" must be like http://proxy.example.com:3128", c.Execute())
+}
+
+func TestExecute_SSHClone(t *testing.T) {
+	c := setup(map[string]interface{}{
+		GHUserFlag:        "user",
+		GHTokenFlag:       "token",
+		RepoAllowlistFlag: "*",
+		SSHCloneFlag:      true,
+	}, t)
+	ErrEquals(t, "--ssh-known-hosts-file must be set when using --ssh-clone", c.Execute())
+
+	c = setup(map[string]interface{}{
+		GHUserFlag:            "user",
+		GHTokenFlag:           "token",
+		RepoAllowlistFlag:     "*",
+		SSHCloneFlag:          true,
+		SSHKnownHostsFileFlag: "/path/to/known_hosts",
+		WriteGitCredsFlag:     true,
+	}, t)
+	ErrEquals(t, "cannot use --ssh-clone and --write-git-creds at the same time", c.Execute())
+}
+
+func TestExecute_Diagnose(t *testing.T) {
+	c := setup(map[string]interface{}{
+		GHUserFlag:        "user",
+		GHTokenFlag:       "token",
+		RepoAllowlistFlag: "*",
+	}, t)
+	Ok(t, c.Execute())
+	Assert(t, !diagnosed, "expected server to be started")
+
+	c = setup(map[string]interface{}{
+		GHUserFlag:        "user",
+		GHTokenFlag:       "token",
+		RepoAllowlistFlag: "*",
+		DiagnoseFlag:      true,
+	}, t)
+	Ok(t, c.Execute())
+	Assert(t, diagnosed, "expected server to be diagnosed instead of started")
+}
+
+func TestExecute_ValidateConfig(t *testing.T) {
+	passedConfig = server.UserConfig{}
+	validCfg := tempFile(t, "repos:\n- id: https://github.com/owner/repo\n  apply_requirements: [approved]\n")
+	defer os.Remove(validCfg) // nolint: errcheck
+	invalidCfg := tempFile(t, "repos:\n- id: github.com/owner/repo\n  apply_requirements: [unknown]\n")
+	defer os.Remove(invalidCfg) // nolint: errcheck
+
+	// VCS credentials aren't required and the server isn't created.
+	c := setup(map[string]interface{}{
+		ValidateConfigFlag: true,
+		RepoConfigFlag:     validCfg,
+	}, t)
+	Ok(t, c.Execute())
+	Equals(t, server.UserConfig{}, passedConfig)
+
+	c = setup(map[string]interface{}{
+		ValidateConfigFlag: true,
+		RepoConfigFlag:     invalidCfg,
+	}, t)
+	err := c.Execute()
+	ErrContains(t, "parsing "+invalidCfg+" file", err)
+	ErrContains(t, "apply_requirements", err)
+
+	c = setup(map[string]interface{}{
+		ValidateConfigFlag: true,
+	}, t)
+	ErrEquals(t, "there's no server-side repo config to validate, set --repo-config or --repo-config-json", c.Execute())
+}
+
+func TestExecute_ChangeTickets(t *testing.T) {
+	c := setup(map[string]interface{}{
+		GHUserFlag:        "user",
+		GHTokenFlag:       "token",
+		RepoAllowlistFlag: "*",
+		JiraURLFlag:       "https://example.atlassian.net",
+		JiraUserFlag:      "user",
+	}, t)
+	ErrEquals(t, "--jira-user, --jira-token and --jira-project must be set when using --jira-url", c.Execute())
+
+	c = setup(map[string]interface{}{
+		GHUserFlag:        "user",
+		GHTokenFlag:       "token",
+		RepoAllowlistFlag: "*",
+		JiraURLFlag:       "https://example.atlassian.net",
+		JiraUserFlag:      "user",
+		JiraTokenFlag:     "token",
+		JiraProjectFlag:   "CHG",
+		ServiceNowURLFlag: "https://example.service-now.com",
+	}, t)
+	ErrEquals(t, "cannot use --jira-url and --servicenow-url at the same time", c.Execute())
+
+	c = setup(map[string]interface{}{
+		GHUserFlag:        "user",
+		GHTokenFlag:       "token",
+		RepoAllowlistFlag: "*",
+		ServiceNowURLFlag: "https://example.service-now.com",
+	}, t)
+	ErrEquals(t, "--servicenow-user and --servicenow-password must be set when using --servicenow-url", c.Execute())
+}
+
 // Can't use both --repo-config and --repo-config-json.
 func TestExecute_RepoCfgFlags(t *testing.T) {
 	c := setup(map[string]interface{}{
@@ -698,6 +1202,50 @@ func TestExecute_RepoCfgFlags(t *testing.T) {
 	ErrEquals(t, "cannot use --repo-config and --repo-config-json at the same time", err)
 }
 
+func TestExecute_RepoConfigGitInvalid(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		RepoConfigGitFlag: "git@github.com:org/atlantis-config.git",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, `invalid --repo-config-git: "git@github.com:org/atlantis-config.git" must be in the form <url>@<ref>, ex. https://github.com/org/atlantis-config.git@main`, err)
+}
+
+func TestExecute_GithubAdditionalHosts(t *testing.T) {
+	cases := []struct {
+		hosts  string
+		expErr string
+	}{
+		{
+			hosts: `[{"hostname":"github.mycompany.com","user":"user","token":"token"}]`,
+		},
+		{
+			hosts:  `[{"hostname":"github.mycompany.com","user":"user"}]`,
+			expErr: "invalid --gh-additional-hosts: hostname, user and token are required for each host",
+		},
+		{
+			hosts:  `[{"hostname":"github.com","user":"user","token":"token"}]`,
+			expErr: `invalid --gh-additional-hosts: host "github.com" is configured more than once`,
+		},
+		{
+			hosts:  `{}`,
+			expErr: "invalid --gh-additional-hosts: json: cannot unmarshal object into Go value of type []server.GithubHost",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.hosts, func(t *testing.T) {
+			cmd := setupWithDefaults(map[string]interface{}{
+				GHAdditionalHostsFlag: c.hosts,
+			}, t)
+			err := cmd.Execute()
+			if c.expErr == "" {
+				Ok(t, err)
+				return
+			}
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
 // Can't use both --tfe-hostname flag without --tfe-token.
 func TestExecute_TFEHostnameOnly(t *testing.T) {
 	c := setup(map[string]interface{}{
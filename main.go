@@ -45,8 +45,17 @@ func main() {
 	}
 	version := &cmd.VersionCmd{AtlantisVersion: atlantisVersion}
 	testdrive := &cmd.TestdriveCmd{}
+	agent := &cmd.AgentCmd{
+		Viper:  viper.New(),
+		Logger: logger,
+	}
+	requiredContexts := &cmd.RequiredContextsCmd{Viper: viper.New()}
+	decryptStateSnapshot := &cmd.DecryptStateSnapshotCmd{Viper: viper.New()}
 	cmd.RootCmd.AddCommand(server.Init())
 	cmd.RootCmd.AddCommand(version.Init())
 	cmd.RootCmd.AddCommand(testdrive.Init())
+	cmd.RootCmd.AddCommand(agent.Init())
+	cmd.RootCmd.AddCommand(requiredContexts.Init())
+	cmd.RootCmd.AddCommand(decryptStateSnapshot.Init())
 	cmd.Execute()
 }
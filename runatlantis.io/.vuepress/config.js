@@ -75,14 +75,17 @@ module.exports = {
                         'checkout-strategy',
                         'terraform-versions',
                         'terraform-cloud',
-                        'using-slack-hooks'
+                        'remote-agents',
+                        'using-slack-hooks',
+                        'using-cloud-messaging-hooks'
                     ]
                 },
                 {
                     title: 'Using Atlantis',
                     collapsable: true,
                     children: [
-                        ['using-atlantis', 'Overview']
+                        ['using-atlantis', 'Overview'],
+                        'api-endpoints'
                     ]
                 },
                 {
@@ -0,0 +1,176 @@
+package agents
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// retryInterval is how long an agent waits to pull again after it couldn't
+// reach the server.
+const retryInterval = 10 * time.Second
+
+// Agent pulls the jobs of its pool from the server and runs them.
+type Agent struct {
+	Client AgentsClient
+	Name   string
+	Pool   string
+	// Terraform runs the commands. It shouldn't use a plugin cache since the
+	// providers it installs are copied back to the server with the other
+	// files in the repo.
+	Terraform runtime.AsyncTFExec
+	// DataDir is the dir that the repos of jobs are extracted into.
+	DataDir string
+	Log     logging.SimpleLogging
+
+	// jobs interrupts Terraform when the server cancels a job.
+	jobs *jobs.JobCanceller
+}
+
+// Run pulls and runs jobs until ctx is done. It returns an error if the
+// server rejects the agent's token.
+func (a *Agent) Run(ctx context.Context) error {
+	a.Log.Info("pulling jobs of pool %q", a.Pool)
+	a.jobs = jobs.NewJobCanceller(nil, jobs.DefaultCancelGracePeriod)
+	for {
+		job, err := a.Client.PullJob(ctx, &PullJobRequest{Agent: a.Name, Pool: a.Pool})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if status.Code(err) == codes.Unauthenticated {
+			return err
+		}
+		if err != nil {
+			a.Log.Warn("pulling job, retrying in %s: %s", retryInterval, err)
+			select {
+			case <-time.After(retryInterval):
+			case <-ctx.Done():
+				return nil
+			}
+			continue
+		}
+		if job.ID == "" {
+			continue
+		}
+		if err := a.runJob(ctx, job); err != nil {
+			a.Log.Err("running job %s: %s", job.ID, err)
+		}
+	}
+}
+
+// runJob runs job and sends its output and the files it changed to the
+// server.
+func (a *Agent) runJob(ctx context.Context, job *Job) error {
+	stream, err := a.Client.ReportJob(ctx)
+	if err != nil {
+		return err
+	}
+	// The first update tells the server that the job started.
+	if err := stream.Send(&JobUpdate{JobID: job.ID}); err != nil {
+		return err
+	}
+	jobCtx := command.ProjectContext{Log: a.Log, JobID: job.ID, Processes: a.jobs}
+	end := a.jobs.Begin(jobCtx, job.Command)
+	defer end()
+	// The server only ends the stream before the last update if the job was
+	// cancelled or it can't take any more updates, so Terraform is
+	// interrupted.
+	ended := make(chan error, 1)
+	go func() {
+		err := stream.RecvMsg(new(ReportJobResponse))
+		if err != nil {
+			a.jobs.Cancel(job.ID, "the server") // nolint: errcheck
+		}
+		ended <- err
+	}()
+
+	done, err := a.execute(jobCtx, job, func(line string) error {
+		return stream.Send(&JobUpdate{JobID: job.ID, Lines: []string{line}})
+	})
+	if err != nil {
+		done = &JobUpdate{JobID: job.ID, Done: true, Error: err.Error()}
+	}
+	// If the server ended the stream, sending fails and its status is
+	// returned by RecvMsg.
+	if stream.Send(done) == nil {
+		stream.CloseSend() // nolint: errcheck
+	}
+	return <-ended
+}
+
+// execute runs job in a temporary dir and returns its last update. send is
+// called with each line of output.
+func (a *Agent) execute(ctx command.ProjectContext, job *Job, send func(line string) error) (*JobUpdate, error) {
+	dir, err := os.MkdirTemp(a.DataDir, "job")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating dir for job")
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+	if err := extract(job.Repo, dir, nil); err != nil {
+		return nil, errors.Wrap(err, "extracting repo")
+	}
+	before, err := snapshot(dir)
+	if err != nil {
+		return nil, err
+	}
+	var v *version.Version
+	if job.TerraformVersion != "" {
+		if v, err = version.NewVersion(job.TerraformVersion); err != nil {
+			return nil, errors.Wrap(err, "parsing Terraform version")
+		}
+	}
+	path, err := pathIn(dir, job.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Log.Info("running terraform %v in %s", job.Args, job.Dir)
+	ctx.Workspace = job.Workspace
+	ctx.TerraformLogLevel = job.TerraformLogLevel
+	_, outCh := a.Terraform.RunCommandAsync(ctx, path, job.Args, job.Envs, v, job.Workspace)
+	var runErr, sendErr error
+	for line := range outCh {
+		if line.Err != nil {
+			runErr = line.Err
+			break
+		}
+		// Output that can't be sent is dropped but Terraform isn't left
+		// running.
+		if sendErr == nil {
+			sendErr = send(line.Line)
+		}
+	}
+	if sendErr != nil {
+		return nil, errors.Wrap(sendErr, "sending output")
+	}
+
+	after, err := snapshot(dir)
+	if err != nil {
+		return nil, err
+	}
+	changed, deleted := changedFiles(before, after)
+	files, err := archive(dir, changed)
+	if err != nil {
+		return nil, errors.Wrap(err, "archiving changed files")
+	}
+	update := &JobUpdate{JobID: job.ID, Done: true, Files: files, Deleted: deleted}
+	if runErr != nil {
+		// The error has the path of the temporary dir which means nothing to
+		// the server.
+		update.Error = errors.Cause(runErr).Error()
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			a.Log.Warn("terraform %v in %s failed: %s", job.Args, rel, runErr)
+		}
+	}
+	return update, nil
+}
@@ -0,0 +1,268 @@
+package agents_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/agents"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	runtimemodels "github.com/runatlantis/atlantis/server/core/runtime/models"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeTerraform writes a plan file, deletes stale.txt and prints two lines
+// or fails with err. If started isn't nil, it then runs a process that
+// doesn't exit until it's interrupted, closes started and sends how the
+// process exited to exited.
+type fakeTerraform struct {
+	err     error
+	args    []string
+	envs    map[string]string
+	started chan struct{}
+	exited  chan error
+}
+
+func (f *fakeTerraform) RunCommandWithVersion(ctx command.ProjectContext, path string, args []string, envs map[string]string, v *version.Version, workspace string) (string, error) {
+	return "local", nil
+}
+
+func (f *fakeTerraform) RunCommandAsync(ctx command.ProjectContext, path string, args []string, envs map[string]string, v *version.Version, workspace string) (chan<- string, <-chan runtimemodels.Line) {
+	f.args = args
+	f.envs = envs
+	outCh := make(chan runtimemodels.Line)
+	go func() {
+		defer close(outCh)
+		if _, err := os.Stat(filepath.Join(path, "main.tf")); err != nil {
+			outCh <- runtimemodels.Line{Err: err}
+			return
+		}
+		os.WriteFile(filepath.Join(path, "default.tfplan"), []byte("plan"), 0600) // nolint: errcheck
+		os.Remove(filepath.Join(path, "stale.txt"))                               // nolint: errcheck
+		outCh <- runtimemodels.Line{Line: "line1"}
+		outCh <- runtimemodels.Line{Line: "line2"}
+		if f.started != nil {
+			cmd := exec.Command("sleep", "30")
+			done, err := ctx.Processes.Start(ctx.JobID, cmd)
+			if err != nil {
+				outCh <- runtimemodels.Line{Err: err}
+				return
+			}
+			close(f.started)
+			err = cmd.Wait()
+			done()
+			f.exited <- err
+			outCh <- runtimemodels.Line{Err: err}
+			return
+		}
+		if f.err != nil {
+			outCh <- runtimemodels.Line{Err: f.err}
+		}
+	}()
+	return make(chan string), outCh
+}
+
+func (f *fakeTerraform) EnsureVersion(log logging.SimpleLogging, v *version.Version) error {
+	return nil
+}
+
+func TestRemoteTerraformExecutor(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	dispatcher := agents.NewDispatcher("token", logger)
+	conn := serveAgents(t, dispatcher)
+	globalCfg := valid.NewGlobalCfgStore(valid.GlobalCfg{
+		Repos: []valid.Repo{{ID: "github.com/owner/remote", AgentPool: "private"}},
+	})
+	executor := &agents.RemoteTerraformExecutor{
+		Local:         &fakeTerraform{},
+		Dispatcher:    dispatcher,
+		GlobalCfg:     globalCfg,
+		OutputHandler: &jobs.NoopProjectOutputHandler{},
+	}
+
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	projectDir := filepath.Join(repoDir, "project")
+	Ok(t, os.MkdirAll(filepath.Join(repoDir, ".git"), 0700))
+	Ok(t, os.MkdirAll(projectDir, 0700))
+	Ok(t, os.WriteFile(filepath.Join(projectDir, "main.tf"), []byte(""), 0600))
+	Ok(t, os.WriteFile(filepath.Join(projectDir, "stale.txt"), []byte(""), 0600))
+	ctx := command.ProjectContext{
+		Log:        logger,
+		BaseRepo:   models.Repo{FullName: "owner/remote", VCSHost: models.VCSHost{Hostname: "github.com"}},
+		RepoRelDir: "project",
+	}
+
+	t.Run("local", func(t *testing.T) {
+		localCtx := ctx
+		localCtx.BaseRepo.FullName = "owner/local"
+		out, err := executor.RunCommandWithVersion(localCtx, projectDir, []string{"plan"}, nil, nil, "default")
+		Ok(t, err)
+		Equals(t, "local", out)
+	})
+
+	t.Run("no agents", func(t *testing.T) {
+		_, err := executor.RunCommandWithVersion(ctx, projectDir, []string{"plan"}, nil, nil, "default")
+		ErrContains(t, `no agents in pool "private" are connected`, err)
+	})
+
+	tf := &fakeTerraform{}
+	runAgent(t, conn, "token", tf)
+	waitForAgent(t, executor, ctx, projectDir)
+
+	t.Run("success", func(t *testing.T) {
+		_, outCh := executor.RunCommandAsync(ctx, projectDir, []string{"plan", "-out", "default.tfplan"}, map[string]string{"KEY": "value"}, nil, "default")
+		var lines []string
+		for line := range outCh {
+			Ok(t, line.Err)
+			lines = append(lines, line.Line)
+		}
+		Equals(t, []string{"line1", "line2"}, lines)
+		Equals(t, []string{"plan", "-out", "default.tfplan"}, tf.args)
+		Equals(t, map[string]string{"KEY": "value"}, tf.envs)
+
+		plan, err := os.ReadFile(filepath.Join(projectDir, "default.tfplan"))
+		Ok(t, err)
+		Equals(t, "plan", string(plan))
+		_, err = os.Stat(filepath.Join(projectDir, "stale.txt"))
+		Assert(t, os.IsNotExist(err), "expected stale.txt to be deleted")
+		_, err = os.Stat(filepath.Join(repoDir, ".git"))
+		Ok(t, err)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		tf.err = errors.New("exit status 1")
+		out, err := executor.RunCommandWithVersion(ctx, projectDir, []string{"apply"}, nil, nil, "default")
+		ErrContains(t, `running "terraform apply" in "`+projectDir+`" on agent pool "private": exit status 1`, err)
+		Equals(t, "line1\nline2\n", out)
+	})
+
+	t.Run("cloud credentials", func(t *testing.T) {
+		credsCtx := ctx
+		credsCtx.CloudCredentials = &valid.CloudCredentials{AWS: &valid.AWSCredentials{RoleARN: "arn:aws:iam::123456789012:role/atlantis"}}
+		_, err := executor.RunCommandWithVersion(credsCtx, projectDir, []string{"plan"}, nil, nil, "default")
+		ErrEquals(t, `cloud_credentials can't be used with agent pool "private" since their files are on the server`, err)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		canceller := jobs.NewJobCanceller(nil, time.Minute)
+		executor.JobCanceller = canceller
+		tf.err = nil
+		tf.started = make(chan struct{})
+		tf.exited = make(chan error, 1)
+		jobCtx := ctx
+		jobCtx.JobID = "1234"
+		end := canceller.Begin(jobCtx, command.Apply)
+		defer end()
+
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := executor.RunCommandWithVersion(jobCtx, projectDir, []string{"apply"}, nil, nil, "default")
+			errCh <- err
+		}()
+		select {
+		case <-tf.started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("agent didn't start the job")
+		}
+		_, err := canceller.Cancel("1234", "@alice")
+		Ok(t, err)
+
+		select {
+		case err := <-errCh:
+			ErrContains(t, "context canceled", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("command wasn't cancelled")
+		}
+		select {
+		case err := <-tf.exited:
+			ErrContains(t, "signal: interrupt", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("agent didn't interrupt the job")
+		}
+	})
+}
+
+func TestAgent_InvalidToken(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	conn := serveAgents(t, agents.NewDispatcher("token", logger))
+	agent := &agents.Agent{
+		Client:    agents.NewAgentsClient(conn),
+		Name:      "agent",
+		Pool:      "private",
+		Terraform: &fakeTerraform{},
+		Log:       logger,
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer wrong")
+	err := agent.Run(ctx)
+	Equals(t, codes.Unauthenticated, status.Code(err))
+}
+
+// serveAgents serves dispatcher in memory and returns a connection to it.
+func serveAgents(t *testing.T, dispatcher *agents.Dispatcher) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(agents.ServerOptions()...)
+	agents.RegisterAgentsServer(server, dispatcher)
+	go server.Serve(lis) // nolint: errcheck
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure())
+	Ok(t, err)
+	t.Cleanup(func() { conn.Close() }) // nolint: errcheck
+	return conn
+}
+
+// runAgent runs an agent of pool private until the test ends.
+func runAgent(t *testing.T, conn *grpc.ClientConn, token string, tf *fakeTerraform) {
+	dataDir, cleanup := TempDir(t)
+	t.Cleanup(cleanup)
+	agent := &agents.Agent{
+		Client:    agents.NewAgentsClient(conn),
+		Name:      "agent",
+		Pool:      "private",
+		Terraform: tf,
+		DataDir:   dataDir,
+		Log:       logging.NewNoopLogger(t),
+	}
+	ctx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token))
+	done := make(chan struct{})
+	go func() {
+		agent.Run(ctx) // nolint: errcheck
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// waitForAgent waits until the agent has pulled once so that commands are
+// dispatched to it.
+func waitForAgent(t *testing.T, executor *agents.RemoteTerraformExecutor, ctx command.ProjectContext, path string) {
+	for i := 0; i < 100; i++ {
+		_, err := executor.RunCommandWithVersion(ctx, path, []string{"version"}, nil, nil, "default")
+		if err == nil || !strings.Contains(err.Error(), "no agents") {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("agent didn't connect")
+}
@@ -0,0 +1,275 @@
+package agents
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// fileState is what's compared to find the files that a job changed.
+type fileState struct {
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+}
+
+// snapshot returns the state of the files in dir, keyed by their path
+// relative to dir. The .git dir is skipped.
+func snapshot(dir string) (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = fileState{size: info.Size(), modTime: info.ModTime(), mode: info.Mode()}
+		return nil
+	})
+	return files, err
+}
+
+// archive returns a gzipped tar of the files in dir, or only the ones with
+// paths in include if it isn't nil.
+func archive(dir string, include map[string]bool) ([]byte, error) {
+	files, err := snapshot(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for path := range files {
+		if include == nil || include[path] {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, path := range paths {
+		absPath := filepath.Join(dir, filepath.FromSlash(path))
+		info, err := os.Lstat(absPath)
+		if err != nil {
+			return nil, err
+		}
+		var link string
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(absPath); err != nil {
+				return nil, err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return nil, errors.Wrapf(err, "archiving %s", path)
+		}
+		hdr.Name = path
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		if err := copyFile(tw, absPath); err != nil {
+			return nil, errors.Wrapf(err, "archiving %s", path)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// changedFiles returns the paths of the files in after that were created or
+// changed since before and the paths of the files in before that were
+// deleted, sorted.
+func changedFiles(before map[string]fileState, after map[string]fileState) (map[string]bool, []string) {
+	changed := make(map[string]bool)
+	for path, state := range after {
+		if prev, ok := before[path]; !ok || prev != state {
+			changed[path] = true
+		}
+	}
+	var deleted []string
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			deleted = append(deleted, path)
+		}
+	}
+	sort.Strings(deleted)
+	return changed, deleted
+}
+
+// extract writes the files in the gzipped tar data to dir and then deletes
+// the files in deleted, which are relative to dir.
+func extract(data []byte, dir string, deleted []string) error {
+	if len(data) > 0 {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return errors.Wrap(err, "reading archive")
+		}
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return errors.Wrap(err, "reading archive")
+			}
+			path, err := securePathIn(dir, hdr.Name)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return err
+			}
+			// Files are replaced rather than written to since they can be
+			// symlinks or read-only.
+			if err := os.RemoveAll(path); err != nil {
+				return err
+			}
+			switch hdr.Typeflag {
+			case tar.TypeSymlink:
+				if err := symlinkIn(dir, hdr.Linkname, path); err != nil {
+					return errors.Wrapf(err, "%q", hdr.Name)
+				}
+			case tar.TypeReg:
+				if err := writeFile(path, tr, hdr.FileInfo().Mode().Perm()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, name := range deleted {
+		path, err := securePathIn(dir, name)
+		if err != nil {
+			return err
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pathIn returns the path of name in dir. It returns an error if name is
+// outside of dir.
+func pathIn(dir string, name string) (string, error) {
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if !within(dir, path) {
+		return "", fmt.Errorf("%q is outside of the repo", name)
+	}
+	return path, nil
+}
+
+// securePathIn is like pathIn but also returns an error if the dir that name
+// is in resolves to outside of dir through a symlink, since writing to or
+// deleting name would then change files outside of dir.
+func securePathIn(dir string, name string) (string, error) {
+	path, err := pathIn(dir, name)
+	if err != nil {
+		return "", err
+	}
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	// Dirs that don't exist yet are created by extract so only the closest
+	// one that does exist can lead outside of dir.
+	parent := filepath.Dir(path)
+	for parent != dir {
+		if _, err := os.Lstat(parent); err == nil {
+			break
+		}
+		parent = filepath.Dir(parent)
+	}
+	resolved, err := filepath.EvalSymlinks(parent)
+	if err != nil {
+		return "", err
+	}
+	if !within(root, resolved) {
+		return "", fmt.Errorf("%q is outside of the repo", name)
+	}
+	return path, nil
+}
+
+// symlinkIn creates the symlink path to target. It returns an error if
+// target is absolute or resolves to outside of dir.
+func symlinkIn(dir string, target string, path string) error {
+	if filepath.IsAbs(target) || !within(dir, filepath.Join(filepath.Dir(path), target)) {
+		return fmt.Errorf("symlink to %q is outside of the repo", target)
+	}
+	if err := os.Symlink(target, path); err != nil {
+		return err
+	}
+	// Targets can also lead outside of dir through other symlinks. Targets
+	// that don't exist are checked when something is written through them.
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil
+	}
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	if !within(root, resolved) {
+		os.Remove(path) // nolint: errcheck
+		return fmt.Errorf("symlink to %q is outside of the repo", target)
+	}
+	return nil
+}
+
+// within returns true if path is dir or is in it.
+func within(dir string, path string) bool {
+	dir = filepath.Clean(dir)
+	path = filepath.Clean(path)
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+func copyFile(w io.Writer, path string) error {
+	f, err := os.Open(path) // nolint: gosec
+	if err != nil {
+		return err
+	}
+	defer f.Close() // nolint: errcheck
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func writeFile(path string, r io.Reader, perm fs.FileMode) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm|0600) // nolint: gosec
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil { // nolint: gosec
+		f.Close() // nolint: errcheck
+		return err
+	}
+	return f.Close()
+}
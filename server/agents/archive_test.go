@@ -0,0 +1,150 @@
+package agents
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestArchive_RoundTrip(t *testing.T) {
+	src, cleanup := TempDir(t)
+	defer cleanup()
+	Ok(t, os.MkdirAll(filepath.Join(src, ".git"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(src, ".git", "HEAD"), []byte("ref"), 0600))
+	Ok(t, os.MkdirAll(filepath.Join(src, "dir"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(src, "dir", "main.tf"), []byte("main"), 0600))
+	Ok(t, os.WriteFile(filepath.Join(src, "script.sh"), []byte("#!/bin/sh"), 0700))
+	Ok(t, os.Symlink("dir/main.tf", filepath.Join(src, "link.tf")))
+
+	data, err := archive(src, nil)
+	Ok(t, err)
+	dst, cleanupDst := TempDir(t)
+	defer cleanupDst()
+	Ok(t, extract(data, dst, nil))
+
+	main, err := os.ReadFile(filepath.Join(dst, "dir", "main.tf"))
+	Ok(t, err)
+	Equals(t, "main", string(main))
+	info, err := os.Stat(filepath.Join(dst, "script.sh"))
+	Ok(t, err)
+	Equals(t, os.FileMode(0700), info.Mode().Perm())
+	link, err := os.Readlink(filepath.Join(dst, "link.tf"))
+	Ok(t, err)
+	Equals(t, "dir/main.tf", link)
+	_, err = os.Stat(filepath.Join(dst, ".git"))
+	Assert(t, os.IsNotExist(err), "expected .git to be skipped")
+}
+
+func TestChangedFiles(t *testing.T) {
+	dir, cleanup := TempDir(t)
+	defer cleanup()
+	Ok(t, os.WriteFile(filepath.Join(dir, "same"), []byte("same"), 0600))
+	Ok(t, os.WriteFile(filepath.Join(dir, "changed"), []byte("before"), 0600))
+	Ok(t, os.WriteFile(filepath.Join(dir, "deleted"), []byte("deleted"), 0600))
+	before, err := snapshot(dir)
+	Ok(t, err)
+
+	Ok(t, os.WriteFile(filepath.Join(dir, "changed"), []byte("after!"), 0600))
+	Ok(t, os.Remove(filepath.Join(dir, "deleted")))
+	Ok(t, os.WriteFile(filepath.Join(dir, "created"), []byte("created"), 0600))
+	after, err := snapshot(dir)
+	Ok(t, err)
+
+	changed, deleted := changedFiles(before, after)
+	Equals(t, map[string]bool{"changed": true, "created": true}, changed)
+	Equals(t, []string{"deleted"}, deleted)
+}
+
+func TestExtract_OutsideDir(t *testing.T) {
+	dir, cleanup := TempDir(t)
+	defer cleanup()
+	ErrEquals(t, `"../evil" is outside of the repo`, extract(nil, dir, []string{"../evil"}))
+}
+
+func TestExtract_Symlinks(t *testing.T) {
+	outside, cleanupOutside := TempDir(t)
+	defer cleanupOutside()
+	cases := []struct {
+		description string
+		entries     []tar.Header
+		expErr      string
+	}{
+		{
+			description: "absolute symlink",
+			entries:     []tar.Header{{Name: "x", Typeflag: tar.TypeSymlink, Linkname: outside}},
+			expErr:      fmt.Sprintf(`"x": symlink to %q is outside of the repo`, outside),
+		},
+		{
+			description: "relative symlink out of the repo",
+			entries:     []tar.Header{{Name: "dir/x", Typeflag: tar.TypeSymlink, Linkname: "../../.."}},
+			expErr:      `"dir/x": symlink to "../../.." is outside of the repo`,
+		},
+		{
+			description: "symlink out of the repo through another symlink",
+			entries: []tar.Header{
+				{Name: "a/b/up", Typeflag: tar.TypeSymlink, Linkname: ".."},
+				{Name: "a/b/x", Typeflag: tar.TypeSymlink, Linkname: "up/../.."},
+			},
+			expErr: `"a/b/x": symlink to "up/../.." is outside of the repo`,
+		},
+		{
+			description: "file through a symlink in the repo",
+			entries: []tar.Header{
+				{Name: "dir/main.tf", Typeflag: tar.TypeReg, Mode: 0600},
+				{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "dir"},
+				{Name: "link/other.tf", Typeflag: tar.TypeReg, Mode: 0600},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			dir, cleanup := TempDir(t)
+			defer cleanup()
+			err := extract(tarOf(t, c.entries), dir, nil)
+			if c.expErr != "" {
+				ErrEquals(t, c.expErr, err)
+				return
+			}
+			Ok(t, err)
+		})
+	}
+}
+
+func TestExtract_ThroughSymlinkOutsideDir(t *testing.T) {
+	dir, cleanup := TempDir(t)
+	defer cleanup()
+	outside, cleanupOutside := TempDir(t)
+	defer cleanupOutside()
+	Ok(t, os.WriteFile(filepath.Join(outside, "keep"), []byte("keep"), 0600))
+	// A symlink that was already in the repo, ex. from the clone.
+	Ok(t, os.Symlink(outside, filepath.Join(dir, "x")))
+
+	err := extract(tarOf(t, []tar.Header{{Name: "x/pwn", Typeflag: tar.TypeReg, Mode: 0600}}), dir, nil)
+	ErrEquals(t, `"x/pwn" is outside of the repo`, err)
+	_, err = os.Stat(filepath.Join(outside, "pwn"))
+	Assert(t, os.IsNotExist(err), "expected nothing to be written outside of the repo")
+
+	ErrEquals(t, `"x/keep" is outside of the repo`, extract(nil, dir, []string{"x/keep"}))
+	_, err = os.Stat(filepath.Join(outside, "keep"))
+	Ok(t, err)
+}
+
+// tarOf returns a gzipped tar of entries. Regular files are empty.
+func tarOf(t *testing.T, entries []tar.Header) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, hdr := range entries {
+		hdr := hdr
+		Ok(t, tw.WriteHeader(&hdr))
+	}
+	Ok(t, tw.Close())
+	Ok(t, gz.Close())
+	return buf.Bytes()
+}
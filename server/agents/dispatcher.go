@@ -0,0 +1,250 @@
+package agents
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/runatlantis/atlantis/server/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// pollTimeout is how long PullJob waits for a job before it returns an
+	// empty job.
+	pollTimeout = 30 * time.Second
+	// agentTimeout is how long after its last pull an agent that isn't
+	// running a job is considered disconnected.
+	agentTimeout = 2 * pollTimeout
+	// pickupTimeout is how long a job waits for an agent in its pool to pull
+	// it when all the agents are busy.
+	pickupTimeout = 10 * time.Minute
+	// startTimeout is how long a job waits for its agent to report that it
+	// started after pulling it.
+	startTimeout = time.Minute
+)
+
+// Dispatcher queues jobs for the agents of each pool and implements the
+// AgentsServer that the agents pull them from.
+type Dispatcher struct {
+	// Token is the secret that agents authenticate with.
+	Token string
+	Log   logging.SimpleLogging
+
+	mu     sync.Mutex
+	queues map[string]chan *pendingJob
+	// agents are the times at which the agents of each pool last pulled,
+	// keyed by pool and then by agent.
+	agents  map[string]map[string]time.Time
+	running map[string]*pendingJob
+}
+
+// pendingJob is a job that's queued or running.
+type pendingJob struct {
+	job    *Job
+	pool   string
+	agent  string
+	output func(line string)
+	// started is closed when the agent sends its first update.
+	started   chan struct{}
+	startOnce sync.Once
+	done      chan *JobUpdate
+	// cancelled is closed if the job is cancelled after it's pulled.
+	cancelled chan struct{}
+}
+
+// NewDispatcher returns a dispatcher that agents authenticate to with token.
+func NewDispatcher(token string, log logging.SimpleLogging) *Dispatcher {
+	return &Dispatcher{
+		Token:   token,
+		Log:     log,
+		queues:  make(map[string]chan *pendingJob),
+		agents:  make(map[string]map[string]time.Time),
+		running: make(map[string]*pendingJob),
+	}
+}
+
+// Run runs job on an agent of pool and returns the agent's last update.
+// output is called with each line of output as the agent sends it. If ctx is
+// done, the agent is told to stop the job.
+func (d *Dispatcher) Run(ctx context.Context, pool string, job *Job, output func(line string)) (*JobUpdate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !d.hasAgents(pool) {
+		return nil, fmt.Errorf("no agents in pool %q are connected", pool)
+	}
+	job.ID = uuid.New().String()
+	p := &pendingJob{
+		job:       job,
+		pool:      pool,
+		output:    output,
+		started:   make(chan struct{}),
+		done:      make(chan *JobUpdate, 1),
+		cancelled: make(chan struct{}),
+	}
+	defer func() {
+		d.mu.Lock()
+		delete(d.running, job.ID)
+		d.mu.Unlock()
+	}()
+
+	select {
+	case d.queue(pool) <- p:
+	case <-time.After(pickupTimeout):
+		return nil, fmt.Errorf("no agent in pool %q was free to run the job within %s", pool, pickupTimeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case <-p.started:
+	case <-time.After(startTimeout):
+		return nil, fmt.Errorf("agent %s pulled the job but didn't start it", p.agent)
+	case <-ctx.Done():
+		close(p.cancelled)
+		return nil, ctx.Err()
+	}
+	select {
+	case update := <-p.done:
+		return update, nil
+	case <-ctx.Done():
+		close(p.cancelled)
+		return nil, ctx.Err()
+	}
+}
+
+func (d *Dispatcher) PullJob(ctx context.Context, req *PullJobRequest) (*Job, error) {
+	if err := d.authenticate(ctx); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	if d.agents[req.Pool] == nil {
+		d.agents[req.Pool] = make(map[string]time.Time)
+	}
+	if _, ok := d.agents[req.Pool][req.Agent]; !ok {
+		d.Log.Info("agent %s in pool %q is waiting for jobs", req.Agent, req.Pool)
+	}
+	d.agents[req.Pool][req.Agent] = time.Now()
+	d.mu.Unlock()
+
+	select {
+	case p := <-d.queue(req.Pool):
+		d.mu.Lock()
+		p.agent = req.Agent
+		d.running[p.job.ID] = p
+		d.mu.Unlock()
+		d.Log.Debug("agent %s in pool %q pulled job %s", req.Agent, req.Pool, p.job.ID)
+		return p.job, nil
+	case <-time.After(pollTimeout):
+		return &Job{}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (d *Dispatcher) ReportJob(stream ReportJobServer) error {
+	if err := d.authenticate(stream.Context()); err != nil {
+		return err
+	}
+	updates := make(chan *JobUpdate)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			update, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case updates <- update:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	var p *pendingJob
+	for {
+		var update *JobUpdate
+		var cancelled chan struct{}
+		if p != nil {
+			cancelled = p.cancelled
+		}
+		select {
+		case update = <-updates:
+		case err := <-recvErr:
+			// The stream ends before the last update if the agent stops.
+			if p != nil {
+				p.done <- &JobUpdate{Done: true, Error: fmt.Sprintf("agent %s stopped before finishing the job", p.agent)}
+			}
+			if err == io.EOF {
+				return stream.SendAndClose(&ReportJobResponse{})
+			}
+			return err
+		case <-cancelled:
+			// Ending the stream tells the agent to stop the job.
+			return status.Errorf(codes.Canceled, "job %s was cancelled", p.job.ID)
+		}
+		if p == nil {
+			d.mu.Lock()
+			p = d.running[update.JobID]
+			d.mu.Unlock()
+			if p == nil {
+				return status.Errorf(codes.NotFound, "job %s isn't running", update.JobID)
+			}
+			p.startOnce.Do(func() { close(p.started) })
+		}
+		for _, line := range update.Lines {
+			p.output(line)
+		}
+		if update.Done {
+			p.done <- update
+			return stream.SendAndClose(&ReportJobResponse{})
+		}
+	}
+}
+
+// hasAgents returns true if an agent of pool pulled recently or is running a
+// job.
+func (d *Dispatcher) hasAgents(pool string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, lastPull := range d.agents[pool] {
+		if time.Since(lastPull) < agentTimeout {
+			return true
+		}
+	}
+	for _, p := range d.running {
+		if p.agent != "" && p.pool == pool {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dispatcher) queue(pool string) chan *pendingJob {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	q, ok := d.queues[pool]
+	if !ok {
+		q = make(chan *pendingJob)
+		d.queues[pool] = q
+	}
+	return q
+}
+
+func (d *Dispatcher) authenticate(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, auth := range md.Get("authorization") {
+		if subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+d.Token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid agent token")
+}
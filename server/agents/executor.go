@@ -0,0 +1,163 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	runtimemodels "github.com/runatlantis/atlantis/server/core/runtime/models"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/terraform/ansi"
+	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// TerraformExec is the part of the Terraform client that
+// RemoteTerraformExecutor runs commands with.
+type TerraformExec interface {
+	runtime.TerraformExec
+	runtime.AsyncTFExec
+}
+
+// RemoteTerraformExecutor runs the Terraform commands of the repos that have
+// an agent_pool in the server-side repo config on the agents of that pool
+// and the commands of other repos with Local. The repo is sent to the agent
+// with each command and the files that the command changes, ex. the plan
+// file, are copied back so that the next command can run anywhere.
+type RemoteTerraformExecutor struct {
+	Local      TerraformExec
+	Dispatcher *Dispatcher
+	// GlobalCfg is used to look up the agent pool of each repo.
+	GlobalCfg *valid.GlobalCfgStore
+	// OutputHandler streams the output of commands run with RunCommandAsync
+	// to the job's page like Local does.
+	OutputHandler jobs.ProjectCommandOutputHandler
+	// JobCanceller stops commands on their agents when their jobs are
+	// cancelled. If nil, they run to completion.
+	JobCanceller *jobs.JobCanceller
+}
+
+func (r *RemoteTerraformExecutor) RunCommandWithVersion(ctx command.ProjectContext, path string, args []string, envs map[string]string, v *version.Version, workspace string) (string, error) {
+	pool := r.pool(ctx)
+	if pool == "" {
+		return r.Local.RunCommandWithVersion(ctx, path, args, envs, v, workspace)
+	}
+	var lines []string
+	err := r.run(ctx, pool, path, args, envs, v, workspace, func(line string) {
+		lines = append(lines, line)
+	})
+	return ansi.Strip(strings.Join(lines, "\n") + "\n"), err
+}
+
+func (r *RemoteTerraformExecutor) RunCommandAsync(ctx command.ProjectContext, path string, args []string, envs map[string]string, v *version.Version, workspace string) (chan<- string, <-chan runtimemodels.Line) {
+	pool := r.pool(ctx)
+	if pool == "" {
+		return r.Local.RunCommandAsync(ctx, path, args, envs, v, workspace)
+	}
+	outCh := make(chan runtimemodels.Line)
+	inCh := make(chan string)
+	go func() {
+		// Agents don't read stdin.
+		for range inCh {
+		}
+	}()
+	go func() {
+		defer func() {
+			close(outCh)
+			close(inCh)
+		}()
+		err := r.run(ctx, pool, path, args, envs, v, workspace, func(line string) {
+			outCh <- runtimemodels.Line{Line: line}
+			r.OutputHandler.Send(ctx, line, false)
+		})
+		if err != nil {
+			outCh <- runtimemodels.Line{Err: err}
+		}
+	}()
+	return inCh, outCh
+}
+
+func (r *RemoteTerraformExecutor) EnsureVersion(log logging.SimpleLogging, v *version.Version) error {
+	return r.Local.EnsureVersion(log, v)
+}
+
+// pool returns the agent pool of ctx's repo or an empty string if its
+// commands run on the server.
+func (r *RemoteTerraformExecutor) pool(ctx command.ProjectContext) string {
+	if ctx.BaseRepo.FullName == "" {
+		return ""
+	}
+	return r.GlobalCfg.Get().AgentPool(ctx.BaseRepo.ID())
+}
+
+// run runs terraform with args in path on an agent of pool and calls output
+// with each line of output.
+func (r *RemoteTerraformExecutor) run(ctx command.ProjectContext, pool string, path string, args []string, envs map[string]string, v *version.Version, workspace string, output func(line string)) error {
+	// Different repo configs can set the agent pool and cloud credentials so
+	// they're also checked here.
+	if ctx.CloudCredentials != nil {
+		return fmt.Errorf("cloud_credentials can't be used with agent pool %q since their files are on the server", pool)
+	}
+	repoDir, dir, err := splitRepoDir(path, ctx.RepoRelDir)
+	if err != nil {
+		return err
+	}
+	repo, err := archive(repoDir, nil)
+	if err != nil {
+		return errors.Wrap(err, "archiving repo for agent")
+	}
+	job := &Job{
+		Repo:              repo,
+		Dir:               dir,
+		Args:              args,
+		Envs:              envs,
+		Workspace:         workspace,
+		TerraformLogLevel: ctx.TerraformLogLevel,
+		Command:           ctx.CommandName,
+	}
+	if v != nil {
+		job.TerraformVersion = v.String()
+	}
+	tfCmd := "terraform " + strings.Join(args, " ")
+	ctx.Log.Debug("running %q in %q on agent pool %q", tfCmd, path, pool)
+	jobCtx, cancel := context.WithCancel(context.Background())
+	if r.JobCanceller != nil {
+		jobCtx, cancel = r.JobCanceller.Context(ctx.JobID)
+	}
+	defer cancel()
+	update, err := r.Dispatcher.Run(jobCtx, pool, job, output)
+	if err != nil {
+		err = errors.Wrapf(err, "running %q in %q on agent pool %q", tfCmd, path, pool)
+		ctx.Log.Err(err.Error())
+		return err
+	}
+	if err := extract(update.Files, repoDir, update.Deleted); err != nil {
+		return errors.Wrap(err, "copying files from agent")
+	}
+	if update.Error != "" {
+		err := fmt.Errorf("running %q in %q on agent pool %q: %s", tfCmd, path, pool, update.Error)
+		ctx.Log.Err(err.Error())
+		return err
+	}
+	ctx.Log.Info("successfully ran %q in %q on agent pool %q", tfCmd, path, pool)
+	return nil
+}
+
+// splitRepoDir returns the root of the repo that path is repoRelDir of and
+// repoRelDir cleaned.
+func splitRepoDir(path string, repoRelDir string) (string, string, error) {
+	dir := filepath.Clean(repoRelDir)
+	if dir == "." {
+		return path, dir, nil
+	}
+	suffix := string(filepath.Separator) + dir
+	if !strings.HasSuffix(filepath.Clean(path), suffix) {
+		return "", "", fmt.Errorf("%q isn't in dir %q of a repo", path, repoRelDir)
+	}
+	return strings.TrimSuffix(filepath.Clean(path), suffix), filepath.ToSlash(dir), nil
+}
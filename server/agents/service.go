@@ -0,0 +1,210 @@
+// Package agents runs Terraform commands on agents that pull jobs from the
+// server over gRPC, ex. in a private network that the server can't reach.
+// The messages are encoded with grpcjson.
+package agents
+
+import (
+	"context"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/grpcjson"
+	"google.golang.org/grpc"
+)
+
+const (
+	// maxMessageBytes is the largest message the server and agents accept.
+	// Jobs and their results contain the repo's files.
+	maxMessageBytes = 1 << 30
+	serviceName     = "atlantis.Agents"
+)
+
+// PullJobRequest is sent by agents to get their next job.
+type PullJobRequest struct {
+	// Agent is the agent's name, used in logs.
+	Agent string
+	// Pool is the pool the agent runs the jobs of.
+	Pool string
+}
+
+// Job is a Terraform command for an agent to run. A Job with an empty ID
+// means there's no job yet and the agent should pull again.
+type Job struct {
+	ID string
+	// Repo is a gzipped tar of the repo the command runs in, without its .git
+	// dir.
+	Repo []byte
+	// Dir is the dir that the command runs in, relative to the repo root.
+	Dir  string
+	Args []string
+	Envs map[string]string
+	// TerraformVersion is the version to run. Empty for the agent's default
+	// version.
+	TerraformVersion  string
+	Workspace         string
+	TerraformLogLevel string
+	// Command is the Atlantis command that the Terraform command is part of.
+	Command command.Name
+}
+
+// JobUpdate is sent by agents while they run a job. The last update of a job
+// has Done set.
+type JobUpdate struct {
+	JobID string
+	// Lines are the lines of output since the last update.
+	Lines []string
+	Done  bool
+	// Error is the error the command failed with. Empty if it succeeded.
+	Error string
+	// Files is a gzipped tar of the files in the repo that the command
+	// created or changed, ex. the plan file.
+	Files []byte
+	// Deleted are the files in the repo that the command deleted, relative
+	// to the repo root.
+	Deleted []string
+}
+
+// ReportJobResponse is the response to a job's updates.
+type ReportJobResponse struct{}
+
+// AgentsServer is the server side of the service that agents connect to.
+type AgentsServer interface {
+	// PullJob returns the next job of the agent's pool. It returns a Job
+	// without an ID if there's no job for a while so that the agent can
+	// check the connection and pull again.
+	PullJob(ctx context.Context, req *PullJobRequest) (*Job, error)
+	// ReportJob receives the updates of a job until it's done.
+	ReportJob(stream ReportJobServer) error
+}
+
+// ReportJobServer receives the updates of a job.
+type ReportJobServer interface {
+	SendAndClose(*ReportJobResponse) error
+	Recv() (*JobUpdate, error)
+	grpc.ServerStream
+}
+
+type agentsReportJobServer struct {
+	grpc.ServerStream
+}
+
+func (s *agentsReportJobServer) SendAndClose(resp *ReportJobResponse) error {
+	return s.ServerStream.SendMsg(resp)
+}
+
+func (s *agentsReportJobServer) Recv() (*JobUpdate, error) {
+	update := new(JobUpdate)
+	if err := s.ServerStream.RecvMsg(update); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*AgentsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PullJob",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := new(PullJobRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return srv.(AgentsServer).PullJob(ctx, req)
+				}
+				info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/PullJob"}
+				return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(AgentsServer).PullJob(ctx, req.(*PullJobRequest))
+				})
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "ReportJob",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				return srv.(AgentsServer).ReportJob(&agentsReportJobServer{stream})
+			},
+			ClientStreams: true,
+		},
+	},
+}
+
+// RegisterAgentsServer registers srv to serve the service on s.
+func RegisterAgentsServer(s *grpc.Server, srv AgentsServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+// AgentsClient is the client side of the service, used by agents.
+type AgentsClient interface {
+	PullJob(ctx context.Context, req *PullJobRequest) (*Job, error)
+	ReportJob(ctx context.Context) (ReportJobClient, error)
+}
+
+// ReportJobClient sends the updates of a job.
+type ReportJobClient interface {
+	Send(*JobUpdate) error
+	CloseAndRecv() (*ReportJobResponse, error)
+	grpc.ClientStream
+}
+
+// NewAgentsClient returns a client of the service on conn.
+func NewAgentsClient(conn *grpc.ClientConn) AgentsClient {
+	return &agentsClient{conn: conn}
+}
+
+type agentsClient struct {
+	conn *grpc.ClientConn
+}
+
+func (c *agentsClient) PullJob(ctx context.Context, req *PullJobRequest) (*Job, error) {
+	job := new(Job)
+	if err := c.conn.Invoke(ctx, "/"+serviceName+"/PullJob", req, job, callOptions()...); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (c *agentsClient) ReportJob(ctx context.Context) (ReportJobClient, error) {
+	stream, err := c.conn.NewStream(ctx, &serviceDesc.Streams[0], "/"+serviceName+"/ReportJob", callOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	return &agentsReportJobClient{stream}, nil
+}
+
+type agentsReportJobClient struct {
+	grpc.ClientStream
+}
+
+func (c *agentsReportJobClient) Send(update *JobUpdate) error {
+	return c.ClientStream.SendMsg(update)
+}
+
+func (c *agentsReportJobClient) CloseAndRecv() (*ReportJobResponse, error) {
+	if err := c.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	resp := new(ReportJobResponse)
+	if err := c.ClientStream.RecvMsg(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func callOptions() []grpc.CallOption {
+	return []grpc.CallOption{
+		grpc.CallContentSubtype(grpcjson.Name),
+		grpc.MaxCallRecvMsgSize(maxMessageBytes),
+		grpc.MaxCallSendMsgSize(maxMessageBytes),
+	}
+}
+
+// ServerOptions are the options of the gRPC server that agents connect to.
+func ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(maxMessageBytes),
+		grpc.MaxSendMsgSize(maxMessageBytes),
+	}
+}
@@ -1,17 +1,31 @@
 package controllers
 
 import (
+	"bytes"
 	"encoding/json"
+	"expvar"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/core/runtime"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/uber-go/tally"
 	"gopkg.in/go-playground/validator.v9"
@@ -22,6 +36,7 @@ const atlantisTokenHeader = "X-Atlantis-Token"
 type APIController struct {
 	APISecret                 []byte
 	Locker                    locking.Locker
+	Freezer                   locking.Freezer
 	Logger                    logging.SimpleLogging
 	Parser                    events.EventParsing
 	ProjectCommandBuilder     events.ProjectCommandBuilder
@@ -30,6 +45,37 @@ type APIController struct {
 	RepoAllowlistChecker      *events.RepoAllowlistChecker
 	Scope                     tally.Scope
 	VCSClient                 vcs.Client
+	WorkingDir                events.WorkingDir
+	// ShowStepRunner converts plans to JSON for PlanJSON.
+	ShowStepRunner events.StepRunner
+	Inventory      events.Inventory
+	ProjectRuns    events.ProjectRunStore
+	LockContention events.LockContentionStore
+	// StatusBadges is true if project status badges can be fetched without
+	// the API secret.
+	StatusBadges bool
+	// ParserValidator, GlobalCfg and AutoplanFileList are used to explain
+	// which projects modified files resolve to.
+	ParserValidator  *config.ParserValidator
+	GlobalCfg        *valid.GlobalCfgStore
+	AutoplanFileList string
+	// SupportBundle writes the bundles for DownloadSupportBundle.
+	SupportBundle *SupportBundle
+	// WorkingDirLocker and Drainer are used to list the commands that are
+	// running for DebugLocks.
+	WorkingDirLocker *events.DefaultWorkingDirLocker
+	Drainer          *events.Drainer
+	// JobCanceller cancels the running plans and applies for Cancel. If
+	// nil, they can't be cancelled.
+	JobCanceller *jobs.JobCanceller
+	// CommitStatusUpdater names the commit statuses for RequiredContexts.
+	CommitStatusUpdater *events.DefaultCommitStatusUpdater
+	// PolicyChecksEnabled is true if policy check statuses are required by
+	// default.
+	PolicyChecksEnabled bool
+	// BranchProtection updates the required status checks of branches for
+	// SyncRequiredContexts. If nil, they can't be synced.
+	BranchProtection vcs.BranchProtectionClient
 }
 
 type APIRequest struct {
@@ -42,6 +88,226 @@ type APIRequest struct {
 		Directory string
 		Workspace string
 	}
+	// DryRun is true if a plan request should only return the projects it
+	// would plan.
+	DryRun bool
+}
+
+// ProjectStatusResponse is the body of project status responses.
+type ProjectStatusResponse struct {
+	models.ProjectRunStatus
+	// Drift is "drifted" if the project's last plan found objects that
+	// changed outside of Terraform, "none" if it didn't and "unknown" if
+	// it's not known.
+	Drift string
+	// State summarizes the project's latest run, ex. "applied".
+	State string
+}
+
+// ProjectOutputsResponse is the body of project outputs responses.
+type ProjectOutputsResponse struct {
+	RepoFullName string
+	ProjectName  string
+	Path         string
+	Workspace    string
+	// PullNum and HeadCommit are the pull request and commit of the apply
+	// that the outputs were captured after.
+	PullNum    int
+	HeadCommit string
+	// JobID is the ID of the apply's job.
+	JobID     string
+	AppliedAt time.Time
+	// Outputs are the outputs by name. The values of sensitive outputs are
+	// left out.
+	Outputs map[string]models.TerraformOutput
+}
+
+// ProjectDurationsResponse is the duration stats of a project's plans and
+// applies in durations responses.
+type ProjectDurationsResponse struct {
+	RepoFullName string
+	ProjectName  string
+	Path         string
+	Workspace    string
+	Plan         DurationStatsResponse
+	Apply        DurationStatsResponse
+}
+
+// DurationStatsResponse is models.DurationStats with the durations in
+// seconds.
+type DurationStatsResponse struct {
+	Runs             int
+	P50Seconds       float64
+	P95Seconds       float64
+	RecentP50Seconds float64
+	// GettingSlower is true if the recent runs are at least 20% slower than
+	// the median run.
+	GettingSlower bool
+}
+
+// LockContentionResponse is how often a project's lock blocked other pull
+// requests in lock contention responses.
+type LockContentionResponse struct {
+	RepoFullName string
+	Path         string
+	Workspace    string
+	// Blocked is how many pull requests waited for the lock during the
+	// requested days and WaitedSeconds is how long they waited in total.
+	Blocked       int
+	WaitedSeconds float64
+	// BlockedPerDay is how many pull requests waited for the lock on each of
+	// the requested days, oldest first.
+	BlockedPerDay []int
+	// BlockedPulls are the pull requests that are waiting for the lock.
+	BlockedPulls []int
+}
+
+// DurationsRequest filters the projects that ListDurations returns.
+type DurationsRequest struct {
+	// Repository only returns the projects of that repo if it's set.
+	Repository string
+}
+
+// LockContentionRequest filters the projects that ListLockContention
+// returns.
+type LockContentionRequest struct {
+	// Repository only returns the projects of that repo if it's set.
+	Repository string
+	// Days is how many days back to look, DefaultLockContentionDays if it's
+	// 0.
+	Days int
+}
+
+// SupportBundleResponse is the gRPC response of SupportBundle. The REST API
+// downloads the zip file instead.
+type SupportBundleResponse struct {
+	Filename string
+	Zip      []byte
+}
+
+// DefaultLockContentionDays is how many days of lock contention are returned
+// by default.
+const DefaultLockContentionDays = 14
+
+// maxLockContentionDays is the most days of lock contention that can be
+// requested.
+const maxLockContentionDays = 90
+
+// RequiredContextsRequest is the body of required contexts sync requests
+// and the query parameters of required contexts requests.
+type RequiredContextsRequest struct {
+	Repository string `validate:"required"`
+	Type       string `validate:"required"`
+	// Branch is the branch whose required status checks are synced. The
+	// repo's atlantis.yaml is read from it to list the project contexts. It's
+	// optional when the contexts are only listed.
+	Branch string
+	// Commands are the commands whose statuses are required: plan,
+	// policy_check and apply. Defaults to plan, and policy_check if policy
+	// checks are enabled.
+	Commands []string
+}
+
+// RequiredContextsResponse is the body of required contexts responses.
+type RequiredContextsResponse struct {
+	// Contexts are reported on every pull request so branch protection can
+	// require them.
+	Contexts []string
+	// ProjectContexts are only reported on the pull requests that modify
+	// their project, so they shouldn't be required.
+	ProjectContexts []string
+}
+
+// ProjectResolutionRequest is the body of project resolution requests.
+type ProjectResolutionRequest struct {
+	Repository string `validate:"required"`
+	Type       string `validate:"required"`
+	// Ref is the branch or commit to download the repo's atlantis.yaml file
+	// from. It's ignored if RepoConfig is set.
+	Ref string
+	// ModifiedFiles are the paths of the modified files relative to the
+	// repo root.
+	ModifiedFiles []string
+	// RepoConfig is the contents of an atlantis.yaml file to use instead of
+	// the repo's.
+	RepoConfig string
+}
+
+// DebugLocksResponse is the body of DebugLocks responses.
+type DebugLocksResponse struct {
+	// InProgressOps is the number of commands that are running.
+	InProgressOps int
+	// WorkingDirLocks are held by the running commands, oldest first.
+	WorkingDirLocks []events.WorkingDirLock
+	// ProjectLocks are held by pull requests until they're applied or
+	// closed, oldest first.
+	ProjectLocks []DebugProjectLock
+}
+
+// DebugProjectLock is a project lock in DebugLocksResponse.
+type DebugProjectLock struct {
+	ID        string
+	Repo      string
+	Pull      int
+	User      string
+	Workspace string
+	Since     time.Time
+}
+
+// PlanExportRequest identifies the project whose pending plan PlanJSON and
+// PlanOutput return.
+type PlanExportRequest struct {
+	Repository string
+	Type       string
+	PR         int
+	// Dir, Workspace and Project select the project if the pull request has
+	// more than one.
+	Dir       string
+	Workspace string
+	Project   string
+}
+
+// InventoryRequest filters the projects that ListInventory returns. Empty
+// fields don't filter.
+type InventoryRequest struct {
+	Repository      string
+	Provider        string
+	ProviderVersion string
+	Module          string
+	ModuleVersion   string
+}
+
+// ProjectStatusRequest identifies the project whose status ProjectStatus
+// returns.
+type ProjectStatusRequest struct {
+	Repository string
+	Project    string
+	// Workspace is required if the project has more than one.
+	Workspace string
+}
+
+// CancelRequest is the body of cancel requests. It selects the running plans
+// and applies of a pull request to cancel.
+type CancelRequest struct {
+	// Repository is the owner and name of the repo, ex.
+	// "runatlantis/atlantis".
+	Repository string `validate:"required"`
+	PR         int    `validate:"required"`
+	// Dir, Workspace and Project only cancel the jobs of the projects they
+	// match. If they're empty, all the pull request's jobs are cancelled.
+	Dir       string
+	Workspace string
+	Project   string
+	// User is recorded as who cancelled the jobs. Defaults to "the API".
+	User string
+}
+
+// FreezeRequest is the body of freeze requests. If Repository is empty, all
+// repos are frozen. If Project is empty, all projects in the repo are frozen.
+type FreezeRequest struct {
+	Repository string
+	Project    string
+	Message    string
 }
 
 func (a *APIRequest) getCommands(ctx *command.Context, cmdBuilder func(*command.Context, *events.CommentCommand) ([]command.ProjectContext, error)) ([]command.ProjectContext, error) {
@@ -71,6 +337,34 @@ func (a *APIRequest) getCommands(ctx *command.Context, cmdBuilder func(*command.
 	return cmds, nil
 }
 
+// RegisterRoutes registers the REST API's routes on router. Keep
+// APIGRPCServer's methods in sync: every route has one except the badge,
+// pprof and vars routes, which only make sense over HTTP.
+func (a *APIController) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/plan", a.Plan).Methods("POST")
+	router.HandleFunc("/api/apply", a.Apply).Methods("POST")
+	router.HandleFunc("/api/plan/json", a.PlanJSON).Methods("GET")
+	router.HandleFunc("/api/plan/output", a.PlanOutput).Methods("GET")
+	router.HandleFunc("/api/inventory", a.ListInventory).Methods("GET")
+	router.HandleFunc("/api/durations", a.ListDurations).Methods("GET")
+	router.HandleFunc("/api/lock-contention", a.ListLockContention).Methods("GET")
+	router.HandleFunc("/api/required-contexts", a.RequiredContexts).Methods("GET")
+	router.HandleFunc("/api/required-contexts/sync", a.SyncRequiredContexts).Methods("POST")
+	router.HandleFunc("/api/cancel", a.Cancel).Methods("POST")
+	router.HandleFunc("/api/debug/project-resolution", a.ProjectResolution).Methods("POST")
+	router.HandleFunc("/api/debug/support-bundle", a.DownloadSupportBundle).Methods("GET")
+	router.HandleFunc("/api/debug/pprof/", a.DebugPprof).Methods("GET")
+	router.HandleFunc("/api/debug/pprof/{profile}", a.DebugPprof).Methods("GET", "POST")
+	router.HandleFunc("/api/debug/vars", a.DebugVars).Methods("GET")
+	router.HandleFunc("/api/debug/locks", a.DebugLocks).Methods("GET")
+	router.HandleFunc("/api/repos/{repo:.+}/projects/{project:.+}/status", a.ProjectStatus).Methods("GET")
+	router.HandleFunc("/api/repos/{repo:.+}/projects/{project:.+}/badge", a.ProjectStatusBadge).Methods("GET")
+	router.HandleFunc("/api/repos/{repo:.+}/projects/{project:.+}/outputs", a.ProjectOutputs).Methods("GET")
+	router.HandleFunc("/api/freeze", a.ListFreezes).Methods("GET")
+	router.HandleFunc("/api/freeze", a.Freeze).Methods("POST")
+	router.HandleFunc("/api/freeze", a.Unfreeze).Methods("DELETE")
+}
+
 func (a *APIController) apiReportError(w http.ResponseWriter, code int, err error) {
 	response, _ := json.Marshal(map[string]string{
 		"error": err.Error(),
@@ -87,15 +381,11 @@ func (a *APIController) Plan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := a.apiPlan(request, ctx)
+	result, code, err := a.apiRunPlan(request, ctx)
 	if err != nil {
-		a.apiReportError(w, http.StatusInternalServerError, err)
+		a.apiReportError(w, code, err)
 		return
 	}
-	defer a.Locker.UnlockByPull(ctx.HeadRepo.FullName, 0) // nolint: errcheck
-	if result.HasErrors() {
-		code = http.StatusInternalServerError
-	}
 
 	// TODO: make a better response
 	response, err := json.Marshal(result)
@@ -115,115 +405,1173 @@ func (a *APIController) Apply(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// We must first make the plan for all projects
-	_, err = a.apiPlan(request, ctx)
+	result, code, err := a.apiRunApply(request, ctx)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	response, err := json.Marshal(result)
 	if err != nil {
 		a.apiReportError(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer a.Locker.UnlockByPull(ctx.HeadRepo.FullName, 0) // nolint: errcheck
+	a.respond(w, logging.Debug, code, string(response))
+}
 
-	// We can now prepare and run the apply step
-	result, err := a.apiApply(request, ctx)
+// ListFreezes returns all freezes.
+func (a *APIController) ListFreezes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiCheckSecret(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	freezes, code, err := a.apiListFreezes()
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	response, err := json.Marshal(freezes)
 	if err != nil {
 		a.apiReportError(w, http.StatusInternalServerError, err)
 		return
 	}
-	if result.HasErrors() {
-		code = http.StatusInternalServerError
+	a.respond(w, logging.Debug, http.StatusOK, string(response))
+}
+
+// Freeze pauses applies globally, for a repo or for a project.
+func (a *APIController) Freeze(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	request, code, err := a.apiParseFreezeRequest(r)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
 	}
 
-	response, err := json.Marshal(result)
+	freeze, code, err := a.apiFreeze(*request)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	response, err := json.Marshal(freeze)
 	if err != nil {
 		a.apiReportError(w, http.StatusInternalServerError, err)
 		return
 	}
-	a.respond(w, logging.Debug, code, string(response))
+	a.respond(w, logging.Info, http.StatusOK, string(response))
 }
 
-func (a *APIController) apiPlan(request *APIRequest, ctx *command.Context) (*command.Result, error) {
-	cmds, err := request.getCommands(ctx, a.ProjectCommandBuilder.BuildPlanCommands)
+// Unfreeze deletes the freeze for a repo and project.
+func (a *APIController) Unfreeze(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	request, code, err := a.apiParseFreezeRequest(r)
 	if err != nil {
-		return nil, err
+		a.apiReportError(w, code, err)
+		return
 	}
 
-	var projectResults []command.ProjectResult
-	for _, cmd := range cmds {
-		res := a.ProjectPlanCommandRunner.Plan(cmd)
-		projectResults = append(projectResults, res)
+	freeze, code, err := a.apiUnfreeze(*request)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
 	}
-	return &command.Result{ProjectResults: projectResults}, nil
+	response, err := json.Marshal(freeze)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Info, http.StatusOK, string(response))
 }
 
-func (a *APIController) apiApply(request *APIRequest, ctx *command.Context) (*command.Result, error) {
-	cmds, err := request.getCommands(ctx, a.ProjectCommandBuilder.BuildApplyCommands)
+// Cancel cancels the running plans and applies that the request selects and
+// returns them.
+func (a *APIController) Cancel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	request, code, err := a.apiParseCancelRequest(r)
 	if err != nil {
-		return nil, err
+		a.apiReportError(w, code, err)
+		return
+	}
+	cancelled, code, err := a.apiCancel(request)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	response, err := json.Marshal(cancelled)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
 	}
+	a.respond(w, logging.Info, http.StatusOK, string(response))
+}
 
-	var projectResults []command.ProjectResult
-	for _, cmd := range cmds {
-		res := a.ProjectApplyCommandRunner.Apply(cmd)
-		projectResults = append(projectResults, res)
+// PlanJSON returns the JSON representation of a project's pending plan, as
+// output by terraform show -json.
+func (a *APIController) PlanJSON(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	request, code, err := a.apiParsePlanExportRequest(r)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
 	}
-	return &command.Result{ProjectResults: projectResults}, nil
+	output, code, err := a.apiPlanJSON(request)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	a.respondWithArtifact(w, output)
 }
 
-func (a *APIController) apiParseAndValidate(r *http.Request) (*APIRequest, *command.Context, int, error) {
-	if len(a.APISecret) == 0 {
-		return nil, nil, http.StatusBadRequest, fmt.Errorf("ignoring request since API is disabled")
+// PlanOutput returns the output of terraform plan for a project's pending
+// plan.
+func (a *APIController) PlanOutput(w http.ResponseWriter, r *http.Request) {
+	request, code, err := a.apiParsePlanExportRequest(r)
+	if err == nil {
+		var output string
+		if output, code, err = a.apiPlanOutput(request); err == nil {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			a.respondWithArtifact(w, output)
+			return
+		}
 	}
+	w.Header().Set("Content-Type", "application/json")
+	a.apiReportError(w, code, err)
+}
 
-	// Validate the secret token
-	secret := r.Header.Get(atlantisTokenHeader)
-	if secret != string(a.APISecret) {
-		return nil, nil, http.StatusUnauthorized, fmt.Errorf("header %s did not match expected secret", atlantisTokenHeader)
+// ListInventory returns the providers and modules each project uses. The
+// projects can be filtered by repository, provider and module, ex. to find
+// the projects that use a vulnerable provider version.
+func (a *APIController) ListInventory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiCheckSecret(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	query := r.URL.Query()
+	matches, code, err := a.apiListInventory(InventoryRequest{
+		Repository:      query.Get("repository"),
+		Provider:        query.Get("provider"),
+		ProviderVersion: query.Get("provider_version"),
+		Module:          query.Get("module"),
+		ModuleVersion:   query.Get("module_version"),
+	})
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	response, err := json.Marshal(matches)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
 	}
+	a.respond(w, logging.Debug, http.StatusOK, string(response))
+}
 
-	// Parse the JSON payload
-	bytes, err := io.ReadAll(r.Body)
+// ListDurations returns the p50 and p95 durations of the plans and applies of
+// each project, slowest first. The repository query parameter only returns
+// the projects of that repo.
+func (a *APIController) ListDurations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiCheckSecret(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	durations, code, err := a.apiListDurations(DurationsRequest{Repository: r.URL.Query().Get("repository")})
 	if err != nil {
-		return nil, nil, http.StatusBadRequest, fmt.Errorf("failed to read request")
+		a.apiReportError(w, code, err)
+		return
 	}
-	var request APIRequest
-	if err = json.Unmarshal(bytes, &request); err != nil {
-		return nil, nil, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err.Error())
+	response, err := json.Marshal(durations)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
 	}
-	if err = validator.New().Struct(request); err != nil {
-		return nil, nil, http.StatusBadRequest, fmt.Errorf("request %q is missing fields", string(bytes))
+	a.respond(w, logging.Debug, http.StatusOK, string(response))
+}
+
+// ListLockContention returns how often and how long each project's locks
+// blocked pull requests, most contended first. The repository query parameter
+// only returns the projects of that repo and days sets how many days back to
+// look, 14 by default.
+func (a *APIController) ListLockContention(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiCheckSecret(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	request := LockContentionRequest{Repository: r.URL.Query().Get("repository")}
+	if days := r.URL.Query().Get("days"); days != "" {
+		var err error
+		if request.Days, err = strconv.Atoi(days); err != nil || request.Days == 0 {
+			a.apiReportError(w, http.StatusBadRequest, errLockContentionDays)
+			return
+		}
+	}
+	contention, code, err := a.apiListLockContention(request, time.Now())
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	response, err := json.Marshal(contention)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
 	}
+	a.respond(w, logging.Debug, http.StatusOK, string(response))
+}
+
+// RequiredContexts returns the contexts of the commit statuses that Atlantis
+// reports on a repo's pull requests, to set as the required status checks of
+// its branch protection.
+func (a *APIController) RequiredContexts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	VCSHostType, err := models.NewVCSHostType(request.Type)
+	if code, err := a.apiCheckSecret(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	query := r.URL.Query()
+	request := RequiredContextsRequest{
+		Repository: query.Get("repository"),
+		Type:       query.Get("type"),
+		Branch:     query.Get("branch"),
+	}
+	if commands := query.Get("commands"); commands != "" {
+		request.Commands = strings.Split(commands, ",")
+	}
+	contexts, code, err := a.apiRequiredContexts(request)
 	if err != nil {
-		return nil, nil, http.StatusBadRequest, err
+		a.apiReportError(w, code, err)
+		return
 	}
-	cloneURL, err := a.VCSClient.GetCloneURL(VCSHostType, request.Repository)
+	response, err := json.Marshal(contexts)
 	if err != nil {
-		return nil, nil, http.StatusInternalServerError, err
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
 	}
+	a.respond(w, logging.Debug, http.StatusOK, string(response))
+}
+
+// SyncRequiredContexts sets the required status checks of a branch to the
+// contexts that RequiredContexts returns, removing the ones Atlantis no longer
+// reports on every pull request and keeping the ones of other tools.
+func (a *APIController) SyncRequiredContexts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	baseRepo, err := a.Parser.ParseAPIPlanRequest(VCSHostType, request.Repository, cloneURL)
+	if code, err := a.apiCheckSecret(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	var request RequiredContextsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err))
+		return
+	}
+	sync, code, err := a.apiSyncRequiredContexts(request)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	response, err := json.Marshal(sync)
 	if err != nil {
-		return nil, nil, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err)
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
 	}
+	a.respond(w, logging.Info, http.StatusOK, string(response))
+}
 
-	// Check if the repo is allowlisted
-	if !a.RepoAllowlistChecker.IsAllowlisted(baseRepo.FullName, baseRepo.VCSHost.Hostname) {
-		return nil, nil, http.StatusForbidden, fmt.Errorf("repo not allowlisted")
+// ProjectStatus returns the last plan and apply of a project and whether it
+// has drifted.
+func (a *APIController) ProjectStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiCheckSecret(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	vars := mux.Vars(r)
+	status, code, err := a.apiProjectStatus(ProjectStatusRequest{
+		Repository: vars["repo"],
+		Project:    vars["project"],
+		Workspace:  r.URL.Query().Get("workspace"),
+	})
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	response, err := json.Marshal(status)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
 	}
+	a.respond(w, logging.Debug, http.StatusOK, string(response))
+}
 
-	return &request, &command.Context{
-		HeadRepo: baseRepo,
-		Pull: models.PullRequest{
-			Num:        request.PR,
-			BaseBranch: request.Ref,
-			HeadBranch: request.Ref,
-			HeadCommit: request.Ref,
-			BaseRepo:   baseRepo,
-		},
-		Scope: a.Scope,
-		Log:   a.Logger,
-	}, http.StatusOK, nil
+// ProjectOutputs returns the outputs of a project after its last successful
+// apply, for automation that uses them.
+func (a *APIController) ProjectOutputs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiCheckSecret(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	vars := mux.Vars(r)
+	outputs, code, err := a.apiProjectOutputs(ProjectStatusRequest{
+		Repository: vars["repo"],
+		Project:    vars["project"],
+		Workspace:  r.URL.Query().Get("workspace"),
+	})
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	response, err := json.Marshal(outputs)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, string(response))
+}
+
+// ProjectStatusBadge returns an SVG badge with the state of a project for
+// READMEs and dashboards. Badges don't need the API secret since images can't
+// set headers, so they're disabled unless StatusBadges is true.
+func (a *APIController) ProjectStatusBadge(w http.ResponseWriter, r *http.Request) {
+	if !a.StatusBadges {
+		w.Header().Set("Content-Type", "application/json")
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("status badges are disabled"))
+		return
+	}
+
+	label := r.URL.Query().Get("label")
+	if label == "" {
+		label = mux.Vars(r)["project"]
+	}
+	// Projects we don't know about get an "unknown" badge rather than an
+	// error so that READMEs don't show broken images before the first plan.
+	state := "unknown"
+	vars := mux.Vars(r)
+	if status, _, err := a.apiFindProjectStatus(vars["repo"], vars["project"], r.URL.Query().Get("workspace")); err == nil {
+		state = status.State()
+	} else {
+		a.Logger.Debug("rendering unknown status badge: %s", err)
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "no-cache")
+	a.respondWithArtifact(w, renderStatusBadge(label, state))
+}
+
+func (a *APIController) apiPlan(request *APIRequest, ctx *command.Context) (*command.Result, error) {
+	cmds, err := request.getCommands(ctx, a.ProjectCommandBuilder.BuildPlanCommands)
+	if err != nil {
+		return nil, err
+	}
+
+	var projectResults []command.ProjectResult
+	for _, cmd := range cmds {
+		res := a.ProjectPlanCommandRunner.Plan(cmd)
+		projectResults = append(projectResults, res)
+	}
+	return &command.Result{ProjectResults: projectResults}, nil
+}
+
+// ProjectResolution explains which projects a list of modified files resolves
+// to using the repo's atlantis.yaml file.
+func (a *APIController) ProjectResolution(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiCheckSecret(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	var request ProjectResolutionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err))
+		return
+	}
+	resolution, code, err := a.apiResolveProjects(request)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	response, err := json.Marshal(resolution)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, string(response))
+}
+
+// DownloadSupportBundle returns a zip file with the server's redacted config,
+// version, the results of the readiness checks, recent errors and a goroutine
+// dump for troubleshooting the installation.
+func (a *APIController) DownloadSupportBundle(w http.ResponseWriter, r *http.Request) {
+	if code, err := a.apiCheckSecret(r); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		a.apiReportError(w, code, err)
+		return
+	}
+	bundle, code, err := a.apiSupportBundle(time.Now())
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		a.apiReportError(w, code, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", bundle.Filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(bundle.Zip) // nolint: errcheck
+}
+
+// DebugPprof serves the pprof index at /api/debug/pprof/ and the profiles
+// under it, ex. /api/debug/pprof/heap, to debug memory growth and hangs.
+func (a *APIController) DebugPprof(w http.ResponseWriter, r *http.Request) {
+	if code, err := a.apiCheckSecret(r); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		a.apiReportError(w, code, err)
+		return
+	}
+	// pprof.Index only serves profiles under /debug/pprof/ so they're
+	// served by name here.
+	switch profile := mux.Vars(r)["profile"]; profile {
+	case "":
+		pprof.Index(w, r)
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Handler(profile).ServeHTTP(w, r)
+	}
+}
+
+// DebugVars returns the expvar variables, ex. memstats, as JSON.
+func (a *APIController) DebugVars(w http.ResponseWriter, r *http.Request) {
+	if code, err := a.apiCheckSecret(r); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		a.apiReportError(w, code, err)
+		return
+	}
+	expvar.Handler().ServeHTTP(w, r)
+}
+
+// DebugLocks returns the number of commands that are running and the locks
+// held, to find commands that are stuck.
+func (a *APIController) DebugLocks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiCheckSecret(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	resp, code, err := a.apiDebugLocks()
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	response, err := json.Marshal(resp)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, string(response))
+}
+
+// apiDebugLocks returns the running commands and the locks they hold.
+func (a *APIController) apiDebugLocks() (DebugLocksResponse, int, error) {
+	projectLocks, err := a.Locker.List()
+	if err != nil {
+		return DebugLocksResponse{}, http.StatusInternalServerError, errors.Wrap(err, "listing project locks")
+	}
+	resp := DebugLocksResponse{
+		InProgressOps:   a.Drainer.GetStatus().InProgressOps,
+		WorkingDirLocks: a.WorkingDirLocker.List(),
+		ProjectLocks:    []DebugProjectLock{},
+	}
+	for id, l := range projectLocks {
+		resp.ProjectLocks = append(resp.ProjectLocks, DebugProjectLock{
+			ID:        id,
+			Repo:      l.Project.RepoFullName,
+			Pull:      l.Pull.Num,
+			User:      l.User.Username,
+			Workspace: l.Workspace,
+			Since:     l.Time,
+		})
+	}
+	sort.Slice(resp.ProjectLocks, func(i, j int) bool {
+		if !resp.ProjectLocks[i].Since.Equal(resp.ProjectLocks[j].Since) {
+			return resp.ProjectLocks[i].Since.Before(resp.ProjectLocks[j].Since)
+		}
+		return resp.ProjectLocks[i].ID < resp.ProjectLocks[j].ID
+	})
+	return resp, http.StatusOK, nil
+}
+
+// apiGetRepoCfg returns the repo config that request's files are resolved
+// with, or nil if the repo doesn't have an atlantis.yaml file.
+func (a *APIController) apiGetRepoCfg(repo models.Repo, request ProjectResolutionRequest) (*valid.RepoCfg, int, error) {
+	repoCfgData := []byte(request.RepoConfig)
+	if request.RepoConfig == "" {
+		if request.Ref == "" {
+			return nil, http.StatusBadRequest, fmt.Errorf("Ref or RepoConfig is required")
+		}
+		if !a.VCSClient.SupportsSingleFileDownload(repo) {
+			return nil, http.StatusBadRequest, fmt.Errorf("downloading %s from %s isn't supported, set RepoConfig instead", config.AtlantisYAMLFilename, repo.VCSHost.Type.String())
+		}
+		hasRepoCfg, data, err := a.VCSClient.DownloadRepoConfigFile(models.PullRequest{
+			BaseRepo:   repo,
+			HeadBranch: request.Ref,
+			HeadCommit: request.Ref,
+			BaseBranch: request.Ref,
+		})
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrapf(err, "downloading %s", config.AtlantisYAMLFilename)
+		}
+		if !hasRepoCfg {
+			return nil, http.StatusOK, nil
+		}
+		repoCfgData = data
+	}
+
+	repoCfg, err := a.ParserValidator.ParseRepoCfgData(repoCfgData, a.GlobalCfg.Get(), repo.ID())
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrapf(err, "parsing %s", config.AtlantisYAMLFilename)
+	}
+	return &repoCfg, http.StatusOK, nil
+}
+
+// apiRunPlan plans the projects of request and returns the result, or the
+// projects it would plan if it's a dry run. The code is 500 if a project
+// failed to plan.
+func (a *APIController) apiRunPlan(request *APIRequest, ctx *command.Context) (interface{}, int, error) {
+	if request.DryRun {
+		cmds, err := request.getCommands(ctx, a.ProjectCommandBuilder.BuildPlanCommands)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		return events.NewDryRunProjects(cmds), http.StatusOK, nil
+	}
+
+	result, err := a.apiPlan(request, ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer a.Locker.UnlockByPull(ctx.HeadRepo.FullName, 0) // nolint: errcheck
+	if result.HasErrors() {
+		return result, http.StatusInternalServerError, nil
+	}
+	return result, http.StatusOK, nil
+}
+
+// apiRunApply plans and then applies the projects of request. The code is
+// 500 if a project failed to apply.
+func (a *APIController) apiRunApply(request *APIRequest, ctx *command.Context) (*command.Result, int, error) {
+	// We must first make the plan for all projects
+	_, err := a.apiPlan(request, ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer a.Locker.UnlockByPull(ctx.HeadRepo.FullName, 0) // nolint: errcheck
+
+	// We can now prepare and run the apply step
+	result, err := a.apiApply(request, ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if result.HasErrors() {
+		return result, http.StatusInternalServerError, nil
+	}
+	return result, http.StatusOK, nil
+}
+
+func (a *APIController) apiListFreezes() ([]models.Freeze, int, error) {
+	freezes, err := a.Freezer.ListFreezes()
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if freezes == nil {
+		freezes = []models.Freeze{}
+	}
+	return freezes, http.StatusOK, nil
+}
+
+func (a *APIController) apiFreeze(request FreezeRequest) (models.Freeze, int, error) {
+	freeze, err := a.Freezer.Freeze(request.Repository, request.Project, request.Message)
+	if err != nil {
+		return models.Freeze{}, http.StatusBadRequest, err
+	}
+	return freeze, http.StatusOK, nil
+}
+
+func (a *APIController) apiUnfreeze(request FreezeRequest) (*models.Freeze, int, error) {
+	freeze, err := a.Freezer.Unfreeze(request.Repository, request.Project)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if freeze == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("no freeze found")
+	}
+	return freeze, http.StatusOK, nil
+}
+
+func (a *APIController) apiCancel(request CancelRequest) ([]jobs.RunningJob, int, error) {
+	if a.JobCanceller == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("no plans or applies that match are running")
+	}
+	user := request.User
+	if user == "" {
+		user = "the API"
+	}
+	dir := request.Dir
+	if dir != "" {
+		dir = filepath.Clean(dir)
+	}
+	cancelled := []jobs.RunningJob{}
+	for _, job := range a.JobCanceller.Running(request.Repository, request.PR) {
+		if !job.Matches(request.Project, dir, request.Workspace) {
+			continue
+		}
+		job, err := a.JobCanceller.Cancel(job.ID, user)
+		if err != nil {
+			a.Logger.Warn("unable to cancel job: %s", err)
+			continue
+		}
+		cancelled = append(cancelled, job)
+	}
+	if len(cancelled) == 0 {
+		return nil, http.StatusNotFound, fmt.Errorf("no plans or applies that match are running")
+	}
+	return cancelled, http.StatusOK, nil
+}
+
+// apiPlanJSON returns the JSON representation of the pending plan of the
+// project that request identifies.
+func (a *APIController) apiPlanJSON(request PlanExportRequest) (string, int, error) {
+	projCtx, projAbsPath, code, err := a.apiFindPlan(request)
+	if err != nil {
+		return "", code, err
+	}
+	// We always run terraform show instead of reusing the JSON written by
+	// policy checks since it may be for an older plan.
+	output, err := a.ShowStepRunner.Run(projCtx, nil, projAbsPath, map[string]string{})
+	if err != nil {
+		return "", http.StatusInternalServerError, err
+	}
+	return output, http.StatusOK, nil
+}
+
+// apiPlanOutput returns the output of terraform plan for the pending plan of
+// the project that request identifies.
+func (a *APIController) apiPlanOutput(request PlanExportRequest) (string, int, error) {
+	projCtx, projAbsPath, code, err := a.apiFindPlan(request)
+	if err != nil {
+		return "", code, err
+	}
+	output, err := os.ReadFile(filepath.Join(projAbsPath, projCtx.GetPlanOutputFileName()))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", http.StatusNotFound, fmt.Errorf("no plan output found for project, it may have been planned before plan outputs were saved")
+		}
+		return "", http.StatusInternalServerError, err
+	}
+	return string(output), http.StatusOK, nil
+}
+
+// apiListInventory returns the inventory of the projects that match request.
+func (a *APIController) apiListInventory(request InventoryRequest) ([]models.ProjectInventory, int, error) {
+	if request.ProviderVersion != "" && request.Provider == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("the provider query parameter is required to filter by provider_version")
+	}
+	if request.ModuleVersion != "" && request.Module == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("the module query parameter is required to filter by module_version")
+	}
+
+	inventory, err := a.Inventory.ListInventory()
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	matches := []models.ProjectInventory{}
+	for _, i := range inventory {
+		if request.Repository != "" && !strings.EqualFold(i.RepoFullName, request.Repository) {
+			continue
+		}
+		if request.Provider != "" && !i.UsesProvider(request.Provider, request.ProviderVersion) {
+			continue
+		}
+		if request.Module != "" && !i.UsesModule(request.Module, request.ModuleVersion) {
+			continue
+		}
+		matches = append(matches, i)
+	}
+	return matches, http.StatusOK, nil
+}
+
+func (a *APIController) apiListDurations(request DurationsRequest) ([]ProjectDurationsResponse, int, error) {
+	if a.ProjectRuns == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("project runs aren't recorded")
+	}
+	runs, err := a.ProjectRuns.ListProjectRuns()
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	durations := []ProjectDurationsResponse{}
+	for _, status := range models.SortBySlowest(models.NewProjectRunStatuses(runs)) {
+		if request.Repository != "" && !strings.EqualFold(status.RepoFullName, request.Repository) {
+			continue
+		}
+		durations = append(durations, ProjectDurationsResponse{
+			RepoFullName: status.RepoFullName,
+			ProjectName:  status.ProjectName,
+			Path:         status.Path,
+			Workspace:    status.Workspace,
+			Plan:         newDurationStatsResponse(status.PlanDurations()),
+			Apply:        newDurationStatsResponse(status.ApplyDurations()),
+		})
+	}
+	return durations, http.StatusOK, nil
+}
+
+var errLockContentionDays = fmt.Errorf("days must be a number between 1 and %d", maxLockContentionDays)
+
+func (a *APIController) apiListLockContention(request LockContentionRequest, now time.Time) ([]LockContentionResponse, int, error) {
+	if a.LockContention == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("lock contention isn't recorded")
+	}
+	days := request.Days
+	if days == 0 {
+		days = DefaultLockContentionDays
+	}
+	if days < 1 || days > maxLockContentionDays {
+		return nil, http.StatusBadRequest, errLockContentionDays
+	}
+	contention, err := a.LockContention.ListLockContention()
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	since := now.AddDate(0, 0, -days)
+	responses := []LockContentionResponse{}
+	for _, c := range models.SortByContention(contention, since, now) {
+		if request.Repository != "" && !strings.EqualFold(c.RepoFullName, request.Repository) {
+			continue
+		}
+		blocked, waited := c.Waited(since, now)
+		responses = append(responses, LockContentionResponse{
+			RepoFullName:  c.RepoFullName,
+			Path:          c.Path,
+			Workspace:     c.Workspace,
+			Blocked:       blocked,
+			WaitedSeconds: waited.Seconds(),
+			BlockedPerDay: c.BlockedPerDay(now, days),
+			BlockedPulls:  c.BlockedPulls(),
+		})
+	}
+	return responses, http.StatusOK, nil
+}
+
+func newDurationStatsResponse(stats models.DurationStats) DurationStatsResponse {
+	return DurationStatsResponse{
+		Runs:             stats.Runs,
+		P50Seconds:       stats.P50.Seconds(),
+		P95Seconds:       stats.P95.Seconds(),
+		RecentP50Seconds: stats.RecentP50.Seconds(),
+		GettingSlower:    stats.GettingSlower(),
+	}
+}
+
+func (a *APIController) apiProjectStatus(request ProjectStatusRequest) (ProjectStatusResponse, int, error) {
+	status, code, err := a.apiFindProjectStatus(request.Repository, request.Project, request.Workspace)
+	if err != nil {
+		return ProjectStatusResponse{}, code, err
+	}
+	return ProjectStatusResponse{
+		ProjectRunStatus: status,
+		Drift:            status.Drift(),
+		State:            status.State(),
+	}, http.StatusOK, nil
+}
+
+func (a *APIController) apiProjectOutputs(request ProjectStatusRequest) (ProjectOutputsResponse, int, error) {
+	status, code, err := a.apiFindProjectStatus(request.Repository, request.Project, request.Workspace)
+	if err != nil {
+		return ProjectOutputsResponse{}, code, err
+	}
+	apply := status.LastApply
+	if apply == nil || apply.Outcome != models.SuccessProjectRunOutcome || apply.Outputs == nil {
+		return ProjectOutputsResponse{}, http.StatusNotFound, fmt.Errorf("no outputs found for project %q in repo %q: its last apply didn't succeed or its outputs weren't captured", request.Project, request.Repository)
+	}
+	return ProjectOutputsResponse{
+		RepoFullName: status.RepoFullName,
+		ProjectName:  status.ProjectName,
+		Path:         status.Path,
+		Workspace:    status.Workspace,
+		PullNum:      apply.PullNum,
+		HeadCommit:   apply.HeadCommit,
+		JobID:        apply.JobID,
+		AppliedAt:    apply.Time,
+		Outputs:      apply.Outputs,
+	}, http.StatusOK, nil
+}
+
+// apiSupportBundle writes a support bundle at now.
+func (a *APIController) apiSupportBundle(now time.Time) (SupportBundleResponse, int, error) {
+	var bundle bytes.Buffer
+	if _, err := a.SupportBundle.Write(&bundle, now); err != nil {
+		return SupportBundleResponse{}, http.StatusInternalServerError, fmt.Errorf("writing support bundle: %s", err)
+	}
+	return SupportBundleResponse{Filename: SupportBundleFilename(now), Zip: bundle.Bytes()}, http.StatusOK, nil
+}
+
+// apiResolveProjects explains which projects request's modified files
+// resolve to.
+func (a *APIController) apiResolveProjects(request ProjectResolutionRequest) (events.ProjectResolution, int, error) {
+	if err := validator.New().Struct(request); err != nil {
+		return events.ProjectResolution{}, http.StatusBadRequest, fmt.Errorf("request is missing fields: %v", err)
+	}
+	repo, code, err := a.apiParseRepo(request.Type, request.Repository)
+	if err != nil {
+		return events.ProjectResolution{}, code, err
+	}
+
+	repoCfg, code, err := a.apiGetRepoCfg(repo, request)
+	if err != nil {
+		return events.ProjectResolution{}, code, err
+	}
+	resolution, err := events.ResolveProjects(request.ModifiedFiles, repoCfg, a.AutoplanFileList)
+	if err != nil {
+		return events.ProjectResolution{}, http.StatusBadRequest, err
+	}
+	return resolution, http.StatusOK, nil
+}
+
+func (a *APIController) apiRequiredContexts(request RequiredContextsRequest) (RequiredContextsResponse, int, error) {
+	_, cmds, repoCfg, code, err := a.apiParseRequiredContextsRequest(request)
+	if err != nil {
+		return RequiredContextsResponse{}, code, err
+	}
+	contexts, err := a.CommitStatusUpdater.StatusContexts(cmds, repoCfg)
+	if err != nil {
+		return RequiredContextsResponse{}, http.StatusInternalServerError, err
+	}
+	return RequiredContextsResponse{Contexts: contexts.Required, ProjectContexts: contexts.Project}, http.StatusOK, nil
+}
+
+func (a *APIController) apiSyncRequiredContexts(request RequiredContextsRequest) (events.RequiredStatusChecksSync, int, error) {
+	if request.Branch == "" {
+		return events.RequiredStatusChecksSync{}, http.StatusBadRequest, fmt.Errorf("Branch is required")
+	}
+	repo, cmds, repoCfg, code, err := a.apiParseRequiredContextsRequest(request)
+	if err != nil {
+		return events.RequiredStatusChecksSync{}, code, err
+	}
+	if a.BranchProtection == nil || repo.VCSHost.Type != models.Github {
+		return events.RequiredStatusChecksSync{}, http.StatusBadRequest, fmt.Errorf("syncing required status checks is only supported for GitHub")
+	}
+	sync, err := a.CommitStatusUpdater.SyncRequiredStatusChecks(a.BranchProtection, repo, request.Branch, cmds, repoCfg)
+	if err != nil {
+		return events.RequiredStatusChecksSync{}, http.StatusInternalServerError, err
+	}
+	return sync, http.StatusOK, nil
+}
+
+// apiParseRequiredContextsRequest returns the repo, the commands whose
+// statuses are required and the repo's atlantis.yaml on the request's branch,
+// which is nil if it doesn't have one or the branch isn't set.
+func (a *APIController) apiParseRequiredContextsRequest(request RequiredContextsRequest) (models.Repo, []command.Name, *valid.RepoCfg, int, error) {
+	if err := validator.New().Struct(request); err != nil {
+		return models.Repo{}, nil, nil, http.StatusBadRequest, fmt.Errorf("request is missing fields: %v", err)
+	}
+	repo, code, err := a.apiParseRepo(request.Type, request.Repository)
+	if err != nil {
+		return models.Repo{}, nil, nil, code, err
+	}
+
+	cmds := []command.Name{command.Plan}
+	if a.PolicyChecksEnabled {
+		cmds = append(cmds, command.PolicyCheck)
+	}
+	if len(request.Commands) > 0 {
+		cmds = nil
+		for _, name := range request.Commands {
+			cmd, ok := statusCommand(name)
+			if !ok {
+				return models.Repo{}, nil, nil, http.StatusBadRequest, fmt.Errorf("invalid command %q: must be one of plan, policy_check or apply", name)
+			}
+			cmds = append(cmds, cmd)
+		}
+	}
+
+	var repoCfg *valid.RepoCfg
+	if request.Branch != "" {
+		repoCfg, code, err = a.apiGetRepoCfg(repo, ProjectResolutionRequest{Ref: request.Branch})
+		if err != nil {
+			return models.Repo{}, nil, nil, code, err
+		}
+	}
+	return repo, cmds, repoCfg, http.StatusOK, nil
+}
+
+// statusCommand returns the command named name that Atlantis reports commit
+// statuses for.
+func statusCommand(name string) (command.Name, bool) {
+	for _, cmd := range events.StatusCommands {
+		if cmd.String() == strings.TrimSpace(name) {
+			return cmd, true
+		}
+	}
+	return 0, false
+}
+
+func (a *APIController) apiApply(request *APIRequest, ctx *command.Context) (*command.Result, error) {
+	cmds, err := request.getCommands(ctx, a.ProjectCommandBuilder.BuildApplyCommands)
+	if err != nil {
+		return nil, err
+	}
+
+	var projectResults []command.ProjectResult
+	for _, cmd := range cmds {
+		res := a.ProjectApplyCommandRunner.Apply(cmd)
+		projectResults = append(projectResults, res)
+	}
+	return &command.Result{ProjectResults: projectResults}, nil
+}
+
+// apiCheckSecret returns an error if the API is disabled or the request's
+// token doesn't match the API secret.
+func (a *APIController) apiCheckSecret(r *http.Request) (int, error) {
+	if len(a.APISecret) == 0 {
+		return http.StatusBadRequest, fmt.Errorf("ignoring request since API is disabled")
+	}
+
+	// Validate the secret token
+	secret := r.Header.Get(atlantisTokenHeader)
+	if secret != string(a.APISecret) {
+		return http.StatusUnauthorized, fmt.Errorf("header %s did not match expected secret", atlantisTokenHeader)
+	}
+	return http.StatusOK, nil
+}
+
+func (a *APIController) apiParseFreezeRequest(r *http.Request) (*FreezeRequest, int, error) {
+	if code, err := a.apiCheckSecret(r); err != nil {
+		return nil, code, err
+	}
+
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to read request")
+	}
+	var request FreezeRequest
+	if len(bytes) > 0 {
+		if err = json.Unmarshal(bytes, &request); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err.Error())
+		}
+	}
+	return &request, http.StatusOK, nil
+}
+
+func (a *APIController) apiParseCancelRequest(r *http.Request) (CancelRequest, int, error) {
+	if code, err := a.apiCheckSecret(r); err != nil {
+		return CancelRequest{}, code, err
+	}
+
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		return CancelRequest{}, http.StatusBadRequest, fmt.Errorf("failed to read request")
+	}
+	var request CancelRequest
+	if err = json.Unmarshal(bytes, &request); err != nil {
+		return CancelRequest{}, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err.Error())
+	}
+	if err = validator.New().Struct(request); err != nil {
+		return CancelRequest{}, http.StatusBadRequest, fmt.Errorf("request %q is missing fields", string(bytes))
+	}
+	return request, http.StatusOK, nil
+}
+
+// apiParsePlanExportRequest returns the plan export request that the query
+// parameters of r make.
+func (a *APIController) apiParsePlanExportRequest(r *http.Request) (PlanExportRequest, int, error) {
+	if code, err := a.apiCheckSecret(r); err != nil {
+		return PlanExportRequest{}, code, err
+	}
+
+	query := r.URL.Query()
+	if query.Get("repository") == "" || query.Get("type") == "" || query.Get("pr") == "" {
+		return PlanExportRequest{}, http.StatusBadRequest, fmt.Errorf("the repository, type and pr query parameters are required")
+	}
+	pullNum, err := strconv.Atoi(query.Get("pr"))
+	if err != nil {
+		return PlanExportRequest{}, http.StatusBadRequest, fmt.Errorf("invalid pr %q: must be a number", query.Get("pr"))
+	}
+	return PlanExportRequest{
+		Repository: query.Get("repository"),
+		Type:       query.Get("type"),
+		PR:         pullNum,
+		Dir:        query.Get("dir"),
+		Workspace:  query.Get("workspace"),
+		Project:    query.Get("project"),
+	}, http.StatusOK, nil
+}
+
+// apiFindPlan returns the context of the project with a pending plan that
+// request identifies and the absolute path to the project.
+func (a *APIController) apiFindPlan(request PlanExportRequest) (command.ProjectContext, string, int, error) {
+	if request.Repository == "" || request.Type == "" || request.PR == 0 {
+		return command.ProjectContext{}, "", http.StatusBadRequest, fmt.Errorf("the repository, type and pr query parameters are required")
+	}
+	pullNum := request.PR
+	baseRepo, code, err := a.apiParseRepo(request.Type, request.Repository)
+	if err != nil {
+		return command.ProjectContext{}, "", code, err
+	}
+
+	ctx := &command.Context{
+		HeadRepo: baseRepo,
+		Pull: models.PullRequest{
+			Num:      pullNum,
+			BaseRepo: baseRepo,
+		},
+		Scope: a.Scope,
+		Log:   a.Logger,
+	}
+	projectCmds, err := a.ProjectCommandBuilder.BuildApplyCommands(ctx, &events.CommentCommand{
+		Name:        command.Apply,
+		RepoRelDir:  strings.TrimRight(request.Dir, "/"),
+		Workspace:   request.Workspace,
+		ProjectName: request.Project,
+	})
+	if err != nil {
+		return command.ProjectContext{}, "", http.StatusInternalServerError, err
+	}
+	if len(projectCmds) != 1 {
+		return command.ProjectContext{}, "", http.StatusBadRequest, fmt.Errorf("request must match exactly one project but matched %d, use the project or dir and workspace query parameters to specify the project", len(projectCmds))
+	}
+	projCtx := projectCmds[0]
+
+	repoDir, err := a.WorkingDir.GetWorkingDir(baseRepo, ctx.Pull, projCtx.Workspace)
+	if err != nil {
+		return command.ProjectContext{}, "", http.StatusNotFound, fmt.Errorf("no plan found for project")
+	}
+	projAbsPath := filepath.Join(repoDir, projCtx.RepoRelDir)
+	if _, err := os.Stat(filepath.Join(projAbsPath, runtime.GetPlanFilename(projCtx.Workspace, projCtx.ProjectName))); err != nil {
+		return command.ProjectContext{}, "", http.StatusNotFound, fmt.Errorf("no plan found for project")
+	}
+	return projCtx, projAbsPath, http.StatusOK, nil
+}
+
+// apiFindProjectStatus returns the status of project in workspace of repo.
+// workspace can be empty if the project only has one.
+func (a *APIController) apiFindProjectStatus(repo string, project string, workspace string) (models.ProjectRunStatus, int, error) {
+	if a.ProjectRuns == nil {
+		return models.ProjectRunStatus{}, http.StatusNotFound, fmt.Errorf("project statuses aren't recorded")
+	}
+	runs, err := a.ProjectRuns.ListProjectRuns()
+	if err != nil {
+		return models.ProjectRunStatus{}, http.StatusInternalServerError, err
+	}
+	var matches []models.ProjectRunStatus
+	for _, status := range models.NewProjectRunStatuses(runs) {
+		if status.Matches(repo, project, workspace) {
+			matches = append(matches, status)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return models.ProjectRunStatus{}, http.StatusNotFound, fmt.Errorf("no status found for project %q in repo %q", project, repo)
+	case 1:
+		return matches[0], http.StatusOK, nil
+	default:
+		return models.ProjectRunStatus{}, http.StatusBadRequest, fmt.Errorf("project matched %d workspaces, use the workspace query parameter to specify the workspace", len(matches))
+	}
+}
+
+// apiParseRepo returns the repo named repository on the VCS host of type
+// vcsType. It returns an error if the repo isn't allowlisted.
+func (a *APIController) apiParseRepo(vcsType string, repository string) (models.Repo, int, error) {
+	VCSHostType, err := models.NewVCSHostType(vcsType)
+	if err != nil {
+		return models.Repo{}, http.StatusBadRequest, err
+	}
+	cloneURL, err := a.VCSClient.GetCloneURL(VCSHostType, repository)
+	if err != nil {
+		return models.Repo{}, http.StatusInternalServerError, err
+	}
+
+	baseRepo, err := a.Parser.ParseAPIPlanRequest(VCSHostType, repository, cloneURL)
+	if err != nil {
+		return models.Repo{}, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err)
+	}
+
+	// Check if the repo is allowlisted
+	if !a.RepoAllowlistChecker.IsAllowlisted(baseRepo.FullName, baseRepo.VCSHost.Hostname) {
+		return models.Repo{}, http.StatusForbidden, fmt.Errorf("repo not allowlisted")
+	}
+	return baseRepo, http.StatusOK, nil
+}
+
+func (a *APIController) apiParseAndValidate(r *http.Request) (*APIRequest, *command.Context, int, error) {
+	if code, err := a.apiCheckSecret(r); err != nil {
+		return nil, nil, code, err
+	}
+
+	// Parse the JSON payload
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, fmt.Errorf("failed to read request")
+	}
+	var request APIRequest
+	if err = json.Unmarshal(bytes, &request); err != nil {
+		return nil, nil, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err.Error())
+	}
+	if err = validator.New().Struct(request); err != nil {
+		return nil, nil, http.StatusBadRequest, fmt.Errorf("request %q is missing fields", string(bytes))
+	}
+	ctx, code, err := a.apiNewCommandContext(&request)
+	if err != nil {
+		return nil, nil, code, err
+	}
+	return &request, ctx, http.StatusOK, nil
+}
+
+// apiNewCommandContext returns the context that request's commands run in.
+func (a *APIController) apiNewCommandContext(request *APIRequest) (*command.Context, int, error) {
+	baseRepo, code, err := a.apiParseRepo(request.Type, request.Repository)
+	if err != nil {
+		return nil, code, err
+	}
+
+	return &command.Context{
+		HeadRepo: baseRepo,
+		Pull: models.PullRequest{
+			Num:        request.PR,
+			BaseBranch: request.Ref,
+			HeadBranch: request.Ref,
+			HeadCommit: request.Ref,
+			BaseRepo:   baseRepo,
+		},
+		Scope: a.Scope,
+		Log:   a.Logger,
+	}, http.StatusOK, nil
+}
+
+// respondWithArtifact writes artifact to w. Unlike respond, it doesn't log
+// artifact since plans can be large and contain sensitive values.
+func (a *APIController) respondWithArtifact(w http.ResponseWriter, artifact string) {
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, artifact)
 }
 
 func (a *APIController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
@@ -7,15 +7,25 @@ import (
 	"github.com/runatlantis/atlantis/server/events/models"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gorilla/mux"
 	. "github.com/petergtz/pegomock"
 	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/core/config"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/db"
+	"github.com/runatlantis/atlantis/server/core/locking"
 	. "github.com/runatlantis/atlantis/server/core/locking/mocks"
 	"github.com/runatlantis/atlantis/server/events"
 	. "github.com/runatlantis/atlantis/server/events/mocks"
 	. "github.com/runatlantis/atlantis/server/events/mocks/matchers"
 	. "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/metrics"
 	. "github.com/runatlantis/atlantis/testing"
@@ -41,6 +51,33 @@ func TestAPIController_Plan(t *testing.T) {
 	projectCommandRunner.VerifyWasCalledOnce().Plan(AnyModelsProjectCommandContext())
 }
 
+func TestAPIController_Plan_DryRun(t *testing.T) {
+	ac, projectCommandBuilder, projectCommandRunner := setup(t)
+	When(projectCommandBuilder.BuildPlanCommands(AnyPtrToEventsCommandContext(), AnyPtrToEventsCommentCommand())).
+		ThenReturn([]command.ProjectContext{{
+			CommandName:       command.Plan,
+			ProjectName:       "staging",
+			RepoRelDir:        "staging",
+			Workspace:         "default",
+			WorkflowName:      "default",
+			AutoplanEnabled:   true,
+			ApplyRequirements: []string{"approved"},
+		}}, nil)
+	body, _ := json.Marshal(controllers.APIRequest{
+		Repository: "Repo",
+		Ref:        "main",
+		Type:       "Gitlab",
+		Projects:   []string{"staging"},
+		DryRun:     true,
+	})
+	req, _ := http.NewRequest("POST", "", bytes.NewBuffer(body))
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w := httptest.NewRecorder()
+	ac.Plan(w, req)
+	ResponseContains(t, w, http.StatusOK, `[{"ProjectName":"staging","RepoRelDir":"staging","Workspace":"default","Workflow":"default","AutoplanEnabled":true,"ApplyRequirements":["approved"],"TerraformVersion":""}]`)
+	projectCommandRunner.VerifyWasCalled(Never()).Plan(AnyModelsProjectCommandContext())
+}
+
 func TestAPIController_Apply(t *testing.T) {
 	ac, projectCommandBuilder, projectCommandRunner := setup(t)
 	body, _ := json.Marshal(controllers.APIRequest{
@@ -59,6 +96,563 @@ func TestAPIController_Apply(t *testing.T) {
 	projectCommandRunner.VerifyWasCalledOnce().Apply(AnyModelsProjectCommandContext())
 }
 
+func TestAPIController_Freeze(t *testing.T) {
+	ac, _, _ := setup(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltDB, err := db.New(tmp)
+	Ok(t, err)
+	ac.Freezer = locking.NewFreezeClient(boltDB)
+
+	do := func(method string, request *controllers.FreezeRequest, token string) *httptest.ResponseRecorder {
+		var body []byte
+		if request != nil {
+			body, _ = json.Marshal(request)
+		}
+		req, _ := http.NewRequest(method, "", bytes.NewBuffer(body))
+		req.Header.Set(atlantisTokenHeader, token)
+		w := httptest.NewRecorder()
+		switch method {
+		case "GET":
+			ac.ListFreezes(w, req)
+		case "POST":
+			ac.Freeze(w, req)
+		case "DELETE":
+			ac.Unfreeze(w, req)
+		}
+		return w
+	}
+
+	ResponseContains(t, do("POST", &controllers.FreezeRequest{Message: "incident"}, "wrong"), http.StatusUnauthorized, "did not match expected secret")
+	ResponseContains(t, do("GET", nil, atlantisToken), http.StatusOK, "[]")
+
+	ResponseContains(t, do("POST", &controllers.FreezeRequest{Repository: "owner/repo", Project: "prod", Message: "incident"}, atlantisToken), http.StatusOK, `"Message":"incident"`)
+	ResponseContains(t, do("GET", nil, atlantisToken), http.StatusOK, `"RepoFullName":"owner/repo","Project":"prod","Message":"incident"`)
+	ResponseContains(t, do("POST", &controllers.FreezeRequest{Project: "prod"}, atlantisToken), http.StatusBadRequest, "a repo is required to freeze a project")
+
+	ResponseContains(t, do("DELETE", &controllers.FreezeRequest{Repository: "owner/repo", Project: "prod"}, atlantisToken), http.StatusOK, `"Project":"prod"`)
+	ResponseContains(t, do("DELETE", &controllers.FreezeRequest{Repository: "owner/repo", Project: "prod"}, atlantisToken), http.StatusNotFound, "no freeze found")
+}
+
+func TestAPIController_Cancel(t *testing.T) {
+	ac, _, _ := setup(t)
+	ac.JobCanceller = jobs.NewJobCanceller(nil, time.Minute)
+	end := ac.JobCanceller.Begin(command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		BaseRepo:   models.Repo{FullName: "owner/repo"},
+		Pull:       models.PullRequest{Num: 1},
+		JobID:      "1234",
+		RepoRelDir: "apps/app",
+		Workspace:  "default",
+	}, command.Apply)
+	defer end()
+
+	do := func(request controllers.CancelRequest, token string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(request)
+		req, _ := http.NewRequest("POST", "", bytes.NewBuffer(body))
+		req.Header.Set(atlantisTokenHeader, token)
+		w := httptest.NewRecorder()
+		ac.Cancel(w, req)
+		return w
+	}
+
+	ResponseContains(t, do(controllers.CancelRequest{Repository: "owner/repo", PR: 1}, "wrong"), http.StatusUnauthorized, "did not match expected secret")
+	ResponseContains(t, do(controllers.CancelRequest{Repository: "owner/repo"}, atlantisToken), http.StatusBadRequest, "is missing fields")
+	ResponseContains(t, do(controllers.CancelRequest{Repository: "owner/repo", PR: 1, Dir: "other"}, atlantisToken), http.StatusNotFound, "no plans or applies that match are running")
+	ResponseContains(t, do(controllers.CancelRequest{Repository: "owner/repo", PR: 1, Dir: "./apps/app/", User: "deploy-bot"}, atlantisToken), http.StatusOK, `"ID":"1234"`)
+	Equals(t, "deploy-bot", end())
+}
+
+func TestAPIController_PlanExport(t *testing.T) {
+	ac, projectCommandBuilder, _ := setup(t)
+	When(projectCommandBuilder.BuildApplyCommands(AnyPtrToEventsCommandContext(), AnyPtrToEventsCommentCommand())).
+		ThenReturn([]command.ProjectContext{{
+			CommandName: command.Apply,
+			RepoRelDir:  ".",
+			Workspace:   "default",
+		}}, nil)
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	workingDir := NewMockWorkingDir()
+	When(workingDir.GetWorkingDir(AnyModelsRepo(), AnyModelsPullRequest(), AnyString())).ThenReturn(repoDir, nil)
+	ac.WorkingDir = workingDir
+	showStepRunner := NewMockStepRunner()
+	When(showStepRunner.Run(AnyModelsProjectCommandContext(), AnyStringSlice(), AnyString(), AnyMapOfStringToString())).
+		ThenReturn(`{"format_version":"1.0"}`, nil)
+	ac.ShowStepRunner = showStepRunner
+
+	do := func(query string, token string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/plan/json?"+query, nil)
+		req.Header.Set(atlantisTokenHeader, token)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+	query := "repository=owner/repo&type=Github&pr=1&dir=.&workspace=default"
+
+	ResponseContains(t, do(query, "wrong", ac.PlanJSON), http.StatusUnauthorized, "did not match expected secret")
+	ResponseContains(t, do("repository=owner/repo&type=Github", atlantisToken, ac.PlanJSON), http.StatusBadRequest, "the repository, type and pr query parameters are required")
+	ResponseContains(t, do("repository=owner/repo&type=Github&pr=one", atlantisToken, ac.PlanJSON), http.StatusBadRequest, `invalid pr \"one\"`)
+	ResponseContains(t, do(query, atlantisToken, ac.PlanJSON), http.StatusNotFound, "no plan found for project")
+
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "default.tfplan"), nil, 0600))
+	ResponseContains(t, do(query, atlantisToken, ac.PlanJSON), http.StatusOK, `{"format_version":"1.0"}`)
+	ResponseContains(t, do(query, atlantisToken, ac.PlanOutput), http.StatusNotFound, "no plan output found for project")
+
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "default.plan.txt"), []byte("Plan: 1 to add, 0 to change, 0 to destroy."), 0600))
+	w := do(query, atlantisToken, ac.PlanOutput)
+	ResponseContains(t, w, http.StatusOK, "Plan: 1 to add, 0 to change, 0 to destroy.")
+	Equals(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+func TestAPIController_ListInventory(t *testing.T) {
+	ac, _, _ := setup(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltDB, err := db.New(tmp)
+	Ok(t, err)
+	ac.Inventory = boltDB
+	Ok(t, boltDB.UpdateInventory(models.ProjectInventory{
+		RepoFullName: "owner/repo",
+		Path:         "staging",
+		Providers:    []models.InventoryProvider{{Source: "registry.terraform.io/hashicorp/aws", Version: "4.36.1"}},
+	}))
+	Ok(t, boltDB.UpdateInventory(models.ProjectInventory{
+		RepoFullName: "owner/other",
+		Path:         "prod",
+		Providers:    []models.InventoryProvider{{Source: "registry.terraform.io/hashicorp/aws", Version: "4.30.0"}},
+	}))
+
+	do := func(query string, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/inventory?"+query, nil)
+		req.Header.Set(atlantisTokenHeader, token)
+		w := httptest.NewRecorder()
+		ac.ListInventory(w, req)
+		return w
+	}
+
+	ResponseContains(t, do("", "wrong"), http.StatusUnauthorized, "did not match expected secret")
+	ResponseContains(t, do("provider_version=4.36.1", atlantisToken), http.StatusBadRequest, "the provider query parameter is required to filter by provider_version")
+
+	w := do("", atlantisToken)
+	Equals(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	Assert(t, strings.Contains(body, `"Path":"staging"`) && strings.Contains(body, `"Path":"prod"`), "exp both projects, got %s", body)
+
+	w = do("provider=hashicorp/aws&provider_version=4.36.1", atlantisToken)
+	Equals(t, http.StatusOK, w.Code)
+	body = w.Body.String()
+	Assert(t, strings.Contains(body, `"Path":"staging"`) && !strings.Contains(body, `"Path":"prod"`), "exp only staging, got %s", body)
+
+	ResponseContains(t, do("repository=owner/none", atlantisToken), http.StatusOK, "[]")
+}
+
+func TestAPIController_DownloadSupportBundle(t *testing.T) {
+	ac, _, _ := setup(t)
+	ac.SupportBundle = &controllers.SupportBundle{
+		AtlantisVersion: "0.19.8",
+		Config:          map[string]interface{}{},
+		Readiness:       &controllers.ReadinessController{Logger: ac.Logger, Timeout: time.Second},
+	}
+
+	do := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/debug/support-bundle", nil)
+		req.Header.Set(atlantisTokenHeader, token)
+		w := httptest.NewRecorder()
+		ac.DownloadSupportBundle(w, req)
+		return w
+	}
+
+	ResponseContains(t, do("wrong"), http.StatusUnauthorized, "did not match expected secret")
+
+	w := do(atlantisToken)
+	Equals(t, http.StatusOK, w.Code)
+	Equals(t, "application/zip", w.Header().Get("Content-Type"))
+	Assert(t, strings.HasPrefix(w.Header().Get("Content-Disposition"), `attachment; filename="atlantis-support-bundle-`), "got %s", w.Header().Get("Content-Disposition"))
+	Assert(t, strings.HasPrefix(w.Body.String(), "PK"), "expected a zip file")
+}
+
+func TestAPIController_Debug(t *testing.T) {
+	ac, _, _ := setup(t)
+	since := time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC)
+	When(ac.Locker.List()).ThenReturn(map[string]models.ProjectLock{
+		"owner/repo/staging/default": {
+			Project:   models.Project{RepoFullName: "owner/repo", Path: "staging"},
+			Pull:      models.PullRequest{Num: 1},
+			User:      models.User{Username: "alice"},
+			Workspace: "default",
+			Time:      since,
+		},
+	}, nil)
+	ac.WorkingDirLocker = events.NewDefaultWorkingDirLocker()
+	_, err := ac.WorkingDirLocker.TryLock("owner/repo", 1, "default", "staging")
+	Ok(t, err)
+	ac.Drainer = &events.Drainer{}
+	Assert(t, ac.Drainer.StartOp(), "exp op to start")
+
+	do := func(handler http.HandlerFunc, path string, vars map[string]string, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req = mux.SetURLVars(req, vars)
+		req.Header.Set(atlantisTokenHeader, token)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	ResponseContains(t, do(ac.DebugPprof, "/api/debug/pprof/", nil, "wrong"), http.StatusUnauthorized, "did not match expected secret")
+	ResponseContains(t, do(ac.DebugVars, "/api/debug/vars", nil, "wrong"), http.StatusUnauthorized, "did not match expected secret")
+	ResponseContains(t, do(ac.DebugLocks, "/api/debug/locks", nil, "wrong"), http.StatusUnauthorized, "did not match expected secret")
+
+	ResponseContains(t, do(ac.DebugPprof, "/api/debug/pprof/", nil, atlantisToken), http.StatusOK, "goroutine?debug=1")
+	ResponseContains(t, do(ac.DebugPprof, "/api/debug/pprof/goroutine?debug=2", map[string]string{"profile": "goroutine"}, atlantisToken), http.StatusOK, "TestAPIController_Debug")
+	ResponseContains(t, do(ac.DebugVars, "/api/debug/vars", nil, atlantisToken), http.StatusOK, `"memstats"`)
+
+	w := do(ac.DebugLocks, "/api/debug/locks", nil, atlantisToken)
+	Equals(t, http.StatusOK, w.Code)
+	var resp controllers.DebugLocksResponse
+	Ok(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	Equals(t, 1, resp.InProgressOps)
+	Equals(t, 1, len(resp.WorkingDirLocks))
+	Equals(t, "owner/repo/1/default/staging", resp.WorkingDirLocks[0].Key)
+	Equals(t, []controllers.DebugProjectLock{{
+		ID:        "owner/repo/staging/default",
+		Repo:      "owner/repo",
+		Pull:      1,
+		User:      "alice",
+		Workspace: "default",
+		Since:     since,
+	}}, resp.ProjectLocks)
+}
+
+func TestAPIController_ProjectStatus(t *testing.T) {
+	ac, _, _ := setup(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltDB, err := db.New(tmp)
+	Ok(t, err)
+	ac.ProjectRuns = boltDB
+	planTime := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	for _, run := range []models.ProjectRun{
+		{RepoFullName: "owner/repo", ProjectName: "staging", Path: "staging", Workspace: "default", Command: "plan", Outcome: models.SuccessProjectRunOutcome, Drifted: true, Time: planTime},
+		{RepoFullName: "owner/repo", ProjectName: "staging", Path: "staging", Workspace: "default", Command: "apply", Outcome: models.SuccessProjectRunOutcome, Time: planTime.Add(time.Minute)},
+		{RepoFullName: "owner/repo", Path: "prod", Workspace: "default", Command: "plan", Outcome: models.SuccessProjectRunOutcome, Time: planTime},
+		{RepoFullName: "owner/repo", Path: "prod", Workspace: "other", Command: "plan", Outcome: models.FailedProjectRunOutcome, Time: planTime},
+	} {
+		Ok(t, boltDB.UpdateProjectRun(run))
+	}
+
+	do := func(project string, query string, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/repos/owner/repo/projects/"+project+"/status?"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"repo": "owner/repo", "project": project})
+		req.Header.Set(atlantisTokenHeader, token)
+		w := httptest.NewRecorder()
+		ac.ProjectStatus(w, req)
+		return w
+	}
+
+	ResponseContains(t, do("staging", "", "wrong"), http.StatusUnauthorized, "did not match expected secret")
+	ResponseContains(t, do("none", "", atlantisToken), http.StatusNotFound, "no status found for project")
+	ResponseContains(t, do("prod", "", atlantisToken), http.StatusBadRequest, "project matched 2 workspaces")
+
+	w := do("staging", "", atlantisToken)
+	Equals(t, http.StatusOK, w.Code)
+	var status controllers.ProjectStatusResponse
+	Ok(t, json.Unmarshal(w.Body.Bytes(), &status))
+	Equals(t, "applied", status.State)
+	Equals(t, "drifted", status.Drift)
+	Equals(t, planTime, status.LastPlan.Time)
+
+	w = do("prod", "workspace=other", atlantisToken)
+	Equals(t, http.StatusOK, w.Code)
+	Ok(t, json.Unmarshal(w.Body.Bytes(), &status))
+	Equals(t, "plan failed", status.State)
+	Equals(t, "unknown", status.Drift)
+	Assert(t, status.LastApply == nil, "exp no apply")
+}
+
+func TestAPIController_ProjectOutputs(t *testing.T) {
+	ac, _, _ := setup(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltDB, err := db.New(tmp)
+	Ok(t, err)
+	ac.ProjectRuns = boltDB
+	applyTime := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	outputs := map[string]models.TerraformOutput{
+		"vpc_id":   {Type: json.RawMessage(`"string"`), Value: json.RawMessage(`"vpc-123"`)},
+		"password": {Sensitive: true, Type: json.RawMessage(`"string"`)},
+	}
+	for _, run := range []models.ProjectRun{
+		{RepoFullName: "owner/repo", ProjectName: "network", Path: "network", Workspace: "default", Command: "apply", Outcome: models.SuccessProjectRunOutcome,
+			PullNum: 3, HeadCommit: "abc123", JobID: "job-1", Outputs: outputs, Time: applyTime},
+		{RepoFullName: "owner/repo", Path: "prod", Workspace: "default", Command: "apply", Outcome: models.FailedProjectRunOutcome, Time: applyTime},
+	} {
+		Ok(t, boltDB.UpdateProjectRun(run))
+	}
+
+	do := func(project string, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/repos/owner/repo/projects/"+project+"/outputs", nil)
+		req = mux.SetURLVars(req, map[string]string{"repo": "owner/repo", "project": project})
+		req.Header.Set(atlantisTokenHeader, token)
+		w := httptest.NewRecorder()
+		ac.ProjectOutputs(w, req)
+		return w
+	}
+
+	ResponseContains(t, do("network", "wrong"), http.StatusUnauthorized, "did not match expected secret")
+	ResponseContains(t, do("none", atlantisToken), http.StatusNotFound, "no status found for project")
+	ResponseContains(t, do("prod", atlantisToken), http.StatusNotFound, "its last apply didn't succeed")
+
+	w := do("network", atlantisToken)
+	Equals(t, http.StatusOK, w.Code)
+	var resp controllers.ProjectOutputsResponse
+	Ok(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	Equals(t, controllers.ProjectOutputsResponse{
+		RepoFullName: "owner/repo",
+		ProjectName:  "network",
+		Path:         "network",
+		Workspace:    "default",
+		PullNum:      3,
+		HeadCommit:   "abc123",
+		JobID:        "job-1",
+		AppliedAt:    applyTime,
+		Outputs:      outputs,
+	}, resp)
+}
+
+func TestAPIController_ProjectStatusBadge(t *testing.T) {
+	ac, _, _ := setup(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltDB, err := db.New(tmp)
+	Ok(t, err)
+	ac.ProjectRuns = boltDB
+	Ok(t, boltDB.UpdateProjectRun(models.ProjectRun{RepoFullName: "owner/repo", ProjectName: "staging", Path: "staging", Workspace: "default", Command: "apply", Outcome: models.FailedProjectRunOutcome}))
+
+	do := func(project string, query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/repos/owner/repo/projects/"+project+"/badge?"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"repo": "owner/repo", "project": project})
+		w := httptest.NewRecorder()
+		ac.ProjectStatusBadge(w, req)
+		return w
+	}
+
+	ResponseContains(t, do("staging", ""), http.StatusNotFound, "status badges are disabled")
+
+	ac.StatusBadges = true
+	w := do("staging", "")
+	Equals(t, http.StatusOK, w.Code)
+	Equals(t, "image/svg+xml", w.Header().Get("Content-Type"))
+	Assert(t, strings.Contains(w.Body.String(), "<title>staging: apply failed</title>"), "got %s", w.Body.String())
+
+	w = do("none", "label=<prod>")
+	Equals(t, http.StatusOK, w.Code)
+	Assert(t, strings.Contains(w.Body.String(), "<title>&lt;prod&gt;: unknown</title>"), "got %s", w.Body.String())
+}
+
+func TestAPIController_ListDurations(t *testing.T) {
+	ac, _, _ := setup(t)
+	do := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/durations?"+query, nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.ListDurations(w, req)
+		return w
+	}
+	ResponseContains(t, do(""), http.StatusNotFound, "project runs aren't recorded")
+
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltDB, err := db.New(tmp)
+	Ok(t, err)
+	ac.ProjectRuns = boltDB
+	for _, run := range []models.ProjectRun{
+		{RepoFullName: "owner/repo", Path: "staging", Workspace: "default", Command: "plan", Duration: time.Minute},
+		{RepoFullName: "owner/repo", Path: "staging", Workspace: "default", Command: "plan", Duration: 3 * time.Minute},
+		{RepoFullName: "owner/repo", Path: "prod", Workspace: "default", Command: "apply", Duration: 10 * time.Minute},
+		{RepoFullName: "owner/other", Path: "prod", Workspace: "default", Command: "plan", Duration: time.Second},
+	} {
+		Ok(t, boltDB.UpdateProjectRun(run))
+	}
+
+	w := do("repository=owner/repo")
+	Equals(t, http.StatusOK, w.Code)
+	var durations []controllers.ProjectDurationsResponse
+	Ok(t, json.Unmarshal(w.Body.Bytes(), &durations))
+	Equals(t, []controllers.ProjectDurationsResponse{
+		{
+			RepoFullName: "owner/repo",
+			Path:         "prod",
+			Workspace:    "default",
+			Apply:        controllers.DurationStatsResponse{Runs: 1, P50Seconds: 600, P95Seconds: 600, RecentP50Seconds: 600},
+		},
+		{
+			RepoFullName: "owner/repo",
+			Path:         "staging",
+			Workspace:    "default",
+			Plan:         controllers.DurationStatsResponse{Runs: 2, P50Seconds: 60, P95Seconds: 180, RecentP50Seconds: 60},
+		},
+	}, durations)
+
+	Ok(t, json.Unmarshal(do("").Body.Bytes(), &durations))
+	Equals(t, 3, len(durations))
+}
+
+func TestAPIController_ListLockContention(t *testing.T) {
+	ac, _, _ := setup(t)
+	do := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/lock-contention?"+query, nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.ListLockContention(w, req)
+		return w
+	}
+	ResponseContains(t, do(""), http.StatusNotFound, "lock contention isn't recorded")
+
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltDB, err := db.New(tmp)
+	Ok(t, err)
+	ac.LockContention = boltDB
+	now := time.Now()
+	for _, attempt := range []models.LockAttempt{
+		{RepoFullName: "owner/repo", Path: "staging", Workspace: "default", PullNum: 2, BlockingPullNum: 1, Time: now.Add(-3 * time.Hour)},
+		{RepoFullName: "owner/repo", Path: "staging", Workspace: "default", PullNum: 2, Time: now.Add(-time.Hour)},
+		{RepoFullName: "owner/repo", Path: "prod", Workspace: "default", PullNum: 3, BlockingPullNum: 1, Time: now.Add(-time.Hour)},
+		{RepoFullName: "owner/other", Path: "prod", Workspace: "default", PullNum: 5, BlockingPullNum: 4, Time: now.Add(-20 * 24 * time.Hour)},
+	} {
+		Ok(t, boltDB.RecordLockAttempt(attempt))
+	}
+
+	ResponseContains(t, do("days=0"), http.StatusBadRequest, "days must be a number between 1 and 90")
+
+	w := do("repository=owner/repo&days=1")
+	Equals(t, http.StatusOK, w.Code)
+	var contention []controllers.LockContentionResponse
+	Ok(t, json.Unmarshal(w.Body.Bytes(), &contention))
+	Equals(t, 2, len(contention))
+	Equals(t, "staging", contention[0].Path)
+	Equals(t, 1, contention[0].Blocked)
+	Equals(t, 2*time.Hour, time.Duration(contention[0].WaitedSeconds)*time.Second)
+	Equals(t, 1, len(contention[0].BlockedPerDay))
+	Equals(t, []int(nil), contention[0].BlockedPulls)
+	Equals(t, "prod", contention[1].Path)
+	Equals(t, []int{3}, contention[1].BlockedPulls)
+
+	Ok(t, json.Unmarshal(do("days=30").Body.Bytes(), &contention))
+	Equals(t, 3, len(contention))
+	Equals(t, 30, len(contention[0].BlockedPerDay))
+}
+
+func TestAPIController_ProjectResolution(t *testing.T) {
+	ac, _, _ := setup(t)
+	ac.ParserValidator = &config.ParserValidator{}
+	ac.GlobalCfg = valid.NewGlobalCfgStore(valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{AllowRepoCfg: true}))
+	ac.AutoplanFileList = "**/*.tf"
+	vcsClient := NewMockClient()
+	ac.VCSClient = vcsClient
+
+	do := func(request controllers.ProjectResolutionRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(request)
+		req, _ := http.NewRequest("POST", "/api/debug/project-resolution", bytes.NewBuffer(body))
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.ProjectResolution(w, req)
+		return w
+	}
+	repoCfg := "version: 3\nprojects:\n- dir: staging\n  autoplan:\n    when_modified: [\"*.tf\"]\n"
+
+	ResponseContains(t, do(controllers.ProjectResolutionRequest{
+		Repository:    "owner/repo",
+		Type:          "Github",
+		ModifiedFiles: []string{"staging/main.tf"},
+		RepoConfig:    repoCfg,
+	}), http.StatusOK, `"Files":[{"File":"staging/main.tf","Matched":true,"Rule":"staging/*.tf"}]`)
+
+	// The repo's atlantis.yaml file is downloaded from Ref.
+	When(vcsClient.SupportsSingleFileDownload(AnyModelsRepo())).ThenReturn(true)
+	When(vcsClient.DownloadRepoConfigFile(AnyModelsPullRequest())).ThenReturn(true, []byte(repoCfg), nil)
+	ResponseContains(t, do(controllers.ProjectResolutionRequest{
+		Repository:    "owner/repo",
+		Type:          "Github",
+		Ref:           "main",
+		ModifiedFiles: []string{"README.md"},
+	}), http.StatusOK, `"UnmatchedFiles":[{"File":"README.md","Matched":false,"Rule":""}]`)
+
+	// Repos without an atlantis.yaml file detect projects.
+	When(vcsClient.DownloadRepoConfigFile(AnyModelsPullRequest())).ThenReturn(false, nil, nil)
+	ResponseContains(t, do(controllers.ProjectResolutionRequest{
+		Repository:    "owner/repo",
+		Type:          "Github",
+		Ref:           "main",
+		ModifiedFiles: []string{"production/main.tf"},
+	}), http.StatusOK, `"RepoCfg":false,"Projects":[{"ProjectName":"","Dir":"production"`)
+
+	ResponseContains(t, do(controllers.ProjectResolutionRequest{
+		Repository: "owner/repo",
+		Type:       "Github",
+	}), http.StatusBadRequest, "Ref or RepoConfig is required")
+}
+
+type fakeBranchProtection struct {
+	contexts []string
+}
+
+func (f *fakeBranchProtection) GetRequiredStatusChecks(repo models.Repo, branch string) ([]string, error) {
+	return f.contexts, nil
+}
+
+func (f *fakeBranchProtection) SetRequiredStatusChecks(repo models.Repo, branch string, contexts []string) error {
+	f.contexts = contexts
+	return nil
+}
+
+func TestAPIController_RequiredContexts(t *testing.T) {
+	ac, _, _ := setup(t)
+	ac.ParserValidator = &config.ParserValidator{}
+	ac.GlobalCfg = valid.NewGlobalCfgStore(valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{AllowRepoCfg: true}))
+	ac.CommitStatusUpdater = &events.DefaultCommitStatusUpdater{StatusName: "atlantis"}
+	ac.PolicyChecksEnabled = true
+	vcsClient := NewMockClient()
+	ac.VCSClient = vcsClient
+	When(vcsClient.SupportsSingleFileDownload(AnyModelsRepo())).ThenReturn(true)
+	When(vcsClient.DownloadRepoConfigFile(AnyModelsPullRequest())).ThenReturn(true, []byte("version: 3\nprojects:\n- dir: staging\n"), nil)
+
+	do := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/required-contexts?"+query, nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.RequiredContexts(w, req)
+		return w
+	}
+	ResponseContains(t, do("repository=owner/repo&type=Github"), http.StatusOK,
+		`{"Contexts":["atlantis/plan","atlantis/policy_check"],"ProjectContexts":null}`)
+	ResponseContains(t, do("repository=owner/repo&type=Github&branch=main&commands=plan,apply"), http.StatusOK,
+		`{"Contexts":["atlantis/plan","atlantis/apply"],"ProjectContexts":["atlantis/plan: staging/default","atlantis/apply: staging/default"]}`)
+	ResponseContains(t, do("repository=owner/repo&type=Github&commands=fmt"), http.StatusBadRequest, `invalid command \"fmt\"`)
+	ResponseContains(t, do("type=Github"), http.StatusBadRequest, "request is missing fields")
+
+	sync := func(request controllers.RequiredContextsRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(request)
+		req, _ := http.NewRequest("POST", "/api/required-contexts/sync", bytes.NewBuffer(body))
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.SyncRequiredContexts(w, req)
+		return w
+	}
+	request := controllers.RequiredContextsRequest{Repository: "owner/repo", Type: "Github", Branch: "main"}
+	ResponseContains(t, sync(request), http.StatusBadRequest, "only supported for GitHub")
+
+	branchProtection := &fakeBranchProtection{contexts: []string{"ci/test", "atlantis/apply"}}
+	ac.BranchProtection = branchProtection
+	ResponseContains(t, sync(request), http.StatusOK,
+		`{"Contexts":["atlantis/plan","atlantis/policy_check","ci/test"],"Added":["atlantis/plan","atlantis/policy_check"],"Removed":["atlantis/apply"]}`)
+	Equals(t, []string{"atlantis/plan", "atlantis/policy_check", "ci/test"}, branchProtection.contexts)
+
+	request.Branch = ""
+	ResponseContains(t, sync(request), http.StatusBadRequest, "Branch is required")
+}
+
 func setup(t *testing.T) (controllers.APIController, *MockProjectCommandBuilder, *MockProjectCommandRunner) {
 	RegisterMockTestingT(t)
 	locker := NewMockLocker()
@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	// The API's messages are encoded as JSON.
+	_ "github.com/runatlantis/atlantis/server/grpcjson"
+	"github.com/runatlantis/atlantis/server/jobs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+const (
+	apiServiceName = "atlantis.API"
+	// apiTokenMetadata is the metadata key of the API secret, the gRPC
+	// counterpart of the X-Atlantis-Token header.
+	apiTokenMetadata = "x-atlantis-token"
+)
+
+// APIGRPCServer serves the API over gRPC. Its methods take and return the
+// same JSON as the REST API's endpoints, encoded with grpcjson, except that
+// SupportBundle returns the zip file in its response. WatchJobs streams the
+// output of jobs as they run so that integrations don't need to poll.
+type APIGRPCServer struct {
+	API *APIController
+	// JobEvents are streamed by WatchJobs. Nil if the output of jobs isn't
+	// streamed, ex. with Terraform Cloud's remote execution mode.
+	JobEvents *jobs.JobEvents
+}
+
+// WatchJobsRequest filters the events that WatchJobs streams. Empty fields
+// don't filter.
+type WatchJobsRequest struct {
+	// Repository is the owner and name of the repo, ex. runatlantis/atlantis.
+	Repository string
+	PR         int
+	JobID      string
+}
+
+func (w WatchJobsRequest) matches(event jobs.JobEvent) bool {
+	return (w.Repository == "" || strings.EqualFold(w.Repository, event.RepoFullName)) &&
+		(w.PR == 0 || w.PR == event.PullNum) &&
+		(w.JobID == "" || w.JobID == event.JobID)
+}
+
+// RegisterAPIGRPCServer registers srv to serve the API on s.
+func RegisterAPIGRPCServer(s *grpc.Server, srv *APIGRPCServer) {
+	s.RegisterService(&apiServiceDesc, srv)
+}
+
+var apiServiceDesc = grpc.ServiceDesc{
+	ServiceName: apiServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		apiMethod("Plan", func() interface{} { return new(APIRequest) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			request := req.(*APIRequest)
+			ctx, code, err := a.apiValidateCommandRequest(request)
+			if err != nil {
+				return nil, code, err
+			}
+			return a.apiRunPlan(request, ctx)
+		}),
+		apiMethod("Apply", func() interface{} { return new(APIRequest) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			request := req.(*APIRequest)
+			ctx, code, err := a.apiValidateCommandRequest(request)
+			if err != nil {
+				return nil, code, err
+			}
+			return a.apiRunApply(request, ctx)
+		}),
+		apiMethod("ListFreezes", func() interface{} { return new(struct{}) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			return a.apiListFreezes()
+		}),
+		apiMethod("Freeze", func() interface{} { return new(FreezeRequest) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			return a.apiFreeze(*req.(*FreezeRequest))
+		}),
+		apiMethod("Unfreeze", func() interface{} { return new(FreezeRequest) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			return a.apiUnfreeze(*req.(*FreezeRequest))
+		}),
+		apiMethod("PlanJSON", func() interface{} { return new(PlanExportRequest) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			return a.apiPlanJSON(*req.(*PlanExportRequest))
+		}),
+		apiMethod("PlanOutput", func() interface{} { return new(PlanExportRequest) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			return a.apiPlanOutput(*req.(*PlanExportRequest))
+		}),
+		apiMethod("ListInventory", func() interface{} { return new(InventoryRequest) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			return a.apiListInventory(*req.(*InventoryRequest))
+		}),
+		apiMethod("ProjectStatus", func() interface{} { return new(ProjectStatusRequest) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			return a.apiProjectStatus(*req.(*ProjectStatusRequest))
+		}),
+		apiMethod("ListDurations", func() interface{} { return new(DurationsRequest) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			return a.apiListDurations(*req.(*DurationsRequest))
+		}),
+		apiMethod("ListLockContention", func() interface{} { return new(LockContentionRequest) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			return a.apiListLockContention(*req.(*LockContentionRequest), time.Now())
+		}),
+		apiMethod("RequiredContexts", func() interface{} { return new(RequiredContextsRequest) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			return a.apiRequiredContexts(*req.(*RequiredContextsRequest))
+		}),
+		apiMethod("SyncRequiredContexts", func() interface{} { return new(RequiredContextsRequest) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			return a.apiSyncRequiredContexts(*req.(*RequiredContextsRequest))
+		}),
+		apiMethod("Cancel", func() interface{} { return new(CancelRequest) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			request := *req.(*CancelRequest)
+			if err := validator.New().Struct(request); err != nil {
+				return nil, http.StatusBadRequest, fmt.Errorf("request is missing fields: %v", err)
+			}
+			return a.apiCancel(request)
+		}),
+		apiMethod("ProjectOutputs", func() interface{} { return new(ProjectStatusRequest) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			return a.apiProjectOutputs(*req.(*ProjectStatusRequest))
+		}),
+		apiMethod("SupportBundle", func() interface{} { return new(struct{}) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			return a.apiSupportBundle(time.Now())
+		}),
+		apiMethod("ProjectResolution", func() interface{} { return new(ProjectResolutionRequest) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			return a.apiResolveProjects(*req.(*ProjectResolutionRequest))
+		}),
+		apiMethod("DebugLocks", func() interface{} { return new(struct{}) }, func(a *APIController, req interface{}) (interface{}, int, error) {
+			return a.apiDebugLocks()
+		}),
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "WatchJobs",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(WatchJobsRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(*APIGRPCServer).watchJobs(*req, stream)
+			},
+			ServerStreams: true,
+		},
+	},
+}
+
+// apiMethod returns the unary method name that decodes its request into
+// newRequest() and responds with what handle returns. handle's code is an
+// HTTP status code like the REST API's, which is converted to a gRPC code
+// if handle fails.
+func apiMethod(name string, newRequest func() interface{}, handle func(a *APIController, req interface{}) (interface{}, int, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := newRequest()
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			call := func(ctx context.Context, req interface{}) (interface{}, error) {
+				s := srv.(*APIGRPCServer)
+				if err := s.checkToken(ctx); err != nil {
+					return nil, err
+				}
+				resp, code, err := handle(s.API, req)
+				if err != nil {
+					s.API.Logger.Warn("%s: %s", name, err)
+					return nil, status.Error(grpcCode(code), err.Error())
+				}
+				return resp, nil
+			}
+			if interceptor == nil {
+				return call(ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + apiServiceName + "/" + name}
+			return interceptor(ctx, req, info, call)
+		},
+	}
+}
+
+// watchJobs streams the events of the jobs that req matches until the
+// client disconnects.
+func (s *APIGRPCServer) watchJobs(req WatchJobsRequest, stream grpc.ServerStream) error {
+	if err := s.checkToken(stream.Context()); err != nil {
+		return err
+	}
+	if s.JobEvents == nil {
+		return status.Error(codes.Unavailable, "job events aren't available since the output of jobs isn't streamed")
+	}
+	events := s.JobEvents.Subscribe()
+	defer s.JobEvents.Unsubscribe(events)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "stream fell too far behind the job events")
+			}
+			if !req.matches(event) {
+				continue
+			}
+			if err := stream.SendMsg(&event); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// checkToken returns an error if the API is disabled or ctx's token doesn't
+// match the API secret.
+func (s *APIGRPCServer) checkToken(ctx context.Context) error {
+	if len(s.API.APISecret) == 0 {
+		return status.Error(codes.FailedPrecondition, "ignoring request since API is disabled")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	tokens := md.Get(apiTokenMetadata)
+	if len(tokens) != 1 || tokens[0] != string(s.API.APISecret) {
+		return status.Errorf(codes.Unauthenticated, "metadata %s did not match expected secret", apiTokenMetadata)
+	}
+	return nil
+}
+
+// apiValidateCommandRequest validates a plan or apply request and returns
+// the context that its commands run in.
+func (a *APIController) apiValidateCommandRequest(request *APIRequest) (*command.Context, int, error) {
+	if err := validator.New().Struct(request); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return a.apiNewCommandContext(request)
+}
+
+// grpcCode returns the gRPC code of an HTTP status code.
+func grpcCode(httpCode int) codes.Code {
+	switch httpCode {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	default:
+		return codes.Internal
+	}
+}
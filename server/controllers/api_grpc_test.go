@@ -0,0 +1,206 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/core/db"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/command"
+	. "github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/grpcjson"
+	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestAPIGRPCServer_Plan(t *testing.T) {
+	ac, projectCommandBuilder, projectCommandRunner := setup(t)
+	conn := serveAPIGRPC(t, &controllers.APIGRPCServer{API: &ac})
+
+	var result command.Result
+	err := conn.Invoke(apiGRPCContext(atlantisToken), "/atlantis.API/Plan", controllers.APIRequest{
+		Repository: "Repo",
+		Ref:        "main",
+		Type:       "Gitlab",
+		Projects:   []string{"default"},
+	}, &result, grpc.CallContentSubtype(grpcjson.Name))
+	Ok(t, err)
+	Equals(t, 1, len(result.ProjectResults))
+	projectCommandBuilder.VerifyWasCalledOnce().BuildPlanCommands(AnyPtrToEventsCommandContext(), AnyPtrToEventsCommentCommand())
+	projectCommandRunner.VerifyWasCalledOnce().Plan(AnyModelsProjectCommandContext())
+
+	err = conn.Invoke(apiGRPCContext(atlantisToken), "/atlantis.API/Plan", controllers.APIRequest{Repository: "Repo"}, &result, grpc.CallContentSubtype(grpcjson.Name))
+	Equals(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAPIGRPCServer_Freeze(t *testing.T) {
+	ac, _, _ := setup(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltDB, err := db.New(tmp)
+	Ok(t, err)
+	ac.Freezer = locking.NewFreezeClient(boltDB)
+	conn := serveAPIGRPC(t, &controllers.APIGRPCServer{API: &ac})
+
+	invoke := func(method string, request interface{}, token string, response interface{}) error {
+		return conn.Invoke(apiGRPCContext(token), "/atlantis.API/"+method, request, response, grpc.CallContentSubtype(grpcjson.Name))
+	}
+
+	var freeze models.Freeze
+	err = invoke("Freeze", controllers.FreezeRequest{Message: "incident"}, "wrong", &freeze)
+	Equals(t, codes.Unauthenticated, status.Code(err))
+
+	Ok(t, invoke("Freeze", controllers.FreezeRequest{Repository: "owner/repo", Project: "prod", Message: "incident"}, atlantisToken, &freeze))
+	Equals(t, "incident", freeze.Message)
+	var freezes []models.Freeze
+	Ok(t, invoke("ListFreezes", struct{}{}, atlantisToken, &freezes))
+	Equals(t, 1, len(freezes))
+	Equals(t, "owner/repo", freezes[0].RepoFullName)
+
+	Ok(t, invoke("Unfreeze", controllers.FreezeRequest{Repository: "owner/repo", Project: "prod"}, atlantisToken, &freeze))
+	err = invoke("Unfreeze", controllers.FreezeRequest{Repository: "owner/repo", Project: "prod"}, atlantisToken, &freeze)
+	Equals(t, codes.NotFound, status.Code(err))
+	ErrContains(t, "no freeze found", err)
+}
+
+func TestAPIGRPCServer_WatchJobs(t *testing.T) {
+	ac, _, _ := setup(t)
+	jobEvents := jobs.NewJobEvents()
+	outputHandler := jobs.NewAsyncProjectCommandOutputHandler(make(chan *jobs.ProjectCmdOutputLine), logging.NewNoopLogger(t), nil, 0, jobEvents)
+	go outputHandler.Handle()
+	conn := serveAPIGRPC(t, &controllers.APIGRPCServer{API: &ac, JobEvents: jobEvents})
+
+	ctx, cancel := context.WithCancel(apiGRPCContext(atlantisToken))
+	defer cancel()
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, "/atlantis.API/WatchJobs", grpc.CallContentSubtype(grpcjson.Name))
+	Ok(t, err)
+	Ok(t, stream.SendMsg(controllers.WatchJobsRequest{Repository: "owner/repo", PR: 1}))
+	Ok(t, stream.CloseSend())
+
+	// Output is sent until the stream receives it since the server
+	// subscribes to the events after the stream is opened.
+	other := command.ProjectContext{JobID: "other", BaseRepo: models.Repo{FullName: "owner/other"}, Pull: models.PullRequest{Num: 1}}
+	watched := command.ProjectContext{JobID: "watched", BaseRepo: models.Repo{FullName: "owner/repo"}, Pull: models.PullRequest{Num: 1}}
+	go func() {
+		for ctx.Err() == nil {
+			outputHandler.Send(other, "other line", false)
+			outputHandler.Send(watched, "watched line", false)
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	var event jobs.JobEvent
+	Ok(t, stream.RecvMsg(&event))
+	Equals(t, "watched", event.JobID)
+	Equals(t, "watched line", event.Line)
+	Equals(t, "owner/repo", event.RepoFullName)
+}
+
+func TestAPIGRPCServer_WatchJobsWithoutEvents(t *testing.T) {
+	ac, _, _ := setup(t)
+	conn := serveAPIGRPC(t, &controllers.APIGRPCServer{API: &ac})
+
+	stream, err := conn.NewStream(apiGRPCContext(atlantisToken), &grpc.StreamDesc{ServerStreams: true}, "/atlantis.API/WatchJobs", grpc.CallContentSubtype(grpcjson.Name))
+	Ok(t, err)
+	Ok(t, stream.SendMsg(controllers.WatchJobsRequest{}))
+	var event json.RawMessage
+	err = stream.RecvMsg(&event)
+	Equals(t, codes.Unavailable, status.Code(err))
+}
+
+// TestAPIGRPCServer_MirrorsREST checks that every REST API route has a
+// gRPC method and that every unary method has a route.
+func TestAPIGRPCServer_MirrorsREST(t *testing.T) {
+	// methods maps each route to its method, or to "" if it's only served
+	// over HTTP.
+	methods := map[string]string{
+		"POST /api/plan":                                   "Plan",
+		"POST /api/apply":                                  "Apply",
+		"POST /api/cancel":                                 "Cancel",
+		"GET /api/plan/json":                               "PlanJSON",
+		"GET /api/plan/output":                             "PlanOutput",
+		"GET /api/inventory":                               "ListInventory",
+		"GET /api/durations":                               "ListDurations",
+		"GET /api/lock-contention":                         "ListLockContention",
+		"GET /api/required-contexts":                       "RequiredContexts",
+		"POST /api/required-contexts/sync":                 "SyncRequiredContexts",
+		"POST /api/debug/project-resolution":               "ProjectResolution",
+		"GET /api/debug/support-bundle":                    "SupportBundle",
+		"GET /api/debug/pprof/":                            "",
+		"GET,POST /api/debug/pprof/{profile}":              "",
+		"GET /api/debug/vars":                              "",
+		"GET /api/debug/locks":                             "DebugLocks",
+		"GET /api/repos/{repo}/projects/{project}/status":  "ProjectStatus",
+		"GET /api/repos/{repo}/projects/{project}/badge":   "",
+		"GET /api/repos/{repo}/projects/{project}/outputs": "ProjectOutputs",
+		"GET /api/freeze":                                  "ListFreezes",
+		"POST /api/freeze":                                 "Freeze",
+		"DELETE /api/freeze":                               "Unfreeze",
+	}
+
+	router := mux.NewRouter()
+	(&controllers.APIController{}).RegisterRoutes(router)
+	routeMethods := map[string]bool{}
+	Ok(t, router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		httpMethods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+		key := strings.Join(httpMethods, ",") + " " + strings.NewReplacer(":.+", "").Replace(path)
+		method, ok := methods[key]
+		Assert(t, ok, "route %q isn't in methods, add its gRPC method to APIGRPCServer", key)
+		if method != "" {
+			routeMethods[method] = true
+		}
+		return nil
+	}))
+
+	server := grpc.NewServer()
+	controllers.RegisterAPIGRPCServer(server, &controllers.APIGRPCServer{})
+	grpcMethods := map[string]bool{}
+	for _, method := range server.GetServiceInfo()["atlantis.API"].Methods {
+		if !method.IsServerStream {
+			grpcMethods[method.Name] = true
+		}
+	}
+	Equals(t, routeMethods, grpcMethods)
+}
+
+// serveAPIGRPC serves srv in memory and returns a connection to it.
+func serveAPIGRPC(t *testing.T, srv *controllers.APIGRPCServer) *grpc.ClientConn {
+	RegisterMockTestingT(t)
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	controllers.RegisterAPIGRPCServer(server, srv)
+	go server.Serve(lis) // nolint: errcheck
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure())
+	Ok(t, err)
+	t.Cleanup(func() { conn.Close() }) // nolint: errcheck
+	return conn
+}
+
+func apiGRPCContext(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-atlantis-token", token)
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package controllers
+
+import "syscall"
+
+// diskFree returns the bytes available to Atlantis on dir's file system.
+func diskFree(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil // nolint: unconvert
+}
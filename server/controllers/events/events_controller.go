@@ -14,6 +14,8 @@
 package events
 
 import (
+	"crypto/subtle"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -24,10 +26,12 @@ import (
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketserver"
+	"github.com/runatlantis/atlantis/server/events/vcs/gerrit"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/uber-go/tally"
 	gitlab "github.com/xanzy/go-gitlab"
@@ -37,11 +41,16 @@ const githubHeader = "X-Github-Event"
 const gitlabHeader = "X-Gitlab-Event"
 const azuredevopsHeader = "Request-Id"
 
-// bitbucketEventTypeHeader is the same in both cloud and server.
+// bitbucketEventTypeHeader and bitbucketSignatureHeader are the same in both
+// cloud and server.
 const bitbucketEventTypeHeader = "X-Event-Key"
+const bitbucketSignatureHeader = "X-Hub-Signature"
 const bitbucketCloudRequestIDHeader = "X-Request-UUID"
 const bitbucketServerRequestIDHeader = "X-Request-ID"
-const bitbucketServerSignatureHeader = "X-Hub-Signature"
+
+// gerritSecretParam is the query parameter that Gerrit webhooks send the
+// webhook secret in since Gerrit's webhooks plugin can't sign requests.
+const gerritSecretParam = "secret"
 
 // VCSEventsController handles all webhook requests which signify 'events' in the
 // VCS host, ex. GitHub.
@@ -52,6 +61,9 @@ type VCSEventsController struct {
 	Scope         tally.Scope
 	Parser        events.EventParsing
 	CommentParser events.CommentParsing
+	// RepoHelp builds what help comments show about the repo they're for.
+	// If nil, help comments show every command.
+	RepoHelp      *events.RepoHelpBuilder
 	ApplyDisabled bool
 	// GithubWebhookSecret is the secret added to this webhook via the GitHub
 	// UI that identifies this call as coming from GitHub. If empty, no
@@ -86,6 +98,10 @@ type VCSEventsController struct {
 	// Azure DevOps Team Project. If empty, no request validation is done.
 	AzureDevopsWebhookBasicPassword []byte
 	AzureDevopsRequestValidator     AzureDevopsRequestValidator
+	// GerritWebhookSecret is the secret added to the URL of Gerrit webhooks
+	// as the secret query parameter that identifies this call as coming from
+	// Gerrit. If empty, no request validation is done.
+	GerritWebhookSecret []byte
 }
 
 // Post handles POST webhook requests.
@@ -134,6 +150,13 @@ func (e *VCSEventsController) Post(w http.ResponseWriter, r *http.Request) {
 		e.Logger.Debug("handling AzureDevops post")
 		e.handleAzureDevopsPost(w, r)
 		return
+	} else if e.supportsHost(models.Gerrit) {
+		// Gerrit's webhooks plugin doesn't send any identifying headers so
+		// other requests are handled as Gerrit events if Gerrit is
+		// configured.
+		e.Logger.Debug("handling Gerrit post")
+		e.handleGerritPost(w, r)
+		return
 	}
 	e.respond(w, logging.Debug, http.StatusBadRequest, "Ignoring request")
 }
@@ -198,12 +221,20 @@ func (e *VCSEventsController) handleGithubPost(w http.ResponseWriter, r *http.Re
 func (e *VCSEventsController) handleBitbucketCloudPost(w http.ResponseWriter, r *http.Request) {
 	eventType := r.Header.Get(bitbucketEventTypeHeader)
 	reqID := r.Header.Get(bitbucketCloudRequestIDHeader)
+	sig := r.Header.Get(bitbucketSignatureHeader)
 	defer r.Body.Close() // nolint: errcheck
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		e.respond(w, logging.Error, http.StatusBadRequest, "Unable to read body: %s %s=%s", err, bitbucketCloudRequestIDHeader, reqID)
 		return
 	}
+	if len(e.BitbucketWebhookSecret) > 0 {
+		// Bitbucket Cloud signs webhooks the same way as Bitbucket Server.
+		if err := bitbucketserver.ValidateSignature(body, sig, e.BitbucketWebhookSecret); err != nil {
+			e.respond(w, logging.Warn, http.StatusBadRequest, errors.Wrap(err, "request did not pass validation").Error())
+			return
+		}
+	}
 	switch eventType {
 	case bitbucketcloud.PullCreatedHeader, bitbucketcloud.PullUpdatedHeader, bitbucketcloud.PullFulfilledHeader, bitbucketcloud.PullRejectedHeader:
 		e.Logger.Debug("handling as pull request state changed event")
@@ -221,7 +252,7 @@ func (e *VCSEventsController) handleBitbucketCloudPost(w http.ResponseWriter, r
 func (e *VCSEventsController) handleBitbucketServerPost(w http.ResponseWriter, r *http.Request) {
 	eventType := r.Header.Get(bitbucketEventTypeHeader)
 	reqID := r.Header.Get(bitbucketServerRequestIDHeader)
-	sig := r.Header.Get(bitbucketServerSignatureHeader)
+	sig := r.Header.Get(bitbucketSignatureHeader)
 	defer r.Body.Close() // nolint: errcheck
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -281,6 +312,40 @@ func (e *VCSEventsController) handleAzureDevopsPost(w http.ResponseWriter, r *ht
 	}
 }
 
+func (e *VCSEventsController) handleGerritPost(w http.ResponseWriter, r *http.Request) {
+	if len(e.GerritWebhookSecret) > 0 {
+		secret := []byte(r.URL.Query().Get(gerritSecretParam))
+		if subtle.ConstantTimeCompare(secret, e.GerritWebhookSecret) != 1 {
+			e.respond(w, logging.Warn, http.StatusBadRequest, "request did not pass validation: %s query parameter didn't match the webhook secret", gerritSecretParam)
+			return
+		}
+	}
+	defer r.Body.Close() // nolint: errcheck
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Unable to read body: %s", err)
+		return
+	}
+	var event gerrit.EventType
+	if err := json.Unmarshal(body, &event); err != nil || event.Type == nil {
+		e.respond(w, logging.Debug, http.StatusBadRequest, "Ignoring request")
+		return
+	}
+	switch *event.Type {
+	case gerrit.PatchsetCreatedEvent, gerrit.ChangeAbandonedEvent, gerrit.ChangeDeletedEvent:
+		e.Logger.Debug("handling as change event")
+		e.handleGerritChangeEvent(w, body)
+	case gerrit.ChangeMergedEvent:
+		e.Logger.Debug("handling as change merged event")
+		e.handleGerritChangeMergedEvent(w, body)
+	case gerrit.CommentAddedEvent:
+		e.Logger.Debug("handling as comment added event")
+		e.HandleGerritCommentEvent(w, body)
+	default:
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring unsupported event type %s", *event.Type)
+	}
+}
+
 // HandleGithubCommentEvent handles comment events from GitHub where Atlantis
 // commands can come from. It's exported to make testing easier.
 func (e *VCSEventsController) HandleGithubCommentEvent(event *github.IssueCommentEvent, githubReqID string, logger logging.SimpleLogging) HTTPResponse {
@@ -306,7 +371,7 @@ func (e *VCSEventsController) HandleGithubCommentEvent(event *github.IssueCommen
 
 	// We pass in nil for maybeHeadRepo because the head repo data isn't
 	// available in the GithubIssueComment event.
-	return e.handleCommentEvent(logger, baseRepo, nil, nil, user, pullNum, event.Comment.GetBody(), models.Github)
+	return e.handleCommentEvent(logger, baseRepo, nil, nil, user, pullNum, event.Comment.GetID(), event.Comment.GetBody(), models.Github)
 }
 
 // HandleBitbucketCloudCommentEvent handles comment events from Bitbucket.
@@ -316,7 +381,7 @@ func (e *VCSEventsController) HandleBitbucketCloudCommentEvent(w http.ResponseWr
 		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing pull data: %s %s=%s", err, bitbucketCloudRequestIDHeader, reqID)
 		return
 	}
-	resp := e.handleCommentEvent(e.Logger, baseRepo, &headRepo, &pull, user, pull.Num, comment, models.BitbucketCloud)
+	resp := e.handleCommentEvent(e.Logger, baseRepo, &headRepo, &pull, user, pull.Num, 0, comment, models.BitbucketCloud)
 
 	//TODO: move this to the outer most function similar to github
 	lvl := logging.Debug
@@ -337,7 +402,7 @@ func (e *VCSEventsController) HandleBitbucketServerCommentEvent(w http.ResponseW
 		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing pull data: %s %s=%s", err, bitbucketCloudRequestIDHeader, reqID)
 		return
 	}
-	resp := e.handleCommentEvent(e.Logger, baseRepo, &headRepo, &pull, user, pull.Num, comment, models.BitbucketCloud)
+	resp := e.handleCommentEvent(e.Logger, baseRepo, &headRepo, &pull, user, pull.Num, 0, comment, models.BitbucketCloud)
 
 	//TODO: move this to the outer most function similar to github
 	lvl := logging.Debug
@@ -511,7 +576,7 @@ func (e *VCSEventsController) HandleGitlabCommentEvent(w http.ResponseWriter, ev
 		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing webhook: %s", err)
 		return
 	}
-	resp := e.handleCommentEvent(e.Logger, baseRepo, &headRepo, nil, user, event.MergeRequest.IID, event.ObjectAttributes.Note, models.Gitlab)
+	resp := e.handleCommentEvent(e.Logger, baseRepo, &headRepo, nil, user, event.MergeRequest.IID, int64(event.ObjectAttributes.ID), event.ObjectAttributes.Note, models.Gitlab)
 
 	//TODO: move this to the outer most function similar to github
 	lvl := logging.Debug
@@ -525,7 +590,7 @@ func (e *VCSEventsController) HandleGitlabCommentEvent(w http.ResponseWriter, ev
 	e.respond(w, lvl, code, msg)
 }
 
-func (e *VCSEventsController) handleCommentEvent(logger logging.SimpleLogging, baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, comment string, vcsHost models.VCSHostType) HTTPResponse {
+func (e *VCSEventsController) handleCommentEvent(logger logging.SimpleLogging, baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, commentID int64, comment string, vcsHost models.VCSHostType) HTTPResponse {
 	parseResult := e.CommentParser.Parse(comment, vcsHost)
 	if parseResult.Ignore {
 		truncated := comment
@@ -561,6 +626,11 @@ func (e *VCSEventsController) handleCommentEvent(logger logging.SimpleLogging, b
 	// We do this here rather than earlier because we need access to the pull
 	// variable to comment back on the pull request.
 	if parseResult.CommentResponse != "" {
+		// The comment is parsed again with the repo's help only when it's
+		// needed since building it reads the repo's config.
+		if e.RepoHelp != nil {
+			parseResult = e.CommentParser.ParseForRepo(comment, vcsHost, e.RepoHelp.Build(logger, baseRepo, pullNum))
+		}
 		if err := e.VCSClient.CreateComment(baseRepo, pullNum, parseResult.CommentResponse, ""); err != nil {
 			logger.Err("unable to comment on pull request: %s", err)
 		}
@@ -570,6 +640,9 @@ func (e *VCSEventsController) handleCommentEvent(logger logging.SimpleLogging, b
 	}
 
 	logger.Debug("executing command")
+	if parseResult.Command != nil {
+		parseResult.Command.CommentID = commentID
+	}
 	if !e.TestingMode {
 		// Respond with success and then actually execute the command asynchronously.
 		// We use a goroutine so that this function returns and the connection is
@@ -638,7 +711,7 @@ func (e *VCSEventsController) HandleAzureDevopsPullRequestCommentedEvent(w http.
 		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing pull request repository field: %s; %s", err, azuredevopsReqID)
 		return
 	}
-	resp := e.handleCommentEvent(e.Logger, baseRepo, nil, nil, user, resource.PullRequest.GetPullRequestID(), string(strippedComment), models.AzureDevops)
+	resp := e.handleCommentEvent(e.Logger, baseRepo, nil, nil, user, resource.PullRequest.GetPullRequestID(), 0, string(strippedComment), models.AzureDevops)
 
 	//TODO: move this to the outer most function similar to github
 	lvl := logging.Debug
@@ -693,6 +766,88 @@ func (e *VCSEventsController) HandleAzureDevopsPullRequestEvent(w http.ResponseW
 	e.respond(w, lvl, code, msg)
 }
 
+// HandleGerritCommentEvent handles comment-added events from Gerrit where
+// Atlantis commands can come from. It's exported to make testing easier.
+func (e *VCSEventsController) HandleGerritCommentEvent(w http.ResponseWriter, body []byte) {
+	pull, baseRepo, headRepo, user, comment, err := e.Parser.ParseGerritCommentEvent(body)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing change data: %s", err)
+		return
+	}
+	resp := e.handleCommentEvent(e.Logger, baseRepo, &headRepo, &pull, user, pull.Num, 0, comment, models.Gerrit)
+
+	//TODO: move this to the outer most function similar to github
+	lvl := logging.Debug
+	code := http.StatusOK
+	msg := resp.body
+	if resp.err.code != 0 {
+		lvl = logging.Error
+		code = resp.err.code
+		msg = resp.err.err.Error()
+	}
+	e.respond(w, lvl, code, msg)
+}
+
+func (e *VCSEventsController) handleGerritChangeEvent(w http.ResponseWriter, body []byte) {
+	pull, pullEventType, baseRepo, headRepo, user, err := e.Parser.ParseGerritChangeEvent(body)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing change data: %s", err)
+		return
+	}
+	e.Logger.Info("identified event as type %q", pullEventType.String())
+	resp := e.handlePullRequestEvent(e.Logger, baseRepo, headRepo, pull, user, pullEventType)
+
+	//TODO: move this to the outer most function similar to github
+	lvl := logging.Debug
+	code := http.StatusOK
+	msg := resp.body
+	if resp.err.code != 0 {
+		lvl = logging.Error
+		code = resp.err.code
+		msg = resp.err.err.Error()
+	}
+	e.respond(w, lvl, code, msg)
+}
+
+// handleGerritChangeMergedEvent applies a change when it's submitted and
+// then deletes its locks and workspace like for other closed pull requests.
+func (e *VCSEventsController) handleGerritChangeMergedEvent(w http.ResponseWriter, body []byte) {
+	pull, _, baseRepo, headRepo, user, err := e.Parser.ParseGerritChangeEvent(body)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing change data: %s", err)
+		return
+	}
+	if !e.RepoAllowlistChecker.IsAllowlisted(baseRepo.FullName, baseRepo.VCSHost.Hostname) {
+		err := errors.Errorf("Pull request event from non-allowlisted repo \"%s/%s\"", baseRepo.VCSHost.Hostname, baseRepo.FullName)
+		lvl := logging.Error
+		if e.SilenceAllowlistErrors {
+			lvl = logging.Debug
+		}
+		e.respond(w, lvl, http.StatusForbidden, err.Error())
+		return
+	}
+
+	applyAndCleanUp := func() {
+		// The change has already been merged but it's applied as if it were
+		// still open since it was just submitted.
+		openPull := pull
+		openPull.State = models.OpenPullState
+		e.CommandRunner.RunCommentCommand(baseRepo, &headRepo, &openPull, user, pull.Num, events.NewCommentCommand("", nil, command.Apply, false, false, "", ""))
+		if err := e.PullCleaner.CleanUpPull(baseRepo, pull); err != nil {
+			e.Logger.Err("unable to clean up change %d: %s", pull.Num, err)
+			return
+		}
+		e.Logger.Info("deleted locks and workspace for repo %s, pull %d", baseRepo.FullName, pull.Num)
+	}
+	if !e.TestingMode {
+		go applyAndCleanUp()
+	} else {
+		// When testing we want to wait for everything to complete.
+		applyAndCleanUp()
+	}
+	e.respond(w, logging.Debug, http.StatusOK, "Processing...")
+}
+
 // supportsHost returns true if h is in e.SupportedVCSHosts and false otherwise.
 func (e *VCSEventsController) supportsHost(h models.VCSHostType) bool {
 	for _, supported := range e.SupportedVCSHosts {
@@ -756,7 +756,7 @@ func TestGitHubWorkflowWithPolicyCheck(t *testing.T) {
 
 			// Setup test dependencies.
 			w := httptest.NewRecorder()
-			When(vcsClient.PullIsMergeable(AnyRepo(), matchers.AnyModelsPullRequest(), "atlantis-test")).ThenReturn(true, nil)
+			When(vcsClient.PullIsMergeable(AnyRepo(), matchers.AnyModelsPullRequest(), "atlantis-test", nil)).ThenReturn(true, nil)
 			When(vcsClient.PullIsApproved(AnyRepo(), matchers.AnyModelsPullRequest())).ThenReturn(models.ApprovalStatus{
 				IsApproved: true,
 			}, nil)
@@ -905,7 +905,7 @@ func setupE2E(t *testing.T, repoDir string) (events_controllers.VCSEventsControl
 	mockPreWorkflowHookRunner = runtimemocks.NewMockPreWorkflowHookRunner()
 	preWorkflowHooksCommandRunner := &events.DefaultPreWorkflowHooksCommandRunner{
 		VCSClient:             e2eVCSClient,
-		GlobalCfg:             globalCfg,
+		GlobalCfg:             valid.NewGlobalCfgStore(globalCfg),
 		WorkingDirLocker:      locker,
 		WorkingDir:            workingDir,
 		PreWorkflowHookRunner: mockPreWorkflowHookRunner,
@@ -914,7 +914,7 @@ func setupE2E(t *testing.T, repoDir string) (events_controllers.VCSEventsControl
 	mockPostWorkflowHookRunner = runtimemocks.NewMockPostWorkflowHookRunner()
 	postWorkflowHooksCommandRunner := &events.DefaultPostWorkflowHooksCommandRunner{
 		VCSClient:              e2eVCSClient,
-		GlobalCfg:              globalCfg,
+		GlobalCfg:              valid.NewGlobalCfgStore(globalCfg),
 		WorkingDirLocker:       locker,
 		WorkingDir:             workingDir,
 		PostWorkflowHookRunner: mockPostWorkflowHookRunner,
@@ -928,7 +928,7 @@ func setupE2E(t *testing.T, repoDir string) (events_controllers.VCSEventsControl
 		e2eVCSClient,
 		workingDir,
 		locker,
-		globalCfg,
+		valid.NewGlobalCfgStore(globalCfg),
 		&events.DefaultPendingPlanFinder{},
 		commentParser,
 		false,
@@ -1063,6 +1063,7 @@ func setupE2E(t *testing.T, repoDir string) (events_controllers.VCSEventsControl
 		mocks.NewMockDeleteLockCommand(),
 		e2eVCSClient,
 		silenceNoProjects,
+		nil,
 	)
 
 	versionCommandRunner := events.NewVersionCommandRunner(
@@ -1087,7 +1088,7 @@ func setupE2E(t *testing.T, repoDir string) (events_controllers.VCSEventsControl
 		GithubPullGetter:               e2eGithubGetter,
 		GitlabMergeRequestGetter:       e2eGitlabGetter,
 		Logger:                         logger,
-		GlobalCfg:                      globalCfg,
+		GlobalCfg:                      valid.NewGlobalCfgStore(globalCfg),
 		StatsScope:                     statsScope,
 		AllowForkPRs:                   allowForkPRs,
 		AllowForkPRsFlag:               "allow-fork-prs",
@@ -15,12 +15,18 @@ package events_test
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	. "github.com/petergtz/pegomock"
 	events_controllers "github.com/runatlantis/atlantis/server/controllers/events"
 	"github.com/runatlantis/atlantis/server/controllers/events/mocks"
+	"github.com/runatlantis/atlantis/server/core/config"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
 	emocks "github.com/runatlantis/atlantis/server/events/mocks"
 	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -35,6 +41,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -347,6 +354,38 @@ func TestPost_GithubCommentResponse(t *testing.T) {
 	ResponseContains(t, w, http.StatusOK, "Commenting back on pull request")
 }
 
+func TestPost_GithubCommentResponseForRepo(t *testing.T) {
+	t.Log("when a comment response is needed it's parsed again with the repo's help")
+	e, v, _, p, _, _, vcsClient, cp := setup(t)
+	workingDir := emocks.NewMockWorkingDir()
+	globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	globalCfg.Repos = append(globalCfg.Repos, valid.Repo{
+		IDRegex:         regexp.MustCompile(".*"),
+		AllowedCommands: []string{"plan"},
+	})
+	e.RepoHelp = &events.RepoHelpBuilder{
+		GlobalCfg:        valid.NewGlobalCfgStore(globalCfg),
+		WorkingDir:       workingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		ParserValidator:  &config.ParserValidator{},
+	}
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req.Header.Set(githubHeader, "issue_comment")
+	event := `{"action": "created"}`
+	When(v.Validate(req, secret)).ThenReturn([]byte(event), nil)
+	baseRepo := models.Repo{}
+	user := models.User{}
+	When(p.ParseGithubIssueCommentEvent(matchers.AnyPtrToGithubIssueCommentEvent())).ThenReturn(baseRepo, user, 1, nil)
+	When(workingDir.GetWorkingDir(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())).ThenReturn("", errors.New("not cloned"))
+	When(cp.Parse("", models.Github)).ThenReturn(events.CommentParseResult{CommentResponse: "a comment"})
+	When(cp.ParseForRepo("", models.Github, events.RepoHelp{AllowedCommands: []string{"plan"}})).ThenReturn(events.CommentParseResult{CommentResponse: "a comment for the repo"})
+	w := httptest.NewRecorder()
+
+	e.Post(w, req)
+	vcsClient.VerifyWasCalledOnce().CreateComment(baseRepo, 1, "a comment for the repo", "")
+	ResponseContains(t, w, http.StatusOK, "Commenting back on pull request")
+}
+
 func TestPost_GitlabCommentSuccess(t *testing.T) {
 	t.Log("when the event is a gitlab comment with a valid command we call the command handler")
 	e, _, gl, _, cr, _, _, _ := setup(t)
@@ -767,6 +806,116 @@ func TestPost_PullOpenedOrUpdated(t *testing.T) {
 	}
 }
 
+func TestPost_BBCloudSignature(t *testing.T) {
+	body := []byte(`{}`)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body) // nolint: errcheck
+	validSig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	cases := []struct {
+		description string
+		sig         string
+		expCode     int
+		expBody     string
+	}{
+		{
+			"missing signature",
+			"",
+			http.StatusBadRequest,
+			"request did not pass validation: missing signature",
+		},
+		{
+			"wrong signature",
+			"sha256=" + hex.EncodeToString([]byte("wrong")),
+			http.StatusBadRequest,
+			"request did not pass validation: payload signature check failed",
+		},
+		{
+			"valid signature",
+			validSig,
+			http.StatusOK,
+			"Ignoring unsupported event type repo:push",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			e, _, _, _, _, _, _, _ := setup(t)
+			e.SupportedVCSHosts = []models.VCSHostType{models.BitbucketCloud}
+			e.BitbucketWebhookSecret = secret
+			req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(body))
+			req.Header.Set("X-Request-UUID", "request-id")
+			req.Header.Set("X-Event-Key", "repo:push")
+			if c.sig != "" {
+				req.Header.Set("X-Hub-Signature", c.sig)
+			}
+			w := httptest.NewRecorder()
+			e.Post(w, req)
+			ResponseContains(t, w, c.expCode, c.expBody)
+		})
+	}
+}
+
+func TestPost_GerritInvalidSecret(t *testing.T) {
+	t.Log("when the secret query parameter doesn't match we return a 400")
+	e, _, _, _, _, _, _, _ := setup(t)
+	e.SupportedVCSHosts = []models.VCSHostType{models.Gerrit}
+	e.GerritWebhookSecret = secret
+	req, _ := http.NewRequest("POST", "/events?secret=wrong", bytes.NewBuffer([]byte(`{"type":"change-merged"}`)))
+	w := httptest.NewRecorder()
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusBadRequest, "request did not pass validation")
+}
+
+func TestPost_GerritUnsupportedEvent(t *testing.T) {
+	t.Log("when the event type isn't one we handle we return a 200")
+	e, _, _, _, _, _, _, _ := setup(t)
+	e.SupportedVCSHosts = []models.VCSHostType{models.Gerrit}
+	e.GerritWebhookSecret = secret
+	req, _ := http.NewRequest("POST", "/events?secret=secret", bytes.NewBuffer([]byte(`{"type":"ref-updated"}`)))
+	w := httptest.NewRecorder()
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusOK, "Ignoring unsupported event type ref-updated")
+}
+
+func TestPost_GerritChangeMerged(t *testing.T) {
+	t.Log("when a change is submitted we apply it and then clean up")
+	e, _, _, p, cr, c, _, _ := setup(t)
+	e.SupportedVCSHosts = []models.VCSHostType{models.Gerrit}
+	e.GerritWebhookSecret = secret
+
+	requestBytes, err := os.ReadFile(filepath.Join("testfixtures", "gerrit-change-merged-event.json"))
+	Ok(t, err)
+	repo := models.Repo{
+		FullName: "infra/terraform",
+		Owner:    "infra",
+		Name:     "terraform",
+		VCSHost: models.VCSHost{
+			Hostname: "gerrit.mycorp.com",
+			Type:     models.Gerrit,
+		},
+	}
+	pull := models.PullRequest{
+		Num:        12345,
+		HeadBranch: "refs/changes/45/12345/2",
+		BaseBranch: "main",
+		State:      models.ClosedPullState,
+		BaseRepo:   repo,
+	}
+	user := models.User{Username: "jsmith"}
+	When(p.ParseGerritChangeEvent(requestBytes)).ThenReturn(pull, models.ClosedPullEvent, repo, repo, user, nil)
+
+	req, err := http.NewRequest("POST", "/events?secret=secret", bytes.NewBuffer(requestBytes))
+	Ok(t, err)
+	w := httptest.NewRecorder()
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusOK, "Processing...")
+
+	openPull := pull
+	openPull.State = models.OpenPullState
+	cr.VerifyWasCalledOnce().RunCommentCommand(repo, &repo, &openPull, user, 12345, events.NewCommentCommand("", nil, command.Apply, false, false, "", ""))
+	c.VerifyWasCalledOnce().CleanUpPull(repo, pull)
+}
+
 func setup(t *testing.T) (events_controllers.VCSEventsController, *mocks.MockGithubRequestValidator, *mocks.MockGitlabRequestParserValidator, *emocks.MockEventParsing, *emocks.MockCommandRunner, *emocks.MockPullCleaner, *vcsmocks.MockClient, *emocks.MockCommentParsing) {
 	RegisterMockTestingT(t)
 	v := mocks.NewMockGithubRequestValidator()
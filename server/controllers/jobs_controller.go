@@ -4,16 +4,25 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/runatlantis/atlantis/server/controllers/templates"
 	"github.com/runatlantis/atlantis/server/controllers/websocket"
+	"github.com/runatlantis/atlantis/server/core/artifacts"
 	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/metrics"
 	"github.com/uber-go/tally"
 )
 
+// jobIDRegex matches the IDs of jobs, which are UUIDs, so that they can't be
+// used to read other artifacts than graphs.
+var jobIDRegex = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
 type JobIDKeyGenerator struct{}
 
 func (g JobIDKeyGenerator) Generate(r *http.Request) (string, error) {
@@ -35,6 +44,15 @@ type JobsController struct {
 	WsMux                    *websocket.Multiplexor
 	KeyGenerator             JobIDKeyGenerator
 	StatsScope               tally.Scope
+	// LogRegistry streams the output of jobs for the log download and tail
+	// endpoints.
+	LogRegistry websocket.PartitionRegistry
+	// JobCanceller cancels running jobs from the job page. If nil, they
+	// can't be cancelled.
+	JobCanceller *jobs.JobCanceller
+	// Graphs stores the dependency graphs rendered by graph steps. If nil,
+	// there aren't any.
+	Graphs artifacts.Store
 }
 
 func (j *JobsController) getProjectJobs(w http.ResponseWriter, r *http.Request) error {
@@ -50,6 +68,9 @@ func (j *JobsController) getProjectJobs(w http.ResponseWriter, r *http.Request)
 		ProjectPath:     jobID,
 		CleanedBasePath: j.AtlantisURL.Path,
 	}
+	if j.JobCanceller != nil {
+		_, viewData.Running = j.JobCanceller.Get(jobID)
+	}
 
 	return j.ProjectJobsTemplate.Execute(w, viewData)
 }
@@ -87,6 +108,163 @@ func (j *JobsController) GetProjectJobsWS(w http.ResponseWriter, r *http.Request
 	}
 }
 
+// CancelProjectJob cancels a running job. The web basic auth user, if there
+// is one, is recorded as who cancelled it.
+func (j *JobsController) CancelProjectJob(w http.ResponseWriter, r *http.Request) {
+	errorCounter := j.StatsScope.SubScope("cancelprojectjob").Counter(metrics.ExecutionErrorMetric)
+	jobID, err := j.KeyGenerator.Generate(r)
+	if err != nil {
+		j.respond(w, logging.Error, http.StatusBadRequest, err.Error())
+		errorCounter.Inc(1)
+		return
+	}
+	if j.JobCanceller == nil {
+		j.respond(w, logging.Warn, http.StatusNotFound, "job %s isn't running", jobID)
+		errorCounter.Inc(1)
+		return
+	}
+	user := "the Atlantis UI"
+	if username, _, ok := r.BasicAuth(); ok && username != "" {
+		user = fmt.Sprintf("%s on the Atlantis UI", username)
+	}
+	job, err := j.JobCanceller.Cancel(jobID, user)
+	if err != nil {
+		j.respond(w, logging.Warn, http.StatusNotFound, err.Error())
+		errorCounter.Inc(1)
+		return
+	}
+	j.respond(w, logging.Info, http.StatusOK, "Cancelled %s of %s", job.Command, job.RepoFullName)
+}
+
+// GetProjectJobLogs downloads the output of a job as plain text. If the job is
+// still running, the response ends when it completes.
+func (j *JobsController) GetProjectJobLogs(w http.ResponseWriter, r *http.Request) {
+	errorCounter := j.StatsScope.SubScope("getprojectjoblogs").Counter(metrics.ExecutionErrorMetric)
+	jobID, ok := j.checkJobExists(w, r)
+	if !ok {
+		errorCounter.Inc(1)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", jobID+".log"))
+	w.WriteHeader(http.StatusOK)
+	j.streamJobLogs(r, jobID, func(line string) error {
+		_, err := fmt.Fprintln(w, line)
+		return err
+	})
+}
+
+// TailProjectJobLogs streams the output of a job as Server-Sent Events. Each
+// line is sent as a message and a "complete" event is sent when the job
+// completes.
+func (j *JobsController) TailProjectJobLogs(w http.ResponseWriter, r *http.Request) {
+	errorCounter := j.StatsScope.SubScope("tailprojectjoblogs").Counter(metrics.ExecutionErrorMetric)
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		j.respond(w, logging.Error, http.StatusInternalServerError, "streaming is not supported")
+		errorCounter.Inc(1)
+		return
+	}
+	jobID, ok := j.checkJobExists(w, r)
+	if !ok {
+		errorCounter.Inc(1)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	completed := j.streamJobLogs(r, jobID, func(line string) error {
+		// Lines can't contain newlines but a message can span several data
+		// fields.
+		for _, field := range strings.Split(line, "\n") {
+			if _, err := fmt.Fprintf(w, "data: %s\n", field); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprint(w, "\n")
+		flusher.Flush()
+		return err
+	})
+	if completed {
+		fmt.Fprint(w, "event: complete\ndata: \n\n")
+		flusher.Flush()
+	}
+}
+
+// GetProjectGraph serves the dependency graph rendered by a job's graph step
+// as an SVG.
+func (j *JobsController) GetProjectGraph(w http.ResponseWriter, r *http.Request) {
+	errorCounter := j.StatsScope.SubScope("getprojectgraph").Counter(metrics.ExecutionErrorMetric)
+	jobID, err := j.KeyGenerator.Generate(r)
+	if err != nil {
+		j.respond(w, logging.Error, http.StatusBadRequest, err.Error())
+		errorCounter.Inc(1)
+		return
+	}
+	if j.Graphs == nil || !jobIDRegex.MatchString(jobID) {
+		j.respond(w, logging.Debug, http.StatusNotFound, "graph of job %s not found", jobID)
+		errorCounter.Inc(1)
+		return
+	}
+	svg, err := j.Graphs.Get(events.GraphKey(jobID))
+	if err != nil {
+		j.respond(w, logging.Debug, http.StatusNotFound, "graph of job %s not found", jobID)
+		errorCounter.Inc(1)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	// Labels come from the repo's code so scripts are never allowed to run.
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	w.WriteHeader(http.StatusOK)
+	w.Write(svg) // nolint: errcheck
+}
+
+// checkJobExists returns the ID of the job in r's route. If the job doesn't
+// exist, it responds with an error and returns false.
+func (j *JobsController) checkJobExists(w http.ResponseWriter, r *http.Request) (string, bool) {
+	jobID, err := j.KeyGenerator.Generate(r)
+	if err != nil {
+		j.respond(w, logging.Error, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	if !j.LogRegistry.IsKeyExists(jobID) {
+		j.respond(w, logging.Debug, http.StatusNotFound, "job %s not found", jobID)
+		return "", false
+	}
+	return jobID, true
+}
+
+// streamJobLogs calls write with each line of the job's output until the job
+// completes, the client disconnects or write fails. It returns true if the job
+// completed.
+func (j *JobsController) streamJobLogs(r *http.Request, jobID string, write func(line string) error) bool {
+	// Buffer size matches the websocket endpoint so that receivers that fall
+	// slightly behind aren't dropped.
+	buffer := make(chan string, 1000)
+	go j.LogRegistry.Register(jobID, buffer)
+	defer j.LogRegistry.Deregister(jobID, buffer)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return false
+		case line, ok := <-buffer:
+			if !ok {
+				return true
+			}
+			if err := write(line); err != nil {
+				j.Logger.Warn("writing logs of job %s: %s", jobID, err)
+				return false
+			}
+		}
+	}
+}
+
 func (j *JobsController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
 	response := fmt.Sprintf(format, args...)
 	j.Logger.Log(lvl, response)
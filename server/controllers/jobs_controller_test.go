@@ -0,0 +1,123 @@
+package controllers_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/core/artifacts"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	"github.com/uber-go/tally"
+)
+
+// fakeLogRegistry sends the lines of each job to registered buffers and then
+// closes them, as if the job had completed.
+type fakeLogRegistry struct {
+	jobs map[string][]string
+}
+
+func (f fakeLogRegistry) Register(key string, buffer chan string) {
+	for _, line := range f.jobs[key] {
+		buffer <- line
+	}
+	close(buffer)
+}
+
+func (f fakeLogRegistry) Deregister(string, chan string) {}
+
+func (f fakeLogRegistry) IsKeyExists(key string) bool {
+	_, ok := f.jobs[key]
+	return ok
+}
+
+func newJobsController(t *testing.T) *controllers.JobsController {
+	return &controllers.JobsController{
+		Logger:       logging.NewNoopLogger(t),
+		KeyGenerator: controllers.JobIDKeyGenerator{},
+		StatsScope:   tally.NewTestScope("jobs", nil),
+		LogRegistry: fakeLogRegistry{jobs: map[string][]string{
+			"1234": {"Initializing...", "Plan: 1 to add\nwith details"},
+		}},
+	}
+}
+
+func TestJobsController_GetProjectJobLogs(t *testing.T) {
+	jc := newJobsController(t)
+	req, _ := http.NewRequest("GET", "/jobs/1234/logs", nil)
+	req = mux.SetURLVars(req, map[string]string{"job-id": "1234"})
+	w := httptest.NewRecorder()
+	jc.GetProjectJobLogs(w, req)
+
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	Equals(t, `attachment; filename="1234.log"`, w.Result().Header.Get("Content-Disposition"))
+	body, err := io.ReadAll(w.Result().Body)
+	Ok(t, err)
+	Equals(t, "Initializing...\nPlan: 1 to add\nwith details\n", string(body))
+}
+
+func TestJobsController_GetProjectJobLogs_NotFound(t *testing.T) {
+	jc := newJobsController(t)
+	req, _ := http.NewRequest("GET", "/jobs/other/logs", nil)
+	req = mux.SetURLVars(req, map[string]string{"job-id": "other"})
+	w := httptest.NewRecorder()
+	jc.GetProjectJobLogs(w, req)
+
+	ResponseContains(t, w, http.StatusNotFound, "job other not found")
+}
+
+func TestJobsController_TailProjectJobLogs(t *testing.T) {
+	jc := newJobsController(t)
+	req, _ := http.NewRequest("GET", "/jobs/1234/logs/stream", nil)
+	req = mux.SetURLVars(req, map[string]string{"job-id": "1234"})
+	w := httptest.NewRecorder()
+	jc.TailProjectJobLogs(w, req)
+
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	Equals(t, "text/event-stream", w.Result().Header.Get("Content-Type"))
+	body, err := io.ReadAll(w.Result().Body)
+	Ok(t, err)
+	exp := "data: Initializing...\n\n" +
+		"data: Plan: 1 to add\ndata: with details\n\n" +
+		"event: complete\ndata: \n\n"
+	Equals(t, exp, string(body))
+}
+
+func TestJobsController_GetProjectGraph(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	store := &artifacts.FileStore{Dir: tmp}
+	Ok(t, store.Put(events.GraphKey("1234"), []byte("<svg></svg>")))
+	Ok(t, store.Put("state-snapshots/secret.tfstate.enc", []byte("secret")))
+	jc := newJobsController(t)
+	jc.Graphs = store
+
+	cases := []struct {
+		jobID     string
+		expStatus int
+	}{
+		{"1234", http.StatusOK},
+		{"5678", http.StatusNotFound},
+		{"../state-snapshots/secret.tfstate.enc#", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.jobID, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/jobs/"+c.jobID+"/graph.svg", nil)
+			req = mux.SetURLVars(req, map[string]string{"job-id": c.jobID})
+			w := httptest.NewRecorder()
+			jc.GetProjectGraph(w, req)
+
+			Equals(t, c.expStatus, w.Result().StatusCode)
+			if c.expStatus == http.StatusOK {
+				Equals(t, "image/svg+xml", w.Result().Header.Get("Content-Type"))
+				body, err := io.ReadAll(w.Result().Body)
+				Ok(t, err)
+				Equals(t, "<svg></svg>", string(body))
+			}
+		})
+	}
+}
@@ -22,6 +22,7 @@ type LocksController struct {
 	Locker             locking.Locker
 	Logger             logging.SimpleLogging
 	ApplyLocker        locking.ApplyLocker
+	Freezer            locking.Freezer
 	VCSClient          vcs.Client
 	LockDetailTemplate templates.TemplateWriter
 	WorkingDir         events.WorkingDir
@@ -54,6 +55,34 @@ func (l *LocksController) UnlockApply(w http.ResponseWriter, r *http.Request) {
 	l.respond(w, logging.Info, http.StatusOK, "Deleted apply lock")
 }
 
+// Freeze handles creating a freeze from the repo, project and message form
+// values. If a freeze already exists for the repo and project its message is
+// replaced.
+func (l *LocksController) Freeze(w http.ResponseWriter, r *http.Request) {
+	freeze, err := l.Freezer.Freeze(r.FormValue("repo"), r.FormValue("project"), r.FormValue("message"))
+	if err != nil {
+		l.respond(w, logging.Error, http.StatusBadRequest, "creating freeze failed with: %s", err)
+		return
+	}
+
+	l.respond(w, logging.Info, http.StatusOK, "Applies are frozen since %s", freeze.Time.Format("2006-01-02 15:04:05"))
+}
+
+// Unfreeze handles deleting the freeze for the repo and project query params.
+func (l *LocksController) Unfreeze(w http.ResponseWriter, r *http.Request) {
+	freeze, err := l.Freezer.Unfreeze(r.URL.Query().Get("repo"), r.URL.Query().Get("project"))
+	if err != nil {
+		l.respond(w, logging.Error, http.StatusInternalServerError, "deleting freeze failed with: %s", err)
+		return
+	}
+	if freeze == nil {
+		l.respond(w, logging.Info, http.StatusNotFound, "No freeze found")
+		return
+	}
+
+	l.respond(w, logging.Info, http.StatusOK, "Deleted freeze")
+}
+
 // GetLock is the GET /locks/{id} route. It renders the lock detail view.
 func (l *LocksController) GetLock(w http.ResponseWriter, r *http.Request) {
 	id, ok := mux.Vars(r)["id"]
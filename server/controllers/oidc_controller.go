@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/runatlantis/atlantis/server/core/oidc"
+)
+
+// OIDCController serves the discovery document and public keys that cloud
+// providers need to verify the tokens Atlantis issues.
+type OIDCController struct {
+	Issuer *oidc.Issuer
+}
+
+// Discovery is the GET /.well-known/openid-configuration route.
+func (o *OIDCController) Discovery(w http.ResponseWriter, _ *http.Request) {
+	o.respond(w, o.Issuer.Discovery())
+}
+
+// JWKS is the GET /.well-known/jwks route.
+func (o *OIDCController) JWKS(w http.ResponseWriter, _ *http.Request) {
+	o.respond(w, o.Issuer.JWKS())
+}
+
+func (o *OIDCController) respond(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "Error creating json response: %s", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data) // nolint: errcheck
+}
@@ -0,0 +1,46 @@
+package controllers_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/core/oidc"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestOIDCController(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Ok(t, err)
+	issuer, err := oidc.NewIssuer("https://atlantis.example.com", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	Ok(t, err)
+	o := &controllers.OIDCController{Issuer: issuer}
+
+	req, _ := http.NewRequest("GET", oidc.DiscoveryPath, nil)
+	w := httptest.NewRecorder()
+	o.Discovery(w, req)
+	Equals(t, http.StatusOK, w.Code)
+	Equals(t, "application/json", w.Header().Get("Content-Type"))
+	var discovery map[string]interface{}
+	Ok(t, json.Unmarshal(w.Body.Bytes(), &discovery))
+	Equals(t, "https://atlantis.example.com", discovery["issuer"])
+	Equals(t, "https://atlantis.example.com/.well-known/jwks", discovery["jwks_uri"])
+
+	req, _ = http.NewRequest("GET", oidc.JWKSPath, nil)
+	w = httptest.NewRecorder()
+	o.JWKS(w, req)
+	Equals(t, http.StatusOK, w.Code)
+	var jwks struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	Ok(t, json.Unmarshal(w.Body.Bytes(), &jwks))
+	Equals(t, 1, len(jwks.Keys))
+	Equals(t, "RSA", jwks.Keys[0]["kty"])
+	Equals(t, "RS256", jwks.Keys[0]["alg"])
+}
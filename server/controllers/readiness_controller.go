@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// ReadinessCheck checks that a dependency of Atlantis can be used.
+type ReadinessCheck struct {
+	Name  string
+	Check func() error
+}
+
+// ReadinessController handles the readiness probe of Atlantis, which unlike
+// /healthz fails if Atlantis can't reach the services it depends on.
+type ReadinessController struct {
+	Logger logging.SimpleLogging
+	Checks []ReadinessCheck
+	// Timeout is how long each check can take before it fails.
+	Timeout time.Duration
+}
+
+type ReadinessResponse struct {
+	Status string                 `json:"status"`
+	Checks []ReadinessCheckResult `json:"checks"`
+}
+
+type ReadinessCheckResult struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+const (
+	readinessOK     = "ok"
+	readinessFailed = "failed"
+)
+
+// Get is the GET /readyz route. It responds with 503 if any of the checks
+// failed, with the result of each check.
+func (c *ReadinessController) Get(w http.ResponseWriter, _ *http.Request) {
+	resp := c.Run()
+	for _, r := range resp.Checks {
+		if r.Status != readinessOK {
+			c.Logger.Warn("readiness check %s failed: %s", r.Name, r.Error)
+		}
+	}
+	data, err := json.MarshalIndent(&resp, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "Error creating readiness json response: %s", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if resp.Status != readinessOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(data) // nolint: errcheck
+}
+
+// Run runs the checks at the same time and returns their results.
+func (c *ReadinessController) Run() ReadinessResponse {
+	results := make([]ReadinessCheckResult, len(c.Checks))
+	done := make(chan struct{}, len(c.Checks))
+	for i, check := range c.Checks {
+		go func(i int, check ReadinessCheck) {
+			results[i] = c.run(check)
+			done <- struct{}{}
+		}(i, check)
+	}
+	for range c.Checks {
+		<-done
+	}
+
+	resp := ReadinessResponse{Status: readinessOK, Checks: results}
+	for _, r := range results {
+		if r.Status != readinessOK {
+			resp.Status = readinessFailed
+		}
+	}
+	return resp
+}
+
+// run runs check, failing it if it takes longer than the timeout.
+func (c *ReadinessController) run(check ReadinessCheck) ReadinessCheckResult {
+	start := time.Now()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- check.Check()
+	}()
+	var err error
+	select {
+	case err = <-errs:
+	case <-time.After(c.Timeout):
+		err = fmt.Errorf("timed out after %s", c.Timeout)
+	}
+	result := ReadinessCheckResult{
+		Name:       check.Name,
+		Status:     readinessOK,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = readinessFailed
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// DiskSpaceCheck returns a check that fails if dir's file system has less than
+// minFree bytes free.
+func DiskSpaceCheck(dir string, minFree uint64) func() error {
+	return func() error {
+		free, err := diskFree(dir)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("%d MiB free in %s, less than the minimum of %d MiB", free>>20, dir, minFree>>20)
+		}
+		return nil
+	}
+}
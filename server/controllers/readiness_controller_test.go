@@ -0,0 +1,89 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func getReadiness(t *testing.T, c *controllers.ReadinessController) (int, controllers.ReadinessResponse) {
+	r, _ := http.NewRequest("GET", "/readyz", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	c.Get(w, r)
+	body, err := io.ReadAll(w.Result().Body)
+	Ok(t, err)
+	Equals(t, "application/json", w.Result().Header.Get("Content-Type"))
+	var resp controllers.ReadinessResponse
+	Ok(t, json.Unmarshal(body, &resp))
+	// Durations vary so they aren't compared.
+	for i := range resp.Checks {
+		resp.Checks[i].DurationMS = 0
+	}
+	return w.Result().StatusCode, resp
+}
+
+func TestReadinessController_Ready(t *testing.T) {
+	c := &controllers.ReadinessController{
+		Logger: logging.NewNoopLogger(t),
+		Checks: []controllers.ReadinessCheck{
+			{Name: "github", Check: func() error { return nil }},
+			{Name: "disk-space", Check: controllers.DiskSpaceCheck(t.TempDir(), 0)},
+		},
+		Timeout: time.Second,
+	}
+	code, resp := getReadiness(t, c)
+	Equals(t, http.StatusOK, code)
+	Equals(t, controllers.ReadinessResponse{
+		Status: "ok",
+		Checks: []controllers.ReadinessCheckResult{
+			{Name: "github", Status: "ok"},
+			{Name: "disk-space", Status: "ok"},
+		},
+	}, resp)
+}
+
+func TestReadinessController_NotReady(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	c := &controllers.ReadinessController{
+		Logger: logging.NewNoopLogger(t),
+		Checks: []controllers.ReadinessCheck{
+			{Name: "github", Check: func() error { return nil }},
+			{Name: "database", Check: func() error { return errors.New("connection refused") }},
+			{Name: "gitlab", Check: func() error {
+				<-block
+				return nil
+			}},
+		},
+		Timeout: 10 * time.Millisecond,
+	}
+	code, resp := getReadiness(t, c)
+	Equals(t, http.StatusServiceUnavailable, code)
+	Equals(t, controllers.ReadinessResponse{
+		Status: "failed",
+		Checks: []controllers.ReadinessCheckResult{
+			{Name: "github", Status: "ok"},
+			{Name: "database", Status: "failed", Error: "connection refused"},
+			{Name: "gitlab", Status: "failed", Error: "timed out after 10ms"},
+		},
+	}, resp)
+}
+
+func TestDiskSpaceCheck(t *testing.T) {
+	dir := t.TempDir()
+	Ok(t, controllers.DiskSpaceCheck(dir, 1)())
+	err := controllers.DiskSpaceCheck(dir, 1<<62)()
+	Assert(t, err != nil, "expected error")
+	Assert(t, bytes.Contains([]byte(err.Error()), []byte("less than the minimum of 4398046511104 MiB")), "got %s", err)
+
+	ErrContains(t, "no such file or directory", controllers.DiskSpaceCheck(dir+"/missing", 1)())
+}
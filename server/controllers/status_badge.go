@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"fmt"
+	"html"
+)
+
+// statusBadgeColors are the colors of the message side of status badges by
+// project state. States that aren't listed are grey.
+var statusBadgeColors = map[string]string{
+	"applied":      "#4c1",
+	"planned":      "#007ec6",
+	"drifted":      "#fe7d37",
+	"plan failed":  "#e05d44",
+	"apply failed": "#e05d44",
+}
+
+const statusBadgeTemplate = `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[3]s: %[4]s">
+<title>%[3]s: %[4]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%[2]d" height="20" fill="#555"/><rect x="%[2]d" width="%[5]d" height="20" fill="%[6]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[7]d" y="14">%[3]s</text>
+<text x="%[8]d" y="14">%[4]s</text>
+</g>
+</svg>
+`
+
+// renderStatusBadge returns an SVG badge in the style of shields.io with
+// label on the left and the project's state on the right.
+func renderStatusBadge(label string, state string) string {
+	color, ok := statusBadgeColors[state]
+	if !ok {
+		color = "#9f9f9f"
+	}
+	// Verdana at 11px is about 7px per character, plus 5px of padding on
+	// each side.
+	labelWidth := 7*len([]rune(label)) + 10
+	stateWidth := 7*len([]rune(state)) + 10
+	return fmt.Sprintf(statusBadgeTemplate,
+		labelWidth+stateWidth, labelWidth, html.EscapeString(label), html.EscapeString(state),
+		stateWidth, color, labelWidth/2, labelWidth+stateWidth/2)
+}
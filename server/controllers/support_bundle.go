@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// Redacted replaces secrets in support bundles.
+const Redacted = "<redacted>"
+
+// SupportBundle writes support bundles, zip files with what's needed to
+// troubleshoot an Atlantis installation.
+type SupportBundle struct {
+	AtlantisVersion string
+	// StartTime is when the server started.
+	StartTime time.Time
+	// Config is the server's config, with secrets already redacted.
+	Config map[string]interface{}
+	// Secrets are redacted wherever they appear in the bundle, ex. in an
+	// error that includes a URL with a token.
+	Secrets []string
+	// Readiness runs the connectivity checks.
+	Readiness *ReadinessController
+	// RecentErrors are the warnings and errors logged recently. If nil,
+	// they aren't included.
+	RecentErrors *logging.RecentErrors
+}
+
+// SupportBundleFilename returns the name of a bundle written at now.
+func SupportBundleFilename(now time.Time) string {
+	return fmt.Sprintf("atlantis-support-bundle-%s.zip", now.UTC().Format("20060102T150405Z"))
+}
+
+// Write writes a bundle to w with these files:
+//   - version.txt: the versions of Atlantis and Go, the platform and uptime.
+//   - config.json: the server's config.
+//   - checks.json: the results of the readiness checks.
+//   - errors.log: the warnings and errors logged recently.
+//   - goroutines.txt: the stack of every goroutine.
+//
+// It returns the results of the readiness checks.
+func (b *SupportBundle) Write(w io.Writer, now time.Time) (ReadinessResponse, error) {
+	checks := b.Readiness.Run()
+
+	var version bytes.Buffer
+	fmt.Fprintf(&version, "atlantis %s\n", b.AtlantisVersion)
+	fmt.Fprintf(&version, "%s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&version, "generated %s\n", now.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&version, "uptime %s\n", now.Sub(b.StartTime).Round(time.Second))
+
+	config, err := marshalBundleJSON(b.Config)
+	if err != nil {
+		return checks, err
+	}
+	checksJSON, err := marshalBundleJSON(checks)
+	if err != nil {
+		return checks, err
+	}
+
+	var recentErrors bytes.Buffer
+	if b.RecentErrors != nil {
+		for _, e := range b.RecentErrors.Entries() {
+			fmt.Fprintf(&recentErrors, "%s %s %s %s\n", e.Time.UTC().Format(time.RFC3339), e.Level, e.Caller, e.Message)
+		}
+	}
+
+	var goroutines bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&goroutines, 2); err != nil {
+		return checks, err
+	}
+
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"version.txt", version.Bytes()},
+		{"config.json", config},
+		{"checks.json", checksJSON},
+		{"errors.log", recentErrors.Bytes()},
+		{"goroutines.txt", goroutines.Bytes()},
+	}
+	redactor := b.redactor()
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return checks, err
+		}
+		if _, err := redactor.WriteString(fw, string(f.content)); err != nil {
+			return checks, err
+		}
+	}
+	return checks, zw.Close()
+}
+
+// redactor replaces the secrets with Redacted, including where they're
+// escaped in JSON. Secrets shorter than four characters are left alone since
+// they'd match all over the bundle.
+func (b *SupportBundle) redactor() *strings.Replacer {
+	var oldnew []string
+	for _, s := range b.Secrets {
+		if len(s) < 4 {
+			continue
+		}
+		oldnew = append(oldnew, s, Redacted)
+		if escaped, err := marshalBundleJSON(s); err == nil && string(escaped) != `"`+s+`"` {
+			oldnew = append(oldnew, string(escaped[1:len(escaped)-1]), Redacted)
+		}
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+// marshalBundleJSON marshals v as indented JSON without escaping HTML so the
+// redacted values stay readable.
+func marshalBundleJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -47,10 +47,70 @@ type ApplyLockData struct {
 	TimeFormatted string
 }
 
+// FreezeIndexData holds the fields needed to display a freeze in the index
+// view.
+type FreezeIndexData struct {
+	RepoFullName  string
+	Project       string
+	Message       string
+	TimeFormatted string
+}
+
+// ProjectDurationsIndexData holds the fields needed to display how long a
+// project's plans and applies take in the index view. Durations are empty if
+// they aren't known.
+type ProjectDurationsIndexData struct {
+	RepoFullName string
+	// Project is the project's name, or its dir if it isn't named.
+	Project   string
+	Workspace string
+	PlanP50   string
+	PlanP95   string
+	ApplyP50  string
+	ApplyP95  string
+	// GettingSlower is true if the project's recent plans or applies are
+	// slower than usual.
+	GettingSlower bool
+}
+
+// LockContentionIndexData holds the heatmap of the locks that blocked pull
+// requests the longest in the index view.
+type LockContentionIndexData struct {
+	// Days are the heatmap's days, oldest first, ex. "Oct 3".
+	Days  []string
+	Locks []ContendedLockIndexData
+}
+
+// ContendedLockIndexData is a row of the lock contention heatmap.
+type ContendedLockIndexData struct {
+	RepoFullName string
+	Path         string
+	Workspace    string
+	// Blocked is how many pull requests waited for the lock and Waited is how
+	// long they waited in total, ex. "3h20m0s".
+	Blocked int
+	Waited  string
+	// BlockedNow is how many pull requests are waiting for the lock.
+	BlockedNow int
+	Days       []LockContentionDayIndexData
+}
+
+// LockContentionDayIndexData is a cell of the lock contention heatmap.
+type LockContentionDayIndexData struct {
+	Day string
+	// Blocked is how many pull requests waited for the lock that day.
+	Blocked int
+	// Heat is the cell's shade from 0, no pull requests, to 4.
+	Heat int
+}
+
 // IndexData holds the data for rendering the index page
 type IndexData struct {
 	Locks           []LockIndexData
 	ApplyLock       ApplyLockData
+	Freezes         []FreezeIndexData
+	SlowestProjects []ProjectDurationsIndexData
+	LockContention  LockContentionIndexData
 	AtlantisVersion string
 	// CleanedBasePath is the path Atlantis is accessible at externally. If
 	// not using a path-based proxy, this will be an empty string. Never ends
@@ -80,6 +140,21 @@ var IndexTemplate = template.Must(template.New("index.html.tmpl").Parse(`
   <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/skeleton.css">
   <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/custom.css">
   <link rel="icon" type="image/png" href="{{ .CleanedBasePath }}/static/images/atlantis-icon.png">
+  <style>
+    .lock-contention .heat-day {
+      font-size: 0.9rem;
+      white-space: nowrap;
+    }
+
+    .lock-contention .heat {
+      text-align: center;
+    }
+
+    .heat-1 { background-color: #fde0dd; }
+    .heat-2 { background-color: #fa9fb5; }
+    .heat-3 { background-color: #f768a1; }
+    .heat-4 { background-color: #c51b8a; color: white; }
+  </style>
 </head>
 <body>
 <div class="container">
@@ -104,6 +179,27 @@ var IndexTemplate = template.Must(template.New("index.html.tmpl").Parse(`
     {{ end }}
   </section>
   <br>
+  <section>
+    <p class="title-heading small"><strong>Freezes</strong></p>
+    {{ if .Freezes }}
+    {{ range .Freezes }}
+      <div class="twelve columns button content lock-row">
+      <div class="list-title">{{ if .RepoFullName }}{{ .RepoFullName }}{{ else }}All repos{{ end }} {{ if .Project }}<code>{{ .Project }}</code>{{ end }} <span class="heading-font-size">{{ .Message }}</span></div>
+      <div class="list-status"><a class="js-unfreeze" data-repo="{{ .RepoFullName }}" data-project="{{ .Project }}"><code>Unfreeze</code></a></div>
+      <div class="list-timestamp"><span class="heading-font-size">{{ .TimeFormatted }}</span></div>
+      </div>
+    {{ end }}
+    {{ else }}
+    <p class="placeholder">No freezes found.</p>
+    {{ end }}
+    <form id="freezeForm" class="twelve columns">
+      <input type="text" name="repo" placeholder="owner/repo (empty for all repos)">
+      <input type="text" name="project" placeholder="project name or dir (optional)">
+      <input type="text" name="message" placeholder="message for pull requests">
+      <input class="button-primary" type="submit" value="Freeze Applies">
+    </form>
+  </section>
+  <br>
   <br>
   <br>
   <section>
@@ -123,6 +219,57 @@ var IndexTemplate = template.Must(template.New("index.html.tmpl").Parse(`
     <p class="placeholder">No locks found.</p>
     {{ end }}
   </section>
+  <br>
+  <br>
+  <br>
+  <section>
+    <p class="title-heading small"><strong>Slowest Projects</strong></p>
+    {{ if .SlowestProjects }}
+    <table class="u-full-width">
+      <thead>
+        <tr><th>Project</th><th>Workspace</th><th>Plan p50</th><th>Plan p95</th><th>Apply p50</th><th>Apply p95</th></tr>
+      </thead>
+      <tbody>
+      {{ range .SlowestProjects }}
+        <tr>
+          <td>{{ .RepoFullName }} <code>{{ .Project }}</code>{{ if .GettingSlower }} <strong>getting slower</strong>{{ end }}</td>
+          <td><code>{{ .Workspace }}</code></td>
+          <td>{{ .PlanP50 }}</td>
+          <td>{{ .PlanP95 }}</td>
+          <td>{{ .ApplyP50 }}</td>
+          <td>{{ .ApplyP95 }}</td>
+        </tr>
+      {{ end }}
+      </tbody>
+    </table>
+    {{ else }}
+    <p class="placeholder">No run durations recorded.</p>
+    {{ end }}
+  </section>
+  <br>
+  <br>
+  <section>
+    <p class="title-heading small"><strong>Lock Contention</strong></p>
+    {{ if .LockContention.Locks }}
+    <table class="u-full-width lock-contention">
+      <thead>
+        <tr><th>Lock</th><th>Blocked PRs</th><th>Waited</th>{{ range .LockContention.Days }}<th class="heat-day">{{ . }}</th>{{ end }}</tr>
+      </thead>
+      <tbody>
+      {{ range .LockContention.Locks }}
+        <tr>
+          <td>{{ .RepoFullName }} <code>{{ .Path }}</code> <code>{{ .Workspace }}</code>{{ if .BlockedNow }} <strong>{{ .BlockedNow }} waiting</strong>{{ end }}</td>
+          <td>{{ .Blocked }}</td>
+          <td>{{ .Waited }}</td>
+          {{ range .Days }}<td class="heat heat-{{ .Heat }}" title="{{ .Blocked }} blocked on {{ .Day }}">{{ if .Blocked }}{{ .Blocked }}{{ end }}</td>{{ end }}
+        </tr>
+      {{ end }}
+      </tbody>
+    </table>
+    {{ else }}
+    <p class="placeholder">No pull requests were blocked by locks in the last two weeks.</p>
+    {{ end }}
+  </section>
   <div id="applyLockMessageModal" class="modal">
     <!-- Modal content -->
     <div class="modal-content">
@@ -155,6 +302,28 @@ v{{ .AtlantisVersion }}
 </footer>
 <script>
 
+  $("#freezeForm").submit(function(event) {
+    event.preventDefault();
+    $.ajax({
+        url: '{{ .CleanedBasePath }}/freeze',
+        type: 'POST',
+        data: $(this).serialize(),
+        success: function(result) {
+          window.location.replace("{{ .CleanedBasePath }}/");
+        }
+    });
+  });
+
+  $("a.js-unfreeze").click(function() {
+    $.ajax({
+        url: '{{ .CleanedBasePath }}/unfreeze?' + $.param({repo: $(this).data("repo"), project: $(this).data("project")}),
+        type: 'DELETE',
+        success: function(result) {
+          window.location.replace("{{ .CleanedBasePath }}/");
+        }
+    });
+  });
+
   function applyLockModalSetup(lockOrUnlock) {
       // Get the modal
       switch( lockOrUnlock ) {
@@ -357,6 +526,8 @@ type ProjectJobData struct {
 	AtlantisVersion string
 	ProjectPath     string
 	CleanedBasePath string
+	// Running is true if the job is running and can be cancelled.
+	Running bool
 }
 
 var ProjectJobsTemplate = template.Must(template.New("blank.html.tmpl").Parse(`
@@ -394,6 +565,9 @@ var ProjectJobsTemplate = template.Must(template.New("blank.html.tmpl").Parse(`
     <a title="atlantis" href="{{ .CleanedBasePath }}/"><img class="hero" src="{{ .CleanedBasePath }}/static/images/atlantis-icon_512.png"/></a>
     <p class="title-heading">atlantis</p>
     <p class="title-heading"><strong></strong></p>
+    {{ if .Running }}
+    <p><button class="js-cancel-job" type="button">Cancel</button> <span class="js-cancel-job-result"></span></p>
+    {{ end }}
     </section>
     <div class="spacer"></div>
     <br>
@@ -426,6 +600,25 @@ var ProjectJobsTemplate = template.Must(template.New("blank.html.tmpl").Parse(`
       term.open(document.getElementById("terminal"));
       fitAddon.fit();
       window.addEventListener("resize", () => fitAddon.fit());
+
+      $(".js-cancel-job").click(function() {
+        if (!confirm("Are you sure you want to cancel this job? Its running process will be interrupted.")) {
+          return;
+        }
+        var btn = $(this);
+        btn.prop("disabled", true);
+        $.ajax({
+          url: document.location.pathname + "/cancel",
+          type: "POST",
+          success: function() {
+            $(".js-cancel-job-result").text("Cancelled, waiting for the process to exit.");
+          },
+          error: function(xhr) {
+            btn.prop("disabled", false);
+            $(".js-cancel-job-result").text(xhr.responseText);
+          }
+        });
+      });
     </script>
   </body>
 </html>
@@ -0,0 +1,147 @@
+// Package artifacts stores files that Atlantis produces, ex. snapshots of
+// Terraform state, outside of its data dir so they outlive the pull requests
+// that produced them.
+package artifacts
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+)
+
+// Store stores artifacts by key. Keys are slash-separated paths, ex.
+// state-snapshots/owner/repo/dir/default/1650000000.tfstate.enc.
+type Store interface {
+	// Put stores data under key, replacing anything already stored there.
+	Put(key string, data []byte) error
+	// Get returns the data stored under key.
+	Get(key string) ([]byte, error)
+	// URL returns where the artifact with key is stored, ex. to link to it.
+	URL(key string) string
+}
+
+// NewStore returns the store at storeURL, which is either an S3 bucket with an
+// optional prefix, ex. s3://bucket/prefix?region=us-east-1, or a directory,
+// ex. file:///var/atlantis-artifacts or /var/atlantis-artifacts.
+func NewStore(storeURL string) (Store, error) {
+	u, err := url.Parse(storeURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid artifact store %q: %s", storeURL, err)
+	}
+	switch u.Scheme {
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid artifact store %q, must be like s3://<bucket>/<prefix>", storeURL)
+		}
+		sess, err := session.NewSessionWithOptions(session.Options{
+			Config:            aws.Config{Region: stringOrNil(u.Query().Get("region"))},
+			SharedConfigState: session.SharedConfigEnable,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("creating AWS session: %s", err)
+		}
+		return &S3Store{
+			Client: s3.New(sess),
+			Bucket: u.Host,
+			Prefix: strings.Trim(u.Path, "/"),
+		}, nil
+	case "file":
+		return &FileStore{Dir: u.Path}, nil
+	case "":
+		return &FileStore{Dir: storeURL}, nil
+	default:
+		return nil, fmt.Errorf("invalid artifact store %q, only s3:// and file:// are supported", storeURL)
+	}
+}
+
+// FileStore stores artifacts as files under Dir.
+type FileStore struct {
+	Dir string
+}
+
+func (f *FileStore) Put(key string, data []byte) error {
+	path := f.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrapf(err, "creating dir for %s", key)
+	}
+	return errors.Wrapf(os.WriteFile(path, data, 0600), "writing %s", key)
+}
+
+func (f *FileStore) Get(key string) ([]byte, error) {
+	data, err := os.ReadFile(f.path(key))
+	return data, errors.Wrapf(err, "reading %s", key)
+}
+
+func (f *FileStore) URL(key string) string {
+	return "file://" + f.path(key)
+}
+
+func (f *FileStore) path(key string) string {
+	// Clean the key as if it were absolute so that it can't escape Dir.
+	return filepath.Join(f.Dir, filepath.FromSlash(filepath.Clean("/"+key)))
+}
+
+// S3Client is the part of the S3 client that S3Store uses.
+type S3Client interface {
+	PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error)
+	GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error)
+}
+
+// S3Store stores artifacts as objects in an S3 bucket, under Prefix if it's
+// set. Credentials come from the default AWS credential chain.
+type S3Store struct {
+	Client S3Client
+	Bucket string
+	Prefix string
+}
+
+func (s *S3Store) Put(key string, data []byte) error {
+	_, err := s.Client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(key)),
+		Body:   bytes.NewReader(data),
+	})
+	return errors.Wrapf(err, "uploading %s to s3://%s", s.key(key), s.Bucket)
+}
+
+func (s *S3Store) Get(key string) ([]byte, error) {
+	out, err := s.Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "downloading %s from s3://%s", s.key(key), s.Bucket)
+	}
+	defer out.Body.Close() // nolint: errcheck
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(out.Body); err != nil {
+		return nil, errors.Wrapf(err, "downloading %s from s3://%s", s.key(key), s.Bucket)
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *S3Store) URL(key string) string {
+	return fmt.Sprintf("s3://%s/%s", s.Bucket, s.key(key))
+}
+
+func (s *S3Store) key(key string) string {
+	if s.Prefix == "" {
+		return key
+	}
+	return s.Prefix + "/" + key
+}
+
+func stringOrNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
@@ -0,0 +1,101 @@
+package artifacts_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/runatlantis/atlantis/server/core/artifacts"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestNewStore(t *testing.T) {
+	store, err := artifacts.NewStore("/var/artifacts")
+	Ok(t, err)
+	Equals(t, &artifacts.FileStore{Dir: "/var/artifacts"}, store)
+
+	store, err = artifacts.NewStore("file:///var/artifacts")
+	Ok(t, err)
+	Equals(t, &artifacts.FileStore{Dir: "/var/artifacts"}, store)
+
+	store, err = artifacts.NewStore("s3://bucket/atlantis/?region=us-east-1")
+	Ok(t, err)
+	Equals(t, "bucket", store.(*artifacts.S3Store).Bucket)
+	Equals(t, "atlantis", store.(*artifacts.S3Store).Prefix)
+
+	_, err = artifacts.NewStore("gs://bucket")
+	ErrEquals(t, `invalid artifact store "gs://bucket", only s3:// and file:// are supported`, err)
+}
+
+func TestFileStore(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	store := &artifacts.FileStore{Dir: tmp}
+
+	Ok(t, store.Put("a/b/c.txt", []byte("data")))
+	data, err := store.Get("a/b/c.txt")
+	Ok(t, err)
+	Equals(t, "data", string(data))
+	Equals(t, "file://"+tmp+"/a/b/c.txt", store.URL("a/b/c.txt"))
+
+	// Keys can't escape the dir.
+	Equals(t, "file://"+tmp+"/etc/passwd", store.URL("../../etc/passwd"))
+}
+
+type fakeS3 struct {
+	objects map[string][]byte
+}
+
+func (f *fakeS3) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.objects[*input.Bucket+"/"+*input.Key] = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(f.objects[*input.Bucket+"/"+*input.Key]))}, nil
+}
+
+func TestS3Store(t *testing.T) {
+	client := &fakeS3{objects: map[string][]byte{}}
+	store := &artifacts.S3Store{Client: client, Bucket: "bucket", Prefix: "atlantis"}
+
+	Ok(t, store.Put("a/b.txt", []byte("data")))
+	Equals(t, map[string][]byte{"bucket/atlantis/a/b.txt": []byte("data")}, client.objects)
+	data, err := store.Get("a/b.txt")
+	Ok(t, err)
+	Equals(t, "data", string(data))
+	Equals(t, "s3://bucket/atlantis/a/b.txt", store.URL("a/b.txt"))
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), artifacts.KeySize)
+	encrypted, err := artifacts.Encrypt(key, []byte("state"))
+	Ok(t, err)
+	Assert(t, !bytes.Contains(encrypted, []byte("state")), "exp data to be encrypted")
+
+	plain, err := artifacts.Decrypt(key, encrypted)
+	Ok(t, err)
+	Equals(t, "state", string(plain))
+
+	_, err = artifacts.Decrypt(bytes.Repeat([]byte("x"), artifacts.KeySize), encrypted)
+	ErrEquals(t, "decrypting: wrong key or data was modified", err)
+}
+
+func TestParseKey(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), artifacts.KeySize)
+	parsed, err := artifacts.ParseKey(base64.StdEncoding.EncodeToString(key))
+	Ok(t, err)
+	Equals(t, key, parsed)
+
+	_, err = artifacts.ParseKey(base64.StdEncoding.EncodeToString([]byte("short")))
+	ErrEquals(t, "key must be 32 bytes, got 5", err)
+
+	_, err = artifacts.ParseKey("not base64!")
+	Assert(t, err != nil, "exp error")
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"os"
+	"regexp"
+)
+
+// envVarRef matches ${NAME} references to environment variables.
+var envVarRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// interpolateEnv replaces the ${NAME} references in data to the environment
+// variables in allowlist with their values, or an empty string if they're
+// unset. Other references are left as is so that run steps can still use
+// shell variables.
+func interpolateEnv(data []byte, allowlist []string) []byte {
+	if len(allowlist) == 0 {
+		return data
+	}
+	allowed := make(map[string]bool, len(allowlist))
+	for _, name := range allowlist {
+		allowed[name] = true
+	}
+	return envVarRef.ReplaceAllFunc(data, func(ref []byte) []byte {
+		name := string(envVarRef.FindSubmatch(ref)[1])
+		if !allowed[name] {
+			return ref
+		}
+		return []byte(os.Getenv(name))
+	})
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// LintGlobalCfg returns warnings about the repos in cfg, which has already
+// been validated, whose id can never match a repo. allowlist are the rules of
+// --repo-allowlist. If none of them have wildcards, repos that don't match any
+// of them are also warned about since Atlantis ignores their webhooks.
+func LintGlobalCfg(cfg valid.GlobalCfg, allowlist []string) []string {
+	var warnings []string
+	exactAllowlist := true
+	for _, rule := range allowlist {
+		if strings.Contains(rule, "*") {
+			exactAllowlist = false
+		}
+	}
+
+	for _, repo := range cfg.Repos {
+		// The repos set by the default config match every repo.
+		if repo.IDRegex != nil && repo.IDRegex.String() == ".*" {
+			continue
+		}
+		if reason := unmatchableRepoID(repo); reason != "" {
+			warnings = append(warnings, fmt.Sprintf("repo id %s can't match any repo: %s", repo.IDString(), reason))
+			continue
+		}
+		if !exactAllowlist || len(allowlist) == 0 {
+			continue
+		}
+		allowed := false
+		for _, rule := range allowlist {
+			if repo.IDMatches(rule) || strings.EqualFold(repo.ID, rule) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			warnings = append(warnings, fmt.Sprintf("repo id %s doesn't match any repo in --repo-allowlist", repo.IDString()))
+		}
+	}
+	return warnings
+}
+
+// unmatchableRepoID returns why repo's id can't match a repo ID, which is the
+// VCS hostname followed by the repo's full name, ex. github.com/owner/repo.
+// It returns an empty string if the id looks fine.
+func unmatchableRepoID(repo valid.Repo) string {
+	const (
+		schemeReason = "repo IDs don't include the URL scheme, ex. github.com/owner/repo"
+		gitReason    = "repo IDs don't end in .git, ex. github.com/owner/repo"
+		hostReason   = "repo IDs start with the VCS hostname, ex. github.com/owner/repo"
+	)
+	if repo.ID != "" {
+		switch {
+		case strings.Contains(repo.ID, "://"):
+			return schemeReason
+		case strings.HasSuffix(repo.ID, ".git"):
+			return gitReason
+		case strings.Count(repo.ID, "/") < 2:
+			return hostReason
+		}
+		return ""
+	}
+
+	re, err := syntax.Parse(repo.IDRegex.String(), syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	re = re.Simplify()
+	parts := []*syntax.Regexp{re}
+	if re.Op == syntax.OpConcat {
+		parts = re.Sub
+	}
+	// Literals that are concatenated at the top level must be part of every
+	// match, unlike ones in optional groups or alternations.
+	for _, part := range parts {
+		if part.Op == syntax.OpLiteral && strings.Contains(string(part.Rune), "://") {
+			return schemeReason
+		}
+	}
+	// If the regex is anchored to the start, its leading literal is the
+	// start of the repo ID so it should include a hostname before the
+	// first /, ex. ^github.com/owner/.
+	if len(parts) > 1 && (parts[0].Op == syntax.OpBeginText || parts[0].Op == syntax.OpBeginLine) && parts[1].Op == syntax.OpLiteral {
+		leading := string(parts[1].Rune)
+		if i := strings.Index(leading, "/"); i >= 0 && !strings.Contains(leading[:i], ".") && !strings.Contains(leading[:i], ":") {
+			return hostReason
+		}
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestLintGlobalCfg(t *testing.T) {
+	cases := []struct {
+		description string
+		cfgJSON     string
+		allowlist   []string
+		exp         []string
+	}{
+		{
+			description: "valid ids",
+			cfgJSON:     `{"repos":[{"id":"github.com/owner/repo"},{"id":"/^github\\.com/owner/.*/"},{"id":"/.*/"},{"id":"/(https://)?github.com/.*/"}]}`,
+			allowlist:   []string{"*"},
+		},
+		{
+			description: "id with scheme",
+			cfgJSON:     `{"repos":[{"id":"https://github.com/owner/repo"},{"id":"/https://github.com/owner/.*/"}]}`,
+			exp: []string{
+				"repo id https://github.com/owner/repo can't match any repo: repo IDs don't include the URL scheme, ex. github.com/owner/repo",
+				"repo id /https://github.com/owner/.*/ can't match any repo: repo IDs don't include the URL scheme, ex. github.com/owner/repo",
+			},
+		},
+		{
+			description: "id without hostname",
+			cfgJSON:     `{"repos":[{"id":"owner/repo"},{"id":"/^owner/.*/"},{"id":"/^bitbucket.example.com:7990/owner/.*/"}]}`,
+			exp: []string{
+				"repo id owner/repo can't match any repo: repo IDs start with the VCS hostname, ex. github.com/owner/repo",
+				"repo id /^owner/.*/ can't match any repo: repo IDs start with the VCS hostname, ex. github.com/owner/repo",
+			},
+		},
+		{
+			description: "id ending in .git",
+			cfgJSON:     `{"repos":[{"id":"github.com/owner/repo.git"}]}`,
+			exp: []string{
+				"repo id github.com/owner/repo.git can't match any repo: repo IDs don't end in .git, ex. github.com/owner/repo",
+			},
+		},
+		{
+			description: "exact allowlist",
+			cfgJSON:     `{"repos":[{"id":"github.com/owner/repo"},{"id":"github.com/owner/other"},{"id":"/github.com/owner/.*/"},{"id":"/gitlab.com/.*/"}]}`,
+			allowlist:   []string{"github.com/owner/repo", "github.com/Owner/Other"},
+			exp: []string{
+				"repo id /gitlab.com/.*/ doesn't match any repo in --repo-allowlist",
+			},
+		},
+		{
+			description: "allowlist with wildcards isn't checked",
+			cfgJSON:     `{"repos":[{"id":"/gitlab.com/.*/"}]}`,
+			allowlist:   []string{"github.com/owner/repo", "github.com/other/*"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r := config.ParserValidator{}
+			cfg, err := r.ParseGlobalCfgJSON(c.cfgJSON, valid.NewGlobalCfgFromArgs(globalCfgArgs))
+			Ok(t, err)
+			Equals(t, c.exp, config.LintGlobalCfg(cfg, c.allowlist))
+		})
+	}
+}
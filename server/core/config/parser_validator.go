@@ -20,7 +20,12 @@ const AtlantisYAMLFilename = "atlantis.yaml"
 
 // ParserValidator parses and validates server-side repo config files and
 // repo-level atlantis.yaml files.
-type ParserValidator struct{}
+type ParserValidator struct {
+	// EnvAllowlist are the environment variables that are interpolated with
+	// ${NAME} in repo config files, ex. to use the same config on staging and
+	// production Atlantis servers.
+	EnvAllowlist []string
+}
 
 // HasRepoCfg returns true if there is a repo config (atlantis.yaml) file
 // for the repo at absRepoDir.
@@ -55,12 +60,21 @@ func (p *ParserValidator) ParseRepoCfg(absRepoDir string, globalCfg valid.Global
 		// able to detect if it's a NotExist err.
 		return valid.RepoCfg{}, err
 	}
-	return p.ParseRepoCfgData(configData, globalCfg, repoID)
+	return p.parseRepoCfgData(configData, globalCfg, repoID, absRepoDir)
 }
 
+// ParseRepoCfgData parses and validates repoCfgData like ParseRepoCfg. Since
+// the repo isn't available, projects whose dir is a glob aren't expanded.
 func (p *ParserValidator) ParseRepoCfgData(repoCfgData []byte, globalCfg valid.GlobalCfg, repoID string) (valid.RepoCfg, error) {
+	return p.parseRepoCfgData(repoCfgData, globalCfg, repoID, "")
+}
+
+func (p *ParserValidator) parseRepoCfgData(repoCfgData []byte, globalCfg valid.GlobalCfg, repoID string, absRepoDir string) (valid.RepoCfg, error) {
 	var rawConfig raw.RepoCfg
-	if err := yaml.UnmarshalStrict(repoCfgData, &rawConfig); err != nil {
+	if err := yaml.UnmarshalStrict(interpolateEnv(repoCfgData, p.EnvAllowlist), &rawConfig); err != nil {
+		return valid.RepoCfg{}, err
+	}
+	if err := rawConfig.ExpandDirGlobs(absRepoDir); err != nil {
 		return valid.RepoCfg{}, err
 	}
 
@@ -77,6 +91,9 @@ func (p *ParserValidator) ParseRepoCfgData(repoCfgData []byte, globalCfg valid.G
 	if err := p.validateProjectNames(validConfig); err != nil {
 		return valid.RepoCfg{}, err
 	}
+	if err := p.validatePromotions(validConfig); err != nil {
+		return valid.RepoCfg{}, err
+	}
 	if validConfig.Version == 2 {
 		// The only difference between v2 and v3 is how we parse custom run
 		// commands.
@@ -93,20 +110,95 @@ func (p *ParserValidator) ParseRepoCfgData(repoCfgData []byte, globalCfg valid.G
 // configFile. defaultCfg will be merged into the parsed config.
 // If there is no file at configFile it will return an error.
 func (p *ParserValidator) ParseGlobalCfg(configFile string, defaultCfg valid.GlobalCfg) (valid.GlobalCfg, error) {
+	rawCfg, err := p.readRawGlobalCfg(configFile)
+	if err != nil {
+		return valid.GlobalCfg{}, err
+	}
+	return p.validateRawGlobalCfg(rawCfg, defaultCfg, "yaml")
+}
+
+// ParseGlobalCfgs returns the parsed and validated global repo config built by
+// layering configFiles, ex. org-wide, team and repo-specific files, in order
+// of increasing precedence over defaultCfg. Each file's repos are matched after
+// the repos of the files before it so later files take precedence, workflows
+// and project templates with the same name are replaced, and tenants,
+// policies, metrics and apply concurrency groups are inherited unless a later
+// file sets them.
+func (p *ParserValidator) ParseGlobalCfgs(configFiles []string, defaultCfg valid.GlobalCfg) (valid.GlobalCfg, error) {
+	cfg := defaultCfg
+	for _, configFile := range configFiles {
+		rawCfg, err := p.readRawGlobalCfg(configFile)
+		if err != nil {
+			return valid.GlobalCfg{}, err
+		}
+		validation.ErrorTag = "yaml"
+		if err := rawCfg.ValidateInheriting(cfg.Workflows); err != nil {
+			return valid.GlobalCfg{}, errors.Wrapf(err, "validating %s", configFile)
+		}
+
+		layeredCfg := rawCfg.ToValid(cfg)
+		if len(rawCfg.PolicySets.PolicySets) == 0 {
+			layeredCfg.PolicySets = cfg.PolicySets
+		}
+		if rawCfg.Metrics.Statsd == nil && rawCfg.Metrics.Prometheus == nil {
+			layeredCfg.Metrics = cfg.Metrics
+		}
+		layeredCfg.ApplyConcurrencyGroups = mergeApplyConcurrencyGroups(cfg.ApplyConcurrencyGroups, rawCfg.ApplyConcurrencyGroups)
+		layeredCfg.ProjectTemplates = mergeProjectTemplates(cfg.ProjectTemplates, layeredCfg.ProjectTemplates)
+		if len(rawCfg.Tenants) == 0 {
+			layeredCfg.Tenants = cfg.Tenants
+		}
+		cfg = layeredCfg
+	}
+	return cfg, nil
+}
+
+func (p *ParserValidator) readRawGlobalCfg(configFile string) (raw.GlobalCfg, error) {
 	configData, err := os.ReadFile(configFile) // nolint: gosec
 	if err != nil {
-		return valid.GlobalCfg{}, errors.Wrapf(err, "unable to read %s file", configFile)
+		return raw.GlobalCfg{}, errors.Wrapf(err, "unable to read %s file", configFile)
 	}
 	if len(configData) == 0 {
-		return valid.GlobalCfg{}, fmt.Errorf("file %s was empty", configFile)
+		return raw.GlobalCfg{}, fmt.Errorf("file %s was empty", configFile)
 	}
 
 	var rawCfg raw.GlobalCfg
-	if err := yaml.UnmarshalStrict(configData, &rawCfg); err != nil {
-		return valid.GlobalCfg{}, err
+	if err := yaml.UnmarshalStrict(interpolateEnv(configData, p.EnvAllowlist), &rawCfg); err != nil {
+		return raw.GlobalCfg{}, err
 	}
+	return rawCfg, nil
+}
 
-	return p.validateRawGlobalCfg(rawCfg, defaultCfg, "yaml")
+// mergeApplyConcurrencyGroups returns the groups in inherited and overrides,
+// using the limit in overrides for groups in both.
+func mergeApplyConcurrencyGroups(inherited map[string]int, overrides map[string]int) map[string]int {
+	if len(inherited) == 0 {
+		return overrides
+	}
+	merged := make(map[string]int, len(inherited)+len(overrides))
+	for name, limit := range inherited {
+		merged[name] = limit
+	}
+	for name, limit := range overrides {
+		merged[name] = limit
+	}
+	return merged
+}
+
+// mergeProjectTemplates returns the templates in inherited and overrides,
+// using the template in overrides for names in both.
+func mergeProjectTemplates(inherited map[string]valid.ProjectTemplate, overrides map[string]valid.ProjectTemplate) map[string]valid.ProjectTemplate {
+	if len(inherited) == 0 {
+		return overrides
+	}
+	merged := make(map[string]valid.ProjectTemplate, len(inherited)+len(overrides))
+	for name, template := range inherited {
+		merged[name] = template
+	}
+	for name, template := range overrides {
+		merged[name] = template
+	}
+	return merged
 }
 
 // ParseGlobalCfgJSON parses a json string cfgJSON into global config.
@@ -172,6 +264,25 @@ func (p *ParserValidator) validateProjectNames(config valid.RepoCfg) error {
 	return nil
 }
 
+// validatePromotions validates that every project's promote_to key names
+// another project in a different dir. Neither project can be at the repo root
+// since their changes would overlap.
+func (p *ParserValidator) validatePromotions(config valid.RepoCfg) error {
+	for _, project := range config.Projects {
+		if project.PromoteTo == "" {
+			continue
+		}
+		target := config.FindProjectByName(project.PromoteTo)
+		if target == nil {
+			return fmt.Errorf("project in dir %q has promote_to: %q but there is no project with that name", project.Dir, project.PromoteTo)
+		}
+		if target.Dir == project.Dir || target.Dir == "." || project.Dir == "." {
+			return fmt.Errorf("project in dir %q cannot be promoted to %q: projects must be in different dirs below the repo root", project.Dir, project.PromoteTo)
+		}
+	}
+	return nil
+}
+
 // applyLegacyShellParsing changes any custom run commands in cfg to use the old
 // parsing method with shlex.Split().
 func (p *ParserValidator) applyLegacyShellParsing(cfg *valid.RepoCfg) error {
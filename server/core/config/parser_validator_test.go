@@ -11,6 +11,7 @@ import (
 	"github.com/hashicorp/go-version"
 	"github.com/runatlantis/atlantis/server/core/config"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
 )
 
@@ -780,6 +781,120 @@ projects:
 				Workflows: map[string]valid.Workflow{},
 			},
 		},
+		{
+			description: "project promoted to another project",
+			input: `
+version: 3
+projects:
+- name: staging
+  dir: staging
+  promote_to: prod
+- name: prod
+  dir: prod`,
+			exp: valid.RepoCfg{
+				Version: 3,
+				Projects: []valid.Project{
+					{
+						Name:      String("staging"),
+						Dir:       "staging",
+						Workspace: "default",
+						Autoplan: valid.Autoplan{
+							WhenModified: []string{"**/*.tf*", "**/terragrunt.hcl"},
+							Enabled:      true,
+						},
+						PromoteTo: "prod",
+					},
+					{
+						Name:      String("prod"),
+						Dir:       "prod",
+						Workspace: "default",
+						Autoplan: valid.Autoplan{
+							WhenModified: []string{"**/*.tf*", "**/terragrunt.hcl"},
+							Enabled:      true,
+						},
+					},
+				},
+				Workflows: map[string]valid.Workflow{},
+			},
+		},
+		{
+			description: "project promoted to a project that doesn't exist",
+			input: `
+version: 3
+projects:
+- name: staging
+  dir: staging
+  promote_to: prod`,
+			expErr: "project in dir \"staging\" has promote_to: \"prod\" but there is no project with that name",
+		},
+		{
+			description: "project promoted to a project in the same dir",
+			input: `
+version: 3
+projects:
+- name: staging
+  dir: infra
+  workspace: staging
+  promote_to: prod
+- name: prod
+  dir: infra
+  workspace: prod`,
+			expErr: "project in dir \"infra\" cannot be promoted to \"prod\": projects must be in different dirs below the repo root",
+		},
+		{
+			description: "project depends on projects in other repos",
+			input: `
+version: 3
+projects:
+- dir: app
+  depends_on:
+  - repo: owner/network
+    project: vpc
+  - repo: owner/iam
+    project: roles
+    workspace: prod
+    enforcement: block`,
+			exp: valid.RepoCfg{
+				Version: 3,
+				Projects: []valid.Project{
+					{
+						Dir:       "app",
+						Workspace: "default",
+						Autoplan: valid.Autoplan{
+							WhenModified: []string{"**/*.tf*", "**/terragrunt.hcl"},
+							Enabled:      true,
+						},
+						DependsOn: []valid.ProjectDependency{
+							{Repo: "owner/network", Project: "vpc", Workspace: "default"},
+							{Repo: "owner/iam", Project: "roles", Workspace: "prod", Block: true},
+						},
+					},
+				},
+				Workflows: map[string]valid.Workflow{},
+			},
+		},
+		{
+			description: "project depends on a project without a repo",
+			input: `
+version: 3
+projects:
+- dir: app
+  depends_on:
+  - project: vpc`,
+			expErr: "projects: (0: (depends_on: (0: (repo: cannot be blank.).).).).",
+		},
+		{
+			description: "project dependency with an invalid enforcement",
+			input: `
+version: 3
+projects:
+- dir: app
+  depends_on:
+  - repo: owner/network
+    project: vpc
+    enforcement: fail`,
+			expErr: "projects: (0: (depends_on: (0: (enforcement: must be \"warn\" or \"block\".).).).).",
+		},
 		{
 			description: "if steps are set then we parse them properly",
 			input: `
@@ -1110,6 +1225,92 @@ workflows:
 	ErrEquals(t, "repo config not allowed to set 'workflow' key: server-side config needs 'allowed_overrides: [workflow]'", err)
 }
 
+func TestParseRepoCfg_DirGlob(t *testing.T) {
+	tmpDir, cleanup := TempDir(t)
+	defer cleanup()
+
+	for _, dir := range []string{"stacks/network", "stacks/dns", "stacks/.cache", "modules/vpc"} {
+		Ok(t, os.MkdirAll(filepath.Join(tmpDir, dir), 0700))
+	}
+	Ok(t, os.WriteFile(filepath.Join(tmpDir, "stacks", "README.md"), nil, 0600))
+	repoCfg := `
+version: 3
+projects:
+- dir: stacks/*/
+  name: "stack-{{ .DirName }}"
+  workspace: "{{ .DirName }}"
+- dir: modules/vpc
+`
+	Ok(t, os.WriteFile(filepath.Join(tmpDir, "atlantis.yaml"), []byte(repoCfg), 0600))
+
+	r := config.ParserValidator{}
+	cfg, err := r.ParseRepoCfg(tmpDir, globalCfg, "")
+	Ok(t, err)
+	Equals(t, 3, len(cfg.Projects))
+	Equals(t, "stacks/dns", cfg.Projects[0].Dir)
+	Equals(t, "stack-dns", *cfg.Projects[0].Name)
+	Equals(t, "dns", cfg.Projects[0].Workspace)
+	Equals(t, "stacks/network", cfg.Projects[1].Dir)
+	Equals(t, "stack-network", *cfg.Projects[1].Name)
+	Equals(t, "network", cfg.Projects[1].Workspace)
+	Equals(t, "modules/vpc", cfg.Projects[2].Dir)
+
+	// Without the repo the glob isn't expanded and the templates are dropped.
+	cfg, err = r.ParseRepoCfgData([]byte(repoCfg), globalCfg, "")
+	Ok(t, err)
+	Equals(t, 2, len(cfg.Projects))
+	Equals(t, "stacks/*", cfg.Projects[0].Dir)
+	Assert(t, cfg.Projects[0].Name == nil, "exp name to be dropped")
+	Equals(t, "default", cfg.Projects[0].Workspace)
+}
+
+func TestParseRepoCfg_DirGlobDuplicateNames(t *testing.T) {
+	tmpDir, cleanup := TempDir(t)
+	defer cleanup()
+
+	for _, dir := range []string{"aws/network", "gcp/network"} {
+		Ok(t, os.MkdirAll(filepath.Join(tmpDir, dir), 0700))
+	}
+	repoCfg := `
+version: 3
+projects:
+- dir: "*/*"
+  name: "{{ .DirName }}"
+`
+	Ok(t, os.WriteFile(filepath.Join(tmpDir, "atlantis.yaml"), []byte(repoCfg), 0600))
+
+	r := config.ParserValidator{}
+	_, err := r.ParseRepoCfg(tmpDir, globalCfg, "")
+	ErrContains(t, `found two or more projects with name "network"`, err)
+}
+
+func TestParseRepoCfg_EnvInterpolation(t *testing.T) {
+	t.Setenv("ATLANTIS_TEST_TF_VERSION", "v1.3.0")
+	t.Setenv("ATLANTIS_TEST_ENV", "staging")
+	t.Setenv("ATLANTIS_TEST_SECRET", "secret")
+
+	repoCfg := `
+version: 3
+projects:
+- dir: .
+  workspace: ${ATLANTIS_TEST_ENV}
+  terraform_version: ${ATLANTIS_TEST_TF_VERSION}
+  workflow: custom
+workflows:
+  custom:
+    plan:
+      steps:
+      - run: echo ${ATLANTIS_TEST_ENV} ${ATLANTIS_TEST_SECRET} ${WORKSPACE}
+`
+	globalCfgArgs := valid.GlobalCfgArgs{AllowRepoCfg: true}
+	r := config.ParserValidator{EnvAllowlist: []string{"ATLANTIS_TEST_TF_VERSION", "ATLANTIS_TEST_ENV"}}
+	cfg, err := r.ParseRepoCfgData([]byte(repoCfg), valid.NewGlobalCfgFromArgs(globalCfgArgs), "")
+	Ok(t, err)
+	Equals(t, "staging", cfg.Projects[0].Workspace)
+	Equals(t, "1.3.0", cfg.Projects[0].TerraformVersion.String())
+	Equals(t, "echo staging ${ATLANTIS_TEST_SECRET} ${WORKSPACE}", cfg.Workflows["custom"].Plan.Steps[0].RunCommand)
+}
+
 func TestParseGlobalCfg_NotExist(t *testing.T) {
 	r := config.ParserValidator{}
 	globalCfgArgs := valid.GlobalCfgArgs{
@@ -1143,6 +1344,11 @@ func TestParseGlobalCfg(t *testing.T) {
 	}
 	postWorkflowHooks := []*valid.WorkflowHook{postWorkflowHook}
 
+	weekdayWindow, err := valid.NewApplyWindow("* 9-16 * * 1-5", "")
+	Ok(t, err)
+	awsProvider, err := valid.NewAllowedProvider("hashicorp/aws", ">= 4.0, < 5.0")
+	Ok(t, err)
+
 	customWorkflow1 := valid.Workflow{
 		Name: "custom1",
 		Plan: valid.Stage{
@@ -1229,13 +1435,191 @@ func TestParseGlobalCfg(t *testing.T) {
 			input: `repos:
 - id: /.*/
   allowed_overrides: [invalid]`,
-			expErr: "repos: (0: (allowed_overrides: \"invalid\" is not a valid override, only \"apply_requirements\", \"workflow\" and \"delete_source_branch_on_merge\" are supported.).).",
+			expErr: "repos: (0: (allowed_overrides: \"invalid\" is not a valid override, only \"apply_requirements\", \"workflow\", \"delete_source_branch_on_merge\", \"apply_windows\" and \"markdown_template_dir\" are supported.).).",
 		},
 		"invalid apply_requirement": {
 			input: `repos:
 - id: /.*/
   apply_requirements: [invalid]`,
-			expErr: "repos: (0: (apply_requirements: \"invalid\" is not a valid apply_requirement, only \"approved\", \"mergeable\" and \"undiverged\" are supported.).).",
+			expErr: "repos: (0: (apply_requirements: \"invalid\" is not a valid apply_requirement, only \"approved\", \"mergeable\", \"undiverged\", \"no_destroys\" and \"change_ticket_approved\" are supported.).).",
+		},
+		"invalid superseded_comments": {
+			input: `repos:
+- id: /.*/
+  superseded_comments: invalid`,
+			expErr: "repos: (0: (superseded_comments: only \"hide\", \"delete\" and \"keep\" are supported.).).",
+		},
+		"invalid allowed_providers source": {
+			input: `repos:
+- id: /.*/
+  allowed_providers:
+  - source: aws`,
+			expErr: "repos: (0: (allowed_providers: (0: source \"aws\" must be in the form [HOSTNAME/]NAMESPACE/TYPE.).).).",
+		},
+		"invalid allowed_providers versions": {
+			input: `repos:
+- id: /.*/
+  allowed_providers:
+  - source: hashicorp/aws
+    versions: "> four"`,
+			expErr: "repos: (0: (allowed_providers: (0: versions for source \"hashicorp/aws\": Malformed constraint: > four.).).).",
+		},
+		"empty allowed_module_sources source": {
+			input: `repos:
+- id: /.*/
+  allowed_module_sources: [""]`,
+			expErr: "repos: (0: (allowed_module_sources: sources cannot be empty.).).",
+		},
+		"unknown allowed_commands command": {
+			input: `repos:
+- id: /.*/
+  allowed_commands: [plan, destroy]`,
+			expErr: "repos: (0: (allowed_commands: \"destroy\" is not a command, only plan, apply, unlock, approve_policies, approve_resources, version, force_unlock_state, init_config, fmt, providers, cancel, revert are supported.).).",
+		},
+		"invalid tenant name": {
+			input: `tenants:
+- name: pay/ments
+  repos: [github.com/owner/repo]`,
+			expErr: "tenants: (0: (name: must only contain letters, numbers, hyphens and underscores.).).",
+		},
+		"tenant without repos": {
+			input: `tenants:
+- name: payments`,
+			expErr: "tenants: (0: (repos: cannot be blank.).).",
+		},
+		"invalid tenant repo regex": {
+			input: `tenants:
+- name: payments
+  repos: ["/?/"]`,
+			expErr: "tenants: (0: (repos: parsing: /?/: error parsing regexp: missing argument to repetition operator: `?`.).).",
+		},
+		"tenant vcs_user without vcs_token_file": {
+			input: `tenants:
+- name: payments
+  repos: [github.com/owner/repo]
+  vcs_user: payments-bot`,
+			expErr: "tenants: (0: (vcs_token_file: vcs_user and vcs_token_file must be set together.).).",
+		},
+		"tenant metrics_tags sets tenant": {
+			input: `tenants:
+- name: payments
+  repos: [github.com/owner/repo]
+  metrics_tags:
+    tenant: other`,
+			expErr: "tenants: (0: (metrics_tags: \"tenant\" is set to the tenant's name.).).",
+		},
+		"duplicate tenant": {
+			input: `tenants:
+- name: payments
+  repos: [github.com/owner/repo]
+- name: payments
+  repos: [github.com/owner/other]`,
+			expErr: "tenant \"payments\" is defined more than once",
+		},
+		"tenant workflow doesn't exist": {
+			input: `tenants:
+- name: payments
+  repos: [github.com/owner/repo]
+  workflow: notdefined`,
+			expErr: "workflow \"notdefined\" of tenant \"payments\" is not defined",
+		},
+		"tenants with different metrics_tags": {
+			input: `tenants:
+- name: payments
+  repos: [github.com/owner/repo]
+  metrics_tags:
+    business_unit: payments
+- name: lending
+  repos: [github.com/owner/other]`,
+			expErr: "tenant \"lending\" must set the same metrics_tags as tenant \"payments\"",
+		},
+		"empty cloud_credentials": {
+			input: `repos:
+- id: /.*/
+  cloud_credentials: {}`,
+			expErr: "repos: (0: (cloud_credentials: at least one of aws, gcp or azure must be set.).).",
+		},
+		"cloud_credentials missing role_arn": {
+			input: `repos:
+- id: /.*/
+  cloud_credentials:
+    aws:
+      audience: sts.amazonaws.com`,
+			expErr: "repos: (0: (cloud_credentials: (aws: (role_arn: cannot be blank.).).).).",
+		},
+		"agent_pool with cloud_credentials": {
+			input: `repos:
+- id: /.*/
+  agent_pool: private
+  cloud_credentials:
+    aws:
+      role_arn: arn:aws:iam::123456789012:role/atlantis`,
+			expErr: "repos: (0: (agent_pool: cannot be used with cloud_credentials.).).",
+		},
+		"empty destroy_protection address": {
+			input: `repos:
+- id: /.*/
+  destroy_protection:
+    addresses: [""]`,
+			expErr: "repos: (0: (destroy_protection: (addresses: cannot be empty.).).).",
+		},
+		"zero max_resource_changes": {
+			input: `repos:
+- id: /.*/
+  max_resource_changes: 0`,
+			expErr: "repos: (0: (max_resource_changes: must be greater than 0.).).",
+		},
+		"protected_resources without approver_teams": {
+			input: `repos:
+- id: /.*/
+  protected_resources:
+    addresses: [aws_route53_zone.*]`,
+			expErr: "repos: (0: (protected_resources: (approver_teams: cannot be blank.).).).",
+		},
+		"terraform_defaults with unsupported step": {
+			input: `repos:
+- id: /.*/
+  terraform_defaults:
+  - extra_args:
+      import: [-lock-timeout=5m]`,
+			expErr: `repos: (0: (terraform_defaults: (0: (extra_args: "import" is not a valid step, only init, validate, plan, show, apply are supported.).).).).`,
+		},
+		"terraform_defaults with invalid env var": {
+			input: `repos:
+- id: /.*/
+  terraform_defaults:
+  - env:
+      "A=B": value`,
+			expErr: `repos: (0: (terraform_defaults: (0: (env: "A=B" is not a valid env var name.).).).).`,
+		},
+		"terraform_defaults with invalid var": {
+			input: `repos:
+- id: /.*/
+  terraform_defaults:
+  - vars:
+      "cost center": "1234"`,
+			expErr: `repos: (0: (terraform_defaults: (0: (vars: "cost center" is not a valid Terraform variable name.).).).).`,
+		},
+		"provider_targets without default attribute": {
+			input: `repos:
+- id: /.*/
+  provider_targets:
+  - provider: datadog
+    allowed: [https://api.datadoghq.eu/]`,
+			expErr: `repos: (0: (provider_targets: (0: (attribute: must be set since provider "datadog" doesn't have a default.).).).).`,
+		},
+		"provider_targets without allowed": {
+			input: `repos:
+- id: /.*/
+  provider_targets:
+  - provider: aws`,
+			expErr: "repos: (0: (provider_targets: (0: (allowed: cannot be blank.).).).).",
+		},
+		"relative deploy_key_file": {
+			input: `repos:
+- id: /.*/
+  deploy_key_file: deploy-keys/repo`,
+			expErr: "repos: (0: (deploy_key_file: must be an absolute path.).).",
 		},
 		"no workflows key": {
 			input: `repos: []`,
@@ -1316,12 +1700,56 @@ repos:
     - run: custom workflow command
   allowed_overrides: [apply_requirements, workflow, delete_source_branch_on_merge]
   allow_custom_workflows: true
+  mergeable_ignored_checks: [atlantis/apply*, flaky-check]
+  apply_windows:
+  - schedule: "* 9-16 * * 1-5"
+  apply_window_teams: [admin]
+  max_concurrent_applies: 2
+  max_resource_changes: 50
+  max_resource_changes_teams: [admin]
+  allowed_providers:
+  - source: hashicorp/aws
+    versions: ">= 4.0, < 5.0"
+  require_pinned_providers: true
+  allowed_module_sources: ["app.terraform.io/my-org/*"]
+  cloud_credentials:
+    aws:
+      role_arn: arn:aws:iam::123456789012:role/atlantis
+    azure:
+      client_id: client
+      tenant_id: tenant
+  destroy_protection:
+    addresses: ["*aws_db_instance.*"]
+    approver_teams: [dba]
+  protected_resources:
+    addresses: [aws_route53_zone.*]
+    approver_teams: [network]
+  deploy_key_file: /etc/atlantis/deploy-keys/repo
+  terraform_defaults:
+  - extra_args:
+      plan: [-lock-timeout=5m, -compact-warnings]
+    env:
+      TF_IN_AUTOMATION: "true"
+  - dir: ./live/*
+    extra_args:
+      apply: [-lock-timeout=10m]
+    vars:
+      environment: prod
+  provider_targets:
+  - dir: prod/*
+    provider: aws
+    allowed: ["arn:aws:iam::111111111111:role/*"]
+  - provider: datadog
+    attribute: api_url
+    allowed: [https://api.datadoghq.eu/]
+  allowed_commands: [plan, apply, unlock]
 - id: /.*/
   branch: /(master|main)/
   pre_workflow_hooks:
     - run: custom workflow command
   post_workflow_hooks:
     - run: custom workflow command
+  agent_pool: private
 workflows:
   custom1:
     plan:
@@ -1346,24 +1774,71 @@ policies:
     - name: good-policy
       path: rel/path/to/policy
       source: local
+apply_concurrency_groups:
+  aws-prod: 1
 `,
 			exp: valid.GlobalCfg{
 				Repos: []valid.Repo{
 					defaultCfg.Repos[0],
 					{
-						ID:                   "github.com/owner/repo",
-						ApplyRequirements:    []string{"approved", "mergeable"},
-						PreWorkflowHooks:     preWorkflowHooks,
-						Workflow:             &customWorkflow1,
-						PostWorkflowHooks:    postWorkflowHooks,
-						AllowedOverrides:     []string{"apply_requirements", "workflow", "delete_source_branch_on_merge"},
-						AllowCustomWorkflows: Bool(true),
+						ID:                      "github.com/owner/repo",
+						ApplyRequirements:       []string{"approved", "mergeable"},
+						PreWorkflowHooks:        preWorkflowHooks,
+						Workflow:                &customWorkflow1,
+						PostWorkflowHooks:       postWorkflowHooks,
+						AllowedOverrides:        []string{"apply_requirements", "workflow", "delete_source_branch_on_merge"},
+						AllowCustomWorkflows:    Bool(true),
+						MergeableIgnoredChecks:  []string{"atlantis/apply*", "flaky-check"},
+						ApplyWindows:            []valid.ApplyWindow{weekdayWindow},
+						ApplyWindowTeams:        []string{"admin"},
+						MaxConcurrentApplies:    Int(2),
+						MaxResourceChanges:      Int(50),
+						MaxResourceChangesTeams: []string{"admin"},
+						AllowedProviders:        []valid.AllowedProvider{awsProvider},
+						RequirePinnedProviders:  Bool(true),
+						AllowedModuleSources:    []string{"app.terraform.io/my-org/*"},
+						CloudCredentials: &valid.CloudCredentials{
+							AWS: &valid.AWSCredentials{
+								RoleARN:  "arn:aws:iam::123456789012:role/atlantis",
+								Audience: "sts.amazonaws.com",
+							},
+							Azure: &valid.AzureCredentials{
+								ClientID: "client",
+								TenantID: "tenant",
+							},
+						},
+						DestroyProtection: &valid.DestroyProtection{
+							Addresses:     []string{"*aws_db_instance.*"},
+							ApproverTeams: []string{"dba"},
+						},
+						ProtectedResources: &valid.ProtectedResources{
+							Addresses:     []string{"aws_route53_zone.*"},
+							ApproverTeams: []string{"network"},
+						},
+						DeployKeyFile: "/etc/atlantis/deploy-keys/repo",
+						TerraformDefaults: []valid.TerraformDefaults{
+							{
+								ExtraArgs: map[string][]string{"plan": {"-lock-timeout=5m", "-compact-warnings"}},
+								Env:       map[string]string{"TF_IN_AUTOMATION": "true"},
+							},
+							{
+								Dir:       "live/*",
+								ExtraArgs: map[string][]string{"apply": {"-lock-timeout=10m"}},
+								Vars:      map[string]string{"environment": "prod"},
+							},
+						},
+						ProviderTargets: []valid.ProviderTarget{
+							{Dir: "prod/*", Provider: "aws", Attribute: "assume_role.role_arn", Allowed: []string{"arn:aws:iam::111111111111:role/*"}},
+							{Provider: "datadog", Attribute: "api_url", Allowed: []string{"https://api.datadoghq.eu/"}},
+						},
+						AllowedCommands: []string{"plan", "apply", "unlock"},
 					},
 					{
 						IDRegex:           regexp.MustCompile(".*"),
 						BranchRegex:       regexp.MustCompile("(master|main)"),
 						PreWorkflowHooks:  preWorkflowHooks,
 						PostWorkflowHooks: postWorkflowHooks,
+						AgentPool:         "private",
 					},
 				},
 				Workflows: map[string]valid.Workflow{
@@ -1380,6 +1855,7 @@ policies:
 						},
 					},
 				},
+				ApplyConcurrencyGroups: map[string]int{"aws-prod": 1},
 			},
 		},
 		"id regex with trailing slash": {
@@ -1525,6 +2001,229 @@ workflows:
 }
 
 // Test that if we pass in JSON strings everything should parse fine.
+func TestParseGlobalCfgs(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+
+	orgFile := filepath.Join(tmp, "org.yaml")
+	Ok(t, os.WriteFile(orgFile, []byte(`
+repos:
+- id: /.*/
+  apply_requirements: [approved]
+workflows:
+  custom:
+    plan:
+      steps: [init]
+metrics:
+  prometheus:
+    endpoint: /metrics
+apply_concurrency_groups:
+  prod: 1
+  staging: 2
+`), 0600))
+	teamFile := filepath.Join(tmp, "team.yaml")
+	Ok(t, os.WriteFile(teamFile, []byte(`
+repos:
+- id: github.com/owner/repo
+  apply_requirements: [mergeable]
+  workflow: custom
+apply_concurrency_groups:
+  prod: 3
+`), 0600))
+
+	r := config.ParserValidator{}
+	cfg, err := r.ParseGlobalCfgs([]string{orgFile, teamFile}, valid.NewGlobalCfgFromArgs(globalCfgArgs))
+	Ok(t, err)
+
+	// The default repo, then the org's, then the team's.
+	Equals(t, 3, len(cfg.Repos))
+	Equals(t, []string{"approved"}, cfg.Repos[1].ApplyRequirements)
+	Equals(t, "github.com/owner/repo", cfg.Repos[2].ID)
+	Equals(t, "custom", cfg.Repos[2].Workflow.Name)
+	Equals(t, []string{"mergeable"}, cfg.MergeProjectCfg(logging.NewNoopLogger(t), "github.com/owner/repo", valid.Project{}, valid.RepoCfg{}).ApplyRequirements)
+	Equals(t, []string{"approved"}, cfg.MergeProjectCfg(logging.NewNoopLogger(t), "github.com/owner/other", valid.Project{}, valid.RepoCfg{}).ApplyRequirements)
+
+	// Settings the team file doesn't set are inherited.
+	Equals(t, "/metrics", cfg.Metrics.Prometheus.Endpoint)
+	Equals(t, map[string]int{"prod": 3, "staging": 2}, cfg.ApplyConcurrencyGroups)
+}
+
+func TestParseGlobalCfgs_UndefinedWorkflow(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+
+	orgFile := filepath.Join(tmp, "org.yaml")
+	Ok(t, os.WriteFile(orgFile, []byte(`
+repos:
+- id: /.*/
+`), 0600))
+	teamFile := filepath.Join(tmp, "team.yaml")
+	Ok(t, os.WriteFile(teamFile, []byte(`
+repos:
+- id: github.com/owner/repo
+  workflow: custom
+`), 0600))
+
+	r := config.ParserValidator{}
+	_, err := r.ParseGlobalCfgs([]string{orgFile, teamFile}, valid.NewGlobalCfgFromArgs(globalCfgArgs))
+	ErrEquals(t, fmt.Sprintf("validating %s: workflow \"custom\" is not defined", teamFile), err)
+}
+
+// Test that repo config projects get the settings of the project template
+// they use and that later config files replace templates with the same name.
+func TestParseGlobalCfgs_ProjectTemplates(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+
+	orgFile := filepath.Join(tmp, "org.yaml")
+	Ok(t, os.WriteFile(orgFile, []byte(`
+repos:
+- id: /.*/
+workflows:
+  terragrunt:
+    plan:
+      steps: [init]
+project_templates:
+  aws-stack:
+    workflow: terragrunt
+    apply_requirements: [approved, mergeable]
+    terraform_version: v1.3.0
+  gcp-stack:
+    apply_requirements: [approved]
+`), 0600))
+	teamFile := filepath.Join(tmp, "team.yaml")
+	Ok(t, os.WriteFile(teamFile, []byte(`
+project_templates:
+  gcp-stack:
+    terraform_version: v1.2.0
+`), 0600))
+
+	r := config.ParserValidator{}
+	globalCfg, err := r.ParseGlobalCfgs([]string{orgFile, teamFile}, valid.NewGlobalCfgFromArgs(globalCfgArgs))
+	Ok(t, err)
+	Equals(t, 2, len(globalCfg.ProjectTemplates))
+	Assert(t, globalCfg.ProjectTemplates["gcp-stack"].ApplyRequirements == nil, "exp gcp-stack to be replaced")
+
+	repoCfg, err := r.ParseRepoCfgData([]byte(`
+version: 3
+projects:
+- dir: network
+  template: aws-stack
+- dir: dns
+  template: aws-stack
+  terraform_version: v1.4.0
+- dir: data
+  template: gcp-stack
+`), globalCfg, "github.com/owner/repo")
+	Ok(t, err)
+	log := logging.NewNoopLogger(t)
+
+	network := globalCfg.MergeProjectCfg(log, "github.com/owner/repo", repoCfg.Projects[0], repoCfg)
+	Equals(t, "terragrunt", network.Workflow.Name)
+	Equals(t, []string{"approved", "mergeable"}, network.ApplyRequirements)
+	Equals(t, "1.3.0", network.TerraformVersion.String())
+
+	// Settings the project sets itself take precedence.
+	dns := globalCfg.MergeProjectCfg(log, "github.com/owner/repo", repoCfg.Projects[1], repoCfg)
+	Equals(t, "1.4.0", dns.TerraformVersion.String())
+
+	data := globalCfg.MergeProjectCfg(log, "github.com/owner/repo", repoCfg.Projects[2], repoCfg)
+	Equals(t, "default", data.Workflow.Name)
+	Equals(t, []string{}, data.ApplyRequirements)
+	Equals(t, "1.2.0", data.TerraformVersion.String())
+
+	_, err = r.ParseRepoCfgData([]byte(`
+version: 3
+projects:
+- dir: .
+  template: azure-stack
+`), globalCfg, "github.com/owner/repo")
+	ErrEquals(t, `project template "azure-stack" is not defined by server`, err)
+}
+
+func TestParseGlobalCfg_Tenants(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	path := filepath.Join(tmp, "conf.yaml")
+	Ok(t, os.WriteFile(path, []byte(`
+repos:
+- id: github.com/payments/legacy
+  workflow: default
+workflows:
+  terragrunt:
+    plan:
+      steps: [init]
+tenants:
+- name: payments
+  repos: [/github\.com/payments/.*/]
+  workflow: terragrunt
+  data_dir_quota_mb: 1024
+  vcs_user: payments-bot
+  vcs_token_file: /etc/atlantis/tenants/payments/token
+  deploy_key_file: /etc/atlantis/tenants/payments/deploy-key
+  metrics_tags:
+    business_unit: payments
+- name: lending
+  repos: [github.com/lending/infra]
+  metrics_tags:
+    business_unit: lending
+`), 0600))
+
+	r := config.ParserValidator{}
+	globalCfg, err := r.ParseGlobalCfg(path, valid.NewGlobalCfgFromArgs(globalCfgArgs))
+	Ok(t, err)
+	Equals(t, 2, len(globalCfg.Tenants))
+
+	payments := globalCfg.Tenant("github.com/payments/network")
+	Assert(t, payments != nil, "exp payments tenant")
+	Equals(t, "payments", payments.Name)
+	Equals(t, 1024, payments.DataDirQuotaMB)
+	Equals(t, "payments-bot", payments.VCSUser)
+	Equals(t, "/etc/atlantis/tenants/payments/token", payments.VCSTokenFile)
+	Equals(t, map[string]string{"tenant": "payments", "business_unit": "payments"}, payments.Tags())
+	Equals(t, "/etc/atlantis/tenants/payments/deploy-key", globalCfg.DeployKeyFile("github.com/payments/network"))
+	Equals(t, "lending", globalCfg.Tenant("github.com/lending/infra").Name)
+
+	Assert(t, globalCfg.RepoAllowedByTenants("github.com/lending/infra"), "exp lending repo to be allowed")
+	Assert(t, !globalCfg.RepoAllowedByTenants("github.com/lending/other"), "exp repo outside of tenants to be denied")
+
+	// The tenant's workflow is the default for its repos but the repos
+	// config takes precedence.
+	log := logging.NewNoopLogger(t)
+	network := globalCfg.DefaultProjCfg(log, "github.com/payments/network", ".", "default")
+	Equals(t, "terragrunt", network.Workflow.Name)
+	legacy := globalCfg.DefaultProjCfg(log, "github.com/payments/legacy", ".", "default")
+	Equals(t, "default", legacy.Workflow.Name)
+	infra := globalCfg.DefaultProjCfg(log, "github.com/lending/infra", ".", "default")
+	Equals(t, "default", infra.Workflow.Name)
+
+	// Later files inherit the tenants unless they set them.
+	teamFile := filepath.Join(tmp, "team.yaml")
+	Ok(t, os.WriteFile(teamFile, []byte(`
+repos:
+- id: github.com/lending/infra
+  apply_requirements: [approved]
+`), 0600))
+	globalCfg, err = r.ParseGlobalCfgs([]string{path, teamFile}, valid.NewGlobalCfgFromArgs(globalCfgArgs))
+	Ok(t, err)
+	Equals(t, 2, len(globalCfg.Tenants))
+}
+
+func TestParseGlobalCfg_ProjectTemplateUndefinedWorkflow(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	path := filepath.Join(tmp, "conf.yaml")
+	Ok(t, os.WriteFile(path, []byte(`
+project_templates:
+  aws-stack:
+    workflow: terragrunt
+`), 0600))
+
+	r := config.ParserValidator{}
+	_, err := r.ParseGlobalCfg(path, valid.NewGlobalCfgFromArgs(globalCfgArgs))
+	ErrEquals(t, `workflow "terragrunt" of project template "aws-stack" is not defined`, err)
+}
+
 func TestParserValidator_ParseGlobalCfgJSON(t *testing.T) {
 	customWorkflow := valid.Workflow{
 		Name: "custom",
@@ -1785,3 +2484,7 @@ func String(v string) *string { return &v }
 // Bool is a helper routine that allocates a new bool value
 // to store v and returns a pointer to it.
 func Bool(v bool) *bool { return &v }
+
+// Int is a helper routine that allocates a new int value
+// to store v and returns a pointer to it.
+func Int(v int) *int { return &v }
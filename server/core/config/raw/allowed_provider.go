@@ -0,0 +1,33 @@
+package raw
+
+import (
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// AllowedProvider is a provider that projects are allowed to use.
+type AllowedProvider struct {
+	Source   string `yaml:"source" json:"source"`
+	Versions string `yaml:"versions,omitempty" json:"versions,omitempty"`
+}
+
+func (a AllowedProvider) Validate() error {
+	_, err := valid.NewAllowedProvider(a.Source, a.Versions)
+	return err
+}
+
+func (a AllowedProvider) ToValid() valid.AllowedProvider {
+	// Safe to ignore the error because we test it in Validate().
+	v, _ := valid.NewAllowedProvider(a.Source, a.Versions)
+	return v
+}
+
+func allowedProvidersToValid(providers []AllowedProvider) []valid.AllowedProvider {
+	if providers == nil {
+		return nil
+	}
+	v := []valid.AllowedProvider{}
+	for _, p := range providers {
+		v = append(v, p.ToValid())
+	}
+	return v
+}
@@ -0,0 +1,33 @@
+package raw
+
+import (
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// ApplyWindow is a window of time in which applies are allowed.
+type ApplyWindow struct {
+	Schedule string `yaml:"schedule" json:"schedule"`
+	Timezone string `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+}
+
+func (a ApplyWindow) Validate() error {
+	_, err := valid.NewApplyWindow(a.Schedule, a.Timezone)
+	return err
+}
+
+func (a ApplyWindow) ToValid() valid.ApplyWindow {
+	// Safe to ignore the error because we test it in Validate().
+	v, _ := valid.NewApplyWindow(a.Schedule, a.Timezone)
+	return v
+}
+
+func applyWindowsToValid(windows []ApplyWindow) []valid.ApplyWindow {
+	if windows == nil {
+		return nil
+	}
+	v := []valid.ApplyWindow{}
+	for _, w := range windows {
+		v = append(v, w.ToValid())
+	}
+	return v
+}
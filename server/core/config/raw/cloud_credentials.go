@@ -0,0 +1,92 @@
+package raw
+
+import (
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// CloudCredentials configures the cloud credentials projects get through
+// OpenID Connect federation.
+type CloudCredentials struct {
+	AWS   *AWSCredentials   `yaml:"aws,omitempty" json:"aws,omitempty"`
+	GCP   *GCPCredentials   `yaml:"gcp,omitempty" json:"gcp,omitempty"`
+	Azure *AzureCredentials `yaml:"azure,omitempty" json:"azure,omitempty"`
+}
+
+type AWSCredentials struct {
+	RoleARN  string `yaml:"role_arn" json:"role_arn"`
+	Audience string `yaml:"audience,omitempty" json:"audience,omitempty"`
+}
+
+type GCPCredentials struct {
+	WorkloadIdentityProvider string `yaml:"workload_identity_provider" json:"workload_identity_provider"`
+	ServiceAccount           string `yaml:"service_account,omitempty" json:"service_account,omitempty"`
+}
+
+type AzureCredentials struct {
+	ClientID       string `yaml:"client_id" json:"client_id"`
+	TenantID       string `yaml:"tenant_id" json:"tenant_id"`
+	SubscriptionID string `yaml:"subscription_id,omitempty" json:"subscription_id,omitempty"`
+}
+
+func (c CloudCredentials) Validate() error {
+	if c.AWS == nil && c.GCP == nil && c.Azure == nil {
+		return errors.New("at least one of aws, gcp or azure must be set")
+	}
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.AWS),
+		validation.Field(&c.GCP),
+		validation.Field(&c.Azure),
+	)
+}
+
+func (a AWSCredentials) Validate() error {
+	return validation.ValidateStruct(&a,
+		validation.Field(&a.RoleARN, validation.Required),
+	)
+}
+
+func (g GCPCredentials) Validate() error {
+	return validation.ValidateStruct(&g,
+		validation.Field(&g.WorkloadIdentityProvider, validation.Required),
+	)
+}
+
+func (a AzureCredentials) Validate() error {
+	return validation.ValidateStruct(&a,
+		validation.Field(&a.ClientID, validation.Required),
+		validation.Field(&a.TenantID, validation.Required),
+	)
+}
+
+func (c *CloudCredentials) ToValid() *valid.CloudCredentials {
+	if c == nil {
+		return nil
+	}
+	v := &valid.CloudCredentials{}
+	if c.AWS != nil {
+		v.AWS = &valid.AWSCredentials{
+			RoleARN:  c.AWS.RoleARN,
+			Audience: c.AWS.Audience,
+		}
+		if v.AWS.Audience == "" {
+			v.AWS.Audience = valid.DefaultAWSAudience
+		}
+	}
+	if c.GCP != nil {
+		v.GCP = &valid.GCPCredentials{
+			WorkloadIdentityProvider: c.GCP.WorkloadIdentityProvider,
+			ServiceAccount:           c.GCP.ServiceAccount,
+		}
+	}
+	if c.Azure != nil {
+		v.Azure = &valid.AzureCredentials{
+			ClientID:       c.Azure.ClientID,
+			TenantID:       c.Azure.TenantID,
+			SubscriptionID: c.Azure.SubscriptionID,
+		}
+	}
+	return v
+}
@@ -0,0 +1,40 @@
+package raw
+
+import (
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// DestroyProtection configures the resources protected by the no_destroys
+// apply requirement.
+type DestroyProtection struct {
+	Addresses     []string `yaml:"addresses,omitempty" json:"addresses,omitempty"`
+	ApproverTeams []string `yaml:"approver_teams,omitempty" json:"approver_teams,omitempty"`
+}
+
+func (d DestroyProtection) Validate() error {
+	notEmpty := func(value interface{}) error {
+		for _, s := range value.([]string) {
+			if s == "" {
+				return errors.New("cannot be empty")
+			}
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&d,
+		validation.Field(&d.Addresses, validation.By(notEmpty)),
+		validation.Field(&d.ApproverTeams, validation.By(notEmpty)),
+	)
+}
+
+func (d *DestroyProtection) ToValid() *valid.DestroyProtection {
+	if d == nil {
+		return nil
+	}
+	return &valid.DestroyProtection{
+		Addresses:     d.Addresses,
+		ApproverTeams: d.ApproverTeams,
+	}
+}
@@ -2,6 +2,7 @@ package raw
 
 import (
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -16,32 +17,71 @@ type GlobalCfg struct {
 	Workflows  map[string]Workflow `yaml:"workflows" json:"workflows"`
 	PolicySets PolicySets          `yaml:"policies" json:"policies"`
 	Metrics    Metrics             `yaml:"metrics" json:"metrics"`
+	// ApplyConcurrencyGroups maps the name of an apply concurrency group to
+	// the maximum number of applies that can run at once in that group.
+	ApplyConcurrencyGroups map[string]int `yaml:"apply_concurrency_groups,omitempty" json:"apply_concurrency_groups,omitempty"`
+	// ProjectTemplates maps the name of a project template to its settings.
+	ProjectTemplates map[string]ProjectTemplate `yaml:"project_templates,omitempty" json:"project_templates,omitempty"`
+	Tenants          []Tenant                   `yaml:"tenants,omitempty" json:"tenants,omitempty"`
 }
 
 // Repo is the raw schema for repos in the server-side repo config.
 type Repo struct {
-	ID                        string         `yaml:"id" json:"id"`
-	Branch                    string         `yaml:"branch" json:"branch"`
-	ApplyRequirements         []string       `yaml:"apply_requirements" json:"apply_requirements"`
-	PreWorkflowHooks          []WorkflowHook `yaml:"pre_workflow_hooks" json:"pre_workflow_hooks"`
-	Workflow                  *string        `yaml:"workflow,omitempty" json:"workflow,omitempty"`
-	PostWorkflowHooks         []WorkflowHook `yaml:"post_workflow_hooks" json:"post_workflow_hooks"`
-	AllowedWorkflows          []string       `yaml:"allowed_workflows,omitempty" json:"allowed_workflows,omitempty"`
-	AllowedOverrides          []string       `yaml:"allowed_overrides" json:"allowed_overrides"`
-	AllowCustomWorkflows      *bool          `yaml:"allow_custom_workflows,omitempty" json:"allow_custom_workflows,omitempty"`
-	DeleteSourceBranchOnMerge *bool          `yaml:"delete_source_branch_on_merge,omitempty" json:"delete_source_branch_on_merge,omitempty"`
+	ID                        string              `yaml:"id" json:"id"`
+	Branch                    string              `yaml:"branch" json:"branch"`
+	ApplyRequirements         []string            `yaml:"apply_requirements" json:"apply_requirements"`
+	PreWorkflowHooks          []WorkflowHook      `yaml:"pre_workflow_hooks" json:"pre_workflow_hooks"`
+	Workflow                  *string             `yaml:"workflow,omitempty" json:"workflow,omitempty"`
+	PostWorkflowHooks         []WorkflowHook      `yaml:"post_workflow_hooks" json:"post_workflow_hooks"`
+	AllowedWorkflows          []string            `yaml:"allowed_workflows,omitempty" json:"allowed_workflows,omitempty"`
+	AllowedOverrides          []string            `yaml:"allowed_overrides" json:"allowed_overrides"`
+	AllowCustomWorkflows      *bool               `yaml:"allow_custom_workflows,omitempty" json:"allow_custom_workflows,omitempty"`
+	DeleteSourceBranchOnMerge *bool               `yaml:"delete_source_branch_on_merge,omitempty" json:"delete_source_branch_on_merge,omitempty"`
+	SupersededComments        *string             `yaml:"superseded_comments,omitempty" json:"superseded_comments,omitempty"`
+	MergeableIgnoredChecks    []string            `yaml:"mergeable_ignored_checks,omitempty" json:"mergeable_ignored_checks,omitempty"`
+	ApplyWindows              []ApplyWindow       `yaml:"apply_windows,omitempty" json:"apply_windows,omitempty"`
+	ApplyWindowTeams          []string            `yaml:"apply_window_teams,omitempty" json:"apply_window_teams,omitempty"`
+	MaxConcurrentApplies      *int                `yaml:"max_concurrent_applies,omitempty" json:"max_concurrent_applies,omitempty"`
+	MaxResourceChanges        *int                `yaml:"max_resource_changes,omitempty" json:"max_resource_changes,omitempty"`
+	MaxResourceChangesTeams   []string            `yaml:"max_resource_changes_teams,omitempty" json:"max_resource_changes_teams,omitempty"`
+	AllowedProviders          []AllowedProvider   `yaml:"allowed_providers,omitempty" json:"allowed_providers,omitempty"`
+	RequirePinnedProviders    *bool               `yaml:"require_pinned_providers,omitempty" json:"require_pinned_providers,omitempty"`
+	AllowedModuleSources      []string            `yaml:"allowed_module_sources,omitempty" json:"allowed_module_sources,omitempty"`
+	CloudCredentials          *CloudCredentials   `yaml:"cloud_credentials,omitempty" json:"cloud_credentials,omitempty"`
+	DeployKeyFile             string              `yaml:"deploy_key_file,omitempty" json:"deploy_key_file,omitempty"`
+	AgentPool                 string              `yaml:"agent_pool,omitempty" json:"agent_pool,omitempty"`
+	DestroyProtection         *DestroyProtection  `yaml:"destroy_protection,omitempty" json:"destroy_protection,omitempty"`
+	ProtectedResources        *ProtectedResources `yaml:"protected_resources,omitempty" json:"protected_resources,omitempty"`
+	TerraformDefaults         []TerraformDefaults `yaml:"terraform_defaults,omitempty" json:"terraform_defaults,omitempty"`
+	ProviderTargets           []ProviderTarget    `yaml:"provider_targets,omitempty" json:"provider_targets,omitempty"`
+	AllowedCommands           []string            `yaml:"allowed_commands,omitempty" json:"allowed_commands,omitempty"`
 }
 
 func (g GlobalCfg) Validate() error {
+	return g.ValidateInheriting(nil)
+}
+
+// ValidateInheriting validates g like Validate but also allows repos to
+// reference the inherited workflows, which are defined by the config files
+// that g is layered over.
+func (g GlobalCfg) ValidateInheriting(inherited map[string]valid.Workflow) error {
 	err := validation.ValidateStruct(&g,
 		validation.Field(&g.Repos),
 		validation.Field(&g.Workflows),
 		validation.Field(&g.Metrics),
+		validation.Field(&g.ProjectTemplates),
+		validation.Field(&g.Tenants),
 	)
 	if err != nil {
 		return err
 	}
 
+	for name, limit := range g.ApplyConcurrencyGroups {
+		if limit <= 0 {
+			return fmt.Errorf("apply_concurrency_groups: limit for %q must be greater than 0", name)
+		}
+	}
+
 	// Check that all workflows referenced by repos are actually defined.
 	for _, repo := range g.Repos {
 		if repo.Workflow == nil {
@@ -52,14 +92,7 @@ func (g GlobalCfg) Validate() error {
 			// The 'default' workflow will always be defined.
 			continue
 		}
-		found := false
-		for w := range g.Workflows {
-			if w == name {
-				found = true
-				break
-			}
-		}
-		if !found {
+		if !g.workflowDefined(name, inherited) {
 			return fmt.Errorf("workflow %q is not defined", name)
 		}
 	}
@@ -74,19 +107,32 @@ func (g GlobalCfg) Validate() error {
 				// The 'default' workflow will always be defined.
 				continue
 			}
-			found := false
-			for w := range g.Workflows {
-				if w == name {
-					found = true
-					break
-				}
-			}
-			if !found {
+			if !g.workflowDefined(name, inherited) {
 				return fmt.Errorf("workflow %q is not defined", name)
 			}
 		}
 	}
-	return nil
+
+	// Check that all workflows referenced by project templates are defined.
+	for templateName, template := range g.ProjectTemplates {
+		if template.Workflow == nil || *template.Workflow == valid.DefaultWorkflowName {
+			continue
+		}
+		if !g.workflowDefined(*template.Workflow, inherited) {
+			return fmt.Errorf("workflow %q of project template %q is not defined", *template.Workflow, templateName)
+		}
+	}
+	return validateTenants(g.Tenants, func(name string) bool {
+		return g.workflowDefined(name, inherited)
+	})
+}
+
+func (g GlobalCfg) workflowDefined(name string, inherited map[string]valid.Workflow) bool {
+	if _, ok := g.Workflows[name]; ok {
+		return true
+	}
+	_, ok := inherited[name]
+	return ok
 }
 
 func (g GlobalCfg) ToValid(defaultCfg valid.GlobalCfg) valid.GlobalCfg {
@@ -128,11 +174,27 @@ func (g GlobalCfg) ToValid(defaultCfg valid.GlobalCfg) valid.GlobalCfg {
 	}
 	repos = append(defaultCfg.Repos, repos...)
 
+	var templates map[string]valid.ProjectTemplate
+	if g.ProjectTemplates != nil {
+		templates = make(map[string]valid.ProjectTemplate)
+		for name, t := range g.ProjectTemplates {
+			templates[name] = t.ToValid(workflows)
+		}
+	}
+
+	var tenants []valid.Tenant
+	for _, t := range g.Tenants {
+		tenants = append(tenants, t.ToValid(workflows))
+	}
+
 	return valid.GlobalCfg{
-		Repos:      repos,
-		Workflows:  workflows,
-		PolicySets: g.PolicySets.ToValid(),
-		Metrics:    g.Metrics.ToValid(),
+		Repos:                  repos,
+		Workflows:              workflows,
+		PolicySets:             g.PolicySets.ToValid(),
+		Metrics:                g.Metrics.ToValid(),
+		ApplyConcurrencyGroups: g.ApplyConcurrencyGroups,
+		ProjectTemplates:       templates,
+		Tenants:                tenants,
 	}
 }
 
@@ -169,8 +231,8 @@ func (r Repo) Validate() error {
 	overridesValid := func(value interface{}) error {
 		overrides := value.([]string)
 		for _, o := range overrides {
-			if o != valid.ApplyRequirementsKey && o != valid.WorkflowKey && o != valid.DeleteSourceBranchOnMergeKey {
-				return fmt.Errorf("%q is not a valid override, only %q, %q and %q are supported", o, valid.ApplyRequirementsKey, valid.WorkflowKey, valid.DeleteSourceBranchOnMergeKey)
+			if o != valid.ApplyRequirementsKey && o != valid.WorkflowKey && o != valid.DeleteSourceBranchOnMergeKey && o != valid.ApplyWindowsKey && o != valid.MarkdownTemplateDirKey {
+				return fmt.Errorf("%q is not a valid override, only %q, %q, %q, %q and %q are supported", o, valid.ApplyRequirementsKey, valid.WorkflowKey, valid.DeleteSourceBranchOnMergeKey, valid.ApplyWindowsKey, valid.MarkdownTemplateDirKey)
 			}
 		}
 		return nil
@@ -182,6 +244,52 @@ func (r Repo) Validate() error {
 		return nil
 	}
 
+	greaterThanZero := func(value interface{}) error {
+		max := value.(*int)
+		if max != nil && *max <= 0 {
+			return errors.New("must be greater than 0")
+		}
+		return nil
+	}
+
+	allowedModuleSourcesValid := func(value interface{}) error {
+		for _, source := range value.([]string) {
+			if source == "" {
+				return errors.New("sources cannot be empty")
+			}
+		}
+		return nil
+	}
+
+	deployKeyFileValid := func(value interface{}) error {
+		keyFile := value.(string)
+		if keyFile != "" && !filepath.IsAbs(keyFile) {
+			return errors.New("must be an absolute path")
+		}
+		return nil
+	}
+
+	allowedCommandsValid := func(value interface{}) error {
+	next:
+		for _, cmd := range value.([]string) {
+			for _, c := range valid.CommentCommands {
+				if cmd == c {
+					continue next
+				}
+			}
+			return fmt.Errorf("%q is not a command, only %s are supported", cmd, strings.Join(valid.CommentCommands, ", "))
+		}
+		return nil
+	}
+
+	// The credentials are files on the server that agents can't read.
+	agentPoolValid := func(value interface{}) error {
+		if value.(string) != "" && r.CloudCredentials != nil {
+			return errors.New("cannot be used with cloud_credentials")
+		}
+		return nil
+	}
+
 	deleteSourceBranchOnMergeValid := func(value interface{}) error {
 		//TOBE IMPLEMENTED
 		return nil
@@ -194,6 +302,21 @@ func (r Repo) Validate() error {
 		validation.Field(&r.ApplyRequirements, validation.By(validApplyReq)),
 		validation.Field(&r.Workflow, validation.By(workflowExists)),
 		validation.Field(&r.DeleteSourceBranchOnMerge, validation.By(deleteSourceBranchOnMergeValid)),
+		validation.Field(&r.SupersededComments, validation.In(valid.HideSupersededComments, valid.DeleteSupersededComments, valid.KeepSupersededComments).Error(
+			fmt.Sprintf("only %q, %q and %q are supported", valid.HideSupersededComments, valid.DeleteSupersededComments, valid.KeepSupersededComments))),
+		validation.Field(&r.ApplyWindows),
+		validation.Field(&r.MaxConcurrentApplies, validation.By(greaterThanZero)),
+		validation.Field(&r.MaxResourceChanges, validation.By(greaterThanZero)),
+		validation.Field(&r.AllowedProviders),
+		validation.Field(&r.AllowedModuleSources, validation.By(allowedModuleSourcesValid)),
+		validation.Field(&r.CloudCredentials),
+		validation.Field(&r.DeployKeyFile, validation.By(deployKeyFileValid)),
+		validation.Field(&r.AgentPool, validation.By(agentPoolValid)),
+		validation.Field(&r.DestroyProtection),
+		validation.Field(&r.ProtectedResources),
+		validation.Field(&r.TerraformDefaults),
+		validation.Field(&r.ProviderTargets),
+		validation.Field(&r.AllowedCommands, validation.By(allowedCommandsValid)),
 	)
 }
 
@@ -264,5 +387,23 @@ OUTER:
 		AllowedOverrides:          r.AllowedOverrides,
 		AllowCustomWorkflows:      r.AllowCustomWorkflows,
 		DeleteSourceBranchOnMerge: r.DeleteSourceBranchOnMerge,
+		SupersededComments:        r.SupersededComments,
+		MergeableIgnoredChecks:    r.MergeableIgnoredChecks,
+		ApplyWindows:              applyWindowsToValid(r.ApplyWindows),
+		ApplyWindowTeams:          r.ApplyWindowTeams,
+		MaxConcurrentApplies:      r.MaxConcurrentApplies,
+		MaxResourceChanges:        r.MaxResourceChanges,
+		MaxResourceChangesTeams:   r.MaxResourceChangesTeams,
+		AllowedProviders:          allowedProvidersToValid(r.AllowedProviders),
+		RequirePinnedProviders:    r.RequirePinnedProviders,
+		AllowedModuleSources:      r.AllowedModuleSources,
+		CloudCredentials:          r.CloudCredentials.ToValid(),
+		DeployKeyFile:             r.DeployKeyFile,
+		AgentPool:                 r.AgentPool,
+		DestroyProtection:         r.DestroyProtection.ToValid(),
+		ProtectedResources:        r.ProtectedResources.ToValid(),
+		TerraformDefaults:         terraformDefaultsToValid(r.TerraformDefaults),
+		ProviderTargets:           providerTargetsToValid(r.ProviderTargets),
+		AllowedCommands:           r.AllowedCommands,
 	}
 }
@@ -1,6 +1,9 @@
 package raw
 
 import (
+	"fmt"
+	"regexp"
+
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/go-ozzo/ozzo-validation/is"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -9,6 +12,7 @@ import (
 type Metrics struct {
 	Statsd     *Statsd     `yaml:"statsd" json:"statsd"`
 	Prometheus *Prometheus `yaml:"prometheus" json:"prometheus"`
+	CloudWatch *CloudWatch `yaml:"cloudwatch" json:"cloudwatch"`
 }
 
 type Prometheus struct {
@@ -20,8 +24,10 @@ func (p *Prometheus) Validate() error {
 }
 
 type Statsd struct {
-	Port string `yaml:"port" json:"port"`
-	Host string `yaml:"host" json:"host"`
+	Port      string            `yaml:"port" json:"port"`
+	Host      string            `yaml:"host" json:"host"`
+	TagFormat string            `yaml:"tag_format" json:"tag_format"`
+	Tags      map[string]string `yaml:"tags" json:"tags"`
 }
 
 func (s *Statsd) Validate() error {
@@ -29,13 +35,33 @@ func (s *Statsd) Validate() error {
 		validation.Field(&s.Host, validation.Required),
 		validation.Field(&s.Port, validation.Required),
 		validation.Field(&s.Host, is.Host),
-		validation.Field(&s.Port, is.Int))
+		validation.Field(&s.Port, is.Int),
+		validation.Field(&s.TagFormat, validation.In(valid.DogStatsdTagFormat)),
+		validation.Field(&s.Tags, validation.By(func(interface{}) error {
+			if len(s.Tags) > 0 && s.TagFormat != valid.DogStatsdTagFormat {
+				return fmt.Errorf("are only sent with tag_format %s", valid.DogStatsdTagFormat)
+			}
+			return nil
+		})))
+}
+
+// CloudWatch configures metrics in CloudWatch's embedded metric format.
+type CloudWatch struct {
+	Namespace string `yaml:"namespace" json:"namespace"`
+	Endpoint  string `yaml:"endpoint" json:"endpoint"`
+}
+
+func (c *CloudWatch) Validate() error {
+	return validation.ValidateStruct(c,
+		validation.Field(&c.Namespace, validation.Required),
+		validation.Field(&c.Endpoint, validation.Match(regexp.MustCompile(`^(tcp|udp)://[^/]+$`)).Error("must be tcp://host:port or udp://host:port")))
 }
 
 func (m Metrics) Validate() error {
 	res := validation.ValidateStruct(&m,
 		validation.Field(&m.Statsd, validation.NilOrNotEmpty),
 		validation.Field(&m.Prometheus, validation.NilOrNotEmpty),
+		validation.Field(&m.CloudWatch, validation.NilOrNotEmpty),
 	)
 	return res
 }
@@ -45,8 +71,10 @@ func (m Metrics) ToValid() valid.Metrics {
 	if m.Statsd != nil {
 		return valid.Metrics{
 			Statsd: &valid.Statsd{
-				Host: m.Statsd.Host,
-				Port: m.Statsd.Port,
+				Host:      m.Statsd.Host,
+				Port:      m.Statsd.Port,
+				TagFormat: m.Statsd.TagFormat,
+				Tags:      m.Statsd.Tags,
 			},
 		}
 	}
@@ -57,5 +85,13 @@ func (m Metrics) ToValid() valid.Metrics {
 			},
 		}
 	}
+	if m.CloudWatch != nil {
+		return valid.Metrics{
+			CloudWatch: &valid.CloudWatch{
+				Namespace: m.CloudWatch.Namespace,
+				Endpoint:  m.CloudWatch.Endpoint,
+			},
+		}
+	}
 	return valid.Metrics{}
 }
@@ -16,8 +16,14 @@ func TestMetrics_Unmarshal(t *testing.T) {
 statsd:
   host: 127.0.0.1
   port: 8125
+  tag_format: dogstatsd
+  tags:
+    env: prod
 prometheus:
   endpoint: /metrics
+cloudwatch:
+  namespace: Atlantis
+  endpoint: tcp://127.0.0.1:25888
 `
 
 		var result raw.Metrics
@@ -80,6 +86,34 @@ func TestMetrics_Validate_Success(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "success with dogstatsd tags",
+			subject: raw.Metrics{
+				Statsd: &raw.Statsd{
+					Host:      "127.0.0.1",
+					Port:      "8125",
+					TagFormat: "dogstatsd",
+					Tags:      map[string]string{"env": "prod"},
+				},
+			},
+		},
+		{
+			description: "success with cloudwatch config",
+			subject: raw.Metrics{
+				CloudWatch: &raw.CloudWatch{
+					Namespace: "Atlantis",
+				},
+			},
+		},
+		{
+			description: "success with cloudwatch agent endpoint",
+			subject: raw.Metrics{
+				CloudWatch: &raw.CloudWatch{
+					Namespace: "Atlantis",
+					Endpoint:  "udp://127.0.0.1:25888",
+				},
+			},
+		},
 		{
 			description: "success with both configs",
 			subject: raw.Metrics{
@@ -131,6 +165,41 @@ func TestMetrics_Validate_Error(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "invalid tag format",
+			subject: raw.Metrics{
+				Statsd: &raw.Statsd{
+					Host:      "127.0.0.1",
+					Port:      "8125",
+					TagFormat: "influxdb",
+				},
+			},
+		},
+		{
+			description: "tags without dogstatsd",
+			subject: raw.Metrics{
+				Statsd: &raw.Statsd{
+					Host: "127.0.0.1",
+					Port: "8125",
+					Tags: map[string]string{"env": "prod"},
+				},
+			},
+		},
+		{
+			description: "missing cloudwatch namespace",
+			subject: raw.Metrics{
+				CloudWatch: &raw.CloudWatch{},
+			},
+		},
+		{
+			description: "invalid cloudwatch endpoint",
+			subject: raw.Metrics{
+				CloudWatch: &raw.CloudWatch{
+					Namespace: "Atlantis",
+					Endpoint:  "http://127.0.0.1:25888",
+				},
+			},
+		},
 		{
 			description: "invalid endpoint",
 			subject: raw.Metrics{
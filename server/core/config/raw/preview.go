@@ -0,0 +1,45 @@
+package raw
+
+import (
+	"text/template"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// Preview configures the preview environments of a project whose workspace
+// is named after each pull request, ex. to review infrastructure changes
+// like a review app.
+type Preview struct {
+	// DestroyOnClose destroys the pull request's environment before its
+	// workspace is deleted when it's closed.
+	DestroyOnClose *bool `yaml:"destroy_on_close,omitempty"`
+	// URL is a Go template of the environment's URL that's linked to after
+	// it's applied, ex. https://{{ .Workspace }}.preview.example.com.
+	URL *string `yaml:"url,omitempty"`
+}
+
+func (p Preview) Validate() error {
+	validURL := func(value interface{}) error {
+		url := value.(*string)
+		if url == nil {
+			return nil
+		}
+		_, err := template.New("").Parse(*url)
+		return err
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.URL, validation.By(validURL)),
+	)
+}
+
+func (p Preview) ToValid() *valid.Preview {
+	v := &valid.Preview{}
+	if p.DestroyOnClose != nil {
+		v.DestroyOnClose = *p.DestroyOnClose
+	}
+	if p.URL != nil {
+		v.URL = *p.URL
+	}
+	return v
+}
@@ -1,10 +1,13 @@
 package raw
 
 import (
+	"bytes"
 	"fmt"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
+	"text/template"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	version "github.com/hashicorp/go-version"
@@ -13,22 +16,32 @@ import (
 )
 
 const (
-	DefaultWorkspace           = "default"
-	ApprovedApplyRequirement   = "approved"
-	MergeableApplyRequirement  = "mergeable"
-	UnDivergedApplyRequirement = "undiverged"
+	DefaultWorkspace                     = "default"
+	ApprovedApplyRequirement             = "approved"
+	MergeableApplyRequirement            = "mergeable"
+	UnDivergedApplyRequirement           = "undiverged"
+	NoDestroysApplyRequirement           = "no_destroys"
+	ChangeTicketApprovedApplyRequirement = "change_ticket_approved"
 )
 
 type Project struct {
-	Name                      *string   `yaml:"name,omitempty"`
-	Dir                       *string   `yaml:"dir,omitempty"`
-	Workspace                 *string   `yaml:"workspace,omitempty"`
-	Workflow                  *string   `yaml:"workflow,omitempty"`
-	TerraformVersion          *string   `yaml:"terraform_version,omitempty"`
-	Autoplan                  *Autoplan `yaml:"autoplan,omitempty"`
-	ApplyRequirements         []string  `yaml:"apply_requirements,omitempty"`
-	DeleteSourceBranchOnMerge *bool     `yaml:"delete_source_branch_on_merge,omitempty"`
-	ExecutionOrderGroup       *int      `yaml:"execution_order_group,omitempty"`
+	Name                      *string             `yaml:"name,omitempty"`
+	Dir                       *string             `yaml:"dir,omitempty"`
+	Workspace                 *string             `yaml:"workspace,omitempty"`
+	Workflow                  *string             `yaml:"workflow,omitempty"`
+	TerraformVersion          *string             `yaml:"terraform_version,omitempty"`
+	Autoplan                  *Autoplan           `yaml:"autoplan,omitempty"`
+	ApplyRequirements         []string            `yaml:"apply_requirements,omitempty"`
+	DeleteSourceBranchOnMerge *bool               `yaml:"delete_source_branch_on_merge,omitempty"`
+	ExecutionOrderGroup       *int                `yaml:"execution_order_group,omitempty"`
+	ApplyWindows              []ApplyWindow       `yaml:"apply_windows,omitempty"`
+	ApplyConcurrencyGroup     *string             `yaml:"apply_concurrency_group,omitempty"`
+	PromoteTo                 *string             `yaml:"promote_to,omitempty"`
+	DependsOn                 []ProjectDependency `yaml:"depends_on,omitempty"`
+	Template                  *string             `yaml:"template,omitempty"`
+	WorkspaceFrom             *string             `yaml:"workspace_from,omitempty"`
+	Preview                   *Preview            `yaml:"preview,omitempty"`
+	ResourceTags              *ResourceTags       `yaml:"resource_tags,omitempty"`
 }
 
 func (p Project) Validate() error {
@@ -57,6 +70,23 @@ func (p Project) Validate() error {
 		validation.Field(&p.ApplyRequirements, validation.By(validApplyReq)),
 		validation.Field(&p.TerraformVersion, validation.By(VersionValidator)),
 		validation.Field(&p.Name, validation.By(validName)),
+		validation.Field(&p.PromoteTo, validation.By(validName)),
+		validation.Field(&p.ApplyWindows),
+		validation.Field(&p.DependsOn),
+		validation.Field(&p.WorkspaceFrom, validation.By(validWorkspaceFrom)),
+		validation.Field(&p.Workspace, validation.By(func(value interface{}) error {
+			if p.WorkspaceFrom != nil && value.(*string) != nil {
+				return errors.New("cannot be set with workspace_from")
+			}
+			return nil
+		})),
+		validation.Field(&p.ResourceTags),
+		validation.Field(&p.Preview, validation.By(func(value interface{}) error {
+			if value.(*Preview) != nil && p.WorkspaceFrom == nil {
+				return errors.New("requires workspace_from so that each pull request gets its own environment")
+			}
+			return nil
+		})),
 	)
 }
 
@@ -97,9 +127,108 @@ func (p Project) ToValid() valid.Project {
 		v.ExecutionOrderGroup = *p.ExecutionOrderGroup
 	}
 
+	v.ApplyWindows = applyWindowsToValid(p.ApplyWindows)
+
+	if p.ApplyConcurrencyGroup != nil {
+		v.ApplyConcurrencyGroup = *p.ApplyConcurrencyGroup
+	}
+
+	if p.PromoteTo != nil {
+		v.PromoteTo = *p.PromoteTo
+	}
+
+	v.DependsOn = projectDependenciesToValid(p.DependsOn)
+
+	if p.Template != nil {
+		v.Template = *p.Template
+	}
+
+	// The workspace of projects with workspace_from stays the default one
+	// until it's resolved for a pull request.
+	if p.WorkspaceFrom != nil {
+		v.WorkspaceFrom = *p.WorkspaceFrom
+	}
+
+	if p.Preview != nil {
+		v.Preview = p.Preview.ToValid()
+	}
+
+	if p.ResourceTags != nil {
+		v.ResourceTags = p.ResourceTags.ToValid()
+	}
+
 	return v
 }
 
+// HasDirGlob returns true if the project's dir is a glob, ex. stacks/*, that
+// expands to a project for each dir that matches it.
+func (p Project) HasDirGlob() bool {
+	return p.Dir != nil && strings.ContainsAny(*p.Dir, "*?[")
+}
+
+// dirGlobData is what the name and workspace templates of a project whose
+// dir is a glob can use.
+type dirGlobData struct {
+	// Dir is the matching dir relative to the repo root, ex. stacks/network.
+	Dir string
+	// DirName is the last element of Dir, ex. network.
+	DirName string
+}
+
+// ExpandDirGlob returns a copy of the project for each dir under absRepoDir
+// that matches the project's dir glob, sorted by dir. The project's name and
+// workspace are Go templates that can use {{ .Dir }} and {{ .DirName }}.
+// Hidden dirs are skipped.
+func (p Project) ExpandDirGlob(absRepoDir string) ([]Project, error) {
+	pattern := filepath.Clean("./" + *p.Dir)
+	matches, err := filepath.Glob(filepath.Join(absRepoDir, pattern))
+	if err != nil {
+		return nil, errors.Wrapf(err, "expanding dir %q", *p.Dir)
+	}
+	var projects []Project
+	for _, match := range matches {
+		if strings.HasPrefix(filepath.Base(match), ".") {
+			continue
+		}
+		if info, err := os.Stat(match); err != nil || !info.IsDir() {
+			continue
+		}
+		relDir, err := filepath.Rel(absRepoDir, match)
+		if err != nil {
+			return nil, err
+		}
+		relDir = filepath.ToSlash(relDir)
+		data := dirGlobData{Dir: relDir, DirName: filepath.Base(relDir)}
+
+		expanded := p
+		expanded.Dir = &relDir
+		if expanded.Name, err = renderDirGlobTemplate(p.Name, data); err != nil {
+			return nil, errors.Wrapf(err, "rendering name of project with dir %q", *p.Dir)
+		}
+		if expanded.Workspace, err = renderDirGlobTemplate(p.Workspace, data); err != nil {
+			return nil, errors.Wrapf(err, "rendering workspace of project with dir %q", *p.Dir)
+		}
+		projects = append(projects, expanded)
+	}
+	return projects, nil
+}
+
+func renderDirGlobTemplate(text *string, data dirGlobData) (*string, error) {
+	if text == nil || !strings.Contains(*text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New("").Option("missingkey=error").Parse(*text)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	rendered := buf.String()
+	return &rendered, nil
+}
+
 // validProjectName returns true if the project name is valid.
 // Since the name might be used in URLs and definitely in files we don't
 // support any characters that must be url escaped *except* for '/' because
@@ -109,11 +238,23 @@ func validProjectName(name string) bool {
 	return nameWithoutSlashes == url.QueryEscape(nameWithoutSlashes)
 }
 
+func validWorkspaceFrom(value interface{}) error {
+	from := value.(*string)
+	if from == nil {
+		return nil
+	}
+	if *from != valid.BranchWorkspaceFrom && *from != valid.PullNumberWorkspaceFrom {
+		return fmt.Errorf("%q is not supported, only %q and %q are", *from, valid.BranchWorkspaceFrom, valid.PullNumberWorkspaceFrom)
+	}
+	return nil
+}
+
 func validApplyReq(value interface{}) error {
 	reqs := value.([]string)
 	for _, r := range reqs {
-		if r != ApprovedApplyRequirement && r != MergeableApplyRequirement && r != UnDivergedApplyRequirement {
-			return fmt.Errorf("%q is not a valid apply_requirement, only %q, %q and %q are supported", r, ApprovedApplyRequirement, MergeableApplyRequirement, UnDivergedApplyRequirement)
+		if r != ApprovedApplyRequirement && r != MergeableApplyRequirement && r != UnDivergedApplyRequirement && r != NoDestroysApplyRequirement && r != ChangeTicketApprovedApplyRequirement {
+			return fmt.Errorf("%q is not a valid apply_requirement, only %q, %q, %q, %q and %q are supported",
+				r, ApprovedApplyRequirement, MergeableApplyRequirement, UnDivergedApplyRequirement, NoDestroysApplyRequirement, ChangeTicketApprovedApplyRequirement)
 		}
 	}
 	return nil
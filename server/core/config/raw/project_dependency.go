@@ -0,0 +1,74 @@
+package raw
+
+import (
+	"errors"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+const (
+	WarnDependencyEnforcement  = "warn"
+	BlockDependencyEnforcement = "block"
+)
+
+// ProjectDependency is a project in another repo that a project depends on,
+// ex. the network that an app is deployed into.
+type ProjectDependency struct {
+	// Repo is the owner and name of the dependency's repo, ex.
+	// "owner/network".
+	Repo string `yaml:"repo"`
+	// Project is the name of the dependency, or its dir if it isn't named.
+	Project   string  `yaml:"project"`
+	Workspace *string `yaml:"workspace,omitempty"`
+	// Enforcement is whether an out of date or failing dependency warns, the
+	// default, or blocks applies.
+	Enforcement *string `yaml:"enforcement,omitempty"`
+}
+
+func (d ProjectDependency) Validate() error {
+	validRepo := func(value interface{}) error {
+		parts := strings.Split(value.(string), "/")
+		if len(parts) < 2 || parts[0] == "" || parts[len(parts)-1] == "" {
+			return errors.New("must be the repo's full name, ex. owner/repo")
+		}
+		return nil
+	}
+	validEnforcement := func(value interface{}) error {
+		e := value.(*string)
+		if e != nil && *e != WarnDependencyEnforcement && *e != BlockDependencyEnforcement {
+			return errors.New("must be \"warn\" or \"block\"")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&d,
+		validation.Field(&d.Repo, validation.Required, validation.By(validRepo)),
+		validation.Field(&d.Project, validation.Required),
+		validation.Field(&d.Enforcement, validation.By(validEnforcement)),
+	)
+}
+
+func (d ProjectDependency) ToValid() valid.ProjectDependency {
+	v := valid.ProjectDependency{
+		Repo:      d.Repo,
+		Project:   d.Project,
+		Workspace: DefaultWorkspace,
+	}
+	if d.Workspace != nil && *d.Workspace != "" {
+		v.Workspace = *d.Workspace
+	}
+	v.Block = d.Enforcement != nil && *d.Enforcement == BlockDependencyEnforcement
+	return v
+}
+
+func projectDependenciesToValid(deps []ProjectDependency) []valid.ProjectDependency {
+	if deps == nil {
+		return nil
+	}
+	v := []valid.ProjectDependency{}
+	for _, d := range deps {
+		v = append(v, d.ToValid())
+	}
+	return v
+}
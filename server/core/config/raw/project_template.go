@@ -0,0 +1,36 @@
+package raw
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// ProjectTemplate is a set of project settings defined in the server-side
+// config that repo config projects can use by name.
+type ProjectTemplate struct {
+	Workflow          *string  `yaml:"workflow,omitempty" json:"workflow,omitempty"`
+	ApplyRequirements []string `yaml:"apply_requirements,omitempty" json:"apply_requirements,omitempty"`
+	TerraformVersion  *string  `yaml:"terraform_version,omitempty" json:"terraform_version,omitempty"`
+}
+
+func (p ProjectTemplate) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.ApplyRequirements, validation.By(validApplyReq)),
+		validation.Field(&p.TerraformVersion, validation.By(VersionValidator)),
+	)
+}
+
+func (p ProjectTemplate) ToValid(workflows map[string]valid.Workflow) valid.ProjectTemplate {
+	var v valid.ProjectTemplate
+	if p.Workflow != nil {
+		// The workflow exists because we test it in Validate.
+		workflow := workflows[*p.Workflow]
+		v.Workflow = &workflow
+	}
+	v.ApplyRequirements = p.ApplyRequirements
+	if p.TerraformVersion != nil {
+		v.TerraformVersion, _ = version.NewVersion(*p.TerraformVersion)
+	}
+	return v
+}
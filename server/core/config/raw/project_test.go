@@ -103,7 +103,7 @@ func TestProject_Validate(t *testing.T) {
 				Dir:               String("."),
 				ApplyRequirements: []string{"unsupported"},
 			},
-			expErr: "apply_requirements: \"unsupported\" is not a valid apply_requirement, only \"approved\", \"mergeable\" and \"undiverged\" are supported.",
+			expErr: "apply_requirements: \"unsupported\" is not a valid apply_requirement, only \"approved\", \"mergeable\", \"undiverged\", \"no_destroys\" and \"change_ticket_approved\" are supported.",
 		},
 		{
 			description: "apply reqs with approved requirement",
@@ -233,6 +233,81 @@ func TestProject_Validate(t *testing.T) {
 			},
 			expErr: `name: "namewith\\" is not allowed: must contain only URL safe characters.`,
 		},
+		{
+			description: "workspace from branch",
+			input: raw.Project{
+				Dir:           String("."),
+				WorkspaceFrom: String("branch"),
+			},
+			expErr: "",
+		},
+		{
+			description: "workspace from unsupported",
+			input: raw.Project{
+				Dir:           String("."),
+				WorkspaceFrom: String("commit"),
+			},
+			expErr: "workspace_from: \"commit\" is not supported, only \"branch\" and \"pull_number\" are.",
+		},
+		{
+			description: "workspace with workspace from",
+			input: raw.Project{
+				Dir:           String("."),
+				Workspace:     String("staging"),
+				WorkspaceFrom: String("pull_number"),
+			},
+			expErr: "workspace: cannot be set with workspace_from.",
+		},
+		{
+			description: "preview",
+			input: raw.Project{
+				Dir:           String("."),
+				WorkspaceFrom: String("branch"),
+				Preview:       &raw.Preview{DestroyOnClose: Bool(true), URL: String("https://{{ .Workspace }}.example.com")},
+			},
+			expErr: "",
+		},
+		{
+			description: "preview without workspace from",
+			input: raw.Project{
+				Dir:     String("."),
+				Preview: &raw.Preview{DestroyOnClose: Bool(true)},
+			},
+			expErr: "preview: requires workspace_from so that each pull request gets its own environment.",
+		},
+		{
+			description: "preview with invalid url",
+			input: raw.Project{
+				Dir:           String("."),
+				WorkspaceFrom: String("branch"),
+				Preview:       &raw.Preview{URL: String("https://{{ .Workspace")},
+			},
+			expErr: "preview: (url: template: :1: unclosed action.).",
+		},
+		{
+			description: "resource tags",
+			input: raw.Project{
+				Dir:          String("."),
+				ResourceTags: &raw.ResourceTags{Variable: String("tags"), Include: []string{"pull", "run_id"}},
+			},
+			expErr: "",
+		},
+		{
+			description: "resource tags with unsupported tag",
+			input: raw.Project{
+				Dir:          String("."),
+				ResourceTags: &raw.ResourceTags{Include: []string{"commit"}},
+			},
+			expErr: "resource_tags: (include: \"commit\" is not a supported tag, only repo, pull, author, run_id are.).",
+		},
+		{
+			description: "resource tags with invalid variable",
+			input: raw.Project{
+				Dir:          String("."),
+				ResourceTags: &raw.ResourceTags{Variable: String("my tags")},
+			},
+			expErr: "resource_tags: (variable: \"my tags\" is not a valid Terraform variable name.).",
+		},
 	}
 	validation.ErrorTag = "yaml"
 	for _, c := range cases {
@@ -432,6 +507,24 @@ func TestProject_ToValid(t *testing.T) {
 					Enabled:      true,
 				},
 			},
+		}, {
+			description: "resource tags defaults",
+			input: raw.Project{
+				Dir:          String("."),
+				ResourceTags: &raw.ResourceTags{},
+			},
+			exp: valid.Project{
+				Dir:       ".",
+				Workspace: "default",
+				Autoplan: valid.Autoplan{
+					WhenModified: []string{"**/*.tf*", "**/terragrunt.hcl"},
+					Enabled:      true,
+				},
+				ResourceTags: &valid.ResourceTags{
+					Variable: "atlantis_tags",
+					Include:  []string{"repo", "pull", "author", "run_id"},
+				},
+			},
 		},
 	}
 	for _, c := range cases {
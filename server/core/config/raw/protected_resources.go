@@ -0,0 +1,40 @@
+package raw
+
+import (
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// ProtectedResources configures the resources whose changes must be approved
+// with approve_resources.
+type ProtectedResources struct {
+	Addresses     []string `yaml:"addresses,omitempty" json:"addresses,omitempty"`
+	ApproverTeams []string `yaml:"approver_teams,omitempty" json:"approver_teams,omitempty"`
+}
+
+func (p ProtectedResources) Validate() error {
+	notEmpty := func(value interface{}) error {
+		for _, s := range value.([]string) {
+			if s == "" {
+				return errors.New("cannot be empty")
+			}
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Addresses, validation.Required, validation.By(notEmpty)),
+		validation.Field(&p.ApproverTeams, validation.Required, validation.By(notEmpty)),
+	)
+}
+
+func (p *ProtectedResources) ToValid() *valid.ProtectedResources {
+	if p == nil {
+		return nil
+	}
+	return &valid.ProtectedResources{
+		Addresses:     p.Addresses,
+		ApproverTeams: p.ApproverTeams,
+	}
+}
@@ -0,0 +1,75 @@
+package raw
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+var providerTargetAttribute = regexp.MustCompile(`^[a-z0-9_]+(\.[a-z0-9_]+)*$`)
+
+// ProviderTarget is the raw schema for the accounts, projects or
+// subscriptions that the provider configs of a repo's projects can target.
+type ProviderTarget struct {
+	Dir       string   `yaml:"dir,omitempty" json:"dir,omitempty"`
+	Provider  string   `yaml:"provider" json:"provider"`
+	Attribute string   `yaml:"attribute,omitempty" json:"attribute,omitempty"`
+	Allowed   []string `yaml:"allowed" json:"allowed"`
+}
+
+func (t ProviderTarget) Validate() error {
+	attributeValid := func(value interface{}) error {
+		attribute := value.(string)
+		if attribute == "" {
+			if _, ok := valid.DefaultProviderTargetAttributes[t.Provider]; !ok {
+				return fmt.Errorf("must be set since provider %q doesn't have a default", t.Provider)
+			}
+			return nil
+		}
+		if !providerTargetAttribute.MatchString(attribute) {
+			return fmt.Errorf("%q is not a valid attribute, nested blocks must be separated by dots, ex. assume_role.role_arn", attribute)
+		}
+		return nil
+	}
+	notEmpty := func(value interface{}) error {
+		for _, s := range value.([]string) {
+			if s == "" {
+				return errors.New("cannot be empty")
+			}
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.Provider, validation.Required),
+		validation.Field(&t.Attribute, validation.By(attributeValid)),
+		validation.Field(&t.Allowed, validation.Required, validation.By(notEmpty)),
+	)
+}
+
+func (t ProviderTarget) ToValid() valid.ProviderTarget {
+	attribute := t.Attribute
+	if attribute == "" {
+		attribute = valid.DefaultProviderTargetAttributes[t.Provider]
+	}
+	return valid.ProviderTarget{
+		Dir:       strings.TrimSuffix(strings.TrimPrefix(t.Dir, "./"), "/"),
+		Provider:  t.Provider,
+		Attribute: attribute,
+		Allowed:   t.Allowed,
+	}
+}
+
+func providerTargetsToValid(targets []ProviderTarget) []valid.ProviderTarget {
+	if targets == nil {
+		return nil
+	}
+	v := []valid.ProviderTarget{}
+	for _, t := range targets {
+		v = append(v, t.ToValid())
+	}
+	return v
+}
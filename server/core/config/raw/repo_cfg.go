@@ -2,6 +2,8 @@ package raw
 
 import (
 	"errors"
+	"path/filepath"
+	"strings"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -33,6 +35,9 @@ type RepoCfg struct {
 	ParallelPlan              *bool               `yaml:"parallel_plan,omitempty"`
 	DeleteSourceBranchOnMerge *bool               `yaml:"delete_source_branch_on_merge,omitempty"`
 	AllowedRegexpPrefixes     []string            `yaml:"allowed_regexp_prefixes,omitempty"`
+	// MarkdownTemplateDir is the dir in the repo with templates that
+	// override the templates of the repo's comments.
+	MarkdownTemplateDir *string `yaml:"markdown_template_dir,omitempty"`
 }
 
 func (r RepoCfg) Validate() error {
@@ -50,9 +55,47 @@ func (r RepoCfg) Validate() error {
 		validation.Field(&r.Version, validation.By(equals2)),
 		validation.Field(&r.Projects),
 		validation.Field(&r.Workflows),
+		validation.Field(&r.MarkdownTemplateDir, validation.By(func(value interface{}) error {
+			if dir := value.(*string); dir != nil && (strings.Contains(*dir, "..") || filepath.IsAbs(*dir)) {
+				return errors.New("must be a dir in the repo")
+			}
+			return nil
+		})),
 	)
 }
 
+// ExpandDirGlobs replaces each project whose dir is a glob with a project for
+// each dir under absRepoDir that matches it. If absRepoDir is empty because
+// the repo hasn't been cloned, the projects are kept, without their name and
+// workspace templates, so that their when_modified patterns still match the
+// files in any of the dirs.
+func (r *RepoCfg) ExpandDirGlobs(absRepoDir string) error {
+	var projects []Project
+	for _, p := range r.Projects {
+		if !p.HasDirGlob() {
+			projects = append(projects, p)
+			continue
+		}
+		if absRepoDir == "" {
+			if p.Name != nil && strings.Contains(*p.Name, "{{") {
+				p.Name = nil
+			}
+			if p.Workspace != nil && strings.Contains(*p.Workspace, "{{") {
+				p.Workspace = nil
+			}
+			projects = append(projects, p)
+			continue
+		}
+		expanded, err := p.ExpandDirGlob(absRepoDir)
+		if err != nil {
+			return err
+		}
+		projects = append(projects, expanded...)
+	}
+	r.Projects = projects
+	return nil
+}
+
 func (r RepoCfg) ToValid() valid.RepoCfg {
 	validWorkflows := make(map[string]valid.Workflow)
 	for k, v := range r.Workflows {
@@ -79,6 +122,11 @@ func (r RepoCfg) ToValid() valid.RepoCfg {
 		parallelPlan = *r.ParallelPlan
 	}
 
+	var markdownTemplateDir string
+	if r.MarkdownTemplateDir != nil {
+		markdownTemplateDir = *r.MarkdownTemplateDir
+	}
+
 	return valid.RepoCfg{
 		Version:                   *r.Version,
 		Projects:                  validProjects,
@@ -89,5 +137,6 @@ func (r RepoCfg) ToValid() valid.RepoCfg {
 		ParallelPolicyCheck:       parallelPlan,
 		DeleteSourceBranchOnMerge: r.DeleteSourceBranchOnMerge,
 		AllowedRegexpPrefixes:     r.AllowedRegexpPrefixes,
+		MarkdownTemplateDir:       markdownTemplateDir,
 	}
 }
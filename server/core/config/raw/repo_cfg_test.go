@@ -217,6 +217,21 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expErr: "version: only versions 2 and 3 are supported.",
 		},
+		{
+			description: "markdown template dir in the repo",
+			input: raw.RepoCfg{
+				Version:             Int(3),
+				MarkdownTemplateDir: String(".atlantis/templates"),
+			},
+		},
+		{
+			description: "markdown template dir outside the repo",
+			input: raw.RepoCfg{
+				Version:             Int(3),
+				MarkdownTemplateDir: String("../templates"),
+			},
+			expErr: "markdown_template_dir: must be a dir in the repo.",
+		},
 	}
 	validation.ErrorTag = "yaml"
 	for _, c := range cases {
@@ -0,0 +1,64 @@
+package raw
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// variableName matches the names of Terraform variables.
+var variableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+// ResourceTags configures the tags that a project's commands pass to
+// Terraform so that its resources can be traced back to the pull requests
+// that changed them.
+type ResourceTags struct {
+	// Variable is the name of the map(string) variable the tags are set in.
+	// Defaults to valid.DefaultResourceTagsVariable.
+	Variable *string `yaml:"variable,omitempty"`
+	// Include are the tags that are set. Defaults to all of them.
+	Include []string `yaml:"include,omitempty"`
+}
+
+func (r ResourceTags) Validate() error {
+	validVariable := func(value interface{}) error {
+		v := value.(*string)
+		if v != nil && !variableName.MatchString(*v) {
+			return fmt.Errorf("%q is not a valid Terraform variable name", *v)
+		}
+		return nil
+	}
+	validInclude := func(value interface{}) error {
+		supported := make(map[string]bool)
+		for _, tag := range valid.ResourceTagNames {
+			supported[tag] = true
+		}
+		for _, tag := range value.([]string) {
+			if !supported[tag] {
+				return fmt.Errorf("%q is not a supported tag, only %s are", tag, strings.Join(valid.ResourceTagNames, ", "))
+			}
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Variable, validation.By(validVariable)),
+		validation.Field(&r.Include, validation.By(validInclude)),
+	)
+}
+
+func (r ResourceTags) ToValid() *valid.ResourceTags {
+	v := &valid.ResourceTags{
+		Variable: valid.DefaultResourceTagsVariable,
+		Include:  valid.ResourceTagNames,
+	}
+	if r.Variable != nil {
+		v.Variable = *r.Variable
+	}
+	if len(r.Include) > 0 {
+		v.Include = r.Include
+	}
+	return v
+}
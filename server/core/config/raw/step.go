@@ -9,6 +9,7 @@ import (
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
+	yaml "gopkg.in/yaml.v2"
 )
 
 const (
@@ -22,12 +23,29 @@ const (
 	PolicyCheckStepName = "policy_check"
 	ApplyStepName       = "apply"
 	InitStepName        = "init"
+	ValidateStepName    = "validate"
+	GraphStepName       = "graph"
 	EnvStepName         = "env"
 	MultiEnvStepName    = "multienv"
+	VerifyStepName      = "verify"
+	// StepEnvKey is the key that any step can set its own env vars with.
+	StepEnvKey = "env"
+	// StepOutputKey is the key that run steps set what's done with their
+	// output with.
+	StepOutputKey = "output"
+	// CaptureAsKey is the env var that a run step's output is captured as.
+	CaptureAsKey = "capture_as"
+	// StepWhenKey is the key that any step sets the condition it's run on
+	// with.
+	StepWhenKey = "when"
+	// StepOnFailureKey is the key that verify steps set what happens when
+	// they fail with.
+	StepOnFailureKey = "on_failure"
 )
 
 // Step represents a single action/command to perform. In YAML, it can be set as
 // 1. A single string for a built-in command:
+//   - validate
 //   - init
 //   - plan
 //   - policy_check
@@ -45,6 +63,26 @@ const (
 // 4. A map for a custom run command:
 //   - run: my custom command
 //
+// Any step can also set env vars for itself only, alongside its key, ex.
+//   - run: my custom command
+//     env:
+//     TOKEN: secret://deploy-token
+//
+// Run steps can capture their output as an env var for the steps after them:
+//   - run: terraform output -raw vpc_id
+//     output:
+//     capture_as: VPC_ID
+//
+// Any step can also only be run when the plan has changes, has no changes or
+// destroys resources:
+//   - run: infracost breakdown --path $PLANFILE
+//     when: has_changes
+//
+// 5. A map for a verify command, which is run like a run step and can set
+// what happens when it fails:
+//   - verify: ./smoke-test.sh
+//     on_failure: revert
+//
 // Here we parse step in the most generic fashion possible. See fields for more
 // details.
 type Step struct {
@@ -57,6 +95,15 @@ type Step struct {
 	Map map[string]map[string][]string
 	// StringVal will be set in case #4 above.
 	StringVal map[string]string
+	// StepEnv are the env vars set for this step only. Values prefixed with
+	// valid.SecretRefPrefix are resolved when the step runs.
+	StepEnv map[string]string
+	// Output is what's done with a run step's output, ex. capture_as.
+	Output map[string]string
+	// When is the condition the step is run on, ex. has_changes.
+	When string
+	// OnFailure is what happens when a verify step fails, ex. revert.
+	OnFailure string
 }
 
 func (s *Step) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -83,6 +130,8 @@ func (s *Step) MarshalJSON() ([]byte, error) {
 
 func (s Step) validStepName(stepName string) bool {
 	return stepName == InitStepName ||
+		stepName == ValidateStepName ||
+		stepName == GraphStepName ||
 		stepName == PlanStepName ||
 		stepName == ApplyStepName ||
 		stepName == EnvStepName ||
@@ -197,13 +246,56 @@ func (s Step) Validate() error {
 				len(keys), strings.Join(keys, ","))
 		}
 		for stepName := range elem {
-			if stepName != RunStepName && stepName != MultiEnvStepName {
+			if stepName != RunStepName && stepName != MultiEnvStepName && stepName != VerifyStepName {
 				return fmt.Errorf("%q is not a valid step type", stepName)
 			}
 		}
 		return nil
 	}
 
+	for name, value := range s.StepEnv {
+		if name == "" {
+			return errors.New("step env var names can't be empty")
+		}
+		if value == valid.SecretRefPrefix {
+			return fmt.Errorf("step env var %s must name a secret after %q", name, valid.SecretRefPrefix)
+		}
+	}
+	if len(s.StepEnv) > 0 && len(s.Env) > 0 {
+		return errors.New("env steps can't set their own env vars")
+	}
+	switch s.When {
+	case "", valid.WhenHasChanges, valid.WhenNoChanges, valid.WhenDestroyDetected:
+	default:
+		return fmt.Errorf("%q is not a valid step condition, only %q, %q and %q are supported",
+			s.When, valid.WhenHasChanges, valid.WhenNoChanges, valid.WhenDestroyDetected)
+	}
+	if len(s.Output) > 0 {
+		if _, ok := s.StringVal[RunStepName]; !ok {
+			return fmt.Errorf("only run steps support the %q key", StepOutputKey)
+		}
+		for k, v := range s.Output {
+			if k != CaptureAsKey {
+				return fmt.Errorf("run step %s only supports the %q key, found %q", StepOutputKey, CaptureAsKey, k)
+			}
+			if v == "" {
+				return fmt.Errorf("run step %s %q can't be empty", StepOutputKey, CaptureAsKey)
+			}
+		}
+	}
+
+	if s.OnFailure != "" {
+		if _, ok := s.StringVal[VerifyStepName]; !ok {
+			return fmt.Errorf("only verify steps support the %q key", StepOnFailureKey)
+		}
+		switch s.OnFailure {
+		case valid.OnFailureFail, valid.OnFailureWarn, valid.OnFailureRevert:
+		default:
+			return fmt.Errorf("%q is not a valid %s, only %q, %q and %q are supported",
+				s.OnFailure, StepOnFailureKey, valid.OnFailureFail, valid.OnFailureWarn, valid.OnFailureRevert)
+		}
+	}
+
 	if s.Key != nil {
 		return validation.Validate(s.Key, validation.By(validStep))
 	}
@@ -220,6 +312,20 @@ func (s Step) Validate() error {
 }
 
 func (s Step) ToValid() valid.Step {
+	step := s.toValid()
+	step.Env = s.StepEnv
+	step.CaptureAs = s.Output[CaptureAsKey]
+	step.When = s.When
+	if step.StepName == VerifyStepName {
+		step.OnFailure = s.OnFailure
+		if step.OnFailure == "" {
+			step.OnFailure = valid.OnFailureFail
+		}
+	}
+	return step
+}
+
+func (s Step) toValid() valid.Step {
 	// This will trigger in case #1 (see Step docs).
 	if s.Key != nil {
 		return valid.Step{
@@ -290,6 +396,47 @@ func (s *Step) unmarshalGeneric(unmarshal func(interface{}) error) error {
 		return nil
 	}
 
+	// This represents any step with its own env vars or a condition, or a
+	// run step with an output, ex:
+	//   run: my command
+	//   env:
+	//     k: v
+	//   output:
+	//     capture_as: VAR
+	//   when: has_changes
+	// These keys are split out and the rest is unmarshalled as the step.
+	var generic map[string]interface{}
+	if err := unmarshal(&generic); err == nil && len(generic) > 1 {
+		found := false
+		for key, out := range map[string]interface{}{StepOutputKey: &s.Output, StepWhenKey: &s.When, StepOnFailureKey: &s.OnFailure} {
+			if val, ok := generic[key]; ok {
+				found = true
+				delete(generic, key)
+				if err := remarshal(val, out); err != nil {
+					return err
+				}
+			}
+		}
+		// If env is the only key left, this is an env step.
+		if env, ok := generic[StepEnvKey]; ok && len(generic) > 1 {
+			found = true
+			delete(generic, StepEnvKey)
+			if err := remarshal(env, &s.StepEnv); err != nil {
+				return err
+			}
+		}
+		if found {
+			// A built-in step without args, ex. "init:", is a single string.
+			for key, val := range generic {
+				if val == nil && len(generic) == 1 {
+					s.Key = &key
+					return nil
+				}
+			}
+			return remarshal(generic, s)
+		}
+	}
+
 	// This represents a step with extra_args, ex:
 	//   init:
 	//     extra_args: [a, b]
@@ -314,10 +461,10 @@ func (s *Step) unmarshalGeneric(unmarshal func(interface{}) error) error {
 		return nil
 	}
 
-	// Try to unmarshal as a custom run step, ex.
+	// Try to unmarshal as a custom run or verify step, ex.
 	// steps:
 	// - run: my command
-	// We validate if the key is run later.
+	// We validate if the key is run or verify later.
 	var runStep map[string]string
 	err = unmarshal(&runStep)
 	if err == nil {
@@ -328,7 +475,44 @@ func (s *Step) unmarshalGeneric(unmarshal func(interface{}) error) error {
 	return err
 }
 
+// remarshal unmarshals in, which was unmarshalled generically, into out.
+func remarshal(in interface{}, out interface{}) error {
+	data, err := yaml.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return yaml.UnmarshalStrict(data, out)
+}
+
 func (s Step) marshalGeneric() (interface{}, error) {
+	if len(s.StepEnv) != 0 || len(s.Output) != 0 || s.When != "" || s.OnFailure != "" {
+		generic := make(map[string]interface{})
+		if s.When != "" {
+			generic[StepWhenKey] = s.When
+		}
+		if s.OnFailure != "" {
+			generic[StepOnFailureKey] = s.OnFailure
+		}
+		if len(s.StepEnv) != 0 {
+			generic[StepEnvKey] = s.StepEnv
+		}
+		if len(s.Output) != 0 {
+			generic[StepOutputKey] = s.Output
+		}
+		for k, v := range s.StringVal {
+			generic[k] = v
+		}
+		for k, v := range s.Env {
+			generic[k] = v
+		}
+		for k, v := range s.Map {
+			generic[k] = v
+		}
+		if s.Key != nil {
+			generic[*s.Key] = nil
+		}
+		return generic, nil
+	}
 	if len(s.StringVal) != 0 {
 		return s.StringVal, nil
 	} else if len(s.Map) != 0 {
@@ -129,6 +129,111 @@ key: value`,
 			},
 		},
 
+		// Step env
+		{
+			description: "run step with env",
+			input: `
+run: my command
+env:
+  FOO: bar
+  TOKEN: secret://deploy-token`,
+			exp: raw.Step{
+				StringVal: map[string]string{
+					"run": "my command",
+				},
+				StepEnv: map[string]string{
+					"FOO":   "bar",
+					"TOKEN": "secret://deploy-token",
+				},
+			},
+		},
+		{
+			description: "extra_args style with env",
+			input: `
+plan:
+  extra_args: [-var-file=staging.tfvars]
+env:
+  TF_LOG: debug`,
+			exp: raw.Step{
+				Map: MapType{
+					"plan": {
+						"extra_args": {"-var-file=staging.tfvars"},
+					},
+				},
+				StepEnv: map[string]string{
+					"TF_LOG": "debug",
+				},
+			},
+		},
+		{
+			description: "run step with output",
+			input: `
+run: terraform output -raw vpc_id
+output:
+  capture_as: VPC_ID`,
+			exp: raw.Step{
+				StringVal: map[string]string{
+					"run": "terraform output -raw vpc_id",
+				},
+				Output: map[string]string{
+					"capture_as": "VPC_ID",
+				},
+			},
+		},
+		{
+			description: "built-in step with env",
+			input: `
+init:
+env:
+  TF_TOKEN: secret://tfc-token`,
+			exp: raw.Step{
+				Key: String("init"),
+				StepEnv: map[string]string{
+					"TF_TOKEN": "secret://tfc-token",
+				},
+			},
+		},
+
+		{
+			description: "built-in step with condition",
+			input: `
+policy_check:
+when: has_changes`,
+			exp: raw.Step{
+				Key:  String("policy_check"),
+				When: "has_changes",
+			},
+		},
+		{
+			description: "env step with condition",
+			input: `
+env:
+  name: test
+  value: value
+when: destroy_detected`,
+			exp: raw.Step{
+				Env: EnvType{
+					"env": {
+						"name":  "test",
+						"value": "value",
+					},
+				},
+				When: "destroy_detected",
+			},
+		},
+		{
+			description: "verify step with on_failure",
+			input: `
+verify: ./smoke-test.sh
+on_failure: revert`,
+			exp: raw.Step{
+				StringVal: map[string]string{
+					"verify": "./smoke-test.sh",
+				},
+				OnFailure: "revert",
+			},
+		},
+
 		// Empty
 		{
 			description: "empty",
@@ -163,8 +268,11 @@ key:
 			Ok(t, err)
 			Equals(t, c.exp, got)
 
-			_, err = yaml.Marshal(got)
+			marshalled, err := yaml.Marshal(got)
 			Ok(t, err)
+			var remarshalled raw.Step
+			Ok(t, yaml.UnmarshalStrict(marshalled, &remarshalled))
+			Equals(t, got, remarshalled)
 
 			var got2 raw.Step
 			err = yaml.UnmarshalStrict([]byte(c.input), &got2)
@@ -188,6 +296,13 @@ func TestStep_Validate(t *testing.T) {
 			},
 			expErr: "",
 		},
+		{
+			description: "validate step",
+			input: raw.Step{
+				Key: String("validate"),
+			},
+			expErr: "",
+		},
 		{
 			description: "plan step",
 			input: raw.Step{
@@ -195,6 +310,13 @@ func TestStep_Validate(t *testing.T) {
 			},
 			expErr: "",
 		},
+		{
+			description: "graph step",
+			input: raw.Step{
+				Key: String("graph"),
+			},
+			expErr: "",
+		},
 		{
 			description: "apply step",
 			input: raw.Step{
@@ -256,8 +378,95 @@ func TestStep_Validate(t *testing.T) {
 			},
 			expErr: "",
 		},
+		{
+			description: "run step with env",
+			input: raw.Step{
+				StringVal: map[string]string{
+					"run": "my command",
+				},
+				StepEnv: map[string]string{
+					"TOKEN": "secret://deploy-token",
+				},
+			},
+			expErr: "",
+		},
 
 		// Invalid inputs.
+		{
+			description: "invalid condition",
+			input: raw.Step{
+				Key:  String("plan"),
+				When: "always",
+			},
+			expErr: "\"always\" is not a valid step condition, only \"has_changes\", \"no_changes\" and \"destroy_detected\" are supported",
+		},
+		{
+			description: "on_failure on run step",
+			input: raw.Step{
+				StringVal: map[string]string{
+					"run": "my command",
+				},
+				OnFailure: "revert",
+			},
+			expErr: "only verify steps support the \"on_failure\" key",
+		},
+		{
+			description: "invalid on_failure",
+			input: raw.Step{
+				StringVal: map[string]string{
+					"verify": "./smoke-test.sh",
+				},
+				OnFailure: "retry",
+			},
+			expErr: "\"retry\" is not a valid on_failure, only \"fail\", \"warn\" and \"revert\" are supported",
+		},
+		{
+			description: "output on built-in step",
+			input: raw.Step{
+				Key: String("plan"),
+				Output: map[string]string{
+					"capture_as": "PLAN",
+				},
+			},
+			expErr: "only run steps support the \"output\" key",
+		},
+		{
+			description: "unsupported output key",
+			input: raw.Step{
+				StringVal: map[string]string{
+					"run": "my command",
+				},
+				Output: map[string]string{
+					"capture": "VAR",
+				},
+			},
+			expErr: "run step output only supports the \"capture_as\" key, found \"capture\"",
+		},
+		{
+			description: "step env secret without name",
+			input: raw.Step{
+				Key: String("plan"),
+				StepEnv: map[string]string{
+					"TOKEN": "secret://",
+				},
+			},
+			expErr: "step env var TOKEN must name a secret after \"secret://\"",
+		},
+		{
+			description: "env step with env",
+			input: raw.Step{
+				Env: EnvType{
+					"env": {
+						"name":  "test",
+						"value": "value",
+					},
+				},
+				StepEnv: map[string]string{
+					"FOO": "bar",
+				},
+			},
+			expErr: "env steps can't set their own env vars",
+		},
 		{
 			description: "empty elem",
 			input:       raw.Step{},
@@ -433,6 +642,24 @@ func TestStep_ToValid(t *testing.T) {
 				StepName: "plan",
 			},
 		},
+		{
+			description: "validate step",
+			input: raw.Step{
+				Key: String("validate"),
+			},
+			exp: valid.Step{
+				StepName: "validate",
+			},
+		},
+		{
+			description: "graph step",
+			input: raw.Step{
+				Key: String("graph"),
+			},
+			exp: valid.Step{
+				StepName: "graph",
+			},
+		},
 		{
 			description: "policy_check step",
 			input: raw.Step{
@@ -535,6 +762,66 @@ func TestStep_ToValid(t *testing.T) {
 				RunCommand: "my 'run command'",
 			},
 		},
+		{
+			description: "run step with env",
+			input: raw.Step{
+				StringVal: map[string]string{
+					"run": "my command",
+				},
+				StepEnv: map[string]string{
+					"TOKEN": "secret://deploy-token",
+				},
+			},
+			exp: valid.Step{
+				StepName:   "run",
+				RunCommand: "my command",
+				Env: map[string]string{
+					"TOKEN": "secret://deploy-token",
+				},
+			},
+		},
+		{
+			description: "run step with output",
+			input: raw.Step{
+				StringVal: map[string]string{
+					"run": "my command",
+				},
+				Output: map[string]string{
+					"capture_as": "VAR",
+				},
+			},
+			exp: valid.Step{
+				StepName:   "run",
+				RunCommand: "my command",
+				CaptureAs:  "VAR",
+			},
+		}, {
+			description: "verify step",
+			input: raw.Step{
+				StringVal: map[string]string{
+					"verify": "./smoke-test.sh",
+				},
+			},
+			exp: valid.Step{
+				StepName:   "verify",
+				RunCommand: "./smoke-test.sh",
+				OnFailure:  "fail",
+			},
+		},
+		{
+			description: "verify step with on_failure",
+			input: raw.Step{
+				StringVal: map[string]string{
+					"verify": "./smoke-test.sh",
+				},
+				OnFailure: "revert",
+			},
+			exp: valid.Step{
+				StepName:   "verify",
+				RunCommand: "./smoke-test.sh",
+				OnFailure:  "revert",
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
@@ -0,0 +1,130 @@
+package raw
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+var (
+	// tenantNameRegex matches names that are safe to use in paths since each
+	// tenant's repos are cloned into a dir named after it.
+	tenantNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	metricsTagRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Tenant is the raw schema for tenants in the server-side repo config.
+type Tenant struct {
+	Name           string            `yaml:"name" json:"name"`
+	Repos          []string          `yaml:"repos" json:"repos"`
+	Workflow       *string           `yaml:"workflow,omitempty" json:"workflow,omitempty"`
+	DataDirQuotaMB int               `yaml:"data_dir_quota_mb,omitempty" json:"data_dir_quota_mb,omitempty"`
+	VCSUser        string            `yaml:"vcs_user,omitempty" json:"vcs_user,omitempty"`
+	VCSTokenFile   string            `yaml:"vcs_token_file,omitempty" json:"vcs_token_file,omitempty"`
+	DeployKeyFile  string            `yaml:"deploy_key_file,omitempty" json:"deploy_key_file,omitempty"`
+	MetricsTags    map[string]string `yaml:"metrics_tags,omitempty" json:"metrics_tags,omitempty"`
+}
+
+func (t Tenant) Validate() error {
+	reposValid := func(value interface{}) error {
+		for _, id := range value.([]string) {
+			if id == "" {
+				return errors.New("ids cannot be empty")
+			}
+			if isRegexID(id) {
+				if _, err := regexp.Compile(id[1 : len(id)-1]); err != nil {
+					return fmt.Errorf("parsing: %s: %s", id, err)
+				}
+			}
+		}
+		return nil
+	}
+	credentialsValid := func(value interface{}) error {
+		if (t.VCSUser == "") != (t.VCSTokenFile == "") {
+			return errors.New("vcs_user and vcs_token_file must be set together")
+		}
+		return nil
+	}
+	metricsTagsValid := func(value interface{}) error {
+		for key := range value.(map[string]string) {
+			if key == valid.TenantMetricsTag {
+				return fmt.Errorf("%q is set to the tenant's name", key)
+			}
+			if !metricsTagRegex.MatchString(key) {
+				return fmt.Errorf("%q must only contain letters, numbers and underscores", key)
+			}
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.Name, validation.Required, validation.Match(tenantNameRegex).Error("must only contain letters, numbers, hyphens and underscores")),
+		validation.Field(&t.Repos, validation.Required, validation.By(reposValid)),
+		validation.Field(&t.DataDirQuotaMB, validation.Min(0)),
+		validation.Field(&t.VCSTokenFile, validation.By(credentialsValid)),
+		validation.Field(&t.MetricsTags, validation.By(metricsTagsValid)),
+	)
+}
+
+func (t Tenant) ToValid(workflows map[string]valid.Workflow) valid.Tenant {
+	v := valid.Tenant{
+		Name:           t.Name,
+		DataDirQuotaMB: t.DataDirQuotaMB,
+		VCSUser:        t.VCSUser,
+		VCSTokenFile:   t.VCSTokenFile,
+		DeployKeyFile:  t.DeployKeyFile,
+		MetricsTags:    t.MetricsTags,
+	}
+	for _, id := range t.Repos {
+		if isRegexID(id) {
+			// The regex compiles because we test it in Validate.
+			v.RepoIDRegexes = append(v.RepoIDRegexes, regexp.MustCompile(id[1:len(id)-1]))
+		} else {
+			v.RepoIDs = append(v.RepoIDs, id)
+		}
+	}
+	if t.Workflow != nil {
+		// The workflow exists because we test it in GlobalCfg.Validate.
+		workflow := workflows[*t.Workflow]
+		v.Workflow = &workflow
+	}
+	return v
+}
+
+// validateTenants checks that tenants' names are unique, their workflows are
+// defined and that they set the same metrics tags since metrics with
+// different tags can't be reported together.
+func validateTenants(tenants []Tenant, workflowDefined func(name string) bool) error {
+	names := make(map[string]bool)
+	var tagKeys string
+	for i, t := range tenants {
+		if names[t.Name] {
+			return fmt.Errorf("tenant %q is defined more than once", t.Name)
+		}
+		names[t.Name] = true
+		if t.Workflow != nil && *t.Workflow != valid.DefaultWorkflowName && !workflowDefined(*t.Workflow) {
+			return fmt.Errorf("workflow %q of tenant %q is not defined", *t.Workflow, t.Name)
+		}
+		var keys []string
+		for k := range t.MetricsTags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		if i == 0 {
+			tagKeys = strings.Join(keys, ",")
+		} else if strings.Join(keys, ",") != tagKeys {
+			return fmt.Errorf("tenant %q must set the same metrics_tags as tenant %q", t.Name, tenants[0].Name)
+		}
+	}
+	return nil
+}
+
+// isRegexID returns true if id is a regex wrapped in slashes rather than an
+// exact repo ID.
+func isRegexID(id string) bool {
+	return len(id) > 1 && strings.HasPrefix(id, "/") && strings.HasSuffix(id, "/")
+}
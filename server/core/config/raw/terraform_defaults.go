@@ -0,0 +1,74 @@
+package raw
+
+import (
+	"fmt"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// TerraformDefaults is the raw schema for the extra args, env vars and
+// variables that the Terraform commands of a repo's projects get by default.
+type TerraformDefaults struct {
+	Dir       string              `yaml:"dir,omitempty" json:"dir,omitempty"`
+	ExtraArgs map[string][]string `yaml:"extra_args,omitempty" json:"extra_args,omitempty"`
+	Env       map[string]string   `yaml:"env,omitempty" json:"env,omitempty"`
+	Vars      map[string]string   `yaml:"vars,omitempty" json:"vars,omitempty"`
+}
+
+func (t TerraformDefaults) Validate() error {
+	extraArgsValid := func(value interface{}) error {
+	OUTER:
+		for step := range value.(map[string][]string) {
+			for _, s := range valid.TerraformDefaultsSteps {
+				if step == s {
+					continue OUTER
+				}
+			}
+			return fmt.Errorf("%q is not a valid step, only %s are supported", step, strings.Join(valid.TerraformDefaultsSteps, ", "))
+		}
+		return nil
+	}
+	envValid := func(value interface{}) error {
+		for name := range value.(map[string]string) {
+			if name == "" || strings.Contains(name, "=") {
+				return fmt.Errorf("%q is not a valid env var name", name)
+			}
+		}
+		return nil
+	}
+	varsValid := func(value interface{}) error {
+		for name := range value.(map[string]string) {
+			if !variableName.MatchString(name) {
+				return fmt.Errorf("%q is not a valid Terraform variable name", name)
+			}
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.ExtraArgs, validation.By(extraArgsValid)),
+		validation.Field(&t.Env, validation.By(envValid)),
+		validation.Field(&t.Vars, validation.By(varsValid)),
+	)
+}
+
+func (t TerraformDefaults) ToValid() valid.TerraformDefaults {
+	return valid.TerraformDefaults{
+		Dir:       strings.TrimSuffix(strings.TrimPrefix(t.Dir, "./"), "/"),
+		ExtraArgs: t.ExtraArgs,
+		Env:       t.Env,
+		Vars:      t.Vars,
+	}
+}
+
+func terraformDefaultsToValid(defaults []TerraformDefaults) []valid.TerraformDefaults {
+	if defaults == nil {
+		return nil
+	}
+	v := []valid.TerraformDefaults{}
+	for _, d := range defaults {
+		v = append(v, d.ToValid())
+	}
+	return v
+}
@@ -0,0 +1,179 @@
+package valid
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ApplyWindow is a window of time in which applies are allowed. Its schedule
+// is a standard five field cron expression (minute, hour, day of month, month,
+// day of week) and every minute it matches is inside the window, ex.
+// "* 9-16 * * 1-5" allows applies from 9:00 to 16:59 on weekdays.
+type ApplyWindow struct {
+	// Schedule is the cron expression the window was parsed from.
+	Schedule string
+	// Location is the time zone the schedule is evaluated in.
+	Location *time.Location
+
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+	// domStar and dowStar are true if the day of month or day of week field
+	// was *. Like cron, if both are restricted a day matches if either does.
+	domStar bool
+	dowStar bool
+}
+
+// cronField describes the allowed values of one cron field.
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// NewApplyWindow parses schedule in the time zone timezone. If timezone is
+// empty the schedule is evaluated in UTC.
+func NewApplyWindow(schedule string, timezone string) (ApplyWindow, error) {
+	loc := time.UTC
+	if timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return ApplyWindow{}, fmt.Errorf("invalid timezone %q: %s", timezone, err)
+		}
+	}
+
+	fields := strings.Fields(schedule)
+	if len(fields) != len(cronFields) {
+		return ApplyWindow{}, fmt.Errorf("invalid schedule %q: expected %d fields but got %d", schedule, len(cronFields), len(fields))
+	}
+	var bits [5]uint64
+	for i, f := range fields {
+		b, err := parseCronField(f, cronFields[i])
+		if err != nil {
+			return ApplyWindow{}, fmt.Errorf("invalid schedule %q: %s", schedule, err)
+		}
+		bits[i] = b
+	}
+	// Sunday can be written as 0 or 7.
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return ApplyWindow{
+		Schedule:    schedule,
+		Location:    loc,
+		minutes:     bits[0],
+		hours:       bits[1],
+		daysOfMonth: bits[2],
+		months:      bits[3],
+		daysOfWeek:  bits[4],
+		domStar:     strings.HasPrefix(fields[2], "*"),
+		dowStar:     strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// parseCronField parses a comma separated list of *, n, n-m, */s and n-m/s
+// into a bitset of the values it matches.
+func parseCronField(s string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		rng := part
+		step := 1
+		hasStep := false
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rng, hasStep = part[:i], true
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", part[i+1:], f.name)
+			}
+		}
+
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			lo, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q in %s field", bounds[0], f.name)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				hi, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return 0, fmt.Errorf("invalid value %q in %s field", bounds[1], f.name)
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+		}
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("%s field %q must be between %d and %d", f.name, part, f.min, f.max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Contains returns true if t is inside the window.
+func (w ApplyWindow) Contains(t time.Time) bool {
+	t = t.In(w.Location)
+	return w.minutes&(1<<uint(t.Minute())) != 0 &&
+		w.hours&(1<<uint(t.Hour())) != 0 &&
+		w.months&(1<<uint(t.Month())) != 0 &&
+		w.dayMatches(t)
+}
+
+func (w ApplyWindow) dayMatches(t time.Time) bool {
+	dom := w.daysOfMonth&(1<<uint(t.Day())) != 0
+	dow := w.daysOfWeek&(1<<uint(t.Weekday())) != 0
+	if w.domStar || w.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// maxApplyWindowSearch is how far ahead NextApplyWindow looks for an open
+// window.
+const maxApplyWindowSearch = 366 * 24 * time.Hour
+
+// InApplyWindows returns true if t is inside any of windows. If there are no
+// windows, applies are always allowed.
+func InApplyWindows(windows []ApplyWindow, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	for _, w := range windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextApplyWindow returns the start of the next minute after t that is inside
+// one of windows. It returns false if none opens within the next year.
+func NextApplyWindow(windows []ApplyWindow, t time.Time) (time.Time, bool) {
+	end := t.Add(maxApplyWindowSearch)
+	for next := t.Truncate(time.Minute).Add(time.Minute); next.Before(end); next = next.Add(time.Minute) {
+		if InApplyWindows(windows, next) {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
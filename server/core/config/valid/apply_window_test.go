@@ -0,0 +1,130 @@
+package valid_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestNewApplyWindow_Errors(t *testing.T) {
+	cases := map[string]struct {
+		schedule string
+		timezone string
+		expErr   string
+	}{
+		"too few fields": {
+			schedule: "* * * *",
+			expErr:   `invalid schedule "* * * *": expected 5 fields but got 4`,
+		},
+		"out of range": {
+			schedule: "* 24 * * *",
+			expErr:   `invalid schedule "* 24 * * *": hour field "24" must be between 0 and 23`,
+		},
+		"bad value": {
+			schedule: "* * * * mon",
+			expErr:   `invalid schedule "* * * * mon": invalid value "mon" in day of week field`,
+		},
+		"bad step": {
+			schedule: "*/0 * * * *",
+			expErr:   `invalid schedule "*/0 * * * *": invalid step "0" in minute field`,
+		},
+		"bad timezone": {
+			schedule: "* * * * *",
+			timezone: "Nowhere/Special",
+			expErr:   `invalid timezone "Nowhere/Special": unknown time zone Nowhere/Special`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := valid.NewApplyWindow(c.schedule, c.timezone)
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
+func TestApplyWindow_Contains(t *testing.T) {
+	// 2022-08-01 is a Monday.
+	monday := func(hour, min int) time.Time {
+		return time.Date(2022, 8, 1, hour, min, 0, 0, time.UTC)
+	}
+	cases := map[string]struct {
+		schedule string
+		timezone string
+		t        time.Time
+		exp      bool
+	}{
+		"weekday business hours": {
+			schedule: "* 9-16 * * 1-5",
+			t:        monday(12, 30),
+			exp:      true,
+		},
+		"after business hours": {
+			schedule: "* 9-16 * * 1-5",
+			t:        monday(17, 0),
+			exp:      false,
+		},
+		"weekend": {
+			schedule: "* 9-16 * * 1-5",
+			t:        monday(12, 0).AddDate(0, 0, 5),
+			exp:      false,
+		},
+		"sunday as 7": {
+			schedule: "* * * * 7",
+			t:        monday(12, 0).AddDate(0, 0, 6),
+			exp:      true,
+		},
+		"steps and lists": {
+			schedule: "0-29/10,45 * * * *",
+			t:        monday(12, 20),
+			exp:      true,
+		},
+		"steps and lists miss": {
+			schedule: "0-29/10,45 * * * *",
+			t:        monday(12, 25),
+			exp:      false,
+		},
+		"day of month or day of week": {
+			schedule: "* * 15 * 1",
+			t:        monday(12, 0),
+			exp:      true,
+		},
+		"timezone": {
+			schedule: "* 9-16 * * *",
+			timezone: "America/New_York",
+			// 9:00 in New York during daylight saving time.
+			t:   monday(13, 0),
+			exp: true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w, err := valid.NewApplyWindow(c.schedule, c.timezone)
+			Ok(t, err)
+			Equals(t, c.exp, w.Contains(c.t))
+		})
+	}
+}
+
+func TestNextApplyWindow(t *testing.T) {
+	w, err := valid.NewApplyWindow("* 9-16 * * 1-5", "")
+	Ok(t, err)
+	windows := []valid.ApplyWindow{w}
+
+	// Friday evening opens on Monday morning.
+	friday := time.Date(2022, 8, 5, 18, 42, 10, 0, time.UTC)
+	Equals(t, false, valid.InApplyWindows(windows, friday))
+	next, ok := valid.NextApplyWindow(windows, friday)
+	Equals(t, true, ok)
+	Equals(t, time.Date(2022, 8, 8, 9, 0, 0, 0, time.UTC), next)
+
+	// No windows means applies are always allowed.
+	Equals(t, true, valid.InApplyWindows(nil, friday))
+
+	// February 30th never happens.
+	never, err := valid.NewApplyWindow("* * 30 2 *", "")
+	Ok(t, err)
+	_, ok = valid.NextApplyWindow([]valid.ApplyWindow{never}, friday)
+	Equals(t, false, ok)
+}
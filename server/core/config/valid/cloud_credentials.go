@@ -0,0 +1,42 @@
+package valid
+
+// DefaultAWSAudience is the audience of the tokens exchanged for AWS
+// credentials if none is set.
+const DefaultAWSAudience = "sts.amazonaws.com"
+
+// CloudCredentials configures the cloud credentials that projects get by
+// exchanging tokens issued by Atlantis through OpenID Connect federation.
+type CloudCredentials struct {
+	// AWS is nil if no AWS credentials are configured.
+	AWS *AWSCredentials
+	// GCP is nil if no GCP credentials are configured.
+	GCP *GCPCredentials
+	// Azure is nil if no Azure credentials are configured.
+	Azure *AzureCredentials
+}
+
+// AWSCredentials are credentials for an IAM role that trusts Atlantis as a
+// web identity provider.
+type AWSCredentials struct {
+	RoleARN  string
+	Audience string
+}
+
+// GCPCredentials are credentials from a workload identity pool provider that
+// trusts Atlantis.
+type GCPCredentials struct {
+	// WorkloadIdentityProvider is the provider's resource name, ex.
+	// "projects/123/locations/global/workloadIdentityPools/pool/providers/atlantis".
+	WorkloadIdentityProvider string
+	// ServiceAccount is the email of the service account to impersonate. If
+	// empty, the federated identity is used directly.
+	ServiceAccount string
+}
+
+// AzureCredentials are credentials for an Azure AD application with a
+// federated credential that trusts Atlantis.
+type AzureCredentials struct {
+	ClientID       string
+	TenantID       string
+	SubscriptionID string
+}
@@ -0,0 +1,27 @@
+package valid
+
+// DestroyProtection configures which resources the no_destroys apply
+// requirement protects and who can approve destroying them.
+type DestroyProtection struct {
+	// Addresses are patterns of the protected resource addresses, where *
+	// matches any characters, ex. "*aws_db_instance.*". If empty, every
+	// resource is protected.
+	Addresses []string
+	// ApproverTeams are the VCS teams whose members can approve destroying
+	// protected resources with apply --destroy-approved.
+	ApproverTeams []string
+}
+
+// Protects returns true if the resource at address is protected. d can be nil,
+// in which case every resource is protected.
+func (d *DestroyProtection) Protects(address string) bool {
+	if d == nil || len(d.Addresses) == 0 {
+		return true
+	}
+	for _, pattern := range d.Addresses {
+		if globMatch(pattern, address) {
+			return true
+		}
+	}
+	return false
+}
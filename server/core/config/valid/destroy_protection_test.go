@@ -0,0 +1,20 @@
+package valid_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestDestroyProtection_Protects(t *testing.T) {
+	var unset *valid.DestroyProtection
+	Assert(t, unset.Protects("aws_instance.web"), "exp nil protection to protect everything")
+	Assert(t, (&valid.DestroyProtection{}).Protects("aws_instance.web"), "exp no addresses to protect everything")
+
+	d := &valid.DestroyProtection{Addresses: []string{"*aws_db_instance.*", "module.vpc.*"}}
+	Assert(t, d.Protects("aws_db_instance.main"), "exp db instance to be protected")
+	Assert(t, d.Protects("module.app.aws_db_instance.main"), "exp db instance in module to be protected")
+	Assert(t, d.Protects("module.vpc.aws_subnet.private[0]"), "exp module resource to be protected")
+	Assert(t, !d.Protects("aws_instance.web"), "exp other resource not to be protected")
+}
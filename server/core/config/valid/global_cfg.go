@@ -13,6 +13,8 @@ const MergeableApplyReq = "mergeable"
 const ApprovedApplyReq = "approved"
 const UnDivergedApplyReq = "undiverged"
 const PoliciesPassedApplyReq = "policies_passed"
+const NoDestroysApplyReq = "no_destroys"
+const ChangeTicketApprovedApplyReq = "change_ticket_approved"
 const ApplyRequirementsKey = "apply_requirements"
 const PreWorkflowHooksKey = "pre_workflow_hooks"
 const WorkflowKey = "workflow"
@@ -22,6 +24,21 @@ const AllowedOverridesKey = "allowed_overrides"
 const AllowCustomWorkflowsKey = "allow_custom_workflows"
 const DefaultWorkflowName = "default"
 const DeleteSourceBranchOnMergeKey = "delete_source_branch_on_merge"
+const SupersededCommentsKey = "superseded_comments"
+const ApplyWindowsKey = "apply_windows"
+const MarkdownTemplateDirKey = "markdown_template_dir"
+
+// HideSupersededComments, DeleteSupersededComments and KeepSupersededComments
+// are the policies for what to do with Atlantis comments once a newer comment
+// for the same command and project supersedes them.
+const HideSupersededComments = "hide"
+const DeleteSupersededComments = "delete"
+const KeepSupersededComments = "keep"
+
+// CommentCommands are the commands that can be run by commenting on pull
+// requests, as they're written in comments, and so can be in a repo's
+// allowed_commands.
+var CommentCommands = []string{"plan", "apply", "unlock", "approve_policies", "approve_resources", "version", "force_unlock_state", "init_config", "fmt", "providers", "cancel", "revert"}
 
 // NonOverrideableApplyReqs will get applied across all "repos" in the server side config.
 // If repo config is allowed overrides, they can override this.
@@ -36,16 +53,41 @@ type GlobalCfg struct {
 	Workflows  map[string]Workflow
 	PolicySets PolicySets
 	Metrics    Metrics
+	// ApplyConcurrencyGroups maps the name of an apply concurrency group to
+	// the maximum number of applies that can run at once in that group.
+	ApplyConcurrencyGroups map[string]int
+	// ProjectTemplates maps the name of a project template to its settings.
+	ProjectTemplates map[string]ProjectTemplate
+	// Tenants are the tenants that repos are isolated into. Empty if repos
+	// aren't isolated.
+	Tenants []Tenant
 }
 
 type Metrics struct {
 	Statsd     *Statsd
 	Prometheus *Prometheus
+	CloudWatch *CloudWatch
 }
 
+// DogStatsdTagFormat sends statsd metrics with DogStatsD tags.
+const DogStatsdTagFormat = "dogstatsd"
+
 type Statsd struct {
 	Port string
 	Host string
+	// TagFormat is DogStatsdTagFormat to send the metrics' tags and Tags,
+	// otherwise tags aren't sent.
+	TagFormat string
+	Tags      map[string]string
+}
+
+// CloudWatch configures metrics in CloudWatch's embedded metric format.
+type CloudWatch struct {
+	Namespace string
+	// Endpoint is the tcp:// or udp:// address of the CloudWatch agent that
+	// metrics are sent to. If empty, they're written to stdout, ex. for the
+	// awslogs log driver to send to CloudWatch Logs.
+	Endpoint string
 }
 
 type Prometheus struct {
@@ -69,6 +111,63 @@ type Repo struct {
 	AllowedOverrides          []string
 	AllowCustomWorkflows      *bool
 	DeleteSourceBranchOnMerge *bool
+	// SupersededComments is one of HideSupersededComments,
+	// DeleteSupersededComments or KeepSupersededComments. Nil if not set.
+	SupersededComments *string
+	// MergeableIgnoredChecks are the names of the commit statuses and checks
+	// that are ignored when deciding if a pull request is mergeable. Names
+	// ending in * match any check that starts with the rest of the name.
+	MergeableIgnoredChecks []string
+	// ApplyWindows are the windows of time in which applies are allowed. If
+	// empty, applies are always allowed.
+	ApplyWindows []ApplyWindow
+	// ApplyWindowTeams are the VCS teams whose members can apply outside of
+	// ApplyWindows.
+	ApplyWindowTeams []string
+	// MaxConcurrentApplies is the maximum number of applies that can run at
+	// once for this repo. Nil if not set.
+	MaxConcurrentApplies *int
+	// MaxResourceChanges is the maximum number of resources that an apply
+	// can change without being approved by a member of
+	// MaxResourceChangesTeams. Nil if not set.
+	MaxResourceChanges *int
+	// MaxResourceChangesTeams are the VCS teams whose members can approve
+	// applies that change more than MaxResourceChanges resources.
+	MaxResourceChangesTeams []string
+	// AllowedProviders are the providers that this repo's projects can use.
+	// Nil if not set.
+	AllowedProviders []AllowedProvider
+	// RequirePinnedProviders is true if this repo's projects must have a
+	// version constraint for every provider. Nil if not set.
+	RequirePinnedProviders *bool
+	// AllowedModuleSources are patterns of the module sources that this
+	// repo's projects can use. Nil if not set.
+	AllowedModuleSources []string
+	// CloudCredentials are the cloud credentials this repo's projects get.
+	// Nil if not set.
+	CloudCredentials *CloudCredentials
+	// DeployKeyFile is the path to the SSH key that this repo is cloned with.
+	// Empty if not set.
+	DeployKeyFile string
+	// AgentPool is the pool of agents that runs the Terraform commands of
+	// this repo's projects. Empty if not set, in which case they run on the
+	// server.
+	AgentPool string
+	// DestroyProtection configures the no_destroys apply requirement. Nil if
+	// not set.
+	DestroyProtection *DestroyProtection
+	// ProtectedResources configures which resource changes must be approved
+	// with approve_resources. Nil if not set.
+	ProtectedResources *ProtectedResources
+	// TerraformDefaults are the default extra args and env vars of the
+	// Terraform commands of this repo's projects.
+	TerraformDefaults []TerraformDefaults
+	// ProviderTargets restrict what the provider configs of this repo's
+	// projects can target.
+	ProviderTargets []ProviderTarget
+	// AllowedCommands are the comment commands that can be run on this
+	// repo's pull requests, ex. plan. Nil if not set.
+	AllowedCommands []string
 }
 
 type MergedProjectCfg struct {
@@ -85,6 +184,38 @@ type MergedProjectCfg struct {
 	PolicySets                PolicySets
 	DeleteSourceBranchOnMerge bool
 	ExecutionOrderGroup       int
+	ApplyWindows              []ApplyWindow
+	ApplyWindowTeams          []string
+	MaxConcurrentApplies      int
+	MaxResourceChanges        int
+	MaxResourceChangesTeams   []string
+	ApplyConcurrencyGroup     string
+	ApplyConcurrencyLimit     int
+	PromoteTo                 string
+	PromoteToDir              string
+	DependsOn                 []ProjectDependency
+	ProviderPolicy            ProviderPolicy
+	AllowedModuleSources      []string
+	CloudCredentials          *CloudCredentials
+	DestroyProtection         *DestroyProtection
+	ProtectedResources        *ProtectedResources
+	// TerraformExtraArgs maps a step in TerraformDefaultsSteps to the extra
+	// args it's run with before its own.
+	TerraformExtraArgs map[string][]string
+	// TerraformEnv are the env vars that every step is run with.
+	TerraformEnv map[string]string
+	// EphemeralWorkspace is true if Workspace belongs to the pull request and
+	// is deleted when it's closed.
+	EphemeralWorkspace bool
+	// Preview configures the preview environment in Workspace. Nil if it
+	// isn't one.
+	Preview *Preview
+	// ResourceTags configures the tags that the project's commands pass to
+	// Terraform. Nil if they don't pass any.
+	ResourceTags *ResourceTags
+	// ProviderTargets restrict what the project's provider configs can
+	// target.
+	ProviderTargets []ProviderTarget
 }
 
 // WorkflowHook is a map of custom run commands to run before or after workflows.
@@ -159,8 +290,11 @@ type GlobalCfgArgs struct {
 	ApprovedReq        bool
 	UnDivergedReq      bool
 	PolicyCheckEnabled bool
-	PreWorkflowHooks   []*WorkflowHook
-	PostWorkflowHooks  []*WorkflowHook
+	// ValidateEnabled adds a validate step before init to the plan stage of
+	// the default workflow.
+	ValidateEnabled   bool
+	PreWorkflowHooks  []*WorkflowHook
+	PostWorkflowHooks []*WorkflowHook
 }
 
 func NewGlobalCfgFromArgs(args GlobalCfgArgs) GlobalCfg {
@@ -170,6 +304,11 @@ func NewGlobalCfgFromArgs(args GlobalCfgArgs) GlobalCfg {
 		Plan:        DefaultPlanStage,
 		PolicyCheck: DefaultPolicyCheckStage,
 	}
+	if args.ValidateEnabled {
+		defaultWorkflow.Plan = Stage{
+			Steps: append([]Step{{StepName: "validate"}}, DefaultPlanStage.Steps...),
+		}
+	}
 	// Must construct slices here instead of using a `var` declaration because
 	// we treat nil slices differently.
 	applyReqs := []string{}
@@ -246,6 +385,24 @@ func (r Repo) IDString() string {
 func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, proj Project, rCfg RepoCfg) MergedProjectCfg {
 	log.Debug("MergeProjectCfg started")
 	applyReqs, workflow, allowedOverrides, allowCustomWorkflows, deleteSourceBranchOnMerge := g.getMatchingCfg(log, repoID)
+	applyWindows, applyWindowTeams := g.applyWindows(repoID)
+	maxResourceChanges, maxResourceChangesTeams := g.maxResourceChanges(repoID)
+	terraformVersion := proj.TerraformVersion
+
+	// Project templates are defined by the server so they're applied even if
+	// the repo isn't allowed to override their keys.
+	if template, ok := g.ProjectTemplates[proj.Template]; ok && proj.Template != "" {
+		log.Debug("applying project template %q", proj.Template)
+		if template.ApplyRequirements != nil {
+			applyReqs = template.ApplyRequirements
+		}
+		if template.Workflow != nil {
+			workflow = *template.Workflow
+		}
+		if terraformVersion == nil {
+			terraformVersion = template.TerraformVersion
+		}
+	}
 
 	// If repos are allowed to override certain keys then override them.
 	for _, key := range allowedOverrides {
@@ -291,6 +448,11 @@ func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, pro
 				deleteSourceBranchOnMerge = *proj.DeleteSourceBranchOnMerge
 			}
 			log.Debug("merged deleteSourceBranchOnMerge: [%t]", deleteSourceBranchOnMerge)
+		case ApplyWindowsKey:
+			if proj.ApplyWindows != nil {
+				log.Debug("overriding server-defined %s with repo settings", ApplyWindowsKey)
+				applyWindows = proj.ApplyWindows
+			}
 		}
 		log.Debug("MergeProjectCfg completed")
 	}
@@ -298,6 +460,14 @@ func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, pro
 	log.Debug("final settings: %s: [%s], %s: %s",
 		ApplyRequirementsKey, strings.Join(applyReqs, ","), WorkflowKey, workflow.Name)
 
+	var promoteToDir string
+	if proj.PromoteTo != "" {
+		if target := rCfg.FindProjectByName(proj.PromoteTo); target != nil {
+			promoteToDir = target.Dir
+		}
+	}
+
+	terraformExtraArgs, terraformEnv := g.terraformDefaults(repoID, proj.Dir)
 	return MergedProjectCfg{
 		ApplyRequirements:         applyReqs,
 		Workflow:                  workflow,
@@ -305,11 +475,32 @@ func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, pro
 		Workspace:                 proj.Workspace,
 		Name:                      proj.GetName(),
 		AutoplanEnabled:           proj.Autoplan.Enabled,
-		TerraformVersion:          proj.TerraformVersion,
+		TerraformVersion:          terraformVersion,
 		RepoCfgVersion:            rCfg.Version,
 		PolicySets:                g.PolicySets,
 		DeleteSourceBranchOnMerge: deleteSourceBranchOnMerge,
 		ExecutionOrderGroup:       proj.ExecutionOrderGroup,
+		ApplyWindows:              applyWindows,
+		ApplyWindowTeams:          applyWindowTeams,
+		MaxConcurrentApplies:      g.maxConcurrentApplies(repoID),
+		MaxResourceChanges:        maxResourceChanges,
+		MaxResourceChangesTeams:   maxResourceChangesTeams,
+		ApplyConcurrencyGroup:     proj.ApplyConcurrencyGroup,
+		ApplyConcurrencyLimit:     g.ApplyConcurrencyGroups[proj.ApplyConcurrencyGroup],
+		PromoteTo:                 proj.PromoteTo,
+		PromoteToDir:              promoteToDir,
+		DependsOn:                 proj.DependsOn,
+		ProviderPolicy:            g.providerPolicy(repoID),
+		AllowedModuleSources:      g.allowedModuleSources(repoID),
+		CloudCredentials:          g.cloudCredentials(repoID),
+		DestroyProtection:         g.destroyProtection(repoID),
+		ProtectedResources:        g.ProtectedResources(repoID),
+		TerraformExtraArgs:        terraformExtraArgs,
+		TerraformEnv:              terraformEnv,
+		EphemeralWorkspace:        proj.WorkspaceFrom != "",
+		Preview:                   proj.Preview,
+		ResourceTags:              proj.ResourceTags,
+		ProviderTargets:           g.providerTargets(repoID, proj.Dir),
 	}
 }
 
@@ -318,6 +509,9 @@ func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, pro
 func (g GlobalCfg) DefaultProjCfg(log logging.SimpleLogging, repoID string, repoRelDir string, workspace string) MergedProjectCfg {
 	log.Debug("building config based on server-side config")
 	applyReqs, workflow, _, _, deleteSourceBranchOnMerge := g.getMatchingCfg(log, repoID)
+	applyWindows, applyWindowTeams := g.applyWindows(repoID)
+	maxResourceChanges, maxResourceChangesTeams := g.maxResourceChanges(repoID)
+	terraformExtraArgs, terraformEnv := g.terraformDefaults(repoID, repoRelDir)
 	return MergedProjectCfg{
 		ApplyRequirements:         applyReqs,
 		Workflow:                  workflow,
@@ -328,6 +522,19 @@ func (g GlobalCfg) DefaultProjCfg(log logging.SimpleLogging, repoID string, repo
 		TerraformVersion:          nil,
 		PolicySets:                g.PolicySets,
 		DeleteSourceBranchOnMerge: deleteSourceBranchOnMerge,
+		ApplyWindows:              applyWindows,
+		ApplyWindowTeams:          applyWindowTeams,
+		MaxConcurrentApplies:      g.maxConcurrentApplies(repoID),
+		MaxResourceChanges:        maxResourceChanges,
+		MaxResourceChangesTeams:   maxResourceChangesTeams,
+		ProviderPolicy:            g.providerPolicy(repoID),
+		AllowedModuleSources:      g.allowedModuleSources(repoID),
+		CloudCredentials:          g.cloudCredentials(repoID),
+		DestroyProtection:         g.destroyProtection(repoID),
+		ProtectedResources:        g.ProtectedResources(repoID),
+		TerraformExtraArgs:        terraformExtraArgs,
+		TerraformEnv:              terraformEnv,
+		ProviderTargets:           g.providerTargets(repoID, repoRelDir),
 	}
 }
 
@@ -361,6 +568,9 @@ func (g GlobalCfg) ValidateRepoCfg(rCfg RepoCfg, repoID string) error {
 			}
 		}
 	}
+	if rCfg.MarkdownTemplateDir != "" && !sliceContainsF(allowedOverrides, MarkdownTemplateDirKey) {
+		return fmt.Errorf("repo config not allowed to set '%s' key: server-side config needs '%s: [%s]'", MarkdownTemplateDirKey, AllowedOverridesKey, MarkdownTemplateDirKey)
+	}
 	for _, p := range rCfg.Projects {
 		if p.WorkflowName != nil && !sliceContainsF(allowedOverrides, WorkflowKey) {
 			return fmt.Errorf("repo config not allowed to set '%s' key: server-side config needs '%s: [%s]'", WorkflowKey, AllowedOverridesKey, WorkflowKey)
@@ -371,6 +581,19 @@ func (g GlobalCfg) ValidateRepoCfg(rCfg RepoCfg, repoID string) error {
 		if p.DeleteSourceBranchOnMerge != nil && !sliceContainsF(allowedOverrides, DeleteSourceBranchOnMergeKey) {
 			return fmt.Errorf("repo config not allowed to set '%s' key: server-side config needs '%s: [%s]'", DeleteSourceBranchOnMergeKey, AllowedOverridesKey, DeleteSourceBranchOnMergeKey)
 		}
+		if p.ApplyWindows != nil && !sliceContainsF(allowedOverrides, ApplyWindowsKey) {
+			return fmt.Errorf("repo config not allowed to set '%s' key: server-side config needs '%s: [%s]'", ApplyWindowsKey, AllowedOverridesKey, ApplyWindowsKey)
+		}
+		if p.ApplyConcurrencyGroup != "" {
+			if _, ok := g.ApplyConcurrencyGroups[p.ApplyConcurrencyGroup]; !ok {
+				return fmt.Errorf("apply concurrency group %q is not defined by server", p.ApplyConcurrencyGroup)
+			}
+		}
+		if p.Template != "" {
+			if _, ok := g.ProjectTemplates[p.Template]; !ok {
+				return fmt.Errorf("project template %q is not defined by server", p.Template)
+			}
+		}
 	}
 
 	// Check custom workflows.
@@ -451,6 +674,7 @@ func (g GlobalCfg) getMatchingCfg(log logging.SimpleLogging, repoID string) (app
 		return fmt.Sprintf("setting %s: %s from %s", key, valStr, from)
 	}
 
+	tenant := g.Tenant(repoID)
 	for _, key := range []string{ApplyRequirementsKey, WorkflowKey, AllowedOverridesKey, AllowCustomWorkflowsKey, DeleteSourceBranchOnMergeKey} {
 		for i, repo := range g.Repos {
 			if repo.IDMatches(repoID) {
@@ -482,6 +706,12 @@ func (g GlobalCfg) getMatchingCfg(log logging.SimpleLogging, repoID string) (app
 					}
 				}
 			}
+			// The tenant's workflow takes precedence over the default server
+			// config but not over the repos config.
+			if key == WorkflowKey && i == 0 && tenant != nil && tenant.Workflow != nil {
+				toLog[WorkflowKey] = fmt.Sprintf("setting %s: %q from tenant %s", WorkflowKey, tenant.Workflow.Name, tenant.Name)
+				workflow = *tenant.Workflow
+			}
 		}
 	}
 	for _, l := range toLog {
@@ -490,6 +720,277 @@ func (g GlobalCfg) getMatchingCfg(log logging.SimpleLogging, repoID string) (app
 	return
 }
 
+// SupersededCommentsPolicy returns the superseded comments policy for repoID
+// or an empty string if no repo config sets it. If multiple repos set it, the
+// last one wins for consistency with getMatchingCfg.
+func (g GlobalCfg) SupersededCommentsPolicy(repoID string) string {
+	var policy string
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.SupersededComments != nil {
+			policy = *repo.SupersededComments
+		}
+	}
+	return policy
+}
+
+// MergeableIgnoredChecks returns the checks that are ignored when deciding
+// if pull requests for repoID are mergeable. If multiple repos set them, the
+// last one wins for consistency with getMatchingCfg.
+func (g GlobalCfg) MergeableIgnoredChecks(repoID string) []string {
+	var checks []string
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.MergeableIgnoredChecks != nil {
+			checks = repo.MergeableIgnoredChecks
+		}
+	}
+	return checks
+}
+
+// applyWindows returns the apply windows and apply window admins for repoID.
+// If multiple repos set them, the last one wins for consistency with
+// getMatchingCfg.
+func (g GlobalCfg) applyWindows(repoID string) (windows []ApplyWindow, teams []string) {
+	for _, repo := range g.Repos {
+		if !repo.IDMatches(repoID) {
+			continue
+		}
+		if repo.ApplyWindows != nil {
+			windows = repo.ApplyWindows
+		}
+		if repo.ApplyWindowTeams != nil {
+			teams = repo.ApplyWindowTeams
+		}
+	}
+	return
+}
+
+// maxConcurrentApplies returns the maximum number of concurrent applies for
+// repoID or 0 if there is no limit. If multiple repos set it, the last one
+// wins for consistency with getMatchingCfg.
+func (g GlobalCfg) maxConcurrentApplies(repoID string) int {
+	max := 0
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.MaxConcurrentApplies != nil {
+			max = *repo.MaxConcurrentApplies
+		}
+	}
+	return max
+}
+
+// maxResourceChanges returns the maximum number of resources an apply can
+// change for repoID, or 0 if there is no limit, and the teams whose members
+// can approve applies over it. If multiple repos set them, the last one wins for
+// consistency with getMatchingCfg.
+func (g GlobalCfg) maxResourceChanges(repoID string) (max int, teams []string) {
+	for _, repo := range g.Repos {
+		if !repo.IDMatches(repoID) {
+			continue
+		}
+		if repo.MaxResourceChanges != nil {
+			max = *repo.MaxResourceChanges
+		}
+		if repo.MaxResourceChangesTeams != nil {
+			teams = repo.MaxResourceChangesTeams
+		}
+	}
+	return
+}
+
+// providerPolicy returns the provider policy for repoID. If multiple repos
+// set allowed_providers or require_pinned_providers, the last one wins for
+// consistency with getMatchingCfg.
+func (g GlobalCfg) providerPolicy(repoID string) ProviderPolicy {
+	var policy ProviderPolicy
+	for _, repo := range g.Repos {
+		if !repo.IDMatches(repoID) {
+			continue
+		}
+		if repo.AllowedProviders != nil {
+			policy.AllowedProviders = repo.AllowedProviders
+		}
+		if repo.RequirePinnedProviders != nil {
+			policy.RequirePinned = *repo.RequirePinnedProviders
+		}
+	}
+	return policy
+}
+
+// allowedModuleSources returns the allowed module sources for repoID or nil if
+// any source is allowed. If multiple repos set them, the last one wins for
+// consistency with getMatchingCfg.
+func (g GlobalCfg) allowedModuleSources(repoID string) []string {
+	var allowed []string
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.AllowedModuleSources != nil {
+			allowed = repo.AllowedModuleSources
+		}
+	}
+	return allowed
+}
+
+// AllowedCommands returns the comment commands that can be run on the pull
+// requests of repoID or nil if every command can be. If multiple repos set
+// them, the last one wins for consistency with getMatchingCfg.
+func (g GlobalCfg) AllowedCommands(repoID string) []string {
+	var allowed []string
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.AllowedCommands != nil {
+			allowed = repo.AllowedCommands
+		}
+	}
+	return allowed
+}
+
+// cloudCredentials returns the cloud credentials for repoID or nil if there
+// are none. If multiple repos set them, the last one wins for consistency with
+// getMatchingCfg.
+func (g GlobalCfg) cloudCredentials(repoID string) *CloudCredentials {
+	var creds *CloudCredentials
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.CloudCredentials != nil {
+			creds = repo.CloudCredentials
+		}
+	}
+	return creds
+}
+
+// destroyProtection returns the destroy protection for repoID or nil if there
+// is none. If multiple repos set it, the last one wins for consistency with
+// getMatchingCfg.
+func (g GlobalCfg) destroyProtection(repoID string) *DestroyProtection {
+	var protection *DestroyProtection
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.DestroyProtection != nil {
+			protection = repo.DestroyProtection
+		}
+	}
+	return protection
+}
+
+// ProtectedResources returns the protected resources of repoID or nil if
+// there are none. If multiple repos set them, the last one wins for
+// consistency with getMatchingCfg.
+func (g GlobalCfg) ProtectedResources(repoID string) *ProtectedResources {
+	var protected *ProtectedResources
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.ProtectedResources != nil {
+			protected = repo.ProtectedResources
+		}
+	}
+	return protected
+}
+
+// terraformDefaults returns the default extra args and env vars of the
+// project in repoRelDir of repoID, with the default variables as TF_VAR_ env
+// vars. Defaults are merged in order so later matching defaults replace the
+// extra args of the same step and the env vars with the same name, for
+// consistency with getMatchingCfg.
+func (g GlobalCfg) terraformDefaults(repoID string, repoRelDir string) (map[string][]string, map[string]string) {
+	var extraArgs map[string][]string
+	var env map[string]string
+	for _, repo := range g.Repos {
+		if !repo.IDMatches(repoID) {
+			continue
+		}
+		for _, d := range repo.TerraformDefaults {
+			if !d.MatchesDir(repoRelDir) {
+				continue
+			}
+			for step, args := range d.ExtraArgs {
+				if extraArgs == nil {
+					extraArgs = make(map[string][]string)
+				}
+				extraArgs[step] = args
+			}
+			for name, val := range d.Env {
+				if env == nil {
+					env = make(map[string]string)
+				}
+				env[name] = val
+			}
+			for name, val := range d.Vars {
+				if env == nil {
+					env = make(map[string]string)
+				}
+				env["TF_VAR_"+name] = val
+			}
+		}
+	}
+	return extraArgs, env
+}
+
+// providerTargets returns the provider targets of the project in repoRelDir
+// of repoID. Later matching targets replace the ones for the same provider
+// and attribute, for consistency with terraformDefaults.
+func (g GlobalCfg) providerTargets(repoID string, repoRelDir string) []ProviderTarget {
+	var targets []ProviderTarget
+	for _, repo := range g.Repos {
+		if !repo.IDMatches(repoID) {
+			continue
+		}
+	TARGETS:
+		for _, t := range repo.ProviderTargets {
+			if !t.MatchesDir(repoRelDir) {
+				continue
+			}
+			for i, existing := range targets {
+				if existing.Provider == t.Provider && existing.Attribute == t.Attribute {
+					targets[i] = t
+					continue TARGETS
+				}
+			}
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// DeployKeyFile returns the path to the SSH deploy key of repoID or an empty
+// string if no repo config sets it. If multiple repos set it, the last one
+// wins for consistency with getMatchingCfg.
+func (g GlobalCfg) DeployKeyFile(repoID string) string {
+	var keyFile string
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.DeployKeyFile != "" {
+			keyFile = repo.DeployKeyFile
+		}
+	}
+	if tenant := g.Tenant(repoID); keyFile == "" && tenant != nil {
+		keyFile = tenant.DeployKeyFile
+	}
+	return keyFile
+}
+
+// Tenant returns the tenant of repoID or nil if it isn't a repo of any
+// tenant. If multiple tenants match, the first one wins.
+func (g GlobalCfg) Tenant(repoID string) *Tenant {
+	for i := range g.Tenants {
+		if g.Tenants[i].Matches(repoID) {
+			return &g.Tenants[i]
+		}
+	}
+	return nil
+}
+
+// RepoAllowedByTenants returns true if no tenants are configured or repoID
+// is a repo of one of them.
+func (g GlobalCfg) RepoAllowedByTenants(repoID string) bool {
+	return len(g.Tenants) == 0 || g.Tenant(repoID) != nil
+}
+
+// AgentPool returns the agent pool that runs the Terraform commands of
+// repoID or an empty string if no repo config sets it. If multiple repos set
+// it, the last one wins for consistency with getMatchingCfg.
+func (g GlobalCfg) AgentPool(repoID string) string {
+	var pool string
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.AgentPool != "" {
+			pool = repo.AgentPool
+		}
+	}
+	return pool
+}
+
 // MatchingRepo returns an instance of Repo which matches a given repoID.
 // If multiple repos match, return the last one for consistency with getMatchingCfg.
 func (g GlobalCfg) MatchingRepo(repoID string) *Repo {
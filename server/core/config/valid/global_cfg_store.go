@@ -0,0 +1,34 @@
+package valid
+
+import "sync"
+
+// GlobalCfgStore holds the server-side repo config so it can be reloaded
+// while Atlantis is running. Get always returns a complete config so callers
+// never see a partially reloaded one.
+type GlobalCfgStore struct {
+	mu  sync.RWMutex
+	cfg GlobalCfg
+}
+
+// NewGlobalCfgStore returns a store holding cfg.
+func NewGlobalCfgStore(cfg GlobalCfg) *GlobalCfgStore {
+	return &GlobalCfgStore{cfg: cfg}
+}
+
+// Get returns the current config, or an empty config if s is nil.
+func (s *GlobalCfgStore) Get() GlobalCfg {
+	if s == nil {
+		return GlobalCfg{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// Set replaces the current config. Commands that already built their project
+// contexts keep using the config they were built with.
+func (s *GlobalCfgStore) Set(cfg GlobalCfg) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+}
@@ -193,6 +193,22 @@ func TestNewGlobalCfg(t *testing.T) {
 	}
 }
 
+// Test that the validate step is added before init in the default workflow.
+func TestNewGlobalCfg_ValidateEnabled(t *testing.T) {
+	cfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{ValidateEnabled: true})
+	exp := valid.Stage{
+		Steps: []valid.Step{
+			{StepName: "validate"},
+			{StepName: "init"},
+			{StepName: "plan"},
+		},
+	}
+	Equals(t, exp, cfg.Workflows["default"].Plan)
+	Equals(t, exp, cfg.Repos[0].Workflow.Plan)
+	// The default stage isn't changed.
+	Equals(t, 2, len(valid.DefaultPlanStage.Steps))
+}
+
 func TestGlobalCfg_ValidateRepoCfg(t *testing.T) {
 	cases := map[string]struct {
 		gCfg   valid.GlobalCfg
@@ -992,3 +1008,354 @@ func String(v string) *string { return &v }
 // Bool is a helper routine that allocates a new bool value
 // to store v and returns a pointer to it.
 func Bool(v bool) *bool { return &v }
+
+func TestGlobalCfg_SupersededCommentsPolicy(t *testing.T) {
+	hide := valid.HideSupersededComments
+	del := valid.DeleteSupersededComments
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex:            regexp.MustCompile(".*"),
+				SupersededComments: &hide,
+			},
+			{
+				ID:                 "github.com/owner/repo",
+				SupersededComments: &del,
+			},
+			{
+				ID: "github.com/owner/other-repo",
+			},
+		},
+	}
+	Equals(t, valid.DeleteSupersededComments, gCfg.SupersededCommentsPolicy("github.com/owner/repo"))
+	Equals(t, valid.HideSupersededComments, gCfg.SupersededCommentsPolicy("github.com/owner/other-repo"))
+	Equals(t, "", valid.GlobalCfg{}.SupersededCommentsPolicy("github.com/owner/repo"))
+}
+
+func TestGlobalCfg_MergeableIgnoredChecks(t *testing.T) {
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex:                regexp.MustCompile(".*"),
+				MergeableIgnoredChecks: []string{"atlantis/apply*"},
+			},
+			{
+				ID:                     "github.com/owner/repo",
+				MergeableIgnoredChecks: []string{"flaky-check"},
+			},
+			{
+				ID: "github.com/owner/other-repo",
+			},
+		},
+	}
+	Equals(t, []string{"flaky-check"}, gCfg.MergeableIgnoredChecks("github.com/owner/repo"))
+	Equals(t, []string{"atlantis/apply*"}, gCfg.MergeableIgnoredChecks("github.com/owner/other-repo"))
+	Equals(t, []string(nil), valid.GlobalCfg{}.MergeableIgnoredChecks("github.com/owner/repo"))
+}
+
+func TestGlobalCfg_DeployKeyFile(t *testing.T) {
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex:       regexp.MustCompile(".*"),
+				DeployKeyFile: "/keys/default",
+			},
+			{
+				ID:            "github.com/owner/repo",
+				DeployKeyFile: "/keys/repo",
+			},
+			{
+				ID: "github.com/owner/other-repo",
+			},
+		},
+	}
+	Equals(t, "/keys/repo", gCfg.DeployKeyFile("github.com/owner/repo"))
+	Equals(t, "/keys/default", gCfg.DeployKeyFile("github.com/owner/other-repo"))
+	Equals(t, "", valid.GlobalCfg{}.DeployKeyFile("github.com/owner/repo"))
+}
+
+func TestGlobalCfg_AgentPool(t *testing.T) {
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex:   regexp.MustCompile(".*"),
+				AgentPool: "default",
+			},
+			{
+				ID:        "github.com/owner/repo",
+				AgentPool: "private",
+			},
+			{
+				ID: "github.com/owner/other-repo",
+			},
+		},
+	}
+	Equals(t, "private", gCfg.AgentPool("github.com/owner/repo"))
+	Equals(t, "default", gCfg.AgentPool("github.com/owner/other-repo"))
+	Equals(t, "", valid.GlobalCfg{}.AgentPool("github.com/owner/repo"))
+}
+
+func TestGlobalCfg_MergeProjectCfgApplyWindows(t *testing.T) {
+	serverWindow, err := valid.NewApplyWindow("* 9-16 * * 1-5", "")
+	Ok(t, err)
+	projWindow, err := valid.NewApplyWindow("* 10-11 * * *", "")
+	Ok(t, err)
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	gCfg.Repos[0].ApplyWindows = []valid.ApplyWindow{serverWindow}
+	gCfg.Repos[0].ApplyWindowTeams = []string{"admin"}
+	log := logging.NewNoopLogger(t)
+
+	proj := valid.Project{Dir: ".", Workspace: "default", ApplyWindows: []valid.ApplyWindow{projWindow}}
+	merged := gCfg.MergeProjectCfg(log, "github.com/owner/repo", proj, valid.RepoCfg{})
+	Equals(t, []valid.ApplyWindow{serverWindow}, merged.ApplyWindows)
+	Equals(t, []string{"admin"}, merged.ApplyWindowTeams)
+
+	gCfg.Repos[0].AllowedOverrides = []string{valid.ApplyWindowsKey}
+	merged = gCfg.MergeProjectCfg(log, "github.com/owner/repo", proj, valid.RepoCfg{})
+	Equals(t, []valid.ApplyWindow{projWindow}, merged.ApplyWindows)
+	Equals(t, []string{"admin"}, merged.ApplyWindowTeams)
+
+	merged = gCfg.DefaultProjCfg(log, "github.com/owner/repo", ".", "default")
+	Equals(t, []valid.ApplyWindow{serverWindow}, merged.ApplyWindows)
+}
+
+func TestGlobalCfg_ValidateRepoCfgMarkdownTemplateDir(t *testing.T) {
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	rCfg := valid.RepoCfg{MarkdownTemplateDir: ".atlantis/templates"}
+	ErrEquals(t, "repo config not allowed to set 'markdown_template_dir' key: server-side config needs 'allowed_overrides: [markdown_template_dir]'", gCfg.ValidateRepoCfg(rCfg, "github.com/owner/repo"))
+
+	gCfg.Repos[0].AllowedOverrides = []string{valid.MarkdownTemplateDirKey}
+	Ok(t, gCfg.ValidateRepoCfg(rCfg, "github.com/owner/repo"))
+}
+
+func TestGlobalCfg_MergeProjectCfgConcurrentApplies(t *testing.T) {
+	max := 3
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	gCfg.Repos[0].MaxConcurrentApplies = &max
+	gCfg.ApplyConcurrencyGroups = map[string]int{"aws-prod": 2}
+	log := logging.NewNoopLogger(t)
+
+	proj := valid.Project{Dir: ".", Workspace: "default", ApplyConcurrencyGroup: "aws-prod"}
+	Ok(t, gCfg.ValidateRepoCfg(valid.RepoCfg{Projects: []valid.Project{proj}}, "github.com/owner/repo"))
+	merged := gCfg.MergeProjectCfg(log, "github.com/owner/repo", proj, valid.RepoCfg{})
+	Equals(t, 3, merged.MaxConcurrentApplies)
+	Equals(t, "aws-prod", merged.ApplyConcurrencyGroup)
+	Equals(t, 2, merged.ApplyConcurrencyLimit)
+
+	merged = gCfg.DefaultProjCfg(log, "github.com/owner/repo", ".", "default")
+	Equals(t, 3, merged.MaxConcurrentApplies)
+	Equals(t, "", merged.ApplyConcurrencyGroup)
+
+	proj.ApplyConcurrencyGroup = "gcp-prod"
+	err := gCfg.ValidateRepoCfg(valid.RepoCfg{Projects: []valid.Project{proj}}, "github.com/owner/repo")
+	ErrEquals(t, `apply concurrency group "gcp-prod" is not defined by server`, err)
+}
+
+func TestGlobalCfg_MergeProjectCfgMaxResourceChanges(t *testing.T) {
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	log := logging.NewNoopLogger(t)
+	merged := gCfg.DefaultProjCfg(log, "github.com/owner/repo", ".", "default")
+	Equals(t, 0, merged.MaxResourceChanges)
+
+	max, prodMax := 50, 10
+	gCfg.Repos[0].MaxResourceChanges = &max
+	gCfg.Repos[0].MaxResourceChangesTeams = []string{"admin"}
+	gCfg.Repos = append(gCfg.Repos, valid.Repo{ID: "github.com/owner/prod", MaxResourceChanges: &prodMax})
+
+	proj := valid.Project{Dir: ".", Workspace: "default"}
+	merged = gCfg.MergeProjectCfg(log, "github.com/owner/repo", proj, valid.RepoCfg{})
+	Equals(t, 50, merged.MaxResourceChanges)
+	Equals(t, []string{"admin"}, merged.MaxResourceChangesTeams)
+
+	merged = gCfg.DefaultProjCfg(log, "github.com/owner/prod", ".", "default")
+	Equals(t, 10, merged.MaxResourceChanges)
+	Equals(t, []string{"admin"}, merged.MaxResourceChangesTeams)
+}
+
+func TestGlobalCfg_MergeProjectCfgPromoteTo(t *testing.T) {
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	log := logging.NewNoopLogger(t)
+
+	staging := valid.Project{Name: String("staging"), Dir: "envs/staging", Workspace: "default", PromoteTo: "prod"}
+	prod := valid.Project{Name: String("prod"), Dir: "envs/prod", Workspace: "default"}
+	rCfg := valid.RepoCfg{Projects: []valid.Project{staging, prod}}
+
+	merged := gCfg.MergeProjectCfg(log, "github.com/owner/repo", staging, rCfg)
+	Equals(t, "prod", merged.PromoteTo)
+	Equals(t, "envs/prod", merged.PromoteToDir)
+
+	merged = gCfg.MergeProjectCfg(log, "github.com/owner/repo", prod, rCfg)
+	Equals(t, "", merged.PromoteTo)
+	Equals(t, "", merged.PromoteToDir)
+}
+
+func TestGlobalCfg_MergeProjectCfgProviderPolicy(t *testing.T) {
+	aws, err := valid.NewAllowedProvider("hashicorp/aws", "~> 4.0")
+	Ok(t, err)
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	gCfg.Repos = append(gCfg.Repos,
+		valid.Repo{
+			IDRegex:                regexp.MustCompile(".*"),
+			AllowedProviders:       []valid.AllowedProvider{aws},
+			RequirePinnedProviders: Bool(true),
+		},
+		valid.Repo{
+			ID:                     "github.com/owner/legacy",
+			RequirePinnedProviders: Bool(false),
+		},
+	)
+	log := logging.NewNoopLogger(t)
+	proj := valid.Project{Dir: ".", Workspace: "default"}
+
+	merged := gCfg.MergeProjectCfg(log, "github.com/owner/repo", proj, valid.RepoCfg{})
+	Equals(t, valid.ProviderPolicy{AllowedProviders: []valid.AllowedProvider{aws}, RequirePinned: true}, merged.ProviderPolicy)
+
+	// The last matching repo wins.
+	merged = gCfg.MergeProjectCfg(log, "github.com/owner/legacy", proj, valid.RepoCfg{})
+	Equals(t, valid.ProviderPolicy{AllowedProviders: []valid.AllowedProvider{aws}, RequirePinned: false}, merged.ProviderPolicy)
+
+	merged = gCfg.DefaultProjCfg(log, "github.com/owner/repo", ".", "default")
+	Equals(t, valid.ProviderPolicy{AllowedProviders: []valid.AllowedProvider{aws}, RequirePinned: true}, merged.ProviderPolicy)
+}
+
+func TestGlobalCfg_MergeProjectCfgAllowedModuleSources(t *testing.T) {
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	gCfg.Repos = append(gCfg.Repos,
+		valid.Repo{
+			IDRegex:              regexp.MustCompile(".*"),
+			AllowedModuleSources: []string{"app.terraform.io/my-org/*"},
+		},
+		valid.Repo{
+			ID:                   "github.com/owner/repo",
+			AllowedModuleSources: []string{"git::https://github.com/owner/*"},
+		},
+	)
+	log := logging.NewNoopLogger(t)
+	proj := valid.Project{Dir: ".", Workspace: "default"}
+
+	merged := gCfg.MergeProjectCfg(log, "github.com/owner/other", proj, valid.RepoCfg{})
+	Equals(t, []string{"app.terraform.io/my-org/*"}, merged.AllowedModuleSources)
+
+	// The last matching repo wins.
+	merged = gCfg.MergeProjectCfg(log, "github.com/owner/repo", proj, valid.RepoCfg{})
+	Equals(t, []string{"git::https://github.com/owner/*"}, merged.AllowedModuleSources)
+
+	merged = valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{}).DefaultProjCfg(log, "github.com/owner/repo", ".", "default")
+	Assert(t, merged.AllowedModuleSources == nil, "exp any source to be allowed by default")
+}
+
+func TestGlobalCfg_MergeProjectCfgCloudCredentials(t *testing.T) {
+	staging := &valid.CloudCredentials{AWS: &valid.AWSCredentials{RoleARN: "arn:aws:iam::111111111111:role/atlantis"}}
+	prod := &valid.CloudCredentials{AWS: &valid.AWSCredentials{RoleARN: "arn:aws:iam::222222222222:role/atlantis"}}
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	gCfg.Repos = append(gCfg.Repos,
+		valid.Repo{IDRegex: regexp.MustCompile(".*"), CloudCredentials: staging},
+		valid.Repo{ID: "github.com/owner/prod", CloudCredentials: prod},
+	)
+	log := logging.NewNoopLogger(t)
+	proj := valid.Project{Dir: ".", Workspace: "default"}
+
+	Equals(t, staging, gCfg.MergeProjectCfg(log, "github.com/owner/repo", proj, valid.RepoCfg{}).CloudCredentials)
+	// The last matching repo wins.
+	Equals(t, prod, gCfg.MergeProjectCfg(log, "github.com/owner/prod", proj, valid.RepoCfg{}).CloudCredentials)
+	Equals(t, prod, gCfg.DefaultProjCfg(log, "github.com/owner/prod", ".", "default").CloudCredentials)
+}
+
+func TestGlobalCfg_MergeProjectCfgProviderTargets(t *testing.T) {
+	prodAWS := valid.ProviderTarget{Dir: "prod/*", Provider: "aws", Attribute: "assume_role.role_arn", Allowed: []string{"arn:aws:iam::111111111111:role/*"}}
+	google := valid.ProviderTarget{Provider: "google", Attribute: "project", Allowed: []string{"acme-*"}}
+	paymentsAWS := valid.ProviderTarget{Dir: "prod/*", Provider: "aws", Attribute: "assume_role.role_arn", Allowed: []string{"arn:aws:iam::333333333333:role/*"}}
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	gCfg.Repos = append(gCfg.Repos,
+		valid.Repo{
+			IDRegex:         regexp.MustCompile(".*"),
+			ProviderTargets: []valid.ProviderTarget{prodAWS, google},
+		},
+		valid.Repo{
+			ID:              "github.com/owner/payments",
+			ProviderTargets: []valid.ProviderTarget{paymentsAWS},
+		},
+	)
+	log := logging.NewNoopLogger(t)
+
+	cfg := gCfg.MergeProjectCfg(log, "github.com/owner/repo", valid.Project{Dir: "prod/vpc", Workspace: "default"}, valid.RepoCfg{})
+	Equals(t, []valid.ProviderTarget{prodAWS, google}, cfg.ProviderTargets)
+	cfg = gCfg.DefaultProjCfg(log, "github.com/owner/repo", "staging/vpc", "default")
+	Equals(t, []valid.ProviderTarget{google}, cfg.ProviderTargets)
+
+	// Later matching targets replace the ones for the same provider and
+	// attribute.
+	cfg = gCfg.MergeProjectCfg(log, "github.com/owner/payments", valid.Project{Dir: "prod/vpc", Workspace: "default"}, valid.RepoCfg{})
+	Equals(t, []valid.ProviderTarget{paymentsAWS, google}, cfg.ProviderTargets)
+}
+
+func TestGlobalCfg_MergeProjectCfgTerraformDefaults(t *testing.T) {
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	gCfg.Repos = append(gCfg.Repos,
+		valid.Repo{
+			IDRegex: regexp.MustCompile(".*"),
+			TerraformDefaults: []valid.TerraformDefaults{
+				{
+					ExtraArgs: map[string][]string{"plan": {"-lock-timeout=5m"}, "apply": {"-lock-timeout=5m"}},
+					Env:       map[string]string{"TF_IN_AUTOMATION": "true"},
+				},
+				{
+					Dir:       "live/*",
+					ExtraArgs: map[string][]string{"plan": {"-compact-warnings"}},
+					Vars:      map[string]string{"environment": "staging", "cost_center": "1234"},
+				},
+				{
+					Dir:  "live/prod/*",
+					Vars: map[string]string{"environment": "prod"},
+				},
+			},
+		},
+		valid.Repo{
+			ID: "github.com/owner/prod",
+			TerraformDefaults: []valid.TerraformDefaults{
+				{Env: map[string]string{"TF_IN_AUTOMATION": "1", "TF_CLI_ARGS_plan": "-parallelism=5"}},
+			},
+		},
+	)
+	log := logging.NewNoopLogger(t)
+
+	cfg := gCfg.MergeProjectCfg(log, "github.com/owner/repo", valid.Project{Dir: "modules/vpc", Workspace: "default"}, valid.RepoCfg{})
+	Equals(t, map[string][]string{"plan": {"-lock-timeout=5m"}, "apply": {"-lock-timeout=5m"}}, cfg.TerraformExtraArgs)
+	Equals(t, map[string]string{"TF_IN_AUTOMATION": "true"}, cfg.TerraformEnv)
+
+	// Later matching defaults replace earlier ones.
+	cfg = gCfg.MergeProjectCfg(log, "github.com/owner/repo", valid.Project{Dir: "live/prod/vpc", Workspace: "default"}, valid.RepoCfg{})
+	Equals(t, map[string][]string{"plan": {"-compact-warnings"}, "apply": {"-lock-timeout=5m"}}, cfg.TerraformExtraArgs)
+	Equals(t, map[string]string{"TF_IN_AUTOMATION": "true", "TF_VAR_environment": "prod", "TF_VAR_cost_center": "1234"}, cfg.TerraformEnv)
+	cfg = gCfg.MergeProjectCfg(log, "github.com/owner/repo", valid.Project{Dir: "live/staging/vpc", Workspace: "default"}, valid.RepoCfg{})
+	Equals(t, map[string]string{"TF_IN_AUTOMATION": "true", "TF_VAR_environment": "staging", "TF_VAR_cost_center": "1234"}, cfg.TerraformEnv)
+	cfg = gCfg.DefaultProjCfg(log, "github.com/owner/prod", ".", "default")
+	Equals(t, map[string]string{"TF_IN_AUTOMATION": "1", "TF_CLI_ARGS_plan": "-parallelism=5"}, cfg.TerraformEnv)
+
+	// Without matching defaults, there are none.
+	gCfg = valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	cfg = gCfg.DefaultProjCfg(log, "github.com/owner/repo", ".", "default")
+	Equals(t, map[string][]string(nil), cfg.TerraformExtraArgs)
+	Equals(t, map[string]string(nil), cfg.TerraformEnv)
+}
+
+func TestGlobalCfg_AllowedCommands(t *testing.T) {
+	global := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex: regexp.MustCompile(".*"),
+			},
+			{
+				IDRegex:         regexp.MustCompile("github.com/owner/.*"),
+				AllowedCommands: []string{"plan", "unlock"},
+			},
+			{
+				ID:              "github.com/owner/infra",
+				AllowedCommands: []string{"plan", "apply", "unlock"},
+			},
+		},
+	}
+	Equals(t, []string{"plan", "apply", "unlock"}, global.AllowedCommands("github.com/owner/infra"))
+	Equals(t, []string{"plan", "unlock"}, global.AllowedCommands("github.com/owner/app"))
+	Assert(t, global.AllowedCommands("github.com/other/app") == nil, "exp every command to be allowed by default")
+}
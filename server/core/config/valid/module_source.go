@@ -0,0 +1,44 @@
+package valid
+
+import (
+	"strings"
+)
+
+// ModuleSourceAllowed returns true if a module with source can be used when
+// only the module sources matching allowed are. Each pattern matches a source
+// exactly except that * matches any characters, ex.
+// "git::https://github.com/my-org/*" matches every git module in my-org.
+// Local paths are always allowed because they're part of the repo or of a
+// module that was already allowed.
+func ModuleSourceAllowed(allowed []string, source string) bool {
+	if allowed == nil || strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+		return true
+	}
+	for _, pattern := range allowed {
+		if globMatch(pattern, source) {
+			return true
+		}
+	}
+	return false
+}
+
+// globMatch returns true if s matches pattern, where * in pattern matches any
+// characters, including /.
+func globMatch(pattern string, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
@@ -0,0 +1,39 @@
+package valid_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestModuleSourceAllowed(t *testing.T) {
+	allowed := []string{
+		"app.terraform.io/my-org/*",
+		"git::https://github.com/my-org/*",
+		"registry.terraform.io/terraform-aws-modules/vpc/aws",
+	}
+	cases := []struct {
+		allowed []string
+		source  string
+		exp     bool
+	}{
+		{nil, "git::https://github.com/evil/modules.git", true},
+		{[]string{}, "registry.terraform.io/terraform-aws-modules/vpc/aws", false},
+		{allowed, "./modules/vpc", true},
+		{allowed, "../shared", true},
+		{allowed, "app.terraform.io/my-org/vpc/aws", true},
+		{allowed, "git::https://github.com/my-org/modules.git//dns?ref=v1.2.0", true},
+		{allowed, "registry.terraform.io/terraform-aws-modules/vpc/aws", true},
+		{allowed, "registry.terraform.io/terraform-aws-modules/eks/aws", false},
+		{allowed, "git::https://github.com/my-org-fork/modules.git", false},
+		{allowed, "git::https://github.com/evil/modules.git?ref=app.terraform.io/my-org/", false},
+		{[]string{"git::https://github.com/*/modules.git*"}, "git::https://github.com/my-org/modules.git?ref=v1", true},
+		{[]string{"git::https://github.com/*/modules.git"}, "git::https://github.com/my-org/other.git", false},
+	}
+	for _, c := range cases {
+		t.Run(c.source, func(t *testing.T) {
+			Equals(t, c.exp, valid.ModuleSourceAllowed(c.allowed, c.source))
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package valid
+
+// Preview configures the preview environments of a project whose workspace
+// is named after each pull request.
+type Preview struct {
+	// DestroyOnClose is true if the environment is destroyed before its
+	// workspace is deleted when the pull request is closed.
+	DestroyOnClose bool
+	// URL is a Go template of the environment's URL. It can use
+	// {{ .Workspace }}, {{ .PullNum }}, {{ .HeadBranch }} and the outputs
+	// of the project after it's applied, ex. {{ .Outputs.url }}. Empty if
+	// the environment isn't linked to.
+	URL string
+}
@@ -0,0 +1,13 @@
+package valid
+
+import version "github.com/hashicorp/go-version"
+
+// ProjectTemplate is a set of project settings defined in the server-side
+// config that repo config projects use with template: NAME. Since the server
+// defines them, they're applied even if the repo isn't allowed to override
+// the settings itself. Unset settings are nil.
+type ProjectTemplate struct {
+	Workflow          *Workflow
+	ApplyRequirements []string
+	TerraformVersion  *version.Version
+}
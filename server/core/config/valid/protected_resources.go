@@ -0,0 +1,30 @@
+package valid
+
+// ProtectedResources configures the resources whose changes must be approved
+// with approve_resources before they can be applied.
+type ProtectedResources struct {
+	// Addresses are patterns of the protected resource addresses, where *
+	// matches any characters, ex. "module.vpc.*".
+	Addresses []string
+	// ApproverTeams are the VCS teams whose members can approve changes to
+	// protected resources.
+	ApproverTeams []string
+}
+
+// Matching returns the addresses that match one of p's patterns. p can be nil,
+// in which case no addresses match.
+func (p *ProtectedResources) Matching(addresses []string) []string {
+	if p == nil {
+		return nil
+	}
+	var matching []string
+	for _, address := range addresses {
+		for _, pattern := range p.Addresses {
+			if globMatch(pattern, address) {
+				matching = append(matching, address)
+				break
+			}
+		}
+	}
+	return matching
+}
@@ -0,0 +1,17 @@
+package valid_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestProtectedResources_Matching(t *testing.T) {
+	var unset *valid.ProtectedResources
+	Equals(t, 0, len(unset.Matching([]string{"aws_route53_zone.main"})))
+
+	p := &valid.ProtectedResources{Addresses: []string{"aws_route53_zone.*", "module.vpc.*"}}
+	Equals(t, []string{"aws_route53_zone.main", "module.vpc.aws_subnet.private[0]"},
+		p.Matching([]string{"aws_instance.web", "aws_route53_zone.main", "module.vpc.aws_subnet.private[0]", "module.app.aws_route53_zone.main"}))
+}
@@ -0,0 +1,115 @@
+package valid
+
+import (
+	"fmt"
+	"strings"
+
+	version "github.com/hashicorp/go-version"
+)
+
+// defaultProviderRegistry is the registry hostname of provider source
+// addresses that don't specify one.
+const defaultProviderRegistry = "registry.terraform.io"
+
+// AllowedProvider is a provider that projects are allowed to use.
+type AllowedProvider struct {
+	// Source is the provider's source address, ex. "hashicorp/aws". If it
+	// doesn't have a hostname, registry.terraform.io is assumed. Any part can
+	// be *, ex. "hashicorp/*" allows every provider in the hashicorp namespace.
+	Source string
+	// Versions constrains the versions of the provider that can be used. If
+	// nil, any version can be used.
+	Versions version.Constraints
+}
+
+// NewAllowedProvider parses source and versions. versions is a Terraform
+// version constraint, ex. ">= 4.0, < 5.0", and can be empty.
+func NewAllowedProvider(source string, versions string) (AllowedProvider, error) {
+	if parts := strings.Split(source, "/"); len(parts) < 2 || len(parts) > 3 {
+		return AllowedProvider{}, fmt.Errorf("source %q must be in the form [HOSTNAME/]NAMESPACE/TYPE", source)
+	}
+	a := AllowedProvider{Source: source}
+	if versions != "" {
+		c, err := version.NewConstraint(versions)
+		if err != nil {
+			return AllowedProvider{}, fmt.Errorf("versions for source %q: %s", source, err)
+		}
+		a.Versions = c
+	}
+	return a, nil
+}
+
+// MatchesSource returns true if source, a fully qualified source address like
+// "registry.terraform.io/hashicorp/aws", matches a's source.
+func (a AllowedProvider) MatchesSource(source string) bool {
+	exp := strings.Split(qualifyProviderSource(a.Source), "/")
+	act := strings.Split(qualifyProviderSource(source), "/")
+	if len(exp) != len(act) {
+		return false
+	}
+	for i := range exp {
+		if exp[i] != "*" && !strings.EqualFold(exp[i], act[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ProviderPolicy restricts the providers that projects can use.
+type ProviderPolicy struct {
+	// AllowedProviders are the providers that can be used. If nil, any
+	// provider can be used.
+	AllowedProviders []AllowedProvider
+	// RequirePinned is true if every provider must have a version constraint
+	// in the project's configuration.
+	RequirePinned bool
+}
+
+// Violation returns why using version v of the provider with source address
+// source and version constraints constraints is against the policy, or an
+// empty string if it isn't.
+func (p ProviderPolicy) Violation(source string, v string, constraints string) string {
+	if p.RequirePinned && strings.TrimSpace(constraints) == "" {
+		return fmt.Sprintf("provider %q is not pinned: add a version constraint for it to required_providers", source)
+	}
+	if p.AllowedProviders == nil {
+		return ""
+	}
+
+	var sourceAllowed bool
+	for _, a := range p.AllowedProviders {
+		if !a.MatchesSource(source) {
+			continue
+		}
+		sourceAllowed = true
+		if a.Versions == nil {
+			return ""
+		}
+		parsed, err := version.NewVersion(v)
+		if err == nil && a.Versions.Check(parsed) {
+			return ""
+		}
+	}
+	if !sourceAllowed {
+		return fmt.Sprintf("provider %q is not allowed", source)
+	}
+	return fmt.Sprintf("version %s of provider %q is not allowed, allowed versions: %s", v, source, p.allowedVersions(source))
+}
+
+// allowedVersions returns the allowed version constraints of source.
+func (p ProviderPolicy) allowedVersions(source string) string {
+	var versions []string
+	for _, a := range p.AllowedProviders {
+		if a.MatchesSource(source) && a.Versions != nil {
+			versions = append(versions, fmt.Sprintf("%q", a.Versions.String()))
+		}
+	}
+	return strings.Join(versions, " or ")
+}
+
+func qualifyProviderSource(source string) string {
+	if len(strings.Split(source, "/")) == 2 {
+		return defaultProviderRegistry + "/" + source
+	}
+	return source
+}
@@ -0,0 +1,98 @@
+package valid_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestAllowedProvider_MatchesSource(t *testing.T) {
+	cases := []struct {
+		allowed string
+		source  string
+		exp     bool
+	}{
+		{"hashicorp/aws", "registry.terraform.io/hashicorp/aws", true},
+		{"HashiCorp/AWS", "registry.terraform.io/hashicorp/aws", true},
+		{"registry.terraform.io/hashicorp/aws", "registry.terraform.io/hashicorp/aws", true},
+		{"hashicorp/*", "registry.terraform.io/hashicorp/random", true},
+		{"hashicorp/aws", "registry.terraform.io/hashicorp/awscc", false},
+		{"hashicorp/aws", "example.com/hashicorp/aws", false},
+		{"*/hashicorp/aws", "example.com/hashicorp/aws", true},
+	}
+	for _, c := range cases {
+		t.Run(c.allowed+" "+c.source, func(t *testing.T) {
+			a, err := valid.NewAllowedProvider(c.allowed, "")
+			Ok(t, err)
+			Equals(t, c.exp, a.MatchesSource(c.source))
+		})
+	}
+}
+
+func TestProviderPolicy_Violation(t *testing.T) {
+	aws3, err := valid.NewAllowedProvider("hashicorp/aws", "~> 3.0")
+	Ok(t, err)
+	aws4, err := valid.NewAllowedProvider("hashicorp/aws", "~> 4.0")
+	Ok(t, err)
+	random, err := valid.NewAllowedProvider("hashicorp/random", "")
+	Ok(t, err)
+
+	cases := map[string]struct {
+		policy      valid.ProviderPolicy
+		source      string
+		version     string
+		constraints string
+		exp         string
+	}{
+		"no policy": {
+			source:  "registry.terraform.io/hashicorp/aws",
+			version: "4.36.1",
+		},
+		"allowed version": {
+			policy:  valid.ProviderPolicy{AllowedProviders: []valid.AllowedProvider{aws3, aws4}},
+			source:  "registry.terraform.io/hashicorp/aws",
+			version: "4.36.1",
+		},
+		"any version": {
+			policy:  valid.ProviderPolicy{AllowedProviders: []valid.AllowedProvider{random}},
+			source:  "registry.terraform.io/hashicorp/random",
+			version: "3.4.3",
+		},
+		"banned version": {
+			policy:  valid.ProviderPolicy{AllowedProviders: []valid.AllowedProvider{aws3, aws4}},
+			source:  "registry.terraform.io/hashicorp/aws",
+			version: "5.0.0",
+			exp:     `version 5.0.0 of provider "registry.terraform.io/hashicorp/aws" is not allowed, allowed versions: "~> 3.0" or "~> 4.0"`,
+		},
+		"banned provider": {
+			policy:  valid.ProviderPolicy{AllowedProviders: []valid.AllowedProvider{aws4}},
+			source:  "registry.terraform.io/hashicorp/random",
+			version: "3.4.3",
+			exp:     `provider "registry.terraform.io/hashicorp/random" is not allowed`,
+		},
+		"no providers allowed": {
+			policy:  valid.ProviderPolicy{AllowedProviders: []valid.AllowedProvider{}},
+			source:  "registry.terraform.io/hashicorp/random",
+			version: "3.4.3",
+			exp:     `provider "registry.terraform.io/hashicorp/random" is not allowed`,
+		},
+		"pinned": {
+			policy:      valid.ProviderPolicy{RequirePinned: true},
+			source:      "registry.terraform.io/hashicorp/aws",
+			version:     "4.36.1",
+			constraints: "~> 4.0",
+		},
+		"unpinned": {
+			policy:  valid.ProviderPolicy{RequirePinned: true},
+			source:  "registry.terraform.io/hashicorp/aws",
+			version: "4.36.1",
+			exp:     `provider "registry.terraform.io/hashicorp/aws" is not pinned: add a version constraint for it to required_providers`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			Equals(t, c.exp, c.policy.Violation(c.source, c.version, c.constraints))
+		})
+	}
+}
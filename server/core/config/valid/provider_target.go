@@ -0,0 +1,43 @@
+package valid
+
+// DefaultProviderTargetAttributes maps providers to the attribute of their
+// provider config that selects the account, project or subscription that
+// they change, for ProviderTargets that don't set Attribute.
+var DefaultProviderTargetAttributes = map[string]string{
+	"aws":         "assume_role.role_arn",
+	"google":      "project",
+	"google-beta": "project",
+	"azurerm":     "subscription_id",
+}
+
+// ProviderTarget restricts the accounts, projects or subscriptions that the
+// provider configs of a repo's projects can target.
+type ProviderTarget struct {
+	// Dir is a pattern of the dirs of the projects that are restricted,
+	// where * matches any characters, ex. "prod/*". If empty, every project
+	// is.
+	Dir string
+	// Provider is the name of the provider, ex. "aws".
+	Provider string
+	// Attribute is the attribute of the provider's configs that is checked.
+	// Nested blocks are separated by dots, ex. "assume_role.role_arn".
+	Attribute string
+	// Allowed are patterns of the attribute's allowed values, where *
+	// matches any characters.
+	Allowed []string
+}
+
+// MatchesDir returns true if the project in repoRelDir is restricted by t.
+func (t ProviderTarget) MatchesDir(repoRelDir string) bool {
+	return t.Dir == "" || globMatch(t.Dir, repoRelDir)
+}
+
+// Allows returns true if value matches one of t's allowed patterns.
+func (t ProviderTarget) Allows(value string) bool {
+	for _, pattern := range t.Allowed {
+		if globMatch(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
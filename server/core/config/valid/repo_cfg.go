@@ -24,6 +24,10 @@ type RepoCfg struct {
 	ParallelPolicyCheck       bool
 	DeleteSourceBranchOnMerge *bool
 	AllowedRegexpPrefixes     []string
+	// MarkdownTemplateDir is the dir, relative to the repo root, with
+	// templates that override the templates of the repo's comments. Empty if
+	// the repo doesn't override them.
+	MarkdownTemplateDir string
 }
 
 func (r RepoCfg) FindProjectsByDirWorkspace(repoRelDir string, workspace string) []Project {
@@ -36,6 +40,16 @@ func (r RepoCfg) FindProjectsByDirWorkspace(repoRelDir string, workspace string)
 	return ps
 }
 
+// ResolvePullWorkspaces sets the workspace of each project with WorkspaceFrom
+// set to the one of the pull request with headBranch and pullNum.
+func (r *RepoCfg) ResolvePullWorkspaces(headBranch string, pullNum int) {
+	for i, p := range r.Projects {
+		if p.WorkspaceFrom != "" {
+			r.Projects[i].Workspace = PullWorkspace(p.WorkspaceFrom, headBranch, pullNum)
+		}
+	}
+}
+
 // FindProjectsByDir returns all projects that are in dir.
 func (r RepoCfg) FindProjectsByDir(dir string) []Project {
 	var ps []Project
@@ -127,6 +141,40 @@ type Project struct {
 	ApplyRequirements         []string
 	DeleteSourceBranchOnMerge *bool
 	ExecutionOrderGroup       int
+	ApplyWindows              []ApplyWindow
+	ApplyConcurrencyGroup     string
+	// PromoteTo is the name of the project that this project's changes are
+	// promoted to after a successful apply.
+	PromoteTo string
+	// DependsOn are the projects in other repos that must be applied
+	// successfully before this project.
+	DependsOn []ProjectDependency
+	// Template is the name of the server-side project template whose settings
+	// the project uses unless it sets them itself.
+	Template string
+	// WorkspaceFrom is BranchWorkspaceFrom or PullNumberWorkspaceFrom if
+	// each pull request gets its own workspace of the project, named after
+	// its head branch or number. The workspace is created when the pull
+	// request is first planned and deleted when it's closed.
+	WorkspaceFrom string
+	// Preview configures the preview environments of the project's
+	// workspaces. Nil unless WorkspaceFrom is set and they're configured.
+	Preview *Preview
+	// ResourceTags configures the tags that the project's commands pass to
+	// Terraform. Nil if they don't pass any.
+	ResourceTags *ResourceTags
+}
+
+// ProjectDependency is a project in another repo that a project depends on.
+type ProjectDependency struct {
+	// Repo is the owner and name of the dependency's repo.
+	Repo string
+	// Project is the name of the dependency, or its dir if it isn't named.
+	Project   string
+	Workspace string
+	// Block is true if applies are blocked while the dependency is out of
+	// date or failing, rather than only warned about.
+	Block bool
 }
 
 // GetName returns the name of the project or an empty string if there is no
@@ -158,11 +206,86 @@ type Step struct {
 	EnvVarName string
 	// EnvVarValue is the value to set EnvVarName to.
 	EnvVarValue string
+	// Env are the env vars set for this step only. Values prefixed with
+	// SecretRefPrefix are resolved by the secrets provider when the step runs.
+	Env map[string]string
+	// CaptureAs is the env var that a run step's output is set as for the
+	// steps after it, instead of being added to the output.
+	CaptureAs string
+	// When is the condition that the plan has to meet for the step to run,
+	// ex. WhenHasChanges. The step always runs if it's empty.
+	When string
+	// OnFailure is what happens when a verify step fails, ex.
+	// OnFailureRevert.
+	OnFailure string
 }
 
+// Conditions that steps can be run on.
+const (
+	// WhenHasChanges runs the step if the plan changes any resources.
+	WhenHasChanges = "has_changes"
+	// WhenNoChanges runs the step if the plan doesn't change any resources.
+	WhenNoChanges = "no_changes"
+	// WhenDestroyDetected runs the step if the plan destroys any resources.
+	WhenDestroyDetected = "destroy_detected"
+)
+
+// What happens when a verify step fails.
+const (
+	// OnFailureFail fails the apply.
+	OnFailureFail = "fail"
+	// OnFailureWarn adds a warning to the apply's output.
+	OnFailureWarn = "warn"
+	// OnFailureRevert fails the apply and opens a pull request that reverts
+	// the changes that were applied.
+	OnFailureRevert = "revert"
+)
+
+// SecretRefPrefix prefixes the values of step env vars that refer to a
+// secret, ex. secret://deploy-token.
+const SecretRefPrefix = "secret://"
+
 type Workflow struct {
 	Name        string
 	Apply       Stage
 	Plan        Stage
 	PolicyCheck Stage
 }
+
+const (
+	// BranchWorkspaceFrom names the workspace of a pull request after its
+	// number and head branch, ex. pr-12-feature-vpc for feature/vpc.
+	BranchWorkspaceFrom = "branch"
+	// PullNumberWorkspaceFrom names the workspace of a pull request after its
+	// number, ex. pr-12.
+	PullNumberWorkspaceFrom = "pull_number"
+)
+
+// invalidWorkspaceChars matches the characters that can't be used in
+// workspace names, which end up in dirs, lock keys and state paths.
+var invalidWorkspaceChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// PullWorkspace returns the workspace of the pull request with headBranch and
+// pullNum for a project whose WorkspaceFrom is from. It always starts with the
+// pull request's number so that a branch named after an existing workspace,
+// ex. prod, can't plan, apply or delete it.
+func PullWorkspace(from string, headBranch string, pullNum int) string {
+	workspace := pullWorkspacePrefix(pullNum)
+	if from == BranchWorkspaceFrom {
+		if branch := strings.Trim(invalidWorkspaceChars.ReplaceAllString(headBranch, "-"), "-."); branch != "" {
+			workspace += "-" + branch
+		}
+	}
+	return workspace
+}
+
+// IsPullWorkspace returns true if workspace is named like the workspaces that
+// PullWorkspace returns for the pull request with pullNum.
+func IsPullWorkspace(workspace string, pullNum int) bool {
+	prefix := pullWorkspacePrefix(pullNum)
+	return workspace == prefix || strings.HasPrefix(workspace, prefix+"-")
+}
+
+func pullWorkspacePrefix(pullNum int) string {
+	return fmt.Sprintf("pr-%d", pullNum)
+}
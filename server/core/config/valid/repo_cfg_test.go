@@ -215,3 +215,59 @@ func TestConfig_FindProjectsByDir(t *testing.T) {
 		})
 	}
 }
+
+func TestRepoCfg_ResolvePullWorkspaces(t *testing.T) {
+	cfg := valid.RepoCfg{
+		Projects: []valid.Project{
+			{Dir: "static", Workspace: "staging"},
+			{Dir: "branch", Workspace: "default", WorkspaceFrom: valid.BranchWorkspaceFrom},
+			{Dir: "pull", Workspace: "default", WorkspaceFrom: valid.PullNumberWorkspaceFrom},
+		},
+	}
+	cfg.ResolvePullWorkspaces("feature/new vpc", 12)
+	Equals(t, "staging", cfg.Projects[0].Workspace)
+	Equals(t, "pr-12-feature-new-vpc", cfg.Projects[1].Workspace)
+	Equals(t, "pr-12", cfg.Projects[2].Workspace)
+}
+
+func TestPullWorkspace(t *testing.T) {
+	cases := []struct {
+		from       string
+		headBranch string
+		exp        string
+	}{
+		{valid.BranchWorkspaceFrom, "feature/vpc", "pr-3-feature-vpc"},
+		{valid.BranchWorkspaceFrom, "Fix_1.2", "pr-3-Fix_1.2"},
+		{valid.BranchWorkspaceFrom, "/feature//vpc/", "pr-3-feature-vpc"},
+		// Existing workspaces must never be used.
+		{valid.BranchWorkspaceFrom, "default", "pr-3-default"},
+		{valid.BranchWorkspaceFrom, "prod", "pr-3-prod"},
+		{valid.BranchWorkspaceFrom, "??", "pr-3"},
+		{valid.PullNumberWorkspaceFrom, "feature/vpc", "pr-3"},
+	}
+	for _, c := range cases {
+		t.Run(c.from+" "+c.headBranch, func(t *testing.T) {
+			Equals(t, c.exp, valid.PullWorkspace(c.from, c.headBranch, 3))
+		})
+	}
+}
+
+func TestIsPullWorkspace(t *testing.T) {
+	cases := []struct {
+		workspace string
+		exp       bool
+	}{
+		{"pr-3", true},
+		{"pr-3-feature-vpc", true},
+		{"pr-31", false},
+		{"pr-31-feature-vpc", false},
+		{"pr-4", false},
+		{"prod", false},
+		{"default", false},
+	}
+	for _, c := range cases {
+		t.Run(c.workspace, func(t *testing.T) {
+			Equals(t, c.exp, valid.IsPullWorkspace(c.workspace, 3))
+		})
+	}
+}
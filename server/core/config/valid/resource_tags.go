@@ -0,0 +1,35 @@
+package valid
+
+// DefaultResourceTagsVariable is the variable that resource tags are set in
+// unless a project configures another one.
+const DefaultResourceTagsVariable = "atlantis_tags"
+
+// The tags that can be set in a project's resource tags variable. Each one
+// is set as atlantis_<name>, ex. atlantis_pull.
+const (
+	// RepoResourceTag is the full name of the repo, ex. owner/repo.
+	RepoResourceTag = "repo"
+	// PullResourceTag is the number of the pull request.
+	PullResourceTag = "pull"
+	// AuthorResourceTag is the username of the pull request's author.
+	AuthorResourceTag = "author"
+	// RunIDResourceTag is the id of the Atlantis job that planned the
+	// changes.
+	RunIDResourceTag = "run_id"
+)
+
+// ResourceTagNames are all the tags that can be set, in the order they're
+// documented.
+var ResourceTagNames = []string{RepoResourceTag, PullResourceTag, AuthorResourceTag, RunIDResourceTag}
+
+// ResourceTags configures the tags that a project's commands set in a
+// Terraform variable so that its resources can be traced back to the pull
+// requests that changed them.
+type ResourceTags struct {
+	// Variable is the name of the map(string) variable the tags are set in.
+	// It's set through its TF_VAR_ env var, which Terraform ignores if the
+	// variable isn't declared.
+	Variable string
+	// Include are the names of the tags that are set.
+	Include []string
+}
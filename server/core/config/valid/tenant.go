@@ -0,0 +1,76 @@
+package valid
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TenantMetricsTag is the metrics tag that's set to the name of the tenant
+// of the repo that a command runs for.
+const TenantMetricsTag = "tenant"
+
+// Tenant is a group of repos, ex. the repos of a business unit, that are
+// isolated from the repos of other tenants. Each tenant has its own data dir
+// and can have its own VCS credentials, default workflow and metrics tags.
+// If any tenants are configured, only repos of a tenant are allowed.
+type Tenant struct {
+	Name string
+	// RepoIDs are the exact IDs of the tenant's repos and RepoIDRegexes
+	// match the IDs of the rest.
+	RepoIDs       []string
+	RepoIDRegexes []*regexp.Regexp
+	// Workflow is the workflow of the tenant's repos that the repos config
+	// doesn't set one for. Nil to use the default workflow.
+	Workflow *Workflow
+	// DataDirQuotaMB is how many megabytes the clones of the tenant's repos
+	// can use before new clones fail. 0 if unlimited.
+	DataDirQuotaMB int
+	// VCSUser and VCSTokenFile are the credentials that the tenant's repos
+	// are cloned with and that comments and statuses are made with. The
+	// token is read from the file every time it's used so that rotated
+	// tokens are picked up. Empty to use the server's credentials.
+	VCSUser      string
+	VCSTokenFile string
+	// DeployKeyFile is the SSH key that the tenant's repos without their own
+	// deploy_key_file are cloned with. Empty if not set.
+	DeployKeyFile string
+	// MetricsTags are added to the metrics of the tenant's commands with
+	// TenantMetricsTag.
+	MetricsTags map[string]string
+}
+
+// Matches returns true if repoID is a repo of the tenant.
+func (t Tenant) Matches(repoID string) bool {
+	for _, id := range t.RepoIDs {
+		if id == repoID {
+			return true
+		}
+	}
+	for _, r := range t.RepoIDRegexes {
+		if r.MatchString(repoID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tags returns the metrics tags of the tenant's commands.
+func (t Tenant) Tags() map[string]string {
+	tags := map[string]string{TenantMetricsTag: t.Name}
+	for k, v := range t.MetricsTags {
+		tags[k] = v
+	}
+	return tags
+}
+
+// VCSToken returns the token in the tenant's VCSTokenFile.
+func (t Tenant) VCSToken() (string, error) {
+	token, err := os.ReadFile(t.VCSTokenFile)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading vcs_token_file of tenant %s", t.Name)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
@@ -0,0 +1,31 @@
+package valid
+
+// TerraformDefaultsSteps are the steps that TerraformDefaults can set extra
+// args for.
+var TerraformDefaultsSteps = []string{"init", "validate", "plan", "show", "apply"}
+
+// TerraformDefaults are the extra args, env vars and variables that the
+// Terraform commands of a repo's projects get without each repo config setting
+// them.
+type TerraformDefaults struct {
+	// Dir is a pattern of the dirs of the projects that get the defaults,
+	// where * matches any characters, ex. "live/*". If empty, every project
+	// gets them.
+	Dir string
+	// ExtraArgs maps a step in TerraformDefaultsSteps to the args that are
+	// passed to it before the step's own extra_args, so that the step's args
+	// win if they set the same flag.
+	ExtraArgs map[string][]string
+	// Env are env vars that every step gets. Env vars that the steps set
+	// themselves win.
+	Env map[string]string
+	// Vars maps the names of Terraform variables to their values. They're
+	// passed as TF_VAR_<name> env vars, so they replace Env vars of the same
+	// name, and -var args and .tfvars files take precedence over them.
+	Vars map[string]string
+}
+
+// MatchesDir returns true if the project in repoRelDir gets the defaults.
+func (t TerraformDefaults) MatchesDir(repoRelDir string) bool {
+	return t.Dir == "" || globMatch(t.Dir, repoRelDir)
+}
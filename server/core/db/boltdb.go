@@ -29,6 +29,11 @@ const (
 	pullsBucketName       = "pulls"
 	globalLocksBucketName = "globalLocks"
 	pullKeySeparator      = "::"
+	freezeKeyPrefix       = "freeze/"
+	inventoryKeyPrefix    = "inventory/"
+	projectRunKeyPrefix   = "project-run/"
+	lockContentionPrefix  = "lock-contention/"
+	jobKeyPrefix          = "job/"
 )
 
 // New returns a valid locker. We need to be able to write to dataDir
@@ -252,6 +257,243 @@ func (b *BoltDB) CheckCommandLock(cmdName command.Name) (*command.Lock, error) {
 	return nil, err
 }
 
+// Freeze creates or replaces the freeze for freeze's repo and project.
+func (b *BoltDB) Freeze(freeze models.Freeze) error {
+	serialized, _ := json.Marshal(freeze)
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.globalLocksBucketName)
+		return bucket.Put([]byte(b.freezeKey(freeze.RepoFullName, freeze.Project)), serialized)
+	})
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// Unfreeze deletes the freeze for repoFullName and project and returns it.
+// If there is no freeze, it returns a nil pointer.
+func (b *BoltDB) Unfreeze(repoFullName string, project string) (*models.Freeze, error) {
+	var freeze *models.Freeze
+	key := []byte(b.freezeKey(repoFullName, project))
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.globalLocksBucketName)
+		serialized := bucket.Get(key)
+		if serialized == nil {
+			return nil
+		}
+		freeze = &models.Freeze{}
+		if err := json.Unmarshal(serialized, freeze); err != nil {
+			return errors.Wrapf(err, "deserializing freeze at key %q", string(key))
+		}
+		return bucket.Delete(key)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	return freeze, nil
+}
+
+// ListFreezes returns all freezes.
+func (b *BoltDB) ListFreezes() ([]models.Freeze, error) {
+	var freezes []models.Freeze
+	prefix := []byte(freezeKeyPrefix)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(b.globalLocksBucketName).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var freeze models.Freeze
+			if err := json.Unmarshal(v, &freeze); err != nil {
+				return errors.Wrapf(err, "deserializing freeze at key %q", string(k))
+			}
+			freezes = append(freezes, freeze)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	return freezes, nil
+}
+
+// UpdateInventory creates or replaces the inventory of inventory's project.
+func (b *BoltDB) UpdateInventory(inventory models.ProjectInventory) error {
+	serialized, _ := json.Marshal(inventory)
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.globalLocksBucketName)
+		return bucket.Put([]byte(b.inventoryKey(inventory)), serialized)
+	})
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// ListInventory returns the inventory of every project.
+func (b *BoltDB) ListInventory() ([]models.ProjectInventory, error) {
+	var inventory []models.ProjectInventory
+	prefix := []byte(inventoryKeyPrefix)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(b.globalLocksBucketName).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var projInventory models.ProjectInventory
+			if err := json.Unmarshal(v, &projInventory); err != nil {
+				return errors.Wrapf(err, "deserializing inventory at key %q", string(k))
+			}
+			inventory = append(inventory, projInventory)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	return inventory, nil
+}
+
+// UpdateProjectRun creates or replaces the last run of run's command for
+// run's project, keeping the durations of the previous runs.
+func (b *BoltDB) UpdateProjectRun(run models.ProjectRun) error {
+	key := []byte(b.projectRunKey(run))
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.globalLocksBucketName)
+		var previous *models.ProjectRun
+		if serialized := bucket.Get(key); serialized != nil {
+			previous = &models.ProjectRun{}
+			if err := json.Unmarshal(serialized, previous); err != nil {
+				return errors.Wrapf(err, "deserializing project run at key %q", string(key))
+			}
+		}
+		serialized, _ := json.Marshal(run.WithDurations(previous))
+		return bucket.Put(key, serialized)
+	})
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// ListProjectRuns returns the last plan and apply of every project.
+func (b *BoltDB) ListProjectRuns() ([]models.ProjectRun, error) {
+	var runs []models.ProjectRun
+	prefix := []byte(projectRunKeyPrefix)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(b.globalLocksBucketName).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var run models.ProjectRun
+			if err := json.Unmarshal(v, &run); err != nil {
+				return errors.Wrapf(err, "deserializing project run at key %q", string(k))
+			}
+			runs = append(runs, run)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	return runs, nil
+}
+
+// RecordLockAttempt records attempt in the lock contention of its project's
+// workspace.
+func (b *BoltDB) RecordLockAttempt(attempt models.LockAttempt) error {
+	key := []byte(b.lockContentionKey(attempt))
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.globalLocksBucketName)
+		var contention models.LockContention
+		if serialized := bucket.Get(key); serialized != nil {
+			if err := json.Unmarshal(serialized, &contention); err != nil {
+				return errors.Wrapf(err, "deserializing lock contention at key %q", string(key))
+			}
+		}
+		contention, changed := contention.WithAttempt(attempt)
+		if !changed {
+			return nil
+		}
+		serialized, _ := json.Marshal(contention)
+		return bucket.Put(key, serialized)
+	})
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// ListLockContention returns the lock contention of every project's
+// workspaces whose locks blocked pull requests.
+func (b *BoltDB) ListLockContention() ([]models.LockContention, error) {
+	var contention []models.LockContention
+	prefix := []byte(lockContentionPrefix)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(b.globalLocksBucketName).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var lockContention models.LockContention
+			if err := json.Unmarshal(v, &lockContention); err != nil {
+				return errors.Wrapf(err, "deserializing lock contention at key %q", string(k))
+			}
+			contention = append(contention, lockContention)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	return contention, nil
+}
+
+// SaveJob creates or replaces the job with job's ID.
+func (b *BoltDB) SaveJob(job models.Job) error {
+	serialized, _ := json.Marshal(job)
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.globalLocksBucketName)
+		return bucket.Put([]byte(jobKeyPrefix+job.ID), serialized)
+	})
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// GetJob returns the job with ID jobID. If there is no such job, it returns
+// nil.
+func (b *BoltDB) GetJob(jobID string) (*models.Job, error) {
+	var job *models.Job
+	err := b.db.View(func(tx *bolt.Tx) error {
+		serialized := tx.Bucket(b.globalLocksBucketName).Get([]byte(jobKeyPrefix + jobID))
+		if serialized == nil {
+			return nil
+		}
+		job = &models.Job{}
+		return errors.Wrapf(json.Unmarshal(serialized, job), "deserializing job %q", jobID)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	return job, nil
+}
+
+// Ping reads the locks bucket, which fails if the database was closed.
+func (b *BoltDB) Ping() error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(b.locksBucketName) == nil {
+			return fmt.Errorf("bucket %q not found", b.locksBucketName)
+		}
+		return nil
+	})
+}
+
+// DeleteJobsCompletedBefore deletes the jobs that completed before t and
+// returns how many were deleted.
+func (b *BoltDB) DeleteJobsCompletedBefore(t time.Time) (int, error) {
+	deleted := 0
+	prefix := []byte(jobKeyPrefix)
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.globalLocksBucketName)
+		var expired [][]byte
+		c := bucket.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var job models.Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return errors.Wrapf(err, "deserializing job at key %q", string(k))
+			}
+			if job.CompletedAt.Before(t) {
+				expired = append(expired, k)
+			}
+		}
+		// Keys can't be deleted while iterating with the cursor.
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	return deleted, errors.Wrap(err, "db transaction failed")
+}
+
 // UnlockByPull deletes all locks associated with that pull request and returns them.
 func (b *BoltDB) UnlockByPull(repoFullName string, pullNum int) ([]models.ProjectLock, error) {
 	var locks []models.ProjectLock
@@ -357,6 +599,9 @@ func (b *BoltDB) UpdatePullWithResults(pull models.PullRequest, newResults []com
 						res.ProjectName == proj.ProjectName {
 
 						proj.Status = res.PlanStatus()
+						if res.JobID != "" {
+							proj.JobID = res.JobID
+						}
 						updatedExisting = true
 						break
 					}
@@ -456,6 +701,22 @@ func (b *BoltDB) commandLockKey(cmdName command.Name) string {
 	return fmt.Sprintf("%s/lock", cmdName)
 }
 
+func (b *BoltDB) freezeKey(repoFullName string, project string) string {
+	return fmt.Sprintf("%s%s%s%s", freezeKeyPrefix, repoFullName, pullKeySeparator, project)
+}
+
+func (b *BoltDB) inventoryKey(inventory models.ProjectInventory) string {
+	return fmt.Sprintf("%s%s", inventoryKeyPrefix, strings.Join([]string{inventory.RepoFullName, inventory.Path, inventory.Workspace, inventory.ProjectName}, pullKeySeparator))
+}
+
+func (b *BoltDB) projectRunKey(run models.ProjectRun) string {
+	return fmt.Sprintf("%s%s", projectRunKeyPrefix, strings.Join([]string{run.RepoFullName, run.Path, run.Workspace, run.ProjectName, run.Command}, pullKeySeparator))
+}
+
+func (b *BoltDB) lockContentionKey(attempt models.LockAttempt) string {
+	return fmt.Sprintf("%s%s", lockContentionPrefix, strings.Join([]string{attempt.RepoFullName, attempt.Path, attempt.Workspace}, pullKeySeparator))
+}
+
 func (b *BoltDB) lockKey(p models.Project, workspace string) string {
 	return fmt.Sprintf("%s/%s/%s", p.RepoFullName, p.Path, workspace)
 }
@@ -483,9 +744,12 @@ func (b *BoltDB) writePullToBucket(bucket *bolt.Bucket, key []byte, pull models.
 
 func (b *BoltDB) projectResultToProject(p command.ProjectResult) models.ProjectStatus {
 	return models.ProjectStatus{
-		Workspace:   p.Workspace,
-		RepoRelDir:  p.RepoRelDir,
-		ProjectName: p.ProjectName,
-		Status:      p.PlanStatus(),
+		Workspace:          p.Workspace,
+		RepoRelDir:         p.RepoRelDir,
+		ProjectName:        p.ProjectName,
+		Status:             p.PlanStatus(),
+		JobID:              p.JobID,
+		EphemeralWorkspace: p.EphemeralWorkspace,
+		DestroyOnClose:     p.DestroyOnClose,
 	}
 }
@@ -120,6 +120,162 @@ func TestMixedLocksPresent(t *testing.T) {
 	Equals(t, 1, len(ls))
 }
 
+func TestFreeze(t *testing.T) {
+	t.Log("freezes can be created, listed and deleted")
+	db, b := newTestDB()
+	defer cleanupDB(db)
+	global := models.Freeze{Message: "incident", Time: time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)}
+	project := models.Freeze{RepoFullName: "owner/repo", Project: "prod", Time: time.Date(2022, 8, 1, 13, 0, 0, 0, time.UTC)}
+	Ok(t, b.Freeze(global))
+	Ok(t, b.Freeze(project))
+
+	freezes, err := b.ListFreezes()
+	Ok(t, err)
+	Equals(t, 2, len(freezes))
+
+	// Freezing again replaces the message.
+	global.Message = "change freeze"
+	Ok(t, b.Freeze(global))
+	freezes, err = b.ListFreezes()
+	Ok(t, err)
+	Equals(t, 2, len(freezes))
+
+	deleted, err := b.Unfreeze("", "")
+	Ok(t, err)
+	Equals(t, &global, deleted)
+
+	deleted, err = b.Unfreeze("", "")
+	Ok(t, err)
+	Assert(t, deleted == nil, "exp nil")
+
+	freezes, err = b.ListFreezes()
+	Ok(t, err)
+	Equals(t, []models.Freeze{project}, freezes)
+}
+
+func TestInventory(t *testing.T) {
+	t.Log("inventory can be updated and listed")
+	db, b := newTestDB()
+	defer cleanupDB(db)
+	staging := models.ProjectInventory{
+		RepoFullName: "owner/repo",
+		Path:         "staging",
+		Workspace:    "default",
+		Providers:    []models.InventoryProvider{{Source: "registry.terraform.io/hashicorp/aws", Version: "4.0.0"}},
+		Time:         time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC),
+	}
+	prod := staging
+	prod.Path = "prod"
+	Ok(t, b.UpdateInventory(staging))
+	Ok(t, b.UpdateInventory(prod))
+
+	// Updating a project again replaces its inventory.
+	staging.Providers = []models.InventoryProvider{{Source: "registry.terraform.io/hashicorp/aws", Version: "4.1.0"}}
+	Ok(t, b.UpdateInventory(staging))
+
+	inventory, err := b.ListInventory()
+	Ok(t, err)
+	Equals(t, []models.ProjectInventory{prod, staging}, inventory)
+}
+
+func TestProjectRuns(t *testing.T) {
+	t.Log("the last plan and apply of projects can be updated and listed")
+	db, b := newTestDB()
+	defer cleanupDB(db)
+	plan := models.ProjectRun{
+		RepoFullName: "owner/repo",
+		Path:         "staging",
+		Workspace:    "default",
+		Command:      "plan",
+		Outcome:      models.FailedProjectRunOutcome,
+		Time:         time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC),
+	}
+	apply := plan
+	apply.Command = "apply"
+	Ok(t, b.UpdateProjectRun(plan))
+	Ok(t, b.UpdateProjectRun(apply))
+
+	// Updating a command again replaces its last run.
+	plan.Outcome = models.SuccessProjectRunOutcome
+	Ok(t, b.UpdateProjectRun(plan))
+
+	runs, err := b.ListProjectRuns()
+	Ok(t, err)
+	Equals(t, []models.ProjectRun{apply, plan}, runs)
+}
+
+func TestProjectRuns_RecentDurations(t *testing.T) {
+	t.Log("updating a project's run keeps the durations of its previous runs")
+	db, b := newTestDB()
+	defer cleanupDB(db)
+	run := models.ProjectRun{
+		RepoFullName: "owner/repo",
+		Path:         "staging",
+		Workspace:    "default",
+		Command:      "apply",
+		Duration:     time.Minute,
+	}
+	Ok(t, b.UpdateProjectRun(run))
+	run.Duration = 3 * time.Minute
+	Ok(t, b.UpdateProjectRun(run))
+
+	runs, err := b.ListProjectRuns()
+	Ok(t, err)
+	Equals(t, 1, len(runs))
+	Equals(t, 2, len(runs[0].Durations))
+	Equals(t, 3*time.Minute, runs[0].Durations[1].Duration)
+	Equals(t, 2*time.Minute, runs[0].ExpectedDuration())
+}
+
+func TestLockContention(t *testing.T) {
+	t.Log("blocked lock attempts are recorded until the lock is acquired")
+	db, b := newTestDB()
+	defer cleanupDB(db)
+	attempt := models.LockAttempt{RepoFullName: "owner/repo", Path: "staging", Workspace: "default", PullNum: 2, Time: time.Now()}
+	Ok(t, b.RecordLockAttempt(attempt))
+	contention, err := b.ListLockContention()
+	Ok(t, err)
+	Equals(t, 0, len(contention))
+
+	attempt.BlockingPullNum = 1
+	Ok(t, b.RecordLockAttempt(attempt))
+	attempt.BlockingPullNum = 0
+	attempt.Time = attempt.Time.Add(time.Hour)
+	Ok(t, b.RecordLockAttempt(attempt))
+
+	contention, err = b.ListLockContention()
+	Ok(t, err)
+	Equals(t, 1, len(contention))
+	Equals(t, 1, len(contention[0].Waits))
+	Equals(t, 1, contention[0].Waits[0].BlockingPullNum)
+	Assert(t, !contention[0].Waits[0].Blocked(), "exp wait to have ended")
+}
+
+func TestJobs(t *testing.T) {
+	t.Log("jobs can be saved, fetched and expired")
+	db, b := newTestDB()
+	defer cleanupDB(db)
+	old := models.Job{ID: "old", Lines: []string{"line"}, CompletedAt: time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)}
+	recent := models.Job{ID: "recent", Lines: []string{"line"}, CompletedAt: time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)}
+	Ok(t, b.SaveJob(old))
+	Ok(t, b.SaveJob(recent))
+
+	job, err := b.GetJob("old")
+	Ok(t, err)
+	Equals(t, &old, job)
+
+	deleted, err := b.DeleteJobsCompletedBefore(time.Date(2022, 8, 15, 0, 0, 0, 0, time.UTC))
+	Ok(t, err)
+	Equals(t, 1, deleted)
+
+	job, err = b.GetJob("old")
+	Ok(t, err)
+	Assert(t, job == nil, "exp old job to be deleted")
+	job, err = b.GetJob("recent")
+	Ok(t, err)
+	Equals(t, &recent, job)
+}
+
 func TestListNoLocks(t *testing.T) {
 	t.Log("listing locks when there are none should return an empty list")
 	db, b := newTestDB()
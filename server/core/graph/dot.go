@@ -0,0 +1,315 @@
+// Package graph parses the DOT that terraform graph outputs and renders it
+// as SVG without depending on Graphviz.
+package graph
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Graph is a directed graph.
+type Graph struct {
+	// Nodes are in the order they were first declared or used in an edge.
+	Nodes []*Node
+	Edges []Edge
+	nodes map[string]*Node
+}
+
+// Node is a node of a Graph.
+type Node struct {
+	ID string
+	// Label is the node's label attribute, or its ID if it doesn't have one.
+	Label string
+	// Shape is the node's shape attribute, ex. "box". Empty if it doesn't
+	// have one.
+	Shape string
+	// Declared is true if the node has its own statement, not just edges.
+	Declared bool
+}
+
+// Edge is an edge from the node with ID From to the node with ID To.
+type Edge struct {
+	From string
+	To   string
+}
+
+// ParseDOT parses a digraph in the DOT language. Only the subset that
+// terraform graph outputs is supported: node and edge statements, attributes
+// and subgraphs. The nodes of subgraphs are added to the graph itself.
+func ParseDOT(dot string) (*Graph, error) {
+	tokens, err := tokenize(dot)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, graph: &Graph{nodes: make(map[string]*Node)}}
+	if p.peekID("strict") {
+		p.pos++
+	}
+	if !p.peekID("digraph") {
+		return nil, fmt.Errorf("expected digraph, got %s", p.describe())
+	}
+	p.pos++
+	if p.peek().kind == idToken {
+		p.pos++
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	if err := p.parseStatements(); err != nil {
+		return nil, err
+	}
+	return p.graph, nil
+}
+
+// Declared returns the graph of only the declared nodes and the edges
+// between them, which leaves out nodes that Terraform only uses internally,
+// ex. its root node. If no nodes are declared, g is returned.
+func (g *Graph) Declared() *Graph {
+	declared := &Graph{nodes: make(map[string]*Node)}
+	for _, n := range g.Nodes {
+		if n.Declared {
+			declared.Nodes = append(declared.Nodes, n)
+			declared.nodes[n.ID] = n
+		}
+	}
+	if len(declared.Nodes) == 0 {
+		return g
+	}
+	for _, e := range g.Edges {
+		if declared.nodes[e.From] != nil && declared.nodes[e.To] != nil {
+			declared.Edges = append(declared.Edges, e)
+		}
+	}
+	return declared
+}
+
+func (g *Graph) node(id string) *Node {
+	n, ok := g.nodes[id]
+	if !ok {
+		n = &Node{ID: id, Label: id}
+		g.nodes[id] = n
+		g.Nodes = append(g.Nodes, n)
+	}
+	return n
+}
+
+type tokenKind int
+
+const (
+	idToken tokenKind = iota
+	arrowToken
+	punctToken
+	eofToken
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+func tokenize(dot string) ([]token, error) {
+	var tokens []token
+	runes := []rune(dot)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '/', c == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := strings.Index(string(runes[i+2:]), "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += 2 + len([]rune(string(runes[i+2:])[:end])) + 2
+		case c == '"':
+			var b strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				// Only quotes are escaped, other backslashes are kept.
+				if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '"' {
+					i++
+				}
+				b.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			i++
+			tokens = append(tokens, token{kind: idToken, value: b.String()})
+		case c == '-' && i+1 < len(runes) && runes[i+1] == '>':
+			tokens = append(tokens, token{kind: arrowToken, value: "->"})
+			i += 2
+		case strings.ContainsRune("{}[]=;,", c):
+			tokens = append(tokens, token{kind: punctToken, value: string(c)})
+			i++
+		case c == '_' || c == '.' || c == '-' || unicode.IsLetter(c) || unicode.IsDigit(c):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '.' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || (runes[i] == '-' && (i+1 >= len(runes) || runes[i+1] != '>'))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: idToken, value: string(runes[start:i])})
+		default:
+			return nil, fmt.Errorf("unexpected %q", c)
+		}
+	}
+	return append(tokens, token{kind: eofToken}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	graph  *Graph
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekID(value string) bool {
+	t := p.peek()
+	return t.kind == idToken && strings.EqualFold(t.value, value)
+}
+
+func (p *parser) peekPunct(value string) bool {
+	t := p.peek()
+	return t.kind == punctToken && t.value == value
+}
+
+func (p *parser) describe() string {
+	t := p.peek()
+	if t.kind == eofToken {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+func (p *parser) expect(punct string) error {
+	if !p.peekPunct(punct) {
+		return fmt.Errorf("expected %q, got %s", punct, p.describe())
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) expectID() (string, error) {
+	t := p.peek()
+	if t.kind != idToken {
+		return "", fmt.Errorf("expected an ID, got %s", p.describe())
+	}
+	p.pos++
+	return t.value, nil
+}
+
+// parseStatements parses statements up to and including the closing brace
+// of the graph or subgraph.
+func (p *parser) parseStatements() error {
+	for {
+		switch {
+		case p.peekPunct("}"):
+			p.pos++
+			return nil
+		case p.peek().kind == eofToken:
+			return fmt.Errorf("expected %q, got end of input", "}")
+		case p.peekPunct(";"), p.peekPunct(","):
+			p.pos++
+		case p.peekID("subgraph"), p.peekPunct("{"):
+			if p.peekID("subgraph") {
+				p.pos++
+				if p.peek().kind == idToken {
+					p.pos++
+				}
+			}
+			if err := p.expect("{"); err != nil {
+				return err
+			}
+			if err := p.parseStatements(); err != nil {
+				return err
+			}
+		case p.peekID("node"), p.peekID("edge"), p.peekID("graph"):
+			p.pos++
+			if _, err := p.parseAttributes(); err != nil {
+				return err
+			}
+		default:
+			if err := p.parseNodeOrEdge(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (p *parser) parseNodeOrEdge() error {
+	id, err := p.expectID()
+	if err != nil {
+		return err
+	}
+	// Graph attribute, ex. rankdir = "RL".
+	if p.peekPunct("=") {
+		p.pos++
+		_, err := p.expectID()
+		return err
+	}
+	ids := []string{id}
+	for p.peek().kind == arrowToken {
+		p.pos++
+		to, err := p.expectID()
+		if err != nil {
+			return err
+		}
+		ids = append(ids, to)
+	}
+	attrs, err := p.parseAttributes()
+	if err != nil {
+		return err
+	}
+	if len(ids) == 1 {
+		n := p.graph.node(id)
+		n.Declared = true
+		if label, ok := attrs["label"]; ok {
+			n.Label = label
+		}
+		if shape, ok := attrs["shape"]; ok {
+			n.Shape = shape
+		}
+		return nil
+	}
+	for i := 1; i < len(ids); i++ {
+		p.graph.node(ids[i-1])
+		p.graph.node(ids[i])
+		p.graph.Edges = append(p.graph.Edges, Edge{From: ids[i-1], To: ids[i]})
+	}
+	return nil
+}
+
+// parseAttributes parses any attribute lists, ex. [label = "a", shape = "box"].
+func (p *parser) parseAttributes() (map[string]string, error) {
+	attrs := make(map[string]string)
+	for p.peekPunct("[") {
+		p.pos++
+		for !p.peekPunct("]") {
+			if p.peekPunct(",") || p.peekPunct(";") {
+				p.pos++
+				continue
+			}
+			name, err := p.expectID()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			value, err := p.expectID()
+			if err != nil {
+				return nil, err
+			}
+			attrs[name] = value
+		}
+		p.pos++
+	}
+	return attrs, nil
+}
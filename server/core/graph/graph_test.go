@@ -0,0 +1,125 @@
+package graph_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/graph"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const terraformGraph = `digraph {
+	compound = "true"
+	newrank = "true"
+	subgraph "root" {
+		"[root] aws_instance.web (expand)" [label = "aws_instance.web", shape = "box"]
+		"[root] aws_security_group.web (expand)" [label = "aws_security_group.web", shape = "box"]
+		"[root] provider[\"registry.terraform.io/hashicorp/aws\"]" [label = "provider[\"registry.terraform.io/hashicorp/aws\"]", shape = "diamond"]
+		"[root] var.ami" [label = "var.ami", shape = "note"]
+		"[root] aws_instance.web (expand)" -> "[root] aws_security_group.web (expand)"
+		"[root] aws_instance.web (expand)" -> "[root] var.ami"
+		"[root] aws_security_group.web (expand)" -> "[root] provider[\"registry.terraform.io/hashicorp/aws\"]"
+		"[root] provider[\"registry.terraform.io/hashicorp/aws\"] (close)" -> "[root] aws_instance.web (expand)"
+		"[root] root" -> "[root] provider[\"registry.terraform.io/hashicorp/aws\"] (close)"
+	}
+}
+`
+
+func TestParseDOT(t *testing.T) {
+	g, err := graph.ParseDOT(terraformGraph)
+	Ok(t, err)
+	Equals(t, 6, len(g.Nodes))
+	Equals(t, 5, len(g.Edges))
+	Equals(t, &graph.Node{
+		ID:       `[root] provider["registry.terraform.io/hashicorp/aws"]`,
+		Label:    `provider["registry.terraform.io/hashicorp/aws"]`,
+		Shape:    "diamond",
+		Declared: true,
+	}, g.Nodes[2])
+	Equals(t, graph.Edge{From: "[root] aws_instance.web (expand)", To: "[root] var.ami"}, g.Edges[1])
+
+	declared := g.Declared()
+	var labels []string
+	for _, n := range declared.Nodes {
+		labels = append(labels, n.Label)
+	}
+	Equals(t, []string{"aws_instance.web", "aws_security_group.web", `provider["registry.terraform.io/hashicorp/aws"]`, "var.ami"}, labels)
+	Equals(t, 3, len(declared.Edges))
+}
+
+func TestParseDOT_Syntax(t *testing.T) {
+	g, err := graph.ParseDOT(`
+// comment
+strict digraph G {
+	/* block
+	   comment */
+	node [shape = box];
+	a -> b -> c;
+	{ d }
+	# comment
+}`)
+	Ok(t, err)
+	Equals(t, []graph.Edge{{From: "a", To: "b"}, {From: "b", To: "c"}}, g.Edges)
+	Equals(t, 4, len(g.Nodes))
+	Equals(t, "d", g.Declared().Nodes[0].ID)
+}
+
+func TestParseDOT_Errors(t *testing.T) {
+	cases := map[string]string{
+		`graph { a -- b }`:   `expected digraph, got "graph"`,
+		`digraph { a -> }`:   `expected an ID, got "}"`,
+		`digraph { "a }`:     "unterminated string",
+		`digraph { a`:        `expected "}", got end of input`,
+		`digraph { a [b] }`:  `expected "=", got "]"`,
+		`digraph { a @ b }`:  `unexpected '@'`,
+		`digraph { /* a }`:   "unterminated comment",
+		`digraph { a -> b `:  `expected "}", got end of input`,
+		`digraph { a = }`:    `expected an ID, got "}"`,
+		`digraph { a [b = ]`: `expected an ID, got "]"`,
+	}
+	for dot, expErr := range cases {
+		t.Run(dot, func(t *testing.T) {
+			_, err := graph.ParseDOT(dot)
+			ErrEquals(t, expErr, err)
+		})
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	g, err := graph.ParseDOT(terraformGraph)
+	Ok(t, err)
+	svg := string(graph.RenderSVG(g.Declared()))
+
+	Assert(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg"`), "exp svg element, got %s", svg)
+	Assert(t, strings.HasSuffix(svg, `</svg>`), "exp svg to be closed, got %s", svg)
+	Equals(t, 4, strings.Count(svg, "<title>"))
+	Equals(t, 3, strings.Count(svg, `marker-end="url(#arrow)"`))
+	Assert(t, strings.Contains(svg, `>provider[&#34;registry.terraform.io/hashicorp/aws&#34;]</text>`), "exp label to be escaped, got %s", svg)
+	Assert(t, strings.Contains(svg, `fill="#fff8c5"`), "exp provider to be drawn as a provider, got %s", svg)
+	Equals(t, svg, string(graph.RenderSVG(g.Declared())))
+}
+
+func TestRenderSVG_Layers(t *testing.T) {
+	// b depends on a and c depends on both, so they're laid out left to
+	// right in the order a, b, c.
+	g, err := graph.ParseDOT(`digraph { a; b; c; b -> a; c -> b; c -> a }`)
+	Ok(t, err)
+	svg := string(graph.RenderSVG(g))
+	a := strings.Index(svg, `<title>a</title><rect x="20"`)
+	b := strings.Index(svg, `<title>b</title><rect x="160"`)
+	c := strings.Index(svg, `<title>c</title><rect x="300"`)
+	Assert(t, a > 0 && b > 0 && c > 0, "exp nodes in layers a, b, c, got %s", svg)
+}
+
+func TestRenderSVG_Cycle(t *testing.T) {
+	g, err := graph.ParseDOT(`digraph { a -> b; b -> a; a -> a }`)
+	Ok(t, err)
+	svg := string(graph.RenderSVG(g))
+	Equals(t, 2, strings.Count(svg, "<title>"))
+	Equals(t, 2, strings.Count(svg, `marker-end="url(#arrow)"`))
+}
+
+func TestRenderSVG_Empty(t *testing.T) {
+	svg := string(graph.RenderSVG(&graph.Graph{}))
+	Assert(t, strings.Contains(svg, "No resources"), "exp placeholder, got %s", svg)
+}
@@ -0,0 +1,235 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"sort"
+	"unicode/utf8"
+)
+
+const (
+	nodeHeight   = 32
+	charWidth    = 7
+	nodePadding  = 12
+	minNodeWidth = 60
+	layerGap     = 80
+	nodeGap      = 16
+	margin       = 20
+	fontSize     = 12
+	// orderingSweeps is how many times the nodes of each layer are reordered
+	// by the positions of their neighbours to reduce edge crossings.
+	orderingSweeps = 4
+)
+
+type box struct {
+	node  *Node
+	layer int
+	order float64
+	x, y  int
+	width int
+}
+
+// RenderSVG renders g as an SVG, laid out from left to right so that each
+// node is to the right of the nodes it has edges to. The output only
+// depends on g, so rendering the same graph twice gives the same SVG.
+func RenderSVG(g *Graph) []byte {
+	var buf bytes.Buffer
+	if len(g.Nodes) == 0 {
+		fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, 200, 60, 200, 60)
+		fmt.Fprintf(&buf, `<text x="100" y="34" text-anchor="middle" font-family="sans-serif" font-size="%d" fill="#57606a">No resources</text></svg>`, fontSize)
+		return buf.Bytes()
+	}
+
+	boxes, layers := layout(g)
+	width, height := 0, 0
+	for _, b := range boxes {
+		if b.x+b.width > width {
+			width = b.x + b.width
+		}
+		if b.y+nodeHeight > height {
+			height = b.y + nodeHeight
+		}
+	}
+	width += margin
+	height += margin
+
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="%d">`, width, height, width, height, fontSize)
+	buf.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="6" markerHeight="6" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z" fill="#57606a"/></marker></defs>`)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#ffffff"/>`, width, height)
+
+	// Edges point from a node to its dependency, so the arrow is drawn from
+	// the dependent node on the right to the dependency on the left.
+	for _, e := range g.Edges {
+		from, to := boxes[e.From], boxes[e.To]
+		if from == nil || to == nil || from == to {
+			continue
+		}
+		x1, y1 := from.x, from.y+nodeHeight/2
+		x2, y2 := to.x+to.width, to.y+nodeHeight/2
+		if from.layer <= to.layer {
+			// Edges that were reversed to break a cycle.
+			x1, x2 = from.x+from.width, to.x
+		}
+		mid := (x1 + x2) / 2
+		fmt.Fprintf(&buf, `<path d="M %d %d C %d %d, %d %d, %d %d" fill="none" stroke="#57606a" stroke-width="1" marker-end="url(#arrow)"/>`, x1, y1, mid, y1, mid, y2, x2, y2)
+	}
+
+	for _, layer := range layers {
+		for _, b := range layer {
+			fill, stroke := nodeColors(b.node.Shape)
+			buf.WriteString(`<g>`)
+			fmt.Fprintf(&buf, `<title>%s</title>`, html.EscapeString(b.node.ID))
+			fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" rx="6" fill="%s" stroke="%s"/>`, b.x, b.y, b.width, nodeHeight, fill, stroke)
+			fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="middle" dominant-baseline="central" fill="#24292f">%s</text>`, b.x+b.width/2, b.y+nodeHeight/2, html.EscapeString(b.node.Label))
+			buf.WriteString(`</g>`)
+		}
+	}
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
+
+// nodeColors returns the fill and stroke of a node. terraform graph gives
+// providers the diamond shape and variables, locals and outputs the note
+// shape.
+func nodeColors(shape string) (string, string) {
+	switch shape {
+	case "diamond":
+		return "#fff8c5", "#bf8700"
+	case "note":
+		return "#f6f8fa", "#8c959f"
+	default:
+		return "#ddf4ff", "#0969da"
+	}
+}
+
+// layout assigns each node a position and returns the boxes by node ID and
+// the boxes of each layer in order.
+func layout(g *Graph) (map[string]*box, [][]*box) {
+	edges := acyclicEdges(g)
+	dependencies := make(map[string][]string)
+	dependents := make(map[string][]string)
+	for _, e := range edges {
+		dependencies[e.From] = append(dependencies[e.From], e.To)
+		dependents[e.To] = append(dependents[e.To], e.From)
+	}
+
+	// A node's layer is the length of the longest path to a node without
+	// dependencies, so every node is right of what it depends on.
+	layerOf := make(map[string]int)
+	var assign func(id string) int
+	assign = func(id string) int {
+		if l, ok := layerOf[id]; ok {
+			return l
+		}
+		l := 0
+		for _, dep := range dependencies[id] {
+			if dl := assign(dep) + 1; dl > l {
+				l = dl
+			}
+		}
+		layerOf[id] = l
+		return l
+	}
+
+	boxes := make(map[string]*box)
+	var layers [][]*box
+	for _, n := range g.Nodes {
+		l := assign(n.ID)
+		for len(layers) <= l {
+			layers = append(layers, nil)
+		}
+		width := utf8.RuneCountInString(n.Label)*charWidth + 2*nodePadding
+		if width < minNodeWidth {
+			width = minNodeWidth
+		}
+		b := &box{node: n, layer: l, width: width, order: float64(len(layers[l]))}
+		boxes[n.ID] = b
+		layers[l] = append(layers[l], b)
+	}
+
+	for sweep := 0; sweep < orderingSweeps; sweep++ {
+		for l := 1; l < len(layers); l++ {
+			reorder(layers[l], dependencies, boxes)
+		}
+		for l := len(layers) - 2; l >= 0; l-- {
+			reorder(layers[l], dependents, boxes)
+		}
+	}
+
+	x := margin
+	for _, layer := range layers {
+		layerWidth := 0
+		y := margin
+		for _, b := range layer {
+			b.x, b.y = x, y
+			y += nodeHeight + nodeGap
+			if b.width > layerWidth {
+				layerWidth = b.width
+			}
+		}
+		x += layerWidth + layerGap
+	}
+	return boxes, layers
+}
+
+// reorder sorts layer by the average order of each node's neighbours, which
+// tends to reduce edge crossings. Nodes without neighbours keep their order.
+func reorder(layer []*box, neighbours map[string][]string, boxes map[string]*box) {
+	for _, b := range layer {
+		ns := neighbours[b.node.ID]
+		if len(ns) == 0 {
+			continue
+		}
+		sum := 0.0
+		for _, n := range ns {
+			sum += boxes[n].order
+		}
+		b.order = sum / float64(len(ns))
+	}
+	sort.SliceStable(layer, func(i, j int) bool {
+		return layer[i].order < layer[j].order
+	})
+	for i, b := range layer {
+		b.order = float64(i)
+	}
+}
+
+// acyclicEdges returns the edges of g without self loops and without the
+// edges that close a cycle, which Terraform would reject anyway but which
+// would otherwise make the layering loop forever.
+func acyclicEdges(g *Graph) []Edge {
+	out := make(map[string][]Edge)
+	for _, e := range g.Edges {
+		if e.From != e.To {
+			out[e.From] = append(out[e.From], e)
+		}
+	}
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var edges []Edge
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		for _, e := range out[id] {
+			switch state[e.To] {
+			case visiting:
+				continue
+			case unvisited:
+				visit(e.To)
+			}
+			edges = append(edges, e)
+		}
+		state[id] = visited
+	}
+	for _, n := range g.Nodes {
+		if state[n.ID] == unvisited {
+			visit(n.ID)
+		}
+	}
+	return edges
+}
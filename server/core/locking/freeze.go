@@ -0,0 +1,97 @@
+package locking
+
+import (
+	"errors"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// FreezeChecker checks if applies are frozen for a project.
+type FreezeChecker interface {
+	// CheckFreeze returns the freeze that applies to the project with name
+	// projectName or directory repoRelDir in repo repoFullName. If the project
+	// isn't frozen it returns a nil pointer.
+	CheckFreeze(repoFullName string, projectName string, repoRelDir string) (*models.Freeze, error)
+}
+
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_freezer.go Freezer
+
+// Freezer manages freezes that pause applies globally, per-repo or
+// per-project, ex. during a change freeze or an ongoing incident.
+type Freezer interface {
+	// Freeze pauses applies for repoFullName and project. If repoFullName is
+	// empty, applies are paused for all repos. If project is empty, applies
+	// are paused for all projects in the repo. Freezing the same repo and
+	// project again replaces the message.
+	Freeze(repoFullName string, project string, message string) (models.Freeze, error)
+	// Unfreeze deletes the freeze for repoFullName and project and returns it.
+	// If there was no freeze, it returns a nil pointer.
+	Unfreeze(repoFullName string, project string) (*models.Freeze, error)
+	// ListFreezes returns all freezes.
+	ListFreezes() ([]models.Freeze, error)
+	FreezeChecker
+}
+
+// FreezeClient is a Freezer backed by the locking database.
+type FreezeClient struct {
+	backend Backend
+}
+
+func NewFreezeClient(backend Backend) Freezer {
+	return &FreezeClient{
+		backend: backend,
+	}
+}
+
+func (c *FreezeClient) Freeze(repoFullName string, project string, message string) (models.Freeze, error) {
+	if repoFullName == "" && project != "" {
+		return models.Freeze{}, errors.New("a repo is required to freeze a project")
+	}
+	freeze := models.Freeze{
+		RepoFullName: repoFullName,
+		Project:      project,
+		Message:      message,
+		Time:         time.Now(),
+	}
+	return freeze, c.backend.Freeze(freeze)
+}
+
+func (c *FreezeClient) Unfreeze(repoFullName string, project string) (*models.Freeze, error) {
+	return c.backend.Unfreeze(repoFullName, project)
+}
+
+func (c *FreezeClient) ListFreezes() ([]models.Freeze, error) {
+	return c.backend.ListFreezes()
+}
+
+// CheckFreeze returns the first freeze that matches the project. Freezes are
+// checked from the most to the least specific so the message is the one most
+// relevant to the project.
+func (c *FreezeClient) CheckFreeze(repoFullName string, projectName string, repoRelDir string) (*models.Freeze, error) {
+	freezes, err := c.backend.ListFreezes()
+	if err != nil {
+		return nil, err
+	}
+	var match *models.Freeze
+	for i, f := range freezes {
+		if !f.Matches(repoFullName, projectName, repoRelDir) {
+			continue
+		}
+		if match == nil || specificity(f) > specificity(*match) {
+			match = &freezes[i]
+		}
+	}
+	return match, nil
+}
+
+func specificity(f models.Freeze) int {
+	switch {
+	case f.Project != "":
+		return 2
+	case f.RepoFullName != "":
+		return 1
+	default:
+		return 0
+	}
+}
@@ -43,6 +43,26 @@ type Backend interface {
 	LockCommand(cmdName command.Name, lockTime time.Time) (*command.Lock, error)
 	UnlockCommand(cmdName command.Name) error
 	CheckCommandLock(cmdName command.Name) (*command.Lock, error)
+
+	Freeze(freeze models.Freeze) error
+	Unfreeze(repoFullName string, project string) (*models.Freeze, error)
+	ListFreezes() ([]models.Freeze, error)
+
+	UpdateInventory(inventory models.ProjectInventory) error
+	ListInventory() ([]models.ProjectInventory, error)
+
+	UpdateProjectRun(run models.ProjectRun) error
+	ListProjectRuns() ([]models.ProjectRun, error)
+
+	RecordLockAttempt(attempt models.LockAttempt) error
+	ListLockContention() ([]models.LockContention, error)
+
+	SaveJob(job models.Job) error
+	GetJob(jobID string) (*models.Job, error)
+	DeleteJobsCompletedBefore(t time.Time) (int, error)
+
+	// Ping returns an error if the database can't be used.
+	Ping() error
 }
 
 // TryLockResponse results from an attempted lock.
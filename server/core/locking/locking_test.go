@@ -286,3 +286,45 @@ func TestApplyLocker(t *testing.T) {
 		})
 	})
 }
+
+func TestFreezeClient_CheckFreeze(t *testing.T) {
+	RegisterMockTestingT(t)
+	global := models.Freeze{Message: "global"}
+	repo := models.Freeze{RepoFullName: "owner/repo", Message: "repo"}
+	proj := models.Freeze{RepoFullName: "owner/repo", Project: "prod", Message: "project"}
+	backend := mocks.NewMockBackend()
+	When(backend.ListFreezes()).ThenReturn([]models.Freeze{proj, global, repo}, nil)
+	f := locking.NewFreezeClient(backend)
+
+	t.Log("the most specific freeze wins")
+	freeze, err := f.CheckFreeze("owner/repo", "prod", "prod")
+	Ok(t, err)
+	Equals(t, &proj, freeze)
+
+	t.Log("projects can be matched by directory")
+	freeze, err = f.CheckFreeze("owner/repo", "", "prod")
+	Ok(t, err)
+	Equals(t, &proj, freeze)
+
+	freeze, err = f.CheckFreeze("owner/repo", "staging", "staging")
+	Ok(t, err)
+	Equals(t, &repo, freeze)
+
+	freeze, err = f.CheckFreeze("owner/other", "prod", "prod")
+	Ok(t, err)
+	Equals(t, &global, freeze)
+
+	When(backend.ListFreezes()).ThenReturn(nil, nil)
+	freeze, err = f.CheckFreeze("owner/repo", "prod", "prod")
+	Ok(t, err)
+	Assert(t, freeze == nil, "exp nil")
+}
+
+func TestFreezeClient_FreezeProjectRequiresRepo(t *testing.T) {
+	RegisterMockTestingT(t)
+	backend := mocks.NewMockBackend()
+	f := locking.NewFreezeClient(backend)
+	_, err := f.Freeze("", "prod", "")
+	ErrEquals(t, "a repo is required to freeze a project", err)
+	backend.VerifyWasCalled(Never()).Freeze(matchers.AnyModelsFreeze())
+}
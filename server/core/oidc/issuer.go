@@ -0,0 +1,122 @@
+// Package oidc lets Atlantis act as an OpenID Connect identity provider so
+// that cloud providers can exchange the tokens it issues for short-lived
+// credentials.
+package oidc
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DiscoveryPath is the path that the issuer's discovery document is
+	// served on, relative to the issuer URL.
+	DiscoveryPath = "/.well-known/openid-configuration"
+	// JWKSPath is the path that the issuer's public keys are served on,
+	// relative to the issuer URL.
+	JWKSPath = "/.well-known/jwks"
+)
+
+// Issuer issues tokens signed with an RSA key.
+type Issuer struct {
+	url   string
+	key   *rsa.PrivateKey
+	keyID string
+}
+
+// NewIssuer returns an issuer whose tokens have url as their issuer and are
+// signed with the PEM encoded RSA private key keyPEM, which can be in PKCS #1
+// or PKCS #8 form.
+func NewIssuer(url string, keyPEM []byte) (*Issuer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("no PEM encoded key found")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if pkcs8Err != nil {
+			return nil, errors.Wrap(err, "parsing key")
+		}
+		var ok bool
+		if key, ok = parsed.(*rsa.PrivateKey); !ok {
+			return nil, errors.New("key must be an RSA key")
+		}
+	}
+
+	// The key ID is derived from the public key so that it changes when the
+	// key is rotated.
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding public key")
+	}
+	sum := sha256.Sum256(pub)
+	return &Issuer{
+		url:   strings.TrimSuffix(url, "/"),
+		key:   key,
+		keyID: base64.RawURLEncoding.EncodeToString(sum[:])[:16],
+	}, nil
+}
+
+// URL returns the issuer URL.
+func (i *Issuer) URL() string {
+	return i.url
+}
+
+// Token returns a signed token for subject and audience that expires after
+// ttl. claims are added to the token's standard claims.
+func (i *Issuer) Token(subject string, audience string, ttl time.Duration, claims map[string]interface{}) (string, error) {
+	now := time.Now()
+	mapClaims := jwt.MapClaims{}
+	for k, v := range claims {
+		mapClaims[k] = v
+	}
+	mapClaims["iss"] = i.url
+	mapClaims["sub"] = subject
+	mapClaims["aud"] = audience
+	mapClaims["iat"] = now.Unix()
+	mapClaims["nbf"] = now.Unix()
+	mapClaims["exp"] = now.Add(ttl).Unix()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, mapClaims)
+	token.Header["kid"] = i.keyID
+	signed, err := token.SignedString(i.key)
+	return signed, errors.Wrap(err, "signing token")
+}
+
+// Discovery returns the issuer's OpenID Connect discovery document.
+func (i *Issuer) Discovery() map[string]interface{} {
+	return map[string]interface{}{
+		"issuer":                                i.url,
+		"jwks_uri":                              i.url + JWKSPath,
+		"response_types_supported":              []string{"id_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"claims_supported":                      []string{"iss", "sub", "aud", "iat", "nbf", "exp"},
+	}
+}
+
+// JWKS returns the issuer's public keys as a JSON Web Key Set.
+func (i *Issuer) JWKS() map[string]interface{} {
+	return map[string]interface{}{
+		"keys": []map[string]interface{}{
+			{
+				"kty": "RSA",
+				"use": "sig",
+				"alg": "RS256",
+				"kid": i.keyID,
+				"n":   base64.RawURLEncoding.EncodeToString(i.key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(i.key.PublicKey.E)).Bytes()),
+			},
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package oidc_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/runatlantis/atlantis/server/core/oidc"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestNewIssuer_Errors(t *testing.T) {
+	_, err := oidc.NewIssuer("https://atlantis.example.com", []byte("not a key"))
+	ErrEquals(t, "no PEM encoded key found", err)
+
+	_, err = oidc.NewIssuer("https://atlantis.example.com", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("garbage")}))
+	ErrContains(t, "parsing key", err)
+}
+
+func TestIssuer_Token(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Ok(t, err)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	Ok(t, err)
+
+	for name, keyPEM := range map[string][]byte{
+		"pkcs1": pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		"pkcs8": pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			issuer, err := oidc.NewIssuer("https://atlantis.example.com/", keyPEM)
+			Ok(t, err)
+			Equals(t, "https://atlantis.example.com", issuer.URL())
+			Equals(t, "https://atlantis.example.com/.well-known/jwks", issuer.Discovery()["jwks_uri"])
+
+			signed, err := issuer.Token("repo:owner/repo", "sts.amazonaws.com", time.Hour, map[string]interface{}{"workspace": "default"})
+			Ok(t, err)
+
+			// The token must be verifiable with the published key.
+			jwk := issuer.JWKS()["keys"].([]map[string]interface{})[0]
+			n, err := base64.RawURLEncoding.DecodeString(jwk["n"].(string))
+			Ok(t, err)
+			e, err := base64.RawURLEncoding.DecodeString(jwk["e"].(string))
+			Ok(t, err)
+			pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+
+			token, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+				Equals(t, jwk["kid"], token.Header["kid"])
+				return pub, nil
+			})
+			Ok(t, err)
+			claims := token.Claims.(jwt.MapClaims)
+			Equals(t, "https://atlantis.example.com", claims["iss"])
+			Equals(t, "repo:owner/repo", claims["sub"])
+			Equals(t, "sts.amazonaws.com", claims["aud"])
+			Equals(t, "default", claims["workspace"])
+			Equals(t, float64(time.Hour/time.Second), claims["exp"].(float64)-claims["iat"].(float64))
+		})
+	}
+}
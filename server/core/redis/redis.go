@@ -23,7 +23,12 @@ type RedisDB struct { // nolint: revive
 }
 
 const (
-	pullKeySeparator = "::"
+	pullKeySeparator     = "::"
+	freezeKeyPrefix      = "global/freeze/"
+	inventoryKeyPrefix   = "global/inventory/"
+	projectRunKeyPrefix  = "global/project-run/"
+	lockContentionPrefix = "global/lock-contention/"
+	jobKeyPrefix         = "global/job/"
 )
 
 func New(hostname string, port int, password string, tlsEnabled bool, insecureSkipVerify bool, db int) (*RedisDB, error) {
@@ -237,6 +242,227 @@ func (r *RedisDB) CheckCommandLock(cmdName command.Name) (*command.Lock, error)
 	}
 }
 
+// Freeze creates or replaces the freeze for freeze's repo and project.
+func (r *RedisDB) Freeze(freeze models.Freeze) error {
+	serialized, _ := json.Marshal(freeze)
+	err := r.client.Set(ctx, r.freezeKey(freeze.RepoFullName, freeze.Project), serialized, 0).Err()
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// Unfreeze deletes the freeze for repoFullName and project and returns it.
+// If there is no freeze, it returns a nil pointer.
+func (r *RedisDB) Unfreeze(repoFullName string, project string) (*models.Freeze, error) {
+	key := r.freezeKey(repoFullName, project)
+	val, err := r.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	var freeze models.Freeze
+	if err := json.Unmarshal([]byte(val), &freeze); err != nil {
+		return nil, errors.Wrapf(err, "deserializing freeze at key %q", key)
+	}
+	if err := r.client.Del(ctx, key).Err(); err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	return &freeze, nil
+}
+
+// ListFreezes returns all freezes.
+func (r *RedisDB) ListFreezes() ([]models.Freeze, error) {
+	var freezes []models.Freeze
+	iter := r.client.Scan(ctx, 0, freezeKeyPrefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		val, err := r.client.Get(ctx, iter.Val()).Result()
+		if err != nil {
+			return nil, errors.Wrap(err, "db transaction failed")
+		}
+		var freeze models.Freeze
+		if err := json.Unmarshal([]byte(val), &freeze); err != nil {
+			return freezes, errors.Wrapf(err, "deserializing freeze at key %q", iter.Val())
+		}
+		freezes = append(freezes, freeze)
+	}
+	if err := iter.Err(); err != nil {
+		return freezes, errors.Wrap(err, "db transaction failed")
+	}
+	return freezes, nil
+}
+
+// UpdateInventory creates or replaces the inventory of inventory's project.
+func (r *RedisDB) UpdateInventory(inventory models.ProjectInventory) error {
+	serialized, _ := json.Marshal(inventory)
+	err := r.client.Set(ctx, r.inventoryKey(inventory), serialized, 0).Err()
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// ListInventory returns the inventory of every project.
+func (r *RedisDB) ListInventory() ([]models.ProjectInventory, error) {
+	var inventory []models.ProjectInventory
+	iter := r.client.Scan(ctx, 0, inventoryKeyPrefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		val, err := r.client.Get(ctx, iter.Val()).Result()
+		if err != nil {
+			return nil, errors.Wrap(err, "db transaction failed")
+		}
+		var projInventory models.ProjectInventory
+		if err := json.Unmarshal([]byte(val), &projInventory); err != nil {
+			return inventory, errors.Wrapf(err, "deserializing inventory at key %q", iter.Val())
+		}
+		inventory = append(inventory, projInventory)
+	}
+	if err := iter.Err(); err != nil {
+		return inventory, errors.Wrap(err, "db transaction failed")
+	}
+	return inventory, nil
+}
+
+// UpdateProjectRun creates or replaces the last run of run's command for
+// run's project, keeping the durations of the previous runs.
+func (r *RedisDB) UpdateProjectRun(run models.ProjectRun) error {
+	key := r.projectRunKey(run)
+	var previous *models.ProjectRun
+	val, err := r.client.Get(ctx, key).Result()
+	switch {
+	case err == redis.Nil:
+	case err != nil:
+		return errors.Wrap(err, "db transaction failed")
+	default:
+		previous = &models.ProjectRun{}
+		if err := json.Unmarshal([]byte(val), previous); err != nil {
+			return errors.Wrapf(err, "deserializing project run at key %q", key)
+		}
+	}
+	serialized, _ := json.Marshal(run.WithDurations(previous))
+	err = r.client.Set(ctx, key, serialized, 0).Err()
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// ListProjectRuns returns the last plan and apply of every project.
+func (r *RedisDB) ListProjectRuns() ([]models.ProjectRun, error) {
+	var runs []models.ProjectRun
+	iter := r.client.Scan(ctx, 0, projectRunKeyPrefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		val, err := r.client.Get(ctx, iter.Val()).Result()
+		if err != nil {
+			return nil, errors.Wrap(err, "db transaction failed")
+		}
+		var run models.ProjectRun
+		if err := json.Unmarshal([]byte(val), &run); err != nil {
+			return runs, errors.Wrapf(err, "deserializing project run at key %q", iter.Val())
+		}
+		runs = append(runs, run)
+	}
+	if err := iter.Err(); err != nil {
+		return runs, errors.Wrap(err, "db transaction failed")
+	}
+	return runs, nil
+}
+
+// RecordLockAttempt records attempt in the lock contention of its project's
+// workspace.
+func (r *RedisDB) RecordLockAttempt(attempt models.LockAttempt) error {
+	key := r.lockContentionKey(attempt)
+	var contention models.LockContention
+	val, err := r.client.Get(ctx, key).Result()
+	switch {
+	case err == redis.Nil:
+	case err != nil:
+		return errors.Wrap(err, "db transaction failed")
+	default:
+		if err := json.Unmarshal([]byte(val), &contention); err != nil {
+			return errors.Wrapf(err, "deserializing lock contention at key %q", key)
+		}
+	}
+	contention, changed := contention.WithAttempt(attempt)
+	if !changed {
+		return nil
+	}
+	serialized, _ := json.Marshal(contention)
+	err = r.client.Set(ctx, key, serialized, 0).Err()
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// ListLockContention returns the lock contention of every project's
+// workspaces whose locks blocked pull requests.
+func (r *RedisDB) ListLockContention() ([]models.LockContention, error) {
+	var contention []models.LockContention
+	iter := r.client.Scan(ctx, 0, lockContentionPrefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		val, err := r.client.Get(ctx, iter.Val()).Result()
+		if err != nil {
+			return nil, errors.Wrap(err, "db transaction failed")
+		}
+		var lockContention models.LockContention
+		if err := json.Unmarshal([]byte(val), &lockContention); err != nil {
+			return contention, errors.Wrapf(err, "deserializing lock contention at key %q", iter.Val())
+		}
+		contention = append(contention, lockContention)
+	}
+	if err := iter.Err(); err != nil {
+		return contention, errors.Wrap(err, "db transaction failed")
+	}
+	return contention, nil
+}
+
+// SaveJob creates or replaces the job with job's ID.
+func (r *RedisDB) SaveJob(job models.Job) error {
+	serialized, _ := json.Marshal(job)
+	err := r.client.Set(ctx, jobKeyPrefix+job.ID, serialized, 0).Err()
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// GetJob returns the job with ID jobID. If there is no such job, it returns
+// nil.
+func (r *RedisDB) GetJob(jobID string) (*models.Job, error) {
+	val, err := r.client.Get(ctx, jobKeyPrefix+jobID).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	var job models.Job
+	if err := json.Unmarshal([]byte(val), &job); err != nil {
+		return nil, errors.Wrapf(err, "deserializing job %q", jobID)
+	}
+	return &job, nil
+}
+
+// Ping pings the Redis server.
+func (r *RedisDB) Ping() error {
+	return r.client.Ping(ctx).Err()
+}
+
+// DeleteJobsCompletedBefore deletes the jobs that completed before t and
+// returns how many were deleted.
+func (r *RedisDB) DeleteJobsCompletedBefore(t time.Time) (int, error) {
+	deleted := 0
+	iter := r.client.Scan(ctx, 0, jobKeyPrefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		val, err := r.client.Get(ctx, iter.Val()).Result()
+		if err != nil {
+			return deleted, errors.Wrap(err, "db transaction failed")
+		}
+		var job models.Job
+		if err := json.Unmarshal([]byte(val), &job); err != nil {
+			return deleted, errors.Wrapf(err, "deserializing job at key %q", iter.Val())
+		}
+		if !job.CompletedAt.Before(t) {
+			continue
+		}
+		if err := r.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return deleted, errors.Wrap(err, "db transaction failed")
+		}
+		deleted++
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, errors.Wrap(err, "db transaction failed")
+	}
+	return deleted, nil
+}
+
 // UpdatePullWithResults updates pull's status with the latest project results.
 // It returns the new PullStatus object.
 func (r *RedisDB) UpdateProjectStatus(pull models.PullRequest, workspace string, repoRelDir string, newStatus models.ProjectPlanStatus) error {
@@ -330,6 +556,9 @@ func (r *RedisDB) UpdatePullWithResults(pull models.PullRequest, newResults []co
 					res.ProjectName == proj.ProjectName {
 
 					proj.Status = res.PlanStatus()
+					if res.JobID != "" {
+						proj.JobID = res.JobID
+					}
 					updatedExisting = true
 					break
 				}
@@ -384,6 +613,22 @@ func (r *RedisDB) commandLockKey(cmdName command.Name) string {
 	return fmt.Sprintf("global/%s/lock", cmdName)
 }
 
+func (r *RedisDB) freezeKey(repoFullName string, project string) string {
+	return fmt.Sprintf("%s%s%s%s", freezeKeyPrefix, repoFullName, pullKeySeparator, project)
+}
+
+func (r *RedisDB) inventoryKey(inventory models.ProjectInventory) string {
+	return fmt.Sprintf("%s%s", inventoryKeyPrefix, strings.Join([]string{inventory.RepoFullName, inventory.Path, inventory.Workspace, inventory.ProjectName}, pullKeySeparator))
+}
+
+func (r *RedisDB) projectRunKey(run models.ProjectRun) string {
+	return fmt.Sprintf("%s%s", projectRunKeyPrefix, strings.Join([]string{run.RepoFullName, run.Path, run.Workspace, run.ProjectName, run.Command}, pullKeySeparator))
+}
+
+func (r *RedisDB) lockContentionKey(attempt models.LockAttempt) string {
+	return fmt.Sprintf("%s%s", lockContentionPrefix, strings.Join([]string{attempt.RepoFullName, attempt.Path, attempt.Workspace}, pullKeySeparator))
+}
+
 func (r *RedisDB) pullKey(pull models.PullRequest) (string, error) {
 	hostname := pull.BaseRepo.VCSHost.Hostname
 	if strings.Contains(hostname, pullKeySeparator) {
@@ -399,9 +644,12 @@ func (r *RedisDB) pullKey(pull models.PullRequest) (string, error) {
 
 func (r *RedisDB) projectResultToProject(p command.ProjectResult) models.ProjectStatus {
 	return models.ProjectStatus{
-		Workspace:   p.Workspace,
-		RepoRelDir:  p.RepoRelDir,
-		ProjectName: p.ProjectName,
-		Status:      p.PlanStatus(),
+		Workspace:          p.Workspace,
+		RepoRelDir:         p.RepoRelDir,
+		ProjectName:        p.ProjectName,
+		Status:             p.PlanStatus(),
+		JobID:              p.JobID,
+		EphemeralWorkspace: p.EphemeralWorkspace,
+		DestroyOnClose:     p.DestroyOnClose,
 	}
 }
@@ -156,6 +156,152 @@ func TestMixedLocksPresent(t *testing.T) {
 	Equals(t, 1, len(ls))
 }
 
+func TestFreeze(t *testing.T) {
+	t.Log("freezes can be created, listed and deleted")
+	s := miniredis.RunT(t)
+	r := newTestRedis(s)
+	global := models.Freeze{Message: "incident", Time: time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)}
+	project := models.Freeze{RepoFullName: "owner/repo", Project: "prod", Time: time.Date(2022, 8, 1, 13, 0, 0, 0, time.UTC)}
+	Ok(t, r.Freeze(global))
+	Ok(t, r.Freeze(project))
+
+	freezes, err := r.ListFreezes()
+	Ok(t, err)
+	Equals(t, 2, len(freezes))
+
+	// Freezing again replaces the message.
+	global.Message = "change freeze"
+	Ok(t, r.Freeze(global))
+	freezes, err = r.ListFreezes()
+	Ok(t, err)
+	Equals(t, 2, len(freezes))
+
+	deleted, err := r.Unfreeze("", "")
+	Ok(t, err)
+	Equals(t, &global, deleted)
+
+	deleted, err = r.Unfreeze("", "")
+	Ok(t, err)
+	Assert(t, deleted == nil, "exp nil")
+
+	freezes, err = r.ListFreezes()
+	Ok(t, err)
+	Equals(t, []models.Freeze{project}, freezes)
+}
+
+func TestInventory(t *testing.T) {
+	t.Log("inventory can be updated and listed")
+	s := miniredis.RunT(t)
+	r := newTestRedis(s)
+	staging := models.ProjectInventory{
+		RepoFullName: "owner/repo",
+		Path:         "staging",
+		Workspace:    "default",
+		Providers:    []models.InventoryProvider{{Source: "registry.terraform.io/hashicorp/aws", Version: "4.0.0"}},
+		Time:         time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC),
+	}
+	Ok(t, r.UpdateInventory(staging))
+
+	// Updating a project again replaces its inventory.
+	staging.Providers = []models.InventoryProvider{{Source: "registry.terraform.io/hashicorp/aws", Version: "4.1.0"}}
+	Ok(t, r.UpdateInventory(staging))
+
+	inventory, err := r.ListInventory()
+	Ok(t, err)
+	Equals(t, []models.ProjectInventory{staging}, inventory)
+}
+
+func TestProjectRuns(t *testing.T) {
+	t.Log("the last plan and apply of projects can be updated and listed")
+	s := miniredis.RunT(t)
+	r := newTestRedis(s)
+	plan := models.ProjectRun{
+		RepoFullName: "owner/repo",
+		Path:         "staging",
+		Workspace:    "default",
+		Command:      "plan",
+		Outcome:      models.FailedProjectRunOutcome,
+		Time:         time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC),
+	}
+	Ok(t, r.UpdateProjectRun(plan))
+
+	// Updating a command again replaces its last run.
+	plan.Outcome = models.SuccessProjectRunOutcome
+	Ok(t, r.UpdateProjectRun(plan))
+
+	runs, err := r.ListProjectRuns()
+	Ok(t, err)
+	Equals(t, []models.ProjectRun{plan}, runs)
+}
+
+func TestProjectRuns_RecentDurations(t *testing.T) {
+	t.Log("updating a project's run keeps the durations of its previous runs")
+	s := miniredis.RunT(t)
+	r := newTestRedis(s)
+	run := models.ProjectRun{
+		RepoFullName: "owner/repo",
+		Path:         "staging",
+		Workspace:    "default",
+		Command:      "apply",
+		Duration:     time.Minute,
+	}
+	Ok(t, r.UpdateProjectRun(run))
+	run.Duration = 3 * time.Minute
+	Ok(t, r.UpdateProjectRun(run))
+
+	runs, err := r.ListProjectRuns()
+	Ok(t, err)
+	Equals(t, 1, len(runs))
+	Equals(t, 2, len(runs[0].Durations))
+	Equals(t, 3*time.Minute, runs[0].Durations[1].Duration)
+	Equals(t, 2*time.Minute, runs[0].ExpectedDuration())
+}
+
+func TestLockContention(t *testing.T) {
+	t.Log("blocked lock attempts are recorded until the lock is acquired")
+	s := miniredis.RunT(t)
+	r := newTestRedis(s)
+	attempt := models.LockAttempt{RepoFullName: "owner/repo", Path: "staging", Workspace: "default", PullNum: 2, Time: time.Now()}
+	Ok(t, r.RecordLockAttempt(attempt))
+	contention, err := r.ListLockContention()
+	Ok(t, err)
+	Equals(t, 0, len(contention))
+
+	attempt.BlockingPullNum = 1
+	Ok(t, r.RecordLockAttempt(attempt))
+	attempt.BlockingPullNum = 0
+	attempt.Time = attempt.Time.Add(time.Hour)
+	Ok(t, r.RecordLockAttempt(attempt))
+
+	contention, err = r.ListLockContention()
+	Ok(t, err)
+	Equals(t, 1, len(contention))
+	Equals(t, 1, len(contention[0].Waits))
+	Equals(t, 1, contention[0].Waits[0].BlockingPullNum)
+	Assert(t, !contention[0].Waits[0].Blocked(), "exp wait to have ended")
+}
+
+func TestJobs(t *testing.T) {
+	t.Log("jobs can be saved, fetched and expired")
+	s := miniredis.RunT(t)
+	r := newTestRedis(s)
+	old := models.Job{ID: "old", Lines: []string{"line"}, CompletedAt: time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)}
+	recent := models.Job{ID: "recent", Lines: []string{"line"}, CompletedAt: time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)}
+	Ok(t, r.SaveJob(old))
+	Ok(t, r.SaveJob(recent))
+
+	deleted, err := r.DeleteJobsCompletedBefore(time.Date(2022, 8, 15, 0, 0, 0, 0, time.UTC))
+	Ok(t, err)
+	Equals(t, 1, deleted)
+
+	job, err := r.GetJob("old")
+	Ok(t, err)
+	Assert(t, job == nil, "exp old job to be deleted")
+	job, err = r.GetJob("recent")
+	Ok(t, err)
+	Equals(t, &recent, job)
+}
+
 func TestListNoLocks(t *testing.T) {
 	t.Log("listing locks when there are none should return an empty list")
 	s := miniredis.RunT(t)
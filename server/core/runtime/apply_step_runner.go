@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -20,6 +21,12 @@ type ApplyStepRunner struct {
 	DefaultTFVersion    *version.Version
 	CommitStatusUpdater StatusUpdater
 	AsyncTFExec         AsyncTFExec
+	// StateLockRetries is how many times to retry an apply that failed
+	// because the state was locked by another operation.
+	StateLockRetries int
+	// StateLockRetryDelay is how long to wait before the first retry. Each
+	// following retry waits twice as long as the previous one.
+	StateLockRetryDelay time.Duration
 }
 
 func (a *ApplyStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
@@ -50,7 +57,7 @@ func (a *ApplyStepRunner) Run(ctx command.ProjectContext, extraArgs []string, pa
 		// NOTE: we need to quote the plan path because Bitbucket Server can
 		// have spaces in its repo owner names which is part of the path.
 		args := append(append(append([]string{"apply", "-input=false"}, extraArgs...), ctx.EscapedCommentArgs...), fmt.Sprintf("%q", planPath))
-		out, err = a.TerraformExecutor.RunCommandWithVersion(ctx, path, args, envs, ctx.TerraformVersion, ctx.Workspace)
+		out, err = a.runApplyWithRetries(ctx, path, args, envs)
 	}
 
 	// If the apply was successful, delete the plan.
@@ -63,6 +70,30 @@ func (a *ApplyStepRunner) Run(ctx command.ProjectContext, extraArgs []string, pa
 	return out, err
 }
 
+// runApplyWithRetries runs the apply and retries it with exponential backoff
+// while the state is locked by another operation. If the state is still
+// locked, the lock info is added to the output.
+func (a *ApplyStepRunner) runApplyWithRetries(ctx command.ProjectContext, path string, args []string, envs map[string]string) (string, error) {
+	delay := a.StateLockRetryDelay
+	for attempt := 0; ; attempt++ {
+		out, err := a.TerraformExecutor.RunCommandWithVersion(ctx, path, args, envs, ctx.TerraformVersion, ctx.Workspace)
+		if err == nil {
+			return out, nil
+		}
+		info, locked := ParseStateLockInfo(out)
+		if !locked {
+			return out, err
+		}
+		if attempt >= a.StateLockRetries {
+			ctx.Log.Warn("state is locked by %q with lock ID %q", info.Who, info.ID)
+			return fmt.Sprintf("%s\n\n%s", info.Summary(ctx), out), err
+		}
+		ctx.Log.Info("state is locked by %q, retrying apply in %s", info.Who, delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
 func (a *ApplyStepRunner) hasTargetFlag(ctx command.ProjectContext, extraArgs []string) bool {
 	isTargetFlag := func(s string) bool {
 		if s == "-target" {
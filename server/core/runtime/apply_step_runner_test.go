@@ -453,3 +453,77 @@ var noConfirmationOut = `
 
 Error: Apply discarded.
 `
+
+const stateLockedOutput = `
+Error: Error acquiring the state lock
+
+Error message: ConditionalCheckFailedException: The conditional request failed
+Lock Info:
+  ID:        5d1c2a3e-04b1-2c4d-9f1e-3b8a5e7d6c01
+  Path:      my-bucket/prod/terraform.tfstate
+  Operation: OperationTypeApply
+  Who:       jane@laptop
+  Version:   1.2.3
+  Created:   2022-06-01 10:00:00.000000000 +0000 UTC
+  Info:
+`
+
+func TestRun_StateLockedRetries(t *testing.T) {
+	tmpDir, cleanup := TempDir(t)
+	defer cleanup()
+	planPath := filepath.Join(tmpDir, "workspace.tfplan")
+	Ok(t, os.WriteFile(planPath, nil, 0600))
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Workspace:  "workspace",
+		RepoRelDir: ".",
+	}
+
+	RegisterMockTestingT(t)
+	terraform := mocks.NewMockClient()
+	o := runtime.ApplyStepRunner{
+		TerraformExecutor: terraform,
+		StateLockRetries:  1,
+	}
+
+	When(terraform.RunCommandWithVersion(matchers.AnyModelsProjectCommandContext(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString())).
+		ThenReturn(stateLockedOutput, errors.New("exit status 1")).
+		ThenReturn("output", nil)
+	output, err := o.Run(ctx, nil, tmpDir, map[string]string(nil))
+	Ok(t, err)
+	Equals(t, "output", output)
+	terraform.VerifyWasCalled(Times(2)).RunCommandWithVersion(matchers.AnyModelsProjectCommandContext(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString())
+}
+
+func TestRun_StateLocked(t *testing.T) {
+	tmpDir, cleanup := TempDir(t)
+	defer cleanup()
+	planPath := filepath.Join(tmpDir, "workspace.tfplan")
+	Ok(t, os.WriteFile(planPath, nil, 0600))
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Workspace:  "workspace",
+		RepoRelDir: ".",
+	}
+
+	RegisterMockTestingT(t)
+	terraform := mocks.NewMockClient()
+	o := runtime.ApplyStepRunner{
+		TerraformExecutor: terraform,
+	}
+
+	When(terraform.RunCommandWithVersion(matchers.AnyModelsProjectCommandContext(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString())).
+		ThenReturn(stateLockedOutput, errors.New("exit status 1"))
+	output, err := o.Run(ctx, nil, tmpDir, map[string]string(nil))
+	ErrEquals(t, "exit status 1", err)
+	Assert(t, strings.HasPrefix(output, `The Terraform state is locked by another operation.
+  Lock ID:   5d1c2a3e-04b1-2c4d-9f1e-3b8a5e7d6c01
+  Held by:   jane@laptop
+  Operation: OperationTypeApply
+  Created:   2022-06-01 10:00:00.000000000 +0000 UTC
+  Path:      my-bucket/prod/terraform.tfstate
+Wait for that operation to finish and apply again. If the lock is stale, an admin can remove it by commenting:
+  atlantis force_unlock_state 5d1c2a3e-04b1-2c4d-9f1e-3b8a5e7d6c01 -d . -w workspace`), "got %q", output)
+	_, err = os.Stat(planPath)
+	Ok(t, err)
+}
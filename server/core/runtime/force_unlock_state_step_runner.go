@@ -0,0 +1,30 @@
+package runtime
+
+import (
+	"path/filepath"
+
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// ForceUnlockStateStepRunner runs terraform force-unlock to remove the state
+// lock with the ID in ctx.
+type ForceUnlockStateStepRunner struct {
+	TerraformExecutor TerraformExec
+	DefaultTFVersion  *version.Version
+}
+
+func (f *ForceUnlockStateStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	if ctx.StateLockID == "" {
+		return "", errors.New("no lock ID given")
+	}
+	tfVersion := f.DefaultTFVersion
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+
+	ctx.Log.Info("removing state lock %q", ctx.StateLockID)
+	args := []string{"force-unlock", "-force", ctx.StateLockID}
+	return f.TerraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), args, envs, tfVersion, ctx.Workspace)
+}
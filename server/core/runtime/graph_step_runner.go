@@ -0,0 +1,29 @@
+package runtime
+
+import (
+	"path/filepath"
+
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// GraphStepRunner runs terraform graph to get the dependency graph of a
+// project's resources in the DOT language.
+type GraphStepRunner struct {
+	TerraformExecutor TerraformExec
+	DefaultTFVersion  *version.Version
+}
+
+func (g *GraphStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfVersion := g.DefaultTFVersion
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+	args := append([]string{"graph"}, extraArgs...)
+	output, err := g.TerraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), args, envs, tfVersion, ctx.Workspace)
+	if err != nil {
+		return "", errors.Wrap(err, "running terraform graph")
+	}
+	return output, nil
+}
@@ -0,0 +1,41 @@
+package runtime
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestGraphStepRunner(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockExecutor := mocks.NewMockClient()
+	defaultVersion, _ := version.NewVersion("1.3.0")
+	projectVersion, _ := version.NewVersion("1.2.9")
+	envs := map[string]string{"key": "val"}
+	ctx := command.ProjectContext{
+		Workspace:        "staging",
+		Log:              logging.NewNoopLogger(t),
+		TerraformVersion: projectVersion,
+	}
+	subject := GraphStepRunner{
+		TerraformExecutor: mockExecutor,
+		DefaultTFVersion:  defaultVersion,
+	}
+
+	When(mockExecutor.RunCommandWithVersion(ctx, "/path", []string{"graph", "-type=plan"}, envs, projectVersion, "staging")).
+		ThenReturn("digraph {\n}\n", nil)
+	out, err := subject.Run(ctx, []string{"-type=plan"}, "/path", envs)
+	Ok(t, err)
+	Equals(t, "digraph {\n}\n", out)
+
+	When(mockExecutor.RunCommandWithVersion(ctx, "/path", []string{"graph"}, envs, projectVersion, "staging")).
+		ThenReturn("", errors.New("exit status 1"))
+	_, err = subject.Run(ctx, nil, "/path", envs)
+	ErrEquals(t, "running terraform graph: exit status 1", err)
+}
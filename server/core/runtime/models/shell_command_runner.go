@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"io"
 	"os/exec"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -13,6 +14,10 @@ import (
 	"github.com/runatlantis/atlantis/server/jobs"
 )
 
+// terraformLogLineRegex matches the lines Terraform writes to stderr when
+// TF_LOG is set, ex. "2022-10-20T14:02:11.000Z [TRACE] ...".
+var terraformLogLineRegex = regexp.MustCompile(`^\d{4}[-/]\d{2}[-/]\d{2}[T ]\S+ \[(TRACE|DEBUG|INFO|WARN|ERROR)\]`)
+
 // Setting the buffer size to 10mb
 const BufioScannerBufferSize = 10 * 1024 * 1024
 
@@ -91,13 +96,14 @@ func (s *ShellCommandRunner) RunCommandAsync(ctx command.ProjectContext) (chan<-
 		stdin, _ := s.cmd.StdinPipe()
 
 		ctx.Log.Debug("starting %q in %q", s.command, s.workingDir)
-		err := s.cmd.Start()
+		done, err := startCmd(ctx, s.cmd)
 		if err != nil {
 			err = errors.Wrapf(err, "running %q in %q", s.command, s.workingDir)
 			ctx.Log.Err(err.Error())
 			outCh <- Line{Err: err}
 			return
 		}
+		defer done()
 
 		// If we get anything on inCh, write it to stdin.
 		// This function will exit when inCh is closed which we do in our defer.
@@ -132,6 +138,12 @@ func (s *ShellCommandRunner) RunCommandAsync(ctx command.ProjectContext) (chan<-
 			scanner := bufio.NewScanner(stderr)
 			for scanner.Scan() {
 				message := scanner.Text()
+				// Terraform's log is only sent to the job output so that it
+				// doesn't end up in the comment.
+				if s.streamOutput && ctx.TerraformLogLevel != "" && terraformLogLineRegex.MatchString(message) {
+					s.outputHandler.Send(ctx, message, false)
+					continue
+				}
 				outCh <- Line{Line: message}
 				if s.streamOutput {
 					s.outputHandler.Send(ctx, message, false)
@@ -159,3 +171,12 @@ func (s *ShellCommandRunner) RunCommandAsync(ctx command.ProjectContext) (chan<-
 
 	return inCh, outCh
 }
+
+// startCmd starts cmd with ctx's process starter, if it has one, so that it
+// can be cancelled. done must be called once cmd has exited.
+func startCmd(ctx command.ProjectContext, cmd *exec.Cmd) (done func(), err error) {
+	if ctx.Processes != nil {
+		return ctx.Processes.Start(ctx.JobID, cmd)
+	}
+	return func() {}, cmd.Start()
+}
@@ -73,3 +73,26 @@ func TestShellCommandRunner_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestShellCommandRunner_Run_TerraformLogLevel(t *testing.T) {
+	RegisterMockTestingT(t)
+	ctx := command.ProjectContext{
+		Log:               logging.NewNoopLogger(t),
+		Workspace:         "default",
+		RepoRelDir:        ".",
+		TerraformLogLevel: "TRACE",
+	}
+	projectCmdOutputHandler := mocks.NewMockProjectCommandOutputHandler()
+	cwd, err := os.Getwd()
+	Ok(t, err)
+
+	logLine := "2022-10-20T14:02:11.000Z [TRACE] provider: plugin exited"
+	runner := models.NewShellCommandRunner(fmt.Sprintf(">&2 echo '%s'; >&2 echo 'Error: failed'", logLine), nil, cwd, true, projectCmdOutputHandler)
+	output, err := runner.Run(ctx)
+	Ok(t, err)
+
+	// Terraform's log is only sent to the job output.
+	Equals(t, "Error: failed\n", output)
+	projectCmdOutputHandler.VerifyWasCalledOnce().Send(ctx, logLine, false)
+	projectCmdOutputHandler.VerifyWasCalledOnce().Send(ctx, "Error: failed", false)
+}
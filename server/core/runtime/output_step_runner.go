@@ -0,0 +1,29 @@
+package runtime
+
+import (
+	"path/filepath"
+
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// OutputStepRunner runs terraform output -json so that a project's outputs
+// can be captured after it's applied.
+type OutputStepRunner struct {
+	TerraformExecutor TerraformExec
+	DefaultTFVersion  *version.Version
+}
+
+func (o *OutputStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfVersion := o.DefaultTFVersion
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+	args := append([]string{"output", "-json"}, extraArgs...)
+	output, err := o.TerraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), args, envs, tfVersion, ctx.Workspace)
+	if err != nil {
+		return "", errors.Wrap(err, "running terraform output")
+	}
+	return output, nil
+}
@@ -0,0 +1,39 @@
+package runtime
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestOutputStepRunner(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockExecutor := mocks.NewMockClient()
+	tfVersion, _ := version.NewVersion("1.3.0")
+	envs := map[string]string{"key": "val"}
+	ctx := command.ProjectContext{
+		Workspace: "default",
+		Log:       logging.NewNoopLogger(t),
+	}
+	subject := OutputStepRunner{
+		TerraformExecutor: mockExecutor,
+		DefaultTFVersion:  tfVersion,
+	}
+
+	When(mockExecutor.RunCommandWithVersion(ctx, "/path", []string{"output", "-json"}, envs, tfVersion, "default")).
+		ThenReturn(`{"vpc_id":{"sensitive":false,"type":"string","value":"vpc-123"}}`, nil)
+	out, err := subject.Run(ctx, nil, "/path", envs)
+	Ok(t, err)
+	Equals(t, `{"vpc_id":{"sensitive":false,"type":"string","value":"vpc-123"}}`, out)
+
+	When(mockExecutor.RunCommandWithVersion(ctx, "/path", []string{"output", "-json"}, envs, tfVersion, "default")).
+		ThenReturn("", errors.New("no state"))
+	_, err = subject.Run(ctx, nil, "/path", envs)
+	ErrEquals(t, "running terraform output: no state", err)
+}
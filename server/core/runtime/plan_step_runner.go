@@ -9,6 +9,7 @@ import (
 
 	version "github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 )
@@ -120,6 +121,9 @@ func (p *PlanStepRunner) switchWorkspace(ctx command.ProjectContext, path string
 	if runningZeroPointNine {
 		workspaceCmd = "env"
 	}
+	if ctx.EphemeralWorkspace {
+		return p.switchEphemeralWorkspace(ctx, path, workspaceCmd, tfVersion, envs)
+	}
 
 	// Use `workspace show` to find out what workspace we're in now. If we're
 	// already in the right workspace then no need to switch. This will save us
@@ -152,6 +156,32 @@ func (p *PlanStepRunner) switchWorkspace(ctx command.ProjectContext, path string
 	return nil
 }
 
+// switchEphemeralWorkspace changes to the workspace of the pull request in
+// ctx, creating it the first time the project is planned. Atlantis only uses
+// workspaces it created for the pull request so that it never plans, applies
+// or, once the pull request is closed, deletes one that happened to exist.
+func (p *PlanStepRunner) switchEphemeralWorkspace(ctx command.ProjectContext, path string, workspaceCmd string, tfVersion *version.Version, envs map[string]string) error {
+	if !valid.IsPullWorkspace(ctx.Workspace, ctx.Pull.Num) {
+		return fmt.Errorf("workspace %q doesn't belong to pull request #%d", ctx.Workspace, ctx.Pull.Num)
+	}
+	marker := ephemeralWorkspaceMarker(path, ctx.RepoRelDir, ctx.Workspace)
+	if _, err := os.Stat(marker); err == nil {
+		out, err := p.TerraformExecutor.RunCommandWithVersion(ctx, path, []string{workspaceCmd, "select", ctx.Workspace}, envs, tfVersion, ctx.Workspace)
+		if err != nil {
+			return fmt.Errorf("%s: %s", err, out)
+		}
+		return nil
+	}
+	out, err := p.TerraformExecutor.RunCommandWithVersion(ctx, path, []string{workspaceCmd, "new", ctx.Workspace}, envs, tfVersion, ctx.Workspace)
+	if err != nil {
+		return fmt.Errorf("creating workspace %q, which must not exist yet because Atlantis only uses workspaces it created for this pull request: %s: %s", ctx.Workspace, err, out)
+	}
+	if err := os.MkdirAll(filepath.Dir(marker), 0700); err != nil {
+		return errors.Wrap(err, "recording that the workspace was created")
+	}
+	return errors.Wrap(os.WriteFile(marker, nil, 0600), "recording that the workspace was created")
+}
+
 func (p *PlanStepRunner) buildPlanCmd(ctx command.ProjectContext, extraArgs []string, path string, tfVersion *version.Version, planFile string) []string {
 	tfVars := p.tfVars(ctx, tfVersion)
 
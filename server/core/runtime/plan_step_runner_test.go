@@ -372,6 +372,62 @@ func TestRun_NoWorkspaceSwitchIfNotNecessary(t *testing.T) {
 	terraform.VerifyWasCalled(Never()).RunCommandWithVersion(ctx, "/path", []string{"workspace", "select", "workspace"}, map[string]string(nil), tfVersion, "workspace")
 }
 
+func TestRun_EphemeralWorkspace(t *testing.T) {
+	RegisterMockTestingT(t)
+	tfVersion, _ := version.NewVersion("1.3.0")
+	ctx := command.ProjectContext{
+		Log:                logging.NewNoopLogger(t),
+		Workspace:          "pr-2-feature",
+		RepoRelDir:         "app",
+		EphemeralWorkspace: true,
+		Pull:               models.PullRequest{Num: 2},
+	}
+	newArgs := []string{"workspace", "new", "pr-2-feature"}
+	selectArgs := []string{"workspace", "select", "pr-2-feature"}
+
+	t.Run("creates the workspace and selects it afterwards", func(t *testing.T) {
+		terraform := mocks.NewMockClient()
+		When(terraform.RunCommandWithVersion(matchers.AnyModelsProjectCommandContext(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString())).
+			ThenReturn("output", nil)
+		s := runtime.PlanStepRunner{TerraformExecutor: terraform, DefaultTFVersion: tfVersion}
+		path := filepath.Join(t.TempDir(), "pr-2-feature", "app")
+
+		_, err := s.Run(ctx, nil, path, map[string]string(nil))
+		Ok(t, err)
+		terraform.VerifyWasCalledOnce().RunCommandWithVersion(ctx, path, newArgs, map[string]string(nil), tfVersion, "pr-2-feature")
+		terraform.VerifyWasCalled(Never()).RunCommandWithVersion(ctx, path, selectArgs, map[string]string(nil), tfVersion, "pr-2-feature")
+
+		_, err = s.Run(ctx, nil, path, map[string]string(nil))
+		Ok(t, err)
+		terraform.VerifyWasCalledOnce().RunCommandWithVersion(ctx, path, newArgs, map[string]string(nil), tfVersion, "pr-2-feature")
+		terraform.VerifyWasCalledOnce().RunCommandWithVersion(ctx, path, selectArgs, map[string]string(nil), tfVersion, "pr-2-feature")
+	})
+
+	t.Run("doesn't use a workspace that already exists", func(t *testing.T) {
+		terraform := mocks.NewMockClient()
+		path := filepath.Join(t.TempDir(), "pr-2-feature", "app")
+		When(terraform.RunCommandWithVersion(ctx, path, newArgs, map[string]string(nil), tfVersion, "pr-2-feature")).
+			ThenReturn("Workspace \"pr-2-feature\" already exists", errors.New("exit status 1"))
+		s := runtime.PlanStepRunner{TerraformExecutor: terraform, DefaultTFVersion: tfVersion}
+
+		_, err := s.Run(ctx, nil, path, map[string]string(nil))
+		ErrContains(t, "Atlantis only uses workspaces it created for this pull request", err)
+		terraform.VerifyWasCalled(Never()).RunCommandWithVersion(ctx, path, selectArgs, map[string]string(nil), tfVersion, "pr-2-feature")
+		terraform.VerifyWasCalledOnce().RunCommandWithVersion(matchers.AnyModelsProjectCommandContext(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString())
+	})
+
+	t.Run("doesn't use a workspace of another pull request", func(t *testing.T) {
+		terraform := mocks.NewMockClient()
+		s := runtime.PlanStepRunner{TerraformExecutor: terraform, DefaultTFVersion: tfVersion}
+		prodCtx := ctx
+		prodCtx.Workspace = "prod"
+
+		_, err := s.Run(prodCtx, nil, t.TempDir(), map[string]string(nil))
+		ErrEquals(t, `workspace "prod" doesn't belong to pull request #2`, err)
+		terraform.VerifyWasCalled(Never()).RunCommandWithVersion(matchers.AnyModelsProjectCommandContext(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString())
+	})
+}
+
 func TestRun_AddsEnvVarFile(t *testing.T) {
 	// Test that if env/workspace.tfvars file exists we use -var-file option.
 	RegisterMockTestingT(t)
@@ -0,0 +1,87 @@
+package runtime
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// stateLockErr is printed by Terraform when it can't acquire the state lock.
+const stateLockErr = "Error acquiring the state lock"
+
+// lockInfoRegex matches the fields Terraform prints under "Lock Info:". Since
+// Terraform 0.15, diagnostics are prefixed with a box drawing character.
+var lockInfoRegex = regexp.MustCompile(`(?m)^[│\s]*(ID|Path|Operation|Who|Version|Created):[ \t]*(.*?)\s*$`)
+
+// StateLockInfo describes a Terraform state lock that's held by another
+// operation.
+type StateLockInfo struct {
+	ID        string
+	Path      string
+	Operation string
+	Who       string
+	Version   string
+	Created   string
+}
+
+// ParseStateLockInfo parses the lock info out of the output of a Terraform
+// command. It returns false if the command didn't fail because the state was
+// locked.
+func ParseStateLockInfo(output string) (StateLockInfo, bool) {
+	idx := strings.Index(output, stateLockErr)
+	if idx < 0 {
+		return StateLockInfo{}, false
+	}
+	var info StateLockInfo
+	lockInfo := output[idx:]
+	if i := strings.Index(lockInfo, "Lock Info:"); i >= 0 {
+		lockInfo = lockInfo[i:]
+	}
+	for _, match := range lockInfoRegex.FindAllStringSubmatch(lockInfo, -1) {
+		switch match[1] {
+		case "ID":
+			info.ID = match[2]
+		case "Path":
+			info.Path = match[2]
+		case "Operation":
+			info.Operation = match[2]
+		case "Who":
+			info.Who = match[2]
+		case "Version":
+			info.Version = match[2]
+		case "Created":
+			info.Created = match[2]
+		}
+	}
+	return info, true
+}
+
+// Summary describes who holds the lock and how it can be removed for the
+// project in ctx.
+func (i StateLockInfo) Summary(ctx command.ProjectContext) string {
+	var b strings.Builder
+	b.WriteString("The Terraform state is locked by another operation.\n")
+	for _, field := range []struct{ name, value string }{
+		{"Lock ID", i.ID},
+		{"Held by", i.Who},
+		{"Operation", i.Operation},
+		{"Created", i.Created},
+		{"Path", i.Path},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(&b, "  %-10s %s\n", field.name+":", field.value)
+		}
+	}
+	b.WriteString("Wait for that operation to finish and apply again.")
+	if i.ID != "" {
+		flags := fmt.Sprintf("-d %s -w %s", ctx.RepoRelDir, ctx.Workspace)
+		if ctx.ProjectName != "" {
+			flags = fmt.Sprintf("-p %s", ctx.ProjectName)
+		}
+		fmt.Fprintf(&b, " If the lock is stale, an admin can remove it by commenting:\n  atlantis %s %s %s",
+			command.ForceUnlockState.String(), i.ID, flags)
+	}
+	return b.String()
+}
@@ -0,0 +1,73 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestParseStateLockInfo(t *testing.T) {
+	cases := map[string]struct {
+		output    string
+		expLocked bool
+		expInfo   runtime.StateLockInfo
+	}{
+		"not locked": {
+			output: "Error: Invalid provider configuration",
+		},
+		"terraform 0.14": {
+			output: `Error: Error locking state: Error acquiring the state lock: storage: service returned error: StatusCode=409, ErrorCode=LeaseAlreadyPresent
+Lock Info:
+  ID:        e2d5e6a1-c8d7-3f3a-1b3c-b1e2d3f4a5b6
+  Path:      tfstate/prod.tfstate
+  Operation: OperationTypePlan
+  Who:       runner@ci
+  Version:   0.14.11
+  Created:   2021-09-01 10:00:00 +0000 UTC
+  Info:
+`,
+			expLocked: true,
+			expInfo: runtime.StateLockInfo{
+				ID:        "e2d5e6a1-c8d7-3f3a-1b3c-b1e2d3f4a5b6",
+				Path:      "tfstate/prod.tfstate",
+				Operation: "OperationTypePlan",
+				Who:       "runner@ci",
+				Version:   "0.14.11",
+				Created:   "2021-09-01 10:00:00 +0000 UTC",
+			},
+		},
+		"terraform 1.x diagnostics": {
+			output: `╷
+│ Error: Error acquiring the state lock
+│ 
+│ Error message: writing "gs://bucket/default.tflock" failed: googleapi: Error 412
+│ Lock Info:
+│   ID:        1654077600123456
+│   Path:      gs://bucket/default.tflock
+│   Operation: OperationTypeApply
+│   Who:       jane@laptop
+│   Version:   1.2.3
+│   Created:   2022-06-01 10:00:00.123 +0000 UTC
+│   Info:      
+╵
+`,
+			expLocked: true,
+			expInfo: runtime.StateLockInfo{
+				ID:        "1654077600123456",
+				Path:      "gs://bucket/default.tflock",
+				Operation: "OperationTypeApply",
+				Who:       "jane@laptop",
+				Version:   "1.2.3",
+				Created:   "2022-06-01 10:00:00.123 +0000 UTC",
+			},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			info, locked := runtime.ParseStateLockInfo(c.output)
+			Equals(t, c.expLocked, locked)
+			Equals(t, c.expInfo, info)
+		})
+	}
+}
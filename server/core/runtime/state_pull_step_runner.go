@@ -0,0 +1,29 @@
+package runtime
+
+import (
+	"path/filepath"
+
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// StatePullStepRunner runs terraform state pull so that a project's state can
+// be snapshotted before it's applied.
+type StatePullStepRunner struct {
+	TerraformExecutor TerraformExec
+	DefaultTFVersion  *version.Version
+}
+
+func (s *StatePullStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfVersion := s.DefaultTFVersion
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+	args := append([]string{"state", "pull"}, extraArgs...)
+	output, err := s.TerraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), args, envs, tfVersion, ctx.Workspace)
+	if err != nil {
+		return "", errors.Wrap(err, "running terraform state pull")
+	}
+	return output, nil
+}
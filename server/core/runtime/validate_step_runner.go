@@ -0,0 +1,104 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// ValidateStepRunner runs `terraform validate` before the project is
+// initialized with its backend so that invalid configuration fails fast,
+// without waiting for the state to be read and refreshed.
+type ValidateStepRunner struct {
+	TerraformExecutor TerraformExec
+	DefaultTFVersion  *version.Version
+}
+
+// validateOutput is the output of terraform validate -json.
+type validateOutput struct {
+	Valid       bool                 `json:"valid"`
+	ErrorCount  int                  `json:"error_count"`
+	Diagnostics []validateDiagnostic `json:"diagnostics"`
+}
+
+type validateDiagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail"`
+	Range    *struct {
+		Filename string `json:"filename"`
+		Start    struct {
+			Line   int `json:"line"`
+			Column int `json:"column"`
+		} `json:"start"`
+	} `json:"range"`
+	Snippet *struct {
+		Code string `json:"code"`
+	} `json:"snippet"`
+}
+
+// Run initializes the project without its backend, so only its providers and
+// modules are downloaded, and then validates it. Providers are read from the
+// plugin cache that's shared by all projects so usually nothing is
+// downloaded. It returns an error that points to the file and line of each
+// problem if the configuration isn't valid, and no output if it is.
+func (v *ValidateStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfVersion := v.DefaultTFVersion
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+	if MustConstraint("< 0.12.0").Check(tfVersion) {
+		return "", fmt.Errorf("the validate step requires Terraform 0.12.0 or later, this project uses %s", tfVersion)
+	}
+
+	initCmd := []string{"init", "-backend=false", "-input=false"}
+	if out, err := v.TerraformExecutor.RunCommandWithVersion(ctx, path, initCmd, envs, tfVersion, ctx.Workspace); err != nil {
+		return out, err
+	}
+
+	validateCmd := append([]string{"validate", "-json"}, extraArgs...)
+	out, err := v.TerraformExecutor.RunCommandWithVersion(ctx, path, validateCmd, envs, tfVersion, ctx.Workspace)
+	var result validateOutput
+	if jsonErr := json.Unmarshal([]byte(out), &result); jsonErr != nil {
+		// If the output isn't JSON, terraform failed before validating, ex.
+		// because of an invalid flag, and the output says why.
+		if err != nil {
+			return out, err
+		}
+		return out, errors.Wrap(jsonErr, "parsing terraform validate output")
+	}
+	if result.Valid {
+		return "", nil
+	}
+	return "", errors.New(formatValidateDiagnostics(ctx.RepoRelDir, result))
+}
+
+// formatValidateDiagnostics returns the errors in result with the path of
+// the file, relative to the repo root, and the line they're on.
+func formatValidateDiagnostics(repoRelDir string, result validateOutput) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "terraform validate found %d error(s):\n", result.ErrorCount)
+	for _, d := range result.Diagnostics {
+		if d.Severity != "error" {
+			continue
+		}
+		b.WriteString("\n")
+		if d.Range != nil {
+			fmt.Fprintf(&b, "%s:%d: ", path.Join(repoRelDir, d.Range.Filename), d.Range.Start.Line)
+		}
+		b.WriteString(d.Summary)
+		if d.Detail != "" {
+			fmt.Fprintf(&b, ": %s", d.Detail)
+		}
+		b.WriteString("\n")
+		if d.Snippet != nil && d.Snippet.Code != "" {
+			fmt.Fprintf(&b, "  %s\n", strings.TrimSpace(d.Snippet.Code))
+		}
+	}
+	return b.String()
+}
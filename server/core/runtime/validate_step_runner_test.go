@@ -0,0 +1,106 @@
+package runtime
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestValidateStepRunner_Run(t *testing.T) {
+	invalidOutput := `{
+  "format_version": "1.0",
+  "valid": false,
+  "error_count": 2,
+  "warning_count": 1,
+  "diagnostics": [
+    {
+      "severity": "warning",
+      "summary": "Deprecated attribute",
+      "detail": "The attribute \"name\" is deprecated.",
+      "range": {"filename": "main.tf", "start": {"line": 3, "column": 3}}
+    },
+    {
+      "severity": "error",
+      "summary": "Unsupported argument",
+      "detail": "An argument named \"foo\" is not expected here.",
+      "range": {"filename": "main.tf", "start": {"line": 12, "column": 3}},
+      "snippet": {"code": "  foo = \"bar\""}
+    },
+    {
+      "severity": "error",
+      "summary": "Missing required provider",
+      "detail": ""
+    }
+  ]
+}`
+	cases := []struct {
+		description string
+		tfVersion   string
+		output      string
+		tfErr       error
+		expOut      string
+		expErr      string
+	}{
+		{
+			description: "valid",
+			tfVersion:   "1.1.0",
+			output:      `{"valid": true, "error_count": 0, "diagnostics": []}`,
+		},
+		{
+			description: "invalid",
+			tfVersion:   "1.1.0",
+			output:      invalidOutput,
+			tfErr:       errors.New("exit status 1"),
+			expErr: "terraform validate found 2 error(s):\n\n" +
+				"modules/app/main.tf:12: Unsupported argument: An argument named \"foo\" is not expected here.\n" +
+				"  foo = \"bar\"\n\n" +
+				"Missing required provider\n",
+		},
+		{
+			description: "not json",
+			tfVersion:   "1.1.0",
+			output:      "flag provided but not defined: -bad",
+			tfErr:       errors.New("exit status 1"),
+			expOut:      "flag provided but not defined: -bad",
+			expErr:      "exit status 1",
+		},
+		{
+			description: "old version",
+			tfVersion:   "0.11.14",
+			expErr:      "the validate step requires Terraform 0.12.0 or later, this project uses 0.11.14",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			terraform := mocks.NewMockClient()
+			tfVersion, _ := version.NewVersion(c.tfVersion)
+			ctx := command.ProjectContext{
+				Log:        logging.NewNoopLogger(t),
+				Workspace:  "default",
+				RepoRelDir: "modules/app",
+			}
+			When(terraform.RunCommandWithVersion(ctx, "/path", []string{"validate", "-json"}, map[string]string(nil), tfVersion, "default")).
+				ThenReturn(c.output, c.tfErr)
+			s := &ValidateStepRunner{
+				TerraformExecutor: terraform,
+				DefaultTFVersion:  tfVersion,
+			}
+
+			out, err := s.Run(ctx, nil, "/path", map[string]string(nil))
+			Equals(t, c.expOut, out)
+			if c.expErr != "" {
+				ErrEquals(t, c.expErr, err)
+				return
+			}
+			Ok(t, err)
+			terraform.VerifyWasCalledOnce().RunCommandWithVersion(ctx, "/path", []string{"init", "-backend=false", "-input=false"}, map[string]string(nil), tfVersion, "default")
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package runtime
 
 import (
+	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/hashicorp/go-version"
 	"github.com/runatlantis/atlantis/server/events/command"
@@ -11,9 +13,18 @@ import (
 type VersionStepRunner struct {
 	TerraformExecutor TerraformExec
 	DefaultTFVersion  *version.Version
+	// AtlantisVersion is reported along with the Terraform version so
+	// version drift between environments is easy to spot.
+	AtlantisVersion string
+	// PolicyChecksEnabled is whether conftest is run on plans. If it isn't,
+	// the conftest version isn't reported.
+	PolicyChecksEnabled    bool
+	DefaultConftestVersion *version.Version
 }
 
-// Run ensures a given version for the executable, builds the args from the project context and then runs executable returning the result
+// Run ensures a given version for the executable, builds the args from the project context and then runs executable returning the result.
+// The output of terraform version is preceded by the versions of the tools
+// Atlantis uses for the project.
 func (v *VersionStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
 	tfVersion := v.DefaultTFVersion
 	if ctx.TerraformVersion != nil {
@@ -21,5 +32,47 @@ func (v *VersionStepRunner) Run(ctx command.ProjectContext, extraArgs []string,
 	}
 
 	versionCmd := []string{"version"}
-	return v.TerraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), versionCmd, envs, tfVersion, ctx.Workspace)
+	out, err := v.TerraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), versionCmd, envs, tfVersion, ctx.Workspace)
+	if err != nil {
+		return out, err
+	}
+	if tools := v.toolVersions(ctx, tfVersion); tools != "" {
+		out = tools + "\n" + out
+	}
+	return out, nil
+}
+
+// toolVersions returns a line for each tool Atlantis uses for the project
+// with the version it resolved to and where that version came from, or an
+// empty string if there aren't any.
+func (v *VersionStepRunner) toolVersions(ctx command.ProjectContext, tfVersion *version.Version) string {
+	var lines []string
+	if v.AtlantisVersion != "" {
+		lines = append(lines, fmt.Sprintf("atlantis: %s", v.AtlantisVersion))
+	}
+	switch {
+	case ctx.TerraformVersion != nil:
+		lines = append(lines, fmt.Sprintf("terraform: %s (set by project, server default %s)", tfVersion, versionString(v.DefaultTFVersion)))
+	case tfVersion != nil:
+		lines = append(lines, fmt.Sprintf("terraform: %s (server default)", tfVersion))
+	}
+	if v.PolicyChecksEnabled {
+		if ctx.PolicySets.Version != nil {
+			lines = append(lines, fmt.Sprintf("conftest: %s (set by policies, server default %s)", ctx.PolicySets.Version, versionString(v.DefaultConftestVersion)))
+		} else {
+			lines = append(lines, fmt.Sprintf("conftest: %s (server default)", versionString(v.DefaultConftestVersion)))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// versionString returns v as a string or "none" if it isn't set.
+func versionString(v *version.Version) string {
+	if v == nil {
+		return "none"
+	}
+	return v.String()
 }
@@ -48,4 +48,51 @@ func TestRunVersionStep(t *testing.T) {
 		terraform.VerifyWasCalledOnce().RunCommandWithVersion(context, tmpDir, []string{"version"}, map[string]string(nil), tfVersion, "default")
 		Ok(t, err)
 	})
+
+	t.Run("reports tool versions", func(t *testing.T) {
+		projectVersion, _ := version.NewVersion("1.1.0")
+		conftestVersion, _ := version.NewVersion("0.25.0")
+		policyVersion, _ := version.NewVersion("0.30.0")
+		ctx := context
+		ctx.TerraformVersion = projectVersion
+		ctx.PolicySets.Version = policyVersion
+		When(terraform.RunCommandWithVersion(ctx, tmpDir, []string{"version"}, map[string]string(nil), projectVersion, "default")).
+			ThenReturn("Terraform v1.1.0\n", nil)
+		s := &VersionStepRunner{
+			TerraformExecutor:      terraform,
+			DefaultTFVersion:       tfVersion,
+			AtlantisVersion:        "0.19.2",
+			PolicyChecksEnabled:    true,
+			DefaultConftestVersion: conftestVersion,
+		}
+
+		out, err := s.Run(ctx, []string{}, tmpDir, map[string]string(nil))
+		Ok(t, err)
+		Equals(t, `atlantis: 0.19.2
+terraform: 1.1.0 (set by project, server default 0.15.0)
+conftest: 0.30.0 (set by policies, server default 0.25.0)
+
+Terraform v1.1.0
+`, out)
+	})
+
+	t.Run("reports server defaults", func(t *testing.T) {
+		When(terraform.RunCommandWithVersion(context, tmpDir, []string{"version"}, map[string]string(nil), tfVersion, "default")).
+			ThenReturn("Terraform v0.15.0\n", nil)
+		s := &VersionStepRunner{
+			TerraformExecutor:   terraform,
+			DefaultTFVersion:    tfVersion,
+			AtlantisVersion:     "0.19.2",
+			PolicyChecksEnabled: true,
+		}
+
+		out, err := s.Run(context, []string{}, tmpDir, map[string]string(nil))
+		Ok(t, err)
+		Equals(t, `atlantis: 0.19.2
+terraform: 0.15.0 (server default)
+conftest: none (server default)
+
+Terraform v0.15.0
+`, out)
+	})
 }
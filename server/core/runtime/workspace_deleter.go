@@ -0,0 +1,72 @@
+package runtime
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// WorkspaceDeleter deletes the Terraform workspaces that belong to pull
+// requests once they're closed.
+type WorkspaceDeleter struct {
+	// TerraformExecutor runs terraform with its default version, which it
+	// resolves when the workspaces are deleted.
+	TerraformExecutor TerraformExec
+}
+
+// Delete selects the default workspace of the initialized root module in the
+// dir repoRelDir of the pull request with pullNum cloned into cloneDir and
+// deletes workspace. Terraform refuses to delete it if its state still has
+// resources, so if destroy is true they're destroyed first. Only workspaces
+// that Atlantis created for the pull request are destroyed or deleted.
+func (w *WorkspaceDeleter) Delete(log logging.SimpleLogging, cloneDir string, repoRelDir string, workspace string, pullNum int, destroy bool) error {
+	path := filepath.Join(cloneDir, repoRelDir)
+	if !valid.IsPullWorkspace(workspace, pullNum) {
+		return fmt.Errorf("workspace %q doesn't belong to pull request #%d", workspace, pullNum)
+	}
+	if _, err := os.Stat(ephemeralWorkspaceMarker(path, repoRelDir, workspace)); err != nil {
+		return fmt.Errorf("workspace %q wasn't created by Atlantis for pull request #%d", workspace, pullNum)
+	}
+
+	// run runs terraform with args while workspace current is selected.
+	run := func(current string, args ...string) error {
+		ctx := command.ProjectContext{Log: log, Workspace: current}
+		out, err := w.TerraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), args, nil, nil, current)
+		if err != nil {
+			return fmt.Errorf("%s: %s", err, out)
+		}
+		return nil
+	}
+	if destroy {
+		if err := run(workspace, "workspace", "select", workspace); err != nil {
+			return err
+		}
+		if err := run(workspace, "destroy", "-auto-approve", "-input=false"); err != nil {
+			return err
+		}
+	}
+	if err := run(defaultWorkspace, "workspace", "select", defaultWorkspace); err != nil {
+		return err
+	}
+	return run(defaultWorkspace, "workspace", "delete", workspace)
+}
+
+// ephemeralWorkspaceMarker returns the path of the file that records that
+// Atlantis created workspace for the root module in path, which is the dir
+// repoRelDir of a pull request's clone. It's kept next to the clone, like its
+// git config, so that it survives the clone being re-cloned and is deleted
+// with the pull request's other clones once it's closed.
+func ephemeralWorkspaceMarker(path string, repoRelDir string, workspace string) string {
+	cloneDir := filepath.Clean(path)
+	rel := filepath.Clean(repoRelDir)
+	if rel != "." {
+		cloneDir = strings.TrimSuffix(cloneDir, string(filepath.Separator)+rel)
+	}
+	return filepath.Join(cloneDir+".workspaces", workspace, "dir-"+url.PathEscape(filepath.ToSlash(rel)))
+}
@@ -0,0 +1,108 @@
+package runtime
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestWorkspaceDeleter_Delete(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockExecutor := mocks.NewMockClient()
+	// The executor runs terraform with its default version.
+	var defaultVersion *version.Version
+	log := logging.NewNoopLogger(t)
+	ctx := command.ProjectContext{Workspace: "default", Log: log}
+	var envs map[string]string
+	subject := WorkspaceDeleter{
+		TerraformExecutor: mockExecutor,
+	}
+	cloneDir := t.TempDir()
+	for _, workspace := range []string{"pr-1", "pr-1-fix", "pr-1-preview"} {
+		markCreated(t, cloneDir, workspace)
+	}
+
+	Ok(t, subject.Delete(log, cloneDir, ".", "pr-1", 1, false))
+	mockExecutor.VerifyWasCalledOnce().RunCommandWithVersion(ctx, cloneDir, []string{"workspace", "select", "default"}, envs, defaultVersion, "default")
+	mockExecutor.VerifyWasCalledOnce().RunCommandWithVersion(ctx, cloneDir, []string{"workspace", "delete", "pr-1"}, envs, defaultVersion, "default")
+
+	When(mockExecutor.RunCommandWithVersion(ctx, cloneDir, []string{"workspace", "delete", "pr-1-fix"}, envs, defaultVersion, "default")).
+		ThenReturn("Workspace is not empty", errors.New("exit status 1"))
+	ErrEquals(t, "exit status 1: Workspace is not empty", subject.Delete(log, cloneDir, ".", "pr-1-fix", 1, false))
+
+	// Destroying runs in the workspace that's deleted.
+	previewCtx := command.ProjectContext{Workspace: "pr-1-preview", Log: log}
+	Ok(t, subject.Delete(log, cloneDir, ".", "pr-1-preview", 1, true))
+	mockExecutor.VerifyWasCalledOnce().RunCommandWithVersion(previewCtx, cloneDir, []string{"workspace", "select", "pr-1-preview"}, envs, defaultVersion, "pr-1-preview")
+	mockExecutor.VerifyWasCalledOnce().RunCommandWithVersion(previewCtx, cloneDir, []string{"destroy", "-auto-approve", "-input=false"}, envs, defaultVersion, "pr-1-preview")
+	mockExecutor.VerifyWasCalledOnce().RunCommandWithVersion(ctx, cloneDir, []string{"workspace", "delete", "pr-1-preview"}, envs, defaultVersion, "default")
+}
+
+func TestWorkspaceDeleter_DeleteOnlyCreatedWorkspaces(t *testing.T) {
+	RegisterMockTestingT(t)
+	log := logging.NewNoopLogger(t)
+	cloneDir := t.TempDir()
+	// The workspace of a pull request from a branch named after an existing
+	// workspace would have been created by Atlantis in an older version.
+	markCreated(t, cloneDir, "prod")
+
+	cases := []struct {
+		description string
+		workspace   string
+		expErr      string
+	}{
+		{
+			"branch named after an existing workspace",
+			"prod",
+			`workspace "prod" doesn't belong to pull request #1`,
+		},
+		{
+			"another pull request's workspace",
+			"pr-12-prod",
+			`workspace "pr-12-prod" doesn't belong to pull request #1`,
+		},
+		{
+			"workspace that Atlantis didn't create",
+			"pr-1-prod",
+			`workspace "pr-1-prod" wasn't created by Atlantis for pull request #1`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			executor := &recordingExecutor{}
+			subject := WorkspaceDeleter{TerraformExecutor: executor}
+			ErrEquals(t, c.expErr, subject.Delete(log, cloneDir, ".", c.workspace, 1, true))
+			Equals(t, 0, len(executor.calls))
+		})
+	}
+}
+
+// markCreated records that Atlantis created workspace for the root module
+// of the clone in cloneDir.
+func markCreated(t *testing.T, cloneDir string, workspace string) {
+	marker := ephemeralWorkspaceMarker(cloneDir, ".", workspace)
+	Ok(t, os.MkdirAll(filepath.Dir(marker), 0700))
+	Ok(t, os.WriteFile(marker, nil, 0600))
+}
+
+// recordingExecutor records the terraform commands it's asked to run.
+type recordingExecutor struct {
+	calls [][]string
+}
+
+func (r *recordingExecutor) RunCommandWithVersion(_ command.ProjectContext, _ string, args []string, _ map[string]string, _ *version.Version, _ string) (string, error) {
+	r.calls = append(r.calls, args)
+	return "", nil
+}
+
+func (r *recordingExecutor) EnsureVersion(_ logging.SimpleLogging, _ *version.Version) error {
+	return nil
+}
@@ -0,0 +1,114 @@
+package terraform
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-getter"
+	"github.com/pkg/errors"
+)
+
+// sha256Regex matches a hex encoded sha256 checksum.
+var sha256Regex = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
+
+// PinnedChecksumsDownloader is a Downloader that verifies Terraform releases
+// against pinned sha256 checksums instead of the SHA256SUMS file that's
+// downloaded from the same place as them. That way a compromised mirror can't
+// serve a different binary.
+type PinnedChecksumsDownloader struct {
+	Downloader Downloader
+	// Checksums maps the filename of a release, ex.
+	// terraform_1.3.7_linux_amd64.zip, to its hex encoded sha256 checksum.
+	// Releases without a checksum can't be downloaded.
+	Checksums map[string]string
+}
+
+// LoadPinnedChecksums reads the checksums in path, which is in the format of
+// the SHA256SUMS files of Terraform releases: a checksum and a filename on
+// each line. Blank lines and lines starting with # are ignored so the
+// SHA256SUMS files of several versions can be concatenated and commented.
+func LoadPinnedChecksums(path string) (map[string]string, error) {
+	f, err := os.Open(path) // nolint: gosec
+	if err != nil {
+		return nil, errors.Wrap(err, "opening checksums file")
+	}
+	defer f.Close() // nolint: errcheck
+
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !sha256Regex.MatchString(fields[0]) {
+			return nil, fmt.Errorf("checksums file %s line %d: expected a sha256 checksum and a filename, got %q", path, lineNum, line)
+		}
+		// sha256sum marks files read in binary mode with a *.
+		filename := strings.TrimPrefix(fields[1], "*")
+		if existing, ok := checksums[filename]; ok && existing != strings.ToLower(fields[0]) {
+			return nil, fmt.Errorf("checksums file %s line %d: %s has different checksums", path, lineNum, filename)
+		}
+		checksums[filename] = strings.ToLower(fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading checksums file")
+	}
+	return checksums, nil
+}
+
+// See go-getter.GetFile.
+func (p *PinnedChecksumsDownloader) GetFile(dst, src string, opts ...getter.ClientOption) error {
+	pinnedSrc, err := p.pin(src)
+	if err != nil {
+		return err
+	}
+	return p.Downloader.GetFile(dst, pinnedSrc, opts...)
+}
+
+// See go-getter.GetAny.
+func (p *PinnedChecksumsDownloader) GetAny(dst, src string, opts ...getter.ClientOption) error {
+	pinnedSrc, err := p.pin(src)
+	if err != nil {
+		return err
+	}
+	return p.Downloader.GetAny(dst, pinnedSrc, opts...)
+}
+
+// pin returns src with its checksum replaced by the pinned checksum of the
+// file it downloads.
+func (p *PinnedChecksumsDownloader) pin(src string) (string, error) {
+	u, err := url.Parse(src)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing %q", src)
+	}
+	filename := path.Base(u.Path)
+	checksum, ok := p.Checksums[filename]
+	if !ok {
+		return "", fmt.Errorf("%s doesn't have a pinned checksum, add it to the checksums file to download it", filename)
+	}
+	q := u.Query()
+	q.Set("checksum", "sha256:"+checksum)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// NewDownloader returns a DefaultDownloader that verifies releases against
+// the checksums in checksumsFile if it's set, or against the SHA256SUMS file
+// of each release otherwise.
+func NewDownloader(checksumsFile string) (Downloader, error) {
+	if checksumsFile == "" {
+		return &DefaultDownloader{}, nil
+	}
+	checksums, err := LoadPinnedChecksums(checksumsFile)
+	if err != nil {
+		return nil, err
+	}
+	return &PinnedChecksumsDownloader{Downloader: &DefaultDownloader{}, Checksums: checksums}, nil
+}
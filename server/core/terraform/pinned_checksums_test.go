@@ -0,0 +1,115 @@
+package terraform_test
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/cmd"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+	jobmocks "github.com/runatlantis/atlantis/server/jobs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestLoadPinnedChecksums(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	checksum := fmt.Sprintf("%064x", 1)
+
+	cases := map[string]struct {
+		contents string
+		exp      map[string]string
+		expErr   string
+	}{
+		"concatenated sums files": {
+			contents: fmt.Sprintf("# terraform 1.3.7\n%s  terraform_1.3.7_linux_amd64.zip\n\n%s *terraform_1.4.0_linux_amd64.zip\n", checksum, checksum),
+			exp: map[string]string{
+				"terraform_1.3.7_linux_amd64.zip": checksum,
+				"terraform_1.4.0_linux_amd64.zip": checksum,
+			},
+		},
+		"missing filename": {
+			contents: checksum + "\n",
+			expErr:   fmt.Sprintf("line 1: expected a sha256 checksum and a filename, got %q", checksum),
+		},
+		"invalid checksum": {
+			contents: "abc  terraform_1.3.7_linux_amd64.zip\n",
+			expErr:   `line 1: expected a sha256 checksum and a filename, got "abc  terraform_1.3.7_linux_amd64.zip"`,
+		},
+		"conflicting checksums": {
+			contents: fmt.Sprintf("%s  terraform_1.3.7_linux_amd64.zip\n%064x  terraform_1.3.7_linux_amd64.zip\n", checksum, 2),
+			expErr:   "line 2: terraform_1.3.7_linux_amd64.zip has different checksums",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(tmp, "checksums")
+			Ok(t, os.WriteFile(path, []byte(c.contents), 0600))
+			checksums, err := terraform.LoadPinnedChecksums(path)
+			if c.expErr != "" {
+				ErrContains(t, c.expErr, err)
+				return
+			}
+			Ok(t, err)
+			Equals(t, c.exp, checksums)
+		})
+	}
+}
+
+// Test that terraform is downloaded from a local mirror when its checksum is
+// pinned and not when it isn't or the checksum doesn't match.
+func TestPinnedChecksumsDownloader(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	tmp, binDir, cacheDir, cleanup := mkSubDirs(t)
+	defer cleanup()
+	defer tempSetEnv(t, "PATH", "")()
+
+	mirrorDir := filepath.Join(tmp, "mirror")
+	releaseDir := filepath.Join(mirrorDir, "terraform", "99.99.99")
+	Ok(t, os.MkdirAll(releaseDir, 0700))
+	filename := fmt.Sprintf("terraform_99.99.99_%s_%s.zip", runtime.GOOS, runtime.GOARCH)
+	zipPath := filepath.Join(releaseDir, filename)
+	zipFile, err := os.Create(zipPath)
+	Ok(t, err)
+	zipWriter := zip.NewWriter(zipFile)
+	w, err := zipWriter.Create("terraform")
+	Ok(t, err)
+	_, err = w.Write([]byte("#!/bin/sh\necho 'Terraform v99.99.99'\n"))
+	Ok(t, err)
+	Ok(t, zipWriter.Close())
+	Ok(t, zipFile.Close())
+	contents, err := os.ReadFile(zipPath)
+	Ok(t, err)
+	sum := sha256.Sum256(contents)
+
+	v, err := version.NewVersion("99.99.99")
+	Ok(t, err)
+	newClient := func(checksums map[string]string) *terraform.DefaultClient {
+		downloader := &terraform.PinnedChecksumsDownloader{
+			Downloader: &terraform.DefaultDownloader{},
+			Checksums:  checksums,
+		}
+		c, err := terraform.NewTestClient(logger, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, "file://"+mirrorDir, downloader, true, jobmocks.NewMockProjectCommandOutputHandler())
+		Ok(t, err)
+		return c
+	}
+
+	err = newClient(nil).EnsureVersion(logger, v)
+	ErrContains(t, filename+" doesn't have a pinned checksum", err)
+
+	err = newClient(map[string]string{filename: fmt.Sprintf("%064x", 1)}).EnsureVersion(logger, v)
+	ErrContains(t, "Checksums did not match", err)
+	_, err = os.Stat(filepath.Join(binDir, "terraform99.99.99"))
+	Assert(t, os.IsNotExist(err), "exp binary to not be downloaded")
+
+	Ok(t, newClient(map[string]string{filename: hex.EncodeToString(sum[:])}).EnsureVersion(logger, v))
+	_, err = os.Stat(filepath.Join(binDir, "terraform99.99.99"))
+	Ok(t, err)
+}
@@ -399,6 +399,9 @@ func (c *DefaultClient) RunCommandAsync(ctx command.ProjectContext, path string,
 	for key, val := range customEnvVars {
 		envVars = append(envVars, fmt.Sprintf("%s=%s", key, val))
 	}
+	if ctx.TerraformLogLevel != "" {
+		envVars = append(envVars, fmt.Sprintf("TF_LOG=%s", ctx.TerraformLogLevel))
+	}
 
 	runner := models.NewShellCommandRunner(cmd, envVars, path, true, c.projectCmdOutputHandler)
 	inCh, outCh := runner.RunCommandAsync(ctx)
@@ -0,0 +1,48 @@
+package events
+
+import (
+	"github.com/runatlantis/atlantis/server/events/alerts"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// AlertingProjectCommandRunner opens incidents when a project's apply errors
+// or its plan finds drift, and resolves them when the apply succeeds or the
+// plan doesn't find drift.
+type AlertingProjectCommandRunner struct {
+	ProjectCommandRunner
+	Notifier *alerts.Notifier
+}
+
+func (a *AlertingProjectCommandRunner) Plan(ctx command.ProjectContext) command.ProjectResult {
+	result := a.ProjectCommandRunner.Plan(ctx)
+	if result.PlanSuccess != nil {
+		a.Notifier.PlanFinished(alertProject(ctx), result.PlanSuccess.Drifted())
+	}
+	return result
+}
+
+func (a *AlertingProjectCommandRunner) Apply(ctx command.ProjectContext) command.ProjectResult {
+	result := a.ProjectCommandRunner.Apply(ctx)
+	// Failures are applies that Atlantis refused to run, ex. because they
+	// weren't approved, so they aren't incidents. Neither are applies of
+	// dirs that were deleted.
+	if _, ok := result.Error.(DirNotExistErr); ok {
+		return result
+	}
+	if result.Error != nil || result.ApplySuccess != "" {
+		a.Notifier.ApplyFinished(alertProject(ctx), result.Error)
+	}
+	return result
+}
+
+func alertProject(ctx command.ProjectContext) alerts.Project {
+	return alerts.Project{
+		RepoFullName: ctx.Pull.BaseRepo.FullName,
+		Name:         ctx.ProjectName,
+		Dir:          ctx.RepoRelDir,
+		Workspace:    ctx.Workspace,
+		BaseBranch:   ctx.Pull.BaseBranch,
+		PullURL:      ctx.Pull.URL,
+		User:         ctx.User.Username,
+	}
+}
@@ -0,0 +1,65 @@
+package events_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/alerts"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakeAlerter struct {
+	calls []string
+}
+
+func (f *fakeAlerter) Trigger(alert alerts.Alert) error {
+	f.calls = append(f.calls, "trigger "+alert.DedupKey)
+	return nil
+}
+
+func (f *fakeAlerter) Resolve(dedupKey string) error {
+	f.calls = append(f.calls, "resolve "+dedupKey)
+	return nil
+}
+
+func TestAlertingProjectCommandRunner(t *testing.T) {
+	RegisterMockTestingT(t)
+	wrapped := mocks.NewMockProjectCommandRunner()
+	alerter := &fakeAlerter{}
+	runner := &events.AlertingProjectCommandRunner{
+		ProjectCommandRunner: wrapped,
+		Notifier: &alerts.Notifier{
+			Alerters:     []alerts.Alerter{alerter},
+			BranchRegex:  regexp.MustCompile(""),
+			ProjectRegex: regexp.MustCompile(""),
+			Logger:       logging.NewNoopLogger(t),
+		},
+	}
+	ctx := command.ProjectContext{
+		Pull:       models.PullRequest{BaseBranch: "main", BaseRepo: models.Repo{FullName: "owner/repo"}},
+		RepoRelDir: "dir",
+		Workspace:  "default",
+	}
+
+	When(wrapped.Plan(ctx)).ThenReturn(command.ProjectResult{PlanSuccess: &models.PlanSuccess{TerraformOutput: "Objects have changed outside of Terraform"}})
+	runner.Plan(ctx)
+	When(wrapped.Apply(ctx)).ThenReturn(command.ProjectResult{Failure: "Pull request must be approved"})
+	runner.Apply(ctx)
+	When(wrapped.Apply(ctx)).ThenReturn(command.ProjectResult{Error: errors.New("apply failed")})
+	runner.Apply(ctx)
+	When(wrapped.Apply(ctx)).ThenReturn(command.ProjectResult{ApplySuccess: "success"})
+	runner.Apply(ctx)
+
+	Equals(t, []string{
+		"trigger atlantis/drift/owner/repo/dir/default",
+		"trigger atlantis/apply/owner/repo/dir/default",
+		"resolve atlantis/apply/owner/repo/dir/default",
+	}, alerter.calls)
+}
@@ -0,0 +1,135 @@
+// Package alerts opens incidents in PagerDuty or Opsgenie when applies fail
+// or drift is detected, and resolves them once the project is healthy again.
+package alerts
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// Alert is an incident about a project.
+type Alert struct {
+	// DedupKey identifies the incident so that alerts about the same problem
+	// with the same project are grouped and can be resolved.
+	DedupKey string
+	Summary  string
+	// Details are shown in the incident.
+	Details map[string]string
+}
+
+// Alerter opens and resolves incidents.
+type Alerter interface {
+	Trigger(alert Alert) error
+	Resolve(dedupKey string) error
+}
+
+// Project is the project that an alert is about.
+type Project struct {
+	RepoFullName string
+	// Name is empty if the project doesn't have one.
+	Name       string
+	Dir        string
+	Workspace  string
+	BaseBranch string
+	PullURL    string
+	User       string
+}
+
+// Notifier decides which applies and plans to alert about.
+type Notifier struct {
+	Alerters []Alerter
+	// BranchRegex matches the base branches of the pull requests whose
+	// failed applies are alerted about.
+	BranchRegex *regexp.Regexp
+	// ProjectRegex matches the names, or dirs if they don't have names, of
+	// the projects whose drift is alerted about.
+	ProjectRegex *regexp.Regexp
+	Logger       logging.SimpleLogging
+}
+
+// ApplyFinished opens an incident if the apply of p errored, or resolves it
+// if the apply succeeded.
+func (n *Notifier) ApplyFinished(p Project, applyErr error) {
+	if !n.BranchRegex.MatchString(p.BaseBranch) {
+		return
+	}
+	key := dedupKey("apply", p)
+	if applyErr == nil {
+		n.resolve(key)
+		return
+	}
+	details := p.details()
+	details["error"] = applyErr.Error()
+	n.trigger(Alert{
+		DedupKey: key,
+		Summary:  fmt.Sprintf("Atlantis apply failed for %s", p.description()),
+		Details:  details,
+	})
+}
+
+// PlanFinished opens an incident if the plan of p found drift, or resolves
+// it if it didn't.
+func (n *Notifier) PlanFinished(p Project, drifted bool) {
+	name := p.Name
+	if name == "" {
+		name = p.Dir
+	}
+	if !n.ProjectRegex.MatchString(name) {
+		return
+	}
+	key := dedupKey("drift", p)
+	if !drifted {
+		n.resolve(key)
+		return
+	}
+	n.trigger(Alert{
+		DedupKey: key,
+		Summary:  fmt.Sprintf("Atlantis detected drift in %s", p.description()),
+		Details:  p.details(),
+	})
+}
+
+func (n *Notifier) trigger(alert Alert) {
+	for _, a := range n.Alerters {
+		if err := a.Trigger(alert); err != nil {
+			n.Logger.Warn("unable to open incident %q: %s", alert.DedupKey, err)
+		}
+	}
+}
+
+func (n *Notifier) resolve(key string) {
+	for _, a := range n.Alerters {
+		if err := a.Resolve(key); err != nil {
+			n.Logger.Warn("unable to resolve incident %q: %s", key, err)
+		}
+	}
+}
+
+// dedupKey returns the key of the incidents of kind about p, ex.
+// "atlantis/apply/owner/repo/dir/default".
+func dedupKey(kind string, p Project) string {
+	return strings.Join([]string{"atlantis", kind, p.RepoFullName, p.Dir, p.Workspace}, "/")
+}
+
+func (p Project) description() string {
+	desc := fmt.Sprintf("%s dir: %s workspace: %s", p.RepoFullName, p.Dir, p.Workspace)
+	if p.Name != "" {
+		desc = fmt.Sprintf("%s project: %s", desc, p.Name)
+	}
+	return desc
+}
+
+func (p Project) details() map[string]string {
+	return map[string]string{
+		"repo":        p.RepoFullName,
+		"project":     p.Name,
+		"dir":         p.Dir,
+		"workspace":   p.Workspace,
+		"base_branch": p.BaseBranch,
+		"pull_url":    p.PullURL,
+		"user":        p.User,
+	}
+}
@@ -0,0 +1,53 @@
+package alerts_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/alerts"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakeAlerter struct {
+	// calls are the triggered and resolved dedup keys in order.
+	calls []string
+}
+
+func (f *fakeAlerter) Trigger(alert alerts.Alert) error {
+	f.calls = append(f.calls, "trigger "+alert.DedupKey+": "+alert.Summary)
+	return nil
+}
+
+func (f *fakeAlerter) Resolve(dedupKey string) error {
+	f.calls = append(f.calls, "resolve "+dedupKey)
+	return nil
+}
+
+func TestNotifier(t *testing.T) {
+	alerter := &fakeAlerter{}
+	notifier := &alerts.Notifier{
+		Alerters:     []alerts.Alerter{alerter},
+		BranchRegex:  regexp.MustCompile("^main$"),
+		ProjectRegex: regexp.MustCompile("^prod"),
+		Logger:       logging.NewNoopLogger(t),
+	}
+	prod := alerts.Project{RepoFullName: "owner/repo", Name: "production", Dir: "prod", Workspace: "default", BaseBranch: "main"}
+	staging := alerts.Project{RepoFullName: "owner/repo", Dir: "staging", Workspace: "default", BaseBranch: "develop"}
+
+	notifier.ApplyFinished(prod, errors.New("apply failed"))
+	notifier.ApplyFinished(prod, nil)
+	// Applies into other branches and drift in other projects are ignored.
+	notifier.ApplyFinished(staging, errors.New("apply failed"))
+	notifier.PlanFinished(staging, true)
+	notifier.PlanFinished(prod, true)
+	notifier.PlanFinished(prod, false)
+
+	Equals(t, []string{
+		"trigger atlantis/apply/owner/repo/prod/default: Atlantis apply failed for owner/repo dir: prod workspace: default project: production",
+		"resolve atlantis/apply/owner/repo/prod/default",
+		"trigger atlantis/drift/owner/repo/prod/default: Atlantis detected drift in owner/repo dir: prod workspace: default project: production",
+		"resolve atlantis/drift/owner/repo/prod/default",
+	}, alerter.calls)
+}
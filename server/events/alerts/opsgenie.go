@@ -0,0 +1,85 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultOpsgenieURL is the URL of Opsgenie's API. Accounts in the EU
+// instance use https://api.eu.opsgenie.com.
+const DefaultOpsgenieURL = "https://api.opsgenie.com"
+
+// Opsgenie implements Alerter with Opsgenie's Alert API. Alerts are
+// identified by their alias, which is the dedup key.
+type Opsgenie struct {
+	APIKey string
+	URL    string
+	Client *http.Client
+}
+
+// NewOpsgenie returns an alerter for the Opsgenie API at apiURL that
+// authenticates with apiKey.
+func NewOpsgenie(apiKey string, apiURL string) *Opsgenie {
+	return &Opsgenie{
+		APIKey: apiKey,
+		URL:    strings.TrimSuffix(apiURL, "/"),
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Source      string            `json:"source"`
+	Priority    string            `json:"priority"`
+	Details     map[string]string `json:"details,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+func (o *Opsgenie) Trigger(alert Alert) error {
+	// Opsgenie limits messages to 130 characters, the full summary is in the
+	// description.
+	msg := alert.Summary
+	if len(msg) > 130 {
+		msg = msg[:127] + "..."
+	}
+	return o.post("/v2/alerts", opsgenieAlert{
+		Message:     msg,
+		Alias:       alert.DedupKey,
+		Source:      "atlantis",
+		Priority:    "P2",
+		Details:     alert.Details,
+		Description: alert.Summary,
+	})
+}
+
+func (o *Opsgenie) Resolve(dedupKey string) error {
+	return o.post(fmt.Sprintf("/v2/alerts/%s/close?identifierType=alias", url.PathEscape(dedupKey)), map[string]string{"source": "atlantis"})
+}
+
+func (o *Opsgenie) post(path string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, o.URL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+o.APIKey)
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("got status %d from Opsgenie", resp.StatusCode)
+	}
+	return nil
+}
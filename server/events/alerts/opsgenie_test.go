@@ -0,0 +1,31 @@
+package alerts_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/alerts"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestOpsgenie(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "GenieKey key", r.Header.Get("Authorization"))
+		body, err := io.ReadAll(r.Body)
+		Ok(t, err)
+		requests = append(requests, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	og := alerts.NewOpsgenie("key", server.URL+"/")
+	Ok(t, og.Trigger(alerts.Alert{DedupKey: "atlantis/drift/owner/repo/dir/default", Summary: "drifted"}))
+	Ok(t, og.Resolve("atlantis/drift/owner/repo/dir/default"))
+	Equals(t, []string{
+		`POST /v2/alerts {"message":"drifted","alias":"atlantis/drift/owner/repo/dir/default","source":"atlantis","priority":"P2","description":"drifted"}`,
+		`POST /v2/alerts/atlantis%2Fdrift%2Fowner%2Frepo%2Fdir%2Fdefault/close?identifierType=alias {"source":"atlantis"}`,
+	}, requests)
+}
@@ -0,0 +1,81 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultPagerDutyURL is the endpoint of PagerDuty's Events API v2.
+const DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty implements Alerter with PagerDuty's Events API v2.
+type PagerDuty struct {
+	// RoutingKey is the integration key of the PagerDuty service.
+	RoutingKey string
+	URL        string
+	Client     *http.Client
+}
+
+// NewPagerDuty returns an alerter for the PagerDuty service with routingKey.
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{
+		RoutingKey: routingKey,
+		URL:        DefaultPagerDutyURL,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+func (p *PagerDuty) Trigger(alert Alert) error {
+	return p.send(pagerDutyEvent{
+		RoutingKey:  p.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    alert.DedupKey,
+		Payload: &pagerDutyPayload{
+			Summary:       alert.Summary,
+			Source:        "atlantis",
+			Severity:      "error",
+			CustomDetails: alert.Details,
+		},
+	})
+}
+
+func (p *PagerDuty) Resolve(dedupKey string) error {
+	return p.send(pagerDutyEvent{
+		RoutingKey:  p.RoutingKey,
+		EventAction: "resolve",
+		DedupKey:    dedupKey,
+	})
+}
+
+func (p *PagerDuty) send(event pagerDutyEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := p.Client.Post(p.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("got status %d from PagerDuty", resp.StatusCode)
+	}
+	return nil
+}
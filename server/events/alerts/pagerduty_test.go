@@ -0,0 +1,42 @@
+package alerts_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/alerts"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestPagerDuty(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		Ok(t, err)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	pd := alerts.NewPagerDuty("key")
+	pd.URL = server.URL
+	Ok(t, pd.Trigger(alerts.Alert{DedupKey: "atlantis/apply/owner/repo/dir/default", Summary: "failed", Details: map[string]string{"dir": "dir"}}))
+	Ok(t, pd.Resolve("atlantis/apply/owner/repo/dir/default"))
+	Equals(t, []string{
+		`{"routing_key":"key","event_action":"trigger","dedup_key":"atlantis/apply/owner/repo/dir/default","payload":{"summary":"failed","source":"atlantis","severity":"error","custom_details":{"dir":"dir"}}}`,
+		`{"routing_key":"key","event_action":"resolve","dedup_key":"atlantis/apply/owner/repo/dir/default"}`,
+	}, bodies)
+}
+
+func TestPagerDuty_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	pd := alerts.NewPagerDuty("key")
+	pd.URL = server.URL
+	ErrEquals(t, "got status 400 from PagerDuty", pd.Resolve("key"))
+}
@@ -0,0 +1,198 @@
+// Package analytics loads records of the commands that Atlantis runs into a
+// data warehouse so that organizations can build metrics on infrastructure
+// changes, ex. how often changes are applied and how often they fail.
+package analytics
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// The outcomes of commands.
+const (
+	SuccessOutcome = "success"
+	// FailureOutcome is a command that didn't run, ex. because the project
+	// was locked or its apply requirements weren't met.
+	FailureOutcome = "failure"
+	ErrorOutcome   = "error"
+)
+
+// Record is a project command that ran and its outcome. It's a row of the
+// warehouse table.
+type Record struct {
+	// ID is unique per record so that warehouses can deduplicate retried
+	// loads.
+	ID          string    `json:"id"`
+	Time        time.Time `json:"time"`
+	Repo        string    `json:"repo"`
+	Pull        int       `json:"pull"`
+	PullAuthor  string    `json:"pull_author"`
+	BaseBranch  string    `json:"base_branch"`
+	HeadCommit  string    `json:"head_commit"`
+	ProjectName string    `json:"project_name"`
+	Dir         string    `json:"dir"`
+	Workspace   string    `json:"workspace"`
+	// Command is "plan" or "apply".
+	Command string `json:"command"`
+	User    string `json:"user"`
+	// Outcome is SuccessOutcome, FailureOutcome or ErrorOutcome.
+	Outcome         string  `json:"outcome"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	// The resource counts are only set for plans that succeeded.
+	ResourcesToAdd     int `json:"resources_to_add"`
+	ResourcesToChange  int `json:"resources_to_change"`
+	ResourcesToDestroy int `json:"resources_to_destroy"`
+}
+
+// Recorder records the commands that ran.
+type Recorder interface {
+	Add(record Record)
+}
+
+// Warehouse loads records into a table.
+type Warehouse interface {
+	Load(records []Record) error
+}
+
+// NewWarehouse returns the warehouse for a URL like
+// bigquery://project/dataset/table or
+// snowflake://token@account.snowflakecomputing.com/database/schema/table.
+func NewWarehouse(raw string) (Warehouse, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing analytics sink")
+	}
+	switch u.Scheme {
+	case "bigquery":
+		return NewBigQuery(u)
+	case "snowflake":
+		return NewSnowflake(u)
+	default:
+		return nil, fmt.Errorf("unsupported analytics sink %q, must be a bigquery or snowflake URL", u.Redacted())
+	}
+}
+
+// maxPendingBatches is how many batches of records a BatchSink keeps while
+// its warehouse is failing. The oldest records are dropped after that.
+const maxPendingBatches = 10
+
+// BatchSink implements Recorder by loading the records into a warehouse in
+// batches of up to BatchSize, when a batch is full and every FlushInterval,
+// so that the warehouse isn't called for every command. Records that fail to
+// load are retried with the next batch.
+type BatchSink struct {
+	Warehouse     Warehouse
+	BatchSize     int
+	FlushInterval time.Duration
+	Logger        logging.SimpleLogging
+
+	mu      sync.Mutex
+	pending []Record
+	// loading is held while records are loaded so that loads don't overlap.
+	loading sync.Mutex
+	full    chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewBatchSink returns a sink that loads records into warehouse. Start must be
+// called for the records to be loaded.
+func NewBatchSink(warehouse Warehouse, batchSize int, flushInterval time.Duration, logger logging.SimpleLogging) *BatchSink {
+	return &BatchSink{
+		Warehouse:     warehouse,
+		BatchSize:     batchSize,
+		FlushInterval: flushInterval,
+		Logger:        logger,
+		full:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+}
+
+// Add queues record to be loaded.
+func (b *BatchSink) Add(record Record) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = append(b.pending, record)
+	if max := maxPendingBatches * b.BatchSize; len(b.pending) > max {
+		b.Logger.Warn("dropping %d analytics records since the warehouse is failing", len(b.pending)-max)
+		b.pending = b.pending[len(b.pending)-max:]
+	}
+	if len(b.pending) >= b.BatchSize {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Start loads the records in the background until Close is called.
+func (b *BatchSink) Start() {
+	go func() {
+		defer close(b.stopped)
+		ticker := time.NewTicker(b.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-b.full:
+			case <-b.stop:
+				return
+			}
+			b.Flush()
+		}
+	}()
+}
+
+// Flush loads the queued records in batches. It stops at the first batch
+// that fails to load, which is kept to be retried.
+func (b *BatchSink) Flush() {
+	b.loading.Lock()
+	defer b.loading.Unlock()
+	for {
+		b.mu.Lock()
+		n := len(b.pending)
+		if n > b.BatchSize {
+			n = b.BatchSize
+		}
+		batch := append([]Record(nil), b.pending[:n]...)
+		b.mu.Unlock()
+		if len(batch) == 0 {
+			return
+		}
+		if err := b.Warehouse.Load(batch); err != nil {
+			b.Logger.Warn("unable to load %d analytics records, will retry: %s", len(batch), err)
+			return
+		}
+		b.mu.Lock()
+		// Records may have been dropped from the front while loading.
+		b.pending = b.pending[b.loaded(batch):]
+		b.mu.Unlock()
+	}
+}
+
+// loaded returns how many records at the front of pending are in batch. It
+// must be called with mu held.
+func (b *BatchSink) loaded(batch []Record) int {
+	loaded := make(map[string]bool, len(batch))
+	for _, r := range batch {
+		loaded[r.ID] = true
+	}
+	n := 0
+	for n < len(b.pending) && loaded[b.pending[n].ID] {
+		n++
+	}
+	return n
+}
+
+// Close stops loading records in the background and loads the queued ones.
+func (b *BatchSink) Close() {
+	close(b.stop)
+	<-b.stopped
+	b.Flush()
+}
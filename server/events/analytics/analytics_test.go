@@ -0,0 +1,107 @@
+package analytics_test
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/analytics"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeWarehouse records the batches it loads and fails while err is set.
+type fakeWarehouse struct {
+	mu      sync.Mutex
+	batches [][]string
+	err     error
+}
+
+func (f *fakeWarehouse) Load(records []analytics.Record) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	var ids []string
+	for _, r := range records {
+		ids = append(ids, r.ID)
+	}
+	f.batches = append(f.batches, ids)
+	return nil
+}
+
+func (f *fakeWarehouse) loaded() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.batches
+}
+
+func TestBatchSink_Flush(t *testing.T) {
+	warehouse := &fakeWarehouse{err: errors.New("unavailable")}
+	sink := analytics.NewBatchSink(warehouse, 2, time.Hour, logging.NewNoopLogger(t))
+	for i := 1; i <= 3; i++ {
+		sink.Add(analytics.Record{ID: strconv.Itoa(i)})
+	}
+	sink.Flush()
+	Equals(t, 0, len(warehouse.loaded()))
+
+	warehouse.err = nil
+	sink.Flush()
+	Equals(t, [][]string{{"1", "2"}, {"3"}}, warehouse.loaded())
+	sink.Flush()
+	Equals(t, 2, len(warehouse.loaded()))
+}
+
+func TestBatchSink_DropsOldestRecords(t *testing.T) {
+	warehouse := &fakeWarehouse{}
+	sink := analytics.NewBatchSink(warehouse, 1, time.Hour, logging.NewNoopLogger(t))
+	for i := 1; i <= 12; i++ {
+		sink.Add(analytics.Record{ID: strconv.Itoa(i)})
+	}
+	sink.Flush()
+	loaded := warehouse.loaded()
+	Equals(t, 10, len(loaded))
+	Equals(t, []string{"3"}, loaded[0])
+}
+
+func TestBatchSink_LoadsFullBatches(t *testing.T) {
+	warehouse := &fakeWarehouse{}
+	sink := analytics.NewBatchSink(warehouse, 2, time.Hour, logging.NewNoopLogger(t))
+	sink.Start()
+	sink.Add(analytics.Record{ID: "1"})
+	sink.Add(analytics.Record{ID: "2"})
+	deadline := time.Now().Add(5 * time.Second)
+	for len(warehouse.loaded()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	Equals(t, [][]string{{"1", "2"}}, warehouse.loaded())
+
+	sink.Add(analytics.Record{ID: "3"})
+	sink.Close()
+	Equals(t, [][]string{{"1", "2"}, {"3"}}, warehouse.loaded())
+}
+
+func TestNewWarehouse(t *testing.T) {
+	_, err := analytics.NewWarehouse("kafka://broker/topic")
+	ErrEquals(t, `unsupported analytics sink "kafka://broker/topic", must be a bigquery or snowflake URL`, err)
+	_, err = analytics.NewWarehouse("bigquery://project/dataset")
+	ErrEquals(t, `BigQuery URL "bigquery://project/dataset" must be bigquery://project/dataset/table`, err)
+	_, err = analytics.NewWarehouse("snowflake://account.snowflakecomputing.com/db/schema/table")
+	ErrEquals(t, `Snowflake URL "snowflake://account.snowflakecomputing.com/db/schema/table" has no OAuth token`, err)
+	_, err = analytics.NewWarehouse("snowflake://token@account.snowflakecomputing.com/db/schema/t;drop")
+	ErrEquals(t, `Snowflake table "t;drop" must be an unquoted identifier`, err)
+
+	warehouse, err := analytics.NewWarehouse("snowflake://token@account.snowflakecomputing.com/db/schema/commands")
+	Ok(t, err)
+	Equals(t, &analytics.Snowflake{
+		URL:      "https://account.snowflakecomputing.com",
+		Database: "db",
+		Schema:   "schema",
+		Table:    "commands",
+		Token:    "token",
+		Client:   warehouse.(*analytics.Snowflake).Client,
+	}, warehouse)
+}
@@ -0,0 +1,109 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2/google"
+)
+
+// DefaultBigQueryURL is the endpoint of the BigQuery API.
+const DefaultBigQueryURL = "https://bigquery.googleapis.com/bigquery/v2"
+
+// bigQueryScope is the OAuth scope needed to insert rows.
+const bigQueryScope = "https://www.googleapis.com/auth/bigquery.insertdata"
+
+// BigQuery implements Warehouse by streaming the records into a BigQuery table
+// whose columns match the fields of Record.
+type BigQuery struct {
+	Project string
+	Dataset string
+	Table   string
+	URL     string
+	// Client authenticates the requests.
+	Client *http.Client
+}
+
+// NewBigQuery returns the warehouse for a URL like
+// bigquery://project/dataset/table. Requests are authenticated with the
+// Google application default credentials.
+func NewBigQuery(u *url.URL) (*BigQuery, error) {
+	path := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Host == "" || len(path) != 2 || path[0] == "" || path[1] == "" {
+		return nil, fmt.Errorf("BigQuery URL %q must be bigquery://project/dataset/table", u.Redacted())
+	}
+	client, err := google.DefaultClient(context.Background(), bigQueryScope)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding Google credentials for BigQuery")
+	}
+	client.Timeout = 30 * time.Second
+	return &BigQuery{
+		Project: u.Host,
+		Dataset: path[0],
+		Table:   path[1],
+		URL:     DefaultBigQueryURL,
+		Client:  client,
+	}, nil
+}
+
+type bigQueryInsertRequest struct {
+	Rows []bigQueryRow `json:"rows"`
+}
+
+type bigQueryRow struct {
+	InsertID string `json:"insertId"`
+	JSON     Record `json:"json"`
+}
+
+type bigQueryInsertResponse struct {
+	InsertErrors []struct {
+		Index  int `json:"index"`
+		Errors []struct {
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"insertErrors"`
+}
+
+func (b *BigQuery) Load(records []Record) error {
+	req := bigQueryInsertRequest{}
+	for _, r := range records {
+		req.Rows = append(req.Rows, bigQueryRow{InsertID: r.ID, JSON: r})
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/projects/%s/datasets/%s/tables/%s/insertAll", b.URL,
+		url.PathEscape(b.Project), url.PathEscape(b.Dataset), url.PathEscape(b.Table))
+	resp, err := b.Client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("got status %d from BigQuery", resp.StatusCode)
+	}
+	var insertResp bigQueryInsertResponse
+	if err := json.NewDecoder(resp.Body).Decode(&insertResp); err != nil {
+		return errors.Wrap(err, "decoding BigQuery response")
+	}
+	if len(insertResp.InsertErrors) > 0 {
+		// Rows with errors aren't inserted and neither are the other rows
+		// unless skipInvalidRows is set, so the whole batch is retried.
+		first := insertResp.InsertErrors[0]
+		msg := "unknown error"
+		if len(first.Errors) > 0 {
+			msg = first.Errors[0].Message
+		}
+		return fmt.Errorf("BigQuery rejected %d rows, row %d: %s", len(insertResp.InsertErrors), first.Index, msg)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Snowflake implements Warehouse by inserting the records into a Snowflake
+// table with the SQL API. The table has a single VARIANT column, RECORD,
+// that each record is inserted into as a JSON object.
+type Snowflake struct {
+	// URL is the account's URL, ex.
+	// https://myorg-myaccount.snowflakecomputing.com.
+	URL      string
+	Database string
+	Schema   string
+	Table    string
+	// Token is an OAuth token of a user that can insert into the table.
+	Token  string
+	Client *http.Client
+}
+
+// NewSnowflake returns the warehouse for a URL like
+// snowflake://token@myorg-myaccount.snowflakecomputing.com/database/schema/table.
+func NewSnowflake(u *url.URL) (*Snowflake, error) {
+	path := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Host == "" || len(path) != 3 || path[0] == "" || path[1] == "" || path[2] == "" {
+		return nil, fmt.Errorf("Snowflake URL %q must be snowflake://token@account.snowflakecomputing.com/database/schema/table", u.Redacted())
+	}
+	if !snowflakeIdentifierRegex.MatchString(path[2]) {
+		return nil, fmt.Errorf("Snowflake table %q must be an unquoted identifier", path[2])
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("Snowflake URL %q has no OAuth token", u.Redacted())
+	}
+	return &Snowflake{
+		URL:      "https://" + u.Host,
+		Database: path[0],
+		Schema:   path[1],
+		Table:    path[2],
+		Token:    u.User.Username(),
+		Client:   &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// snowflakeIdentifierRegex matches the unquoted identifiers that the table
+// can be named with, since the table is interpolated into the statement.
+var snowflakeIdentifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+type snowflakeStatement struct {
+	Statement string                      `json:"statement"`
+	Database  string                      `json:"database"`
+	Schema    string                      `json:"schema"`
+	Timeout   int                         `json:"timeout"`
+	Bindings  map[string]snowflakeBinding `json:"bindings"`
+}
+
+type snowflakeBinding struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func (s *Snowflake) Load(records []Record) error {
+	stmt := snowflakeStatement{
+		Database: s.Database,
+		Schema:   s.Schema,
+		Timeout:  60,
+		Bindings: make(map[string]snowflakeBinding),
+	}
+	values := make([]string, 0, len(records))
+	for i, r := range records {
+		record, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		values = append(values, "(?)")
+		stmt.Bindings[strconv.Itoa(i+1)] = snowflakeBinding{Type: "TEXT", Value: string(record)}
+	}
+	// PARSE_JSON can't be used in a VALUES clause so the values are selected.
+	stmt.Statement = fmt.Sprintf("INSERT INTO %s (RECORD) SELECT PARSE_JSON(column1) FROM VALUES %s",
+		s.Table, strings.Join(values, ", "))
+	body, err := json.Marshal(stmt)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", s.URL+"/api/v2/statements", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("X-Snowflake-Authorization-Token-Type", "OAUTH")
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	// 202 means the statement is still running after the timeout, which
+	// Snowflake completes on its own.
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		var errResp struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&errResp) // nolint: errcheck
+		return fmt.Errorf("got status %d from Snowflake: %s", resp.StatusCode, errResp.Message)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package analytics_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/analytics"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+var testRecord = analytics.Record{
+	ID:              "id",
+	Time:            time.Date(2022, 10, 3, 9, 0, 0, 0, time.UTC),
+	Repo:            "owner/repo",
+	Pull:            1,
+	Dir:             "dir",
+	Workspace:       "default",
+	Command:         "apply",
+	Outcome:         analytics.SuccessOutcome,
+	DurationSeconds: 42,
+}
+
+func TestBigQuery_Load(t *testing.T) {
+	var path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		b, err := io.ReadAll(r.Body)
+		Ok(t, err)
+		body = string(b)
+		w.Write([]byte(`{"kind": "bigquery#tableDataInsertAllResponse"}`)) // nolint: errcheck
+	}))
+	defer server.Close()
+
+	bq := &analytics.BigQuery{Project: "project", Dataset: "atlantis", Table: "commands", URL: server.URL, Client: server.Client()}
+	Ok(t, bq.Load([]analytics.Record{testRecord}))
+	Equals(t, "/projects/project/datasets/atlantis/tables/commands/insertAll", path)
+	var req struct {
+		Rows []struct {
+			InsertID string           `json:"insertId"`
+			JSON     analytics.Record `json:"json"`
+		} `json:"rows"`
+	}
+	Ok(t, json.Unmarshal([]byte(body), &req))
+	Equals(t, 1, len(req.Rows))
+	Equals(t, "id", req.Rows[0].InsertID)
+	Equals(t, testRecord, req.Rows[0].JSON)
+}
+
+func TestBigQuery_LoadInsertErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"insertErrors": [{"index": 0, "errors": [{"reason": "invalid", "message": "no such field: pull_author"}]}]}`)) // nolint: errcheck
+	}))
+	defer server.Close()
+
+	bq := &analytics.BigQuery{Project: "project", Dataset: "atlantis", Table: "commands", URL: server.URL, Client: server.Client()}
+	ErrEquals(t, "BigQuery rejected 1 rows, row 0: no such field: pull_author", bq.Load([]analytics.Record{testRecord}))
+}
+
+func TestSnowflake_Load(t *testing.T) {
+	var headers http.Header
+	var stmt struct {
+		Statement string `json:"statement"`
+		Database  string `json:"database"`
+		Schema    string `json:"schema"`
+		Bindings  map[string]struct {
+			Type  string `json:"type"`
+			Value string `json:"value"`
+		} `json:"bindings"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "/api/v2/statements", r.URL.Path)
+		headers = r.Header
+		Ok(t, json.NewDecoder(r.Body).Decode(&stmt))
+	}))
+	defer server.Close()
+
+	sf := &analytics.Snowflake{URL: server.URL, Database: "db", Schema: "public", Table: "commands", Token: "token", Client: server.Client()}
+	second := testRecord
+	second.ID = "id2"
+	Ok(t, sf.Load([]analytics.Record{testRecord, second}))
+	Equals(t, "Bearer token", headers.Get("Authorization"))
+	Equals(t, "OAUTH", headers.Get("X-Snowflake-Authorization-Token-Type"))
+	Equals(t, "INSERT INTO commands (RECORD) SELECT PARSE_JSON(column1) FROM VALUES (?), (?)", stmt.Statement)
+	Equals(t, "db", stmt.Database)
+	Equals(t, "public", stmt.Schema)
+	var record analytics.Record
+	Ok(t, json.Unmarshal([]byte(stmt.Bindings["2"].Value), &record))
+	Equals(t, second, record)
+	Equals(t, "TEXT", stmt.Bindings["2"].Type)
+}
+
+func TestSnowflake_LoadError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message": "Table 'COMMANDS' does not exist"}`)) // nolint: errcheck
+	}))
+	defer server.Close()
+
+	sf := &analytics.Snowflake{URL: server.URL, Database: "db", Schema: "public", Table: "commands", Token: "token", Client: server.Client()}
+	ErrEquals(t, "got status 422 from Snowflake: Table 'COMMANDS' does not exist", sf.Load([]analytics.Record{testRecord}))
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/runatlantis/atlantis/server/events/analytics"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// AnalyticsProjectCommandRunner records the outcome and duration of each
+// project's plans and applies for a data warehouse.
+type AnalyticsProjectCommandRunner struct {
+	ProjectCommandRunner
+	Recorder analytics.Recorder
+}
+
+func (a *AnalyticsProjectCommandRunner) Plan(ctx command.ProjectContext) command.ProjectResult {
+	start := time.Now()
+	result := a.ProjectCommandRunner.Plan(ctx)
+	a.record(ctx, result, start)
+	return result
+}
+
+func (a *AnalyticsProjectCommandRunner) Apply(ctx command.ProjectContext) command.ProjectResult {
+	start := time.Now()
+	result := a.ProjectCommandRunner.Apply(ctx)
+	a.record(ctx, result, start)
+	return result
+}
+
+// record records the result of the command for ctx that started at start.
+func (a *AnalyticsProjectCommandRunner) record(ctx command.ProjectContext, result command.ProjectResult, start time.Time) {
+	record := analytics.Record{
+		ID:              uuid.New().String(),
+		Time:            start.UTC(),
+		Repo:            ctx.BaseRepo.FullName,
+		Pull:            ctx.Pull.Num,
+		PullAuthor:      ctx.Pull.Author,
+		BaseBranch:      ctx.Pull.BaseBranch,
+		HeadCommit:      ctx.Pull.HeadCommit,
+		ProjectName:     ctx.ProjectName,
+		Dir:             ctx.RepoRelDir,
+		Workspace:       ctx.Workspace,
+		Command:         result.Command.String(),
+		User:            ctx.User.Username,
+		Outcome:         analytics.SuccessOutcome,
+		DurationSeconds: time.Since(start).Seconds(),
+	}
+	switch {
+	case result.Error != nil:
+		record.Outcome = analytics.ErrorOutcome
+	case result.Failure != "":
+		record.Outcome = analytics.FailureOutcome
+	}
+	if result.PlanSuccess != nil {
+		stats := result.PlanSuccess.Stats()
+		record.ResourcesToAdd = stats.Add
+		record.ResourcesToChange = stats.Change
+		record.ResourcesToDestroy = stats.Destroy
+	}
+	a.Recorder.Add(record)
+}
@@ -0,0 +1,70 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/analytics"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakeRecorder struct {
+	records []analytics.Record
+}
+
+func (f *fakeRecorder) Add(record analytics.Record) {
+	f.records = append(f.records, record)
+}
+
+func TestAnalyticsProjectCommandRunner(t *testing.T) {
+	RegisterMockTestingT(t)
+	wrapped := mocks.NewMockProjectCommandRunner()
+	recorder := &fakeRecorder{}
+	runner := &events.AnalyticsProjectCommandRunner{ProjectCommandRunner: wrapped, Recorder: recorder}
+	ctx := command.ProjectContext{
+		BaseRepo:   models.Repo{FullName: "owner/repo"},
+		Pull:       models.PullRequest{Num: 1, Author: "author", BaseBranch: "main", HeadCommit: "abc123"},
+		RepoRelDir: "dir",
+		Workspace:  "default",
+		User:       models.User{Username: "user"},
+	}
+
+	When(wrapped.Plan(ctx)).ThenReturn(command.ProjectResult{Command: command.Plan, PlanSuccess: &models.PlanSuccess{
+		TerraformOutput: "Plan: 1 to add, 2 to change, 3 to destroy.",
+	}})
+	runner.Plan(ctx)
+	When(wrapped.Apply(ctx)).ThenReturn(command.ProjectResult{Command: command.Apply, Failure: "locked"})
+	runner.Apply(ctx)
+	When(wrapped.Apply(ctx)).ThenReturn(command.ProjectResult{Command: command.Apply, Error: errors.New("exit status 1")})
+	runner.Apply(ctx)
+
+	Equals(t, 3, len(recorder.records))
+	plan := recorder.records[0]
+	Assert(t, plan.ID != "" && plan.ID != recorder.records[1].ID, "exp unique ids")
+	Assert(t, !plan.Time.IsZero(), "exp time to be set")
+	plan.ID, plan.Time, plan.DurationSeconds = "", time.Time{}, 0
+	Equals(t, analytics.Record{
+		Repo:               "owner/repo",
+		Pull:               1,
+		PullAuthor:         "author",
+		BaseBranch:         "main",
+		HeadCommit:         "abc123",
+		Dir:                "dir",
+		Workspace:          "default",
+		Command:            "plan",
+		User:               "user",
+		Outcome:            analytics.SuccessOutcome,
+		ResourcesToAdd:     1,
+		ResourcesToChange:  2,
+		ResourcesToDestroy: 3,
+	}, plan)
+	Equals(t, "apply", recorder.records[1].Command)
+	Equals(t, analytics.FailureOutcome, recorder.records[1].Outcome)
+	Equals(t, analytics.ErrorOutcome, recorder.records[2].Outcome)
+}
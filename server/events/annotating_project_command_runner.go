@@ -0,0 +1,46 @@
+package events
+
+import (
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/annotations"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// AnnotatingProjectCommandRunner annotates dashboards with a project's
+// applies once they finish.
+type AnnotatingProjectCommandRunner struct {
+	ProjectCommandRunner
+	Publisher *annotations.Publisher
+}
+
+func (a *AnnotatingProjectCommandRunner) Apply(ctx command.ProjectContext) command.ProjectResult {
+	start := time.Now()
+	result := a.ProjectCommandRunner.Apply(ctx)
+	// Failures are applies that Atlantis refused to run, ex. because they
+	// weren't approved, so they didn't change anything. Neither did applies
+	// of dirs that were deleted.
+	if _, ok := result.Error.(DirNotExistErr); ok {
+		return result
+	}
+	if result.Error == nil && result.ApplySuccess == "" {
+		return result
+	}
+	apply := annotations.Apply{
+		RepoFullName: ctx.Pull.BaseRepo.FullName,
+		ProjectName:  ctx.ProjectName,
+		Dir:          ctx.RepoRelDir,
+		Workspace:    ctx.Workspace,
+		PullNum:      ctx.Pull.Num,
+		PullURL:      ctx.Pull.URL,
+		User:         ctx.User.Username,
+		Success:      result.Error == nil,
+		Start:        start,
+		End:          time.Now(),
+	}
+	if result.Error != nil {
+		apply.Error = result.Error.Error()
+	}
+	a.Publisher.ApplyFinished(apply)
+	return result
+}
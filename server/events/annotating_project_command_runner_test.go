@@ -0,0 +1,64 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/annotations"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakeAnnotator struct {
+	applies []annotations.Apply
+}
+
+func (f *fakeAnnotator) Annotate(apply annotations.Apply) error {
+	f.applies = append(f.applies, apply)
+	return nil
+}
+
+func TestAnnotatingProjectCommandRunner(t *testing.T) {
+	RegisterMockTestingT(t)
+	wrapped := mocks.NewMockProjectCommandRunner()
+	annotator := &fakeAnnotator{}
+	runner := &events.AnnotatingProjectCommandRunner{
+		ProjectCommandRunner: wrapped,
+		Publisher: &annotations.Publisher{
+			Annotators: []annotations.Annotator{annotator},
+			Logger:     logging.NewNoopLogger(t),
+		},
+	}
+	ctx := command.ProjectContext{
+		Pull:        models.PullRequest{Num: 1, BaseRepo: models.Repo{FullName: "owner/repo"}},
+		User:        models.User{Username: "alice"},
+		ProjectName: "vpc",
+		RepoRelDir:  "dir",
+		Workspace:   "default",
+	}
+
+	When(wrapped.Plan(ctx)).ThenReturn(command.ProjectResult{PlanSuccess: &models.PlanSuccess{}})
+	runner.Plan(ctx)
+	When(wrapped.Apply(ctx)).ThenReturn(command.ProjectResult{Failure: "Pull request must be approved"})
+	runner.Apply(ctx)
+	When(wrapped.Apply(ctx)).ThenReturn(command.ProjectResult{Error: events.DirNotExistErr{RepoRelDir: "dir"}})
+	runner.Apply(ctx)
+	When(wrapped.Apply(ctx)).ThenReturn(command.ProjectResult{Error: errors.New("apply failed")})
+	runner.Apply(ctx)
+	When(wrapped.Apply(ctx)).ThenReturn(command.ProjectResult{ApplySuccess: "success"})
+	runner.Apply(ctx)
+
+	Equals(t, 2, len(annotator.applies))
+	Equals(t, false, annotator.applies[0].Success)
+	Equals(t, "apply failed", annotator.applies[0].Error)
+	Equals(t, true, annotator.applies[1].Success)
+	Equals(t, "owner/repo", annotator.applies[1].RepoFullName)
+	Equals(t, "vpc", annotator.applies[1].ProjectName)
+	Equals(t, "alice", annotator.applies[1].User)
+	Assert(t, !annotator.applies[1].End.Before(annotator.applies[1].Start), "end should not be before start")
+}
@@ -0,0 +1,110 @@
+// Package annotations annotates dashboards, ex. in Grafana, with the applies
+// that Atlantis runs so that infrastructure changes show up next to the
+// metrics and incidents they may have caused.
+package annotations
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// Apply is an apply of a project that finished.
+type Apply struct {
+	RepoFullName string `json:"repo"`
+	// ProjectName is empty if the project doesn't have a name.
+	ProjectName string `json:"project_name,omitempty"`
+	Dir         string `json:"dir"`
+	Workspace   string `json:"workspace"`
+	// Environment is the project's environment, ex. "prod". Empty if it's
+	// not known.
+	Environment string `json:"environment,omitempty"`
+	PullNum     int    `json:"pull"`
+	PullURL     string `json:"pull_url"`
+	User        string `json:"user"`
+	Success     bool   `json:"success"`
+	// Error is why the apply failed. Empty if it succeeded.
+	Error string    `json:"error,omitempty"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Text describes the apply, ex. "Atlantis apply succeeded for owner/repo
+// dir: staging workspace: default (#12 by alice)".
+func (a Apply) Text() string {
+	outcome := "succeeded"
+	if !a.Success {
+		outcome = "failed"
+	}
+	project := fmt.Sprintf("%s dir: %s workspace: %s", a.RepoFullName, a.Dir, a.Workspace)
+	if a.ProjectName != "" {
+		project = fmt.Sprintf("%s project: %s", project, a.ProjectName)
+	}
+	return fmt.Sprintf("Atlantis apply %s for %s (#%d by %s)", outcome, project, a.PullNum, a.User)
+}
+
+// Tags returns the tags that dashboards can filter the apply's annotations
+// by, ex. "atlantis", "apply", "success", "repo:owner/repo" and
+// "environment:prod".
+func (a Apply) Tags() []string {
+	outcome := "success"
+	if !a.Success {
+		outcome = "failure"
+	}
+	tags := []string{"atlantis", "apply", outcome, "repo:" + a.RepoFullName, "dir:" + a.Dir, "workspace:" + a.Workspace}
+	if a.ProjectName != "" {
+		tags = append(tags, "project:"+a.ProjectName)
+	}
+	if a.Environment != "" {
+		tags = append(tags, "environment:"+a.Environment)
+	}
+	return tags
+}
+
+// Annotator annotates dashboards with applies.
+type Annotator interface {
+	Annotate(apply Apply) error
+}
+
+// Publisher sends the applies that finish to each of its annotators.
+type Publisher struct {
+	Annotators []Annotator
+	// EnvironmentRegex finds the environment of a project in its name, or
+	// dir if it doesn't have a name. The environment is the first
+	// subexpression, or the whole match if there isn't one. If nil, the
+	// environment isn't set.
+	EnvironmentRegex *regexp.Regexp
+	Logger           logging.SimpleLogging
+}
+
+// ApplyFinished annotates the dashboards with apply. Errors are logged since
+// annotations are best effort.
+func (p *Publisher) ApplyFinished(apply Apply) {
+	apply.Environment = p.environment(apply)
+	for _, a := range p.Annotators {
+		if err := a.Annotate(apply); err != nil {
+			p.Logger.Warn("unable to annotate apply: %s", err)
+		}
+	}
+}
+
+func (p *Publisher) environment(apply Apply) string {
+	if p.EnvironmentRegex == nil {
+		return ""
+	}
+	name := apply.ProjectName
+	if name == "" {
+		name = apply.Dir
+	}
+	match := p.EnvironmentRegex.FindStringSubmatch(name)
+	switch {
+	case match == nil:
+		return ""
+	case len(match) > 1:
+		return match[1]
+	default:
+		return match[0]
+	}
+}
@@ -0,0 +1,61 @@
+package annotations_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/annotations"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakeAnnotator struct {
+	applies []annotations.Apply
+	err     error
+}
+
+func (f *fakeAnnotator) Annotate(apply annotations.Apply) error {
+	f.applies = append(f.applies, apply)
+	return f.err
+}
+
+func TestApply_TextAndTags(t *testing.T) {
+	apply := annotations.Apply{RepoFullName: "owner/repo", Dir: "prod", Workspace: "default", PullNum: 12, User: "alice", Success: true}
+	Equals(t, "Atlantis apply succeeded for owner/repo dir: prod workspace: default (#12 by alice)", apply.Text())
+	Equals(t, []string{"atlantis", "apply", "success", "repo:owner/repo", "dir:prod", "workspace:default"}, apply.Tags())
+
+	apply.Success = false
+	apply.ProjectName = "prod-vpc"
+	apply.Environment = "prod"
+	Equals(t, "Atlantis apply failed for owner/repo dir: prod workspace: default project: prod-vpc (#12 by alice)", apply.Text())
+	Equals(t, []string{"atlantis", "apply", "failure", "repo:owner/repo", "dir:prod", "workspace:default", "project:prod-vpc", "environment:prod"}, apply.Tags())
+}
+
+func TestPublisher_ApplyFinished(t *testing.T) {
+	cases := []struct {
+		description string
+		regex       *regexp.Regexp
+		apply       annotations.Apply
+		exp         string
+	}{
+		{"no regex", nil, annotations.Apply{ProjectName: "prod-vpc"}, ""},
+		{"subexpression of name", regexp.MustCompile(`^(prod|staging)-`), annotations.Apply{ProjectName: "prod-vpc", Dir: "vpc"}, "prod"},
+		{"whole match of dir", regexp.MustCompile(`staging`), annotations.Apply{Dir: "envs/staging"}, "staging"},
+		{"no match", regexp.MustCompile(`^(prod|staging)-`), annotations.Apply{ProjectName: "dev-vpc"}, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			failing := &fakeAnnotator{err: errors.New("unavailable")}
+			annotator := &fakeAnnotator{}
+			publisher := &annotations.Publisher{
+				Annotators:       []annotations.Annotator{failing, annotator},
+				EnvironmentRegex: c.regex,
+				Logger:           logging.NewNoopLogger(t),
+			}
+			publisher.ApplyFinished(c.apply)
+			Equals(t, 1, len(annotator.applies))
+			Equals(t, c.exp, annotator.applies[0].Environment)
+		})
+	}
+}
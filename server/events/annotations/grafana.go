@@ -0,0 +1,70 @@
+package annotations
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Grafana implements Annotator with Grafana's annotations HTTP API. The
+// annotations are organization wide so that any dashboard can show them by
+// querying their tags.
+type Grafana struct {
+	// URL is Grafana's URL, ex. https://grafana.example.com.
+	URL string
+	// APIKey is a service account token or API key with the Editor role.
+	APIKey string
+	Client *http.Client
+}
+
+// NewGrafana returns an annotator for the Grafana at url that authenticates
+// with apiKey.
+func NewGrafana(url string, apiKey string) *Grafana {
+	return &Grafana{
+		URL:    strings.TrimSuffix(url, "/"),
+		APIKey: apiKey,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type grafanaAnnotation struct {
+	// Time and TimeEnd are in milliseconds since the epoch.
+	Time    int64    `json:"time"`
+	TimeEnd int64    `json:"timeEnd"`
+	Tags    []string `json:"tags"`
+	Text    string   `json:"text"`
+}
+
+func (g *Grafana) Annotate(apply Apply) error {
+	text := apply.Text()
+	if apply.PullURL != "" {
+		text = fmt.Sprintf("%s\n%s", text, apply.PullURL)
+	}
+	body, err := json.Marshal(grafanaAnnotation{
+		Time:    apply.Start.UnixMilli(),
+		TimeEnd: apply.End.UnixMilli(),
+		Tags:    apply.Tags(),
+		Text:    text,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", g.URL+"/api/annotations", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+g.APIKey)
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("got status %d from Grafana", resp.StatusCode)
+	}
+	return nil
+}
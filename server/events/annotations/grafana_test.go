@@ -0,0 +1,50 @@
+package annotations_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/annotations"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestGrafana(t *testing.T) {
+	var path, auth, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		Ok(t, err)
+		path, auth, body = r.URL.Path, r.Header.Get("Authorization"), string(b)
+	}))
+	defer server.Close()
+
+	grafana := annotations.NewGrafana(server.URL+"/", "key")
+	start := time.Unix(1700000000, 0)
+	Ok(t, grafana.Annotate(annotations.Apply{
+		RepoFullName: "owner/repo",
+		Dir:          "dir",
+		Workspace:    "default",
+		PullNum:      1,
+		PullURL:      "https://github.com/owner/repo/pull/1",
+		User:         "alice",
+		Success:      true,
+		Start:        start,
+		End:          start.Add(90 * time.Second),
+	}))
+	Equals(t, "/api/annotations", path)
+	Equals(t, "Bearer key", auth)
+	Equals(t, `{"time":1700000000000,"timeEnd":1700000090000,"tags":["atlantis","apply","success","repo:owner/repo","dir:dir","workspace:default"],`+
+		`"text":"Atlantis apply succeeded for owner/repo dir: dir workspace: default (#1 by alice)\nhttps://github.com/owner/repo/pull/1"}`, body)
+}
+
+func TestGrafana_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	grafana := annotations.NewGrafana(server.URL, "key")
+	ErrEquals(t, "got status 401 from Grafana", grafana.Annotate(annotations.Apply{}))
+}
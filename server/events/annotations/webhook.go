@@ -0,0 +1,47 @@
+package annotations
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Webhook implements Annotator by POSTing the applies as JSON to URL, for
+// dashboards and timelines that Atlantis doesn't integrate with directly.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhook returns an annotator that POSTs to url.
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// webhookAnnotation is the body of the webhook requests.
+type webhookAnnotation struct {
+	Apply
+	Text string   `json:"text"`
+	Tags []string `json:"tags"`
+}
+
+func (w *Webhook) Annotate(apply Apply) error {
+	body, err := json.Marshal(webhookAnnotation{Apply: apply, Text: apply.Text(), Tags: apply.Tags()})
+	if err != nil {
+		return err
+	}
+	resp, err := w.Client.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sending to %s: got status %d", w.URL, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package annotations_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/annotations"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestWebhook(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		Ok(t, err)
+		body = string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	start := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)
+	Ok(t, annotations.NewWebhook(server.URL).Annotate(annotations.Apply{
+		RepoFullName: "owner/repo",
+		Dir:          "prod",
+		Workspace:    "default",
+		Environment:  "prod",
+		PullNum:      1,
+		PullURL:      "https://github.com/owner/repo/pull/1",
+		User:         "alice",
+		Error:        "exit status 1",
+		Start:        start,
+		End:          start.Add(time.Minute),
+	}))
+	Equals(t, `{"repo":"owner/repo","dir":"prod","workspace":"default","environment":"prod","pull":1,"pull_url":"https://github.com/owner/repo/pull/1",`+
+		`"user":"alice","success":false,"error":"exit status 1","start":"2023-11-14T22:13:20Z","end":"2023-11-14T22:14:20Z",`+
+		`"text":"Atlantis apply failed for owner/repo dir: prod workspace: default (#1 by alice)",`+
+		`"tags":["atlantis","apply","failure","repo:owner/repo","dir:prod","workspace:default","environment:prod"]}`, body)
+}
+
+func TestWebhook_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ErrEquals(t, "sending to "+server.URL+": got status 500", annotations.NewWebhook(server.URL).Annotate(annotations.Apply{}))
+}
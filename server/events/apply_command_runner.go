@@ -1,6 +1,9 @@
 package events
 
 import (
+	"fmt"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -63,6 +66,13 @@ type ApplyCommandRunner struct {
 	// are found
 	VCSStatusName              string
 	silenceVCSStatusNoProjects bool
+	// GlobalCfg is used to look up the checks that are ignored when checking
+	// if a pull request is mergeable.
+	GlobalCfg *valid.GlobalCfgStore
+	// PullStacks refuses to apply stacked pull requests until the pull
+	// requests they're stacked on are merged. If nil, pull requests aren't
+	// stacked.
+	PullStacks *PullStacks
 }
 
 func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
@@ -96,6 +106,24 @@ func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 		return
 	}
 
+	if a.PullStacks != nil {
+		ancestors, err := a.PullStacks.Ancestors(pull)
+		if err != nil {
+			ctx.Log.Err("finding the pull requests this pull request is stacked on: %s", err)
+			if err := a.vcsClient.CreateComment(baseRepo, pull.Num, fmt.Sprintf("**Error:** %s", err), command.Apply.String()); err != nil {
+				ctx.Log.Err("unable to comment on pull request: %s", err)
+			}
+			return
+		}
+		if len(ancestors) > 0 {
+			ctx.Log.Info("ignoring apply command since the pull request is stacked on #%d", ancestors[0].Num)
+			if err := a.vcsClient.CreateComment(baseRepo, pull.Num, pullStackApplyComment(ancestors), command.Apply.String()); err != nil {
+				ctx.Log.Err("unable to comment on pull request: %s", err)
+			}
+			return
+		}
+	}
+
 	if err = a.commitStatusUpdater.UpdateCombined(baseRepo, pull, models.PendingCommitStatus, cmd.CommandName()); err != nil {
 		ctx.Log.Warn("unable to update commit status: %s", err)
 	}
@@ -105,7 +133,7 @@ func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 	// required the Atlantis status checks to pass, then we've now changed
 	// the mergeability status of the pull request.
 	// This sets the approved, mergeable, and sqlocked status in the context.
-	ctx.PullRequestStatus, err = a.pullReqStatusFetcher.FetchPullStatus(baseRepo, pull, a.VCSStatusName)
+	ctx.PullRequestStatus, err = a.pullReqStatusFetcher.FetchPullStatus(baseRepo, pull, a.VCSStatusName, a.GlobalCfg.Get().MergeableIgnoredChecks(baseRepo.ID()))
 	if err != nil {
 		// On error we continue the request with mergeable assumed false.
 		// We want to continue because not all apply's will need this status,
@@ -125,6 +153,14 @@ func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 		return
 	}
 
+	for i := range projectCmds {
+		projectCmds[i].ForceApply = cmd.Force
+		projectCmds[i].DestroyApproved = cmd.DestroyApproved
+		projectCmds[i].ResourceChangesApproved = cmd.ResourceChangesApproved
+		projectCmds[i].PinnedCommit = cmd.SHA
+		projectCmds[i].TerraformLogLevel = cmd.TerraformLogLevel
+	}
+
 	// If there are no projects to apply, don't respond to the PR and ignore
 	if len(projectCmds) == 0 && a.SilenceNoProjects {
 		ctx.Log.Info("determined there was no project to run apply in.")
@@ -0,0 +1,225 @@
+package events
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// ApplyLimiter limits the number of applies that run at the same time so that
+// provider rate limits and state lock contention don't cause applies to fail.
+// Applies are limited globally, per repo and per apply concurrency group.
+// Applies over a limit wait until a running apply finishes. Waiting applies
+// run in the order they started waiting unless the applies ahead of them are
+// still over a limit.
+type ApplyLimiter struct {
+	// maxApplies is the maximum number of applies across all repos. 0 means
+	// there is no limit.
+	maxApplies int
+	// Reporter is told about the applies that wait and estimates how long
+	// applies take. If nil, waiting applies aren't reported.
+	Reporter ApplyQueueReporter
+
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	running int
+	repos   map[string]int
+	groups  map[string]int
+	// runningApplies and waiting are the applies that are running and the
+	// ones that are waiting, in the order they started waiting.
+	runningApplies map[*limitedApply]struct{}
+	waiting        []*limitedApply
+}
+
+// ApplyQueueReporter is told about the applies that wait for an ApplyLimiter.
+type ApplyQueueReporter interface {
+	// ExpectedDuration returns how long the apply for ctx is expected to
+	// run, or 0 if it isn't known.
+	ExpectedDuration(ctx command.ProjectContext) time.Duration
+	// Queued is called when the apply for ctx starts waiting and whenever
+	// its position changes. position is 1 for the first apply in the queue.
+	// eta is how long until the apply is expected to start, or 0 if it
+	// isn't known.
+	Queued(ctx command.ProjectContext, position int, eta time.Duration)
+	// Dequeued is called when the apply for ctx stops waiting after waited.
+	Dequeued(ctx command.ProjectContext, waited time.Duration)
+}
+
+// limitedApply is an apply that's running or waiting.
+type limitedApply struct {
+	ctx command.ProjectContext
+	// expected is how long the apply is expected to run, 0 if unknown.
+	expected time.Duration
+	started  time.Time
+}
+
+// NewApplyLimiter returns an ApplyLimiter that runs at most maxApplies
+// applies at once. If maxApplies is 0 only the per repo and per group limits
+// apply.
+func NewApplyLimiter(maxApplies int) *ApplyLimiter {
+	l := &ApplyLimiter{
+		maxApplies:     maxApplies,
+		repos:          make(map[string]int),
+		groups:         make(map[string]int),
+		runningApplies: make(map[*limitedApply]struct{}),
+	}
+	l.cond = sync.NewCond(&l.mutex)
+	return l
+}
+
+// Acquire blocks until an apply for ctx can run without exceeding any limit.
+// It returns a function that must be called once the apply is complete.
+func (l *ApplyLimiter) Acquire(ctx command.ProjectContext) func() {
+	repo := ctx.BaseRepo.FullName
+	group := ctx.ApplyConcurrencyGroup
+	apply := &limitedApply{ctx: ctx}
+	if l.Reporter != nil {
+		apply.expected = l.Reporter.ExpectedDuration(ctx)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	queuedAt := time.Now()
+	position := 0
+	for !l.canRun(ctx) || l.runnableAhead(apply) {
+		if position == 0 {
+			ctx.Log.Info("waiting for a running apply to finish before applying, %d applies are running", l.running)
+			l.waiting = append(l.waiting, apply)
+		}
+		if newPosition := l.position(apply); l.Reporter != nil && newPosition != position {
+			eta := l.eta(apply)
+			// The reporter may call the VCS host so it isn't called with
+			// the mutex held. The limits are checked again afterwards.
+			l.mutex.Unlock()
+			l.Reporter.Queued(ctx, newPosition, eta)
+			l.mutex.Lock()
+			position = newPosition
+			continue
+		}
+		position = l.position(apply)
+		l.cond.Wait()
+	}
+	apply.started = time.Now()
+	l.runningApplies[apply] = struct{}{}
+	l.running++
+	l.repos[repo]++
+	if group != "" {
+		l.groups[group]++
+	}
+	if position > 0 {
+		l.remove(apply)
+		// The applies behind this one moved up.
+		l.cond.Broadcast()
+		if l.Reporter != nil {
+			l.mutex.Unlock()
+			l.Reporter.Dequeued(ctx, apply.started.Sub(queuedAt))
+			l.mutex.Lock()
+		}
+	}
+
+	return func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		delete(l.runningApplies, apply)
+		l.running--
+		l.decrement(l.repos, repo)
+		if group != "" {
+			l.decrement(l.groups, group)
+		}
+		l.cond.Broadcast()
+	}
+}
+
+// canRun returns true if another apply for ctx is within every limit. It must
+// be called with mutex held.
+func (l *ApplyLimiter) canRun(ctx command.ProjectContext) bool {
+	if l.maxApplies > 0 && l.running >= l.maxApplies {
+		return false
+	}
+	if ctx.MaxConcurrentApplies > 0 && l.repos[ctx.BaseRepo.FullName] >= ctx.MaxConcurrentApplies {
+		return false
+	}
+	if ctx.ApplyConcurrencyGroup != "" && ctx.ApplyConcurrencyLimit > 0 && l.groups[ctx.ApplyConcurrencyGroup] >= ctx.ApplyConcurrencyLimit {
+		return false
+	}
+	return true
+}
+
+// runnableAhead returns true if an apply that's waiting ahead of apply can
+// run, in which case it runs first. It must be called with mutex held.
+func (l *ApplyLimiter) runnableAhead(apply *limitedApply) bool {
+	for _, waiting := range l.waiting {
+		if waiting == apply {
+			return false
+		}
+		if l.canRun(waiting.ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+// position returns the position of apply in the queue, starting at 1. It
+// must be called with mutex held.
+func (l *ApplyLimiter) position(apply *limitedApply) int {
+	for i, waiting := range l.waiting {
+		if waiting == apply {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// eta estimates how long until apply starts by assuming that each running
+// apply frees a slot once it has run for its expected duration and that the
+// applies ahead of apply take the slots in order. It returns 0 if the
+// duration of any of those applies isn't known. It must be called with mutex
+// held.
+func (l *ApplyLimiter) eta(apply *limitedApply) time.Duration {
+	now := time.Now()
+	// slots are when each running apply is expected to finish, from now.
+	var slots []time.Duration
+	for running := range l.runningApplies {
+		if running.expected == 0 {
+			return 0
+		}
+		remaining := running.expected - now.Sub(running.started)
+		if remaining < 0 {
+			remaining = 0
+		}
+		slots = append(slots, remaining)
+	}
+	if len(slots) == 0 {
+		return 0
+	}
+	for _, ahead := range l.waiting {
+		sort.Slice(slots, func(i, j int) bool { return slots[i] < slots[j] })
+		if ahead == apply {
+			break
+		}
+		if ahead.expected == 0 {
+			return 0
+		}
+		slots[0] += ahead.expected
+	}
+	return slots[0]
+}
+
+// remove removes apply from the queue. It must be called with mutex held.
+func (l *ApplyLimiter) remove(apply *limitedApply) {
+	for i, waiting := range l.waiting {
+		if waiting == apply {
+			l.waiting = append(l.waiting[:i], l.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+func (l *ApplyLimiter) decrement(counts map[string]int, key string) {
+	counts[key]--
+	if counts[key] <= 0 {
+		delete(counts, key)
+	}
+}
@@ -0,0 +1,162 @@
+package events_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func limiterCtx(t *testing.T, repo string, group string) command.ProjectContext {
+	return command.ProjectContext{
+		Log:                   logging.NewNoopLogger(t),
+		BaseRepo:              models.Repo{FullName: repo},
+		MaxConcurrentApplies:  2,
+		ApplyConcurrencyGroup: group,
+		ApplyConcurrencyLimit: 1,
+	}
+}
+
+// acquired returns true if Acquire for ctx returns before the timeout.
+func acquired(l *events.ApplyLimiter, ctx command.ProjectContext) (bool, chan func()) {
+	released := make(chan func(), 1)
+	go func() {
+		released <- l.Acquire(ctx)
+	}()
+	select {
+	case release := <-released:
+		released <- release
+		return true, released
+	case <-time.After(50 * time.Millisecond):
+		return false, released
+	}
+}
+
+func TestApplyLimiter_Group(t *testing.T) {
+	l := events.NewApplyLimiter(0)
+	release := l.Acquire(limiterCtx(t, "owner/a", "aws-prod"))
+
+	// The group limit is 1 so another project in the group waits, even in a
+	// different repo, but a project in another group doesn't.
+	ok, waiting := acquired(l, limiterCtx(t, "owner/b", "aws-prod"))
+	Assert(t, !ok, "exp apply in the same group to wait")
+	ok, _ = acquired(l, limiterCtx(t, "owner/b", "aws-dev"))
+	Assert(t, ok, "exp apply in another group to run")
+
+	release()
+	select {
+	case <-waiting:
+	case <-time.After(time.Second):
+		t.Fatal("exp waiting apply to run after release")
+	}
+}
+
+func TestApplyLimiter_Repo(t *testing.T) {
+	l := events.NewApplyLimiter(0)
+	l.Acquire(limiterCtx(t, "owner/a", ""))
+	l.Acquire(limiterCtx(t, "owner/a", ""))
+
+	ok, _ := acquired(l, limiterCtx(t, "owner/a", ""))
+	Assert(t, !ok, "exp third apply in the repo to wait")
+	ok, _ = acquired(l, limiterCtx(t, "owner/b", ""))
+	Assert(t, ok, "exp apply in another repo to run")
+}
+
+func TestApplyLimiter_Global(t *testing.T) {
+	l := events.NewApplyLimiter(1)
+	release := l.Acquire(limiterCtx(t, "owner/a", ""))
+
+	ok, waiting := acquired(l, limiterCtx(t, "owner/b", ""))
+	Assert(t, !ok, "exp apply to wait for the global limit")
+
+	release()
+	select {
+	case <-waiting:
+	case <-time.After(time.Second):
+		t.Fatal("exp waiting apply to run after release")
+	}
+}
+
+// recordingQueueReporter records the applies reported to it.
+type recordingQueueReporter struct {
+	expected map[string]time.Duration
+
+	mu      sync.Mutex
+	reports []string
+}
+
+func (r *recordingQueueReporter) ExpectedDuration(ctx command.ProjectContext) time.Duration {
+	return r.expected[ctx.RepoRelDir]
+}
+
+func (r *recordingQueueReporter) Queued(ctx command.ProjectContext, position int, eta time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, fmt.Sprintf("%s queued at %d, starts in %s", ctx.RepoRelDir, position, eta.Round(time.Minute)))
+}
+
+func (r *recordingQueueReporter) Dequeued(ctx command.ProjectContext, waited time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, fmt.Sprintf("%s dequeued", ctx.RepoRelDir))
+}
+
+func (r *recordingQueueReporter) Reports() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.reports...)
+}
+
+func TestApplyLimiter_Reporter(t *testing.T) {
+	l := events.NewApplyLimiter(1)
+	reporter := &recordingQueueReporter{expected: map[string]time.Duration{
+		"a": 10 * time.Minute,
+		"b": 5 * time.Minute,
+		"c": time.Minute,
+	}}
+	l.Reporter = reporter
+	ctx := func(dir string) command.ProjectContext {
+		ctx := limiterCtx(t, "owner/repo", "")
+		ctx.MaxConcurrentApplies = 0
+		ctx.RepoRelDir = dir
+		return ctx
+	}
+	release := l.Acquire(ctx("a"))
+	ok, waitingB := acquired(l, ctx("b"))
+	Assert(t, !ok, "exp b to wait")
+	ok, waitingC := acquired(l, ctx("c"))
+	Assert(t, !ok, "exp c to wait")
+	Equals(t, []string{
+		"b queued at 1, starts in 10m0s",
+		"c queued at 2, starts in 15m0s",
+	}, reporter.Reports())
+
+	// c moves up once b runs.
+	release()
+	select {
+	case release = <-waitingB:
+	case <-time.After(time.Second):
+		t.Fatal("exp b to run after a")
+	}
+	time.Sleep(50 * time.Millisecond)
+	Equals(t, []string{
+		"b queued at 1, starts in 10m0s",
+		"c queued at 2, starts in 15m0s",
+		"b dequeued",
+		"c queued at 1, starts in 5m0s",
+	}, reporter.Reports())
+
+	release()
+	select {
+	case <-waitingC:
+	case <-time.After(time.Second):
+		t.Fatal("exp c to run after b")
+	}
+	Equals(t, "c dequeued", reporter.Reports()[4])
+}
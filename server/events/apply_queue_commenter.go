@@ -0,0 +1,73 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+// applyQueueCommentMarker is hidden in the comment about a project's apply
+// waiting in the queue so that the comment can be updated as the apply moves
+// up. It's followed by the project's dir, workspace and name.
+const applyQueueCommentMarker = "<!-- atlantis-apply-queue"
+
+// ApplyQueueCommenter comments on the pull requests of the applies that wait
+// for the ApplyLimiter with their position in the queue and when they're
+// expected to start. It implements ApplyQueueReporter.
+type ApplyQueueCommenter struct {
+	VCSClient vcs.Client
+	// ProjectRuns are the projects' previous runs, used to estimate how long
+	// applies take. If nil, no estimates are made.
+	ProjectRuns ProjectRunStore
+}
+
+// ExpectedDuration returns the average duration of the project's recent
+// applies.
+func (a *ApplyQueueCommenter) ExpectedDuration(ctx command.ProjectContext) time.Duration {
+	if a.ProjectRuns == nil {
+		return 0
+	}
+	runs, err := a.ProjectRuns.ListProjectRuns()
+	if err != nil {
+		ctx.Log.Warn("unable to list project runs to estimate how long the apply takes: %s", err)
+		return 0
+	}
+	for _, run := range runs {
+		if run.Command == command.Apply.String() && run.RepoFullName == ctx.BaseRepo.FullName &&
+			run.Path == ctx.RepoRelDir && run.Workspace == ctx.Workspace && run.ProjectName == ctx.ProjectName {
+			return run.ExpectedDuration()
+		}
+	}
+	return 0
+}
+
+func (a *ApplyQueueCommenter) Queued(ctx command.ProjectContext, position int, eta time.Duration) {
+	msg := fmt.Sprintf("%s is waiting for other applies to finish. It's number %d in the queue", applyQueueProjectName(ctx), position)
+	if eta > 0 {
+		msg += fmt.Sprintf(" and should start in about %s", eta.Round(time.Second))
+	}
+	a.upsert(ctx, msg+".")
+}
+
+func (a *ApplyQueueCommenter) Dequeued(ctx command.ProjectContext, waited time.Duration) {
+	a.upsert(ctx, fmt.Sprintf("%s started after waiting %s in the queue.", applyQueueProjectName(ctx), waited.Round(time.Second)))
+}
+
+func (a *ApplyQueueCommenter) upsert(ctx command.ProjectContext, msg string) {
+	marker := fmt.Sprintf("%s %s -->", applyQueueCommentMarker, strings.Join([]string{ctx.RepoRelDir, ctx.Workspace, ctx.ProjectName}, " "))
+	if err := a.VCSClient.UpsertComment(ctx.Pull.BaseRepo, ctx.Pull.Num, fmt.Sprintf("%s\n%s", marker, msg), marker); err != nil {
+		ctx.Log.Warn("unable to comment about the apply's position in the queue: %s", err)
+	}
+}
+
+// applyQueueProjectName returns how the apply of ctx's project is referred
+// to in comments.
+func applyQueueProjectName(ctx command.ProjectContext) string {
+	if ctx.ProjectName != "" {
+		return fmt.Sprintf("The apply of project `%s`", ctx.ProjectName)
+	}
+	return fmt.Sprintf("The apply of dir `%s` workspace `%s`", ctx.RepoRelDir, ctx.Workspace)
+}
@@ -0,0 +1,53 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// staticProjectRuns is a ProjectRunStore that lists runs.
+type staticProjectRuns []models.ProjectRun
+
+func (s staticProjectRuns) UpdateProjectRun(run models.ProjectRun) error { return nil }
+
+func (s staticProjectRuns) ListProjectRuns() ([]models.ProjectRun, error) { return s, nil }
+
+func TestApplyQueueCommenter(t *testing.T) {
+	RegisterMockTestingT(t)
+	vcsClient := vcsmocks.NewMockClient()
+	repo := models.Repo{FullName: "owner/repo"}
+	commenter := &events.ApplyQueueCommenter{
+		VCSClient: vcsClient,
+		ProjectRuns: staticProjectRuns{
+			{RepoFullName: "owner/repo", Path: "staging", Workspace: "default", Command: "plan", Durations: []models.RunDuration{{Duration: time.Minute}}},
+			{RepoFullName: "owner/repo", Path: "staging", Workspace: "default", Command: "apply", Durations: []models.RunDuration{{Duration: 4 * time.Minute}, {Duration: 6 * time.Minute}}},
+		},
+	}
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		BaseRepo:   repo,
+		Pull:       models.PullRequest{BaseRepo: repo, Num: 1},
+		RepoRelDir: "staging",
+		Workspace:  "default",
+	}
+	Equals(t, 5*time.Minute, commenter.ExpectedDuration(ctx))
+	other := ctx
+	other.RepoRelDir = "prod"
+	Equals(t, time.Duration(0), commenter.ExpectedDuration(other))
+
+	marker := "<!-- atlantis-apply-queue staging default  -->"
+	commenter.Queued(ctx, 2, 3*time.Minute+20*time.Second)
+	vcsClient.VerifyWasCalledOnce().UpsertComment(repo, 1, marker+"\nThe apply of dir `staging` workspace `default` is waiting for other applies to finish. It's number 2 in the queue and should start in about 3m20s.", marker)
+	commenter.Queued(ctx, 1, 0)
+	vcsClient.VerifyWasCalledOnce().UpsertComment(repo, 1, marker+"\nThe apply of dir `staging` workspace `default` is waiting for other applies to finish. It's number 1 in the queue.", marker)
+	commenter.Dequeued(ctx, 4*time.Minute)
+	vcsClient.VerifyWasCalledOnce().UpsertComment(repo, 1, marker+"\nThe apply of dir `staging` workspace `default` started after waiting 4m0s in the queue.", marker)
+}
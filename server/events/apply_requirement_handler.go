@@ -1,10 +1,20 @@
 package events
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/core/config/raw"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/changetickets"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/i18n"
 )
 
 //go:generate pegomock generate -m --package mocks -o mocks/mock_apply_handler.go ApplyRequirement
@@ -14,6 +24,14 @@ type ApplyRequirement interface {
 
 type AggregateApplyRequirements struct {
 	WorkingDir WorkingDir
+	// VCSClient looks up the teams of users who approve destroying protected
+	// resources. If nil, destroys can't be approved.
+	VCSClient vcs.Client
+	// ChangeTickets opens the change tickets that must be approved before
+	// applying. If nil, the change_ticket_approved requirement can't be met.
+	ChangeTickets changetickets.Client
+	// Catalog translates the failures. If nil, they're in English.
+	Catalog *i18n.Catalog
 }
 
 func (a *AggregateApplyRequirements) ValidateProject(repoDir string, ctx command.ProjectContext) (failure string, err error) {
@@ -21,23 +39,142 @@ func (a *AggregateApplyRequirements) ValidateProject(repoDir string, ctx command
 		switch req {
 		case raw.ApprovedApplyRequirement:
 			if !ctx.PullReqStatus.ApprovalStatus.IsApproved {
-				return "Pull request must be approved by at least one person other than the author before running apply.", nil
+				return a.Catalog.T("apply_req.approved"), nil
 			}
 		// this should come before mergeability check since mergeability is a superset of this check.
 		case valid.PoliciesPassedApplyReq:
 			if ctx.ProjectPlanStatus == models.ErroredPolicyCheckStatus {
-				return "All policies must pass for project before running apply", nil
+				return a.Catalog.T("apply_req.policies_passed"), nil
 			}
 		case raw.MergeableApplyRequirement:
 			if !ctx.PullReqStatus.Mergeable {
-				return "Pull request must be mergeable before running apply.", nil
+				return a.Catalog.T("apply_req.mergeable"), nil
 			}
 		case raw.UnDivergedApplyRequirement:
 			if a.WorkingDir.HasDiverged(ctx.Log, repoDir) {
-				return "Default branch must be rebased onto pull request before running apply.", nil
+				return a.Catalog.T("apply_req.undiverged"), nil
+			}
+		case valid.NoDestroysApplyReq:
+			if failure, err := a.checkNoDestroys(repoDir, ctx); failure != "" || err != nil {
+				return failure, err
+			}
+		case valid.ChangeTicketApprovedApplyReq:
+			if failure, err := a.checkChangeTicket(repoDir, ctx); failure != "" || err != nil {
+				return failure, err
 			}
 		}
 	}
 	// Passed all apply requirements configured.
 	return "", nil
 }
+
+// checkNoDestroys returns a failure if the project's plan destroys or replaces
+// protected resources, unless the user approved it with --destroy-approved
+// and is in one of the approver teams.
+func (a *AggregateApplyRequirements) checkNoDestroys(repoDir string, ctx command.ProjectContext) (string, error) {
+	changes, err := readPlanResourceChanges(ctx, filepath.Join(repoDir, ctx.RepoRelDir))
+	if os.IsNotExist(errors.Cause(err)) {
+		return a.Catalog.T("apply_req.no_plan_json"), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var protected []string
+	for _, address := range changes.DestroyedAddresses() {
+		if ctx.DestroyProtection.Protects(address) {
+			protected = append(protected, address)
+		}
+	}
+	if len(protected) == 0 {
+		return "", nil
+	}
+
+	var approverTeams []string
+	if ctx.DestroyProtection != nil {
+		approverTeams = ctx.DestroyProtection.ApproverTeams
+	}
+	if ctx.DestroyApproved && a.VCSClient != nil && len(approverTeams) > 0 {
+		teams, err := a.VCSClient.GetTeamNamesForUser(ctx.Pull.BaseRepo, ctx.User)
+		if err != nil {
+			return "", errors.Wrapf(err, "getting teams of %s", ctx.User.Username)
+		}
+		if inTeams(teams, approverTeams) {
+			ctx.Log.Info("applying plan that destroys %s since %s approved it", strings.Join(protected, ", "), ctx.User.Username)
+			return "", nil
+		}
+		return a.Catalog.T("apply_req.destroys", strings.Join(protected, ", ")) + " " +
+			a.Catalog.T("apply_req.not_approver", strings.Join(approverTeams, ", ")), nil
+	}
+	failure := a.Catalog.T("apply_req.destroys", strings.Join(protected, ", "))
+	if len(approverTeams) > 0 {
+		failure += " " + a.Catalog.T("apply_req.destroy_approver", strings.Join(approverTeams, ", "))
+	}
+	return failure, nil
+}
+
+// readPlanResourceChanges returns the changes that the plan of ctx in
+// projAbsPath makes, from the output of terraform show -json that was saved
+// when it was planned.
+func readPlanResourceChanges(ctx command.ProjectContext, projAbsPath string) (models.PlanResourceChanges, error) {
+	showJSON, err := os.ReadFile(filepath.Join(projAbsPath, ctx.GetShowResultFileName())) // nolint: gosec
+	if err != nil {
+		return nil, errors.Wrap(err, "reading plan JSON")
+	}
+	return models.ParsePlanResourceChanges(showJSON)
+}
+
+// changeTicketExt is appended to the name of a plan file to get the name of
+// the file with the ID of the plan's change ticket.
+const changeTicketExt = ".change-ticket"
+
+// checkChangeTicket opens a change ticket with the plan's summary the first
+// time the plan is applied and returns a failure until the ticket is
+// approved.
+func (a *AggregateApplyRequirements) checkChangeTicket(repoDir string, ctx command.ProjectContext) (string, error) {
+	if a.ChangeTickets == nil {
+		return a.Catalog.T("apply_req.no_change_mgmt"), nil
+	}
+	projAbsPath := filepath.Join(repoDir, ctx.RepoRelDir)
+	ticketPath := filepath.Join(projAbsPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName)) + changeTicketExt
+	id, err := os.ReadFile(ticketPath) // nolint: gosec
+	if err == nil {
+		approved, err := a.ChangeTickets.Approved(string(id))
+		if err != nil {
+			return "", errors.Wrapf(err, "checking change ticket %s", id)
+		}
+		if !approved {
+			return a.Catalog.T("apply_req.ticket_pending", id, a.ChangeTickets.URL(string(id))), nil
+		}
+		return "", nil
+	}
+	if !os.IsNotExist(err) {
+		return "", errors.Wrap(err, "reading change ticket")
+	}
+
+	output, err := os.ReadFile(filepath.Join(projAbsPath, ctx.GetPlanOutputFileName())) // nolint: gosec
+	if os.IsNotExist(err) {
+		return a.Catalog.T("apply_req.no_plan_output"), nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "reading plan output")
+	}
+	plan := models.PlanSuccess{TerraformOutput: string(output)}
+	project := ctx.ProjectName
+	if project == "" {
+		project = ctx.RepoRelDir
+	}
+	ticket := changetickets.Ticket{
+		Summary: fmt.Sprintf("Apply %s in %s", project, ctx.Pull.BaseRepo.FullName),
+		Description: fmt.Sprintf("Atlantis will apply the plan of %s in workspace %s once this ticket is approved.\n\nPull request: %s\nRequested by: %s\nPlan: %s",
+			project, ctx.Workspace, ctx.Pull.URL, ctx.User.Username, plan.Summary()),
+	}
+	newID, err := a.ChangeTickets.Create(ticket)
+	if err != nil {
+		return "", errors.Wrap(err, "opening change ticket")
+	}
+	if err := os.WriteFile(ticketPath, []byte(newID), 0600); err != nil {
+		return "", errors.Wrap(err, "recording change ticket")
+	}
+	ctx.Log.Info("opened change ticket %s", newID)
+	return a.Catalog.T("apply_req.ticket_opened", newID, a.ChangeTickets.URL(newID)), nil
+}
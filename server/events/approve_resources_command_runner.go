@@ -0,0 +1,139 @@
+package events
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+// resourcesApprovedExt is appended to the name of a plan file to get the name
+// of the file that records who approved the plan's changes to protected
+// resources.
+const resourcesApprovedExt = ".resources-approved"
+
+func NewApproveResourcesCommandRunner(
+	vcsClient vcs.Client,
+	workingDir WorkingDir,
+	pendingPlanFinder PendingPlanFinder,
+	globalCfgStore *valid.GlobalCfgStore,
+) *ApproveResourcesCommandRunner {
+	return &ApproveResourcesCommandRunner{
+		vcsClient:         vcsClient,
+		workingDir:        workingDir,
+		pendingPlanFinder: pendingPlanFinder,
+		globalCfgStore:    globalCfgStore,
+	}
+}
+
+// ApproveResourcesCommandRunner approves the changes to protected resources in
+// a pull request's plans so that they can be applied.
+type ApproveResourcesCommandRunner struct {
+	vcsClient         vcs.Client
+	workingDir        WorkingDir
+	pendingPlanFinder PendingPlanFinder
+	globalCfgStore    *valid.GlobalCfgStore
+}
+
+func (a *ApproveResourcesCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
+	msg, err := a.approve(ctx)
+	if err != nil {
+		ctx.Log.Err("approving changes to protected resources: %s", err)
+		msg = fmt.Sprintf("**%s Error**\n```\n%s\n```", command.ApproveResources.TitleString(), err)
+	}
+	if commentErr := a.vcsClient.CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, msg, command.ApproveResources.String()); commentErr != nil {
+		ctx.Log.Err("unable to comment: %s", commentErr)
+	}
+}
+
+// approve records the approval next to every pending plan that changes
+// protected resources and returns the comment to reply with.
+func (a *ApproveResourcesCommandRunner) approve(ctx *command.Context) (string, error) {
+	protected := a.globalCfgStore.Get().ProtectedResources(ctx.Pull.BaseRepo.ID())
+	if protected == nil {
+		return "This repo doesn't have any protected resources.", nil
+	}
+	teams, err := a.vcsClient.GetTeamNamesForUser(ctx.Pull.BaseRepo, ctx.User)
+	if err != nil {
+		return "", errors.Wrapf(err, "getting teams of %s", ctx.User.Username)
+	}
+	if !inTeams(teams, protected.ApproverTeams) {
+		return "", fmt.Errorf("only members of %s can approve changes to protected resources", strings.Join(protected.ApproverTeams, ", "))
+	}
+
+	pullDir, err := a.workingDir.GetPullDir(ctx.Pull.BaseRepo, ctx.Pull)
+	if os.IsNotExist(err) {
+		return "There are no plans to approve. Run plan first.", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	plans, err := a.pendingPlanFinder.Find(pullDir)
+	if err != nil {
+		return "", err
+	}
+	var approved []string
+	for _, plan := range plans {
+		projAbsPath := filepath.Join(plan.RepoDir, plan.RepoRelDir)
+		prjCtx := command.ProjectContext{ProjectName: plan.ProjectName, Workspace: plan.Workspace}
+		addresses, err := protectedChanges(protected, prjCtx, projAbsPath)
+		if err != nil {
+			return "", err
+		}
+		if len(addresses) == 0 {
+			continue
+		}
+		planPath := filepath.Join(projAbsPath, runtime.GetPlanFilename(plan.Workspace, plan.ProjectName))
+		if err := os.WriteFile(planPath+resourcesApprovedExt, []byte(ctx.User.Username), 0600); err != nil {
+			return "", errors.Wrap(err, "recording approval")
+		}
+		ctx.Log.Info("%s approved changes to %s in dir %s workspace %s", ctx.User.Username, strings.Join(addresses, ", "), plan.RepoRelDir, plan.Workspace)
+		approved = append(approved, fmt.Sprintf("* dir: `%s` workspace: `%s`: %s", plan.RepoRelDir, plan.Workspace, strings.Join(addresses, ", ")))
+	}
+	if len(approved) == 0 {
+		return "None of this pull request's plans change protected resources.", nil
+	}
+	return fmt.Sprintf("Approved the changes to protected resources in these plans:\n%s\n\nPlanning again discards the approval.", strings.Join(approved, "\n")), nil
+}
+
+// protectedChanges returns the protected resources that the plan of ctx in
+// projAbsPath changes.
+func protectedChanges(protected *valid.ProtectedResources, ctx command.ProjectContext, projAbsPath string) ([]string, error) {
+	changes, err := readPlanResourceChanges(ctx, projAbsPath)
+	if err != nil {
+		return nil, err
+	}
+	return protected.Matching(changes.ChangedAddresses()), nil
+}
+
+// userInTeams returns true if user is a member of one of allowed on the VCS
+// host of repo.
+func userInTeams(client vcs.Client, repo models.Repo, user models.User, allowed []string) (bool, error) {
+	if len(allowed) == 0 {
+		return false, nil
+	}
+	teams, err := client.GetTeamNamesForUser(repo, user)
+	if err != nil {
+		return false, errors.Wrapf(err, "getting teams of %s", user.Username)
+	}
+	return inTeams(teams, allowed), nil
+}
+
+// inTeams returns true if one of teams is one of allowed.
+func inTeams(teams []string, allowed []string) bool {
+	for _, team := range teams {
+		for _, a := range allowed {
+			if strings.EqualFold(team, a) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestApproveResourcesCommandRunner_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	vcsClient := vcsmocks.NewMockClient()
+	workingDir := mocks.NewMockWorkingDir()
+	finder := mocks.NewMockPendingPlanFinder()
+	globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	globalCfg.Repos[0].ProtectedResources = &valid.ProtectedResources{
+		Addresses:     []string{"aws_route53_zone.*", "module.vpc.*"},
+		ApproverTeams: []string{"network"},
+	}
+	r := events.NewApproveResourcesCommandRunner(vcsClient, workingDir, finder, valid.NewGlobalCfgStore(globalCfg))
+
+	pullDir, cleanup := TempDir(t)
+	defer cleanup()
+	repoDir := filepath.Join(pullDir, "default")
+	Ok(t, os.MkdirAll(filepath.Join(repoDir, "dns"), 0700))
+	Ok(t, os.MkdirAll(filepath.Join(repoDir, "app"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "dns", "default.json"), []byte(`{"resource_changes": [{"address": "aws_route53_zone.main", "change": {"actions": ["update"]}}]}`), 0600))
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "app", "default.json"), []byte(`{"resource_changes": [{"address": "aws_instance.web", "change": {"actions": ["create"]}}]}`), 0600))
+
+	repo := models.Repo{FullName: "owner/repo"}
+	pull := models.PullRequest{BaseRepo: repo, Num: 1}
+	user := models.User{Username: "user"}
+	ctx := &command.Context{
+		User: user,
+		Log:  logging.NewNoopLogger(t),
+		Pull: pull,
+	}
+	When(workingDir.GetPullDir(repo, pull)).ThenReturn(pullDir, nil)
+	When(finder.Find(pullDir)).ThenReturn([]events.PendingPlan{
+		{RepoDir: repoDir, RepoRelDir: "dns", Workspace: "default"},
+		{RepoDir: repoDir, RepoRelDir: "app", Workspace: "default"},
+	}, nil)
+	approvalFile := filepath.Join(repoDir, "dns", "default.tfplan.resources-approved")
+
+	When(vcsClient.GetTeamNamesForUser(repo, user)).ThenReturn([]string{"app"}, nil)
+	r.Run(ctx, &events.CommentCommand{Name: command.ApproveResources})
+	vcsClient.VerifyWasCalledOnce().CreateComment(repo, 1, "**Approve Resources Error**\n```\nonly members of network can approve changes to protected resources\n```", "approve_resources")
+	_, err := os.Stat(approvalFile)
+	Assert(t, os.IsNotExist(err), "exp approval not to be recorded")
+
+	When(vcsClient.GetTeamNamesForUser(repo, user)).ThenReturn([]string{"app", "network"}, nil)
+	r.Run(ctx, &events.CommentCommand{Name: command.ApproveResources})
+	vcsClient.VerifyWasCalledOnce().CreateComment(repo, 1, "Approved the changes to protected resources in these plans:\n* dir: `dns` workspace: `default`: aws_route53_zone.main\n\nPlanning again discards the approval.", "approve_resources")
+	approver, err := os.ReadFile(approvalFile)
+	Ok(t, err)
+	Equals(t, "user", string(approver))
+	_, err = os.Stat(filepath.Join(repoDir, "app", "default.tfplan.resources-approved"))
+	Assert(t, os.IsNotExist(err), "exp plan without protected resources not to be approved")
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/jobs"
+)
+
+func NewCancelCommandRunner(
+	vcsClient vcs.Client,
+	canceller *jobs.JobCanceller,
+) *CancelCommandRunner {
+	return &CancelCommandRunner{
+		vcsClient: vcsClient,
+		canceller: canceller,
+	}
+}
+
+// CancelCommandRunner cancels the plans and applies that are running in a
+// pull request.
+type CancelCommandRunner struct {
+	vcsClient vcs.Client
+	canceller *jobs.JobCanceller
+}
+
+func (c *CancelCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
+	var cancelled []string
+	var errs []string
+	for _, job := range c.canceller.Running(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num) {
+		if !job.Matches(cmd.ProjectName, cmd.RepoRelDir, cmd.Workspace) {
+			continue
+		}
+		if _, err := c.canceller.Cancel(job.ID, "@"+ctx.User.Username); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		cancelled = append(cancelled, fmt.Sprintf("* `%s` of %s", job.Command, runningJobName(job)))
+	}
+
+	var msg string
+	switch {
+	case len(cancelled) > 0:
+		msg = fmt.Sprintf("Cancelled:\n%s\n\nTheir processes were interrupted so that Terraform can release its state locks.", strings.Join(cancelled, "\n"))
+	case len(errs) > 0:
+		msg = fmt.Sprintf("**%s Error**\n```\n%s\n```", command.Cancel.TitleString(), strings.Join(errs, "\n"))
+	default:
+		msg = "No plans or applies that match are running in this pull request."
+	}
+	if err := c.vcsClient.CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, msg, command.Cancel.String()); err != nil {
+		ctx.Log.Err("unable to comment: %s", err)
+	}
+}
+
+// runningJobName returns how job's project is referred to in comments.
+func runningJobName(job jobs.RunningJob) string {
+	if job.ProjectName != "" {
+		return fmt.Sprintf("project `%s`", job.ProjectName)
+	}
+	return fmt.Sprintf("dir `%s` workspace `%s`", job.RepoRelDir, job.Workspace)
+}
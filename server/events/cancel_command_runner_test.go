@@ -0,0 +1,86 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCancelCommandRunner_Run(t *testing.T) {
+	repo := models.Repo{FullName: "owner/repo"}
+	pull := models.PullRequest{BaseRepo: repo, Num: 1}
+	projectCtx := func(jobID string, projectName string, dir string) command.ProjectContext {
+		return command.ProjectContext{
+			Log:         logging.NewNoopLogger(t),
+			BaseRepo:    repo,
+			Pull:        pull,
+			JobID:       jobID,
+			ProjectName: projectName,
+			RepoRelDir:  dir,
+			Workspace:   "default",
+		}
+	}
+
+	cases := []struct {
+		description string
+		cmd         events.CommentCommand
+		expComment  string
+		expRunning  []string
+	}{
+		{
+			description: "all jobs",
+			cmd:         events.CommentCommand{Name: command.Cancel},
+			expComment:  "Cancelled:\n* `plan` of project `app`\n* `apply` of dir `modules/db` workspace `default`\n\nTheir processes were interrupted so that Terraform can release its state locks.",
+		},
+		{
+			description: "by project",
+			cmd:         events.CommentCommand{Name: command.Cancel, ProjectName: "app"},
+			expComment:  "Cancelled:\n* `plan` of project `app`\n\nTheir processes were interrupted so that Terraform can release its state locks.",
+			expRunning:  []string{"db-job"},
+		},
+		{
+			description: "no match",
+			cmd:         events.CommentCommand{Name: command.Cancel, RepoRelDir: "other"},
+			expComment:  "No plans or applies that match are running in this pull request.",
+			expRunning:  []string{"app-job", "db-job"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			vcsClient := vcsmocks.NewMockClient()
+			canceller := jobs.NewJobCanceller(nil, time.Minute)
+			endApp := canceller.Begin(projectCtx("app-job", "app", "apps/app"), command.Plan)
+			defer endApp()
+			endDB := canceller.Begin(projectCtx("db-job", "", "modules/db"), command.Apply)
+			defer endDB()
+
+			r := events.NewCancelCommandRunner(vcsClient, canceller)
+			ctx := &command.Context{
+				User: models.User{Username: "alice"},
+				Log:  logging.NewNoopLogger(t),
+				Pull: pull,
+			}
+			r.Run(ctx, &c.cmd)
+
+			vcsClient.VerifyWasCalledOnce().CreateComment(repo, 1, c.expComment, "cancel")
+			var running []string
+			for _, job := range canceller.Running(repo.FullName, pull.Num) {
+				if job.CancelledBy == "" {
+					running = append(running, job.ID)
+				} else {
+					Equals(t, "@alice", job.CancelledBy)
+				}
+			}
+			Equals(t, c.expRunning, running)
+		})
+	}
+}
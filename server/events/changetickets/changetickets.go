@@ -0,0 +1,69 @@
+// Package changetickets opens change tickets for applies in change management
+// systems and checks that they're approved.
+package changetickets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Ticket is a change ticket for applying a project's plan.
+type Ticket struct {
+	Summary     string
+	Description string
+}
+
+// Client opens, checks and closes change tickets in a change management
+// system.
+type Client interface {
+	// Create opens ticket and returns its ID.
+	Create(ticket Ticket) (string, error)
+	// Approved returns true if the ticket with id has been approved.
+	Approved(id string) (bool, error)
+	// Close closes the ticket with id after its change was applied, with
+	// comment as the closing notes.
+	Close(id string, comment string) error
+	// URL returns the link to the ticket with id.
+	URL(id string) string
+}
+
+// newJSONRequest returns a request to url with body encoded as JSON, or no
+// body if it's nil.
+func newJSONRequest(method string, url string, body interface{}) (*http.Request, error) {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// doJSON sends req and decodes the response into resp unless it's nil.
+func doJSON(client *http.Client, req *http.Request, system string, resp interface{}) error {
+	r, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close() // nolint: errcheck
+	if r.StatusCode < 200 || r.StatusCode >= 300 {
+		return fmt.Errorf("got status %d from %s", r.StatusCode, system)
+	}
+	if resp == nil {
+		return nil
+	}
+	return json.NewDecoder(r.Body).Decode(resp)
+}
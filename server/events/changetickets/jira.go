@@ -0,0 +1,110 @@
+package changetickets
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Jira implements Client with issues in a Jira project. Issues are approved
+// when they reach ApprovedStatus and closed with the CloseTransition
+// workflow transition.
+type Jira struct {
+	BaseURL string
+	User    string
+	// Token is the API token of User, or its password on Jira Server.
+	Token string
+	// Project is the key of the project that issues are created in.
+	Project         string
+	IssueType       string
+	ApprovedStatus  string
+	CloseTransition string
+	Client          *http.Client
+}
+
+// NewJira returns a client for the Jira site at jiraURL that creates issues of
+// issueType in project.
+func NewJira(jiraURL string, user string, token string, project string, issueType string, approvedStatus string, closeTransition string) *Jira {
+	return &Jira{
+		BaseURL:         strings.TrimSuffix(jiraURL, "/"),
+		User:            user,
+		Token:           token,
+		Project:         project,
+		IssueType:       issueType,
+		ApprovedStatus:  approvedStatus,
+		CloseTransition: closeTransition,
+		Client:          &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (j *Jira) Create(ticket Ticket) (string, error) {
+	req := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": j.Project},
+			"issuetype":   map[string]string{"name": j.IssueType},
+			"summary":     ticket.Summary,
+			"description": ticket.Description,
+		},
+	}
+	var issue struct {
+		Key string `json:"key"`
+	}
+	if err := j.do(http.MethodPost, "/rest/api/2/issue", req, &issue); err != nil {
+		return "", err
+	}
+	return issue.Key, nil
+}
+
+func (j *Jira) Approved(id string) (bool, error) {
+	var issue struct {
+		Fields struct {
+			Status struct {
+				Name string `json:"name"`
+			} `json:"status"`
+		} `json:"fields"`
+	}
+	if err := j.do(http.MethodGet, "/rest/api/2/issue/"+url.PathEscape(id)+"?fields=status", nil, &issue); err != nil {
+		return false, err
+	}
+	return strings.EqualFold(issue.Fields.Status.Name, j.ApprovedStatus), nil
+}
+
+func (j *Jira) Close(id string, comment string) error {
+	issuePath := "/rest/api/2/issue/" + url.PathEscape(id)
+	if err := j.do(http.MethodPost, issuePath+"/comment", map[string]string{"body": comment}, nil); err != nil {
+		return err
+	}
+	var transitions struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"transitions"`
+	}
+	if err := j.do(http.MethodGet, issuePath+"/transitions", nil, &transitions); err != nil {
+		return err
+	}
+	for _, t := range transitions.Transitions {
+		if strings.EqualFold(t.Name, j.CloseTransition) {
+			req := map[string]interface{}{"transition": map[string]string{"id": t.ID}}
+			return j.do(http.MethodPost, issuePath+"/transitions", req, nil)
+		}
+	}
+	return fmt.Errorf("Jira issue %s has no %q transition", id, j.CloseTransition)
+}
+
+func (j *Jira) URL(id string) string {
+	return j.BaseURL + "/browse/" + url.PathEscape(id)
+}
+
+// do sends a request with body as JSON to path and decodes the response into
+// resp unless it's nil.
+func (j *Jira) do(method string, path string, body interface{}, resp interface{}) error {
+	req, err := newJSONRequest(method, j.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(j.User, j.Token)
+	return doJSON(j.Client, req, "Jira", resp)
+}
@@ -0,0 +1,62 @@
+package changetickets_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/changetickets"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestJira(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, token, _ := r.BasicAuth()
+		Equals(t, "user:token", user+":"+token)
+		body, err := io.ReadAll(r.Body)
+		Ok(t, err)
+		requests = append(requests, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+		switch r.URL.Path {
+		case "/rest/api/2/issue":
+			w.Write([]byte(`{"id":"10001","key":"CHG-1"}`)) // nolint: errcheck
+		case "/rest/api/2/issue/CHG-1":
+			w.Write([]byte(`{"fields":{"status":{"name":"approved"}}}`)) // nolint: errcheck
+		case "/rest/api/2/issue/CHG-1/transitions":
+			if r.Method == http.MethodGet {
+				w.Write([]byte(`{"transitions":[{"id":"11","name":"Reject"},{"id":"31","name":"Done"}]}`)) // nolint: errcheck
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case "/rest/api/2/issue/CHG-1/comment":
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	jira := changetickets.NewJira(server.URL+"/", "user", "token", "CHG", "Change", "Approved", "Done")
+	id, err := jira.Create(changetickets.Ticket{Summary: "Apply network", Description: "Plan: 1 to add, 0 to change, 0 to destroy."})
+	Ok(t, err)
+	Equals(t, "CHG-1", id)
+	approved, err := jira.Approved(id)
+	Ok(t, err)
+	Assert(t, approved, "expected ticket to be approved")
+	Ok(t, jira.Close(id, "Applied"))
+	Equals(t, server.URL+"/browse/CHG-1", jira.URL(id))
+
+	Equals(t, []string{
+		`POST /rest/api/2/issue {"fields":{"description":"Plan: 1 to add, 0 to change, 0 to destroy.","issuetype":{"name":"Change"},"project":{"key":"CHG"},"summary":"Apply network"}}`,
+		`GET /rest/api/2/issue/CHG-1?fields=status `,
+		`POST /rest/api/2/issue/CHG-1/comment {"body":"Applied"}`,
+		`GET /rest/api/2/issue/CHG-1/transitions `,
+		`POST /rest/api/2/issue/CHG-1/transitions {"transition":{"id":"31"}}`,
+	}, requests)
+
+	_, err = jira.Approved("CHG-2")
+	ErrEquals(t, "got status 404 from Jira", err)
+	jira.CloseTransition = "Close"
+	ErrEquals(t, `Jira issue CHG-1 has no "Close" transition`, jira.Close(id, "Applied"))
+}
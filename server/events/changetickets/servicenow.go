@@ -0,0 +1,105 @@
+package changetickets
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// serviceNowClosedState is the state of closed change requests.
+const serviceNowClosedState = "3"
+
+// ServiceNow implements Client with change requests in a ServiceNow
+// instance. Tickets are identified by their number, ex. CHG0030001, and are
+// approved when their approval is "approved".
+type ServiceNow struct {
+	BaseURL  string
+	User     string
+	Password string
+	Client   *http.Client
+}
+
+// NewServiceNow returns a client for the ServiceNow instance at instanceURL.
+func NewServiceNow(instanceURL string, user string, password string) *ServiceNow {
+	return &ServiceNow{
+		BaseURL:  strings.TrimSuffix(instanceURL, "/"),
+		User:     user,
+		Password: password,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type serviceNowChange struct {
+	SysID    string `json:"sys_id"`
+	Number   string `json:"number"`
+	Approval string `json:"approval"`
+}
+
+func (s *ServiceNow) Create(ticket Ticket) (string, error) {
+	req := map[string]string{
+		"short_description": ticket.Summary,
+		"description":       ticket.Description,
+	}
+	var resp struct {
+		Result serviceNowChange `json:"result"`
+	}
+	if err := s.do(http.MethodPost, "/api/now/table/change_request", req, &resp); err != nil {
+		return "", err
+	}
+	return resp.Result.Number, nil
+}
+
+func (s *ServiceNow) Approved(id string) (bool, error) {
+	change, err := s.get(id)
+	if err != nil {
+		return false, err
+	}
+	return change.Approval == "approved", nil
+}
+
+func (s *ServiceNow) Close(id string, comment string) error {
+	change, err := s.get(id)
+	if err != nil {
+		return err
+	}
+	req := map[string]string{
+		"state":       serviceNowClosedState,
+		"close_code":  "successful",
+		"close_notes": comment,
+	}
+	return s.do(http.MethodPatch, "/api/now/table/change_request/"+url.PathEscape(change.SysID), req, nil)
+}
+
+func (s *ServiceNow) URL(id string) string {
+	return s.BaseURL + "/change_request.do?sysparm_query=" + url.QueryEscape("number="+id)
+}
+
+// get returns the change request with number id.
+func (s *ServiceNow) get(id string) (serviceNowChange, error) {
+	query := url.Values{
+		"sysparm_query":  {"number=" + id},
+		"sysparm_fields": {"sys_id,number,approval"},
+		"sysparm_limit":  {"1"},
+	}
+	var resp struct {
+		Result []serviceNowChange `json:"result"`
+	}
+	if err := s.do(http.MethodGet, "/api/now/table/change_request?"+query.Encode(), nil, &resp); err != nil {
+		return serviceNowChange{}, err
+	}
+	if len(resp.Result) == 0 {
+		return serviceNowChange{}, fmt.Errorf("ServiceNow change request %s not found", id)
+	}
+	return resp.Result[0], nil
+}
+
+func (s *ServiceNow) do(method string, path string, body interface{}, resp interface{}) error {
+	req, err := newJSONRequest(method, s.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.User, s.Password)
+	return doJSON(s.Client, req, "ServiceNow", resp)
+}
@@ -0,0 +1,61 @@
+package changetickets_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/changetickets"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestServiceNow(t *testing.T) {
+	var requests []string
+	approval := "requested"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		Equals(t, "user:password", user+":"+password)
+		body, err := io.ReadAll(r.Body)
+		Ok(t, err)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"result":{"sys_id":"abc","number":"CHG0030001","approval":"not yet requested"}}`)) // nolint: errcheck
+		case r.Method == http.MethodGet && r.URL.Query().Get("sysparm_query") == "number=CHG0030001":
+			w.Write([]byte(`{"result":[{"sys_id":"abc","number":"CHG0030001","approval":"` + approval + `"}]}`)) // nolint: errcheck
+		case r.Method == http.MethodGet:
+			w.Write([]byte(`{"result":[]}`)) // nolint: errcheck
+		default:
+			w.Write([]byte(`{"result":{}}`)) // nolint: errcheck
+		}
+	}))
+	defer server.Close()
+
+	sn := changetickets.NewServiceNow(server.URL, "user", "password")
+	id, err := sn.Create(changetickets.Ticket{Summary: "Apply network", Description: "Plan: 1 to add, 0 to change, 0 to destroy."})
+	Ok(t, err)
+	Equals(t, "CHG0030001", id)
+
+	approved, err := sn.Approved(id)
+	Ok(t, err)
+	Assert(t, !approved, "expected ticket not to be approved")
+	approval = "approved"
+	approved, err = sn.Approved(id)
+	Ok(t, err)
+	Assert(t, approved, "expected ticket to be approved")
+
+	Ok(t, sn.Close(id, "Applied"))
+	Equals(t, server.URL+"/change_request.do?sysparm_query=number%3DCHG0030001", sn.URL(id))
+	Equals(t, []string{
+		`POST /api/now/table/change_request {"description":"Plan: 1 to add, 0 to change, 0 to destroy.","short_description":"Apply network"}`,
+		`GET /api/now/table/change_request `,
+		`GET /api/now/table/change_request `,
+		`GET /api/now/table/change_request `,
+		`PATCH /api/now/table/change_request/abc {"close_code":"successful","close_notes":"Applied","state":"3"}`,
+	}, requests)
+
+	_, err = sn.Approved("CHG0030002")
+	ErrEquals(t, "ServiceNow change request CHG0030002 not found", err)
+}
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/oidc"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+const (
+	// cloudCredentialsTokenTTL is how long the tokens exchanged for cloud
+	// credentials are valid for.
+	cloudCredentialsTokenTTL = time.Hour
+	// azureTokenAudience is the audience Azure AD expects federated tokens
+	// to have.
+	azureTokenAudience = "api://AzureADTokenExchange"
+)
+
+// CloudCredentialsProvider provides projects with short-lived cloud
+// credentials so that long-lived keys don't need to be stored on the server.
+type CloudCredentialsProvider interface {
+	// Env returns the environment variables that give the project's commands
+	// the credentials configured in ctx.CloudCredentials, and a func that
+	// deletes any files they refer to once the commands are done.
+	Env(ctx command.ProjectContext) (map[string]string, func(), error)
+}
+
+// OIDCCredentialsProvider implements CloudCredentialsProvider by issuing a
+// token for each cloud. The clouds' Terraform providers and SDKs exchange the
+// tokens for credentials themselves.
+type OIDCCredentialsProvider struct {
+	Issuer *oidc.Issuer
+	// DataDir is the dir that the tokens are temporarily written to.
+	DataDir string
+}
+
+func (o *OIDCCredentialsProvider) Env(ctx command.ProjectContext) (map[string]string, func(), error) {
+	creds := ctx.CloudCredentials
+	if creds == nil {
+		return nil, func() {}, nil
+	}
+	dir, err := os.MkdirTemp(o.DataDir, "credentials")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "creating dir for credentials")
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(dir); err != nil {
+			ctx.Log.Warn("unable to delete credentials: %s", err)
+		}
+	}
+
+	env := make(map[string]string)
+	if creds.AWS != nil {
+		tokenFile, err := o.writeToken(ctx, dir, "aws-token", creds.AWS.Audience)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		env["AWS_ROLE_ARN"] = creds.AWS.RoleARN
+		env["AWS_WEB_IDENTITY_TOKEN_FILE"] = tokenFile
+		env["AWS_ROLE_SESSION_NAME"] = fmt.Sprintf("atlantis-%d", ctx.Pull.Num)
+	}
+	if creds.GCP != nil {
+		tokenFile, err := o.writeToken(ctx, dir, "gcp-token", "https://iam.googleapis.com/"+creds.GCP.WorkloadIdentityProvider)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		// This is the credential configuration file that gcloud generates
+		// for workload identity federation with a file-sourced token.
+		config := map[string]interface{}{
+			"type":               "external_account",
+			"audience":           "//iam.googleapis.com/" + creds.GCP.WorkloadIdentityProvider,
+			"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+			"token_url":          "https://sts.googleapis.com/v1/token",
+			"credential_source":  map[string]string{"file": tokenFile},
+		}
+		if creds.GCP.ServiceAccount != "" {
+			config["service_account_impersonation_url"] = fmt.Sprintf("https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/%s:generateAccessToken", creds.GCP.ServiceAccount)
+		}
+		configFile := filepath.Join(dir, "gcp-credentials.json")
+		contents, err := json.Marshal(config)
+		if err == nil {
+			err = os.WriteFile(configFile, contents, 0600)
+		}
+		if err != nil {
+			cleanup()
+			return nil, nil, errors.Wrap(err, "writing GCP credential configuration")
+		}
+		env["GOOGLE_APPLICATION_CREDENTIALS"] = configFile
+	}
+	if creds.Azure != nil {
+		tokenFile, err := o.writeToken(ctx, dir, "azure-token", azureTokenAudience)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		env["ARM_USE_OIDC"] = "true"
+		env["ARM_CLIENT_ID"] = creds.Azure.ClientID
+		env["ARM_TENANT_ID"] = creds.Azure.TenantID
+		env["ARM_OIDC_TOKEN_FILE_PATH"] = tokenFile
+		if creds.Azure.SubscriptionID != "" {
+			env["ARM_SUBSCRIPTION_ID"] = creds.Azure.SubscriptionID
+		}
+	}
+	return env, cleanup, nil
+}
+
+// writeToken writes a token for ctx with audience to a file called name in
+// dir and returns the file's path.
+func (o *OIDCCredentialsProvider) writeToken(ctx command.ProjectContext, dir string, name string, audience string) (string, error) {
+	token, err := o.Issuer.Token(CloudCredentialsSubject(ctx), audience, cloudCredentialsTokenTTL, map[string]interface{}{
+		"repository":  ctx.BaseRepo.FullName,
+		"pull_number": ctx.Pull.Num,
+		"project":     ctx.ProjectName,
+		"dir":         ctx.RepoRelDir,
+		"workspace":   ctx.Workspace,
+		"command":     ctx.CommandName.String(),
+		"actor":       ctx.User.Username,
+	})
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	return path, errors.Wrapf(os.WriteFile(path, []byte(token), 0600), "writing %s", name)
+}
+
+// CloudCredentialsSubject returns the subject of the tokens issued for ctx.
+// Cloud providers' trust policies match on it to scope credentials to repos,
+// projects, workspaces and commands, ex. to only let plans use a read-only
+// role. Projects without a name are identified by their dir.
+func CloudCredentialsSubject(ctx command.ProjectContext) string {
+	project := ctx.ProjectName
+	if project == "" {
+		project = ctx.RepoRelDir
+	}
+	return fmt.Sprintf("repo:%s:project:%s:workspace:%s:command:%s", ctx.BaseRepo.FullName, project, ctx.Workspace, ctx.CommandName.String())
+}
@@ -0,0 +1,111 @@
+package events_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/oidc"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestOIDCCredentialsProvider_Env(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Ok(t, err)
+	issuer, err := oidc.NewIssuer("https://atlantis.example.com", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	Ok(t, err)
+	dataDir, cleanup := TempDir(t)
+	defer cleanup()
+	provider := &events.OIDCCredentialsProvider{
+		Issuer:  issuer,
+		DataDir: dataDir,
+	}
+
+	ctx := command.ProjectContext{
+		Log:         logging.NewNoopLogger(t),
+		CommandName: command.Plan,
+		BaseRepo:    models.Repo{FullName: "owner/repo"},
+		Pull:        models.PullRequest{Num: 1},
+		RepoRelDir:  "production",
+		Workspace:   "default",
+		CloudCredentials: &valid.CloudCredentials{
+			AWS: &valid.AWSCredentials{
+				RoleARN:  "arn:aws:iam::123456789012:role/atlantis",
+				Audience: valid.DefaultAWSAudience,
+			},
+			GCP: &valid.GCPCredentials{
+				WorkloadIdentityProvider: "projects/123/locations/global/workloadIdentityPools/pool/providers/atlantis",
+				ServiceAccount:           "terraform@project.iam.gserviceaccount.com",
+			},
+			Azure: &valid.AzureCredentials{
+				ClientID: "client",
+				TenantID: "tenant",
+			},
+		},
+	}
+	env, cleanupCreds, err := provider.Env(ctx)
+	Ok(t, err)
+	Equals(t, "arn:aws:iam::123456789012:role/atlantis", env["AWS_ROLE_ARN"])
+	Equals(t, "atlantis-1", env["AWS_ROLE_SESSION_NAME"])
+	Equals(t, "true", env["ARM_USE_OIDC"])
+	Equals(t, "client", env["ARM_CLIENT_ID"])
+	Equals(t, "tenant", env["ARM_TENANT_ID"])
+	_, ok := env["ARM_SUBSCRIPTION_ID"]
+	Assert(t, !ok, "exp no subscription ID")
+
+	for tokenEnv, audience := range map[string]string{
+		"AWS_WEB_IDENTITY_TOKEN_FILE": "sts.amazonaws.com",
+		"ARM_OIDC_TOKEN_FILE_PATH":    "api://AzureADTokenExchange",
+	} {
+		token, err := os.ReadFile(env[tokenEnv])
+		Ok(t, err)
+		parsed, err := jwt.Parse(string(token), func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil })
+		Ok(t, err)
+		claims := parsed.Claims.(jwt.MapClaims)
+		Equals(t, audience, claims["aud"])
+		Equals(t, "repo:owner/repo:project:production:workspace:default:command:plan", claims["sub"])
+		Equals(t, "owner/repo", claims["repository"])
+	}
+
+	gcpConfig, err := os.ReadFile(env["GOOGLE_APPLICATION_CREDENTIALS"])
+	Ok(t, err)
+	var config map[string]interface{}
+	Ok(t, json.Unmarshal(gcpConfig, &config))
+	Equals(t, "external_account", config["type"])
+	Equals(t, "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/atlantis", config["audience"])
+	Equals(t, "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/terraform@project.iam.gserviceaccount.com:generateAccessToken", config["service_account_impersonation_url"])
+	tokenFile := config["credential_source"].(map[string]interface{})["file"].(string)
+	token, err := os.ReadFile(tokenFile) // nolint: gosec
+	Ok(t, err)
+	parsed, err := jwt.Parse(string(token), func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil })
+	Ok(t, err)
+	Equals(t, "https://iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/atlantis", parsed.Claims.(jwt.MapClaims)["aud"])
+
+	// The tokens are deleted once the commands are done.
+	cleanupCreds()
+	_, err = os.Stat(filepath.Dir(tokenFile))
+	Assert(t, os.IsNotExist(err), "exp credentials to be deleted")
+}
+
+func TestCloudCredentialsSubject(t *testing.T) {
+	ctx := command.ProjectContext{
+		CommandName: command.Apply,
+		BaseRepo:    models.Repo{FullName: "owner/repo"},
+		RepoRelDir:  "envs/prod",
+		Workspace:   "default",
+	}
+	Equals(t, "repo:owner/repo:project:envs/prod:workspace:default:command:apply", events.CloudCredentialsSubject(ctx))
+	ctx.ProjectName = "prod"
+	Equals(t, "repo:owner/repo:project:prod:workspace:default:command:apply", events.CloudCredentialsSubject(ctx))
+}
@@ -0,0 +1,148 @@
+// Package cloudevents publishes Atlantis's lifecycle events as CloudEvents
+// (https://cloudevents.io) so that other systems can automate on them.
+package cloudevents
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// The types of the events that are published.
+const (
+	PlanStartedType   = "io.runatlantis.plan.started"
+	PlanFinishedType  = "io.runatlantis.plan.finished"
+	ApplyFinishedType = "io.runatlantis.apply.finished"
+	LockCreatedType   = "io.runatlantis.lock.created"
+	LockReleasedType  = "io.runatlantis.lock.released"
+	DriftDetectedType = "io.runatlantis.drift.detected"
+)
+
+// Event is a CloudEvent in the JSON event format of version 1.0 of the spec.
+type Event struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            time.Time   `json:"time"`
+	DataContentType string      `json:"datacontenttype"`
+	Data            interface{} `json:"data"`
+}
+
+// ProjectData is the data of the plan, apply and drift events.
+type ProjectData struct {
+	Repo        string `json:"repo"`
+	Pull        int    `json:"pull"`
+	HeadCommit  string `json:"head_commit"`
+	ProjectName string `json:"project_name,omitempty"`
+	Dir         string `json:"dir"`
+	Workspace   string `json:"workspace"`
+	User        string `json:"user"`
+	// Success, Failure, Error and Stats are only set once the command has
+	// finished.
+	Success bool                     `json:"success,omitempty"`
+	Failure string                   `json:"failure,omitempty"`
+	Error   string                   `json:"error,omitempty"`
+	Stats   *models.PlanSuccessStats `json:"stats,omitempty"`
+	// JobURL links to the command's output.
+	JobURL string `json:"job_url,omitempty"`
+}
+
+// LockData is the data of the lock events.
+type LockData struct {
+	Repo      string    `json:"repo"`
+	Pull      int       `json:"pull"`
+	Dir       string    `json:"dir"`
+	Workspace string    `json:"workspace"`
+	User      string    `json:"user"`
+	Time      time.Time `json:"time"`
+}
+
+// NewLockData returns the data of lock's events.
+func NewLockData(lock models.ProjectLock) LockData {
+	return LockData{
+		Repo:      lock.Project.RepoFullName,
+		Pull:      lock.Pull.Num,
+		Dir:       lock.Project.Path,
+		Workspace: lock.Workspace,
+		User:      lock.User.Username,
+		Time:      lock.Time,
+	}
+}
+
+// Sink delivers events.
+type Sink interface {
+	Send(event Event) error
+}
+
+// Emitter emits events.
+type Emitter interface {
+	// Emit publishes an event of eventType about subject. Errors are logged
+	// rather than returned since events are best effort.
+	Emit(eventType string, subject string, data interface{})
+}
+
+// Publisher implements Emitter by sending the events to each of its sinks.
+type Publisher struct {
+	// Source identifies this Atlantis in the events, ex. its URL.
+	Source string
+	Sinks  []Sink
+	Logger logging.SimpleLogging
+}
+
+// NewPublisher returns a publisher of events from source to the sinks at
+// sinkURLs. http and https URLs are sent to with HTTP POST requests, nats URLs
+// are published to the NATS subject in their path.
+func NewPublisher(source string, sinkURLs []string, logger logging.SimpleLogging) (*Publisher, error) {
+	p := &Publisher{Source: source, Logger: logger}
+	for _, raw := range sinkURLs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing CloudEvents sink %q", raw)
+		}
+		switch u.Scheme {
+		case "http", "https":
+			p.Sinks = append(p.Sinks, NewHTTPSink(raw))
+		case "nats":
+			sink, err := NewNATSSink(u)
+			if err != nil {
+				return nil, err
+			}
+			p.Sinks = append(p.Sinks, sink)
+		default:
+			return nil, fmt.Errorf("unsupported CloudEvents sink %q, must be an http, https or nats URL", raw)
+		}
+	}
+	return p, nil
+}
+
+func (p *Publisher) Emit(eventType string, subject string, data interface{}) {
+	event := Event{
+		SpecVersion:     "1.0",
+		ID:              uuid.New().String(),
+		Source:          p.Source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+	for _, sink := range p.Sinks {
+		if err := sink.Send(event); err != nil {
+			p.Logger.Warn("unable to send %s event: %s", eventType, err)
+		}
+	}
+}
+
+// ProjectSubject returns the subject of the events about a project, ex.
+// "owner/repo/dir/workspace".
+func ProjectSubject(repoFullName string, dir string, workspace string) string {
+	return strings.Join([]string{repoFullName, dir, workspace}, "/")
+}
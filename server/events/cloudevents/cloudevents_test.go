@@ -0,0 +1,97 @@
+package cloudevents_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/cloudevents"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestPublisher_HTTP(t *testing.T) {
+	var got cloudevents.Event
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, err := io.ReadAll(r.Body)
+		Ok(t, err)
+		Ok(t, json.Unmarshal(body, &got))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	publisher, err := cloudevents.NewPublisher("https://atlantis.example.com", []string{server.URL}, logging.NewNoopLogger(t))
+	Ok(t, err)
+	publisher.Emit(cloudevents.PlanStartedType, "owner/repo/dir/default", cloudevents.ProjectData{Repo: "owner/repo", Dir: "dir"})
+
+	Equals(t, "application/cloudevents+json", contentType)
+	Equals(t, "1.0", got.SpecVersion)
+	Equals(t, "https://atlantis.example.com", got.Source)
+	Equals(t, "io.runatlantis.plan.started", got.Type)
+	Equals(t, "owner/repo/dir/default", got.Subject)
+	Equals(t, "application/json", got.DataContentType)
+	Equals(t, map[string]interface{}{"repo": "owner/repo", "pull": float64(0), "head_commit": "", "dir": "dir", "workspace": "", "user": ""}, got.Data)
+	Assert(t, got.ID != "", "exp event to have an id")
+}
+
+func TestNewPublisher_UnsupportedSink(t *testing.T) {
+	_, err := cloudevents.NewPublisher("https://atlantis.example.com", []string{"kafka://kafka:9092"}, logging.NewNoopLogger(t))
+	ErrEquals(t, `unsupported CloudEvents sink "kafka://kafka:9092", must be an http, https or nats URL`, err)
+}
+
+func TestNATSSink(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Ok(t, err)
+	defer listener.Close() // nolint: errcheck
+
+	type published struct {
+		connect string
+		subject string
+		payload string
+	}
+	done := make(chan published, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close() // nolint: errcheck
+
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n")) // nolint: errcheck
+		r := bufio.NewReader(conn)
+		var p published
+		connect, _ := r.ReadString('\n')
+		p.connect = strings.TrimSpace(connect)
+		pub, _ := r.ReadString('\n')
+		fields := strings.Fields(pub)
+		p.subject = fields[1]
+		size, _ := strconv.Atoi(fields[2])
+		payload := make([]byte, size+2)
+		io.ReadFull(r, payload) // nolint: errcheck
+		p.payload = string(payload[:size])
+		ping, _ := r.ReadString('\n')
+		if strings.TrimSpace(ping) == "PING" {
+			conn.Write([]byte("PONG\r\n")) // nolint: errcheck
+		}
+		done <- p
+	}()
+
+	publisher, err := cloudevents.NewPublisher("https://atlantis.example.com", []string{"nats://token@" + listener.Addr().String()}, logging.NewNoopLogger(t))
+	Ok(t, err)
+	publisher.Emit(cloudevents.LockCreatedType, "owner/repo/dir/default", cloudevents.LockData{Repo: "owner/repo"})
+
+	p := <-done
+	Equals(t, `CONNECT {"auth_token":"token","name":"atlantis","pedantic":false,"verbose":false}`, p.connect)
+	Equals(t, cloudevents.DefaultNATSSubject, p.subject)
+	var event cloudevents.Event
+	Ok(t, json.Unmarshal([]byte(p.payload), &event))
+	Equals(t, "io.runatlantis.lock.created", event.Type)
+}
@@ -0,0 +1,43 @@
+package cloudevents
+
+import (
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// Locker wraps a locking.Locker to emit an event when a lock is created or
+// released.
+type Locker struct {
+	locking.Locker
+	Emitter Emitter
+}
+
+func (l *Locker) TryLock(p models.Project, workspace string, pull models.PullRequest, user models.User) (locking.TryLockResponse, error) {
+	resp, err := l.Locker.TryLock(p, workspace, pull, user)
+	if err == nil && resp.LockAcquired {
+		l.emit(LockCreatedType, resp.CurrLock)
+	}
+	return resp, err
+}
+
+func (l *Locker) Unlock(key string) (*models.ProjectLock, error) {
+	lock, err := l.Locker.Unlock(key)
+	if err == nil && lock != nil {
+		l.emit(LockReleasedType, *lock)
+	}
+	return lock, err
+}
+
+func (l *Locker) UnlockByPull(repoFullName string, pullNum int) ([]models.ProjectLock, error) {
+	locks, err := l.Locker.UnlockByPull(repoFullName, pullNum)
+	if err == nil {
+		for _, lock := range locks {
+			l.emit(LockReleasedType, lock)
+		}
+	}
+	return locks, err
+}
+
+func (l *Locker) emit(eventType string, lock models.ProjectLock) {
+	l.Emitter.Emit(eventType, ProjectSubject(lock.Project.RepoFullName, lock.Project.Path, lock.Workspace), NewLockData(lock))
+}
@@ -0,0 +1,54 @@
+package cloudevents_test
+
+import (
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	lockmocks "github.com/runatlantis/atlantis/server/core/locking/mocks"
+	"github.com/runatlantis/atlantis/server/events/cloudevents"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakeEmitter struct {
+	// events are the emitted events as "type subject".
+	events []string
+}
+
+func (f *fakeEmitter) Emit(eventType string, subject string, data interface{}) {
+	f.events = append(f.events, eventType+" "+subject)
+}
+
+func TestLocker(t *testing.T) {
+	RegisterMockTestingT(t)
+	wrapped := lockmocks.NewMockLocker()
+	emitter := &fakeEmitter{}
+	locker := &cloudevents.Locker{Locker: wrapped, Emitter: emitter}
+
+	project := models.NewProject("owner/repo", "dir")
+	pull := models.PullRequest{Num: 1}
+	user := models.User{Username: "user"}
+	lock := models.ProjectLock{Project: project, Workspace: "default", Pull: pull, User: user}
+
+	// Only locks that are acquired are created.
+	When(wrapped.TryLock(project, "default", pull, user)).ThenReturn(locking.TryLockResponse{LockAcquired: true, CurrLock: lock}, nil)
+	_, err := locker.TryLock(project, "default", pull, user)
+	Ok(t, err)
+	When(wrapped.TryLock(project, "staging", pull, user)).ThenReturn(locking.TryLockResponse{LockAcquired: false}, nil)
+	_, err = locker.TryLock(project, "staging", pull, user)
+	Ok(t, err)
+
+	When(wrapped.Unlock("owner/repo/dir/default")).ThenReturn(&lock, nil)
+	_, err = locker.Unlock("owner/repo/dir/default")
+	Ok(t, err)
+	When(wrapped.UnlockByPull("owner/repo", 1)).ThenReturn([]models.ProjectLock{lock}, nil)
+	_, err = locker.UnlockByPull("owner/repo", 1)
+	Ok(t, err)
+
+	Equals(t, []string{
+		"io.runatlantis.lock.created owner/repo/dir/default",
+		"io.runatlantis.lock.released owner/repo/dir/default",
+		"io.runatlantis.lock.released owner/repo/dir/default",
+	}, emitter.events)
+}
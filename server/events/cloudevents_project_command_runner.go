@@ -0,0 +1,66 @@
+package events
+
+import (
+	"github.com/runatlantis/atlantis/server/events/cloudevents"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/jobs"
+)
+
+// CloudEventsProjectCommandRunner emits an event when a project's plan starts
+// and finishes, when its apply finishes and when its plan finds drift.
+type CloudEventsProjectCommandRunner struct {
+	ProjectCommandRunner
+	Emitter cloudevents.Emitter
+	// JobURLGenerator generates the links to the commands' output in the
+	// events. If nil, the events don't link to it.
+	JobURLGenerator jobs.ProjectJobURLGenerator
+}
+
+func (c *CloudEventsProjectCommandRunner) Plan(ctx command.ProjectContext) command.ProjectResult {
+	c.emit(cloudevents.PlanStartedType, ctx, nil)
+	result := c.ProjectCommandRunner.Plan(ctx)
+	c.emit(cloudevents.PlanFinishedType, ctx, &result)
+	if result.PlanSuccess != nil && result.PlanSuccess.Drifted() {
+		c.emit(cloudevents.DriftDetectedType, ctx, &result)
+	}
+	return result
+}
+
+func (c *CloudEventsProjectCommandRunner) Apply(ctx command.ProjectContext) command.ProjectResult {
+	result := c.ProjectCommandRunner.Apply(ctx)
+	c.emit(cloudevents.ApplyFinishedType, ctx, &result)
+	return result
+}
+
+// emit emits an event of eventType about ctx's project. result is nil if the
+// command hasn't finished yet.
+func (c *CloudEventsProjectCommandRunner) emit(eventType string, ctx command.ProjectContext, result *command.ProjectResult) {
+	data := cloudevents.ProjectData{
+		Repo:        ctx.Pull.BaseRepo.FullName,
+		Pull:        ctx.Pull.Num,
+		HeadCommit:  ctx.Pull.HeadCommit,
+		ProjectName: ctx.ProjectName,
+		Dir:         ctx.RepoRelDir,
+		Workspace:   ctx.Workspace,
+		User:        ctx.User.Username,
+	}
+	if c.JobURLGenerator != nil && ctx.JobID != "" {
+		url, err := c.JobURLGenerator.GenerateProjectJobURL(ctx)
+		if err != nil {
+			ctx.Log.Warn("unable to generate job url for event: %s", err)
+		}
+		data.JobURL = url
+	}
+	if result != nil {
+		data.Success = result.IsSuccessful()
+		data.Failure = result.Failure
+		if result.Error != nil {
+			data.Error = result.Error.Error()
+		}
+		if result.PlanSuccess != nil {
+			stats := result.PlanSuccess.Stats()
+			data.Stats = &stats
+		}
+	}
+	c.Emitter.Emit(eventType, cloudevents.ProjectSubject(data.Repo, data.Dir, data.Workspace), data)
+}
@@ -0,0 +1,55 @@
+package events_test
+
+import (
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/cloudevents"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakeEmitter struct {
+	types []string
+	data  []cloudevents.ProjectData
+}
+
+func (f *fakeEmitter) Emit(eventType string, subject string, data interface{}) {
+	f.types = append(f.types, eventType)
+	f.data = append(f.data, data.(cloudevents.ProjectData))
+}
+
+func TestCloudEventsProjectCommandRunner(t *testing.T) {
+	RegisterMockTestingT(t)
+	wrapped := mocks.NewMockProjectCommandRunner()
+	emitter := &fakeEmitter{}
+	runner := &events.CloudEventsProjectCommandRunner{ProjectCommandRunner: wrapped, Emitter: emitter}
+	ctx := command.ProjectContext{
+		Pull:       models.PullRequest{Num: 1, BaseRepo: models.Repo{FullName: "owner/repo"}},
+		RepoRelDir: "dir",
+		Workspace:  "default",
+		User:       models.User{Username: "user"},
+	}
+
+	When(wrapped.Plan(ctx)).ThenReturn(command.ProjectResult{PlanSuccess: &models.PlanSuccess{
+		TerraformOutput: "Note: Objects have changed outside of Terraform\n\nPlan: 1 to add, 0 to change, 0 to destroy.",
+	}})
+	runner.Plan(ctx)
+	When(wrapped.Apply(ctx)).ThenReturn(command.ProjectResult{Failure: "failed"})
+	runner.Apply(ctx)
+
+	Equals(t, []string{
+		cloudevents.PlanStartedType,
+		cloudevents.PlanFinishedType,
+		cloudevents.DriftDetectedType,
+		cloudevents.ApplyFinishedType,
+	}, emitter.types)
+	Equals(t, cloudevents.ProjectData{Repo: "owner/repo", Pull: 1, Dir: "dir", Workspace: "default", User: "user"}, emitter.data[0])
+	Equals(t, true, emitter.data[1].Success)
+	Equals(t, 1, emitter.data[1].Stats.Add)
+	Equals(t, false, emitter.data[3].Success)
+	Equals(t, "failed", emitter.data[3].Failure)
+}
@@ -1,6 +1,8 @@
 package command
 
 import (
+	"text/template"
+
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/uber-go/tally"
@@ -37,4 +39,9 @@ type Context struct {
 	PullStatus *models.PullStatus
 
 	Trigger Trigger
+
+	// MarkdownTemplateOverrides are the templates that override the
+	// templates of the comments, loaded from the markdown_template_dir of the
+	// repo's atlantis.yaml. Nil if it isn't set.
+	MarkdownTemplateOverrides map[string]*template.Template
 }
@@ -20,13 +20,34 @@ const (
 	Autoplan
 	// Version is a command to run terraform version.
 	Version
+	// ForceUnlockState is a command to run terraform force-unlock to remove
+	// a stale state lock.
+	ForceUnlockState
+	// InitConfig is a command to open a pull request that adds a generated
+	// atlantis.yaml.
+	InitConfig
+	// ApproveResources is a command to approve the changes to protected
+	// resources in the pull request's plans.
+	ApproveResources
+	// Fmt is a command to check the formatting of the Terraform files in a
+	// pull request with terraform fmt, and optionally push a fix.
+	Fmt
+	// ProvidersLock is a command to update the dependency lock files of the
+	// Terraform root modules in a pull request with terraform providers lock.
+	ProvidersLock
+	// Cancel is a command to cancel the plans and applies that are running
+	// in a pull request.
+	Cancel
+	// Revert is a command to open a pull request that reverts a merged pull
+	// request and plan it.
+	Revert
 	// Adding more? Don't forget to update String() below
 )
 
 // TitleString returns the string representation in title form.
 // ie. policy_check becomes Policy Check
 func (c Name) TitleString() string {
-	return strings.Title(strings.ReplaceAll(strings.ToLower(c.String()), "_", " "))
+	return strings.Title(strings.NewReplacer("_", " ", "-", " ").Replace(strings.ToLower(c.String())))
 }
 
 // String returns the string representation of c.
@@ -44,6 +65,20 @@ func (c Name) String() string {
 		return "approve_policies"
 	case Version:
 		return "version"
+	case ForceUnlockState:
+		return "force_unlock_state"
+	case InitConfig:
+		return "init_config"
+	case ApproveResources:
+		return "approve_resources"
+	case Fmt:
+		return "fmt"
+	case ProvidersLock:
+		return "providers"
+	case Cancel:
+		return "cancel"
+	case Revert:
+		return "revert"
 	}
 	return ""
 }
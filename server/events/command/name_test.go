@@ -30,3 +30,45 @@ func TestUnlockCommand_String(t *testing.T) {
 
 	Equals(t, "unlock", uc.String())
 }
+
+func TestForceUnlockStateCommand_String(t *testing.T) {
+	uc := command.ForceUnlockState
+
+	Equals(t, "force_unlock_state", uc.String())
+	Equals(t, "Force Unlock State", uc.TitleString())
+}
+
+func TestApproveResourcesCommand_String(t *testing.T) {
+	uc := command.ApproveResources
+
+	Equals(t, "approve_resources", uc.String())
+	Equals(t, "Approve Resources", uc.TitleString())
+}
+
+func TestInitConfigCommand_String(t *testing.T) {
+	uc := command.InitConfig
+
+	Equals(t, "init_config", uc.String())
+	Equals(t, "Init Config", uc.TitleString())
+}
+
+func TestFmtCommand_String(t *testing.T) {
+	uc := command.Fmt
+
+	Equals(t, "fmt", uc.String())
+	Equals(t, "Fmt", uc.TitleString())
+}
+
+func TestProvidersLockCommand_String(t *testing.T) {
+	uc := command.ProvidersLock
+
+	Equals(t, "providers", uc.String())
+	Equals(t, "Providers", uc.TitleString())
+}
+
+func TestCancelCommand_String(t *testing.T) {
+	uc := command.Cancel
+
+	Equals(t, "cancel", uc.String())
+	Equals(t, "Cancel", uc.TitleString())
+}
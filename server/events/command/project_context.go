@@ -2,7 +2,9 @@ package command
 
 import (
 	"fmt"
+	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -15,6 +17,14 @@ const (
 	planfileSlashReplace = "::"
 )
 
+// ProcessStarter starts the processes of project commands.
+type ProcessStarter interface {
+	// Start starts cmd for the job with jobID. done must be called once cmd
+	// has exited. It returns an error without starting cmd if the job was
+	// cancelled.
+	Start(jobID string, cmd *exec.Cmd) (done func(), err error)
+}
+
 // ProjectContext defines the context for a plan or apply stage that will
 // be executed for a project.
 type ProjectContext struct {
@@ -77,8 +87,31 @@ type ProjectContext struct {
 	TerraformVersion *version.Version
 	// Configuration metadata for a given project.
 	User models.User
+	// WorkflowName is the name of the workflow the project uses.
+	WorkflowName string
 	// Verbose is true when the user would like verbose output.
 	Verbose bool
+	// ForceApply is true when the user asked to apply the plan even if it's
+	// older than the maximum plan age.
+	ForceApply bool
+	// DestroyApproved is true when the user asked to apply the plan even if
+	// it destroys resources protected by the no_destroys apply requirement.
+	// It's only honored if the user is in one of the approver teams.
+	DestroyApproved bool
+	// ResourceChangesApproved is true when the user asked to apply the plan
+	// even if it changes more than MaxResourceChanges resources.
+	ResourceChangesApproved bool
+	// TerraformLogLevel is the level TF_LOG is set to when running
+	// Terraform, ex. TRACE. If set, Terraform's log and the Atlantis log are
+	// sent to the job output instead of the comment.
+	TerraformLogLevel string
+	// PinnedCommit is the commit that the user asked to apply the plan from
+	// with --sha. If empty, the plan must be from the pull request's head
+	// commit.
+	PinnedCommit string
+	// StateLockID is the ID of the Terraform state lock to remove when running
+	// force_unlock_state.
+	StateLockID string
 	// Workspace is the Terraform workspace this project is in. It will always
 	// be set.
 	Workspace string
@@ -91,6 +124,77 @@ type ProjectContext struct {
 	JobID string
 	// The index of order group. Before planning/applying it will use to sort projects. Default is 0.
 	ExecutionOrderGroup int
+	// ApplyWindows are the windows of time in which this project can be
+	// applied. If empty, it can always be applied.
+	ApplyWindows []valid.ApplyWindow
+	// ApplyWindowTeams are the VCS teams whose members can apply outside of
+	// ApplyWindows.
+	ApplyWindowTeams []string
+	// MaxConcurrentApplies is the maximum number of applies that can run at
+	// once for this project's repo. 0 means there is no limit.
+	MaxConcurrentApplies int
+	// MaxResourceChanges is the maximum number of resources that an apply
+	// can change unless a member of MaxResourceChangesTeams approved it with
+	// ResourceChangesApproved. 0 means there is no limit.
+	MaxResourceChanges int
+	// MaxResourceChangesTeams are the VCS teams whose members can approve
+	// applies over MaxResourceChanges.
+	MaxResourceChangesTeams []string
+	// ApplyConcurrencyGroup is the apply concurrency group this project is
+	// tagged with, if any.
+	ApplyConcurrencyGroup string
+	// ApplyConcurrencyLimit is the maximum number of applies that can run at
+	// once in ApplyConcurrencyGroup.
+	ApplyConcurrencyLimit int
+	// PromoteTo is the name of the project that this project's changes are
+	// promoted to after a successful apply. Empty if changes aren't promoted.
+	PromoteTo string
+	// PromoteToDir is the repo relative dir of the PromoteTo project.
+	PromoteToDir string
+	// EphemeralWorkspace is true if Workspace belongs to the pull request and
+	// is deleted when it's closed.
+	EphemeralWorkspace bool
+	// Preview configures the preview environment in Workspace. Nil if it
+	// isn't one.
+	Preview *valid.Preview
+	// DependsOn are the projects in other repos that this project depends on.
+	DependsOn []valid.ProjectDependency
+	// ProviderPolicy restricts the providers this project can use.
+	ProviderPolicy valid.ProviderPolicy
+	// AllowedModuleSources are patterns of the module sources this project
+	// can use. If nil, any source can be used.
+	AllowedModuleSources []string
+	// ProviderTargets restrict what the project's provider configs can
+	// target. They're checked before the project is planned.
+	ProviderTargets []valid.ProviderTarget
+	// CloudCredentials are the cloud credentials this project's commands get.
+	// Nil if it doesn't get any.
+	CloudCredentials *valid.CloudCredentials
+	// DestroyProtection configures the no_destroys apply requirement. Nil if
+	// it isn't configured, in which case every resource is protected.
+	DestroyProtection *valid.DestroyProtection
+	// ProtectedResources configures which resource changes must be approved
+	// with approve_resources before applying. Nil if not set.
+	ProtectedResources *valid.ProtectedResources
+	// TerraformExtraArgs maps the name of a Terraform step to the extra args
+	// that the server config passes to it before the step's own.
+	TerraformExtraArgs map[string][]string
+	// TerraformEnv are the env vars that the server config sets for every
+	// step. Env vars that steps set themselves override them.
+	TerraformEnv map[string]string
+	// PlanStats are the stats of the project's plan once it has succeeded.
+	// They're shown in the project's commit status. Nil otherwise.
+	PlanStats *models.PlanSuccessStats
+	// RunningFor is how long the project's command has been running when it
+	// runs long enough for heartbeats to update its pending commit status.
+	// It's 0 otherwise.
+	RunningFor time.Duration
+	// LastOutputLine is the last line of the output of the project's command
+	// when RunningFor is set.
+	LastOutputLine string
+	// Processes starts the processes that the project's command runs so
+	// that they can be cancelled. If nil, they're started directly.
+	Processes ProcessStarter
 }
 
 // SetScope sets the scope of the stats object field. Note: we deliberately set this on the value
@@ -108,6 +212,26 @@ func (p ProjectContext) GetShowResultFileName() string {
 	return fmt.Sprintf("%s-%s.json", projName, p.Workspace)
 }
 
+// GetPlanOutputFileName returns the filename (not the path) to store the
+// output of terraform plan
+func (p ProjectContext) GetPlanOutputFileName() string {
+	if p.ProjectName == "" {
+		return fmt.Sprintf("%s.plan.txt", p.Workspace)
+	}
+	projName := strings.Replace(p.ProjectName, "/", planfileSlashReplace, -1)
+	return fmt.Sprintf("%s-%s.plan.txt", projName, p.Workspace)
+}
+
+// GetOutputsFileName returns the filename (not the path) to store the output
+// of terraform output -json after an apply
+func (p ProjectContext) GetOutputsFileName() string {
+	if p.ProjectName == "" {
+		return fmt.Sprintf("%s.outputs.json", p.Workspace)
+	}
+	projName := strings.Replace(p.ProjectName, "/", planfileSlashReplace, -1)
+	return fmt.Sprintf("%s-%s.outputs.json", projName, p.Workspace)
+}
+
 // Gets a unique identifier for the current pull request as a single string
 func (p ProjectContext) PullInfo() string {
 	normalizedOwner := strings.ReplaceAll(p.BaseRepo.Owner, "/", "-")
@@ -6,16 +6,29 @@ import (
 
 // ProjectResult is the result of executing a plan/policy_check/apply for a specific project.
 type ProjectResult struct {
-	Command            Name
-	RepoRelDir         string
-	Workspace          string
-	Error              error
-	Failure            string
-	PlanSuccess        *models.PlanSuccess
-	PolicyCheckSuccess *models.PolicyCheckSuccess
-	ApplySuccess       string
-	VersionSuccess     string
-	ProjectName        string
+	Command                 Name
+	RepoRelDir              string
+	Workspace               string
+	Error                   error
+	Failure                 string
+	PlanSuccess             *models.PlanSuccess
+	PolicyCheckSuccess      *models.PolicyCheckSuccess
+	ApplySuccess            string
+	VersionSuccess          string
+	ForceUnlockStateSuccess string
+	ProjectName             string
+	// JobID is the id of the job that streamed this project's output. It's
+	// empty if the command's output wasn't streamed.
+	JobID string
+	// Outputs are the outputs of the project's root module after a
+	// successful apply. Nil if they weren't captured.
+	Outputs map[string]models.TerraformOutput
+	// EphemeralWorkspace is true if Workspace belongs to the pull request and
+	// is deleted when it's closed.
+	EphemeralWorkspace bool
+	// DestroyOnClose is true if the preview environment in Workspace is
+	// destroyed before it's deleted.
+	DestroyOnClose bool
 }
 
 // CommitStatus returns the vcs commit status of this project result.
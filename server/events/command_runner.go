@@ -16,6 +16,8 @@ package events
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/go-github/v31/github"
 	"github.com/mcdafydd/go-azuredevops/azuredevops"
@@ -24,6 +26,8 @@ import (
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/i18n"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/metrics"
 	"github.com/runatlantis/atlantis/server/recovery"
@@ -31,9 +35,9 @@ import (
 	gitlab "github.com/xanzy/go-gitlab"
 )
 
-const (
-	ShutdownComment = "Atlantis server is shutting down, please try again later."
-)
+// ShutdownComment is the comment we add to the pull request when a command
+// is run while Atlantis is shutting down.
+var ShutdownComment = (*i18n.Catalog)(nil).T("shutting_down")
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_command_runner.go CommandRunner
 
@@ -99,7 +103,7 @@ type DefaultCommandRunner struct {
 	DisableAutoplan          bool
 	EventParser              EventParsing
 	Logger                   logging.SimpleLogging
-	GlobalCfg                valid.GlobalCfg
+	GlobalCfg                *valid.GlobalCfgStore
 	StatsScope               tally.Scope
 	// AllowForkPRs controls whether we operate on pull requests from forks.
 	AllowForkPRs bool
@@ -123,12 +127,29 @@ type DefaultCommandRunner struct {
 	PullStatusFetcher              PullStatusFetcher
 	TeamAllowlistChecker           *TeamAllowlistChecker
 	VarFileAllowlistChecker        *VarFileAllowlistChecker
+	// CommentReactions controls whether we react to the comment that
+	// triggered a command when it's picked up and when it starts running.
+	CommentReactions bool
+	// ForcePushInvalidator discards the plans of pull requests whose branch
+	// was force-pushed when they're updated. Nil to keep them.
+	ForcePushInvalidator *ForcePushInvalidator
+	// AutoplanFmtChecker checks the formatting of the Terraform files before
+	// autoplanning. Nil to not check it.
+	AutoplanFmtChecker *FmtCommandRunner
+	// SupersededPlanCanceller cancels the running plans of a pull request's
+	// previous commits before it's autoplanned. Nil to let them complete.
+	SupersededPlanCanceller *jobs.JobCanceller
+	// DebugAdminTeams are the VCS teams whose members can set a Terraform log
+	// level with --verbose=<level>.
+	DebugAdminTeams []string
+	// Catalog translates the error comments. If nil, they're in English.
+	Catalog *i18n.Catalog
 }
 
 // RunAutoplanCommand runs plan and policy_checks when a pull request is opened or updated.
 func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User) {
 	if opStarted := c.Drainer.StartOp(); !opStarted {
-		if commentErr := c.VCSClient.CreateComment(baseRepo, pull.Num, ShutdownComment, command.Plan.String()); commentErr != nil {
+		if commentErr := c.VCSClient.CreateComment(baseRepo, pull.Num, c.Catalog.T("shutting_down"), command.Plan.String()); commentErr != nil {
 			c.Logger.Log(logging.Error, "unable to comment that Atlantis is shutting down: %s", commentErr)
 		}
 		return
@@ -143,7 +164,7 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 		log.Err("Unable to fetch pull status, this is likely a bug.", err)
 	}
 
-	scope := c.StatsScope.SubScope("autoplan")
+	scope := c.repoScope(baseRepo).SubScope("autoplan")
 	timer := scope.Timer(metrics.ExecutionTimeMetric).Start()
 	defer timer.Stop()
 
@@ -156,12 +177,32 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 		PullStatus: status,
 		Trigger:    command.AutoTrigger,
 	}
-	if !c.validateCtxAndComment(ctx) {
+	if !c.validateCtxAndComment(ctx, nil) {
 		return
 	}
+	// Check for force-pushes even if autoplan is disabled because the
+	// previous plans can still be applied until they're discarded.
+	if c.ForcePushInvalidator != nil {
+		invalidated, err := c.ForcePushInvalidator.Invalidate(log, pull, status)
+		if err != nil {
+			log.Warn("unable to check if pull request was force-pushed: %s", err)
+		}
+		if invalidated {
+			if ctx.PullStatus, err = c.PullStatusFetcher.GetPullStatus(pull); err != nil {
+				log.Err("Unable to fetch pull status, this is likely a bug.", err)
+			}
+		}
+	}
 	if c.DisableAutoplan {
 		return
 	}
+	// If a commit that formats the files was pushed, it's autoplanned when
+	// its webhook arrives.
+	if c.AutoplanFmtChecker != nil && c.AutoplanFmtChecker.Autoplan(ctx) {
+		return
+	}
+
+	c.cancelSupersededPlans(ctx)
 
 	err = c.PreWorkflowHooksCommandRunner.RunPreHooks(ctx)
 
@@ -180,10 +221,37 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 	}
 }
 
+// cancelSupersededPlans cancels the running plans of the pull request's
+// previous commits and waits for them to exit so that they don't race with
+// the autoplan on its working dir.
+func (c *DefaultCommandRunner) cancelSupersededPlans(ctx *command.Context) {
+	if c.SupersededPlanCanceller == nil {
+		return
+	}
+	commit := ctx.Pull.HeadCommit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	cancelled := c.SupersededPlanCanceller.Supersede(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, ctx.Pull.HeadCommit, fmt.Sprintf("the push of %s", commit))
+	if len(cancelled) == 0 {
+		return
+	}
+	var ids []string
+	for _, job := range cancelled {
+		ids = append(ids, job.ID)
+	}
+	ctx.Log.Info("cancelled %d superseded plans", len(cancelled))
+	// The processes are killed once the grace period is over, so they should
+	// have exited shortly after it.
+	if !c.SupersededPlanCanceller.Wait(ids, c.SupersededPlanCanceller.GracePeriod()+10*time.Second) {
+		ctx.Log.Warn("superseded plans are still running, proceeding with %s command", command.Plan)
+	}
+}
+
 // commentUserDoesNotHavePermissions comments on the pull request that the user
 // is not allowed to execute the command.
 func (c *DefaultCommandRunner) commentUserDoesNotHavePermissions(baseRepo models.Repo, pullNum int, user models.User, cmd *CommentCommand) {
-	errMsg := fmt.Sprintf("```\nError: User @%s does not have permissions to execute '%s' command.\n```", user.Username, cmd.Name.String())
+	errMsg := fmt.Sprintf("```\n%s\n```", c.Catalog.T("no_permissions", user.Username, cmd.Name.String()))
 	if err := c.VCSClient.CreateComment(baseRepo, pullNum, errMsg, ""); err != nil {
 		c.Logger.Err("unable to comment on pull request: %s", err)
 	}
@@ -215,6 +283,37 @@ func (c *DefaultCommandRunner) checkVarFilesInPlanCommandAllowlisted(cmd *Commen
 	return c.VarFileAllowlistChecker.Check(cmd.Flags)
 }
 
+// checkTerraformLogLevelAllowed returns an error if cmd sets a Terraform log
+// level and user isn't a member of one of the debug admin teams.
+func (c *DefaultCommandRunner) checkTerraformLogLevelAllowed(repo models.Repo, user models.User, cmd *CommentCommand) error {
+	if cmd == nil || cmd.TerraformLogLevel == "" {
+		return nil
+	}
+	ok, err := userInTeams(c.VCSClient, repo, user, c.DebugAdminTeams)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+	return fmt.Errorf("user @%s is not allowed to set a log level with --verbose. Ask an Atlantis admin to run the command or use --verbose without a level.", user.Username)
+}
+
+// checkCommandAllowed returns an error if the allowed_commands of repo don't
+// allow cmd.
+func (c *DefaultCommandRunner) checkCommandAllowed(repo models.Repo, cmd *CommentCommand) error {
+	allowed := c.GlobalCfg.Get().AllowedCommands(repo.ID())
+	if cmd == nil || allowed == nil {
+		return nil
+	}
+	for _, a := range allowed {
+		if a == cmd.Name.String() {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not allowed on this repo. The allowed commands are: %s.", cmd.Name.String(), strings.Join(allowed, ", "))
+}
+
 // RunCommentCommand executes the command.
 // We take in a pointer for maybeHeadRepo because for some events there isn't
 // enough data to construct the Repo model and callers might want to wait until
@@ -222,7 +321,7 @@ func (c *DefaultCommandRunner) checkVarFilesInPlanCommandAllowlisted(cmd *Commen
 // wasteful) call to get the necessary data.
 func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, cmd *CommentCommand) {
 	if opStarted := c.Drainer.StartOp(); !opStarted {
-		if commentErr := c.VCSClient.CreateComment(baseRepo, pullNum, ShutdownComment, ""); commentErr != nil {
+		if commentErr := c.VCSClient.CreateComment(baseRepo, pullNum, c.Catalog.T("shutting_down"), ""); commentErr != nil {
 			c.Logger.Log(logging.Error, "unable to comment that Atlantis is shutting down: %s", commentErr)
 		}
 		return
@@ -232,7 +331,9 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 	log := c.buildLogger(baseRepo.FullName, pullNum)
 	defer c.logPanics(baseRepo, pullNum, log)
 
-	scope := c.StatsScope.SubScope("comment")
+	c.reactToComment(baseRepo, pullNum, cmd, models.SeenCommentReaction, log)
+
+	scope := c.repoScope(baseRepo).SubScope("comment")
 
 	if cmd != nil {
 		scope = scope.SubScope(cmd.Name.String())
@@ -260,6 +361,22 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		return
 	}
 
+	if err := c.checkCommandAllowed(baseRepo, cmd); err != nil {
+		errMsg := fmt.Sprintf("```\n%s\n```", c.Catalog.T("command_not_allowed", err.Error()))
+		if commentErr := c.VCSClient.CreateComment(baseRepo, pullNum, errMsg, ""); commentErr != nil {
+			c.Logger.Err("unable to comment on pull request: %s", commentErr)
+		}
+		return
+	}
+
+	if err := c.checkTerraformLogLevelAllowed(baseRepo, user, cmd); err != nil {
+		errMsg := fmt.Sprintf("```\n%s\n```", c.Catalog.T("command_not_allowed", err.Error()))
+		if commentErr := c.VCSClient.CreateComment(baseRepo, pullNum, errMsg, ""); commentErr != nil {
+			c.Logger.Err("unable to comment on pull request: %s", commentErr)
+		}
+		return
+	}
+
 	headRepo, pull, err := c.ensureValidRepoMetadata(baseRepo, maybeHeadRepo, maybePull, user, pullNum, log)
 	if err != nil {
 		return
@@ -281,7 +398,7 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		Trigger:    command.CommentTrigger,
 	}
 
-	if !c.validateCtxAndComment(ctx) {
+	if !c.validateCtxAndComment(ctx, cmd) {
 		return
 	}
 
@@ -293,6 +410,7 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 
 	cmdRunner := buildCommentCommandRunner(c, cmd.CommandName())
 
+	c.reactToComment(baseRepo, pullNum, cmd, models.StartedCommentReaction, log)
 	cmdRunner.Run(ctx, cmd)
 
 	err = c.PostWorkflowHooksCommandRunner.RunPostHooks(ctx)
@@ -302,6 +420,17 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 	}
 }
 
+// reactToComment reacts to the comment that triggered cmd if comment
+// reactions are enabled. Errors are logged since reactions are best effort.
+func (c *DefaultCommandRunner) reactToComment(baseRepo models.Repo, pullNum int, cmd *CommentCommand, reaction models.CommentReaction, log logging.SimpleLogging) {
+	if !c.CommentReactions || cmd == nil || cmd.CommentID == 0 {
+		return
+	}
+	if err := c.VCSClient.ReactToComment(baseRepo, pullNum, cmd.CommentID, reaction); err != nil {
+		log.Warn("unable to add %s reaction to comment: %s", reaction, err)
+	}
+}
+
 func (c *DefaultCommandRunner) getGithubData(baseRepo models.Repo, pullNum int) (models.PullRequest, models.Repo, error) {
 	if c.GithubPullGetter == nil {
 		return models.PullRequest{}, models.Repo{}, errors.New("Atlantis not configured to support GitHub")
@@ -369,7 +498,7 @@ func (c *DefaultCommandRunner) ensureValidRepoMetadata(
 		pull, headRepo, err = c.getGithubData(baseRepo, pullNum)
 	case models.Gitlab:
 		pull, err = c.getGitlabData(baseRepo, pullNum)
-	case models.BitbucketCloud, models.BitbucketServer:
+	case models.BitbucketCloud, models.BitbucketServer, models.Gerrit:
 		if maybePull == nil {
 			err = errors.New("pull request should not be nil–this is a bug")
 			break
@@ -391,27 +520,39 @@ func (c *DefaultCommandRunner) ensureValidRepoMetadata(
 	return
 }
 
-func (c *DefaultCommandRunner) validateCtxAndComment(ctx *command.Context) bool {
+// validateCtxAndComment returns false and comments why if cmd can't be run
+// on ctx.Pull. cmd is nil for autoplans.
+func (c *DefaultCommandRunner) validateCtxAndComment(ctx *command.Context, cmd *CommentCommand) bool {
 	if !c.AllowForkPRs && ctx.HeadRepo.Owner != ctx.Pull.BaseRepo.Owner {
 		if c.SilenceForkPRErrors {
 			return false
 		}
 		ctx.Log.Info("command was run on a fork pull request which is disallowed")
-		if err := c.VCSClient.CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, fmt.Sprintf("Atlantis commands can't be run on fork pull requests. To enable, set --%s  or, to disable this message, set --%s", c.AllowForkPRsFlag, c.SilenceForkPRErrorsFlag), ""); err != nil {
+		if err := c.VCSClient.CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, c.Catalog.T("fork_pull_request", c.AllowForkPRsFlag, c.SilenceForkPRErrorsFlag), ""); err != nil {
 			ctx.Log.Err("unable to comment: %s", err)
 		}
 		return false
 	}
 
-	if ctx.Pull.State != models.OpenPullState {
+	// Only merged pull requests can be reverted, every other command is run
+	// on open pull requests.
+	reverting := cmd != nil && cmd.Name == command.Revert
+	if reverting && !ctx.Pull.Merged {
+		ctx.Log.Info("revert was run on a pull request that isn't merged")
+		if err := c.VCSClient.CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, c.Catalog.T("revert_not_merged"), ""); err != nil {
+			ctx.Log.Err("unable to comment: %s", err)
+		}
+		return false
+	}
+	if !reverting && ctx.Pull.State != models.OpenPullState {
 		ctx.Log.Info("command was run on closed pull request")
-		if err := c.VCSClient.CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, "Atlantis commands can't be run on closed pull requests", ""); err != nil {
+		if err := c.VCSClient.CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, c.Catalog.T("pull_request_closed"), ""); err != nil {
 			ctx.Log.Err("unable to comment: %s", err)
 		}
 		return false
 	}
 
-	repo := c.GlobalCfg.MatchingRepo(ctx.Pull.BaseRepo.ID())
+	repo := c.GlobalCfg.Get().MatchingRepo(ctx.Pull.BaseRepo.ID())
 	if !repo.BranchMatches(ctx.Pull.BaseBranch) {
 		ctx.Log.Info("command was run on a pull request which doesn't match base branches")
 		// just ignore it to allow us to use any git workflows without malicious intentions.
@@ -420,6 +561,15 @@ func (c *DefaultCommandRunner) validateCtxAndComment(ctx *command.Context) bool
 	return true
 }
 
+// repoScope returns the scope of the metrics of repo's commands, which are
+// tagged with its tenant's metrics tags if it has a tenant.
+func (c *DefaultCommandRunner) repoScope(repo models.Repo) tally.Scope {
+	if tenant := c.GlobalCfg.Get().Tenant(repo.ID()); tenant != nil {
+		return c.StatsScope.Tagged(tenant.Tags())
+	}
+	return c.StatsScope
+}
+
 // logPanics logs and creates a comment on the pull request for panics.
 func (c *DefaultCommandRunner) logPanics(baseRepo models.Repo, pullNum int, logger logging.SimpleLogging) {
 	if err := recover(); err != nil {
@@ -428,7 +578,7 @@ func (c *DefaultCommandRunner) logPanics(baseRepo models.Repo, pullNum int, logg
 		if commentErr := c.VCSClient.CreateComment(
 			baseRepo,
 			pullNum,
-			fmt.Sprintf("**Error: goroutine panic. This is a bug.**\n```\n%s\n%s```", err, stack),
+			fmt.Sprintf("**%s**\n```\n%s\n%s```", c.Catalog.T("panic"), err, stack),
 			"",
 		); commentErr != nil {
 			logger.Err("unable to comment: %s", commentErr)
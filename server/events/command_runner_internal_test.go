@@ -166,6 +166,9 @@ func (m *MockCSU) UpdateCombinedCount(repo models.Repo, pull models.PullRequest,
 	m.CalledNumTotal = numTotal
 	return nil
 }
+func (m *MockCSU) UpdateCombinedNoProjects(repo models.Repo, pull models.PullRequest, cmdNames []command.Name) error {
+	return nil
+}
 func (m *MockCSU) UpdateCombined(repo models.Repo, pull models.PullRequest, status models.CommitStatus, command command.Name) error {
 	return nil
 }
@@ -20,6 +20,7 @@ import (
 	"net/url"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -27,6 +28,7 @@ import (
 	"github.com/runatlantis/atlantis/server/core/config"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/i18n"
 	"github.com/spf13/pflag"
 )
 
@@ -39,6 +41,18 @@ const (
 	projectFlagShort           = "p"
 	autoMergeDisabledFlagLong  = "auto-merge-disabled"
 	autoMergeDisabledFlagShort = ""
+	forceFlagLong              = "force"
+	forceFlagShort             = ""
+	destroyApprovedFlagLong    = "destroy-approved"
+	destroyApprovedFlagShort   = ""
+	changesApprovedFlagLong    = "resource-changes-approved"
+	changesApprovedFlagShort   = ""
+	shaFlagLong                = "sha"
+	shaFlagShort               = ""
+	dryRunFlagLong             = "dry-run"
+	dryRunFlagShort            = ""
+	fixFlagLong                = "fix"
+	fixFlagShort               = ""
 	verboseFlagLong            = "verbose"
 	verboseFlagShort           = ""
 	atlantisExecutable         = "atlantis"
@@ -51,6 +65,13 @@ const (
 // and pasting GitHub comments.
 var multiLineRegex = regexp.MustCompile(`.*\r?\n[^\r\n]+`)
 
+// stateLockIDRegex matches the IDs of Terraform state locks. We don't allow
+// other characters since the ID is passed to terraform force-unlock.
+var stateLockIDRegex = regexp.MustCompile(`^[a-zA-Z0-9._:/-]+$`)
+
+// shaRegex matches full and abbreviated commit SHAs.
+var shaRegex = regexp.MustCompile(`^[0-9a-fA-F]{7,64}$`)
+
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_comment_parsing.go CommentParsing
 
 // CommentParsing handles parsing pull request comments.
@@ -58,6 +79,9 @@ type CommentParsing interface {
 	// Parse attempts to parse a pull request comment to see if it's an Atlantis
 	// command.
 	Parse(comment string, vcsHost models.VCSHostType) CommentParseResult
+	// ParseForRepo is like Parse but the help in the comment response only
+	// shows what's allowed and configured for the repo described by repo.
+	ParseForRepo(comment string, vcsHost models.VCSHostType, repo RepoHelp) CommentParseResult
 }
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_comment_building.go CommentBuilder
@@ -78,7 +102,11 @@ type CommentParser struct {
 	GitlabUser      string
 	BitbucketUser   string
 	AzureDevopsUser string
+	GerritUser      string
 	ApplyDisabled   bool
+	// Catalog translates the comment responses. If nil, responses are in
+	// English.
+	Catalog *i18n.Catalog
 }
 
 // CommentParseResult describes the result of parsing a comment as a command.
@@ -99,7 +127,8 @@ type CommentParseResult struct {
 //   - The initial "executable" name, 'run' or 'atlantis' or '@GithubUser'
 //     where GithubUser is the API user Atlantis is running as.
 //   - Then a command: 'plan', 'apply', 'unlock', 'version, 'approve_policies',
-//     or 'help'.
+//     'approve_resources', 'force_unlock_state', 'init_config', 'fmt',
+//     'providers lock', 'cancel', 'revert' or 'help'.
 //   - Then optional flags, then an optional separator '--' followed by optional
 //     extra flags to be appended to the terraform plan/apply command.
 //
@@ -112,7 +141,20 @@ type CommentParseResult struct {
 // - atlantis unlock
 // - atlantis version
 // - atlantis approve_policies
+// - atlantis approve_resources
+// - atlantis force_unlock_state 5d1c2a3e-04b1-2c4d-9f1e-3b8a5e7d6c01 -d dir
+// - atlantis init_config
+// - atlantis fmt --fix
+// - atlantis providers lock -d dir
+// - atlantis cancel -p project
+// - atlantis revert
 func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) CommentParseResult {
+	return e.ParseForRepo(rawComment, vcsHost, RepoHelp{})
+}
+
+// ParseForRepo is like Parse but the help in the comment response only shows
+// what's allowed and configured for the repo described by repo.
+func (e *CommentParser) ParseForRepo(rawComment string, vcsHost models.VCSHostType, repo RepoHelp) CommentParseResult {
 	comment := strings.TrimSpace(rawComment)
 
 	if multiLineRegex.MatchString(comment) {
@@ -128,7 +170,7 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 
 	// Helpfully warn the user if they're using "terraform" instead of "atlantis"
 	if args[0] == "terraform" {
-		return CommentParseResult{CommentResponse: DidYouMeanAtlantisComment}
+		return CommentParseResult{CommentResponse: e.Catalog.T("did_you_mean_atlantis")}
 	}
 
 	// Atlantis can be invoked using the name of the VCS host user we're
@@ -143,6 +185,8 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		vcsUser = e.BitbucketUser
 	case models.AzureDevops:
 		vcsUser = e.AzureDevopsUser
+	case models.Gerrit:
+		vcsUser = e.GerritUser
 	}
 	executableNames := []string{"run", atlantisExecutable, "@" + vcsUser}
 	if !e.stringInSlice(args[0], executableNames) {
@@ -153,7 +197,7 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 	// parser.
 	args, err := shlex.Split(comment)
 	if err != nil {
-		return CommentParseResult{CommentResponse: fmt.Sprintf("```\nError parsing command: %s\n```", err)}
+		return CommentParseResult{CommentResponse: fmt.Sprintf("```\n%s\n```", e.Catalog.T("error_parsing_command", err))}
 	}
 	if len(args) < 1 {
 		return CommentParseResult{Ignore: true}
@@ -162,63 +206,28 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 	// If they've just typed the name of the executable then give them the help
 	// output.
 	if len(args) == 1 {
-		return CommentParseResult{CommentResponse: e.HelpComment(e.ApplyDisabled)}
+		return CommentParseResult{CommentResponse: e.HelpComment(e.ApplyDisabled, repo)}
 	}
 	cmd := args[1]
 
 	// Help output.
 	if e.stringInSlice(cmd, []string{"help", "-h", "--help"}) {
-		return CommentParseResult{CommentResponse: e.HelpComment(e.ApplyDisabled)}
+		// atlantis help <command> gives the usage of that command.
+		if len(args) == 3 && e.stringInSlice(args[2], e.allowedCommands(e.ApplyDisabled, repo)) {
+			_, flagSet := e.flagSet(args[2], &commentFlags{})
+			return CommentParseResult{CommentResponse: e.usageMarkdown(args[2], flagSet)}
+		}
+		return CommentParseResult{CommentResponse: e.HelpComment(e.ApplyDisabled, repo)}
 	}
 
 	// Need to have a plan, apply, approve_policy or unlock at this point.
-	if !e.stringInSlice(cmd, []string{command.Plan.String(), command.Apply.String(), command.Unlock.String(), command.ApprovePolicies.String(), command.Version.String()}) {
-		return CommentParseResult{CommentResponse: fmt.Sprintf("```\nError: unknown command %q.\nRun 'atlantis --help' for usage.\n```", cmd)}
+	if !e.stringInSlice(cmd, []string{command.Plan.String(), command.Apply.String(), command.Unlock.String(), command.ApprovePolicies.String(), command.ApproveResources.String(), command.Version.String(), command.ForceUnlockState.String(), command.InitConfig.String(), command.Fmt.String(), command.ProvidersLock.String(), command.Cancel.String(), command.Revert.String()}) {
+		return CommentParseResult{CommentResponse: fmt.Sprintf("```\n%s\n```\n%s", e.Catalog.T("unknown_command", cmd), e.HelpComment(e.ApplyDisabled, repo))}
 	}
 
-	var workspace string
-	var dir string
-	var project string
-	var verbose, autoMergeDisabled bool
-	var flagSet *pflag.FlagSet
-	var name command.Name
-
-	// Set up the flag parsing depending on the command.
-	switch cmd {
-	case command.Plan.String():
-		name = command.Plan
-		flagSet = pflag.NewFlagSet(command.Plan.String(), pflag.ContinueOnError)
-		flagSet.SetOutput(io.Discard)
-		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Switch to this Terraform workspace before planning.")
-		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Which directory to run plan in relative to root of repo, ex. 'child/dir'.")
-		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", fmt.Sprintf("Which project to run plan for. Refers to the name of the project configured in %s. Cannot be used at same time as workspace or dir flags.", config.AtlantisYAMLFilename))
-		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
-	case command.Apply.String():
-		name = command.Apply
-		flagSet = pflag.NewFlagSet(command.Apply.String(), pflag.ContinueOnError)
-		flagSet.SetOutput(io.Discard)
-		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Apply the plan for this Terraform workspace.")
-		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Apply the plan for this directory, relative to root of repo, ex. 'child/dir'.")
-		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", fmt.Sprintf("Apply the plan for this project. Refers to the name of the project configured in %s. Cannot be used at same time as workspace or dir flags.", config.AtlantisYAMLFilename))
-		flagSet.BoolVarP(&autoMergeDisabled, autoMergeDisabledFlagLong, autoMergeDisabledFlagShort, false, "Disable automerge after apply.")
-		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
-	case command.ApprovePolicies.String():
-		name = command.ApprovePolicies
-		flagSet = pflag.NewFlagSet(command.ApprovePolicies.String(), pflag.ContinueOnError)
-		flagSet.SetOutput(io.Discard)
-		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
-	case command.Unlock.String():
-		name = command.Unlock
-		flagSet = pflag.NewFlagSet(command.Unlock.String(), pflag.ContinueOnError)
-		flagSet.SetOutput(io.Discard)
-	case command.Version.String():
-		name = command.Version
-		flagSet = pflag.NewFlagSet(command.Version.String(), pflag.ContinueOnError)
-		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Switch to this Terraform workspace before running version.")
-		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Which directory to run version in relative to root of repo, ex. 'child/dir'.")
-		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", fmt.Sprintf("Print the version for this project. Refers to the name of the project configured in %s.", config.AtlantisYAMLFilename))
-		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
-	default:
+	var f commentFlags
+	name, flagSet := e.flagSet(cmd, &f)
+	if flagSet == nil {
 		return CommentParseResult{CommentResponse: fmt.Sprintf("Error: unknown command %q – this is a bug", cmd)}
 	}
 
@@ -226,11 +235,11 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 	// It's safe to use [2:] because we know there's at least 2 elements in args.
 	err = flagSet.Parse(args[2:])
 	if err == pflag.ErrHelp {
-		return CommentParseResult{CommentResponse: fmt.Sprintf("```\nUsage of %s:\n%s\n```", cmd, flagSet.FlagUsagesWrapped(usagesCols))}
+		return CommentParseResult{CommentResponse: e.usageMarkdown(cmd, flagSet)}
 	}
 	if err != nil {
 		if cmd == command.Unlock.String() {
-			return CommentParseResult{CommentResponse: UnlockUsage}
+			return CommentParseResult{CommentResponse: unlockUsage(e.Catalog)}
 		}
 		return CommentParseResult{CommentResponse: e.errMarkdown(err.Error(), cmd, flagSet)}
 	}
@@ -241,6 +250,26 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 	} else {
 		unusedArgs = flagSet.Args()[0:flagSet.ArgsLenAtDash()]
 	}
+	// force_unlock_state takes the ID of the lock to remove as its only
+	// argument.
+	var lockID string
+	if name == command.ForceUnlockState {
+		if len(unusedArgs) != 1 {
+			return CommentParseResult{CommentResponse: e.errMarkdown("the ID of the state lock to remove is required, ex. atlantis force_unlock_state LOCK_ID -d dir", cmd, flagSet)}
+		}
+		lockID = unusedArgs[0]
+		if !stateLockIDRegex.MatchString(lockID) {
+			return CommentParseResult{CommentResponse: e.errMarkdown(fmt.Sprintf("invalid lock ID: %q", lockID), cmd, flagSet)}
+		}
+		unusedArgs = nil
+	}
+	// providers only has the lock subcommand.
+	if name == command.ProvidersLock {
+		if len(unusedArgs) != 1 || unusedArgs[0] != "lock" {
+			return CommentParseResult{CommentResponse: e.errMarkdown("the lock subcommand is required, ex. atlantis providers lock -d dir", cmd, flagSet)}
+		}
+		unusedArgs = nil
+	}
 	if len(unusedArgs) > 0 {
 		return CommentParseResult{CommentResponse: e.errMarkdown(fmt.Sprintf("unknown argument(s) – %s", strings.Join(unusedArgs, " ")), cmd, flagSet)}
 	}
@@ -250,7 +279,7 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		extraArgs = flagSet.Args()[flagSet.ArgsLenAtDash():]
 	}
 
-	dir, err = e.validateDir(dir)
+	f.dir, err = e.validateDir(f.dir)
 	if err != nil {
 		return CommentParseResult{CommentResponse: e.errMarkdown(err.Error(), cmd, flagSet)}
 	}
@@ -258,8 +287,12 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 	// Use the same validation that Terraform uses: https://git.io/vxGhU. Plus
 	// we also don't allow '..'. We don't want the workspace to contain a path
 	// since we create files based on the name.
-	if workspace != url.PathEscape(workspace) || strings.Contains(workspace, "..") {
-		return CommentParseResult{CommentResponse: e.errMarkdown(fmt.Sprintf("invalid workspace: %q", workspace), cmd, flagSet)}
+	if f.workspace != url.PathEscape(f.workspace) || strings.Contains(f.workspace, "..") {
+		return CommentParseResult{CommentResponse: e.errMarkdown(fmt.Sprintf("invalid workspace: %q", f.workspace), cmd, flagSet)}
+	}
+
+	if f.sha != "" && !shaRegex.MatchString(f.sha) {
+		return CommentParseResult{CommentResponse: e.errMarkdown(fmt.Sprintf("invalid sha: %q", f.sha), cmd, flagSet)}
 	}
 
 	// If project is specified, dir or workspace should not be set. Since we
@@ -267,14 +300,190 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 	// to the default or didn't set the flag so there is an edge case here we
 	// don't detect, ex. atlantis plan -p project -d . -w default won't cause
 	// an error.
-	if project != "" && (workspace != "" || dir != "") {
+	if f.project != "" && (f.workspace != "" || f.dir != "") {
 		err := fmt.Sprintf("cannot use -%s/--%s at same time as -%s/--%s or -%s/--%s", projectFlagShort, projectFlagLong, dirFlagShort, dirFlagLong, workspaceFlagShort, workspaceFlagLong)
 		return CommentParseResult{CommentResponse: e.errMarkdown(err, cmd, flagSet)}
 	}
 
+	commentCmd := NewCommentCommand(f.dir, extraArgs, name, f.verbose, f.autoMergeDisabled, f.workspace, f.project)
+	commentCmd.Force = f.force
+	commentCmd.DestroyApproved = f.destroyApproved
+	commentCmd.ResourceChangesApproved = f.changesApproved
+	commentCmd.SHA = f.sha
+	commentCmd.DryRun = f.dryRun
+	commentCmd.TerraformLogLevel = f.logLevel
+	commentCmd.LockID = lockID
+	commentCmd.Fix = f.fix
 	return CommentParseResult{
-		Command: NewCommentCommand(dir, extraArgs, name, verbose, autoMergeDisabled, workspace, project),
+		Command: commentCmd,
+	}
+}
+
+// commentFlags are the values of the flags of a comment command.
+type commentFlags struct {
+	workspace         string
+	dir               string
+	project           string
+	verbose           bool
+	autoMergeDisabled bool
+	force             bool
+	destroyApproved   bool
+	changesApproved   bool
+	sha               string
+	dryRun            bool
+	fix               bool
+	// logLevel is the Terraform log level set with --verbose=<level>.
+	logLevel string
+}
+
+// terraformLogLevels are the levels that TF_LOG can be set to with
+// --verbose=<level>.
+var terraformLogLevels = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR"}
+
+// verboseLevelValue is the value of a --verbose flag that, in addition to
+// true and false, can be set to a Terraform log level.
+type verboseLevelValue struct {
+	f *commentFlags
+}
+
+func (v verboseLevelValue) Set(s string) error {
+	if b, err := strconv.ParseBool(s); err == nil {
+		v.f.verbose = b
+		return nil
+	}
+	for _, level := range terraformLogLevels {
+		if strings.EqualFold(s, level) {
+			v.f.logLevel = level
+			return nil
+		}
+	}
+	return fmt.Errorf("must be true, false or one of %s", strings.ToLower(strings.Join(terraformLogLevels, ", ")))
+}
+
+func (v verboseLevelValue) String() string {
+	if v.f == nil || v.f.logLevel == "" {
+		return ""
 	}
+	return strings.ToLower(v.f.logLevel)
+}
+
+func (v verboseLevelValue) Type() string {
+	return "level"
+}
+
+// verboseLevelVarP adds a --verbose flag to flagSet that can optionally be
+// set to a Terraform log level, ex. --verbose=trace.
+func verboseLevelVarP(flagSet *pflag.FlagSet, f *commentFlags) {
+	flag := flagSet.VarPF(verboseLevelValue{f: f}, verboseFlagLong, verboseFlagShort, "Append Atlantis log to comment, or set TF_LOG to level, ex. --verbose=trace, and send the logs to the job output (admins only).")
+	flag.NoOptDefVal = "true"
+}
+
+// flagSet returns the name and flags of the command cmd. The flag values are
+// stored in f. If cmd isn't a command, the returned flag set is nil.
+func (e *CommentParser) flagSet(cmd string, f *commentFlags) (command.Name, *pflag.FlagSet) {
+	var flagSet *pflag.FlagSet
+	var name command.Name
+
+	// Set up the flag parsing depending on the command.
+	switch cmd {
+	case command.Plan.String():
+		name = command.Plan
+		flagSet = pflag.NewFlagSet(command.Plan.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+		flagSet.StringVarP(&f.workspace, workspaceFlagLong, workspaceFlagShort, "", "Switch to this Terraform workspace before planning.")
+		flagSet.StringVarP(&f.dir, dirFlagLong, dirFlagShort, "", "Which directory to run plan in relative to root of repo, ex. 'child/dir'.")
+		flagSet.StringVarP(&f.project, projectFlagLong, projectFlagShort, "", fmt.Sprintf("Which project to run plan for. Refers to the name of the project configured in %s. Cannot be used at same time as workspace or dir flags.", config.AtlantisYAMLFilename))
+		flagSet.BoolVarP(&f.dryRun, dryRunFlagLong, dryRunFlagShort, false, "Show which projects plan would run for without planning.")
+		verboseLevelVarP(flagSet, f)
+	case command.Apply.String():
+		name = command.Apply
+		flagSet = pflag.NewFlagSet(command.Apply.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+		flagSet.StringVarP(&f.workspace, workspaceFlagLong, workspaceFlagShort, "", "Apply the plan for this Terraform workspace.")
+		flagSet.StringVarP(&f.dir, dirFlagLong, dirFlagShort, "", "Apply the plan for this directory, relative to root of repo, ex. 'child/dir'.")
+		flagSet.StringVarP(&f.project, projectFlagLong, projectFlagShort, "", fmt.Sprintf("Apply the plan for this project. Refers to the name of the project configured in %s. Cannot be used at same time as workspace or dir flags.", config.AtlantisYAMLFilename))
+		flagSet.BoolVarP(&f.autoMergeDisabled, autoMergeDisabledFlagLong, autoMergeDisabledFlagShort, false, "Disable automerge after apply.")
+		flagSet.BoolVarP(&f.force, forceFlagLong, forceFlagShort, false, "Apply the plan even if it is older than the maximum plan age.")
+		flagSet.BoolVarP(&f.destroyApproved, destroyApprovedFlagLong, destroyApprovedFlagShort, false, "Apply the plan even if it destroys protected resources (destroy approver teams only).")
+		flagSet.BoolVarP(&f.changesApproved, changesApprovedFlagLong, changesApprovedFlagShort, false, "Apply the plan even if it changes more resources than allowed (max resource changes teams only).")
+		flagSet.StringVarP(&f.sha, shaFlagLong, shaFlagShort, "", "Apply the plans that were created from this commit even if the pull request's head has moved.")
+		verboseLevelVarP(flagSet, f)
+	case command.ApprovePolicies.String():
+		name = command.ApprovePolicies
+		flagSet = pflag.NewFlagSet(command.ApprovePolicies.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+		flagSet.BoolVarP(&f.verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
+	case command.ApproveResources.String():
+		name = command.ApproveResources
+		flagSet = pflag.NewFlagSet(command.ApproveResources.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+	case command.Unlock.String():
+		name = command.Unlock
+		flagSet = pflag.NewFlagSet(command.Unlock.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+	case command.Version.String():
+		name = command.Version
+		flagSet = pflag.NewFlagSet(command.Version.String(), pflag.ContinueOnError)
+		flagSet.StringVarP(&f.workspace, workspaceFlagLong, workspaceFlagShort, "", "Switch to this Terraform workspace before running version.")
+		flagSet.StringVarP(&f.dir, dirFlagLong, dirFlagShort, "", "Which directory to run version in relative to root of repo, ex. 'child/dir'.")
+		flagSet.StringVarP(&f.project, projectFlagLong, projectFlagShort, "", fmt.Sprintf("Print the version for this project. Refers to the name of the project configured in %s.", config.AtlantisYAMLFilename))
+		flagSet.BoolVarP(&f.verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
+	case command.ForceUnlockState.String():
+		name = command.ForceUnlockState
+		flagSet = pflag.NewFlagSet(command.ForceUnlockState.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+		flagSet.StringVarP(&f.workspace, workspaceFlagLong, workspaceFlagShort, "", "Remove the state lock for this Terraform workspace.")
+		flagSet.StringVarP(&f.dir, dirFlagLong, dirFlagShort, "", "Remove the state lock for this directory, relative to root of repo, ex. 'child/dir'.")
+		flagSet.StringVarP(&f.project, projectFlagLong, projectFlagShort, "", fmt.Sprintf("Remove the state lock for this project. Refers to the name of the project configured in %s. Cannot be used at same time as workspace or dir flags.", config.AtlantisYAMLFilename))
+		flagSet.BoolVarP(&f.verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
+	case command.InitConfig.String():
+		name = command.InitConfig
+		flagSet = pflag.NewFlagSet(command.InitConfig.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+	case command.Fmt.String():
+		name = command.Fmt
+		flagSet = pflag.NewFlagSet(command.Fmt.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+		flagSet.BoolVarP(&f.fix, fixFlagLong, fixFlagShort, false, "Push a commit that formats the Terraform files to the pull request's branch.")
+	case command.ProvidersLock.String():
+		name = command.ProvidersLock
+		flagSet = pflag.NewFlagSet(command.ProvidersLock.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+		flagSet.StringVarP(&f.dir, dirFlagLong, dirFlagShort, "", "Update the lock file of this directory, relative to root of repo, ex. 'child/dir'. Defaults to the root modules the pull request modifies.")
+	case command.Cancel.String():
+		name = command.Cancel
+		flagSet = pflag.NewFlagSet(command.Cancel.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+		flagSet.StringVarP(&f.workspace, workspaceFlagLong, workspaceFlagShort, "", "Cancel the command running in this Terraform workspace.")
+		flagSet.StringVarP(&f.dir, dirFlagLong, dirFlagShort, "", "Cancel the command running in this directory, relative to root of repo, ex. 'child/dir'.")
+		flagSet.StringVarP(&f.project, projectFlagLong, projectFlagShort, "", fmt.Sprintf("Cancel the command running for this project. Refers to the name of the project configured in %s. Cannot be used at same time as workspace or dir flags.", config.AtlantisYAMLFilename))
+	case command.Revert.String():
+		name = command.Revert
+		flagSet = pflag.NewFlagSet(command.Revert.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+	}
+	return name, flagSet
+}
+
+// allowedCommands returns the commands that can be run with this server's
+// configuration on the repo described by repo in the order they're shown in
+// the help comment.
+func (e *CommentParser) allowedCommands(applyDisabled bool, repo RepoHelp) []string {
+	cmds := []string{command.Plan.String()}
+	if !applyDisabled {
+		cmds = append(cmds, command.Apply.String())
+	}
+	cmds = append(cmds, command.Unlock.String(), command.ForceUnlockState.String(), command.ApprovePolicies.String(), command.ApproveResources.String(), command.Version.String(), command.Fmt.String(), command.ProvidersLock.String(), command.Cancel.String(), command.InitConfig.String(), command.Revert.String())
+	if repo.AllowedCommands == nil {
+		return cmds
+	}
+	var allowed []string
+	for _, cmd := range cmds {
+		if e.stringInSlice(cmd, repo.AllowedCommands) {
+			allowed = append(allowed, cmd)
+		}
+	}
+	return allowed
 }
 
 // BuildPlanComment builds a plan comment for the specified args.
@@ -363,17 +572,74 @@ func (e *CommentParser) stringInSlice(a string, list []string) bool {
 	return false
 }
 
+func (e *CommentParser) usageMarkdown(cmd string, flagSet *pflag.FlagSet) string {
+	return fmt.Sprintf("```\n%s\n%s\n```", e.Catalog.T("usage_of", cmd), flagSet.FlagUsagesWrapped(usagesCols))
+}
+
 func (e *CommentParser) errMarkdown(errMsg string, cmd string, flagSet *pflag.FlagSet) string {
-	return fmt.Sprintf("```\nError: %s.\nUsage of %s:\n%s```", errMsg, cmd, flagSet.FlagUsagesWrapped(usagesCols))
+	return fmt.Sprintf("```\n%s\n%s\n%s```", e.Catalog.T("error", errMsg), e.Catalog.T("usage_of", cmd), flagSet.FlagUsagesWrapped(usagesCols))
+}
+
+// helpCommand is a command listed in the help comment.
+type helpCommand struct {
+	// Heading is the command name padded so that the descriptions line up.
+	Heading string
+	// DescriptionKey is the catalog key of the command's description.
+	DescriptionKey string
+	// Flags lists the command's flags, ex. "-d/--dir, --verbose".
+	Flags string
 }
 
-func (e *CommentParser) HelpComment(applyDisabled bool) string {
+// HelpComment returns the help comment listing the commands that are allowed
+// on the repo described by repo and their flags, and the repo's projects.
+func (e *CommentParser) HelpComment(applyDisabled bool, repo RepoHelp) string {
+	var cmds []helpCommand
+	allowed := e.allowedCommands(applyDisabled, repo)
+	for _, cmd := range allowed {
+		heading := fmt.Sprintf("%-9s", cmd)
+		if len(cmd) >= len(heading) {
+			heading = cmd + "\n" + strings.Repeat(" ", 11)
+		}
+		_, flagSet := e.flagSet(cmd, &commentFlags{})
+		var flags []string
+		flagSet.VisitAll(func(f *pflag.Flag) {
+			if f.Shorthand != "" {
+				flags = append(flags, fmt.Sprintf("-%s/--%s", f.Shorthand, f.Name))
+			} else {
+				flags = append(flags, "--"+f.Name)
+			}
+		})
+		cmds = append(cmds, helpCommand{
+			Heading:        heading,
+			DescriptionKey: "help." + cmd,
+			Flags:          strings.Join(flags, ", "),
+		})
+	}
+
+	// Projects are listed with the flags that select them.
+	var projects []string
+	for _, p := range repo.Projects {
+		if p.Name != nil {
+			projects = append(projects, fmt.Sprintf("-p %s", *p.Name))
+			continue
+		}
+		flags := fmt.Sprintf("-d %s", p.Dir)
+		if p.Workspace != DefaultWorkspace {
+			flags += fmt.Sprintf(" -w %s", p.Workspace)
+		}
+		projects = append(projects, flags)
+	}
+
 	buf := &bytes.Buffer{}
-	var tmpl = template.Must(template.New("").Parse(helpCommentTemplate))
+	var tmpl = template.Must(template.New("").Funcs(template.FuncMap{"t": e.Catalog.T}).Parse(helpCommentTemplate))
 	if err := tmpl.Execute(buf, struct {
 		ApplyDisabled bool
+		Commands      []helpCommand
+		Projects      []string
 	}{
-		ApplyDisabled: applyDisabled,
+		ApplyDisabled: applyDisabled || !e.stringInSlice(command.Apply.String(), allowed),
+		Commands:      cmds,
+		Projects:      projects,
 	}); err != nil {
 		return fmt.Sprintf("Failed to render template, this is a bug: %v", err)
 	}
@@ -383,54 +649,54 @@ func (e *CommentParser) HelpComment(applyDisabled bool) string {
 
 var helpCommentTemplate = "```cmake\n" +
 	`atlantis
-Terraform Pull Request Automation
+{{ t "help.title" }}
 
-Usage:
+{{ t "help.usage" }}
   atlantis <command> [options] -- [terraform options]
 
-Examples:
-  # run plan in the root directory passing the -target flag to terraform
+{{ t "help.examples" }}
+  # {{ t "help.example.plan" }}
   atlantis plan -d . -- -target=resource
   {{- if not .ApplyDisabled }}
 
-  # apply all unapplied plans from this pull request
+  # {{ t "help.example.apply_all" }}
   atlantis apply
 
-  # apply the plan for the root directory and staging workspace
+  # {{ t "help.example.apply_dir" }}
   atlantis apply -d . -w staging
 {{- end }}
 
-Commands:
-  plan     Runs 'terraform plan' for the changes in this pull request.
-           To plan a specific project, use the -d, -w and -p flags.
-{{- if not .ApplyDisabled }}
-  apply    Runs 'terraform apply' on all unapplied plans from this pull request.
-           To only apply a specific plan, use the -d, -w and -p flags.
+{{ t "help.commands" }}
+{{- range .Commands }}
+  {{ .Heading }}{{ t .DescriptionKey }}
+{{- if .Flags }}
+           {{ t "help.flags" }} {{ .Flags }}
+{{- end }}
+{{- end }}
+  help     {{ t "help.help" }}
+{{- if .Projects }}
+
+{{ t "help.projects" }}
+{{- range .Projects }}
+  {{ . }}
+{{- end }}
 {{- end }}
-  unlock   Removes all atlantis locks and discards all plans for this PR.
-           To unlock a specific plan you can use the Atlantis UI.
-  approve_policies
-           Approves all current policy checking failures for the PR.
-  version  Print the output of 'terraform version'
-  help     View help.
 
-Flags:
-  -h, --help   help for atlantis
+{{ t "help.flags" }}
+  -h, --help   {{ t "help.help_flag" }}
 
-Use "atlantis [command] --help" for more information about a command.` +
+{{ t "help.more" }}` +
 	"\n```"
 
 // DidYouMeanAtlantisComment is the comment we add to the pull request when
 // someone runs a command with terraform instead of atlantis.
-var DidYouMeanAtlantisComment = "Did you mean to use `atlantis` instead of `terraform`?"
+var DidYouMeanAtlantisComment = (*i18n.Catalog)(nil).T("did_you_mean_atlantis")
 
 // UnlockUsage is the comment we add to the pull request when someone runs
 // `atlantis unlock` with flags.
 
-var UnlockUsage = "`Usage of unlock:`\n\n ```cmake\n" +
-	`atlantis unlock
+var UnlockUsage = unlockUsage(nil)
 
-  Unlocks the entire PR and discards all plans in this PR.
-  Arguments or flags are not supported at the moment.
-  If you need to unlock a specific project please use the atlantis UI.` +
-	"\n```"
+func unlockUsage(c *i18n.Catalog) string {
+	return fmt.Sprintf("`%s`\n\n ```cmake\natlantis unlock\n\n  %s\n```", c.T("usage_of", command.Unlock.String()), c.T("unlock_usage"))
+}
@@ -18,9 +18,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/i18n"
 	. "github.com/runatlantis/atlantis/testing"
 )
 
@@ -53,11 +55,10 @@ func TestParse_HelpResponse(t *testing.T) {
 		"atlantis --help",
 		"atlantis -h",
 		"atlantis help something else",
-		"atlantis help plan",
 	}
 	for _, c := range helpComments {
 		r := commentParser.Parse(c, models.Github)
-		Equals(t, commentParser.HelpComment(false), r.CommentResponse)
+		Equals(t, commentParser.HelpComment(false, events.RepoHelp{}), r.CommentResponse)
 	}
 }
 
@@ -70,12 +71,11 @@ func TestParse_HelpResponseWithApplyDisabled(t *testing.T) {
 		"atlantis --help",
 		"atlantis -h",
 		"atlantis help something else",
-		"atlantis help plan",
 	}
 	for _, c := range helpComments {
 		commentParser.ApplyDisabled = true
 		r := commentParser.Parse(c, models.Github)
-		Equals(t, commentParser.HelpComment(true), r.CommentResponse)
+		Equals(t, commentParser.HelpComment(true, events.RepoHelp{}), r.CommentResponse)
 	}
 }
 
@@ -191,12 +191,58 @@ func TestParse_InvalidCommand(t *testing.T) {
 	}
 	for _, c := range comments {
 		r := commentParser.Parse(c, models.Github)
-		exp := fmt.Sprintf("```\nError: unknown command %q.\nRun 'atlantis --help' for usage.\n```", strings.Fields(c)[1])
+		exp := fmt.Sprintf("```\nError: unknown command %q.\n```\n%s", strings.Fields(c)[1], commentParser.HelpComment(commentParser.ApplyDisabled, events.RepoHelp{}))
 		Assert(t, r.CommentResponse == exp,
 			"For comment %q expected CommentResponse==%q but got %q", c, exp, r.CommentResponse)
 	}
 }
 
+func TestParse_HelpSubcommand(t *testing.T) {
+	cases := []struct {
+		comment       string
+		applyDisabled bool
+		exp           string
+	}{
+		{
+			"atlantis help plan",
+			false,
+			"```\n" + PlanUsage + "\n```",
+		},
+		{
+			"atlantis help apply",
+			false,
+			"```\n" + ApplyUsage + "\n```",
+		},
+		{
+			"atlantis help apply",
+			true,
+			commentParser.HelpComment(true, events.RepoHelp{}),
+		},
+		{
+			"atlantis help force_unlock_state",
+			false,
+			"```\nUsage of force_unlock_state:\n" +
+				"  -d, --dir string         Remove the state lock for this directory, relative to\n" +
+				"                           root of repo, ex. 'child/dir'.\n" +
+				"  -p, --project string     Remove the state lock for this project. Refers to the\n" +
+				"                           name of the project configured in atlantis.yaml. Cannot\n" +
+				"                           be used at same time as workspace or dir flags.\n" +
+				"      --verbose            Append Atlantis log to comment.\n" +
+				"  -w, --workspace string   Remove the state lock for this Terraform workspace.\n" +
+				"\n```",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.comment, func(t *testing.T) {
+			cp := events.CommentParser{
+				GithubUser:    "github-user",
+				ApplyDisabled: c.applyDisabled,
+			}
+			Equals(t, c.exp, cp.Parse(c.comment, models.Github).CommentResponse)
+		})
+	}
+}
+
 func TestParse_SubcommandUsage(t *testing.T) {
 	t.Log("given a comment asking for the usage of a subcommand should " +
 		"return help")
@@ -272,6 +318,182 @@ func TestParse_RelativeDirPath(t *testing.T) {
 	}
 }
 
+func TestParse_Force(t *testing.T) {
+	r := commentParser.Parse("atlantis apply -d . --force", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, true, r.Command.Force)
+
+	r = commentParser.Parse("atlantis apply -d .", models.Github)
+	Equals(t, false, r.Command.Force)
+
+	r = commentParser.Parse("atlantis plan --force", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "unknown flag: --force"), "got %q", r.CommentResponse)
+}
+
+func TestParse_DestroyApproved(t *testing.T) {
+	r := commentParser.Parse("atlantis apply -p network --destroy-approved", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, true, r.Command.DestroyApproved)
+
+	r = commentParser.Parse("atlantis apply -p network", models.Github)
+	Equals(t, false, r.Command.DestroyApproved)
+
+	r = commentParser.Parse("atlantis plan --destroy-approved", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "unknown flag: --destroy-approved"), "got %q", r.CommentResponse)
+}
+
+func TestParse_ResourceChangesApproved(t *testing.T) {
+	r := commentParser.Parse("atlantis apply -p network --resource-changes-approved", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, true, r.Command.ResourceChangesApproved)
+	Equals(t, false, r.Command.Force)
+
+	r = commentParser.Parse("atlantis plan --resource-changes-approved", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "unknown flag: --resource-changes-approved"), "got %q", r.CommentResponse)
+}
+
+func TestParse_ApproveResources(t *testing.T) {
+	r := commentParser.Parse("atlantis approve_resources", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, command.ApproveResources, r.Command.Name)
+
+	r = commentParser.Parse("atlantis approve_resources -d dir", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "unknown shorthand flag: 'd' in -d"), "got %q", r.CommentResponse)
+}
+
+func TestParse_SHA(t *testing.T) {
+	r := commentParser.Parse("atlantis apply --sha=3a5b7c9", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, "3a5b7c9", r.Command.SHA)
+
+	r = commentParser.Parse("atlantis apply --sha 3a5b", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, `invalid sha: "3a5b"`), "got %q", r.CommentResponse)
+
+	r = commentParser.Parse("atlantis apply --sha=main", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, `invalid sha: "main"`), "got %q", r.CommentResponse)
+
+	r = commentParser.Parse("atlantis plan --sha=3a5b7c9", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "unknown flag: --sha"), "got %q", r.CommentResponse)
+}
+
+func TestParse_DryRun(t *testing.T) {
+	r := commentParser.Parse("atlantis plan --dry-run -d dir", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, true, r.Command.DryRun)
+
+	r = commentParser.Parse("atlantis plan -d dir", models.Github)
+	Equals(t, false, r.Command.DryRun)
+
+	r = commentParser.Parse("atlantis apply --dry-run", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "unknown flag: --dry-run"), "got %q", r.CommentResponse)
+}
+
+func TestParse_VerboseLevel(t *testing.T) {
+	r := commentParser.Parse("atlantis plan --verbose=trace", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, "TRACE", r.Command.TerraformLogLevel)
+	Equals(t, false, r.Command.Verbose)
+
+	r = commentParser.Parse("atlantis apply --verbose=Debug", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, "DEBUG", r.Command.TerraformLogLevel)
+
+	r = commentParser.Parse("atlantis plan --verbose -d dir", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, "", r.Command.TerraformLogLevel)
+	Equals(t, true, r.Command.Verbose)
+
+	r = commentParser.Parse("atlantis plan --verbose=loud", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "must be true, false or one of trace, debug, info, warn, error"), "got %q", r.CommentResponse)
+
+	r = commentParser.Parse("atlantis approve_policies --verbose=trace", models.Github)
+	Assert(t, r.Command == nil, "exp approve_policies not to accept a level")
+}
+
+func TestParse_ForceUnlockState(t *testing.T) {
+	r := commentParser.Parse("atlantis force_unlock_state 5d1c2a3e-04b1-2c4d-9f1e-3b8a5e7d6c01 -d dir -w staging", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, command.ForceUnlockState, r.Command.Name)
+	Equals(t, "5d1c2a3e-04b1-2c4d-9f1e-3b8a5e7d6c01", r.Command.LockID)
+	Equals(t, "dir", r.Command.RepoRelDir)
+	Equals(t, "staging", r.Command.Workspace)
+
+	r = commentParser.Parse("atlantis force_unlock_state -p project", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "the ID of the state lock to remove is required"), "got %q", r.CommentResponse)
+
+	r = commentParser.Parse("atlantis force_unlock_state 'id;rm' -p project", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, `invalid lock ID: "id;rm"`), "got %q", r.CommentResponse)
+}
+
+func TestParse_InitConfig(t *testing.T) {
+	r := commentParser.Parse("atlantis init_config", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, command.InitConfig, r.Command.Name)
+
+	r = commentParser.Parse("atlantis init_config -d dir", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "unknown shorthand flag: 'd' in -d"), "got %q", r.CommentResponse)
+}
+
+func TestParse_Revert(t *testing.T) {
+	r := commentParser.Parse("atlantis revert", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, command.Revert, r.Command.Name)
+
+	r = commentParser.Parse("atlantis revert -p project", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "unknown shorthand flag: 'p' in -p"), "got %q", r.CommentResponse)
+}
+
+func TestParse_Fmt(t *testing.T) {
+	r := commentParser.Parse("atlantis fmt", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, command.Fmt, r.Command.Name)
+	Equals(t, false, r.Command.Fix)
+
+	r = commentParser.Parse("atlantis fmt --fix", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, true, r.Command.Fix)
+
+	r = commentParser.Parse("atlantis fmt -d dir", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "unknown shorthand flag: 'd' in -d"), "got %q", r.CommentResponse)
+}
+
+func TestParse_ProvidersLock(t *testing.T) {
+	r := commentParser.Parse("atlantis providers lock", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, command.ProvidersLock, r.Command.Name)
+	Equals(t, "", r.Command.RepoRelDir)
+
+	r = commentParser.Parse("atlantis providers lock -d dir", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, "dir", r.Command.RepoRelDir)
+
+	for _, comment := range []string{"atlantis providers", "atlantis providers mirror", "atlantis providers lock extra"} {
+		r = commentParser.Parse(comment, models.Github)
+		Assert(t, strings.Contains(r.CommentResponse, "the lock subcommand is required"), "got %q for %q", r.CommentResponse, comment)
+	}
+}
+
+func TestParse_Cancel(t *testing.T) {
+	r := commentParser.Parse("atlantis cancel", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, command.Cancel, r.Command.Name)
+	Equals(t, "", r.Command.RepoRelDir)
+	Equals(t, "", r.Command.Workspace)
+	Equals(t, "", r.Command.ProjectName)
+
+	r = commentParser.Parse("atlantis cancel -d dir -w staging", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, "dir", r.Command.RepoRelDir)
+	Equals(t, "staging", r.Command.Workspace)
+
+	r = commentParser.Parse("atlantis cancel -p project", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, "project", r.Command.ProjectName)
+
+	r = commentParser.Parse("atlantis cancel -p project -d dir", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "cannot use -p/--project at same time as -d/--dir or -w/--workspace"), "got %q", r.CommentResponse)
+}
+
 // If there's multiple lines but it's whitespace, allow the command. This
 // occurs when you copy and paste via GitHub.
 func TestParse_Multiline(t *testing.T) {
@@ -730,13 +952,38 @@ Examples:
 Commands:
   plan     Runs 'terraform plan' for the changes in this pull request.
            To plan a specific project, use the -d, -w and -p flags.
+           Flags: -d/--dir, --dry-run, -p/--project, --verbose, -w/--workspace
   apply    Runs 'terraform apply' on all unapplied plans from this pull request.
            To only apply a specific plan, use the -d, -w and -p flags.
+           Flags: --auto-merge-disabled, --destroy-approved, -d/--dir, --force, -p/--project, --resource-changes-approved, --sha, --verbose, -w/--workspace
   unlock   Removes all atlantis locks and discards all plans for this PR.
            To unlock a specific plan you can use the Atlantis UI.
+  force_unlock_state
+           Runs 'terraform force-unlock' to remove a stale state lock.
+           Usage: atlantis force_unlock_state LOCK_ID
+           Flags: -d/--dir, -p/--project, --verbose, -w/--workspace
   approve_policies
            Approves all current policy checking failures for the PR.
+           Flags: --verbose
+  approve_resources
+           Approves the changes to protected resources in this PR's plans.
   version  Print the output of 'terraform version'
+           Flags: -d/--dir, -p/--project, --verbose, -w/--workspace
+  fmt      Checks the formatting of the Terraform files with 'terraform fmt'.
+           To push a commit that formats them, use --fix.
+           Flags: --fix
+  providers
+           Runs 'terraform providers lock' and pushes the updated lock files.
+           Usage: atlantis providers lock
+           Flags: -d/--dir
+  cancel   Cancels the plans and applies that are running in this pull request.
+           To only cancel a specific project, use the -d, -w and -p flags.
+           Flags: -d/--dir, -p/--project, -w/--workspace
+  init_config
+           Opens a pull request that adds a generated atlantis.yaml
+           with a project for each Terraform root module.
+  revert   Opens a pull request that reverts this merged pull request
+           and plans the projects it changes.
   help     View help.
 
 Flags:
@@ -761,11 +1008,35 @@ Examples:
 Commands:
   plan     Runs 'terraform plan' for the changes in this pull request.
            To plan a specific project, use the -d, -w and -p flags.
+           Flags: -d/--dir, --dry-run, -p/--project, --verbose, -w/--workspace
   unlock   Removes all atlantis locks and discards all plans for this PR.
            To unlock a specific plan you can use the Atlantis UI.
+  force_unlock_state
+           Runs 'terraform force-unlock' to remove a stale state lock.
+           Usage: atlantis force_unlock_state LOCK_ID
+           Flags: -d/--dir, -p/--project, --verbose, -w/--workspace
   approve_policies
            Approves all current policy checking failures for the PR.
+           Flags: --verbose
+  approve_resources
+           Approves the changes to protected resources in this PR's plans.
   version  Print the output of 'terraform version'
+           Flags: -d/--dir, -p/--project, --verbose, -w/--workspace
+  fmt      Checks the formatting of the Terraform files with 'terraform fmt'.
+           To push a commit that formats them, use --fix.
+           Flags: --fix
+  providers
+           Runs 'terraform providers lock' and pushes the updated lock files.
+           Usage: atlantis providers lock
+           Flags: -d/--dir
+  cancel   Cancels the plans and applies that are running in this pull request.
+           To only cancel a specific project, use the -d, -w and -p flags.
+           Flags: -d/--dir, -p/--project, -w/--workspace
+  init_config
+           Opens a pull request that adds a generated atlantis.yaml
+           with a project for each Terraform root module.
+  revert   Opens a pull request that reverts this merged pull request
+           and plans the projects it changes.
   help     View help.
 
 Flags:
@@ -778,11 +1049,77 @@ Use "atlantis [command] --help" for more information about a command.` +
 
 	for _, c := range cases {
 		t.Run(fmt.Sprintf("ApplyDisabled: %v", c.applyDisabled), func(t *testing.T) {
-			Equals(t, commentParser.HelpComment(c.applyDisabled), c.expectResult)
+			Equals(t, commentParser.HelpComment(c.applyDisabled, events.RepoHelp{}), c.expectResult)
 		})
 	}
 }
 
+func TestCommentParser_HelpCommentForRepo(t *testing.T) {
+	staging := "staging"
+	repo := events.RepoHelp{
+		AllowedCommands: []string{"plan", "unlock"},
+		Projects: []valid.Project{
+			{Name: &staging, Dir: "staging", Workspace: "default"},
+			{Dir: "production", Workspace: "default"},
+			{Dir: "production", Workspace: "eu"},
+		},
+	}
+	exp := "```cmake\n" +
+		`atlantis
+Terraform Pull Request Automation
+
+Usage:
+  atlantis <command> [options] -- [terraform options]
+
+Examples:
+  # run plan in the root directory passing the -target flag to terraform
+  atlantis plan -d . -- -target=resource
+
+Commands:
+  plan     Runs 'terraform plan' for the changes in this pull request.
+           To plan a specific project, use the -d, -w and -p flags.
+           Flags: -d/--dir, --dry-run, -p/--project, --verbose, -w/--workspace
+  unlock   Removes all atlantis locks and discards all plans for this PR.
+           To unlock a specific plan you can use the Atlantis UI.
+  help     View help.
+
+Projects (select one with these flags):
+  -p staging
+  -d production
+  -d production -w eu
+
+Flags:
+  -h, --help   help for atlantis
+
+Use "atlantis [command] --help" for more information about a command.` +
+		"\n```"
+	Equals(t, exp, commentParser.HelpComment(false, repo))
+
+	// The usage of commands the repo doesn't allow isn't shown.
+	r := commentParser.ParseForRepo("atlantis help apply", models.Github, repo)
+	Equals(t, exp, r.CommentResponse)
+	r = commentParser.ParseForRepo("atlantis help plan", models.Github, repo)
+	Assert(t, strings.HasPrefix(r.CommentResponse, "```\nUsage of plan:"), "exp usage of plan, got %q", r.CommentResponse)
+}
+
+func TestCommentParser_Locale(t *testing.T) {
+	catalog, err := i18n.NewCatalog("es")
+	Ok(t, err)
+	cp := events.CommentParser{
+		GithubUser: "github-user",
+		Catalog:    catalog,
+	}
+
+	r := cp.Parse("terraform plan", models.Github)
+	Equals(t, "¿Quisiste usar `atlantis` en lugar de `terraform`?", r.CommentResponse)
+
+	r = cp.Parse("atlantis unknown", models.Github)
+	Assert(t, strings.HasPrefix(r.CommentResponse, "```\nError: comando desconocido \"unknown\".\n```\n"), "exp error to be translated, got %q", r.CommentResponse)
+
+	r = cp.Parse("atlantis help", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "Automatización de Pull Requests para Terraform"), "exp help to be translated, got %q", r.CommentResponse)
+}
+
 func TestParse_VCSUsername(t *testing.T) {
 	cp := events.CommentParser{
 		GithubUser:      "gh",
@@ -819,30 +1156,44 @@ func TestParse_VCSUsername(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.vcs.String(), func(t *testing.T) {
 			r := cp.Parse(fmt.Sprintf("@%s %s", c.user, "help"), c.vcs)
-			Equals(t, commentParser.HelpComment(false), r.CommentResponse)
+			Equals(t, commentParser.HelpComment(false, events.RepoHelp{}), r.CommentResponse)
 		})
 	}
 }
 
 var PlanUsage = `Usage of plan:
-  -d, --dir string         Which directory to run plan in relative to root of repo,
-                           ex. 'child/dir'.
-  -p, --project string     Which project to run plan for. Refers to the name of the
-                           project configured in atlantis.yaml. Cannot be used at
-                           same time as workspace or dir flags.
-      --verbose            Append Atlantis log to comment.
-  -w, --workspace string   Switch to this Terraform workspace before planning.
+  -d, --dir string             Which directory to run plan in relative to root of
+                               repo, ex. 'child/dir'.
+      --dry-run                Show which projects plan would run for without planning.
+  -p, --project string         Which project to run plan for. Refers to the name of
+                               the project configured in atlantis.yaml. Cannot be
+                               used at same time as workspace or dir flags.
+      --verbose level[=true]   Append Atlantis log to comment, or set TF_LOG to
+                               level, ex. --verbose=trace, and send the logs to the
+                               job output (admins only).
+  -w, --workspace string       Switch to this Terraform workspace before planning.
 `
 
 var ApplyUsage = `Usage of apply:
-      --auto-merge-disabled   Disable automerge after apply.
-  -d, --dir string            Apply the plan for this directory, relative to root of
-                              repo, ex. 'child/dir'.
-  -p, --project string        Apply the plan for this project. Refers to the name of
-                              the project configured in atlantis.yaml. Cannot be
-                              used at same time as workspace or dir flags.
-      --verbose               Append Atlantis log to comment.
-  -w, --workspace string      Apply the plan for this Terraform workspace.
+      --auto-merge-disabled         Disable automerge after apply.
+      --destroy-approved            Apply the plan even if it destroys protected
+                                    resources (destroy approver teams only).
+  -d, --dir string                  Apply the plan for this directory, relative to
+                                    root of repo, ex. 'child/dir'.
+      --force                       Apply the plan even if it is older than the
+                                    maximum plan age.
+  -p, --project string              Apply the plan for this project. Refers to the
+                                    name of the project configured in atlantis.yaml.
+                                    Cannot be used at same time as workspace or dir
+                                    flags.
+      --resource-changes-approved   Apply the plan even if it changes more resources
+                                    than allowed (max resource changes teams only).
+      --sha string                  Apply the plans that were created from this
+                                    commit even if the pull request's head has moved.
+      --verbose level[=true]        Append Atlantis log to comment, or set TF_LOG to
+                                    level, ex. --verbose=trace, and send the logs to
+                                    the job output (admins only).
+  -w, --workspace string            Apply the plan for this Terraform workspace.
 `
 
 var ApprovePolicyUsage = `Usage of approve_policies:
@@ -14,9 +14,14 @@
 package events
 
 import (
+	"bytes"
 	"fmt"
+	"regexp"
 	"strings"
+	"text/template"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
@@ -33,20 +38,66 @@ type CommitStatusUpdater interface {
 	// UpdateCombinedCount updates the combined status to reflect the
 	// numSuccess out of numTotal.
 	UpdateCombinedCount(repo models.Repo, pull models.PullRequest, status models.CommitStatus, cmdName command.Name, numSuccess int, numTotal int) error
+	// UpdateCombinedNoProjects sets the combined statuses of cmdNames to
+	// success with 0/0 projects for a pull request that has no projects to run
+	// them in.
+	UpdateCombinedNoProjects(repo models.Repo, pull models.PullRequest, cmdNames []command.Name) error
 	// UpdateProject sets the commit status for the project represented by
 	// ctx.
 	UpdateProject(ctx command.ProjectContext, cmdName command.Name, status models.CommitStatus, url string) error
 }
 
+const (
+	// CombinedStatusGranularity sets a single commit status that's updated by
+	// every command.
+	CombinedStatusGranularity = "combined"
+	// CommandStatusGranularity sets a commit status per command, ex.
+	// atlantis/plan and atlantis/apply.
+	CommandStatusGranularity = "command"
+	// ProjectStatusGranularity sets a commit status per command and a commit
+	// status per command and project, ex. atlantis/plan: dir/default.
+	ProjectStatusGranularity = "project"
+)
+
+// StatusNameData is the data available to the templates that name commit
+// statuses.
+type StatusNameData struct {
+	// StatusName is the name used to identify Atlantis, ex. atlantis.
+	StatusName string
+	// Command is the name of the command, ex. plan. It's empty for combined
+	// statuses.
+	Command string
+	// Project is the project's name if it has one, otherwise its dir and
+	// workspace joined with a /. It's only set for project statuses.
+	Project     string
+	ProjectName string
+	Dir         string
+	Workspace   string
+}
+
 // DefaultCommitStatusUpdater implements CommitStatusUpdater.
 type DefaultCommitStatusUpdater struct {
 	Client vcs.Client
 	// StatusName is the name used to identify Atlantis when creating PR statuses.
 	StatusName string
+	// Granularity is one of CombinedStatusGranularity,
+	// CommandStatusGranularity or ProjectStatusGranularity. If empty,
+	// ProjectStatusGranularity is used.
+	Granularity string
+	// StatusNameTemplate names the combined and command statuses. If nil,
+	// combined statuses are named StatusName and command statuses
+	// StatusName/command.
+	StatusNameTemplate *template.Template
+	// ProjectStatusNameTemplate names the project statuses. If nil, they're
+	// named StatusName/command: project.
+	ProjectStatusNameTemplate *template.Template
 }
 
 func (d *DefaultCommitStatusUpdater) UpdateCombined(repo models.Repo, pull models.PullRequest, status models.CommitStatus, cmdName command.Name) error {
-	src := fmt.Sprintf("%s/%s", d.StatusName, cmdName.String())
+	src, err := d.combinedSrc(cmdName)
+	if err != nil {
+		return err
+	}
 	var descripWords string
 	switch status {
 	case models.PendingCommitStatus:
@@ -61,7 +112,10 @@ func (d *DefaultCommitStatusUpdater) UpdateCombined(repo models.Repo, pull model
 }
 
 func (d *DefaultCommitStatusUpdater) UpdateCombinedCount(repo models.Repo, pull models.PullRequest, status models.CommitStatus, cmdName command.Name, numSuccess int, numTotal int) error {
-	src := fmt.Sprintf("%s/%s", d.StatusName, cmdName.String())
+	src, err := d.combinedSrc(cmdName)
+	if err != nil {
+		return err
+	}
 	cmdVerb := "unknown"
 
 	switch cmdName {
@@ -76,12 +130,28 @@ func (d *DefaultCommitStatusUpdater) UpdateCombinedCount(repo models.Repo, pull
 	return d.Client.UpdateStatus(repo, pull, status, src, fmt.Sprintf("%d/%d projects %s successfully.", numSuccess, numTotal, cmdVerb), "")
 }
 
+func (d *DefaultCommitStatusUpdater) UpdateCombinedNoProjects(repo models.Repo, pull models.PullRequest, cmdNames []command.Name) error {
+	for _, cmdName := range cmdNames {
+		if err := d.UpdateCombinedCount(repo, pull, models.SuccessCommitStatus, cmdName, 0, 0); err != nil {
+			return err
+		}
+		// All commands share the combined status so it only needs setting
+		// once.
+		if d.Granularity == CombinedStatusGranularity {
+			return nil
+		}
+	}
+	return nil
+}
+
 func (d *DefaultCommitStatusUpdater) UpdateProject(ctx command.ProjectContext, cmdName command.Name, status models.CommitStatus, url string) error {
-	projectID := ctx.ProjectName
-	if projectID == "" {
-		projectID = fmt.Sprintf("%s/%s", ctx.RepoRelDir, ctx.Workspace)
+	if d.Granularity != "" && d.Granularity != ProjectStatusGranularity {
+		return nil
+	}
+	src, err := d.projectSrc(cmdName, ctx.ProjectName, ctx.RepoRelDir, ctx.Workspace)
+	if err != nil {
+		return err
 	}
-	src := fmt.Sprintf("%s/%s: %s", d.StatusName, cmdName.String(), projectID)
 	var descripWords string
 	switch status {
 	case models.PendingCommitStatus:
@@ -93,5 +163,112 @@ func (d *DefaultCommitStatusUpdater) UpdateProject(ctx command.ProjectContext, c
 	}
 
 	descrip := fmt.Sprintf("%s %s", strings.Title(cmdName.String()), descripWords)
+	// Summarizing the plan lets reviewers triage it from the list of checks.
+	if status == models.SuccessCommitStatus && ctx.PlanStats != nil {
+		descrip = fmt.Sprintf("%s succeeded: %s", strings.Title(cmdName.String()), ctx.PlanStats)
+	}
+	// Heartbeats of long running commands show that they're still making
+	// progress.
+	if status == models.PendingCommitStatus && ctx.RunningFor > 0 {
+		descrip = fmt.Sprintf("%s in progress for %s", strings.Title(cmdName.String()), ctx.RunningFor.Round(time.Second))
+		if ctx.LastOutputLine != "" {
+			descrip = truncateStatusDescription(fmt.Sprintf("%s: %s", descrip, ctx.LastOutputLine))
+		}
+	}
 	return d.Client.UpdateStatus(ctx.BaseRepo, ctx.Pull, status, src, descrip, url)
 }
+
+// maxStatusDescriptionLen is the longest commit status description that
+// GitHub accepts.
+const maxStatusDescriptionLen = 140
+
+// truncateStatusDescription shortens descrip to maxStatusDescriptionLen
+// characters.
+func truncateStatusDescription(descrip string) string {
+	runes := []rune(descrip)
+	if len(runes) <= maxStatusDescriptionLen {
+		return descrip
+	}
+	return string(runes[:maxStatusDescriptionLen-3]) + "..."
+}
+
+// applyCountDescription matches the descriptions of the combined statuses
+// that UpdateCombinedCount sets for apply.
+var applyCountDescription = regexp.MustCompile(`^\d+/\d+ projects applied successfully\.$`)
+
+// IsApplyStatus returns true if the commit status with name and description
+// was set by apply. With CombinedStatusGranularity, every command sets the
+// same status so it's only an apply status if apply set it last.
+func (d *DefaultCommitStatusUpdater) IsApplyStatus(name string, description string) bool {
+	src, err := d.combinedSrc(command.Apply)
+	if err != nil {
+		return false
+	}
+	switch d.Granularity {
+	case CombinedStatusGranularity:
+		return name == src && (strings.HasPrefix(description, strings.Title(command.Apply.String())+" ") || applyCountDescription.MatchString(description))
+	case CommandStatusGranularity:
+		return name == src
+	}
+	if name == src {
+		return true
+	}
+	pattern, err := d.projectSrcPattern(command.Apply)
+	return err == nil && pattern.MatchString(name)
+}
+
+// projectSrc returns the name of the status of cmdName for a project.
+func (d *DefaultCommitStatusUpdater) projectSrc(cmdName command.Name, projectName string, dir string, workspace string) (string, error) {
+	projectID := projectName
+	if projectID == "" {
+		projectID = fmt.Sprintf("%s/%s", dir, workspace)
+	}
+	if d.ProjectStatusNameTemplate == nil {
+		return fmt.Sprintf("%s/%s: %s", d.StatusName, cmdName.String(), projectID), nil
+	}
+	return renderStatusName(d.ProjectStatusNameTemplate, StatusNameData{
+		StatusName:  d.StatusName,
+		Command:     cmdName.String(),
+		Project:     projectID,
+		ProjectName: projectName,
+		Dir:         dir,
+		Workspace:   workspace,
+	})
+}
+
+// projectSrcPattern returns a regexp that matches the names of the statuses
+// of cmdName for any project.
+func (d *DefaultCommitStatusUpdater) projectSrcPattern(cmdName command.Name) (*regexp.Regexp, error) {
+	// The placeholder can't be part of a name so it only marks where the
+	// project is.
+	const placeholder = "\x00"
+	src, err := d.projectSrc(cmdName, placeholder, placeholder, placeholder)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile("^" + strings.ReplaceAll(regexp.QuoteMeta(src), placeholder, ".*") + "$")
+}
+
+// combinedSrc returns the name of the status that represents all projects for
+// cmdName.
+func (d *DefaultCommitStatusUpdater) combinedSrc(cmdName command.Name) (string, error) {
+	data := StatusNameData{StatusName: d.StatusName}
+	if d.Granularity != CombinedStatusGranularity {
+		data.Command = cmdName.String()
+	}
+	if d.StatusNameTemplate != nil {
+		return renderStatusName(d.StatusNameTemplate, data)
+	}
+	if data.Command == "" {
+		return d.StatusName, nil
+	}
+	return fmt.Sprintf("%s/%s", d.StatusName, data.Command), nil
+}
+
+func renderStatusName(tmpl *template.Template, data StatusNameData) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", errors.Wrap(err, "rendering commit status name")
+	}
+	return buf.String(), nil
+}
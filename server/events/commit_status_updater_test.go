@@ -15,13 +15,17 @@ package events_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"text/template"
+	"time"
 
 	. "github.com/petergtz/pegomock"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/events/vcs/mocks/matchers"
 	. "github.com/runatlantis/atlantis/testing"
 )
 
@@ -242,6 +246,48 @@ func TestDefaultCommitStatusUpdater_UpdateProject(t *testing.T) {
 	}
 }
 
+// Test that the successful plan's status summarizes the plan.
+func TestDefaultCommitStatusUpdater_UpdateProjectPlanStats(t *testing.T) {
+	RegisterMockTestingT(t)
+	client := mocks.NewMockClient()
+	s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis"}
+	ctx := command.ProjectContext{
+		RepoRelDir: ".",
+		Workspace:  "default",
+		PlanStats:  &models.PlanSuccessStats{Add: 3, Change: 1, Changes: true},
+	}
+	Ok(t, s.UpdateProject(ctx, command.Plan, models.SuccessCommitStatus, "url"))
+	client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{}, models.SuccessCommitStatus, "atlantis/plan: ./default", "Plan succeeded: +3 ~1 -0", "url")
+
+	ctx.PlanStats = &models.PlanSuccessStats{}
+	Ok(t, s.UpdateProject(ctx, command.Plan, models.SuccessCommitStatus, "url"))
+	client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{}, models.SuccessCommitStatus, "atlantis/plan: ./default", "Plan succeeded: no changes", "url")
+}
+
+// Test that heartbeats of long running commands say how long they've been
+// running and their last line of output.
+func TestDefaultCommitStatusUpdater_UpdateProjectHeartbeat(t *testing.T) {
+	RegisterMockTestingT(t)
+	client := mocks.NewMockClient()
+	s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis"}
+	ctx := command.ProjectContext{
+		RepoRelDir: ".",
+		Workspace:  "default",
+		RunningFor: 5*time.Minute + 300*time.Millisecond,
+	}
+	Ok(t, s.UpdateProject(ctx, command.Apply, models.PendingCommitStatus, "url"))
+	client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{}, models.PendingCommitStatus, "atlantis/apply: ./default", "Apply in progress for 5m0s", "url")
+
+	ctx.LastOutputLine = "aws_db_instance.db: Still modifying... [5m0s elapsed]"
+	Ok(t, s.UpdateProject(ctx, command.Apply, models.PendingCommitStatus, "url"))
+	client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{}, models.PendingCommitStatus, "atlantis/apply: ./default", "Apply in progress for 5m0s: aws_db_instance.db: Still modifying... [5m0s elapsed]", "url")
+
+	// Descriptions are truncated to the length that GitHub accepts.
+	ctx.LastOutputLine = strings.Repeat("a", 200)
+	Ok(t, s.UpdateProject(ctx, command.Apply, models.PendingCommitStatus, "url"))
+	client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{}, models.PendingCommitStatus, "atlantis/apply: ./default", "Apply in progress for 5m0s: "+strings.Repeat("a", 109)+"...", "url")
+}
+
 // Test that we can set the status name.
 func TestDefaultCommitStatusUpdater_UpdateProjectCustomStatusName(t *testing.T) {
 	RegisterMockTestingT(t)
@@ -258,3 +304,120 @@ func TestDefaultCommitStatusUpdater_UpdateProjectCustomStatusName(t *testing.T)
 	client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{},
 		models.SuccessCommitStatus, "custom/apply: ./default", "Apply succeeded.", "url")
 }
+
+func TestDefaultCommitStatusUpdater_Granularity(t *testing.T) {
+	RegisterMockTestingT(t)
+	cases := []struct {
+		granularity      string
+		expCombinedSrc   string
+		expProjectUpdate bool
+	}{
+		{
+			granularity:      events.CombinedStatusGranularity,
+			expCombinedSrc:   "atlantis",
+			expProjectUpdate: false,
+		},
+		{
+			granularity:      events.CommandStatusGranularity,
+			expCombinedSrc:   "atlantis/plan",
+			expProjectUpdate: false,
+		},
+		{
+			granularity:      events.ProjectStatusGranularity,
+			expCombinedSrc:   "atlantis/plan",
+			expProjectUpdate: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.granularity, func(t *testing.T) {
+			client := mocks.NewMockClient()
+			s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis", Granularity: c.granularity}
+			err := s.UpdateCombinedCount(models.Repo{}, models.PullRequest{}, models.SuccessCommitStatus, command.Plan, 1, 1)
+			Ok(t, err)
+			client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{}, models.SuccessCommitStatus, c.expCombinedSrc, "1/1 projects planned successfully.", "")
+
+			err = s.UpdateProject(command.ProjectContext{RepoRelDir: ".", Workspace: "default"}, command.Plan, models.SuccessCommitStatus, "url")
+			Ok(t, err)
+			if c.expProjectUpdate {
+				client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{}, models.SuccessCommitStatus, "atlantis/plan: ./default", "Plan succeeded.", "url")
+			} else {
+				client.VerifyWasCalled(Never()).UpdateStatus(models.Repo{}, models.PullRequest{}, models.SuccessCommitStatus, "atlantis/plan: ./default", "Plan succeeded.", "url")
+			}
+		})
+	}
+}
+
+func TestDefaultCommitStatusUpdater_NameTemplates(t *testing.T) {
+	RegisterMockTestingT(t)
+	client := mocks.NewMockClient()
+	s := events.DefaultCommitStatusUpdater{
+		Client:                    client,
+		StatusName:                "atlantis",
+		StatusNameTemplate:        template.Must(template.New("").Parse("ci/{{ .StatusName }}-{{ .Command }}")),
+		ProjectStatusNameTemplate: template.Must(template.New("").Parse("ci/{{ .StatusName }}-{{ .Command }} ({{ .Dir }}, {{ .Workspace }})")),
+	}
+	err := s.UpdateCombined(models.Repo{}, models.PullRequest{}, models.PendingCommitStatus, command.Apply)
+	Ok(t, err)
+	client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{}, models.PendingCommitStatus, "ci/atlantis-apply", "Apply in progress...", "")
+
+	err = s.UpdateProject(command.ProjectContext{RepoRelDir: "dir", Workspace: "staging"}, command.Apply, models.PendingCommitStatus, "url")
+	Ok(t, err)
+	client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{}, models.PendingCommitStatus, "ci/atlantis-apply (dir, staging)", "Apply in progress...", "url")
+}
+
+func TestDefaultCommitStatusUpdater_IsApplyStatus(t *testing.T) {
+	nameTemplate := template.Must(template.New("").Parse("ci/{{ .StatusName }}-{{ .Command }}"))
+	projectNameTemplate := template.Must(template.New("").Parse("ci/{{ .StatusName }}-{{ .Command }} ({{ .Dir }}, {{ .Workspace }})"))
+	cases := []struct {
+		description string
+		updater     events.DefaultCommitStatusUpdater
+		name        string
+		statusDesc  string
+		exp         bool
+	}{
+		{"project apply", events.DefaultCommitStatusUpdater{}, "atlantis/apply: dir/default", "Apply failed.", true},
+		{"command apply", events.DefaultCommitStatusUpdater{}, "atlantis/apply", "0/2 projects applied successfully.", true},
+		{"project plan", events.DefaultCommitStatusUpdater{}, "atlantis/plan: dir/default", "Plan failed.", false},
+		{"command plan", events.DefaultCommitStatusUpdater{}, "atlantis/plan", "Plan failed.", false},
+		{"templated project apply", events.DefaultCommitStatusUpdater{StatusNameTemplate: nameTemplate, ProjectStatusNameTemplate: projectNameTemplate}, "ci/atlantis-apply (dir, default)", "Apply failed.", true},
+		{"templated command apply", events.DefaultCommitStatusUpdater{StatusNameTemplate: nameTemplate, ProjectStatusNameTemplate: projectNameTemplate}, "ci/atlantis-apply", "Apply failed.", true},
+		{"templated project plan", events.DefaultCommitStatusUpdater{StatusNameTemplate: nameTemplate, ProjectStatusNameTemplate: projectNameTemplate}, "ci/atlantis-plan (dir, default)", "Plan failed.", false},
+		{"default name with templates", events.DefaultCommitStatusUpdater{StatusNameTemplate: nameTemplate, ProjectStatusNameTemplate: projectNameTemplate}, "atlantis/apply", "Apply failed.", false},
+		{"command granularity apply", events.DefaultCommitStatusUpdater{Granularity: events.CommandStatusGranularity}, "atlantis/apply", "Apply in progress...", true},
+		{"command granularity project apply", events.DefaultCommitStatusUpdater{Granularity: events.CommandStatusGranularity}, "atlantis/apply: dir/default", "Apply failed.", false},
+		{"combined pending apply", events.DefaultCommitStatusUpdater{Granularity: events.CombinedStatusGranularity}, "atlantis", "Apply in progress...", true},
+		{"combined apply count", events.DefaultCommitStatusUpdater{Granularity: events.CombinedStatusGranularity}, "atlantis", "1/2 projects applied successfully.", true},
+		{"combined plan", events.DefaultCommitStatusUpdater{Granularity: events.CombinedStatusGranularity}, "atlantis", "Plan failed.", false},
+		{"combined plan count", events.DefaultCommitStatusUpdater{Granularity: events.CombinedStatusGranularity}, "atlantis", "1/2 projects planned successfully.", false},
+		{"templated combined apply", events.DefaultCommitStatusUpdater{Granularity: events.CombinedStatusGranularity, StatusNameTemplate: nameTemplate}, "ci/atlantis-", "Apply in progress...", true},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			c.updater.StatusName = "atlantis"
+			Equals(t, c.exp, c.updater.IsApplyStatus(c.name, c.statusDesc))
+		})
+	}
+}
+
+func TestDefaultCommitStatusUpdater_UpdateCombinedNoProjects(t *testing.T) {
+	RegisterMockTestingT(t)
+	cmdNames := []command.Name{command.Plan, command.PolicyCheck, command.Apply}
+
+	t.Run("sets a status per command", func(t *testing.T) {
+		client := mocks.NewMockClient()
+		s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis", Granularity: events.CommandStatusGranularity}
+		Ok(t, s.UpdateCombinedNoProjects(models.Repo{}, models.PullRequest{}, cmdNames))
+		client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{}, models.SuccessCommitStatus, "atlantis/plan", "0/0 projects planned successfully.", "")
+		client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{}, models.SuccessCommitStatus, "atlantis/policy_check", "0/0 projects policies checked successfully.", "")
+		client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{}, models.SuccessCommitStatus, "atlantis/apply", "0/0 projects applied successfully.", "")
+	})
+
+	t.Run("sets the combined status once", func(t *testing.T) {
+		client := mocks.NewMockClient()
+		s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis", Granularity: events.CombinedStatusGranularity}
+		Ok(t, s.UpdateCombinedNoProjects(models.Repo{}, models.PullRequest{}, cmdNames))
+		client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{}, models.SuccessCommitStatus, "atlantis", "0/0 projects planned successfully.", "")
+		client.VerifyWasCalledOnce().UpdateStatus(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyModelsCommitStatus(), AnyString(), AnyString(), AnyString())
+	})
+}
@@ -0,0 +1,226 @@
+package events
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"gopkg.in/yaml.v2"
+)
+
+// initConfigBranch is the branch that the generated repo config is pushed to.
+const initConfigBranch = "atlantis/init_config"
+
+// ConfigInitializer onboards repos that don't have a repo config yet.
+type ConfigInitializer interface {
+	// InitConfig opens a pull request into the base branch of ctx.Pull that
+	// adds an atlantis.yaml with a project for each Terraform root module in
+	// the repo, or updates the pull request if it's already open. It returns
+	// the pull request and the number of projects.
+	InitConfig(ctx *command.Context) (models.PullRequest, int, error)
+}
+
+// GitConfigInitializer implements ConfigInitializer by cloning the base
+// branch, generating the config from the Terraform dirs in it and pushing it
+// to a branch.
+type GitConfigInitializer struct {
+	GitCloner
+	VCSClient vcs.Client
+}
+
+func (g *GitConfigInitializer) InitConfig(ctx *command.Context) (models.PullRequest, int, error) {
+	pull := ctx.Pull
+	cloneDir, repo, cleanup, err := g.clone(ctx.Log, "init_config", pull.BaseRepo, "--depth=1", "--branch", pull.BaseBranch, "--single-branch")
+	defer cleanup()
+	if err != nil {
+		return models.PullRequest{}, 0, err
+	}
+	git := func(args ...string) error {
+		_, err := g.git(ctx.Log, cloneDir, "", []models.Repo{repo}, args...)
+		return err
+	}
+
+	configPath := filepath.Join(cloneDir, config.AtlantisYAMLFilename)
+	if _, err := os.Stat(configPath); err == nil {
+		return models.PullRequest{}, 0, fmt.Errorf("%s already has an %s", pull.BaseBranch, config.AtlantisYAMLFilename)
+	}
+	projects, err := findRootModules(cloneDir)
+	if err != nil {
+		return models.PullRequest{}, 0, err
+	}
+	if len(projects) == 0 {
+		return models.PullRequest{}, 0, fmt.Errorf("no Terraform files found on %s", pull.BaseBranch)
+	}
+	repoCfg, err := generateRepoConfig(projects)
+	if err != nil {
+		return models.PullRequest{}, 0, err
+	}
+	if err := os.WriteFile(configPath, repoCfg, 0600); err != nil {
+		return models.PullRequest{}, 0, errors.Wrapf(err, "writing %s", config.AtlantisYAMLFilename)
+	}
+
+	// The branch is recreated from the base branch every time so that the
+	// config matches the latest Terraform dirs.
+	msg := fmt.Sprintf("Add %s\n\nGenerated by atlantis init_config with a project for each Terraform root module.", config.AtlantisYAMLFilename)
+	for _, args := range [][]string{
+		{"checkout", "-B", initConfigBranch},
+		{"add", config.AtlantisYAMLFilename},
+		{"commit", "-m", msg},
+		{"push", "--force", "origin", initConfigBranch},
+	} {
+		if err := git(args...); err != nil {
+			return models.PullRequest{}, 0, err
+		}
+	}
+
+	link, err := g.VCSClient.MarkdownPullLink(pull)
+	if err != nil {
+		return models.PullRequest{}, 0, errors.Wrap(err, "getting link to pull request")
+	}
+	title := fmt.Sprintf("Add %s", config.AtlantisYAMLFilename)
+	body := fmt.Sprintf("Adds an `%s` with a project for each of the %d Terraform root modules in this repo, "+
+		"which @%s asked for in %s.\n\n"+
+		"Each project is autoplanned when its `.tf` files or the local modules it uses are modified. "+
+		"Review the projects before merging, see the [repo config docs](https://www.runatlantis.io/docs/repo-level-atlantis-yaml.html) for the other settings.",
+		config.AtlantisYAMLFilename, len(projects), ctx.User.Username, link)
+	initPull, err := g.VCSClient.CreateOrUpdatePull(pull.BaseRepo, initConfigBranch, pull.BaseBranch, title, body)
+	if err != nil {
+		return models.PullRequest{}, 0, errors.Wrap(err, "opening pull request")
+	}
+	return initPull, len(projects), nil
+}
+
+// rootModule is a Terraform root module found in a repo.
+type rootModule struct {
+	// Dir is relative to the repo root.
+	Dir string
+	// LocalModules are the dirs of the local modules that the root module
+	// uses, directly or through other modules, relative to the repo root.
+	LocalModules []string
+}
+
+// findRootModules returns the dirs in repoDir that have Terraform files and
+// aren't used as a local module by another dir, sorted by dir.
+func findRootModules(repoDir string) ([]rootModule, error) {
+	// moduleCalls are the local modules each dir with Terraform files calls.
+	moduleCalls := make(map[string][]string)
+	err := filepath.WalkDir(repoDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != repoDir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !tfconfig.IsModuleDir(path) {
+			return nil
+		}
+		relDir, err := filepath.Rel(repoDir, path)
+		if err != nil {
+			return err
+		}
+		// Files that can't be parsed are skipped, the module calls in the
+		// other files are still found.
+		module, _ := tfconfig.LoadModule(path)
+		var calls []string
+		for _, call := range module.ModuleCalls {
+			if strings.HasPrefix(call.Source, "./") || strings.HasPrefix(call.Source, "../") {
+				calls = append(calls, filepath.Join(relDir, call.Source))
+			}
+		}
+		moduleCalls[filepath.ToSlash(relDir)] = calls
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	called := make(map[string]bool)
+	for _, calls := range moduleCalls {
+		for _, c := range calls {
+			called[filepath.ToSlash(c)] = true
+		}
+	}
+	var roots []rootModule
+	for dir := range moduleCalls {
+		if called[dir] {
+			continue
+		}
+		roots = append(roots, rootModule{Dir: dir, LocalModules: localModules(dir, moduleCalls)})
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i].Dir < roots[j].Dir })
+	return roots, nil
+}
+
+// localModules returns the local modules that dir uses, directly or through
+// other modules, sorted.
+func localModules(dir string, moduleCalls map[string][]string) []string {
+	seen := map[string]bool{dir: true}
+	queue := []string{dir}
+	var modules []string
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, c := range moduleCalls[next] {
+			c = filepath.ToSlash(c)
+			if seen[c] {
+				continue
+			}
+			seen[c] = true
+			modules = append(modules, c)
+			queue = append(queue, c)
+		}
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// generatedRepoCfg is the atlantis.yaml written by init_config. Its fields
+// are in the order they're written in.
+type generatedRepoCfg struct {
+	Version  int                `yaml:"version"`
+	Projects []generatedProject `yaml:"projects"`
+}
+
+type generatedProject struct {
+	Dir      string `yaml:"dir"`
+	Autoplan struct {
+		WhenModified []string `yaml:"when_modified"`
+	} `yaml:"autoplan"`
+}
+
+// generateRepoConfig returns an atlantis.yaml with a project for each root
+// module that's autoplanned when the module's own files or the local modules
+// it uses are modified.
+func generateRepoConfig(roots []rootModule) ([]byte, error) {
+	cfg := generatedRepoCfg{Version: 3}
+	for _, root := range roots {
+		p := generatedProject{Dir: root.Dir}
+		p.Autoplan.WhenModified = []string{"*.tf*", ".terraform.lock.hcl"}
+		for _, m := range root.LocalModules {
+			rel, err := filepath.Rel(root.Dir, m)
+			if err != nil {
+				return nil, err
+			}
+			p.Autoplan.WhenModified = append(p.Autoplan.WhenModified, filepath.ToSlash(rel)+"/*.tf*")
+		}
+		cfg.Projects = append(cfg.Projects, p)
+	}
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	header := "# Generated by atlantis init_config.\n# See https://www.runatlantis.io/docs/repo-level-atlantis-yaml.html\n"
+	return append([]byte(header), out...), nil
+}
@@ -0,0 +1,142 @@
+package events_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/config"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	vcsmatchers "github.com/runatlantis/atlantis/server/events/vcs/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// Test that a project is generated for each root module, that modules used
+// by other dirs aren't projects and that the projects are autoplanned when
+// the local modules they use change.
+func TestGitConfigInitializer_InitConfig(t *testing.T) {
+	repoDir, cleanup := initRepo(t)
+	defer cleanup()
+	files := map[string]string{
+		"main.tf":                    "resource \"null_resource\" \"root\" {}\n",
+		"network/main.tf":            "module \"vpc\" {\n  source = \"../modules/vpc\"\n}\n",
+		"modules/vpc/main.tf":        "module \"subnet\" {\n  source = \"./subnet\"\n}\n",
+		"modules/vpc/subnet/main.tf": "resource \"null_resource\" \"subnet\" {}\n",
+		"apps/web/main.tf":           "module \"registry\" {\n  source = \"terraform-aws-modules/s3-bucket/aws\"\n}\n",
+		".terraform/modules/x/x.tf":  "resource \"null_resource\" \"ignored\" {}\n",
+		"docs/README.md":             "docs\n",
+	}
+	for path, contents := range files {
+		Ok(t, os.MkdirAll(filepath.Dir(filepath.Join(repoDir, path)), 0700))
+		writeFile(t, filepath.Join(repoDir, path), contents)
+	}
+	runCmd(t, repoDir, "git", "add", "-f", ".")
+	runCmd(t, repoDir, "git", "commit", "-m", "add terraform")
+
+	dataDir, cleanup2 := TempDir(t)
+	defer cleanup2()
+	RegisterMockTestingT(t)
+	vcsClient := vcsmocks.NewMockClient()
+	When(vcsClient.MarkdownPullLink(vcsmatchers.AnyModelsPullRequest())).ThenReturn("#1", nil)
+	When(vcsClient.CreateOrUpdatePull(vcsmatchers.AnyModelsRepo(), AnyString(), AnyString(), AnyString(), AnyString())).
+		ThenReturn(models.PullRequest{Num: 2, URL: "https://github.com/owner/repo/pull/2"}, nil)
+	initializer := &events.GitConfigInitializer{
+		VCSClient: vcsClient,
+		GitCloner: events.GitCloner{DataDir: dataDir},
+	}
+
+	ctx := initConfigCtx(t, repoDir)
+	pull, numProjects, err := initializer.InitConfig(ctx)
+	Ok(t, err)
+	Equals(t, "https://github.com/owner/repo/pull/2", pull.URL)
+	Equals(t, 3, numProjects)
+
+	Equals(t, `# Generated by atlantis init_config.
+# See https://www.runatlantis.io/docs/repo-level-atlantis-yaml.html
+version: 3
+projects:
+- dir: .
+  autoplan:
+    when_modified:
+    - '*.tf*'
+    - .terraform.lock.hcl
+- dir: apps/web
+  autoplan:
+    when_modified:
+    - '*.tf*'
+    - .terraform.lock.hcl
+- dir: network
+  autoplan:
+    when_modified:
+    - '*.tf*'
+    - .terraform.lock.hcl
+    - ../modules/vpc/*.tf*
+    - ../modules/vpc/subnet/*.tf*
+`, runCmd(t, repoDir, "git", "show", "atlantis/init_config:atlantis.yaml"))
+	vcsClient.VerifyWasCalledOnce().CreateOrUpdatePull(
+		ctx.Pull.BaseRepo,
+		"atlantis/init_config",
+		"master",
+		"Add atlantis.yaml",
+		"Adds an `atlantis.yaml` with a project for each of the 3 Terraform root modules in this repo, which @lkysow asked for in #1.\n\n"+
+			"Each project is autoplanned when its `.tf` files or the local modules it uses are modified. "+
+			"Review the projects before merging, see the [repo config docs](https://www.runatlantis.io/docs/repo-level-atlantis-yaml.html) for the other settings.",
+	)
+
+	// The generated config must be valid.
+	parser := &config.ParserValidator{}
+	runCmd(t, repoDir, "git", "checkout", "atlantis/init_config")
+	repoCfg, err := parser.ParseRepoCfg(repoDir, valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{AllowRepoCfg: true}), "github.com/owner/repo")
+	Ok(t, err)
+	Equals(t, 3, len(repoCfg.Projects))
+}
+
+// Test that repos that already have a repo config aren't changed.
+func TestGitConfigInitializer_InitConfigExists(t *testing.T) {
+	repoDir, cleanup := initRepo(t)
+	defer cleanup()
+	writeFile(t, filepath.Join(repoDir, "main.tf"), "resource \"null_resource\" \"root\" {}\n")
+	writeFile(t, filepath.Join(repoDir, "atlantis.yaml"), "version: 3\n")
+	runCmd(t, repoDir, "git", "add", ".")
+	runCmd(t, repoDir, "git", "commit", "-m", "add terraform")
+
+	dataDir, cleanup2 := TempDir(t)
+	defer cleanup2()
+	RegisterMockTestingT(t)
+	vcsClient := vcsmocks.NewMockClient()
+	initializer := &events.GitConfigInitializer{
+		VCSClient: vcsClient,
+		GitCloner: events.GitCloner{DataDir: dataDir},
+	}
+
+	_, _, err := initializer.InitConfig(initConfigCtx(t, repoDir))
+	ErrEquals(t, "master already has an atlantis.yaml", err)
+	vcsClient.VerifyWasCalled(Never()).CreateOrUpdatePull(vcsmatchers.AnyModelsRepo(), AnyString(), AnyString(), AnyString(), AnyString())
+}
+
+func initConfigCtx(t *testing.T, repoDir string) *command.Context {
+	repo := models.Repo{
+		FullName:          "owner/repo",
+		CloneURL:          fmt.Sprintf("file://%s", repoDir),
+		SanitizedCloneURL: fmt.Sprintf("file://%s", repoDir),
+	}
+	return &command.Context{
+		Log:      logging.NewNoopLogger(t),
+		User:     models.User{Username: "lkysow"},
+		HeadRepo: repo,
+		Pull: models.PullRequest{
+			Num:        1,
+			URL:        "https://github.com/owner/repo/pull/1",
+			HeadBranch: "feature",
+			BaseBranch: "master",
+			BaseRepo:   repo,
+		},
+	}
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/graph"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// graphExt is the extension of the file next to a plan that holds the URL
+// of the dependency graph that was rendered while planning.
+const graphExt = ".graph"
+
+// GraphURLGenerator generates URLs to the dependency graphs of plans.
+type GraphURLGenerator interface {
+	// GenerateProjectGraphURL returns the full URL to the dependency graph
+	// rendered by the job in ctx.
+	GenerateProjectGraphURL(ctx command.ProjectContext) (string, error)
+}
+
+// GraphKey returns the key in the graph store that the dependency graph
+// rendered by the job with jobID is stored under.
+func GraphKey(jobID string) string {
+	return path.Join("graphs", jobID+".svg")
+}
+
+// graph runs terraform graph for the project at absPath, renders the graph
+// as an SVG and stores it so that the plan comment can link to it.
+func (p *DefaultProjectCommandRunner) graph(ctx command.ProjectContext, extraArgs []string, absPath string, envs map[string]string) error {
+	if p.GraphStore == nil {
+		ctx.Log.Warn("skipping graph step since there's nowhere to store graphs")
+		return nil
+	}
+	out, err := p.GraphStepRunner.Run(ctx, extraArgs, absPath, envs)
+	if err != nil {
+		return err
+	}
+	// Skip anything Terraform printed before the graph, ex. warnings.
+	start := strings.Index(out, "digraph")
+	if start < 0 {
+		return errors.Errorf("terraform graph didn't output a graph: %s", strings.TrimSpace(out))
+	}
+	g, err := graph.ParseDOT(out[start:])
+	if err != nil {
+		return errors.Wrap(err, "parsing terraform graph")
+	}
+	if err := p.GraphStore.Put(GraphKey(ctx.JobID), graph.RenderSVG(g.Declared())); err != nil {
+		return errors.Wrap(err, "storing graph")
+	}
+	url, err := p.GraphURLGenerator.GenerateProjectGraphURL(ctx)
+	if err != nil {
+		return err
+	}
+	planPath := filepath.Join(absPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	return errors.Wrap(os.WriteFile(planPath+graphExt, []byte(url), 0600), "recording graph url")
+}
+
+// graphURL returns the URL of the dependency graph rendered while planning
+// the project at absPath, or an empty string if it wasn't rendered.
+func graphURL(ctx command.ProjectContext, absPath string) string {
+	planPath := filepath.Join(absPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	url, err := os.ReadFile(planPath + graphExt) // nolint: gosec
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ctx.Log.Warn("unable to read graph url: %s", err)
+		}
+		return ""
+	}
+	return string(url)
+}
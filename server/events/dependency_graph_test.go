@@ -0,0 +1,113 @@
+package events_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/artifacts"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type mockGraphURLGenerator struct{}
+
+func (m mockGraphURLGenerator) GenerateProjectGraphURL(ctx command.ProjectContext) (string, error) {
+	return "https://atlantis/jobs/" + ctx.JobID + "/graph.svg", nil
+}
+
+func TestDefaultProjectCommandRunner_PlanGraph(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	mockGraph := mocks.NewMockStepRunner()
+	storeDir, cleanupStore := TempDir(t)
+	defer cleanupStore()
+	store := &artifacts.FileStore{Dir: storeDir}
+	runner := &events.DefaultProjectCommandRunner{
+		Locker:            mockLocker,
+		LockURLGenerator:  mockURLGenerator{},
+		WorkingDir:        mockWorkingDir,
+		WorkingDirLocker:  events.NewDefaultWorkingDirLocker(),
+		GraphStepRunner:   mockGraph,
+		GraphStore:        store,
+		GraphURLGenerator: mockGraphURLGenerator{},
+	}
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Steps:      []valid.Step{{StepName: "graph"}},
+		Workspace:  "default",
+		RepoRelDir: ".",
+		JobID:      "1234",
+	}
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.Clone(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())).ThenReturn(tmp, false, nil)
+	When(mockLocker.TryLock(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsPullRequest(), matchers.AnyModelsUser(), AnyString(), matchers.AnyModelsProject())).
+		ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key", UnlockFn: func() error { return nil }}, nil)
+	When(mockGraph.Run(ctx, nil, tmp, map[string]string{})).ThenReturn(`Warning: deprecated attribute
+digraph {
+	subgraph "root" {
+		"[root] aws_instance.web (expand)" [label = "aws_instance.web", shape = "box"]
+		"[root] aws_vpc.main (expand)" [label = "aws_vpc.main", shape = "box"]
+		"[root] aws_instance.web (expand)" -> "[root] aws_vpc.main (expand)"
+		"[root] root" -> "[root] aws_instance.web (expand)"
+	}
+}
+`, nil)
+
+	res := runner.Plan(ctx)
+	Ok(t, res.Error)
+	Equals(t, "", res.Failure)
+	Equals(t, "https://atlantis/jobs/1234/graph.svg", res.PlanSuccess.GraphURL)
+	Equals(t, "", res.PlanSuccess.TerraformOutput)
+	svg, err := store.Get(events.GraphKey("1234"))
+	Ok(t, err)
+	Assert(t, strings.Contains(string(svg), "aws_instance.web"), "exp graph to be rendered, got %s", svg)
+	Assert(t, !strings.Contains(string(svg), "[root] root"), "exp internal nodes to be left out, got %s", svg)
+
+	// The graph isn't linked from plans that don't render it.
+	ctx.Steps = nil
+	res = runner.Plan(ctx)
+	Ok(t, res.Error)
+	Equals(t, "", res.PlanSuccess.GraphURL)
+}
+
+func TestDefaultProjectCommandRunner_PlanGraphErr(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	mockGraph := mocks.NewMockStepRunner()
+	storeDir, cleanupStore := TempDir(t)
+	defer cleanupStore()
+	runner := &events.DefaultProjectCommandRunner{
+		Locker:            mockLocker,
+		LockURLGenerator:  mockURLGenerator{},
+		WorkingDir:        mockWorkingDir,
+		WorkingDirLocker:  events.NewDefaultWorkingDirLocker(),
+		GraphStepRunner:   mockGraph,
+		GraphStore:        &artifacts.FileStore{Dir: storeDir},
+		GraphURLGenerator: mockGraphURLGenerator{},
+	}
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Steps:      []valid.Step{{StepName: "graph"}},
+		Workspace:  "default",
+		RepoRelDir: ".",
+		JobID:      "1234",
+	}
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.Clone(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())).ThenReturn(tmp, false, nil)
+	When(mockLocker.TryLock(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsPullRequest(), matchers.AnyModelsUser(), AnyString(), matchers.AnyModelsProject())).
+		ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key", UnlockFn: func() error { return nil }}, nil)
+	When(mockGraph.Run(ctx, nil, tmp, map[string]string{})).ThenReturn("Error: Invalid reference\n", nil)
+
+	res := runner.Plan(ctx)
+	ErrEquals(t, "terraform graph didn't output a graph: Error: Invalid reference\n", res.Error)
+}
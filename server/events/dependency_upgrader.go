@@ -0,0 +1,437 @@
+package events
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// dependencyUpgradeBranch is the branch that dependency upgrades are pushed
+// to.
+const dependencyUpgradeBranch = "atlantis/dependency-upgrades"
+
+// DependencyUpgrade is a provider or module that was upgraded in a root
+// module.
+type DependencyUpgrade struct {
+	// Dir is the root module, relative to the repo root.
+	Dir string
+	// Name is the source address of providers, ex.
+	// registry.terraform.io/hashicorp/aws, or module.<name> for modules.
+	Name string
+	// From is the locked version of providers and the version constraint of
+	// modules.
+	From string
+	// To is the version that was upgraded to.
+	To string
+	// Constraint is the new version constraint if the old one didn't allow
+	// To. Empty otherwise.
+	Constraint string
+}
+
+// DependencyUpgrader opens pull requests that upgrade the providers and
+// modules of repos.
+type DependencyUpgrader interface {
+	// Upgrade upgrades the providers and registry modules of each Terraform
+	// root module on the default branch of repo to their latest versions and
+	// opens a pull request with the changes, or updates the pull request if
+	// it's already open. It returns the upgrades and the pull request, which
+	// is nil if the pull request is already up to date or nothing was
+	// upgraded.
+	Upgrade(log logging.SimpleLogging, repo models.Repo) ([]DependencyUpgrade, *models.PullRequest, error)
+}
+
+// GitDependencyUpgrader implements DependencyUpgrader by cloning the default
+// branch, bumping the version constraints that don't allow the latest
+// versions, updating the lock files with terraform and pushing the changes
+// to a branch.
+type GitDependencyUpgrader struct {
+	GitCloner
+	VCSClient         vcs.Client
+	TerraformExecutor runtime.TerraformExec
+	Registry          TerraformRegistry
+	// Platforms are the platforms, ex. linux_amd64, that the lock files have
+	// checksums for.
+	Platforms []string
+}
+
+func (g *GitDependencyUpgrader) Upgrade(log logging.SimpleLogging, repo models.Repo) ([]DependencyUpgrade, *models.PullRequest, error) {
+	cloneDir, cloneRepo, cleanup, err := g.clone(log, "dependency-upgrades", repo, "--depth=1", "--single-branch")
+	defer cleanup()
+	if err != nil {
+		return nil, nil, err
+	}
+	git := func(args ...string) (string, error) {
+		out, err := g.git(log, cloneDir, "", []models.Repo{cloneRepo}, args...)
+		return strings.TrimSpace(out), err
+	}
+
+	defaultBranch, err := git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, nil, err
+	}
+	roots, err := findRootModules(cloneDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var upgrades []DependencyUpgrade
+	var files []string
+	for _, root := range roots {
+		rootUpgrades, rootFiles, err := g.upgradeRoot(log, cloneDir, root.Dir)
+		if err != nil {
+			// One broken root module shouldn't hold back the upgrades of the
+			// others.
+			log.Warn("not upgrading the dependencies of %s in %s: %s", root.Dir, repo.FullName, err)
+			continue
+		}
+		upgrades = append(upgrades, rootUpgrades...)
+		files = append(files, rootFiles...)
+	}
+	if len(upgrades) == 0 {
+		return nil, nil, nil
+	}
+
+	// The branch is recreated from the default branch every time so that it
+	// has the latest versions.
+	msg := fmt.Sprintf("Upgrade Terraform providers and modules\n\nUpgrades %d providers and modules to their latest versions.", len(upgrades))
+	for _, args := range [][]string{
+		{"checkout", "-B", dependencyUpgradeBranch},
+		append([]string{"add", "--"}, files...),
+		{"commit", "-m", msg},
+	} {
+		if _, err := git(args...); err != nil {
+			return nil, nil, err
+		}
+	}
+	// Pushing the same upgrades again would replan the pull request every
+	// time the job runs.
+	remote, err := git("ls-remote", "--heads", "origin", dependencyUpgradeBranch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if remote != "" {
+		if _, err := git("fetch", "--depth=1", "origin", dependencyUpgradeBranch); err != nil {
+			return nil, nil, err
+		}
+		diff, err := git("diff", "--name-only", "HEAD", "FETCH_HEAD")
+		if err != nil {
+			return nil, nil, err
+		}
+		if diff == "" {
+			return upgrades, nil, nil
+		}
+	}
+	if _, err := git("push", "--force", "origin", dependencyUpgradeBranch); err != nil {
+		return nil, nil, err
+	}
+
+	pull, err := g.VCSClient.CreateOrUpdatePull(repo, dependencyUpgradeBranch, defaultBranch, "Upgrade Terraform providers and modules", dependencyUpgradeBody(upgrades))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "opening pull request")
+	}
+	return upgrades, &pull, nil
+}
+
+// upgradeRoot upgrades the providers and registry modules of the root module
+// in dir and returns the upgrades and the files it changed, relative to the
+// repo root.
+func (g *GitDependencyUpgrader) upgradeRoot(log logging.SimpleLogging, cloneDir string, dir string) ([]DependencyUpgrade, []string, error) {
+	absDir := filepath.Join(cloneDir, dir)
+	module, diags := tfconfig.LoadModule(absDir)
+	if diags.HasErrors() {
+		return nil, nil, diags.Err()
+	}
+	var upgrades []DependencyUpgrade
+	changed := make(map[string]bool)
+
+	var names []string
+	for name := range module.ModuleCalls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		call := module.ModuleCalls[name]
+		// Registry modules without a constraint get the latest version.
+		if _, _, ok := parseRegistryModuleSource(call.Source); !ok || call.Version == "" {
+			continue
+		}
+		latest, err := g.Registry.LatestModuleVersion(call.Source)
+		if err != nil {
+			return nil, nil, err
+		}
+		constraint, err := version.NewConstraint(call.Version)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "parsing version constraint of module %s", name)
+		}
+		if constraint.Check(latest) {
+			continue
+		}
+		bumped, ok := bumpConstraint(call.Version, latest)
+		if !ok {
+			log.Warn("can't change version constraint %q of module %s in %s to allow %s", call.Version, name, dir, latest)
+			continue
+		}
+		if err := setModuleVersion(call.Pos.Filename, name, bumped); err != nil {
+			return nil, nil, err
+		}
+		changed[call.Pos.Filename] = true
+		upgrades = append(upgrades, DependencyUpgrade{Dir: dir, Name: "module." + name, From: call.Version, To: latest.String(), Constraint: bumped})
+	}
+
+	// Projects that use Terraform versions before 0.14 don't have a lock
+	// file so their providers can't be upgraded.
+	locked, err := ReadInventoryProviders(absDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	bumpedProviders := make(map[string]string)
+	outdated := false
+	for _, p := range locked {
+		latest, err := g.Registry.LatestProviderVersion(p.Source)
+		if err != nil {
+			return nil, nil, err
+		}
+		current, err := version.NewVersion(p.Version)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "parsing version of provider %s", p.Source)
+		}
+		if !latest.GreaterThan(current) {
+			continue
+		}
+		outdated = true
+		if p.Constraints == "" {
+			continue
+		}
+		constraint, err := version.NewConstraint(p.Constraints)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "parsing version constraint of provider %s", p.Source)
+		}
+		if constraint.Check(latest) {
+			continue
+		}
+		filename, bumped, err := g.bumpProviderConstraint(module, p.Source, latest)
+		if err != nil {
+			return nil, nil, err
+		}
+		if filename == "" {
+			log.Warn("can't change version constraint %q of provider %s in %s to allow %s", p.Constraints, p.Source, dir, latest)
+			continue
+		}
+		changed[filename] = true
+		bumpedProviders[p.Source] = bumped
+	}
+
+	if len(locked) > 0 && (outdated || len(upgrades) > 0) {
+		lockCmd := []string{"providers", "lock"}
+		for _, p := range g.Platforms {
+			lockCmd = append(lockCmd, "-platform="+p)
+		}
+		prjCtx := command.ProjectContext{Log: log, Workspace: DefaultWorkspace}
+		for _, args := range [][]string{{"init", "-upgrade", "-backend=false", "-input=false"}, lockCmd} {
+			if out, err := g.TerraformExecutor.RunCommandWithVersion(prjCtx, absDir, args, nil, nil, DefaultWorkspace); err != nil {
+				return nil, nil, errors.Wrapf(err, "running terraform %s: %s", args[0], out)
+			}
+		}
+		relocked, err := ReadInventoryProviders(absDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		from := make(map[string]string)
+		for _, p := range locked {
+			from[p.Source] = p.Version
+		}
+		for _, p := range relocked {
+			if p.Version == from[p.Source] {
+				continue
+			}
+			changed[filepath.Join(absDir, lockFileName)] = true
+			upgrades = append(upgrades, DependencyUpgrade{Dir: dir, Name: p.Source, From: from[p.Source], To: p.Version, Constraint: bumpedProviders[p.Source]})
+		}
+	}
+
+	var files []string
+	for f := range changed {
+		rel, err := filepath.Rel(cloneDir, f)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, filepath.ToSlash(rel))
+	}
+	sort.Strings(files)
+	return upgrades, files, nil
+}
+
+// bumpProviderConstraint changes the version constraint of the provider with
+// source address source in the required_providers of module so that it allows
+// latest. It returns the file it changed and the new constraint. The file is
+// empty if module doesn't constrain the provider or the constraint can't be
+// changed.
+func (g *GitDependencyUpgrader) bumpProviderConstraint(module *tfconfig.Module, source string, latest *version.Version) (string, string, error) {
+	for name, req := range module.RequiredProviders {
+		if providerSourceAddr(name, req.Source) != source || len(req.VersionConstraints) == 0 {
+			continue
+		}
+		bumped, ok := bumpConstraint(strings.Join(req.VersionConstraints, ", "), latest)
+		if !ok {
+			return "", "", nil
+		}
+		filename, err := setProviderVersion(module.Path, name, bumped)
+		return filename, bumped, err
+	}
+	return "", "", nil
+}
+
+// providerSourceAddr returns the fully qualified source address of the
+// provider with local name name and source address source, which can omit
+// the hostname or be empty for hashicorp providers.
+func providerSourceAddr(name string, source string) string {
+	if source == "" {
+		source = "hashicorp/" + name
+	}
+	if strings.Count(source, "/") == 1 {
+		source = defaultRegistryHost + "/" + source
+	}
+	return strings.ToLower(source)
+}
+
+// bumpConstraint returns a version constraint like constraint that allows
+// latest. Exact versions become latest and pessimistic constraints, ex.
+// "~> 3.1", keep their precision. ok is false for other constraints.
+func bumpConstraint(constraint string, latest *version.Version) (string, bool) {
+	constraint = strings.TrimSpace(constraint)
+	if strings.Contains(constraint, ",") {
+		return "", false
+	}
+	if strings.HasPrefix(constraint, "~>") {
+		precision := len(strings.Split(strings.TrimSpace(strings.TrimPrefix(constraint, "~>")), "."))
+		segments := latest.Segments()
+		if precision > len(segments) {
+			precision = len(segments)
+		}
+		parts := make([]string, precision)
+		for i := range parts {
+			parts[i] = fmt.Sprint(segments[i])
+		}
+		return "~> " + strings.Join(parts, "."), true
+	}
+	if strings.HasPrefix(constraint, "=") {
+		return "= " + latest.String(), true
+	}
+	if _, err := version.NewVersion(constraint); err == nil {
+		return latest.String(), true
+	}
+	return "", false
+}
+
+// setModuleVersion sets the version of the module call name in filename.
+func setModuleVersion(filename string, name string, constraint string) error {
+	f, err := parseHCLWriteFile(filename)
+	if err != nil {
+		return err
+	}
+	for _, block := range f.Body().Blocks() {
+		if block.Type() == "module" && len(block.Labels()) == 1 && block.Labels()[0] == name {
+			block.Body().SetAttributeValue("version", cty.StringVal(constraint))
+		}
+	}
+	return os.WriteFile(filename, f.Bytes(), 0600)
+}
+
+// setProviderVersion sets the version constraint of the provider with local
+// name name in the required_providers of the Terraform files in dir. It
+// returns the file it changed or an empty string if no file requires the
+// provider.
+func setProviderVersion(dir string, name string, constraint string) (string, error) {
+	filenames, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return "", err
+	}
+	for _, filename := range filenames {
+		f, err := parseHCLWriteFile(filename)
+		if err != nil {
+			return "", err
+		}
+		set := false
+		for _, tfBlock := range f.Body().Blocks() {
+			if tfBlock.Type() != "terraform" {
+				continue
+			}
+			for _, reqBlock := range tfBlock.Body().Blocks() {
+				if reqBlock.Type() != "required_providers" {
+					continue
+				}
+				if attr := reqBlock.Body().GetAttribute(name); attr != nil {
+					set = setVersionToken(attr.Expr().BuildTokens(nil), constraint) || set
+				}
+			}
+		}
+		if set {
+			return filename, os.WriteFile(filename, f.Bytes(), 0600)
+		}
+	}
+	return "", nil
+}
+
+// setVersionToken sets the string in tokens that's the version of a
+// required_providers entry, ex. { source = "hashicorp/aws", version = "~> 3.0" },
+// or the whole entry in the legacy form, ex. "~> 3.0". The tokens are shared
+// with the file so it's changed in place.
+func setVersionToken(tokens hclwrite.Tokens, constraint string) bool {
+	if len(tokens) == 3 && tokens[0].Type == hclsyntax.TokenOQuote && tokens[1].Type == hclsyntax.TokenQuotedLit {
+		tokens[1].Bytes = []byte(constraint)
+		return true
+	}
+	for i := 0; i+3 < len(tokens); i++ {
+		if tokens[i].Type == hclsyntax.TokenIdent && string(tokens[i].Bytes) == "version" &&
+			tokens[i+1].Type == hclsyntax.TokenEqual && tokens[i+2].Type == hclsyntax.TokenOQuote &&
+			tokens[i+3].Type == hclsyntax.TokenQuotedLit {
+			tokens[i+3].Bytes = []byte(constraint)
+			return true
+		}
+	}
+	return false
+}
+
+func parseHCLWriteFile(filename string) (*hclwrite.File, error) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	f, diags := hclwrite.ParseConfig(src, filepath.Base(filename), hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing %s: %s", filepath.Base(filename), diags.Error())
+	}
+	return f, nil
+}
+
+// dependencyUpgradeBody returns the description of the pull request that
+// makes upgrades.
+func dependencyUpgradeBody(upgrades []DependencyUpgrade) string {
+	var b strings.Builder
+	b.WriteString("Upgrades the Terraform providers and modules of this repo to their latest versions.\n\n")
+	b.WriteString("| Dir | Dependency | From | To |\n| --- | --- | --- | --- |\n")
+	for _, u := range upgrades {
+		to := "`" + u.To + "`"
+		if u.Constraint != "" {
+			to += fmt.Sprintf(" (constraint changed to `%s`)", u.Constraint)
+		}
+		fmt.Fprintf(&b, "| `%s` | `%s` | `%s` | %s |\n", u.Dir, u.Name, u.From, to)
+	}
+	b.WriteString("\nAtlantis plans the projects that this pull request modifies so the upgrades can be reviewed before they're merged. " +
+		"This pull request was opened by Atlantis and is updated when newer versions are released.")
+	return b.String()
+}
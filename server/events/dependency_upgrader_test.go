@@ -0,0 +1,136 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock"
+	tmocks "github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	tfmatchers "github.com/runatlantis/atlantis/server/core/terraform/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	vcsmatchers "github.com/runatlantis/atlantis/server/events/vcs/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeTerraformRegistry returns the latest versions it's set up with.
+type fakeTerraformRegistry struct {
+	providers map[string]string
+	modules   map[string]string
+}
+
+func (f *fakeTerraformRegistry) LatestProviderVersion(source string) (*version.Version, error) {
+	return version.NewVersion(f.providers[source])
+}
+
+func (f *fakeTerraformRegistry) LatestModuleVersion(source string) (*version.Version, error) {
+	return version.NewVersion(f.modules[source])
+}
+
+func TestGitDependencyUpgrader_Upgrade(t *testing.T) {
+	networkTF := `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 4.0"
+    }
+  }
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "~> 3.14"
+}
+`
+	awsLock := func(version string, constraints string) string {
+		return "provider \"registry.terraform.io/hashicorp/aws\" {\n  version     = \"" + version + "\"\n  constraints = \"" + constraints + "\"\n}\n"
+	}
+	repoDir, cleanup := initRepo(t)
+	defer cleanup()
+	files := map[string]string{
+		"network/main.tf":             networkTF,
+		"network/.terraform.lock.hcl": awsLock("4.67.0", "~> 4.0"),
+		"apps/main.tf":                "resource \"null_resource\" \"app\" {}\n",
+		"apps/.terraform.lock.hcl":    "provider \"registry.terraform.io/hashicorp/null\" {\n  version = \"3.2.1\"\n}\n",
+	}
+	for path, contents := range files {
+		Ok(t, os.MkdirAll(filepath.Dir(filepath.Join(repoDir, path)), 0700))
+		writeFile(t, filepath.Join(repoDir, path), contents)
+	}
+	runCmd(t, repoDir, "git", "add", "-f", ".")
+	runCmd(t, repoDir, "git", "commit", "-m", "add terraform")
+
+	dataDir, cleanup2 := TempDir(t)
+	defer cleanup2()
+	RegisterMockTestingT(t)
+	vcsClient := vcsmocks.NewMockClient()
+	When(vcsClient.CreateOrUpdatePull(vcsmatchers.AnyModelsRepo(), AnyString(), AnyString(), AnyString(), AnyString())).
+		ThenReturn(models.PullRequest{Num: 2, URL: "https://github.com/owner/repo/pull/2"}, nil)
+	tfClient := tmocks.NewMockClient()
+	When(tfClient.RunCommandWithVersion(matchers.AnyModelsProjectCommandContext(), AnyString(), tfmatchers.AnySliceOfString(), tfmatchers.AnyMapOfStringToString(), tfmatchers.AnyPtrToGoVersionVersion(), AnyString())).
+		Then(func(params []Param) ReturnValues {
+			dir, args := params[1].(string), params[2].([]string)
+			Equals(t, "network", filepath.Base(dir))
+			if args[0] == "init" {
+				Equals(t, []string{"init", "-upgrade", "-backend=false", "-input=false"}, args)
+			} else {
+				Equals(t, []string{"providers", "lock", "-platform=linux_amd64"}, args)
+				Ok(t, os.WriteFile(filepath.Join(dir, ".terraform.lock.hcl"), []byte(awsLock("5.31.0", "~> 5.31")), 0600))
+			}
+			return ReturnValues{"", nil}
+		})
+	registry := &fakeTerraformRegistry{
+		providers: map[string]string{
+			"registry.terraform.io/hashicorp/aws":  "5.31.0",
+			"registry.terraform.io/hashicorp/null": "3.2.1",
+		},
+		modules: map[string]string{"terraform-aws-modules/vpc/aws": "5.4.0"},
+	}
+	upgrader := &events.GitDependencyUpgrader{
+		VCSClient:         vcsClient,
+		TerraformExecutor: tfClient,
+		Registry:          registry,
+		Platforms:         []string{"linux_amd64"},
+		GitCloner:         events.GitCloner{DataDir: dataDir},
+	}
+	repo := models.Repo{
+		FullName:          "owner/repo",
+		CloneURL:          "file://" + repoDir,
+		SanitizedCloneURL: "file://" + repoDir,
+	}
+	logger := logging.NewNoopLogger(t)
+
+	upgrades, pull, err := upgrader.Upgrade(logger, repo)
+	Ok(t, err)
+	Equals(t, []events.DependencyUpgrade{
+		{Dir: "network", Name: "module.vpc", From: "~> 3.14", To: "5.4.0", Constraint: "~> 5.4"},
+		{Dir: "network", Name: "registry.terraform.io/hashicorp/aws", From: "4.67.0", To: "5.31.0", Constraint: "~> 5.31"},
+	}, upgrades)
+	Equals(t, 2, pull.Num)
+	mainTF := runCmd(t, repoDir, "git", "show", "atlantis/dependency-upgrades:network/main.tf")
+	Equals(t, strings.Replace(strings.Replace(networkTF, `"~> 4.0"`, `"~> 5.31"`, 1), `"~> 3.14"`, `"~> 5.4"`, 1), mainTF)
+	Equals(t, awsLock("5.31.0", "~> 5.31"), runCmd(t, repoDir, "git", "show", "atlantis/dependency-upgrades:network/.terraform.lock.hcl"))
+	_, _, _, title, body := vcsClient.VerifyWasCalledOnce().CreateOrUpdatePull(vcsmatchers.AnyModelsRepo(), EqString("atlantis/dependency-upgrades"), EqString("master"), AnyString(), AnyString()).GetCapturedArguments()
+	Equals(t, "Upgrade Terraform providers and modules", title)
+	Assert(t, strings.Contains(body, "| `network` | `registry.terraform.io/hashicorp/aws` | `4.67.0` | `5.31.0` (constraint changed to `~> 5.31`) |"), "got %q", body)
+
+	// The branch already has the upgrades so the pull request isn't updated.
+	upgrades, pull, err = upgrader.Upgrade(logger, repo)
+	Ok(t, err)
+	Equals(t, 2, len(upgrades))
+	Assert(t, pull == nil, "exp pull request to not be updated")
+	vcsClient.VerifyWasCalledOnce().CreateOrUpdatePull(vcsmatchers.AnyModelsRepo(), AnyString(), AnyString(), AnyString(), AnyString())
+
+	// Nothing is upgraded once the default branch has the latest versions.
+	runCmd(t, repoDir, "git", "merge", "--ff-only", "atlantis/dependency-upgrades")
+	upgrades, pull, err = upgrader.Upgrade(logger, repo)
+	Ok(t, err)
+	Equals(t, 0, len(upgrades))
+	Assert(t, pull == nil, "exp no pull request")
+}
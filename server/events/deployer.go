@@ -0,0 +1,89 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/jobs"
+)
+
+// Deployer tracks applies as deployments on the VCS host so that they show up
+// in its environments dashboards.
+type Deployer interface {
+	// StartDeployment creates an in progress deployment for the apply of
+	// ctx's project and returns its id, or 0 if the apply isn't tracked. It
+	// returns an error if the VCS host rejects the deployment, ex. because
+	// of the environment's protection rules, in which case the apply
+	// shouldn't run.
+	StartDeployment(ctx command.ProjectContext) (int64, error)
+	// FinishDeployment marks the deployment with id as succeeded or failed.
+	FinishDeployment(ctx command.ProjectContext, id int64, success bool)
+}
+
+// GithubDeployer implements Deployer with GitHub Deployments. Applies of repos
+// on other VCS hosts aren't tracked.
+type GithubDeployer struct {
+	Client vcs.GithubDeploymentsClient
+	// JobURLGenerator generates the links to the applies' output. If nil,
+	// deployments don't link to it.
+	JobURLGenerator jobs.ProjectJobURLGenerator
+}
+
+func (g *GithubDeployer) StartDeployment(ctx command.ProjectContext) (int64, error) {
+	repo := ctx.Pull.BaseRepo
+	if repo.VCSHost.Type != models.Github {
+		return 0, nil
+	}
+	env := deploymentEnvironment(ctx)
+	// The deployment is of the branch rather than the commit so that the
+	// environment's deployment branch rules apply to it.
+	id, err := g.Client.CreateDeployment(repo, ctx.Pull.HeadBranch, env, fmt.Sprintf("atlantis apply from pull request #%d", ctx.Pull.Num))
+	if err != nil {
+		return 0, errors.Wrapf(err, "creating GitHub deployment to environment %q", env)
+	}
+	if err := g.Client.UpdateDeploymentStatus(repo, id, "in_progress", g.logURL(ctx)); err != nil {
+		ctx.Log.Warn("unable to update status of GitHub deployment %d: %s", id, err)
+	}
+	return id, nil
+}
+
+func (g *GithubDeployer) FinishDeployment(ctx command.ProjectContext, id int64, success bool) {
+	if id == 0 {
+		return
+	}
+	state := "failure"
+	if success {
+		state = "success"
+	}
+	if err := g.Client.UpdateDeploymentStatus(ctx.Pull.BaseRepo, id, state, g.logURL(ctx)); err != nil {
+		ctx.Log.Warn("unable to update status of GitHub deployment %d: %s", id, err)
+	}
+}
+
+func (g *GithubDeployer) logURL(ctx command.ProjectContext) string {
+	if g.JobURLGenerator == nil || ctx.JobID == "" {
+		return ""
+	}
+	url, err := g.JobURLGenerator.GenerateProjectJobURL(ctx)
+	if err != nil {
+		ctx.Log.Warn("unable to generate job url for deployment: %s", err)
+	}
+	return url
+}
+
+// deploymentEnvironment returns the environment that ctx's project is
+// deployed to. It's the project's name, or its dir if it doesn't have one,
+// followed by the workspace unless it's the default workspace.
+func deploymentEnvironment(ctx command.ProjectContext) string {
+	env := ctx.ProjectName
+	if env == "" {
+		env = ctx.RepoRelDir
+	}
+	if ctx.Workspace != DefaultWorkspace {
+		env = fmt.Sprintf("%s/%s", env, ctx.Workspace)
+	}
+	return env
+}
@@ -0,0 +1,88 @@
+package events_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakeGithubDeploymentsClient struct {
+	// rejected are the environments that deployments are rejected to.
+	rejected map[string]bool
+	// calls are the calls made, in order.
+	calls []string
+}
+
+func (f *fakeGithubDeploymentsClient) CreateDeployment(repo models.Repo, ref string, environment string, description string) (int64, error) {
+	f.calls = append(f.calls, fmt.Sprintf("create %s %s %s", ref, environment, description))
+	if f.rejected[environment] {
+		return 0, errors.New("Branch \"feature\" is not allowed to deploy to production due to environment protection rules.")
+	}
+	return 7, nil
+}
+
+func (f *fakeGithubDeploymentsClient) UpdateDeploymentStatus(repo models.Repo, deploymentID int64, state string, logURL string) error {
+	f.calls = append(f.calls, fmt.Sprintf("status %d %s %s", deploymentID, state, logURL))
+	return nil
+}
+
+type fakeJobURLGenerator struct{}
+
+func (fakeJobURLGenerator) GenerateProjectJobURL(ctx command.ProjectContext) (string, error) {
+	return "https://atlantis/jobs/" + ctx.JobID, nil
+}
+
+func TestGithubDeployer(t *testing.T) {
+	newCtx := func(projectName string, workspace string, host models.VCSHostType) command.ProjectContext {
+		return command.ProjectContext{
+			Log: logging.NewNoopLogger(t),
+			Pull: models.PullRequest{
+				Num:        2,
+				HeadBranch: "feature",
+				BaseRepo:   models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Type: host}},
+			},
+			ProjectName: projectName,
+			RepoRelDir:  "network",
+			Workspace:   workspace,
+			JobID:       "1234",
+		}
+	}
+
+	t.Run("tracks the apply", func(t *testing.T) {
+		client := &fakeGithubDeploymentsClient{}
+		deployer := &events.GithubDeployer{Client: client, JobURLGenerator: fakeJobURLGenerator{}}
+		ctx := newCtx("", "staging", models.Github)
+		id, err := deployer.StartDeployment(ctx)
+		Ok(t, err)
+		Equals(t, int64(7), id)
+		deployer.FinishDeployment(ctx, id, true)
+		Equals(t, []string{
+			"create feature network/staging atlantis apply from pull request #2",
+			"status 7 in_progress https://atlantis/jobs/1234",
+			"status 7 success https://atlantis/jobs/1234",
+		}, client.calls)
+	})
+
+	t.Run("fails if the environment rejects the deployment", func(t *testing.T) {
+		client := &fakeGithubDeploymentsClient{rejected: map[string]bool{"production": true}}
+		deployer := &events.GithubDeployer{Client: client}
+		_, err := deployer.StartDeployment(newCtx("production", "default", models.Github))
+		ErrEquals(t, `creating GitHub deployment to environment "production": Branch "feature" is not allowed to deploy to production due to environment protection rules.`, err)
+	})
+
+	t.Run("doesn't track applies of other VCS hosts", func(t *testing.T) {
+		client := &fakeGithubDeploymentsClient{}
+		deployer := &events.GithubDeployer{Client: client}
+		ctx := newCtx("", "default", models.Gitlab)
+		id, err := deployer.StartDeployment(ctx)
+		Ok(t, err)
+		deployer.FinishDeployment(ctx, id, false)
+		Equals(t, 0, len(client.calls))
+	})
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// DryRunProject describes a project that a command would run for. It's used
+// to show what `atlantis plan --dry-run` would do without planning.
+type DryRunProject struct {
+	ProjectName string
+	RepoRelDir  string
+	Workspace   string
+	// Workflow is the name of the workflow the project uses.
+	Workflow string
+	// AutoplanEnabled is true if the project is planned automatically when
+	// the pull request is updated.
+	AutoplanEnabled bool
+	// ApplyRequirements must be met before the project can be applied.
+	ApplyRequirements []string
+	// TerraformVersion is empty if the server's default version is used.
+	TerraformVersion string
+}
+
+// NewDryRunProjects describes the projects that cmds would run for.
+func NewDryRunProjects(cmds []command.ProjectContext) []DryRunProject {
+	projects := make([]DryRunProject, 0, len(cmds))
+	for _, cmd := range cmds {
+		project := DryRunProject{
+			ProjectName:       cmd.ProjectName,
+			RepoRelDir:        cmd.RepoRelDir,
+			Workspace:         cmd.Workspace,
+			Workflow:          cmd.WorkflowName,
+			AutoplanEnabled:   cmd.AutoplanEnabled,
+			ApplyRequirements: cmd.ApplyRequirements,
+		}
+		if cmd.TerraformVersion != nil {
+			project.TerraformVersion = cmd.TerraformVersion.String()
+		}
+		projects = append(projects, project)
+	}
+	return projects
+}
+
+// RenderDryRun renders a comment listing the projects that cmdName would run
+// for.
+func RenderDryRun(cmdName command.Name, projects []DryRunProject) string {
+	if len(projects) == 0 {
+		return fmt.Sprintf("Dry run: `atlantis %s` wouldn't run for any projects.", cmdName.String())
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Dry run: `atlantis %s` would run for %d project(s):\n\n", cmdName.String(), len(projects))
+	b.WriteString("| Project | Dir | Workspace | Workflow | Autoplan | Apply Requirements | Terraform |\n")
+	b.WriteString("|---|---|---|---|---|---|---|\n")
+	for _, p := range projects {
+		autoplan := "no"
+		if p.AutoplanEnabled {
+			autoplan = "yes"
+		}
+		applyReqs := "none"
+		if len(p.ApplyRequirements) > 0 {
+			applyReqs = "`" + strings.Join(p.ApplyRequirements, "`, `") + "`"
+		}
+		tfVersion := "default"
+		if p.TerraformVersion != "" {
+			tfVersion = "`" + p.TerraformVersion + "`"
+		}
+		fmt.Fprintf(&b, "| %s | `%s` | `%s` | `%s` | %s | %s | %s |\n", p.ProjectName, p.RepoRelDir, p.Workspace, p.Workflow, autoplan, applyReqs, tfVersion)
+	}
+	b.WriteString("\nNo plans were run.")
+	return b.String()
+}
@@ -0,0 +1,38 @@
+package events_test
+
+import (
+	"testing"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestRenderDryRun(t *testing.T) {
+	Equals(t, "Dry run: `atlantis plan` wouldn't run for any projects.", events.RenderDryRun(command.Plan, events.NewDryRunProjects(nil)))
+
+	projects := events.NewDryRunProjects([]command.ProjectContext{
+		{
+			ProjectName:       "staging",
+			RepoRelDir:        "staging",
+			Workspace:         "default",
+			WorkflowName:      "custom",
+			AutoplanEnabled:   true,
+			ApplyRequirements: []string{"approved", "mergeable"},
+			TerraformVersion:  version.Must(version.NewVersion("1.3.2")),
+		},
+		{
+			RepoRelDir:   "production",
+			Workspace:    "prod",
+			WorkflowName: "default",
+		},
+	})
+	exp := "Dry run: `atlantis plan` would run for 2 project(s):\n\n" +
+		"| Project | Dir | Workspace | Workflow | Autoplan | Apply Requirements | Terraform |\n" +
+		"|---|---|---|---|---|---|---|\n" +
+		"| staging | `staging` | `default` | `custom` | yes | `approved`, `mergeable` | `1.3.2` |\n" +
+		"|  | `production` | `prod` | `default` | no | none | default |\n" +
+		"\nNo plans were run."
+	Equals(t, exp, events.RenderDryRun(command.Plan, projects))
+}
@@ -25,13 +25,16 @@ import (
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketserver"
+	"github.com/runatlantis/atlantis/server/events/vcs/gerrit"
 	"github.com/xanzy/go-gitlab"
 	"gopkg.in/go-playground/validator.v9"
 )
 
 const gitlabPullOpened = "opened"
+const gitlabPullMerged = "merged"
 const usagesCols = 90
 
 // PullCommand is a command to run on a pull request.
@@ -94,8 +97,33 @@ type CommentCommand struct {
 	Name command.Name
 	// AutoMergeDisabled is true if the command should not automerge after apply.
 	AutoMergeDisabled bool
+	// Force is true if an apply should go ahead even if the plan is older
+	// than the maximum plan age.
+	Force bool
+	// DestroyApproved is true if an apply should go ahead even if the plan
+	// destroys protected resources.
+	DestroyApproved bool
+	// ResourceChangesApproved is true if an apply should go ahead even if
+	// the plan changes more resources than allowed.
+	ResourceChangesApproved bool
+	// SHA is the commit that the plans to apply must have been created from.
+	// If empty, they must have been created from the pull request's head
+	// commit.
+	SHA string
+	// DryRun is true if a plan should only report the projects it would run
+	// for.
+	DryRun bool
+	// LockID is the ID of the Terraform state lock to remove for
+	// force_unlock_state.
+	LockID string
+	// Fix is true if fmt should push a commit that formats the files.
+	Fix bool
 	// Verbose is true if the command should output verbosely.
 	Verbose bool
+	// TerraformLogLevel is the level TF_LOG is set to for this command, ex.
+	// TRACE. If set, the Terraform and Atlantis logs are sent to the job
+	// output. If empty, TF_LOG isn't set.
+	TerraformLogLevel string
 	// Workspace is the name of the Terraform workspace to run the command in.
 	// If empty then the comment specified no workspace.
 	Workspace string
@@ -103,6 +131,9 @@ type CommentCommand struct {
 	// project specified in an atlantis.yaml file.
 	// If empty then the comment specified no project.
 	ProjectName string
+	// CommentID is the VCS host's ID for the comment this command came from.
+	// Will be 0 if the VCS host doesn't support reacting to comments.
+	CommentID int64
 }
 
 // IsForSpecificProject returns true if the command is for a specific dir, workspace
@@ -289,12 +320,37 @@ type EventParsing interface {
 	// ParseAzureDevopsRepo parses the response from the Azure DevOps API endpoint that
 	// returns a repo into the Atlantis model.
 	ParseAzureDevopsRepo(adRepo *azuredevops.GitRepository) (models.Repo, error)
+
+	// ParseGerritChangeEvent parses a change event from Gerrit's webhooks
+	// plugin, ex. patchset-created.
+	// pull is the parsed change.
+	// pullEventType is the type of event, for example opened/closed.
+	// baseRepo is the repo the change will be merged into.
+	// headRepo is the same as baseRepo since changes are pushed to it.
+	// user is the account that caused the event.
+	ParseGerritChangeEvent(body []byte) (
+		pull models.PullRequest, pullEventType models.PullRequestEventType,
+		baseRepo models.Repo, headRepo models.Repo, user models.User, err error)
+
+	// ParseGerritCommentEvent parses a comment-added event from Gerrit's
+	// webhooks plugin.
+	// pull is the parsed change.
+	// baseRepo is the repo the change will be merged into.
+	// headRepo is the same as baseRepo since changes are pushed to it.
+	// user is the comment author.
+	// comment is the comment without the line Gerrit adds before it.
+	ParseGerritCommentEvent(body []byte) (
+		pull models.PullRequest, baseRepo models.Repo, headRepo models.Repo,
+		user models.User, comment string, err error)
 }
 
 // EventParser parses VCS events.
 type EventParser struct {
-	GithubUser         string
-	GithubToken        string
+	GithubUser  string
+	GithubToken string
+	// GithubHosts are the credentials for GitHub hosts other than the one
+	// GithubUser and GithubToken are for, keyed by hostname.
+	GithubHosts        map[string]vcs.GithubUserCredentials
 	GitlabUser         string
 	GitlabToken        string
 	AllowDraftPRs      bool
@@ -303,12 +359,16 @@ type EventParser struct {
 	BitbucketServerURL string
 	AzureDevopsToken   string
 	AzureDevopsUser    string
+	GerritUser         string
+	GerritToken        string
+	GerritURL          string
 }
 
 func (e *EventParser) ParseAPIPlanRequest(vcsHostType models.VCSHostType, repoFullName string, cloneURL string) (models.Repo, error) {
 	switch vcsHostType {
 	case models.Github:
-		return models.NewRepo(vcsHostType, repoFullName, cloneURL, e.GithubUser, e.GithubToken)
+		user, token := e.githubCredentials(cloneURL)
+		return models.NewRepo(vcsHostType, repoFullName, cloneURL, user, token)
 	case models.Gitlab:
 		return models.NewRepo(vcsHostType, repoFullName, cloneURL, e.GitlabUser, e.GitlabToken)
 	}
@@ -390,6 +450,7 @@ func (e *EventParser) parseCommonBitbucketCloudEventData(event bitbucketcloud.Co
 		BaseBranch: *event.PullRequest.Destination.Branch.Name,
 		Author:     *event.Actor.AccountID,
 		State:      prState,
+		Merged:     *event.PullRequest.State == "MERGED",
 		BaseRepo:   baseRepo,
 	}
 	user = models.User{
@@ -543,6 +604,7 @@ func (e *EventParser) ParseGithubPull(pull *github.PullRequest) (pullModel model
 		URL:        url,
 		Num:        num,
 		State:      pullState,
+		Merged:     pull.GetMerged(),
 		BaseRepo:   baseRepo,
 		BaseBranch: baseBranch,
 	}
@@ -553,7 +615,19 @@ func (e *EventParser) ParseGithubPull(pull *github.PullRequest) (pullModel model
 // returns a repo into the Atlantis model.
 // See EventParsing for return value docs.
 func (e *EventParser) ParseGithubRepo(ghRepo *github.Repository) (models.Repo, error) {
-	return models.NewRepo(models.Github, ghRepo.GetFullName(), ghRepo.GetCloneURL(), e.GithubUser, e.GithubToken)
+	user, token := e.githubCredentials(ghRepo.GetCloneURL())
+	return models.NewRepo(models.Github, ghRepo.GetFullName(), ghRepo.GetCloneURL(), user, token)
+}
+
+// githubCredentials returns the user and token for the GitHub host of
+// cloneURL.
+func (e *EventParser) githubCredentials(cloneURL string) (string, string) {
+	if u, err := url.Parse(cloneURL); err == nil {
+		if creds, ok := e.GithubHosts[u.Hostname()]; ok {
+			return creds.User, creds.Token
+		}
+	}
+	return e.GithubUser, e.GithubToken
 }
 
 // ParseGitlabMergeRequestUpdateEvent dives deeper into Gitlab merge request update events
@@ -595,7 +669,7 @@ func (e *EventParser) ParseGitlabMergeRequestEvent(event gitlab.MergeEvent) (pul
 		modelState = models.OpenPullState
 	}
 	// GitLab also has a "merged" state, but we map that to Closed so we don't
-	// need to check for it.
+	// need to check for it. Merged records it instead.
 
 	baseRepo, err = models.NewRepo(models.Gitlab, event.Project.PathWithNamespace, event.Project.GitHTTPURL, e.GitlabUser, e.GitlabToken)
 	if err != nil {
@@ -614,6 +688,7 @@ func (e *EventParser) ParseGitlabMergeRequestEvent(event gitlab.MergeEvent) (pul
 		HeadBranch: event.ObjectAttributes.SourceBranch,
 		BaseBranch: event.ObjectAttributes.TargetBranch,
 		State:      modelState,
+		Merged:     event.ObjectAttributes.State == gitlabPullMerged,
 		BaseRepo:   baseRepo,
 	}
 
@@ -675,7 +750,7 @@ func (e *EventParser) ParseGitlabMergeRequest(mr *gitlab.MergeRequest, baseRepo
 		pullState = models.OpenPullState
 	}
 	// GitLab also has a "merged" state, but we map that to Closed so we don't
-	// need to check for it.
+	// need to check for it. Merged records it instead.
 
 	return models.PullRequest{
 		URL:        mr.WebURL,
@@ -685,6 +760,7 @@ func (e *EventParser) ParseGitlabMergeRequest(mr *gitlab.MergeRequest, baseRepo
 		HeadBranch: mr.SourceBranch,
 		BaseBranch: mr.TargetBranch,
 		State:      pullState,
+		Merged:     mr.State == gitlabPullMerged,
 		BaseRepo:   baseRepo,
 	}
 }
@@ -769,6 +845,7 @@ func (e *EventParser) parseCommonBitbucketServerEventData(event bitbucketserver.
 		BaseBranch: *event.PullRequest.ToRef.DisplayID,
 		Author:     *event.Actor.Username,
 		State:      prState,
+		Merged:     *event.PullRequest.State == "MERGED",
 		BaseRepo:   baseRepo,
 	}
 	user = models.User{
@@ -892,6 +969,7 @@ func (e *EventParser) ParseAzureDevopsPull(pull *azuredevops.GitPullRequest) (pu
 		URL:        url,
 		Num:        num,
 		State:      pullState,
+		Merged:     *pull.Status == azuredevops.PullCompleted.String(),
 		BaseRepo:   baseRepo,
 		BaseBranch: strings.Replace(baseBranch, "refs/heads/", "", 1),
 	}
@@ -944,3 +1022,122 @@ func (e *EventParser) ParseAzureDevopsRepo(adRepo *azuredevops.GitRepository) (m
 	fullName := fmt.Sprintf("%s/%s/%s", owner, project, repo)
 	return models.NewRepo(models.AzureDevops, fullName, cloneURL, e.AzureDevopsUser, e.AzureDevopsToken)
 }
+
+// ParseGerritChangeEvent parses a change event from Gerrit's webhooks
+// plugin.
+// See EventParsing for return value docs.
+func (e *EventParser) ParseGerritChangeEvent(body []byte) (pull models.PullRequest, pullEventType models.PullRequestEventType, baseRepo models.Repo, headRepo models.Repo, user models.User, err error) {
+	var event gerrit.ChangeEvent
+	if err = json.Unmarshal(body, &event); err != nil {
+		err = errors.Wrap(err, "parsing json")
+		return
+	}
+	if err = validator.New().Struct(event); err != nil {
+		err = errors.Wrapf(err, "API response %q was missing fields", string(body))
+		return
+	}
+
+	var actor *gerrit.Account
+	switch *event.Type {
+	case gerrit.PatchsetCreatedEvent:
+		actor = event.Uploader
+		pullEventType = models.UpdatedPullEvent
+		if *event.PatchSet.Number == 1 {
+			pullEventType = models.OpenedPullEvent
+		}
+	case gerrit.ChangeMergedEvent:
+		actor = event.Submitter
+		pullEventType = models.ClosedPullEvent
+	case gerrit.ChangeAbandonedEvent:
+		actor = event.Abandoner
+		pullEventType = models.ClosedPullEvent
+	case gerrit.ChangeDeletedEvent:
+		actor = event.Deleter
+		pullEventType = models.ClosedPullEvent
+	default:
+		pullEventType = models.OtherPullEvent
+	}
+	if actor == nil || actor.Username == nil {
+		// Fall back to the change owner for events without an actor.
+		actor = event.Change.Owner
+	}
+
+	pull, baseRepo, err = e.parseGerritChange(event.Change, event.PatchSet)
+	if err != nil {
+		return
+	}
+	headRepo = baseRepo
+	user = models.User{Username: *actor.Username}
+	return
+}
+
+// ParseGerritCommentEvent parses a comment-added event from Gerrit's
+// webhooks plugin.
+// See EventParsing for return value docs.
+func (e *EventParser) ParseGerritCommentEvent(body []byte) (pull models.PullRequest, baseRepo models.Repo, headRepo models.Repo, user models.User, comment string, err error) {
+	var event gerrit.CommentEvent
+	if err = json.Unmarshal(body, &event); err != nil {
+		err = errors.Wrap(err, "parsing json")
+		return
+	}
+	if err = validator.New().Struct(event); err != nil {
+		err = errors.Wrapf(err, "API response %q was missing fields", string(body))
+		return
+	}
+	pull, baseRepo, err = e.parseGerritChange(event.Change, event.PatchSet)
+	if err != nil {
+		return
+	}
+	headRepo = baseRepo
+	user = models.User{Username: *event.Author.Username}
+
+	// Gerrit starts comments with a line like "Patch Set 3: Code-Review+1"
+	// that lists any votes.
+	comment = *event.Comment
+	if strings.HasPrefix(comment, "Patch Set ") {
+		comment = ""
+		if i := strings.Index(*event.Comment, "\n"); i >= 0 {
+			comment = (*event.Comment)[i+1:]
+		}
+	}
+	comment = strings.TrimSpace(comment)
+	return
+}
+
+func (e *EventParser) parseGerritChange(change *gerrit.Change, patchSet *gerrit.PatchSet) (pull models.PullRequest, baseRepo models.Repo, err error) {
+	var prState models.PullRequestState
+	switch *change.Status {
+	case "NEW":
+		prState = models.OpenPullState
+	case "MERGED", "ABANDONED":
+		prState = models.ClosedPullState
+	default:
+		err = fmt.Errorf("unable to determine change state from %q–this is a bug", *change.Status)
+		return
+	}
+
+	gerritURL := strings.TrimRight(e.GerritURL, "/")
+	// Gerrit serves authenticated clones under /a/.
+	cloneURL := fmt.Sprintf("%s/a/%s", gerritURL, *change.Project)
+	baseRepo, err = models.NewRepo(models.Gerrit, *change.Project, cloneURL, e.GerritUser, e.GerritToken)
+	if err != nil {
+		return
+	}
+
+	pullURL := fmt.Sprintf("%s/c/%s/+/%d", gerritURL, *change.Project, *change.Number)
+	if change.URL != nil {
+		pullURL = *change.URL
+	}
+	pull = models.PullRequest{
+		Num:        *change.Number,
+		HeadCommit: *patchSet.Revision,
+		URL:        pullURL,
+		HeadBranch: *patchSet.Ref,
+		BaseBranch: *change.Branch,
+		Author:     *change.Owner.Username,
+		State:      prState,
+		Merged:     *change.Status == "MERGED",
+		BaseRepo:   baseRepo,
+	}
+	return
+}
@@ -0,0 +1,111 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+func NewFmtCommandRunner(
+	vcsClient vcs.Client,
+	formatter Formatter,
+	allowFix bool,
+	autoplanFix bool,
+) *FmtCommandRunner {
+	return &FmtCommandRunner{
+		vcsClient:   vcsClient,
+		formatter:   formatter,
+		allowFix:    allowFix,
+		autoplanFix: autoplanFix,
+	}
+}
+
+// FmtCommandRunner checks the formatting of the Terraform files in a pull
+// request with terraform fmt and comments with the diff of the files that
+// aren't formatted.
+type FmtCommandRunner struct {
+	vcsClient vcs.Client
+	formatter Formatter
+	// allowFix is whether atlantis fmt --fix can push commits to pull
+	// requests.
+	allowFix bool
+	// autoplanFix is whether a commit that formats the files is pushed when
+	// they're checked before autoplanning.
+	autoplanFix bool
+}
+
+func (f *FmtCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
+	var diff string
+	var err error
+	switch {
+	case cmd.Fix && !f.allowFix:
+		err = errors.New("pushing formatting fixes isn't enabled on this Atlantis server, run terraform fmt -recursive and push the changes")
+	case cmd.Fix && isFork(ctx):
+		err = errForkFix
+	default:
+		diff, err = f.formatter.Fmt(ctx, cmd.Fix)
+		if err != nil {
+			ctx.Log.Err("running terraform fmt: %s", err)
+		}
+	}
+
+	var msg string
+	switch {
+	case err != nil:
+		msg = fmt.Sprintf("**%s Error**\n```\n%s\n```", command.Fmt.TitleString(), err)
+	case diff == "":
+		msg = "All Terraform files are formatted."
+	default:
+		msg = f.diffComment(diff, cmd.Fix)
+	}
+	f.comment(ctx, msg)
+}
+
+// Autoplan checks the formatting of the Terraform files before the pull
+// request is autoplanned and comments if any aren't formatted. It returns
+// true if it pushed a commit that formats them, in which case that commit is
+// autoplanned instead.
+func (f *FmtCommandRunner) Autoplan(ctx *command.Context) bool {
+	fix := f.autoplanFix && !isFork(ctx)
+	diff, err := f.formatter.Fmt(ctx, fix)
+	if err != nil {
+		// Errors, ex. invalid syntax, are reported by the plan.
+		ctx.Log.Warn("unable to check the formatting of the Terraform files: %s", err)
+		return false
+	}
+	if diff == "" {
+		return false
+	}
+	f.comment(ctx, f.diffComment(diff, fix))
+	return fix
+}
+
+// errForkFix is returned when a fix is asked for on a pull request from a
+// fork, whose branch Atlantis usually can't push to.
+var errForkFix = errors.New("can't push formatting fixes to pull requests from forks, run terraform fmt -recursive and push the changes")
+
+// isFork returns true if ctx.Pull is from a fork.
+func isFork(ctx *command.Context) bool {
+	return ctx.HeadRepo.FullName != ctx.Pull.BaseRepo.FullName
+}
+
+// diffComment returns the comment with the diff of the files that aren't
+// formatted.
+func (f *FmtCommandRunner) diffComment(diff string, fixed bool) string {
+	if fixed {
+		return fmt.Sprintf("Pushed a commit that formats these Terraform files:\n```diff\n%s\n```", diff)
+	}
+	fix := "run `terraform fmt -recursive` and push the changes"
+	if f.allowFix {
+		fix += " or comment `atlantis fmt --fix`"
+	}
+	return fmt.Sprintf("**%s Failed**: these Terraform files aren't formatted:\n```diff\n%s\n```\nTo format them, %s.", command.Fmt.TitleString(), diff, fix)
+}
+
+func (f *FmtCommandRunner) comment(ctx *command.Context, msg string) {
+	if err := f.vcsClient.CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, msg, command.Fmt.String()); err != nil {
+		ctx.Log.Err("unable to comment: %s", err)
+	}
+}
@@ -0,0 +1,163 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	vcsmatchers "github.com/runatlantis/atlantis/server/events/vcs/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeFormatter returns diff from Fmt and records whether it was asked to fix
+// the files.
+type fakeFormatter struct {
+	diff  string
+	err   error
+	fixed bool
+}
+
+func (f *fakeFormatter) Fmt(_ *command.Context, fix bool) (string, error) {
+	f.fixed = fix
+	return f.diff, f.err
+}
+
+func TestFmtCommandRunner_Run(t *testing.T) {
+	cases := []struct {
+		description string
+		diff        string
+		err         error
+		allowFix    bool
+		fork        bool
+		fix         bool
+		expFixed    bool
+		expComment  string
+	}{
+		{
+			description: "formatted",
+			expComment:  "All Terraform files are formatted.",
+		},
+		{
+			description: "not formatted",
+			diff:        "main.tf",
+			expComment:  "**Fmt Failed**: these Terraform files aren't formatted:\n```diff\nmain.tf\n```\nTo format them, run `terraform fmt -recursive` and push the changes.",
+		},
+		{
+			description: "not formatted with fix allowed",
+			diff:        "main.tf",
+			allowFix:    true,
+			expComment:  "**Fmt Failed**: these Terraform files aren't formatted:\n```diff\nmain.tf\n```\nTo format them, run `terraform fmt -recursive` and push the changes or comment `atlantis fmt --fix`.",
+		},
+		{
+			description: "fix",
+			diff:        "main.tf",
+			allowFix:    true,
+			fix:         true,
+			expFixed:    true,
+			expComment:  "Pushed a commit that formats these Terraform files:\n```diff\nmain.tf\n```",
+		},
+		{
+			description: "fix not allowed",
+			fix:         true,
+			expComment:  "**Fmt Error**\n```\npushing formatting fixes isn't enabled on this Atlantis server, run terraform fmt -recursive and push the changes\n```",
+		},
+		{
+			description: "fix fork",
+			allowFix:    true,
+			fork:        true,
+			fix:         true,
+			expComment:  "**Fmt Error**\n```\ncan't push formatting fixes to pull requests from forks, run terraform fmt -recursive and push the changes\n```",
+		},
+		{
+			description: "error",
+			err:         errors.New("Error: Invalid block definition"),
+			expComment:  "**Fmt Error**\n```\nError: Invalid block definition\n```",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			vcsClient := vcsmocks.NewMockClient()
+			formatter := &fakeFormatter{diff: c.diff, err: c.err}
+			runner := events.NewFmtCommandRunner(vcsClient, formatter, c.allowFix, false)
+
+			ctx := fmtRunnerCtx(t, c.fork)
+			runner.Run(ctx, &events.CommentCommand{Name: command.Fmt, Fix: c.fix})
+			Equals(t, c.expFixed, formatter.fixed)
+			vcsClient.VerifyWasCalledOnce().CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, c.expComment, "fmt")
+		})
+	}
+}
+
+func TestFmtCommandRunner_Autoplan(t *testing.T) {
+	cases := []struct {
+		description string
+		diff        string
+		autoplanFix bool
+		fork        bool
+		expPushed   bool
+		expComment  string
+	}{
+		{
+			description: "formatted",
+			autoplanFix: true,
+		},
+		{
+			description: "check",
+			diff:        "main.tf",
+			expComment:  "**Fmt Failed**: these Terraform files aren't formatted:\n```diff\nmain.tf\n```\nTo format them, run `terraform fmt -recursive` and push the changes.",
+		},
+		{
+			description: "fix",
+			diff:        "main.tf",
+			autoplanFix: true,
+			expPushed:   true,
+			expComment:  "Pushed a commit that formats these Terraform files:\n```diff\nmain.tf\n```",
+		},
+		{
+			description: "fix fork",
+			diff:        "main.tf",
+			autoplanFix: true,
+			fork:        true,
+			expComment:  "**Fmt Failed**: these Terraform files aren't formatted:\n```diff\nmain.tf\n```\nTo format them, run `terraform fmt -recursive` and push the changes.",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			vcsClient := vcsmocks.NewMockClient()
+			formatter := &fakeFormatter{diff: c.diff}
+			runner := events.NewFmtCommandRunner(vcsClient, formatter, false, c.autoplanFix)
+
+			ctx := fmtRunnerCtx(t, c.fork)
+			Equals(t, c.expPushed, runner.Autoplan(ctx))
+			if c.expComment == "" {
+				vcsClient.VerifyWasCalled(Never()).CreateComment(vcsmatchers.AnyModelsRepo(), AnyInt(), AnyString(), AnyString())
+			} else {
+				vcsClient.VerifyWasCalledOnce().CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, c.expComment, "fmt")
+			}
+		})
+	}
+}
+
+func fmtRunnerCtx(t *testing.T, fork bool) *command.Context {
+	baseRepo := models.Repo{FullName: "owner/repo"}
+	headRepo := baseRepo
+	if fork {
+		headRepo = models.Repo{FullName: "contributor/repo"}
+	}
+	return &command.Context{
+		Log:      logging.NewNoopLogger(t),
+		User:     models.User{Username: "lkysow"},
+		HeadRepo: headRepo,
+		Pull: models.PullRequest{
+			Num:      1,
+			BaseRepo: baseRepo,
+		},
+	}
+}
@@ -0,0 +1,88 @@
+package events
+
+import (
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// ForcePushedComment is commented on pull requests whose plans were discarded
+// because their branch was force-pushed.
+const ForcePushedComment = "The branch of this pull request was force-pushed so its previous plans were discarded." +
+	" They were made from commits that are no longer on the branch. Run `atlantis plan` to plan the current commit."
+
+// ForcePushInvalidator discards the plans of pull requests whose branch was
+// force-pushed. Workspaces that aren't re-planned after a push keep their old
+// plans, which could otherwise be applied even though they were made from
+// commits that aren't part of the pull request anymore.
+type ForcePushInvalidator struct {
+	WorkingDir        WorkingDir
+	WorkingDirLocker  WorkingDirLocker
+	PendingPlanFinder PendingPlanFinder
+	Backend           locking.Backend
+	VCSClient         vcs.Client
+}
+
+// Invalidate discards pull's plans and comments on it if pull's branch was
+// force-pushed since the workspaces of its plans were cloned. status is the
+// pull's current status. It returns true if the plans were discarded. The
+// locks of the pull's projects are kept.
+func (f *ForcePushInvalidator) Invalidate(log logging.SimpleLogging, pull models.PullRequest, status *models.PullStatus) (bool, error) {
+	if status == nil {
+		return false, nil
+	}
+	var planned []models.ProjectStatus
+	for _, p := range status.Projects {
+		switch p.Status {
+		case models.ErroredPlanStatus, models.AppliedPlanStatus, models.DiscardedPlanStatus:
+		default:
+			planned = append(planned, p)
+		}
+	}
+	if len(planned) == 0 {
+		return false, nil
+	}
+
+	unlock, err := f.WorkingDirLocker.TryLockPull(pull.BaseRepo.FullName, pull.Num)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	forcePushed := false
+	checked := make(map[string]bool)
+	for _, p := range planned {
+		if checked[p.Workspace] {
+			continue
+		}
+		checked[p.Workspace] = true
+		if forcePushed, err = f.WorkingDir.WasForcePushed(log, pull, p.Workspace); err != nil {
+			return false, err
+		}
+		if forcePushed {
+			break
+		}
+	}
+	if !forcePushed {
+		return false, nil
+	}
+
+	log.Info("discarding plans because the pull request was force-pushed")
+	pullDir, err := f.WorkingDir.GetPullDir(pull.BaseRepo, pull)
+	if err != nil {
+		return false, err
+	}
+	if err := f.PendingPlanFinder.DeletePlans(pullDir); err != nil {
+		return false, err
+	}
+	for _, p := range planned {
+		if err := f.Backend.UpdateProjectStatus(pull, p.Workspace, p.RepoRelDir, models.DiscardedPlanStatus); err != nil {
+			log.Err("unable to update project status: %s", err)
+		}
+	}
+	if err := f.VCSClient.CreateComment(pull.BaseRepo, pull.Num, ForcePushedComment, ""); err != nil {
+		log.Err("unable to comment on pull request: %s", err)
+	}
+	return true, nil
+}
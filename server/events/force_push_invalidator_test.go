@@ -0,0 +1,123 @@
+package events_test
+
+import (
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/db"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestForcePushInvalidator_Invalidate(t *testing.T) {
+	cases := []struct {
+		description    string
+		results        []command.ProjectResult
+		forcePushed    bool
+		expInvalidated bool
+		expStatuses    []models.ProjectPlanStatus
+	}{
+		{
+			description: "no plans",
+			results: []command.ProjectResult{
+				{Command: command.Plan, RepoRelDir: ".", Workspace: "default", Failure: "failure"},
+			},
+			forcePushed:    true,
+			expInvalidated: false,
+			expStatuses:    []models.ProjectPlanStatus{models.ErroredPlanStatus},
+		},
+		{
+			description: "not force-pushed",
+			results: []command.ProjectResult{
+				{Command: command.Plan, RepoRelDir: ".", Workspace: "default", PlanSuccess: &models.PlanSuccess{}},
+			},
+			forcePushed:    false,
+			expInvalidated: false,
+			expStatuses:    []models.ProjectPlanStatus{models.PlannedPlanStatus},
+		},
+		{
+			description: "force-pushed",
+			results: []command.ProjectResult{
+				{Command: command.Plan, RepoRelDir: ".", Workspace: "default", PlanSuccess: &models.PlanSuccess{}},
+				{Command: command.Plan, RepoRelDir: "staging", Workspace: "staging", PlanSuccess: &models.PlanSuccess{}},
+				{Command: command.Plan, RepoRelDir: "errored", Workspace: "default", Failure: "failure"},
+			},
+			forcePushed:    true,
+			expInvalidated: true,
+			expStatuses: []models.ProjectPlanStatus{
+				models.DiscardedPlanStatus,
+				models.DiscardedPlanStatus,
+				models.ErroredPlanStatus,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			workingDir := events.NewMockWorkingDir()
+			pendingPlanFinder := mocks.NewMockPendingPlanFinder()
+			vcsClient := vcsmocks.NewMockClient()
+			tmp, cleanup := TempDir(t)
+			defer cleanup()
+			backend, err := db.New(tmp)
+			Ok(t, err)
+
+			pull := models.PullRequest{
+				Num:      1,
+				BaseRepo: models.Repo{FullName: "owner/repo"},
+			}
+			status, err := backend.UpdatePullWithResults(pull, c.results)
+			Ok(t, err)
+			When(workingDir.WasForcePushed(matchers.AnyLoggingSimpleLogging(), matchers.AnyModelsPullRequest(), AnyString())).ThenReturn(c.forcePushed, nil)
+			When(workingDir.GetPullDir(pull.BaseRepo, pull)).ThenReturn("/pull-dir", nil)
+
+			invalidator := &events.ForcePushInvalidator{
+				WorkingDir:        workingDir,
+				WorkingDirLocker:  events.NewDefaultWorkingDirLocker(),
+				PendingPlanFinder: pendingPlanFinder,
+				Backend:           backend,
+				VCSClient:         vcsClient,
+			}
+			invalidated, err := invalidator.Invalidate(logging.NewNoopLogger(t), pull, &status)
+			Ok(t, err)
+			Equals(t, c.expInvalidated, invalidated)
+
+			if c.expInvalidated {
+				pendingPlanFinder.VerifyWasCalledOnce().DeletePlans("/pull-dir")
+				vcsClient.VerifyWasCalledOnce().CreateComment(pull.BaseRepo, pull.Num, events.ForcePushedComment, "")
+				// Each workspace is only checked until a force-push is found.
+				workingDir.VerifyWasCalledOnce().WasForcePushed(matchers.AnyLoggingSimpleLogging(), matchers.AnyModelsPullRequest(), AnyString())
+			} else {
+				pendingPlanFinder.VerifyWasCalled(Never()).DeletePlans(AnyString())
+				vcsClient.VerifyWasCalled(Never()).CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString(), AnyString())
+			}
+
+			actStatus, err := backend.GetPullStatus(pull)
+			Ok(t, err)
+			var actStatuses []models.ProjectPlanStatus
+			for _, p := range actStatus.Projects {
+				actStatuses = append(actStatuses, p.Status)
+			}
+			Equals(t, c.expStatuses, actStatuses)
+		})
+	}
+}
+
+func TestForcePushInvalidator_NoStatus(t *testing.T) {
+	RegisterMockTestingT(t)
+	workingDir := events.NewMockWorkingDir()
+	invalidator := &events.ForcePushInvalidator{
+		WorkingDir:       workingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+	}
+	invalidated, err := invalidator.Invalidate(logging.NewNoopLogger(t), models.PullRequest{}, nil)
+	Ok(t, err)
+	Equals(t, false, invalidated)
+	workingDir.VerifyWasCalled(Never()).WasForcePushed(matchers.AnyLoggingSimpleLogging(), matchers.AnyModelsPullRequest(), AnyString())
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+func NewForceUnlockStateCommandRunner(
+	vcsClient vcs.Client,
+	pullUpdater *PullUpdater,
+	prjCmdBuilder ProjectForceUnlockStateCommandBuilder,
+	prjCmdRunner ProjectForceUnlockStateCommandRunner,
+	adminTeams []string,
+) *ForceUnlockStateCommandRunner {
+	return &ForceUnlockStateCommandRunner{
+		vcsClient:     vcsClient,
+		pullUpdater:   pullUpdater,
+		prjCmdBuilder: prjCmdBuilder,
+		prjCmdRunner:  prjCmdRunner,
+		adminTeams:    adminTeams,
+	}
+}
+
+// ForceUnlockStateCommandRunner removes a Terraform state lock for a single
+// project. Since removing a lock that's still in use can corrupt the state,
+// only members of the admin teams can run it.
+type ForceUnlockStateCommandRunner struct {
+	vcsClient     vcs.Client
+	pullUpdater   *PullUpdater
+	prjCmdBuilder ProjectForceUnlockStateCommandBuilder
+	prjCmdRunner  ProjectForceUnlockStateCommandRunner
+	// adminTeams are the VCS teams whose members can run force_unlock_state.
+	adminTeams []string
+}
+
+func (f *ForceUnlockStateCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
+	baseRepo := ctx.Pull.BaseRepo
+	pullNum := ctx.Pull.Num
+
+	isAdmin, err := userInTeams(f.vcsClient, baseRepo, ctx.User, f.adminTeams)
+	if err != nil {
+		f.pullUpdater.updatePull(ctx, cmd, command.Result{Error: err})
+		return
+	}
+	if !isAdmin {
+		ctx.Log.Info("user %s is not allowed to run %s", ctx.User.Username, command.ForceUnlockState)
+		msg := fmt.Sprintf("```\nError: User @%s is not allowed to run %s. Ask an Atlantis admin to remove the lock.\n```", ctx.User.Username, command.ForceUnlockState)
+		if err := f.vcsClient.CreateComment(baseRepo, pullNum, msg, command.ForceUnlockState.String()); err != nil {
+			ctx.Log.Err("unable to comment: %s", err)
+		}
+		return
+	}
+
+	projectCmds, err := f.prjCmdBuilder.BuildForceUnlockStateCommands(ctx, cmd)
+	if err != nil {
+		f.pullUpdater.updatePull(ctx, cmd, command.Result{Error: err})
+		return
+	}
+	if len(projectCmds) != 1 {
+		f.pullUpdater.updatePull(ctx, cmd, command.Result{
+			Failure: fmt.Sprintf("%s must match exactly one project but matched %d. Use the -d, -w or -p flags to specify the project.", command.ForceUnlockState, len(projectCmds)),
+		})
+		return
+	}
+
+	projectCmds[0].StateLockID = cmd.LockID
+	result := runProjectCmds(projectCmds, f.prjCmdRunner.ForceUnlockState)
+	f.pullUpdater.updatePull(ctx, cmd, result)
+}
@@ -0,0 +1,34 @@
+package events_test
+
+import (
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+func TestForceUnlockStateCommandRunner_NotAdmin(t *testing.T) {
+	RegisterMockTestingT(t)
+	vcsClient := vcsmocks.NewMockClient()
+	builder := mocks.NewMockProjectCommandBuilder()
+	runner := mocks.NewMockProjectCommandRunner()
+	r := events.NewForceUnlockStateCommandRunner(vcsClient, nil, builder, runner, []string{"admin"})
+
+	repo := models.Repo{FullName: "owner/repo"}
+	ctx := &command.Context{
+		User: models.User{Username: "user"},
+		Log:  logging.NewNoopLogger(t),
+		Pull: models.PullRequest{BaseRepo: repo, Num: 1},
+	}
+	When(vcsClient.GetTeamNamesForUser(repo, ctx.User)).ThenReturn([]string{"developers"}, nil)
+	r.Run(ctx, &events.CommentCommand{Name: command.ForceUnlockState, LockID: "id", RepoRelDir: "."})
+
+	vcsClient.VerifyWasCalledOnce().CreateComment(repo, 1, "```\nError: User @user is not allowed to run force_unlock_state. Ask an Atlantis admin to remove the lock.\n```", "force_unlock_state")
+	builder.VerifyWasCalled(Never()).BuildForceUnlockStateCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())
+}
@@ -0,0 +1,70 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// Formatter checks and fixes the formatting of the Terraform files in pull
+// requests.
+type Formatter interface {
+	// Fmt runs terraform fmt on the head branch of ctx.Pull and returns the
+	// diff of the files that aren't formatted, which is empty if they all
+	// are. If fix is true and some files aren't formatted, a commit that
+	// formats them is pushed to the head branch.
+	Fmt(ctx *command.Context, fix bool) (string, error)
+}
+
+// GitFormatter implements Formatter by cloning the head branch into a
+// temporary dir and running terraform fmt in it.
+type GitFormatter struct {
+	GitCloner
+	TerraformExecutor runtime.TerraformExec
+}
+
+func (g *GitFormatter) Fmt(ctx *command.Context, fix bool) (string, error) {
+	pull := ctx.Pull
+	cloneDir, repo, cleanup, err := g.clone(ctx.Log, "fmt", ctx.HeadRepo, "--depth=1", "--branch", pull.HeadBranch, "--single-branch")
+	defer cleanup()
+	if err != nil {
+		return "", err
+	}
+	git := func(args ...string) (string, error) {
+		return g.git(ctx.Log, cloneDir, "", []models.Repo{repo}, args...)
+	}
+
+	// The files are formatted in the clone, which is thrown away, so unlike
+	// terraform fmt -check the diff is also what a fix would commit.
+	prjCtx := command.ProjectContext{Log: ctx.Log, Workspace: DefaultWorkspace}
+	diff, err := g.TerraformExecutor.RunCommandWithVersion(prjCtx, cloneDir, []string{"fmt", "-diff", "-recursive"}, nil, nil, DefaultWorkspace)
+	if err != nil {
+		return "", errors.Wrapf(err, "running terraform fmt: %s", diff)
+	}
+	status, err := git("status", "--porcelain")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(status) == "" {
+		return "", nil
+	}
+	diff = strings.TrimSpace(diff)
+	if !fix {
+		return diff, nil
+	}
+
+	msg := fmt.Sprintf("Run terraform fmt\n\nPushed by Atlantis for @%s.", ctx.User.Username)
+	for _, args := range [][]string{
+		{"commit", "-a", "-m", msg},
+		{"push", "origin", "HEAD:" + pull.HeadBranch},
+	} {
+		if _, err := git(args...); err != nil {
+			return "", err
+		}
+	}
+	return diff, nil
+}
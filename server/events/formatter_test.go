@@ -0,0 +1,113 @@
+package events_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	tmocks "github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	tfmatchers "github.com/runatlantis/atlantis/server/core/terraform/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const (
+	unformattedTF = "resource \"null_resource\" \"a\" {\n  triggers = {\n    a = \"a\"\n    abc = \"abc\"\n  }\n}\n"
+	formattedTF   = "resource \"null_resource\" \"a\" {\n  triggers = {\n    a   = \"a\"\n    abc = \"abc\"\n  }\n}\n"
+	fmtDiff       = "main.tf\n--- old/main.tf\n+++ new/main.tf\n@@ -1,6 +1,6 @@\n-    a = \"a\"\n+    a   = \"a\""
+)
+
+func TestGitFormatter_Fmt(t *testing.T) {
+	cases := []struct {
+		description string
+		formatted   bool
+		fix         bool
+		expDiff     string
+		expTF       string
+	}{
+		{
+			description: "formatted",
+			formatted:   true,
+			expTF:       formattedTF,
+		},
+		{
+			description: "check",
+			expDiff:     fmtDiff,
+			expTF:       unformattedTF,
+		},
+		{
+			description: "fix",
+			fix:         true,
+			expDiff:     fmtDiff,
+			expTF:       formattedTF,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			repoDir, cleanup := initRepo(t)
+			defer cleanup()
+			runCmd(t, repoDir, "git", "checkout", "-b", "feature")
+			tf := unformattedTF
+			if c.formatted {
+				tf = formattedTF
+			}
+			writeFile(t, filepath.Join(repoDir, "main.tf"), tf)
+			runCmd(t, repoDir, "git", "add", "main.tf")
+			runCmd(t, repoDir, "git", "commit", "-m", "add terraform")
+			// The branch that's pushed to can't be checked out.
+			runCmd(t, repoDir, "git", "checkout", "master")
+
+			dataDir, cleanup2 := TempDir(t)
+			defer cleanup2()
+			RegisterMockTestingT(t)
+			tfClient := tmocks.NewMockClient()
+			When(tfClient.RunCommandWithVersion(matchers.AnyModelsProjectCommandContext(), AnyString(), tfmatchers.AnySliceOfString(), tfmatchers.AnyMapOfStringToString(), tfmatchers.AnyPtrToGoVersionVersion(), AnyString())).
+				Then(func(params []Param) ReturnValues {
+					Equals(t, []string{"fmt", "-diff", "-recursive"}, params[2])
+					if c.formatted {
+						return ReturnValues{"", nil}
+					}
+					Ok(t, os.WriteFile(filepath.Join(params[1].(string), "main.tf"), []byte(formattedTF), 0600))
+					return ReturnValues{fmtDiff + "\n", nil}
+				})
+			formatter := &events.GitFormatter{
+				TerraformExecutor: tfClient,
+				GitCloner:         events.GitCloner{DataDir: dataDir},
+			}
+
+			diff, err := formatter.Fmt(fmtCtx(t, repoDir), c.fix)
+			Ok(t, err)
+			Equals(t, c.expDiff, diff)
+			Equals(t, c.expTF, runCmd(t, repoDir, "git", "show", "feature:main.tf"))
+			if c.fix {
+				Assert(t, strings.HasPrefix(runCmd(t, repoDir, "git", "log", "-1", "--format=%B", "feature"), "Run terraform fmt\n\nPushed by Atlantis for @lkysow."), "expected the fix to be committed")
+			}
+		})
+	}
+}
+
+func fmtCtx(t *testing.T, repoDir string) *command.Context {
+	repo := models.Repo{
+		FullName:          "owner/repo",
+		CloneURL:          fmt.Sprintf("file://%s", repoDir),
+		SanitizedCloneURL: fmt.Sprintf("file://%s", repoDir),
+	}
+	return &command.Context{
+		Log:      logging.NewNoopLogger(t),
+		User:     models.User{Username: "lkysow"},
+		HeadRepo: repo,
+		Pull: models.PullRequest{
+			Num:        1,
+			HeadBranch: "feature",
+			BaseBranch: "master",
+			BaseRepo:   repo,
+		},
+	}
+}
@@ -0,0 +1,138 @@
+package events
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// GitCloner clones repos into temporary dirs for the commands that push
+// commits to them, ex. fmt --fix and promotions, so that they don't touch
+// the pull requests' working dirs.
+type GitCloner struct {
+	// DataDir is the dir that repos are temporarily cloned into.
+	DataDir string
+	// GitEnvs are extra env vars for the git commands run against repos on
+	// each VCS host, ex. to connect through the host's proxy.
+	GitEnvs map[models.VCSHostType][]string
+	// SSHCredentials clones and pushes over SSH if set. Otherwise HTTPS is
+	// used.
+	SSHCredentials *SSHCredentialsWriter
+	// GitCredentials are the credentials used to clone and push over HTTPS.
+	GitCredentials []GitCredentials
+	// TenantCredentials replaces GitCredentials for the repos of tenants
+	// that have their own credentials.
+	TenantCredentials *TenantCredentials
+}
+
+// clone clones repo into a new dir named after pattern, passing args to git
+// clone, ex. the branch to clone. It returns the dir and repo with the
+// credentials to push to it. cleanup removes the dir and must be called even
+// if there's an error.
+func (g GitCloner) clone(log logging.SimpleLogging, pattern string, repo models.Repo, args ...string) (cloneDir string, cloneRepo models.Repo, cleanup func(), err error) {
+	cloneDir, cleanup, err = g.mkdir(pattern)
+	if err != nil {
+		return cloneDir, repo, cleanup, err
+	}
+	if cloneRepo, err = g.repo(repo); err != nil {
+		return cloneDir, repo, cleanup, err
+	}
+	args = append(append([]string{"clone"}, args...), cloneRepo.CloneURL, cloneDir)
+	_, err = g.git(log, cloneDir, "", []models.Repo{cloneRepo}, args...)
+	return cloneDir, cloneRepo, cleanup, err
+}
+
+// mkdir creates a new dir named after pattern to clone into, with its own
+// git config. cleanup removes it and must be called even if there's an
+// error.
+func (g GitCloner) mkdir(pattern string) (dir string, cleanup func(), err error) {
+	cleanup = func() {}
+	dir, err = os.MkdirTemp(g.DataDir, pattern)
+	if err != nil {
+		return "", cleanup, errors.Wrap(err, "creating dir to clone into")
+	}
+	cleanup = func() {
+		os.RemoveAll(dir)             // nolint: errcheck
+		os.Remove(GitConfigFile(dir)) // nolint: errcheck
+	}
+	return dir, cleanup, writeGitConfig(GitConfigFile(dir))
+}
+
+// repo returns repo with the credentials to clone and push it.
+func (g GitCloner) repo(repo models.Repo) (models.Repo, error) {
+	repo = g.TenantCredentials.Repo(repo)
+	if g.SSHCredentials != nil {
+		return g.SSHCredentials.Repo(repo)
+	}
+	return repo, nil
+}
+
+// git runs git with args in dir, a dir created by mkdir, and returns its
+// stdout. It pushes to repos[0] and the clone URLs of repos are sanitized in
+// errors since they contain credentials.
+func (g GitCloner) git(log logging.SimpleLogging, dir string, stdin string, repos []models.Repo, args ...string) (string, error) {
+	env, err := g.env(dir, repos[0])
+	if err != nil {
+		return "", err
+	}
+	return runGit(log, dir, env, stdin, repos, args...)
+}
+
+// env returns the env vars for git commands that commit in dir and push to
+// repo.
+func (g GitCloner) env(dir string, repo models.Repo) ([]string, error) {
+	// git commit requires these env vars are set.
+	env := []string{
+		"EMAIL=atlantis@runatlantis.io",
+		"GIT_AUTHOR_NAME=atlantis",
+		"GIT_COMMITTER_NAME=atlantis",
+		"GIT_CONFIG_GLOBAL=" + GitConfigFile(dir),
+	}
+	env = append(env, g.GitEnvs[repo.VCSHost.Type]...)
+	var credsEnv []string
+	var err error
+	if g.SSHCredentials != nil {
+		credsEnv, err = g.SSHCredentials.Env(repo)
+	} else {
+		credsEnv, err = GitCredentialsEnv(g.TenantCredentials.GitCredentials(repo, g.GitCredentials))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(env, credsEnv...), nil
+}
+
+// runGit runs git with args in dir and env and returns its stdout. The clone
+// URLs of repos are sanitized in errors since they contain credentials.
+func runGit(log logging.SimpleLogging, dir string, env []string, stdin string, repos []models.Repo, args ...string) (string, error) {
+	cmd := exec.Command("git", args...) // nolint: gosec
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+
+	sanitize := func(s string) string {
+		for _, repo := range repos {
+			if repo.CloneURL != "" {
+				s = strings.Replace(s, repo.CloneURL, repo.SanitizedCloneURL, -1)
+			}
+		}
+		return s
+	}
+	cmdStr := sanitize(strings.Join(cmd.Args, " "))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running %s: %s: %s", cmdStr, sanitize(stderr.String()+stdout.String()), sanitize(err.Error()))
+	}
+	log.Debug("ran: %s", cmdStr)
+	return stdout.String(), nil
+}
@@ -0,0 +1,74 @@
+package events
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// GitCredentials are the credentials that git authenticates to a VCS host
+// with over HTTP(S).
+type GitCredentials struct {
+	// URL is the VCS host's base URL, ex. https://github.com.
+	URL      string
+	Username string
+	// Token returns the password or token to authenticate with. It's called
+	// every time git is run so that tokens that expire, like GitHub App
+	// installation tokens, are refreshed.
+	Token func() (string, error)
+}
+
+// StaticToken returns a GitCredentials.Token func that always returns token.
+func StaticToken(token string) func() (string, error) {
+	return func() (string, error) {
+		return token, nil
+	}
+}
+
+// GitCredentialsEnv returns the env vars that make git authenticate with
+// creds. Rather than writing the credentials to a .git-credentials file, git
+// is configured through GIT_CONFIG_COUNT with a credential helper that reads
+// them from the env of the git process. That way tokens never land on disk
+// and concurrent git processes can't clobber each other's credentials.
+// It requires git 2.31 or later.
+func GitCredentialsEnv(creds []GitCredentials) ([]string, error) {
+	var env []string
+	var config [][2]string
+	for i, c := range creds {
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing url of %s", c.URL)
+		}
+		token, err := c.Token()
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting git token for %s", u.Host)
+		}
+		usernameVar := fmt.Sprintf("ATLANTIS_GIT_USERNAME_%d", i)
+		passwordVar := fmt.Sprintf("ATLANTIS_GIT_PASSWORD_%d", i)
+		env = append(env, usernameVar+"="+c.Username, passwordVar+"="+token)
+
+		// git runs the helper with the action as its last argument and only
+		// expects an answer to "get".
+		helper := fmt.Sprintf(`!f() { test "$1" = get && echo "username=${%s}" && echo "password=${%s}"; }; f`, usernameVar, passwordVar)
+		hostURL := fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+		config = append(config,
+			// An empty helper resets the helpers from git's config files, ex. a
+			// store helper that an older version of Atlantis configured, so that
+			// only these credentials are used.
+			[2]string{"credential." + hostURL + ".helper", ""},
+			[2]string{"credential." + hostURL + ".helper", helper},
+			// Modules are often sourced over SSH so clone them over HTTP(S)
+			// instead, where the credentials are used.
+			[2]string{"url." + hostURL + "/.insteadOf", "ssh://git@" + u.Hostname() + "/"},
+		)
+	}
+	if len(config) == 0 {
+		return nil, nil
+	}
+	env = append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", len(config)))
+	for i, kv := range config {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, kv[0]), fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, kv[1]))
+	}
+	return env, nil
+}
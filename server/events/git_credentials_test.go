@@ -0,0 +1,88 @@
+package events_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestGitCredentialsEnv_None(t *testing.T) {
+	env, err := events.GitCredentialsEnv(nil)
+	Ok(t, err)
+	Equals(t, 0, len(env))
+}
+
+func TestGitCredentialsEnv_TokenErr(t *testing.T) {
+	_, err := events.GitCredentialsEnv([]events.GitCredentials{
+		{
+			URL:      "https://github.com",
+			Username: "x-access-token",
+			Token: func() (string, error) {
+				return "", errors.New("installation not found")
+			},
+		},
+	})
+	ErrEquals(t, "getting git token for github.com: installation not found", err)
+}
+
+// Test that git gets the credentials for each host from the helper.
+func TestGitCredentialsEnv_Fill(t *testing.T) {
+	env, err := events.GitCredentialsEnv([]events.GitCredentials{
+		{
+			URL:      "https://github.com",
+			Username: "x-access-token",
+			Token:    events.StaticToken("gh-token"),
+		},
+		{
+			URL:      "http://bitbucket.corp:7990/basepath",
+			Username: "bb-user",
+			Token:    events.StaticToken("bb-token"),
+		},
+	})
+	Ok(t, err)
+
+	// Make sure credentials from the user's git config aren't used.
+	home := t.TempDir()
+	cfgCmd := exec.Command("git", "config", "--global", "credential.helper", "!echo password=from-config #")
+	cfgCmd.Env = append(os.Environ(), "HOME="+home)
+	out, err := cfgCmd.CombinedOutput()
+	Ok(t, err)
+	Equals(t, "", string(out))
+
+	fill := func(input string) string {
+		cmd := exec.Command("git", "credential", "fill")
+		cmd.Env = append(append(os.Environ(), "HOME="+home, "GIT_TERMINAL_PROMPT=0"), env...)
+		cmd.Stdin = strings.NewReader(input)
+		out, err := cmd.CombinedOutput()
+		Ok(t, err)
+		return string(out)
+	}
+	Equals(t, "protocol=https\nhost=github.com\nusername=x-access-token\npassword=gh-token\n",
+		fill("protocol=https\nhost=github.com\n\n"))
+	Equals(t, "protocol=http\nhost=bitbucket.corp:7990\nusername=bb-user\npassword=bb-token\n",
+		fill("protocol=http\nhost=bitbucket.corp:7990\n\n"))
+}
+
+// Test that SSH URLs of the hosts are rewritten to HTTPS so that the
+// credentials are used for them.
+func TestGitCredentialsEnv_InsteadOf(t *testing.T) {
+	env, err := events.GitCredentialsEnv([]events.GitCredentials{
+		{
+			URL:      "https://github.com",
+			Username: "user",
+			Token:    events.StaticToken("token"),
+		},
+	})
+	Ok(t, err)
+
+	cmd := exec.Command("git", "ls-remote", "--get-url", "ssh://git@github.com/owner/repo.git")
+	cmd.Env = append(append(os.Environ(), "HOME="+t.TempDir()), env...)
+	out, err := cmd.CombinedOutput()
+	Ok(t, err)
+	Equals(t, "https://github.com/owner/repo.git\n", string(out))
+}
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/runatlantis/atlantis/server/core/config"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+func NewInitConfigCommandRunner(
+	vcsClient vcs.Client,
+	initializer ConfigInitializer,
+) *InitConfigCommandRunner {
+	return &InitConfigCommandRunner{
+		vcsClient:   vcsClient,
+		initializer: initializer,
+	}
+}
+
+// InitConfigCommandRunner onboards a repo by opening a pull request that adds
+// a generated atlantis.yaml.
+type InitConfigCommandRunner struct {
+	vcsClient   vcs.Client
+	initializer ConfigInitializer
+}
+
+func (i *InitConfigCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
+	baseRepo := ctx.Pull.BaseRepo
+	pullNum := ctx.Pull.Num
+
+	var msg string
+	initPull, numProjects, err := i.initializer.InitConfig(ctx)
+	if err != nil {
+		ctx.Log.Err("generating %s: %s", config.AtlantisYAMLFilename, err)
+		msg = fmt.Sprintf("**%s Error**\n```\n%s\n```", command.InitConfig.TitleString(), err)
+	} else {
+		link, linkErr := i.vcsClient.MarkdownPullLink(initPull)
+		if linkErr != nil {
+			link = initPull.URL
+		}
+		msg = fmt.Sprintf("Opened %s to add an `%s` with %d projects. Review the projects before merging it.", link, config.AtlantisYAMLFilename, numProjects)
+	}
+	if commentErr := i.vcsClient.CreateComment(baseRepo, pullNum, msg, command.InitConfig.String()); commentErr != nil {
+		ctx.Log.Err("unable to comment: %s", commentErr)
+	}
+}
@@ -0,0 +1,130 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+const (
+	// lockFileName is the name of Terraform's dependency lock file.
+	lockFileName = ".terraform.lock.hcl"
+	// modulesManifestPath is the path to the manifest terraform init writes
+	// for the modules it installs.
+	modulesManifestPath = ".terraform/modules/modules.json"
+)
+
+// Inventory stores the providers and modules that each project uses.
+type Inventory interface {
+	// UpdateInventory creates or replaces the inventory of inventory's
+	// project.
+	UpdateInventory(inventory models.ProjectInventory) error
+	// ListInventory returns the inventory of every project.
+	ListInventory() ([]models.ProjectInventory, error)
+}
+
+var lockFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "provider",
+			LabelNames: []string{"source"},
+		},
+	},
+}
+
+var lockFileProviderSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "version",
+			Required: true,
+		},
+		{
+			Name: "constraints",
+		},
+	},
+}
+
+// ReadInventoryProviders returns the providers in the dependency lock file of
+// the project at projAbsPath, sorted by source. Projects that use Terraform
+// versions before 0.14 don't have a lock file so it returns nil.
+func ReadInventoryProviders(projAbsPath string) ([]models.InventoryProvider, error) {
+	path := filepath.Join(projAbsPath, lockFileName)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	file, diags := hclparse.NewParser().ParseHCLFile(path)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing %s: %s", lockFileName, diags.Error())
+	}
+	content, _, diags := file.Body.PartialContent(lockFileSchema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing %s: %s", lockFileName, diags.Error())
+	}
+
+	var providers []models.InventoryProvider
+	for _, block := range content.Blocks {
+		attrs, _, diags := block.Body.PartialContent(lockFileProviderSchema)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("parsing provider %q in %s: %s", block.Labels[0], lockFileName, diags.Error())
+		}
+		var version string
+		if diags := gohcl.DecodeExpression(attrs.Attributes["version"].Expr, nil, &version); diags.HasErrors() {
+			return nil, fmt.Errorf("parsing version of provider %q in %s: %s", block.Labels[0], lockFileName, diags.Error())
+		}
+		var constraints string
+		if attr, ok := attrs.Attributes["constraints"]; ok {
+			if diags := gohcl.DecodeExpression(attr.Expr, nil, &constraints); diags.HasErrors() {
+				return nil, fmt.Errorf("parsing constraints of provider %q in %s: %s", block.Labels[0], lockFileName, diags.Error())
+			}
+		}
+		providers = append(providers, models.InventoryProvider{
+			Source:      block.Labels[0],
+			Version:     version,
+			Constraints: constraints,
+		})
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].Source < providers[j].Source
+	})
+	return providers, nil
+}
+
+// ReadInventoryModules returns the modules that terraform init installed for
+// the project at projAbsPath, sorted by key. If the project doesn't call any
+// modules it returns nil.
+func ReadInventoryModules(projAbsPath string) ([]models.InventoryModule, error) {
+	contents, err := os.ReadFile(filepath.Join(projAbsPath, modulesManifestPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading modules manifest")
+	}
+	var manifest struct {
+		Modules []models.InventoryModule
+	}
+	if err := json.Unmarshal(contents, &manifest); err != nil {
+		return nil, errors.Wrap(err, "parsing modules manifest")
+	}
+
+	var modules []models.InventoryModule
+	for _, m := range manifest.Modules {
+		// The root module has an empty key.
+		if m.Key == "" {
+			continue
+		}
+		modules = append(modules, m)
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Key < modules[j].Key
+	})
+	return modules, nil
+}
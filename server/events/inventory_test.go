@@ -0,0 +1,69 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestReadInventoryProviders(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+
+	providers, err := events.ReadInventoryProviders(tmp)
+	Ok(t, err)
+	Assert(t, providers == nil, "exp no providers without a lock file, got %v", providers)
+
+	lockFile := `# This file is maintained automatically by "terraform init".
+# Manual edits may be lost in future updates.
+
+provider "registry.terraform.io/hashicorp/random" {
+  version = "3.4.3"
+  hashes = [
+    "h1:xZGZf18JjMS06pFa4NErzANI98qi59SEcBsOcS2P2yQ=",
+  ]
+}
+
+provider "registry.terraform.io/hashicorp/aws" {
+  version     = "4.36.1"
+  constraints = "~> 4.0"
+  hashes = [
+    "h1:Ojk7c4pdkH+M6fWg9ReqeDRv0GfrNrU9KsfBpB3ZRAE=",
+  ]
+}
+`
+	Ok(t, os.WriteFile(filepath.Join(tmp, ".terraform.lock.hcl"), []byte(lockFile), 0600))
+	providers, err = events.ReadInventoryProviders(tmp)
+	Ok(t, err)
+	Equals(t, []models.InventoryProvider{
+		{Source: "registry.terraform.io/hashicorp/aws", Version: "4.36.1", Constraints: "~> 4.0"},
+		{Source: "registry.terraform.io/hashicorp/random", Version: "3.4.3"},
+	}, providers)
+}
+
+func TestReadInventoryModules(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+
+	modules, err := events.ReadInventoryModules(tmp)
+	Ok(t, err)
+	Assert(t, modules == nil, "exp no modules without a manifest, got %v", modules)
+
+	manifest := `{"Modules":[
+{"Key":"vpc","Source":"registry.terraform.io/terraform-aws-modules/vpc/aws","Version":"3.18.1","Dir":".terraform/modules/vpc"},
+{"Key":"","Source":"","Dir":"."},
+{"Key":"dns","Source":"git::https://github.com/owner/modules.git//dns?ref=v1.2.0","Dir":".terraform/modules/dns/dns"}
+]}`
+	Ok(t, os.MkdirAll(filepath.Join(tmp, ".terraform", "modules"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(tmp, ".terraform", "modules", "modules.json"), []byte(manifest), 0600))
+	modules, err = events.ReadInventoryModules(tmp)
+	Ok(t, err)
+	Equals(t, []models.InventoryModule{
+		{Key: "dns", Source: "git::https://github.com/owner/modules.git//dns?ref=v1.2.0"},
+		{Key: "vpc", Source: "registry.terraform.io/terraform-aws-modules/vpc/aws", Version: "3.18.1"},
+	}, modules)
+}
@@ -0,0 +1,16 @@
+package events
+
+import (
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// LockContentionStore records the pull requests that are blocked by other pull
+// requests' locks so that contention hot spots can be found.
+type LockContentionStore interface {
+	// RecordLockAttempt records attempt in the lock contention of its
+	// project's workspace.
+	RecordLockAttempt(attempt models.LockAttempt) error
+	// ListLockContention returns the lock contention of every project's
+	// workspaces whose locks blocked pull requests.
+	ListLockContention() ([]models.LockContention, error)
+}
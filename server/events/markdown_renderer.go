@@ -16,12 +16,18 @@ package events
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 
 	"github.com/Masterminds/sprig/v3"
+	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/i18n"
+	"github.com/runatlantis/atlantis/server/jobs"
 )
 
 var (
@@ -30,11 +36,16 @@ var (
 	policyCheckCommandTitle     = command.PolicyCheck.TitleString()
 	approvePoliciesCommandTitle = command.ApprovePolicies.TitleString()
 	versionCommandTitle         = command.Version.TitleString()
+	forceUnlockStateTitle       = command.ForceUnlockState.TitleString()
 	// maxUnwrappedLines is the maximum number of lines the Terraform output
 	// can be before we wrap it in an expandable template.
 	maxUnwrappedLines = 12
 )
 
+// markdownTemplateOverrideExt is the extension of the files in the markdown
+// template overrides directory.
+const markdownTemplateOverrideExt = ".tmpl"
+
 // MarkdownRenderer renders responses as markdown.
 type MarkdownRenderer struct {
 	// GitlabSupportsCommonMark is true if the version of GitLab we're
@@ -46,6 +57,20 @@ type MarkdownRenderer struct {
 	DisableMarkdownFolding   bool
 	DisableRepoLocking       bool
 	EnableDiffMarkdownFormat bool
+	// FilterPlanOutput is true if refresh noise and hidden unchanged attribute
+	// markers should be stripped from plan output. When the VCS host supports
+	// folding, the full output is still available in a collapsed block.
+	// Otherwise it's linked to with JobURLGenerator.
+	FilterPlanOutput bool
+	// JobURLGenerator generates the links to the full output of filtered
+	// plans on VCS hosts that don't support folding. If nil, there's no link.
+	JobURLGenerator jobs.ProjectJobURLGenerator
+	// TemplateOverrides are templates that replace the default templates,
+	// keyed by template name. See LoadMarkdownTemplateOverrides.
+	TemplateOverrides map[string]*template.Template
+	// Catalog translates the text of comments. If nil, comments are in
+	// English.
+	Catalog *i18n.Catalog
 }
 
 // commonData is data that all responses have.
@@ -75,6 +100,8 @@ type failureData struct {
 // resultData is data about a successful response.
 type resultData struct {
 	Results []projectResultTmplData
+	// Stats are the totals of the stats of the successful plans.
+	Stats models.PlanSuccessStats
 	commonData
 }
 
@@ -85,6 +112,12 @@ type planSuccessData struct {
 	DisableApply             bool
 	DisableRepoLocking       bool
 	EnableDiffMarkdownFormat bool
+	// FullTerraformOutput is the unfiltered plan output. It's only set if
+	// the plan output was filtered and can be shown in a collapsed block.
+	FullTerraformOutput string
+	// FullOutputURL links to the job with the unfiltered plan output. It's
+	// only set if the plan output was filtered and can't be folded.
+	FullOutputURL string
 }
 
 type policyCheckSuccessData struct {
@@ -96,6 +129,47 @@ type projectResultTmplData struct {
 	RepoRelDir  string
 	ProjectName string
 	Rendered    string
+	// Stats are the stats of the project's plan if it succeeded.
+	Stats models.PlanSuccessStats
+}
+
+// statusCommentData is data about the status comment.
+type statusCommentData struct {
+	Marker   string
+	Command  string
+	Error    string
+	Failure  string
+	Projects []statusCommentProject
+}
+
+// statusCommentProject is a row in the status comment's project table.
+type statusCommentProject struct {
+	models.ProjectStatus
+	// JobURL links to the output of the job that last planned or applied
+	// the project. It's empty if there's no job.
+	JobURL string
+}
+
+// planSummaryCommentData is data about the plan summary comment.
+type planSummaryCommentData struct {
+	Marker   string
+	Projects []planSummaryProject
+}
+
+// planSummaryProject is a row in the plan summary comment's project table.
+type planSummaryProject struct {
+	Workspace   string
+	RepoRelDir  string
+	ProjectName string
+	// Changes summarizes the resources the plan changes, ex. "+3 ~1 -2", or
+	// is the project's status if it wasn't planned.
+	Changes string
+	// JobURL links to the output of the project's plan. It's empty if
+	// there's no job.
+	JobURL string
+	// stats are the stats of the project's plan, they're empty if it wasn't
+	// planned.
+	stats models.PlanSuccessStats
 }
 
 // Render formats the data into a markdown string.
@@ -121,8 +195,36 @@ func (m *MarkdownRenderer) Render(res command.Result, cmdName command.Name, log
 	return m.renderProjectResults(res.ProjectResults, common, vcsHost)
 }
 
+// RenderStatusComment formats projects as the status comment for a pull
+// request. marker is included so the comment can be found again to be
+// updated. res is the result of cmdName, the command that last updated the
+// comment, and is only used to show errors that aren't specific to a project.
+func (m *MarkdownRenderer) RenderStatusComment(marker string, projects []statusCommentProject, res command.Result, cmdName command.Name) string {
+	data := statusCommentData{
+		Marker:   marker,
+		Command:  strings.Title(strings.Replace(cmdName.String(), "_", " ", -1)),
+		Failure:  res.Failure,
+		Projects: projects,
+	}
+	if res.Error != nil {
+		data.Error = res.Error.Error()
+	}
+	return m.renderTemplate(m.getTemplate("status_comment"), data)
+}
+
+// RenderPlanSummaryComment formats projects as a summary of a plan across
+// projects. marker is included so the comment can be found again to be
+// updated.
+func (m *MarkdownRenderer) RenderPlanSummaryComment(marker string, projects []planSummaryProject) string {
+	return m.renderTemplate(m.getTemplate("plan_summary_comment"), planSummaryCommentData{
+		Marker:   marker,
+		Projects: projects,
+	})
+}
+
 func (m *MarkdownRenderer) renderProjectResults(results []command.ProjectResult, common commonData, vcsHost models.VCSHostType) string {
 	var resultsTmplData []projectResultTmplData
+	var totalStats models.PlanSuccessStats
 	numPlanSuccesses := 0
 	numPolicyCheckSuccesses := 0
 	numVersionSuccesses := 0
@@ -134,9 +236,9 @@ func (m *MarkdownRenderer) renderProjectResults(results []command.ProjectResult,
 			ProjectName: result.ProjectName,
 		}
 		if result.Error != nil {
-			tmpl := unwrappedErrTmpl
+			tmpl := m.getTemplate("unwrapped_err")
 			if m.shouldUseWrappedTmpl(vcsHost, result.Error.Error()) {
-				tmpl = wrappedErrTmpl
+				tmpl = m.getTemplate("wrapped_err")
 			}
 			resultData.Rendered = m.renderTemplate(tmpl, struct {
 				Command string
@@ -154,24 +256,43 @@ func (m *MarkdownRenderer) renderProjectResults(results []command.ProjectResult,
 				Failure: result.Failure,
 			})
 		} else if result.PlanSuccess != nil {
-			if m.shouldUseWrappedTmpl(vcsHost, result.PlanSuccess.TerraformOutput) {
-				resultData.Rendered = m.renderTemplate(planSuccessWrappedTmpl, planSuccessData{PlanSuccess: *result.PlanSuccess, PlanSummary: result.PlanSuccess.Summary(), PlanWasDeleted: common.PlansDeleted, DisableApply: common.DisableApply, DisableRepoLocking: common.DisableRepoLocking, EnableDiffMarkdownFormat: common.EnableDiffMarkdownFormat})
+			planSuccess := *result.PlanSuccess
+			resultData.Stats = planSuccess.Stats()
+			totalStats.Import += resultData.Stats.Import
+			totalStats.Add += resultData.Stats.Add
+			totalStats.Change += resultData.Stats.Change
+			totalStats.Destroy += resultData.Stats.Destroy
+			totalStats.Changes = totalStats.Changes || resultData.Stats.Changes
+			var fullOutput, fullOutputURL string
+			if m.FilterPlanOutput {
+				filteredOutput := planSuccess.FilteredTerraformOutput()
+				if filteredOutput != planSuccess.TerraformOutput {
+					if m.supportsFolding(vcsHost) {
+						fullOutput = planSuccess.TerraformOutput
+					} else {
+						fullOutputURL = m.jobURL(result)
+					}
+				}
+				planSuccess.TerraformOutput = filteredOutput
+			}
+			if m.shouldUseWrappedTmpl(vcsHost, planSuccess.TerraformOutput) {
+				resultData.Rendered = m.renderTemplate(m.getTemplate("plan_success_wrapped"), planSuccessData{PlanSuccess: planSuccess, PlanSummary: planSuccess.Summary(), PlanWasDeleted: common.PlansDeleted, DisableApply: common.DisableApply, DisableRepoLocking: common.DisableRepoLocking, EnableDiffMarkdownFormat: common.EnableDiffMarkdownFormat, FullTerraformOutput: fullOutput, FullOutputURL: fullOutputURL})
 			} else {
-				resultData.Rendered = m.renderTemplate(planSuccessUnwrappedTmpl, planSuccessData{PlanSuccess: *result.PlanSuccess, PlanWasDeleted: common.PlansDeleted, DisableApply: common.DisableApply, DisableRepoLocking: common.DisableRepoLocking, EnableDiffMarkdownFormat: common.EnableDiffMarkdownFormat})
+				resultData.Rendered = m.renderTemplate(m.getTemplate("plan_success_unwrapped"), planSuccessData{PlanSuccess: planSuccess, PlanWasDeleted: common.PlansDeleted, DisableApply: common.DisableApply, DisableRepoLocking: common.DisableRepoLocking, EnableDiffMarkdownFormat: common.EnableDiffMarkdownFormat, FullTerraformOutput: fullOutput, FullOutputURL: fullOutputURL})
 			}
 			numPlanSuccesses++
 		} else if result.PolicyCheckSuccess != nil {
 			if m.shouldUseWrappedTmpl(vcsHost, result.PolicyCheckSuccess.PolicyCheckOutput) {
-				resultData.Rendered = m.renderTemplate(policyCheckSuccessWrappedTmpl, policyCheckSuccessData{PolicyCheckSuccess: *result.PolicyCheckSuccess})
+				resultData.Rendered = m.renderTemplate(m.getTemplate("policy_check_success_wrapped"), policyCheckSuccessData{PolicyCheckSuccess: *result.PolicyCheckSuccess})
 			} else {
-				resultData.Rendered = m.renderTemplate(policyCheckSuccessUnwrappedTmpl, policyCheckSuccessData{PolicyCheckSuccess: *result.PolicyCheckSuccess})
+				resultData.Rendered = m.renderTemplate(m.getTemplate("policy_check_success_unwrapped"), policyCheckSuccessData{PolicyCheckSuccess: *result.PolicyCheckSuccess})
 			}
 			numPolicyCheckSuccesses++
 		} else if result.ApplySuccess != "" {
 			if m.shouldUseWrappedTmpl(vcsHost, result.ApplySuccess) {
-				resultData.Rendered = m.renderTemplate(applyWrappedSuccessTmpl, struct{ Output string }{result.ApplySuccess})
+				resultData.Rendered = m.renderTemplate(m.getTemplate("apply_success_wrapped"), struct{ Output string }{result.ApplySuccess})
 			} else {
-				resultData.Rendered = m.renderTemplate(applyUnwrappedSuccessTmpl, struct{ Output string }{result.ApplySuccess})
+				resultData.Rendered = m.renderTemplate(m.getTemplate("apply_success_unwrapped"), struct{ Output string }{result.ApplySuccess})
 			}
 		} else if result.VersionSuccess != "" {
 			if m.shouldUseWrappedTmpl(vcsHost, result.VersionSuccess) {
@@ -180,6 +301,8 @@ func (m *MarkdownRenderer) renderProjectResults(results []command.ProjectResult,
 				resultData.Rendered = m.renderTemplate(versionUnwrappedSuccessTmpl, struct{ Output string }{result.VersionSuccess})
 			}
 			numVersionSuccesses++
+		} else if result.ForceUnlockStateSuccess != "" {
+			resultData.Rendered = m.renderTemplate(versionUnwrappedSuccessTmpl, struct{ Output string }{result.ForceUnlockStateSuccess})
 		} else {
 			resultData.Rendered = "Found no template. This is a bug!"
 		}
@@ -189,32 +312,47 @@ func (m *MarkdownRenderer) renderProjectResults(results []command.ProjectResult,
 	var tmpl *template.Template
 	switch {
 	case len(resultsTmplData) == 1 && common.Command == planCommandTitle && numPlanSuccesses > 0:
-		tmpl = singleProjectPlanSuccessTmpl
+		tmpl = m.getTemplate("single_project_plan_success")
 	case len(resultsTmplData) == 1 && common.Command == planCommandTitle && numPlanSuccesses == 0:
 		tmpl = singleProjectPlanUnsuccessfulTmpl
 	case len(resultsTmplData) == 1 && common.Command == policyCheckCommandTitle && numPolicyCheckSuccesses > 0:
-		tmpl = singleProjectPlanSuccessTmpl
+		tmpl = m.getTemplate("single_project_plan_success")
 	case len(resultsTmplData) == 1 && common.Command == policyCheckCommandTitle && numPolicyCheckSuccesses == 0:
 		tmpl = singleProjectPlanUnsuccessfulTmpl
 	case len(resultsTmplData) == 1 && common.Command == versionCommandTitle && numVersionSuccesses > 0:
 		tmpl = singleProjectVersionSuccessTmpl
 	case len(resultsTmplData) == 1 && common.Command == versionCommandTitle && numVersionSuccesses == 0:
 		tmpl = singleProjectVersionUnsuccessfulTmpl
+	case len(resultsTmplData) == 1 && common.Command == forceUnlockStateTitle:
+		tmpl = singleProjectVersionSuccessTmpl
 	case len(resultsTmplData) == 1 && common.Command == applyCommandTitle:
-		tmpl = singleProjectApplyTmpl
+		tmpl = m.getTemplate("single_project_apply")
 	case common.Command == planCommandTitle,
 		common.Command == policyCheckCommandTitle:
-		tmpl = multiProjectPlanTmpl
+		tmpl = m.getTemplate("multi_project_plan")
 	case common.Command == approvePoliciesCommandTitle:
 		tmpl = approveAllProjectsTmpl
 	case common.Command == applyCommandTitle:
-		tmpl = multiProjectApplyTmpl
+		tmpl = m.getTemplate("multi_project_apply")
 	case common.Command == versionCommandTitle:
 		tmpl = multiProjectVersionTmpl
 	default:
 		return "no template matched–this is a bug"
 	}
-	return m.renderTemplate(tmpl, resultData{resultsTmplData, common})
+	return m.renderTemplate(tmpl, resultData{Results: resultsTmplData, Stats: totalStats, commonData: common})
+}
+
+// jobURL returns the link to the output of result's job, or "" if there's no
+// job or link.
+func (m *MarkdownRenderer) jobURL(result command.ProjectResult) string {
+	if m.JobURLGenerator == nil || result.JobID == "" {
+		return ""
+	}
+	url, err := m.JobURLGenerator.GenerateProjectJobURL(command.ProjectContext{JobID: result.JobID})
+	if err != nil {
+		return ""
+	}
+	return url
 }
 
 // shouldUseWrappedTmpl returns true if we should use the wrapped markdown
@@ -222,12 +360,23 @@ func (m *MarkdownRenderer) renderProjectResults(results []command.ProjectResult,
 // load. Some VCS providers or versions of VCS providers don't support this
 // syntax.
 func (m *MarkdownRenderer) shouldUseWrappedTmpl(vcsHost models.VCSHostType, output string) bool {
+	if !m.supportsFolding(vcsHost) {
+		return false
+	}
+
+	return strings.Count(output, "\n") > maxUnwrappedLines
+}
+
+// supportsFolding returns true if the folding markdown syntax can be used
+// for vcsHost.
+func (m *MarkdownRenderer) supportsFolding(vcsHost models.VCSHostType) bool {
 	if m.DisableMarkdownFolding {
 		return false
 	}
 
-	// Bitbucket Cloud and Server don't support the folding markdown syntax.
-	if vcsHost == models.BitbucketServer || vcsHost == models.BitbucketCloud {
+	// Bitbucket Cloud and Server and Gerrit don't support the folding
+	// markdown syntax.
+	if vcsHost == models.BitbucketServer || vcsHost == models.BitbucketCloud || vcsHost == models.Gerrit {
 		return false
 	}
 
@@ -235,156 +384,287 @@ func (m *MarkdownRenderer) shouldUseWrappedTmpl(vcsHost models.VCSHostType, outp
 		return false
 	}
 
-	return strings.Count(output, "\n") > maxUnwrappedLines
+	return true
+}
+
+// getTemplate returns the override for the template called name if one was
+// loaded, otherwise the default template.
+// WithTemplateOverrides returns m with its templates also overridden by
+// overrides, which take precedence over m's own overrides.
+func (m *MarkdownRenderer) WithTemplateOverrides(overrides map[string]*template.Template) *MarkdownRenderer {
+	if len(overrides) == 0 {
+		return m
+	}
+	r := *m
+	r.TemplateOverrides = make(map[string]*template.Template, len(m.TemplateOverrides)+len(overrides))
+	for name, tmpl := range m.TemplateOverrides {
+		r.TemplateOverrides[name] = tmpl
+	}
+	for name, tmpl := range overrides {
+		r.TemplateOverrides[name] = tmpl
+	}
+	return &r
+}
+
+func (m *MarkdownRenderer) getTemplate(name string) *template.Template {
+	if tmpl, ok := m.TemplateOverrides[name]; ok {
+		return tmpl
+	}
+	return overridableTemplates[name]
+}
+
+// LoadMarkdownTemplateOverrides parses the templates in dir that override the
+// default templates. Each file must be named after the template it overrides
+// with a .tmpl extension, ex. plan_success_wrapped.tmpl. Templates have access
+// to templateFuncs and to the same data as the templates they replace.
+func LoadMarkdownTemplateOverrides(dir string) (map[string]*template.Template, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading markdown template overrides dir %q", dir)
+	}
+
+	overrides := make(map[string]*template.Template)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != markdownTemplateOverrideExt {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), markdownTemplateOverrideExt)
+		if _, ok := overridableTemplates[name]; !ok {
+			var names []string
+			for n := range overridableTemplates {
+				names = append(names, n+markdownTemplateOverrideExt)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("%q does not override a known template, supported file names are: %s", entry.Name(), strings.Join(names, ", "))
+		}
+
+		contents, err := os.ReadFile(filepath.Join(dir, entry.Name())) // nolint: gosec
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", entry.Name())
+		}
+		tmpl, err := template.New(name).Funcs(templateFuncs).Parse(string(contents))
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", entry.Name())
+		}
+		overrides[name] = tmpl
+	}
+	return overrides, nil
 }
 
 func (m *MarkdownRenderer) renderTemplate(tmpl *template.Template, data interface{}) string {
 	buf := &bytes.Buffer{}
+	// Clone the template so that setting the locale's "t" function doesn't
+	// affect other renderers.
+	tmpl, err := tmpl.Clone()
+	if err != nil {
+		return fmt.Sprintf("Failed to render template, this is a bug: %v", err)
+	}
+	tmpl.Funcs(template.FuncMap{"t": m.Catalog.T})
 	if err := tmpl.Execute(buf, data); err != nil {
 		return fmt.Sprintf("Failed to render template, this is a bug: %v", err)
 	}
 	return buf.String()
 }
 
+// templateFuncs are the functions available to all templates. Along with the
+// sprig functions, "t" translates messages. It's replaced when rendering with
+// the function for the renderer's locale.
+var templateFuncs = func() template.FuncMap {
+	funcs := sprig.TxtFuncMap()
+	funcs["t"] = (*i18n.Catalog)(nil).T
+	return funcs
+}()
+
+// overridableTemplates are the templates that can be replaced by
+// TemplateOverrides, keyed by template name.
+var overridableTemplates = map[string]*template.Template{
+	"single_project_apply":           singleProjectApplyTmpl,
+	"single_project_plan_success":    singleProjectPlanSuccessTmpl,
+	"multi_project_plan":             multiProjectPlanTmpl,
+	"multi_project_apply":            multiProjectApplyTmpl,
+	"plan_success_unwrapped":         planSuccessUnwrappedTmpl,
+	"plan_success_wrapped":           planSuccessWrappedTmpl,
+	"policy_check_success_unwrapped": policyCheckSuccessUnwrappedTmpl,
+	"policy_check_success_wrapped":   policyCheckSuccessWrappedTmpl,
+	"apply_success_unwrapped":        applyUnwrappedSuccessTmpl,
+	"apply_success_wrapped":          applyWrappedSuccessTmpl,
+	"unwrapped_err":                  unwrappedErrTmpl,
+	"wrapped_err":                    wrappedErrTmpl,
+	"status_comment":                 statusCommentTmpl,
+	"plan_summary_comment":           planSummaryCommentTmpl,
+}
+
+// projectTmpl identifies the project of $result in the templates of command
+// results.
+var projectTmpl = "{{ if $result.ProjectName }}{{ t \"project_name\" $result.ProjectName }} {{ end }}" + dirWorkspaceTmpl
+
+// dirWorkspaceTmpl identifies the dir and workspace of $result.
+var dirWorkspaceTmpl = "{{ t \"dir_workspace\" $result.RepoRelDir $result.Workspace }}"
+
 // todo: refactor to remove duplication #refactor
-var singleProjectApplyTmpl = template.Must(template.New("").Parse(
-	"{{$result := index .Results 0}}Ran {{.Command}} for {{ if $result.ProjectName }}project: `{{$result.ProjectName}}` {{ end }}dir: `{{$result.RepoRelDir}}` workspace: `{{$result.Workspace}}`\n\n{{$result.Rendered}}\n" + logTmpl))
-var singleProjectPlanSuccessTmpl = template.Must(template.New("").Parse(
-	"{{$result := index .Results 0}}Ran {{.Command}} for {{ if $result.ProjectName }}project: `{{$result.ProjectName}}` {{ end }}dir: `{{$result.RepoRelDir}}` workspace: `{{$result.Workspace}}`\n\n{{$result.Rendered}}\n" +
+var singleProjectApplyTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
+	"{{$result := index .Results 0}}{{ t \"ran_command_for\" .Command }} " + projectTmpl + "\n\n{{$result.Rendered}}\n" + logTmpl))
+var singleProjectPlanSuccessTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
+	"{{$result := index .Results 0}}{{ t \"ran_command_for\" .Command }} " + projectTmpl + "\n\n{{$result.Rendered}}\n" +
 		"\n" +
 		"{{ if ne .DisableApplyAll true  }}---\n" +
-		"* :fast_forward: To **apply** all unapplied plans from this pull request, comment:\n" +
+		"* :fast_forward: {{ t \"apply_all\" }}\n" +
 		"    * `atlantis apply`\n" +
-		"* :put_litter_in_its_place: To delete all plans and locks for the PR, comment:\n" +
+		"* :put_litter_in_its_place: {{ t \"delete_all\" }}\n" +
 		"    * `atlantis unlock`{{ end }}" + logTmpl))
-var singleProjectPlanUnsuccessfulTmpl = template.Must(template.New("").Parse(
-	"{{$result := index .Results 0}}Ran {{.Command}} for dir: `{{$result.RepoRelDir}}` workspace: `{{$result.Workspace}}`\n\n" +
+var singleProjectPlanUnsuccessfulTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
+	"{{$result := index .Results 0}}{{ t \"ran_command_for\" .Command }} " + dirWorkspaceTmpl + "\n\n" +
 		"{{$result.Rendered}}\n" + logTmpl))
-var singleProjectVersionSuccessTmpl = template.Must(template.New("").Parse(
-	"{{$result := index .Results 0}}Ran {{.Command}} for {{ if $result.ProjectName }}project: `{{$result.ProjectName}}` {{ end }}dir: `{{$result.RepoRelDir}}` workspace: `{{$result.Workspace}}`\n\n{{$result.Rendered}}\n" + logTmpl))
-var singleProjectVersionUnsuccessfulTmpl = template.Must(template.New("").Parse(
-	"{{$result := index .Results 0}}Ran {{.Command}} for dir: `{{$result.RepoRelDir}}` workspace: `{{$result.Workspace}}`\n\n{{$result.Rendered}}\n" + logTmpl))
-var approveAllProjectsTmpl = template.Must(template.New("").Funcs(sprig.TxtFuncMap()).Parse(
-	"Approved Policies for {{ len .Results }} projects:\n\n" +
+var singleProjectVersionSuccessTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
+	"{{$result := index .Results 0}}{{ t \"ran_command_for\" .Command }} " + projectTmpl + "\n\n{{$result.Rendered}}\n" + logTmpl))
+var singleProjectVersionUnsuccessfulTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
+	"{{$result := index .Results 0}}{{ t \"ran_command_for\" .Command }} " + dirWorkspaceTmpl + "\n\n{{$result.Rendered}}\n" + logTmpl))
+var approveAllProjectsTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
+	"{{ t \"approved_policies_for\" (len .Results) }}\n\n" +
 		"{{ range $result := .Results }}" +
-		"1. {{ if $result.ProjectName }}project: `{{$result.ProjectName}}` {{ end }}dir: `{{$result.RepoRelDir}}` workspace: `{{$result.Workspace}}`\n" +
+		"1. " + projectTmpl + "\n" +
 		"{{end}}\n" + logTmpl))
-var multiProjectPlanTmpl = template.Must(template.New("").Funcs(sprig.TxtFuncMap()).Parse(
-	"Ran {{.Command}} for {{ len .Results }} projects:\n\n" +
+var multiProjectPlanTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
+	"{{ t \"ran_command_for_projects\" .Command (len .Results) }}\n\n" +
 		"{{ range $result := .Results }}" +
-		"1. {{ if $result.ProjectName }}project: `{{$result.ProjectName}}` {{ end }}dir: `{{$result.RepoRelDir}}` workspace: `{{$result.Workspace}}`\n" +
+		"1. " + projectTmpl + "\n" +
 		"{{end}}\n" +
 		"{{ $disableApplyAll := .DisableApplyAll }}{{ range $i, $result := .Results }}" +
-		"### {{add $i 1}}. {{ if $result.ProjectName }}project: `{{$result.ProjectName}}` {{ end }}dir: `{{$result.RepoRelDir}}` workspace: `{{$result.Workspace}}`\n" +
+		"### {{add $i 1}}. " + projectTmpl + "\n" +
 		"{{$result.Rendered}}\n\n" +
-		"{{ if ne $disableApplyAll true }}---\n{{end}}{{end}}{{ if ne .DisableApplyAll true }}{{ if and (gt (len .Results) 0) (not .PlansDeleted) }}* :fast_forward: To **apply** all unapplied plans from this pull request, comment:\n" +
+		"{{ if ne $disableApplyAll true }}---\n{{end}}{{end}}{{ if ne .DisableApplyAll true }}{{ if and (gt (len .Results) 0) (not .PlansDeleted) }}* :fast_forward: {{ t \"apply_all\" }}\n" +
 		"    * `atlantis apply`\n" +
-		"* :put_litter_in_its_place: To delete all plans and locks for the PR, comment:\n" +
+		"* :put_litter_in_its_place: {{ t \"delete_all\" }}\n" +
 		"    * `atlantis unlock`" +
 		"{{end}}{{end}}" +
 		logTmpl))
-var multiProjectApplyTmpl = template.Must(template.New("").Funcs(sprig.TxtFuncMap()).Parse(
-	"Ran {{.Command}} for {{ len .Results }} projects:\n\n" +
+var multiProjectApplyTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
+	"{{ t \"ran_command_for_projects\" .Command (len .Results) }}\n\n" +
 		"{{ range $result := .Results }}" +
-		"1. {{ if $result.ProjectName }}project: `{{$result.ProjectName}}` {{ end }}dir: `{{$result.RepoRelDir}}` workspace: `{{$result.Workspace}}`\n" +
+		"1. " + projectTmpl + "\n" +
 		"{{end}}\n" +
 		"{{ range $i, $result := .Results }}" +
-		"### {{add $i 1}}. {{ if $result.ProjectName }}project: `{{$result.ProjectName}}` {{ end }}dir: `{{$result.RepoRelDir}}` workspace: `{{$result.Workspace}}`\n" +
+		"### {{add $i 1}}. " + projectTmpl + "\n" +
 		"{{$result.Rendered}}\n\n" +
 		"---\n{{end}}" +
 		logTmpl))
-var multiProjectVersionTmpl = template.Must(template.New("").Funcs(sprig.TxtFuncMap()).Parse(
-	"Ran {{.Command}} for {{ len .Results }} projects:\n\n" +
+var multiProjectVersionTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
+	"{{ t \"ran_command_for_projects\" .Command (len .Results) }}\n\n" +
 		"{{ range $result := .Results }}" +
-		"1. {{ if $result.ProjectName }}project: `{{$result.ProjectName}}` {{ end }}dir: `{{$result.RepoRelDir}}` workspace: `{{$result.Workspace}}`\n" +
+		"1. " + projectTmpl + "\n" +
 		"{{end}}\n" +
 		"{{ range $i, $result := .Results }}" +
-		"### {{add $i 1}}. {{ if $result.ProjectName }}project: `{{$result.ProjectName}}` {{ end }}dir: `{{$result.RepoRelDir}}` workspace: `{{$result.Workspace}}`\n" +
+		"### {{add $i 1}}. " + projectTmpl + "\n" +
 		"{{$result.Rendered}}\n\n" +
 		"---\n{{end}}" +
 		logTmpl))
-var planSuccessUnwrappedTmpl = template.Must(template.New("").Parse(
+var planSuccessUnwrappedTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
 	"```diff\n" +
 		"{{ if .EnableDiffMarkdownFormat }}{{.DiffMarkdownFormattedTerraformOutput}}{{else}}{{.TerraformOutput}}{{end}}\n" +
-		"```\n\n" + planNextSteps +
-		"{{ if .HasDiverged }}\n\n:warning: The branch we're merging into is ahead, it is recommended to pull new commits first.{{end}}"))
+		"```\n\n" + planFullOutput + planNextSteps +
+		"{{ if .HasDiverged }}\n\n:warning: {{ t \"branch_diverged\" }}{{end}}"))
 
-var planSuccessWrappedTmpl = template.Must(template.New("").Parse(
-	"<details><summary>Show Output</summary>\n\n" +
+var planSuccessWrappedTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
+	"<details><summary>{{ t \"show_output\" }}</summary>\n\n" +
 		"```diff\n" +
 		"{{ if .EnableDiffMarkdownFormat }}{{.DiffMarkdownFormattedTerraformOutput}}{{else}}{{.TerraformOutput}}{{end}}\n" +
 		"```\n\n" +
+		planFullOutput +
 		planNextSteps + "\n" +
 		"</details>" + "\n" +
 		"{{.PlanSummary}}" +
-		"{{ if .HasDiverged }}\n\n:warning: The branch we're merging into is ahead, it is recommended to pull new commits first.{{end}}"))
+		"{{ if .HasDiverged }}\n\n:warning: {{ t \"branch_diverged\" }}{{end}}"))
 
-var policyCheckSuccessUnwrappedTmpl = template.Must(template.New("").Parse(
+var policyCheckSuccessUnwrappedTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
 	"```diff\n" +
 		"{{.PolicyCheckOutput}}\n" +
 		"```\n\n" + policyCheckNextSteps +
-		"{{ if .HasDiverged }}\n\n:warning: The branch we're merging into is ahead, it is recommended to pull new commits first.{{end}}"))
+		"{{ if .HasDiverged }}\n\n:warning: {{ t \"branch_diverged\" }}{{end}}"))
 
-var policyCheckSuccessWrappedTmpl = template.Must(template.New("").Parse(
-	"<details><summary>Show Output</summary>\n\n" +
+var policyCheckSuccessWrappedTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
+	"<details><summary>{{ t \"show_output\" }}</summary>\n\n" +
 		"```diff\n" +
 		"{{.PolicyCheckOutput}}\n" +
 		"```\n\n" +
 		policyCheckNextSteps + "\n" +
 		"</details>" +
-		"{{ if .HasDiverged }}\n\n:warning: The branch we're merging into is ahead, it is recommended to pull new commits first.{{end}}"))
+		"{{ if .HasDiverged }}\n\n:warning: {{ t \"branch_diverged\" }}{{end}}"))
 
 // policyCheckNextSteps are instructions appended after successful plans as to what
 // to do next.
-var policyCheckNextSteps = "* :arrow_forward: To **apply** this plan, comment:\n" +
+var policyCheckNextSteps = "* :arrow_forward: {{ t \"apply_plan\" }}\n" +
 	"    * `{{.ApplyCmd}}`\n" +
-	"* :put_litter_in_its_place: To **delete** this plan click [here]({{.LockURL}})\n" +
-	"* :repeat: To re-run policies **plan** this project again by commenting:\n" +
+	"* :put_litter_in_its_place: {{ t \"delete_plan\" .LockURL }}\n" +
+	"* :repeat: {{ t \"replan_policies\" }}\n" +
 	"    * `{{.RePlanCmd}}`"
 
+// planFullOutput is the collapsed unfiltered plan output, or a link to it if
+// it can't be collapsed, shown when the plan output has been filtered.
+var planFullOutput = "{{ if .FullTerraformOutput }}<details><summary>{{ t \"show_full_output\" }}</summary>\n\n" +
+	"```diff\n" +
+	"{{.FullTerraformOutput}}\n" +
+	"```\n" +
+	"</details>\n\n{{ else if .FullOutputURL }}{{ t \"view_full_output\" .FullOutputURL }}\n\n{{end}}"
+
 // planNextSteps are instructions appended after successful plans as to what
 // to do next.
-var planNextSteps = "{{ if .PlanWasDeleted }}This plan was not saved because one or more projects failed and automerge requires all plans pass.{{ else }}" +
-	"{{ if not .DisableApply }}* :arrow_forward: To **apply** this plan, comment:\n" +
+var planNextSteps = "{{ if .PlanWasDeleted }}{{ t \"plan_not_saved\" }}{{ else }}" +
+	"{{ if not .DisableApply }}* :arrow_forward: {{ t \"apply_plan\" }}\n" +
 	"    * `{{.ApplyCmd}}`\n{{end}}" +
-	"{{ if not .DisableRepoLocking }}* :put_litter_in_its_place: To **delete** this plan click [here]({{.LockURL}})\n{{end}}" +
-	"* :repeat: To **plan** this project again, comment:\n" +
+	"{{ if not .DisableRepoLocking }}* :put_litter_in_its_place: {{ t \"delete_plan\" .LockURL }}\n{{end}}" +
+	"{{ if .GraphURL }}* :world_map: {{ t \"view_graph\" .GraphURL }}\n{{end}}" +
+	"* :repeat: {{ t \"replan\" }}\n" +
 	"    * `{{.RePlanCmd}}`{{end}}"
-var applyUnwrappedSuccessTmpl = template.Must(template.New("").Parse(
+var applyUnwrappedSuccessTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
 	"```diff\n" +
 		"{{.Output}}\n" +
 		"```"))
-var applyWrappedSuccessTmpl = template.Must(template.New("").Parse(
-	"<details><summary>Show Output</summary>\n\n" +
+var applyWrappedSuccessTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
+	"<details><summary>{{ t \"show_output\" }}</summary>\n\n" +
 		"```diff\n" +
 		"{{.Output}}\n" +
 		"```\n" +
 		"</details>"))
-var versionUnwrappedSuccessTmpl = template.Must(template.New("").Parse("```\n{{.Output}}```"))
-var versionWrappedSuccessTmpl = template.Must(template.New("").Parse(
-	"<details><summary>Show Output</summary>\n\n" +
+var versionUnwrappedSuccessTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse("```\n{{.Output}}```"))
+var versionWrappedSuccessTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
+	"<details><summary>{{ t \"show_output\" }}</summary>\n\n" +
 		"```\n" +
 		"{{.Output}}" +
 		"```\n" +
 		"</details>"))
-var unwrappedErrTmplText = "**{{.Command}} Error**\n" +
+var unwrappedErrTmplText = "**{{ t \"command_error\" .Command }}**\n" +
 	"```\n" +
 	"{{.Error}}\n" +
 	"```" +
 	"{{ if eq .Command \"Policy Check\" }}" +
-	"\n* :heavy_check_mark: To **approve** failing policies an authorized approver can comment:\n" +
+	"\n* :heavy_check_mark: {{ t \"approve_policies\" }}\n" +
 	"    * `atlantis approve_policies`\n" +
-	"* :repeat: Or, address the policy failure by modifying the codebase and re-planning.\n" +
+	"* :repeat: {{ t \"address_policy_failure\" }}\n" +
 	"{{ end }}"
-var wrappedErrTmplText = "**{{.Command}} Error**\n" +
-	"<details><summary>Show Output</summary>\n\n" +
+var wrappedErrTmplText = "**{{ t \"command_error\" .Command }}**\n" +
+	"<details><summary>{{ t \"show_output\" }}</summary>\n\n" +
 	"```\n" +
 	"{{.Error}}\n" +
 	"```\n</details>"
-var unwrappedErrTmpl = template.Must(template.New("").Parse(unwrappedErrTmplText))
-var unwrappedErrWithLogTmpl = template.Must(template.New("").Parse(unwrappedErrTmplText + logTmpl))
-var wrappedErrTmpl = template.Must(template.New("").Parse(wrappedErrTmplText))
-var failureTmplText = "**{{.Command}} Failed**: {{.Failure}}"
-var failureTmpl = template.Must(template.New("").Parse(failureTmplText))
-var failureWithLogTmpl = template.Must(template.New("").Parse(failureTmplText + logTmpl))
-var logTmpl = "{{if .Verbose}}\n<details><summary>Log</summary>\n  <p>\n\n```\n{{.Log}}```\n</p></details>{{end}}\n"
+var unwrappedErrTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(unwrappedErrTmplText))
+var unwrappedErrWithLogTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(unwrappedErrTmplText + logTmpl))
+var wrappedErrTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(wrappedErrTmplText))
+var failureTmplText = "**{{ t \"command_failed\" .Command }}**: {{.Failure}}"
+var failureTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(failureTmplText))
+var failureWithLogTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(failureTmplText + logTmpl))
+var logTmpl = "{{if .Verbose}}\n<details><summary>{{ t \"log\" }}</summary>\n  <p>\n\n```\n{{.Log}}```\n</p></details>{{end}}\n"
+var statusCommentTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
+	"{{ .Marker }}\n" +
+		"### {{ t \"status_comment_title\" }}\n\n" +
+		"{{ if .Projects }}| {{ t \"project\" }} | {{ t \"dir\" }} | {{ t \"workspace\" }} | {{ t \"status\" }} | {{ t \"log\" }} |\n" +
+		"|---|---|---|---|---|\n" +
+		"{{ range .Projects }}| {{ if .ProjectName }}`{{ .ProjectName }}`{{ end }} | `{{ .RepoRelDir }}` | `{{ .Workspace }}` | {{ .Status }} | {{ if .JobURL }}[{{ t \"show_output\" }}]({{ .JobURL }}){{ end }} |\n{{ end }}" +
+		"{{ else }}{{ t \"status_comment_no_projects\" }}\n{{ end }}" +
+		"{{ if .Error }}\n**{{ t \"command_error\" .Command }}**\n```\n{{ .Error }}\n```\n" +
+		"{{ else if .Failure }}\n**{{ t \"command_failed\" .Command }}**: {{ .Failure }}\n{{ end }}"))
+var planSummaryCommentTmpl = template.Must(template.New("").Funcs(templateFuncs).Parse(
+	"{{ .Marker }}\n" +
+		"### {{ t \"plan_summary_title\" }}\n\n" +
+		"| {{ t \"project\" }} | {{ t \"dir\" }} | {{ t \"workspace\" }} | {{ t \"changes\" }} | {{ t \"log\" }} |\n" +
+		"|---|---|---|---|---|\n" +
+		"{{ range .Projects }}| {{ if .ProjectName }}`{{ .ProjectName }}`{{ end }} | `{{ .RepoRelDir }}` | `{{ .Workspace }}` | {{ .Changes }} | {{ if .JobURL }}[{{ t \"show_output\" }}]({{ .JobURL }}){{ end }} |\n{{ end }}"))
@@ -16,12 +16,15 @@ package events_test
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/i18n"
 	. "github.com/runatlantis/atlantis/testing"
 )
 
@@ -172,6 +175,43 @@ $$$
 * :repeat: To **plan** this project again, comment:
     * $atlantis plan -d path -w workspace$
 
+---
+* :fast_forward: To **apply** all unapplied plans from this pull request, comment:
+    * $atlantis apply$
+* :put_litter_in_its_place: To delete all plans and locks for the PR, comment:
+    * $atlantis unlock$
+`,
+		},
+		{
+			"single successful plan with graph",
+			command.Plan,
+			[]command.ProjectResult{
+				{
+					PlanSuccess: &models.PlanSuccess{
+						TerraformOutput: "terraform-output",
+						LockURL:         "lock-url",
+						RePlanCmd:       "atlantis plan -d path -w workspace",
+						ApplyCmd:        "atlantis apply -d path -w workspace",
+						GraphURL:        "graph-url",
+					},
+					Workspace:  "workspace",
+					RepoRelDir: "path",
+				},
+			},
+			models.Github,
+			`Ran Plan for dir: $path$ workspace: $workspace$
+
+$$$diff
+terraform-output
+$$$
+
+* :arrow_forward: To **apply** this plan, comment:
+    * $atlantis apply -d path -w workspace$
+* :put_litter_in_its_place: To **delete** this plan click [here](lock-url)
+* :world_map: To **view** the dependency graph of this project click [here](graph-url)
+* :repeat: To **plan** this project again, comment:
+    * $atlantis plan -d path -w workspace$
+
 ---
 * :fast_forward: To **apply** all unapplied plans from this pull request, comment:
     * $atlantis apply$
@@ -2437,3 +2477,286 @@ Plan: 1 to add, 1 to change, 1 to destroy.
 		})
 	}
 }
+
+func TestRenderProjectResults_FilterPlanOutput(t *testing.T) {
+	tfOutput := `null_resource.a: Refreshing state... [id=1]
+
+Terraform will perform the following actions:
+
++ null_resource.b
+
+Plan: 1 to add, 0 to change, 0 to destroy.`
+	cases := []struct {
+		Description string
+		VCSHost     models.VCSHostType
+		JobID       string
+		Expected    string
+	}{
+		{
+			"github shows full output in a collapsed block",
+			models.Github,
+			"1",
+			`Ran Plan for dir: $path$ workspace: $workspace$
+
+$$$diff
+Terraform will perform the following actions:
+
++ null_resource.b
+
+Plan: 1 to add, 0 to change, 0 to destroy.
+$$$
+
+<details><summary>Show Full Output</summary>
+
+$$$diff
+null_resource.a: Refreshing state... [id=1]
+
+Terraform will perform the following actions:
+
++ null_resource.b
+
+Plan: 1 to add, 0 to change, 0 to destroy.
+$$$
+</details>
+
+* :arrow_forward: To **apply** this plan, comment:
+    * $atlantis apply -d path -w workspace$
+* :put_litter_in_its_place: To **delete** this plan click [here](lock-url)
+* :repeat: To **plan** this project again, comment:
+    * $atlantis plan -d path -w workspace$
+
+---
+* :fast_forward: To **apply** all unapplied plans from this pull request, comment:
+    * $atlantis apply$
+* :put_litter_in_its_place: To delete all plans and locks for the PR, comment:
+    * $atlantis unlock$
+`,
+		},
+		{
+			"bitbucket links to full output",
+			models.BitbucketCloud,
+			"1",
+			`Ran Plan for dir: $path$ workspace: $workspace$
+
+$$$diff
+Terraform will perform the following actions:
+
++ null_resource.b
+
+Plan: 1 to add, 0 to change, 0 to destroy.
+$$$
+
+The output above was filtered, to **view** the full output click [here](https://atlantis/jobs/1)
+
+* :arrow_forward: To **apply** this plan, comment:
+    * $atlantis apply -d path -w workspace$
+* :put_litter_in_its_place: To **delete** this plan click [here](lock-url)
+* :repeat: To **plan** this project again, comment:
+    * $atlantis plan -d path -w workspace$
+
+---
+* :fast_forward: To **apply** all unapplied plans from this pull request, comment:
+    * $atlantis apply$
+* :put_litter_in_its_place: To delete all plans and locks for the PR, comment:
+    * $atlantis unlock$
+`,
+		},
+		{
+			"bitbucket only shows filtered output without a job",
+			models.BitbucketCloud,
+			"",
+			`Ran Plan for dir: $path$ workspace: $workspace$
+
+$$$diff
+Terraform will perform the following actions:
+
++ null_resource.b
+
+Plan: 1 to add, 0 to change, 0 to destroy.
+$$$
+
+* :arrow_forward: To **apply** this plan, comment:
+    * $atlantis apply -d path -w workspace$
+* :put_litter_in_its_place: To **delete** this plan click [here](lock-url)
+* :repeat: To **plan** this project again, comment:
+    * $atlantis plan -d path -w workspace$
+
+---
+* :fast_forward: To **apply** all unapplied plans from this pull request, comment:
+    * $atlantis apply$
+* :put_litter_in_its_place: To delete all plans and locks for the PR, comment:
+    * $atlantis unlock$
+`,
+		},
+	}
+	r := events.MarkdownRenderer{
+		FilterPlanOutput: true,
+		JobURLGenerator:  fakeJobURLGenerator{},
+	}
+	for _, c := range cases {
+		t.Run(c.Description, func(t *testing.T) {
+			res := command.Result{
+				ProjectResults: []command.ProjectResult{
+					{
+						Workspace:  "workspace",
+						RepoRelDir: "path",
+						JobID:      c.JobID,
+						PlanSuccess: &models.PlanSuccess{
+							TerraformOutput: tfOutput,
+							LockURL:         "lock-url",
+							RePlanCmd:       "atlantis plan -d path -w workspace",
+							ApplyCmd:        "atlantis apply -d path -w workspace",
+						},
+					},
+				},
+			}
+			s := r.Render(res, command.Plan, "log", false, c.VCSHost)
+			Equals(t, strings.Replace(c.Expected, "$", "`", -1), s)
+		})
+	}
+}
+
+func TestRenderProjectResults_TemplateOverrides(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "plan_success_unwrapped.tmpl"), []byte(
+		"{{ $stats := .Stats }}Adds {{ $stats.Add }}, changes {{ $stats.Change }}, destroys {{ $stats.Destroy }}. {{ .ApplyCmd | upper }}"), 0600)
+	Ok(t, err)
+	err = os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a template"), 0600)
+	Ok(t, err)
+
+	overrides, err := events.LoadMarkdownTemplateOverrides(dir)
+	Ok(t, err)
+	r := events.MarkdownRenderer{
+		DisableApplyAll:   true,
+		TemplateOverrides: overrides,
+	}
+	s := r.Render(command.Result{
+		ProjectResults: []command.ProjectResult{
+			{
+				Workspace:  "workspace",
+				RepoRelDir: "path",
+				PlanSuccess: &models.PlanSuccess{
+					TerraformOutput: "Plan: 1 to add, 2 to change, 3 to destroy.",
+					ApplyCmd:        "atlantis apply -d path",
+				},
+			},
+		},
+	}, command.Plan, "log", false, models.Github)
+	Equals(t, "Ran Plan for dir: `path` workspace: `workspace`\n\nAdds 1, changes 2, destroys 3. ATLANTIS APPLY -D PATH\n\n\n", s)
+}
+
+func TestRenderProjectResults_RepoTemplateOverrides(t *testing.T) {
+	serverDir := t.TempDir()
+	Ok(t, os.WriteFile(filepath.Join(serverDir, "multi_project_plan.tmpl"), []byte("server"), 0600))
+	Ok(t, os.WriteFile(filepath.Join(serverDir, "plan_success_unwrapped.tmpl"), []byte("plan"), 0600))
+	repoDir := t.TempDir()
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "multi_project_plan.tmpl"), []byte(
+		"{{ range .Results }}{{ .RepoRelDir }}: {{ .Stats }}, {{ .Rendered }}\n{{ end }}Total: {{ .Stats }}"), 0600))
+
+	serverOverrides, err := events.LoadMarkdownTemplateOverrides(serverDir)
+	Ok(t, err)
+	repoOverrides, err := events.LoadMarkdownTemplateOverrides(repoDir)
+	Ok(t, err)
+	r := &events.MarkdownRenderer{
+		DisableApplyAll:   true,
+		TemplateOverrides: serverOverrides,
+	}
+	res := command.Result{
+		ProjectResults: []command.ProjectResult{
+			{
+				Workspace:  "default",
+				RepoRelDir: "path",
+				PlanSuccess: &models.PlanSuccess{
+					TerraformOutput: "Plan: 1 to add, 2 to change, 3 to destroy.",
+				},
+			},
+			{
+				Workspace:  "default",
+				RepoRelDir: "path2",
+				PlanSuccess: &models.PlanSuccess{
+					TerraformOutput: "Plan: 4 to add, 0 to change, 0 to destroy.",
+				},
+			},
+		},
+	}
+
+	Equals(t, "server", r.Render(res, command.Plan, "log", false, models.Github))
+	Equals(t, "path: +1 ~2 -3, plan\npath2: +4 ~0 -0, plan\nTotal: +5 ~2 -3",
+		r.WithTemplateOverrides(repoOverrides).Render(res, command.Plan, "log", false, models.Github))
+	// The repo's overrides don't leak into the server's renderer.
+	Equals(t, "server", r.Render(res, command.Plan, "log", false, models.Github))
+}
+
+func TestLoadMarkdownTemplateOverrides_Errors(t *testing.T) {
+	cases := []struct {
+		description string
+		filename    string
+		contents    string
+		expErr      string
+	}{
+		{
+			"unknown template",
+			"plan_sucess_wrapped.tmpl",
+			"",
+			`"plan_sucess_wrapped.tmpl" does not override a known template`,
+		},
+		{
+			"invalid template",
+			"plan_success_wrapped.tmpl",
+			"{{ .TerraformOutput ",
+			"parsing plan_success_wrapped.tmpl",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			dir := t.TempDir()
+			Ok(t, os.WriteFile(filepath.Join(dir, c.filename), []byte(c.contents), 0600))
+			_, err := events.LoadMarkdownTemplateOverrides(dir)
+			ErrContains(t, c.expErr, err)
+		})
+	}
+
+	_, err := events.LoadMarkdownTemplateOverrides(filepath.Join(t.TempDir(), "missing"))
+	ErrContains(t, "reading markdown template overrides dir", err)
+}
+
+func TestRenderProjectResults_Locale(t *testing.T) {
+	catalog, err := i18n.NewCatalog("de")
+	Ok(t, err)
+	r := events.MarkdownRenderer{
+		Catalog: catalog,
+	}
+	s := r.Render(command.Result{
+		ProjectResults: []command.ProjectResult{
+			{
+				Workspace:  "workspace",
+				RepoRelDir: "path",
+				PlanSuccess: &models.PlanSuccess{
+					TerraformOutput: "terraform-output",
+					LockURL:         "lock-url",
+					RePlanCmd:       "atlantis plan -d path -w workspace",
+					ApplyCmd:        "atlantis apply -d path -w workspace",
+				},
+			},
+		},
+	}, command.Plan, "log", false, models.Github)
+	exp := `Plan ausgeführt für Verzeichnis: $path$ Workspace: $workspace$
+
+$$$diff
+terraform-output
+$$$
+
+* :arrow_forward: Um diesen Plan **anzuwenden**, kommentiere:
+    * $atlantis apply -d path -w workspace$
+* :put_litter_in_its_place: Um diesen Plan zu **löschen**, klicke [hier](lock-url)
+* :repeat: Um für dieses Projekt erneut einen **Plan** zu erstellen, kommentiere:
+    * $atlantis plan -d path -w workspace$
+
+---
+* :fast_forward: Um alle noch nicht angewendeten Pläne dieses Pull Requests **anzuwenden**, kommentiere:
+    * $atlantis apply$
+* :put_litter_in_its_place: Um alle Pläne und Sperren dieses Pull Requests zu löschen, kommentiere:
+    * $atlantis unlock$
+`
+	Equals(t, strings.Replace(exp, "$", "`", -1), s)
+}
@@ -42,6 +42,21 @@ func (mock *MockCommentParsing) Parse(comment string, vcsHost models.VCSHostType
 	return ret0
 }
 
+func (mock *MockCommentParsing) ParseForRepo(comment string, vcsHost models.VCSHostType, repo events.RepoHelp) events.CommentParseResult {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockCommentParsing().")
+	}
+	params := []pegomock.Param{comment, vcsHost, repo}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ParseForRepo", params, []reflect.Type{reflect.TypeOf((*events.CommentParseResult)(nil)).Elem()})
+	var ret0 events.CommentParseResult
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(events.CommentParseResult)
+		}
+	}
+	return ret0
+}
+
 func (mock *MockCommentParsing) VerifyWasCalledOnce() *VerifierMockCommentParsing {
 	return &VerifierMockCommentParsing{
 		mock:                   mock,
@@ -109,3 +124,38 @@ func (c *MockCommentParsing_Parse_OngoingVerification) GetAllCapturedArguments()
 	}
 	return
 }
+
+func (verifier *VerifierMockCommentParsing) ParseForRepo(comment string, vcsHost models.VCSHostType, repo events.RepoHelp) *MockCommentParsing_ParseForRepo_OngoingVerification {
+	params := []pegomock.Param{comment, vcsHost, repo}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ParseForRepo", params, verifier.timeout)
+	return &MockCommentParsing_ParseForRepo_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockCommentParsing_ParseForRepo_OngoingVerification struct {
+	mock              *MockCommentParsing
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockCommentParsing_ParseForRepo_OngoingVerification) GetCapturedArguments() (string, models.VCSHostType, events.RepoHelp) {
+	comment, vcsHost, repo := c.GetAllCapturedArguments()
+	return comment[len(comment)-1], vcsHost[len(vcsHost)-1], repo[len(repo)-1]
+}
+
+func (c *MockCommentParsing_ParseForRepo_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []models.VCSHostType, _param2 []events.RepoHelp) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+		_param1 = make([]models.VCSHostType, len(c.methodInvocations))
+		for u, param := range params[1] {
+			_param1[u] = param.(models.VCSHostType)
+		}
+		_param2 = make([]events.RepoHelp, len(c.methodInvocations))
+		for u, param := range params[2] {
+			_param2[u] = param.(events.RepoHelp)
+		}
+	}
+	return
+}
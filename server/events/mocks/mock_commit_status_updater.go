@@ -57,6 +57,21 @@ func (mock *MockCommitStatusUpdater) UpdateCombinedCount(repo models.Repo, pull
 	return ret0
 }
 
+func (mock *MockCommitStatusUpdater) UpdateCombinedNoProjects(repo models.Repo, pull models.PullRequest, cmdNames []command.Name) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockCommitStatusUpdater().")
+	}
+	params := []pegomock.Param{repo, pull, cmdNames}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("UpdateCombinedNoProjects", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
 func (mock *MockCommitStatusUpdater) UpdateProject(ctx command.ProjectContext, cmdName command.Name, status models.CommitStatus, url string) error {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockCommitStatusUpdater().")
@@ -195,6 +210,41 @@ func (c *MockCommitStatusUpdater_UpdateCombinedCount_OngoingVerification) GetAll
 	return
 }
 
+func (verifier *VerifierMockCommitStatusUpdater) UpdateCombinedNoProjects(repo models.Repo, pull models.PullRequest, cmdNames []command.Name) *MockCommitStatusUpdater_UpdateCombinedNoProjects_OngoingVerification {
+	params := []pegomock.Param{repo, pull, cmdNames}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdateCombinedNoProjects", params, verifier.timeout)
+	return &MockCommitStatusUpdater_UpdateCombinedNoProjects_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockCommitStatusUpdater_UpdateCombinedNoProjects_OngoingVerification struct {
+	mock              *MockCommitStatusUpdater
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockCommitStatusUpdater_UpdateCombinedNoProjects_OngoingVerification) GetCapturedArguments() (models.Repo, models.PullRequest, []command.Name) {
+	repo, pull, cmdNames := c.GetAllCapturedArguments()
+	return repo[len(repo)-1], pull[len(pull)-1], cmdNames[len(cmdNames)-1]
+}
+
+func (c *MockCommitStatusUpdater_UpdateCombinedNoProjects_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Repo, _param1 []models.PullRequest, _param2 [][]command.Name) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Repo, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Repo)
+		}
+		_param1 = make([]models.PullRequest, len(c.methodInvocations))
+		for u, param := range params[1] {
+			_param1[u] = param.(models.PullRequest)
+		}
+		_param2 = make([][]command.Name, len(c.methodInvocations))
+		for u, param := range params[2] {
+			_param2[u] = param.([]command.Name)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockCommitStatusUpdater) UpdateProject(ctx command.ProjectContext, cmdName command.Name, status models.CommitStatus, url string) *MockCommitStatusUpdater_UpdateProject_OngoingVerification {
 	params := []pegomock.Param{ctx, cmdName, status, url}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdateProject", params, verifier.timeout)
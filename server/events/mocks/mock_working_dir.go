@@ -140,6 +140,25 @@ func (mock *MockWorkingDir) IsFileTracked(log logging.SimpleLogging, cloneDir st
 	return ret0, ret1
 }
 
+func (mock *MockWorkingDir) WasForcePushed(log logging.SimpleLogging, p models.PullRequest, workspace string) (bool, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockWorkingDir().")
+	}
+	params := []pegomock.Param{log, p, workspace}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("WasForcePushed", params, []reflect.Type{reflect.TypeOf((*bool)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 bool
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(bool)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
 func (mock *MockWorkingDir) VerifyWasCalledOnce() *VerifierMockWorkingDir {
 	return &VerifierMockWorkingDir{
 		mock:                   mock,
@@ -413,3 +432,38 @@ func (c *MockWorkingDir_IsFileTracked_OngoingVerification) GetAllCapturedArgumen
 	}
 	return
 }
+
+func (verifier *VerifierMockWorkingDir) WasForcePushed(log logging.SimpleLogging, p models.PullRequest, workspace string) *MockWorkingDir_WasForcePushed_OngoingVerification {
+	params := []pegomock.Param{log, p, workspace}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "WasForcePushed", params, verifier.timeout)
+	return &MockWorkingDir_WasForcePushed_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockWorkingDir_WasForcePushed_OngoingVerification struct {
+	mock              *MockWorkingDir
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockWorkingDir_WasForcePushed_OngoingVerification) GetCapturedArguments() (logging.SimpleLogging, models.PullRequest, string) {
+	log, p, workspace := c.GetAllCapturedArguments()
+	return log[len(log)-1], p[len(p)-1], workspace[len(workspace)-1]
+}
+
+func (c *MockWorkingDir_WasForcePushed_OngoingVerification) GetAllCapturedArguments() (_param0 []logging.SimpleLogging, _param1 []models.PullRequest, _param2 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]logging.SimpleLogging, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(logging.SimpleLogging)
+		}
+		_param1 = make([]models.PullRequest, len(c.methodInvocations))
+		for u, param := range params[1] {
+			_param1[u] = param.(models.PullRequest)
+		}
+		_param2 = make([]string, len(c.methodInvocations))
+		for u, param := range params[2] {
+			_param2[u] = param.(string)
+		}
+	}
+	return
+}
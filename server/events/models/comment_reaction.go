@@ -0,0 +1,30 @@
+package models
+
+// CommentReaction is a reaction Atlantis leaves on the comment that triggered
+// a command so users know it's being handled before the result is posted.
+type CommentReaction int
+
+const (
+	// SeenCommentReaction means the command was picked up.
+	SeenCommentReaction CommentReaction = iota
+	// StartedCommentReaction means the command has started running.
+	StartedCommentReaction
+	// SuccessCommentReaction means the command completed without errors.
+	SuccessCommentReaction
+	// FailedCommentReaction means the command completed with errors.
+	FailedCommentReaction
+)
+
+func (r CommentReaction) String() string {
+	switch r {
+	case SeenCommentReaction:
+		return "seen"
+	case StartedCommentReaction:
+		return "started"
+	case SuccessCommentReaction:
+		return "success"
+	case FailedCommentReaction:
+		return "failed"
+	}
+	return "failed"
+}
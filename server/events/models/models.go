@@ -19,10 +19,13 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	paths "path"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -91,11 +94,12 @@ func NewRepo(vcsHostType VCSHostType, repoFullName string, cloneURL string, vcsU
 	}
 
 	// Ensure the Clone URL is for the same repo to avoid something malicious.
-	// We skip this check for Bitbucket Server because its format is different
-	// and because the caller in that case actually constructs the clone url
-	// from the repo name and so there's no point checking if they match.
+	// We skip this check for Bitbucket Server and Gerrit because their
+	// formats are different and because the caller in those cases actually
+	// constructs the clone url from the repo name and so there's no point
+	// checking if they match.
 	// Azure DevOps also does not require .git at the end of clone urls.
-	if vcsHostType != BitbucketServer && vcsHostType != AzureDevops {
+	if vcsHostType != BitbucketServer && vcsHostType != AzureDevops && vcsHostType != Gerrit {
 		expClonePath := fmt.Sprintf("/%s.git", repoFullName)
 		if expClonePath != cloneURLParsed.Path {
 			return Repo{}, fmt.Errorf("expected clone url to have path %q but had %q", expClonePath, cloneURLParsed.Path)
@@ -109,6 +113,12 @@ func NewRepo(vcsHostType VCSHostType, repoFullName string, cloneURL string, vcsU
 	escapedVCSToken := url.QueryEscape(vcsToken)
 	auth := fmt.Sprintf("%s:%s@", escapedVCSUser, escapedVCSToken)
 	redactedAuth := fmt.Sprintf("%s:<redacted>@", escapedVCSUser)
+	if vcsUser != "" && vcsToken == "" {
+		// Without a password in the URL, git gets it from the credential
+		// helper, ex. for access tokens that expire.
+		auth = escapedVCSUser + "@"
+		redactedAuth = auth
+	}
 
 	// Construct clone urls with http and https auth. Need to do both
 	// because Bitbucket supports http.
@@ -122,9 +132,10 @@ func NewRepo(vcsHostType VCSHostType, repoFullName string, cloneURL string, vcsU
 	if owner == "" || repo == "" {
 		return Repo{}, fmt.Errorf("invalid repo format %q, owner %q or repo %q was empty", repoFullName, owner, repo)
 	}
-	// Only GitLab and AzureDevops repos can have /'s in their owners.
-	// This is for GitLab subgroups and Azure DevOps Team Projects.
-	if strings.Contains(owner, "/") && vcsHostType != Gitlab && vcsHostType != AzureDevops {
+	// Only GitLab, AzureDevops and Gerrit repos can have /'s in their owners.
+	// This is for GitLab subgroups, Azure DevOps Team Projects and nested
+	// Gerrit projects.
+	if strings.Contains(owner, "/") && vcsHostType != Gitlab && vcsHostType != AzureDevops && vcsHostType != Gerrit {
 		return Repo{}, fmt.Errorf("invalid repo format %q, owner %q should not contain any /'s", repoFullName, owner)
 	}
 	if strings.Contains(repo, "/") {
@@ -175,6 +186,8 @@ type PullRequest struct {
 	// Gitlab supports an additional "merged" state but Github doesn't so we map
 	// merged to Closed.
 	State PullRequestState
+	// Merged is true if the pull request is closed because it was merged.
+	Merged bool
 	// BaseRepo is the repository that the pull request will be merged into.
 	BaseRepo Repo
 }
@@ -239,6 +252,634 @@ type ProjectLock struct {
 	Time time.Time
 }
 
+// Freeze pauses applies for all repos, a single repo or a single project in
+// a repo.
+type Freeze struct {
+	// RepoFullName is the owner and repo name of the frozen repo, ex.
+	// "runatlantis/atlantis". If empty, all repos are frozen.
+	RepoFullName string
+	// Project is the name or directory of the frozen project. If empty, all
+	// projects in the repo are frozen.
+	Project string
+	// Message is shown on pull requests that try to apply while frozen.
+	Message string
+	// Time is the time at which the freeze was created.
+	Time time.Time
+}
+
+// Matches returns true if the freeze applies to the project with name
+// projectName or directory repoRelDir in the repo repoFullName.
+func (f Freeze) Matches(repoFullName string, projectName string, repoRelDir string) bool {
+	if f.RepoFullName == "" {
+		return true
+	}
+	if !strings.EqualFold(f.RepoFullName, repoFullName) {
+		return false
+	}
+	return f.Project == "" || f.Project == projectName || f.Project == repoRelDir
+}
+
+// ProjectInventory records the Terraform providers and modules a project used
+// when it was last planned or applied so that they can be audited.
+type ProjectInventory struct {
+	// RepoFullName is the owner and repo name of the repo, ex.
+	// "runatlantis/atlantis".
+	RepoFullName string
+	// ProjectName is the name of the project. Empty if the project isn't
+	// named.
+	ProjectName string
+	// Path is the path to the project relative to the repo root.
+	Path string
+	// Workspace is the Terraform workspace of the project.
+	Workspace string
+	// PullNum is the pull request the project was last planned or applied
+	// in.
+	PullNum int
+	// HeadCommit is the commit that was planned.
+	HeadCommit string
+	// Applied is true if the plan was applied. Otherwise the providers and
+	// modules may change before the pull request is merged.
+	Applied bool
+	// Providers are the providers in the project's dependency lock file.
+	Providers []InventoryProvider
+	// Modules are the modules the project calls.
+	Modules []InventoryModule
+	// Time is the time at which the project was planned or applied.
+	Time time.Time
+}
+
+// UsesProvider returns true if the project uses the provider with source
+// address source. source can omit the registry hostname, ex. "hashicorp/aws",
+// but not the namespace.
+// If version is set, the project must also use that version of the provider.
+func (i ProjectInventory) UsesProvider(source string, version string) bool {
+	for _, p := range i.Providers {
+		if !strings.EqualFold(p.Source, source) && !(strings.Contains(source, "/") && strings.HasSuffix(strings.ToLower(p.Source), "/"+strings.ToLower(source))) {
+			continue
+		}
+		if version == "" || p.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesModule returns true if the project calls a module with source source.
+// Like UsesProvider, registry modules can omit the registry hostname.
+// If version is set, the project must also use that version of the module.
+func (i ProjectInventory) UsesModule(source string, version string) bool {
+	for _, m := range i.Modules {
+		if m.Source != source && !(strings.Contains(source, "/") && strings.HasSuffix(m.Source, "/"+source)) {
+			continue
+		}
+		if version == "" || m.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// InventoryProvider is a Terraform provider used by a project.
+type InventoryProvider struct {
+	// Source is the fully qualified source address of the provider, ex.
+	// "registry.terraform.io/hashicorp/aws".
+	Source string
+	// Version is the exact version of the provider that was selected.
+	Version string
+	// Constraints are the version constraints the project's configuration
+	// puts on the provider, ex. "~> 4.0". Empty if it isn't constrained.
+	Constraints string `json:",omitempty"`
+}
+
+// InventoryModule is a Terraform module called by a project.
+type InventoryModule struct {
+	// Key identifies the module call, ex. "vpc" or "vpc.subnets" for a nested
+	// module.
+	Key string
+	// Source is the source of the module.
+	Source string
+	// Version is the version of registry modules. Empty for other modules.
+	Version string
+}
+
+// ProjectRunOutcome is the outcome of a plan or apply that ran Terraform.
+type ProjectRunOutcome string
+
+const (
+	SuccessProjectRunOutcome ProjectRunOutcome = "success"
+	FailedProjectRunOutcome  ProjectRunOutcome = "failed"
+)
+
+// ProjectRun records the outcome of the last plan or apply of a project so that
+// the project's status can be reported outside of pull requests.
+type ProjectRun struct {
+	// RepoFullName is the owner and repo name of the repo, ex.
+	// "runatlantis/atlantis".
+	RepoFullName string
+	// ProjectName is the name of the project. Empty if the project isn't
+	// named.
+	ProjectName string
+	// Path is the path to the project relative to the repo root.
+	Path string
+	// Workspace is the Terraform workspace of the project.
+	Workspace string
+	// Command is the command that ran, "plan" or "apply".
+	Command string
+	// Outcome is whether the command succeeded.
+	Outcome ProjectRunOutcome
+	// PullNum is the pull request the command ran in.
+	PullNum int
+	// HeadCommit is the commit the command ran on.
+	HeadCommit string
+	// Summary is the plan's summary line, ex. "Plan: 1 to add, 0 to change,
+	// 0 to destroy.". Empty for applies.
+	Summary string `json:",omitempty"`
+	// Drifted is true if the plan found objects that changed outside of
+	// Terraform. Always false for applies.
+	Drifted bool
+	// Time is the time at which the command finished.
+	Time time.Time
+	// Duration is how long the command ran.
+	Duration time.Duration `json:",omitempty"`
+	// Durations are how long the project's last runs of the command took,
+	// oldest first and including this run. Stores keep up to
+	// MaxRunDurations of them.
+	Durations []RunDuration `json:",omitempty"`
+	// JobID is the ID of the job that streamed the command's output. Empty
+	// if the output wasn't streamed.
+	JobID string `json:",omitempty"`
+	// Outputs are the outputs of the project's root module after the
+	// apply, by name. Nil for plans and if the outputs weren't captured.
+	Outputs map[string]TerraformOutput `json:",omitempty"`
+}
+
+// TerraformOutput is an output of a project's root module, as reported by
+// terraform output -json.
+type TerraformOutput struct {
+	Sensitive bool `json:"sensitive"`
+	// Type is the output's type in Terraform's JSON type syntax, ex.
+	// "string" or ["list","string"].
+	Type json.RawMessage `json:"type,omitempty"`
+	// Value is the output's value. It's left out of sensitive outputs so
+	// that secrets aren't stored or shown.
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// RunDuration is how long a run of a project's command took.
+type RunDuration struct {
+	// Time is the time at which the run finished.
+	Time     time.Time
+	Duration time.Duration
+}
+
+// MaxRunDurations is how many durations of a project's runs of a command are
+// kept.
+const MaxRunDurations = 100
+
+// recentRuns is how many of a project's most recent runs of a command are
+// used to estimate how long its next run takes.
+const recentRuns = 10
+
+// WithDurations returns r with the durations of previous, the project's
+// previous run of the command, and r's duration in Durations. previous is
+// nil if the project hasn't run the command.
+func (r ProjectRun) WithDurations(previous *ProjectRun) ProjectRun {
+	var durations []RunDuration
+	if previous != nil {
+		durations = append(durations, previous.Durations...)
+	}
+	if r.Duration > 0 {
+		durations = append(durations, RunDuration{Time: r.Time, Duration: r.Duration})
+	}
+	if len(durations) > MaxRunDurations {
+		durations = durations[len(durations)-MaxRunDurations:]
+	}
+	r.Durations = durations
+	return r
+}
+
+// ExpectedDuration returns the average duration of the project's recent runs
+// of the command, or 0 if they aren't known.
+func (r ProjectRun) ExpectedDuration() time.Duration {
+	recent := r.recentDurations()
+	if len(recent) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, d := range recent {
+		total += d.Duration
+	}
+	return total / time.Duration(len(recent))
+}
+
+// DurationStats summarizes how long a project's runs of a command took.
+type DurationStats struct {
+	// Runs is how many runs the stats are computed from.
+	Runs int
+	// P50 and P95 are the median and 95th percentile durations of the runs.
+	P50 time.Duration
+	P95 time.Duration
+	// RecentP50 is the median duration of the 10 most recent runs. If it's
+	// higher than P50, the runs are getting slower.
+	RecentP50 time.Duration
+}
+
+// DurationStats returns the stats of the durations of the project's runs of
+// the command.
+func (r ProjectRun) DurationStats() DurationStats {
+	return DurationStats{
+		Runs:      len(r.Durations),
+		P50:       durationPercentile(r.Durations, 50),
+		P95:       durationPercentile(r.Durations, 95),
+		RecentP50: durationPercentile(r.recentDurations(), 50),
+	}
+}
+
+// GettingSlower returns true if the recent runs are at least 20% slower than
+// the median run.
+func (s DurationStats) GettingSlower() bool {
+	return s.Runs > recentRuns && s.RecentP50 > s.P50+s.P50/5
+}
+
+func (r ProjectRun) recentDurations() []RunDuration {
+	if len(r.Durations) > recentRuns {
+		return r.Durations[len(r.Durations)-recentRuns:]
+	}
+	return r.Durations
+}
+
+// durationPercentile returns the nearest-rank percentile of durations, or 0
+// if there are none.
+func durationPercentile(durations []RunDuration, percentile int) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, 0, len(durations))
+	for _, d := range durations {
+		sorted = append(sorted, d.Duration)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := (percentile*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// ProjectRunStatus is the status of a project built from its last plan and
+// apply.
+type ProjectRunStatus struct {
+	// RepoFullName is the owner and repo name of the repo, ex.
+	// "runatlantis/atlantis".
+	RepoFullName string
+	// ProjectName is the name of the project. Empty if the project isn't
+	// named.
+	ProjectName string
+	// Path is the path to the project relative to the repo root.
+	Path string
+	// Workspace is the Terraform workspace of the project.
+	Workspace string
+	// LastPlan is the last plan of the project. Nil if it hasn't been
+	// planned.
+	LastPlan *ProjectRun
+	// LastApply is the last apply of the project. Nil if it hasn't been
+	// applied.
+	LastApply *ProjectRun
+}
+
+// NewProjectRunStatuses groups runs by project and returns the status of each
+// project, sorted by repo, path, workspace and project name.
+func NewProjectRunStatuses(runs []ProjectRun) []ProjectRunStatus {
+	byKey := make(map[string]*ProjectRunStatus)
+	var keys []string
+	for i := range runs {
+		run := runs[i]
+		key := strings.Join([]string{run.RepoFullName, run.Path, run.Workspace, run.ProjectName}, "::")
+		status, ok := byKey[key]
+		if !ok {
+			status = &ProjectRunStatus{
+				RepoFullName: run.RepoFullName,
+				ProjectName:  run.ProjectName,
+				Path:         run.Path,
+				Workspace:    run.Workspace,
+			}
+			byKey[key] = status
+			keys = append(keys, key)
+		}
+		switch run.Command {
+		case "plan":
+			if status.LastPlan == nil || run.Time.After(status.LastPlan.Time) {
+				status.LastPlan = &run
+			}
+		case "apply":
+			if status.LastApply == nil || run.Time.After(status.LastApply.Time) {
+				status.LastApply = &run
+			}
+		}
+	}
+	sort.Strings(keys)
+	statuses := make([]ProjectRunStatus, 0, len(keys))
+	for _, key := range keys {
+		statuses = append(statuses, *byKey[key])
+	}
+	return statuses
+}
+
+// Matches returns true if the status is for the project with name or
+// directory project in the repo repoFullName and, if workspace is set, in
+// that workspace.
+func (s ProjectRunStatus) Matches(repoFullName string, project string, workspace string) bool {
+	if !strings.EqualFold(s.RepoFullName, repoFullName) {
+		return false
+	}
+	if workspace != "" && s.Workspace != workspace {
+		return false
+	}
+	return s.ProjectName == project || (s.ProjectName == "" && s.Path == project)
+}
+
+// PlanDurations returns the stats of the durations of the project's plans.
+func (s ProjectRunStatus) PlanDurations() DurationStats {
+	if s.LastPlan == nil {
+		return DurationStats{}
+	}
+	return s.LastPlan.DurationStats()
+}
+
+// ApplyDurations returns the stats of the durations of the project's applies.
+func (s ProjectRunStatus) ApplyDurations() DurationStats {
+	if s.LastApply == nil {
+		return DurationStats{}
+	}
+	return s.LastApply.DurationStats()
+}
+
+// SortBySlowest returns the statuses of the projects whose run durations are
+// known, sorted by the 95th percentile duration of their plans or applies,
+// whichever is slower, slowest first.
+func SortBySlowest(statuses []ProjectRunStatus) []ProjectRunStatus {
+	slowest := func(s ProjectRunStatus) time.Duration {
+		if s.ApplyDurations().P95 > s.PlanDurations().P95 {
+			return s.ApplyDurations().P95
+		}
+		return s.PlanDurations().P95
+	}
+	var sorted []ProjectRunStatus
+	for _, s := range statuses {
+		if slowest(s) > 0 {
+			sorted = append(sorted, s)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return slowest(sorted[i]) > slowest(sorted[j]) })
+	return sorted
+}
+
+// Drift returns "drifted" if the last plan found objects that changed outside
+// of Terraform, "none" if it didn't and "unknown" if the last plan failed or
+// the project hasn't been planned.
+func (s ProjectRunStatus) Drift() string {
+	if s.LastPlan == nil || s.LastPlan.Outcome != SuccessProjectRunOutcome {
+		return "unknown"
+	}
+	if s.LastPlan.Drifted {
+		return "drifted"
+	}
+	return "none"
+}
+
+// State summarizes the status in a few words based on the project's latest
+// run, ex. "applied" or "plan failed".
+func (s ProjectRunStatus) State() string {
+	switch {
+	case s.LastApply != nil && (s.LastPlan == nil || !s.LastPlan.Time.After(s.LastApply.Time)):
+		if s.LastApply.Outcome == SuccessProjectRunOutcome {
+			return "applied"
+		}
+		return "apply failed"
+	case s.LastPlan != nil:
+		if s.LastPlan.Outcome != SuccessProjectRunOutcome {
+			return "plan failed"
+		}
+		if s.LastPlan.Drifted {
+			return "drifted"
+		}
+		return "planned"
+	default:
+		return "unknown"
+	}
+}
+
+// LockAttempt is a pull request trying to lock a project's workspace.
+type LockAttempt struct {
+	// RepoFullName is the owner and repo name of the repo, ex.
+	// "runatlantis/atlantis".
+	RepoFullName string
+	// Path is the path to the project relative to the repo root.
+	Path string
+	// Workspace is the Terraform workspace of the project.
+	Workspace string
+	// PullNum is the pull request that tried to lock the project.
+	PullNum int
+	// BlockingPullNum is the pull request that held the lock. 0 if the lock
+	// was acquired.
+	BlockingPullNum int
+	// Time is the time of the attempt.
+	Time time.Time
+}
+
+// LockContention records the pull requests that were blocked by another pull
+// request's lock on a project's workspace and how long they waited for it.
+type LockContention struct {
+	// RepoFullName is the owner and repo name of the repo, ex.
+	// "runatlantis/atlantis".
+	RepoFullName string
+	// Path is the path to the project relative to the repo root.
+	Path string
+	// Workspace is the Terraform workspace of the project.
+	Workspace string
+	// Waits are the most recent waits for the lock, oldest first. There are
+	// at most MaxLockWaits of them.
+	Waits []LockWait
+}
+
+// LockWait is a pull request waiting for a lock held by another pull request.
+type LockWait struct {
+	// PullNum is the pull request that was blocked.
+	PullNum int
+	// BlockingPullNum is the pull request that held the lock when PullNum was
+	// first blocked.
+	BlockingPullNum int
+	// Attempts is how many times PullNum tried to lock the project while it
+	// was blocked.
+	Attempts int
+	// Since is when PullNum was first blocked.
+	Since time.Time
+	// LastAttempt is when PullNum last tried to lock the project.
+	LastAttempt time.Time
+	// Until is when PullNum acquired the lock or gave up. It's zero while
+	// PullNum is blocked.
+	Until time.Time
+}
+
+// MaxLockWaits is how many waits for a project's lock are kept.
+const MaxLockWaits = 100
+
+// abandonedLockWait is how long after its last attempt a pull request that's
+// still blocked is considered to have given up, ex. because it was closed.
+const abandonedLockWait = 7 * 24 * time.Hour
+
+// Blocked returns true if the pull request is still waiting for the lock.
+func (w LockWait) Blocked() bool {
+	return w.Until.IsZero()
+}
+
+// end returns when the wait ended, or now if it hasn't.
+func (w LockWait) end(now time.Time) time.Time {
+	if w.Blocked() {
+		return now
+	}
+	return w.Until
+}
+
+// WithAttempt returns c with attempt recorded. A blocked attempt starts a
+// wait for its pull request unless one is already in progress and an attempt
+// that acquired the lock ends it. Waits whose last attempt was too long ago
+// end at that attempt. It returns false if c didn't change.
+func (c LockContention) WithAttempt(attempt LockAttempt) (LockContention, bool) {
+	c.RepoFullName = attempt.RepoFullName
+	c.Path = attempt.Path
+	c.Workspace = attempt.Workspace
+	changed := false
+	waiting := false
+	waits := make([]LockWait, 0, len(c.Waits)+1)
+	for _, wait := range c.Waits {
+		switch {
+		case !wait.Blocked():
+		case wait.PullNum == attempt.PullNum:
+			waiting = true
+			changed = true
+			if attempt.BlockingPullNum == 0 {
+				wait.Until = attempt.Time
+			} else {
+				wait.Attempts++
+				wait.LastAttempt = attempt.Time
+			}
+		case attempt.Time.Sub(wait.LastAttempt) > abandonedLockWait:
+			changed = true
+			wait.Until = wait.LastAttempt
+		}
+		waits = append(waits, wait)
+	}
+	if !waiting && attempt.BlockingPullNum != 0 {
+		changed = true
+		waits = append(waits, LockWait{
+			PullNum:         attempt.PullNum,
+			BlockingPullNum: attempt.BlockingPullNum,
+			Attempts:        1,
+			Since:           attempt.Time,
+			LastAttempt:     attempt.Time,
+		})
+	}
+	if len(waits) > MaxLockWaits {
+		waits = waits[len(waits)-MaxLockWaits:]
+	}
+	c.Waits = waits
+	return c, changed
+}
+
+// Waited returns how many pull requests waited for the lock between since and
+// now and how long they waited in total during that time.
+func (c LockContention) Waited(since time.Time, now time.Time) (blocked int, waited time.Duration) {
+	for _, wait := range c.Waits {
+		start, end := wait.Since, wait.end(now)
+		if !end.After(since) || !start.Before(now) {
+			continue
+		}
+		if start.Before(since) {
+			start = since
+		}
+		if end.After(now) {
+			end = now
+		}
+		blocked++
+		waited += end.Sub(start)
+	}
+	return blocked, waited
+}
+
+// BlockedPerDay returns how many pull requests were waiting for the lock on
+// each of the last days days up to now, oldest first. Days start at midnight
+// in now's location.
+func (c LockContention) BlockedPerDay(now time.Time, days int) []int {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	blocked := make([]int, days)
+	for i := range blocked {
+		start := today.AddDate(0, 0, i-days+1)
+		end := start.AddDate(0, 0, 1)
+		for _, wait := range c.Waits {
+			if wait.Since.Before(end) && !wait.end(now).Before(start) {
+				blocked[i]++
+			}
+		}
+	}
+	return blocked
+}
+
+// BlockedPulls returns the pull requests that are waiting for the lock.
+func (c LockContention) BlockedPulls() []int {
+	var pulls []int
+	for _, wait := range c.Waits {
+		if wait.Blocked() {
+			pulls = append(pulls, wait.PullNum)
+		}
+	}
+	return pulls
+}
+
+// SortByContention returns the contention of the locks that blocked pull
+// requests between since and now, sorted by how long pull requests waited for
+// them, longest first.
+func SortByContention(contention []LockContention, since time.Time, now time.Time) []LockContention {
+	waited := func(c LockContention) time.Duration {
+		_, w := c.Waited(since, now)
+		return w
+	}
+	var sorted []LockContention
+	for _, c := range contention {
+		if blocked, _ := c.Waited(since, now); blocked > 0 {
+			sorted = append(sorted, c)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return waited(sorted[i]) > waited(sorted[j]) })
+	return sorted
+}
+
+// Job is the output of a completed job, ex. a project's plan, that's persisted
+// so that it can be viewed after Atlantis restarts or the pull request is
+// closed.
+type Job struct {
+	// ID is the ID of the job in its URL.
+	ID string
+	// Repo is the name of the repo, ex. "atlantis".
+	Repo string
+	// PullNum is the pull request the job ran in.
+	PullNum int
+	// ProjectName is the name of the project. Empty if the project isn't
+	// named.
+	ProjectName string
+	// Workspace is the Terraform workspace of the project.
+	Workspace string
+	// HeadCommit is the commit the job ran on.
+	HeadCommit string
+	// Lines are the lines of the job's output.
+	Lines []string
+	// TruncatedLines is the number of lines that were dropped from the start
+	// of the output to keep it under the maximum log size.
+	TruncatedLines int `json:",omitempty"`
+	// CompletedAt is the time at which the job completed.
+	CompletedAt time.Time
+}
+
 // Project represents a Terraform project. Since there may be multiple
 // Terraform projects in a single repo we also include Path to the project
 // root relative to the repo root.
@@ -299,6 +940,7 @@ const (
 	BitbucketCloud
 	BitbucketServer
 	AzureDevops
+	Gerrit
 )
 
 func (h VCSHostType) String() string {
@@ -313,6 +955,8 @@ func (h VCSHostType) String() string {
 		return "BitbucketServer"
 	case AzureDevops:
 		return "AzureDevops"
+	case Gerrit:
+		return "Gerrit"
 	}
 	return "<missing String() implementation>"
 }
@@ -329,6 +973,8 @@ func NewVCSHostType(t string) (VCSHostType, error) {
 		return BitbucketServer, nil
 	case "AzureDevops":
 		return AzureDevops, nil
+	case "Gerrit":
+		return Gerrit, nil
 	}
 
 	return -1, fmt.Errorf("%q is not a valid type", t)
@@ -364,6 +1010,9 @@ type PlanSuccess struct {
 	// branch we're merging into has been updated since we cloned and merged
 	// it.
 	HasDiverged bool
+	// GraphURL is the URL of the dependency graph rendered by the graph
+	// step, or empty if the project's workflow doesn't have one.
+	GraphURL string
 }
 
 // Summary extracts one line summary of plan changes from TerraformOutput.
@@ -382,6 +1031,131 @@ func (p *PlanSuccess) Summary() string {
 	return note + r.FindString(p.TerraformOutput)
 }
 
+// PlanSuccessStats holds the number of resources a plan will change.
+type PlanSuccessStats struct {
+	Import  int
+	Add     int
+	Change  int
+	Destroy int
+	// Changes is true if the plan has any changes.
+	Changes bool
+}
+
+// String returns a short summary of the stats, ex. "+3 ~1 -0".
+func (s PlanSuccessStats) String() string {
+	if !s.Changes {
+		return "no changes"
+	}
+	summary := fmt.Sprintf("+%d ~%d -%d", s.Add, s.Change, s.Destroy)
+	if s.Import > 0 {
+		summary = fmt.Sprintf("%s, %d to import", summary, s.Import)
+	}
+	return summary
+}
+
+// Drifted returns true if terraform plan found objects that changed outside
+// of Terraform.
+func (p PlanSuccess) Drifted() bool {
+	return strings.Contains(p.TerraformOutput, "Objects have changed outside of Terraform")
+}
+
+// Stats parses the plan summary line of TerraformOutput into PlanSuccessStats.
+func (p PlanSuccess) Stats() PlanSuccessStats {
+	r := regexp.MustCompile(`Plan: (?:(\d+) to import, )?(\d+) to add, (\d+) to change, (\d+) to destroy.`)
+	match := r.FindStringSubmatch(p.TerraformOutput)
+	if match == nil {
+		return PlanSuccessStats{}
+	}
+	// We can ignore the errors since the regex only matches digits.
+	imp, _ := strconv.Atoi(match[1])
+	add, _ := strconv.Atoi(match[2])
+	change, _ := strconv.Atoi(match[3])
+	destroy, _ := strconv.Atoi(match[4])
+	return PlanSuccessStats{
+		Import:  imp,
+		Add:     add,
+		Change:  change,
+		Destroy: destroy,
+		Changes: imp+add+change+destroy > 0,
+	}
+}
+
+// PlanResourceChange is a change that a plan makes to a resource, from the
+// resource_changes of the plan's JSON representation.
+type PlanResourceChange struct {
+	Address string `json:"address"`
+	Change  struct {
+		// Actions are the actions Terraform takes on the resource, ex.
+		// ["update"], or ["delete", "create"] if it's replaced.
+		Actions []string `json:"actions"`
+	} `json:"change"`
+}
+
+// PlanResourceChanges are the changes that a plan makes to its resources.
+type PlanResourceChanges []PlanResourceChange
+
+// ParsePlanResourceChanges parses the resource_changes of showJSON, the output
+// of terraform show -json for a plan.
+func ParsePlanResourceChanges(showJSON []byte) (PlanResourceChanges, error) {
+	var plan struct {
+		ResourceChanges PlanResourceChanges `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(showJSON, &plan); err != nil {
+		return nil, errors.Wrap(err, "parsing plan JSON")
+	}
+	return plan.ResourceChanges, nil
+}
+
+// DestroyedAddresses returns the addresses of the resources that the plan
+// destroys or replaces.
+func (c PlanResourceChanges) DestroyedAddresses() []string {
+	var addresses []string
+	for _, rc := range c {
+		for _, action := range rc.Change.Actions {
+			if action == "delete" {
+				addresses = append(addresses, rc.Address)
+				break
+			}
+		}
+	}
+	return addresses
+}
+
+// ChangedAddresses returns the addresses of the resources that the plan
+// creates, updates, destroys or replaces.
+func (c PlanResourceChanges) ChangedAddresses() []string {
+	var addresses []string
+	for _, rc := range c {
+		if rc.count() > 0 {
+			addresses = append(addresses, rc.Address)
+		}
+	}
+	return addresses
+}
+
+// Count returns the number of changes that the plan makes. A resource that's
+// replaced counts twice since it's both created and destroyed.
+func (c PlanResourceChanges) Count() int {
+	count := 0
+	for _, rc := range c {
+		count += rc.count()
+	}
+	return count
+}
+
+// count returns the number of create, update and delete actions on the
+// resource. Reads and no-ops don't change anything.
+func (rc PlanResourceChange) count() int {
+	count := 0
+	for _, action := range rc.Change.Actions {
+		switch action {
+		case "create", "update", "delete":
+			count++
+		}
+	}
+	return count
+}
+
 // DiffMarkdownFormattedTerraformOutput formats the Terraform output to match diff markdown format
 func (p PlanSuccess) DiffMarkdownFormattedTerraformOutput() string {
 	diffKeywordRegex := regexp.MustCompile(`(?m)^( +)([-+~]\s)(.*)(\s=\s|\s->\s|<<|\{|\(known after apply\)|\[)(.*)`)
@@ -395,6 +1169,21 @@ func (p PlanSuccess) DiffMarkdownFormattedTerraformOutput() string {
 	return formattedTerraformOutput
 }
 
+// FilteredTerraformOutput returns the Terraform output with the refresh noise
+// and hidden unchanged attribute markers removed so that only the resource
+// changes and the plan summary remain.
+func (p PlanSuccess) FilteredTerraformOutput() string {
+	noiseRegex := regexp.MustCompile(`(?m)^.*(: Refreshing state\.\.\.|: Reading\.\.\.|: Read complete after ).*\n?`)
+	unchangedRegex := regexp.MustCompile(`(?m)^\s*# \(\d+ unchanged (attribute|block|element)s? hidden\)\n?`)
+	blankLinesRegex := regexp.MustCompile(`\n{3,}`)
+
+	filteredTerraformOutput := noiseRegex.ReplaceAllString(p.TerraformOutput, "")
+	filteredTerraformOutput = unchangedRegex.ReplaceAllString(filteredTerraformOutput, "")
+	filteredTerraformOutput = blankLinesRegex.ReplaceAllString(filteredTerraformOutput, "\n\n")
+
+	return strings.TrimLeft(filteredTerraformOutput, "\n")
+}
+
 // PolicyCheckSuccess is the result of a successful policy check run.
 type PolicyCheckSuccess struct {
 	// PolicyCheckOutput is the output from policy check binary(conftest|opa)
@@ -441,6 +1230,14 @@ type ProjectStatus struct {
 	ProjectName string
 	// Status is the status of where this project is at in the planning cycle.
 	Status ProjectPlanStatus
+	// JobID is the id of the job that last planned or applied this project.
+	JobID string
+	// EphemeralWorkspace is true if Workspace belongs to the pull request and
+	// is deleted when it's closed.
+	EphemeralWorkspace bool
+	// DestroyOnClose is true if the preview environment in Workspace is
+	// destroyed before it's deleted.
+	DestroyOnClose bool
 }
 
 // ProjectPlanStatus is the status of where this project is at in the planning
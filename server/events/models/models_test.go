@@ -16,6 +16,7 @@ package models_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
@@ -159,6 +160,15 @@ func TestNewRepo_HTTPSAuth(t *testing.T) {
 	}, repo)
 }
 
+func TestNewRepo_NoToken(t *testing.T) {
+	// When there's only a user, git should get the password from the
+	// credential helper.
+	repo, err := models.NewRepo(models.BitbucketCloud, "owner/repo", "https://bitbucket.org/owner/repo.git", "x-token-auth", "")
+	Ok(t, err)
+	Equals(t, "https://x-token-auth@bitbucket.org/owner/repo.git", repo.CloneURL)
+	Equals(t, "https://x-token-auth@bitbucket.org/owner/repo.git", repo.SanitizedCloneURL)
+}
+
 func TestProject_String(t *testing.T) {
 	Equals(t, "repofullname=owner/repo path=my/path", (models.Project{
 		RepoFullName: "owner/repo",
@@ -389,3 +399,312 @@ func TestPullStatus_StatusCount(t *testing.T) {
 	Equals(t, 1, ps.StatusCount(models.ErroredPolicyCheckStatus))
 	Equals(t, 1, ps.StatusCount(models.PassedPolicyCheckStatus))
 }
+
+func TestPlanSuccess_FilteredTerraformOutput(t *testing.T) {
+	cases := []struct {
+		description string
+		output      string
+		exp         string
+	}{
+		{
+			"no noise",
+			"Terraform will perform the following actions:\n\nPlan: 1 to add, 0 to change, 0 to destroy.",
+			"Terraform will perform the following actions:\n\nPlan: 1 to add, 0 to change, 0 to destroy.",
+		},
+		{
+			"refreshing and reading lines",
+			`null_resource.a: Refreshing state... [id=1]
+null_resource.b: Refreshing state... [id=2]
+data.aws_caller_identity.current: Reading...
+data.aws_caller_identity.current: Read complete after 0s [id=1234]
+
+Terraform will perform the following actions:
+
+  # null_resource.c will be created
++ resource "null_resource" "c" {
+      + id = (known after apply)
+    }
+
+Plan: 1 to add, 0 to change, 0 to destroy.`,
+			`Terraform will perform the following actions:
+
+  # null_resource.c will be created
++ resource "null_resource" "c" {
+      + id = (known after apply)
+    }
+
+Plan: 1 to add, 0 to change, 0 to destroy.`,
+		},
+		{
+			"unchanged attributes hidden",
+			`~ resource "aws_instance" "a" {
+      ~ instance_type = "t2.micro" -> "t2.small"
+        # (27 unchanged attributes hidden)
+
+        # (4 unchanged blocks hidden)
+        # (1 unchanged element hidden)
+    }`,
+			`~ resource "aws_instance" "a" {
+      ~ instance_type = "t2.micro" -> "t2.small"
+    }`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			pse := models.PlanSuccess{TerraformOutput: c.output}
+			Equals(t, c.exp, pse.FilteredTerraformOutput())
+		})
+	}
+}
+
+func TestPlanSuccess_Stats(t *testing.T) {
+	cases := []struct {
+		output string
+		exp    models.PlanSuccessStats
+	}{
+		{
+			"Plan: 1 to add, 2 to change, 3 to destroy.",
+			models.PlanSuccessStats{Add: 1, Change: 2, Destroy: 3, Changes: true},
+		},
+		{
+			"Plan: 4 to import, 0 to add, 0 to change, 0 to destroy.",
+			models.PlanSuccessStats{Import: 4, Changes: true},
+		},
+		{
+			"No changes. Your infrastructure matches the configuration.",
+			models.PlanSuccessStats{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.output, func(t *testing.T) {
+			pse := models.PlanSuccess{TerraformOutput: c.output}
+			Equals(t, c.exp, pse.Stats())
+		})
+	}
+}
+
+func TestPlanSuccessStats_String(t *testing.T) {
+	Equals(t, "+3 ~1 -0", models.PlanSuccessStats{Add: 3, Change: 1, Changes: true}.String())
+	Equals(t, "+0 ~0 -0, 4 to import", models.PlanSuccessStats{Import: 4, Changes: true}.String())
+	Equals(t, "no changes", models.PlanSuccessStats{}.String())
+}
+
+func TestParsePlanResourceChanges(t *testing.T) {
+	showJSON := `{
+  "format_version": "1.1",
+  "resource_changes": [
+    {"address": "aws_db_instance.main", "change": {"actions": ["delete", "create"]}},
+    {"address": "aws_instance.web", "change": {"actions": ["update"]}},
+    {"address": "module.vpc.aws_subnet.private[0]", "change": {"actions": ["delete"]}},
+    {"address": "aws_instance.worker", "change": {"actions": ["create", "delete"]}},
+    {"address": "aws_route53_zone.main", "change": {"actions": ["create"]}},
+    {"address": "data.aws_ami.ubuntu", "change": {"actions": ["read"]}},
+    {"address": "aws_s3_bucket.logs", "change": {"actions": ["no-op"]}}
+  ]
+}`
+	changes, err := models.ParsePlanResourceChanges([]byte(showJSON))
+	Ok(t, err)
+	Equals(t, []string{"aws_db_instance.main", "module.vpc.aws_subnet.private[0]", "aws_instance.worker"}, changes.DestroyedAddresses())
+	Equals(t, []string{"aws_db_instance.main", "aws_instance.web", "module.vpc.aws_subnet.private[0]", "aws_instance.worker", "aws_route53_zone.main"}, changes.ChangedAddresses())
+	Equals(t, 7, changes.Count())
+
+	changes, err = models.ParsePlanResourceChanges([]byte(`{"format_version": "1.1"}`))
+	Ok(t, err)
+	Equals(t, 0, len(changes.DestroyedAddresses()))
+	Equals(t, 0, changes.Count())
+
+	_, err = models.ParsePlanResourceChanges([]byte("Plan: 1 to add, 0 to change, 0 to destroy."))
+	Assert(t, err != nil, "exp error parsing plan text")
+}
+
+func TestProjectInventory_Uses(t *testing.T) {
+	inventory := models.ProjectInventory{
+		Providers: []models.InventoryProvider{
+			{Source: "registry.terraform.io/hashicorp/aws", Version: "4.36.1"},
+		},
+		Modules: []models.InventoryModule{
+			{Key: "vpc", Source: "registry.terraform.io/terraform-aws-modules/vpc/aws", Version: "3.18.1"},
+		},
+	}
+
+	Assert(t, inventory.UsesProvider("registry.terraform.io/hashicorp/aws", ""), "exp full source to match")
+	Assert(t, inventory.UsesProvider("hashicorp/aws", "4.36.1"), "exp short source and version to match")
+	Assert(t, inventory.UsesProvider("Hashicorp/AWS", ""), "exp source to be case insensitive")
+	Assert(t, !inventory.UsesProvider("hashicorp/aws", "4.36.0"), "exp other version not to match")
+	Assert(t, !inventory.UsesProvider("aws", ""), "exp partial name not to match")
+	Assert(t, !inventory.UsesProvider("hashicorp/google", ""), "exp other provider not to match")
+
+	Assert(t, inventory.UsesModule("terraform-aws-modules/vpc/aws", "3.18.1"), "exp short source and version to match")
+	Assert(t, !inventory.UsesModule("terraform-aws-modules/vpc/aws", "3.18.0"), "exp other version not to match")
+	Assert(t, !inventory.UsesModule("terraform-aws-modules/eks/aws", ""), "exp other module not to match")
+}
+
+func TestNewProjectRunStatuses(t *testing.T) {
+	t0 := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	runs := []models.ProjectRun{
+		{RepoFullName: "owner/repo", Path: "staging", Workspace: "default", Command: "plan", Outcome: models.SuccessProjectRunOutcome, Time: t0},
+		{RepoFullName: "owner/repo", Path: "staging", Workspace: "default", Command: "apply", Outcome: models.SuccessProjectRunOutcome, Time: t0.Add(time.Minute)},
+		{RepoFullName: "owner/repo", Path: "prod", Workspace: "default", Command: "plan", Outcome: models.SuccessProjectRunOutcome, Drifted: true, Time: t0},
+		{RepoFullName: "owner/repo", Path: "dev", Workspace: "default", Command: "apply", Outcome: models.SuccessProjectRunOutcome, Time: t0},
+		{RepoFullName: "owner/repo", Path: "dev", Workspace: "default", Command: "plan", Outcome: models.FailedProjectRunOutcome, Time: t0.Add(time.Minute)},
+	}
+	statuses := models.NewProjectRunStatuses(runs)
+	Equals(t, 3, len(statuses))
+
+	Equals(t, "dev", statuses[0].Path)
+	Equals(t, "plan failed", statuses[0].State())
+	Equals(t, "unknown", statuses[0].Drift())
+
+	Equals(t, "prod", statuses[1].Path)
+	Equals(t, "drifted", statuses[1].State())
+	Equals(t, "drifted", statuses[1].Drift())
+	Assert(t, statuses[1].LastApply == nil, "exp no apply")
+
+	Equals(t, "staging", statuses[2].Path)
+	Equals(t, "applied", statuses[2].State())
+	Equals(t, "none", statuses[2].Drift())
+	Equals(t, t0, statuses[2].LastPlan.Time)
+
+	Equals(t, "unknown", models.ProjectRunStatus{}.State())
+}
+
+func TestProjectRun_WithDurations(t *testing.T) {
+	t0 := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	Equals(t, time.Duration(0), models.ProjectRun{}.WithDurations(nil).ExpectedDuration())
+
+	run := models.ProjectRun{Time: t0, Duration: time.Minute}.WithDurations(nil)
+	Equals(t, []models.RunDuration{{Time: t0, Duration: time.Minute}}, run.Durations)
+
+	// Only the most recent durations are kept.
+	for i := 2; i <= models.MaxRunDurations+2; i++ {
+		next := models.ProjectRun{Time: t0.Add(time.Duration(i) * time.Hour), Duration: time.Duration(i) * time.Minute}
+		run = next.WithDurations(&run)
+	}
+	Equals(t, models.MaxRunDurations, len(run.Durations))
+	Equals(t, 3*time.Minute, run.Durations[0].Duration)
+	Equals(t, t0.Add(102*time.Hour), run.Durations[len(run.Durations)-1].Time)
+
+	// Runs without a duration keep the previous durations.
+	Equals(t, run.Durations, models.ProjectRun{}.WithDurations(&run).Durations)
+}
+
+func TestProjectRun_DurationStats(t *testing.T) {
+	Equals(t, models.DurationStats{}, models.ProjectRun{}.DurationStats())
+
+	// 20 runs that took 1 to 20 minutes, the slowest last.
+	var run models.ProjectRun
+	for i := 1; i <= 20; i++ {
+		run.Durations = append(run.Durations, models.RunDuration{Duration: time.Duration(i) * time.Minute})
+	}
+	Equals(t, models.DurationStats{
+		Runs:      20,
+		P50:       10 * time.Minute,
+		P95:       19 * time.Minute,
+		RecentP50: 15 * time.Minute,
+	}, run.DurationStats())
+	Equals(t, 15*time.Minute+30*time.Second, run.ExpectedDuration())
+}
+
+func TestSortBySlowest(t *testing.T) {
+	durations := func(d ...time.Duration) []models.RunDuration {
+		var runs []models.RunDuration
+		for _, duration := range d {
+			runs = append(runs, models.RunDuration{Duration: duration})
+		}
+		return runs
+	}
+	statuses := []models.ProjectRunStatus{
+		{Path: "unknown", LastPlan: &models.ProjectRun{}},
+		{Path: "slow-plan", LastPlan: &models.ProjectRun{Durations: durations(5 * time.Minute)}, LastApply: &models.ProjectRun{Durations: durations(time.Minute)}},
+		{Path: "fast", LastPlan: &models.ProjectRun{Durations: durations(time.Minute)}},
+		{Path: "slow-apply", LastApply: &models.ProjectRun{Durations: durations(10 * time.Minute)}},
+	}
+	var paths []string
+	for _, s := range models.SortBySlowest(statuses) {
+		paths = append(paths, s.Path)
+	}
+	Equals(t, []string{"slow-apply", "slow-plan", "fast"}, paths)
+}
+
+func TestDurationStats_GettingSlower(t *testing.T) {
+	Assert(t, models.DurationStats{Runs: 20, P50: 10 * time.Minute, RecentP50: 13 * time.Minute}.GettingSlower(), "exp 30% slower to be getting slower")
+	Assert(t, !models.DurationStats{Runs: 20, P50: 10 * time.Minute, RecentP50: 11 * time.Minute}.GettingSlower(), "exp 10% slower not to be getting slower")
+	Assert(t, !models.DurationStats{Runs: 10, P50: 10 * time.Minute, RecentP50: 20 * time.Minute}.GettingSlower(), "exp too few runs not to be getting slower")
+}
+
+func TestLockContention_WithAttempt(t *testing.T) {
+	start := time.Date(2022, 10, 3, 9, 0, 0, 0, time.UTC)
+	attempt := func(pull int, blockingPull int, at time.Duration) models.LockAttempt {
+		return models.LockAttempt{RepoFullName: "owner/repo", Path: "staging", Workspace: "default", PullNum: pull, BlockingPullNum: blockingPull, Time: start.Add(at)}
+	}
+
+	var c models.LockContention
+	c, changed := c.WithAttempt(attempt(1, 0, 0))
+	Assert(t, !changed, "exp acquiring an unwaited lock not to change the contention")
+
+	c, changed = c.WithAttempt(attempt(2, 1, 0))
+	Assert(t, changed, "exp a blocked attempt to change the contention")
+	c, _ = c.WithAttempt(attempt(2, 1, time.Hour))
+	c, _ = c.WithAttempt(attempt(3, 1, time.Hour))
+	Equals(t, "staging", c.Path)
+	Equals(t, []int{2, 3}, c.BlockedPulls())
+
+	c, _ = c.WithAttempt(attempt(2, 0, 2*time.Hour))
+	Equals(t, []models.LockWait{
+		{PullNum: 2, BlockingPullNum: 1, Attempts: 2, Since: start, LastAttempt: start.Add(time.Hour), Until: start.Add(2 * time.Hour)},
+		{PullNum: 3, BlockingPullNum: 1, Attempts: 1, Since: start.Add(time.Hour), LastAttempt: start.Add(time.Hour)},
+	}, c.Waits)
+
+	// Pull 3 gave up a week after its last attempt.
+	c, _ = c.WithAttempt(attempt(4, 2, 8*24*time.Hour))
+	Equals(t, start.Add(time.Hour), c.Waits[1].Until)
+	Equals(t, []int{4}, c.BlockedPulls())
+}
+
+func TestLockContention_Waited(t *testing.T) {
+	start := time.Date(2022, 10, 3, 9, 0, 0, 0, time.UTC)
+	c := models.LockContention{Waits: []models.LockWait{
+		{PullNum: 2, Since: start, Until: start.Add(2 * time.Hour)},
+		{PullNum: 3, Since: start.Add(24 * time.Hour), Until: start.Add(49 * time.Hour)},
+		{PullNum: 4, Since: start.Add(72 * time.Hour)},
+	}}
+	now := start.Add(73 * time.Hour)
+
+	blocked, waited := c.Waited(start.Add(time.Hour), now)
+	Equals(t, 3, blocked)
+	Equals(t, 27*time.Hour, waited)
+	blocked, waited = c.Waited(start.Add(50*time.Hour), now)
+	Equals(t, 1, blocked)
+	Equals(t, time.Hour, waited)
+
+	Equals(t, []int{0, 1, 1, 1, 1}, c.BlockedPerDay(now, 5))
+}
+
+func TestSortByContention(t *testing.T) {
+	now := time.Date(2022, 10, 3, 9, 0, 0, 0, time.UTC)
+	contention := []models.LockContention{
+		{Path: "none"},
+		{Path: "short", Waits: []models.LockWait{{Since: now.Add(-time.Hour)}}},
+		{Path: "old", Waits: []models.LockWait{{Since: now.Add(-30 * 24 * time.Hour), Until: now.Add(-20 * 24 * time.Hour)}}},
+		{Path: "long", Waits: []models.LockWait{{Since: now.Add(-5 * time.Hour), Until: now.Add(-time.Hour)}}},
+	}
+	var paths []string
+	for _, c := range models.SortByContention(contention, now.Add(-14*24*time.Hour), now) {
+		paths = append(paths, c.Path)
+	}
+	Equals(t, []string{"long", "short"}, paths)
+}
+
+func TestProjectRunStatus_Matches(t *testing.T) {
+	named := models.ProjectRunStatus{RepoFullName: "owner/repo", ProjectName: "staging", Path: "envs/staging", Workspace: "default"}
+	Assert(t, named.Matches("Owner/Repo", "staging", ""), "exp name to match")
+	Assert(t, named.Matches("owner/repo", "staging", "default"), "exp workspace to match")
+	Assert(t, !named.Matches("owner/repo", "staging", "other"), "exp other workspace not to match")
+	Assert(t, !named.Matches("owner/repo", "envs/staging", ""), "exp dir of named project not to match")
+	Assert(t, !named.Matches("owner/other", "staging", ""), "exp other repo not to match")
+
+	unnamed := models.ProjectRunStatus{RepoFullName: "owner/repo", Path: "envs/staging", Workspace: "default"}
+	Assert(t, unnamed.Matches("owner/repo", "envs/staging", ""), "exp dir of unnamed project to match")
+}
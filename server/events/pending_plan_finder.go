@@ -49,6 +49,10 @@ func (p *DefaultPendingPlanFinder) findWithAbsPaths(pullDir string) ([]PendingPl
 	var plans []PendingPlan
 	var absPaths []string
 	for _, workspaceDir := range workspaceDirs {
+		// Skip files like the workspaces' git configs.
+		if !workspaceDir.IsDir() {
+			continue
+		}
 		workspace := workspaceDir.Name()
 		repoDir := filepath.Join(pullDir, workspace)
 
@@ -180,6 +180,22 @@ func TestPendingPlanFinder_Find(t *testing.T) {
 				},
 			},
 		},
+		{
+			"workspace git config",
+			map[string]interface{}{
+				"default": map[string]interface{}{
+					"default.tfplan": nil,
+				},
+				"default.gitconfig": nil,
+			},
+			[]events.PendingPlan{
+				{
+					RepoDir:    "???/default",
+					RepoRelDir: ".",
+					Workspace:  "default",
+				},
+			},
+		},
 	}
 
 	pf := &events.DefaultPendingPlanFinder{}
@@ -1,6 +1,7 @@
 package events
 
 import (
+	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -68,6 +69,9 @@ type PlanCommandRunner struct {
 	parallelPoolSize           int
 	pullStatusFetcher          PullStatusFetcher
 	lockingLocker              locking.Locker
+	// SecretScanner blocks plans of pull requests that commit credentials.
+	// If nil, pull requests aren't scanned.
+	SecretScanner *SecretScanner
 }
 
 func (p *PlanCommandRunner) runAutoplan(ctx *command.Context) {
@@ -92,13 +96,7 @@ func (p *PlanCommandRunner) runAutoplan(ctx *command.Context) {
 			// with 0/0 projects planned/policy_checked/applied successfully because some users require
 			// the Atlantis status to be passing for all pull requests.
 			ctx.Log.Debug("setting VCS status to success with no projects found")
-			if err := p.commitStatusUpdater.UpdateCombinedCount(baseRepo, pull, models.SuccessCommitStatus, command.Plan, 0, 0); err != nil {
-				ctx.Log.Warn("unable to update commit status: %s", err)
-			}
-			if err := p.commitStatusUpdater.UpdateCombinedCount(baseRepo, pull, models.SuccessCommitStatus, command.PolicyCheck, 0, 0); err != nil {
-				ctx.Log.Warn("unable to update commit status: %s", err)
-			}
-			if err := p.commitStatusUpdater.UpdateCombinedCount(baseRepo, pull, models.SuccessCommitStatus, command.Apply, 0, 0); err != nil {
+			if err := p.commitStatusUpdater.UpdateCombinedNoProjects(baseRepo, pull, []command.Name{command.Plan, command.PolicyCheck, command.Apply}); err != nil {
 				ctx.Log.Warn("unable to update commit status: %s", err)
 			}
 		}
@@ -118,6 +116,10 @@ func (p *PlanCommandRunner) runAutoplan(ctx *command.Context) {
 		ctx.Log.Err("deleting locks: %s", err)
 	}
 
+	if !p.scanSecrets(ctx, AutoplanCommand{}) {
+		return
+	}
+
 	// Only run commands in parallel if enabled
 	var result command.Result
 	if p.isParallelEnabled(projectCmds) {
@@ -159,6 +161,11 @@ func (p *PlanCommandRunner) runAutoplan(ctx *command.Context) {
 }
 
 func (p *PlanCommandRunner) run(ctx *command.Context, cmd *CommentCommand) {
+	if cmd.DryRun {
+		p.runDryRun(ctx, cmd)
+		return
+	}
+
 	var err error
 	baseRepo := ctx.Pull.BaseRepo
 	pull := ctx.Pull
@@ -190,6 +197,10 @@ func (p *PlanCommandRunner) run(ctx *command.Context, cmd *CommentCommand) {
 		return
 	}
 
+	for i := range projectCmds {
+		projectCmds[i].TerraformLogLevel = cmd.TerraformLogLevel
+	}
+
 	projectCmds, policyCheckCmds := p.partitionProjectCmds(ctx, projectCmds)
 
 	// if the plan is generic, new plans will be generated based on changes
@@ -203,6 +214,10 @@ func (p *PlanCommandRunner) run(ctx *command.Context, cmd *CommentCommand) {
 		}
 	}
 
+	if !p.scanSecrets(ctx, cmd) {
+		return
+	}
+
 	// Only run commands in parallel if enabled
 	var result command.Result
 	if p.isParallelEnabled(projectCmds) {
@@ -240,6 +255,39 @@ func (p *PlanCommandRunner) run(ctx *command.Context, cmd *CommentCommand) {
 	}
 }
 
+// scanSecrets scans the lines that the pull request adds for credentials once
+// before any of its projects are planned. If it finds any, or can't scan, it
+// comments with why and returns false so that nothing is planned.
+func (p *PlanCommandRunner) scanSecrets(ctx *command.Context, cmd PullCommand) bool {
+	if p.SecretScanner == nil {
+		return true
+	}
+	failure, err := p.SecretScanner.Scan(ctx)
+	if failure == "" && err == nil {
+		return true
+	}
+	if statusErr := p.commitStatusUpdater.UpdateCombined(ctx.Pull.BaseRepo, ctx.Pull, models.FailedCommitStatus, command.Plan); statusErr != nil {
+		ctx.Log.Warn("unable to update commit status: %s", statusErr)
+	}
+	p.pullUpdater.updatePull(ctx, cmd, command.Result{Failure: failure, Error: errors.Wrap(err, "scanning for secrets")})
+	return false
+}
+
+// runDryRun comments with the projects that cmd would plan without planning
+// them or updating commit statuses.
+func (p *PlanCommandRunner) runDryRun(ctx *command.Context, cmd *CommentCommand) {
+	projectCmds, err := p.prjCmdBuilder.BuildPlanCommands(ctx, cmd)
+	if err != nil {
+		p.pullUpdater.updatePull(ctx, cmd, command.Result{Error: err})
+		return
+	}
+
+	comment := RenderDryRun(command.Plan, NewDryRunProjects(projectCmds))
+	if err := p.vcsClient.CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, comment, command.Plan.String()); err != nil {
+		ctx.Log.Err("unable to comment: %s", err)
+	}
+}
+
 func (p *PlanCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 	if ctx.Trigger == command.AutoTrigger {
 		p.runAutoplan(ctx)
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// planSignatureExt is appended to the name of a plan file to get the name of
+// the file its signature is stored in.
+const planSignatureExt = ".sig"
+
+// planCommitExt is appended to the name of a plan file to get the name of the
+// file that the commit it was created from is recorded in.
+const planCommitExt = ".commit"
+
+// PlanSigner signs plan files when they're created and verifies them before
+// they're applied so that plans that were modified or swapped on disk aren't
+// applied.
+type PlanSigner interface {
+	// Sign signs the plan file at planPath that was created for ctx.
+	Sign(ctx command.ProjectContext, planPath string) error
+	// Verify returns an error if the plan file at planPath wasn't signed for
+	// ctx or was modified since it was signed.
+	Verify(ctx command.ProjectContext, planPath string) error
+}
+
+// HMACPlanSigner implements PlanSigner with HMAC-SHA256 signatures that are
+// stored next to the plan files.
+type HMACPlanSigner struct {
+	Key []byte
+}
+
+func (h *HMACPlanSigner) Sign(ctx command.ProjectContext, planPath string) error {
+	sig, err := h.signature(ctx, ctx.Pull.HeadCommit, planPath)
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(os.WriteFile(planPath+planSignatureExt, []byte(sig), 0600), "writing plan signature")
+}
+
+func (h *HMACPlanSigner) Verify(ctx command.ProjectContext, planPath string) error {
+	stored, err := os.ReadFile(planPath + planSignatureExt)
+	if os.IsNotExist(err) {
+		return errors.New("plan is not signed")
+	}
+	if err != nil {
+		return errors.Wrap(err, "reading plan signature")
+	}
+	// The plan can be applied from another commit than the pull request's
+	// head with --sha so the commit it was created from is read from the
+	// file that records it. A missing or modified file fails verification.
+	commit, err := os.ReadFile(planPath + planCommitExt) // nolint: gosec
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "reading plan commit")
+	}
+	sig, err := h.signature(ctx, strings.TrimSpace(string(commit)), planPath)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(sig), []byte(strings.TrimSpace(string(stored)))) {
+		return errors.New("plan signature is invalid")
+	}
+	return nil
+}
+
+// signature returns the hex encoded signature of the plan file at planPath
+// that was created from commit. The project and commit are signed along with
+// the plan so that a plan can't be swapped with a plan that was signed for
+// another project, pull request or commit.
+func (h *HMACPlanSigner) signature(ctx command.ProjectContext, commit string, planPath string) (string, error) {
+	f, err := os.Open(planPath) // nolint: gosec
+	if err != nil {
+		return "", errors.Wrap(err, "opening plan")
+	}
+	defer f.Close() // nolint: errcheck
+
+	mac := hmac.New(sha256.New, h.Key)
+	fmt.Fprintf(mac, "%s\x00%d\x00%s\x00%s\x00%s\x00%s\x00", ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, ctx.RepoRelDir, ctx.Workspace, ctx.ProjectName, commit)
+	if _, err := io.Copy(mac, f); err != nil {
+		return "", errors.Wrap(err, "reading plan")
+	}
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,63 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestHMACPlanSigner(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	planPath := filepath.Join(tmp, "default.tfplan")
+	Ok(t, os.WriteFile(planPath, []byte("plan"), 0600))
+	signer := &events.HMACPlanSigner{Key: []byte("key")}
+	ctx := command.ProjectContext{
+		Pull: models.PullRequest{
+			Num:        1,
+			BaseRepo:   models.Repo{FullName: "owner/repo"},
+			HeadCommit: "abc123",
+		},
+		RepoRelDir: "staging",
+		Workspace:  "default",
+	}
+
+	ErrEquals(t, "plan is not signed", signer.Verify(ctx, planPath))
+
+	Ok(t, signer.Sign(ctx, planPath))
+	Ok(t, os.WriteFile(planPath+".commit", []byte("abc123"), 0600))
+	Ok(t, signer.Verify(ctx, planPath))
+
+	// The plan is verified against the commit it was created from, not the
+	// pull request's current head.
+	other := ctx
+	other.Pull.HeadCommit = "def456"
+	Ok(t, signer.Verify(other, planPath))
+
+	// The commit the plan was created from can't be changed or removed.
+	Ok(t, os.WriteFile(planPath+".commit", []byte("def456"), 0600))
+	ErrEquals(t, "plan signature is invalid", signer.Verify(ctx, planPath))
+	Ok(t, os.Remove(planPath+".commit"))
+	ErrEquals(t, "plan signature is invalid", signer.Verify(ctx, planPath))
+	Ok(t, os.WriteFile(planPath+".commit", []byte("abc123"), 0600))
+
+	// The signature can't be verified with another key.
+	ErrEquals(t, "plan signature is invalid", (&events.HMACPlanSigner{Key: []byte("other")}).Verify(ctx, planPath))
+
+	// The plan can't be used for another project.
+	other = ctx
+	other.RepoRelDir = "production"
+	ErrEquals(t, "plan signature is invalid", signer.Verify(other, planPath))
+	other = ctx
+	other.Pull.Num = 2
+	ErrEquals(t, "plan signature is invalid", signer.Verify(other, planPath))
+
+	// The plan can't be modified.
+	Ok(t, os.WriteFile(planPath, []byte("modified plan"), 0600))
+	ErrEquals(t, "plan signature is invalid", signer.Verify(ctx, planPath))
+}
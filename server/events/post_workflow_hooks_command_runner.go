@@ -19,7 +19,7 @@ type DefaultPostWorkflowHooksCommandRunner struct {
 	VCSClient              vcs.Client
 	WorkingDirLocker       WorkingDirLocker
 	WorkingDir             WorkingDir
-	GlobalCfg              valid.GlobalCfg
+	GlobalCfg              *valid.GlobalCfgStore
 	PostWorkflowHookRunner runtime.PostWorkflowHookRunner
 }
 
@@ -34,7 +34,7 @@ func (w *DefaultPostWorkflowHooksCommandRunner) RunPostHooks(
 	log := ctx.Log
 
 	postWorkflowHooks := make([]*valid.WorkflowHook, 0)
-	for _, repo := range w.GlobalCfg.Repos {
+	for _, repo := range w.GlobalCfg.Get().Repos {
 		if repo.IDMatches(baseRepo.ID()) && repo.BranchMatches(pull.BaseBranch) && len(repo.PostWorkflowHooks) > 0 {
 			postWorkflowHooks = append(postWorkflowHooks, repo.PostWorkflowHooks...)
 		}
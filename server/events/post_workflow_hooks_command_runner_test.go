@@ -88,7 +88,7 @@ func TestRunPostHooks_Clone(t *testing.T) {
 			},
 		}
 
-		postWh.GlobalCfg = globalCfg
+		postWh.GlobalCfg = valid.NewGlobalCfgStore(globalCfg)
 
 		When(postWhWorkingDirLocker.TryLock(fixtures.GithubRepo.FullName, newPull.Num, events.DefaultWorkspace, events.DefaultRepoRelDir)).ThenReturn(unlockFn, nil)
 		When(postWhWorkingDir.Clone(log, fixtures.GithubRepo, newPull, events.DefaultWorkspace)).ThenReturn(repoDir, false, nil)
@@ -119,7 +119,7 @@ func TestRunPostHooks_Clone(t *testing.T) {
 			},
 		}
 
-		postWh.GlobalCfg = globalCfg
+		postWh.GlobalCfg = valid.NewGlobalCfgStore(globalCfg)
 
 		err := postWh.RunPostHooks(ctx)
 
@@ -143,7 +143,7 @@ func TestRunPostHooks_Clone(t *testing.T) {
 			},
 		}
 
-		postWh.GlobalCfg = globalCfg
+		postWh.GlobalCfg = valid.NewGlobalCfgStore(globalCfg)
 
 		When(postWhWorkingDirLocker.TryLock(fixtures.GithubRepo.FullName, newPull.Num, events.DefaultWorkspace, events.DefaultRepoRelDir)).ThenReturn(func() {}, errors.New("some error"))
 
@@ -173,7 +173,7 @@ func TestRunPostHooks_Clone(t *testing.T) {
 			},
 		}
 
-		postWh.GlobalCfg = globalCfg
+		postWh.GlobalCfg = valid.NewGlobalCfgStore(globalCfg)
 
 		When(postWhWorkingDirLocker.TryLock(fixtures.GithubRepo.FullName, newPull.Num, events.DefaultWorkspace, events.DefaultRepoRelDir)).ThenReturn(unlockFn, nil)
 		When(postWhWorkingDir.Clone(log, fixtures.GithubRepo, newPull, events.DefaultWorkspace)).ThenReturn(repoDir, false, errors.New("some error"))
@@ -205,7 +205,7 @@ func TestRunPostHooks_Clone(t *testing.T) {
 			},
 		}
 
-		postWh.GlobalCfg = globalCfg
+		postWh.GlobalCfg = valid.NewGlobalCfgStore(globalCfg)
 
 		When(postWhWorkingDirLocker.TryLock(fixtures.GithubRepo.FullName, newPull.Num, events.DefaultWorkspace, events.DefaultRepoRelDir)).ThenReturn(unlockFn, nil)
 		When(postWhWorkingDir.Clone(log, fixtures.GithubRepo, newPull, events.DefaultWorkspace)).ThenReturn(repoDir, false, nil)
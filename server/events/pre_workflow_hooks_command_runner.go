@@ -19,7 +19,7 @@ type DefaultPreWorkflowHooksCommandRunner struct {
 	VCSClient             vcs.Client
 	WorkingDirLocker      WorkingDirLocker
 	WorkingDir            WorkingDir
-	GlobalCfg             valid.GlobalCfg
+	GlobalCfg             *valid.GlobalCfgStore
 	PreWorkflowHookRunner runtime.PreWorkflowHookRunner
 }
 
@@ -34,7 +34,7 @@ func (w *DefaultPreWorkflowHooksCommandRunner) RunPreHooks(
 	log := ctx.Log
 
 	preWorkflowHooks := make([]*valid.WorkflowHook, 0)
-	for _, repo := range w.GlobalCfg.Repos {
+	for _, repo := range w.GlobalCfg.Get().Repos {
 		if repo.IDMatches(baseRepo.ID()) && len(repo.PreWorkflowHooks) > 0 {
 			preWorkflowHooks = append(preWorkflowHooks, repo.PreWorkflowHooks...)
 		}
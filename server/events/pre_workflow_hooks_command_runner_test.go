@@ -91,7 +91,7 @@ func TestRunPreHooks_Clone(t *testing.T) {
 			},
 		}
 
-		preWh.GlobalCfg = globalCfg
+		preWh.GlobalCfg = valid.NewGlobalCfgStore(globalCfg)
 
 		When(preWhWorkingDirLocker.TryLock(fixtures.GithubRepo.FullName, newPull.Num, events.DefaultWorkspace, events.DefaultRepoRelDir)).ThenReturn(unlockFn, nil)
 		When(preWhWorkingDir.Clone(log, fixtures.GithubRepo, newPull, events.DefaultWorkspace)).ThenReturn(repoDir, false, nil)
@@ -122,7 +122,7 @@ func TestRunPreHooks_Clone(t *testing.T) {
 			},
 		}
 
-		preWh.GlobalCfg = globalCfg
+		preWh.GlobalCfg = valid.NewGlobalCfgStore(globalCfg)
 
 		err := preWh.RunPreHooks(ctx)
 
@@ -146,7 +146,7 @@ func TestRunPreHooks_Clone(t *testing.T) {
 			},
 		}
 
-		preWh.GlobalCfg = globalCfg
+		preWh.GlobalCfg = valid.NewGlobalCfgStore(globalCfg)
 
 		When(preWhWorkingDirLocker.TryLock(fixtures.GithubRepo.FullName, newPull.Num, events.DefaultWorkspace, events.DefaultRepoRelDir)).ThenReturn(func() {}, errors.New("some error"))
 
@@ -176,7 +176,7 @@ func TestRunPreHooks_Clone(t *testing.T) {
 			},
 		}
 
-		preWh.GlobalCfg = globalCfg
+		preWh.GlobalCfg = valid.NewGlobalCfgStore(globalCfg)
 
 		When(preWhWorkingDirLocker.TryLock(fixtures.GithubRepo.FullName, newPull.Num, events.DefaultWorkspace, events.DefaultRepoRelDir)).ThenReturn(unlockFn, nil)
 		When(preWhWorkingDir.Clone(log, fixtures.GithubRepo, newPull, events.DefaultWorkspace)).ThenReturn(repoDir, false, errors.New("some error"))
@@ -208,7 +208,7 @@ func TestRunPreHooks_Clone(t *testing.T) {
 			},
 		}
 
-		preWh.GlobalCfg = globalCfg
+		preWh.GlobalCfg = valid.NewGlobalCfgStore(globalCfg)
 
 		When(preWhWorkingDirLocker.TryLock(fixtures.GithubRepo.FullName, newPull.Num, events.DefaultWorkspace, events.DefaultRepoRelDir)).ThenReturn(unlockFn, nil)
 		When(preWhWorkingDir.Clone(log, fixtures.GithubRepo, newPull, events.DefaultWorkspace)).ThenReturn(repoDir, false, nil)
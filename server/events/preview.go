@@ -0,0 +1,76 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"text/template"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// PreviewTagsEnvVar sets the atlantis_preview_tags Terraform variable of
+// preview environments to the tags that identify their resources, ex. to
+// use as the provider's default tags. Terraform ignores it if the variable
+// isn't declared.
+const PreviewTagsEnvVar = "TF_VAR_atlantis_preview_tags"
+
+// previewTags returns the tags of the resources of the preview environment
+// of projCfg in pull.
+func previewTags(pull models.PullRequest, projCfg valid.MergedProjectCfg) map[string]string {
+	return map[string]string{
+		"atlantis_repo":      pull.BaseRepo.FullName,
+		"atlantis_pull":      strconv.Itoa(pull.Num),
+		"atlantis_workspace": projCfg.Workspace,
+	}
+}
+
+// previewURLData is what the URL templates of preview environments can use.
+type previewURLData struct {
+	Workspace  string
+	PullNum    int
+	HeadBranch string
+	// Outputs are the non-sensitive outputs of the project. Strings are
+	// unquoted, other values are JSON.
+	Outputs map[string]string
+}
+
+// previewURL returns a line for the apply output that links to the preview
+// environment of ctx, whose outputs after the apply are outputs, or "" if
+// it doesn't have a URL. Since the changes have already been applied,
+// failing to render the URL doesn't fail the apply.
+func previewURL(ctx command.ProjectContext, outputs map[string]models.TerraformOutput) string {
+	if ctx.Preview == nil || ctx.Preview.URL == "" {
+		return ""
+	}
+	data := previewURLData{
+		Workspace:  ctx.Workspace,
+		PullNum:    ctx.Pull.Num,
+		HeadBranch: ctx.Pull.HeadBranch,
+		Outputs:    make(map[string]string),
+	}
+	for name, output := range outputs {
+		if output.Sensitive {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(output.Value, &s); err == nil {
+			data.Outputs[name] = s
+		} else {
+			data.Outputs[name] = string(output.Value)
+		}
+	}
+	tmpl, err := template.New("").Option("missingkey=error").Parse(ctx.Preview.URL)
+	var buf bytes.Buffer
+	if err == nil {
+		err = tmpl.Execute(&buf, data)
+	}
+	if err != nil {
+		ctx.Log.Err("rendering preview environment url: %s", err)
+		return fmt.Sprintf("\nWarning: unable to render the preview environment's url: %s", err)
+	}
+	return fmt.Sprintf("\nPreview environment: %s", buf.String())
+}
@@ -0,0 +1,86 @@
+package events_test
+
+import (
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// Test that the apply of a preview environment links to it, using the
+// outputs of the apply.
+func TestDefaultProjectCommandRunner_ApplyLinksPreview(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockApply := mocks.NewMockStepRunner()
+	mockOutput := mocks.NewMockStepRunner()
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	runner := events.DefaultProjectCommandRunner{
+		ApplyStepRunner:  mockApply,
+		OutputStepRunner: mockOutput,
+		WorkingDir:       mockWorkingDir,
+		Webhooks:         mocks.NewMockWebhooksSender(),
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		AggregateApplyRequirements: &events.AggregateApplyRequirements{
+			WorkingDir: mockWorkingDir,
+		},
+	}
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.GetWorkingDir(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())).ThenReturn(repoDir, nil)
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Steps:      []valid.Step{{StepName: "apply"}},
+		Workspace:  "pr-1",
+		RepoRelDir: ".",
+		Pull:       models.PullRequest{Num: 1},
+		Preview:    &valid.Preview{URL: "https://{{ .Workspace }}.example.com/{{ .Outputs.vpc_id }}"},
+	}
+	When(mockApply.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("Apply complete!", nil)
+	When(mockOutput.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn(outputJSON, nil)
+
+	res := runner.Apply(ctx)
+	Ok(t, res.Error)
+	Equals(t, "Apply complete!\n\nPreview environment: https://pr-1.example.com/vpc-123", res.ApplySuccess)
+
+	// Outputs that don't exist don't fail the apply.
+	ctx.Preview.URL = "https://{{ .Outputs.url }}"
+	res = runner.Apply(ctx)
+	Ok(t, res.Error)
+	Assert(t, res.Failure == "", "exp apply to succeed, got %q", res.Failure)
+	Equals(t, "Apply complete!\n\nWarning: unable to render the preview environment's url: "+
+		`template: :1:19: executing "" at <.Outputs.url>: map has no entry for key "url"`, res.ApplySuccess)
+}
+
+// Test that preview environments get the tags of their resources.
+func TestProjectCommandContextBuilder_PreviewTags(t *testing.T) {
+	RegisterMockTestingT(t)
+	subject := events.DefaultProjectCommandContextBuilder{
+		CommentBuilder: mocks.NewMockCommentBuilder(),
+	}
+	projCfg := valid.MergedProjectCfg{
+		RepoRelDir:   "preview",
+		Workspace:    "pr-1",
+		Workflow:     valid.Workflow{Name: valid.DefaultWorkflowName, Plan: valid.DefaultPlanStage},
+		TerraformEnv: map[string]string{"TF_LOG": "info"},
+		Preview:      &valid.Preview{},
+	}
+	ctx := &command.Context{
+		Log:  logging.NewNoopLogger(t),
+		Pull: models.PullRequest{Num: 1, BaseRepo: models.Repo{FullName: "owner/repo"}},
+	}
+
+	projCtxs := subject.BuildProjectContext(ctx, command.Plan, projCfg, []string{}, "some/dir", false, false, false, false, false)
+	Equals(t, map[string]string{
+		"TF_LOG":                 "info",
+		events.PreviewTagsEnvVar: `{"atlantis_pull":"1","atlantis_repo":"owner/repo","atlantis_workspace":"pr-1"}`,
+	}, projCtxs[0].TerraformEnv)
+	// The server's env vars aren't modified.
+	Equals(t, map[string]string{"TF_LOG": "info"}, projCfg.TerraformEnv)
+}
@@ -3,6 +3,7 @@ package events
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -39,7 +40,7 @@ func NewInstrumentedProjectCommandBuilder(
 	vcsClient vcs.Client,
 	workingDir WorkingDir,
 	workingDirLocker WorkingDirLocker,
-	globalCfg valid.GlobalCfg,
+	globalCfg *valid.GlobalCfgStore,
 	pendingPlanFinder *DefaultPendingPlanFinder,
 	commentBuilder CommentBuilder,
 	skipCloneNoChanges bool,
@@ -76,7 +77,7 @@ func NewProjectCommandBuilder(
 	vcsClient vcs.Client,
 	workingDir WorkingDir,
 	workingDirLocker WorkingDirLocker,
-	globalCfg valid.GlobalCfg,
+	globalCfg *valid.GlobalCfgStore,
 	pendingPlanFinder *DefaultPendingPlanFinder,
 	commentBuilder CommentBuilder,
 	skipCloneNoChanges bool,
@@ -133,6 +134,12 @@ type ProjectVersionCommandBuilder interface {
 	BuildVersionCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error)
 }
 
+type ProjectForceUnlockStateCommandBuilder interface {
+	// BuildForceUnlockStateCommands builds project ForceUnlockState commands
+	// for the project specified by comment.
+	BuildForceUnlockStateCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error)
+}
+
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_project_command_builder.go ProjectCommandBuilder
 
 // ProjectCommandBuilder builds commands that run on individual projects.
@@ -141,6 +148,7 @@ type ProjectCommandBuilder interface {
 	ProjectApplyCommandBuilder
 	ProjectApprovePoliciesCommandBuilder
 	ProjectVersionCommandBuilder
+	ProjectForceUnlockStateCommandBuilder
 }
 
 // DefaultProjectCommandBuilder implements ProjectCommandBuilder.
@@ -152,7 +160,7 @@ type DefaultProjectCommandBuilder struct {
 	VCSClient                    vcs.Client
 	WorkingDir                   WorkingDir
 	WorkingDirLocker             WorkingDirLocker
-	GlobalCfg                    valid.GlobalCfg
+	GlobalCfg                    *valid.GlobalCfgStore
 	PendingPlanFinder            *DefaultPendingPlanFinder
 	ProjectCommandContextBuilder ProjectCommandContextBuilder
 	SkipCloneNoChanges           bool
@@ -204,13 +212,20 @@ func (p *DefaultProjectCommandBuilder) BuildVersionCommands(ctx *command.Context
 	if !cmd.IsForSpecificProject() {
 		return p.buildAllProjectCommands(ctx, cmd)
 	}
-	pac, err := p.buildProjectVersionCommand(ctx, cmd)
+	pac, err := p.buildProjectCommand(ctx, cmd)
 	return pac, err
 }
 
+func (p *DefaultProjectCommandBuilder) BuildForceUnlockStateCommands(ctx *command.Context, cmd *CommentCommand) ([]command.ProjectContext, error) {
+	return p.buildProjectCommand(ctx, cmd)
+}
+
 // buildPlanAllCommands builds plan contexts for all projects we determine were
 // modified in this ctx.
 func (p *DefaultProjectCommandBuilder) buildPlanAllCommands(ctx *command.Context, commentFlags []string, verbose bool) ([]command.ProjectContext, error) {
+	// Use the same config for every project even if it's reloaded meanwhile.
+	globalCfg := p.GlobalCfg.Get()
+
 	// We'll need the list of modified files.
 	modifiedFiles, err := p.VCSClient.GetModifiedFiles(ctx.Pull.BaseRepo, ctx.Pull)
 	if err != nil {
@@ -225,7 +240,7 @@ func (p *DefaultProjectCommandBuilder) buildPlanAllCommands(ctx *command.Context
 		}
 
 		if hasRepoCfg {
-			repoCfg, err := p.ParserValidator.ParseRepoCfgData(repoCfgData, p.GlobalCfg, ctx.Pull.BaseRepo.ID())
+			repoCfg, err := p.ParserValidator.ParseRepoCfgData(repoCfgData, globalCfg, ctx.Pull.BaseRepo.ID())
 			if err != nil {
 				return nil, errors.Wrapf(err, "parsing %s", config.AtlantisYAMLFilename)
 			}
@@ -272,11 +287,15 @@ func (p *DefaultProjectCommandBuilder) buildPlanAllCommands(ctx *command.Context
 	if hasRepoCfg {
 		// If there's a repo cfg then we'll use it to figure out which projects
 		// should be planed.
-		repoCfg, err := p.ParserValidator.ParseRepoCfg(repoDir, p.GlobalCfg, ctx.Pull.BaseRepo.ID())
+		repoCfg, err := p.ParserValidator.ParseRepoCfg(repoDir, globalCfg, ctx.Pull.BaseRepo.ID())
 		if err != nil {
 			return nil, errors.Wrapf(err, "parsing %s", config.AtlantisYAMLFilename)
 		}
+		repoCfg.ResolvePullWorkspaces(ctx.Pull.HeadBranch, ctx.Pull.Num)
 		ctx.Log.Info("successfully parsed %s file", config.AtlantisYAMLFilename)
+		if err := loadRepoMarkdownTemplateOverrides(ctx, repoDir, repoCfg); err != nil {
+			return nil, err
+		}
 		matchingProjects, err := p.ProjectFinder.DetermineProjectsViaConfig(ctx.Log, modifiedFiles, repoCfg, repoDir)
 		if err != nil {
 			return nil, err
@@ -285,7 +304,7 @@ func (p *DefaultProjectCommandBuilder) buildPlanAllCommands(ctx *command.Context
 
 		for _, mp := range matchingProjects {
 			ctx.Log.Debug("determining config for project at dir: %q workspace: %q", mp.Dir, mp.Workspace)
-			mergedCfg := p.GlobalCfg.MergeProjectCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), mp, repoCfg)
+			mergedCfg := globalCfg.MergeProjectCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), mp, repoCfg)
 
 			projCtxs = append(projCtxs,
 				p.ProjectCommandContextBuilder.BuildProjectContext(
@@ -313,7 +332,7 @@ func (p *DefaultProjectCommandBuilder) buildPlanAllCommands(ctx *command.Context
 			if err != nil {
 				return nil, errors.Wrapf(err, "looking for Terraform Cloud workspace from configuration %s", repoDir)
 			}
-			pCfg := p.GlobalCfg.DefaultProjCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), mp.Path, pWorkspace)
+			pCfg := globalCfg.DefaultProjCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), mp.Path, pWorkspace)
 
 			projCtxs = append(projCtxs,
 				p.ProjectCommandContextBuilder.BuildProjectContext(
@@ -401,11 +420,15 @@ func (p *DefaultProjectCommandBuilder) getCfg(ctx *command.Context, projectName
 	}
 
 	var repoConfig valid.RepoCfg
-	repoConfig, err = p.ParserValidator.ParseRepoCfg(repoDir, p.GlobalCfg, ctx.Pull.BaseRepo.ID())
+	repoConfig, err = p.ParserValidator.ParseRepoCfg(repoDir, p.GlobalCfg.Get(), ctx.Pull.BaseRepo.ID())
 	if err != nil {
 		return
 	}
+	repoConfig.ResolvePullWorkspaces(ctx.Pull.HeadBranch, ctx.Pull.Num)
 	repoCfg = &repoConfig
+	if err = loadRepoMarkdownTemplateOverrides(ctx, repoDir, repoConfig); err != nil {
+		return
+	}
 
 	// If they've specified a project by name we look it up. Otherwise we
 	// use the dir and workspace.
@@ -436,6 +459,21 @@ func (p *DefaultProjectCommandBuilder) getCfg(ctx *command.Context, projectName
 	return
 }
 
+// loadRepoMarkdownTemplateOverrides sets ctx's template overrides to the
+// templates in the markdown_template_dir of repoCfg, the config of the repo
+// in repoDir.
+func loadRepoMarkdownTemplateOverrides(ctx *command.Context, repoDir string, repoCfg valid.RepoCfg) error {
+	if repoCfg.MarkdownTemplateDir == "" {
+		return nil
+	}
+	overrides, err := LoadMarkdownTemplateOverrides(filepath.Join(repoDir, repoCfg.MarkdownTemplateDir))
+	if err != nil {
+		return errors.Wrapf(err, "loading markdown_template_dir of %s", config.AtlantisYAMLFilename)
+	}
+	ctx.MarkdownTemplateOverrides = overrides
+	return nil
+}
+
 // buildAllProjectCommands builds contexts for a command for every project that has
 // pending plans in this ctx.
 func (p *DefaultProjectCommandBuilder) buildAllProjectCommands(ctx *command.Context, commentCmd *CommentCommand) ([]command.ProjectContext, error) {
@@ -521,9 +559,9 @@ func (p *DefaultProjectCommandBuilder) buildProjectApplyCommand(ctx *command.Con
 	)
 }
 
-// buildProjectVersionCommand builds a version command for the single project
+// buildProjectCommand builds a command for the single project
 // identified by cmd.
-func (p *DefaultProjectCommandBuilder) buildProjectVersionCommand(ctx *command.Context, cmd *CommentCommand) ([]command.ProjectContext, error) {
+func (p *DefaultProjectCommandBuilder) buildProjectCommand(ctx *command.Context, cmd *CommentCommand) ([]command.ProjectContext, error) {
 	workspace := DefaultWorkspace
 	if cmd.Workspace != "" {
 		workspace = cmd.Workspace
@@ -552,7 +590,7 @@ func (p *DefaultProjectCommandBuilder) buildProjectVersionCommand(ctx *command.C
 
 	return p.buildProjectCommandCtx(
 		ctx,
-		command.Version,
+		cmd.Name,
 		cmd.ProjectName,
 		cmd.Flags,
 		repoDir,
@@ -596,7 +634,7 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *command.Conte
 		workspace = projCfg.Workspace
 		for _, mp := range matchingProjects {
 			ctx.Log.Debug("Merging config for project at dir: %q workspace: %q", mp.Dir, mp.Workspace)
-			projCfg = p.GlobalCfg.MergeProjectCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), mp, *repoCfgPtr)
+			projCfg = p.GlobalCfg.Get().MergeProjectCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), mp, *repoCfgPtr)
 
 			projCtxs = append(projCtxs,
 				p.ProjectCommandContextBuilder.BuildProjectContext(
@@ -613,7 +651,7 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *command.Conte
 				)...)
 		}
 	} else {
-		projCfg = p.GlobalCfg.DefaultProjCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), repoRelDir, workspace)
+		projCfg = p.GlobalCfg.Get().DefaultProjCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), repoRelDir, workspace)
 		projCtxs = append(projCtxs,
 			p.ProjectCommandContextBuilder.BuildProjectContext(
 				ctx,
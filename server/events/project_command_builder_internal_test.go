@@ -80,6 +80,7 @@ workflows:
 				RepoRelDir:        "project1",
 				User:              models.User{},
 				Verbose:           true,
+				WorkflowName:      "default",
 				Workspace:         "myworkspace",
 				PolicySets:        emptyPolicySets,
 			},
@@ -135,6 +136,7 @@ projects:
 				TerraformVersion:  mustVersion("10.0"),
 				User:              models.User{},
 				Verbose:           true,
+				WorkflowName:      "default",
 				Workspace:         "myworkspace",
 				PolicySets:        emptyPolicySets,
 			},
@@ -190,6 +192,7 @@ projects:
 				TerraformVersion:  mustVersion("10.0"),
 				User:              models.User{},
 				Verbose:           true,
+				WorkflowName:      "default",
 				Workspace:         "myworkspace",
 				PolicySets:        emptyPolicySets,
 			},
@@ -253,6 +256,7 @@ projects:
 				TerraformVersion:  mustVersion("10.0"),
 				User:              models.User{},
 				Verbose:           true,
+				WorkflowName:      "specific",
 				Workspace:         "myworkspace",
 				PolicySets:        emptyPolicySets,
 			},
@@ -403,6 +407,7 @@ workflows:
 				TerraformVersion:  mustVersion("10.0"),
 				User:              models.User{},
 				Verbose:           true,
+				WorkflowName:      "custom",
 				Workspace:         "myworkspace",
 				PolicySets:        emptyPolicySets,
 			},
@@ -462,6 +467,7 @@ projects:
 				TerraformVersion:  mustVersion("10.0"),
 				User:              models.User{},
 				Verbose:           true,
+				WorkflowName:      "custom",
 				Workspace:         "myworkspace",
 				PolicySets:        emptyPolicySets,
 			},
@@ -524,6 +530,7 @@ workflows:
 				TerraformVersion:  mustVersion("10.0"),
 				User:              models.User{},
 				Verbose:           true,
+				WorkflowName:      "custom",
 				Workspace:         "myworkspace",
 				PolicySets:        emptyPolicySets,
 			},
@@ -569,6 +576,7 @@ projects:
 				RepoRelDir:        "project1",
 				User:              models.User{},
 				Verbose:           true,
+				WorkflowName:      "custom",
 				Workspace:         "myworkspace",
 				PolicySets:        emptyPolicySets,
 			},
@@ -620,7 +628,7 @@ projects:
 				vcsClient,
 				workingDir,
 				NewDefaultWorkingDirLocker(),
-				globalCfg,
+				valid.NewGlobalCfgStore(globalCfg),
 				&DefaultPendingPlanFinder{},
 				&CommentParser{},
 				false,
@@ -774,6 +782,7 @@ projects:
 				TerraformVersion:  mustVersion("10.0"),
 				User:              models.User{},
 				Verbose:           true,
+				WorkflowName:      "default",
 				Workspace:         "myworkspace",
 				PolicySets:        emptyPolicySets,
 			},
@@ -822,7 +831,7 @@ projects:
 				vcsClient,
 				workingDir,
 				NewDefaultWorkingDirLocker(),
-				globalCfg,
+				valid.NewGlobalCfgStore(globalCfg),
 				&DefaultPendingPlanFinder{},
 				&CommentParser{},
 				false,
@@ -940,6 +949,7 @@ repos:
 				RepoRelDir:        "project1",
 				User:              models.User{},
 				Verbose:           true,
+				WorkflowName:      "default",
 				Workspace:         "myworkspace",
 				PolicySets:        emptyPolicySets,
 			},
@@ -1000,6 +1010,7 @@ workflows:
 				TerraformVersion:  mustVersion("10.0"),
 				User:              models.User{},
 				Verbose:           true,
+				WorkflowName:      "custom",
 				Workspace:         "myworkspace",
 				PolicySets:        emptyPolicySets,
 			},
@@ -1052,7 +1063,7 @@ workflows:
 				vcsClient,
 				workingDir,
 				NewDefaultWorkingDirLocker(),
-				globalCfg,
+				valid.NewGlobalCfgStore(globalCfg),
 				&DefaultPendingPlanFinder{},
 				&CommentParser{},
 				false,
@@ -153,7 +153,7 @@ projects:
 				vcsClient,
 				workingDir,
 				events.NewDefaultWorkingDirLocker(),
-				valid.NewGlobalCfgFromArgs(globalCfgArgs),
+				valid.NewGlobalCfgStore(valid.NewGlobalCfgFromArgs(globalCfgArgs)),
 				&events.DefaultPendingPlanFinder{},
 				&events.CommentParser{},
 				false,
@@ -421,7 +421,7 @@ projects:
 					vcsClient,
 					workingDir,
 					events.NewDefaultWorkingDirLocker(),
-					valid.NewGlobalCfgFromArgs(globalCfgArgs),
+					valid.NewGlobalCfgStore(valid.NewGlobalCfgFromArgs(globalCfgArgs)),
 					&events.DefaultPendingPlanFinder{},
 					&events.CommentParser{},
 					false,
@@ -576,7 +576,7 @@ projects:
 				vcsClient,
 				workingDir,
 				events.NewDefaultWorkingDirLocker(),
-				valid.NewGlobalCfgFromArgs(globalCfgArgs),
+				valid.NewGlobalCfgStore(valid.NewGlobalCfgFromArgs(globalCfgArgs)),
 				&events.DefaultPendingPlanFinder{},
 				&events.CommentParser{},
 				false,
@@ -611,6 +611,88 @@ projects:
 	}
 }
 
+// Test that the markdown templates of the repo's atlantis.yaml are loaded
+// when the server-side config allows it.
+func TestDefaultProjectCommandBuilder_MarkdownTemplateDir(t *testing.T) {
+	cases := map[string]struct {
+		AllowedOverrides []string
+		Template         string
+		ExpErr           string
+	}{
+		"allowed": {
+			AllowedOverrides: []string{valid.MarkdownTemplateDirKey},
+			Template:         "multi_project_plan.tmpl",
+		},
+		"not allowed": {
+			Template: "multi_project_plan.tmpl",
+			ExpErr:   "repo config not allowed to set 'markdown_template_dir' key",
+		},
+		"unknown template": {
+			AllowedOverrides: []string{valid.MarkdownTemplateDirKey},
+			Template:         "multi_project_plans.tmpl",
+			ExpErr:           "loading markdown_template_dir of atlantis.yaml",
+		},
+	}
+
+	logger := logging.NewNoopLogger(t)
+	scope, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			tmpDir, cleanup := DirStructure(t, map[string]interface{}{
+				"main.tf": nil,
+				"templates": map[string]interface{}{
+					c.Template: nil,
+				},
+			})
+			defer cleanup()
+			err := os.WriteFile(filepath.Join(tmpDir, config.AtlantisYAMLFilename), []byte(`version: 3
+markdown_template_dir: templates
+projects:
+- dir: .`), 0600)
+			Ok(t, err)
+
+			workingDir := mocks.NewMockWorkingDir()
+			When(workingDir.Clone(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())).ThenReturn(tmpDir, false, nil)
+			When(workingDir.GetWorkingDir(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())).ThenReturn(tmpDir, nil)
+			vcsClient := vcsmocks.NewMockClient()
+			When(vcsClient.GetModifiedFiles(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest())).ThenReturn([]string{"main.tf"}, nil)
+
+			globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+			globalCfg.Repos[0].AllowedOverrides = c.AllowedOverrides
+
+			builder := events.NewProjectCommandBuilder(
+				false,
+				&config.ParserValidator{},
+				&events.DefaultProjectFinder{},
+				vcsClient,
+				workingDir,
+				events.NewDefaultWorkingDirLocker(),
+				valid.NewGlobalCfgStore(globalCfg),
+				&events.DefaultPendingPlanFinder{},
+				&events.CommentParser{},
+				false,
+				false,
+				"**/*.tf,**/*.tfvars,**/*.tfvars.json,**/terragrunt.hcl,**/.terraform.lock.hcl",
+				scope,
+				logger,
+			)
+
+			ctx := &command.Context{
+				Log:   logger,
+				Scope: scope,
+			}
+			_, err = builder.BuildPlanCommands(ctx, &events.CommentCommand{Name: command.Plan})
+			if c.ExpErr != "" {
+				ErrContains(t, c.ExpErr, err)
+				return
+			}
+			Ok(t, err)
+			Assert(t, ctx.MarkdownTemplateOverrides["multi_project_plan"] != nil, "expected multi_project_plan to be overridden")
+		})
+	}
+}
+
 // Test building apply command for multiple projects when the comment
 // isn't for a specific project, i.e. atlantis apply.
 // In this case we should apply all outstanding plans.
@@ -667,7 +749,7 @@ func TestDefaultProjectCommandBuilder_BuildMultiApply(t *testing.T) {
 		nil,
 		workingDir,
 		events.NewDefaultWorkingDirLocker(),
-		valid.NewGlobalCfgFromArgs(globalCfgArgs),
+		valid.NewGlobalCfgStore(valid.NewGlobalCfgFromArgs(globalCfgArgs)),
 		&events.DefaultPendingPlanFinder{},
 		&events.CommentParser{},
 		false,
@@ -752,7 +834,7 @@ projects:
 		nil,
 		workingDir,
 		events.NewDefaultWorkingDirLocker(),
-		valid.NewGlobalCfgFromArgs(globalCfgArgs),
+		valid.NewGlobalCfgStore(valid.NewGlobalCfgFromArgs(globalCfgArgs)),
 		&events.DefaultPendingPlanFinder{},
 		&events.CommentParser{},
 		false,
@@ -831,7 +913,7 @@ func TestDefaultProjectCommandBuilder_EscapeArgs(t *testing.T) {
 				vcsClient,
 				workingDir,
 				events.NewDefaultWorkingDirLocker(),
-				valid.NewGlobalCfgFromArgs(globalCfgArgs),
+				valid.NewGlobalCfgStore(valid.NewGlobalCfgFromArgs(globalCfgArgs)),
 				&events.DefaultPendingPlanFinder{},
 				&events.CommentParser{},
 				false,
@@ -975,6 +1057,49 @@ projects:
 		},
 	}
 
+	// Version files are used if the terraform config doesn't set an exact
+	// version, with the closest file to the project winning.
+	testCases["terraform config and version file"] = testCase{
+		DirStructure: map[string]interface{}{
+			"project1": map[string]interface{}{
+				"main.tf":            fmt.Sprintf(baseVersionConfig, exactSymbols[0]),
+				".terraform-version": "0.13.0\n",
+			},
+		},
+		ModifiedFiles: []string{"project1/main.tf"},
+		Exp: map[string][]int{
+			"project1": {0, 12, 8},
+		},
+	}
+
+	testCases["version files"] = testCase{
+		DirStructure: map[string]interface{}{
+			"project1": map[string]interface{}{
+				"main.tf":            fmt.Sprintf(baseVersionConfig, nonExactSymbols[1]),
+				".terraform-version": "1.3.7\n",
+			},
+			"project2": map[string]interface{}{
+				"main.tf": nil,
+			},
+			"project3": map[string]interface{}{
+				"main.tf":            nil,
+				".terraform-version": "latest\n",
+			},
+			"project4": map[string]interface{}{
+				"main.tf":        nil,
+				".tool-versions": "golang 1.19.4\n",
+			},
+			".tool-versions": "# pinned for all projects\nterraform 1.2.9 1.1.0\n",
+		},
+		ModifiedFiles: []string{"project1/main.tf", "project2/main.tf", "project3/main.tf", "project4/main.tf"},
+		Exp: map[string][]int{
+			"project1": {1, 3, 7},
+			"project2": {1, 2, 9},
+			"project3": nil,
+			"project4": {1, 2, 9},
+		},
+	}
+
 	logger := logging.NewNoopLogger(t)
 	scope, _, _ := metrics.NewLoggingScope(logger, "atlantis")
 
@@ -1014,7 +1139,7 @@ projects:
 				vcsClient,
 				workingDir,
 				events.NewDefaultWorkingDirLocker(),
-				valid.NewGlobalCfgFromArgs(globalCfgArgs),
+				valid.NewGlobalCfgStore(valid.NewGlobalCfgFromArgs(globalCfgArgs)),
 				&events.DefaultPendingPlanFinder{},
 				&events.CommentParser{},
 				false,
@@ -1081,7 +1206,7 @@ projects:
 		vcsClient,
 		workingDir,
 		events.NewDefaultWorkingDirLocker(),
-		valid.NewGlobalCfgFromArgs(globalCfgArgs),
+		valid.NewGlobalCfgStore(valid.NewGlobalCfgFromArgs(globalCfgArgs)),
 		&events.DefaultPendingPlanFinder{},
 		&events.CommentParser{},
 		true,
@@ -1139,7 +1264,7 @@ func TestDefaultProjectCommandBuilder_WithPolicyCheckEnabled_BuildAutoplanComman
 		vcsClient,
 		workingDir,
 		events.NewDefaultWorkingDirLocker(),
-		globalCfg,
+		valid.NewGlobalCfgStore(globalCfg),
 		&events.DefaultPendingPlanFinder{},
 		&events.CommentParser{},
 		false,
@@ -1221,7 +1346,7 @@ func TestDefaultProjectCommandBuilder_BuildVersionCommand(t *testing.T) {
 		nil,
 		workingDir,
 		events.NewDefaultWorkingDirLocker(),
-		valid.NewGlobalCfgFromArgs(globalCfgArgs),
+		valid.NewGlobalCfgStore(valid.NewGlobalCfgFromArgs(globalCfgArgs)),
 		&events.DefaultPendingPlanFinder{},
 		&events.CommentParser{},
 		false,
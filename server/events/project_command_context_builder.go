@@ -1,8 +1,10 @@
 package events
 
 import (
+	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/go-version"
@@ -105,13 +107,20 @@ func (cb *DefaultProjectCommandContextBuilder) BuildProjectContext(
 		steps = []valid.Step{{
 			StepName: "version",
 		}}
+	case command.ForceUnlockState:
+		steps = []valid.Step{{
+			StepName: "force_unlock_state",
+		}}
 	}
 
 	// If TerraformVersion not defined in config file look for a
-	// terraform.require_version block.
+	// terraform.require_version block, then for a version file.
 	if prjCfg.TerraformVersion == nil {
 		prjCfg.TerraformVersion = getTfVersion(ctx, filepath.Join(repoDir, prjCfg.RepoRelDir))
 	}
+	if prjCfg.TerraformVersion == nil {
+		prjCfg.TerraformVersion = getTfVersionFromFiles(ctx, repoDir, prjCfg.RepoRelDir)
+	}
 
 	projectCmdContext := newProjectCommandContext(
 		ctx,
@@ -153,10 +162,13 @@ func (cb *PolicyCheckProjectCommandContextBuilder) BuildProjectContext(
 	ctx.Log.Debug("PolicyChecks are enabled")
 
 	// If TerraformVersion not defined in config file look for a
-	// terraform.require_version block.
+	// terraform.require_version block, then for a version file.
 	if prjCfg.TerraformVersion == nil {
 		prjCfg.TerraformVersion = getTfVersion(ctx, filepath.Join(repoDir, prjCfg.RepoRelDir))
 	}
+	if prjCfg.TerraformVersion == nil {
+		prjCfg.TerraformVersion = getTfVersionFromFiles(ctx, repoDir, prjCfg.RepoRelDir)
+	}
 
 	projectCmds = cb.ProjectCommandContextBuilder.BuildProjectContext(
 		ctx,
@@ -236,6 +248,7 @@ func newProjectCommandContext(ctx *command.Context,
 		}
 	}
 
+	jobID := uuid.New().String()
 	return command.ProjectContext{
 		CommandName:                cmd,
 		ApplyCmd:                   applyCmd,
@@ -261,11 +274,32 @@ func newProjectCommandContext(ctx *command.Context,
 		TerraformVersion:           projCfg.TerraformVersion,
 		User:                       ctx.User,
 		Verbose:                    verbose,
+		WorkflowName:               projCfg.Workflow.Name,
 		Workspace:                  projCfg.Workspace,
 		PolicySets:                 policySets,
 		PullReqStatus:              pullStatus,
-		JobID:                      uuid.New().String(),
+		JobID:                      jobID,
 		ExecutionOrderGroup:        projCfg.ExecutionOrderGroup,
+		ApplyWindows:               projCfg.ApplyWindows,
+		ApplyWindowTeams:           projCfg.ApplyWindowTeams,
+		MaxConcurrentApplies:       projCfg.MaxConcurrentApplies,
+		MaxResourceChanges:         projCfg.MaxResourceChanges,
+		MaxResourceChangesTeams:    projCfg.MaxResourceChangesTeams,
+		ApplyConcurrencyGroup:      projCfg.ApplyConcurrencyGroup,
+		ApplyConcurrencyLimit:      projCfg.ApplyConcurrencyLimit,
+		PromoteTo:                  projCfg.PromoteTo,
+		PromoteToDir:               projCfg.PromoteToDir,
+		EphemeralWorkspace:         projCfg.EphemeralWorkspace,
+		DependsOn:                  projCfg.DependsOn,
+		ProviderPolicy:             projCfg.ProviderPolicy,
+		AllowedModuleSources:       projCfg.AllowedModuleSources,
+		ProviderTargets:            projCfg.ProviderTargets,
+		CloudCredentials:           projCfg.CloudCredentials,
+		DestroyProtection:          projCfg.DestroyProtection,
+		ProtectedResources:         projCfg.ProtectedResources,
+		TerraformExtraArgs:         projCfg.TerraformExtraArgs,
+		TerraformEnv:               terraformEnv(ctx.Pull, projCfg, jobID),
+		Preview:                    projCfg.Preview,
 	}
 }
 
@@ -313,3 +347,65 @@ func getTfVersion(ctx *command.Context, absProjDir string) *version.Version {
 	ctx.Log.Info("detected module requires version: %q", version.String())
 	return version
 }
+
+// Version files that tfenv and asdf select the Terraform version with.
+const (
+	terraformVersionFilename = ".terraform-version"
+	toolVersionsFilename     = ".tool-versions"
+)
+
+// getTfVersionFromFiles returns the version in the .terraform-version or
+// .tool-versions file closest to the project, looking in its dir and then in
+// each parent dir up to the repo root. In the same dir, .terraform-version
+// wins. Returns nil if there's no file or the closest one doesn't set an exact
+// version, ex. "latest".
+func getTfVersionFromFiles(ctx *command.Context, repoDir string, repoRelDir string) *version.Version {
+	dir := filepath.Clean(repoRelDir)
+	for {
+		for _, filename := range []string{terraformVersionFilename, toolVersionsFilename} {
+			relPath := filepath.Join(dir, filename)
+			contents, err := os.ReadFile(filepath.Join(repoDir, relPath)) // nolint: gosec
+			if err != nil {
+				continue
+			}
+			var versionStr string
+			if filename == terraformVersionFilename {
+				versionStr = strings.TrimSpace(string(contents))
+			} else {
+				versionStr = toolVersionsTerraformVersion(string(contents))
+				if versionStr == "" {
+					// asdf keeps looking in parent dirs for tools that
+					// aren't listed.
+					continue
+				}
+			}
+			v, err := version.NewVersion(versionStr)
+			if err != nil {
+				ctx.Log.Debug("did not specify exact version in %s, found %q", relPath, versionStr)
+				return nil
+			}
+			ctx.Log.Info("detected version %q from %s", v.String(), relPath)
+			return v
+		}
+		if dir == "." {
+			return nil
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// toolVersionsTerraformVersion returns the preferred terraform version in the
+// contents of a .tool-versions file or an empty string if it doesn't list
+// terraform.
+func toolVersionsTerraformVersion(contents string) string {
+	for _, line := range strings.Split(contents, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "terraform" {
+			return fields[1]
+		}
+	}
+	return ""
+}
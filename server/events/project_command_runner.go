@@ -18,13 +18,19 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/artifacts"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/changetickets"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/webhooks"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 )
 
@@ -113,6 +119,12 @@ type ProjectVersionCommandRunner interface {
 	Version(ctx command.ProjectContext) command.ProjectResult
 }
 
+type ProjectForceUnlockStateCommandRunner interface {
+	// ForceUnlockState removes the Terraform state lock with the ID in ctx
+	// for the project described by ctx.
+	ForceUnlockState(ctx command.ProjectContext) command.ProjectResult
+}
+
 // ProjectCommandRunner runs project commands. A project command is a command
 // for a specific TF project.
 type ProjectCommandRunner interface {
@@ -121,6 +133,7 @@ type ProjectCommandRunner interface {
 	ProjectPolicyCheckCommandRunner
 	ProjectApprovePoliciesCommandRunner
 	ProjectVersionCommandRunner
+	ProjectForceUnlockStateCommandRunner
 }
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_job_url_setter.go JobURLSetter
@@ -137,26 +150,132 @@ type JobMessageSender interface {
 	Send(ctx command.ProjectContext, msg string, operationComplete bool)
 }
 
+// JobOutputReader reads the output of running jobs.
+type JobOutputReader interface {
+	// LastLine returns the last line of the output of the job with jobID.
+	LastLine(jobID string) string
+}
+
 // ProjectOutputWrapper is a decorator that creates a new PR status check per project.
 // The status contains a url that outputs current progress of the terraform plan/apply command.
 type ProjectOutputWrapper struct {
 	ProjectCommandRunner
 	JobMessageSender JobMessageSender
 	JobURLSetter     JobURLSetter
+	// ApplyHeartbeatInterval is how often the pending status of a running
+	// apply is updated with how long it's been running and its last output
+	// line, so reviewers can tell a slow apply from a hung one. 0 to not
+	// update it.
+	ApplyHeartbeatInterval time.Duration
+	// JobOutput reads the last output line of running applies for their
+	// heartbeats.
+	JobOutput JobOutputReader
+	// Canceller tracks running plans and applies so that they can be
+	// cancelled. If nil, they can't be.
+	Canceller *jobs.JobCanceller
 }
 
 func (p *ProjectOutputWrapper) Plan(ctx command.ProjectContext) command.ProjectResult {
-	result := p.updateProjectPRStatus(command.Plan, ctx, p.ProjectCommandRunner.Plan)
+	result := p.updateProjectPRStatus(command.Plan, ctx, p.cancellable(command.Plan, p.ProjectCommandRunner.Plan))
+	p.sendAtlantisLog(ctx)
 	p.JobMessageSender.Send(ctx, "", OperationComplete)
 	return result
 }
 
 func (p *ProjectOutputWrapper) Apply(ctx command.ProjectContext) command.ProjectResult {
-	result := p.updateProjectPRStatus(command.Apply, ctx, p.ProjectCommandRunner.Apply)
+	result := p.updateProjectPRStatus(command.Apply, ctx, p.withHeartbeat(command.Apply, p.ApplyHeartbeatInterval, p.cancellable(command.Apply, p.ProjectCommandRunner.Apply)))
+	p.sendAtlantisLog(ctx)
 	p.JobMessageSender.Send(ctx, "", OperationComplete)
 	return result
 }
 
+// sendAtlantisLog sends the Atlantis log to the job output if the user set a
+// Terraform log level, since it isn't appended to the comment then.
+func (p *ProjectOutputWrapper) sendAtlantisLog(ctx command.ProjectContext) {
+	if ctx.TerraformLogLevel == "" {
+		return
+	}
+	p.JobMessageSender.Send(ctx, "Atlantis log:", false)
+	for _, line := range strings.Split(strings.TrimSuffix(ctx.Log.GetHistory(), "\n"), "\n") {
+		p.JobMessageSender.Send(ctx, line, false)
+	}
+}
+
+// cancellable returns execute with the job tracked by the canceller while it
+// runs so that it can be cancelled. The result of a cancelled job that failed
+// says who cancelled it.
+func (p *ProjectOutputWrapper) cancellable(commandName command.Name, execute func(ctx command.ProjectContext) command.ProjectResult) func(ctx command.ProjectContext) command.ProjectResult {
+	if p.Canceller == nil {
+		return execute
+	}
+	return func(ctx command.ProjectContext) command.ProjectResult {
+		end := p.Canceller.Begin(ctx, commandName)
+		// Plans of superseded commits begin cancelled and don't run so that
+		// they don't touch the working dir of the newer commit.
+		if job, _ := p.Canceller.Get(ctx.JobID); job.CancelledBy != "" {
+			end()
+			return command.ProjectResult{
+				Command:     commandName,
+				RepoRelDir:  ctx.RepoRelDir,
+				Workspace:   ctx.Workspace,
+				ProjectName: ctx.ProjectName,
+				Failure:     fmt.Sprintf("%s was cancelled by %s.", commandName.TitleString(), job.CancelledBy),
+			}
+		}
+		ctx.Processes = p.Canceller
+		result := execute(ctx)
+		cancelledBy := end()
+		if cancelledBy == "" || (result.Error == nil && result.Failure == "") {
+			return result
+		}
+		reason := result.Failure
+		if result.Error != nil {
+			reason = result.Error.Error()
+		}
+		result.Error = nil
+		result.Failure = fmt.Sprintf("%s was cancelled by %s.\n%s", commandName.TitleString(), cancelledBy, reason)
+		return result
+	}
+}
+
+// withHeartbeat returns execute with the pending status of the project
+// updated every interval while it runs. The heartbeats stop before execute
+// returns so they can't overwrite the status that's set once it's done.
+func (p *ProjectOutputWrapper) withHeartbeat(commandName command.Name, interval time.Duration, execute func(ctx command.ProjectContext) command.ProjectResult) func(ctx command.ProjectContext) command.ProjectResult {
+	if interval <= 0 {
+		return execute
+	}
+	return func(ctx command.ProjectContext) command.ProjectResult {
+		start := time.Now()
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					heartbeatCtx := ctx
+					heartbeatCtx.RunningFor = time.Since(start)
+					if p.JobOutput != nil {
+						heartbeatCtx.LastOutputLine = p.JobOutput.LastLine(ctx.JobID)
+					}
+					if err := p.JobURLSetter.SetJobURLWithStatus(heartbeatCtx, commandName, models.PendingCommitStatus); err != nil {
+						ctx.Log.Err("updating project PR status: %s", err)
+					}
+				}
+			}
+		}()
+		result := execute(ctx)
+		close(done)
+		<-stopped
+		return result
+	}
+}
+
 func (p *ProjectOutputWrapper) updateProjectPRStatus(commandName command.Name, ctx command.ProjectContext, execute func(ctx command.ProjectContext) command.ProjectResult) command.ProjectResult {
 	// Create a PR status to track project's plan status. The status will
 	// include a link to view the progress of atlantis plan command in real
@@ -167,6 +286,7 @@ func (p *ProjectOutputWrapper) updateProjectPRStatus(commandName command.Name, c
 
 	// ensures we are differentiating between project level command and overall command
 	result := execute(ctx)
+	result.JobID = ctx.JobID
 
 	if result.Error != nil || result.Failure != "" {
 		if err := p.JobURLSetter.SetJobURLWithStatus(ctx, commandName, models.FailedCommitStatus); err != nil {
@@ -176,6 +296,10 @@ func (p *ProjectOutputWrapper) updateProjectPRStatus(commandName command.Name, c
 		return result
 	}
 
+	if result.PlanSuccess != nil {
+		stats := result.PlanSuccess.Stats()
+		ctx.PlanStats = &stats
+	}
 	if err := p.JobURLSetter.SetJobURLWithStatus(ctx, commandName, models.SuccessCommitStatus); err != nil {
 		ctx.Log.Err("updating project PR status", err)
 	}
@@ -188,11 +312,13 @@ type DefaultProjectCommandRunner struct {
 	Locker                     ProjectLocker
 	LockURLGenerator           LockURLGenerator
 	InitStepRunner             StepRunner
+	ValidateStepRunner         StepRunner
 	PlanStepRunner             StepRunner
 	ShowStepRunner             StepRunner
 	ApplyStepRunner            StepRunner
 	PolicyCheckStepRunner      StepRunner
 	VersionStepRunner          StepRunner
+	ForceUnlockStateStepRunner StepRunner
 	RunStepRunner              CustomStepRunner
 	EnvStepRunner              EnvStepRunner
 	MultiEnvStepRunner         MultiEnvStepRunner
@@ -201,20 +327,93 @@ type DefaultProjectCommandRunner struct {
 	Webhooks                   WebhooksSender
 	WorkingDirLocker           WorkingDirLocker
 	AggregateApplyRequirements ApplyRequirement
+	// VCSClient looks up the teams of users that override the max resource
+	// changes and apply window guards.
+	VCSClient vcs.Client
+	// FreezeChecker checks if applies are frozen. If nil, applies are never
+	// frozen.
+	FreezeChecker locking.FreezeChecker
+	// MaxPlanAge is the maximum age of a plan that can be applied without
+	// --force. If 0, plans of any age can be applied.
+	MaxPlanAge time.Duration
+	// ApplyLimiter limits how many applies run at once. If nil, applies are
+	// not limited.
+	ApplyLimiter *ApplyLimiter
+	// ChangeTickets closes the change tickets of plans after they're applied.
+	// If nil, change tickets aren't closed.
+	ChangeTickets changetickets.Client
+	// Promoter promotes applied changes to the project's promote_to project.
+	// If nil, changes are never promoted.
+	Promoter Promoter
+	// Reverter opens pull requests that revert applied changes that fail a
+	// verify step with on_failure: revert. If nil, they aren't reverted.
+	Reverter Reverter
+	// Inventory records the providers and modules projects use. If nil, they
+	// aren't recorded.
+	Inventory Inventory
+	// ProjectRuns records the last plan and apply of each project. If nil,
+	// they aren't recorded.
+	ProjectRuns ProjectRunStore
+	// PlanSigner signs plans and verifies them before they're applied. If
+	// nil, plans aren't signed.
+	PlanSigner PlanSigner
+	// CloudCredentials provides projects with the cloud credentials in their
+	// config. If nil, projects don't get any.
+	CloudCredentials CloudCredentialsProvider
+	// Secrets resolves the secrets that steps' env vars refer to. If nil,
+	// steps can't refer to secrets.
+	Secrets SecretsProvider
+	// GitCredentials are given to the git commands that steps run, ex. to
+	// download modules from private repos. Nil if steps don't get any.
+	GitCredentials []GitCredentials
+	// TenantCredentials replaces GitCredentials for the repos of tenants
+	// that have their own credentials.
+	TenantCredentials *TenantCredentials
+	// Deployer tracks applies as deployments on the VCS host. If nil, they
+	// aren't tracked.
+	Deployer Deployer
+	// OutputStepRunner captures the outputs of projects after they're
+	// applied. If nil, outputs aren't captured.
+	OutputStepRunner StepRunner
+	// CommentOutputs are the names of the outputs that are posted in apply
+	// comments. AllCommentOutputs posts all of them. Sensitive outputs are
+	// never posted.
+	CommentOutputs []string
+	// CheckRemoteStates is true if plans warn about the
+	// terraform_remote_state data sources that read the states of projects
+	// that aren't ready to build on, ex. because their last apply failed.
+	CheckRemoteStates bool
+	// SnapshotStore stores snapshots of projects' states that are taken by
+	// StatePullStepRunner before they're applied. If nil, states aren't
+	// snapshotted.
+	SnapshotStore       artifacts.Store
+	StatePullStepRunner StepRunner
+	// SnapshotKey is the key that state snapshots are encrypted with.
+	SnapshotKey []byte
+	// GraphStore stores the dependency graphs that graph steps render from
+	// GraphStepRunner's output. If nil, graph steps are skipped.
+	GraphStore        artifacts.Store
+	GraphStepRunner   StepRunner
+	GraphURLGenerator GraphURLGenerator
 }
 
 // Plan runs terraform plan for the project described by ctx.
 func (p *DefaultProjectCommandRunner) Plan(ctx command.ProjectContext) command.ProjectResult {
+	start := time.Now()
 	planSuccess, failure, err := p.doPlan(ctx)
-	return command.ProjectResult{
-		Command:     command.Plan,
-		PlanSuccess: planSuccess,
-		Error:       err,
-		Failure:     failure,
-		RepoRelDir:  ctx.RepoRelDir,
-		Workspace:   ctx.Workspace,
-		ProjectName: ctx.ProjectName,
+	result := command.ProjectResult{
+		Command:            command.Plan,
+		PlanSuccess:        planSuccess,
+		Error:              err,
+		Failure:            failure,
+		RepoRelDir:         ctx.RepoRelDir,
+		Workspace:          ctx.Workspace,
+		ProjectName:        ctx.ProjectName,
+		EphemeralWorkspace: ctx.EphemeralWorkspace,
+		DestroyOnClose:     ctx.Preview != nil && ctx.Preview.DestroyOnClose,
 	}
+	p.updateProjectRun(ctx, result, time.Since(start))
+	return result
 }
 
 // PolicyCheck evaluates policies defined with Rego for the project described by ctx.
@@ -233,8 +432,9 @@ func (p *DefaultProjectCommandRunner) PolicyCheck(ctx command.ProjectContext) co
 
 // Apply runs terraform apply for the project described by ctx.
 func (p *DefaultProjectCommandRunner) Apply(ctx command.ProjectContext) command.ProjectResult {
-	applyOut, failure, err := p.doApply(ctx)
-	return command.ProjectResult{
+	start := time.Now()
+	applyOut, outputs, failure, err := p.doApply(ctx, &start)
+	result := command.ProjectResult{
 		Command:      command.Apply,
 		Failure:      failure,
 		Error:        err,
@@ -242,7 +442,10 @@ func (p *DefaultProjectCommandRunner) Apply(ctx command.ProjectContext) command.
 		RepoRelDir:   ctx.RepoRelDir,
 		Workspace:    ctx.Workspace,
 		ProjectName:  ctx.ProjectName,
+		Outputs:      outputs,
 	}
+	p.updateProjectRun(ctx, result, time.Since(start))
+	return result
 }
 
 func (p *DefaultProjectCommandRunner) ApprovePolicies(ctx command.ProjectContext) command.ProjectResult {
@@ -259,7 +462,7 @@ func (p *DefaultProjectCommandRunner) ApprovePolicies(ctx command.ProjectContext
 }
 
 func (p *DefaultProjectCommandRunner) Version(ctx command.ProjectContext) command.ProjectResult {
-	versionOut, failure, err := p.doVersion(ctx)
+	versionOut, failure, err := p.doRunSteps(ctx)
 	return command.ProjectResult{
 		Command:        command.Version,
 		Failure:        failure,
@@ -271,6 +474,19 @@ func (p *DefaultProjectCommandRunner) Version(ctx command.ProjectContext) comman
 	}
 }
 
+func (p *DefaultProjectCommandRunner) ForceUnlockState(ctx command.ProjectContext) command.ProjectResult {
+	out, failure, err := p.doRunSteps(ctx)
+	return command.ProjectResult{
+		Command:                 command.ForceUnlockState,
+		Failure:                 failure,
+		Error:                   err,
+		ForceUnlockStateSuccess: out,
+		RepoRelDir:              ctx.RepoRelDir,
+		Workspace:               ctx.Workspace,
+		ProjectName:             ctx.ProjectName,
+	}
+}
+
 func (p *DefaultProjectCommandRunner) doApprovePolicies(ctx command.ProjectContext) (*models.PolicyCheckSuccess, string, error) {
 
 	// TODO: Make this a bit smarter
@@ -333,7 +549,7 @@ func (p *DefaultProjectCommandRunner) doPolicyCheck(ctx command.ProjectContext)
 		return nil, "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
 	}
 
-	outputs, err := p.runSteps(ctx.Steps, ctx, absPath)
+	outputs, err := p.runSteps(ctx.Steps, ctx, repoDir, absPath)
 	if err != nil {
 		// Note: we are explicitly not unlocking the pr here since a failing policy check will require
 		// approval
@@ -382,8 +598,48 @@ func (p *DefaultProjectCommandRunner) doPlan(ctx command.ProjectContext) (*model
 	if _, err = os.Stat(projAbsPath); os.IsNotExist(err) {
 		return nil, "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
 	}
+	if failure, err := checkProviderTargets(ctx, projAbsPath); failure != "" || err != nil {
+		if unlockErr := lockAttempt.UnlockFn(); unlockErr != nil {
+			ctx.Log.Err("error unlocking state after plan error: %v", unlockErr)
+		}
+		return nil, failure, err
+	}
+	// Approvals of changes to protected resources are only for the plan they
+	// were given for.
+	planPath := filepath.Join(projAbsPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	if err := os.Remove(planPath + resourcesApprovedExt); err != nil && !os.IsNotExist(err) {
+		return nil, "", errors.Wrap(err, "discarding approval of changes to protected resources")
+	}
+	// Change tickets are also only for the plan they were opened for.
+	if err := os.Remove(planPath + changeTicketExt); err != nil && !os.IsNotExist(err) {
+		return nil, "", errors.Wrap(err, "discarding change ticket")
+	}
+	if err := os.Remove(planPath + graphExt); err != nil && !os.IsNotExist(err) {
+		return nil, "", errors.Wrap(err, "discarding graph url")
+	}
+	if err := os.Remove(filepath.Join(projAbsPath, ctx.GetShowResultFileName())); err != nil && !os.IsNotExist(err) {
+		return nil, "", errors.Wrap(err, "discarding plan JSON")
+	}
 
-	outputs, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
+	outputs, err := p.runSteps(ctx.Steps, ctx, repoDir, projAbsPath)
+	if err == nil {
+		// Workflows that run terraform init without the init step are checked
+		// after planning. The plan is deleted so that it can't be applied.
+		if err = checkDependencies(ctx, projAbsPath); err != nil {
+			if rmErr := os.Remove(filepath.Join(projAbsPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))); rmErr != nil && !os.IsNotExist(rmErr) {
+				ctx.Log.Err("error deleting plan that uses disallowed dependencies: %s", rmErr)
+			}
+		}
+	}
+	if err == nil {
+		err = p.showPlan(ctx, projAbsPath)
+	}
+	if err == nil {
+		err = p.signPlan(ctx, projAbsPath)
+	}
+	if err == nil {
+		err = p.recordPlanCommit(ctx, projAbsPath)
+	}
 
 	if err != nil {
 		if unlockErr := lockAttempt.UnlockFn(); unlockErr != nil {
@@ -392,42 +648,136 @@ func (p *DefaultProjectCommandRunner) doPlan(ctx command.ProjectContext) (*model
 		return nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
 	}
 
+	// Plans aren't blocked by dependencies since they don't change anything.
+	if _, warning, err := p.checkDependsOn(ctx, false); err != nil {
+		ctx.Log.Warn("unable to check project dependencies: %s", err)
+	} else if warning != "" {
+		outputs = append(outputs, warning)
+	}
+	if warning, err := p.checkRemoteStates(ctx, repoDir, projAbsPath); err != nil {
+		ctx.Log.Warn("unable to check remote states: %s", err)
+	} else if warning != "" {
+		outputs = append(outputs, warning)
+	}
+
+	output := strings.Join(outputs, "\n")
+	// Save the output so that it can be exported through the API.
+	if err := os.WriteFile(filepath.Join(projAbsPath, ctx.GetPlanOutputFileName()), []byte(output), 0600); err != nil {
+		ctx.Log.Warn("unable to save plan output: %s", err)
+	}
+	p.updateInventory(ctx, projAbsPath, false)
+
 	return &models.PlanSuccess{
 		LockURL:         p.LockURLGenerator.GenerateLockURL(lockAttempt.LockKey),
-		TerraformOutput: strings.Join(outputs, "\n"),
+		TerraformOutput: output,
 		RePlanCmd:       ctx.RePlanCmd,
 		ApplyCmd:        ctx.ApplyCmd,
 		HasDiverged:     hasDiverged,
+		GraphURL:        graphURL(ctx, projAbsPath),
 	}, "", nil
 }
 
-func (p *DefaultProjectCommandRunner) doApply(ctx command.ProjectContext) (applyOut string, failure string, err error) {
+// showPlan saves the output of terraform show -json for the project's plan if
+// any of the checks before it's applied need the plan's resource changes.
+func (p *DefaultProjectCommandRunner) showPlan(ctx command.ProjectContext, projAbsPath string) error {
+	needed := ctx.MaxResourceChanges > 0 || ctx.ProtectedResources != nil
+	for _, req := range ctx.ApplyRequirements {
+		needed = needed || req == valid.NoDestroysApplyReq
+	}
+	if !needed || p.ShowStepRunner == nil {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(projAbsPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))); os.IsNotExist(err) {
+		return nil
+	}
+	_, err := p.ShowStepRunner.Run(ctx, nil, projAbsPath, map[string]string{})
+	return err
+}
+
+// doApply applies the project's plan. start is reset once the apply stops
+// waiting for the ApplyLimiter so that the apply's duration doesn't include
+// the wait.
+func (p *DefaultProjectCommandRunner) doApply(ctx command.ProjectContext, start *time.Time) (applyOut string, tfOutputs map[string]models.TerraformOutput, failure string, err error) {
 	repoDir, err := p.WorkingDir.GetWorkingDir(ctx.Pull.BaseRepo, ctx.Pull, ctx.Workspace)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return "", "", errors.New("project has not been cloned–did you run plan?")
+			return "", nil, "", errors.New("project has not been cloned–did you run plan?")
 		}
-		return "", "", err
+		return "", nil, "", err
 	}
 	absPath := filepath.Join(repoDir, ctx.RepoRelDir)
 	if _, err = os.Stat(absPath); os.IsNotExist(err) {
-		return "", "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
+		return "", nil, "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
 	}
 
 	failure, err = p.AggregateApplyRequirements.ValidateProject(repoDir, ctx)
 	if failure != "" || err != nil {
-		return "", failure, err
+		return "", nil, failure, err
+	}
+
+	if failure, err = p.checkFreeze(ctx); failure != "" || err != nil {
+		return "", nil, failure, err
+	}
+
+	if failure = p.checkPlanAge(ctx, absPath); failure != "" {
+		return "", nil, failure, nil
+	}
+
+	if failure = checkPlanCommit(ctx, absPath); failure != "" {
+		return "", nil, failure, nil
+	}
+
+	if failure, err = p.checkResourceChanges(ctx, absPath); failure != "" || err != nil {
+		return "", nil, failure, err
+	}
+
+	if failure = checkProtectedResources(ctx, absPath); failure != "" {
+		return "", nil, failure, nil
+	}
+
+	if failure, err = p.checkApplyWindows(ctx, time.Now()); failure != "" || err != nil {
+		return "", nil, failure, err
+	}
+
+	failure, dependsOnWarning, err := p.checkDependsOn(ctx, true)
+	if failure != "" || err != nil {
+		return "", nil, failure, err
 	}
 
 	// Acquire internal lock for the directory we're going to operate in.
 	unlockFn, err := p.WorkingDirLocker.TryLock(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace, ctx.RepoRelDir)
 	if err != nil {
-		return "", "", err
+		return "", nil, "", err
 	}
 	defer unlockFn()
 
-	outputs, err := p.runSteps(ctx.Steps, ctx, absPath)
+	if p.ApplyLimiter != nil {
+		release := p.ApplyLimiter.Acquire(ctx)
+		defer release()
+		*start = time.Now()
+	}
 
+	if err := p.verifyPlan(ctx, absPath); err != nil {
+		return "", nil, "", err
+	}
+
+	// Outputs are only for the apply that captured them.
+	if err := os.Remove(filepath.Join(absPath, ctx.GetOutputsFileName())); err != nil && !os.IsNotExist(err) {
+		return "", nil, "", errors.Wrap(err, "discarding outputs of the previous apply")
+	}
+
+	var deploymentID int64
+	if p.Deployer != nil {
+		if deploymentID, err = p.Deployer.StartDeployment(ctx); err != nil {
+			return "", nil, "", err
+		}
+	}
+
+	outputs, err := p.runSteps(ctx.Steps, ctx, repoDir, absPath)
+
+	if p.Deployer != nil {
+		p.Deployer.FinishDeployment(ctx, deploymentID, err == nil)
+	}
 	p.Webhooks.Send(ctx.Log, webhooks.ApplyResult{ // nolint: errcheck
 		Workspace: ctx.Workspace,
 		User:      ctx.User,
@@ -438,13 +788,397 @@ func (p *DefaultProjectCommandRunner) doApply(ctx command.ProjectContext) (apply
 	})
 
 	if err != nil {
-		return "", "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
+		return "", nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
 	}
 
-	return strings.Join(outputs, "\n"), "", nil
+	p.updateInventory(ctx, absPath, true)
+	p.closeChangeTicket(ctx, absPath)
+	if dependsOnWarning != "" {
+		outputs = append(outputs, dependsOnWarning)
+	}
+	if promotion := p.promote(ctx); promotion != "" {
+		outputs = append(outputs, promotion)
+	}
+	tfOutputs = readOutputs(ctx, absPath)
+	if published := renderCommentOutputs(tfOutputs, p.CommentOutputs); published != "" {
+		outputs = append(outputs, published)
+	}
+	if url := previewURL(ctx, tfOutputs); url != "" {
+		outputs = append(outputs, url)
+	}
+	return strings.Join(outputs, "\n"), tfOutputs, "", nil
+}
+
+// closeChangeTicket closes the change ticket opened for the project's plan by
+// the change_ticket_approved apply requirement. The plan has already been
+// applied so errors are only logged.
+func (p *DefaultProjectCommandRunner) closeChangeTicket(ctx command.ProjectContext, absPath string) {
+	if p.ChangeTickets == nil {
+		return
+	}
+	ticketPath := filepath.Join(absPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName)) + changeTicketExt
+	id, err := os.ReadFile(ticketPath) // nolint: gosec
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ctx.Log.Err("reading change ticket: %s", err)
+		}
+		return
+	}
+	comment := fmt.Sprintf("Applied by Atlantis for %s in %s.", ctx.User.Username, ctx.Pull.URL)
+	if err := p.ChangeTickets.Close(string(id), comment); err != nil {
+		ctx.Log.Err("closing change ticket %s: %s", id, err)
+		return
+	}
+	if err := os.Remove(ticketPath); err != nil {
+		ctx.Log.Err("removing change ticket: %s", err)
+	}
+	ctx.Log.Info("closed change ticket %s", id)
+}
+
+// signPlan signs the project's plan file. Workflows that don't create a plan
+// file have nothing to sign.
+func (p *DefaultProjectCommandRunner) signPlan(ctx command.ProjectContext, projAbsPath string) error {
+	planPath := filepath.Join(projAbsPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	if p.PlanSigner == nil {
+		return nil
+	}
+	if _, err := os.Stat(planPath); os.IsNotExist(err) {
+		return nil
+	}
+	return errors.Wrap(p.PlanSigner.Sign(ctx, planPath), "signing plan")
+}
+
+// recordPlanCommit records the commit the project's plan was created from
+// next to the plan file so that applies can check that it's still the pull
+// request's head commit.
+func (p *DefaultProjectCommandRunner) recordPlanCommit(ctx command.ProjectContext, projAbsPath string) error {
+	planPath := filepath.Join(projAbsPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	if _, err := os.Stat(planPath); os.IsNotExist(err) {
+		return nil
+	}
+	return errors.Wrap(os.WriteFile(planPath+planCommitExt, []byte(ctx.Pull.HeadCommit), 0600), "recording plan commit")
+}
+
+// checkPlanCommit returns a failure if the project's plan wasn't created from
+// the commit that's being applied. That's the pull request's head commit
+// unless the user pinned another commit with --sha, which stops commits that
+// were pushed after the plan was reviewed from being applied unreviewed.
+// Plans from before commits were recorded aren't checked.
+func checkPlanCommit(ctx command.ProjectContext, absPath string) string {
+	planPath := filepath.Join(absPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	contents, err := os.ReadFile(planPath + planCommitExt) // nolint: gosec
+	if err != nil {
+		return ""
+	}
+	planCommit := strings.TrimSpace(string(contents))
+	if ctx.PinnedCommit != "" {
+		if !sameCommit(planCommit, ctx.PinnedCommit) {
+			return fmt.Sprintf("Plan was created from commit %s, not %s. Run plan again to plan the pull request's head commit.", planCommit, ctx.PinnedCommit)
+		}
+		return ""
+	}
+	if !sameCommit(planCommit, ctx.Pull.HeadCommit) {
+		return fmt.Sprintf("Plan was created from commit %s but the pull request's head is now %s. Run plan again or comment `atlantis apply --sha=%s` to apply the plan from %s.",
+			planCommit, ctx.Pull.HeadCommit, planCommit, planCommit)
+	}
+	return ""
+}
+
+// sameCommit returns true if a and b are the same commit. Either can be an
+// abbreviated SHA because some VCS hosts only give us those.
+func sameCommit(a string, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	if a == "" || b == "" {
+		return false
+	}
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
+
+// verifyPlan returns an error if the project's plan file doesn't have a valid
+// signature. If there's no plan file, the apply step reports it.
+func (p *DefaultProjectCommandRunner) verifyPlan(ctx command.ProjectContext, projAbsPath string) error {
+	planPath := filepath.Join(projAbsPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	if p.PlanSigner == nil {
+		return nil
+	}
+	if _, err := os.Stat(planPath); os.IsNotExist(err) {
+		return nil
+	}
+	if err := p.PlanSigner.Verify(ctx, planPath); err != nil {
+		ctx.Log.Err("plan failed verification: %s", err)
+		return fmt.Errorf("refusing to apply: %s, the plan may have been tampered with. Run plan again to regenerate it", err)
+	}
+	return nil
+}
+
+// updateInventory records the providers and modules that terraform init
+// installed for the project. Failing to record them doesn't fail the command.
+func (p *DefaultProjectCommandRunner) updateInventory(ctx command.ProjectContext, projAbsPath string, applied bool) {
+	if p.Inventory == nil {
+		return
+	}
+	providers, err := ReadInventoryProviders(projAbsPath)
+	if err != nil {
+		ctx.Log.Warn("unable to read providers for inventory: %s", err)
+		return
+	}
+	modules, err := ReadInventoryModules(projAbsPath)
+	if err != nil {
+		ctx.Log.Warn("unable to read modules for inventory: %s", err)
+		return
+	}
+	err = p.Inventory.UpdateInventory(models.ProjectInventory{
+		RepoFullName: ctx.BaseRepo.FullName,
+		ProjectName:  ctx.ProjectName,
+		Path:         ctx.RepoRelDir,
+		Workspace:    ctx.Workspace,
+		PullNum:      ctx.Pull.Num,
+		HeadCommit:   ctx.Pull.HeadCommit,
+		Applied:      applied,
+		Providers:    providers,
+		Modules:      modules,
+		Time:         time.Now(),
+	})
+	if err != nil {
+		ctx.Log.Warn("unable to update inventory: %s", err)
+	}
+}
+
+// updateProjectRun records the project's run, which took duration, so that
+// the project status API can report it and the durations of its next runs
+// can be estimated. Failing to record it doesn't fail the command.
+func (p *DefaultProjectCommandRunner) updateProjectRun(ctx command.ProjectContext, result command.ProjectResult, duration time.Duration) {
+	if p.ProjectRuns == nil {
+		return
+	}
+	run, ok := NewProjectRun(ctx, result, time.Now())
+	if !ok {
+		return
+	}
+	run.Duration = duration
+	if err := p.ProjectRuns.UpdateProjectRun(run); err != nil {
+		ctx.Log.Warn("unable to update project run: %s", err)
+	}
+}
+
+// checkDependencies returns an error if the providers or modules that
+// terraform init installed for the project aren't allowed.
+func checkDependencies(ctx command.ProjectContext, projAbsPath string) error {
+	if err := checkProviderPolicy(ctx, projAbsPath); err != nil {
+		return err
+	}
+	return checkModuleSources(ctx, projAbsPath)
+}
+
+// checkProviderPolicy returns an error that lists every provider in the
+// project's dependency lock file that is against its provider policy.
+func checkProviderPolicy(ctx command.ProjectContext, projAbsPath string) error {
+	if ctx.ProviderPolicy.AllowedProviders == nil && !ctx.ProviderPolicy.RequirePinned {
+		return nil
+	}
+	providers, err := ReadInventoryProviders(projAbsPath)
+	if err != nil {
+		return errors.Wrap(err, "checking provider policy")
+	}
+	var violations []string
+	for _, provider := range providers {
+		if v := ctx.ProviderPolicy.Violation(provider.Source, provider.Version, provider.Constraints); v != "" {
+			violations = append(violations, "* "+v)
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("project uses providers that are not allowed by the server's provider policy:\n%s", strings.Join(violations, "\n"))
+	}
+	return nil
+}
+
+// checkModuleSources returns an error that lists every module installed for
+// the project whose source isn't allowed.
+func checkModuleSources(ctx command.ProjectContext, projAbsPath string) error {
+	if ctx.AllowedModuleSources == nil {
+		return nil
+	}
+	modules, err := ReadInventoryModules(projAbsPath)
+	if err != nil {
+		return errors.Wrap(err, "checking module sources")
+	}
+	var violations []string
+	for _, module := range modules {
+		if !valid.ModuleSourceAllowed(ctx.AllowedModuleSources, module.Source) {
+			violations = append(violations, fmt.Sprintf("* module %q uses source %q", module.Key, module.Source))
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("project uses module sources that are not allowed by the server's module source allowlist:\n%s", strings.Join(violations, "\n"))
+	}
+	return nil
+}
+
+// promote promotes the applied changes to the project's promote_to project
+// and returns a line for the apply output that links to the promotion. Since
+// the changes have already been applied, failing to promote them doesn't fail
+// the apply.
+func (p *DefaultProjectCommandRunner) promote(ctx command.ProjectContext) string {
+	if p.Promoter == nil || ctx.PromoteTo == "" {
+		return ""
+	}
+	pull, err := p.Promoter.Promote(ctx)
+	if err != nil {
+		ctx.Log.Err("unable to promote changes to %q: %s", ctx.PromoteTo, err)
+		return fmt.Sprintf("\nWarning: unable to promote changes to project %q: %s", ctx.PromoteTo, err)
+	}
+	if pull == nil {
+		return ""
+	}
+	return fmt.Sprintf("\nPromoted changes to project %q in %s", ctx.PromoteTo, pull.URL)
+}
+
+// verificationFailed handles the failure of a verify step with onFailure. It
+// returns the step's output and error: a warning if it only warns, or the
+// error with a link to the pull request that reverts the changes if they're
+// reverted.
+func (p *DefaultProjectCommandRunner) verificationFailed(ctx command.ProjectContext, onFailure string, verifyErr error) (string, error) {
+	if onFailure == valid.OnFailureWarn {
+		return fmt.Sprintf("Warning: verification failed: %s", verifyErr), nil
+	}
+	err := errors.Wrap(verifyErr, "verification failed")
+	// Only applied changes are reverted.
+	if onFailure != valid.OnFailureRevert || ctx.CommandName != command.Apply || p.Reverter == nil {
+		return "", err
+	}
+	pull, revertErr := p.Reverter.Revert(ctx)
+	if revertErr != nil {
+		ctx.Log.Err("unable to revert changes: %s", revertErr)
+		return "", fmt.Errorf("%s\nWarning: unable to open a pull request that reverts the changes: %s", err, revertErr)
+	}
+	if pull == nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s\nOpened %s to revert the changes", err, pull.URL)
+}
+
+// checkFreeze returns a failure with the freeze's message if applies are
+// frozen for the project.
+func (p *DefaultProjectCommandRunner) checkFreeze(ctx command.ProjectContext) (string, error) {
+	if p.FreezeChecker == nil {
+		return "", nil
+	}
+	freeze, err := p.FreezeChecker.CheckFreeze(ctx.BaseRepo.FullName, ctx.ProjectName, ctx.RepoRelDir)
+	if err != nil {
+		return "", errors.Wrap(err, "checking for freezes")
+	}
+	if freeze == nil {
+		return "", nil
+	}
+	if freeze.Message == "" {
+		return "Applies are frozen for this project. Try again once the freeze is lifted.", nil
+	}
+	return fmt.Sprintf("Applies are frozen for this project: %s", freeze.Message), nil
+}
+
+// checkPlanAge returns a failure if the planfile in absPath is older than
+// MaxPlanAge and the apply wasn't forced. If the planfile doesn't exist we
+// let the apply step report the error.
+func (p *DefaultProjectCommandRunner) checkPlanAge(ctx command.ProjectContext, absPath string) string {
+	if p.MaxPlanAge <= 0 || ctx.ForceApply {
+		return ""
+	}
+	planFile := filepath.Join(absPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	info, err := os.Stat(planFile)
+	if err != nil {
+		return ""
+	}
+	age := time.Since(info.ModTime())
+	if age <= p.MaxPlanAge {
+		return ""
+	}
+	return fmt.Sprintf("Plan is %s old which is older than the maximum plan age of %s. Run plan again or comment `atlantis apply --force` to apply it anyway.",
+		age.Truncate(time.Minute), p.MaxPlanAge)
+}
+
+// checkResourceChanges returns a failure if the project's plan changes more
+// than MaxResourceChanges resources and the apply wasn't approved with
+// --resource-changes-approved by a member of MaxResourceChangesTeams.
+func (p *DefaultProjectCommandRunner) checkResourceChanges(ctx command.ProjectContext, absPath string) (string, error) {
+	if ctx.MaxResourceChanges <= 0 {
+		return "", nil
+	}
+	resourceChanges, err := readPlanResourceChanges(ctx, absPath)
+	if err != nil {
+		return "Plan JSON not found so the number of changed resources can't be checked. Run plan again before running apply.", nil
+	}
+	changes := resourceChanges.Count()
+	if changes <= ctx.MaxResourceChanges {
+		return "", nil
+	}
+	failure := fmt.Sprintf("Plan changes %d resources which is more than the maximum of %d.", changes, ctx.MaxResourceChanges)
+	if ctx.ResourceChangesApproved && p.VCSClient != nil && len(ctx.MaxResourceChangesTeams) > 0 {
+		approved, err := userInTeams(p.VCSClient, ctx.Pull.BaseRepo, ctx.User, ctx.MaxResourceChangesTeams)
+		if err != nil {
+			return "", err
+		}
+		if approved {
+			ctx.Log.Info("applying %d resource changes over the maximum of %d since %s approved them", changes, ctx.MaxResourceChanges, ctx.User.Username)
+			return "", nil
+		}
+		return failure + fmt.Sprintf(" Only members of %s can approve this.", strings.Join(ctx.MaxResourceChangesTeams, ", ")), nil
+	}
+	if len(ctx.MaxResourceChangesTeams) > 0 {
+		failure += fmt.Sprintf(" A member of %s can comment `atlantis apply --resource-changes-approved` to apply it anyway.", strings.Join(ctx.MaxResourceChangesTeams, ", "))
+	}
+	return failure, nil
+}
+
+// checkProtectedResources returns a failure if the project's plan changes
+// protected resources and the changes haven't been approved with
+// approve_resources.
+func checkProtectedResources(ctx command.ProjectContext, absPath string) string {
+	if ctx.ProtectedResources == nil {
+		return ""
+	}
+	protected, err := protectedChanges(ctx.ProtectedResources, ctx, absPath)
+	if err != nil {
+		return "Plan JSON not found so changes to protected resources can't be checked. Run plan again before running apply."
+	}
+	if len(protected) == 0 {
+		return ""
+	}
+	planPath := filepath.Join(absPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	if approver, err := os.ReadFile(planPath + resourcesApprovedExt); err == nil { // nolint: gosec
+		ctx.Log.Info("applying changes to protected resources %s since %s approved them", strings.Join(protected, ", "), approver)
+		return ""
+	}
+	return fmt.Sprintf("Plan changes protected resources: %s. A member of %s must comment `atlantis approve_resources` before it can be applied.",
+		strings.Join(protected, ", "), strings.Join(ctx.ProtectedResources.ApproverTeams, ", "))
 }
 
-func (p *DefaultProjectCommandRunner) doVersion(ctx command.ProjectContext) (versionOut string, failure string, err error) {
+// checkApplyWindows returns a failure if now is outside of the project's apply
+// windows and the user isn't a member of one of the apply window teams.
+func (p *DefaultProjectCommandRunner) checkApplyWindows(ctx command.ProjectContext, now time.Time) (string, error) {
+	if valid.InApplyWindows(ctx.ApplyWindows, now) {
+		return "", nil
+	}
+	if p.VCSClient != nil {
+		isAdmin, err := userInTeams(p.VCSClient, ctx.Pull.BaseRepo, ctx.User, ctx.ApplyWindowTeams)
+		if err != nil {
+			return "", err
+		}
+		if isAdmin {
+			ctx.Log.Info("applying outside of apply windows since %s is in one of the apply window teams", ctx.User.Username)
+			return "", nil
+		}
+	}
+	next, ok := valid.NextApplyWindow(ctx.ApplyWindows, now)
+	if !ok {
+		return "Applies are only allowed during this project's apply windows and none of them open in the next year.", nil
+	}
+	next = next.In(ctx.ApplyWindows[0].Location)
+	return fmt.Sprintf("Applies are only allowed during this project's apply windows. The next window opens at %s.", next.Format("2006-01-02 15:04 MST")), nil
+}
+
+// doRunSteps runs the steps in ctx in the project's directory. It's used by
+// commands that only run Terraform in an already cloned project.
+func (p *DefaultProjectCommandRunner) doRunSteps(ctx command.ProjectContext) (out string, failure string, err error) {
 	repoDir, err := p.WorkingDir.GetWorkingDir(ctx.Pull.BaseRepo, ctx.Pull, ctx.Workspace)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -464,7 +1198,7 @@ func (p *DefaultProjectCommandRunner) doVersion(ctx command.ProjectContext) (ver
 	}
 	defer unlockFn()
 
-	outputs, err := p.runSteps(ctx.Steps, ctx, absPath)
+	outputs, err := p.runSteps(ctx.Steps, ctx, repoDir, absPath)
 	if err != nil {
 		return "", "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
 	}
@@ -472,36 +1206,124 @@ func (p *DefaultProjectCommandRunner) doVersion(ctx command.ProjectContext) (ver
 	return strings.Join(outputs, "\n"), "", nil
 }
 
-func (p *DefaultProjectCommandRunner) runSteps(steps []valid.Step, ctx command.ProjectContext, absPath string) ([]string, error) {
+func (p *DefaultProjectCommandRunner) runSteps(steps []valid.Step, ctx command.ProjectContext, repoDir string, absPath string) ([]string, error) {
 	var outputs []string
 
 	envs := make(map[string]string)
+	for name, val := range ctx.TerraformEnv {
+		envs[name] = val
+	}
+	// Scope the git config of the commands that steps run to the workspace.
+	if _, err := os.Stat(GitConfigFile(repoDir)); err == nil {
+		envs["GIT_CONFIG_GLOBAL"] = GitConfigFile(repoDir)
+	}
+	if ctx.CloudCredentials != nil && p.CloudCredentials != nil {
+		credsEnv, cleanup, err := p.CloudCredentials.Env(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting cloud credentials")
+		}
+		defer cleanup()
+		for name, val := range credsEnv {
+			envs[name] = val
+		}
+	}
+	gitCredentials := p.GitCredentials
+	if len(gitCredentials) > 0 {
+		gitCredentials = p.TenantCredentials.GitCredentials(ctx.BaseRepo, gitCredentials)
+	}
+	gitEnv, err := GitCredentialsEnv(gitCredentials)
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range gitEnv {
+		nameVal := strings.SplitN(kv, "=", 2)
+		envs[nameVal[0]] = nameVal[1]
+	}
+	// plan is the latest plan that the conditions of steps are checked
+	// against.
+	var plan *models.PlanSuccess
 	for _, step := range steps {
+		if step.When != "" {
+			if plan == nil {
+				if plan, err = loadPlanSummary(ctx, absPath); err != nil {
+					return outputs, errors.Wrapf(err, "checking %q condition of %s step", step.When, step.StepName)
+				}
+			}
+			if !stepConditionMet(step.When, *plan) {
+				ctx.Log.Debug("skipping %s step since the plan doesn't meet its %q condition", step.StepName, step.When)
+				continue
+			}
+		}
+		stepEnvs, secrets, err := p.stepEnv(envs, step)
+		if err != nil {
+			return outputs, err
+		}
+		if defaultArgs := ctx.TerraformExtraArgs[step.StepName]; len(defaultArgs) > 0 {
+			step.ExtraArgs = append(append([]string{}, defaultArgs...), step.ExtraArgs...)
+		}
 		var out string
-		var err error
 		switch step.StepName {
 		case "init":
-			out, err = p.InitStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+			out, err = p.InitStepRunner.Run(ctx, step.ExtraArgs, absPath, stepEnvs)
+			if err == nil {
+				// Check the providers and modules before they're used.
+				err = checkDependencies(ctx, absPath)
+			}
+		case "validate":
+			out, err = p.ValidateStepRunner.Run(ctx, step.ExtraArgs, absPath, stepEnvs)
 		case "plan":
-			out, err = p.PlanStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+			out, err = p.PlanStepRunner.Run(ctx, step.ExtraArgs, absPath, stepEnvs)
+			if err == nil {
+				plan = &models.PlanSuccess{TerraformOutput: out}
+				err = recordPlanSummary(ctx, absPath, *plan)
+			}
+		case "graph":
+			err = p.graph(ctx, step.ExtraArgs, absPath, stepEnvs)
 		case "show":
-			_, err = p.ShowStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+			_, err = p.ShowStepRunner.Run(ctx, step.ExtraArgs, absPath, stepEnvs)
 		case "policy_check":
-			out, err = p.PolicyCheckStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+			out, err = p.PolicyCheckStepRunner.Run(ctx, step.ExtraArgs, absPath, stepEnvs)
 		case "apply":
-			out, err = p.ApplyStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+			if err = p.snapshotState(ctx, absPath, stepEnvs); err != nil {
+				break
+			}
+			out, err = p.ApplyStepRunner.Run(ctx, step.ExtraArgs, absPath, stepEnvs)
+			if err == nil {
+				p.captureOutputs(ctx, absPath, stepEnvs)
+			}
 		case "version":
-			out, err = p.VersionStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+			out, err = p.VersionStepRunner.Run(ctx, step.ExtraArgs, absPath, stepEnvs)
+		case "force_unlock_state":
+			out, err = p.ForceUnlockStateStepRunner.Run(ctx, step.ExtraArgs, absPath, stepEnvs)
 		case "run":
-			out, err = p.RunStepRunner.Run(ctx, step.RunCommand, absPath, envs, true)
+			if step.CaptureAs == "" {
+				out, err = p.RunStepRunner.Run(ctx, step.RunCommand, absPath, stepEnvs, true)
+				break
+			}
+			// Captured output is passed to the steps after this one instead
+			// of being shown, like an env step's.
+			out, err = p.RunStepRunner.Run(ctx, step.RunCommand, absPath, stepEnvs, false)
+			envs[step.CaptureAs] = strings.TrimSuffix(out, "\n")
+			out = ""
 		case "env":
-			out, err = p.EnvStepRunner.Run(ctx, step.RunCommand, step.EnvVarValue, absPath, envs)
+			out, err = p.EnvStepRunner.Run(ctx, step.RunCommand, step.EnvVarValue, absPath, stepEnvs)
 			envs[step.EnvVarName] = out
 			// We reset out to the empty string because we don't want it to
 			// be printed to the PR, it's solely to set the environment variable.
 			out = ""
 		case "multienv":
-			out, err = p.MultiEnvStepRunner.Run(ctx, step.RunCommand, absPath, envs)
+			out, err = p.MultiEnvStepRunner.Run(ctx, step.RunCommand, absPath, stepEnvs)
+		case "verify":
+			out, err = p.RunStepRunner.Run(ctx, step.RunCommand, absPath, stepEnvs, true)
+			if err != nil {
+				out, err = p.verificationFailed(ctx, step.OnFailure, err)
+			}
+		}
+		if len(secrets) > 0 {
+			out = redactSecrets(out, secrets)
+			if err != nil {
+				err = errors.New(redactSecrets(err.Error(), secrets))
+			}
 		}
 
 		if out != "" {
@@ -513,3 +1335,88 @@ func (p *DefaultProjectCommandRunner) runSteps(steps []valid.Step, ctx command.P
 	}
 	return outputs, nil
 }
+
+// planSummaryExt is appended to the name of a plan file to get the name of the
+// file with the plan's summary, which the conditions of the steps in later
+// stages are checked against.
+const planSummaryExt = ".summary"
+
+// recordPlanSummary writes the summary of plan next to the project's plan
+// file.
+func recordPlanSummary(ctx command.ProjectContext, absPath string, plan models.PlanSuccess) error {
+	planPath := filepath.Join(absPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	if _, err := os.Stat(planPath); os.IsNotExist(err) {
+		return nil
+	}
+	return errors.Wrap(os.WriteFile(planPath+planSummaryExt, []byte(plan.Summary()), 0600), "recording plan summary")
+}
+
+// loadPlanSummary returns the plan whose summary was recorded by
+// recordPlanSummary. During plan, steps can only be conditional on the plan
+// step before them, so the summary of the previous plan isn't used.
+func loadPlanSummary(ctx command.ProjectContext, absPath string) (*models.PlanSuccess, error) {
+	if ctx.CommandName == command.Plan {
+		return nil, errors.New("the step must come after the plan step")
+	}
+	planPath := filepath.Join(absPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	contents, err := os.ReadFile(planPath + planSummaryExt) // nolint: gosec
+	if os.IsNotExist(err) {
+		return nil, errors.New("the plan's summary wasn't recorded, run plan again")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading plan summary")
+	}
+	return &models.PlanSuccess{TerraformOutput: string(contents)}, nil
+}
+
+// stepConditionMet returns true if plan meets the when condition of a step.
+func stepConditionMet(when string, plan models.PlanSuccess) bool {
+	stats := plan.Stats()
+	switch when {
+	case valid.WhenHasChanges:
+		return stats.Changes
+	case valid.WhenNoChanges:
+		return !stats.Changes
+	case valid.WhenDestroyDetected:
+		return stats.Destroy > 0
+	}
+	return true
+}
+
+// stepEnv returns envs with the step's own env vars added, and the values of
+// the secrets that they refer to so they can be redacted from the output.
+func (p *DefaultProjectCommandRunner) stepEnv(envs map[string]string, step valid.Step) (map[string]string, []string, error) {
+	if len(step.Env) == 0 {
+		return envs, nil, nil
+	}
+	stepEnvs := make(map[string]string, len(envs)+len(step.Env))
+	for name, val := range envs {
+		stepEnvs[name] = val
+	}
+	var secrets []string
+	for name, val := range step.Env {
+		if strings.HasPrefix(val, valid.SecretRefPrefix) {
+			if p.Secrets == nil {
+				return nil, nil, fmt.Errorf("env var %s of %s step refers to a secret but no secrets provider is configured", name, step.StepName)
+			}
+			secret, err := p.Secrets.Resolve(strings.TrimPrefix(val, valid.SecretRefPrefix))
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "resolving env var %s of %s step", name, step.StepName)
+			}
+			if secret != "" {
+				secrets = append(secrets, secret)
+			}
+			val = secret
+		}
+		stepEnvs[name] = val
+	}
+	return stepEnvs, secrets, nil
+}
+
+// redactSecrets replaces the secrets in out.
+func redactSecrets(out string, secrets []string) string {
+	for _, secret := range secrets {
+		out = strings.ReplaceAll(out, secret, "<redacted>")
+	}
+	return out
+}
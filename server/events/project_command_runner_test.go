@@ -17,7 +17,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	. "github.com/petergtz/pegomock"
@@ -25,11 +30,14 @@ import (
 	"github.com/runatlantis/atlantis/server/core/runtime"
 	tmocks "github.com/runatlantis/atlantis/server/core/terraform/mocks"
 	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/changetickets"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/mocks"
 	eventmocks "github.com/runatlantis/atlantis/server/events/mocks"
 	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
 	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/jobs"
 	jobmocks "github.com/runatlantis/atlantis/server/jobs/mocks"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
@@ -118,6 +126,9 @@ func TestDefaultProjectCommandRunner_Plan(t *testing.T) {
 	Assert(t, res.PlanSuccess != nil, "exp plan success")
 	Equals(t, "https://lock-key", res.PlanSuccess.LockURL)
 	Equals(t, "run\napply\nplan\ninit", res.PlanSuccess.TerraformOutput)
+	savedOutput, err := os.ReadFile(filepath.Join(repoDir, "default.plan.txt"))
+	Ok(t, err)
+	Equals(t, res.PlanSuccess.TerraformOutput, string(savedOutput))
 	expSteps := []string{"run", "apply", "plan", "init", "env"}
 	for _, step := range expSteps {
 		switch step {
@@ -133,6 +144,230 @@ func TestDefaultProjectCommandRunner_Plan(t *testing.T) {
 	}
 }
 
+// Test that the plan fails after init if the project uses providers that are
+// against its provider policy.
+func TestDefaultProjectCommandRunner_PlanProviderPolicy(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockInit := mocks.NewMockStepRunner()
+	mockPlan := mocks.NewMockStepRunner()
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	runner := events.DefaultProjectCommandRunner{
+		Locker:           mockLocker,
+		LockURLGenerator: mockURLGenerator{},
+		InitStepRunner:   mockInit,
+		PlanStepRunner:   mockPlan,
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+	}
+
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	lockFile := `provider "registry.terraform.io/hashicorp/aws" {
+  version     = "3.75.2"
+  constraints = "~> 3.0"
+}
+
+provider "registry.terraform.io/hashicorp/random" {
+  version     = "3.4.3"
+  constraints = "3.4.3"
+}
+
+provider "registry.terraform.io/hashicorp/null" {
+  version = "3.2.0"
+}
+`
+	Ok(t, os.WriteFile(filepath.Join(repoDir, ".terraform.lock.hcl"), []byte(lockFile), 0600))
+	When(mockWorkingDir.Clone(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsPullRequest(),
+		AnyString(),
+	)).ThenReturn(repoDir, false, nil)
+	unlocked := false
+	When(mockLocker.TryLock(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsPullRequest(),
+		matchers.AnyModelsUser(),
+		AnyString(),
+		matchers.AnyModelsProject(),
+	)).ThenReturn(&events.TryLockResponse{
+		LockAcquired: true,
+		LockKey:      "lock-key",
+		UnlockFn: func() error {
+			unlocked = true
+			return nil
+		},
+	}, nil)
+
+	aws, err := valid.NewAllowedProvider("hashicorp/aws", ">= 4.0, < 5.0")
+	Ok(t, err)
+	null, err := valid.NewAllowedProvider("hashicorp/null", "")
+	Ok(t, err)
+	ctx := command.ProjectContext{
+		Log: logging.NewNoopLogger(t),
+		Steps: []valid.Step{
+			{StepName: "init"},
+			{StepName: "plan"},
+		},
+		Workspace:  "default",
+		RepoRelDir: ".",
+		ProviderPolicy: valid.ProviderPolicy{
+			AllowedProviders: []valid.AllowedProvider{aws, null},
+			RequirePinned:    true,
+		},
+	}
+	When(mockInit.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("init", nil)
+
+	res := runner.Plan(ctx)
+	Assert(t, res.PlanSuccess == nil, "exp plan to fail")
+	ErrEquals(t, `project uses providers that are not allowed by the server's provider policy:
+* version 3.75.2 of provider "registry.terraform.io/hashicorp/aws" is not allowed, allowed versions: ">= 4.0, < 5.0"
+* provider "registry.terraform.io/hashicorp/null" is not pinned: add a version constraint for it to required_providers
+* provider "registry.terraform.io/hashicorp/random" is not allowed
+init`, res.Error)
+	Assert(t, unlocked, "exp lock to be released")
+	mockPlan.VerifyWasCalled(Never()).Run(ctx, nil, repoDir, map[string]string{})
+}
+
+// Test that the plan is discarded if a workflow that doesn't use the init step
+// installs modules with sources that aren't allowed.
+func TestDefaultProjectCommandRunner_PlanModuleSources(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockRun := mocks.NewMockCustomStepRunner()
+	mockPlan := mocks.NewMockStepRunner()
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	runner := events.DefaultProjectCommandRunner{
+		Locker:           mockLocker,
+		LockURLGenerator: mockURLGenerator{},
+		RunStepRunner:    mockRun,
+		PlanStepRunner:   mockPlan,
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+	}
+
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	manifest := `{"Modules":[
+{"Key":"","Source":"","Dir":"."},
+{"Key":"vpc","Source":"git::https://github.com/my-org/modules.git//vpc?ref=v1.0.0","Dir":".terraform/modules/vpc/vpc"},
+{"Key":"vpc.subnets","Source":"./subnets","Dir":".terraform/modules/vpc/vpc/subnets"},
+{"Key":"dns","Source":"git::https://github.com/evil/modules.git//dns","Dir":".terraform/modules/dns/dns"}
+]}`
+	Ok(t, os.MkdirAll(filepath.Join(repoDir, ".terraform", "modules"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(repoDir, ".terraform", "modules", "modules.json"), []byte(manifest), 0600))
+	planFile := filepath.Join(repoDir, "default.tfplan")
+	Ok(t, os.WriteFile(planFile, nil, 0600))
+	When(mockWorkingDir.Clone(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsPullRequest(),
+		AnyString(),
+	)).ThenReturn(repoDir, false, nil)
+	When(mockLocker.TryLock(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsPullRequest(),
+		matchers.AnyModelsUser(),
+		AnyString(),
+		matchers.AnyModelsProject(),
+	)).ThenReturn(&events.TryLockResponse{
+		LockAcquired: true,
+		LockKey:      "lock-key",
+		UnlockFn:     func() error { return nil },
+	}, nil)
+
+	ctx := command.ProjectContext{
+		Log: logging.NewNoopLogger(t),
+		Steps: []valid.Step{
+			{StepName: "run", RunCommand: "terraform init"},
+			{StepName: "plan"},
+		},
+		Workspace:            "default",
+		RepoRelDir:           ".",
+		AllowedModuleSources: []string{"git::https://github.com/my-org/*"},
+	}
+	When(mockRun.Run(ctx, "terraform init", repoDir, map[string]string{}, true)).ThenReturn("init", nil)
+	When(mockPlan.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("plan", nil)
+
+	res := runner.Plan(ctx)
+	Assert(t, res.PlanSuccess == nil, "exp plan to fail")
+	ErrEquals(t, `project uses module sources that are not allowed by the server's module source allowlist:
+* module "dns" uses source "git::https://github.com/evil/modules.git//dns"
+init
+plan`, res.Error)
+	_, err := os.Stat(planFile)
+	Assert(t, os.IsNotExist(err), "exp plan to be deleted")
+}
+
+type fakeCloudCredentials struct {
+	cleanedUp bool
+}
+
+func (f *fakeCloudCredentials) Env(ctx command.ProjectContext) (map[string]string, func(), error) {
+	return map[string]string{"AWS_ROLE_ARN": ctx.CloudCredentials.AWS.RoleARN}, func() { f.cleanedUp = true }, nil
+}
+
+// Test that every step gets the project's cloud credentials and that they're
+// cleaned up afterwards.
+func TestDefaultProjectCommandRunner_PlanCloudCredentials(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockInit := mocks.NewMockStepRunner()
+	mockPlan := mocks.NewMockStepRunner()
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	creds := &fakeCloudCredentials{}
+	runner := events.DefaultProjectCommandRunner{
+		Locker:           mockLocker,
+		LockURLGenerator: mockURLGenerator{},
+		InitStepRunner:   mockInit,
+		PlanStepRunner:   mockPlan,
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		CloudCredentials: creds,
+	}
+
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.Clone(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsPullRequest(),
+		AnyString(),
+	)).ThenReturn(repoDir, false, nil)
+	When(mockLocker.TryLock(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsPullRequest(),
+		matchers.AnyModelsUser(),
+		AnyString(),
+		matchers.AnyModelsProject(),
+	)).ThenReturn(&events.TryLockResponse{
+		LockAcquired: true,
+		LockKey:      "lock-key",
+	}, nil)
+
+	ctx := command.ProjectContext{
+		Log: logging.NewNoopLogger(t),
+		Steps: []valid.Step{
+			{StepName: "init"},
+			{StepName: "plan"},
+		},
+		Workspace:  "default",
+		RepoRelDir: ".",
+		CloudCredentials: &valid.CloudCredentials{
+			AWS: &valid.AWSCredentials{RoleARN: "arn:aws:iam::123456789012:role/atlantis"},
+		},
+	}
+	expEnvs := map[string]string{"AWS_ROLE_ARN": "arn:aws:iam::123456789012:role/atlantis"}
+	When(mockInit.Run(ctx, nil, repoDir, expEnvs)).ThenReturn("init", nil)
+	When(mockPlan.Run(ctx, nil, repoDir, expEnvs)).ThenReturn("plan", nil)
+
+	res := runner.Plan(ctx)
+	Assert(t, res.PlanSuccess != nil, "exp plan success, got %v", res.Error)
+	Equals(t, "init\nplan", res.PlanSuccess.TerraformOutput)
+	Assert(t, creds.cleanedUp, "exp credentials to be cleaned up")
+}
+
 func TestProjectOutputWrapper(t *testing.T) {
 	RegisterMockTestingT(t)
 	ctx := command.ProjectContext{
@@ -228,8 +463,13 @@ func TestProjectOutputWrapper(t *testing.T) {
 				runner.Apply(ctx)
 			}
 
+			// The success status summarizes the plan.
+			expCtx := ctx
+			if c.Success {
+				expCtx.PlanStats = &models.PlanSuccessStats{}
+			}
 			mockJobURLSetter.VerifyWasCalled(Once()).SetJobURLWithStatus(ctx, c.CommandName, models.PendingCommitStatus)
-			mockJobURLSetter.VerifyWasCalled(Once()).SetJobURLWithStatus(ctx, c.CommandName, expCommitStatus)
+			mockJobURLSetter.VerifyWasCalled(Once()).SetJobURLWithStatus(expCtx, c.CommandName, expCommitStatus)
 
 			switch c.CommandName {
 			case command.Plan:
@@ -241,6 +481,129 @@ func TestProjectOutputWrapper(t *testing.T) {
 	}
 }
 
+// recordingJobURLSetter records the statuses that are set.
+type recordingJobURLSetter struct {
+	mu       sync.Mutex
+	ctxs     []command.ProjectContext
+	statuses []models.CommitStatus
+}
+
+func (r *recordingJobURLSetter) SetJobURLWithStatus(ctx command.ProjectContext, _ command.Name, status models.CommitStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ctxs = append(r.ctxs, ctx)
+	r.statuses = append(r.statuses, status)
+	return nil
+}
+
+type lastLineJobOutput string
+
+func (l lastLineJobOutput) LastLine(string) string {
+	return string(l)
+}
+
+// Test that the pending status of long running applies is updated while
+// they run and that the updates stop before the final status is set.
+func TestProjectOutputWrapper_ApplyHeartbeat(t *testing.T) {
+	RegisterMockTestingT(t)
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Workspace:  "default",
+		RepoRelDir: ".",
+		JobID:      "1234",
+	}
+	mockProjectCommandRunner := mocks.NewMockProjectCommandRunner()
+	When(mockProjectCommandRunner.Apply(matchers.AnyModelsProjectCommandContext())).Then(func(params []Param) ReturnValues {
+		time.Sleep(100 * time.Millisecond)
+		return ReturnValues{command.ProjectResult{ApplySuccess: "success"}}
+	})
+	jobURLSetter := &recordingJobURLSetter{}
+	runner := &events.ProjectOutputWrapper{
+		JobURLSetter:           jobURLSetter,
+		JobMessageSender:       eventmocks.NewMockJobMessageSender(),
+		ProjectCommandRunner:   mockProjectCommandRunner,
+		ApplyHeartbeatInterval: 10 * time.Millisecond,
+		JobOutput:              lastLineJobOutput("aws_instance.web: Still creating... [10s elapsed]"),
+	}
+	runner.Apply(ctx)
+
+	jobURLSetter.mu.Lock()
+	defer jobURLSetter.mu.Unlock()
+	Assert(t, len(jobURLSetter.statuses) > 2, "expected heartbeats, got %d statuses", len(jobURLSetter.statuses))
+	Equals(t, models.PendingCommitStatus, jobURLSetter.statuses[0])
+	Equals(t, time.Duration(0), jobURLSetter.ctxs[0].RunningFor)
+	heartbeat := jobURLSetter.ctxs[1]
+	Equals(t, models.PendingCommitStatus, jobURLSetter.statuses[1])
+	Assert(t, heartbeat.RunningFor > 0, "expected heartbeat to have how long the apply has been running")
+	Equals(t, "aws_instance.web: Still creating... [10s elapsed]", heartbeat.LastOutputLine)
+	Equals(t, models.SuccessCommitStatus, jobURLSetter.statuses[len(jobURLSetter.statuses)-1])
+}
+
+func TestProjectOutputWrapper_ApplyCancelled(t *testing.T) {
+	RegisterMockTestingT(t)
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Workspace:  "default",
+		RepoRelDir: ".",
+		JobID:      "1234",
+	}
+	canceller := jobs.NewJobCanceller(nil, time.Minute)
+	mockProjectCommandRunner := mocks.NewMockProjectCommandRunner()
+	When(mockProjectCommandRunner.Apply(matchers.AnyModelsProjectCommandContext())).Then(func(params []Param) ReturnValues {
+		ctx := params[0].(command.ProjectContext)
+		cmd := exec.Command("sleep", "30")
+		done, err := ctx.Processes.Start(ctx.JobID, cmd)
+		if err != nil {
+			return ReturnValues{command.ProjectResult{Error: err}}
+		}
+		defer done()
+		return ReturnValues{command.ProjectResult{Error: cmd.Wait()}}
+	})
+	runner := &events.ProjectOutputWrapper{
+		JobURLSetter:         &recordingJobURLSetter{},
+		JobMessageSender:     eventmocks.NewMockJobMessageSender(),
+		ProjectCommandRunner: mockProjectCommandRunner,
+		Canceller:            canceller,
+	}
+	go func() {
+		for {
+			if _, err := canceller.Cancel("1234", "@alice"); err == nil {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	res := runner.Apply(ctx)
+
+	Equals(t, nil, res.Error)
+	Equals(t, "Apply was cancelled by @alice.\nsignal: interrupt", res.Failure)
+	Equals(t, 0, len(canceller.Running("", 0)))
+}
+
+func TestProjectOutputWrapper_PlanSuperseded(t *testing.T) {
+	RegisterMockTestingT(t)
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Pull:       models.PullRequest{Num: 1, HeadCommit: "old"},
+		Workspace:  "default",
+		RepoRelDir: ".",
+		JobID:      "1234",
+	}
+	canceller := jobs.NewJobCanceller(nil, time.Minute)
+	canceller.Supersede("", 1, "new", "the push of new")
+	mockProjectCommandRunner := mocks.NewMockProjectCommandRunner()
+	runner := &events.ProjectOutputWrapper{
+		JobURLSetter:         &recordingJobURLSetter{},
+		JobMessageSender:     eventmocks.NewMockJobMessageSender(),
+		ProjectCommandRunner: mockProjectCommandRunner,
+		Canceller:            canceller,
+	}
+	res := runner.Plan(ctx)
+
+	Equals(t, "Plan was cancelled by the push of new.", res.Failure)
+	mockProjectCommandRunner.VerifyWasCalled(Never()).Plan(matchers.AnyModelsProjectCommandContext())
+}
+
 // Test what happens if there's no working dir. This signals that the project
 // was never planned.
 func TestDefaultProjectCommandRunner_ApplyNotCloned(t *testing.T) {
@@ -324,44 +687,600 @@ func TestDefaultProjectCommandRunner_ApplyDiverged(t *testing.T) {
 	Equals(t, "Default branch must be rebased onto pull request before running apply.", res.Failure)
 }
 
-// Test that it runs the expected apply steps.
-func TestDefaultProjectCommandRunner_Apply(t *testing.T) {
-	cases := []struct {
-		description string
-		steps       []valid.Step
-		applyReqs   []string
-
-		expSteps      []string
-		expOut        string
-		expFailure    string
-		pullMergeable bool
-	}{
-		{
-			description: "normal workflow",
-			steps:       valid.DefaultApplyStage.Steps,
-			expSteps:    []string{"apply"},
-			expOut:      "apply",
+// Test that if no_destroys is required, plans that destroy protected
+// resources can only be applied when approved by a member of an approver team.
+func TestAggregateApplyRequirements_NoDestroys(t *testing.T) {
+	RegisterMockTestingT(t)
+	vcsClient := vcsmocks.NewMockClient()
+	handler := &events.AggregateApplyRequirements{
+		WorkingDir: mocks.NewMockWorkingDir(),
+		VCSClient:  vcsClient,
+	}
+	ctx := command.ProjectContext{
+		Log:               logging.NewNoopLogger(t),
+		ApplyRequirements: []string{"no_destroys"},
+		RepoRelDir:        ".",
+		Workspace:         "default",
+		User:              models.User{Username: "octocat"},
+		DestroyProtection: &valid.DestroyProtection{
+			Addresses:     []string{"*aws_db_instance.*"},
+			ApproverTeams: []string{"dba"},
 		},
-		{
-			description: "approval required",
-			steps:       valid.DefaultApplyStage.Steps,
-			applyReqs:   []string{"approved"},
-			expSteps:    []string{"approve", "apply"},
-			expOut:      "apply",
+	}
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+
+	failure, err := handler.ValidateProject(repoDir, ctx)
+	Ok(t, err)
+	Equals(t, "Plan JSON not found so destroyed resources can't be checked. Run plan again before running apply.", failure)
+
+	// Resources that are only planned to be destroyed in the plan's text,
+	// ex. in a heredoc, aren't destroyed.
+	Ok(t, os.WriteFile(filepath.Join(repoDir, ctx.GetPlanOutputFileName()), []byte("  # aws_db_instance.other will be destroyed\n"), 0600))
+	showJSON := `{"resource_changes": [
+  {"address": "aws_instance.web", "change": {"actions": ["delete"]}},
+  {"address": "aws_db_instance.main", "change": {"actions": ["delete", "create"]}},
+  {"address": "aws_db_instance.replica", "change": {"actions": ["update"]}}
+]}`
+	Ok(t, os.WriteFile(filepath.Join(repoDir, ctx.GetShowResultFileName()), []byte(showJSON), 0600))
+	failure, err = handler.ValidateProject(repoDir, ctx)
+	Ok(t, err)
+	Equals(t, "Plan destroys or replaces protected resources: aws_db_instance.main. A member of dba can comment `atlantis apply --destroy-approved` to apply it anyway.", failure)
+
+	ctx.DestroyApproved = true
+	When(vcsClient.GetTeamNamesForUser(ctx.Pull.BaseRepo, ctx.User)).ThenReturn([]string{"developers"}, nil)
+	failure, err = handler.ValidateProject(repoDir, ctx)
+	Ok(t, err)
+	Equals(t, "Plan destroys or replaces protected resources: aws_db_instance.main. Only members of dba can approve this.", failure)
+
+	When(vcsClient.GetTeamNamesForUser(ctx.Pull.BaseRepo, ctx.User)).ThenReturn([]string{"developers", "DBA"}, nil)
+	failure, err = handler.ValidateProject(repoDir, ctx)
+	Ok(t, err)
+	Equals(t, "", failure)
+
+	// Destroying resources that aren't protected doesn't need approval.
+	ctx.DestroyApproved = false
+	ctx.DestroyProtection.Addresses = []string{"module.vpc.*"}
+	failure, err = handler.ValidateProject(repoDir, ctx)
+	Ok(t, err)
+	Equals(t, "", failure)
+}
+
+type fakeChangeTickets struct {
+	tickets  []changetickets.Ticket
+	approved map[string]bool
+	closed   map[string]string
+}
+
+func (f *fakeChangeTickets) Create(ticket changetickets.Ticket) (string, error) {
+	f.tickets = append(f.tickets, ticket)
+	return fmt.Sprintf("CHG%d", len(f.tickets)), nil
+}
+
+func (f *fakeChangeTickets) Approved(id string) (bool, error) {
+	return f.approved[id], nil
+}
+
+func (f *fakeChangeTickets) Close(id string, comment string) error {
+	f.closed[id] = comment
+	return nil
+}
+
+func (f *fakeChangeTickets) URL(id string) string {
+	return "https://tickets.example.com/" + id
+}
+
+// Test that if change_ticket_approved is required, a change ticket is opened
+// with the plan's summary and the plan can be applied once it's approved,
+// which closes the ticket.
+func TestDefaultProjectCommandRunner_ApplyChangeTicket(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	tickets := &fakeChangeTickets{approved: map[string]bool{}, closed: map[string]string{}}
+	runner := &events.DefaultProjectCommandRunner{
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		AggregateApplyRequirements: &events.AggregateApplyRequirements{
+			WorkingDir:    mockWorkingDir,
+			ChangeTickets: tickets,
 		},
-		{
-			description:   "mergeable required",
-			steps:         valid.DefaultApplyStage.Steps,
-			pullMergeable: true,
-			applyReqs:     []string{"mergeable"},
-			expSteps:      []string{"apply"},
-			expOut:        "apply",
+		ChangeTickets: tickets,
+		Webhooks:      mocks.NewMockWebhooksSender(),
+	}
+	ctx := command.ProjectContext{
+		Log:               logging.NewNoopLogger(t),
+		ApplyRequirements: []string{"change_ticket_approved"},
+		Workspace:         "default",
+		RepoRelDir:        "network",
+		User:              models.User{Username: "octocat"},
+		Pull: models.PullRequest{
+			URL:      "https://github.com/owner/repo/pull/1",
+			BaseRepo: models.Repo{FullName: "owner/repo"},
 		},
-		{
-			description:   "mergeable required, pull not mergeable",
-			steps:         valid.DefaultApplyStage.Steps,
-			pullMergeable: false,
-			applyReqs:     []string{"mergeable"},
+	}
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.GetWorkingDir(ctx.Pull.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+	Ok(t, os.Mkdir(filepath.Join(tmp, "network"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(tmp, "network", ctx.GetPlanOutputFileName()), []byte("Plan: 1 to add, 0 to change, 0 to destroy."), 0600))
+
+	res := runner.Apply(ctx)
+	Equals(t, "Opened change ticket [CHG1](https://tickets.example.com/CHG1). Run apply again once it's approved.", res.Failure)
+	Equals(t, []changetickets.Ticket{{
+		Summary: "Apply network in owner/repo",
+		Description: "Atlantis will apply the plan of network in workspace default once this ticket is approved.\n\n" +
+			"Pull request: https://github.com/owner/repo/pull/1\nRequested by: octocat\nPlan: Plan: 1 to add, 0 to change, 0 to destroy.",
+	}}, tickets.tickets)
+
+	res = runner.Apply(ctx)
+	Equals(t, "Change ticket [CHG1](https://tickets.example.com/CHG1) must be approved before running apply.", res.Failure)
+
+	tickets.approved["CHG1"] = true
+	res = runner.Apply(ctx)
+	Equals(t, "", res.Failure)
+	Ok(t, res.Error)
+	Equals(t, map[string]string{"CHG1": "Applied by Atlantis for octocat in https://github.com/owner/repo/pull/1."}, tickets.closed)
+
+	// The next apply needs a new ticket.
+	res = runner.Apply(ctx)
+	Equals(t, "Opened change ticket [CHG2](https://tickets.example.com/CHG2). Run apply again once it's approved.", res.Failure)
+}
+
+// Test that plans that change more than the maximum number of resources can
+// only be applied when approved by a member of a max resource changes team.
+func TestDefaultProjectCommandRunner_ApplyMaxResourceChanges(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	vcsClient := vcsmocks.NewMockClient()
+	runner := &events.DefaultProjectCommandRunner{
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		AggregateApplyRequirements: &events.AggregateApplyRequirements{
+			WorkingDir: mockWorkingDir,
+		},
+		Webhooks:  mocks.NewMockWebhooksSender(),
+		VCSClient: vcsClient,
+	}
+	ctx := command.ProjectContext{
+		Log:                     logging.NewNoopLogger(t),
+		Workspace:               "default",
+		RepoRelDir:              ".",
+		User:                    models.User{Username: "octocat"},
+		MaxResourceChanges:      50,
+		MaxResourceChangesTeams: []string{"admin"},
+	}
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+	// 40 creates, 5 updates and 5 replaces, which count twice.
+	var resourceChanges []string
+	for _, c := range []struct {
+		actions string
+		count   int
+	}{{`["create"]`, 40}, {`["update"]`, 5}, {`["delete", "create"]`, 5}} {
+		for i := 0; i < c.count; i++ {
+			resourceChanges = append(resourceChanges, fmt.Sprintf(`{"address": "null_resource.r%d", "change": {"actions": %s}}`, len(resourceChanges), c.actions))
+		}
+	}
+	Ok(t, os.WriteFile(filepath.Join(tmp, ctx.GetShowResultFileName()), []byte(`{"resource_changes": [`+strings.Join(resourceChanges, ",")+`]}`), 0600))
+
+	res := runner.Apply(ctx)
+	Equals(t, "Plan changes 55 resources which is more than the maximum of 50. A member of admin can comment `atlantis apply --resource-changes-approved` to apply it anyway.", res.Failure)
+
+	// --force only overrides the maximum plan age.
+	ctx.ForceApply = true
+	res = runner.Apply(ctx)
+	Equals(t, "Plan changes 55 resources which is more than the maximum of 50. A member of admin can comment `atlantis apply --resource-changes-approved` to apply it anyway.", res.Failure)
+
+	ctx.ResourceChangesApproved = true
+	When(vcsClient.GetTeamNamesForUser(ctx.Pull.BaseRepo, ctx.User)).ThenReturn([]string{"developers"}, nil)
+	res = runner.Apply(ctx)
+	Equals(t, "Plan changes 55 resources which is more than the maximum of 50. Only members of admin can approve this.", res.Failure)
+
+	When(vcsClient.GetTeamNamesForUser(ctx.Pull.BaseRepo, ctx.User)).ThenReturn([]string{"developers", "Admin"}, nil)
+	res = runner.Apply(ctx)
+	Equals(t, "", res.Failure)
+	Ok(t, res.Error)
+}
+
+// Test that plans that change protected resources can only be applied once
+// the changes have been approved.
+func TestDefaultProjectCommandRunner_ApplyProtectedResources(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	runner := &events.DefaultProjectCommandRunner{
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		AggregateApplyRequirements: &events.AggregateApplyRequirements{
+			WorkingDir: mockWorkingDir,
+		},
+		Webhooks: mocks.NewMockWebhooksSender(),
+	}
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Workspace:  "default",
+		RepoRelDir: ".",
+		ProtectedResources: &valid.ProtectedResources{
+			Addresses:     []string{"module.vpc.*"},
+			ApproverTeams: []string{"network"},
+		},
+	}
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+	Ok(t, os.WriteFile(filepath.Join(tmp, ctx.GetShowResultFileName()), []byte(`{"resource_changes": [{"address": "module.vpc.aws_subnet.private", "change": {"actions": ["create"]}}]}`), 0600))
+
+	res := runner.Apply(ctx)
+	Equals(t, "Plan changes protected resources: module.vpc.aws_subnet.private. A member of network must comment `atlantis approve_resources` before it can be applied.", res.Failure)
+
+	Ok(t, os.WriteFile(filepath.Join(tmp, "default.tfplan.resources-approved"), []byte("approver"), 0600))
+	res = runner.Apply(ctx)
+	Equals(t, "", res.Failure)
+	Ok(t, res.Error)
+}
+
+// Test that applying a frozen project fails with the freeze's message.
+func TestDefaultProjectCommandRunner_ApplyFrozen(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	runner := &events.DefaultProjectCommandRunner{
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		AggregateApplyRequirements: &events.AggregateApplyRequirements{
+			WorkingDir: mockWorkingDir,
+		},
+		FreezeChecker: mockFreezeChecker{freeze: &models.Freeze{Message: "Incident in progress, see #ops."}},
+	}
+	ctx := command.ProjectContext{
+		Workspace:  "default",
+		RepoRelDir: ".",
+	}
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+
+	res := runner.Apply(ctx)
+	Equals(t, "Applies are frozen for this project: Incident in progress, see #ops.", res.Failure)
+}
+
+// Test that applying a plan older than the max plan age fails unless forced.
+func TestDefaultProjectCommandRunner_ApplyPlanTooOld(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	runner := &events.DefaultProjectCommandRunner{
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		Webhooks:         mocks.NewMockWebhooksSender(),
+		AggregateApplyRequirements: &events.AggregateApplyRequirements{
+			WorkingDir: mockWorkingDir,
+		},
+		MaxPlanAge: 24 * time.Hour,
+	}
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Workspace:  "default",
+		RepoRelDir: ".",
+	}
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+
+	planFile := filepath.Join(tmp, "default.tfplan")
+	Ok(t, os.WriteFile(planFile, nil, 0600))
+	old := time.Now().Add(-48 * time.Hour)
+	Ok(t, os.Chtimes(planFile, old, old))
+
+	res := runner.Apply(ctx)
+	Assert(t, strings.Contains(res.Failure, "older than the maximum plan age of 24h0m0s"), "unexpected failure: %q", res.Failure)
+
+	ctx.ForceApply = true
+	res = runner.Apply(ctx)
+	Equals(t, "", res.Failure)
+	Ok(t, res.Error)
+}
+
+// Test that plans are only applied if they were created from the pull
+// request's head commit or the commit pinned with --sha.
+func TestDefaultProjectCommandRunner_ApplyPlanCommit(t *testing.T) {
+	cases := []struct {
+		description  string
+		planCommit   string
+		headCommit   string
+		pinnedCommit string
+		expFailure   string
+	}{
+		{
+			description: "no recorded commit",
+			headCommit:  "def4567",
+		},
+		{
+			description: "head commit",
+			planCommit:  "abc1234abc1234abc1234abc1234abc1234abc1",
+			headCommit:  "abc1234abc12",
+		},
+		{
+			description: "head moved",
+			planCommit:  "abc1234",
+			headCommit:  "def4567",
+			expFailure:  "Plan was created from commit abc1234 but the pull request's head is now def4567. Run plan again or comment `atlantis apply --sha=abc1234` to apply the plan from abc1234.",
+		},
+		{
+			description:  "pinned commit",
+			planCommit:   "abc1234",
+			headCommit:   "def4567",
+			pinnedCommit: "ABC1234",
+		},
+		{
+			description:  "wrong pinned commit",
+			planCommit:   "abc1234",
+			headCommit:   "abc1234",
+			pinnedCommit: "def4567",
+			expFailure:   "Plan was created from commit abc1234, not def4567. Run plan again to plan the pull request's head commit.",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			runner := &events.DefaultProjectCommandRunner{
+				WorkingDir:       mockWorkingDir,
+				WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+				Webhooks:         mocks.NewMockWebhooksSender(),
+				AggregateApplyRequirements: &events.AggregateApplyRequirements{
+					WorkingDir: mockWorkingDir,
+				},
+			}
+			ctx := command.ProjectContext{
+				Log:          logging.NewNoopLogger(t),
+				Workspace:    "default",
+				RepoRelDir:   ".",
+				Pull:         models.PullRequest{HeadCommit: c.headCommit},
+				PinnedCommit: c.pinnedCommit,
+			}
+			tmp, cleanup := TempDir(t)
+			defer cleanup()
+			When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+
+			planFile := filepath.Join(tmp, "default.tfplan")
+			Ok(t, os.WriteFile(planFile, nil, 0600))
+			if c.planCommit != "" {
+				Ok(t, os.WriteFile(planFile+".commit", []byte(c.planCommit), 0600))
+			}
+
+			res := runner.Apply(ctx)
+			Ok(t, res.Error)
+			Equals(t, c.expFailure, res.Failure)
+		})
+	}
+}
+
+// Test that the commit a plan was created from is recorded next to it.
+func TestDefaultProjectCommandRunner_PlanRecordsCommit(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	mockPlan := mocks.NewMockStepRunner()
+	runner := &events.DefaultProjectCommandRunner{
+		Locker:           mockLocker,
+		LockURLGenerator: mockURLGenerator{},
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		PlanStepRunner:   mockPlan,
+	}
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Steps:      []valid.Step{{StepName: "plan"}},
+		Workspace:  "default",
+		RepoRelDir: ".",
+		Pull:       models.PullRequest{HeadCommit: "abc1234"},
+	}
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.Clone(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())).ThenReturn(tmp, false, nil)
+	When(mockLocker.TryLock(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsPullRequest(), matchers.AnyModelsUser(), AnyString(), matchers.AnyModelsProject())).
+		ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key"}, nil)
+	When(mockPlan.Run(ctx, nil, tmp, map[string]string{})).Then(func(params []Param) ReturnValues {
+		Ok(t, os.WriteFile(filepath.Join(tmp, "default.tfplan"), nil, 0600))
+		return ReturnValues{"plan", nil}
+	})
+
+	res := runner.Plan(ctx)
+	Ok(t, res.Error)
+	contents, err := os.ReadFile(filepath.Join(tmp, "default.tfplan.commit"))
+	Ok(t, err)
+	Equals(t, "abc1234", string(contents))
+}
+
+// Test that the JSON of plans is saved when the checks before applying them
+// need their resource changes.
+func TestDefaultProjectCommandRunner_PlanShowsPlanForApplyChecks(t *testing.T) {
+	cases := []struct {
+		description string
+		ctx         command.ProjectContext
+		expShow     bool
+	}{
+		{
+			description: "no checks",
+		},
+		{
+			description: "max resource changes",
+			ctx:         command.ProjectContext{MaxResourceChanges: 50},
+			expShow:     true,
+		},
+		{
+			description: "protected resources",
+			ctx:         command.ProjectContext{ProtectedResources: &valid.ProtectedResources{Addresses: []string{"module.vpc.*"}}},
+			expShow:     true,
+		},
+		{
+			description: "no_destroys",
+			ctx:         command.ProjectContext{ApplyRequirements: []string{"approved", "no_destroys"}},
+			expShow:     true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockLocker := mocks.NewMockProjectLocker()
+			mockPlan := mocks.NewMockStepRunner()
+			mockShow := mocks.NewMockStepRunner()
+			runner := &events.DefaultProjectCommandRunner{
+				Locker:           mockLocker,
+				LockURLGenerator: mockURLGenerator{},
+				WorkingDir:       mockWorkingDir,
+				WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+				PlanStepRunner:   mockPlan,
+				ShowStepRunner:   mockShow,
+			}
+			ctx := c.ctx
+			ctx.Log = logging.NewNoopLogger(t)
+			ctx.Steps = []valid.Step{{StepName: "plan"}}
+			ctx.Workspace = "default"
+			ctx.RepoRelDir = "."
+			tmp, cleanup := TempDir(t)
+			defer cleanup()
+			// A stale plan JSON is discarded.
+			Ok(t, os.WriteFile(filepath.Join(tmp, ctx.GetShowResultFileName()), []byte("{}"), 0600))
+			When(mockWorkingDir.Clone(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())).ThenReturn(tmp, false, nil)
+			When(mockLocker.TryLock(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsPullRequest(), matchers.AnyModelsUser(), AnyString(), matchers.AnyModelsProject())).
+				ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key"}, nil)
+			When(mockPlan.Run(ctx, nil, tmp, map[string]string{})).Then(func(params []Param) ReturnValues {
+				Ok(t, os.WriteFile(filepath.Join(tmp, "default.tfplan"), nil, 0600))
+				return ReturnValues{"plan", nil}
+			})
+
+			res := runner.Plan(ctx)
+			Ok(t, res.Error)
+			if c.expShow {
+				mockShow.VerifyWasCalledOnce().Run(ctx, nil, tmp, map[string]string{})
+			} else {
+				mockShow.VerifyWasCalled(Never()).Run(matchers.AnyModelsProjectCommandContext(), AnyStringSlice(), AnyString(), matchers.AnyMapOfStringToString())
+			}
+			_, err := os.Stat(filepath.Join(tmp, ctx.GetShowResultFileName()))
+			Assert(t, os.IsNotExist(err), "exp stale plan JSON to be discarded")
+		})
+	}
+}
+
+// Test that plans are signed and only applied if their signature is valid.
+func TestDefaultProjectCommandRunner_ApplySignedPlan(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockApply := mocks.NewMockStepRunner()
+	signer := &events.HMACPlanSigner{Key: []byte("key")}
+	runner := &events.DefaultProjectCommandRunner{
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		Webhooks:         mocks.NewMockWebhooksSender(),
+		AggregateApplyRequirements: &events.AggregateApplyRequirements{
+			WorkingDir: mockWorkingDir,
+		},
+		ApplyStepRunner: mockApply,
+		PlanSigner:      signer,
+	}
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Steps:      []valid.Step{{StepName: "apply"}},
+		Workspace:  "default",
+		RepoRelDir: ".",
+	}
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+	When(mockApply.Run(ctx, nil, tmp, map[string]string{})).ThenReturn("apply", nil)
+
+	planFile := filepath.Join(tmp, "default.tfplan")
+	Ok(t, os.WriteFile(planFile, []byte("plan"), 0600))
+	Ok(t, signer.Sign(ctx, planFile))
+	res := runner.Apply(ctx)
+	Ok(t, res.Error)
+	Equals(t, "apply", res.ApplySuccess)
+
+	Ok(t, os.WriteFile(planFile, []byte("swapped plan"), 0600))
+	res = runner.Apply(ctx)
+	ErrEquals(t, "refusing to apply: plan signature is invalid, the plan may have been tampered with. Run plan again to regenerate it", res.Error)
+	mockApply.VerifyWasCalledOnce().Run(ctx, nil, tmp, map[string]string{})
+}
+
+// Test that applying outside of the apply windows fails unless the user is a
+// member of an apply window team.
+func TestDefaultProjectCommandRunner_ApplyOutsideWindows(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	vcsClient := vcsmocks.NewMockClient()
+	runner := &events.DefaultProjectCommandRunner{
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		Webhooks:         mocks.NewMockWebhooksSender(),
+		AggregateApplyRequirements: &events.AggregateApplyRequirements{
+			WorkingDir: mockWorkingDir,
+		},
+		VCSClient: vcsClient,
+	}
+	// February 30th never happens so we're always outside of this window.
+	never, err := valid.NewApplyWindow("* * 30 2 *", "")
+	Ok(t, err)
+	ctx := command.ProjectContext{
+		Log:              logging.NewNoopLogger(t),
+		Workspace:        "default",
+		RepoRelDir:       ".",
+		User:             models.User{Username: "dev"},
+		ApplyWindows:     []valid.ApplyWindow{never},
+		ApplyWindowTeams: []string{"admin"},
+	}
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+
+	When(vcsClient.GetTeamNamesForUser(ctx.Pull.BaseRepo, ctx.User)).ThenReturn([]string{"developers"}, nil)
+	res := runner.Apply(ctx)
+	Equals(t, "Applies are only allowed during this project's apply windows and none of them open in the next year.", res.Failure)
+
+	When(vcsClient.GetTeamNamesForUser(ctx.Pull.BaseRepo, ctx.User)).ThenReturn([]string{"admin"}, nil)
+	res = runner.Apply(ctx)
+	Equals(t, "", res.Failure)
+	Ok(t, res.Error)
+}
+
+// Test that it runs the expected apply steps.
+func TestDefaultProjectCommandRunner_Apply(t *testing.T) {
+	cases := []struct {
+		description string
+		steps       []valid.Step
+		applyReqs   []string
+
+		expSteps      []string
+		expOut        string
+		expFailure    string
+		pullMergeable bool
+	}{
+		{
+			description: "normal workflow",
+			steps:       valid.DefaultApplyStage.Steps,
+			expSteps:    []string{"apply"},
+			expOut:      "apply",
+		},
+		{
+			description: "approval required",
+			steps:       valid.DefaultApplyStage.Steps,
+			applyReqs:   []string{"approved"},
+			expSteps:    []string{"approve", "apply"},
+			expOut:      "apply",
+		},
+		{
+			description:   "mergeable required",
+			steps:         valid.DefaultApplyStage.Steps,
+			pullMergeable: true,
+			applyReqs:     []string{"mergeable"},
+			expSteps:      []string{"apply"},
+			expOut:        "apply",
+		},
+		{
+			description:   "mergeable required, pull not mergeable",
+			steps:         valid.DefaultApplyStage.Steps,
+			pullMergeable: false,
+			applyReqs:     []string{"mergeable"},
 			expSteps:      []string{""},
 			expOut:        "",
 			expFailure:    "Pull request must be mergeable before running apply.",
@@ -531,7 +1450,191 @@ func TestDefaultProjectCommandRunner_ApplyRunStepFailure(t *testing.T) {
 	mockApply.VerifyWasCalledOnce().Run(ctx, nil, repoDir, expEnvs)
 }
 
-// Test run and env steps. We don't use mocks for this test since we're
+type fakePromoter struct {
+	pull *models.PullRequest
+	err  error
+}
+
+func (f fakePromoter) Promote(ctx command.ProjectContext) (*models.PullRequest, error) {
+	return f.pull, f.err
+}
+
+// Test that a link to the promotion is added to the apply output and that
+// failing to promote doesn't fail the apply.
+func TestDefaultProjectCommandRunner_ApplyPromote(t *testing.T) {
+	cases := []struct {
+		description string
+		promoter    fakePromoter
+		expOut      string
+	}{
+		{
+			description: "promoted",
+			promoter:    fakePromoter{pull: &models.PullRequest{URL: "https://github.com/owner/repo/pull/2"}},
+			expOut:      "apply\n\nPromoted changes to project \"prod\" in https://github.com/owner/repo/pull/2",
+		},
+		{
+			description: "nothing to promote",
+			promoter:    fakePromoter{},
+			expOut:      "apply",
+		},
+		{
+			description: "promotion failed",
+			promoter:    fakePromoter{err: errors.New("patch does not apply")},
+			expOut:      "apply\n\nWarning: unable to promote changes to project \"prod\": patch does not apply",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockApply := mocks.NewMockStepRunner()
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			runner := events.DefaultProjectCommandRunner{
+				Locker:           mocks.NewMockProjectLocker(),
+				LockURLGenerator: mockURLGenerator{},
+				ApplyStepRunner:  mockApply,
+				WorkingDir:       mockWorkingDir,
+				WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+				AggregateApplyRequirements: &events.AggregateApplyRequirements{
+					WorkingDir: mockWorkingDir,
+				},
+				Webhooks: mocks.NewMockWebhooksSender(),
+				Promoter: c.promoter,
+			}
+			repoDir, cleanup := TempDir(t)
+			defer cleanup()
+			When(mockWorkingDir.GetWorkingDir(
+				matchers.AnyModelsRepo(),
+				matchers.AnyModelsPullRequest(),
+				AnyString(),
+			)).ThenReturn(repoDir, nil)
+
+			ctx := command.ProjectContext{
+				Log: logging.NewNoopLogger(t),
+				Steps: []valid.Step{
+					{
+						StepName: "apply",
+					},
+				},
+				Workspace:    "default",
+				RepoRelDir:   ".",
+				PromoteTo:    "prod",
+				PromoteToDir: "prod",
+			}
+			When(mockApply.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("apply", nil)
+
+			res := runner.Apply(ctx)
+			Equals(t, "", res.Failure)
+			Ok(t, res.Error)
+			Equals(t, c.expOut, res.ApplySuccess)
+		})
+	}
+}
+
+type fakeReverter struct {
+	pull *models.PullRequest
+	err  error
+}
+
+func (f fakeReverter) Revert(ctx command.ProjectContext) (*models.PullRequest, error) {
+	return f.pull, f.err
+}
+
+// Test that a failed verify step fails the apply, only warns, or opens a pull
+// request that reverts the changes, depending on its on_failure.
+func TestDefaultProjectCommandRunner_ApplyVerify(t *testing.T) {
+	cases := []struct {
+		description string
+		onFailure   string
+		reverter    fakeReverter
+		verifyErr   error
+		expOut      string
+		expErr      string
+	}{
+		{
+			description: "verified",
+			onFailure:   valid.OnFailureRevert,
+			expOut:      "apply\nhealthy",
+		},
+		{
+			description: "fail",
+			onFailure:   valid.OnFailureFail,
+			verifyErr:   errors.New("exit status 1"),
+			expErr:      "verification failed: exit status 1\napply",
+		},
+		{
+			description: "warn",
+			onFailure:   valid.OnFailureWarn,
+			verifyErr:   errors.New("exit status 1"),
+			expOut:      "apply\nWarning: verification failed: exit status 1",
+		},
+		{
+			description: "revert",
+			onFailure:   valid.OnFailureRevert,
+			reverter:    fakeReverter{pull: &models.PullRequest{URL: "https://github.com/owner/repo/pull/2"}},
+			verifyErr:   errors.New("exit status 1"),
+			expErr:      "verification failed: exit status 1\nOpened https://github.com/owner/repo/pull/2 to revert the changes\napply",
+		},
+		{
+			description: "revert failed",
+			onFailure:   valid.OnFailureRevert,
+			reverter:    fakeReverter{err: errors.New("patch does not apply")},
+			verifyErr:   errors.New("exit status 1"),
+			expErr:      "verification failed: exit status 1\nWarning: unable to open a pull request that reverts the changes: patch does not apply\napply",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockApply := mocks.NewMockStepRunner()
+			mockRun := mocks.NewMockCustomStepRunner()
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			runner := events.DefaultProjectCommandRunner{
+				Locker:           mocks.NewMockProjectLocker(),
+				LockURLGenerator: mockURLGenerator{},
+				ApplyStepRunner:  mockApply,
+				RunStepRunner:    mockRun,
+				WorkingDir:       mockWorkingDir,
+				WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+				AggregateApplyRequirements: &events.AggregateApplyRequirements{
+					WorkingDir: mockWorkingDir,
+				},
+				Webhooks: mocks.NewMockWebhooksSender(),
+				Reverter: c.reverter,
+			}
+			repoDir, cleanup := TempDir(t)
+			defer cleanup()
+			When(mockWorkingDir.GetWorkingDir(
+				matchers.AnyModelsRepo(),
+				matchers.AnyModelsPullRequest(),
+				AnyString(),
+			)).ThenReturn(repoDir, nil)
+
+			ctx := command.ProjectContext{
+				Log:         logging.NewNoopLogger(t),
+				CommandName: command.Apply,
+				Steps: []valid.Step{
+					{StepName: "apply"},
+					{StepName: "verify", RunCommand: "./smoke-test.sh", OnFailure: c.onFailure},
+				},
+				Workspace:  "default",
+				RepoRelDir: ".",
+			}
+			When(mockApply.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("apply", nil)
+			When(mockRun.Run(ctx, "./smoke-test.sh", repoDir, map[string]string{}, true)).ThenReturn("healthy", c.verifyErr)
+
+			res := runner.Apply(ctx)
+			Equals(t, "", res.Failure)
+			if c.expErr != "" {
+				ErrEquals(t, c.expErr, res.Error)
+				return
+			}
+			Ok(t, res.Error)
+			Equals(t, c.expOut, res.ApplySuccess)
+		})
+	}
+}
+
+// Test run and env steps. We don't use mocks for this test since we're
 // not running any Terraform.
 func TestDefaultProjectCommandRunner_RunEnvSteps(t *testing.T) {
 	RegisterMockTestingT(t)
@@ -614,6 +1717,16 @@ func TestDefaultProjectCommandRunner_RunEnvSteps(t *testing.T) {
 				StepName:   "run",
 				RunCommand: "echo dynamic_var=$dynamic_var",
 			},
+			// Test capturing a run step's output
+			{
+				StepName:   "run",
+				RunCommand: "echo captured_value",
+				CaptureAs:  "captured_var",
+			},
+			{
+				StepName:   "run",
+				RunCommand: "echo captured_var=$captured_var",
+			},
 		},
 		Workspace:  "default",
 		RepoRelDir: ".",
@@ -621,11 +1734,247 @@ func TestDefaultProjectCommandRunner_RunEnvSteps(t *testing.T) {
 	res := runner.Plan(ctx)
 	Assert(t, res.PlanSuccess != nil, "exp plan success")
 	Equals(t, "https://lock-key", res.PlanSuccess.LockURL)
-	Equals(t, "var=\n\nvar=value\n\ndynamic_var=dynamic_value\n\ndynamic_var=overridden\n", res.PlanSuccess.TerraformOutput)
+	Equals(t, "var=\n\nvar=value\n\ndynamic_var=dynamic_value\n\ndynamic_var=overridden\n\ncaptured_var=captured_value\n", res.PlanSuccess.TerraformOutput)
+}
+
+func TestDefaultProjectCommandRunner_RunStepEnv(t *testing.T) {
+	RegisterMockTestingT(t)
+	tfClient := tmocks.NewMockClient()
+	tfVersion, err := version.NewVersion("0.12.0")
+	Ok(t, err)
+	run := runtime.RunStepRunner{
+		TerraformExecutor:       tfClient,
+		DefaultTFVersion:        tfVersion,
+		ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+	}
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+
+	secretsDir, cleanupSecrets := TempDir(t)
+	defer cleanupSecrets()
+	Ok(t, os.WriteFile(filepath.Join(secretsDir, "deploy-token"), []byte("s3cr3t\n"), 0600))
+
+	runner := events.DefaultProjectCommandRunner{
+		Locker:           mockLocker,
+		LockURLGenerator: mockURLGenerator{},
+		RunStepRunner:    &run,
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		Secrets:          &events.FileSecretsProvider{Dir: secretsDir},
+	}
+
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.Clone(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsPullRequest(),
+		AnyString(),
+	)).ThenReturn(repoDir, false, nil)
+	When(mockLocker.TryLock(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsPullRequest(),
+		matchers.AnyModelsUser(),
+		AnyString(),
+		matchers.AnyModelsProject(),
+	)).ThenReturn(&events.TryLockResponse{
+		LockAcquired: true,
+		LockKey:      "lock-key",
+		UnlockFn:     func() error { return nil },
+	}, nil)
+
+	ctx := command.ProjectContext{
+		Log: logging.NewNoopLogger(t),
+		Steps: []valid.Step{
+			{
+				StepName:   "run",
+				RunCommand: "echo foo=$FOO token=$TOKEN",
+				Env: map[string]string{
+					"FOO":   "bar",
+					"TOKEN": "secret://deploy-token",
+				},
+			},
+			// The env vars are only set for their step.
+			{
+				StepName:   "run",
+				RunCommand: "echo foo=$FOO",
+			},
+		},
+		Workspace:  "default",
+		RepoRelDir: ".",
+	}
+	res := runner.Plan(ctx)
+	Assert(t, res.PlanSuccess != nil, "exp plan success")
+	Equals(t, "foo=bar token=<redacted>\n\nfoo=\n", res.PlanSuccess.TerraformOutput)
+
+	ctx.Steps[0].Env["TOKEN"] = "secret://missing"
+	res = runner.Plan(ctx)
+	ErrContains(t, `resolving env var TOKEN of run step: secret "missing" does not exist`, res.Error)
+}
+
+// Test that the server config's Terraform defaults are passed to steps
+// before the steps' own extra args and env vars.
+func TestDefaultProjectCommandRunner_TerraformDefaults(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockInit := mocks.NewMockStepRunner()
+	mockPlan := mocks.NewMockStepRunner()
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+
+	runner := events.DefaultProjectCommandRunner{
+		Locker:           mockLocker,
+		LockURLGenerator: mockURLGenerator{},
+		InitStepRunner:   mockInit,
+		PlanStepRunner:   mockPlan,
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+	}
+
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.Clone(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsPullRequest(),
+		AnyString(),
+	)).ThenReturn(repoDir, false, nil)
+	When(mockLocker.TryLock(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsPullRequest(),
+		matchers.AnyModelsUser(),
+		AnyString(),
+		matchers.AnyModelsProject(),
+	)).ThenReturn(&events.TryLockResponse{
+		LockAcquired: true,
+		LockKey:      "lock-key",
+	}, nil)
+
+	ctx := command.ProjectContext{
+		Log: logging.NewNoopLogger(t),
+		Steps: []valid.Step{
+			{
+				StepName: "init",
+			},
+			{
+				StepName:  "plan",
+				ExtraArgs: []string{"-lock-timeout=10m"},
+				Env:       map[string]string{"TF_CLI_ARGS_plan": "-parallelism=5"},
+			},
+		},
+		Workspace:  "default",
+		RepoRelDir: ".",
+		TerraformExtraArgs: map[string][]string{
+			"plan": {"-lock-timeout=5m", "-compact-warnings"},
+		},
+		TerraformEnv: map[string]string{
+			"TF_IN_AUTOMATION": "true",
+			"TF_CLI_ARGS_plan": "-parallelism=20",
+		},
+	}
+	initEnvs := map[string]string{
+		"TF_IN_AUTOMATION": "true",
+		"TF_CLI_ARGS_plan": "-parallelism=20",
+	}
+	planEnvs := map[string]string{
+		"TF_IN_AUTOMATION": "true",
+		"TF_CLI_ARGS_plan": "-parallelism=5",
+	}
+	planArgs := []string{"-lock-timeout=5m", "-compact-warnings", "-lock-timeout=10m"}
+	When(mockInit.Run(ctx, nil, repoDir, initEnvs)).ThenReturn("init", nil)
+	When(mockPlan.Run(ctx, planArgs, repoDir, planEnvs)).ThenReturn("plan", nil)
+
+	res := runner.Plan(ctx)
+	Assert(t, res.PlanSuccess != nil, "exp plan success")
+	mockInit.VerifyWasCalledOnce().Run(ctx, nil, repoDir, initEnvs)
+	mockPlan.VerifyWasCalledOnce().Run(ctx, planArgs, repoDir, planEnvs)
+	// The step's own args aren't changed.
+	Equals(t, []string{"-lock-timeout=10m"}, ctx.Steps[1].ExtraArgs)
+}
+
+func TestDefaultProjectCommandRunner_ConditionalSteps(t *testing.T) {
+	RegisterMockTestingT(t)
+	tfClient := tmocks.NewMockClient()
+	tfVersion, err := version.NewVersion("0.12.0")
+	Ok(t, err)
+	run := runtime.RunStepRunner{
+		TerraformExecutor:       tfClient,
+		DefaultTFVersion:        tfVersion,
+		ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+	}
+	mockPlan := mocks.NewMockStepRunner()
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+
+	runner := events.DefaultProjectCommandRunner{
+		Locker:           mockLocker,
+		LockURLGenerator: mockURLGenerator{},
+		PlanStepRunner:   mockPlan,
+		RunStepRunner:    &run,
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+	}
+
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	// The plan step is mocked so the plan file is created up front.
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "default.tfplan"), nil, 0600))
+	When(mockWorkingDir.Clone(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsPullRequest(),
+		AnyString(),
+	)).ThenReturn(repoDir, false, nil)
+	When(mockLocker.TryLock(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsPullRequest(),
+		matchers.AnyModelsUser(),
+		AnyString(),
+		matchers.AnyModelsProject(),
+	)).ThenReturn(&events.TryLockResponse{
+		LockAcquired: true,
+		LockKey:      "lock-key",
+		UnlockFn:     func() error { return nil },
+	}, nil)
+
+	ctx := command.ProjectContext{
+		Log:         logging.NewNoopLogger(t),
+		CommandName: command.Plan,
+		Steps: []valid.Step{
+			{StepName: "plan"},
+			{StepName: "run", RunCommand: "echo has_changes", When: valid.WhenHasChanges},
+			{StepName: "run", RunCommand: "echo no_changes", When: valid.WhenNoChanges},
+			{StepName: "run", RunCommand: "echo destroy_detected", When: valid.WhenDestroyDetected},
+		},
+		Workspace:  "default",
+		RepoRelDir: ".",
+	}
+	When(mockPlan.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("Plan: 1 to add, 0 to change, 1 to destroy.", nil)
+	res := runner.Plan(ctx)
+	Assert(t, res.PlanSuccess != nil, "exp plan success")
+	Equals(t, "Plan: 1 to add, 0 to change, 1 to destroy.\nhas_changes\n\ndestroy_detected\n", res.PlanSuccess.TerraformOutput)
+	summary, err := os.ReadFile(filepath.Join(repoDir, "default.tfplan.summary"))
+	Ok(t, err)
+	Equals(t, "Plan: 1 to add, 0 to change, 1 to destroy.", string(summary))
+
+	// During plan, conditional steps can't come before the plan step.
+	ctx.Steps = []valid.Step{
+		{StepName: "run", RunCommand: "echo has_changes", When: valid.WhenHasChanges},
+		{StepName: "plan"},
+	}
+	res = runner.Plan(ctx)
+	ErrContains(t, `checking "has_changes" condition of run step: the step must come after the plan step`, res.Error)
 }
 
 type mockURLGenerator struct{}
 
+type mockFreezeChecker struct {
+	freeze *models.Freeze
+}
+
+func (m mockFreezeChecker) CheckFreeze(repoFullName string, projectName string, repoRelDir string) (*models.Freeze, error) {
+	return m.freeze, nil
+}
+
 func (m mockURLGenerator) GenerateLockURL(lockID string) string {
 	return "https://" + lockID
 }
@@ -0,0 +1,103 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// DependencyProblem is why a project's dependency in another repo isn't
+// ready to build on.
+type DependencyProblem struct {
+	Dependency valid.ProjectDependency
+	Reason     string
+}
+
+func (d DependencyProblem) String() string {
+	return fmt.Sprintf("project %q in %s (workspace %s) %s", d.Dependency.Project, d.Dependency.Repo, d.Dependency.Workspace, d.Reason)
+}
+
+// CheckProjectDependencies returns the problems with deps given the last
+// plans and applies of every project in runs. A dependency has a problem if
+// it hasn't been applied, its last apply failed, or it has been planned with
+// changes or drift since its last apply.
+func CheckProjectDependencies(deps []valid.ProjectDependency, runs []models.ProjectRun) []DependencyProblem {
+	if len(deps) == 0 {
+		return nil
+	}
+	statuses := models.NewProjectRunStatuses(runs)
+	var problems []DependencyProblem
+	for _, dep := range deps {
+		if reason := dependencyProblem(dep, statuses); reason != "" {
+			problems = append(problems, DependencyProblem{Dependency: dep, Reason: reason})
+		}
+	}
+	return problems
+}
+
+func dependencyProblem(dep valid.ProjectDependency, statuses []models.ProjectRunStatus) string {
+	var status *models.ProjectRunStatus
+	for i := range statuses {
+		if statuses[i].Matches(dep.Repo, dep.Project, dep.Workspace) {
+			status = &statuses[i]
+			break
+		}
+	}
+	return projectStatusProblem(status)
+}
+
+// projectStatusProblem returns why the project with status isn't ready to
+// build on, or "" if it is. status is nil if the project hasn't run.
+func projectStatusProblem(status *models.ProjectRunStatus) string {
+	if status == nil || status.LastApply == nil {
+		return "hasn't been applied by Atlantis"
+	}
+	apply := status.LastApply
+	if apply.Outcome == models.FailedProjectRunOutcome {
+		return fmt.Sprintf("failed its last apply in #%d", apply.PullNum)
+	}
+	plan := status.LastPlan
+	if plan == nil || !plan.Time.After(apply.Time) || plan.Outcome != models.SuccessProjectRunOutcome {
+		return ""
+	}
+	if plan.Drifted {
+		return fmt.Sprintf("has drifted since its last apply, found by a plan in #%d", plan.PullNum)
+	}
+	if strings.HasPrefix(plan.Summary, "Plan:") {
+		return fmt.Sprintf("is out of date: #%d planned changes that haven't been applied", plan.PullNum)
+	}
+	return ""
+}
+
+// checkDependsOn checks the dependencies of the project described by ctx. It
+// returns a failure if a dependency that blocks applies has a problem and
+// block is true, and a warning listing the other problems. Neither is
+// returned if the projects' runs aren't recorded.
+func (p *DefaultProjectCommandRunner) checkDependsOn(ctx command.ProjectContext, block bool) (failure string, warning string, err error) {
+	if p.ProjectRuns == nil || len(ctx.DependsOn) == 0 {
+		return "", "", nil
+	}
+	runs, err := p.ProjectRuns.ListProjectRuns()
+	if err != nil {
+		return "", "", errors.Wrap(err, "checking project dependencies")
+	}
+	var blocking, warnings []string
+	for _, problem := range CheckProjectDependencies(ctx.DependsOn, runs) {
+		if block && problem.Dependency.Block {
+			blocking = append(blocking, problem.String())
+		} else {
+			warnings = append(warnings, problem.String())
+		}
+	}
+	if len(blocking) > 0 {
+		failure = fmt.Sprintf("This project can't be applied until its dependencies are applied successfully: %s.", strings.Join(blocking, "; "))
+	}
+	if len(warnings) > 0 {
+		warning = fmt.Sprintf("\nWarning: this project's dependencies may not be ready: %s.", strings.Join(warnings, "; "))
+	}
+	return failure, warning, nil
+}
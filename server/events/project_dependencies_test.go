@@ -0,0 +1,129 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCheckProjectDependencies(t *testing.T) {
+	dep := valid.ProjectDependency{Repo: "owner/network", Project: "vpc", Workspace: "default"}
+	applied := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	apply := func(outcome models.ProjectRunOutcome) models.ProjectRun {
+		return models.ProjectRun{RepoFullName: "owner/network", ProjectName: "vpc", Path: "vpc", Workspace: "default",
+			Command: "apply", Outcome: outcome, PullNum: 1, Time: applied}
+	}
+	plan := func(summary string, drifted bool, at time.Time) models.ProjectRun {
+		return models.ProjectRun{RepoFullName: "owner/network", ProjectName: "vpc", Path: "vpc", Workspace: "default",
+			Command: "plan", Outcome: models.SuccessProjectRunOutcome, PullNum: 2, Summary: summary, Drifted: drifted, Time: at}
+	}
+
+	cases := []struct {
+		description string
+		runs        []models.ProjectRun
+		expReason   string
+	}{
+		{
+			description: "never run",
+			expReason:   "hasn't been applied by Atlantis",
+		},
+		{
+			description: "only planned",
+			runs:        []models.ProjectRun{plan("Plan: 1 to add, 0 to change, 0 to destroy.", false, applied)},
+			expReason:   "hasn't been applied by Atlantis",
+		},
+		{
+			description: "applied",
+			runs:        []models.ProjectRun{apply(models.SuccessProjectRunOutcome)},
+		},
+		{
+			description: "apply failed",
+			runs:        []models.ProjectRun{apply(models.FailedProjectRunOutcome)},
+			expReason:   "failed its last apply in #1",
+		},
+		{
+			description: "planned changes before the apply",
+			runs: []models.ProjectRun{
+				apply(models.SuccessProjectRunOutcome),
+				plan("Plan: 1 to add, 0 to change, 0 to destroy.", false, applied.Add(-time.Hour)),
+			},
+		},
+		{
+			description: "planned without changes since the apply",
+			runs: []models.ProjectRun{
+				apply(models.SuccessProjectRunOutcome),
+				plan("No changes. Your infrastructure matches the configuration.", false, applied.Add(time.Hour)),
+			},
+		},
+		{
+			description: "planned changes since the apply",
+			runs: []models.ProjectRun{
+				apply(models.SuccessProjectRunOutcome),
+				plan("Plan: 1 to add, 0 to change, 0 to destroy.", false, applied.Add(time.Hour)),
+			},
+			expReason: "is out of date: #2 planned changes that haven't been applied",
+		},
+		{
+			description: "drifted since the apply",
+			runs: []models.ProjectRun{
+				apply(models.SuccessProjectRunOutcome),
+				plan("No changes. Your infrastructure matches the configuration.", true, applied.Add(time.Hour)),
+			},
+			expReason: "has drifted since its last apply, found by a plan in #2",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			problems := events.CheckProjectDependencies([]valid.ProjectDependency{dep}, c.runs)
+			if c.expReason == "" {
+				Equals(t, 0, len(problems))
+				return
+			}
+			Equals(t, []events.DependencyProblem{{Dependency: dep, Reason: c.expReason}}, problems)
+		})
+	}
+}
+
+// Test that dependencies with problems block applies if their enforcement is
+// block and are otherwise warned about.
+func TestDefaultProjectCommandRunner_ApplyDependsOn(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	runner := &events.DefaultProjectCommandRunner{
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		Webhooks:         mocks.NewMockWebhooksSender(),
+		AggregateApplyRequirements: &events.AggregateApplyRequirements{
+			WorkingDir: mockWorkingDir,
+		},
+		ProjectRuns: staticProjectRuns{
+			{RepoFullName: "owner/network", ProjectName: "vpc", Path: "vpc", Workspace: "default", Command: "apply", Outcome: models.FailedProjectRunOutcome, PullNum: 3},
+		},
+	}
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Workspace:  "default",
+		RepoRelDir: ".",
+		DependsOn:  []valid.ProjectDependency{{Repo: "owner/network", Project: "vpc", Workspace: "default", Block: true}},
+	}
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+
+	res := runner.Apply(ctx)
+	Equals(t, "This project can't be applied until its dependencies are applied successfully: project \"vpc\" in owner/network (workspace default) failed its last apply in #3.", res.Failure)
+
+	ctx.DependsOn[0].Block = false
+	res = runner.Apply(ctx)
+	Equals(t, "", res.Failure)
+	Ok(t, res.Error)
+	Equals(t, "\nWarning: this project's dependencies may not be ready: project \"vpc\" in owner/network (workspace default) failed its last apply in #3.", res.ApplySuccess)
+}
@@ -173,27 +173,7 @@ func (p *DefaultProjectFinder) DetermineProjectsViaConfig(log logging.SimpleLogg
 	var projects []valid.Project
 	for _, project := range config.Projects {
 		log.Debug("checking if project at dir %q workspace %q was modified", project.Dir, project.Workspace)
-		var whenModifiedRelToRepoRoot []string
-		for _, wm := range project.Autoplan.WhenModified {
-			wm = strings.TrimSpace(wm)
-			// An exclusion uses a '!' at the beginning. If it's there, we need
-			// to remove it, then add in the project path, then add it back.
-			exclusion := false
-			if wm != "" && wm[0] == '!' {
-				wm = wm[1:]
-				exclusion = true
-			}
-
-			// Prepend project dir to when modified patterns because the patterns
-			// are relative to the project dirs but our list of modified files is
-			// relative to the repo root.
-			wmRelPath := filepath.Join(project.Dir, wm)
-			if exclusion {
-				wmRelPath = "!" + wmRelPath
-			}
-			whenModifiedRelToRepoRoot = append(whenModifiedRelToRepoRoot, wmRelPath)
-		}
-		pm, err := fileutils.NewPatternMatcher(whenModifiedRelToRepoRoot)
+		pm, err := fileutils.NewPatternMatcher(whenModifiedRelToRepoRoot(project))
 		if err != nil {
 			return nil, errors.Wrapf(err, "matching modified files with patterns: %v", project.Autoplan.WhenModified)
 		}
@@ -232,6 +212,32 @@ func (p *DefaultProjectFinder) DetermineProjectsViaConfig(log logging.SimpleLogg
 	return projects, nil
 }
 
+// whenModifiedRelToRepoRoot returns the project's when_modified patterns
+// relative to the repo root instead of the project dir.
+func whenModifiedRelToRepoRoot(project valid.Project) []string {
+	var patterns []string
+	for _, wm := range project.Autoplan.WhenModified {
+		wm = strings.TrimSpace(wm)
+		// An exclusion uses a '!' at the beginning. If it's there, we need
+		// to remove it, then add in the project path, then add it back.
+		exclusion := false
+		if wm != "" && wm[0] == '!' {
+			wm = wm[1:]
+			exclusion = true
+		}
+
+		// Prepend project dir to when modified patterns because the patterns
+		// are relative to the project dirs but our list of modified files is
+		// relative to the repo root.
+		wmRelPath := filepath.Join(project.Dir, wm)
+		if exclusion {
+			wmRelPath = "!" + wmRelPath
+		}
+		patterns = append(patterns, wmRelPath)
+	}
+	return patterns
+}
+
 // filterToFileList filters out files not included in the file list
 func (p *DefaultProjectFinder) filterToFileList(log logging.SimpleLogging, files []string, fileList string) []string {
 	var filtered []string
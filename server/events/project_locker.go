@@ -14,11 +14,12 @@
 package events
 
 import (
-	"fmt"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/i18n"
 	"github.com/runatlantis/atlantis/server/logging"
 )
 
@@ -40,6 +41,16 @@ type ProjectLocker interface {
 type DefaultProjectLocker struct {
 	Locker    locking.Locker
 	VCSClient vcs.Client
+	// LockContention records the attempts to lock projects. If nil, they
+	// aren't recorded.
+	LockContention LockContentionStore
+	// PullStacks lets stacked pull requests plan the projects locked by the
+	// pull requests they're stacked on, since their plans include those
+	// changes. If nil, pull requests aren't stacked.
+	PullStacks *PullStacks
+	// Catalog translates the reason a lock wasn't acquired. If nil, it's in
+	// English.
+	Catalog *i18n.Catalog
 }
 
 // TryLockResponse is the result of trying to lock a project.
@@ -63,21 +74,35 @@ func (p *DefaultProjectLocker) TryLock(log logging.SimpleLogging, pull models.Pu
 	if err != nil {
 		return nil, err
 	}
+	if !lockAttempt.LockAcquired && lockAttempt.CurrLock.Pull.Num != pull.Num && p.PullStacks != nil {
+		stacked, err := p.PullStacks.IsAncestor(pull, lockAttempt.CurrLock.Pull.Num)
+		if err != nil {
+			return nil, err
+		}
+		if stacked {
+			log.Info("planning without lock %q since it's held by pull request %d, which this pull request is stacked on", lockAttempt.LockKey, lockAttempt.CurrLock.Pull.Num)
+			return &TryLockResponse{
+				LockAcquired: true,
+				// The lock belongs to the other pull request.
+				UnlockFn: func() error { return nil },
+				LockKey:  lockAttempt.LockKey,
+			}, nil
+		}
+	}
 	if !lockAttempt.LockAcquired && lockAttempt.CurrLock.Pull.Num != pull.Num {
+		p.recordAttempt(log, pull, workspace, project, lockAttempt.CurrLock.Pull.Num)
 		link, err := p.VCSClient.MarkdownPullLink(lockAttempt.CurrLock.Pull)
 		if err != nil {
 			return nil, err
 		}
-		failureMsg := fmt.Sprintf(
-			"This project is currently locked by an unapplied plan from pull %s. To continue, delete the lock from %s or apply that plan and merge the pull request.\n\nOnce the lock is released, comment `atlantis plan` here to re-plan.",
-			link,
-			link)
+		failureMsg := p.Catalog.T("lock.locked_by_pull", link, link)
 		return &TryLockResponse{
 			LockAcquired:      false,
 			LockFailureReason: failureMsg,
 		}, nil
 	}
 	log.Info("acquired lock with id %q", lockAttempt.LockKey)
+	p.recordAttempt(log, pull, workspace, project, 0)
 	return &TryLockResponse{
 		LockAcquired: true,
 		UnlockFn: func() error {
@@ -87,3 +112,22 @@ func (p *DefaultProjectLocker) TryLock(log logging.SimpleLogging, pull models.Pu
 		LockKey: lockAttempt.LockKey,
 	}, nil
 }
+
+// recordAttempt records pull's attempt to lock project's workspace, which was
+// blocked by blockingPullNum unless it's 0.
+func (p *DefaultProjectLocker) recordAttempt(log logging.SimpleLogging, pull models.PullRequest, workspace string, project models.Project, blockingPullNum int) {
+	if p.LockContention == nil {
+		return
+	}
+	err := p.LockContention.RecordLockAttempt(models.LockAttempt{
+		RepoFullName:    project.RepoFullName,
+		Path:            project.Path,
+		Workspace:       workspace,
+		PullNum:         pull.Num,
+		BlockingPullNum: blockingPullNum,
+		Time:            time.Now(),
+	})
+	if err != nil {
+		log.Warn("unable to record lock contention: %s", err)
+	}
+}
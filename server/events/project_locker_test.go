@@ -15,7 +15,9 @@ package events_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/petergtz/pegomock"
 	"github.com/runatlantis/atlantis/server/core/locking"
@@ -23,13 +25,14 @@ import (
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/i18n"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
 )
 
 func TestDefaultProjectLocker_TryLockWhenLocked(t *testing.T) {
 	var githubClient *vcs.GithubClient
-	mockClient := vcs.NewClientProxy(githubClient, nil, nil, nil, nil)
+	mockClient := vcs.NewClientProxy(githubClient, nil, nil, nil, nil, nil)
 	mockLocker := mocks.NewMockLocker()
 	locker := events.DefaultProjectLocker{
 		Locker:    mockLocker,
@@ -62,10 +65,42 @@ func TestDefaultProjectLocker_TryLockWhenLocked(t *testing.T) {
 	}, res)
 }
 
+func TestDefaultProjectLocker_TryLockWhenLockedLocale(t *testing.T) {
+	RegisterMockTestingT(t)
+	var githubClient *vcs.GithubClient
+	mockClient := vcs.NewClientProxy(githubClient, nil, nil, nil, nil, nil)
+	mockLocker := mocks.NewMockLocker()
+	catalog, err := i18n.NewCatalog("de")
+	Ok(t, err)
+	locker := events.DefaultProjectLocker{
+		Locker:    mockLocker,
+		VCSClient: mockClient,
+		Catalog:   catalog,
+	}
+
+	lockingPull := models.PullRequest{
+		Num: 2,
+	}
+	When(mockLocker.TryLock(models.Project{}, "default", models.PullRequest{}, models.User{})).ThenReturn(
+		locking.TryLockResponse{
+			LockAcquired: false,
+			CurrLock: models.ProjectLock{
+				Pull: lockingPull,
+			},
+		},
+		nil,
+	)
+	res, err := locker.TryLock(logging.NewNoopLogger(t), models.PullRequest{}, models.User{}, "default", models.Project{})
+	Ok(t, err)
+	link, _ := mockClient.MarkdownPullLink(lockingPull)
+	Equals(t, catalog.T("lock.locked_by_pull", link, link), res.LockFailureReason)
+	Assert(t, strings.HasPrefix(res.LockFailureReason, "Dieses Projekt ist derzeit"), "expected German lock failure, got %q", res.LockFailureReason)
+}
+
 func TestDefaultProjectLocker_TryLockWhenLockedSamePull(t *testing.T) {
 	RegisterMockTestingT(t)
 	var githubClient *vcs.GithubClient
-	mockClient := vcs.NewClientProxy(githubClient, nil, nil, nil, nil)
+	mockClient := vcs.NewClientProxy(githubClient, nil, nil, nil, nil, nil)
 	mockLocker := mocks.NewMockLocker()
 	locker := events.DefaultProjectLocker{
 		Locker:    mockLocker,
@@ -104,7 +139,7 @@ func TestDefaultProjectLocker_TryLockWhenLockedSamePull(t *testing.T) {
 func TestDefaultProjectLocker_TryLockUnlocked(t *testing.T) {
 	RegisterMockTestingT(t)
 	var githubClient *vcs.GithubClient
-	mockClient := vcs.NewClientProxy(githubClient, nil, nil, nil, nil)
+	mockClient := vcs.NewClientProxy(githubClient, nil, nil, nil, nil, nil)
 	mockLocker := mocks.NewMockLocker()
 	locker := events.DefaultProjectLocker{
 		Locker:    mockLocker,
@@ -139,3 +174,55 @@ func TestDefaultProjectLocker_TryLockUnlocked(t *testing.T) {
 	Ok(t, err)
 	mockLocker.VerifyWasCalledOnce().Unlock(lockKey)
 }
+
+// recordingLockContention is a LockContentionStore that records the attempts.
+type recordingLockContention struct {
+	attempts []models.LockAttempt
+}
+
+func (r *recordingLockContention) RecordLockAttempt(attempt models.LockAttempt) error {
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+func (r *recordingLockContention) ListLockContention() ([]models.LockContention, error) {
+	return nil, nil
+}
+
+func TestDefaultProjectLocker_RecordsLockContention(t *testing.T) {
+	RegisterMockTestingT(t)
+	var githubClient *vcs.GithubClient
+	mockClient := vcs.NewClientProxy(githubClient, nil, nil, nil, nil, nil)
+	mockLocker := mocks.NewMockLocker()
+	contention := &recordingLockContention{}
+	locker := events.DefaultProjectLocker{
+		Locker:         mockLocker,
+		VCSClient:      mockClient,
+		LockContention: contention,
+	}
+	project := models.Project{RepoFullName: "owner/repo", Path: "staging"}
+	pull := models.PullRequest{Num: 2}
+	user := models.User{}
+
+	When(mockLocker.TryLock(project, "default", pull, user)).ThenReturn(
+		locking.TryLockResponse{CurrLock: models.ProjectLock{Pull: models.PullRequest{Num: 1}}},
+		nil,
+	)
+	_, err := locker.TryLock(logging.NewNoopLogger(t), pull, user, "default", project)
+	Ok(t, err)
+	When(mockLocker.TryLock(project, "default", pull, user)).ThenReturn(
+		locking.TryLockResponse{LockAcquired: true, CurrLock: models.ProjectLock{Pull: pull}},
+		nil,
+	)
+	_, err = locker.TryLock(logging.NewNoopLogger(t), pull, user, "default", project)
+	Ok(t, err)
+
+	Equals(t, 2, len(contention.attempts))
+	Equals(t, models.LockAttempt{RepoFullName: "owner/repo", Path: "staging", Workspace: "default", PullNum: 2, BlockingPullNum: 1}, withoutTime(contention.attempts[0]))
+	Equals(t, models.LockAttempt{RepoFullName: "owner/repo", Path: "staging", Workspace: "default", PullNum: 2}, withoutTime(contention.attempts[1]))
+}
+
+func withoutTime(attempt models.LockAttempt) models.LockAttempt {
+	attempt.Time = time.Time{}
+	return attempt
+}
@@ -0,0 +1,166 @@
+package events
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/moby/moby/pkg/fileutils"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// ProjectResolution explains which projects a pull request's modified files
+// resolve to. Unlike DefaultProjectFinder, it doesn't check that project
+// directories exist since the repo isn't cloned.
+type ProjectResolution struct {
+	// RepoCfg is true if the projects were resolved with the repo's
+	// atlantis.yaml file. If false, they were detected from the modified
+	// files.
+	RepoCfg bool
+	// Projects are the projects in the repo's atlantis.yaml file or, if it
+	// doesn't have one, the projects that were detected.
+	Projects []ResolvedProject
+	// UnmatchedFiles are the modified files that didn't match any project.
+	UnmatchedFiles []FileResolution
+}
+
+// ResolvedProject explains why a project was or wasn't matched.
+type ResolvedProject struct {
+	ProjectName string
+	Dir         string
+	Workspace   string
+	// Matched is true if the project would be planned.
+	Matched bool
+	// AutoplanEnabled is false if the project is only planned by commenting.
+	AutoplanEnabled bool
+	// WhenModified are the project's when_modified patterns relative to the
+	// repo root.
+	WhenModified []string
+	// Files are the modified files that the project's patterns matched or
+	// excluded.
+	Files []FileResolution
+}
+
+// FileResolution explains why a modified file did or didn't match.
+type FileResolution struct {
+	File    string
+	Matched bool
+	// Rule is the when_modified pattern, or the rule used to detect projects
+	// without an atlantis.yaml file, that decided whether File matched.
+	Rule string
+}
+
+// ResolveProjects explains which projects modifiedFiles resolve to. If
+// repoCfg is nil, projects are detected from the modified files like when a
+// repo doesn't have an atlantis.yaml file.
+func ResolveProjects(modifiedFiles []string, repoCfg *valid.RepoCfg, autoplanFileList string) (ProjectResolution, error) {
+	if repoCfg == nil {
+		return detectProjects(modifiedFiles, autoplanFileList), nil
+	}
+
+	resolution := ProjectResolution{RepoCfg: true}
+	matchedFiles := make(map[string]bool)
+	for _, project := range repoCfg.Projects {
+		resolved := ResolvedProject{
+			ProjectName:     project.GetName(),
+			Dir:             project.Dir,
+			Workspace:       project.Workspace,
+			AutoplanEnabled: project.Autoplan.Enabled,
+			WhenModified:    whenModifiedRelToRepoRoot(project),
+		}
+		for _, file := range modifiedFiles {
+			fileResolution, err := matchWhenModified(file, resolved.WhenModified)
+			if err != nil {
+				return ProjectResolution{}, errors.Wrapf(err, "matching modified files with patterns: %v", project.Autoplan.WhenModified)
+			}
+			if fileResolution.Rule == "" {
+				continue
+			}
+			if fileResolution.Matched {
+				resolved.Matched = true
+				matchedFiles[file] = true
+			}
+			resolved.Files = append(resolved.Files, fileResolution)
+		}
+		resolution.Projects = append(resolution.Projects, resolved)
+	}
+	for _, file := range modifiedFiles {
+		if !matchedFiles[file] {
+			resolution.UnmatchedFiles = append(resolution.UnmatchedFiles, FileResolution{File: file})
+		}
+	}
+	return resolution, nil
+}
+
+// matchWhenModified returns whether file matches patterns and the pattern
+// that decided it. Like fileutils.PatternMatcher, the last pattern that
+// matches file decides. If no pattern matches, the rule is empty.
+func matchWhenModified(file string, patterns []string) (FileResolution, error) {
+	resolution := FileResolution{File: file}
+	for _, pattern := range patterns {
+		exclusion := strings.HasPrefix(pattern, "!")
+		pm, err := fileutils.NewPatternMatcher([]string{strings.TrimPrefix(pattern, "!")})
+		if err != nil {
+			return FileResolution{}, err
+		}
+		match, err := pm.Matches(file)
+		if err != nil {
+			return FileResolution{}, err
+		}
+		if match {
+			resolution.Matched = !exclusion
+			resolution.Rule = pattern
+		}
+	}
+	return resolution, nil
+}
+
+// detectProjects explains which projects DefaultProjectFinder.DetermineProjects
+// detects from modifiedFiles.
+func detectProjects(modifiedFiles []string, autoplanFileList string) ProjectResolution {
+	finder := &DefaultProjectFinder{}
+	patterns, _ := fileutils.NewPatternMatcher(strings.Split(autoplanFileList, ","))
+
+	var resolution ProjectResolution
+	projectIdx := make(map[string]int)
+	for _, file := range modifiedFiles {
+		if finder.shouldIgnore(file) {
+			resolution.UnmatchedFiles = append(resolution.UnmatchedFiles, FileResolution{File: file, Rule: "Terraform state and tflint files are ignored"})
+			continue
+		}
+		if match, err := patterns.Matches(file); err != nil || !match {
+			resolution.UnmatchedFiles = append(resolution.UnmatchedFiles, FileResolution{File: file, Rule: fmt.Sprintf("doesn't match autoplan file list %q", autoplanFileList)})
+			continue
+		}
+
+		dir, rule := detectProjectDir(file)
+		i, ok := projectIdx[dir]
+		if !ok {
+			i = len(resolution.Projects)
+			projectIdx[dir] = i
+			resolution.Projects = append(resolution.Projects, ResolvedProject{
+				Dir:             dir,
+				Workspace:       DefaultWorkspace,
+				Matched:         true,
+				AutoplanEnabled: true,
+			})
+		}
+		resolution.Projects[i].Files = append(resolution.Projects[i].Files, FileResolution{File: file, Matched: true, Rule: rule})
+	}
+	return resolution
+}
+
+// detectProjectDir returns the project dir that DefaultProjectFinder detects
+// for file and the rule it used.
+func detectProjectDir(file string) (string, string) {
+	dir := path.Dir(file)
+	if path.Base(dir) == "env" {
+		return path.Dir(dir), "files in env/ directories plan the directory above"
+	}
+	if strings.Contains("/"+dir+"/", "/modules/") {
+		modulesParent := strings.SplitN(dir+"/", "modules/", 2)[0]
+		return path.Clean(modulesParent), "files in modules/ directories plan the directory above if it has a main.tf file"
+	}
+	return dir, "files plan the directory they're in"
+}
@@ -0,0 +1,88 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestResolveProjects_RepoCfg(t *testing.T) {
+	name := "staging"
+	repoCfg := &valid.RepoCfg{
+		Projects: []valid.Project{
+			{
+				Dir:       "staging",
+				Workspace: "default",
+				Name:      &name,
+				Autoplan: valid.Autoplan{
+					Enabled:      true,
+					WhenModified: []string{"**/*.tf", "!tests/*", "../modules/**/*.tf"},
+				},
+			},
+			{
+				Dir:       "production",
+				Workspace: "default",
+				Autoplan: valid.Autoplan{
+					Enabled:      false,
+					WhenModified: []string{"**/*.tf"},
+				},
+			},
+		},
+	}
+
+	resolution, err := events.ResolveProjects([]string{"staging/main.tf", "staging/tests/main.tf", "modules/vpc/main.tf", "README.md"}, repoCfg, "**/*.tf")
+	Ok(t, err)
+	Equals(t, events.ProjectResolution{
+		RepoCfg: true,
+		Projects: []events.ResolvedProject{
+			{
+				ProjectName:     "staging",
+				Dir:             "staging",
+				Workspace:       "default",
+				Matched:         true,
+				AutoplanEnabled: true,
+				WhenModified:    []string{"staging/**/*.tf", "!staging/tests/*", "modules/**/*.tf"},
+				Files: []events.FileResolution{
+					{File: "staging/main.tf", Matched: true, Rule: "staging/**/*.tf"},
+					{File: "staging/tests/main.tf", Matched: false, Rule: "!staging/tests/*"},
+					{File: "modules/vpc/main.tf", Matched: true, Rule: "modules/**/*.tf"},
+				},
+			},
+			{
+				Dir:          "production",
+				Workspace:    "default",
+				WhenModified: []string{"production/**/*.tf"},
+			},
+		},
+		UnmatchedFiles: []events.FileResolution{
+			{File: "staging/tests/main.tf"},
+			{File: "README.md"},
+		},
+	}, resolution)
+}
+
+func TestResolveProjects_NoRepoCfg(t *testing.T) {
+	resolution, err := events.ResolveProjects([]string{"staging/main.tf", "staging/env/dev.tfvars", "staging/modules/vpc/main.tf", "staging/terraform.tfstate", "README.md"}, nil, "**/*.tf,**/*.tfvars")
+	Ok(t, err)
+	Equals(t, events.ProjectResolution{
+		Projects: []events.ResolvedProject{
+			{
+				Dir:             "staging",
+				Workspace:       "default",
+				Matched:         true,
+				AutoplanEnabled: true,
+				Files: []events.FileResolution{
+					{File: "staging/main.tf", Matched: true, Rule: "files plan the directory they're in"},
+					{File: "staging/env/dev.tfvars", Matched: true, Rule: "files in env/ directories plan the directory above"},
+					{File: "staging/modules/vpc/main.tf", Matched: true, Rule: "files in modules/ directories plan the directory above if it has a main.tf file"},
+				},
+			},
+		},
+		UnmatchedFiles: []events.FileResolution{
+			{File: "staging/terraform.tfstate", Rule: "Terraform state and tflint files are ignored"},
+			{File: "README.md", Rule: `doesn't match autoplan file list "**/*.tf,**/*.tfvars"`},
+		},
+	}, resolution)
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"strings"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// ProjectRunStore stores the last plan and apply of each project so that
+// their status can be reported outside of pull requests.
+type ProjectRunStore interface {
+	// UpdateProjectRun creates or replaces the last run of run's command for
+	// run's project.
+	UpdateProjectRun(run models.ProjectRun) error
+	// ListProjectRuns returns the last plan and apply of every project.
+	ListProjectRuns() ([]models.ProjectRun, error)
+}
+
+// NewProjectRun returns the run of the project described by ctx that had
+// result. It returns false if Terraform didn't run, ex. because the project
+// was locked or its apply requirements weren't met, since the result says
+// nothing about the project's infrastructure.
+func NewProjectRun(ctx command.ProjectContext, result command.ProjectResult, now time.Time) (models.ProjectRun, bool) {
+	if result.Failure != "" {
+		return models.ProjectRun{}, false
+	}
+	run := models.ProjectRun{
+		RepoFullName: ctx.BaseRepo.FullName,
+		ProjectName:  ctx.ProjectName,
+		Path:         ctx.RepoRelDir,
+		Workspace:    ctx.Workspace,
+		Command:      result.Command.String(),
+		Outcome:      models.SuccessProjectRunOutcome,
+		PullNum:      ctx.Pull.Num,
+		HeadCommit:   ctx.Pull.HeadCommit,
+		Time:         now,
+		JobID:        ctx.JobID,
+		Outputs:      result.Outputs,
+	}
+	if result.Error != nil {
+		run.Outcome = models.FailedProjectRunOutcome
+	}
+	if result.PlanSuccess != nil {
+		// Summary prefixes the plan's summary line with a note if the
+		// project drifted, which Drifted records instead.
+		summary := strings.Split(strings.TrimSpace(result.PlanSuccess.Summary()), "\n")
+		run.Summary = summary[len(summary)-1]
+		run.Drifted = result.PlanSuccess.Drifted()
+	}
+	return run, true
+}
@@ -0,0 +1,52 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestNewProjectRun(t *testing.T) {
+	now := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	ctx := command.ProjectContext{
+		BaseRepo:    models.Repo{FullName: "owner/repo"},
+		Pull:        models.PullRequest{Num: 2, HeadCommit: "abc1234"},
+		ProjectName: "staging",
+		RepoRelDir:  "envs/staging",
+		Workspace:   "default",
+	}
+
+	run, ok := events.NewProjectRun(ctx, command.ProjectResult{
+		Command: command.Plan,
+		PlanSuccess: &models.PlanSuccess{
+			TerraformOutput: "Note: Objects have changed outside of Terraform\n\nPlan: 1 to add, 0 to change, 0 to destroy.",
+		},
+	}, now)
+	Assert(t, ok, "exp plan to be recorded")
+	Equals(t, models.ProjectRun{
+		RepoFullName: "owner/repo",
+		ProjectName:  "staging",
+		Path:         "envs/staging",
+		Workspace:    "default",
+		Command:      "plan",
+		Outcome:      models.SuccessProjectRunOutcome,
+		PullNum:      2,
+		HeadCommit:   "abc1234",
+		Summary:      "Plan: 1 to add, 0 to change, 0 to destroy.",
+		Drifted:      true,
+		Time:         now,
+	}, run)
+
+	run, ok = events.NewProjectRun(ctx, command.ProjectResult{Command: command.Apply, Error: errors.New("apply failed")}, now)
+	Assert(t, ok, "exp errored apply to be recorded")
+	Equals(t, "apply", run.Command)
+	Equals(t, models.FailedProjectRunOutcome, run.Outcome)
+
+	_, ok = events.NewProjectRun(ctx, command.ProjectResult{Command: command.Apply, Failure: "Pull request must be approved"}, now)
+	Assert(t, !ok, "exp apply that didn't run not to be recorded")
+}
@@ -0,0 +1,149 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+// promoteBranchPrefix is the prefix of the branches that promoted changes are
+// pushed to.
+const promoteBranchPrefix = "atlantis/promote/"
+
+// Promoter promotes changes that were applied in one project to another
+// project, ex. from staging to production.
+type Promoter interface {
+	// Promote opens a pull request that makes the changes ctx.Pull made to
+	// ctx.RepoRelDir in ctx.PromoteToDir instead, or updates the pull request
+	// if it's already open. It returns nil if ctx.Pull didn't change
+	// ctx.RepoRelDir.
+	Promote(ctx command.ProjectContext) (*models.PullRequest, error)
+}
+
+// GitPromoter implements Promoter by cloning the base repo, copying the diff
+// of the pull request into the promoted project's dir and pushing it to a
+// branch.
+type GitPromoter struct {
+	GitCloner
+	VCSClient vcs.Client
+	// GithubAppEnabled is true if the pull request's head should be fetched
+	// from the "pull/PR_NUMBER/head" ref of the base repo.
+	GithubAppEnabled bool
+}
+
+func (g *GitPromoter) Promote(ctx command.ProjectContext) (*models.PullRequest, error) {
+	pull := ctx.Pull
+	cloneDir, ctx, diff, cleanup, err := g.clonePullDiff(ctx)
+	defer cleanup()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(diff) == "" {
+		ctx.Log.Info("pull request didn't change %q, nothing to promote", ctx.RepoRelDir)
+		return nil, nil
+	}
+
+	// The branch is recreated from the base branch every time so that it
+	// always has the latest applied changes.
+	branch := g.branch(ctx)
+	msg := fmt.Sprintf("Promote %s to %s\n\nPromotes the changes applied in %s.", g.sourceName(ctx), ctx.PromoteTo, pull.URL)
+	for _, c := range []struct {
+		stdin string
+		args  []string
+	}{
+		{args: []string{"checkout", "-B", branch}},
+		{stdin: diff, args: []string{"apply", "--index", "--directory=" + ctx.PromoteToDir, "-"}},
+		{args: []string{"commit", "-m", msg}},
+		{args: []string{"push", "--force", "origin", branch}},
+	} {
+		if _, err := g.git(ctx, cloneDir, c.stdin, c.args...); err != nil {
+			return nil, err
+		}
+	}
+
+	link, err := g.VCSClient.MarkdownPullLink(pull)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting link to pull request")
+	}
+	title := fmt.Sprintf("Promote %s to %s", g.sourceName(ctx), ctx.PromoteTo)
+	body := fmt.Sprintf("Promotes the changes to `%s` that @%s applied in %s to `%s`.\n\n"+
+		"This pull request was opened by Atlantis and is updated every time %s is applied.",
+		ctx.RepoRelDir, ctx.User.Username, link, ctx.PromoteToDir, link)
+	promotion, err := g.VCSClient.CreateOrUpdatePull(pull.BaseRepo, branch, pull.BaseBranch, title, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening pull request")
+	}
+	return &promotion, nil
+}
+
+// clonePullDiff clones the base branch of ctx.Pull into a new dir, fetches
+// the pull request's head and returns the dir, ctx with its repos set up to
+// push to, and the diff the pull request made to ctx.RepoRelDir, relative to
+// it. cleanup removes the dir and must be called even if there's an error.
+func (g *GitPromoter) clonePullDiff(ctx command.ProjectContext) (cloneDir string, _ command.ProjectContext, diff string, cleanup func(), err error) {
+	cloneDir, ctx, cleanup, err = g.clonePull(ctx)
+	if err != nil {
+		return cloneDir, ctx, "", cleanup, err
+	}
+	mergeBase, err := g.git(ctx, cloneDir, "", "merge-base", "HEAD", "FETCH_HEAD")
+	if err != nil {
+		return cloneDir, ctx, "", cleanup, err
+	}
+	diff, err = g.git(ctx, cloneDir, "", "diff", "--binary", "--relative="+ctx.RepoRelDir+"/", strings.TrimSpace(mergeBase), "FETCH_HEAD", "--", ctx.RepoRelDir)
+	return cloneDir, ctx, diff, cleanup, err
+}
+
+// clonePull clones the base branch of ctx.Pull into a new dir with its full
+// history, fetches the pull request's head into FETCH_HEAD and returns the
+// dir and ctx with its repos set up to push to. cleanup removes the dir and
+// must be called even if there's an error.
+func (g *GitPromoter) clonePull(ctx command.ProjectContext) (cloneDir string, _ command.ProjectContext, cleanup func(), err error) {
+	pull := ctx.Pull
+	cloneDir, cleanup, err = g.mkdir("promote")
+	if err != nil {
+		return cloneDir, ctx, cleanup, err
+	}
+	if ctx.Pull.BaseRepo, err = g.repo(ctx.Pull.BaseRepo); err != nil {
+		return cloneDir, ctx, cleanup, err
+	}
+	if ctx.HeadRepo, err = g.repo(ctx.HeadRepo); err != nil {
+		return cloneDir, ctx, cleanup, err
+	}
+	fetchRemote, fetchRef := "head", fmt.Sprintf("+refs/heads/%s", pull.HeadBranch)
+	if g.GithubAppEnabled {
+		fetchRemote, fetchRef = "origin", fmt.Sprintf("pull/%d/head", pull.Num)
+	}
+	cmds := [][]string{
+		{"clone", "--branch", pull.BaseBranch, "--single-branch", ctx.Pull.BaseRepo.CloneURL, cloneDir},
+		{"remote", "add", "head", ctx.HeadRepo.CloneURL},
+		{"fetch", fetchRemote, fetchRef},
+	}
+	for _, args := range cmds {
+		if _, err := g.git(ctx, cloneDir, "", args...); err != nil {
+			return cloneDir, ctx, cleanup, err
+		}
+	}
+	return cloneDir, ctx, cleanup, nil
+}
+
+// branch returns the name of the branch that changes to ctx are promoted on.
+// Each pull request and project gets its own branch.
+func (g *GitPromoter) branch(ctx command.ProjectContext) string {
+	return fmt.Sprintf("%s%d-%s", promoteBranchPrefix, ctx.Pull.Num, ctx.PromoteTo)
+}
+
+func (g *GitPromoter) sourceName(ctx command.ProjectContext) string {
+	if ctx.ProjectName != "" {
+		return ctx.ProjectName
+	}
+	return ctx.RepoRelDir
+}
+
+// git runs git with args in dir and returns its stdout.
+func (g *GitPromoter) git(ctx command.ProjectContext, dir string, stdin string, args ...string) (string, error) {
+	return g.GitCloner.git(ctx.Log, dir, stdin, []models.Repo{ctx.Pull.BaseRepo, ctx.HeadRepo}, args...)
+}
@@ -0,0 +1,123 @@
+package events_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	vcsmatchers "github.com/runatlantis/atlantis/server/events/vcs/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// Test that the changes the pull request made to the project are pushed to a
+// branch in the promoted project's dir and a pull request is opened for them.
+func TestGitPromoter_Promote(t *testing.T) {
+	repoDir, cleanup := initPromoteRepo(t)
+	defer cleanup()
+	runCmd(t, repoDir, "git", "checkout", "-b", "feature")
+	writeFile(t, filepath.Join(repoDir, "staging", "main.tf"), "resource \"null_resource\" \"new\" {}\n")
+	runCmd(t, repoDir, "git", "commit", "-am", "change staging")
+	runCmd(t, repoDir, "git", "checkout", "master")
+
+	dataDir, cleanup2 := TempDir(t)
+	defer cleanup2()
+	RegisterMockTestingT(t)
+	vcsClient := vcsmocks.NewMockClient()
+	When(vcsClient.MarkdownPullLink(vcsmatchers.AnyModelsPullRequest())).ThenReturn("#1", nil)
+	When(vcsClient.CreateOrUpdatePull(vcsmatchers.AnyModelsRepo(), AnyString(), AnyString(), AnyString(), AnyString())).
+		ThenReturn(models.PullRequest{Num: 2, URL: "https://github.com/owner/repo/pull/2"}, nil)
+	promoter := &events.GitPromoter{
+		VCSClient: vcsClient,
+		GitCloner: events.GitCloner{DataDir: dataDir},
+	}
+
+	ctx := promoteCtx(t, repoDir)
+	pull, err := promoter.Promote(ctx)
+	Ok(t, err)
+	Equals(t, "https://github.com/owner/repo/pull/2", pull.URL)
+
+	// The change should only be made to prod on the promotion branch.
+	Equals(t, "resource \"null_resource\" \"new\" {}\n", runCmd(t, repoDir, "git", "show", "atlantis/promote/1-prod:prod/main.tf"))
+	Equals(t, "resource \"null_resource\" \"old\" {}\n", runCmd(t, repoDir, "git", "show", "atlantis/promote/1-prod:staging/main.tf"))
+	vcsClient.VerifyWasCalledOnce().CreateOrUpdatePull(
+		ctx.Pull.BaseRepo,
+		"atlantis/promote/1-prod",
+		"master",
+		"Promote staging to prod",
+		"Promotes the changes to `staging` that @lkysow applied in #1 to `prod`.\n\nThis pull request was opened by Atlantis and is updated every time #1 is applied.",
+	)
+}
+
+// Test that nothing is promoted if the pull request didn't change the project.
+func TestGitPromoter_PromoteNoChanges(t *testing.T) {
+	repoDir, cleanup := initPromoteRepo(t)
+	defer cleanup()
+	runCmd(t, repoDir, "git", "checkout", "-b", "feature")
+	writeFile(t, filepath.Join(repoDir, "other.txt"), "other\n")
+	runCmd(t, repoDir, "git", "add", "other.txt")
+	runCmd(t, repoDir, "git", "commit", "-m", "change other")
+	runCmd(t, repoDir, "git", "checkout", "master")
+
+	dataDir, cleanup2 := TempDir(t)
+	defer cleanup2()
+	RegisterMockTestingT(t)
+	vcsClient := vcsmocks.NewMockClient()
+	promoter := &events.GitPromoter{
+		VCSClient: vcsClient,
+		GitCloner: events.GitCloner{DataDir: dataDir},
+	}
+
+	pull, err := promoter.Promote(promoteCtx(t, repoDir))
+	Ok(t, err)
+	Assert(t, pull == nil, "exp no pull request, got %v", pull)
+	vcsClient.VerifyWasCalled(Never()).CreateOrUpdatePull(vcsmatchers.AnyModelsRepo(), AnyString(), AnyString(), AnyString(), AnyString())
+}
+
+// initPromoteRepo initializes a git repo with a staging and prod project
+// that have the same contents.
+func initPromoteRepo(t *testing.T) (string, func()) {
+	repoDir, cleanup := initRepo(t)
+	for _, dir := range []string{"staging", "prod"} {
+		Ok(t, os.Mkdir(filepath.Join(repoDir, dir), 0700))
+		writeFile(t, filepath.Join(repoDir, dir, "main.tf"), "resource \"null_resource\" \"old\" {}\n")
+	}
+	runCmd(t, repoDir, "git", "add", ".")
+	runCmd(t, repoDir, "git", "commit", "-m", "add projects")
+	return repoDir, cleanup
+}
+
+func promoteCtx(t *testing.T, repoDir string) command.ProjectContext {
+	repo := models.Repo{
+		FullName:          "owner/repo",
+		CloneURL:          fmt.Sprintf("file://%s", repoDir),
+		SanitizedCloneURL: fmt.Sprintf("file://%s", repoDir),
+	}
+	return command.ProjectContext{
+		Log:          logging.NewNoopLogger(t),
+		RepoRelDir:   "staging",
+		ProjectName:  "staging",
+		PromoteTo:    "prod",
+		PromoteToDir: "prod",
+		User:         models.User{Username: "lkysow"},
+		HeadRepo:     repo,
+		Pull: models.PullRequest{
+			Num:        1,
+			URL:        "https://github.com/owner/repo/pull/1",
+			HeadBranch: "feature",
+			BaseBranch: "master",
+			BaseRepo:   repo,
+		},
+	}
+}
+
+func writeFile(t *testing.T, path string, contents string) {
+	t.Helper()
+	Ok(t, os.WriteFile(path, []byte(contents), 0600))
+}
@@ -0,0 +1,155 @@
+package events
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// checkProviderTargets returns a failure that lists the provider configs of
+// the project in projAbsPath that target something its provider targets
+// don't allow. It's checked before terraform init so that a project can't
+// even read from the wrong account. Only the configs in the project's own
+// .tf and .tf.json files are checked, and their attributes must be literals
+// or only use terraform.workspace, since that's all that's known before
+// Terraform runs. Providers that are in required_providers but aren't
+// configured fail too since what they target can't be checked.
+func checkProviderTargets(ctx command.ProjectContext, projAbsPath string) (string, error) {
+	if len(ctx.ProviderTargets) == 0 {
+		return "", nil
+	}
+	evalCtx := workspaceEvalContext(ctx.Workspace)
+	var violations []string
+	configured := make(map[string]bool)
+	// required maps the providers in required_providers to the file they're
+	// required in.
+	required := make(map[string]string)
+	var requiredOrder []string
+	err := forEachTFBlock(projAbsPath, func(block *hcl.Block) error {
+		if block.Type == "terraform" {
+			for _, name := range requiredProviders(block) {
+				if _, ok := required[name]; !ok {
+					required[name] = filepath.Base(block.DefRange.Filename)
+					requiredOrder = append(requiredOrder, name)
+				}
+			}
+			return nil
+		}
+		if block.Type != "provider" {
+			return nil
+		}
+		configured[block.Labels[0]] = true
+		for _, target := range ctx.ProviderTargets {
+			if target.Provider != block.Labels[0] {
+				continue
+			}
+			if v := providerTargetViolation(block, target, evalCtx); v != "" {
+				violations = append(violations, fmt.Sprintf("* %s in %s: %s", providerConfigName(block), filepath.Base(block.DefRange.Filename), v))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "checking provider targets")
+	}
+	for _, name := range requiredOrder {
+		if configured[name] {
+			continue
+		}
+		for _, target := range ctx.ProviderTargets {
+			if target.Provider == name {
+				violations = append(violations, fmt.Sprintf("* provider %q in %s: is required but not configured, so %s can't be checked", name, required[name], target.Attribute))
+				break
+			}
+		}
+	}
+	if len(violations) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("Project configures providers to target what the server's provider targets don't allow:\n%s", strings.Join(violations, "\n")), nil
+}
+
+// providerTargetViolation returns why the provider config in block doesn't
+// satisfy target, or an empty string if it does.
+func providerTargetViolation(block *hcl.Block, target valid.ProviderTarget, evalCtx *hcl.EvalContext) string {
+	return bodyTargetViolation(block.Body, strings.Split(target.Attribute, "."), target, evalCtx)
+}
+
+// bodyTargetViolation returns why the attribute at path in body doesn't
+// satisfy target, or an empty string if it does. If path goes through nested
+// blocks, every one of them has to satisfy it, ex. each of the assume_role
+// blocks that are chained together.
+func bodyTargetViolation(body hcl.Body, path []string, target valid.ProviderTarget, evalCtx *hcl.EvalContext) string {
+	if len(path) > 1 {
+		content, _, _ := body.PartialContent(&hcl.BodySchema{Blocks: []hcl.BlockHeaderSchema{
+			{Type: path[0]},
+			{Type: "dynamic", LabelNames: []string{"type"}},
+		}})
+		var blocks []*hcl.Block
+		for _, b := range content.Blocks {
+			if b.Type != "dynamic" {
+				blocks = append(blocks, b)
+				continue
+			}
+			if b.Labels[0] == path[0] {
+				return fmt.Sprintf("%s must not be set in a dynamic %s block so that it can be checked before planning", target.Attribute, path[0])
+			}
+		}
+		if len(blocks) == 0 {
+			return fmt.Sprintf("%s is not set", target.Attribute)
+		}
+		for _, b := range blocks {
+			if violation := bodyTargetViolation(b.Body, path[1:], target, evalCtx); violation != "" {
+				return violation
+			}
+		}
+		return ""
+	}
+	name := path[0]
+	content, _, _ := body.PartialContent(&hcl.BodySchema{Attributes: []hcl.AttributeSchema{{Name: name}}})
+	attr, ok := content.Attributes[name]
+	if !ok {
+		return fmt.Sprintf("%s is not set", target.Attribute)
+	}
+	value, ok := stringValue(attr.Expr, evalCtx)
+	if !ok {
+		return fmt.Sprintf("%s must be a literal so that it can be checked before planning", target.Attribute)
+	}
+	if !target.Allows(value) {
+		return fmt.Sprintf("%s %q is not allowed, allowed: %s", target.Attribute, value, strings.Join(target.Allowed, ", "))
+	}
+	return ""
+}
+
+// requiredProviders returns the local names of the providers in the
+// required_providers blocks of the terraform block.
+func requiredProviders(block *hcl.Block) []string {
+	content, _, _ := block.Body.PartialContent(&hcl.BodySchema{Blocks: []hcl.BlockHeaderSchema{{Type: "required_providers"}}})
+	var names []string
+	for _, rp := range content.Blocks {
+		attrs, _ := rp.Body.JustAttributes()
+		for name := range attrs {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// providerConfigName returns the name of the provider config in block, ex.
+// aws.us_east_1 for a config with an alias.
+func providerConfigName(block *hcl.Block) string {
+	content, _, _ := block.Body.PartialContent(&hcl.BodySchema{Attributes: []hcl.AttributeSchema{{Name: "alias"}}})
+	if attr, ok := content.Attributes["alias"]; ok {
+		if alias, ok := stringValue(attr.Expr, nil); ok {
+			return fmt.Sprintf("provider %q", block.Labels[0]+"."+alias)
+		}
+	}
+	return fmt.Sprintf("provider %q", block.Labels[0])
+}
@@ -0,0 +1,191 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestDefaultProjectCommandRunner_PlanProviderTargets(t *testing.T) {
+	targets := []valid.ProviderTarget{
+		{Provider: "aws", Attribute: "assume_role.role_arn", Allowed: []string{"arn:aws:iam::111111111111:role/*"}},
+		{Provider: "google", Attribute: "project", Allowed: []string{"acme-prod-*"}},
+	}
+	cases := []struct {
+		description string
+		tf          string
+		tfJSON      string
+		expFailure  string
+	}{
+		{
+			description: "allowed targets",
+			tf: `
+provider "aws" {
+  region = "us-east-1"
+  assume_role {
+    role_arn = "arn:aws:iam::111111111111:role/atlantis-${terraform.workspace}"
+  }
+}
+
+provider "google" {
+  project = "acme-prod-network"
+}
+
+provider "azurerm" {
+  features {}
+}`,
+		},
+		{
+			description: "disallowed targets",
+			tf: `
+provider "aws" {
+  assume_role {
+    role_arn = "arn:aws:iam::222222222222:role/atlantis"
+  }
+}
+
+provider "aws" {
+  alias  = "us_west_2"
+  region = "us-west-2"
+}
+
+provider "google" {
+  project = var.project
+}`,
+			expFailure: "Project configures providers to target what the server's provider targets don't allow:\n" +
+				"* provider \"aws\" in main.tf: assume_role.role_arn \"arn:aws:iam::222222222222:role/atlantis\" is not allowed, allowed: arn:aws:iam::111111111111:role/*\n" +
+				"* provider \"aws.us_west_2\" in main.tf: assume_role.role_arn is not set\n" +
+				"* provider \"google\" in main.tf: project must be a literal so that it can be checked before planning",
+		},
+		{
+			description: "chained assume_role blocks",
+			tf: `
+provider "aws" {
+  assume_role {
+    role_arn = "arn:aws:iam::111111111111:role/atlantis"
+  }
+  assume_role {
+    role_arn = "arn:aws:iam::222222222222:role/atlantis"
+  }
+}
+
+provider "aws" {
+  alias = "chained"
+  assume_role {
+    role_arn = "arn:aws:iam::111111111111:role/atlantis"
+  }
+  assume_role {
+    role_arn = "arn:aws:iam::111111111111:role/deploy"
+  }
+}
+
+provider "aws" {
+  alias = "dynamic"
+  assume_role {
+    role_arn = "arn:aws:iam::111111111111:role/atlantis"
+  }
+  dynamic "assume_role" {
+    for_each = var.roles
+    content {
+      role_arn = assume_role.value
+    }
+  }
+}`,
+			expFailure: "Project configures providers to target what the server's provider targets don't allow:\n" +
+				"* provider \"aws\" in main.tf: assume_role.role_arn \"arn:aws:iam::222222222222:role/atlantis\" is not allowed, allowed: arn:aws:iam::111111111111:role/*\n" +
+				"* provider \"aws.dynamic\" in main.tf: assume_role.role_arn must not be set in a dynamic assume_role block so that it can be checked before planning",
+		},
+		{
+			description: "required but not configured",
+			tf: `
+terraform {
+  required_providers {
+    aws = {
+      source = "hashicorp/aws"
+    }
+    google = {
+      source = "hashicorp/google"
+    }
+    azurerm = {
+      source = "hashicorp/azurerm"
+    }
+  }
+}
+
+provider "google" {
+  project = "acme-prod-network"
+}`,
+			expFailure: "Project configures providers to target what the server's provider targets don't allow:\n" +
+				"* provider \"aws\" in main.tf: is required but not configured, so assume_role.role_arn can't be checked",
+		},
+		{
+			description: "json",
+			tfJSON: `{
+  "terraform": {
+    "required_providers": {
+      "google": {"source": "hashicorp/google"}
+    }
+  },
+  "provider": {
+    "aws": [
+      {"assume_role": {"role_arn": "arn:aws:iam::111111111111:role/atlantis"}},
+      {"alias": "other", "assume_role": {"role_arn": "arn:aws:iam::222222222222:role/atlantis"}}
+    ]
+  }
+}`,
+			expFailure: "Project configures providers to target what the server's provider targets don't allow:\n" +
+				"* provider \"aws.other\" in main.tf.json: assume_role.role_arn \"arn:aws:iam::222222222222:role/atlantis\" is not allowed, allowed: arn:aws:iam::111111111111:role/*\n" +
+				"* provider \"google\" in main.tf.json: is required but not configured, so project can't be checked",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockLocker := mocks.NewMockProjectLocker()
+			mockInit := mocks.NewMockStepRunner()
+			runner := &events.DefaultProjectCommandRunner{
+				Locker:           mockLocker,
+				LockURLGenerator: mockURLGenerator{},
+				WorkingDir:       mockWorkingDir,
+				WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+				InitStepRunner:   mockInit,
+			}
+			ctx := command.ProjectContext{
+				Log:             logging.NewNoopLogger(t),
+				Steps:           []valid.Step{{StepName: "init"}},
+				Workspace:       "default",
+				RepoRelDir:      ".",
+				ProviderTargets: targets,
+			}
+			tmp, cleanup := TempDir(t)
+			defer cleanup()
+			if c.tf != "" {
+				Ok(t, os.WriteFile(filepath.Join(tmp, "main.tf"), []byte(c.tf), 0600))
+			}
+			if c.tfJSON != "" {
+				Ok(t, os.WriteFile(filepath.Join(tmp, "main.tf.json"), []byte(c.tfJSON), 0600))
+			}
+			When(mockWorkingDir.Clone(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())).ThenReturn(tmp, false, nil)
+			When(mockLocker.TryLock(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsPullRequest(), matchers.AnyModelsUser(), AnyString(), matchers.AnyModelsProject())).
+				ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key", UnlockFn: func() error { return nil }}, nil)
+			When(mockInit.Run(ctx, nil, tmp, map[string]string{})).ThenReturn("init", nil)
+
+			res := runner.Plan(ctx)
+			Ok(t, res.Error)
+			Equals(t, c.expFailure, res.Failure)
+			if c.expFailure != "" {
+				mockInit.VerifyWasCalled(Never()).Run(matchers.AnyModelsProjectCommandContext(), AnyStringSlice(), AnyString(), matchers.AnyMapOfStringToString())
+			}
+		})
+	}
+}
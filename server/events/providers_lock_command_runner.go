@@ -0,0 +1,56 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+func NewProvidersLockCommandRunner(
+	vcsClient vcs.Client,
+	locker ProvidersLocker,
+) *ProvidersLockCommandRunner {
+	return &ProvidersLockCommandRunner{
+		vcsClient: vcsClient,
+		locker:    locker,
+	}
+}
+
+// ProvidersLockCommandRunner updates the dependency lock files of the
+// Terraform root modules in a pull request and pushes them to its branch.
+type ProvidersLockCommandRunner struct {
+	vcsClient vcs.Client
+	locker    ProvidersLocker
+}
+
+func (p *ProvidersLockCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
+	var msg string
+	if isFork(ctx) {
+		msg = fmt.Sprintf("**%s Lock Error**\n```\ncan't push lock files to pull requests from forks, run terraform providers lock and push the changes\n```", command.ProvidersLock.TitleString())
+	} else {
+		locked, updated, err := p.locker.Lock(ctx, cmd.RepoRelDir)
+		switch {
+		case err != nil:
+			ctx.Log.Err("running terraform providers lock: %s", err)
+			msg = fmt.Sprintf("**%s Lock Error**\n```\n%s\n```", command.ProvidersLock.TitleString(), err)
+		case len(updated) == 0:
+			msg = fmt.Sprintf("The `%s` files of %s are up to date.", lockFileName, codeList(locked))
+		default:
+			msg = fmt.Sprintf("Pushed a commit that updates the `%s` of %s.", lockFileName, codeList(updated))
+		}
+	}
+	if err := p.vcsClient.CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, msg, command.ProvidersLock.String()); err != nil {
+		ctx.Log.Err("unable to comment: %s", err)
+	}
+}
+
+// codeList returns items as a comma separated list of inline code.
+func codeList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = "`" + item + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,71 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeProvidersLocker returns the dirs it's set up with from Lock and records
+// the dir it was asked to lock.
+type fakeProvidersLocker struct {
+	locked     []string
+	updated    []string
+	err        error
+	repoRelDir string
+}
+
+func (f *fakeProvidersLocker) Lock(_ *command.Context, repoRelDir string) ([]string, []string, error) {
+	f.repoRelDir = repoRelDir
+	return f.locked, f.updated, f.err
+}
+
+func TestProvidersLockCommandRunner_Run(t *testing.T) {
+	cases := []struct {
+		description string
+		locker      *fakeProvidersLocker
+		fork        bool
+		expComment  string
+	}{
+		{
+			description: "updated",
+			locker:      &fakeProvidersLocker{locked: []string{"apps", "network"}, updated: []string{"network"}},
+			expComment:  "Pushed a commit that updates the `.terraform.lock.hcl` of `network`.",
+		},
+		{
+			description: "up to date",
+			locker:      &fakeProvidersLocker{locked: []string{"apps", "network"}},
+			expComment:  "The `.terraform.lock.hcl` files of `apps`, `network` are up to date.",
+		},
+		{
+			description: "error",
+			locker:      &fakeProvidersLocker{err: errors.New("running terraform init in apps: exit status 1")},
+			expComment:  "**Providers Lock Error**\n```\nrunning terraform init in apps: exit status 1\n```",
+		},
+		{
+			description: "fork",
+			locker:      &fakeProvidersLocker{},
+			fork:        true,
+			expComment:  "**Providers Lock Error**\n```\ncan't push lock files to pull requests from forks, run terraform providers lock and push the changes\n```",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			vcsClient := vcsmocks.NewMockClient()
+			runner := events.NewProvidersLockCommandRunner(vcsClient, c.locker)
+
+			ctx := fmtRunnerCtx(t, c.fork)
+			runner.Run(ctx, &events.CommentCommand{Name: command.ProvidersLock, RepoRelDir: "apps"})
+			vcsClient.VerifyWasCalledOnce().CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, c.expComment, "providers")
+			if !c.fork {
+				Equals(t, "apps", c.locker.repoRelDir)
+			}
+		})
+	}
+}
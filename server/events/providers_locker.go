@@ -0,0 +1,135 @@
+package events
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+// ProvidersLocker updates the dependency lock files of the Terraform root
+// modules in pull requests.
+type ProvidersLocker interface {
+	// Lock runs terraform providers lock in repoRelDir on the head branch of
+	// ctx.Pull, or if it's empty, in each root module that the pull request
+	// modifies, and pushes a commit with the lock files that changed. It
+	// returns the dirs that were locked and the ones whose lock file changed.
+	Lock(ctx *command.Context, repoRelDir string) (locked []string, updated []string, err error)
+}
+
+// GitProvidersLocker implements ProvidersLocker by cloning the head branch
+// into a temporary dir and running terraform in it.
+type GitProvidersLocker struct {
+	GitCloner
+	VCSClient         vcs.Client
+	TerraformExecutor runtime.TerraformExec
+	// Platforms are the platforms, ex. linux_amd64, that the lock files have
+	// checksums for.
+	Platforms []string
+}
+
+func (g *GitProvidersLocker) Lock(ctx *command.Context, repoRelDir string) ([]string, []string, error) {
+	pull := ctx.Pull
+	cloneDir, repo, cleanup, err := g.clone(ctx.Log, "providers-lock", ctx.HeadRepo, "--depth=1", "--branch", pull.HeadBranch, "--single-branch")
+	defer cleanup()
+	if err != nil {
+		return nil, nil, err
+	}
+	git := func(args ...string) (string, error) {
+		return g.git(ctx.Log, cloneDir, "", []models.Repo{repo}, args...)
+	}
+
+	dirs, err := g.dirsToLock(ctx, cloneDir, repoRelDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lockCmd := []string{"providers", "lock"}
+	for _, p := range g.Platforms {
+		lockCmd = append(lockCmd, "-platform="+p)
+	}
+	prjCtx := command.ProjectContext{Log: ctx.Log, Workspace: DefaultWorkspace}
+	var lockFiles []string
+	for _, dir := range dirs {
+		absDir := filepath.Join(cloneDir, dir)
+		// Modules have to be installed for their providers to be locked. The
+		// backend isn't needed.
+		for _, args := range [][]string{{"init", "-backend=false", "-input=false"}, lockCmd} {
+			if out, err := g.TerraformExecutor.RunCommandWithVersion(prjCtx, absDir, args, nil, nil, DefaultWorkspace); err != nil {
+				return nil, nil, errors.Wrapf(err, "running terraform %s in %s: %s", args[0], dir, out)
+			}
+		}
+		lockFiles = append(lockFiles, path.Join(dir, lockFileName))
+	}
+
+	status, err := git(append([]string{"status", "--porcelain", "--"}, lockFiles...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	var updated []string
+	for _, line := range strings.Split(strings.TrimSpace(status), "\n") {
+		if len(line) > 3 {
+			updated = append(updated, path.Dir(strings.Trim(line[3:], `"`)))
+		}
+	}
+	if len(updated) == 0 {
+		return dirs, nil, nil
+	}
+
+	msg := fmt.Sprintf("Update %s\n\nPushed by Atlantis for @%s with the checksums for %s.", lockFileName, ctx.User.Username, strings.Join(g.Platforms, ", "))
+	for _, args := range [][]string{
+		append([]string{"add", "--"}, lockFiles...),
+		{"commit", "-m", msg},
+		{"push", "origin", "HEAD:" + pull.HeadBranch},
+	} {
+		if _, err := git(args...); err != nil {
+			return nil, nil, err
+		}
+	}
+	return dirs, updated, nil
+}
+
+// dirsToLock returns repoRelDir if it's set, otherwise the root modules in
+// cloneDir that have files modified by the pull request, directly or in the
+// local modules they use.
+func (g *GitProvidersLocker) dirsToLock(ctx *command.Context, cloneDir string, repoRelDir string) ([]string, error) {
+	if repoRelDir != "" {
+		if !tfconfig.IsModuleDir(filepath.Join(cloneDir, repoRelDir)) {
+			return nil, fmt.Errorf("%s doesn't have any Terraform files", repoRelDir)
+		}
+		return []string{repoRelDir}, nil
+	}
+
+	modifiedFiles, err := g.VCSClient.GetModifiedFiles(ctx.Pull.BaseRepo, ctx.Pull)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting modified files")
+	}
+	modifiedDirs := make(map[string]bool)
+	for _, f := range modifiedFiles {
+		modifiedDirs[path.Dir(f)] = true
+	}
+	roots, err := findRootModules(cloneDir)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, root := range roots {
+		for _, dir := range append([]string{root.Dir}, root.LocalModules...) {
+			if modifiedDirs[dir] {
+				dirs = append(dirs, root.Dir)
+				break
+			}
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, errors.New("this pull request doesn't modify any Terraform root modules, use -d to lock the providers of a dir")
+	}
+	return dirs, nil
+}
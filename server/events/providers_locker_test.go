@@ -0,0 +1,116 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	tmocks "github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	tfmatchers "github.com/runatlantis/atlantis/server/core/terraform/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	vcsmatchers "github.com/runatlantis/atlantis/server/events/vcs/mocks/matchers"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const lockedTF = "# This file is maintained automatically by \"terraform init\".\nprovider \"registry.terraform.io/hashicorp/null\" {\n  version = \"3.1.1\"\n}\n"
+
+func TestGitProvidersLocker_Lock(t *testing.T) {
+	cases := []struct {
+		description   string
+		repoRelDir    string
+		modifiedFiles []string
+		expLocked     []string
+		expUpdated    []string
+		expErr        string
+	}{
+		{
+			description:   "modified local module",
+			modifiedFiles: []string{"modules/vpc/main.tf"},
+			expLocked:     []string{"network"},
+			expUpdated:    []string{"network"},
+		},
+		{
+			description:   "modified roots",
+			modifiedFiles: []string{"apps/main.tf", "network/main.tf", "README.md"},
+			expLocked:     []string{"apps", "network"},
+			expUpdated:    []string{"network"},
+		},
+		{
+			description: "dir",
+			repoRelDir:  "apps",
+			expLocked:   []string{"apps"},
+		},
+		{
+			description: "dir without terraform",
+			repoRelDir:  "docs",
+			expErr:      "docs doesn't have any Terraform files",
+		},
+		{
+			description:   "no modified roots",
+			modifiedFiles: []string{"README.md"},
+			expErr:        "this pull request doesn't modify any Terraform root modules, use -d to lock the providers of a dir",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			repoDir, cleanup := initRepo(t)
+			defer cleanup()
+			runCmd(t, repoDir, "git", "checkout", "-b", "feature")
+			files := map[string]string{
+				"apps/main.tf":             "resource \"null_resource\" \"app\" {}\n",
+				"apps/.terraform.lock.hcl": lockedTF,
+				"network/main.tf":          "module \"vpc\" {\n  source = \"../modules/vpc\"\n}\n",
+				"modules/vpc/main.tf":      "resource \"null_resource\" \"vpc\" {}\n",
+				"docs/README.md":           "docs\n",
+			}
+			for path, contents := range files {
+				Ok(t, os.MkdirAll(filepath.Dir(filepath.Join(repoDir, path)), 0700))
+				writeFile(t, filepath.Join(repoDir, path), contents)
+			}
+			runCmd(t, repoDir, "git", "add", "-f", ".")
+			runCmd(t, repoDir, "git", "commit", "-m", "add terraform")
+			// The branch that's pushed to can't be checked out.
+			runCmd(t, repoDir, "git", "checkout", "master")
+
+			dataDir, cleanup2 := TempDir(t)
+			defer cleanup2()
+			RegisterMockTestingT(t)
+			vcsClient := vcsmocks.NewMockClient()
+			When(vcsClient.GetModifiedFiles(vcsmatchers.AnyModelsRepo(), vcsmatchers.AnyModelsPullRequest())).ThenReturn(c.modifiedFiles, nil)
+			tfClient := tmocks.NewMockClient()
+			When(tfClient.RunCommandWithVersion(matchers.AnyModelsProjectCommandContext(), AnyString(), tfmatchers.AnySliceOfString(), tfmatchers.AnyMapOfStringToString(), tfmatchers.AnyPtrToGoVersionVersion(), AnyString())).
+				Then(func(params []Param) ReturnValues {
+					args := params[2].([]string)
+					if args[0] == "providers" {
+						Equals(t, []string{"providers", "lock", "-platform=linux_amd64", "-platform=darwin_arm64"}, args)
+						Ok(t, os.WriteFile(filepath.Join(params[1].(string), ".terraform.lock.hcl"), []byte(lockedTF), 0600))
+					}
+					return ReturnValues{"", nil}
+				})
+			locker := &events.GitProvidersLocker{
+				VCSClient:         vcsClient,
+				TerraformExecutor: tfClient,
+				Platforms:         []string{"linux_amd64", "darwin_arm64"},
+				GitCloner:         events.GitCloner{DataDir: dataDir},
+			}
+
+			locked, updated, err := locker.Lock(fmtCtx(t, repoDir), c.repoRelDir)
+			if c.expErr != "" {
+				ErrEquals(t, c.expErr, err)
+				return
+			}
+			Ok(t, err)
+			Equals(t, c.expLocked, locked)
+			Equals(t, c.expUpdated, updated)
+			if len(c.expUpdated) > 0 {
+				Equals(t, lockedTF, runCmd(t, repoDir, "git", "show", "feature:network/.terraform.lock.hcl"))
+				msg := runCmd(t, repoDir, "git", "log", "-1", "--format=%B", "feature")
+				Assert(t, strings.HasPrefix(msg, "Update .terraform.lock.hcl\n\nPushed by Atlantis for @lkysow with the checksums for linux_amd64, darwin_arm64."), "got %q", msg)
+			}
+		})
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/runatlantis/atlantis/server/logging"
 
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
@@ -55,6 +56,17 @@ type PullClosedExecutor struct {
 	Backend                  locking.Backend
 	PullClosedTemplate       PullCleanupTemplate
 	LogStreamResourceCleaner ResourceCleaner
+	// WorkspaceDeleter deletes the ephemeral workspaces of the pull request's
+	// projects. If nil, they're left in place.
+	WorkspaceDeleter WorkspaceDeleter
+}
+
+// WorkspaceDeleter deletes Terraform workspaces.
+type WorkspaceDeleter interface {
+	// Delete deletes workspace of the initialized root module in the dir
+	// repoRelDir of the pull request with pullNum cloned into cloneDir. If
+	// destroy is true, its resources are destroyed first.
+	Delete(log logging.SimpleLogging, cloneDir string, repoRelDir string, workspace string, pullNum int, destroy bool) error
 }
 
 type templatedProject struct {
@@ -97,6 +109,10 @@ func (p *PullClosedExecutor) CleanUpPull(repo models.Repo, pull models.PullReque
 		}
 	}
 
+	// Workspaces are deleted from the projects' clones so they have to be
+	// deleted before the clones are.
+	workspacesComment := p.deleteEphemeralWorkspaces(repo, pull, pullStatus)
+
 	if err := p.WorkingDir.Delete(repo, pull); err != nil {
 		return errors.Wrap(err, "cleaning workspace")
 	}
@@ -114,19 +130,77 @@ func (p *PullClosedExecutor) CleanUpPull(repo models.Repo, pull models.PullReque
 		p.Logger.Err("deleting pull from db: %s", err)
 	}
 
-	// If there are no locks then there's no need to comment.
-	if len(locks) == 0 {
+	// If there are no locks or workspaces then there's no need to comment.
+	if len(locks) == 0 && workspacesComment == "" {
 		return nil
 	}
 
-	templateData := p.buildTemplateData(locks)
 	var buf bytes.Buffer
-	if err = pullClosedTemplate.Execute(&buf, templateData); err != nil {
-		return errors.Wrap(err, "rendering template for comment")
+	if len(locks) > 0 {
+		templateData := p.buildTemplateData(locks)
+		if err = pullClosedTemplate.Execute(&buf, templateData); err != nil {
+			return errors.Wrap(err, "rendering template for comment")
+		}
+	}
+	if workspacesComment != "" {
+		if buf.Len() > 0 {
+			buf.WriteString("\n\n")
+		}
+		buf.WriteString(workspacesComment)
 	}
 	return p.VCSClient.CreateComment(repo, pull.Num, buf.String(), "")
 }
 
+// deleteEphemeralWorkspaces deletes the workspaces that belong to pull of the
+// projects in pullStatus and returns the part of the pull request's comment
+// that lists them, or "" if there weren't any.
+func (p *PullClosedExecutor) deleteEphemeralWorkspaces(repo models.Repo, pull models.PullRequest, pullStatus *models.PullStatus) string {
+	if p.WorkspaceDeleter == nil || pullStatus == nil {
+		return ""
+	}
+	var deleted, failed []string
+	seen := make(map[string]bool)
+	for _, project := range pullStatus.Projects {
+		// Projects in the same dir share their workspaces.
+		key := project.RepoRelDir + "/" + project.Workspace
+		if !project.EphemeralWorkspace || seen[key] {
+			continue
+		}
+		seen[key] = true
+		// Workspaces that aren't named after the pull request, ex. because
+		// they were recorded by an older version of Atlantis, may belong to
+		// someone else so they're left alone.
+		if !valid.IsPullWorkspace(project.Workspace, pull.Num) {
+			p.Logger.Warn("not deleting workspace %q of dir %q because it doesn't belong to pull request #%d", project.Workspace, project.RepoRelDir, pull.Num)
+			continue
+		}
+		item := fmt.Sprintf("- dir: `%s` workspace: `%s`", project.RepoRelDir, project.Workspace)
+		cloneDir, err := p.WorkingDir.GetWorkingDir(repo, pull, project.Workspace)
+		if err == nil {
+			err = p.WorkspaceDeleter.Delete(p.Logger, cloneDir, project.RepoRelDir, project.Workspace, pull.Num, project.DestroyOnClose)
+		}
+		if err != nil {
+			p.Logger.Err("deleting workspace %q of dir %q: %s", project.Workspace, project.RepoRelDir, err)
+			failed = append(failed, fmt.Sprintf("%s: %s", item, err))
+			continue
+		}
+		if project.DestroyOnClose {
+			item += " (destroyed)"
+		}
+		deleted = append(deleted, item)
+	}
+
+	var sections []string
+	if len(deleted) > 0 {
+		sections = append(sections, "Deleted the Terraform workspaces of this pull request:\n"+strings.Join(deleted, "\n"))
+	}
+	if len(failed) > 0 {
+		sections = append(sections, "Unable to delete the Terraform workspaces of this pull request. "+
+			"Destroy their resources and delete them manually:\n"+strings.Join(failed, "\n"))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
 // buildTemplateData formats the lock data into a slice that can easily be
 // templated for the VCS comment. We organize all the workspaces by their
 // respective project paths so the comment can look like:
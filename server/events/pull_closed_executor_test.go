@@ -14,7 +14,10 @@
 package events_test
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -32,6 +35,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/models/fixtures"
 	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
 	loggermocks "github.com/runatlantis/atlantis/server/logging/mocks"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -197,7 +201,7 @@ func TestCleanUpLogStreaming(t *testing.T) {
 
 		// Create Log streaming resources
 		prjCmdOutput := make(chan *jobs.ProjectCmdOutputLine)
-		prjCmdOutHandler := jobs.NewAsyncProjectCommandOutputHandler(prjCmdOutput, logger)
+		prjCmdOutHandler := jobs.NewAsyncProjectCommandOutputHandler(prjCmdOutput, logger, nil, 0, nil)
 		ctx := command.ProjectContext{
 			BaseRepo:    fixtures.GithubRepo,
 			Pull:        fixtures.Pull,
@@ -284,3 +288,58 @@ func TestCleanUpLogStreaming(t *testing.T) {
 		assert.Empty(t, dfPrjCmdOutputHandler.GetReceiverBufferForPull(ctx.PullInfo()))
 	})
 }
+
+type fakeWorkspaceDeleter struct {
+	deleted []string
+}
+
+func (f *fakeWorkspaceDeleter) Delete(_ logging.SimpleLogging, cloneDir string, repoRelDir string, workspace string, _ int, destroy bool) error {
+	path := filepath.Join(cloneDir, repoRelDir)
+	if strings.HasSuffix(path, "fails") {
+		return errors.New("workspace has resources")
+	}
+	f.deleted = append(f.deleted, fmt.Sprintf("%s:%s:%t", path, workspace, destroy))
+	return nil
+}
+
+func TestCleanUpPullEphemeralWorkspaces(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	db, err := db.New(tmp)
+	Ok(t, err)
+	_, err = db.UpdatePullWithResults(fixtures.Pull, []command.ProjectResult{
+		{RepoRelDir: "static", Workspace: "default"},
+		{RepoRelDir: "app", Workspace: "pr-1", ProjectName: "app", EphemeralWorkspace: true},
+		{RepoRelDir: "app", Workspace: "pr-1", ProjectName: "app-2", EphemeralWorkspace: true},
+		{RepoRelDir: "fails", Workspace: "pr-1", EphemeralWorkspace: true},
+		{RepoRelDir: "preview", Workspace: "pr-1", EphemeralWorkspace: true, DestroyOnClose: true},
+		// Workspaces that aren't named after the pull request are never deleted.
+		{RepoRelDir: "prod", Workspace: "prod", EphemeralWorkspace: true, DestroyOnClose: true},
+	})
+	Ok(t, err)
+
+	w := mocks.NewMockWorkingDir()
+	When(w.GetWorkingDir(fixtures.GithubRepo, fixtures.Pull, "pr-1")).ThenReturn("/clone", nil)
+	l := lockmocks.NewMockLocker()
+	cp := vcsmocks.NewMockClient()
+	deleter := &fakeWorkspaceDeleter{}
+	pce := events.PullClosedExecutor{
+		Locker:                   l,
+		VCSClient:                cp,
+		WorkingDir:               w,
+		Backend:                  db,
+		PullClosedTemplate:       &events.PullClosedEventTemplate{},
+		Logger:                   logging.NewNoopLogger(t),
+		LogStreamResourceCleaner: mocks.NewMockResourceCleaner(),
+		WorkspaceDeleter:         deleter,
+	}
+	Ok(t, pce.CleanUpPull(fixtures.GithubRepo, fixtures.Pull))
+
+	// Projects that share a dir share their workspace.
+	Equals(t, []string{"/clone/app:pr-1:false", "/clone/preview:pr-1:true"}, deleter.deleted)
+	cp.VerifyWasCalledOnce().CreateComment(fixtures.GithubRepo, fixtures.Pull.Num,
+		"Deleted the Terraform workspaces of this pull request:\n- dir: `app` workspace: `pr-1`\n- dir: `preview` workspace: `pr-1` (destroyed)\n\n"+
+			"Unable to delete the Terraform workspaces of this pull request. Destroy their resources and delete them manually:\n"+
+			"- dir: `fails` workspace: `pr-1`: workspace has resources", "")
+}
@@ -0,0 +1,118 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// PullReverter reverts merged pull requests.
+type PullReverter interface {
+	// RevertPull opens a pull request into the base branch of ctx.Pull that
+	// reverts all the changes ctx.Pull made, or updates the pull request if
+	// it's already open.
+	RevertPull(ctx *command.Context) (models.PullRequest, error)
+}
+
+// GitPullReverter implements PullReverter by applying the inverse of the
+// merged pull request's diff on top of its base branch and pushing it to a
+// branch. It clones and pushes like its GitPromoter, so the pull request's
+// head branch must still exist unless the head is fetched through the
+// GitHub app.
+type GitPullReverter struct {
+	*GitPromoter
+}
+
+func (g *GitPullReverter) RevertPull(ctx *command.Context) (models.PullRequest, error) {
+	pull := ctx.Pull
+	cloneDir, projCtx, cleanup, err := g.clonePull(command.ProjectContext{
+		Log:      ctx.Log,
+		Pull:     ctx.Pull,
+		HeadRepo: ctx.HeadRepo,
+		User:     ctx.User,
+	})
+	defer cleanup()
+	if err != nil {
+		return models.PullRequest{}, err
+	}
+	from, to, err := g.mergedRange(projCtx, cloneDir)
+	if err != nil {
+		return models.PullRequest{}, err
+	}
+	diff, err := g.git(projCtx, cloneDir, "", "diff", "--binary", from, to)
+	if err != nil {
+		return models.PullRequest{}, err
+	}
+	if strings.TrimSpace(diff) == "" {
+		return models.PullRequest{}, errors.New("pull request didn't make any changes, nothing to revert")
+	}
+
+	// The branch is recreated from the base branch every time so that the
+	// revert applies to its latest commit.
+	branch := fmt.Sprintf("%s%d", revertBranchPrefix, pull.Num)
+	msg := fmt.Sprintf("Revert #%d\n\nReverts the changes of %s.", pull.Num, pull.URL)
+	for _, c := range []struct {
+		stdin string
+		args  []string
+	}{
+		{args: []string{"checkout", "-B", branch}},
+		// --3way resolves changes made to the same files since the pull
+		// request was merged and fails on real conflicts.
+		{stdin: diff, args: []string{"apply", "--reverse", "--3way", "--index", "-"}},
+		{args: []string{"commit", "-m", msg}},
+		{args: []string{"push", "--force", "origin", branch}},
+	} {
+		if _, err := g.git(projCtx, cloneDir, c.stdin, c.args...); err != nil {
+			return models.PullRequest{}, err
+		}
+	}
+
+	link, err := g.VCSClient.MarkdownPullLink(pull)
+	if err != nil {
+		return models.PullRequest{}, errors.Wrap(err, "getting link to pull request")
+	}
+	title := fmt.Sprintf("Revert #%d", pull.Num)
+	body := fmt.Sprintf("Reverts %s, as requested by @%s.\n\n"+
+		"This pull request was opened by Atlantis. Review the plans before applying them.",
+		link, ctx.User.Username)
+	revert, err := g.VCSClient.CreateOrUpdatePull(pull.BaseRepo, branch, pull.BaseBranch, title, body)
+	return revert, errors.Wrap(err, "opening pull request")
+}
+
+// mergedRange returns the commits that the diff of the merged pull request
+// is between, given the clone of its base branch in cloneDir with its head in
+// FETCH_HEAD. If the head is already part of the base branch, the pull
+// request was merged with a merge commit and its diff is the one from the
+// merge commit's first parent. Otherwise it was squashed or rebased and its
+// diff is the one from where its head branched off.
+func (g *GitPullReverter) mergedRange(ctx command.ProjectContext, cloneDir string) (string, string, error) {
+	head, err := g.git(ctx, cloneDir, "", "rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	head = strings.TrimSpace(head)
+	mergeBase, err := g.git(ctx, cloneDir, "", "merge-base", "HEAD", head)
+	if err != nil {
+		return "", "", err
+	}
+	mergeBase = strings.TrimSpace(mergeBase)
+	if mergeBase != head {
+		return mergeBase, head, nil
+	}
+
+	merges, err := g.git(ctx, cloneDir, "", "rev-list", "--merges", "--ancestry-path", "--reverse", "--parents", head+"..HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	for _, line := range strings.Split(merges, "\n") {
+		// Each line is the merge commit followed by its parents.
+		commits := strings.Fields(line)
+		if len(commits) >= 3 && commits[2] == head {
+			return commits[1], commits[0], nil
+		}
+	}
+	return "", "", fmt.Errorf("can't find the commit that merged %s into %s", head, ctx.Pull.BaseBranch)
+}
@@ -0,0 +1,87 @@
+package events_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	vcsmatchers "github.com/runatlantis/atlantis/server/events/vcs/mocks/matchers"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// Test that all the changes of a merged pull request are reverted on top of
+// its base branch, however it was merged.
+func TestGitPullReverter_RevertPull(t *testing.T) {
+	cases := []struct {
+		description string
+		merge       func(t *testing.T, repoDir string)
+	}{
+		{
+			description: "merge commit",
+			merge: func(t *testing.T, repoDir string) {
+				runCmd(t, repoDir, "git", "merge", "--no-ff", "-m", "merge feature", "feature")
+			},
+		},
+		{
+			description: "squash",
+			merge: func(t *testing.T, repoDir string) {
+				runCmd(t, repoDir, "git", "merge", "--squash", "feature")
+				runCmd(t, repoDir, "git", "commit", "-m", "squash feature")
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			repoDir, cleanup := initPromoteRepo(t)
+			defer cleanup()
+			runCmd(t, repoDir, "git", "checkout", "-b", "feature")
+			writeFile(t, filepath.Join(repoDir, "staging", "main.tf"), "resource \"null_resource\" \"new\" {}\n")
+			writeFile(t, filepath.Join(repoDir, "prod", "main.tf"), "resource \"null_resource\" \"new\" {}\n")
+			runCmd(t, repoDir, "git", "commit", "-am", "change staging and prod")
+			runCmd(t, repoDir, "git", "checkout", "master")
+			c.merge(t, repoDir)
+			// A later change to the base branch should be kept.
+			writeFile(t, filepath.Join(repoDir, "other.txt"), "other\n")
+			runCmd(t, repoDir, "git", "add", "other.txt")
+			runCmd(t, repoDir, "git", "commit", "-m", "add other")
+
+			dataDir, cleanup2 := TempDir(t)
+			defer cleanup2()
+			RegisterMockTestingT(t)
+			vcsClient := vcsmocks.NewMockClient()
+			When(vcsClient.MarkdownPullLink(vcsmatchers.AnyModelsPullRequest())).ThenReturn("#1", nil)
+			When(vcsClient.CreateOrUpdatePull(vcsmatchers.AnyModelsRepo(), AnyString(), AnyString(), AnyString(), AnyString())).
+				ThenReturn(models.PullRequest{Num: 2, URL: "https://github.com/owner/repo/pull/2"}, nil)
+			reverter := &events.GitPullReverter{GitPromoter: &events.GitPromoter{
+				VCSClient: vcsClient,
+				GitCloner: events.GitCloner{DataDir: dataDir},
+			}}
+
+			projCtx := promoteCtx(t, repoDir)
+			ctx := &command.Context{
+				Log:      projCtx.Log,
+				Pull:     projCtx.Pull,
+				HeadRepo: projCtx.HeadRepo,
+				User:     projCtx.User,
+			}
+			pull, err := reverter.RevertPull(ctx)
+			Ok(t, err)
+			Equals(t, "https://github.com/owner/repo/pull/2", pull.URL)
+
+			Equals(t, "resource \"null_resource\" \"old\" {}\n", runCmd(t, repoDir, "git", "show", "atlantis/revert/1:staging/main.tf"))
+			Equals(t, "resource \"null_resource\" \"old\" {}\n", runCmd(t, repoDir, "git", "show", "atlantis/revert/1:prod/main.tf"))
+			Equals(t, "other\n", runCmd(t, repoDir, "git", "show", "atlantis/revert/1:other.txt"))
+			vcsClient.VerifyWasCalledOnce().CreateOrUpdatePull(
+				ctx.Pull.BaseRepo,
+				"atlantis/revert/1",
+				"master",
+				"Revert #1",
+				"Reverts #1, as requested by @lkysow.\n\nThis pull request was opened by Atlantis. Review the plans before applying them.",
+			)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+// maxPullStackDepth is how many pull requests a pull request can be stacked
+// on. Deeper stacks are cut off so that a cycle of branches can't loop.
+const maxPullStackDepth = 10
+
+// PullStacks finds the stacks of dependent pull requests, where a pull
+// request is stacked on the open pull request whose head branch is its base
+// branch, so that a stack's combined change is planned and its pull requests
+// are applied in order.
+type PullStacks struct {
+	Client vcs.PullStackClient
+}
+
+// Ancestors returns the open pull requests that pull is stacked on, closest
+// first. It's empty if pull isn't stacked, ex. because it's based on the
+// default branch.
+func (s *PullStacks) Ancestors(pull models.PullRequest) ([]models.PullRequest, error) {
+	var ancestors []models.PullRequest
+	seen := map[int]bool{pull.Num: true}
+	branch := pull.BaseBranch
+	for len(ancestors) < maxPullStackDepth {
+		parent, ok, err := s.Client.GetPullByHeadBranch(pull.BaseRepo, branch)
+		if err != nil {
+			return nil, errors.Wrapf(err, "finding the pull request of branch %s", branch)
+		}
+		if !ok || seen[parent.Num] {
+			break
+		}
+		seen[parent.Num] = true
+		ancestors = append(ancestors, parent)
+		branch = parent.BaseBranch
+	}
+	return ancestors, nil
+}
+
+// IsAncestor returns true if pull is stacked on the pull request numbered
+// num.
+func (s *PullStacks) IsAncestor(pull models.PullRequest, num int) (bool, error) {
+	ancestors, err := s.Ancestors(pull)
+	if err != nil {
+		return false, err
+	}
+	for _, a := range ancestors {
+		if a.Num == num {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PullStackVCSClient is a vcs.Client whose modified files of a stacked pull
+// request include the files modified by the pull requests it's stacked on, so
+// that the projects changed anywhere in the stack are planned with the
+// stack's combined change.
+type PullStackVCSClient struct {
+	vcs.Client
+	Stacks *PullStacks
+}
+
+func (c *PullStackVCSClient) GetModifiedFiles(repo models.Repo, pull models.PullRequest) ([]string, error) {
+	files, err := c.Client.GetModifiedFiles(repo, pull)
+	if err != nil {
+		return nil, err
+	}
+	ancestors, err := c.Stacks.Ancestors(pull)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, f := range files {
+		seen[f] = true
+	}
+	for _, ancestor := range ancestors {
+		ancestorFiles, err := c.Client.GetModifiedFiles(repo, ancestor)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting the modified files of pull request %d", ancestor.Num)
+		}
+		for _, f := range ancestorFiles {
+			if !seen[f] {
+				seen[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+	return files, nil
+}
+
+// pullStackApplyComment is posted when an apply is run on a stacked pull
+// request, which can only be applied once the pull requests it's stacked on
+// are merged.
+func pullStackApplyComment(ancestors []models.PullRequest) string {
+	// The bottom of the stack is merged first.
+	nums := make([]string, 0, len(ancestors))
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		nums = append(nums, fmt.Sprintf("#%d", ancestors[i].Num))
+	}
+	merged := nums[0] + " is"
+	if len(nums) > 1 {
+		merged = strings.Join(nums, ", ") + " are"
+	}
+	return fmt.Sprintf("**Error:** This pull request is stacked on #%d so it can't be applied until %s merged, in order."+
+		" Once this pull request is based on the default branch, comment `atlantis plan` to re-plan it.",
+		ancestors[0].Num, merged)
+}
@@ -0,0 +1,137 @@
+package events_test
+
+import (
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/core/locking/mocks"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/models/fixtures"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakePullStackClient finds pull requests by their head branch.
+type fakePullStackClient struct {
+	pulls []models.PullRequest
+}
+
+func (f *fakePullStackClient) GetPullByHeadBranch(repo models.Repo, branch string) (models.PullRequest, bool, error) {
+	for _, p := range f.pulls {
+		if p.HeadBranch == branch {
+			return p, true, nil
+		}
+	}
+	return models.PullRequest{}, false, nil
+}
+
+// pullStack is a stack of pull requests, #1 based on main, #2 on #1 and #3
+// on #2.
+var pullStack = []models.PullRequest{
+	{Num: 1, HeadBranch: "network", BaseBranch: "main", BaseRepo: fixtures.GithubRepo},
+	{Num: 2, HeadBranch: "cluster", BaseBranch: "network", BaseRepo: fixtures.GithubRepo},
+	{Num: 3, HeadBranch: "app", BaseBranch: "cluster", BaseRepo: fixtures.GithubRepo},
+}
+
+func TestPullStacks_Ancestors(t *testing.T) {
+	stacks := &events.PullStacks{Client: &fakePullStackClient{pulls: pullStack}}
+
+	ancestors, err := stacks.Ancestors(pullStack[2])
+	Ok(t, err)
+	Equals(t, []models.PullRequest{pullStack[1], pullStack[0]}, ancestors)
+
+	ancestors, err = stacks.Ancestors(pullStack[0])
+	Ok(t, err)
+	Equals(t, 0, len(ancestors))
+
+	ok, err := stacks.IsAncestor(pullStack[2], 1)
+	Ok(t, err)
+	Assert(t, ok, "#3 should be stacked on #1")
+	ok, err = stacks.IsAncestor(pullStack[0], 2)
+	Ok(t, err)
+	Assert(t, !ok, "#1 shouldn't be stacked on #2")
+
+	// Cycles of branches stop at the pull request they started from.
+	cycle := []models.PullRequest{
+		{Num: 1, HeadBranch: "a", BaseBranch: "b"},
+		{Num: 2, HeadBranch: "b", BaseBranch: "a"},
+	}
+	stacks = &events.PullStacks{Client: &fakePullStackClient{pulls: cycle}}
+	ancestors, err = stacks.Ancestors(cycle[0])
+	Ok(t, err)
+	Equals(t, []models.PullRequest{cycle[1]}, ancestors)
+}
+
+func TestPullStackVCSClient_GetModifiedFiles(t *testing.T) {
+	RegisterMockTestingT(t)
+	vcsClient := vcsmocks.NewMockClient()
+	When(vcsClient.GetModifiedFiles(fixtures.GithubRepo, pullStack[0])).ThenReturn([]string{"network/main.tf"}, nil)
+	When(vcsClient.GetModifiedFiles(fixtures.GithubRepo, pullStack[1])).ThenReturn([]string{"cluster/main.tf", "network/main.tf"}, nil)
+	client := &events.PullStackVCSClient{
+		Client: vcsClient,
+		Stacks: &events.PullStacks{Client: &fakePullStackClient{pulls: pullStack}},
+	}
+
+	files, err := client.GetModifiedFiles(fixtures.GithubRepo, pullStack[1])
+	Ok(t, err)
+	Equals(t, []string{"cluster/main.tf", "network/main.tf"}, files)
+
+	When(vcsClient.GetModifiedFiles(fixtures.GithubRepo, pullStack[1])).ThenReturn([]string{"cluster/main.tf"}, nil)
+	files, err = client.GetModifiedFiles(fixtures.GithubRepo, pullStack[1])
+	Ok(t, err)
+	Equals(t, []string{"cluster/main.tf", "network/main.tf"}, files)
+}
+
+func TestDefaultProjectLocker_TryLockWhenLockedByStack(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockLocker := mocks.NewMockLocker()
+	locker := events.DefaultProjectLocker{
+		Locker:     mockLocker,
+		VCSClient:  vcsmocks.NewMockClient(),
+		PullStacks: &events.PullStacks{Client: &fakePullStackClient{pulls: pullStack}},
+	}
+	project := models.NewProject(fixtures.GithubRepo.FullName, "network")
+	When(mockLocker.TryLock(project, "default", pullStack[2], fixtures.User)).ThenReturn(
+		locking.TryLockResponse{
+			LockAcquired: false,
+			CurrLock:     models.ProjectLock{Pull: pullStack[0]},
+			LockKey:      "runatlantis/atlantis/network/default",
+		},
+		nil,
+	)
+
+	res, err := locker.TryLock(logging.NewNoopLogger(t), pullStack[2], fixtures.User, "default", project)
+	Ok(t, err)
+	Assert(t, res.LockAcquired, "the lock of a pull request lower in the stack shouldn't block the plan")
+	Equals(t, "runatlantis/atlantis/network/default", res.LockKey)
+	Ok(t, res.UnlockFn())
+	mockLocker.VerifyWasCalled(Never()).Unlock(AnyString())
+}
+
+func TestApplyCommandRunner_PullStacks(t *testing.T) {
+	vcsClient := setup(t)
+	applyCommandRunner.PullStacks = &events.PullStacks{Client: &fakePullStackClient{pulls: pullStack}}
+	defer func() { applyCommandRunner.PullStacks = nil }()
+	scopeNull, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+	ctx := &command.Context{
+		User:     fixtures.User,
+		Log:      logging.NewNoopLogger(t),
+		Scope:    scopeNull,
+		Pull:     pullStack[2],
+		HeadRepo: fixtures.GithubRepo,
+		Trigger:  command.CommentTrigger,
+	}
+
+	applyCommandRunner.Run(ctx, &events.CommentCommand{Name: command.Apply})
+
+	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.GithubRepo, 3,
+		"**Error:** This pull request is stacked on #2 so it can't be applied until #1, #2 are merged, in order."+
+			" Once this pull request is based on the default branch, comment `atlantis plan` to re-plan it.", "apply")
+	projectCommandBuilder.VerifyWasCalled(Never()).BuildApplyCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())
+}
@@ -1,14 +1,46 @@
 package events
 
 import (
+	"sort"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/jobs"
 )
 
+// statusCommentMarker is hidden in the status comment so that we can find it
+// again to update it.
+const statusCommentMarker = "<!-- atlantis-status-comment -->"
+
+// planSummaryCommentMarker is hidden in the plan summary comment so that we
+// can find it again to update it.
+const planSummaryCommentMarker = "<!-- atlantis-plan-summary-comment -->"
+
 type PullUpdater struct {
+	// HidePrevPlanComments is the default for repos that don't set a
+	// superseded comments policy in GlobalCfg.
 	HidePrevPlanComments bool
+	GlobalCfg            *valid.GlobalCfgStore
 	VCSClient            vcs.Client
 	MarkdownRenderer     *MarkdownRenderer
+	// CommentReactions controls whether we react to the comment that
+	// triggered a command with the outcome once it completes.
+	CommentReactions bool
+	// StatusComment is true if instead of commenting with the output of each
+	// plan, policy check and apply, we keep a single comment per pull request
+	// updated with the status of all its projects.
+	StatusComment bool
+	// PlanSummaryComment is true if after a plan across more than one
+	// project we also keep a comment updated with a table of the projects
+	// sorted by how many resources their plans destroy.
+	PlanSummaryComment bool
+	// PullStatusFetcher is only used if StatusComment is true.
+	PullStatusFetcher PullStatusFetcher
+	// JobURLGenerator is only used if StatusComment or PlanSummaryComment
+	// is true.
+	JobURLGenerator jobs.ProjectJobURLGenerator
 }
 
 func (c *PullUpdater) updatePull(ctx *command.Context, cmd PullCommand, res command.Result) {
@@ -19,17 +51,196 @@ func (c *PullUpdater) updatePull(ctx *command.Context, cmd PullCommand, res comm
 		ctx.Log.Warn(res.Failure)
 	}
 
-	// HidePrevCommandComments will hide old comments left from previous runs to reduce
-	// clutter in a pull/merge request. This will not delete the comment, since the
-	// comment trail may be useful in auditing or backtracing problems.
-	if c.HidePrevPlanComments {
-		if err := c.VCSClient.HidePrevCommandComments(ctx.Pull.BaseRepo, ctx.Pull.Num, cmd.CommandName().TitleString()); err != nil {
+	if c.StatusComment && isStatusCommentCommand(cmd.CommandName()) {
+		c.updateStatusComment(ctx, cmd, res)
+	} else {
+		c.comment(ctx, cmd, res)
+	}
+
+	if c.PlanSummaryComment && cmd.CommandName() == command.Plan && len(res.ProjectResults) > 1 {
+		c.updatePlanSummaryComment(ctx, res)
+	}
+
+	if commentCmd, ok := cmd.(*CommentCommand); ok && c.CommentReactions && commentCmd.CommentID != 0 {
+		reaction := models.SuccessCommentReaction
+		if res.HasErrors() {
+			reaction = models.FailedCommentReaction
+		}
+		if err := c.VCSClient.ReactToComment(ctx.Pull.BaseRepo, ctx.Pull.Num, commentCmd.CommentID, reaction); err != nil {
+			ctx.Log.Warn("unable to add %s reaction to comment: %s", reaction, err)
+		}
+	}
+}
+
+// comment comments on the pull request with the output of cmd.
+func (c *PullUpdater) comment(ctx *command.Context, cmd PullCommand, res command.Result) {
+	// Old comments left from previous runs are superseded by this one so we
+	// hide or delete them to reduce clutter in a pull/merge request. Hiding is
+	// preferred since the comment trail may be useful in auditing or
+	// backtracing problems.
+	dir := supersededCommentsDir(cmd, res)
+	switch c.supersededCommentsPolicy(ctx.Pull.BaseRepo) {
+	case valid.HideSupersededComments:
+		if err := c.VCSClient.HidePrevCommandComments(ctx.Pull.BaseRepo, ctx.Pull.Num, cmd.CommandName().TitleString(), dir); err != nil {
 			ctx.Log.Err("unable to hide old comments: %s", err)
 		}
+	case valid.DeleteSupersededComments:
+		if err := c.VCSClient.DeletePrevCommandComments(ctx.Pull.BaseRepo, ctx.Pull.Num, cmd.CommandName().TitleString(), dir); err != nil {
+			ctx.Log.Err("unable to delete old comments: %s", err)
+		}
 	}
 
-	comment := c.MarkdownRenderer.Render(res, cmd.CommandName(), ctx.Log.GetHistory(), cmd.IsVerbose(), ctx.Pull.BaseRepo.VCSHost.Type)
+	comment := c.MarkdownRenderer.WithTemplateOverrides(ctx.MarkdownTemplateOverrides).Render(res, cmd.CommandName(), ctx.Log.GetHistory(), cmd.IsVerbose(), ctx.Pull.BaseRepo.VCSHost.Type)
 	if err := c.VCSClient.CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, comment, cmd.CommandName().String()); err != nil {
 		ctx.Log.Err("unable to comment: %s", err)
 	}
 }
+
+// updateStatusComment updates the pull request's status comment with the
+// results of cmd.
+func (c *PullUpdater) updateStatusComment(ctx *command.Context, cmd PullCommand, res command.Result) {
+	// The status comment is updated before the results are stored so we merge
+	// them into the stored status ourselves.
+	status, err := c.PullStatusFetcher.GetPullStatus(ctx.Pull)
+	if err != nil {
+		ctx.Log.Warn("unable to get pull status, status comment will only include this command's projects: %s", err)
+	}
+
+	var projects []statusCommentProject
+	for _, p := range mergeProjectStatuses(ctx.Pull, status, res.ProjectResults) {
+		project := statusCommentProject{ProjectStatus: p}
+		if p.JobID != "" && c.JobURLGenerator != nil {
+			url, err := c.JobURLGenerator.GenerateProjectJobURL(command.ProjectContext{JobID: p.JobID})
+			if err != nil {
+				ctx.Log.Warn("unable to generate job url for dir %q workspace %q: %s", p.RepoRelDir, p.Workspace, err)
+			}
+			project.JobURL = url
+		}
+		projects = append(projects, project)
+	}
+
+	comment := c.MarkdownRenderer.WithTemplateOverrides(ctx.MarkdownTemplateOverrides).RenderStatusComment(statusCommentMarker, projects, res, cmd.CommandName())
+	if err := c.VCSClient.UpsertComment(ctx.Pull.BaseRepo, ctx.Pull.Num, comment, statusCommentMarker); err != nil {
+		ctx.Log.Err("unable to update status comment: %s", err)
+	}
+}
+
+// updatePlanSummaryComment updates the pull request's plan summary comment
+// with the projects planned in res, the ones that destroy the most resources
+// first.
+func (c *PullUpdater) updatePlanSummaryComment(ctx *command.Context, res command.Result) {
+	var projects []planSummaryProject
+	for _, r := range res.ProjectResults {
+		project := planSummaryProject{
+			Workspace:   r.Workspace,
+			RepoRelDir:  r.RepoRelDir,
+			ProjectName: r.ProjectName,
+			Changes:     r.PlanStatus().String(),
+		}
+		if r.PlanSuccess != nil {
+			project.stats = r.PlanSuccess.Stats()
+			project.Changes = project.stats.String()
+		}
+		if r.JobID != "" && c.JobURLGenerator != nil {
+			url, err := c.JobURLGenerator.GenerateProjectJobURL(command.ProjectContext{JobID: r.JobID})
+			if err != nil {
+				ctx.Log.Warn("unable to generate job url for dir %q workspace %q: %s", r.RepoRelDir, r.Workspace, err)
+			}
+			project.JobURL = url
+		}
+		projects = append(projects, project)
+	}
+	// Projects are sorted by how many resources they destroy and then by how
+	// many they change in total. Equally risky projects keep their order.
+	sort.SliceStable(projects, func(i, j int) bool {
+		a, b := projects[i].stats, projects[j].stats
+		if a.Destroy != b.Destroy {
+			return a.Destroy > b.Destroy
+		}
+		return a.Add+a.Change+a.Destroy > b.Add+b.Change+b.Destroy
+	})
+
+	comment := c.MarkdownRenderer.WithTemplateOverrides(ctx.MarkdownTemplateOverrides).RenderPlanSummaryComment(planSummaryCommentMarker, projects)
+	if err := c.VCSClient.UpsertComment(ctx.Pull.BaseRepo, ctx.Pull.Num, comment, planSummaryCommentMarker); err != nil {
+		ctx.Log.Err("unable to update plan summary comment: %s", err)
+	}
+}
+
+// isStatusCommentCommand returns true if the output of cmdName is shown in
+// the status comment when it's enabled. Other commands still comment with
+// their output.
+func isStatusCommentCommand(cmdName command.Name) bool {
+	switch cmdName {
+	case command.Plan, command.Apply, command.PolicyCheck, command.ApprovePolicies:
+		return true
+	}
+	return false
+}
+
+// mergeProjectStatuses returns the project statuses of pull once results are
+// merged into status, the same way the backend merges them. status can be
+// nil.
+func mergeProjectStatuses(pull models.PullRequest, status *models.PullStatus, results []command.ProjectResult) []models.ProjectStatus {
+	var projects []models.ProjectStatus
+	if status != nil && status.Pull.HeadCommit == pull.HeadCommit {
+		projects = append(projects, status.Projects...)
+	}
+
+	for _, res := range results {
+		// These aren't stored by the DBUpdater either.
+		if _, ok := res.Error.(DirNotExistErr); ok {
+			continue
+		}
+		updatedExisting := false
+		for i := range projects {
+			proj := &projects[i]
+			if res.Workspace == proj.Workspace &&
+				res.RepoRelDir == proj.RepoRelDir &&
+				res.ProjectName == proj.ProjectName {
+
+				proj.Status = res.PlanStatus()
+				if res.JobID != "" {
+					proj.JobID = res.JobID
+				}
+				updatedExisting = true
+				break
+			}
+		}
+		if !updatedExisting {
+			projects = append(projects, models.ProjectStatus{
+				Workspace:          res.Workspace,
+				RepoRelDir:         res.RepoRelDir,
+				ProjectName:        res.ProjectName,
+				Status:             res.PlanStatus(),
+				JobID:              res.JobID,
+				EphemeralWorkspace: res.EphemeralWorkspace,
+				DestroyOnClose:     res.DestroyOnClose,
+			})
+		}
+	}
+	return projects
+}
+
+// supersededCommentsPolicy returns the policy for what to do with comments
+// in repo once they're superseded.
+func (c *PullUpdater) supersededCommentsPolicy(repo models.Repo) string {
+	if policy := c.GlobalCfg.Get().SupersededCommentsPolicy(repo.ID()); policy != "" {
+		return policy
+	}
+	if c.HidePrevPlanComments {
+		return valid.HideSupersededComments
+	}
+	return valid.KeepSupersededComments
+}
+
+// supersededCommentsDir returns the dir that superseded comments should be
+// limited to. If cmd ran on a single, specific project then only comments for
+// that project's dir are superseded, otherwise all comments for the command
+// are.
+func supersededCommentsDir(cmd PullCommand, res command.Result) string {
+	commentCmd, ok := cmd.(*CommentCommand)
+	if !ok || !commentCmd.IsForSpecificProject() || len(res.ProjectResults) != 1 {
+		return ""
+	}
+	return res.ProjectResults[0].RepoRelDir
+}
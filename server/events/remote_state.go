@@ -0,0 +1,324 @@
+package events
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// RemoteState is the state that a terraform_remote_state data source reads.
+type RemoteState struct {
+	// Name is the data source's name, ex. "network" for
+	// data.terraform_remote_state.network.
+	Name    string
+	Backend string
+	// Config are the backend config's values that are known before
+	// Terraform runs, ex. the bucket and key of an s3 backend.
+	Config    map[string]string
+	Workspace string
+}
+
+// Backend is the backend that a module stores its state in.
+type Backend struct {
+	Type string
+	// Config are the backend config's values that are literals.
+	Config map[string]string
+}
+
+// tfFileSchema has the top-level blocks of .tf files that forEachTFBlock
+// calls its fn with.
+var tfFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "data", LabelNames: []string{"type", "name"}},
+		{Type: "terraform"},
+		{Type: "provider", LabelNames: []string{"name"}},
+	},
+}
+
+var remoteStateSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "backend", Required: true},
+		{Name: "config"},
+		{Name: "workspace"},
+	},
+}
+
+var backendBlockSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "backend", LabelNames: []string{"type"}},
+	},
+}
+
+// ReadRemoteStates returns the terraform_remote_state data sources of the
+// module in dir, sorted by name. workspace is the value of
+// terraform.workspace, which remote states often read the same workspace
+// with. Config values that use anything else, ex. variables, are left out.
+func ReadRemoteStates(dir string, workspace string) ([]RemoteState, error) {
+	evalCtx := workspaceEvalContext(workspace)
+	var states []RemoteState
+	err := forEachTFBlock(dir, func(block *hcl.Block) error {
+		if block.Type != "data" || block.Labels[0] != "terraform_remote_state" {
+			return nil
+		}
+		attrs, _, diags := block.Body.PartialContent(remoteStateSchema)
+		if diags.HasErrors() {
+			return fmt.Errorf("parsing data.terraform_remote_state.%s: %s", block.Labels[1], diags.Error())
+		}
+		backend, ok := stringValue(attrs.Attributes["backend"].Expr, evalCtx)
+		if !ok {
+			return nil
+		}
+		state := RemoteState{
+			Name:      block.Labels[1],
+			Backend:   backend,
+			Config:    make(map[string]string),
+			Workspace: DefaultWorkspace,
+		}
+		if attr, ok := attrs.Attributes["workspace"]; ok {
+			if state.Workspace, ok = stringValue(attr.Expr, evalCtx); !ok {
+				return nil
+			}
+		}
+		if attr, ok := attrs.Attributes["config"]; ok {
+			pairs, diags := hcl.ExprMap(attr.Expr)
+			if diags.HasErrors() {
+				return nil
+			}
+			for _, pair := range pairs {
+				key, keyOK := stringValue(pair.Key, nil)
+				value, valueOK := stringValue(pair.Value, evalCtx)
+				if keyOK && valueOK {
+					state.Config[key] = value
+				}
+			}
+		}
+		states = append(states, state)
+		return nil
+	})
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Name < states[j].Name
+	})
+	return states, err
+}
+
+// ReadBackend returns the backend that the module in dir configures. It
+// returns nil if the module doesn't configure one.
+func ReadBackend(dir string) (*Backend, error) {
+	var backend *Backend
+	err := forEachTFBlock(dir, func(block *hcl.Block) error {
+		if block.Type != "terraform" {
+			return nil
+		}
+		content, _, _ := block.Body.PartialContent(backendBlockSchema)
+		for _, b := range content.Blocks {
+			backend = &Backend{Type: b.Labels[0], Config: make(map[string]string)}
+			attrs, _ := b.Body.JustAttributes()
+			for name, attr := range attrs {
+				if value, ok := stringValue(attr.Expr, nil); ok {
+					backend.Config[name] = value
+				}
+			}
+		}
+		return nil
+	})
+	return backend, err
+}
+
+// Reads returns true if the remote state reads the state of the module in
+// dir that has backend. The remote state's config must have at least one
+// value in common with the backend's config and no values that differ, so
+// that ex. states in the same bucket but with different keys don't match.
+// Remote states of the local backend read the module whose dir their path is
+// in, relative to fromDir which is the dir of the module with the remote
+// state.
+func (r RemoteState) Reads(fromDir string, dir string, backend *Backend) bool {
+	if r.Backend == "local" {
+		path, ok := r.Config["path"]
+		if !ok {
+			return false
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(fromDir, path)
+		}
+		return filepath.Clean(filepath.Dir(path)) == filepath.Clean(dir)
+	}
+	if backend == nil || backend.Type != r.Backend {
+		return false
+	}
+	common := 0
+	for key, value := range r.Config {
+		backendValue, ok := backend.Config[key]
+		if !ok {
+			continue
+		}
+		if backendValue != value {
+			return false
+		}
+		common++
+	}
+	return common > 0
+}
+
+// checkRemoteStates returns a warning about the terraform_remote_state data
+// sources of the project described by ctx that read the states of projects in
+// the same repo that aren't ready to build on, ex. because their last apply
+// failed. Remote states of other repos can't be checked since they aren't
+// cloned, which is what depends_on is for.
+func (p *DefaultProjectCommandRunner) checkRemoteStates(ctx command.ProjectContext, repoDir string, projAbsPath string) (string, error) {
+	if !p.CheckRemoteStates || p.ProjectRuns == nil {
+		return "", nil
+	}
+	states, err := ReadRemoteStates(projAbsPath, ctx.Workspace)
+	if err != nil || len(states) == 0 {
+		return "", err
+	}
+	backends, err := readRepoBackends(repoDir)
+	if err != nil {
+		return "", err
+	}
+	runs, err := p.ProjectRuns.ListProjectRuns()
+	if err != nil {
+		return "", errors.Wrap(err, "listing project runs")
+	}
+	statuses := models.NewProjectRunStatuses(runs)
+
+	var problems []string
+	for _, state := range states {
+		for _, relDir := range sortedKeys(backends) {
+			absDir := filepath.Join(repoDir, relDir)
+			if absDir == projAbsPath || !state.Reads(projAbsPath, absDir, backends[relDir]) {
+				continue
+			}
+			var status *models.ProjectRunStatus
+			for i := range statuses {
+				s := statuses[i]
+				if strings.EqualFold(s.RepoFullName, ctx.BaseRepo.FullName) && s.Path == relDir && s.Workspace == state.Workspace {
+					status = &statuses[i]
+					break
+				}
+			}
+			if problem := projectStatusProblem(status); problem != "" {
+				problems = append(problems, fmt.Sprintf("`data.terraform_remote_state.%s` reads the project in dir %q (workspace %s), which %s",
+					state.Name, relDir, state.Workspace, problem))
+			}
+		}
+	}
+	if len(problems) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("\nWarning: this project reads remote states that may be out of date:\n* %s", strings.Join(problems, "\n* ")), nil
+}
+
+// readRepoBackends returns the backends of the modules in the repo cloned to
+// repoDir by their dir relative to the repo root. Modules without a backend
+// are included with a nil backend since remote states of the local backend
+// can read them. Hidden dirs, ex. .git and .terraform, are skipped.
+func readRepoBackends(repoDir string) (map[string]*Backend, error) {
+	backends := make(map[string]*Backend)
+	err := filepath.Walk(repoDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != repoDir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".tf" {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		relDir, err := filepath.Rel(repoDir, dir)
+		if err != nil {
+			return err
+		}
+		if _, ok := backends[relDir]; ok {
+			return nil
+		}
+		backend, err := ReadBackend(dir)
+		if err != nil {
+			return err
+		}
+		backends[relDir] = backend
+		return nil
+	})
+	return backends, errors.Wrap(err, "reading backends")
+}
+
+// forEachTFBlock calls fn with the top-level blocks of the .tf and .tf.json
+// files in dir. Files that can't be parsed are skipped since Terraform
+// reports them.
+func forEachTFBlock(dir string, fn func(block *hcl.Block) error) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return err
+	}
+	jsonPaths, err := filepath.Glob(filepath.Join(dir, "*.tf.json"))
+	if err != nil {
+		return err
+	}
+	parser := hclparse.NewParser()
+	for _, path := range append(paths, jsonPaths...) {
+		var file *hcl.File
+		var diags hcl.Diagnostics
+		if strings.HasSuffix(path, ".json") {
+			file, diags = parser.ParseJSONFile(path)
+		} else {
+			file, diags = parser.ParseHCLFile(path)
+		}
+		if diags.HasErrors() {
+			continue
+		}
+		content, _, _ := file.Body.PartialContent(tfFileSchema)
+		for _, block := range content.Blocks {
+			if err := fn(block); err != nil {
+				return errors.Wrapf(err, "in %s", filepath.Base(path))
+			}
+		}
+	}
+	return nil
+}
+
+// workspaceEvalContext returns the context that expressions which only use
+// terraform.workspace are evaluated in.
+func workspaceEvalContext(workspace string) *hcl.EvalContext {
+	return &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"terraform": cty.ObjectVal(map[string]cty.Value{"workspace": cty.StringVal(workspace)}),
+		},
+	}
+}
+
+// stringValue returns the value of expr if it's a known string, or can be
+// converted to one, without anything but evalCtx.
+func stringValue(expr hcl.Expression, evalCtx *hcl.EvalContext) (string, bool) {
+	value, diags := expr.Value(evalCtx)
+	if diags.HasErrors() || !value.IsWhollyKnown() || value.IsNull() {
+		return "", false
+	}
+	value, err := convert.Convert(value, cty.String)
+	if err != nil {
+		return "", false
+	}
+	return value.AsString(), true
+}
+
+func sortedKeys(backends map[string]*Backend) []string {
+	keys := make([]string, 0, len(backends))
+	for k := range backends {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,130 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestReadRemoteStates(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	Ok(t, os.WriteFile(filepath.Join(tmp, "main.tf"), []byte(`
+variable "region" {}
+
+data "terraform_remote_state" "vpc" {
+  backend   = "s3"
+  workspace = terraform.workspace
+  config = {
+    bucket  = "states"
+    key     = "network/${terraform.workspace}.tfstate"
+    region  = var.region
+    encrypt = true
+  }
+}
+
+data "terraform_remote_state" "dns" {
+  backend = "local"
+  config = {
+    path = "../dns/terraform.tfstate"
+  }
+}
+
+data "aws_caller_identity" "current" {}
+`), 0600))
+
+	states, err := events.ReadRemoteStates(tmp, "prod")
+	Ok(t, err)
+	Equals(t, []events.RemoteState{
+		{
+			Name:      "dns",
+			Backend:   "local",
+			Config:    map[string]string{"path": "../dns/terraform.tfstate"},
+			Workspace: "default",
+		},
+		{
+			Name:      "vpc",
+			Backend:   "s3",
+			Config:    map[string]string{"bucket": "states", "key": "network/prod.tfstate", "encrypt": "true"},
+			Workspace: "prod",
+		},
+	}, states)
+}
+
+func TestReadBackend(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+
+	backend, err := events.ReadBackend(tmp)
+	Ok(t, err)
+	Assert(t, backend == nil, "exp no backend")
+
+	Ok(t, os.WriteFile(filepath.Join(tmp, "backend.tf"), []byte(`
+terraform {
+  required_version = ">= 1.0"
+  backend "s3" {
+    bucket = "states"
+    key    = "network/prod.tfstate"
+  }
+}
+`), 0600))
+	backend, err = events.ReadBackend(tmp)
+	Ok(t, err)
+	Equals(t, &events.Backend{Type: "s3", Config: map[string]string{"bucket": "states", "key": "network/prod.tfstate"}}, backend)
+}
+
+func TestRemoteState_Reads(t *testing.T) {
+	backend := &events.Backend{Type: "s3", Config: map[string]string{"bucket": "states", "key": "network/prod.tfstate", "dynamodb_table": "locks"}}
+	cases := []struct {
+		description string
+		state       events.RemoteState
+		dir         string
+		backend     *events.Backend
+		exp         bool
+	}{
+		{
+			description: "same bucket and key",
+			state:       events.RemoteState{Backend: "s3", Config: map[string]string{"bucket": "states", "key": "network/prod.tfstate", "region": "us-east-1"}},
+			backend:     backend,
+			exp:         true,
+		},
+		{
+			description: "different key",
+			state:       events.RemoteState{Backend: "s3", Config: map[string]string{"bucket": "states", "key": "dns/prod.tfstate"}},
+			backend:     backend,
+		},
+		{
+			description: "different backend",
+			state:       events.RemoteState{Backend: "gcs", Config: map[string]string{"bucket": "states"}},
+			backend:     backend,
+		},
+		{
+			description: "nothing in common",
+			state:       events.RemoteState{Backend: "s3", Config: map[string]string{"region": "us-east-1"}},
+			backend:     backend,
+		},
+		{
+			description: "no backend",
+			state:       events.RemoteState{Backend: "s3", Config: map[string]string{"bucket": "states"}},
+		},
+		{
+			description: "local path in dir",
+			state:       events.RemoteState{Backend: "local", Config: map[string]string{"path": "../dns/terraform.tfstate"}},
+			dir:         "/repo/dns",
+			exp:         true,
+		},
+		{
+			description: "local path in another dir",
+			state:       events.RemoteState{Backend: "local", Config: map[string]string{"path": "../dns/terraform.tfstate"}},
+			dir:         "/repo/network",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			Equals(t, c.exp, c.state.Reads("/repo/app", c.dir, c.backend))
+		})
+	}
+}
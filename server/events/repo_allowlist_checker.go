@@ -16,6 +16,8 @@ package events
 import (
 	"fmt"
 	"strings"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 )
 
 // Wildcard matches 0-n of all characters except commas.
@@ -24,7 +26,10 @@ const Wildcard = "*"
 // RepoAllowlistChecker implements checking if repos are allowlisted to be used with
 // this Atlantis.
 type RepoAllowlistChecker struct {
-	rules []string
+	// GlobalCfg limits the allowed repos to the repos of its tenants if it
+	// has any. Nil to only check the allowlist.
+	GlobalCfg *valid.GlobalCfgStore
+	rules     []string
 }
 
 // NewRepoAllowlistChecker constructs a new checker and validates that the
@@ -45,6 +50,9 @@ func NewRepoAllowlistChecker(allowlist string) (*RepoAllowlistChecker, error) {
 // otherwise.
 func (r *RepoAllowlistChecker) IsAllowlisted(repoFullName string, vcsHostname string) bool {
 	candidate := fmt.Sprintf("%s/%s", vcsHostname, repoFullName)
+	if r.GlobalCfg != nil && !r.GlobalCfg.Get().RepoAllowedByTenants(candidate) {
+		return false
+	}
 	for _, rule := range r.rules {
 		if r.matchesRule(rule, candidate) {
 			return true
@@ -93,3 +101,53 @@ func (r *RepoAllowlistChecker) matchesRule(rule string, candidate string) bool {
 	// substr(rule): abc
 	return candidate[:wildcardIdx] == rule[:wildcardIdx]
 }
+
+// AllowlistTargets are what the allowlist's rules match on a VCS host.
+type AllowlistTargets struct {
+	// Repos are the full names of repos matched by rules without wildcards.
+	Repos []string
+	// Owners are the owners, ex. GitHub organizations or GitLab groups, whose
+	// repos might match a rule with a wildcard. Their repos still need to be
+	// checked with IsAllowlisted.
+	Owners []string
+	// Unenumerable are the rules with a wildcard in the owner, ex.
+	// github.com/*, so the repos they match can't be listed.
+	Unenumerable []string
+}
+
+// Targets returns what the allowlist's rules match on vcsHostname.
+func (r *RepoAllowlistChecker) Targets(vcsHostname string) AllowlistTargets {
+	var targets AllowlistTargets
+	seenOwners := make(map[string]bool)
+	hostPrefix := strings.ToLower(vcsHostname) + "/"
+	for _, rule := range r.rules {
+		lowerRule := strings.ToLower(rule)
+		wildcardIdx := strings.Index(lowerRule, Wildcard)
+		if !strings.HasPrefix(lowerRule, hostPrefix) {
+			// The rule might still match the host if it has a wildcard
+			// before the end of the hostname, ex. * or github.*.
+			if wildcardIdx != -1 && strings.HasPrefix(hostPrefix, lowerRule[:wildcardIdx]) {
+				targets.Unenumerable = append(targets.Unenumerable, rule)
+			}
+			continue
+		}
+
+		path := rule[len(hostPrefix):]
+		if wildcardIdx == -1 {
+			targets.Repos = append(targets.Repos, path)
+			continue
+		}
+		owner := path[:wildcardIdx-len(hostPrefix)]
+		slashIdx := strings.LastIndex(owner, "/")
+		if slashIdx == -1 {
+			targets.Unenumerable = append(targets.Unenumerable, rule)
+			continue
+		}
+		owner = owner[:slashIdx]
+		if !seenOwners[strings.ToLower(owner)] {
+			seenOwners[strings.ToLower(owner)] = true
+			targets.Owners = append(targets.Owners, owner)
+		}
+	}
+	return targets
+}
@@ -14,8 +14,10 @@
 package events_test
 
 import (
+	"regexp"
 	"testing"
 
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -209,3 +211,67 @@ func TestRepoAllowlistChecker_ContainsSchema(t *testing.T) {
 		})
 	}
 }
+
+func TestRepoAllowlistChecker_Targets(t *testing.T) {
+	cases := []struct {
+		Description string
+		Allowlist   string
+		Exp         events.AllowlistTargets
+	}{
+		{
+			"exact repos",
+			"github.com/owner/repo,github.com/owner/other",
+			events.AllowlistTargets{Repos: []string{"owner/repo", "owner/other"}},
+		},
+		{
+			"owner wildcards",
+			"github.com/owner/*,github.com/owner/infra-*,github.com/other/*-tf",
+			events.AllowlistTargets{Owners: []string{"owner", "other"}},
+		},
+		{
+			"subgroup wildcard",
+			"gitlab.com/group/subgroup/*",
+			events.AllowlistTargets{},
+		},
+		{
+			"other hosts are ignored",
+			"gitlab.com/group/*,bitbucket.org/owner/repo",
+			events.AllowlistTargets{},
+		},
+		{
+			"unenumerable",
+			"*,github.*,github.com/*,github.com/org-*,gitlab.*",
+			events.AllowlistTargets{Unenumerable: []string{"*", "github.*", "github.com/*", "github.com/org-*"}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Description, func(t *testing.T) {
+			w, err := events.NewRepoAllowlistChecker(c.Allowlist)
+			Ok(t, err)
+			Equals(t, c.Exp, w.Targets("github.com"))
+		})
+	}
+}
+
+func TestRepoAllowlistChecker_TargetsSubgroups(t *testing.T) {
+	w, err := events.NewRepoAllowlistChecker("gitlab.com/group/subgroup/*,gitlab.com/group/project")
+	Ok(t, err)
+	Equals(t, events.AllowlistTargets{
+		Repos:  []string{"group/project"},
+		Owners: []string{"group/subgroup"},
+	}, w.Targets("gitlab.com"))
+}
+
+func TestRepoAllowlistChecker_Tenants(t *testing.T) {
+	w, err := events.NewRepoAllowlistChecker("*")
+	Ok(t, err)
+	w.GlobalCfg = valid.NewGlobalCfgStore(valid.GlobalCfg{})
+	Assert(t, w.IsAllowlisted("owner/repo", "github.com"), "exp repos to be allowed without tenants")
+
+	w.GlobalCfg.Set(valid.GlobalCfg{
+		Tenants: []valid.Tenant{{Name: "payments", RepoIDRegexes: []*regexp.Regexp{regexp.MustCompile(`^github\.com/payments/`)}}},
+	})
+	Assert(t, w.IsAllowlisted("payments/network", "github.com"), "exp repo of tenant to be allowed")
+	Assert(t, !w.IsAllowlisted("owner/repo", "github.com"), "exp repo outside of tenants to be denied")
+}
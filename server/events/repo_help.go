@@ -0,0 +1,59 @@
+package events
+
+import (
+	"github.com/runatlantis/atlantis/server/core/config"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// RepoHelp is what the help comment shows about the repo that it's for.
+type RepoHelp struct {
+	// AllowedCommands are the commands that the repo's allowed_commands
+	// allow. If nil, every command is allowed.
+	AllowedCommands []string
+	// Projects are the projects configured in the repo's atlantis.yaml.
+	Projects []valid.Project
+}
+
+// RepoHelpBuilder builds the RepoHelp of pull requests.
+type RepoHelpBuilder struct {
+	GlobalCfg  *valid.GlobalCfgStore
+	WorkingDir WorkingDir
+	// WorkingDirLocker is locked while the atlantis.yaml is read so that
+	// it isn't read while a command is re-cloning the pull request.
+	WorkingDirLocker WorkingDirLocker
+	ParserValidator  *config.ParserValidator
+}
+
+// Build returns the RepoHelp of pull request pullNum of repo. Help is
+// commented without cloning the pull request so its projects are read from
+// the atlantis.yaml it was last cloned with. If it hasn't been cloned, or a
+// command is running in its working dir, no projects are returned.
+func (b *RepoHelpBuilder) Build(log logging.SimpleLogging, repo models.Repo, pullNum int) RepoHelp {
+	globalCfg := b.GlobalCfg.Get()
+	help := RepoHelp{AllowedCommands: globalCfg.AllowedCommands(repo.ID())}
+
+	unlockFn, err := b.WorkingDirLocker.TryLock(repo.FullName, pullNum, DefaultWorkspace, DefaultRepoRelDir)
+	if err != nil {
+		log.Debug("not listing projects in the help comment since the working dir is locked: %s", err)
+		return help
+	}
+	defer unlockFn()
+
+	repoDir, err := b.WorkingDir.GetWorkingDir(repo, models.PullRequest{Num: pullNum, BaseRepo: repo}, DefaultWorkspace)
+	if err != nil {
+		return help
+	}
+	hasRepoCfg, err := b.ParserValidator.HasRepoCfg(repoDir)
+	if err != nil || !hasRepoCfg {
+		return help
+	}
+	repoCfg, err := b.ParserValidator.ParseRepoCfg(repoDir, globalCfg, repo.ID())
+	if err != nil {
+		log.Warn("unable to parse %s to list its projects in the help comment: %s", config.AtlantisYAMLFilename, err)
+		return help
+	}
+	help.Projects = repoCfg.Projects
+	return help
+}
@@ -0,0 +1,71 @@
+package events_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/config"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestRepoHelpBuilder_Build(t *testing.T) {
+	RegisterMockTestingT(t)
+	workingDir := mocks.NewMockWorkingDir()
+	globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	globalCfg.Repos = append(globalCfg.Repos, valid.Repo{
+		IDRegex:         regexp.MustCompile(".*"),
+		AllowedCommands: []string{"plan", "unlock"},
+	})
+	workingDirLocker := events.NewDefaultWorkingDirLocker()
+	builder := &events.RepoHelpBuilder{
+		GlobalCfg:        valid.NewGlobalCfgStore(globalCfg),
+		WorkingDir:       workingDir,
+		WorkingDirLocker: workingDirLocker,
+		ParserValidator:  &config.ParserValidator{},
+	}
+	repo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Hostname: "github.com"}}
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	Ok(t, os.WriteFile(filepath.Join(tmp, "atlantis.yaml"), []byte(`version: 3
+projects:
+- name: staging
+  dir: staging
+- dir: production
+`), 0600))
+
+	When(workingDir.GetWorkingDir(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), EqString(events.DefaultWorkspace))).ThenReturn(tmp, nil)
+	help := builder.Build(logging.NewNoopLogger(t), repo, 1)
+	Equals(t, []string{"plan", "unlock"}, help.AllowedCommands)
+	Equals(t, 2, len(help.Projects))
+	Equals(t, "staging", *help.Projects[0].Name)
+	Equals(t, "production", help.Projects[1].Dir)
+	_, pull, _ := workingDir.VerifyWasCalledOnce().GetWorkingDir(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), EqString(events.DefaultWorkspace)).GetCapturedArguments()
+	Equals(t, 1, pull.Num)
+
+	// Projects aren't listed while a command is running in the working dir
+	// since it might be re-cloning it.
+	unlockFn, err := workingDirLocker.TryLockPull(repo.FullName, 1)
+	Ok(t, err)
+	help = builder.Build(logging.NewNoopLogger(t), repo, 1)
+	Equals(t, []string{"plan", "unlock"}, help.AllowedCommands)
+	Equals(t, 0, len(help.Projects))
+	unlockFn()
+	help = builder.Build(logging.NewNoopLogger(t), repo, 1)
+	Equals(t, 2, len(help.Projects))
+
+	// Pull requests that haven't been cloned don't list projects.
+	When(workingDir.GetWorkingDir(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), EqString(events.DefaultWorkspace))).ThenReturn("", errors.New("not cloned"))
+	help = builder.Build(logging.NewNoopLogger(t), repo, 1)
+	Equals(t, []string{"plan", "unlock"}, help.AllowedCommands)
+	Equals(t, 0, len(help.Projects))
+}
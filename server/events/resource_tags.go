@@ -0,0 +1,48 @@
+package events
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// terraformEnv returns the env vars of the commands of projCfg in pull that
+// are run by the job with jobID. They include the tags of the project's
+// resources, if it's configured to get any.
+func terraformEnv(pull models.PullRequest, projCfg valid.MergedProjectCfg, jobID string) map[string]string {
+	if projCfg.Preview == nil && projCfg.ResourceTags == nil {
+		return projCfg.TerraformEnv
+	}
+	// Copy the env vars since they're shared by every project.
+	env := make(map[string]string, len(projCfg.TerraformEnv)+2)
+	for k, v := range projCfg.TerraformEnv {
+		env[k] = v
+	}
+	if projCfg.Preview != nil {
+		env[PreviewTagsEnvVar] = tagsVar(previewTags(pull, projCfg))
+	}
+	if tags := projCfg.ResourceTags; tags != nil {
+		values := map[string]string{
+			valid.RepoResourceTag:   pull.BaseRepo.FullName,
+			valid.PullResourceTag:   strconv.Itoa(pull.Num),
+			valid.AuthorResourceTag: pull.Author,
+			valid.RunIDResourceTag:  jobID,
+		}
+		included := make(map[string]string)
+		for _, name := range tags.Include {
+			included["atlantis_"+name] = values[name]
+		}
+		env["TF_VAR_"+tags.Variable] = tagsVar(included)
+	}
+	return env
+}
+
+// tagsVar returns the value of a map(string) variable with tags. It's JSON,
+// which Terraform parses like HCL.
+func tagsVar(tags map[string]string) string {
+	// Marshaling a map of strings can't fail.
+	value, _ := json.Marshal(tags)
+	return string(value)
+}
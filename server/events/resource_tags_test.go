@@ -0,0 +1,76 @@
+package events_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestProjectCommandContextBuilder_ResourceTags(t *testing.T) {
+	cases := []struct {
+		description string
+		tags        *valid.ResourceTags
+		expEnv      func(jobID string) map[string]string
+	}{
+		{
+			description: "no tags",
+			expEnv: func(string) map[string]string {
+				return map[string]string{"TF_LOG": "info"}
+			},
+		},
+		{
+			description: "all tags",
+			tags:        &valid.ResourceTags{Variable: "atlantis_tags", Include: valid.ResourceTagNames},
+			expEnv: func(jobID string) map[string]string {
+				return map[string]string{
+					"TF_LOG":               "info",
+					"TF_VAR_atlantis_tags": fmt.Sprintf(`{"atlantis_author":"lkysow","atlantis_pull":"1","atlantis_repo":"owner/repo","atlantis_run_id":"%s"}`, jobID),
+				}
+			},
+		},
+		{
+			description: "some tags in another variable",
+			tags:        &valid.ResourceTags{Variable: "labels", Include: []string{valid.PullResourceTag}},
+			expEnv: func(string) map[string]string {
+				return map[string]string{
+					"TF_LOG":        "info",
+					"TF_VAR_labels": `{"atlantis_pull":"1"}`,
+				}
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			subject := events.DefaultProjectCommandContextBuilder{
+				CommentBuilder: mocks.NewMockCommentBuilder(),
+			}
+			projCfg := valid.MergedProjectCfg{
+				RepoRelDir:   "app",
+				Workspace:    "default",
+				Workflow:     valid.Workflow{Name: valid.DefaultWorkflowName, Plan: valid.DefaultPlanStage},
+				TerraformEnv: map[string]string{"TF_LOG": "info"},
+				ResourceTags: c.tags,
+			}
+			ctx := &command.Context{
+				Log: logging.NewNoopLogger(t),
+				Pull: models.PullRequest{
+					Num:      1,
+					Author:   "lkysow",
+					BaseRepo: models.Repo{FullName: "owner/repo"},
+				},
+			}
+
+			projCtxs := subject.BuildProjectContext(ctx, command.Plan, projCfg, []string{}, "some/dir", false, false, false, false, false)
+			Equals(t, c.expEnv(projCtxs[0].JobID), projCtxs[0].TerraformEnv)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+// Autoplanner plans the projects a pull request changes as if it was just
+// opened.
+type Autoplanner interface {
+	RunAutoplanCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User)
+}
+
+func NewRevertCommandRunner(
+	vcsClient vcs.Client,
+	reverter PullReverter,
+	autoplanner Autoplanner,
+) *RevertCommandRunner {
+	return &RevertCommandRunner{
+		vcsClient:   vcsClient,
+		reverter:    reverter,
+		Autoplanner: autoplanner,
+	}
+}
+
+// RevertCommandRunner rolls back a merged pull request by opening a pull
+// request that reverts it and planning it right away.
+type RevertCommandRunner struct {
+	vcsClient vcs.Client
+	reverter  PullReverter
+	// Autoplanner plans the revert. It's exported since the command runner
+	// that plans pull requests is created after the comment command runners.
+	Autoplanner Autoplanner
+}
+
+func (r *RevertCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
+	baseRepo := ctx.Pull.BaseRepo
+	pullNum := ctx.Pull.Num
+
+	revert, err := r.reverter.RevertPull(ctx)
+	var msg string
+	if err != nil {
+		ctx.Log.Err("reverting pull request: %s", err)
+		msg = fmt.Sprintf("**%s Error**\n```\n%s\n```", command.Revert.TitleString(), err)
+	} else {
+		link, linkErr := r.vcsClient.MarkdownPullLink(revert)
+		if linkErr != nil {
+			link = revert.URL
+		}
+		msg = fmt.Sprintf("Opened %s to revert this pull request. Atlantis is planning it now.", link)
+	}
+	if commentErr := r.vcsClient.CreateComment(baseRepo, pullNum, msg, command.Revert.String()); commentErr != nil {
+		ctx.Log.Err("unable to comment: %s", commentErr)
+	}
+	if err != nil {
+		return
+	}
+
+	// The revert is pushed to the base repo so it's also its head repo.
+	r.Autoplanner.RunAutoplanCommand(revert.BaseRepo, revert.BaseRepo, revert, ctx.User)
+}
@@ -0,0 +1,71 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	vcsmatchers "github.com/runatlantis/atlantis/server/events/vcs/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakePullReverter struct {
+	revert models.PullRequest
+	err    error
+}
+
+func (f *fakePullReverter) RevertPull(_ *command.Context) (models.PullRequest, error) {
+	return f.revert, f.err
+}
+
+type fakeAutoplanner struct {
+	planned []models.PullRequest
+}
+
+func (f *fakeAutoplanner) RunAutoplanCommand(_ models.Repo, _ models.Repo, pull models.PullRequest, _ models.User) {
+	f.planned = append(f.planned, pull)
+}
+
+func TestRevertCommandRunner_Run(t *testing.T) {
+	repo := models.Repo{FullName: "owner/repo"}
+	revert := models.PullRequest{Num: 2, URL: "https://github.com/owner/repo/pull/2", BaseRepo: repo}
+	cases := []struct {
+		description string
+		err         error
+		expComment  string
+		expPlanned  []models.PullRequest
+	}{
+		{
+			description: "reverted",
+			expComment:  "Opened #2 to revert this pull request. Atlantis is planning it now.",
+			expPlanned:  []models.PullRequest{revert},
+		},
+		{
+			description: "error",
+			err:         errors.New("conflict"),
+			expComment:  "**Revert Error**\n```\nconflict\n```",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			vcsClient := vcsmocks.NewMockClient()
+			When(vcsClient.MarkdownPullLink(vcsmatchers.AnyModelsPullRequest())).ThenReturn("#2", nil)
+			autoplanner := &fakeAutoplanner{}
+			r := events.NewRevertCommandRunner(vcsClient, &fakePullReverter{revert: revert, err: c.err}, autoplanner)
+			ctx := &command.Context{
+				Log:  logging.NewNoopLogger(t),
+				Pull: models.PullRequest{Num: 1, BaseRepo: repo, Merged: true},
+			}
+			r.Run(ctx, &events.CommentCommand{Name: command.Revert})
+
+			vcsClient.VerifyWasCalledOnce().CreateComment(repo, 1, c.expComment, "revert")
+			Equals(t, c.expPlanned, autoplanner.planned)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// revertBranchPrefix is the prefix of the branches that reverts are pushed
+// to.
+const revertBranchPrefix = "atlantis/revert/"
+
+// Reverter reverts changes that were applied but failed verification.
+type Reverter interface {
+	// Revert opens a pull request into the head branch of ctx.Pull that
+	// reverts the changes ctx.Pull made to ctx.RepoRelDir, or updates the
+	// pull request if it's already open. It returns nil if ctx.Pull didn't
+	// change ctx.RepoRelDir.
+	Revert(ctx command.ProjectContext) (*models.PullRequest, error)
+}
+
+// GitReverter implements Reverter by applying the inverse of the pull
+// request's diff on top of its head and pushing it to a branch. It clones and
+// pushes like its GitPromoter.
+type GitReverter struct {
+	*GitPromoter
+}
+
+func (g *GitReverter) Revert(ctx command.ProjectContext) (*models.PullRequest, error) {
+	pull := ctx.Pull
+	// The revert is pushed to the base repo so it can only be merged into a
+	// head branch that's in the same repo.
+	if ctx.HeadRepo.FullName != pull.BaseRepo.FullName {
+		return nil, fmt.Errorf("can't revert pull requests from forks, %s would have to be pushed to", ctx.HeadRepo.FullName)
+	}
+	cloneDir, ctx, diff, cleanup, err := g.clonePullDiff(ctx)
+	defer cleanup()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(diff) == "" {
+		ctx.Log.Info("pull request didn't change %q, nothing to revert", ctx.RepoRelDir)
+		return nil, nil
+	}
+
+	// The branch is recreated from the head branch every time so that it
+	// reverts the latest applied changes.
+	name := g.sourceName(ctx)
+	if name == "." {
+		name = "root"
+	}
+	branch := fmt.Sprintf("%s%d-%s", revertBranchPrefix, pull.Num, name)
+	msg := fmt.Sprintf("Revert %s\n\nReverts the changes applied in %s since they failed verification.", g.sourceName(ctx), pull.URL)
+	for _, c := range []struct {
+		stdin string
+		args  []string
+	}{
+		{args: []string{"checkout", "-B", branch, "FETCH_HEAD"}},
+		{stdin: diff, args: []string{"apply", "--reverse", "--index", "--directory=" + ctx.RepoRelDir, "-"}},
+		{args: []string{"commit", "-m", msg}},
+		{args: []string{"push", "--force", "origin", branch}},
+	} {
+		if _, err := g.git(ctx, cloneDir, c.stdin, c.args...); err != nil {
+			return nil, err
+		}
+	}
+
+	link, err := g.VCSClient.MarkdownPullLink(pull)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting link to pull request")
+	}
+	title := fmt.Sprintf("Revert %s in %s", g.sourceName(ctx), pull.HeadBranch)
+	body := fmt.Sprintf("Reverts the changes to `%s` that @%s applied in %s, since they failed verification.\n\n"+
+		"Merge this pull request into `%s` and apply %s again to roll `%s` back to `%s`.",
+		ctx.RepoRelDir, ctx.User.Username, link, pull.HeadBranch, link, ctx.RepoRelDir, pull.BaseBranch)
+	revert, err := g.VCSClient.CreateOrUpdatePull(pull.BaseRepo, branch, pull.HeadBranch, title, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening pull request")
+	}
+	return &revert, nil
+}
@@ -0,0 +1,65 @@
+package events_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	vcsmatchers "github.com/runatlantis/atlantis/server/events/vcs/mocks/matchers"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// Test that the changes the pull request made to the project are reverted on
+// top of its head on a branch and a pull request is opened into its head.
+func TestGitReverter_Revert(t *testing.T) {
+	repoDir, cleanup := initPromoteRepo(t)
+	defer cleanup()
+	runCmd(t, repoDir, "git", "checkout", "-b", "feature")
+	writeFile(t, filepath.Join(repoDir, "staging", "main.tf"), "resource \"null_resource\" \"new\" {}\n")
+	writeFile(t, filepath.Join(repoDir, "prod", "main.tf"), "resource \"null_resource\" \"new\" {}\n")
+	runCmd(t, repoDir, "git", "commit", "-am", "change staging and prod")
+	runCmd(t, repoDir, "git", "checkout", "master")
+
+	dataDir, cleanup2 := TempDir(t)
+	defer cleanup2()
+	RegisterMockTestingT(t)
+	vcsClient := vcsmocks.NewMockClient()
+	When(vcsClient.MarkdownPullLink(vcsmatchers.AnyModelsPullRequest())).ThenReturn("#1", nil)
+	When(vcsClient.CreateOrUpdatePull(vcsmatchers.AnyModelsRepo(), AnyString(), AnyString(), AnyString(), AnyString())).
+		ThenReturn(models.PullRequest{Num: 2, URL: "https://github.com/owner/repo/pull/2"}, nil)
+	reverter := &events.GitReverter{GitPromoter: &events.GitPromoter{
+		VCSClient: vcsClient,
+		GitCloner: events.GitCloner{DataDir: dataDir},
+	}}
+
+	ctx := promoteCtx(t, repoDir)
+	pull, err := reverter.Revert(ctx)
+	Ok(t, err)
+	Equals(t, "https://github.com/owner/repo/pull/2", pull.URL)
+
+	// Only the project's changes should be reverted.
+	Equals(t, "resource \"null_resource\" \"old\" {}\n", runCmd(t, repoDir, "git", "show", "atlantis/revert/1-staging:staging/main.tf"))
+	Equals(t, "resource \"null_resource\" \"new\" {}\n", runCmd(t, repoDir, "git", "show", "atlantis/revert/1-staging:prod/main.tf"))
+	vcsClient.VerifyWasCalledOnce().CreateOrUpdatePull(
+		ctx.Pull.BaseRepo,
+		"atlantis/revert/1-staging",
+		"feature",
+		"Revert staging in feature",
+		"Reverts the changes to `staging` that @lkysow applied in #1, since they failed verification.\n\n"+
+			"Merge this pull request into `feature` and apply #1 again to roll `staging` back to `master`.",
+	)
+}
+
+// Test that pull requests from forks aren't reverted since the revert can't
+// be pushed to the fork.
+func TestGitReverter_RevertFork(t *testing.T) {
+	RegisterMockTestingT(t)
+	reverter := &events.GitReverter{GitPromoter: &events.GitPromoter{VCSClient: vcsmocks.NewMockClient()}}
+	ctx := promoteCtx(t, "/repo")
+	ctx.HeadRepo.FullName = "fork/repo"
+	_, err := reverter.Revert(ctx)
+	ErrEquals(t, "can't revert pull requests from forks, fork/repo would have to be pushed to", err)
+}
@@ -0,0 +1,171 @@
+package events
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// secretAllowComment marks a line as not containing a credential. It's the
+// same comment as gitleaks' so that repos that already scan with gitleaks
+// don't have to mark their false positives twice.
+const secretAllowComment = "gitleaks:allow"
+
+// secretRule matches a kind of credential. If pattern has a group, it's the
+// credential itself and is redacted in findings. Otherwise the match isn't
+// shown at all.
+type secretRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+var secretRules = []secretRule{
+	{name: "AWS access key ID", pattern: regexp.MustCompile(`\b((?:AKIA|ASIA|ABIA|ACCA)[A-Z0-9]{16})\b`)},
+	{name: "AWS secret access key", pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+]{40})\b`)},
+	{name: "GitHub token", pattern: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{82})\b`)},
+	{name: "GitLab token", pattern: regexp.MustCompile(`\b(glpat-[A-Za-z0-9_-]{20})\b`)},
+	{name: "Slack token", pattern: regexp.MustCompile(`\b(xox[abposr]-[A-Za-z0-9-]{10,})`)},
+	{name: "Google API key", pattern: regexp.MustCompile(`\b(AIza[A-Za-z0-9_-]{35})\b`)},
+	{name: "Google service account key", pattern: regexp.MustCompile(`"private_key_id"\s*:\s*"([a-f0-9]{40})"`)},
+	{name: "Azure storage account key", pattern: regexp.MustCompile(`AccountKey=([A-Za-z0-9+/]{86}==)`)},
+	{name: "Stripe secret key", pattern: regexp.MustCompile(`\b((?:sk|rk)_live_[A-Za-z0-9]{24,})\b`)},
+	{name: "private key", pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )?PRIVATE KEY(?: BLOCK)?-----`)},
+	// Passwords are only matched when they're string literals so that
+	// references to variables and interpolations aren't findings.
+	{name: "password", pattern: regexp.MustCompile(`(?i)\bpass(?:word|wd)["']?\s*[:=]\s*"([^"$\s]{8,})"`)},
+}
+
+// SecretScanner scans the lines that pull requests add for committed
+// credentials before their projects are planned, so that Atlantis doesn't run
+// terraform init against repos that just leaked keys. It clones like its
+// GitPromoter to diff the pull request's head against where it branched off.
+type SecretScanner struct {
+	*GitPromoter
+}
+
+// secretFinding is a credential that SecretScanner found.
+type secretFinding struct {
+	file string
+	line int
+	rule string
+	// redacted is the redacted credential or empty if it isn't shown.
+	redacted string
+}
+
+// Scan scans the lines that ctx.Pull adds. It returns a failure that lists
+// the redacted findings if it found any.
+func (s *SecretScanner) Scan(ctx *command.Context) (string, error) {
+	cloneDir, projCtx, cleanup, err := s.clonePull(command.ProjectContext{
+		Log:      ctx.Log,
+		Pull:     ctx.Pull,
+		HeadRepo: ctx.HeadRepo,
+		User:     ctx.User,
+	})
+	defer cleanup()
+	if err != nil {
+		return "", err
+	}
+	mergeBase, err := s.git(projCtx, cloneDir, "", "merge-base", "HEAD", "FETCH_HEAD")
+	if err != nil {
+		return "", err
+	}
+	diff, err := s.git(projCtx, cloneDir, "", "-c", "core.quotePath=false", "diff", "--unified=0", "--no-color", "--no-ext-diff", strings.TrimSpace(mergeBase), "FETCH_HEAD")
+	if err != nil {
+		return "", err
+	}
+	findings := scanDiffForSecrets(diff)
+	if len(findings) == 0 {
+		return "", nil
+	}
+	ctx.Log.Warn("found %d possible credentials in the lines the pull request adds", len(findings))
+	return renderSecretFindings(findings), nil
+}
+
+// scanDiffForSecrets returns the findings in the lines that diff, a git diff
+// without context lines, adds. Binary files have no added lines so they
+// aren't scanned.
+func scanDiffForSecrets(diff string) []secretFinding {
+	var findings []secretFinding
+	var file string
+	var lineNum int
+	inHunk := false
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file, inHunk = "", false
+		case !inHunk && strings.HasPrefix(line, "+++ "):
+			// Deleted files are diffed against /dev/null and have no added
+			// lines.
+			file = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+		case strings.HasPrefix(line, "@@ "):
+			inHunk = true
+			lineNum = hunkStartLine(line)
+		case inHunk && strings.HasPrefix(line, "+"):
+			findings = append(findings, scanLineForSecrets(file, lineNum, line[1:])...)
+			lineNum++
+		}
+	}
+	return findings
+}
+
+// hunkHeaderRegex matches the header of a hunk. Its group is the line in the
+// new file that the hunk starts at.
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// hunkStartLine returns the line in the new file that the hunk with header
+// starts at.
+func hunkStartLine(header string) int {
+	match := hunkHeaderRegex.FindStringSubmatch(header)
+	if match == nil {
+		return 0
+	}
+	start, _ := strconv.Atoi(match[1])
+	return start
+}
+
+// scanLineForSecrets returns the findings in line lineNum of file.
+func scanLineForSecrets(file string, lineNum int, line string) []secretFinding {
+	if strings.Contains(line, secretAllowComment) {
+		return nil
+	}
+	var findings []secretFinding
+	for _, rule := range secretRules {
+		match := rule.pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		finding := secretFinding{file: file, line: lineNum, rule: rule.name}
+		if len(match) > 1 {
+			finding.redacted = redactSecret(match[1])
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// redactSecret keeps only enough of secret to find it. Short secrets, ex.
+// passwords, are redacted completely.
+func redactSecret(secret string) string {
+	if len(secret) < 20 {
+		return "****"
+	}
+	return secret[:4] + "****"
+}
+
+func renderSecretFindings(findings []secretFinding) string {
+	var b strings.Builder
+	b.WriteString("Found what look like credentials in the lines that this pull request adds. Atlantis won't plan it until they're removed:\n")
+	for _, f := range findings {
+		fmt.Fprintf(&b, "* `%s` line %d: %s", f.file, f.line, f.rule)
+		if f.redacted != "" {
+			fmt.Fprintf(&b, " `%s`", f.redacted)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "\nRemoving them in a new commit doesn't remove them from the repo's history, so rotate them as well. "+
+		"If a finding isn't a credential, add a `%s` comment to its line.", secretAllowComment)
+	return b.String()
+}
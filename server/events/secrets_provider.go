@@ -0,0 +1,41 @@
+package events
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SecretsProvider resolves the secret:// references in the env vars of
+// workflow steps when the steps run, so that secrets don't need to be in the
+// config or exported by earlier steps.
+type SecretsProvider interface {
+	// Resolve returns the value of the secret called name.
+	Resolve(name string) (string, error)
+}
+
+// FileSecretsProvider implements SecretsProvider by reading each secret from
+// the file in Dir with its name, ex. a mounted Kubernetes secret or the files
+// rendered by the Vault agent.
+type FileSecretsProvider struct {
+	Dir string
+}
+
+func (f *FileSecretsProvider) Resolve(name string) (string, error) {
+	clean := filepath.Clean(name)
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("secret %q must be relative to the secrets dir", name)
+	}
+	data, err := os.ReadFile(filepath.Join(f.Dir, clean))
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("secret %q does not exist", name)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "reading secret %q", name)
+	}
+	// Files usually end with a newline that isn't part of the secret.
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
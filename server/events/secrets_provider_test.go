@@ -0,0 +1,28 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestFileSecretsProvider_Resolve(t *testing.T) {
+	dir, cleanup := TempDir(t)
+	defer cleanup()
+	Ok(t, os.MkdirAll(filepath.Join(dir, "prod"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(dir, "prod", "token"), []byte("s3cr3t\n"), 0600))
+	provider := &events.FileSecretsProvider{Dir: dir}
+
+	secret, err := provider.Resolve("prod/token")
+	Ok(t, err)
+	Equals(t, "s3cr3t", secret)
+
+	_, err = provider.Resolve("prod/missing")
+	ErrEquals(t, `secret "prod/missing" does not exist`, err)
+
+	_, err = provider.Resolve("../prod/token")
+	ErrEquals(t, `secret "../prod/token" must be relative to the secrets dir`, err)
+}
@@ -0,0 +1,247 @@
+package events
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// bitbucketServerSSHPort is the port Bitbucket Server serves git over SSH on
+// by default.
+const bitbucketServerSSHPort = "7999"
+
+// SSHCredentialsWriter lets git clone over SSH with a server key or per-repo
+// deploy keys instead of with HTTPS tokens. It maintains a known_hosts file
+// for the VCS hosts and an ssh-agent for each key. Each key gets its own agent
+// because VCS hosts accept the first deploy key that's registered with any
+// repo, so an agent that offered every deploy key could authenticate as the
+// wrong repo.
+type SSHCredentialsWriter struct {
+	// GlobalCfg is used to look up the deploy key of each repo.
+	GlobalCfg *valid.GlobalCfgStore
+	// ServerKeyFile is the key used for repos without a deploy key. Empty if
+	// every repo must have a deploy key.
+	ServerKeyFile string
+	Logger        logging.SimpleLogging
+
+	dir            string
+	knownHostsFile string
+	mu             sync.Mutex
+	agents         map[string]*sshAgent
+}
+
+// sshAgent is an ssh-agent serving a single key on a unix socket.
+type sshAgent struct {
+	socket   string
+	listener net.Listener
+
+	mu      sync.Mutex
+	keyring agent.Agent
+	// key is the contents of the key file that keyring holds.
+	key []byte
+}
+
+// NewSSHCredentialsWriter returns a writer that keeps its known_hosts file and
+// agent sockets in dir. The known_hosts file contains the entries in
+// knownHostsFile so that git only connects to VCS hosts with those keys.
+func NewSSHCredentialsWriter(dir string, knownHostsFile string, serverKeyFile string, globalCfg *valid.GlobalCfgStore, logger logging.SimpleLogging) (*SSHCredentialsWriter, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "creating dir for ssh credentials")
+	}
+	knownHosts, err := os.ReadFile(knownHostsFile) // nolint: gosec
+	if err != nil {
+		return nil, errors.Wrap(err, "reading known hosts")
+	}
+	if err := validateKnownHosts(knownHosts); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", knownHostsFile)
+	}
+	s := &SSHCredentialsWriter{
+		GlobalCfg:      globalCfg,
+		ServerKeyFile:  serverKeyFile,
+		Logger:         logger,
+		dir:            dir,
+		knownHostsFile: filepath.Join(dir, "known_hosts"),
+		agents:         make(map[string]*sshAgent),
+	}
+	if err := os.WriteFile(s.knownHostsFile, knownHosts, 0600); err != nil {
+		return nil, errors.Wrap(err, "writing known_hosts")
+	}
+	logger.Info("wrote ssh known hosts to %s", s.knownHostsFile)
+
+	// Load the server key now so that a bad key fails at startup rather
+	// than on the first clone.
+	if serverKeyFile != "" {
+		if _, err := s.agent(serverKeyFile); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Repo returns repo with its clone URLs replaced by its SSH clone URL.
+func (s *SSHCredentialsWriter) Repo(repo models.Repo) (models.Repo, error) {
+	cloneURL, err := SSHCloneURL(repo)
+	if err != nil {
+		return repo, err
+	}
+	repo.CloneURL = cloneURL
+	repo.SanitizedCloneURL = cloneURL
+	return repo, nil
+}
+
+// Env returns the env vars that make git authenticate with repo's deploy key,
+// or with the server key if repo doesn't have one.
+func (s *SSHCredentialsWriter) Env(repo models.Repo) ([]string, error) {
+	keyFile := s.GlobalCfg.Get().DeployKeyFile(repo.ID())
+	if keyFile == "" {
+		keyFile = s.ServerKeyFile
+	}
+	if keyFile == "" {
+		return nil, fmt.Errorf("no ssh key for repo %s: set deploy_key_file for it in the server-side repo config or set a server key", repo.ID())
+	}
+	socket, err := s.agent(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return []string{
+		"SSH_AUTH_SOCK=" + socket,
+		fmt.Sprintf("GIT_SSH_COMMAND=ssh -F /dev/null -o UserKnownHostsFile='%s' -o StrictHostKeyChecking=yes", s.knownHostsFile),
+	}, nil
+}
+
+// Close stops the agents.
+func (s *SSHCredentialsWriter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for keyFile, a := range s.agents {
+		a.listener.Close() // nolint: errcheck
+		delete(s.agents, keyFile)
+	}
+	return nil
+}
+
+// agent returns the socket of the agent for keyFile, starting the agent if it
+// isn't running. The key file is reread every time so that rotated keys are
+// picked up without a restart.
+func (s *SSHCredentialsWriter) agent(keyFile string) (string, error) {
+	contents, err := os.ReadFile(keyFile) // nolint: gosec
+	if err != nil {
+		return "", errors.Wrap(err, "reading ssh key")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.agents[keyFile]
+	if !ok {
+		// Sockets are named after their index because unix socket paths
+		// can't be longer than 108 characters.
+		socket := filepath.Join(s.dir, fmt.Sprintf("agent-%d.sock", len(s.agents)))
+		if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+			return "", errors.Wrap(err, "removing stale agent socket")
+		}
+		listener, err := net.Listen("unix", socket)
+		if err != nil {
+			return "", errors.Wrap(err, "starting ssh agent")
+		}
+		a = &sshAgent{keyring: agent.NewKeyring(), socket: socket, listener: listener}
+		go a.serve()
+		s.agents[keyFile] = a
+	}
+	if err := a.setKey(keyFile, contents); err != nil {
+		return "", err
+	}
+	return a.socket, nil
+}
+
+// setKey makes the agent serve the key in contents if it isn't already. The
+// new key is added to a new keyring that replaces the old one so that
+// clones that are authenticating always find a key.
+func (a *sshAgent) setKey(keyFile string, contents []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if bytes.Equal(a.key, contents) {
+		return nil
+	}
+	key, err := ssh.ParseRawPrivateKey(contents)
+	if _, ok := err.(*ssh.PassphraseMissingError); ok {
+		return fmt.Errorf("ssh key %s is encrypted, only keys without a passphrase are supported", keyFile)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "parsing ssh key %s", keyFile)
+	}
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: key, Comment: keyFile}); err != nil {
+		return errors.Wrap(err, "adding ssh key to agent")
+	}
+	a.keyring = keyring
+	a.key = contents
+	return nil
+}
+
+func (a *sshAgent) serve() {
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			return
+		}
+		a.mu.Lock()
+		keyring := a.keyring
+		a.mu.Unlock()
+		go func() {
+			defer conn.Close()              // nolint: errcheck
+			agent.ServeAgent(keyring, conn) // nolint: errcheck
+		}()
+	}
+}
+
+// validateKnownHosts returns an error if knownHosts has an invalid entry.
+func validateKnownHosts(knownHosts []byte) error {
+	rest := knownHosts
+	for len(strings.TrimSpace(string(rest))) > 0 {
+		var err error
+		_, _, _, _, rest, err = ssh.ParseKnownHosts(rest)
+		if err == io.EOF {
+			// The rest of the file is comments.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SSHCloneURL returns the URL for cloning repo over SSH.
+func SSHCloneURL(repo models.Repo) (string, error) {
+	u, err := url.Parse(repo.CloneURL)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing clone url")
+	}
+	switch repo.VCSHost.Type {
+	case models.BitbucketServer:
+		// Bitbucket Server serves HTTPS clones under /scm, possibly after a
+		// context path, and SSH clones on another port without it.
+		path := u.Path
+		if i := strings.Index(path, "/scm/"); i >= 0 {
+			path = path[i+len("/scm"):]
+		}
+		return fmt.Sprintf("ssh://git@%s:%s%s", u.Hostname(), bitbucketServerSSHPort, strings.ToLower(path)), nil
+	case models.AzureDevops:
+		return fmt.Sprintf("ssh://git@ssh.dev.azure.com/v3/%s", repo.FullName), nil
+	default:
+		return fmt.Sprintf("ssh://git@%s%s", u.Hostname(), u.Path), nil
+	}
+}
@@ -0,0 +1,59 @@
+package events
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/artifacts"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// StateSnapshotKey returns the key in the artifact store that the snapshot of
+// the state of the project described by ctx, taken at t, is stored under, ex.
+// state-snapshots/owner/repo/project/default/20220102T150405Z-pull1-abc1234.tfstate.enc.
+func StateSnapshotKey(ctx command.ProjectContext, t time.Time) string {
+	project := ctx.ProjectName
+	if project == "" {
+		project = path.Clean(ctx.RepoRelDir)
+	}
+	commit := ctx.Pull.HeadCommit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	name := fmt.Sprintf("%s-pull%d-%s.tfstate.enc", t.UTC().Format("20060102T150405Z"), ctx.Pull.Num, commit)
+	return path.Join("state-snapshots", ctx.BaseRepo.FullName, project, ctx.Workspace, name)
+}
+
+// snapshotState pulls the state of the project described by ctx and stores
+// it, encrypted, in the artifact store before the project is applied so that
+// a bad apply can be investigated and rolled back. Projects without state
+// yet aren't snapshotted. An error fails the apply so that nothing's applied
+// without a snapshot.
+func (p *DefaultProjectCommandRunner) snapshotState(ctx command.ProjectContext, absPath string, envs map[string]string) error {
+	if p.SnapshotStore == nil {
+		return nil
+	}
+	state, err := p.StatePullStepRunner.Run(ctx, nil, absPath, envs)
+	if err != nil {
+		return errors.Wrap(err, "snapshotting state")
+	}
+	// Skip anything Terraform printed before the state, ex. warnings.
+	start := strings.Index(state, "{")
+	if start < 0 {
+		ctx.Log.Info("not snapshotting state since there isn't any yet")
+		return nil
+	}
+	encrypted, err := artifacts.Encrypt(p.SnapshotKey, []byte(state[start:]))
+	if err != nil {
+		return errors.Wrap(err, "snapshotting state")
+	}
+	key := StateSnapshotKey(ctx, time.Now())
+	if err := p.SnapshotStore.Put(key, encrypted); err != nil {
+		return errors.Wrap(err, "snapshotting state")
+	}
+	ctx.Log.Info("snapshotted state to %s", p.SnapshotStore.URL(key))
+	return nil
+}
@@ -0,0 +1,91 @@
+package events_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/artifacts"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestStateSnapshotKey(t *testing.T) {
+	ctx := command.ProjectContext{
+		BaseRepo:   models.Repo{FullName: "owner/repo"},
+		Pull:       models.PullRequest{Num: 1, HeadCommit: "abc1234567"},
+		Workspace:  "default",
+		RepoRelDir: "network/",
+	}
+	now := time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC)
+	Equals(t, "state-snapshots/owner/repo/network/default/20220102T150405Z-pull1-abc1234.tfstate.enc", events.StateSnapshotKey(ctx, now))
+
+	ctx.ProjectName = "vpc"
+	Equals(t, "state-snapshots/owner/repo/vpc/default/20220102T150405Z-pull1-abc1234.tfstate.enc", events.StateSnapshotKey(ctx, now))
+}
+
+// Test that the state is snapshotted to the artifact store before it's
+// applied, and that nothing's applied if it can't be.
+func TestDefaultProjectCommandRunner_ApplySnapshotsState(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockApply := mocks.NewMockStepRunner()
+	mockStatePull := mocks.NewMockStepRunner()
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	storeDir, cleanupStore := TempDir(t)
+	defer cleanupStore()
+	key := bytes.Repeat([]byte("k"), artifacts.KeySize)
+	runner := events.DefaultProjectCommandRunner{
+		ApplyStepRunner:     mockApply,
+		StatePullStepRunner: mockStatePull,
+		SnapshotStore:       &artifacts.FileStore{Dir: storeDir},
+		SnapshotKey:         key,
+		WorkingDir:          mockWorkingDir,
+		Webhooks:            mocks.NewMockWebhooksSender(),
+		WorkingDirLocker:    events.NewDefaultWorkingDirLocker(),
+		AggregateApplyRequirements: &events.AggregateApplyRequirements{
+			WorkingDir: mockWorkingDir,
+		},
+	}
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.GetWorkingDir(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())).ThenReturn(repoDir, nil)
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Steps:      []valid.Step{{StepName: "apply"}},
+		BaseRepo:   models.Repo{FullName: "owner/repo"},
+		Pull:       models.PullRequest{Num: 1, HeadCommit: "abc1234"},
+		Workspace:  "default",
+		RepoRelDir: ".",
+	}
+	When(mockStatePull.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("Warning: something\n{\"serial\": 3}", nil)
+	When(mockApply.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("Apply complete!", nil)
+
+	res := runner.Apply(ctx)
+	Ok(t, res.Error)
+	Equals(t, "Apply complete!", res.ApplySuccess)
+	snapshots, err := filepath.Glob(filepath.Join(storeDir, "state-snapshots", "owner", "repo", "default", "*-pull1-abc1234.tfstate.enc"))
+	Ok(t, err)
+	Equals(t, 1, len(snapshots))
+	encrypted, err := os.ReadFile(snapshots[0])
+	Ok(t, err)
+	state, err := artifacts.Decrypt(key, encrypted)
+	Ok(t, err)
+	Equals(t, `{"serial": 3}`, string(state))
+
+	// Nothing's applied if the state can't be pulled.
+	When(mockStatePull.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("", errors.New("running terraform state pull: no backend"))
+	res = runner.Apply(ctx)
+	Assert(t, res.Error != nil && strings.HasPrefix(res.Error.Error(), "snapshotting state: running terraform state pull: no backend"), "exp snapshot error, got %v", res.Error)
+	mockApply.VerifyWasCalledOnce().Run(ctx, nil, repoDir, map[string]string{})
+}
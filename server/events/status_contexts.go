@@ -0,0 +1,136 @@
+package events
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+// StatusCommands are the commands that Atlantis reports commit statuses for.
+var StatusCommands = []command.Name{command.Plan, command.PolicyCheck, command.Apply}
+
+// StatusContexts are the names, or contexts, of the commit statuses that
+// Atlantis reports on a repo's pull requests.
+type StatusContexts struct {
+	// Required are reported on every pull request, even ones that don't
+	// modify any projects, so branch protection can require them.
+	Required []string
+	// Project are only reported on the pull requests that modify the
+	// project, so requiring them would block every other pull request. They
+	// can only be listed for projects in the repo's atlantis.yaml.
+	Project []string
+}
+
+// StatusContexts returns the statuses that Atlantis reports for cmds on the
+// pull requests of a repo whose atlantis.yaml is repoCfg, or nil if it
+// doesn't have one.
+func (d *DefaultCommitStatusUpdater) StatusContexts(cmds []command.Name, repoCfg *valid.RepoCfg) (StatusContexts, error) {
+	var contexts StatusContexts
+	seen := make(map[string]bool)
+	for _, cmd := range cmds {
+		src, err := d.combinedSrc(cmd)
+		if err != nil {
+			return StatusContexts{}, err
+		}
+		// Every command updates the same status if it's combined.
+		if !seen[src] {
+			seen[src] = true
+			contexts.Required = append(contexts.Required, src)
+		}
+		if (d.Granularity != "" && d.Granularity != ProjectStatusGranularity) || repoCfg == nil {
+			continue
+		}
+		for _, project := range repoCfg.Projects {
+			src, err := d.projectSrc(cmd, project.GetName(), project.Dir, project.Workspace)
+			if err != nil {
+				return StatusContexts{}, err
+			}
+			contexts.Project = append(contexts.Project, src)
+		}
+	}
+	return contexts, nil
+}
+
+// ownsStatusContext returns true if context is a status that Atlantis
+// reports, for any command, on the pull requests of a repo whose
+// atlantis.yaml is repoCfg.
+func (d *DefaultCommitStatusUpdater) ownsStatusContext(context string, repoCfg *valid.RepoCfg) (bool, error) {
+	if context == d.StatusName || strings.HasPrefix(context, d.StatusName+"/") {
+		return true, nil
+	}
+	// Statuses named with templates don't necessarily start with the status
+	// name.
+	all, err := d.StatusContexts(StatusCommands, repoCfg)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range append(all.Required, all.Project...) {
+		if c == context {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RequiredStatusChecksSync is what SyncRequiredStatusChecks changed.
+type RequiredStatusChecksSync struct {
+	// Contexts are the required status checks after the sync, sorted.
+	Contexts []string
+	Added    []string
+	Removed  []string
+}
+
+// SyncRequiredStatusChecks makes branch of repo require the statuses that
+// Atlantis reports for cmds on every pull request. Other required statuses of
+// Atlantis, ex. of commands that are no longer required or of projects, are
+// removed and the ones of other tools are kept. The branch must already
+// require status checks.
+func (d *DefaultCommitStatusUpdater) SyncRequiredStatusChecks(client vcs.BranchProtectionClient, repo models.Repo, branch string, cmds []command.Name, repoCfg *valid.RepoCfg) (RequiredStatusChecksSync, error) {
+	contexts, err := d.StatusContexts(cmds, repoCfg)
+	if err != nil {
+		return RequiredStatusChecksSync{}, err
+	}
+	current, err := client.GetRequiredStatusChecks(repo, branch)
+	if err != nil {
+		return RequiredStatusChecksSync{}, err
+	}
+
+	var sync RequiredStatusChecksSync
+	want := make(map[string]bool)
+	for _, c := range contexts.Required {
+		want[c] = true
+	}
+	have := make(map[string]bool)
+	for _, c := range current {
+		have[c] = true
+		owned, err := d.ownsStatusContext(c, repoCfg)
+		if err != nil {
+			return RequiredStatusChecksSync{}, err
+		}
+		switch {
+		case want[c] || !owned:
+			sync.Contexts = append(sync.Contexts, c)
+		default:
+			sync.Removed = append(sync.Removed, c)
+		}
+	}
+	for _, c := range contexts.Required {
+		if !have[c] {
+			sync.Added = append(sync.Added, c)
+			sync.Contexts = append(sync.Contexts, c)
+		}
+	}
+	sort.Strings(sync.Contexts)
+	if len(sync.Added) == 0 && len(sync.Removed) == 0 {
+		return sync, nil
+	}
+	if err := client.SetRequiredStatusChecks(repo, branch, sync.Contexts); err != nil {
+		return RequiredStatusChecksSync{}, errors.Wrapf(err, "updating required status checks of %s", branch)
+	}
+	return sync, nil
+}
@@ -0,0 +1,117 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+	"text/template"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestDefaultCommitStatusUpdater_StatusContexts(t *testing.T) {
+	name := "vpc"
+	repoCfg := &valid.RepoCfg{Projects: []valid.Project{
+		{Dir: "staging", Workspace: "default"},
+		{Dir: "prod", Workspace: "default", Name: &name},
+	}}
+	cmds := []command.Name{command.Plan, command.Apply}
+	cases := []struct {
+		description string
+		updater     events.DefaultCommitStatusUpdater
+		repoCfg     *valid.RepoCfg
+		exp         events.StatusContexts
+	}{
+		{
+			"project",
+			events.DefaultCommitStatusUpdater{StatusName: "atlantis"},
+			repoCfg,
+			events.StatusContexts{
+				Required: []string{"atlantis/plan", "atlantis/apply"},
+				Project:  []string{"atlantis/plan: staging/default", "atlantis/plan: vpc", "atlantis/apply: staging/default", "atlantis/apply: vpc"},
+			},
+		},
+		{
+			"project without atlantis.yaml",
+			events.DefaultCommitStatusUpdater{StatusName: "atlantis"},
+			nil,
+			events.StatusContexts{Required: []string{"atlantis/plan", "atlantis/apply"}},
+		},
+		{
+			"command",
+			events.DefaultCommitStatusUpdater{StatusName: "atlantis", Granularity: events.CommandStatusGranularity},
+			repoCfg,
+			events.StatusContexts{Required: []string{"atlantis/plan", "atlantis/apply"}},
+		},
+		{
+			"combined",
+			events.DefaultCommitStatusUpdater{StatusName: "atlantis", Granularity: events.CombinedStatusGranularity},
+			repoCfg,
+			events.StatusContexts{Required: []string{"atlantis"}},
+		},
+		{
+			"templates",
+			events.DefaultCommitStatusUpdater{
+				StatusName:                "atlantis",
+				StatusNameTemplate:        template.Must(template.New("").Parse("terraform-{{ .Command }}")),
+				ProjectStatusNameTemplate: template.Must(template.New("").Parse("terraform-{{ .Command }}-{{ .Dir }}")),
+			},
+			&valid.RepoCfg{Projects: []valid.Project{{Dir: "staging", Workspace: "default"}}},
+			events.StatusContexts{
+				Required: []string{"terraform-plan", "terraform-apply"},
+				Project:  []string{"terraform-plan-staging", "terraform-apply-staging"},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			contexts, err := c.updater.StatusContexts(cmds, c.repoCfg)
+			Ok(t, err)
+			Equals(t, c.exp, contexts)
+		})
+	}
+}
+
+type fakeBranchProtection struct {
+	contexts []string
+	sets     int
+	err      error
+}
+
+func (f *fakeBranchProtection) GetRequiredStatusChecks(repo models.Repo, branch string) ([]string, error) {
+	return f.contexts, f.err
+}
+
+func (f *fakeBranchProtection) SetRequiredStatusChecks(repo models.Repo, branch string, contexts []string) error {
+	f.sets++
+	f.contexts = contexts
+	return nil
+}
+
+func TestDefaultCommitStatusUpdater_SyncRequiredStatusChecks(t *testing.T) {
+	updater := &events.DefaultCommitStatusUpdater{StatusName: "atlantis"}
+	repoCfg := &valid.RepoCfg{Projects: []valid.Project{{Dir: "staging", Workspace: "default"}}}
+	client := &fakeBranchProtection{contexts: []string{"ci/test", "atlantis/apply", "atlantis/plan: staging/default"}}
+
+	sync, err := updater.SyncRequiredStatusChecks(client, models.Repo{}, "main", []command.Name{command.Plan}, repoCfg)
+	Ok(t, err)
+	Equals(t, events.RequiredStatusChecksSync{
+		Contexts: []string{"atlantis/plan", "ci/test"},
+		Added:    []string{"atlantis/plan"},
+		Removed:  []string{"atlantis/apply", "atlantis/plan: staging/default"},
+	}, sync)
+	Equals(t, []string{"atlantis/plan", "ci/test"}, client.contexts)
+
+	// The checks aren't updated if they're already in sync.
+	sync, err = updater.SyncRequiredStatusChecks(client, models.Repo{}, "main", []command.Name{command.Plan}, repoCfg)
+	Ok(t, err)
+	Equals(t, events.RequiredStatusChecksSync{Contexts: []string{"atlantis/plan", "ci/test"}}, sync)
+	Equals(t, 1, client.sets)
+
+	client.err = errors.New("branch main of owner/repo doesn't require status checks")
+	_, err = updater.SyncRequiredStatusChecks(client, models.Repo{}, "main", []command.Name{command.Plan}, repoCfg)
+	ErrEquals(t, "branch main of owner/repo doesn't require status checks", err)
+}
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// TenantCredentials makes git authenticate to the VCS host of the repos of
+// tenants that set vcs_token_file in the server-side repo config with the
+// tenant's credentials instead of the server's. Its methods can be called on
+// a nil *TenantCredentials, in which case they don't change anything.
+type TenantCredentials struct {
+	GlobalCfg *valid.GlobalCfgStore
+}
+
+// Repo returns repo with the server's token removed from its clone URLs if
+// its tenant has its own credentials, so that git gets the tenant's token
+// from the credential helper.
+func (t *TenantCredentials) Repo(repo models.Repo) models.Repo {
+	tenant := t.tenant(repo)
+	if tenant == nil {
+		return repo
+	}
+	repo.CloneURL = withUser(repo.CloneURL, tenant.VCSUser)
+	repo.SanitizedCloneURL = withUser(repo.SanitizedCloneURL, tenant.VCSUser)
+	return repo
+}
+
+// GitCredentials returns creds with the credentials for the VCS host of repo
+// replaced by its tenant's if it has its own.
+func (t *TenantCredentials) GitCredentials(repo models.Repo, creds []GitCredentials) []GitCredentials {
+	tenant := t.tenant(repo)
+	if tenant == nil {
+		return creds
+	}
+	tenantCreds := GitCredentials{
+		URL:      "https://" + repo.VCSHost.Hostname,
+		Username: tenant.VCSUser,
+		Token:    tenant.VCSToken,
+	}
+	replaced := make([]GitCredentials, 0, len(creds)+1)
+	for _, c := range creds {
+		if u, err := url.Parse(c.URL); err == nil && u.Host == repo.VCSHost.Hostname {
+			tenantCreds.URL = c.URL
+			continue
+		}
+		replaced = append(replaced, c)
+	}
+	return append(replaced, tenantCreds)
+}
+
+// tenant returns the tenant of repo if it has its own credentials.
+func (t *TenantCredentials) tenant(repo models.Repo) *valid.Tenant {
+	if t == nil {
+		return nil
+	}
+	tenant := t.GlobalCfg.Get().Tenant(repo.ID())
+	if tenant == nil || tenant.VCSTokenFile == "" {
+		return nil
+	}
+	return tenant
+}
+
+// withUser returns cloneURL with its credentials replaced by user. It's
+// returned unchanged if it doesn't have credentials. The URL isn't parsed
+// since sanitized clone URLs have a password that isn't valid in a URL.
+func withUser(cloneURL string, user string) string {
+	schemeEnd := strings.Index(cloneURL, "://")
+	if schemeEnd == -1 {
+		return cloneURL
+	}
+	rest := cloneURL[schemeEnd+len("://"):]
+	at := strings.Index(rest, "@")
+	if at == -1 || strings.Contains(rest[:at], "/") {
+		return cloneURL
+	}
+	return cloneURL[:schemeEnd+len("://")] + url.User(user).String() + rest[at:]
+}
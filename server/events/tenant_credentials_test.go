@@ -0,0 +1,64 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestTenantCredentials(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	tokenFile := filepath.Join(tmp, "token")
+	Ok(t, os.WriteFile(tokenFile, []byte("tenant-token\n"), 0600))
+	tenantCreds := &events.TenantCredentials{
+		GlobalCfg: valid.NewGlobalCfgStore(valid.GlobalCfg{
+			Tenants: []valid.Tenant{
+				{Name: "payments", RepoIDs: []string{"github.com/payments/repo"}, VCSUser: "payments-bot", VCSTokenFile: tokenFile},
+				{Name: "lending", RepoIDs: []string{"github.com/lending/repo"}},
+			},
+		}),
+	}
+	serverCreds := []events.GitCredentials{
+		{URL: "https://github.com", Username: "atlantis", Token: events.StaticToken("server-token")},
+		{URL: "https://gitlab.com", Username: "atlantis", Token: events.StaticToken("gitlab-token")},
+	}
+	newRepo := func(fullName string) models.Repo {
+		repo, err := models.NewRepo(models.Github, fullName, "https://github.com/"+fullName+".git", "atlantis", "server-token")
+		Ok(t, err)
+		return repo
+	}
+
+	t.Run("tenant with credentials", func(t *testing.T) {
+		repo := tenantCreds.Repo(newRepo("payments/repo"))
+		Equals(t, "https://payments-bot@github.com/payments/repo.git", repo.CloneURL)
+		Equals(t, "https://payments-bot@github.com/payments/repo.git", repo.SanitizedCloneURL)
+
+		creds := tenantCreds.GitCredentials(repo, serverCreds)
+		Equals(t, 2, len(creds))
+		Equals(t, "https://gitlab.com", creds[0].URL)
+		Equals(t, "https://github.com", creds[1].URL)
+		Equals(t, "payments-bot", creds[1].Username)
+		token, err := creds[1].Token()
+		Ok(t, err)
+		Equals(t, "tenant-token", token)
+	})
+
+	t.Run("tenant without credentials", func(t *testing.T) {
+		repo := newRepo("lending/repo")
+		Equals(t, repo, tenantCreds.Repo(repo))
+		Equals(t, 2, len(tenantCreds.GitCredentials(repo, serverCreds)))
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var nilCreds *events.TenantCredentials
+		repo := newRepo("payments/repo")
+		Equals(t, repo, nilCreds.Repo(repo))
+		Equals(t, 2, len(nilCreds.GitCredentials(repo, serverCreds)))
+	})
+}
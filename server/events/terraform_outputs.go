@@ -0,0 +1,109 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// AllCommentOutputs in CommentOutputs posts all of a project's outputs that
+// aren't sensitive.
+const AllCommentOutputs = "*"
+
+// ParseTerraformOutputs parses the output of terraform output -json. The
+// values of sensitive outputs are dropped.
+func ParseTerraformOutputs(out string) (map[string]models.TerraformOutput, error) {
+	// Skip anything Terraform printed before the JSON, ex. warnings.
+	start := strings.Index(out, "{")
+	if start < 0 {
+		return nil, errors.New("terraform output didn't print JSON")
+	}
+	var outputs map[string]models.TerraformOutput
+	if err := json.Unmarshal([]byte(out[start:]), &outputs); err != nil {
+		return nil, errors.Wrap(err, "parsing terraform output")
+	}
+	for name, output := range outputs {
+		if output.Sensitive {
+			output.Value = nil
+			outputs[name] = output
+		}
+	}
+	return outputs, nil
+}
+
+// captureOutputs saves the outputs of the project described by ctx after it's
+// applied so that they're recorded with the apply. The apply has already
+// succeeded so errors are only logged.
+func (p *DefaultProjectCommandRunner) captureOutputs(ctx command.ProjectContext, absPath string, envs map[string]string) {
+	if p.OutputStepRunner == nil {
+		return
+	}
+	out, err := p.OutputStepRunner.Run(ctx, nil, absPath, envs)
+	if err != nil {
+		ctx.Log.Warn("unable to capture outputs: %s", err)
+		return
+	}
+	outputs, err := ParseTerraformOutputs(out)
+	if err != nil {
+		ctx.Log.Warn("unable to capture outputs: %s", err)
+		return
+	}
+	contents, err := json.Marshal(outputs)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(absPath, ctx.GetOutputsFileName()), contents, 0600)
+	}
+	if err != nil {
+		ctx.Log.Warn("unable to save outputs: %s", err)
+	}
+}
+
+// readOutputs returns the outputs that captureOutputs saved for the project
+// described by ctx, or nil if there aren't any.
+func readOutputs(ctx command.ProjectContext, absPath string) map[string]models.TerraformOutput {
+	contents, err := os.ReadFile(filepath.Join(absPath, ctx.GetOutputsFileName())) // nolint: gosec
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ctx.Log.Warn("unable to read outputs: %s", err)
+		}
+		return nil
+	}
+	var outputs map[string]models.TerraformOutput
+	if err := json.Unmarshal(contents, &outputs); err != nil {
+		ctx.Log.Warn("unable to read outputs: %s", err)
+		return nil
+	}
+	return outputs
+}
+
+// renderCommentOutputs returns the outputs named in allowlist, sorted by
+// name, to post in the apply comment, ex. `vpc_id = "vpc-123"`. Sensitive
+// outputs are never posted. It returns an empty string if none of the
+// outputs are allowed.
+func renderCommentOutputs(outputs map[string]models.TerraformOutput, allowlist []string) string {
+	allowed := make(map[string]bool)
+	for _, name := range allowlist {
+		allowed[name] = true
+	}
+	var names []string
+	for name, output := range outputs {
+		if !output.Sensitive && (allowed[name] || allowed[AllCommentOutputs]) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s = %s", name, outputs[name].Value))
+	}
+	return "\nOutputs published by Atlantis:\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,83 @@
+package events_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const outputJSON = `{
+  "password": {"sensitive": true, "type": "string", "value": "hunter2"},
+  "subnets": {"sensitive": false, "type": ["list", "string"], "value": ["a", "b"]},
+  "vpc_id": {"sensitive": false, "type": "string", "value": "vpc-123"}
+}`
+
+func TestParseTerraformOutputs(t *testing.T) {
+	outputs, err := events.ParseTerraformOutputs("Warning: something\n" + outputJSON)
+	Ok(t, err)
+	Equals(t, 3, len(outputs))
+	Assert(t, outputs["password"].Sensitive, "exp password to be sensitive")
+	Assert(t, outputs["password"].Value == nil, "exp sensitive value to be dropped")
+	Equals(t, `"vpc-123"`, string(outputs["vpc_id"].Value))
+	Equals(t, `["list", "string"]`, string(outputs["subnets"].Type))
+
+	_, err = events.ParseTerraformOutputs("Error: no state")
+	ErrEquals(t, "terraform output didn't print JSON", err)
+}
+
+// Test that outputs are captured after an apply and that the allowed ones are
+// posted in the apply comment.
+func TestDefaultProjectCommandRunner_ApplyCapturesOutputs(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockApply := mocks.NewMockStepRunner()
+	mockOutput := mocks.NewMockStepRunner()
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	runner := events.DefaultProjectCommandRunner{
+		ApplyStepRunner:  mockApply,
+		OutputStepRunner: mockOutput,
+		CommentOutputs:   []string{"vpc_id", "password"},
+		WorkingDir:       mockWorkingDir,
+		Webhooks:         mocks.NewMockWebhooksSender(),
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		AggregateApplyRequirements: &events.AggregateApplyRequirements{
+			WorkingDir: mockWorkingDir,
+		},
+	}
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.GetWorkingDir(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())).ThenReturn(repoDir, nil)
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Steps:      []valid.Step{{StepName: "apply"}},
+		Workspace:  "default",
+		RepoRelDir: ".",
+	}
+	When(mockApply.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("Apply complete!", nil)
+	When(mockOutput.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn(outputJSON, nil)
+
+	res := runner.Apply(ctx)
+	Equals(t, "", res.Failure)
+	Ok(t, res.Error)
+	Equals(t, "Apply complete!\n\nOutputs published by Atlantis:\nvpc_id = \"vpc-123\"", res.ApplySuccess)
+	Equals(t, map[string]models.TerraformOutput{
+		"password": {Sensitive: true, Type: json.RawMessage(`"string"`)},
+		"subnets":  {Type: json.RawMessage(`["list","string"]`), Value: json.RawMessage(`["a","b"]`)},
+		"vpc_id":   {Type: json.RawMessage(`"string"`), Value: json.RawMessage(`"vpc-123"`)},
+	}, res.Outputs)
+
+	// Outputs aren't kept for a failed apply.
+	When(mockApply.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("", errors.New("apply failed"))
+	res = runner.Apply(ctx)
+	Assert(t, res.Error != nil, "exp apply to fail")
+	Assert(t, res.Outputs == nil, "exp no outputs")
+}
@@ -0,0 +1,194 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+)
+
+// defaultRegistryHost is the registry that provider and module sources
+// without a hostname are installed from.
+const defaultRegistryHost = "registry.terraform.io"
+
+// TerraformRegistry looks up the versions of providers and modules.
+type TerraformRegistry interface {
+	// LatestProviderVersion returns the latest version of the provider with
+	// source address source, ex. registry.terraform.io/hashicorp/aws.
+	// Pre-releases are ignored.
+	LatestProviderVersion(source string) (*version.Version, error)
+	// LatestModuleVersion returns the latest version of the registry module
+	// with source address source, ex. terraform-aws-modules/vpc/aws.
+	// Pre-releases are ignored.
+	LatestModuleVersion(source string) (*version.Version, error)
+}
+
+// HTTPTerraformRegistry implements TerraformRegistry with the registry
+// protocol. The API of each registry host is found with Terraform's service
+// discovery. Only registries that can be read without credentials are
+// supported.
+type HTTPTerraformRegistry struct {
+	Client *http.Client
+
+	mu sync.Mutex
+	// services are the discovered services of each host, keyed by host and
+	// then by service, ex. "providers.v1".
+	services map[string]map[string]interface{}
+}
+
+func (r *HTTPTerraformRegistry) LatestProviderVersion(source string) (*version.Version, error) {
+	parts := strings.Split(source, "/")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid provider source %q", source)
+	}
+	var resp struct {
+		Versions []struct {
+			Version string `json:"version"`
+		} `json:"versions"`
+	}
+	if err := r.get(parts[0], "providers.v1", parts[1]+"/"+parts[2]+"/versions", &resp); err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, v := range resp.Versions {
+		versions = append(versions, v.Version)
+	}
+	return latestVersion(source, versions)
+}
+
+func (r *HTTPTerraformRegistry) LatestModuleVersion(source string) (*version.Version, error) {
+	host, path, ok := parseRegistryModuleSource(source)
+	if !ok {
+		return nil, fmt.Errorf("%q isn't a registry module", source)
+	}
+	var resp struct {
+		Modules []struct {
+			Versions []struct {
+				Version string `json:"version"`
+			} `json:"versions"`
+		} `json:"modules"`
+	}
+	if err := r.get(host, "modules.v1", path+"/versions", &resp); err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, m := range resp.Modules {
+		for _, v := range m.Versions {
+			versions = append(versions, v.Version)
+		}
+	}
+	return latestVersion(source, versions)
+}
+
+// get decodes the JSON response of the request to path under service on host.
+func (r *HTTPTerraformRegistry) get(host string, service string, path string, v interface{}) error {
+	base, err := r.discover(host, service)
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return errors.Wrapf(err, "parsing %s URL of %s", service, host)
+	}
+	u, err = u.Parse(path)
+	if err != nil {
+		return errors.Wrapf(err, "parsing %s URL of %s", service, host)
+	}
+	return r.getJSON(u.String(), v)
+}
+
+// discover returns the base URL of service on host.
+func (r *HTTPTerraformRegistry) discover(host string, service string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	services, ok := r.services[host]
+	if !ok {
+		wellKnown := "https://" + host + "/.well-known/terraform.json"
+		if err := r.getJSON(wellKnown, &services); err != nil {
+			return "", errors.Wrapf(err, "discovering services of %s", host)
+		}
+		if r.services == nil {
+			r.services = make(map[string]map[string]interface{})
+		}
+		r.services[host] = services
+	}
+	// Some services, ex. login.v1, aren't URLs.
+	base, ok := services[service].(string)
+	if !ok {
+		return "", fmt.Errorf("%s doesn't support %s", host, service)
+	}
+	// Services can be relative to the discovery document.
+	u, err := url.Parse("https://" + host + "/.well-known/terraform.json")
+	if err != nil {
+		return "", err
+	}
+	rel, err := u.Parse(base)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing %s URL of %s", service, host)
+	}
+	return rel.String(), nil
+}
+
+func (r *HTTPTerraformRegistry) getJSON(url string, v interface{}) error {
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(url) // nolint: gosec
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return errors.Wrapf(err, "decoding response of GET %s", url)
+	}
+	return nil
+}
+
+// latestVersion returns the latest of versions that isn't a pre-release.
+func latestVersion(source string, versions []string) (*version.Version, error) {
+	var latest *version.Version
+	for _, raw := range versions {
+		v, err := version.NewVersion(raw)
+		if err != nil || v.Prerelease() != "" {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no versions of %s found", source)
+	}
+	return latest, nil
+}
+
+// parseRegistryModuleSource returns the registry host of a module source and
+// the module's namespace/name/provider path. ok is false if the source isn't
+// a registry module, ex. a local path or a git URL.
+func parseRegistryModuleSource(source string) (host string, path string, ok bool) {
+	if strings.HasPrefix(source, ".") || strings.HasPrefix(source, "/") || strings.Contains(source, "::") || strings.Contains(source, "://") {
+		return "", "", false
+	}
+	// Registry modules can be in a subdir of the package, ex.
+	// hashicorp/consul/aws//modules/consul-cluster.
+	if i := strings.Index(source, "//"); i != -1 {
+		source = source[:i]
+	}
+	parts := strings.Split(source, "/")
+	switch {
+	case len(parts) == 3 && !strings.Contains(parts[0], "."):
+		return defaultRegistryHost, source, true
+	case len(parts) == 4 && strings.Contains(parts[0], ".") && parts[0] != "github.com" && parts[0] != "bitbucket.org":
+		return parts[0], strings.Join(parts[1:], "/"), true
+	}
+	return "", "", false
+}
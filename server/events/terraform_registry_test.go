@@ -0,0 +1,45 @@
+package events_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestHTTPTerraformRegistry(t *testing.T) {
+	responses := map[string]string{
+		"/.well-known/terraform.json":                         `{"login.v1": {"client": "terraform-cli"}, "modules.v1": "/api/modules/", "providers.v1": "/api/providers/"}`,
+		"/api/providers/hashicorp/aws/versions":               `{"versions": [{"version": "4.67.0"}, {"version": "5.31.0"}, {"version": "6.0.0-beta1"}, {"version": "5.9.0"}]}`,
+		"/api/modules/terraform-aws-modules/vpc/aws/versions": `{"modules": [{"versions": [{"version": "3.14.0"}, {"version": "5.4.0"}]}]}`,
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, resp) // nolint: errcheck
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+	registry := &events.HTTPTerraformRegistry{Client: server.Client()}
+
+	v, err := registry.LatestProviderVersion(host + "/hashicorp/aws")
+	Ok(t, err)
+	Equals(t, "5.31.0", v.String())
+
+	v, err = registry.LatestModuleVersion(host + "/terraform-aws-modules/vpc/aws//modules/vpc-endpoints")
+	Ok(t, err)
+	Equals(t, "5.4.0", v.String())
+
+	_, err = registry.LatestProviderVersion(host + "/hashicorp/missing")
+	ErrEquals(t, fmt.Sprintf("GET %s/api/providers/hashicorp/missing/versions returned 404 Not Found", server.URL), err)
+
+	_, err = registry.LatestModuleVersion("git::https://example.com/vpc.git")
+	ErrEquals(t, `"git::https://example.com/vpc.git" isn't a registry module`, err)
+}
@@ -3,17 +3,20 @@ package events
 import (
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/i18n"
 )
 
 func NewUnlockCommandRunner(
 	deleteLockCommand DeleteLockCommand,
 	vcsClient vcs.Client,
 	SilenceNoProjects bool,
+	catalog *i18n.Catalog,
 ) *UnlockCommandRunner {
 	return &UnlockCommandRunner{
 		deleteLockCommand: deleteLockCommand,
 		vcsClient:         vcsClient,
 		SilenceNoProjects: SilenceNoProjects,
+		catalog:           catalog,
 	}
 }
 
@@ -23,6 +26,8 @@ type UnlockCommandRunner struct {
 	// SilenceNoProjects is whether Atlantis should respond to PRs if no projects
 	// are found
 	SilenceNoProjects bool
+	// catalog translates the comments. If nil, they're in English.
+	catalog *i18n.Catalog
 }
 
 func (u *UnlockCommandRunner) Run(
@@ -32,10 +37,10 @@ func (u *UnlockCommandRunner) Run(
 	baseRepo := ctx.Pull.BaseRepo
 	pullNum := ctx.Pull.Num
 
-	vcsMessage := "All Atlantis locks for this PR have been unlocked and plans discarded"
+	vcsMessage := u.catalog.T("lock.unlocked")
 	numLocks, err := u.deleteLockCommand.DeleteLocksByPull(baseRepo.FullName, pullNum)
 	if err != nil {
-		vcsMessage = "Failed to delete PR locks"
+		vcsMessage = u.catalog.T("lock.unlock_failed")
 		ctx.Log.Err("failed to delete locks by pull %s", err.Error())
 	}
 
@@ -130,7 +130,126 @@ func (g *AzureDevopsClient) CreateComment(repo models.Repo, pullNum int, comment
 	return nil
 }
 
-func (g *AzureDevopsClient) HidePrevCommandComments(repo models.Repo, pullNum int, command string) error {
+// HidePrevCommandComments closes the threads Atlantis previously started for
+// command which collapses them in the Azure DevOps UI.
+// Azure DevOps API docs: https://docs.microsoft.com/en-us/rest/api/azure/devops/git/pull%20request%20threads/update
+func (g *AzureDevopsClient) HidePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
+	threads, err := g.prevCommandThreads(repo, pullNum, command, dir)
+	if err != nil {
+		return err
+	}
+	owner, project, repoName := SplitAzureDevopsRepoFullName(repo.FullName)
+	for _, thread := range threads {
+		if thread.GetStatus() == "closed" {
+			continue
+		}
+		u := fmt.Sprintf("%s/%s/_apis/git/repositories/%s/pullrequests/%d/threads/%d?api-version=5.1",
+			owner, project, repoName, pullNum, thread.GetID())
+		req, err := g.Client.NewRequest("PATCH", u, azuredevops.GitPullRequestCommentThread{Status: azuredevops.String("closed")})
+		if err != nil {
+			return errors.Wrap(err, "creating request")
+		}
+		if _, err := g.Client.Execute(g.ctx, req, nil); err != nil {
+			return errors.Wrapf(err, "closing thread %d", thread.GetID())
+		}
+	}
+	return nil
+}
+
+// DeletePrevCommandComments deletes the comments that started the threads
+// Atlantis previously started for command.
+// Azure DevOps API docs: https://docs.microsoft.com/en-us/rest/api/azure/devops/git/pull%20request%20thread%20comments/delete
+func (g *AzureDevopsClient) DeletePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
+	threads, err := g.prevCommandThreads(repo, pullNum, command, dir)
+	if err != nil {
+		return err
+	}
+	owner, project, repoName := SplitAzureDevopsRepoFullName(repo.FullName)
+	for _, thread := range threads {
+		u := fmt.Sprintf("%s/%s/_apis/git/repositories/%s/pullrequests/%d/threads/%d/comments/%d?api-version=5.1",
+			owner, project, repoName, pullNum, thread.GetID(), thread.Comments[0].GetID())
+		req, err := g.Client.NewRequest("DELETE", u, nil)
+		if err != nil {
+			return errors.Wrap(err, "creating request")
+		}
+		if _, err := g.Client.Execute(g.ctx, req, nil); err != nil {
+			return errors.Wrapf(err, "deleting comment in thread %d", thread.GetID())
+		}
+	}
+	return nil
+}
+
+// prevCommandThreads returns the threads on pullNum whose first comment was
+// left by the Atlantis user for command and dir.
+func (g *AzureDevopsClient) prevCommandThreads(repo models.Repo, pullNum int, command string, dir string) ([]*azuredevops.GitPullRequestCommentThread, error) {
+	return g.userThreads(repo, pullNum, func(body string) bool {
+		return common.IsPrevCommandComment(body, command, dir)
+	})
+}
+
+// userThreads returns the threads on pullNum whose first comment was left by
+// the Atlantis user and matches.
+// Azure DevOps API docs: https://docs.microsoft.com/en-us/rest/api/azure/devops/git/pull%20request%20threads/list
+func (g *AzureDevopsClient) userThreads(repo models.Repo, pullNum int, match func(body string) bool) ([]*azuredevops.GitPullRequestCommentThread, error) {
+	owner, project, repoName := SplitAzureDevopsRepoFullName(repo.FullName)
+	u := fmt.Sprintf("%s/%s/_apis/git/repositories/%s/pullrequests/%d/threads?api-version=5.1",
+		owner, project, repoName, pullNum)
+	req, err := g.Client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+	var list struct {
+		Value []*azuredevops.GitPullRequestCommentThread `json:"value"`
+	}
+	if _, err := g.Client.Execute(g.ctx, req, &list); err != nil {
+		return nil, errors.Wrap(err, "listing threads")
+	}
+
+	var threads []*azuredevops.GitPullRequestCommentThread
+	for _, thread := range list.Value {
+		if thread.GetIsDeleted() || len(thread.Comments) == 0 {
+			continue
+		}
+		first := thread.Comments[0]
+		if first.GetIsDeleted() || !strings.EqualFold(first.GetAuthor().GetUniqueName(), g.UserName) {
+			continue
+		}
+		if !match(first.GetContent()) {
+			continue
+		}
+		threads = append(threads, thread)
+	}
+	return threads, nil
+}
+
+func (g *AzureDevopsClient) ReactToComment(repo models.Repo, pullNum int, commentID int64, reaction models.CommentReaction) error {
+	return nil
+}
+
+// UpsertComment edits the comment that started the thread Atlantis
+// previously started containing marker or creates it if there isn't one.
+// Azure DevOps API docs: https://docs.microsoft.com/en-us/rest/api/azure/devops/git/pull%20request%20thread%20comments/update
+func (g *AzureDevopsClient) UpsertComment(repo models.Repo, pullNum int, comment string, marker string) error {
+	threads, err := g.userThreads(repo, pullNum, func(body string) bool {
+		return strings.Contains(body, marker)
+	})
+	if err != nil {
+		return err
+	}
+	if len(threads) == 0 {
+		return g.CreateComment(repo, pullNum, comment, "")
+	}
+	thread := threads[0]
+	owner, project, repoName := SplitAzureDevopsRepoFullName(repo.FullName)
+	u := fmt.Sprintf("%s/%s/_apis/git/repositories/%s/pullrequests/%d/threads/%d/comments/%d?api-version=5.1",
+		owner, project, repoName, pullNum, thread.GetID(), thread.Comments[0].GetID())
+	req, err := g.Client.NewRequest("PATCH", u, azuredevops.Comment{Content: &comment})
+	if err != nil {
+		return errors.Wrap(err, "creating request")
+	}
+	if _, err := g.Client.Execute(g.ctx, req, nil); err != nil {
+		return errors.Wrapf(err, "updating comment in thread %d", thread.GetID())
+	}
 	return nil
 }
 
@@ -167,7 +286,7 @@ func (g *AzureDevopsClient) PullIsApproved(repo models.Repo, pull models.PullReq
 }
 
 // PullIsMergeable returns true if the merge request can be merged.
-func (g *AzureDevopsClient) PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string) (bool, error) {
+func (g *AzureDevopsClient) PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string, ignoredChecks []string) (bool, error) {
 	owner, project, repoName := SplitAzureDevopsRepoFullName(repo.FullName)
 
 	opts := azuredevops.PullRequestGetOptions{IncludeWorkItemRefs: true}
@@ -351,6 +470,11 @@ func (g *AzureDevopsClient) MergePull(pull models.PullRequest, pullOptions model
 	return nil
 }
 
+// CreateOrUpdatePull is not yet supported for Azure DevOps.
+func (g *AzureDevopsClient) CreateOrUpdatePull(repo models.Repo, head string, base string, title string, body string) (models.PullRequest, error) {
+	return models.PullRequest{}, fmt.Errorf("not implemented")
+}
+
 // MarkdownPullLink specifies the string used in a pull request comment to reference another pull request.
 func (g *AzureDevopsClient) MarkdownPullLink(pull models.PullRequest) (string, error) {
 	return fmt.Sprintf("!%d", pull.Num), nil
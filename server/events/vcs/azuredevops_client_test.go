@@ -414,7 +414,7 @@ func TestAzureDevopsClient_PullIsMergeable(t *testing.T) {
 				},
 			}, models.PullRequest{
 				Num: 1,
-			}, "atlantis-test")
+			}, "atlantis-test", nil)
 			Ok(t, err)
 			Equals(t, c.expMergeable, actMergeable)
 		})
@@ -555,6 +555,64 @@ func TestAzureDevopsClient_GetPullRequest(t *testing.T) {
 	})
 }
 
+func TestAzureDevopsClient_UpsertComment(t *testing.T) {
+	// The comment of thread 3 should be edited because it's the Atlantis
+	// user's thread with the marker. Thread 1 has the marker but is by someone
+	// else.
+	threadsResp := `{"value": [
+	{"id": 1, "comments": [{"id": 1, "content": "<!-- marker -->", "author": {"uniqueName": "someone-else"}}]},
+	{"id": 2, "comments": [{"id": 1, "content": "Ran Plan for dir: x", "author": {"uniqueName": "user"}}]},
+	{"id": 3, "comments": [{"id": 1, "content": "<!-- marker -->\nold", "author": {"uniqueName": "user"}}]}
+]}`
+	var gotRequests []string
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.RequestURI {
+			case "GET /owner/project/_apis/git/repositories/repo/pullrequests/1/threads?api-version=5.1":
+				w.Write([]byte(threadsResp)) // nolint: errcheck
+				return
+			case "PATCH /owner/project/_apis/git/repositories/repo/pullrequests/1/threads/3/comments/1?api-version=5.1":
+				body, err := io.ReadAll(r.Body)
+				Ok(t, err)
+				gotRequests = append(gotRequests, r.Method+" "+strings.TrimSpace(string(body)))
+				w.Write([]byte("{}")) // nolint: errcheck
+				return
+			case "POST /owner/project/_apis/git/repositories/repo/pullrequests/1/threads?api-version=5.1-preview.1":
+				gotRequests = append(gotRequests, r.Method)
+				w.Write([]byte("{}")) // nolint: errcheck
+				return
+			default:
+				t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewAzureDevopsClient(testServerURL.Host, "user", "token")
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	repo := models.Repo{
+		FullName: "owner/project/repo",
+		Owner:    "owner",
+		Name:     "repo",
+		VCSHost: models.VCSHost{
+			Type:     models.AzureDevops,
+			Hostname: "dev.azure.com",
+		},
+	}
+	err = client.UpsertComment(repo, 1, "<!-- marker -->\nnew", "<!-- marker -->")
+	Ok(t, err)
+	Equals(t, []string{`PATCH {"content":"<!-- marker -->\nnew"}`}, gotRequests)
+
+	// Without a thread with the marker, one is created.
+	gotRequests = nil
+	err = client.UpsertComment(repo, 1, "<!-- other -->\nnew", "<!-- other -->")
+	Ok(t, err)
+	Equals(t, []string{"POST"}, gotRequests)
+}
+
 func TestAzureDevopsClient_MarkdownPullLink(t *testing.T) {
 	client, err := vcs.NewAzureDevopsClient("hostname", "user", "token")
 	Ok(t, err)
@@ -6,17 +6,23 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"unicode/utf8"
 
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/common"
+	"golang.org/x/oauth2"
 	validator "gopkg.in/go-playground/validator.v9"
 )
 
 type Client struct {
-	HTTPClient  *http.Client
-	Username    string
-	Password    string
+	HTTPClient *http.Client
+	Username   string
+	Password   string
+	// TokenSource is set if the client authenticates with access tokens
+	// instead of Username and Password.
+	TokenSource oauth2.TokenSource
 	BaseURL     string
 	AtlantisURL string
 }
@@ -38,6 +44,15 @@ func NewClient(httpClient *http.Client, username string, password string, atlant
 	}
 }
 
+// NewClientWithTokenSource builds a bitbucket cloud client that authenticates
+// with the access tokens from tokenSource, ex. an OAuth consumer's or a
+// workspace access token, instead of an app password.
+func NewClientWithTokenSource(httpClient *http.Client, tokenSource oauth2.TokenSource, atlantisURL string) *Client {
+	client := NewClient(httpClient, "", "", atlantisURL)
+	client.TokenSource = tokenSource
+	return client
+}
+
 // GetModifiedFiles returns the names of files that were modified in the merge request
 // relative to the repo root, e.g. parent/child/file.txt.
 func (b *Client) GetModifiedFiles(repo models.Repo, pull models.PullRequest) ([]string, error) {
@@ -100,10 +115,110 @@ func (b *Client) CreateComment(repo models.Repo, pullNum int, comment string, co
 	return err
 }
 
-func (b *Client) HidePrevCommandComments(repo models.Repo, pullNum int, command string) error {
+// HidePrevCommandComments is a no-op since Bitbucket Cloud can't hide or
+// collapse comments. Use the delete superseded comments policy instead.
+func (b *Client) HidePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
 	return nil
 }
 
+// DeletePrevCommandComments deletes the comments Atlantis previously left for
+// command.
+func (b *Client) DeletePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
+	prevCommentIDs, err := b.userComments(repo, pullNum, func(body string) bool {
+		return common.IsPrevCommandComment(body, command, dir)
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range prevCommentIDs {
+		path := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d/comments/%d", b.BaseURL, repo.FullName, pullNum, id)
+		if _, err := b.makeRequest("DELETE", path, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// userComments returns the IDs of the comments the current user left on
+// pullNum whose body matches, oldest first.
+func (b *Client) userComments(repo models.Repo, pullNum int, match func(body string) bool) ([]int, error) {
+	resp, err := b.makeRequest("GET", fmt.Sprintf("%s/2.0/user", b.BaseURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	var user Actor
+	if err := json.Unmarshal(resp, &user); err != nil {
+		return nil, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	if err := validator.New().Struct(user); err != nil {
+		return nil, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+	}
+
+	var ids []int
+	nextPageURL := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d/comments", b.BaseURL, repo.FullName, pullNum)
+	// We'll only loop 1000 times as a safety measure.
+	maxLoops := 1000
+	for i := 0; i < maxLoops; i++ {
+		resp, err := b.makeRequest("GET", nextPageURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		var comments PullComments
+		if err := json.Unmarshal(resp, &comments); err != nil {
+			return nil, errors.Wrapf(err, "Could not parse response %q", string(resp))
+		}
+		if err := validator.New().Struct(comments); err != nil {
+			return nil, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+		}
+		for _, c := range comments.Values {
+			// Comments from deleted accounts don't have a user and other
+			// comments can be missing fields, so they're skipped.
+			if c.Deleted || c.ID == nil || c.User == nil || c.User.AccountID == nil || c.Content == nil || c.Content.Raw == nil {
+				continue
+			}
+			if *c.User.AccountID != *user.AccountID {
+				continue
+			}
+			if !match(*c.Content.Raw) {
+				continue
+			}
+			ids = append(ids, *c.ID)
+		}
+		if comments.Next == nil || *comments.Next == "" {
+			break
+		}
+		nextPageURL = *comments.Next
+	}
+	return ids, nil
+}
+
+func (b *Client) ReactToComment(repo models.Repo, pullNum int, commentID int64, reaction models.CommentReaction) error {
+	return nil
+}
+
+// UpsertComment edits the comment Atlantis previously left containing marker
+// or creates it if there isn't one.
+func (b *Client) UpsertComment(repo models.Repo, pullNum int, comment string, marker string) error {
+	ids, err := b.userComments(repo, pullNum, func(body string) bool {
+		return strings.Contains(body, marker)
+	})
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return b.CreateComment(repo, pullNum, comment, "")
+	}
+	bodyBytes, err := json.Marshal(map[string]map[string]string{"content": {
+		"raw": comment,
+	}})
+	if err != nil {
+		return errors.Wrap(err, "json encoding")
+	}
+	path := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d/comments/%d", b.BaseURL, repo.FullName, pullNum, ids[0])
+	_, err = b.makeRequest("PUT", path, bytes.NewBuffer(bodyBytes))
+	return err
+}
+
 // PullIsApproved returns true if the merge request was approved.
 func (b *Client) PullIsApproved(repo models.Repo, pull models.PullRequest) (approvalStatus models.ApprovalStatus, err error) {
 	path := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d", b.BaseURL, repo.FullName, pull.Num)
@@ -132,7 +247,7 @@ func (b *Client) PullIsApproved(repo models.Repo, pull models.PullRequest) (appr
 }
 
 // PullIsMergeable returns true if the merge request has no conflicts and can be merged.
-func (b *Client) PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string) (bool, error) {
+func (b *Client) PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string, ignoredChecks []string) (bool, error) {
 	nextPageURL := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d/diffstat", b.BaseURL, repo.FullName, pull.Num)
 	// We'll only loop 1000 times as a safety measure.
 	maxLoops := 1000
@@ -207,6 +322,11 @@ func (b *Client) MergePull(pull models.PullRequest, pullOptions models.PullReque
 	return err
 }
 
+// CreateOrUpdatePull is not yet supported for Bitbucket Cloud.
+func (b *Client) CreateOrUpdatePull(repo models.Repo, head string, base string, title string, body string) (models.PullRequest, error) {
+	return models.PullRequest{}, fmt.Errorf("not implemented")
+}
+
 // MarkdownPullLink specifies the character used in a pull request comment.
 func (b *Client) MarkdownPullLink(pull models.PullRequest) (string, error) {
 	return fmt.Sprintf("#%d", pull.Num), nil
@@ -218,7 +338,15 @@ func (b *Client) prepRequest(method string, path string, body io.Reader) (*http.
 	if err != nil {
 		return nil, err
 	}
-	req.SetBasicAuth(b.Username, b.Password)
+	if b.TokenSource != nil {
+		token, err := b.TokenSource.Token()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting access token")
+		}
+		token.SetAuthHeader(req)
+	} else {
+		req.SetBasicAuth(b.Username, b.Password)
+	}
 	if body != nil {
 		req.Header.Add("Content-Type", "application/json")
 	}
@@ -240,7 +368,7 @@ func (b *Client) makeRequest(method string, path string, reqBody io.Reader) ([]b
 	defer resp.Body.Close() // nolint: errcheck
 	requestStr := fmt.Sprintf("%s %s", method, path)
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
 		respBody, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("making request %q unexpected status code: %d, body: %s", requestStr, resp.StatusCode, string(respBody))
 	}
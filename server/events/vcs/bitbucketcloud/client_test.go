@@ -2,10 +2,12 @@ package bitbucketcloud_test
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -337,7 +339,7 @@ func TestClient_PullIsMergeable(t *testing.T) {
 				},
 			}, models.PullRequest{
 				Num: 1,
-			}, "atlantis-test")
+			}, "atlantis-test", nil)
 			Ok(t, err)
 			Equals(t, c.ExpMergeable, actMergeable)
 		})
@@ -345,6 +347,96 @@ func TestClient_PullIsMergeable(t *testing.T) {
 
 }
 
+func TestClient_DeletePrevCommandComments(t *testing.T) {
+	// Only comment 3 should be deleted because it's by the Atlantis user and
+	// has "plan" in the first line. Comments missing fields are skipped.
+	commentsResp := `{"values": [
+	{"id": 1, "content": {"raw": "Ran Plan for dir: x"}, "user": {"account_id": "someone-else"}},
+	{"id": 2, "content": {"raw": "Ran Apply for dir: x"}, "user": {"account_id": "atlantis"}},
+	{"id": 3, "content": {"raw": "Ran Plan for dir: x"}, "user": {"account_id": "atlantis"}},
+	{"id": 4, "content": {"raw": "Ran Plan for dir: x"}, "user": {"account_id": "atlantis"}, "deleted": true},
+	{"id": 5, "content": {"raw": "Ran Plan for dir: x"}},
+	{"id": 6, "content": {"raw": "Ran Plan for dir: x"}, "user": {}},
+	{"id": 7, "user": {"account_id": "atlantis"}}
+]}`
+	var gotDeletes []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.RequestURI {
+		case "GET /2.0/user":
+			w.Write([]byte(`{"account_id": "atlantis"}`)) // nolint: errcheck
+			return
+		case "GET /2.0/repositories/owner/repo/pullrequests/1/comments":
+			w.Write([]byte(commentsResp)) // nolint: errcheck
+			return
+		case "DELETE /2.0/repositories/owner/repo/pullrequests/1/comments/3":
+			gotDeletes = append(gotDeletes, r.RequestURI)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		default:
+			t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+	}))
+	defer testServer.Close()
+
+	client := bitbucketcloud.NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
+	client.BaseURL = testServer.URL
+
+	repo, err := models.NewRepo(models.BitbucketCloud, "owner/repo", "https://bitbucket.org/owner/repo.git", "user", "token")
+	Ok(t, err)
+	err = client.DeletePrevCommandComments(repo, 1, "Plan", "")
+	Ok(t, err)
+	Equals(t, []string{"/2.0/repositories/owner/repo/pullrequests/1/comments/3"}, gotDeletes)
+}
+
+func TestClient_UpsertComment(t *testing.T) {
+	// Comment 3 should be edited because it's the Atlantis user's comment
+	// with the marker. Comment 1 has the marker but is by someone else.
+	commentsResp := `{"values": [
+	{"id": 1, "content": {"raw": "<!-- marker -->"}, "user": {"account_id": "someone-else"}},
+	{"id": 2, "content": {"raw": "Ran Plan for dir: x"}, "user": {"account_id": "atlantis"}},
+	{"id": 3, "content": {"raw": "<!-- marker -->\nold"}, "user": {"account_id": "atlantis"}}
+]}`
+	var gotRequests []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.RequestURI {
+		case "GET /2.0/user":
+			w.Write([]byte(`{"account_id": "atlantis"}`)) // nolint: errcheck
+			return
+		case "GET /2.0/repositories/owner/repo/pullrequests/1/comments":
+			w.Write([]byte(commentsResp)) // nolint: errcheck
+			return
+		case "PUT /2.0/repositories/owner/repo/pullrequests/1/comments/3",
+			"POST /2.0/repositories/owner/repo/pullrequests/1/comments":
+			body, err := io.ReadAll(r.Body)
+			Ok(t, err)
+			gotRequests = append(gotRequests, r.Method+" "+r.RequestURI+" "+string(body))
+			return
+		default:
+			t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+	}))
+	defer testServer.Close()
+
+	client := bitbucketcloud.NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
+	client.BaseURL = testServer.URL
+
+	repo, err := models.NewRepo(models.BitbucketCloud, "owner/repo", "https://bitbucket.org/owner/repo.git", "user", "token")
+	Ok(t, err)
+	err = client.UpsertComment(repo, 1, "<!-- marker -->\nnew", "<!-- marker -->")
+	Ok(t, err)
+	Equals(t, []string{`PUT /2.0/repositories/owner/repo/pullrequests/1/comments/3 {"content":{"raw":"\u003c!-- marker --\u003e\nnew"}}`}, gotRequests)
+
+	// Without a comment with the marker, one is created.
+	gotRequests = nil
+	err = client.UpsertComment(repo, 1, "<!-- other -->\nnew", "<!-- other -->")
+	Ok(t, err)
+	Equals(t, []string{`POST /2.0/repositories/owner/repo/pullrequests/1/comments {"content":{"raw":"\u003c!-- other --\u003e\nnew"}}`}, gotRequests)
+}
+
 func TestClient_MarkdownPullLink(t *testing.T) {
 	client := bitbucketcloud.NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
 	pull := models.PullRequest{Num: 1}
@@ -352,3 +444,56 @@ func TestClient_MarkdownPullLink(t *testing.T) {
 	exp := "#1"
 	Equals(t, exp, s)
 }
+
+// Should authenticate with the workspace access token instead of the app
+// password.
+func TestClient_WorkspaceTokenAuth(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "Bearer workspace-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"values": []}`)) // nolint: errcheck
+	}))
+	defer testServer.Close()
+
+	client := bitbucketcloud.NewClientWithTokenSource(http.DefaultClient, bitbucketcloud.NewWorkspaceTokenSource("workspace-token"), "runatlantis.io")
+	client.BaseURL = testServer.URL
+	_, err := client.GetModifiedFiles(models.Repo{FullName: "owner/repo"}, models.PullRequest{Num: 1})
+	Ok(t, err)
+}
+
+// Should request an access token for the OAuth consumer with the client
+// credentials grant and reuse it until it expires.
+func TestNewOAuthTokenSource(t *testing.T) {
+	tokenRequests := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "/site/oauth2/access_token", r.URL.Path)
+		id, secret, _ := r.BasicAuth()
+		Equals(t, "client-id", id)
+		Equals(t, "client-secret", secret)
+		Ok(t, r.ParseForm())
+		Equals(t, "client_credentials", r.PostForm.Get("grant_type"))
+		tokenRequests++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "oauth-token", "token_type": "bearer", "expires_in": 7200}`)) // nolint: errcheck
+	}))
+	defer testServer.Close()
+
+	// Send the requests for bitbucket.org to the test server.
+	httpClient := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		r.URL.Scheme = "http"
+		r.URL.Host = strings.TrimPrefix(testServer.URL, "http://")
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	tokenSource := bitbucketcloud.NewOAuthTokenSource(httpClient, "client-id", "client-secret")
+	for i := 0; i < 2; i++ {
+		token, err := tokenSource.Token()
+		Ok(t, err)
+		Equals(t, "oauth-token", token.AccessToken)
+	}
+	Equals(t, 1, tokenRequests)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -83,6 +83,16 @@ type Comment struct {
 type CommentContent struct {
 	Raw *string `json:"raw,omitempty" validate:"required"`
 }
+type PullComments struct {
+	Values []PullComment `json:"values,omitempty"`
+	Next   *string       `json:"next,omitempty"`
+}
+type PullComment struct {
+	ID      *int            `json:"id,omitempty" validate:"required"`
+	Content *CommentContent `json:"content,omitempty" validate:"required"`
+	User    *Actor          `json:"user,omitempty" validate:"required"`
+	Deleted bool            `json:"deleted,omitempty"`
+}
 type Author struct {
 	UUID *string `json:"uuid,omitempty" validate:"required"`
 }
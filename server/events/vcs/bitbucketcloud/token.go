@@ -0,0 +1,40 @@
+package bitbucketcloud
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/bitbucket"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// TokenAuthUser is the username that git authenticates to Bitbucket Cloud
+// with when using an access token instead of an app password.
+// See https://support.atlassian.com/bitbucket-cloud/docs/using-access-tokens/
+const TokenAuthUser = "x-token-auth"
+
+// NewOAuthTokenSource returns a token source for the access tokens of the
+// OAuth consumer with clientID and clientSecret. It uses the client
+// credentials grant so no user interaction is needed. Tokens are cached and
+// a new one is requested when the current one expires, which is after two
+// hours. httpClient is used to request the tokens.
+func NewOAuthTokenSource(httpClient *http.Client, clientID string, clientSecret string) oauth2.TokenSource {
+	config := clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     bitbucket.Endpoint.TokenURL,
+		AuthStyle:    oauth2.AuthStyleInHeader,
+	}
+	ctx := context.Background()
+	if httpClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	}
+	return config.TokenSource(ctx)
+}
+
+// NewWorkspaceTokenSource returns a token source for a workspace, project or
+// repository access token. These don't expire unless they're revoked.
+func NewWorkspaceTokenSource(token string) oauth2.TokenSource {
+	return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token, TokenType: "Bearer"})
+}
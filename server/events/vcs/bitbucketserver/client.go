@@ -145,10 +145,74 @@ func (b *Client) CreateComment(repo models.Repo, pullNum int, comment string, co
 	return nil
 }
 
-func (b *Client) HidePrevCommandComments(repo models.Repo, pullNum int, command string) error {
+// HidePrevCommandComments is a no-op since Bitbucket Server can't hide or
+// collapse comments. Use the delete superseded comments policy instead.
+func (b *Client) HidePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
 	return nil
 }
 
+// DeletePrevCommandComments deletes the comments Atlantis previously left for
+// command.
+func (b *Client) DeletePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
+	projectKey, err := b.GetProjectKey(repo.Name, repo.SanitizedCloneURL)
+	if err != nil {
+		return err
+	}
+	pullURL := fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d", b.BaseURL, projectKey, repo.Name, pullNum)
+
+	// Comments are deleted by ID and must include the version we're deleting.
+	prevCommentVersions := make(map[int]int)
+	nextPageStart := 0
+	// We'll only loop 1000 times as a safety measure.
+	maxLoops := 1000
+	for i := 0; i < maxLoops; i++ {
+		resp, err := b.makeRequest("GET", fmt.Sprintf("%s/activities?start=%d", pullURL, nextPageStart), nil)
+		if err != nil {
+			return err
+		}
+		var activities Activities
+		if err := json.Unmarshal(resp, &activities); err != nil {
+			return errors.Wrapf(err, "Could not parse response %q", string(resp))
+		}
+		if err := validator.New().Struct(activities); err != nil {
+			return errors.Wrapf(err, "API response %q was missing fields", string(resp))
+		}
+		for _, v := range activities.Values {
+			if *v.Action != "COMMENTED" || v.Comment == nil {
+				continue
+			}
+			if !strings.EqualFold(*v.Comment.Author.Username, b.Username) {
+				continue
+			}
+			if !common.IsPrevCommandComment(*v.Comment.Text, command, dir) {
+				continue
+			}
+			prevCommentVersions[*v.Comment.ID] = *v.Comment.Version
+		}
+		if *activities.IsLastPage {
+			break
+		}
+		nextPageStart = *activities.NextPageStart
+	}
+
+	for id, version := range prevCommentVersions {
+		if _, err := b.makeRequest("DELETE", fmt.Sprintf("%s/comments/%d?version=%d", pullURL, id, version), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Client) ReactToComment(repo models.Repo, pullNum int, commentID int64, reaction models.CommentReaction) error {
+	return nil
+}
+
+// UpsertComment always creates a new comment since we don't support editing
+// comments on this VCS host.
+func (b *Client) UpsertComment(repo models.Repo, pullNum int, comment string, marker string) error {
+	return b.CreateComment(repo, pullNum, comment, "")
+}
+
 // postComment actually posts the comment. It's a helper for CreateComment().
 func (b *Client) postComment(repo models.Repo, pullNum int, comment string) error {
 	bodyBytes, err := json.Marshal(map[string]string{"text": comment})
@@ -193,7 +257,7 @@ func (b *Client) PullIsApproved(repo models.Repo, pull models.PullRequest) (appr
 }
 
 // PullIsMergeable returns true if the merge request has no conflicts and can be merged.
-func (b *Client) PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string) (bool, error) {
+func (b *Client) PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string, ignoredChecks []string) (bool, error) {
 	projectKey, err := b.GetProjectKey(repo.Name, repo.SanitizedCloneURL)
 	if err != nil {
 		return false, err
@@ -289,6 +353,11 @@ func (b *Client) MergePull(pull models.PullRequest, pullOptions models.PullReque
 	return err
 }
 
+// CreateOrUpdatePull is not yet supported for Bitbucket Server.
+func (b *Client) CreateOrUpdatePull(repo models.Repo, head string, base string, title string, body string) (models.PullRequest, error) {
+	return models.PullRequest{}, fmt.Errorf("not implemented")
+}
+
 // MarkdownPullLink specifies the character used in a pull request comment.
 func (b *Client) MarkdownPullLink(pull models.PullRequest) (string, error) {
 	return fmt.Sprintf("#%d", pull.Num), nil
@@ -333,6 +402,12 @@ func (b *Client) makeRequest(method string, path string, reqBody io.Reader) ([]b
 	return respBody, nil
 }
 
+// CheckHealth gets the user that Atlantis authenticates as.
+func (b *Client) CheckHealth() error {
+	_, err := b.makeRequest("GET", fmt.Sprintf("%s/rest/api/1.0/users/%s", b.BaseURL, url.PathEscape(b.Username)), nil)
+	return err
+}
+
 // GetTeamNamesForUser returns the names of the teams or groups that the user belongs to (in the organization the repository belongs to).
 func (b *Client) GetTeamNamesForUser(repo models.Repo, user models.User) ([]string, error) {
 	return nil, nil
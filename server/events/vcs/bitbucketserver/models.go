@@ -72,6 +72,20 @@ type Changes struct {
 	IsLastPage    *bool `json:"isLastPage,omitempty" validate:"required"`
 }
 
+type Activities struct {
+	Values []struct {
+		Action  *string `json:"action,omitempty" validate:"required"`
+		Comment *struct {
+			ID      *int    `json:"id,omitempty" validate:"required"`
+			Version *int    `json:"version,omitempty" validate:"required"`
+			Text    *string `json:"text,omitempty" validate:"required"`
+			Author  *Actor  `json:"author,omitempty" validate:"required"`
+		} `json:"comment,omitempty"`
+	} `json:"values,omitempty" validate:"required"`
+	NextPageStart *int  `json:"nextPageStart,omitempty"`
+	IsLastPage    *bool `json:"isLastPage,omitempty" validate:"required"`
+}
+
 type MergeStatus struct {
 	CanMerge   *bool `json:"canMerge,omitempty" validate:"required"`
 	Conflicted *bool `json:"conflicted,omitempty" validate:"required"`
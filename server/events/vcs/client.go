@@ -25,9 +25,25 @@ type Client interface {
 	// relative to the repo root, e.g. parent/child/file.txt.
 	GetModifiedFiles(repo models.Repo, pull models.PullRequest) ([]string, error)
 	CreateComment(repo models.Repo, pullNum int, comment string, command string) error
-	HidePrevCommandComments(repo models.Repo, pullNum int, command string) error
+	// HidePrevCommandComments hides the comments Atlantis previously left for
+	// command on pullNum. If dir is set, only comments for dir are hidden.
+	HidePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error
+	// DeletePrevCommandComments is like HidePrevCommandComments except it
+	// deletes the comments.
+	DeletePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error
+	// ReactToComment adds reaction to the comment with id commentID on pullNum.
+	// VCS hosts that don't support reactions should return nil.
+	ReactToComment(repo models.Repo, pullNum int, commentID int64, reaction models.CommentReaction) error
+	// UpsertComment edits the comment Atlantis previously left on pullNum
+	// that contains marker, or creates a new comment if there isn't one. VCS
+	// hosts that don't support editing comments always create a new one.
+	UpsertComment(repo models.Repo, pullNum int, comment string, marker string) error
 	PullIsApproved(repo models.Repo, pull models.PullRequest) (models.ApprovalStatus, error)
-	PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string) (bool, error)
+	// PullIsMergeable returns true if pull is mergeable. The commit statuses
+	// and checks named in ignoredChecks don't need to pass for pull to be
+	// mergeable, names ending in * match any name with that prefix. VCS hosts
+	// that can't evaluate individual checks ignore ignoredChecks.
+	PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string, ignoredChecks []string) (bool, error)
 	// UpdateStatus updates the commit status to state for pull. src is the
 	// source of this status. This should be relatively static across runs,
 	// ex. atlantis/plan or atlantis/apply.
@@ -37,6 +53,10 @@ type Client interface {
 	// about this status.
 	UpdateStatus(repo models.Repo, pull models.PullRequest, state models.CommitStatus, src string, description string, url string) error
 	MergePull(pull models.PullRequest, pullOptions models.PullRequestOptions) error
+	// CreateOrUpdatePull opens a pull request in repo from the head branch
+	// into the base branch. If one is already open it updates its title and
+	// body instead.
+	CreateOrUpdatePull(repo models.Repo, head string, base string, title string, body string) (models.PullRequest, error)
 	MarkdownPullLink(pull models.PullRequest) (string, error)
 	GetTeamNamesForUser(repo models.Repo, user models.User) ([]string, error)
 
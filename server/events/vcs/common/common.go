@@ -4,12 +4,46 @@ package common
 
 import (
 	"math"
+	"strings"
 )
 
 // AutomergeCommitMsg is the commit message Atlantis will use when automatically
 // merging pull requests.
 const AutomergeCommitMsg = "[Atlantis] Automatically merging after successful apply"
 
+// SupersededCommentSummary is the summary of the collapsed block that VCS
+// clients without native comment hiding wrap superseded comments in.
+const SupersededCommentSummary = "Superseded by a newer comment"
+
+// IsPrevCommandComment returns true if comment looks like one Atlantis left
+// for command, and for dir if dir is set.
+// This is crude filtering: the comment templates include the command name
+// and, for single project comments, the dir in the first line. Callers
+// should have already filtered the comments by the Atlantis user.
+func IsPrevCommandComment(comment string, command string, dir string) bool {
+	firstLine := strings.ToLower(strings.SplitN(comment, "\n", 2)[0])
+	if !strings.Contains(firstLine, strings.ToLower(command)) {
+		return false
+	}
+	return dir == "" || strings.Contains(firstLine, "`"+strings.ToLower(dir)+"`")
+}
+
+// IsIgnoredCheck returns true if the commit status or check called name is in
+// ignoredChecks. Names in ignoredChecks that end in * match any name that
+// starts with the rest of the name.
+func IsIgnoredCheck(name string, ignoredChecks []string) bool {
+	for _, ignored := range ignoredChecks {
+		if prefix := strings.TrimSuffix(ignored, "*"); prefix != ignored {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == ignored {
+			return true
+		}
+	}
+	return false
+}
+
 // SplitComment splits comment into a slice of comments that are under maxSize.
 // It appends sepEnd to all comments that have a following comment.
 // It prepends sepStart to all comments that have a preceding comment.
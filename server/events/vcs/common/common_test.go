@@ -61,3 +61,45 @@ func TestSplitComment_FourComments(t *testing.T) {
 		sepStart + comment[expMax*2:expMax*3] + sepEnd,
 		sepStart + comment[expMax*3:]}, split)
 }
+
+func TestIsPrevCommandComment(t *testing.T) {
+	cases := []struct {
+		comment string
+		command string
+		dir     string
+		exp     bool
+	}{
+		{"Ran Plan for dir: `.` workspace: `default`\n", "Plan", "", true},
+		{"ran plan for 2 projects:\n", "Plan", "", true},
+		{"Ran Apply for dir: `.` workspace: `default`\n", "Plan", "", false},
+		{"some text\nRan Plan for dir: `.`", "Plan", "", false},
+		{"Ran Plan for dir: `staging` workspace: `default`\n", "Plan", "staging", true},
+		{"Ran Plan for dir: `staging/app` workspace: `default`\n", "Plan", "staging", false},
+		{"Ran Plan for 2 projects:\n\n1. dir: `staging`", "Plan", "staging", false},
+	}
+	for _, c := range cases {
+		t.Run(c.comment, func(t *testing.T) {
+			Equals(t, c.exp, common.IsPrevCommandComment(c.comment, c.command, c.dir))
+		})
+	}
+}
+
+func TestIsIgnoredCheck(t *testing.T) {
+	ignoredChecks := []string{"atlantis/apply*", "flaky"}
+	cases := []struct {
+		name string
+		exp  bool
+	}{
+		{"atlantis/apply", true},
+		{"atlantis/apply: project1", true},
+		{"atlantis/plan", false},
+		{"flaky", true},
+		{"flaky-integration", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			Equals(t, c.exp, common.IsIgnoredCheck(c.name, ignoredChecks))
+		})
+	}
+	Equals(t, false, common.IsIgnoredCheck("flaky", nil))
+}
@@ -0,0 +1,278 @@
+package gerrit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/common"
+)
+
+// maxCommentLength is the maximum number of chars allowed by Gerrit in a
+// single review message by default, see change.commentSizeLimit.
+const maxCommentLength = 16384
+
+// xssiPrefix is prepended to JSON responses by Gerrit to prevent them being
+// used in cross-site scripting attacks.
+const xssiPrefix = ")]}'"
+
+// messageTag tags the review messages Atlantis posts so that Gerrit's UI can
+// filter them out along with other automated messages.
+const messageTag = "autogenerated:atlantis"
+
+type Client struct {
+	HTTPClient *http.Client
+	Username   string
+	Password   string
+	BaseURL    string
+}
+
+// NewClient builds a Gerrit client. Returns an error if the baseURL is
+// malformed. httpClient is the client to use to make the requests, username
+// and password are the HTTP credentials of the Gerrit account Atlantis runs
+// as and baseURL is Gerrit's URL, ex. https://gerrit.corp.com.
+func NewClient(httpClient *http.Client, username string, password string, baseURL string) (*Client, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", baseURL)
+	}
+	if parsedURL.Scheme == "" {
+		return nil, fmt.Errorf("must have 'http://' or 'https://' in base url %q", baseURL)
+	}
+	return &Client{
+		HTTPClient: httpClient,
+		Username:   username,
+		Password:   password,
+		BaseURL:    strings.TrimRight(parsedURL.String(), "/"),
+	}, nil
+}
+
+// GetModifiedFiles returns the names of files that were modified in the
+// change's patch set relative to the repo root, e.g. parent/child/file.txt.
+func (c *Client) GetModifiedFiles(repo models.Repo, pull models.PullRequest) ([]string, error) {
+	var files map[string]FileInfo
+	if err := c.getJSON(fmt.Sprintf("%s/files", c.revisionPath(pull)), &files); err != nil {
+		return nil, err
+	}
+	var modified []string
+	for name, file := range files {
+		// Gerrit includes magic files like /COMMIT_MSG that aren't in the
+		// repo.
+		if strings.HasPrefix(name, "/") {
+			continue
+		}
+		modified = append(modified, name)
+
+		// If the file was renamed, we'll want to run plan in the directory
+		// it was moved from as well.
+		if file.OldPath != "" {
+			modified = append(modified, file.OldPath)
+		}
+	}
+	sort.Strings(modified)
+	return modified, nil
+}
+
+// CreateComment posts comment as a review message on the change. It will
+// post multiple messages if a single message is too long.
+func (c *Client) CreateComment(repo models.Repo, pullNum int, comment string, command string) error {
+	sepEnd := "\n```\n**Warning**: Output length greater than max comment size. Continued in next comment."
+	sepStart := "Continued from previous comment.\n```diff\n"
+	comments := common.SplitComment(comment, maxCommentLength, sepEnd, sepStart)
+	for _, msg := range comments {
+		body := map[string]string{"message": msg, "tag": messageTag}
+		if err := c.postJSON(fmt.Sprintf("/a/changes/%d/revisions/current/review", pullNum), body, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HidePrevCommandComments is a no-op since Gerrit can't hide review
+// messages.
+func (c *Client) HidePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
+	return nil
+}
+
+// DeletePrevCommandComments is a no-op since only Gerrit administrators can
+// delete review messages.
+func (c *Client) DeletePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
+	return nil
+}
+
+func (c *Client) ReactToComment(repo models.Repo, pullNum int, commentID int64, reaction models.CommentReaction) error {
+	return nil
+}
+
+// UpsertComment always creates a new comment since Gerrit review messages
+// can't be edited.
+func (c *Client) UpsertComment(repo models.Repo, pullNum int, comment string, marker string) error {
+	return c.CreateComment(repo, pullNum, comment, "")
+}
+
+// PullIsApproved returns true if the change's Code-Review label has its
+// maximum vote, ex. +2, and no veto.
+func (c *Client) PullIsApproved(repo models.Repo, pull models.PullRequest) (models.ApprovalStatus, error) {
+	change, err := c.getChange(pull.Num)
+	if err != nil {
+		return models.ApprovalStatus{}, err
+	}
+	label, ok := change.Labels[CodeReviewLabel]
+	return models.ApprovalStatus{
+		IsApproved: ok && label.Approved != nil && label.Rejected == nil,
+	}, nil
+}
+
+// PullIsMergeable returns true if the change's Verified label has its
+// maximum vote and no veto. If the project doesn't have a Verified label,
+// the change is mergeable if it can be submitted. Merged changes are always
+// mergeable so that they can be applied on submit.
+func (c *Client) PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string, ignoredChecks []string) (bool, error) {
+	change, err := c.getChange(pull.Num)
+	if err != nil {
+		return false, err
+	}
+	if change.Status == "MERGED" {
+		return true, nil
+	}
+	if change.Status != "NEW" {
+		return false, nil
+	}
+	if label, ok := change.Labels[VerifiedLabel]; ok {
+		return label.Approved != nil && label.Rejected == nil, nil
+	}
+	return change.Submittable, nil
+}
+
+// UpdateStatus is a no-op since Gerrit doesn't have commit statuses. The
+// results of commands are posted as review messages instead.
+func (c *Client) UpdateStatus(repo models.Repo, pull models.PullRequest, state models.CommitStatus, src string, description string, url string) error {
+	return nil
+}
+
+// MergePull submits the change.
+func (c *Client) MergePull(pull models.PullRequest, pullOptions models.PullRequestOptions) error {
+	return c.postJSON(fmt.Sprintf("/a/changes/%d/submit", pull.Num), struct{}{}, nil)
+}
+
+// CreateOrUpdatePull is not supported for Gerrit since changes are created
+// by pushing to refs/for/<branch>.
+func (c *Client) CreateOrUpdatePull(repo models.Repo, head string, base string, title string, body string) (models.PullRequest, error) {
+	return models.PullRequest{}, fmt.Errorf("not implemented")
+}
+
+// MarkdownPullLink returns the change's URL since Gerrit doesn't link change
+// numbers in review messages.
+func (c *Client) MarkdownPullLink(pull models.PullRequest) (string, error) {
+	if pull.URL != "" {
+		return pull.URL, nil
+	}
+	return fmt.Sprintf("%s/c/%s/+/%d", c.BaseURL, pull.BaseRepo.FullName, pull.Num), nil
+}
+
+// CheckHealth gets the account that Atlantis authenticates as.
+func (c *Client) CheckHealth() error {
+	return c.getJSON("/a/accounts/self", nil)
+}
+
+// GetTeamNamesForUser returns the names of the Gerrit groups that user is a
+// member of.
+func (c *Client) GetTeamNamesForUser(repo models.Repo, user models.User) ([]string, error) {
+	var groups []GroupInfo
+	if err := c.getJSON(fmt.Sprintf("/a/accounts/%s/groups", url.PathEscape(user.Username)), &groups); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+	return names, nil
+}
+
+func (c *Client) SupportsSingleFileDownload(repo models.Repo) bool {
+	return false
+}
+
+// DownloadRepoConfigFile return `atlantis.yaml` content from VCS (which support fetch a single file from repository)
+// The first return value indicate that repo contain atlantis.yaml or not
+// if BaseRepo had one repo config file, its content will placed on the second return value
+func (c *Client) DownloadRepoConfigFile(pull models.PullRequest) (bool, []byte, error) {
+	return false, []byte{}, fmt.Errorf("not implemented")
+}
+
+func (c *Client) GetCloneURL(VCSHostType models.VCSHostType, repo string) (string, error) {
+	return "", fmt.Errorf("not yet implemented")
+}
+
+// revisionPath returns the API path of pull's patch set.
+func (c *Client) revisionPath(pull models.PullRequest) string {
+	revision := pull.HeadCommit
+	if revision == "" {
+		revision = "current"
+	}
+	return fmt.Sprintf("/a/changes/%d/revisions/%s", pull.Num, revision)
+}
+
+func (c *Client) getChange(num int) (ChangeInfo, error) {
+	var change ChangeInfo
+	err := c.getJSON(fmt.Sprintf("/a/changes/%d?o=LABELS&o=SUBMITTABLE", num), &change)
+	return change, err
+}
+
+func (c *Client) getJSON(path string, v interface{}) error {
+	return c.makeRequest("GET", path, nil, v)
+}
+
+func (c *Client) postJSON(path string, body interface{}, v interface{}) error {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return errors.Wrap(err, "json encoding")
+	}
+	return c.makeRequest("POST", path, bytes.NewBuffer(bodyBytes), v)
+}
+
+// makeRequest makes an authenticated request to path and, if v isn't nil,
+// decodes the JSON response into v.
+func (c *Client) makeRequest(method string, path string, reqBody io.Reader, v interface{}) error {
+	req, err := http.NewRequest(method, c.BaseURL+path, reqBody)
+	if err != nil {
+		return errors.Wrap(err, "constructing request")
+	}
+	req.SetBasicAuth(c.Username, c.Password)
+	req.Header.Add("Accept", "application/json")
+	if reqBody != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	requestStr := fmt.Sprintf("%s %s", method, path)
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "reading response from request %q", requestStr)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("making request %q unexpected status code: %d, body: %s", requestStr, resp.StatusCode, string(respBody))
+	}
+	if v == nil {
+		return nil
+	}
+	respBody = bytes.TrimPrefix(respBody, []byte(xssiPrefix))
+	if err := json.Unmarshal(respBody, v); err != nil {
+		return errors.Wrapf(err, "Could not parse response %q", string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package gerrit_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/gerrit"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// Test that we include the base path in our base url.
+func TestClient_BasePath(t *testing.T) {
+	cases := []struct {
+		inputURL string
+		expURL   string
+		expErr   string
+	}{
+		{
+			inputURL: "mycompany.com",
+			expErr:   `must have 'http://' or 'https://' in base url "mycompany.com"`,
+		},
+		{
+			inputURL: "https://mycompany.com/",
+			expURL:   "https://mycompany.com",
+		},
+		{
+			inputURL: "https://mycompany.com/gerrit/",
+			expURL:   "https://mycompany.com/gerrit",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.inputURL, func(t *testing.T) {
+			client, err := gerrit.NewClient(nil, "u", "p", c.inputURL)
+			if c.expErr != "" {
+				ErrEquals(t, c.expErr, err)
+			} else {
+				Ok(t, err)
+				Equals(t, c.expURL, client.BaseURL)
+			}
+		})
+	}
+}
+
+func TestClient_GetModifiedFiles(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/a/changes/123/revisions/abc123/files":
+			user, pass, _ := r.BasicAuth()
+			Equals(t, "user", user)
+			Equals(t, "pass", pass)
+			w.Write([]byte(`)]}'
+{
+  "/COMMIT_MSG": {"status": "A"},
+  "main.tf": {},
+  "new/main.tf": {"status": "R", "old_path": "old/main.tf"}
+}`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client, err := gerrit.NewClient(nil, "user", "pass", testServer.URL)
+	Ok(t, err)
+	files, err := client.GetModifiedFiles(models.Repo{}, models.PullRequest{Num: 123, HeadCommit: "abc123"})
+	Ok(t, err)
+	Equals(t, []string{"main.tf", "new/main.tf", "old/main.tf"}, files)
+}
+
+func TestClient_CreateComment(t *testing.T) {
+	var got map[string]string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.RequestURI {
+		case "POST /a/changes/123/revisions/current/review":
+			body, err := io.ReadAll(r.Body)
+			Ok(t, err)
+			Ok(t, json.Unmarshal(body, &got))
+			w.Write([]byte(`)]}'
+{}`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client, err := gerrit.NewClient(nil, "user", "pass", testServer.URL)
+	Ok(t, err)
+	Ok(t, client.CreateComment(models.Repo{}, 123, "Ran Plan", "plan"))
+	Equals(t, map[string]string{"message": "Ran Plan", "tag": "autogenerated:atlantis"}, got)
+}
+
+func TestClient_PullIsApprovedAndMergeable(t *testing.T) {
+	cases := []struct {
+		description  string
+		change       string
+		expApproved  bool
+		expMergeable bool
+	}{
+		{
+			"no votes",
+			`{"status": "NEW", "labels": {"Code-Review": {}, "Verified": {}}}`,
+			false,
+			false,
+		},
+		{
+			"approved and verified",
+			`{"status": "NEW", "labels": {"Code-Review": {"approved": {"username": "a"}}, "Verified": {"approved": {"username": "ci"}}}}`,
+			true,
+			true,
+		},
+		{
+			"vetoed",
+			`{"status": "NEW", "labels": {"Code-Review": {"approved": {"username": "a"}, "rejected": {"username": "b"}}, "Verified": {"approved": {"username": "ci"}, "rejected": {"username": "ci2"}}}}`,
+			false,
+			false,
+		},
+		{
+			"no verified label uses submittable",
+			`{"status": "NEW", "submittable": true, "labels": {"Code-Review": {"approved": {"username": "a"}}}}`,
+			true,
+			true,
+		},
+		{
+			"merged",
+			`{"status": "MERGED", "labels": {"Code-Review": {"approved": {"username": "a"}}, "Verified": {}}}`,
+			true,
+			true,
+		},
+		{
+			"abandoned",
+			`{"status": "ABANDONED", "submittable": true, "labels": {}}`,
+			false,
+			false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.RequestURI {
+				case "/a/changes/123?o=LABELS&o=SUBMITTABLE":
+					w.Write([]byte(")]}'\n" + c.change)) // nolint: errcheck
+				default:
+					t.Errorf("got unexpected request at %q", r.RequestURI)
+					http.Error(w, "not found", http.StatusNotFound)
+				}
+			}))
+			defer testServer.Close()
+
+			client, err := gerrit.NewClient(nil, "user", "pass", testServer.URL)
+			Ok(t, err)
+			pull := models.PullRequest{Num: 123}
+			approved, err := client.PullIsApproved(models.Repo{}, pull)
+			Ok(t, err)
+			Equals(t, c.expApproved, approved.IsApproved)
+			mergeable, err := client.PullIsMergeable(models.Repo{}, pull, "atlantis", nil)
+			Ok(t, err)
+			Equals(t, c.expMergeable, mergeable)
+		})
+	}
+}
+
+func TestClient_GetTeamNamesForUser(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/a/accounts/jdoe/groups":
+			w.Write([]byte(`)]}'
+[{"name": "Administrators"}, {"name": "infra"}]`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client, err := gerrit.NewClient(nil, "user", "pass", testServer.URL)
+	Ok(t, err)
+	teams, err := client.GetTeamNamesForUser(models.Repo{}, models.User{Username: "jdoe"})
+	Ok(t, err)
+	Equals(t, []string{"Administrators", "infra"}, teams)
+}
@@ -0,0 +1,93 @@
+package gerrit
+
+// Event types that Gerrit's webhooks plugin sends.
+const (
+	PatchsetCreatedEvent = "patchset-created"
+	CommentAddedEvent    = "comment-added"
+	ChangeMergedEvent    = "change-merged"
+	ChangeAbandonedEvent = "change-abandoned"
+	ChangeDeletedEvent   = "change-deleted"
+)
+
+// Labels that Atlantis uses as apply requirements.
+const (
+	CodeReviewLabel = "Code-Review"
+	VerifiedLabel   = "Verified"
+)
+
+// EventType is the part of every event that identifies its type.
+type EventType struct {
+	Type *string `json:"type,omitempty" validate:"required"`
+}
+
+// ChangeEvent is an event about a change, ex. patchset-created or
+// change-merged.
+type ChangeEvent struct {
+	Type     *string   `json:"type,omitempty" validate:"required"`
+	Change   *Change   `json:"change,omitempty" validate:"required"`
+	PatchSet *PatchSet `json:"patchSet,omitempty" validate:"required"`
+	// The account that caused the event is in a different field for each
+	// event type.
+	Uploader  *Account `json:"uploader,omitempty"`
+	Submitter *Account `json:"submitter,omitempty"`
+	Abandoner *Account `json:"abandoner,omitempty"`
+	Deleter   *Account `json:"deleter,omitempty"`
+}
+
+// CommentEvent is a comment-added event.
+type CommentEvent struct {
+	Type     *string   `json:"type,omitempty" validate:"required"`
+	Change   *Change   `json:"change,omitempty" validate:"required"`
+	PatchSet *PatchSet `json:"patchSet,omitempty" validate:"required"`
+	Author   *Account  `json:"author,omitempty" validate:"required"`
+	Comment  *string   `json:"comment,omitempty" validate:"required"`
+}
+
+type Change struct {
+	Project *string  `json:"project,omitempty" validate:"required"`
+	Branch  *string  `json:"branch,omitempty" validate:"required"`
+	Number  *int     `json:"number,omitempty" validate:"required"`
+	Status  *string  `json:"status,omitempty" validate:"required"`
+	URL     *string  `json:"url,omitempty"`
+	Owner   *Account `json:"owner,omitempty" validate:"required"`
+}
+
+type PatchSet struct {
+	Number   *int    `json:"number,omitempty" validate:"required"`
+	Revision *string `json:"revision,omitempty" validate:"required"`
+	Ref      *string `json:"ref,omitempty" validate:"required"`
+}
+
+type Account struct {
+	Username *string `json:"username,omitempty" validate:"required"`
+}
+
+// ChangeInfo is the part of the REST API's ChangeInfo entity that Atlantis
+// uses.
+type ChangeInfo struct {
+	Status      string               `json:"status"`
+	Submittable bool                 `json:"submittable"`
+	Labels      map[string]LabelInfo `json:"labels"`
+}
+
+// LabelInfo is a label's votes on a change. Approved and Rejected are set if
+// the label has its maximum or minimum vote.
+type LabelInfo struct {
+	Approved *AccountInfo `json:"approved"`
+	Rejected *AccountInfo `json:"rejected"`
+}
+
+type AccountInfo struct {
+	Username string `json:"username"`
+}
+
+// FileInfo is a file modified by a revision.
+type FileInfo struct {
+	Status  string `json:"status"`
+	OldPath string `json:"old_path"`
+}
+
+// GroupInfo is a group an account is a member of.
+type GroupInfo struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,34 @@
+package vcs
+
+import "github.com/runatlantis/atlantis/server/events/models"
+
+// BranchProtectionClient reads and updates the status checks that a
+// protected branch requires to pass before pull requests can be merged.
+type BranchProtectionClient interface {
+	// GetRequiredStatusChecks returns the contexts of the status checks that
+	// branch requires. It errors if branch doesn't require status checks.
+	GetRequiredStatusChecks(repo models.Repo, branch string) ([]string, error)
+	SetRequiredStatusChecks(repo models.Repo, branch string, contexts []string) error
+}
+
+// GithubBranchProtectionClientProxy uses the client for the repo's hostname,
+// or Default if there isn't one.
+type GithubBranchProtectionClientProxy struct {
+	Default BranchProtectionClient
+	Hosts   map[string]BranchProtectionClient
+}
+
+func (g *GithubBranchProtectionClientProxy) client(repo models.Repo) BranchProtectionClient {
+	if client, ok := g.Hosts[repo.VCSHost.Hostname]; ok {
+		return client
+	}
+	return g.Default
+}
+
+func (g *GithubBranchProtectionClientProxy) GetRequiredStatusChecks(repo models.Repo, branch string) ([]string, error) {
+	return g.client(repo).GetRequiredStatusChecks(repo, branch)
+}
+
+func (g *GithubBranchProtectionClientProxy) SetRequiredStatusChecks(repo models.Repo, branch string, contexts []string) error {
+	return g.client(repo).SetRequiredStatusChecks(repo, branch, contexts)
+}
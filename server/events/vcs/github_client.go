@@ -43,6 +43,9 @@ type GithubClient struct {
 	ctx      context.Context
 	logger   logging.SimpleLogging
 	config   GithubConfig
+	// serverVersion is used to avoid features that GitHub Enterprise Server
+	// doesn't have.
+	serverVersion *githubVersionTransport
 }
 
 // GithubAppTemporarySecrets holds app credentials obtained from github after creation.
@@ -65,6 +68,13 @@ func NewGithubClient(hostname string, credentials GithubCredentials, config Gith
 	if err != nil {
 		return nil, errors.Wrap(err, "error initializing github authentication transport")
 	}
+	serverVersion := &githubVersionTransport{base: transport.Transport}
+	if serverVersion.base == nil {
+		serverVersion.base = http.DefaultTransport
+	}
+	versionedTransport := *transport
+	versionedTransport.Transport = serverVersion
+	transport = &versionedTransport
 
 	var graphqlURL string
 	var client *github.Client
@@ -96,6 +106,8 @@ func NewGithubClient(hostname string, credentials GithubCredentials, config Gith
 		ctx:      context.Background(),
 		logger:   logger,
 		config:   config,
+
+		serverVersion: serverVersion,
 	}, nil
 }
 
@@ -181,7 +193,106 @@ func (g *GithubClient) CreateComment(repo models.Repo, pullNum int, comment stri
 	return nil
 }
 
-func (g *GithubClient) HidePrevCommandComments(repo models.Repo, pullNum int, command string) error {
+// githubReactions maps our reactions to GitHub's reaction content. GitHub
+// doesn't have check mark reactions so we use thumbs up/down on completion.
+var githubReactions = map[models.CommentReaction]string{
+	models.SeenCommentReaction:    "eyes",
+	models.StartedCommentReaction: "rocket",
+	models.SuccessCommentReaction: "+1",
+	models.FailedCommentReaction:  "-1",
+}
+
+// ReactToComment adds a reaction to an issue comment.
+func (g *GithubClient) ReactToComment(repo models.Repo, pullNum int, commentID int64, reaction models.CommentReaction) error {
+	g.logger.Debug("POST /repos/%v/%v/issues/comments/%d/reactions", repo.Owner, repo.Name, commentID)
+	_, _, err := g.client.Reactions.CreateIssueCommentReaction(g.ctx, repo.Owner, repo.Name, commentID, githubReactions[reaction])
+	return err
+}
+
+// HidePrevCommandComments minimizes the comments Atlantis previously left
+// for command as outdated.
+func (g *GithubClient) HidePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
+	comments, err := g.prevCommandComments(repo, pullNum, command, dir)
+	if err != nil {
+		return err
+	}
+	if !g.serverVersion.supports(githubMinimizeCommentVersion) {
+		g.logger.Warn("not hiding previous comments: GitHub Enterprise Server %s or later is required to minimize comments", githubMinimizeCommentVersion)
+		return nil
+	}
+	for _, comment := range comments {
+		var m struct {
+			MinimizeComment struct {
+				MinimizedComment struct {
+					IsMinimized       githubv4.Boolean
+					MinimizedReason   githubv4.String
+					ViewerCanMinimize githubv4.Boolean
+				}
+			} `graphql:"minimizeComment(input:$input)"`
+		}
+		input := githubv4.MinimizeCommentInput{
+			Classifier: githubv4.ReportedContentClassifiersOutdated,
+			SubjectID:  comment.GetNodeID(),
+		}
+		if err := g.v4Client.Mutate(g.ctx, &m, input, nil); err != nil {
+			if isGraphQLSchemaErr(err) {
+				g.logger.Warn("not hiding previous comments: this GitHub version can't minimize comments: %s", err)
+				return nil
+			}
+			return errors.Wrapf(err, "minimize comment %s", comment.GetNodeID())
+		}
+	}
+
+	return nil
+}
+
+// DeletePrevCommandComments deletes the comments Atlantis previously left
+// for command.
+func (g *GithubClient) DeletePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
+	comments, err := g.prevCommandComments(repo, pullNum, command, dir)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		g.logger.Debug("DELETE /repos/%v/%v/issues/comments/%d", repo.Owner, repo.Name, comment.GetID())
+		if _, err := g.client.Issues.DeleteComment(g.ctx, repo.Owner, repo.Name, comment.GetID()); err != nil {
+			return errors.Wrapf(err, "delete comment %d", comment.GetID())
+		}
+	}
+	return nil
+}
+
+// UpsertComment edits the comment Atlantis previously left on pullNum that
+// contains marker, or creates a new comment if there isn't one.
+func (g *GithubClient) UpsertComment(repo models.Repo, pullNum int, comment string, marker string) error {
+	comments, err := g.userComments(repo, pullNum, func(body string) bool {
+		return strings.Contains(body, marker)
+	})
+	if err != nil {
+		return err
+	}
+	if len(comments) == 0 {
+		return g.CreateComment(repo, pullNum, comment, "")
+	}
+	id := comments[0].GetID()
+	g.logger.Debug("PATCH /repos/%v/%v/issues/comments/%d", repo.Owner, repo.Name, id)
+	if _, _, err := g.client.Issues.EditComment(g.ctx, repo.Owner, repo.Name, id, &github.IssueComment{Body: &comment}); err != nil {
+		return errors.Wrapf(err, "editing comment %d", id)
+	}
+	return nil
+}
+
+// prevCommandComments returns the comments the Atlantis user left for command
+// and dir on pullNum.
+func (g *GithubClient) prevCommandComments(repo models.Repo, pullNum int, command string, dir string) ([]*github.IssueComment, error) {
+	return g.userComments(repo, pullNum, func(body string) bool {
+		return common.IsPrevCommandComment(body, command, dir)
+	})
+}
+
+// userComments returns the comments the Atlantis user left on pullNum whose
+// body matches, oldest first.
+func (g *GithubClient) userComments(repo models.Repo, pullNum int, match func(body string) bool) ([]*github.IssueComment, error) {
 	var allComments []*github.IssueComment
 	nextPage := 0
 	for {
@@ -192,7 +303,7 @@ func (g *GithubClient) HidePrevCommandComments(repo models.Repo, pullNum int, co
 			ListOptions: github.ListOptions{Page: nextPage},
 		})
 		if err != nil {
-			return errors.Wrap(err, "listing comments")
+			return nil, errors.Wrap(err, "listing comments")
 		}
 		allComments = append(allComments, comments...)
 		if resp.NextPage == 0 {
@@ -201,6 +312,7 @@ func (g *GithubClient) HidePrevCommandComments(repo models.Repo, pullNum int, co
 		nextPage = resp.NextPage
 	}
 
+	var userComments []*github.IssueComment
 	for _, comment := range allComments {
 		// Using a case insensitive compare here because usernames aren't case
 		// sensitive and users may enter their atlantis users with different
@@ -208,37 +320,12 @@ func (g *GithubClient) HidePrevCommandComments(repo models.Repo, pullNum int, co
 		if comment.User != nil && !strings.EqualFold(comment.User.GetLogin(), g.user) {
 			continue
 		}
-		// Crude filtering: The comment templates typically include the command name
-		// somewhere in the first line. It's a bit of an assumption, but seems like
-		// a reasonable one, given we've already filtered the comments by the
-		// configured Atlantis user.
-		body := strings.Split(comment.GetBody(), "\n")
-		if len(body) == 0 {
-			continue
-		}
-		firstLine := strings.ToLower(body[0])
-		if !strings.Contains(firstLine, strings.ToLower(command)) {
+		if !match(comment.GetBody()) {
 			continue
 		}
-		var m struct {
-			MinimizeComment struct {
-				MinimizedComment struct {
-					IsMinimized       githubv4.Boolean
-					MinimizedReason   githubv4.String
-					ViewerCanMinimize githubv4.Boolean
-				}
-			} `graphql:"minimizeComment(input:$input)"`
-		}
-		input := githubv4.MinimizeCommentInput{
-			Classifier: githubv4.ReportedContentClassifiersOutdated,
-			SubjectID:  comment.GetNodeID(),
-		}
-		if err := g.v4Client.Mutate(g.ctx, &m, input, nil); err != nil {
-			return errors.Wrapf(err, "minimize comment %s", comment.GetNodeID())
-		}
+		userComments = append(userComments, comment)
 	}
-
-	return nil
+	return userComments, nil
 }
 
 // PullIsApproved returns true if the pull request was approved.
@@ -287,15 +374,34 @@ func isRequiredCheck(check string, required []string) bool {
 
 // GetCombinedStatusMinusApply checks Statuses for PR, excluding atlantis apply. Returns true if all other statuses are not in failure.
 func (g *GithubClient) GetCombinedStatusMinusApply(repo models.Repo, pull *github.PullRequest, vcstatusname string) (bool, error) {
+	return g.getCombinedStatusIgnoring(repo, pull, g.applyStatusMatcher(vcstatusname))
+}
+
+// applyStatusMatcher returns a func that returns true for the commit statuses
+// that are set by apply. Unless the config says how they're named, they're
+// expected to be named with the defaults of vcsstatusname.
+func (g *GithubClient) applyStatusMatcher(vcsstatusname string) func(name string, description string) bool {
+	if g.config.IsApplyStatus != nil {
+		return g.config.IsApplyStatus
+	}
+	return func(name string, _ string) bool {
+		return strings.HasPrefix(name, fmt.Sprintf("%s/%s", vcsstatusname, command.Apply.String()))
+	}
+}
+
+// getCombinedStatusIgnoring checks Statuses and required check runs for PR,
+// excluding the ones that ignored returns true for. Returns true if all other
+// statuses and required check runs succeeded.
+func (g *GithubClient) getCombinedStatusIgnoring(repo models.Repo, pull *github.PullRequest, ignored func(name string, description string) bool) (bool, error) {
 	//check combined status api
 	status, _, err := g.client.Repositories.GetCombinedStatus(g.ctx, repo.Owner, repo.Name, *pull.Head.Ref, nil)
 	if err != nil {
 		return false, errors.Wrap(err, "getting combined status")
 	}
 
-	//iterate over statuses - return false if we find one that isnt "apply" and doesnt have state = "success"
+	//iterate over statuses - return false if we find one that isnt ignored and doesnt have state = "success"
 	for _, r := range status.Statuses {
-		if strings.HasPrefix(*r.Context, fmt.Sprintf("%s/%s", vcstatusname, command.Apply.String())) {
+		if ignored(r.GetContext(), r.GetDescription()) {
 			continue
 		}
 		if *r.State != "success" {
@@ -309,6 +415,13 @@ func (g *GithubClient) GetCombinedStatusMinusApply(repo models.Repo, pull *githu
 		return false, errors.Wrap(err, "getting required status checks")
 	}
 
+	// The checks API isn't available on older GitHub Enterprise Server
+	// versions so only statuses can be checked.
+	if !g.serverVersion.supports(githubChecksVersion) {
+		g.logger.Debug("not checking check runs: GitHub Enterprise Server %s or later is required for the checks API", githubChecksVersion)
+		return true, nil
+	}
+
 	//check check suite/check run api
 	checksuites, _, err := g.client.Checks.ListCheckSuitesForRef(context.Background(), repo.Owner, repo.Name, *pull.Head.Ref, nil)
 	if err != nil {
@@ -325,6 +438,9 @@ func (g *GithubClient) GetCombinedStatusMinusApply(repo models.Repo, pull *githu
 			}
 
 			for _, r := range suite.CheckRuns {
+				if ignored(r.GetName(), r.GetOutput().GetSummary()) {
+					continue
+				}
 				//check to see if the check is required
 				if isRequiredCheck(*r.Name, required.RequiredStatusChecks.Contexts) {
 					if *c.Conclusion == "success" {
@@ -346,6 +462,9 @@ func (g *GithubClient) GetCombinedStatusMinusApply(repo models.Repo, pull *githu
 
 // GetPullReviewDecision gets the pull review decision, which takes into account CODEOWNERS
 func (g *GithubClient) GetPullReviewDecision(repo models.Repo, pull models.PullRequest) (approvalStatus bool, err error) {
+	if !g.serverVersion.supports(githubReviewDecisionVersion) {
+		return g.getPullReviewDecisionFromReviews(repo, pull)
+	}
 	var query struct {
 		Repository struct {
 			PullRequest struct {
@@ -361,6 +480,10 @@ func (g *GithubClient) GetPullReviewDecision(repo models.Repo, pull models.PullR
 	}
 
 	err = g.v4Client.Query(g.ctx, &query, variables)
+	if err != nil && isGraphQLSchemaErr(err) {
+		g.logger.Debug("reviewDecision isn't supported, using reviews instead: %s", err)
+		return g.getPullReviewDecisionFromReviews(repo, pull)
+	}
 	if err != nil {
 		return approvalStatus, errors.Wrap(err, "getting reviewDecision")
 	}
@@ -372,8 +495,50 @@ func (g *GithubClient) GetPullReviewDecision(repo models.Repo, pull models.PullR
 	return false, nil
 }
 
+// getPullReviewDecisionFromReviews approximates the pull request's
+// reviewDecision with the REST API for GitHub versions without it. Unlike
+// reviewDecision, it doesn't take CODEOWNERS into account.
+func (g *GithubClient) getPullReviewDecisionFromReviews(repo models.Repo, pull models.PullRequest) (bool, error) {
+	// Each reviewer's latest review that approved or requested changes
+	// counts.
+	latestReviews := make(map[string]string)
+	opts := github.ListOptions{PerPage: 100}
+	for {
+		reviews, resp, err := g.client.PullRequests.ListReviews(g.ctx, repo.Owner, repo.Name, pull.Num, &opts)
+		if err != nil {
+			return false, errors.Wrap(err, "getting reviews")
+		}
+		for _, review := range reviews {
+			if state := review.GetState(); state == "APPROVED" || state == "CHANGES_REQUESTED" {
+				latestReviews[review.GetUser().GetLogin()] = state
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	approvals := 0
+	for _, state := range latestReviews {
+		if state == "CHANGES_REQUESTED" {
+			return false, nil
+		}
+		approvals++
+	}
+
+	required := 0
+	protection, resp, err := g.client.Repositories.GetBranchProtection(g.ctx, repo.Owner, repo.Name, pull.BaseBranch)
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return false, errors.Wrap(err, "getting branch protection")
+	}
+	if err == nil && protection.RequiredPullRequestReviews != nil {
+		required = protection.RequiredPullRequestReviews.RequiredApprovingReviewCount
+	}
+	return approvals >= required, nil
+}
+
 // PullIsMergeable returns true if the pull request is mergeable.
-func (g *GithubClient) PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string) (bool, error) {
+func (g *GithubClient) PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string, ignoredChecks []string) (bool, error) {
 	githubPR, err := g.GetPullRequest(repo, pull.Num)
 	if err != nil {
 		return false, errors.Wrap(err, "getting pull request")
@@ -390,12 +555,21 @@ func (g *GithubClient) PullIsMergeable(repo models.Repo, pull models.PullRequest
 	//            hooks. Merging is allowed (green box).
 	// See: https://github.com/octokit/octokit.net/issues/1763
 	if state != "clean" && state != "unstable" && state != "has_hooks" {
-		//mergeable bypass apply code hidden by feature flag
-		if g.config.AllowMergeableBypassApply {
-			g.logger.Debug("AllowMergeableBypassApply feature flag is enabled - attempting to bypass apply from mergeable requirements")
+		//mergeable bypass apply code hidden by feature flag, ignored checks are
+		//always bypassed
+		if g.config.AllowMergeableBypassApply || len(ignoredChecks) > 0 {
+			if g.config.AllowMergeableBypassApply {
+				g.logger.Debug("AllowMergeableBypassApply feature flag is enabled - attempting to bypass apply from mergeable requirements")
+			}
 			if state == "blocked" {
-				//check status excluding atlantis apply
-				status, err := g.GetCombinedStatusMinusApply(repo, githubPR, vcsstatusname)
+				//check status excluding atlantis apply and ignored checks
+				isApplyStatus := g.applyStatusMatcher(vcsstatusname)
+				status, err := g.getCombinedStatusIgnoring(repo, githubPR, func(name string, description string) bool {
+					if g.config.AllowMergeableBypassApply && isApplyStatus(name, description) {
+						return true
+					}
+					return common.IsIgnoredCheck(name, ignoredChecks)
+				})
 				if err != nil {
 					return false, errors.Wrap(err, "getting pull request status")
 				}
@@ -406,7 +580,7 @@ func (g *GithubClient) PullIsMergeable(repo models.Repo, pull models.PullRequest
 					return false, errors.Wrap(err, "getting pull request reviewDecision")
 				}
 
-				//if all other status checks EXCEPT atlantis/apply are successful, and the PR is approved based on reviewDecision, let it proceed
+				//if all other status checks EXCEPT the ignored ones are successful, and the PR is approved based on reviewDecision, let it proceed
 				if status && approved {
 					return true, nil
 				}
@@ -468,6 +642,60 @@ func (g *GithubClient) UpdateStatus(repo models.Repo, pull models.PullRequest, s
 	return err
 }
 
+// GetRequiredStatusChecks returns the contexts of the status checks that
+// branch requires.
+func (g *GithubClient) GetRequiredStatusChecks(repo models.Repo, branch string) ([]string, error) {
+	checks, resp, err := g.client.Repositories.GetRequiredStatusChecks(g.ctx, repo.Owner, repo.Name, branch)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("branch %s of %s doesn't require status checks, enable them in its branch protection first", branch, repo.FullName)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "getting required status checks")
+	}
+	return checks.Contexts, nil
+}
+
+// SetRequiredStatusChecks replaces the status checks that branch requires
+// with contexts. Whether branches must be up to date before merging is kept.
+func (g *GithubClient) SetRequiredStatusChecks(repo models.Repo, branch string, contexts []string) error {
+	_, _, err := g.client.Repositories.UpdateRequiredStatusChecks(g.ctx, repo.Owner, repo.Name, branch, &github.RequiredStatusChecksRequest{
+		Contexts: contexts,
+	})
+	return err
+}
+
+// CreateDeployment creates a deployment of ref to environment and returns its
+// id. GitHub rejects the deployment if ref isn't allowed to deploy to
+// environment by its protection rules. Deployments don't wait for commit
+// statuses since Atlantis's own apply status is pending while it deploys.
+func (g *GithubClient) CreateDeployment(repo models.Repo, ref string, environment string, description string) (int64, error) {
+	g.logger.Debug("POST /repos/%v/%v/deployments", repo.Owner, repo.Name)
+	deployment, _, err := g.client.Repositories.CreateDeployment(g.ctx, repo.Owner, repo.Name, &github.DeploymentRequest{
+		Ref:              github.String(ref),
+		Environment:      github.String(environment),
+		Description:      github.String(description),
+		AutoMerge:        github.Bool(false),
+		RequiredContexts: &[]string{},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deployment.GetID(), nil
+}
+
+// UpdateDeploymentStatus sets the state of the deployment with deploymentID,
+// ex. "in_progress", "success" or "failure". logURL links to the deployment's
+// output.
+func (g *GithubClient) UpdateDeploymentStatus(repo models.Repo, deploymentID int64, state string, logURL string) error {
+	g.logger.Debug("POST /repos/%v/%v/deployments/%d/statuses", repo.Owner, repo.Name, deploymentID)
+	status := &github.DeploymentStatusRequest{State: github.String(state)}
+	if logURL != "" {
+		status.LogURL = github.String(logURL)
+	}
+	_, _, err := g.client.Repositories.CreateDeploymentStatus(g.ctx, repo.Owner, repo.Name, deploymentID, status)
+	return err
+}
+
 // MergePull merges the pull request.
 func (g *GithubClient) MergePull(pull models.PullRequest, pullOptions models.PullRequestOptions) error {
 	// Users can set their repo to disallow certain types of merging.
@@ -514,11 +742,99 @@ func (g *GithubClient) MergePull(pull models.PullRequest, pullOptions models.Pul
 	return nil
 }
 
+// CreateOrUpdatePull opens a pull request in repo from head into base or, if
+// one is already open, updates its title and body.
+func (g *GithubClient) CreateOrUpdatePull(repo models.Repo, head string, base string, title string, body string) (models.PullRequest, error) {
+	g.logger.Debug("GET /repos/%v/%v/pulls", repo.Owner, repo.Name)
+	pulls, _, err := g.client.PullRequests.List(g.ctx, repo.Owner, repo.Name, &github.PullRequestListOptions{
+		State: "open",
+		Head:  fmt.Sprintf("%s:%s", repo.Owner, head),
+		Base:  base,
+	})
+	if err != nil {
+		return models.PullRequest{}, errors.Wrap(err, "listing pull requests")
+	}
+
+	var pull *github.PullRequest
+	if len(pulls) > 0 {
+		num := pulls[0].GetNumber()
+		g.logger.Debug("PATCH /repos/%v/%v/pulls/%d", repo.Owner, repo.Name, num)
+		pull, _, err = g.client.PullRequests.Edit(g.ctx, repo.Owner, repo.Name, num, &github.PullRequest{
+			Title: &title,
+			Body:  &body,
+		})
+		if err != nil {
+			return models.PullRequest{}, errors.Wrapf(err, "updating pull request %d", num)
+		}
+	} else {
+		g.logger.Debug("POST /repos/%v/%v/pulls", repo.Owner, repo.Name)
+		pull, _, err = g.client.PullRequests.Create(g.ctx, repo.Owner, repo.Name, &github.NewPullRequest{
+			Title: &title,
+			Head:  &head,
+			Base:  &base,
+			Body:  &body,
+		})
+		if err != nil {
+			return models.PullRequest{}, errors.Wrap(err, "creating pull request")
+		}
+	}
+
+	return models.PullRequest{
+		Num:        pull.GetNumber(),
+		HeadCommit: pull.GetHead().GetSHA(),
+		URL:        pull.GetHTMLURL(),
+		HeadBranch: head,
+		BaseBranch: base,
+		Author:     pull.GetUser().GetLogin(),
+		State:      models.OpenPullState,
+		BaseRepo:   repo,
+	}, nil
+}
+
+// GetPullByHeadBranch returns the open pull request whose head is branch of
+// repo, or false if there isn't one.
+func (g *GithubClient) GetPullByHeadBranch(repo models.Repo, branch string) (models.PullRequest, bool, error) {
+	g.logger.Debug("GET /repos/%v/%v/pulls", repo.Owner, repo.Name)
+	pulls, _, err := g.client.PullRequests.List(g.ctx, repo.Owner, repo.Name, &github.PullRequestListOptions{
+		State: "open",
+		Head:  fmt.Sprintf("%s:%s", repo.Owner, branch),
+	})
+	if err != nil {
+		return models.PullRequest{}, false, errors.Wrap(err, "listing pull requests")
+	}
+	if len(pulls) == 0 {
+		return models.PullRequest{}, false, nil
+	}
+	pull := pulls[0]
+	return models.PullRequest{
+		Num:        pull.GetNumber(),
+		HeadCommit: pull.GetHead().GetSHA(),
+		URL:        pull.GetHTMLURL(),
+		HeadBranch: pull.GetHead().GetRef(),
+		BaseBranch: pull.GetBase().GetRef(),
+		Author:     pull.GetUser().GetLogin(),
+		State:      models.OpenPullState,
+		BaseRepo:   repo,
+	}, true, nil
+}
+
 // MarkdownPullLink specifies the string used in a pull request comment to reference another pull request.
 func (g *GithubClient) MarkdownPullLink(pull models.PullRequest) (string, error) {
 	return fmt.Sprintf("#%d", pull.Num), nil
 }
 
+// CheckHealth gets Atlantis's rate limit, which needs valid credentials but
+// doesn't count against the limit. For GitHub Apps this mints an installation
+// token. GitHub Enterprise Server returns a 404 if rate limiting is disabled,
+// which still means the API can be reached.
+func (g *GithubClient) CheckHealth() error {
+	_, resp, err := g.client.RateLimits(g.ctx)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
 // GetTeamNamesForUser returns the names of the teams or groups that the user belongs to (in the organization the repository belongs to).
 // https://docs.github.com/en/graphql/reference/objects#organization
 func (g *GithubClient) GetTeamNamesForUser(repo models.Repo, user models.User) ([]string, error) {
@@ -547,6 +863,10 @@ func (g *GithubClient) GetTeamNamesForUser(repo models.Repo, user models.User) (
 	ctx := context.Background()
 	for {
 		err := g.v4Client.Query(ctx, &q, variables)
+		if err != nil && isGraphQLSchemaErr(err) {
+			g.logger.Debug("teams can't be filtered by user, checking each team's membership instead: %s", err)
+			return g.getTeamNamesForUserFromMemberships(orgName, user)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -561,6 +881,37 @@ func (g *GithubClient) GetTeamNamesForUser(repo models.Repo, user models.User) (
 	return teamNames, nil
 }
 
+// getTeamNamesForUserFromMemberships returns the names of the teams in org
+// that user is an active member of using the REST API, for GitHub versions
+// that can't filter teams by user.
+func (g *GithubClient) getTeamNamesForUserFromMemberships(org string, user models.User) ([]string, error) {
+	var teamNames []string
+	opts := github.ListOptions{PerPage: 100}
+	for {
+		teams, resp, err := g.client.Teams.ListTeams(g.ctx, org, &opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing teams")
+		}
+		for _, team := range teams {
+			membership, memberResp, err := g.client.Teams.GetTeamMembershipBySlug(g.ctx, org, team.GetSlug(), user.Username)
+			if memberResp != nil && memberResp.StatusCode == http.StatusNotFound {
+				continue
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "getting membership of team %s", team.GetSlug())
+			}
+			if membership.GetState() == "active" {
+				teamNames = append(teamNames, team.GetName())
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return teamNames, nil
+}
+
 // ExchangeCode returns a newly created app's info
 func (g *GithubClient) ExchangeCode(code string) (*GithubAppTemporarySecrets, error) {
 	ctx := context.Background()
@@ -610,3 +961,124 @@ func (g *GithubClient) GetCloneURL(VCSHostType models.VCSHostType, repo string)
 	}
 	return repository.GetCloneURL(), nil
 }
+
+// githubWebhookEvents are the events that Atlantis handles.
+var githubWebhookEvents = []string{"issue_comment", "pull_request", "pull_request_review", "push"}
+
+// ListOwnerRepos returns the full names of the organization owner's repos or,
+// if owner isn't an organization, of the user owner's repos. Archived repos
+// are skipped since they can't have pull requests.
+func (g *GithubClient) ListOwnerRepos(owner string) ([]string, error) {
+	var repos []string
+	addPage := func(page []*github.Repository) {
+		for _, r := range page {
+			if !r.GetArchived() {
+				repos = append(repos, r.GetFullName())
+			}
+		}
+	}
+
+	orgOpts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := g.client.Repositories.ListByOrg(g.ctx, owner, orgOpts)
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		addPage(page)
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		orgOpts.Page = resp.NextPage
+	}
+
+	userOpts := &github.RepositoryListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := g.client.Repositories.List(g.ctx, owner, userOpts)
+		if err != nil {
+			return nil, err
+		}
+		addPage(page)
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		userOpts.Page = resp.NextPage
+	}
+}
+
+// EnsureWebhook adds a webhook to hook.URL to repo for the events that
+// Atlantis handles. If repo already has one but it's inactive, is missing
+// events or doesn't send JSON over verified SSL, it's updated.
+func (g *GithubClient) EnsureWebhook(repo string, hook Webhook) (WebhookChange, error) {
+	owner, name := models.SplitRepoFullName(repo)
+	var existing *github.Hook
+	opts := &github.ListOptions{PerPage: 100}
+	for existing == nil {
+		hooks, resp, err := g.client.Repositories.ListHooks(g.ctx, owner, name, opts)
+		if err != nil {
+			return WebhookUnchanged, err
+		}
+		for _, h := range hooks {
+			if h.Config["url"] == hook.URL {
+				existing = h
+				break
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	config := map[string]interface{}{
+		"url":          hook.URL,
+		"content_type": "json",
+		"insecure_ssl": "0",
+	}
+	if hook.Secret != "" {
+		config["secret"] = hook.Secret
+	}
+	desired := &github.Hook{
+		Config: config,
+		Events: githubWebhookEvents,
+		Active: github.Bool(true),
+	}
+
+	if existing == nil {
+		if _, _, err := g.client.Repositories.CreateHook(g.ctx, owner, name, desired); err != nil {
+			return WebhookUnchanged, err
+		}
+		return WebhookCreated, nil
+	}
+
+	if githubHookUpToDate(existing) && !hook.SyncSecret {
+		return WebhookUnchanged, nil
+	}
+	if _, _, err := g.client.Repositories.EditHook(g.ctx, owner, name, existing.GetID(), desired); err != nil {
+		return WebhookUnchanged, err
+	}
+	return WebhookUpdated, nil
+}
+
+// githubHookUpToDate returns true if hook is active, sends JSON over verified SSL
+// and subscribes to all of githubWebhookEvents.
+func githubHookUpToDate(hook *github.Hook) bool {
+	if !hook.GetActive() || hook.Config["content_type"] != "json" || hook.Config["insecure_ssl"] != "0" {
+		return false
+	}
+	events := make(map[string]bool)
+	for _, e := range hook.Events {
+		events[e] = true
+	}
+	if events["*"] {
+		return true
+	}
+	for _, e := range githubWebhookEvents {
+		if !events[e] {
+			return false
+		}
+	}
+	return true
+}
@@ -63,7 +63,7 @@ func TestGithubClient_GetModifiedFiles(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass"}, vcs.GithubConfig{}, logger)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logger)
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -118,7 +118,7 @@ func TestGithubClient_GetModifiedFilesMovedFile(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -212,7 +212,7 @@ func TestGithubClient_PaginatesComments(t *testing.T) {
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
 
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -230,6 +230,7 @@ func TestGithubClient_PaginatesComments(t *testing.T) {
 		},
 		123,
 		command.Plan.TitleString(),
+		"",
 	)
 	Ok(t, err)
 	Equals(t, 2, len(gotMinimizeCalls))
@@ -296,7 +297,7 @@ func TestGithubClient_HideOldComments(t *testing.T) {
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
 
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -314,6 +315,7 @@ func TestGithubClient_HideOldComments(t *testing.T) {
 		},
 		123,
 		command.Plan.TitleString(),
+		"",
 	)
 	Ok(t, err)
 	Equals(t, 3, len(gotMinimizeCalls))
@@ -322,6 +324,161 @@ func TestGithubClient_HideOldComments(t *testing.T) {
 	Equals(t, githubv4.ReportedContentClassifiersOutdated, gotMinimizeCalls[0].Variables.Input.Classifier)
 }
 
+func TestGithubClient_DeleteOldComments(t *testing.T) {
+	// Only comment 3 should be deleted, because it's by the same Atlantis bot
+	// user and it has "plan" and the dir in the first line of the comment body.
+	issueResp := `[
+	{"id": 1, "body": "Ran Plan for dir: ` + "`staging`" + `\nasd", "user": {"login": "someone-else"}},
+	{"id": 2, "body": "Ran Apply for dir: ` + "`staging`" + `\nasd", "user": {"login": "user"}},
+	{"id": 3, "body": "Ran Plan for dir: ` + "`staging`" + ` workspace: ` + "`default`" + `\nasd", "user": {"login": "user"}},
+	{"id": 4, "body": "Ran Plan for dir: ` + "`production`" + `\nasd", "user": {"login": "user"}}
+]`
+	var gotDeletes []string
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.RequestURI {
+			// This gets the pull request's comments.
+			case "GET /api/v3/repos/owner/repo/issues/123/comments?direction=asc&sort=created":
+				w.Write([]byte(issueResp)) // nolint: errcheck
+				return
+			case "DELETE /api/v3/repos/owner/repo/issues/comments/3":
+				gotDeletes = append(gotDeletes, r.RequestURI)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			default:
+				t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+		}),
+	)
+
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	err = client.DeletePrevCommandComments(
+		models.Repo{
+			FullName: "owner/repo",
+			Owner:    "owner",
+			Name:     "repo",
+		},
+		123,
+		command.Plan.TitleString(),
+		"staging",
+	)
+	Ok(t, err)
+	Equals(t, []string{"/api/v3/repos/owner/repo/issues/comments/3"}, gotDeletes)
+}
+
+func TestGithubClient_UpsertComment(t *testing.T) {
+	marker := "<!-- marker -->"
+	cases := []struct {
+		description string
+		issueResp   string
+		expRequest  string
+	}{
+		{
+			description: "edits the comment with the marker",
+			issueResp: `[
+	{"id": 1, "body": "` + marker + `\nold", "user": {"login": "someone-else"}},
+	{"id": 2, "body": "` + marker + `\nold", "user": {"login": "user"}}
+]`,
+			expRequest: "PATCH /api/v3/repos/owner/repo/issues/comments/2",
+		},
+		{
+			description: "creates a comment if there isn't one with the marker",
+			issueResp:   `[{"id": 1, "body": "Ran Plan for dir: ` + "`staging`" + `", "user": {"login": "user"}}]`,
+			expRequest:  "POST /api/v3/repos/owner/repo/issues/123/comments",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			var gotRequests []string
+			testServer := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.Method + " " + r.RequestURI {
+					case "GET /api/v3/repos/owner/repo/issues/123/comments?direction=asc&sort=created":
+						w.Write([]byte(c.issueResp)) // nolint: errcheck
+						return
+					case "PATCH /api/v3/repos/owner/repo/issues/comments/2", "POST /api/v3/repos/owner/repo/issues/123/comments":
+						body, err := io.ReadAll(r.Body)
+						Ok(t, err)
+						Equals(t, `{"body":"`+marker+`\nnew"}`+"\n", string(body))
+						gotRequests = append(gotRequests, r.Method+" "+r.RequestURI)
+						w.Write([]byte("{}")) // nolint: errcheck
+						return
+					default:
+						t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+						return
+					}
+				}),
+			)
+
+			testServerURL, err := url.Parse(testServer.URL)
+			Ok(t, err)
+
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+			Ok(t, err)
+			defer disableSSLVerification()()
+
+			err = client.UpsertComment(
+				models.Repo{
+					FullName: "owner/repo",
+					Owner:    "owner",
+					Name:     "repo",
+				},
+				123,
+				marker+"\nnew",
+				marker,
+			)
+			Ok(t, err)
+			Equals(t, []string{c.expRequest}, gotRequests)
+		})
+	}
+}
+
+func TestGithubClient_CreateDeployment(t *testing.T) {
+	var gotRequests []string
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			Ok(t, err)
+			gotRequests = append(gotRequests, r.Method+" "+r.RequestURI+" "+string(body))
+			switch r.Method + " " + r.RequestURI {
+			case "POST /api/v3/repos/owner/repo/deployments":
+				w.Write([]byte(`{"id": 7}`)) // nolint: errcheck
+			case "POST /api/v3/repos/owner/repo/deployments/7/statuses":
+				w.Write([]byte(`{"id": 1}`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}),
+	)
+
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	repo := models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}
+	id, err := client.CreateDeployment(repo, "feature", "production", "desc")
+	Ok(t, err)
+	Equals(t, int64(7), id)
+	Ok(t, client.UpdateDeploymentStatus(repo, id, "in_progress", "https://atlantis/jobs/1"))
+	Equals(t, []string{
+		`POST /api/v3/repos/owner/repo/deployments {"ref":"feature","auto_merge":false,"required_contexts":[],"environment":"production","description":"desc"}` + "\n",
+		`POST /api/v3/repos/owner/repo/deployments/7/statuses {"state":"in_progress","log_url":"https://atlantis/jobs/1"}` + "\n",
+	}, gotRequests)
+}
+
 func TestGithubClient_UpdateStatus(t *testing.T) {
 	cases := []struct {
 		status   models.CommitStatus
@@ -362,7 +519,7 @@ func TestGithubClient_UpdateStatus(t *testing.T) {
 
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -448,7 +605,7 @@ func TestGithubClient_PullIsApproved(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -563,7 +720,7 @@ func TestGithubClient_PullIsMergeable(t *testing.T) {
 				}))
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -579,7 +736,7 @@ func TestGithubClient_PullIsMergeable(t *testing.T) {
 				},
 			}, models.PullRequest{
 				Num: 1,
-			}, vcsStatusName)
+			}, vcsStatusName, nil)
 			Ok(t, err)
 			Equals(t, c.expMergeable, actMergeable)
 		})
@@ -714,7 +871,7 @@ func TestGithubClient_PullIsMergeableWithAllowMergeableBypassApply(t *testing.T)
 				}))
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass"}, vcs.GithubConfig{AllowMergeableBypassApply: true}, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{AllowMergeableBypassApply: true}, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -730,7 +887,153 @@ func TestGithubClient_PullIsMergeableWithAllowMergeableBypassApply(t *testing.T)
 				},
 			}, models.PullRequest{
 				Num: 1,
-			}, vcsStatusName)
+			}, vcsStatusName, nil)
+			Ok(t, err)
+			Equals(t, c.expMergeable, actMergeable)
+		})
+	}
+}
+
+func TestGithubClient_PullIsMergeableWithAllowMergeableBypassApplyStatusNames(t *testing.T) {
+	jsBytes, err := os.ReadFile("fixtures/github-pull-request.json")
+	Ok(t, err)
+	prJSON := strings.Replace(string(jsBytes), `"mergeable_state": "clean"`, `"mergeable_state": "blocked"`, 1)
+	commitJSON, err := os.ReadFile("fixtures/github-commit-status-full.json")
+	Ok(t, err)
+	branchProtectionJSON, err := os.ReadFile("fixtures/github-branch-protection-required-checks.json")
+	Ok(t, err)
+	checkSuites, err := os.ReadFile("fixtures/github-commit-check-suites.json")
+	Ok(t, err)
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v3/repos/owner/repo/pulls/1":
+				w.Write([]byte(prJSON)) // nolint: errcheck
+			case "/api/v3/repos/owner/repo/commits/new-topic/status":
+				w.Write(commitJSON) // nolint: errcheck
+			case "/api/graphql":
+				w.Write([]byte(`{"data": {"repository": {"pullRequest": {"reviewDecision": "APPROVED"}}}}`)) // nolint: errcheck
+			case "/api/v3/repos/owner/repo/branches/master/protection":
+				w.Write(branchProtectionJSON) // nolint: errcheck
+			case "/api/v3/repos/owner/repo/commits/new-topic/check-suites":
+				w.Write(checkSuites) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	// The failed apply statuses of the fixture are only bypassed if the
+	// config recognizes them as apply statuses.
+	cases := []struct {
+		description  string
+		applyPrefix  string
+		expMergeable bool
+	}{
+		{"apply statuses", "atlantis/apply", true},
+		{"differently named apply statuses", "ci/atlantis-apply", false},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{
+				AllowMergeableBypassApply: true,
+				IsApplyStatus: func(name string, _ string) bool {
+					return strings.HasPrefix(name, c.applyPrefix)
+				},
+			}, logging.NewNoopLogger(t))
+			Ok(t, err)
+			mergeable, err := client.PullIsMergeable(models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}, models.PullRequest{Num: 1}, "atlantis", nil)
+			Ok(t, err)
+			Equals(t, c.expMergeable, mergeable)
+		})
+	}
+}
+
+func TestGithubClient_PullIsMergeableWithIgnoredChecks(t *testing.T) {
+	cases := []struct {
+		description   string
+		ignoredChecks []string
+		expMergeable  bool
+	}{
+		{
+			"no ignored checks",
+			nil,
+			false,
+		},
+		{
+			"ignoring only the combined apply status",
+			[]string{"atlantis/apply"},
+			false,
+		},
+		{
+			"ignoring all apply statuses",
+			[]string{"atlantis/apply*"},
+			true,
+		},
+	}
+
+	// Use a real GitHub json response and edit the mergeable_state field.
+	jsBytes, err := os.ReadFile("fixtures/github-pull-request.json")
+	Ok(t, err)
+	response := strings.Replace(string(jsBytes),
+		`"mergeable_state": "clean"`,
+		`"mergeable_state": "blocked"`,
+		1,
+	)
+
+	// Status Check Response
+	jsBytes, err = os.ReadFile("fixtures/github-commit-status-full.json")
+	Ok(t, err)
+	commitJSON := string(jsBytes)
+
+	// Branch protection Response
+	jsBytes, err = os.ReadFile("fixtures/github-branch-protection-required-checks.json")
+	Ok(t, err)
+	branchProtectionJSON := string(jsBytes)
+
+	// List check suites Response
+	jsBytes, err = os.ReadFile("fixtures/github-commit-check-suites.json")
+	Ok(t, err)
+	checkSuites := string(jsBytes)
+
+	reviewDecision := `{"data": {"repository": {"pullRequest": {"reviewDecision": "APPROVED"}}}}`
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			testServer := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.RequestURI {
+					case "/api/v3/repos/owner/repo/pulls/1":
+						w.Write([]byte(response)) // nolint: errcheck
+					case "/api/v3/repos/owner/repo/commits/new-topic/status":
+						w.Write([]byte(commitJSON)) // nolint: errcheck
+					case "/api/graphql":
+						w.Write([]byte(reviewDecision)) // nolint: errcheck
+					case "/api/v3/repos/owner/repo/branches/master/protection":
+						w.Write([]byte(branchProtectionJSON)) // nolint: errcheck
+					case "/api/v3/repos/owner/repo/commits/new-topic/check-suites":
+						w.Write([]byte(checkSuites)) // nolint: errcheck
+					default:
+						t.Errorf("got unexpected request at %q", r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+					}
+				}))
+			testServerURL, err := url.Parse(testServer.URL)
+			Ok(t, err)
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+			Ok(t, err)
+			defer disableSSLVerification()()
+
+			actMergeable, err := client.PullIsMergeable(models.Repo{
+				FullName: "owner/repo",
+				Owner:    "owner",
+				Name:     "repo",
+			}, models.PullRequest{
+				Num: 1,
+			}, "atlantis", c.ignoredChecks)
 			Ok(t, err)
 			Equals(t, c.expMergeable, actMergeable)
 		})
@@ -796,7 +1099,7 @@ func TestGithubClient_MergePullHandlesError(t *testing.T) {
 
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -919,7 +1222,7 @@ func TestGithubClient_MergePullCorrectMethod(t *testing.T) {
 
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -946,8 +1249,50 @@ func TestGithubClient_MergePullCorrectMethod(t *testing.T) {
 	}
 }
 
+func TestGithubClient_ReactToComment(t *testing.T) {
+	cases := map[models.CommentReaction]string{
+		models.SeenCommentReaction:    "eyes",
+		models.StartedCommentReaction: "rocket",
+		models.SuccessCommentReaction: "+1",
+		models.FailedCommentReaction:  "-1",
+	}
+	for reaction, expContent := range cases {
+		t.Run(reaction.String(), func(t *testing.T) {
+			testServer := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.RequestURI {
+					case "/api/v3/repos/owner/repo/issues/comments/123/reactions":
+						body, err := io.ReadAll(r.Body)
+						Ok(t, err)
+						Equals(t, fmt.Sprintf("{\"content\":\"%s\"}\n", expContent), string(body))
+						defer r.Body.Close() // nolint: errcheck
+						w.WriteHeader(http.StatusCreated)
+						w.Write([]byte("{}")) // nolint: errcheck
+					default:
+						t.Errorf("got unexpected request at %q", r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+						return
+					}
+				}))
+
+			testServerURL, err := url.Parse(testServer.URL)
+			Ok(t, err)
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+			Ok(t, err)
+			defer disableSSLVerification()()
+
+			err = client.ReactToComment(models.Repo{
+				FullName: "owner/repo",
+				Owner:    "owner",
+				Name:     "repo",
+			}, 1, 123, reaction)
+			Ok(t, err)
+		})
+	}
+}
+
 func TestGithubClient_MarkdownPullLink(t *testing.T) {
-	client, err := vcs.NewGithubClient("hostname", &vcs.GithubUserCredentials{"user", "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient("hostname", &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
 	Ok(t, err)
 	pull := models.PullRequest{Num: 1}
 	s, _ := client.MarkdownPullLink(pull)
@@ -1002,7 +1347,7 @@ func TestGithubClient_SplitComments(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 	pull := models.PullRequest{Num: 1}
@@ -1060,7 +1405,7 @@ func TestGithubClient_Retry404(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 	repo := models.Repo{
@@ -1105,7 +1450,7 @@ func TestGithubClient_Retry404Files(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 	repo := models.Repo{
@@ -1158,7 +1503,7 @@ func TestGithubClient_GetTeamNamesForUser(t *testing.T) {
 		}))
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass"}, vcs.GithubConfig{}, logger)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logger)
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -1170,3 +1515,117 @@ func TestGithubClient_GetTeamNamesForUser(t *testing.T) {
 	Ok(t, err)
 	Equals(t, []string{"frontend-developers", "employees"}, teams)
 }
+
+func TestGithubClient_EnsureWebhook(t *testing.T) {
+	upToDate := `{"id":1,"active":true,"events":["issue_comment","pull_request","pull_request_review","push"],"config":{"url":"https://atlantis.com/events","content_type":"json","insecure_ssl":"0"}}`
+	cases := []struct {
+		description string
+		hooks       string
+		syncSecret  bool
+		expChange   vcs.WebhookChange
+		expRequest  string
+	}{
+		{
+			description: "no webhook",
+			hooks:       `[{"id":2,"active":true,"events":["push"],"config":{"url":"https://ci.com/hook"}}]`,
+			expChange:   vcs.WebhookCreated,
+			expRequest:  "POST /api/v3/repos/owner/repo/hooks",
+		},
+		{
+			description: "up to date",
+			hooks:       "[" + upToDate + "]",
+			expChange:   vcs.WebhookUnchanged,
+		},
+		{
+			description: "up to date but secret synced",
+			hooks:       "[" + upToDate + "]",
+			syncSecret:  true,
+			expChange:   vcs.WebhookUpdated,
+			expRequest:  "PATCH /api/v3/repos/owner/repo/hooks/1",
+		},
+		{
+			description: "missing events",
+			hooks:       `[{"id":1,"active":true,"events":["pull_request"],"config":{"url":"https://atlantis.com/events","content_type":"json","insecure_ssl":"0"}}]`,
+			expChange:   vcs.WebhookUpdated,
+			expRequest:  "PATCH /api/v3/repos/owner/repo/hooks/1",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			var gotRequest string
+			testServer := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch {
+					case r.Method == "GET" && r.URL.Path == "/api/v3/repos/owner/repo/hooks":
+						w.Write([]byte(c.hooks)) // nolint: errcheck
+					case r.Method == "POST" || r.Method == "PATCH":
+						gotRequest = r.Method + " " + r.URL.Path
+						body, err := io.ReadAll(r.Body)
+						Ok(t, err)
+						var hook map[string]interface{}
+						Ok(t, json.Unmarshal(body, &hook))
+						Equals(t, map[string]interface{}{
+							"url":          "https://atlantis.com/events",
+							"content_type": "json",
+							"insecure_ssl": "0",
+							"secret":       "secret",
+						}, hook["config"])
+						w.Write([]byte(upToDate)) // nolint: errcheck
+					default:
+						t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+					}
+				}))
+			testServerURL, err := url.Parse(testServer.URL)
+			Ok(t, err)
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+			Ok(t, err)
+			defer disableSSLVerification()()
+
+			change, err := client.EnsureWebhook("owner/repo", vcs.Webhook{
+				URL:        "https://atlantis.com/events",
+				Secret:     "secret",
+				SyncSecret: c.syncSecret,
+			})
+			Ok(t, err)
+			Equals(t, c.expChange, change)
+			Equals(t, c.expRequest, gotRequest)
+		})
+	}
+}
+
+func TestGithubClient_ListOwnerRepos(t *testing.T) {
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v3/orgs/org/repos":
+				if r.URL.Query().Get("page") == "" {
+					w.Header().Set("Link", fmt.Sprintf(`<https://%s/api/v3/orgs/org/repos?page=2>; rel="next"`, r.Host))
+					w.Write([]byte(`[{"full_name":"org/a"},{"full_name":"org/old","archived":true}]`)) // nolint: errcheck
+					return
+				}
+				w.Write([]byte(`[{"full_name":"org/b"}]`)) // nolint: errcheck
+			case "/api/v3/orgs/user/repos":
+				http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+			case "/api/v3/users/user/repos":
+				w.Write([]byte(`[{"full_name":"user/c"}]`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	repos, err := client.ListOwnerRepos("org")
+	Ok(t, err)
+	Equals(t, []string{"org/a", "org/b"}, repos)
+
+	repos, err = client.ListOwnerRepos("user")
+	Ok(t, err)
+	Equals(t, []string{"user/c"}, repos)
+}
@@ -3,4 +3,8 @@ package vcs
 // GithubConfig allows for custom github-specific functionality and behavior
 type GithubConfig struct {
 	AllowMergeableBypassApply bool
+	// IsApplyStatus returns true if the commit status with name and
+	// description was set by apply. If nil, apply statuses are expected to
+	// have the default names.
+	IsApplyStatus func(name string, description string) bool
 }
@@ -44,13 +44,16 @@ func (c *GithubAnonymousCredentials) GetToken() (string, error) {
 type GithubUserCredentials struct {
 	User  string
 	Token string
+	// Transport makes the requests. If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
 }
 
 // Client returns a client for basic auth user credentials.
 func (c *GithubUserCredentials) Client() (*http.Client, error) {
 	tr := &github.BasicAuthTransport{
-		Username: strings.TrimSpace(c.User),
-		Password: strings.TrimSpace(c.Token),
+		Username:  strings.TrimSpace(c.User),
+		Password:  strings.TrimSpace(c.Token),
+		Transport: c.Transport,
 	}
 	return tr.Client(), nil
 }
@@ -74,6 +77,8 @@ type GithubAppCredentials struct {
 	installationID int64
 	tr             *ghinstallation.Transport
 	AppSlug        string
+	// Transport makes the requests. If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
 }
 
 // Client returns a github app installation client.
@@ -126,9 +131,8 @@ func (c *GithubAppCredentials) getInstallationID() (int64, error) {
 		return c.installationID, nil
 	}
 
-	tr := http.DefaultTransport
 	// A non-installation transport
-	t, err := ghinstallation.NewAppsTransport(tr, c.AppID, c.Key)
+	t, err := ghinstallation.NewAppsTransport(c.baseTransport(), c.AppID, c.Key)
 	if err != nil {
 		return 0, err
 	}
@@ -162,8 +166,7 @@ func (c *GithubAppCredentials) transport() (*ghinstallation.Transport, error) {
 		return nil, err
 	}
 
-	tr := http.DefaultTransport
-	itr, err := ghinstallation.New(tr, c.AppID, installationID, c.Key)
+	itr, err := ghinstallation.New(c.baseTransport(), c.AppID, installationID, c.Key)
 	if err == nil {
 		apiURL := c.getAPIURL()
 		itr.BaseURL = strings.TrimSuffix(apiURL.String(), "/")
@@ -172,6 +175,13 @@ func (c *GithubAppCredentials) transport() (*ghinstallation.Transport, error) {
 	return itr, err
 }
 
+func (c *GithubAppCredentials) baseTransport() http.RoundTripper {
+	if c.Transport != nil {
+		return c.Transport
+	}
+	return http.DefaultTransport
+}
+
 func (c *GithubAppCredentials) getAPIURL() *url.URL {
 	if c.apiURL != nil {
 		return c.apiURL
@@ -0,0 +1,32 @@
+package vcs
+
+import "github.com/runatlantis/atlantis/server/events/models"
+
+// GithubDeploymentsClient creates GitHub deployments and updates their
+// statuses.
+type GithubDeploymentsClient interface {
+	CreateDeployment(repo models.Repo, ref string, environment string, description string) (int64, error)
+	UpdateDeploymentStatus(repo models.Repo, deploymentID int64, state string, logURL string) error
+}
+
+// GithubDeploymentsClientProxy uses the client for the repo's hostname, or
+// Default if there isn't one.
+type GithubDeploymentsClientProxy struct {
+	Default GithubDeploymentsClient
+	Hosts   map[string]GithubDeploymentsClient
+}
+
+func (g *GithubDeploymentsClientProxy) client(repo models.Repo) GithubDeploymentsClient {
+	if client, ok := g.Hosts[repo.VCSHost.Hostname]; ok {
+		return client
+	}
+	return g.Default
+}
+
+func (g *GithubDeploymentsClientProxy) CreateDeployment(repo models.Repo, ref string, environment string, description string) (int64, error) {
+	return g.client(repo).CreateDeployment(repo, ref, environment, description)
+}
+
+func (g *GithubDeploymentsClientProxy) UpdateDeploymentStatus(repo models.Repo, deploymentID int64, state string, logURL string) error {
+	return g.client(repo).UpdateDeploymentStatus(repo, deploymentID, state, logURL)
+}
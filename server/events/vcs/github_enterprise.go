@@ -0,0 +1,66 @@
+package vcs
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-version"
+)
+
+// githubEnterpriseVersionHeader is set on API responses by GitHub Enterprise
+// Server to its version, ex. 3.4.2.
+const githubEnterpriseVersionHeader = "X-GitHub-Enterprise-Version"
+
+// GitHub Enterprise Server features that Atlantis uses and the versions that
+// added them. Older versions use fallbacks instead.
+var (
+	// githubChecksVersion added the checks API.
+	githubChecksVersion = version.Must(version.NewVersion("2.14"))
+	// githubMinimizeCommentVersion added the minimizeComment mutation.
+	githubMinimizeCommentVersion = version.Must(version.NewVersion("2.17"))
+	// githubReviewDecisionVersion added the pull request reviewDecision field.
+	githubReviewDecisionVersion = version.Must(version.NewVersion("2.21"))
+)
+
+// githubVersionTransport records the GitHub Enterprise Server version from
+// the responses it transports. Recording it from responses Atlantis makes
+// anyway means detecting the version doesn't need requests of its own.
+type githubVersionTransport struct {
+	base http.RoundTripper
+
+	mu      sync.Mutex
+	version *version.Version
+}
+
+func (t *githubVersionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if header := resp.Header.Get(githubEnterpriseVersionHeader); header != "" {
+		if v, err := version.NewVersion(header); err == nil {
+			t.mu.Lock()
+			t.version = v
+			t.mu.Unlock()
+		}
+	}
+	return resp, nil
+}
+
+// supports returns true if the server supports a feature added in
+// minVersion. If the version isn't known, ex. on github.com or before any
+// responses, features are assumed to be supported.
+func (t *githubVersionTransport) supports(minVersion *version.Version) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.version == nil || t.version.GreaterThanOrEqual(minVersion)
+}
+
+// isGraphQLSchemaErr returns true if err is from a GraphQL query that uses a
+// field or argument that the server's schema doesn't have, which is the case
+// for newer features on older GitHub Enterprise Server versions.
+func isGraphQLSchemaErr(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "doesn't exist on type") || strings.Contains(msg, "doesn't accept argument")
+}
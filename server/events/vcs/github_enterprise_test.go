@@ -0,0 +1,156 @@
+package vcs_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// Test that reviews are used when GitHub Enterprise Server doesn't have the
+// reviewDecision field, and that once the version is known the GraphQL
+// query isn't attempted again.
+func TestGithubClient_GetPullReviewDecision_EnterpriseFallback(t *testing.T) {
+	cases := []struct {
+		description string
+		reviews     string
+		protection  string
+		exp         bool
+	}{
+		{
+			"approved without branch protection",
+			`[{"user": {"login": "a"}, "state": "APPROVED"}]`,
+			"",
+			true,
+		},
+		{
+			"latest review requested changes",
+			`[{"user": {"login": "a"}, "state": "APPROVED"}, {"user": {"login": "b"}, "state": "CHANGES_REQUESTED"}]`,
+			"",
+			false,
+		},
+		{
+			"changes requested then approved",
+			`[{"user": {"login": "a"}, "state": "CHANGES_REQUESTED"}, {"user": {"login": "a"}, "state": "COMMENTED"}, {"user": {"login": "a"}, "state": "APPROVED"}]`,
+			"",
+			true,
+		},
+		{
+			"not enough approvals",
+			`[{"user": {"login": "a"}, "state": "APPROVED"}, {"user": {"login": "a"}, "state": "APPROVED"}]`,
+			`{"required_pull_request_reviews": {"required_approving_review_count": 2}}`,
+			false,
+		},
+		{
+			"enough approvals",
+			`[{"user": {"login": "a"}, "state": "APPROVED"}, {"user": {"login": "b"}, "state": "APPROVED"}]`,
+			`{"required_pull_request_reviews": {"required_approving_review_count": 2}}`,
+			true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			graphQLCalls := 0
+			testServer := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("X-GitHub-Enterprise-Version", "2.20.5")
+					switch r.Method + " " + r.RequestURI {
+					case "POST /api/graphql":
+						graphQLCalls++
+						w.Write([]byte(`{"data": null, "errors": [{"message": "Field 'reviewDecision' doesn't exist on type 'PullRequest'"}]}`)) // nolint: errcheck
+					case "GET /api/v3/repos/owner/repo/pulls/1/reviews?per_page=100":
+						w.Write([]byte(c.reviews)) // nolint: errcheck
+					case "GET /api/v3/repos/owner/repo/branches/main/protection":
+						if c.protection == "" {
+							http.Error(w, `{"message": "Branch not protected"}`, http.StatusNotFound)
+							return
+						}
+						w.Write([]byte(c.protection)) // nolint: errcheck
+					default:
+						t.Errorf("got unexpected request at %q", r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+					}
+				}))
+			testServerURL, err := url.Parse(testServer.URL)
+			Ok(t, err)
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+			Ok(t, err)
+			defer disableSSLVerification()()
+
+			repo := models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}
+			pull := models.PullRequest{Num: 1, BaseBranch: "main"}
+			for i := 0; i < 2; i++ {
+				approved, err := client.GetPullReviewDecision(repo, pull)
+				Ok(t, err)
+				Equals(t, c.exp, approved)
+			}
+			Equals(t, 1, graphQLCalls)
+		})
+	}
+}
+
+func TestGithubClient_HideOldComments_EnterpriseUnsupported(t *testing.T) {
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-GitHub-Enterprise-Version", "2.16.0")
+			switch r.Method + " " + r.RequestURI {
+			case "GET /api/v3/repos/owner/repo/issues/123/comments?direction=asc&sort=created":
+				w.Write([]byte(`[{"node_id": "1", "body": "asd plan\nasd", "user": {"login": "user"}}]`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	err = client.HidePrevCommandComments(models.Repo{
+		FullName: "owner/repo",
+		Owner:    "owner",
+		Name:     "repo",
+	}, 123, "plan", "")
+	Ok(t, err)
+}
+
+func TestGithubClient_GetTeamNamesForUser_EnterpriseFallback(t *testing.T) {
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.RequestURI {
+			case "POST /api/graphql":
+				w.Write([]byte(`{"data": null, "errors": [{"message": "Field 'teams' doesn't accept argument 'userLogins'"}]}`)) // nolint: errcheck
+			case "GET /api/v3/orgs/testorg/teams?per_page=100":
+				w.Write([]byte(`[{"name": "Frontend Developers", "slug": "frontend-developers"}, {"name": "Employees", "slug": "employees"}, {"name": "Admins", "slug": "admins"}]`)) // nolint: errcheck
+			case "GET /api/v3/orgs/testorg/teams/frontend-developers/memberships/testuser":
+				w.Write([]byte(`{"state": "active"}`)) // nolint: errcheck
+			case "GET /api/v3/orgs/testorg/teams/employees/memberships/testuser":
+				w.Write([]byte(`{"state": "pending"}`)) // nolint: errcheck
+			case "GET /api/v3/orgs/testorg/teams/admins/memberships/testuser":
+				http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, logging.NewNoopLogger(t))
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	teams, err := client.GetTeamNamesForUser(models.Repo{
+		Owner: "testorg",
+	}, models.User{
+		Username: "testuser",
+	})
+	Ok(t, err)
+	Equals(t, []string{"Frontend Developers"}, teams)
+}
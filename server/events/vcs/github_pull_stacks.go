@@ -0,0 +1,25 @@
+package vcs
+
+import "github.com/runatlantis/atlantis/server/events/models"
+
+// PullStackClient finds the pull requests that stacked pull requests are
+// based on.
+type PullStackClient interface {
+	// GetPullByHeadBranch returns the open pull request whose head is branch
+	// of repo, or false if there isn't one.
+	GetPullByHeadBranch(repo models.Repo, branch string) (models.PullRequest, bool, error)
+}
+
+// GithubPullStackClientProxy uses the client for the repo's hostname, or
+// Default if there isn't one.
+type GithubPullStackClientProxy struct {
+	Default PullStackClient
+	Hosts   map[string]PullStackClient
+}
+
+func (g *GithubPullStackClientProxy) GetPullByHeadBranch(repo models.Repo, branch string) (models.PullRequest, bool, error) {
+	if client, ok := g.Hosts[repo.VCSHost.Hostname]; ok {
+		return client.GetPullByHeadBranch(repo, branch)
+	}
+	return g.Default.GetPullByHeadBranch(repo, branch)
+}
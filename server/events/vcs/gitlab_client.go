@@ -53,13 +53,18 @@ var commonMarkSupported = MustConstraint(">=11.1")
 // gitlabClientUnderTest is true if we're running under go test.
 var gitlabClientUnderTest = false
 
-// NewGitlabClient returns a valid GitLab client.
-func NewGitlabClient(hostname string, token string, logger logging.SimpleLogging) (*GitlabClient, error) {
+// NewGitlabClient returns a client for the GitLab instance at hostname. If
+// transport is nil, http.DefaultTransport is used.
+func NewGitlabClient(hostname string, token string, transport http.RoundTripper, logger logging.SimpleLogging) (*GitlabClient, error) {
 	client := &GitlabClient{}
+	var opts []gitlab.ClientOptionFunc
+	if transport != nil {
+		opts = append(opts, gitlab.WithHTTPClient(&http.Client{Transport: transport}))
+	}
 
 	// Create the client differently depending on the base URL.
 	if hostname == "gitlab.com" {
-		glClient, err := gitlab.NewClient(token)
+		glClient, err := gitlab.NewClient(token, opts...)
 		if err != nil {
 			return nil, err
 		}
@@ -88,7 +93,7 @@ func NewGitlabClient(hostname string, token string, logger logging.SimpleLogging
 		// Now we're ready to construct the client.
 		absoluteURL = strings.TrimSuffix(absoluteURL, "/")
 		apiURL := fmt.Sprintf("%s/api/v4/", absoluteURL)
-		glClient, err := gitlab.NewClient(token, gitlab.WithBaseURL(apiURL))
+		glClient, err := gitlab.NewClient(token, append(opts, gitlab.WithBaseURL(apiURL))...)
 		if err != nil {
 			return nil, err
 		}
@@ -164,10 +169,115 @@ func (g *GitlabClient) CreateComment(repo models.Repo, pullNum int, comment stri
 	return nil
 }
 
-func (g *GitlabClient) HidePrevCommandComments(repo models.Repo, pullNum int, command string) error {
+// HidePrevCommandComments collapses the notes Atlantis previously left for
+// command since GitLab doesn't support hiding notes.
+func (g *GitlabClient) HidePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
+	notes, err := g.prevCommandNotes(repo, pullNum, command, dir)
+	if err != nil {
+		return err
+	}
+	for _, note := range notes {
+		body := fmt.Sprintf("<details><summary>%s</summary>\n\n%s\n</details>", common.SupersededCommentSummary, note.Body)
+		if _, _, err := g.Client.Notes.UpdateMergeRequestNote(repo.FullName, pullNum, note.ID, &gitlab.UpdateMergeRequestNoteOptions{Body: gitlab.String(body)}); err != nil {
+			return errors.Wrapf(err, "updating note %d", note.ID)
+		}
+	}
+	return nil
+}
+
+// DeletePrevCommandComments deletes the notes Atlantis previously left for
+// command.
+func (g *GitlabClient) DeletePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
+	notes, err := g.prevCommandNotes(repo, pullNum, command, dir)
+	if err != nil {
+		return err
+	}
+	for _, note := range notes {
+		if _, err := g.Client.Notes.DeleteMergeRequestNote(repo.FullName, pullNum, note.ID); err != nil {
+			return errors.Wrapf(err, "deleting note %d", note.ID)
+		}
+	}
 	return nil
 }
 
+// UpsertComment updates the note the current user previously left on pullNum
+// that contains marker, or creates a new note if there isn't one.
+func (g *GitlabClient) UpsertComment(repo models.Repo, pullNum int, comment string, marker string) error {
+	notes, err := g.userNotes(repo, pullNum, func(body string) bool {
+		return strings.Contains(body, marker)
+	})
+	if err != nil {
+		return err
+	}
+	if len(notes) == 0 {
+		return g.CreateComment(repo, pullNum, comment, "")
+	}
+	if _, _, err := g.Client.Notes.UpdateMergeRequestNote(repo.FullName, pullNum, notes[0].ID, &gitlab.UpdateMergeRequestNoteOptions{Body: gitlab.String(comment)}); err != nil {
+		return errors.Wrapf(err, "updating note %d", notes[0].ID)
+	}
+	return nil
+}
+
+// prevCommandNotes returns the notes the current user left for command and
+// dir on pullNum.
+func (g *GitlabClient) prevCommandNotes(repo models.Repo, pullNum int, command string, dir string) ([]*gitlab.Note, error) {
+	return g.userNotes(repo, pullNum, func(body string) bool {
+		return common.IsPrevCommandComment(body, command, dir)
+	})
+}
+
+// userNotes returns the notes the current user left on pullNum whose body
+// matches, oldest first.
+func (g *GitlabClient) userNotes(repo models.Repo, pullNum int, match func(body string) bool) ([]*gitlab.Note, error) {
+	user, _, err := g.Client.Users.CurrentUser()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting current user")
+	}
+
+	var userNotes []*gitlab.Note
+	nextPage := 0
+	for {
+		notes, resp, err := g.Client.Notes.ListMergeRequestNotes(repo.FullName, pullNum, &gitlab.ListMergeRequestNotesOptions{
+			OrderBy:     gitlab.String("created_at"),
+			Sort:        gitlab.String("asc"),
+			ListOptions: gitlab.ListOptions{Page: nextPage},
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "listing notes")
+		}
+		for _, note := range notes {
+			if note.System || !strings.EqualFold(note.Author.Username, user.Username) {
+				continue
+			}
+			if !match(note.Body) {
+				continue
+			}
+			userNotes = append(userNotes, note)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		nextPage = resp.NextPage
+	}
+	return userNotes, nil
+}
+
+// gitlabReactions maps our reactions to GitLab award emoji names.
+var gitlabReactions = map[models.CommentReaction]string{
+	models.SeenCommentReaction:    "eyes",
+	models.StartedCommentReaction: "rocket",
+	models.SuccessCommentReaction: "white_check_mark",
+	models.FailedCommentReaction:  "x",
+}
+
+// ReactToComment awards an emoji to a merge request note.
+func (g *GitlabClient) ReactToComment(repo models.Repo, pullNum int, commentID int64, reaction models.CommentReaction) error {
+	_, _, err := g.Client.AwardEmoji.CreateMergeRequestAwardEmojiOnNote(repo.FullName, pullNum, int(commentID), &gitlab.CreateAwardEmojiOptions{
+		Name: gitlabReactions[reaction],
+	})
+	return err
+}
+
 // PullIsApproved returns true if the merge request was approved.
 func (g *GitlabClient) PullIsApproved(repo models.Repo, pull models.PullRequest) (approvalStatus models.ApprovalStatus, err error) {
 	approvals, _, err := g.Client.MergeRequests.GetMergeRequestApprovals(repo.FullName, pull.Num)
@@ -193,7 +303,7 @@ func (g *GitlabClient) PullIsApproved(repo models.Repo, pull models.PullRequest)
 // See:
 // - https://gitlab.com/gitlab-org/gitlab-ee/issues/3169
 // - https://gitlab.com/gitlab-org/gitlab-ce/issues/42344
-func (g *GitlabClient) PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string) (bool, error) {
+func (g *GitlabClient) PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string, ignoredChecks []string) (bool, error) {
 	mr, _, err := g.Client.MergeRequests.GetMergeRequest(repo.FullName, pull.Num, nil)
 	if err != nil {
 		return false, err
@@ -216,6 +326,9 @@ func (g *GitlabClient) PullIsMergeable(repo models.Repo, pull models.PullRequest
 		if strings.HasPrefix(status.Name, fmt.Sprintf("%s/%s", vcsstatusname, command.Apply.String())) {
 			continue
 		}
+		if common.IsIgnoredCheck(status.Name, ignoredChecks) {
+			continue
+		}
 		if !status.AllowFailure && project.OnlyAllowMergeIfPipelineSucceeds && status.Status != "success" {
 			return false, nil
 		}
@@ -309,6 +422,56 @@ func (g *GitlabClient) MergePull(pull models.PullRequest, pullOptions models.Pul
 	return errors.Wrap(err, "unable to merge merge request, it may not be in a mergeable state")
 }
 
+// CreateOrUpdatePull opens a merge request in repo from head into base or, if
+// one is already open, updates its title and description.
+func (g *GitlabClient) CreateOrUpdatePull(repo models.Repo, head string, base string, title string, body string) (models.PullRequest, error) {
+	state := "opened"
+	mrs, _, err := g.Client.MergeRequests.ListProjectMergeRequests(repo.FullName, &gitlab.ListProjectMergeRequestsOptions{
+		State:        &state,
+		SourceBranch: &head,
+		TargetBranch: &base,
+	})
+	if err != nil {
+		return models.PullRequest{}, errors.Wrap(err, "listing merge requests")
+	}
+
+	var mr *gitlab.MergeRequest
+	if len(mrs) > 0 {
+		mr, _, err = g.Client.MergeRequests.UpdateMergeRequest(repo.FullName, mrs[0].IID, &gitlab.UpdateMergeRequestOptions{
+			Title:       &title,
+			Description: &body,
+		})
+		if err != nil {
+			return models.PullRequest{}, errors.Wrapf(err, "updating merge request %d", mrs[0].IID)
+		}
+	} else {
+		mr, _, err = g.Client.MergeRequests.CreateMergeRequest(repo.FullName, &gitlab.CreateMergeRequestOptions{
+			Title:        &title,
+			Description:  &body,
+			SourceBranch: &head,
+			TargetBranch: &base,
+		})
+		if err != nil {
+			return models.PullRequest{}, errors.Wrap(err, "creating merge request")
+		}
+	}
+
+	var author string
+	if mr.Author != nil {
+		author = mr.Author.Username
+	}
+	return models.PullRequest{
+		Num:        mr.IID,
+		HeadCommit: mr.SHA,
+		URL:        mr.WebURL,
+		HeadBranch: head,
+		BaseBranch: base,
+		Author:     author,
+		State:      models.OpenPullState,
+		BaseRepo:   repo,
+	}, nil
+}
+
 // MarkdownPullLink specifies the string used in a pull request comment to reference another pull request.
 func (g *GitlabClient) MarkdownPullLink(pull models.PullRequest) (string, error) {
 	return fmt.Sprintf("!%d", pull.Num), nil
@@ -357,6 +520,12 @@ func MustConstraint(constraint string) version.Constraints {
 	return c
 }
 
+// CheckHealth gets the user whose token Atlantis uses.
+func (g *GitlabClient) CheckHealth() error {
+	_, _, err := g.Client.Users.CurrentUser()
+	return err
+}
+
 // GetTeamNamesForUser returns the names of the teams or groups that the user belongs to (in the organization the repository belongs to).
 func (g *GitlabClient) GetTeamNamesForUser(repo models.Repo, user models.User) ([]string, error) {
 	return nil, nil
@@ -391,3 +560,135 @@ func (g *GitlabClient) GetCloneURL(VCSHostType models.VCSHostType, repo string)
 	}
 	return project.HTTPURLToRepo, nil
 }
+
+// ListGroupProjects returns the full names of the projects in group and its
+// subgroups, ex. group/subgroup/project. Archived projects are skipped since
+// they can't have merge requests.
+func (g *GitlabClient) ListGroupProjects(group string) ([]string, error) {
+	var projects []string
+	opts := &gitlab.ListGroupProjectsOptions{
+		ListOptions:      gitlab.ListOptions{Page: 1, PerPage: 100},
+		Archived:         gitlab.Bool(false),
+		IncludeSubGroups: gitlab.Bool(true),
+		Simple:           gitlab.Bool(true),
+	}
+	for {
+		page, resp, err := g.Client.Groups.ListGroupProjects(group, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range page {
+			projects = append(projects, p.PathWithNamespace)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return projects, nil
+}
+
+// ListOwnerRepos returns the full names of the projects in the group owner
+// and its subgroups or, if owner isn't a group, of the user owner's projects.
+func (g *GitlabClient) ListOwnerRepos(owner string) ([]string, error) {
+	projects, err := g.ListGroupProjects(owner)
+	if errResp, ok := err.(*gitlab.ErrorResponse); !ok || errResp.Response.StatusCode != http.StatusNotFound {
+		return projects, err
+	}
+
+	projects = nil
+	opts := &gitlab.ListProjectsOptions{
+		ListOptions: gitlab.ListOptions{Page: 1, PerPage: 100},
+		Archived:    gitlab.Bool(false),
+		Simple:      gitlab.Bool(true),
+	}
+	for {
+		page, resp, err := g.Client.Projects.ListUserProjects(owner, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range page {
+			projects = append(projects, p.PathWithNamespace)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return projects, nil
+}
+
+// HasGroupHook returns true if group has a webhook to hookURL. Group webhooks
+// are only available in GitLab Premium so if the hooks can't be listed, it
+// returns false.
+func (g *GitlabClient) HasGroupHook(group string, hookURL string) (bool, error) {
+	hooks, resp, err := g.Client.Groups.ListGroupHooks(group)
+	if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, h := range hooks {
+		if h.URL == hookURL {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// EnsureWebhook adds a webhook to hook.URL to project for the merge request
+// and comment events that Atlantis handles. If project already has one but
+// it's missing those events or doesn't verify SSL, it's updated.
+func (g *GitlabClient) EnsureWebhook(project string, hook Webhook) (WebhookChange, error) {
+	var existing *gitlab.ProjectHook
+	opts := &gitlab.ListProjectHooksOptions{Page: 1, PerPage: 100}
+	for existing == nil {
+		hooks, resp, err := g.Client.Projects.ListProjectHooks(project, opts)
+		if err != nil {
+			return WebhookUnchanged, err
+		}
+		for _, h := range hooks {
+			if h.URL == hook.URL {
+				existing = h
+				break
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	if existing == nil {
+		addOpts := &gitlab.AddProjectHookOptions{
+			URL:                   gitlab.String(hook.URL),
+			MergeRequestsEvents:   gitlab.Bool(true),
+			NoteEvents:            gitlab.Bool(true),
+			PushEvents:            gitlab.Bool(false),
+			EnableSSLVerification: gitlab.Bool(true),
+		}
+		if hook.Secret != "" {
+			addOpts.Token = gitlab.String(hook.Secret)
+		}
+		if _, _, err := g.Client.Projects.AddProjectHook(project, addOpts); err != nil {
+			return WebhookUnchanged, err
+		}
+		return WebhookCreated, nil
+	}
+
+	if existing.MergeRequestsEvents && existing.NoteEvents && existing.EnableSSLVerification && !hook.SyncSecret {
+		return WebhookUnchanged, nil
+	}
+	editOpts := &gitlab.EditProjectHookOptions{
+		URL:                   gitlab.String(hook.URL),
+		MergeRequestsEvents:   gitlab.Bool(true),
+		NoteEvents:            gitlab.Bool(true),
+		EnableSSLVerification: gitlab.Bool(true),
+		Token:                 gitlab.String(hook.Secret),
+	}
+	if _, _, err := g.Client.Projects.EditProjectHook(project, existing.ID, editOpts); err != nil {
+		return WebhookUnchanged, err
+	}
+	return WebhookUpdated, nil
+}
@@ -56,7 +56,7 @@ func TestNewGitlabClient_BaseURL(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.Hostname, func(t *testing.T) {
 			log := logging.NewNoopLogger(t)
-			client, err := NewGitlabClient(c.Hostname, "token", log)
+			client, err := NewGitlabClient(c.Hostname, "token", nil, log)
 			Ok(t, err)
 			Equals(t, c.ExpBaseURL, client.Client.BaseURL().String())
 		})
@@ -248,6 +248,57 @@ func TestGitlabClient_UpdateStatus(t *testing.T) {
 	}
 }
 
+func TestGitlabClient_HidePrevCommandComments(t *testing.T) {
+	// Only note 3 should be collapsed because it's by the Atlantis user, isn't
+	// a system note and has "plan" in the first line.
+	notesResp := `[
+	{"id": 1, "body": "Ran Plan for dir: x", "author": {"username": "someone-else"}},
+	{"id": 2, "body": "Ran Plan for dir: x", "author": {"username": "atlantis"}, "system": true},
+	{"id": 3, "body": "Ran Plan for dir: x\nasd", "author": {"username": "Atlantis"}},
+	{"id": 4, "body": "Ran Apply for dir: x", "author": {"username": "atlantis"}}
+]`
+	gotRequest := false
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.RequestURI {
+			case "GET /api/v4/user":
+				w.Write([]byte(`{"username": "atlantis"}`)) // nolint: errcheck
+			case "GET /api/v4/projects/runatlantis%2Fatlantis/merge_requests/1/notes?order_by=created_at&sort=asc":
+				w.Write([]byte(notesResp)) // nolint: errcheck
+			case "PUT /api/v4/projects/runatlantis%2Fatlantis/merge_requests/1/notes/3":
+				gotRequest = true
+				body, err := io.ReadAll(r.Body)
+				Ok(t, err)
+				exp := `{"body":"\u003cdetails\u003e\u003csummary\u003eSuperseded by a newer comment\u003c/summary\u003e\n\nRan Plan for dir: x\nasd\n\u003c/details\u003e"}`
+				Equals(t, exp, string(body))
+				defer r.Body.Close()  // nolint: errcheck
+				w.Write([]byte("{}")) // nolint: errcheck
+			case "GET /api/v4/":
+				// Rate limiter requests.
+				w.WriteHeader(http.StatusOK)
+			default:
+				t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+
+	internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL))
+	Ok(t, err)
+	client := &GitlabClient{
+		Client:  internalClient,
+		Version: nil,
+	}
+
+	repo := models.Repo{
+		FullName: "runatlantis/atlantis",
+		Owner:    "runatlantis",
+		Name:     "atlantis",
+	}
+	err = client.HidePrevCommandComments(repo, 1, "Plan", "")
+	Ok(t, err)
+	Assert(t, gotRequest, "expected to get the request")
+}
+
 func TestGitlabClient_PullIsMergeable(t *testing.T) {
 	gitlabClientUnderTest = true
 	vcsStatusName := "atlantis-test"
@@ -326,7 +377,7 @@ func TestGitlabClient_PullIsMergeable(t *testing.T) {
 				Num:        1,
 				BaseRepo:   repo,
 				HeadCommit: "sha",
-			}, vcsStatusName)
+			}, vcsStatusName, nil)
 			Ok(t, err)
 			Equals(t, c.expState, mergeable)
 		})
@@ -336,7 +387,7 @@ func TestGitlabClient_PullIsMergeable(t *testing.T) {
 func TestGitlabClient_MarkdownPullLink(t *testing.T) {
 	gitlabClientUnderTest = true
 	defer func() { gitlabClientUnderTest = false }()
-	client, err := NewGitlabClient("gitlab.com", "token", nil)
+	client, err := NewGitlabClient("gitlab.com", "token", nil, nil)
 	Ok(t, err)
 	pull := models.PullRequest{Num: 1}
 	s, _ := client.MarkdownPullLink(pull)
@@ -347,3 +398,193 @@ func TestGitlabClient_MarkdownPullLink(t *testing.T) {
 var mergeSuccess = `{"id":22461274,"iid":13,"project_id":4580910,"title":"Update main.tf","description":"","state":"merged","created_at":"2019-01-15T18:27:29.375Z","updated_at":"2019-01-25T17:28:01.437Z","merged_by":{"id":1755902,"name":"Luke Kysow","username":"lkysow","state":"active","avatar_url":"https://secure.gravatar.com/avatar/25fd57e71590fe28736624ff24d41c5f?s=80\u0026d=identicon","web_url":"https://gitlab.com/lkysow"},"merged_at":"2019-01-25T17:28:01.459Z","closed_by":null,"closed_at":null,"target_branch":"patch-1","source_branch":"patch-1-merger","upvotes":0,"downvotes":0,"author":{"id":1755902,"name":"Luke Kysow","username":"lkysow","state":"active","avatar_url":"https://secure.gravatar.com/avatar/25fd57e71590fe28736624ff24d41c5f?s=80\u0026d=identicon","web_url":"https://gitlab.com/lkysow"},"assignee":null,"source_project_id":4580910,"target_project_id":4580910,"labels":[],"work_in_progress":false,"milestone":null,"merge_when_pipeline_succeeds":false,"merge_status":"can_be_merged","sha":"cb86d70f464632bdfbe1bb9bc0f2f9d847a774a0","merge_commit_sha":"c9b336f1c71d3e64810b8cfa2abcfab232d6bff6","user_notes_count":0,"discussion_locked":null,"should_remove_source_branch":null,"force_remove_source_branch":false,"web_url":"https://gitlab.com/lkysow/atlantis-example/merge_requests/13","time_stats":{"time_estimate":0,"total_time_spent":0,"human_time_estimate":null,"human_total_time_spent":null},"squash":false,"subscribed":true,"changes_count":"1","latest_build_started_at":null,"latest_build_finished_at":null,"first_deployed_to_production_at":null,"pipeline":null,"diff_refs":{"base_sha":"67cb91d3f6198189f433c045154a885784ba6977","head_sha":"cb86d70f464632bdfbe1bb9bc0f2f9d847a774a0","start_sha":"67cb91d3f6198189f433c045154a885784ba6977"},"merge_error":null,"approvals_before_merge":null}`
 var pipelineSuccess = `{"id": 22461274,"iid": 13,"project_id": 4580910,"title": "Update main.tf","description": "","state": "opened","created_at": "2019-01-15T18:27:29.375Z","updated_at": "2019-01-25T17:28:01.437Z","merged_by": null,"merged_at": null,"closed_by": null,"closed_at": null,"target_branch": "patch-1","source_branch": "patch-1-merger","user_notes_count": 0,"upvotes": 0,"downvotes": 0,"author": {"id": 1755902,"name": "Luke Kysow","username": "lkysow","state": "active","avatar_url": "https://secure.gravatar.com/avatar/25fd57e71590fe28736624ff24d41c5f?s=80\u0026d=identicon","web_url": "https://gitlab.com/lkysow"},"assignee": null,"reviewers": [],"source_project_id": 4580910,"target_project_id": 4580910,"labels": [],"work_in_progress": false,"milestone": null,"merge_when_pipeline_succeeds": false,"merge_status": "can_be_merged","sha": "cb86d70f464632bdfbe1bb9bc0f2f9d847a774a0","merge_commit_sha": null,"squash_commit_sha": null,"discussion_locked": null,"should_remove_source_branch": null,"force_remove_source_branch": true,"reference": "!13","references": {"short": "!13","relative": "!13","full": "lkysow/atlantis-example!13"},"web_url": "https://gitlab.com/lkysow/atlantis-example/merge_requests/13","time_stats": {"time_estimate": 0,"total_time_spent": 0,"human_time_estimate": null,"human_total_time_spent": null},"squash": true,"task_completion_status": {"count": 0,"completed_count": 0},"has_conflicts": false,"blocking_discussions_resolved": true,"approvals_before_merge": null,"subscribed": false,"changes_count": "1","latest_build_started_at": "2019-01-15T18:27:29.375Z","latest_build_finished_at": "2019-01-25T17:28:01.437Z","first_deployed_to_production_at": null,"pipeline": {"id": 488598,"sha": "67cb91d3f6198189f433c045154a885784ba6977","ref": "patch-1-merger","status": "success","created_at": "2019-01-15T18:27:29.375Z","updated_at": "2019-01-25T17:28:01.437Z","web_url": "https://gitlab.com/lkysow/atlantis-example/-/pipelines/488598"},"head_pipeline": {"id": 488598,"sha": "67cb91d3f6198189f433c045154a885784ba6977","ref": "patch-1-merger","status": "success","created_at": "2019-01-15T18:27:29.375Z","updated_at": "2019-01-25T17:28:01.437Z","web_url": "https://gitlab.com/lkysow/atlantis-example/-/pipelines/488598","before_sha": "0000000000000000000000000000000000000000","tag": false,"yaml_errors": null,"user": {"id": 1755902,"name": "Luke Kysow","username": "lkysow","state": "active","avatar_url": "https://secure.gravatar.com/avatar/25fd57e71590fe28736624ff24d41c5f?s=80\u0026d=identicon","web_url": "https://gitlab.com/lkysow"},"started_at": "2019-01-15T18:27:29.375Z","finished_at": "2019-01-25T17:28:01.437Z","committed_at": null,"duration": 31,"coverage": null,"detailed_status": {"icon": "status_success","text": "passed","label": "passed","group": "success","tooltip": "passed","has_details": true,"details_path": "/lkysow/atlantis-example/-/pipelines/488598","illustration": null,"favicon": "/assets/ci_favicons/favicon_status_success-8451333011eee8ce9f2ab25dc487fe24a8758c694827a582f17f42b0a90446a2.png"}},"diff_refs": {"base_sha": "67cb91d3f6198189f433c045154a885784ba6977","head_sha": "cb86d70f464632bdfbe1bb9bc0f2f9d847a774a0","start_sha": "67cb91d3f6198189f433c045154a885784ba6977"},"merge_error": null,"first_contribution": false,"user": {"can_merge": true}}`
 var projectSuccess = `{"id": 4580910,"description": "","name": "atlantis-example","name_with_namespace": "lkysow / atlantis-example","path": "atlantis-example","path_with_namespace": "lkysow/atlantis-example","created_at": "2018-04-30T13:44:28.367Z","default_branch": "patch-1","tag_list": [],"ssh_url_to_repo": "git@gitlab.com:lkysow/atlantis-example.git","http_url_to_repo": "https://gitlab.com/lkysow/atlantis-example.git","web_url": "https://gitlab.com/lkysow/atlantis-example","readme_url": "https://gitlab.com/lkysow/atlantis-example/-/blob/main/README.md","avatar_url": "https://gitlab.com/uploads/-/system/project/avatar/4580910/avatar.png","forks_count": 0,"star_count": 7,"last_activity_at": "2021-06-29T21:10:43.968Z","namespace": {"id": 1,"name": "lkysow","path": "lkysow","kind": "group","full_path": "lkysow","parent_id": 1,"avatar_url": "/uploads/-/system/group/avatar/1651/platform.png","web_url": "https://gitlab.com/groups/lkysow"},"_links": {"self": "https://gitlab.com/api/v4/projects/4580910","issues": "https://gitlab.com/api/v4/projects/4580910/issues","merge_requests": "https://gitlab.com/api/v4/projects/4580910/merge_requests","repo_branches": "https://gitlab.com/api/v4/projects/4580910/repository/branches","labels": "https://gitlab.com/api/v4/projects/4580910/labels","events": "https://gitlab.com/api/v4/projects/4580910/events","members": "https://gitlab.com/api/v4/projects/4580910/members"},"packages_enabled": false,"empty_repo": false,"archived": false,"visibility": "private","resolve_outdated_diff_discussions": false,"container_registry_enabled": false,"container_expiration_policy": {"cadence": "1d","enabled": false,"keep_n": 10,"older_than": "90d","name_regex": ".*","name_regex_keep": null,"next_run_at": "2021-05-01T13:44:28.397Z"},"issues_enabled": true,"merge_requests_enabled": true,"wiki_enabled": false,"jobs_enabled": true,"snippets_enabled": true,"service_desk_enabled": false,"service_desk_address": null,"can_create_merge_request_in": true,"issues_access_level": "private","repository_access_level": "enabled","merge_requests_access_level": "enabled","forking_access_level": "enabled","wiki_access_level": "disabled","builds_access_level": "enabled","snippets_access_level": "enabled","pages_access_level": "private","operations_access_level": "disabled","analytics_access_level": "enabled","emails_disabled": null,"shared_runners_enabled": true,"lfs_enabled": false,"creator_id": 818,"import_status": "none","import_error": null,"open_issues_count": 0,"runners_token": "1234456","ci_default_git_depth": 50,"ci_forward_deployment_enabled": true,"public_jobs": true,"build_git_strategy": "fetch","build_timeout": 3600,"auto_cancel_pending_pipelines": "enabled","build_coverage_regex": null,"ci_config_path": "","shared_with_groups": [],"only_allow_merge_if_pipeline_succeeds": true,"allow_merge_on_skipped_pipeline": false,"restrict_user_defined_variables": false,"request_access_enabled": true,"only_allow_merge_if_all_discussions_are_resolved": true,"remove_source_branch_after_merge": true,"printing_merge_request_link_enabled": true,"merge_method": "merge","suggestion_commit_message": "","auto_devops_enabled": false,"auto_devops_deploy_strategy": "continuous","autoclose_referenced_issues": true,"repository_storage": "default","approvals_before_merge": 0,"mirror": false,"external_authorization_classification_label": null,"marked_for_deletion_at": null,"marked_for_deletion_on": null,"requirements_enabled": false,"compliance_frameworks": [],"permissions": {"project_access": null,"group_access": {"access_level": 50,"notification_level": 3}}}`
+
+func TestGitlabClient_ListGroupProjects(t *testing.T) {
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v4/groups/infra/projects?archived=false&include_subgroups=true&page=1&per_page=100&simple=true":
+				w.Header().Set("X-Next-Page", "2")
+				w.Write([]byte(`[{"path_with_namespace": "infra/network"}]`)) // nolint: errcheck
+			case "/api/v4/groups/infra/projects?archived=false&include_subgroups=true&page=2&per_page=100&simple=true":
+				w.Write([]byte(`[{"path_with_namespace": "infra/team/database"}]`)) // nolint: errcheck
+			case "/api/v4/":
+				// Rate limiter requests.
+				w.WriteHeader(http.StatusOK)
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	defer testServer.Close()
+
+	internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL))
+	Ok(t, err)
+	client := &GitlabClient{Client: internalClient}
+
+	projects, err := client.ListGroupProjects("infra")
+	Ok(t, err)
+	Equals(t, []string{"infra/network", "infra/team/database"}, projects)
+}
+
+func TestGitlabClient_HasGroupHook(t *testing.T) {
+	cases := []struct {
+		description string
+		code        int
+		glResponse  string
+		exp         bool
+	}{
+		{
+			"has hook",
+			http.StatusOK,
+			`[{"url": "https://other.com/hook"}, {"url": "https://atlantis.com/events"}]`,
+			true,
+		},
+		{
+			"doesn't have hook",
+			http.StatusOK,
+			`[{"url": "https://other.com/hook"}]`,
+			false,
+		},
+		{
+			"group hooks not available",
+			http.StatusNotFound,
+			`{"message": "404 Not Found"}`,
+			false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			testServer := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.RequestURI {
+					case "/api/v4/groups/infra/hooks":
+						w.WriteHeader(c.code)
+						w.Write([]byte(c.glResponse)) // nolint: errcheck
+					case "/api/v4/":
+						// Rate limiter requests.
+						w.WriteHeader(http.StatusOK)
+					default:
+						t.Errorf("got unexpected request at %q", r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+					}
+				}))
+			defer testServer.Close()
+
+			internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL))
+			Ok(t, err)
+			client := &GitlabClient{Client: internalClient}
+
+			has, err := client.HasGroupHook("infra", "https://atlantis.com/events")
+			Ok(t, err)
+			Equals(t, c.exp, has)
+		})
+	}
+}
+
+func TestGitlabClient_EnsureWebhook(t *testing.T) {
+	cases := []struct {
+		description string
+		hooks       string
+		syncSecret  bool
+		expChange   WebhookChange
+		expRequest  string
+		expBody     string
+	}{
+		{
+			"already has hook",
+			`[{"id": 1, "url": "https://atlantis.com/events", "merge_requests_events": true, "note_events": true, "enable_ssl_verification": true}]`,
+			false,
+			WebhookUnchanged,
+			"",
+			"",
+		},
+		{
+			"adds hook",
+			`[{"id": 1, "url": "https://other.com/hook"}]`,
+			false,
+			WebhookCreated,
+			"POST /api/v4/projects/infra%2Fnetwork/hooks",
+			`{"enable_ssl_verification":true,"merge_requests_events":true,"note_events":true,"push_events":false,"token":"secret","url":"https://atlantis.com/events"}`,
+		},
+		{
+			"updates hook missing events",
+			`[{"id": 1, "url": "https://atlantis.com/events", "merge_requests_events": true, "enable_ssl_verification": true}]`,
+			false,
+			WebhookUpdated,
+			"PUT /api/v4/projects/infra%2Fnetwork/hooks/1",
+			`{"enable_ssl_verification":true,"merge_requests_events":true,"note_events":true,"token":"secret","url":"https://atlantis.com/events"}`,
+		},
+		{
+			"syncs secret",
+			`[{"id": 1, "url": "https://atlantis.com/events", "merge_requests_events": true, "note_events": true, "enable_ssl_verification": true}]`,
+			true,
+			WebhookUpdated,
+			"PUT /api/v4/projects/infra%2Fnetwork/hooks/1",
+			`{"enable_ssl_verification":true,"merge_requests_events":true,"note_events":true,"token":"secret","url":"https://atlantis.com/events"}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			var gotRequest, gotBody string
+			testServer := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.Method + " " + r.RequestURI {
+					case "GET /api/v4/projects/infra%2Fnetwork/hooks?page=1&per_page=100":
+						w.Write([]byte(c.hooks)) // nolint: errcheck
+					case "POST /api/v4/projects/infra%2Fnetwork/hooks", "PUT /api/v4/projects/infra%2Fnetwork/hooks/1":
+						body, err := io.ReadAll(r.Body)
+						Ok(t, err)
+						gotRequest = r.Method + " " + r.RequestURI
+						gotBody = string(body)
+						w.Write([]byte(`{"id": 1}`)) // nolint: errcheck
+					case "GET /api/v4/":
+						// Rate limiter requests.
+						w.WriteHeader(http.StatusOK)
+					default:
+						t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+					}
+				}))
+			defer testServer.Close()
+
+			internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL))
+			Ok(t, err)
+			client := &GitlabClient{Client: internalClient}
+
+			change, err := client.EnsureWebhook("infra/network", Webhook{URL: "https://atlantis.com/events", Secret: "secret", SyncSecret: c.syncSecret})
+			Ok(t, err)
+			Equals(t, c.expChange, change)
+			Equals(t, c.expRequest, gotRequest)
+			Equals(t, c.expBody, gotBody)
+		})
+	}
+}
+
+func TestGitlabClient_ListOwnerRepos(t *testing.T) {
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v4/groups/jdoe/projects?archived=false&include_subgroups=true&page=1&per_page=100&simple=true":
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"message": "404 Group Not Found"}`)) // nolint: errcheck
+			case "/api/v4/users/jdoe/projects?archived=false&page=1&per_page=100&simple=true":
+				w.Write([]byte(`[{"path_with_namespace": "jdoe/infra"}]`)) // nolint: errcheck
+			case "/api/v4/":
+				// Rate limiter requests.
+				w.WriteHeader(http.StatusOK)
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	defer testServer.Close()
+
+	internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL))
+	Ok(t, err)
+	client := &GitlabClient{Client: internalClient}
+
+	projects, err := client.ListOwnerRepos("jdoe")
+	Ok(t, err)
+	Equals(t, []string{"jdoe/infra"}, projects)
+}
@@ -0,0 +1,9 @@
+package vcs
+
+// HealthChecker is implemented by the clients of VCS hosts whose API is
+// checked by Atlantis's readiness probe.
+type HealthChecker interface {
+	// CheckHealth returns an error if the VCS host's API can't be reached or
+	// rejects Atlantis's credentials.
+	CheckHealth() error
+}
@@ -124,7 +124,7 @@ func (c *InstrumentedClient) CreateComment(repo models.Repo, pullNum int, commen
 	executionSuccess.Inc(1)
 	return nil
 }
-func (c *InstrumentedClient) HidePrevCommandComments(repo models.Repo, pullNum int, command string) error {
+func (c *InstrumentedClient) HidePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
 	scope := c.StatsScope.SubScope("hide_prev_plan_comments")
 	logger := c.Logger.WithHistory(fmtLogSrc(repo, pullNum)...)
 
@@ -134,7 +134,7 @@ func (c *InstrumentedClient) HidePrevCommandComments(repo models.Repo, pullNum i
 	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
 	executionError := scope.Counter(metrics.ExecutionErrorMetric)
 
-	if err := c.Client.HidePrevCommandComments(repo, pullNum, command); err != nil {
+	if err := c.Client.HidePrevCommandComments(repo, pullNum, command, dir); err != nil {
 		executionError.Inc(1)
 		logger.Err("Unable to hide previous %s comments, error: %s", command, err.Error())
 		return err
@@ -144,6 +144,63 @@ func (c *InstrumentedClient) HidePrevCommandComments(repo models.Repo, pullNum i
 	return nil
 
 }
+func (c *InstrumentedClient) DeletePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
+	scope := c.StatsScope.SubScope("delete_prev_plan_comments")
+	logger := c.Logger.WithHistory(fmtLogSrc(repo, pullNum)...)
+
+	executionTime := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer executionTime.Stop()
+
+	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
+	executionError := scope.Counter(metrics.ExecutionErrorMetric)
+
+	if err := c.Client.DeletePrevCommandComments(repo, pullNum, command, dir); err != nil {
+		executionError.Inc(1)
+		logger.Err("Unable to delete previous %s comments, error: %s", command, err.Error())
+		return err
+	}
+
+	executionSuccess.Inc(1)
+	return nil
+}
+func (c *InstrumentedClient) ReactToComment(repo models.Repo, pullNum int, commentID int64, reaction models.CommentReaction) error {
+	scope := c.StatsScope.SubScope("react_to_comment")
+	logger := c.Logger.WithHistory(fmtLogSrc(repo, pullNum)...)
+
+	executionTime := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer executionTime.Stop()
+
+	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
+	executionError := scope.Counter(metrics.ExecutionErrorMetric)
+
+	if err := c.Client.ReactToComment(repo, pullNum, commentID, reaction); err != nil {
+		executionError.Inc(1)
+		logger.Err("Unable to add %s reaction to comment %d, error: %s", reaction, commentID, err.Error())
+		return err
+	}
+
+	executionSuccess.Inc(1)
+	return nil
+}
+func (c *InstrumentedClient) UpsertComment(repo models.Repo, pullNum int, comment string, marker string) error {
+	scope := c.StatsScope.SubScope("upsert_comment")
+	logger := c.Logger.WithHistory(fmtLogSrc(repo, pullNum)...)
+
+	executionTime := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer executionTime.Stop()
+
+	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
+	executionError := scope.Counter(metrics.ExecutionErrorMetric)
+
+	if err := c.Client.UpsertComment(repo, pullNum, comment, marker); err != nil {
+		executionError.Inc(1)
+		logger.Err("Unable to upsert comment, error: %s", err.Error())
+		return err
+	}
+
+	executionSuccess.Inc(1)
+	return nil
+}
 func (c *InstrumentedClient) PullIsApproved(repo models.Repo, pull models.PullRequest) (models.ApprovalStatus, error) {
 	scope := c.StatsScope.SubScope("pull_is_approved")
 	logger := c.Logger.WithHistory(fmtLogSrc(repo, pull.Num)...)
@@ -166,7 +223,7 @@ func (c *InstrumentedClient) PullIsApproved(repo models.Repo, pull models.PullRe
 	return approved, err
 
 }
-func (c *InstrumentedClient) PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string) (bool, error) {
+func (c *InstrumentedClient) PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string, ignoredChecks []string) (bool, error) {
 	scope := c.StatsScope.SubScope("pull_is_mergeable")
 	logger := c.Logger.WithHistory(fmtLogSrc(repo, pull.Num)...)
 
@@ -176,7 +233,7 @@ func (c *InstrumentedClient) PullIsMergeable(repo models.Repo, pull models.PullR
 	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
 	executionError := scope.Counter(metrics.ExecutionErrorMetric)
 
-	mergeable, err := c.Client.PullIsMergeable(repo, pull, vcsstatusname)
+	mergeable, err := c.Client.PullIsMergeable(repo, pull, vcsstatusname, ignoredChecks)
 
 	if err != nil {
 		executionError.Inc(1)
@@ -228,6 +285,27 @@ func (c *InstrumentedClient) MergePull(pull models.PullRequest, pullOptions mode
 
 }
 
+func (c *InstrumentedClient) CreateOrUpdatePull(repo models.Repo, head string, base string, title string, body string) (models.PullRequest, error) {
+	scope := c.StatsScope.SubScope("create_or_update_pull")
+	logger := c.Logger.WithHistory("repository", repo.FullName, "head-branch", head)
+
+	executionTime := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer executionTime.Stop()
+
+	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
+	executionError := scope.Counter(metrics.ExecutionErrorMetric)
+
+	pull, err := c.Client.CreateOrUpdatePull(repo, head, base, title, body)
+	if err != nil {
+		executionError.Inc(1)
+		logger.Err("Unable to create or update pull, error: %s", err.Error())
+		return pull, err
+	}
+
+	executionSuccess.Inc(1)
+	return pull, nil
+}
+
 // taken from other parts of the code, would be great to have this in a shared spot
 func fmtLogSrc(repo models.Repo, pullNum int) []interface{} {
 	return []interface{}{
@@ -14,6 +14,7 @@
 package vcs
 
 import (
+	"github.com/google/go-github/v31/github"
 	"github.com/runatlantis/atlantis/server/events/models"
 )
 
@@ -23,9 +24,16 @@ type ClientProxy struct {
 	// clients maps from the vcs host type to the client that implements the
 	// api for that host type, ex. github -> github client.
 	clients map[models.VCSHostType]Client
+	// hostClients maps from a hostname to the client for that host. They
+	// take precedence over clients so that, ex. both github.com and a GitHub
+	// Enterprise host can be used.
+	hostClients map[string]Client
+	// repoClients takes precedence over hostClients and clients for the
+	// repos that it has clients for. Nil if not set.
+	repoClients RepoClients
 }
 
-func NewClientProxy(githubClient Client, gitlabClient Client, bitbucketCloudClient Client, bitbucketServerClient Client, azuredevopsClient Client) *ClientProxy {
+func NewClientProxy(githubClient Client, gitlabClient Client, bitbucketCloudClient Client, bitbucketServerClient Client, azuredevopsClient Client, gerritClient Client) *ClientProxy {
 	if githubClient == nil {
 		githubClient = &NotConfiguredVCSClient{}
 	}
@@ -41,6 +49,9 @@ func NewClientProxy(githubClient Client, gitlabClient Client, bitbucketCloudClie
 	if azuredevopsClient == nil {
 		azuredevopsClient = &NotConfiguredVCSClient{}
 	}
+	if gerritClient == nil {
+		gerritClient = &NotConfiguredVCSClient{}
+	}
 	return &ClientProxy{
 		clients: map[models.VCSHostType]Client{
 			models.Github:          githubClient,
@@ -48,54 +59,189 @@ func NewClientProxy(githubClient Client, gitlabClient Client, bitbucketCloudClie
 			models.BitbucketCloud:  bitbucketCloudClient,
 			models.BitbucketServer: bitbucketServerClient,
 			models.AzureDevops:     azuredevopsClient,
+			models.Gerrit:          gerritClient,
 		},
 	}
 }
 
+// AddHostClient makes the proxy use client for repos on hostname instead of
+// the client for their VCS host type.
+func (d *ClientProxy) AddHostClient(hostname string, client Client) {
+	if d.hostClients == nil {
+		d.hostClients = make(map[string]Client)
+	}
+	d.hostClients[hostname] = client
+}
+
+// SetRepoClients makes the proxy use the clients of repoClients for the
+// repos that it has clients for, ex. the repos of tenants with their own
+// credentials.
+func (d *ClientProxy) SetRepoClients(repoClients RepoClients) {
+	d.repoClients = repoClients
+}
+
+// client returns the client for repo, which is the client of its VCS host
+// unless repoClients has one for it.
+func (d *ClientProxy) client(repo models.Repo) (Client, error) {
+	if d.repoClients != nil {
+		client, err := d.repoClients.Client(repo)
+		if err != nil || client != nil {
+			return client, err
+		}
+	}
+	if client, ok := d.hostClients[repo.VCSHost.Hostname]; ok {
+		return client, nil
+	}
+	return d.clients[repo.VCSHost.Type], nil
+}
+
 func (d *ClientProxy) GetModifiedFiles(repo models.Repo, pull models.PullRequest) ([]string, error) {
-	return d.clients[repo.VCSHost.Type].GetModifiedFiles(repo, pull)
+	client, err := d.client(repo)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetModifiedFiles(repo, pull)
 }
 
 func (d *ClientProxy) CreateComment(repo models.Repo, pullNum int, comment string, command string) error {
-	return d.clients[repo.VCSHost.Type].CreateComment(repo, pullNum, comment, command)
+	client, err := d.client(repo)
+	if err != nil {
+		return err
+	}
+	return client.CreateComment(repo, pullNum, comment, command)
+}
+
+func (d *ClientProxy) HidePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
+	client, err := d.client(repo)
+	if err != nil {
+		return err
+	}
+	return client.HidePrevCommandComments(repo, pullNum, command, dir)
 }
 
-func (d *ClientProxy) HidePrevCommandComments(repo models.Repo, pullNum int, command string) error {
-	return d.clients[repo.VCSHost.Type].HidePrevCommandComments(repo, pullNum, command)
+func (d *ClientProxy) DeletePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
+	client, err := d.client(repo)
+	if err != nil {
+		return err
+	}
+	return client.DeletePrevCommandComments(repo, pullNum, command, dir)
+}
+
+func (d *ClientProxy) ReactToComment(repo models.Repo, pullNum int, commentID int64, reaction models.CommentReaction) error {
+	client, err := d.client(repo)
+	if err != nil {
+		return err
+	}
+	return client.ReactToComment(repo, pullNum, commentID, reaction)
+}
+
+func (d *ClientProxy) UpsertComment(repo models.Repo, pullNum int, comment string, marker string) error {
+	client, err := d.client(repo)
+	if err != nil {
+		return err
+	}
+	return client.UpsertComment(repo, pullNum, comment, marker)
 }
 
 func (d *ClientProxy) PullIsApproved(repo models.Repo, pull models.PullRequest) (models.ApprovalStatus, error) {
-	return d.clients[repo.VCSHost.Type].PullIsApproved(repo, pull)
+	client, err := d.client(repo)
+	if err != nil {
+		return models.ApprovalStatus{}, err
+	}
+	return client.PullIsApproved(repo, pull)
 }
 
-func (d *ClientProxy) PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string) (bool, error) {
-	return d.clients[repo.VCSHost.Type].PullIsMergeable(repo, pull, vcsstatusname)
+func (d *ClientProxy) PullIsMergeable(repo models.Repo, pull models.PullRequest, vcsstatusname string, ignoredChecks []string) (bool, error) {
+	client, err := d.client(repo)
+	if err != nil {
+		return false, err
+	}
+	return client.PullIsMergeable(repo, pull, vcsstatusname, ignoredChecks)
 }
 
 func (d *ClientProxy) UpdateStatus(repo models.Repo, pull models.PullRequest, state models.CommitStatus, src string, description string, url string) error {
-	return d.clients[repo.VCSHost.Type].UpdateStatus(repo, pull, state, src, description, url)
+	client, err := d.client(repo)
+	if err != nil {
+		return err
+	}
+	return client.UpdateStatus(repo, pull, state, src, description, url)
 }
 
 func (d *ClientProxy) MergePull(pull models.PullRequest, pullOptions models.PullRequestOptions) error {
-	return d.clients[pull.BaseRepo.VCSHost.Type].MergePull(pull, pullOptions)
+	client, err := d.client(pull.BaseRepo)
+	if err != nil {
+		return err
+	}
+	return client.MergePull(pull, pullOptions)
+}
+
+func (d *ClientProxy) CreateOrUpdatePull(repo models.Repo, head string, base string, title string, body string) (models.PullRequest, error) {
+	client, err := d.client(repo)
+	if err != nil {
+		return models.PullRequest{}, err
+	}
+	return client.CreateOrUpdatePull(repo, head, base, title, body)
 }
 
 func (d *ClientProxy) MarkdownPullLink(pull models.PullRequest) (string, error) {
-	return d.clients[pull.BaseRepo.VCSHost.Type].MarkdownPullLink(pull)
+	client, err := d.client(pull.BaseRepo)
+	if err != nil {
+		return "", err
+	}
+	return client.MarkdownPullLink(pull)
 }
 
 func (d *ClientProxy) GetTeamNamesForUser(repo models.Repo, user models.User) ([]string, error) {
-	return d.clients[repo.VCSHost.Type].GetTeamNamesForUser(repo, user)
+	client, err := d.client(repo)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetTeamNamesForUser(repo, user)
 }
 
 func (d *ClientProxy) DownloadRepoConfigFile(pull models.PullRequest) (bool, []byte, error) {
-	return d.clients[pull.BaseRepo.VCSHost.Type].DownloadRepoConfigFile(pull)
+	client, err := d.client(pull.BaseRepo)
+	if err != nil {
+		return false, nil, err
+	}
+	return client.DownloadRepoConfigFile(pull)
 }
 
 func (d *ClientProxy) SupportsSingleFileDownload(repo models.Repo) bool {
-	return d.clients[repo.VCSHost.Type].SupportsSingleFileDownload(repo)
+	client, err := d.client(repo)
+	if err != nil {
+		// Calls for the repo will fail with err anyway.
+		return false
+	}
+	return client.SupportsSingleFileDownload(repo)
 }
 
 func (d *ClientProxy) GetCloneURL(VCSHostType models.VCSHostType, repo string) (string, error) {
 	return d.clients[VCSHostType].GetCloneURL(VCSHostType, repo)
 }
+
+// GithubPullRequestGetterProxy gets GitHub pull requests with the client for
+// the repo's hostname, or with Default if there isn't one.
+type GithubPullRequestGetterProxy struct {
+	Default GithubPullRequestGetter
+	Hosts   map[string]GithubPullRequestGetter
+	// Repos takes precedence over Default and Hosts for the repos that it
+	// has GitHub clients for. Nil if not set.
+	Repos RepoClients
+}
+
+func (g *GithubPullRequestGetterProxy) GetPullRequest(repo models.Repo, pullNum int) (*github.PullRequest, error) {
+	if g.Repos != nil {
+		client, err := g.Repos.Client(repo)
+		if err != nil {
+			return nil, err
+		}
+		if getter, ok := client.(GithubPullRequestGetter); ok {
+			return getter.GetPullRequest(repo, pullNum)
+		}
+	}
+	if getter, ok := g.Hosts[repo.VCSHost.Hostname]; ok {
+		return getter.GetPullRequest(repo, pullNum)
+	}
+	return g.Default.GetPullRequest(repo, pullNum)
+}
@@ -0,0 +1,79 @@
+package vcs_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestClientProxy_AddHostClient(t *testing.T) {
+	RegisterMockTestingT(t)
+	githubClient := mocks.NewMockClient()
+	gheClient := mocks.NewMockClient()
+	proxy := vcs.NewClientProxy(githubClient, nil, nil, nil, nil, nil)
+	proxy.AddHostClient("github.mycompany.com", gheClient)
+
+	githubRepo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Hostname: "github.com", Type: models.Github}}
+	gheRepo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Hostname: "github.mycompany.com", Type: models.Github}}
+
+	Ok(t, proxy.CreateComment(githubRepo, 1, "comment", ""))
+	githubClient.VerifyWasCalledOnce().CreateComment(githubRepo, 1, "comment", "")
+	gheClient.VerifyWasCalled(Never()).CreateComment(githubRepo, 1, "comment", "")
+
+	Ok(t, proxy.CreateComment(gheRepo, 2, "comment", ""))
+	gheClient.VerifyWasCalledOnce().CreateComment(gheRepo, 2, "comment", "")
+	githubClient.VerifyWasCalled(Never()).CreateComment(gheRepo, 2, "comment", "")
+}
+
+func TestClientProxy_SetRepoClients(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	tokenFile := filepath.Join(tmp, "token")
+	Ok(t, os.WriteFile(tokenFile, []byte("token1\n"), 0600))
+
+	githubClient := mocks.NewMockClient()
+	var created []string
+	tenantClients := &vcs.TenantClients{
+		GlobalCfg: valid.NewGlobalCfgStore(valid.GlobalCfg{
+			Tenants: []valid.Tenant{{
+				Name:         "payments",
+				RepoIDs:      []string{"github.com/payments/repo"},
+				VCSUser:      "payments-bot",
+				VCSTokenFile: tokenFile,
+			}},
+		}),
+		NewClient: func(repo models.Repo, user string, token string) (vcs.Client, error) {
+			created = append(created, user+":"+token)
+			return mocks.NewMockClient(), nil
+		},
+	}
+	proxy := vcs.NewClientProxy(githubClient, nil, nil, nil, nil, nil)
+	proxy.SetRepoClients(tenantClients)
+
+	otherRepo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Hostname: "github.com", Type: models.Github}}
+	tenantRepo := models.Repo{FullName: "payments/repo", VCSHost: models.VCSHost{Hostname: "github.com", Type: models.Github}}
+
+	Ok(t, proxy.CreateComment(otherRepo, 1, "comment", ""))
+	githubClient.VerifyWasCalledOnce().CreateComment(otherRepo, 1, "comment", "")
+
+	Ok(t, proxy.CreateComment(tenantRepo, 2, "comment", ""))
+	Ok(t, proxy.CreateComment(tenantRepo, 3, "comment", ""))
+	githubClient.VerifyWasCalled(Never()).CreateComment(tenantRepo, 2, "comment", "")
+	Equals(t, []string{"payments-bot:token1"}, created)
+
+	// A rotated token gets a new client.
+	Ok(t, os.WriteFile(tokenFile, []byte("token2"), 0600))
+	Ok(t, proxy.CreateComment(tenantRepo, 4, "comment", ""))
+	Equals(t, []string{"payments-bot:token1", "payments-bot:token2"}, created)
+
+	Ok(t, os.Remove(tokenFile))
+	ErrContains(t, "reading vcs_token_file of tenant payments", proxy.CreateComment(tenantRepo, 5, "comment", ""))
+}
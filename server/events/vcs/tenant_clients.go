@@ -0,0 +1,70 @@
+package vcs
+
+import (
+	"sync"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// RepoClients returns the clients of repos that don't use the client of
+// their VCS host.
+type RepoClients interface {
+	// Client returns the client for repo or nil if it uses its VCS host's
+	// client.
+	Client(repo models.Repo) (Client, error)
+}
+
+// TenantClients returns clients with the credentials of the tenants that set
+// vcs_token_file in the server-side repo config for their repos. Clients are
+// reused until the tenant's token changes.
+type TenantClients struct {
+	GlobalCfg *valid.GlobalCfgStore
+	// NewClient returns a client for the VCS host of repo that authenticates
+	// as user with token.
+	NewClient func(repo models.Repo, user string, token string) (Client, error)
+
+	mu      sync.Mutex
+	clients map[tenantClientKey]tenantClient
+}
+
+// tenantClientKey identifies a tenant's client for a VCS host.
+type tenantClientKey struct {
+	tenant   string
+	hostname string
+}
+
+type tenantClient struct {
+	user   string
+	token  string
+	client Client
+}
+
+// Client returns the client of repo's tenant or nil if it doesn't have a
+// tenant with its own credentials.
+func (t *TenantClients) Client(repo models.Repo) (Client, error) {
+	tenant := t.GlobalCfg.Get().Tenant(repo.ID())
+	if tenant == nil || tenant.VCSTokenFile == "" {
+		return nil, nil
+	}
+	token, err := tenant.VCSToken()
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := tenantClientKey{tenant: tenant.Name, hostname: repo.VCSHost.Hostname}
+	if c, ok := t.clients[key]; ok && c.user == tenant.VCSUser && c.token == token {
+		return c.client, nil
+	}
+	client, err := t.NewClient(repo, tenant.VCSUser, token)
+	if err != nil {
+		return nil, err
+	}
+	if t.clients == nil {
+		t.clients = make(map[tenantClientKey]tenantClient)
+	}
+	t.clients[key] = tenantClient{user: tenant.VCSUser, token: token, client: client}
+	return client, nil
+}
@@ -0,0 +1,84 @@
+package vcs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// TransportConfig configures how Atlantis connects to a VCS host, both from
+// its API client and from git.
+type TransportConfig struct {
+	// CABundleFile is the path to a PEM file of certificate authorities to
+	// trust in addition to the system's. Empty if none.
+	CABundleFile string
+	// ProxyURL is the URL of the proxy to connect through. If empty, the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY env vars are used.
+	ProxyURL string
+}
+
+// IsDefault returns true if t doesn't change how Atlantis connects.
+func (t TransportConfig) IsDefault() bool {
+	return t.CABundleFile == "" && t.ProxyURL == ""
+}
+
+// Transport returns the http.RoundTripper that API clients should use.
+func (t TransportConfig) Transport() (http.RoundTripper, error) {
+	if t.IsDefault() {
+		return http.DefaultTransport, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if t.ProxyURL != "" {
+		proxyURL, err := ParseProxyURL(t.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if t.CABundleFile != "" {
+		bundle, err := os.ReadFile(t.CABundleFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading CA bundle")
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no PEM encoded certificates found in %s", t.CABundleFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return transport, nil
+}
+
+// GitEnv returns the environment variables that make git connect the same
+// way as the API client.
+func (t TransportConfig) GitEnv() []string {
+	var env []string
+	if t.CABundleFile != "" {
+		env = append(env, "GIT_SSL_CAINFO="+t.CABundleFile)
+	}
+	if t.ProxyURL != "" {
+		// git only reads the lowercase http_proxy.
+		env = append(env, "http_proxy="+t.ProxyURL, "https_proxy="+t.ProxyURL, "HTTPS_PROXY="+t.ProxyURL)
+	}
+	return env
+}
+
+// ParseProxyURL parses the URL of an HTTP, HTTPS or SOCKS5 proxy.
+func ParseProxyURL(proxy string) (*url.URL, error) {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing proxy URL")
+	}
+	if (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") || u.Host == "" {
+		return nil, fmt.Errorf("proxy URL %q must be like http://proxy.example.com:3128", proxy)
+	}
+	return u, nil
+}
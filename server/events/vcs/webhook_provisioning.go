@@ -0,0 +1,43 @@
+package vcs
+
+// WebhookProvisioner is implemented by the clients of VCS hosts that Atlantis
+// can create its own webhooks on.
+type WebhookProvisioner interface {
+	// ListOwnerRepos returns the full names of the repos owned by owner, ex.
+	// a GitHub organization or user or a GitLab group, including its
+	// subgroups, or user.
+	ListOwnerRepos(owner string) ([]string, error)
+	// EnsureWebhook creates hook on repo, or updates the repo's webhook to
+	// hook.URL if its events or settings differ from the ones Atlantis needs.
+	EnsureWebhook(repo string, hook Webhook) (WebhookChange, error)
+}
+
+// Webhook is a webhook to Atlantis.
+type Webhook struct {
+	// URL is Atlantis's events endpoint.
+	URL    string
+	Secret string
+	// SyncSecret updates the secret of an existing webhook even if nothing
+	// else changed. VCS hosts don't return webhook secrets so otherwise a
+	// changed secret wouldn't be noticed.
+	SyncSecret bool
+}
+
+// WebhookChange is what EnsureWebhook did.
+type WebhookChange int
+
+const (
+	WebhookUnchanged WebhookChange = iota
+	WebhookCreated
+	WebhookUpdated
+)
+
+func (c WebhookChange) String() string {
+	switch c {
+	case WebhookCreated:
+		return "created"
+	case WebhookUpdated:
+		return "updated"
+	}
+	return "unchanged"
+}
@@ -0,0 +1,126 @@
+package webhooks
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// SNSPublisher is the part of the SNS client that SNSWebhook uses.
+type SNSPublisher interface {
+	Publish(input *sns.PublishInput) (*sns.PublishOutput, error)
+}
+
+// SNSWebhook publishes webhooks to an AWS SNS topic.
+type SNSWebhook struct {
+	Client         SNSPublisher
+	WorkspaceRegex *regexp.Regexp
+	TopicARN       string
+}
+
+// NewSNS returns a webhook that publishes to the topic with topicARN in the
+// topic's region. Credentials come from the default AWS credential chain.
+func NewSNS(r *regexp.Regexp, topicARN string) (*SNSWebhook, error) {
+	parsed, err := arn.Parse(topicARN)
+	if err != nil || parsed.Service != "sns" {
+		return nil, fmt.Errorf("invalid SNS topic ARN %q", topicARN)
+	}
+	sess, err := newAWSSession(parsed.Region)
+	if err != nil {
+		return nil, err
+	}
+	return &SNSWebhook{
+		Client:         sns.New(sess),
+		WorkspaceRegex: r,
+		TopicARN:       topicARN,
+	}, nil
+}
+
+// Send publishes the webhook if the workspace matches the regex.
+func (s *SNSWebhook) Send(log logging.SimpleLogging, applyResult ApplyResult) error {
+	if !s.WorkspaceRegex.MatchString(applyResult.Workspace) {
+		return nil
+	}
+	msg, err := marshalApplyMessage(applyResult)
+	if err != nil {
+		return err
+	}
+	_, err = s.Client.Publish(&sns.PublishInput{
+		TopicArn: aws.String(s.TopicARN),
+		Message:  aws.String(string(msg)),
+	})
+	return err
+}
+
+// SQSSender is the part of the SQS client that SQSWebhook uses.
+type SQSSender interface {
+	SendMessage(input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error)
+}
+
+// SQSWebhook sends webhooks to an AWS SQS queue.
+type SQSWebhook struct {
+	Client         SQSSender
+	WorkspaceRegex *regexp.Regexp
+	QueueURL       string
+}
+
+// sqsHostRegex captures the region from the host of an SQS queue URL, ex.
+// sqs.us-east-1.amazonaws.com.
+var sqsHostRegex = regexp.MustCompile(`^sqs\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+
+// NewSQS returns a webhook that sends to the queue at queueURL in the queue's
+// region. Credentials come from the default AWS credential chain.
+func NewSQS(r *regexp.Regexp, queueURL string) (*SQSWebhook, error) {
+	u, err := url.Parse(queueURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SQS queue URL %q", queueURL)
+	}
+	match := sqsHostRegex.FindStringSubmatch(strings.ToLower(u.Host))
+	if match == nil {
+		return nil, fmt.Errorf("invalid SQS queue URL %q, must be like https://sqs.<region>.amazonaws.com/<account>/<queue>", queueURL)
+	}
+	sess, err := newAWSSession(match[1])
+	if err != nil {
+		return nil, err
+	}
+	return &SQSWebhook{
+		Client:         sqs.New(sess),
+		WorkspaceRegex: r,
+		QueueURL:       queueURL,
+	}, nil
+}
+
+// Send sends the webhook if the workspace matches the regex.
+func (s *SQSWebhook) Send(log logging.SimpleLogging, applyResult ApplyResult) error {
+	if !s.WorkspaceRegex.MatchString(applyResult.Workspace) {
+		return nil
+	}
+	msg, err := marshalApplyMessage(applyResult)
+	if err != nil {
+		return err
+	}
+	_, err = s.Client.SendMessage(&sqs.SendMessageInput{
+		QueueUrl:    aws.String(s.QueueURL),
+		MessageBody: aws.String(string(msg)),
+	})
+	return err
+}
+
+func newAWSSession(region string) (*session.Session, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            aws.Config{Region: aws.String(region)},
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating AWS session: %s", err)
+	}
+	return sess, nil
+}
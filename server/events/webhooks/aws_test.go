@@ -0,0 +1,87 @@
+package webhooks_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/webhooks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakeSNS struct {
+	inputs []*sns.PublishInput
+}
+
+func (f *fakeSNS) Publish(input *sns.PublishInput) (*sns.PublishOutput, error) {
+	f.inputs = append(f.inputs, input)
+	return &sns.PublishOutput{}, nil
+}
+
+type fakeSQS struct {
+	inputs []*sqs.SendMessageInput
+}
+
+func (f *fakeSQS) SendMessage(input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	f.inputs = append(f.inputs, input)
+	return &sqs.SendMessageOutput{}, nil
+}
+
+var testApplyResult = webhooks.ApplyResult{
+	Workspace: "production",
+	Repo:      models.Repo{FullName: "owner/repo"},
+	Pull:      models.PullRequest{Num: 1, URL: "https://github.com/owner/repo/pull/1"},
+	User:      models.User{Username: "user"},
+	Success:   true,
+	Directory: "dir",
+}
+
+const testApplyMessage = `{"event":"apply","repo":"owner/repo","pull":1,"pull_url":"https://github.com/owner/repo/pull/1","user":"user","directory":"dir","workspace":"production","success":true}`
+
+func TestSNSWebhook_Send(t *testing.T) {
+	client := &fakeSNS{}
+	hook := webhooks.SNSWebhook{
+		Client:         client,
+		WorkspaceRegex: regexp.MustCompile("prod.*"),
+		TopicARN:       "arn:aws:sns:us-east-1:123456789012:atlantis",
+	}
+	Ok(t, hook.Send(logging.NewNoopLogger(t), testApplyResult))
+	Ok(t, hook.Send(logging.NewNoopLogger(t), webhooks.ApplyResult{Workspace: "staging"}))
+	Equals(t, []*sns.PublishInput{{
+		TopicArn: aws.String("arn:aws:sns:us-east-1:123456789012:atlantis"),
+		Message:  aws.String(testApplyMessage),
+	}}, client.inputs)
+}
+
+func TestSQSWebhook_Send(t *testing.T) {
+	client := &fakeSQS{}
+	hook := webhooks.SQSWebhook{
+		Client:         client,
+		WorkspaceRegex: regexp.MustCompile("prod.*"),
+		QueueURL:       "https://sqs.us-east-1.amazonaws.com/123456789012/atlantis",
+	}
+	Ok(t, hook.Send(logging.NewNoopLogger(t), testApplyResult))
+	Ok(t, hook.Send(logging.NewNoopLogger(t), webhooks.ApplyResult{Workspace: "staging"}))
+	Equals(t, []*sqs.SendMessageInput{{
+		QueueUrl:    aws.String("https://sqs.us-east-1.amazonaws.com/123456789012/atlantis"),
+		MessageBody: aws.String(testApplyMessage),
+	}}, client.inputs)
+}
+
+func TestNewSNS_InvalidARN(t *testing.T) {
+	_, err := webhooks.NewSNS(regexp.MustCompile(".*"), "arn:aws:sqs:us-east-1:123456789012:atlantis")
+	ErrEquals(t, `invalid SNS topic ARN "arn:aws:sqs:us-east-1:123456789012:atlantis"`, err)
+}
+
+func TestNewSQS(t *testing.T) {
+	_, err := webhooks.NewSQS(regexp.MustCompile(".*"), "https://example.com/123456789012/atlantis")
+	ErrEquals(t, `invalid SQS queue URL "https://example.com/123456789012/atlantis", must be like https://sqs.<region>.amazonaws.com/<account>/<queue>`, err)
+
+	hook, err := webhooks.NewSQS(regexp.MustCompile(".*"), "https://sqs.eu-west-1.amazonaws.com/123456789012/atlantis")
+	Ok(t, err)
+	Equals(t, "https://sqs.eu-west-1.amazonaws.com/123456789012/atlantis", hook.QueueURL)
+}
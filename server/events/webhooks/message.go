@@ -0,0 +1,29 @@
+package webhooks
+
+import "encoding/json"
+
+// applyMessage is the JSON message that the SNS, SQS and Pub/Sub webhooks
+// send for an apply.
+type applyMessage struct {
+	Event     string `json:"event"`
+	Repo      string `json:"repo"`
+	Pull      int    `json:"pull"`
+	PullURL   string `json:"pull_url"`
+	User      string `json:"user"`
+	Directory string `json:"directory"`
+	Workspace string `json:"workspace"`
+	Success   bool   `json:"success"`
+}
+
+func marshalApplyMessage(result ApplyResult) ([]byte, error) {
+	return json.Marshal(applyMessage{
+		Event:     ApplyEvent,
+		Repo:      result.Repo.FullName,
+		Pull:      result.Pull.Num,
+		PullURL:   result.Pull.URL,
+		User:      result.User.Username,
+		Directory: result.Directory,
+		Workspace: result.Workspace,
+		Success:   result.Success,
+	})
+}
@@ -0,0 +1,68 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+
+	"github.com/runatlantis/atlantis/server/logging"
+	"google.golang.org/api/pubsub/v1"
+)
+
+// PubSubPublisher publishes messages to Google Cloud Pub/Sub topics.
+type PubSubPublisher interface {
+	Publish(topic string, data []byte) error
+}
+
+// PubSubWebhook publishes webhooks to a Google Cloud Pub/Sub topic.
+type PubSubWebhook struct {
+	Client         PubSubPublisher
+	WorkspaceRegex *regexp.Regexp
+	Topic          string
+}
+
+// pubSubTopicRegex matches the full name of a Pub/Sub topic.
+var pubSubTopicRegex = regexp.MustCompile(`^projects/[^/]+/topics/[^/]+$`)
+
+// NewPubSub returns a webhook that publishes to topic, which is the topic's
+// full name, ex. projects/my-project/topics/atlantis. Credentials come from
+// Google's application default credentials.
+func NewPubSub(r *regexp.Regexp, topic string) (*PubSubWebhook, error) {
+	if !pubSubTopicRegex.MatchString(topic) {
+		return nil, fmt.Errorf("invalid Pub/Sub topic %q, must be like projects/<project>/topics/<topic>", topic)
+	}
+	svc, err := pubsub.NewService(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("creating Pub/Sub client: %s", err)
+	}
+	return &PubSubWebhook{
+		Client:         &DefaultPubSubPublisher{Service: svc},
+		WorkspaceRegex: r,
+		Topic:          topic,
+	}, nil
+}
+
+// Send publishes the webhook if the workspace matches the regex.
+func (p *PubSubWebhook) Send(log logging.SimpleLogging, applyResult ApplyResult) error {
+	if !p.WorkspaceRegex.MatchString(applyResult.Workspace) {
+		return nil
+	}
+	msg, err := marshalApplyMessage(applyResult)
+	if err != nil {
+		return err
+	}
+	return p.Client.Publish(p.Topic, msg)
+}
+
+// DefaultPubSubPublisher publishes with the Pub/Sub REST API.
+type DefaultPubSubPublisher struct {
+	Service *pubsub.Service
+}
+
+func (d *DefaultPubSubPublisher) Publish(topic string, data []byte) error {
+	_, err := d.Service.Projects.Topics.Publish(topic, &pubsub.PublishRequest{
+		Messages: []*pubsub.PubsubMessage{{Data: base64.StdEncoding.EncodeToString(data)}},
+	}).Do()
+	return err
+}
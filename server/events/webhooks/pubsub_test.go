@@ -0,0 +1,39 @@
+package webhooks_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/webhooks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakePubSub struct {
+	// published are the messages published to each topic.
+	published map[string][]string
+}
+
+func (f *fakePubSub) Publish(topic string, data []byte) error {
+	f.published[topic] = append(f.published[topic], string(data))
+	return nil
+}
+
+func TestPubSubWebhook_Send(t *testing.T) {
+	client := &fakePubSub{published: make(map[string][]string)}
+	hook := webhooks.PubSubWebhook{
+		Client:         client,
+		WorkspaceRegex: regexp.MustCompile("prod.*"),
+		Topic:          "projects/my-project/topics/atlantis",
+	}
+	Ok(t, hook.Send(logging.NewNoopLogger(t), testApplyResult))
+	Ok(t, hook.Send(logging.NewNoopLogger(t), webhooks.ApplyResult{Workspace: "staging"}))
+	Equals(t, map[string][]string{
+		"projects/my-project/topics/atlantis": {testApplyMessage},
+	}, client.published)
+}
+
+func TestNewPubSub_InvalidTopic(t *testing.T) {
+	_, err := webhooks.NewPubSub(regexp.MustCompile(".*"), "atlantis")
+	ErrEquals(t, `invalid Pub/Sub topic "atlantis", must be like projects/<project>/topics/<topic>`, err)
+}
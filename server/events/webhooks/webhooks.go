@@ -24,6 +24,9 @@ import (
 )
 
 const SlackKind = "slack"
+const SNSKind = "sns"
+const SQSKind = "sqs"
+const PubSubKind = "pubsub"
 const ApplyEvent = "apply"
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_sender.go Sender
@@ -54,6 +57,11 @@ type Config struct {
 	WorkspaceRegex string
 	Kind           string
 	Channel        string
+	// Topic is the ARN of the SNS topic or the full name of the Pub/Sub
+	// topic that sns and pubsub webhooks publish to.
+	Topic string
+	// QueueURL is the URL of the queue that sqs webhooks send to.
+	QueueURL string
 }
 
 func NewMultiWebhookSender(configs []Config, client SlackClient) (*MultiWebhookSender, error) {
@@ -82,8 +90,35 @@ func NewMultiWebhookSender(configs []Config, client SlackClient) (*MultiWebhookS
 				return nil, err
 			}
 			webhooks = append(webhooks, slack)
+		case SNSKind:
+			if c.Topic == "" {
+				return nil, errors.New("must specify \"topic\" if using a webhook of \"kind: sns\"")
+			}
+			sns, err := NewSNS(r, c.Topic)
+			if err != nil {
+				return nil, err
+			}
+			webhooks = append(webhooks, sns)
+		case SQSKind:
+			if c.QueueURL == "" {
+				return nil, errors.New("must specify \"queue-url\" if using a webhook of \"kind: sqs\"")
+			}
+			sqs, err := NewSQS(r, c.QueueURL)
+			if err != nil {
+				return nil, err
+			}
+			webhooks = append(webhooks, sqs)
+		case PubSubKind:
+			if c.Topic == "" {
+				return nil, errors.New("must specify \"topic\" if using a webhook of \"kind: pubsub\"")
+			}
+			pubsub, err := NewPubSub(r, c.Topic)
+			if err != nil {
+				return nil, err
+			}
+			webhooks = append(webhooks, pubsub)
 		default:
-			return nil, fmt.Errorf("\"kind: %s\" not supported. Only \"kind: %s\" is supported right now", c.Kind, SlackKind)
+			return nil, fmt.Errorf("\"kind: %s\" not supported. Only \"kind: %s\", \"kind: %s\", \"kind: %s\" and \"kind: %s\" are supported right now", c.Kind, SlackKind, SNSKind, SQSKind, PubSubKind)
 		}
 	}
 
@@ -96,7 +131,7 @@ func NewMultiWebhookSender(configs []Config, client SlackClient) (*MultiWebhookS
 func (w *MultiWebhookSender) Send(log logging.SimpleLogging, result ApplyResult) error {
 	for _, w := range w.Webhooks {
 		if err := w.Send(log, result); err != nil {
-			log.Warn("error sending slack webhook: %s", err)
+			log.Warn("error sending webhook: %s", err)
 		}
 	}
 	return nil
@@ -100,7 +100,23 @@ func TestNewWebhooksManager_UnsupportedKind(t *testing.T) {
 	configs[0].Kind = unsupportedKind
 	_, err := webhooks.NewMultiWebhookSender(configs, client)
 	Assert(t, err != nil, "expected error")
-	Equals(t, "\"kind: badkind\" not supported. Only \"kind: slack\" is supported right now", err.Error())
+	Equals(t, "\"kind: badkind\" not supported. Only \"kind: slack\", \"kind: sns\", \"kind: sqs\" and \"kind: pubsub\" are supported right now", err.Error())
+}
+
+func TestNewWebhooksManager_NoTarget(t *testing.T) {
+	t.Log("When the topic or queue of a cloud messaging webhook is not specified, an error is returned")
+	RegisterMockTestingT(t)
+	client := mocks.NewMockSlackClient()
+	for kind, exp := range map[string]string{
+		webhooks.SNSKind:    "must specify \"topic\" if using a webhook of \"kind: sns\"",
+		webhooks.SQSKind:    "must specify \"queue-url\" if using a webhook of \"kind: sqs\"",
+		webhooks.PubSubKind: "must specify \"topic\" if using a webhook of \"kind: pubsub\"",
+	} {
+		configs := validConfigs()
+		configs[0].Kind = kind
+		_, err := webhooks.NewMultiWebhookSender(configs, client)
+		ErrEquals(t, exp, err)
+	}
 }
 
 func TestNewWebhooksManager_NoConfigSuccess(t *testing.T) {
@@ -16,6 +16,7 @@ package events
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -24,6 +25,7 @@ import (
 	"sync"
 
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
 	"golang.org/x/sync/semaphore"
@@ -31,6 +33,10 @@ import (
 
 const workingDirPrefix = "repos"
 
+// tenantsDirPrefix is the dir that each tenant's repos are cloned into a
+// dir of, ex. tenants/payments/repos.
+const tenantsDirPrefix = "tenants"
+
 var cloneLocks sync.Map
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_working_dir.go WorkingDir
@@ -47,6 +53,10 @@ type WorkingDir interface {
 	// If workspace does not exist on disk, error will be of type os.IsNotExist.
 	GetWorkingDir(r models.Repo, p models.PullRequest, workspace string) (string, error)
 	HasDiverged(log logging.SimpleLogging, cloneDir string) bool
+	// WasForcePushed returns true if p's head commit doesn't descend from the
+	// commit that workspace was cloned at, i.e. p's branch was force-pushed
+	// since. It returns false if workspace doesn't exist.
+	WasForcePushed(log logging.SimpleLogging, p models.PullRequest, workspace string) (bool, error)
 	GetPullDir(r models.Repo, p models.PullRequest) (string, error)
 	// Delete deletes the workspace for this repo and pull.
 	Delete(r models.Repo, p models.PullRequest) error
@@ -61,6 +71,13 @@ type FileWorkspace struct {
 	// If this is false, then we will check out the head branch from the pull
 	// request.
 	CheckoutMerge bool
+	// CheckoutDepth is the number of commits of history to clone. If it's 0,
+	// the branch checkout strategy clones only the latest commit and the
+	// merge checkout strategy clones the full history.
+	CheckoutDepth int
+	// CheckoutFilter is the filter to make a partial clone with,
+	// ex. blob:none. If it's empty the clone isn't partial.
+	CheckoutFilter string
 	// TestingOverrideHeadCloneURL can be used during testing to override the
 	// URL of the head repo to be cloned. If it's empty then we clone normally.
 	TestingOverrideHeadCloneURL string
@@ -73,6 +90,21 @@ type FileWorkspace struct {
 	GithubAppEnabled bool
 	// use the global setting without overriding
 	GpgNoSigningEnabled bool
+	// GitEnvs are extra env vars for the git commands run against repos on
+	// each VCS host, ex. to connect through the host's proxy.
+	GitEnvs map[models.VCSHostType][]string
+	// SSHCredentials clones repos over SSH if set. Otherwise they're cloned
+	// over HTTPS.
+	SSHCredentials *SSHCredentialsWriter
+	// GitCredentials are the credentials repos are cloned with over HTTPS.
+	GitCredentials []GitCredentials
+	// TenantCredentials replaces GitCredentials for the repos of tenants
+	// that have their own credentials.
+	TenantCredentials *TenantCredentials
+	// GlobalCfg is used to look up the tenant of each repo, whose clones are
+	// kept in their own dir and count towards the tenant's data dir quota.
+	// Nil if repos aren't isolated into tenants.
+	GlobalCfg *valid.GlobalCfgStore
 }
 
 // Clone git clones headRepo, checks out the branch and then returns the absolute
@@ -88,6 +120,17 @@ func (w *FileWorkspace) Clone(
 	p models.PullRequest,
 	workspace string) (string, bool, error) {
 	cloneDir := w.cloneDir(p.BaseRepo, p, workspace)
+	p.BaseRepo = w.TenantCredentials.Repo(p.BaseRepo)
+	headRepo = w.TenantCredentials.Repo(headRepo)
+	if w.SSHCredentials != nil {
+		var err error
+		if p.BaseRepo, err = w.SSHCredentials.Repo(p.BaseRepo); err != nil {
+			return "", false, err
+		}
+		if headRepo, err = w.SSHCredentials.Repo(headRepo); err != nil {
+			return "", false, err
+		}
+	}
 
 	// If the directory already exists, check if it's at the right commit.
 	// If so, then we do nothing.
@@ -105,6 +148,7 @@ func (w *FileWorkspace) Clone(
 		}
 		revParseCmd := exec.Command("git", "rev-parse", pullHead) // #nosec
 		revParseCmd.Dir = cloneDir
+		revParseCmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL="+GitConfigFile(cloneDir))
 		outputRevParseCmd, err := revParseCmd.CombinedOutput()
 		if err != nil {
 			log.Warn("will re-clone repo, could not determine if was at correct commit: %s: %s: %s", strings.Join(revParseCmd.Args, " "), err, string(outputRevParseCmd))
@@ -160,9 +204,15 @@ func (w *FileWorkspace) warnDiverged(log logging.SimpleLogging, p models.PullReq
 		},
 	}
 
+	gitEnv, err := w.gitEnv(p.BaseRepo, cloneDir)
+	if err != nil {
+		log.Warn("getting git env failed: %s", err)
+		return false
+	}
 	for _, args := range cmds {
 		cmd := exec.Command(args[0], args[1:]...) // nolint: gosec
 		cmd.Dir = cloneDir
+		cmd.Env = append(os.Environ(), gitEnv...)
 
 		output, err := cmd.CombinedOutput()
 
@@ -190,6 +240,7 @@ func (w *FileWorkspace) HasDiverged(log logging.SimpleLogging, cloneDir string)
 	// Check if remote master branch has diverged.
 	statusUnoCmd := exec.Command("git", "status", "--untracked-files=no")
 	statusUnoCmd.Dir = cloneDir
+	statusUnoCmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL="+GitConfigFile(cloneDir))
 	outputStatusUno, err := statusUnoCmd.CombinedOutput()
 	if err != nil {
 		log.Warn("getting repo status has failed: %s", string(outputStatusUno))
@@ -199,6 +250,83 @@ func (w *FileWorkspace) HasDiverged(log logging.SimpleLogging, cloneDir string)
 	return hasDiverged
 }
 
+// WasForcePushed returns true if p's head commit doesn't descend from the
+// commit that workspace was cloned at. It fetches p's head into the clone to
+// find out. If the branch was force-pushed that fetches the head's whole
+// history because the clone doesn't share any of it.
+func (w *FileWorkspace) WasForcePushed(log logging.SimpleLogging, p models.PullRequest, workspace string) (bool, error) {
+	cloneDir := w.cloneDir(p.BaseRepo, p, workspace)
+	if _, err := os.Stat(cloneDir); os.IsNotExist(err) {
+		return false, nil
+	}
+	if w.SSHCredentials != nil {
+		var err error
+		if p.BaseRepo, err = w.SSHCredentials.Repo(p.BaseRepo); err != nil {
+			return false, err
+		}
+	}
+	gitEnv, err := w.gitEnv(p.BaseRepo, cloneDir)
+	if err != nil {
+		return false, err
+	}
+	runGit := func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...) // nolint: gosec
+		cmd.Dir = cloneDir
+		cmd.Env = append(os.Environ(), gitEnv...)
+		output, err := cmd.CombinedOutput()
+		return strings.TrimSpace(string(output)), err
+	}
+
+	pullHead := "HEAD"
+	if w.CheckoutMerge {
+		pullHead = "HEAD^2"
+	}
+	clonedCommit, err := runGit("rev-parse", pullHead)
+	if err != nil {
+		return false, fmt.Errorf("running git rev-parse %s: %s: %s", pullHead, clonedCommit, err)
+	}
+	if strings.HasPrefix(clonedCommit, p.HeadCommit) {
+		return false, nil
+	}
+
+	// With the branch strategy, origin is the head repo. With the merge
+	// strategy, the head is fetched the same way as when cloning.
+	fetchRemote, fetchRef := "origin", pullHeadRef(p)
+	if w.CheckoutMerge {
+		fetchRemote = "head"
+		if w.GithubAppEnabled {
+			fetchRemote, fetchRef = "origin", fmt.Sprintf("pull/%d/head:", p.Num)
+		}
+	}
+	if out, err := runGit("fetch", fetchRemote, fetchRef); err != nil {
+		sanitized := w.sanitizeGitCredentials(out, p.BaseRepo, p.BaseRepo)
+		return false, fmt.Errorf("fetching head of pull request: %s: %s", sanitized, err)
+	}
+	out, err := runGit("merge-base", "--is-ancestor", clonedCommit, "FETCH_HEAD")
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		log.Info("head of pull request was force-pushed from %q to %q", clonedCommit, p.HeadCommit)
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("running git merge-base: %s: %s", out, err)
+	}
+	return false, nil
+}
+
+// gitEnv returns the extra env vars for the git commands run against repo in
+// cloneDir.
+func (w *FileWorkspace) gitEnv(repo models.Repo, cloneDir string) ([]string, error) {
+	env := append([]string{"GIT_CONFIG_GLOBAL=" + GitConfigFile(cloneDir)}, w.GitEnvs[repo.VCSHost.Type]...)
+	var credsEnv []string
+	var err error
+	if w.SSHCredentials != nil {
+		credsEnv, err = w.SSHCredentials.Env(repo)
+	} else {
+		credsEnv, err = GitCredentialsEnv(w.TenantCredentials.GitCredentials(repo, w.GitCredentials))
+	}
+	return append(env, credsEnv...), err
+}
+
 func (w *FileWorkspace) forceClone(log logging.SimpleLogging,
 	cloneDir string,
 	headRepo models.Repo,
@@ -220,6 +348,9 @@ func (w *FileWorkspace) forceClone(log logging.SimpleLogging,
 	if err != nil {
 		return errors.Wrapf(err, "deleting dir %q before cloning", cloneDir)
 	}
+	if err := w.checkDataDirQuota(p.BaseRepo); err != nil {
+		return err
+	}
 
 	// Create the directory and parents if necessary.
 	log.Info("creating dir %q", cloneDir)
@@ -237,53 +368,16 @@ func (w *FileWorkspace) forceClone(log logging.SimpleLogging,
 		baseCloneURL = w.TestingOverrideBaseCloneURL
 	}
 
-	var cmds [][]string
-	if w.CheckoutMerge {
-		// NOTE: We can't do a shallow clone when we're merging because we'll
-		// get merge conflicts if our clone doesn't have the commits that the
-		// branch we're merging branched off at.
-		// See https://groups.google.com/forum/#!topic/git-users/v3MkuuiDJ98.
-		fetchRef := fmt.Sprintf("+refs/heads/%s:", p.HeadBranch)
-		fetchRemote := "head"
-		if w.GithubAppEnabled {
-			fetchRef = fmt.Sprintf("pull/%d/head:", p.Num)
-			fetchRemote = "origin"
-		}
-		cmds = [][]string{
-			{
-				"git", "clone", "--branch", p.BaseBranch, "--single-branch", baseCloneURL, cloneDir,
-			},
-			{
-				"git", "remote", "add", "head", headCloneURL,
-			},
-			{
-				"git", "fetch", fetchRemote, fetchRef,
-			},
-		}
-		if w.GpgNoSigningEnabled {
-			cmds = append(cmds, []string{
-				"git", "config", "--local", "commit.gpgsign", "false",
-			})
-		}
-		// We use --no-ff because we always want there to be a merge commit.
-		// This way, our branch will look the same regardless if the merge
-		// could be fast forwarded. This is useful later when we run
-		// git rev-parse HEAD^2 to get the head commit because it will
-		// always succeed whereas without --no-ff, if the merge was fast
-		// forwarded then git rev-parse HEAD^2 would fail.
-		cmds = append(cmds, []string{
-			"git", "merge", "-q", "--no-ff", "-m", "atlantis-merge", "FETCH_HEAD",
-		})
-	} else {
-		cmds = [][]string{
-			{
-				"git", "clone", "--branch", p.HeadBranch, "--depth=1", "--single-branch", headCloneURL, cloneDir,
-			},
-		}
+	if err := writeGitConfig(GitConfigFile(cloneDir)); err != nil {
+		return err
+	}
+	gitEnv, err := w.gitEnv(p.BaseRepo, cloneDir)
+	if err != nil {
+		return err
 	}
 
-	for _, args := range cmds {
-		cmd := exec.Command(args[0], args[1:]...) // nolint: gosec
+	runGit := func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...) // nolint: gosec
 		cmd.Dir = cloneDir
 		// The git merge command requires these env vars are set.
 		cmd.Env = append(os.Environ(), []string{
@@ -291,17 +385,164 @@ func (w *FileWorkspace) forceClone(log logging.SimpleLogging,
 			"GIT_AUTHOR_NAME=atlantis",
 			"GIT_COMMITTER_NAME=atlantis",
 		}...)
+		cmd.Env = append(cmd.Env, gitEnv...)
 
 		cmdStr := w.sanitizeGitCredentials(strings.Join(cmd.Args, " "), p.BaseRepo, headRepo)
 		output, err := cmd.CombinedOutput()
 		sanitizedOutput := w.sanitizeGitCredentials(string(output), p.BaseRepo, headRepo)
 		if err != nil {
 			sanitizedErrMsg := w.sanitizeGitCredentials(err.Error(), p.BaseRepo, headRepo)
-			return fmt.Errorf("running %s: %s: %s", cmdStr, sanitizedOutput, sanitizedErrMsg)
+			return sanitizedOutput, fmt.Errorf("running %s: %s: %s", cmdStr, sanitizedOutput, sanitizedErrMsg)
 		}
 		log.Debug("ran: %s. Output: %s", cmdStr, strings.TrimSuffix(sanitizedOutput, "\n"))
+		return sanitizedOutput, nil
+	}
+
+	var filterArgs []string
+	if w.CheckoutFilter != "" {
+		filterArgs = []string{"--filter=" + w.CheckoutFilter}
+	}
+
+	if !w.CheckoutMerge {
+		depth := w.CheckoutDepth
+		if depth == 0 {
+			depth = 1
+		}
+		if p.BaseRepo.VCSHost.Type == models.Gerrit {
+			// Gerrit changes are refs rather than branches so they can't be
+			// cloned directly.
+			args := append([]string{"clone", "--branch", p.BaseBranch, fmt.Sprintf("--depth=%d", depth), "--single-branch"}, filterArgs...)
+			if _, err := runGit(append(args, headCloneURL, cloneDir)...); err != nil {
+				return err
+			}
+			fetchArgs := append([]string{"fetch", fmt.Sprintf("--depth=%d", depth)}, filterArgs...)
+			if _, err := runGit(append(fetchArgs, "origin", pullHeadRef(p))...); err != nil {
+				return err
+			}
+			_, err := runGit("checkout", "-q", "--detach", "FETCH_HEAD")
+			return err
+		}
+		args := append([]string{"clone", "--branch", p.HeadBranch, fmt.Sprintf("--depth=%d", depth), "--single-branch"}, filterArgs...)
+		_, err := runGit(append(args, headCloneURL, cloneDir)...)
+		return err
+	}
+
+	// NOTE: By default we don't do a shallow clone when we're merging because
+	// we'll get merge conflicts if our clone doesn't have the commits that the
+	// branch we're merging branched off at.
+	// See https://groups.google.com/forum/#!topic/git-users/v3MkuuiDJ98.
+	// If a depth is set, the clone is deepened until it has them.
+	var depthArgs []string
+	if w.CheckoutDepth > 0 {
+		depthArgs = []string{fmt.Sprintf("--depth=%d", w.CheckoutDepth)}
+	}
+	fetchRef := pullHeadRef(p)
+	fetchRemote := "head"
+	if w.GithubAppEnabled {
+		fetchRef = fmt.Sprintf("pull/%d/head:", p.Num)
+		fetchRemote = "origin"
+	}
+	cloneArgs := append(append([]string{"clone", "--branch", p.BaseBranch, "--single-branch"}, depthArgs...), filterArgs...)
+	cmds := [][]string{
+		append(cloneArgs, baseCloneURL, cloneDir),
+		{"remote", "add", "head", headCloneURL},
+	}
+	if w.GpgNoSigningEnabled {
+		cmds = append(cmds, []string{"config", "--local", "commit.gpgsign", "false"})
+	}
+	for _, args := range cmds {
+		if _, err := runGit(args...); err != nil {
+			return err
+		}
+	}
+
+	// GitHub and GitLab keep a ref with the result of merging each pull
+	// request so check that out if we can rather than merging locally. That
+	// way we use exactly what the VCS would merge and don't fail on conflicts
+	// that it can resolve. The ref doesn't exist if the pull request has
+	// conflicts and is updated asynchronously so we merge locally if it's
+	// missing or isn't a merge of the pull request's head commit.
+	if mergeRef := vcsMergeRef(p); mergeRef != "" {
+		mergeFetchArgs := []string{"fetch"}
+		if w.CheckoutDepth > 0 {
+			// Fetch one more commit so that the merge's parents are included.
+			mergeFetchArgs = append(mergeFetchArgs, fmt.Sprintf("--depth=%d", w.CheckoutDepth+1))
+		}
+		mergeFetchArgs = append(append(mergeFetchArgs, filterArgs...), "origin", fmt.Sprintf("+%s:", mergeRef))
+		if _, err := runGit(mergeFetchArgs...); err != nil {
+			log.Debug("could not fetch %s, will merge locally: %s", mergeRef, err)
+		} else if headParent, err := runGit("rev-parse", "FETCH_HEAD^2"); err != nil || !strings.HasPrefix(strings.TrimSpace(headParent), p.HeadCommit) {
+			log.Debug("%s isn't a merge of %q yet, will merge locally", mergeRef, p.HeadCommit)
+		} else {
+			_, err := runGit("reset", "-q", "--hard", "FETCH_HEAD")
+			return err
+		}
+	}
+
+	fetchArgs := append(append([]string{"fetch"}, depthArgs...), filterArgs...)
+	if _, err := runGit(append(fetchArgs, fetchRemote, fetchRef)...); err != nil {
+		return err
+	}
+
+	if w.CheckoutDepth > 0 {
+		// Deepen both branches, doubling the number of commits fetched each
+		// time, until they have a common ancestor or the whole history has
+		// been fetched. The head branch is fetched last so that FETCH_HEAD
+		// points to it for the merge.
+		for deepen := w.CheckoutDepth; ; deepen *= 2 {
+			if _, err := runGit("merge-base", "HEAD", "FETCH_HEAD"); err == nil {
+				break
+			}
+			shallow, err := runGit("rev-parse", "--is-shallow-repository")
+			if err != nil {
+				return err
+			}
+			if strings.TrimSpace(shallow) != "true" {
+				// The branches don't have a common ancestor so let the merge
+				// fail with git's error.
+				break
+			}
+			log.Debug("merge base of %q and %q isn't in the clone, fetching %d more commits", p.BaseBranch, p.HeadBranch, deepen)
+			deepenArg := fmt.Sprintf("--deepen=%d", deepen)
+			if _, err := runGit("fetch", deepenArg, "origin", p.BaseBranch); err != nil {
+				return err
+			}
+			if _, err := runGit("fetch", deepenArg, fetchRemote, fetchRef); err != nil {
+				return err
+			}
+		}
+	}
+
+	// We use --no-ff because we always want there to be a merge commit.
+	// This way, our branch will look the same regardless if the merge
+	// could be fast forwarded. This is useful later when we run
+	// git rev-parse HEAD^2 to get the head commit because it will
+	// always succeed whereas without --no-ff, if the merge was fast
+	// forwarded then git rev-parse HEAD^2 would fail.
+	_, err = runGit("merge", "-q", "--no-ff", "-m", "atlantis-merge", "FETCH_HEAD")
+	return err
+}
+
+// pullHeadRef returns the refspec to fetch p's head with. For Gerrit, p's
+// head branch is the change's patch set ref, ex. refs/changes/45/12345/3.
+func pullHeadRef(p models.PullRequest) string {
+	if p.BaseRepo.VCSHost.Type == models.Gerrit {
+		return fmt.Sprintf("+%s:", p.HeadBranch)
+	}
+	return fmt.Sprintf("+refs/heads/%s:", p.HeadBranch)
+}
+
+// vcsMergeRef returns the ref that p's VCS keeps the result of merging p in,
+// or an empty string if the VCS doesn't keep one.
+func vcsMergeRef(p models.PullRequest) string {
+	switch p.BaseRepo.VCSHost.Type {
+	case models.Github:
+		return fmt.Sprintf("refs/pull/%d/merge", p.Num)
+	case models.Gitlab:
+		return fmt.Sprintf("refs/merge-requests/%d/merge", p.Num)
+	default:
+		return ""
 	}
-	return nil
 }
 
 // GetWorkingDir returns the path to the workspace for this repo and pull.
@@ -330,17 +571,92 @@ func (w *FileWorkspace) Delete(r models.Repo, p models.PullRequest) error {
 
 // DeleteForWorkspace deletes the working dir for this workspace.
 func (w *FileWorkspace) DeleteForWorkspace(r models.Repo, p models.PullRequest, workspace string) error {
-	return os.RemoveAll(w.cloneDir(r, p, workspace))
+	cloneDir := w.cloneDir(r, p, workspace)
+	if err := os.RemoveAll(GitConfigFile(cloneDir)); err != nil {
+		return err
+	}
+	return os.RemoveAll(cloneDir)
 }
 
 func (w *FileWorkspace) repoPullDir(r models.Repo, p models.PullRequest) string {
+	if tenant := w.tenant(r); tenant != nil {
+		return filepath.Join(w.tenantDir(*tenant), workingDirPrefix, r.FullName, strconv.Itoa(p.Num))
+	}
 	return filepath.Join(w.DataDir, workingDirPrefix, r.FullName, strconv.Itoa(p.Num))
 }
 
+// tenant returns the tenant of r or nil if it doesn't have one.
+func (w *FileWorkspace) tenant(r models.Repo) *valid.Tenant {
+	if w.GlobalCfg == nil {
+		return nil
+	}
+	return w.GlobalCfg.Get().Tenant(r.ID())
+}
+
+// tenantDir returns the dir that tenant's repos are cloned into a dir of.
+func (w *FileWorkspace) tenantDir(tenant valid.Tenant) string {
+	return filepath.Join(w.DataDir, tenantsDirPrefix, tenant.Name)
+}
+
+// checkDataDirQuota returns an error if the tenant of r has used up its data
+// dir quota.
+func (w *FileWorkspace) checkDataDirQuota(r models.Repo) error {
+	tenant := w.tenant(r)
+	if tenant == nil || tenant.DataDirQuotaMB == 0 {
+		return nil
+	}
+	var size int64
+	err := filepath.WalkDir(w.tenantDir(*tenant), func(path string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "checking data dir usage of tenant %s", tenant.Name)
+	}
+	if usedMB := size / (1024 * 1024); usedMB >= int64(tenant.DataDirQuotaMB) {
+		return fmt.Errorf("tenant %s is using %d MB of its %d MB data dir quota: merge or close pull requests to free up space", tenant.Name, usedMB, tenant.DataDirQuotaMB)
+	}
+	return nil
+}
+
 func (w *FileWorkspace) cloneDir(r models.Repo, p models.PullRequest, workspace string) string {
 	return filepath.Join(w.repoPullDir(r, p), workspace)
 }
 
+// GitConfigFile returns the path to the git config file that's used as the
+// global git config for the git commands run in the workspace cloned into
+// cloneDir. Each workspace gets its own so that nothing that's run in one,
+// ex. a run step that calls git config --global, changes the shared home
+// dir or affects other workspaces. It's kept next to the clone rather than
+// in it so that it isn't part of the repo.
+func GitConfigFile(cloneDir string) string {
+	return filepath.Clean(cloneDir) + ".gitconfig"
+}
+
+// writeGitConfig writes a workspace's git config file to path. It includes
+// the global git config of the user Atlantis runs as so that settings made
+// there still apply.
+func writeGitConfig(path string) error {
+	config := `# Written by Atlantis. Used as the global git config for this workspace.
+[include]
+	path = ~/.gitconfig
+[user]
+	name = atlantis
+	email = atlantis@runatlantis.io
+`
+	return errors.Wrap(os.WriteFile(path, []byte(config), 0600), "writing git config")
+}
+
 // sanitizeGitCredentials replaces any git clone urls that contain credentials
 // in s with the sanitized versions.
 func (w *FileWorkspace) sanitizeGitCredentials(s string, base models.Repo, head models.Repo) string {
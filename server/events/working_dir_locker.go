@@ -15,8 +15,10 @@ package events
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 //go:generate pegomock generate --use-experimental-model-gen --package mocks -o mocks/mock_working_dir_locker.go WorkingDirLocker
@@ -49,6 +51,17 @@ type DefaultWorkingDirLocker struct {
 	// matching to determine if something is locked. It's naive but that's okay
 	// because there won't be many locks at one time.
 	locks []string
+	// lockedAt is when each of the locks was acquired.
+	lockedAt map[string]time.Time
+}
+
+// WorkingDirLock is a lock held on a pull request's working dir, or on one
+// of its workspaces.
+type WorkingDirLock struct {
+	// Key is the repo's full name and pull number, followed by the workspace
+	// and path if only a workspace is locked, ex. owner/repo/1/default/.
+	Key   string
+	Since time.Time
 }
 
 // NewDefaultWorkingDirLocker is a constructor.
@@ -68,7 +81,7 @@ func (d *DefaultWorkingDirLocker) TryLockPull(repoFullName string, pullNum int)
 				"Wait until the previous command is complete and try again.")
 		}
 	}
-	d.locks = append(d.locks, pullKey)
+	d.addLock(pullKey)
 	return func() {
 		d.UnlockPull(repoFullName, pullNum)
 	}, nil
@@ -87,7 +100,7 @@ func (d *DefaultWorkingDirLocker) TryLock(repoFullName string, pullNum int, work
 				"Wait until the previous command is complete and try again.", workspace, path)
 		}
 	}
-	d.locks = append(d.locks, workspaceKey)
+	d.addLock(workspaceKey)
 	return func() {
 		d.unlock(repoFullName, pullNum, workspace, path)
 	}, nil
@@ -111,7 +124,32 @@ func (d *DefaultWorkingDirLocker) UnlockPull(repoFullName string, pullNum int) {
 	d.removeLock(pullKey)
 }
 
+// List returns the locks held, oldest first, so commands that are stuck can
+// be found.
+func (d *DefaultWorkingDirLocker) List() []WorkingDirLock {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	locks := make([]WorkingDirLock, 0, len(d.locks))
+	for _, l := range d.locks {
+		locks = append(locks, WorkingDirLock{Key: l, Since: d.lockedAt[l]})
+	}
+	sort.SliceStable(locks, func(i, j int) bool {
+		return locks[i].Since.Before(locks[j].Since)
+	})
+	return locks
+}
+
+func (d *DefaultWorkingDirLocker) addLock(key string) {
+	if d.lockedAt == nil {
+		d.lockedAt = make(map[string]time.Time)
+	}
+	d.locks = append(d.locks, key)
+	d.lockedAt[key] = time.Now()
+}
+
 func (d *DefaultWorkingDirLocker) removeLock(key string) {
+	delete(d.lockedAt, key)
 	var newLocks []string
 	for _, l := range d.locks {
 		if l != key {
@@ -214,3 +214,23 @@ func TestLockPull_WorkspaceFirst(t *testing.T) {
 	_, err = locker.TryLockPull("owner/repo", 1)
 	Ok(t, err)
 }
+
+func TestList(t *testing.T) {
+	locker := events.NewDefaultWorkingDirLocker()
+	Equals(t, 0, len(locker.List()))
+
+	unlockPull, err := locker.TryLockPull("owner/repo", 1)
+	Ok(t, err)
+	_, err = locker.TryLock("owner/repo", 2, "default", ".")
+	Ok(t, err)
+	locks := locker.List()
+	Equals(t, 2, len(locks))
+	Equals(t, "owner/repo/1", locks[0].Key)
+	Equals(t, "owner/repo/2/default/.", locks[1].Key)
+	Assert(t, !locks[0].Since.IsZero(), "exp lock time to be set")
+
+	unlockPull()
+	locks = locker.List()
+	Equals(t, 1, len(locks))
+	Equals(t, "owner/repo/2/default/.", locks[0].Key)
+}
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -114,6 +117,52 @@ func TestClone_CheckoutMergeNoneExisting(t *testing.T) {
 
 // Test that if we're using the merge method and the repo is already cloned at
 // the right commit, then we don't reclone.
+// Gerrit changes are refs rather than branches so they're fetched by ref.
+func TestClone_GerritChange(t *testing.T) {
+	repoDir, cleanup := initRepo(t)
+	defer cleanup()
+
+	// Push a change to a change ref, like Gerrit does, that isn't on any
+	// branch.
+	runCmd(t, repoDir, "git", "checkout", "--detach", "master")
+	runCmd(t, repoDir, "touch", "change-file")
+	runCmd(t, repoDir, "git", "add", "change-file")
+	runCmd(t, repoDir, "git", "commit", "-m", "change-commit")
+	changeCommit := runCmd(t, repoDir, "git", "rev-parse", "HEAD")
+	runCmd(t, repoDir, "git", "update-ref", "refs/changes/45/12345/1", "HEAD")
+	runCmd(t, repoDir, "git", "checkout", "master")
+
+	for _, checkoutMerge := range []bool{false, true} {
+		t.Run(fmt.Sprintf("merge %t", checkoutMerge), func(t *testing.T) {
+			dataDir, cleanup2 := TempDir(t)
+			defer cleanup2()
+
+			overrideURL := fmt.Sprintf("file://%s", repoDir)
+			wd := &events.FileWorkspace{
+				DataDir:                     dataDir,
+				CheckoutMerge:               checkoutMerge,
+				TestingOverrideHeadCloneURL: overrideURL,
+				TestingOverrideBaseCloneURL: overrideURL,
+				GpgNoSigningEnabled:         true,
+			}
+			repo := models.Repo{VCSHost: models.VCSHost{Type: models.Gerrit}}
+			cloneDir, _, err := wd.Clone(logging.NewNoopLogger(t), repo, models.PullRequest{
+				BaseRepo:   repo,
+				HeadBranch: "refs/changes/45/12345/1",
+				HeadCommit: changeCommit,
+				BaseBranch: "master",
+			}, "default")
+			Ok(t, err)
+
+			pullHead := "HEAD"
+			if checkoutMerge {
+				pullHead = "HEAD^2"
+			}
+			Equals(t, changeCommit, runCmd(t, cloneDir, "git", "rev-parse", pullHead))
+		})
+	}
+}
+
 func TestClone_CheckoutMergeNoReclone(t *testing.T) {
 	// Initialize the git repo.
 	repoDir, cleanup := initRepo(t)
@@ -220,6 +269,201 @@ func TestClone_CheckoutMergeNoRecloneFastForward(t *testing.T) {
 }
 
 // Test that if there's a conflict when merging we return a good error.
+// Test that the branch checkout strategy clones with the checkout depth and
+// filter.
+func TestClone_DepthAndFilter(t *testing.T) {
+	repoDir, cleanup := initRepo(t)
+	defer cleanup()
+	runCmd(t, repoDir, "git", "config", "--local", "uploadpack.allowFilter", "true")
+	runCmd(t, repoDir, "git", "checkout", "branch")
+	for i := 0; i < 3; i++ {
+		runCmd(t, repoDir, "git", "commit", "--allow-empty", "-m", fmt.Sprintf("commit %d", i))
+	}
+
+	dataDir, cleanup2 := TempDir(t)
+	defer cleanup2()
+
+	wd := &events.FileWorkspace{
+		DataDir:                     dataDir,
+		CheckoutDepth:               2,
+		CheckoutFilter:              "blob:none",
+		TestingOverrideHeadCloneURL: fmt.Sprintf("file://%s", repoDir),
+		GpgNoSigningEnabled:         true,
+	}
+	cloneDir, _, err := wd.Clone(logging.NewNoopLogger(t), models.Repo{}, models.PullRequest{
+		BaseRepo:   models.Repo{},
+		HeadBranch: "branch",
+	}, "default")
+	Ok(t, err)
+
+	Equals(t, "2\n", runCmd(t, cloneDir, "git", "rev-list", "--count", "HEAD"))
+	Equals(t, "blob:none\n", runCmd(t, cloneDir, "git", "config", "remote.origin.partialclonefilter"))
+}
+
+// Test that a shallow clone with the merge checkout strategy is deepened
+// until the merge base of the branches is reachable.
+func TestClone_CheckoutMergeDeepen(t *testing.T) {
+	repoDir, cleanup := initRepo(t)
+	defer cleanup()
+
+	// Branch off after some history so that there's history before the
+	// merge base, then add commits to both branches so the merge base is a
+	// few commits back.
+	for i := 0; i < 10; i++ {
+		runCmd(t, repoDir, "git", "commit", "--allow-empty", "-m", fmt.Sprintf("commit %d", i))
+	}
+	runCmd(t, repoDir, "git", "checkout", "-b", "pr")
+	for i := 0; i < 5; i++ {
+		file := fmt.Sprintf("branch-file%d", i)
+		runCmd(t, repoDir, "touch", file)
+		runCmd(t, repoDir, "git", "add", file)
+		runCmd(t, repoDir, "git", "commit", "-m", file)
+	}
+	branchCommit := runCmd(t, repoDir, "git", "rev-parse", "HEAD")
+	runCmd(t, repoDir, "git", "checkout", "master")
+	for i := 0; i < 5; i++ {
+		file := fmt.Sprintf("master-file%d", i)
+		runCmd(t, repoDir, "touch", file)
+		runCmd(t, repoDir, "git", "add", file)
+		runCmd(t, repoDir, "git", "commit", "-m", file)
+	}
+
+	dataDir, cleanup2 := TempDir(t)
+	defer cleanup2()
+
+	overrideURL := fmt.Sprintf("file://%s", repoDir)
+	wd := &events.FileWorkspace{
+		DataDir:                     dataDir,
+		CheckoutMerge:               true,
+		CheckoutDepth:               1,
+		TestingOverrideHeadCloneURL: overrideURL,
+		TestingOverrideBaseCloneURL: overrideURL,
+		GpgNoSigningEnabled:         true,
+	}
+	cloneDir, _, err := wd.Clone(logging.NewNoopLogger(t), models.Repo{}, models.PullRequest{
+		BaseRepo:   models.Repo{},
+		HeadBranch: "pr",
+		BaseBranch: "master",
+	}, "default")
+	Ok(t, err)
+
+	// HEAD^2 should be the head of the branch.
+	Equals(t, branchCommit, runCmd(t, cloneDir, "git", "rev-parse", "HEAD^2"))
+	// The clone should only have been deepened as far as needed.
+	Equals(t, "true\n", runCmd(t, cloneDir, "git", "rev-parse", "--is-shallow-repository"))
+	for i := 0; i < 5; i++ {
+		_, err := os.Stat(filepath.Join(cloneDir, fmt.Sprintf("master-file%d", i)))
+		Ok(t, err)
+		_, err = os.Stat(filepath.Join(cloneDir, fmt.Sprintf("branch-file%d", i)))
+		Ok(t, err)
+	}
+}
+
+// Test that with the merge checkout strategy, the VCS's merge ref is checked
+// out if it's a merge of the pull request's head commit and that otherwise
+// the branches are merged locally.
+func TestClone_CheckoutMergeVCSMergeRef(t *testing.T) {
+	cases := []struct {
+		description  string
+		hostType     models.VCSHostType
+		mergeRef     string
+		staleRef     bool
+		expVCSMerged bool
+	}{
+		{
+			description:  "github",
+			hostType:     models.Github,
+			mergeRef:     "refs/pull/1/merge",
+			expVCSMerged: true,
+		},
+		{
+			description:  "gitlab",
+			hostType:     models.Gitlab,
+			mergeRef:     "refs/merge-requests/1/merge",
+			expVCSMerged: true,
+		},
+		{
+			description:  "stale merge ref",
+			hostType:     models.Github,
+			mergeRef:     "refs/pull/1/merge",
+			staleRef:     true,
+			expVCSMerged: false,
+		},
+		{
+			description:  "no merge ref",
+			hostType:     models.Github,
+			expVCSMerged: false,
+		},
+		{
+			description:  "unsupported vcs",
+			hostType:     models.BitbucketCloud,
+			mergeRef:     "refs/pull/1/merge",
+			expVCSMerged: false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			repoDir, cleanup := initRepo(t)
+			defer cleanup()
+
+			runCmd(t, repoDir, "git", "checkout", "branch")
+			runCmd(t, repoDir, "touch", "branch-file")
+			runCmd(t, repoDir, "git", "add", "branch-file")
+			runCmd(t, repoDir, "git", "commit", "-m", "branch-commit")
+			headCommit := strings.TrimSpace(runCmd(t, repoDir, "git", "rev-parse", "HEAD"))
+			runCmd(t, repoDir, "git", "checkout", "master")
+			runCmd(t, repoDir, "touch", "master-file")
+			runCmd(t, repoDir, "git", "add", "master-file")
+			runCmd(t, repoDir, "git", "commit", "-m", "master-commit")
+
+			// Simulate the VCS's merge with a file that a local merge wouldn't
+			// have.
+			if c.mergeRef != "" {
+				runCmd(t, repoDir, "git", "checkout", "-b", "vcs-merge")
+				runCmd(t, repoDir, "git", "merge", "--no-ff", "-m", "vcs merge", "branch")
+				runCmd(t, repoDir, "touch", "vcs-merged")
+				runCmd(t, repoDir, "git", "add", "vcs-merged")
+				runCmd(t, repoDir, "git", "commit", "--amend", "-m", "vcs merge")
+				runCmd(t, repoDir, "git", "update-ref", c.mergeRef, "HEAD")
+				runCmd(t, repoDir, "git", "checkout", "master")
+			}
+			if c.staleRef {
+				runCmd(t, repoDir, "git", "checkout", "branch")
+				runCmd(t, repoDir, "git", "commit", "--allow-empty", "-m", "new-branch-commit")
+				headCommit = strings.TrimSpace(runCmd(t, repoDir, "git", "rev-parse", "HEAD"))
+				runCmd(t, repoDir, "git", "checkout", "master")
+			}
+
+			dataDir, cleanup2 := TempDir(t)
+			defer cleanup2()
+
+			overrideURL := fmt.Sprintf("file://%s", repoDir)
+			wd := &events.FileWorkspace{
+				DataDir:                     dataDir,
+				CheckoutMerge:               true,
+				TestingOverrideHeadCloneURL: overrideURL,
+				TestingOverrideBaseCloneURL: overrideURL,
+				GpgNoSigningEnabled:         true,
+			}
+			repo := models.Repo{VCSHost: models.VCSHost{Type: c.hostType}}
+			cloneDir, _, err := wd.Clone(logging.NewNoopLogger(t), repo, models.PullRequest{
+				Num:        1,
+				BaseRepo:   repo,
+				HeadBranch: "branch",
+				HeadCommit: headCommit,
+				BaseBranch: "master",
+			}, "default")
+			Ok(t, err)
+
+			Equals(t, headCommit+"\n", runCmd(t, cloneDir, "git", "rev-parse", "HEAD^2"))
+			_, err = os.Stat(filepath.Join(cloneDir, "master-file"))
+			Ok(t, err)
+			_, err = os.Stat(filepath.Join(cloneDir, "vcs-merged"))
+			Equals(t, c.expVCSMerged, err == nil)
+		})
+	}
+}
+
 func TestClone_CheckoutMergeConflict(t *testing.T) {
 	// Initialize the git repo.
 	repoDir, cleanup := initRepo(t)
@@ -334,6 +578,93 @@ func TestClone_RecloneWrongCommit(t *testing.T) {
 
 // Test that if the branch we're merging into has diverged and we're using
 // checkout-strategy=merge, we warn the user (see #804).
+// Test that each workspace gets its own global git config and that it's
+// removed with the workspace.
+func TestClone_GitConfig(t *testing.T) {
+	repoDir, cleanup := initRepo(t)
+	defer cleanup()
+
+	dataDir, cleanup2 := TempDir(t)
+	defer cleanup2()
+
+	wd := &events.FileWorkspace{
+		DataDir:                     dataDir,
+		CheckoutMerge:               false,
+		TestingOverrideHeadCloneURL: fmt.Sprintf("file://%s", repoDir),
+		GpgNoSigningEnabled:         true,
+	}
+	pull := models.PullRequest{
+		BaseRepo:   models.Repo{},
+		HeadBranch: "branch",
+	}
+	cloneDir, _, err := wd.Clone(logging.NewNoopLogger(t), models.Repo{}, pull, "default")
+	Ok(t, err)
+	otherDir, _, err := wd.Clone(logging.NewNoopLogger(t), models.Repo{}, pull, "other")
+	Ok(t, err)
+
+	// Changing the global config of one workspace shouldn't affect the home
+	// dir or the other workspace.
+	home := t.TempDir()
+	gitConfig := func(dir string, args ...string) string {
+		cmd := exec.Command("git", append([]string{"config", "--global"}, args...)...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "HOME="+home, "GIT_CONFIG_GLOBAL="+events.GitConfigFile(dir))
+		out, err := cmd.CombinedOutput()
+		Ok(t, err)
+		return string(out)
+	}
+	Equals(t, "atlantis\n", gitConfig(cloneDir, "user.name"))
+	gitConfig(cloneDir, "user.name", "changed")
+	Equals(t, "changed\n", gitConfig(cloneDir, "user.name"))
+	Equals(t, "atlantis\n", gitConfig(otherDir, "user.name"))
+	_, err = os.Stat(filepath.Join(home, ".gitconfig"))
+	Assert(t, os.IsNotExist(err), "exp home git config to not exist")
+
+	Ok(t, wd.DeleteForWorkspace(models.Repo{}, pull, "default"))
+	_, err = os.Stat(events.GitConfigFile(cloneDir))
+	Assert(t, os.IsNotExist(err), "exp git config to be deleted")
+	_, err = os.Stat(events.GitConfigFile(otherDir))
+	Ok(t, err)
+}
+
+func TestClone_Tenant(t *testing.T) {
+	repoDir, cleanup := initRepo(t)
+	defer cleanup()
+
+	dataDir, cleanup2 := TempDir(t)
+	defer cleanup2()
+
+	wd := &events.FileWorkspace{
+		DataDir:                     dataDir,
+		CheckoutMerge:               false,
+		TestingOverrideHeadCloneURL: fmt.Sprintf("file://%s", repoDir),
+		GpgNoSigningEnabled:         true,
+		GlobalCfg: valid.NewGlobalCfgStore(valid.GlobalCfg{
+			Tenants: []valid.Tenant{
+				{Name: "payments", RepoIDs: []string{"github.com/payments/repo"}, DataDirQuotaMB: 1},
+			},
+		}),
+	}
+	repo := models.Repo{
+		FullName: "payments/repo",
+		VCSHost:  models.VCSHost{Hostname: "github.com", Type: models.Github},
+	}
+	pull := models.PullRequest{
+		Num:        1,
+		BaseRepo:   repo,
+		HeadBranch: "branch",
+	}
+	cloneDir, _, err := wd.Clone(logging.NewNoopLogger(t), repo, pull, "default")
+	Ok(t, err)
+	Equals(t, filepath.Join(dataDir, "tenants", "payments", "repos", "payments", "repo", "1", "default"), cloneDir)
+
+	// Once the tenant has used up its quota, new clones fail.
+	Ok(t, os.WriteFile(filepath.Join(cloneDir, "big"), make([]byte, 1024*1024), 0600))
+	pull.Num = 2
+	_, _, err = wd.Clone(logging.NewNoopLogger(t), repo, pull, "default")
+	ErrEquals(t, "tenant payments is using 1 MB of its 1 MB data dir quota: merge or close pull requests to free up space", err)
+}
+
 func TestClone_MasterHasDiverged(t *testing.T) {
 	// Initialize the git repo.
 	repoDir, cleanup := initRepo(t)
@@ -475,6 +806,65 @@ func TestHasDiverged_MasterHasDiverged(t *testing.T) {
 	Equals(t, hasDiverged, false)
 }
 
+// Test that force-pushes are told apart from fast-forwards for both checkout
+// strategies.
+func TestWasForcePushed(t *testing.T) {
+	for _, checkoutMerge := range []bool{false, true} {
+		t.Run(fmt.Sprintf("merge %t", checkoutMerge), func(t *testing.T) {
+			repoDir, cleanup := initRepo(t)
+			defer cleanup()
+			runCmd(t, repoDir, "git", "checkout", "branch")
+			runCmd(t, repoDir, "git", "commit", "--allow-empty", "-m", "branch-commit")
+			headCommit := strings.TrimSpace(runCmd(t, repoDir, "git", "rev-parse", "HEAD"))
+
+			dataDir, cleanup2 := TempDir(t)
+			defer cleanup2()
+
+			overrideURL := fmt.Sprintf("file://%s", repoDir)
+			wd := &events.FileWorkspace{
+				DataDir:                     dataDir,
+				CheckoutMerge:               checkoutMerge,
+				TestingOverrideHeadCloneURL: overrideURL,
+				TestingOverrideBaseCloneURL: overrideURL,
+				GpgNoSigningEnabled:         true,
+			}
+			pull := models.PullRequest{
+				BaseRepo:   models.Repo{VCSHost: models.VCSHost{Type: models.BitbucketServer}},
+				HeadBranch: "branch",
+				HeadCommit: headCommit,
+				BaseBranch: "master",
+			}
+			logger := logging.NewNoopLogger(t)
+
+			// Workspaces that weren't cloned weren't force-pushed.
+			forcePushed, err := wd.WasForcePushed(logger, pull, "default")
+			Ok(t, err)
+			Equals(t, false, forcePushed)
+
+			_, _, err = wd.Clone(logger, pull.BaseRepo, pull, "default")
+			Ok(t, err)
+			forcePushed, err = wd.WasForcePushed(logger, pull, "default")
+			Ok(t, err)
+			Equals(t, false, forcePushed)
+
+			// Fast-forward.
+			runCmd(t, repoDir, "git", "commit", "--allow-empty", "-m", "new-commit")
+			pull.HeadCommit = strings.TrimSpace(runCmd(t, repoDir, "git", "rev-parse", "HEAD"))
+			forcePushed, err = wd.WasForcePushed(logger, pull, "default")
+			Ok(t, err)
+			Equals(t, false, forcePushed)
+
+			// Force-push.
+			runCmd(t, repoDir, "git", "reset", "--hard", "HEAD~2")
+			runCmd(t, repoDir, "git", "commit", "--allow-empty", "-m", "rewritten-commit")
+			pull.HeadCommit = strings.TrimSpace(runCmd(t, repoDir, "git", "rev-parse", "HEAD"))
+			forcePushed, err = wd.WasForcePushed(logger, pull, "default")
+			Ok(t, err)
+			Equals(t, true, forcePushed)
+		})
+	}
+}
+
 func initRepo(t *testing.T) (string, func()) {
 	repoDir, cleanup := TempDir(t)
 	runCmd(t, repoDir, "git", "init", "--initial-branch=master")
@@ -0,0 +1,32 @@
+// Package grpcjson encodes gRPC messages as JSON instead of protobuf so that
+// services don't need generated code. Clients must set the content subtype
+// to Name, ex. with grpc.CallContentSubtype, which makes the content type
+// application/grpc+json.
+package grpcjson
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// Name is the content subtype of JSON messages.
+const Name = "json"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (codec) Name() string {
+	return Name
+}
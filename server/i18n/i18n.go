@@ -0,0 +1,95 @@
+// Package i18n translates the text Atlantis comments on pull requests.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultLocale is the locale used if no locale is configured.
+const DefaultLocale = "en"
+
+// catalogs holds the messages for each supported locale, keyed by locale.
+var catalogs = map[string]map[string]string{
+	"en": enMessages,
+	"de": deMessages,
+	"es": esMessages,
+}
+
+// Catalog translates message keys into the messages for a single locale.
+// A nil Catalog translates into the DefaultLocale.
+type Catalog struct {
+	locale   string
+	messages map[string]string
+}
+
+// NewCatalog returns the Catalog for locale. It returns an error if locale
+// isn't supported.
+func NewCatalog(locale string) (*Catalog, error) {
+	messages, ok := catalogs[locale]
+	if !ok {
+		return nil, fmt.Errorf("unsupported locale %q, supported locales are: %s", locale, strings.Join(Locales(), ", "))
+	}
+	return &Catalog{
+		locale:   locale,
+		messages: messages,
+	}, nil
+}
+
+// Locales returns the supported locales.
+func Locales() []string {
+	var locales []string
+	for l := range catalogs {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Locale returns the locale of the catalog.
+func (c *Catalog) Locale() string {
+	if c == nil {
+		return DefaultLocale
+	}
+	return c.locale
+}
+
+// T returns the message for key formatted with args. If the catalog doesn't
+// have a message for key, the message from the DefaultLocale is used.
+func (c *Catalog) T(key string, args ...interface{}) string {
+	msg, ok := "", false
+	if c != nil {
+		msg, ok = c.messages[key]
+	}
+	if !ok {
+		msg, ok = enMessages[key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Has returns true if the catalog has its own message for key.
+func (c *Catalog) Has(key string) bool {
+	if c == nil {
+		_, ok := enMessages[key]
+		return ok
+	}
+	_, ok := c.messages[key]
+	return ok
+}
+
+// Keys returns the keys of all messages.
+func Keys() []string {
+	var keys []string
+	for k := range enMessages {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
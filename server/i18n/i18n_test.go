@@ -0,0 +1,52 @@
+package i18n_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/i18n"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestNewCatalog_Unsupported(t *testing.T) {
+	_, err := i18n.NewCatalog("xx")
+	ErrEquals(t, `unsupported locale "xx", supported locales are: de, en, es`, err)
+}
+
+func TestCatalog_T(t *testing.T) {
+	en, err := i18n.NewCatalog("en")
+	Ok(t, err)
+	Equals(t, "Ran Plan for 2 projects:", en.T("ran_command_for_projects", "Plan", 2))
+	Equals(t, "Show Output", en.T("show_output"))
+
+	de, err := i18n.NewCatalog("de")
+	Ok(t, err)
+	Equals(t, "Plan für 2 Projekte ausgeführt:", de.T("ran_command_for_projects", "Plan", 2))
+
+	// Unknown keys are returned as is.
+	Equals(t, "unknown_key", de.T("unknown_key"))
+}
+
+func TestCatalog_TNil(t *testing.T) {
+	var c *i18n.Catalog
+	Equals(t, i18n.DefaultLocale, c.Locale())
+	Equals(t, "Show Output", c.T("show_output"))
+}
+
+// Test that every locale translates every message and uses the same format
+// verbs as the English message.
+func TestCatalogs_Complete(t *testing.T) {
+	verbRegex := regexp.MustCompile(`%[a-z]`)
+	en, err := i18n.NewCatalog(i18n.DefaultLocale)
+	Ok(t, err)
+	for _, locale := range i18n.Locales() {
+		t.Run(locale, func(t *testing.T) {
+			c, err := i18n.NewCatalog(locale)
+			Ok(t, err)
+			for _, key := range i18n.Keys() {
+				Assert(t, c.Has(key), "locale %q is missing message %q", locale, key)
+				Equals(t, verbRegex.FindAllString(en.T(key), -1), verbRegex.FindAllString(c.T(key), -1))
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package i18n
+
+// deMessages are the German messages.
+var deMessages = map[string]string{
+	"ran_command_for":            "%s ausgeführt für",
+	"ran_command_for_projects":   "%s für %d Projekte ausgeführt:",
+	"approved_policies_for":      "Policies für %d Projekte genehmigt:",
+	"apply_all":                  "Um alle noch nicht angewendeten Pläne dieses Pull Requests **anzuwenden**, kommentiere:",
+	"delete_all":                 "Um alle Pläne und Sperren dieses Pull Requests zu löschen, kommentiere:",
+	"show_output":                "Ausgabe anzeigen",
+	"show_full_output":           "Vollständige Ausgabe anzeigen",
+	"view_full_output":           "Die obige Ausgabe wurde gefiltert, um die vollständige Ausgabe **anzusehen**, klicke [hier](%s)",
+	"apply_plan":                 "Um diesen Plan **anzuwenden**, kommentiere:",
+	"delete_plan":                "Um diesen Plan zu **löschen**, klicke [hier](%s)",
+	"view_graph":                 "Um den Abhängigkeitsgraphen dieses Projekts **anzusehen**, klicke [hier](%s)",
+	"replan":                     "Um für dieses Projekt erneut einen **Plan** zu erstellen, kommentiere:",
+	"replan_policies":            "Um die Policies erneut zu prüfen, erstelle erneut einen **Plan** für dieses Projekt mit dem Kommentar:",
+	"plan_not_saved":             "Dieser Plan wurde nicht gespeichert, da mindestens ein Projekt fehlgeschlagen ist und Automerge erfordert, dass alle Pläne erfolgreich sind.",
+	"branch_diverged":            "Der Ziel-Branch ist weiter fortgeschritten, es wird empfohlen, zuerst die neuen Commits zu übernehmen.",
+	"command_error":              "%s Fehler",
+	"command_failed":             "%s fehlgeschlagen",
+	"approve_policies":           "Um fehlgeschlagene Policies zu **genehmigen**, kann eine berechtigte Person kommentieren:",
+	"address_policy_failure":     "Oder behebe den Policy-Fehler, indem du den Code anpasst und erneut einen Plan erstellst.",
+	"log":                        "Log",
+	"did_you_mean_atlantis":      "Wolltest du `atlantis` statt `terraform` verwenden?",
+	"error_parsing_command":      "Fehler beim Parsen des Befehls: %s",
+	"unknown_command":            "Fehler: unbekannter Befehl %q.",
+	"error":                      "Fehler: %s.",
+	"usage_of":                   "Verwendung von %s:",
+	"unlock_usage":               "Entsperrt den gesamten PR und verwirft alle Pläne dieses PRs.\n  Argumente oder Flags werden derzeit nicht unterstützt.\n  Um ein einzelnes Projekt zu entsperren, verwende die Atlantis-Oberfläche.",
+	"help.title":                 "Terraform Pull Request Automatisierung",
+	"help.usage":                 "Verwendung:",
+	"help.examples":              "Beispiele:",
+	"help.example.plan":          "plan im Wurzelverzeichnis ausführen und das -target Flag an terraform übergeben",
+	"help.example.apply_all":     "alle noch nicht angewendeten Pläne dieses Pull Requests anwenden",
+	"help.example.apply_dir":     "den Plan für das Wurzelverzeichnis und den Workspace staging anwenden",
+	"help.commands":              "Befehle:",
+	"help.plan":                  "Führt 'terraform plan' für die Änderungen in diesem Pull Request aus.\n           Um ein bestimmtes Projekt zu planen, verwende die Flags -d, -w und -p.",
+	"help.apply":                 "Führt 'terraform apply' für alle noch nicht angewendeten Pläne aus.\n           Um nur einen bestimmten Plan anzuwenden, verwende die Flags -d, -w und -p.",
+	"help.unlock":                "Entfernt alle Atlantis-Sperren und verwirft alle Pläne dieses PRs.\n           Um einen bestimmten Plan zu entsperren, verwende die Atlantis-Oberfläche.",
+	"help.force_unlock_state":    "Führt 'terraform force-unlock' aus, um eine verwaiste State-Sperre zu entfernen.\n           Verwendung: atlantis force_unlock_state LOCK_ID",
+	"help.approve_policies":      "Genehmigt alle aktuellen Policy-Fehler dieses PRs.",
+	"help.approve_resources":     "Genehmigt die Änderungen an geschützten Ressourcen in den Plänen dieses PRs.",
+	"help.version":               "Gibt die Ausgabe von 'terraform version' aus",
+	"help.fmt":                   "Prüft die Formatierung der Terraform-Dateien mit 'terraform fmt'.\n           Um einen Commit zu pushen, der sie formatiert, verwende --fix.",
+	"help.providers":             "Führt 'terraform providers lock' aus und pusht die aktualisierten Lock-Dateien.\n           Verwendung: atlantis providers lock",
+	"help.cancel":                "Bricht die Pläne und Applies ab, die in diesem Pull Request laufen.\n           Um nur ein bestimmtes Projekt abzubrechen, verwende die Flags -d, -w und -p.",
+	"help.init_config":           "Öffnet einen Pull Request, der eine generierte atlantis.yaml\n           mit einem Projekt pro Terraform-Root-Modul hinzufügt.",
+	"help.revert":                "Öffnet einen Pull Request, der diesen gemergten Pull Request rückgängig macht,\n           und plant die Projekte, die er ändert.",
+	"help.help":                  "Hilfe anzeigen.",
+	"help.projects":              "Projekte (mit diesen Flags auswählen):",
+	"help.flags":                 "Flags:",
+	"help.help_flag":             "Hilfe für atlantis",
+	"help.more":                  "Verwende \"atlantis [command] --help\" für weitere Informationen zu einem Befehl.",
+	"status_comment_title":       "Atlantis Status",
+	"status_comment_no_projects": "Es wurden noch keine Projekte geplant.",
+	"project":                    "Projekt",
+	"dir":                        "Verzeichnis",
+	"workspace":                  "Workspace",
+	"status":                     "Status",
+	"plan_summary_title":         "Plan-Zusammenfassung",
+	"changes":                    "Änderungen",
+	"project_name":               "Projekt: `%s`",
+	"dir_workspace":              "Verzeichnis: `%s` Workspace: `%s`",
+	"lock.locked_by_pull":        "Dieses Projekt ist derzeit durch einen nicht angewendeten Plan aus Pull Request %s gesperrt. Um fortzufahren, lösche die Sperre unter %s oder wende diesen Plan an und merge den Pull Request.\n\nSobald die Sperre aufgehoben ist, kommentiere hier `atlantis plan`, um erneut einen Plan zu erstellen.",
+	"lock.unlocked":              "Alle Atlantis-Sperren dieses PRs wurden aufgehoben und die Pläne verworfen",
+	"lock.unlock_failed":         "Die Sperren des PRs konnten nicht gelöscht werden",
+	"apply_req.approved":         "Der Pull Request muss vor dem Apply von mindestens einer Person außer dem Autor genehmigt werden.",
+	"apply_req.policies_passed":  "Alle Policies des Projekts müssen vor dem Apply erfüllt sein",
+	"apply_req.mergeable":        "Der Pull Request muss vor dem Apply mergebar sein.",
+	"apply_req.undiverged":       "Der Standard-Branch muss vor dem Apply in den Pull Request übernommen werden.",
+	"apply_req.no_plan_json":     "Das Plan-JSON wurde nicht gefunden, daher können gelöschte Ressourcen nicht geprüft werden. Erstelle vor dem Apply erneut einen Plan.",
+	"apply_req.destroys":         "Der Plan löscht oder ersetzt geschützte Ressourcen: %s.",
+	"apply_req.destroy_approver": "Ein Mitglied von %s kann `atlantis apply --destroy-approved` kommentieren, um ihn trotzdem anzuwenden.",
+	"apply_req.not_approver":     "Nur Mitglieder von %s können dies genehmigen.",
+	"apply_req.no_change_mgmt":   "Change-Tickets müssen vor dem Apply genehmigt werden, aber Atlantis ist mit keinem Change-Management-System konfiguriert.",
+	"apply_req.ticket_pending":   "Das Change-Ticket [%s](%s) muss vor dem Apply genehmigt werden.",
+	"apply_req.no_plan_output":   "Die Plan-Ausgabe wurde nicht gefunden, daher kann kein Change-Ticket geöffnet werden. Erstelle vor dem Apply erneut einen Plan.",
+	"apply_req.ticket_opened":    "Change-Ticket [%s](%s) geöffnet. Führe das Apply erneut aus, sobald es genehmigt ist.",
+	"shutting_down":              "Der Atlantis-Server wird heruntergefahren, bitte versuche es später erneut.",
+	"no_permissions":             "Fehler: Benutzer @%s hat keine Berechtigung, den Befehl '%s' auszuführen.",
+	"command_not_allowed":        "Fehler: %s",
+	"fork_pull_request":          "Atlantis-Befehle können nicht auf Pull Requests aus Forks ausgeführt werden. Zum Aktivieren setze --%s  oder, um diese Nachricht zu deaktivieren, setze --%s",
+	"revert_not_merged":          "Nur gemergte Pull Requests können rückgängig gemacht werden",
+	"pull_request_closed":        "Atlantis-Befehle können nicht auf geschlossenen Pull Requests ausgeführt werden",
+	"panic":                      "Fehler: Goroutine-Panic. Dies ist ein Bug.",
+}
@@ -0,0 +1,88 @@
+package i18n
+
+// enMessages are the English messages. Every message must be defined here
+// since it's the fallback for the other locales.
+var enMessages = map[string]string{
+	"ran_command_for":            "Ran %s for",
+	"ran_command_for_projects":   "Ran %s for %d projects:",
+	"approved_policies_for":      "Approved Policies for %d projects:",
+	"apply_all":                  "To **apply** all unapplied plans from this pull request, comment:",
+	"delete_all":                 "To delete all plans and locks for the PR, comment:",
+	"show_output":                "Show Output",
+	"show_full_output":           "Show Full Output",
+	"view_full_output":           "The output above was filtered, to **view** the full output click [here](%s)",
+	"apply_plan":                 "To **apply** this plan, comment:",
+	"delete_plan":                "To **delete** this plan click [here](%s)",
+	"view_graph":                 "To **view** the dependency graph of this project click [here](%s)",
+	"replan":                     "To **plan** this project again, comment:",
+	"replan_policies":            "To re-run policies **plan** this project again by commenting:",
+	"plan_not_saved":             "This plan was not saved because one or more projects failed and automerge requires all plans pass.",
+	"branch_diverged":            "The branch we're merging into is ahead, it is recommended to pull new commits first.",
+	"command_error":              "%s Error",
+	"command_failed":             "%s Failed",
+	"approve_policies":           "To **approve** failing policies an authorized approver can comment:",
+	"address_policy_failure":     "Or, address the policy failure by modifying the codebase and re-planning.",
+	"log":                        "Log",
+	"did_you_mean_atlantis":      "Did you mean to use `atlantis` instead of `terraform`?",
+	"error_parsing_command":      "Error parsing command: %s",
+	"unknown_command":            "Error: unknown command %q.",
+	"error":                      "Error: %s.",
+	"usage_of":                   "Usage of %s:",
+	"unlock_usage":               "Unlocks the entire PR and discards all plans in this PR.\n  Arguments or flags are not supported at the moment.\n  If you need to unlock a specific project please use the atlantis UI.",
+	"help.title":                 "Terraform Pull Request Automation",
+	"help.usage":                 "Usage:",
+	"help.examples":              "Examples:",
+	"help.example.plan":          "run plan in the root directory passing the -target flag to terraform",
+	"help.example.apply_all":     "apply all unapplied plans from this pull request",
+	"help.example.apply_dir":     "apply the plan for the root directory and staging workspace",
+	"help.commands":              "Commands:",
+	"help.plan":                  "Runs 'terraform plan' for the changes in this pull request.\n           To plan a specific project, use the -d, -w and -p flags.",
+	"help.apply":                 "Runs 'terraform apply' on all unapplied plans from this pull request.\n           To only apply a specific plan, use the -d, -w and -p flags.",
+	"help.unlock":                "Removes all atlantis locks and discards all plans for this PR.\n           To unlock a specific plan you can use the Atlantis UI.",
+	"help.force_unlock_state":    "Runs 'terraform force-unlock' to remove a stale state lock.\n           Usage: atlantis force_unlock_state LOCK_ID",
+	"help.approve_policies":      "Approves all current policy checking failures for the PR.",
+	"help.approve_resources":     "Approves the changes to protected resources in this PR's plans.",
+	"help.version":               "Print the output of 'terraform version'",
+	"help.fmt":                   "Checks the formatting of the Terraform files with 'terraform fmt'.\n           To push a commit that formats them, use --fix.",
+	"help.providers":             "Runs 'terraform providers lock' and pushes the updated lock files.\n           Usage: atlantis providers lock",
+	"help.cancel":                "Cancels the plans and applies that are running in this pull request.\n           To only cancel a specific project, use the -d, -w and -p flags.",
+	"help.init_config":           "Opens a pull request that adds a generated atlantis.yaml\n           with a project for each Terraform root module.",
+	"help.revert":                "Opens a pull request that reverts this merged pull request\n           and plans the projects it changes.",
+	"help.help":                  "View help.",
+	"help.projects":              "Projects (select one with these flags):",
+	"help.flags":                 "Flags:",
+	"help.help_flag":             "help for atlantis",
+	"help.more":                  "Use \"atlantis [command] --help\" for more information about a command.",
+	"status_comment_title":       "Atlantis Status",
+	"status_comment_no_projects": "No projects have been planned yet.",
+	"project":                    "Project",
+	"dir":                        "Dir",
+	"workspace":                  "Workspace",
+	"status":                     "Status",
+	"plan_summary_title":         "Plan Summary",
+	"changes":                    "Changes",
+	"project_name":               "project: `%s`",
+	"dir_workspace":              "dir: `%s` workspace: `%s`",
+	"lock.locked_by_pull":        "This project is currently locked by an unapplied plan from pull %s. To continue, delete the lock from %s or apply that plan and merge the pull request.\n\nOnce the lock is released, comment `atlantis plan` here to re-plan.",
+	"lock.unlocked":              "All Atlantis locks for this PR have been unlocked and plans discarded",
+	"lock.unlock_failed":         "Failed to delete PR locks",
+	"apply_req.approved":         "Pull request must be approved by at least one person other than the author before running apply.",
+	"apply_req.policies_passed":  "All policies must pass for project before running apply",
+	"apply_req.mergeable":        "Pull request must be mergeable before running apply.",
+	"apply_req.undiverged":       "Default branch must be rebased onto pull request before running apply.",
+	"apply_req.no_plan_json":     "Plan JSON not found so destroyed resources can't be checked. Run plan again before running apply.",
+	"apply_req.destroys":         "Plan destroys or replaces protected resources: %s.",
+	"apply_req.destroy_approver": "A member of %s can comment `atlantis apply --destroy-approved` to apply it anyway.",
+	"apply_req.not_approver":     "Only members of %s can approve this.",
+	"apply_req.no_change_mgmt":   "Change tickets must be approved before running apply but Atlantis isn't configured with a change management system.",
+	"apply_req.ticket_pending":   "Change ticket [%s](%s) must be approved before running apply.",
+	"apply_req.no_plan_output":   "Plan output not found so a change ticket can't be opened. Run plan again before running apply.",
+	"apply_req.ticket_opened":    "Opened change ticket [%s](%s). Run apply again once it's approved.",
+	"shutting_down":              "Atlantis server is shutting down, please try again later.",
+	"no_permissions":             "Error: User @%s does not have permissions to execute '%s' command.",
+	"command_not_allowed":        "Error: %s",
+	"fork_pull_request":          "Atlantis commands can't be run on fork pull requests. To enable, set --%s  or, to disable this message, set --%s",
+	"revert_not_merged":          "Only merged pull requests can be reverted",
+	"pull_request_closed":        "Atlantis commands can't be run on closed pull requests",
+	"panic":                      "Error: goroutine panic. This is a bug.",
+}
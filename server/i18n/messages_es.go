@@ -0,0 +1,87 @@
+package i18n
+
+// esMessages are the Spanish messages.
+var esMessages = map[string]string{
+	"ran_command_for":            "Se ejecutó %s para",
+	"ran_command_for_projects":   "Se ejecutó %s para %d proyectos:",
+	"approved_policies_for":      "Se aprobaron las políticas para %d proyectos:",
+	"apply_all":                  "Para **aplicar** todos los planes pendientes de este pull request, comenta:",
+	"delete_all":                 "Para eliminar todos los planes y bloqueos del PR, comenta:",
+	"show_output":                "Mostrar salida",
+	"show_full_output":           "Mostrar salida completa",
+	"view_full_output":           "La salida anterior fue filtrada, para **ver** la salida completa haz clic [aquí](%s)",
+	"apply_plan":                 "Para **aplicar** este plan, comenta:",
+	"delete_plan":                "Para **eliminar** este plan haz clic [aquí](%s)",
+	"view_graph":                 "Para **ver** el grafo de dependencias de este proyecto haz clic [aquí](%s)",
+	"replan":                     "Para volver a ejecutar **plan** en este proyecto, comenta:",
+	"replan_policies":            "Para volver a evaluar las políticas, ejecuta **plan** de nuevo en este proyecto comentando:",
+	"plan_not_saved":             "Este plan no se guardó porque uno o más proyectos fallaron y automerge requiere que todos los planes sean exitosos.",
+	"branch_diverged":            "La rama de destino tiene commits nuevos, se recomienda incorporarlos primero.",
+	"command_error":              "Error de %s",
+	"command_failed":             "%s falló",
+	"approve_policies":           "Para **aprobar** las políticas que fallaron, un aprobador autorizado puede comentar:",
+	"address_policy_failure":     "O bien, corrige el fallo de la política modificando el código y volviendo a ejecutar plan.",
+	"log":                        "Registro",
+	"did_you_mean_atlantis":      "¿Quisiste usar `atlantis` en lugar de `terraform`?",
+	"error_parsing_command":      "Error al analizar el comando: %s",
+	"unknown_command":            "Error: comando desconocido %q.",
+	"error":                      "Error: %s.",
+	"usage_of":                   "Uso de %s:",
+	"unlock_usage":               "Desbloquea todo el PR y descarta todos sus planes.\n  Por el momento no se admiten argumentos ni flags.\n  Para desbloquear un proyecto específico usa la interfaz de Atlantis.",
+	"help.title":                 "Automatización de Pull Requests para Terraform",
+	"help.usage":                 "Uso:",
+	"help.examples":              "Ejemplos:",
+	"help.example.plan":          "ejecutar plan en el directorio raíz pasando el flag -target a terraform",
+	"help.example.apply_all":     "aplicar todos los planes pendientes de este pull request",
+	"help.example.apply_dir":     "aplicar el plan del directorio raíz y el workspace staging",
+	"help.commands":              "Comandos:",
+	"help.plan":                  "Ejecuta 'terraform plan' para los cambios de este pull request.\n           Para un proyecto específico, usa los flags -d, -w y -p.",
+	"help.apply":                 "Ejecuta 'terraform apply' en todos los planes pendientes de este pull request.\n           Para aplicar solo un plan específico, usa los flags -d, -w y -p.",
+	"help.unlock":                "Elimina todos los bloqueos de atlantis y descarta todos los planes de este PR.\n           Para desbloquear un plan específico puedes usar la interfaz de Atlantis.",
+	"help.force_unlock_state":    "Ejecuta 'terraform force-unlock' para eliminar un bloqueo de estado huérfano.\n           Uso: atlantis force_unlock_state LOCK_ID",
+	"help.approve_policies":      "Aprueba todos los fallos actuales de políticas del PR.",
+	"help.approve_resources":     "Aprueba los cambios a recursos protegidos en los planes de este PR.",
+	"help.version":               "Muestra la salida de 'terraform version'",
+	"help.fmt":                   "Comprueba el formato de los archivos de Terraform con 'terraform fmt'.\n           Para hacer push de un commit que los formatea, usa --fix.",
+	"help.providers":             "Ejecuta 'terraform providers lock' y hace push de los archivos de bloqueo actualizados.\n           Uso: atlantis providers lock",
+	"help.cancel":                "Cancela los planes y applies que se están ejecutando en este pull request.\n           Para cancelar solo un proyecto específico, usa los flags -d, -w y -p.",
+	"help.init_config":           "Abre un pull request que agrega un atlantis.yaml generado\n           con un proyecto por cada módulo raíz de Terraform.",
+	"help.revert":                "Abre un pull request que revierte este pull request fusionado\n           y planifica los proyectos que cambia.",
+	"help.help":                  "Ver la ayuda.",
+	"help.projects":              "Proyectos (selecciona uno con estos flags):",
+	"help.flags":                 "Flags:",
+	"help.help_flag":             "ayuda de atlantis",
+	"help.more":                  "Usa \"atlantis [command] --help\" para más información sobre un comando.",
+	"status_comment_title":       "Estado de Atlantis",
+	"status_comment_no_projects": "Todavía no se ha ejecutado plan en ningún proyecto.",
+	"project":                    "Proyecto",
+	"dir":                        "Directorio",
+	"workspace":                  "Workspace",
+	"status":                     "Estado",
+	"plan_summary_title":         "Resumen del plan",
+	"changes":                    "Cambios",
+	"project_name":               "proyecto: `%s`",
+	"dir_workspace":              "directorio: `%s` workspace: `%s`",
+	"lock.locked_by_pull":        "Este proyecto está bloqueado por un plan sin aplicar del pull request %s. Para continuar, elimina el bloqueo desde %s o aplica ese plan y haz merge del pull request.\n\nCuando se libere el bloqueo, comenta `atlantis plan` aquí para volver a planificar.",
+	"lock.unlocked":              "Se han liberado todos los bloqueos de Atlantis de este PR y se han descartado los planes",
+	"lock.unlock_failed":         "No se pudieron eliminar los bloqueos del PR",
+	"apply_req.approved":         "El pull request debe ser aprobado por al menos una persona distinta del autor antes de ejecutar apply.",
+	"apply_req.policies_passed":  "Todas las políticas del proyecto deben cumplirse antes de ejecutar apply",
+	"apply_req.mergeable":        "El pull request debe poder fusionarse antes de ejecutar apply.",
+	"apply_req.undiverged":       "La rama por defecto debe integrarse en el pull request antes de ejecutar apply.",
+	"apply_req.no_plan_json":     "No se encontró el JSON del plan, por lo que no se pueden comprobar los recursos eliminados. Vuelve a ejecutar plan antes de ejecutar apply.",
+	"apply_req.destroys":         "El plan elimina o reemplaza recursos protegidos: %s.",
+	"apply_req.destroy_approver": "Un miembro de %s puede comentar `atlantis apply --destroy-approved` para aplicarlo de todos modos.",
+	"apply_req.not_approver":     "Solo los miembros de %s pueden aprobarlo.",
+	"apply_req.no_change_mgmt":   "Los tickets de cambio deben aprobarse antes de ejecutar apply, pero Atlantis no está configurado con un sistema de gestión de cambios.",
+	"apply_req.ticket_pending":   "El ticket de cambio [%s](%s) debe aprobarse antes de ejecutar apply.",
+	"apply_req.no_plan_output":   "No se encontró la salida del plan, por lo que no se puede abrir un ticket de cambio. Vuelve a ejecutar plan antes de ejecutar apply.",
+	"apply_req.ticket_opened":    "Se abrió el ticket de cambio [%s](%s). Vuelve a ejecutar apply cuando esté aprobado.",
+	"shutting_down":              "El servidor de Atlantis se está apagando, inténtalo de nuevo más tarde.",
+	"no_permissions":             "Error: el usuario @%s no tiene permisos para ejecutar el comando '%s'.",
+	"command_not_allowed":        "Error: %s",
+	"fork_pull_request":          "Los comandos de Atlantis no se pueden ejecutar en pull requests de forks. Para habilitarlos, establece --%s  o, para desactivar este mensaje, establece --%s",
+	"revert_not_merged":          "Solo se pueden revertir pull requests fusionados",
+	"pull_request_closed":        "Los comandos de Atlantis no se pueden ejecutar en pull requests cerrados",
+	"panic":                      "Error: pánico en una goroutine. Esto es un bug.",
+}
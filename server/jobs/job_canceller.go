@@ -0,0 +1,337 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// DefaultCancelGracePeriod is how long the processes of a cancelled job have
+// to exit after they're interrupted before they're killed. Terraform uses it
+// to stop gracefully and release its state lock.
+const DefaultCancelGracePeriod = time.Minute
+
+// RunningJob is a project command that's running.
+type RunningJob struct {
+	// ID is the ID of the job in its URL.
+	ID string
+	// RepoFullName is the owner and name of the repo, ex.
+	// "runatlantis/atlantis".
+	RepoFullName string
+	PullNum      int
+	// HeadCommit is the head commit of the pull request that the job is for.
+	HeadCommit string
+	// ProjectName is empty if the project isn't named.
+	ProjectName string
+	RepoRelDir  string
+	Workspace   string
+	// Command is the command that's running, "plan" or "apply".
+	Command string
+	// User is the user that ran the command.
+	User      string
+	StartedAt time.Time
+	// CancelledBy is who cancelled the job, ex. "@alice". Empty if it wasn't
+	// cancelled.
+	CancelledBy string `json:",omitempty"`
+}
+
+// Matches returns true if the job is for the project with projectName,
+// repoRelDir and workspace. Empty arguments match any project.
+func (j RunningJob) Matches(projectName string, repoRelDir string, workspace string) bool {
+	if projectName != "" && j.ProjectName != projectName {
+		return false
+	}
+	if repoRelDir != "" && j.RepoRelDir != repoRelDir {
+		return false
+	}
+	return workspace == "" || j.Workspace == workspace
+}
+
+// JobCanceller tracks the jobs that are running and the processes that they
+// start so that they can be cancelled. It implements command.ProcessStarter.
+type JobCanceller struct {
+	// output receives a line in the output of cancelled jobs. If nil, the
+	// cancellation isn't added to their output.
+	output      ProjectCommandOutputHandler
+	gracePeriod time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*cancellableJob
+	// heads are the latest head commits of the pull requests passed to
+	// Supersede, by pullKey.
+	heads map[string]pullHead
+}
+
+// pullHead is the head commit that a pull request was updated to.
+type pullHead struct {
+	commit    string
+	by        string
+	updatedAt time.Time
+}
+
+// pullHeadTTL is how long Supersede remembers the head commit of a pull
+// request. Plans of older commits that start after it are superseded.
+const pullHeadTTL = 24 * time.Hour
+
+// cancellableJob is a running job and the processes it has started.
+type cancellableJob struct {
+	RunningJob
+	ctx       command.ProjectContext
+	processes map[*exec.Cmd]struct{}
+	// cancelled is closed when the job is cancelled.
+	cancelled chan struct{}
+	// ended is closed when the job ends.
+	ended chan struct{}
+}
+
+// NewJobCanceller returns a canceller that adds cancellations to the output
+// of jobs with output and kills the processes of cancelled jobs if they
+// haven't exited gracePeriod after they're interrupted.
+func NewJobCanceller(output ProjectCommandOutputHandler, gracePeriod time.Duration) *JobCanceller {
+	return &JobCanceller{
+		output:      output,
+		gracePeriod: gracePeriod,
+		jobs:        make(map[string]*cancellableJob),
+		heads:       make(map[string]pullHead),
+	}
+}
+
+// Begin tracks the job that runs cmdName for ctx until end is called. end
+// returns the user that cancelled the job or an empty string if it wasn't
+// cancelled. Plans of commits that were superseded by Supersede begin
+// cancelled.
+func (c *JobCanceller) Begin(ctx command.ProjectContext, cmdName command.Name) (end func() (cancelledBy string)) {
+	job := &cancellableJob{
+		RunningJob: RunningJob{
+			ID:           ctx.JobID,
+			RepoFullName: ctx.BaseRepo.FullName,
+			PullNum:      ctx.Pull.Num,
+			HeadCommit:   ctx.Pull.HeadCommit,
+			ProjectName:  ctx.ProjectName,
+			RepoRelDir:   ctx.RepoRelDir,
+			Workspace:    ctx.Workspace,
+			Command:      cmdName.String(),
+			User:         ctx.User.Username,
+			StartedAt:    time.Now(),
+		},
+		ctx:       ctx,
+		processes: make(map[*exec.Cmd]struct{}),
+		cancelled: make(chan struct{}),
+		ended:     make(chan struct{}),
+	}
+	c.mu.Lock()
+	if head, ok := c.heads[pullKey(job.RepoFullName, job.PullNum)]; ok && cmdName == command.Plan && head.commit != job.HeadCommit {
+		job.CancelledBy = head.by
+		close(job.cancelled)
+	}
+	c.jobs[ctx.JobID] = job
+	c.mu.Unlock()
+	return func() string {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.jobs[ctx.JobID] == job {
+			delete(c.jobs, ctx.JobID)
+			close(job.ended)
+		}
+		return job.CancelledBy
+	}
+}
+
+// Start starts cmd in its own process group. If the job with jobID is
+// running, cmd is interrupted when it's cancelled and isn't started if it
+// already was. See command.ProcessStarter.
+func (c *JobCanceller) Start(jobID string, cmd *exec.Cmd) (func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	job, ok := c.jobs[jobID]
+	if ok && job.CancelledBy != "" {
+		return nil, fmt.Errorf("job was cancelled by %s", job.CancelledBy)
+	}
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return func() {}, nil
+	}
+	job.processes[cmd] = struct{}{}
+	return func() {
+		c.mu.Lock()
+		delete(job.processes, cmd)
+		c.mu.Unlock()
+	}, nil
+}
+
+// Context returns a context that's cancelled when the job with jobID is
+// cancelled, for the work of jobs that doesn't run in local processes, ex.
+// on agents. cancel must be called once the work is done. If the job isn't
+// running, the context is only cancelled by cancel.
+func (c *JobCanceller) Context(jobID string) (ctx context.Context, cancel func()) {
+	ctx, cancel = context.WithCancel(context.Background())
+	c.mu.Lock()
+	job, ok := c.jobs[jobID]
+	c.mu.Unlock()
+	if !ok {
+		return ctx, cancel
+	}
+	go func() {
+		select {
+		case <-job.cancelled:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// Get returns the running job with jobID.
+func (c *JobCanceller) Get(jobID string) (RunningJob, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	job, ok := c.jobs[jobID]
+	if !ok {
+		return RunningJob{}, false
+	}
+	return job.RunningJob, true
+}
+
+// Running returns the jobs that are running in a pull request, oldest first.
+func (c *JobCanceller) Running(repoFullName string, pullNum int) []RunningJob {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var running []RunningJob
+	for _, job := range c.jobs {
+		if job.RepoFullName == repoFullName && job.PullNum == pullNum {
+			running = append(running, job.RunningJob)
+		}
+	}
+	sort.Slice(running, func(i, j int) bool {
+		if running[i].StartedAt.Equal(running[j].StartedAt) {
+			return running[i].ID < running[j].ID
+		}
+		return running[i].StartedAt.Before(running[j].StartedAt)
+	})
+	return running
+}
+
+// Cancel cancels the running job with jobID on behalf of user, ex. "@alice".
+// The process group of each of the job's processes is interrupted, and killed
+// if it hasn't exited within the grace period, and the job can't start any
+// more processes. It returns an error if the job isn't running or was already
+// cancelled.
+func (c *JobCanceller) Cancel(jobID string, user string) (RunningJob, error) {
+	c.mu.Lock()
+	job, ok := c.jobs[jobID]
+	if !ok {
+		c.mu.Unlock()
+		return RunningJob{}, fmt.Errorf("job %s isn't running", jobID)
+	}
+	if job.CancelledBy != "" {
+		c.mu.Unlock()
+		return job.RunningJob, fmt.Errorf("job %s was already cancelled by %s", jobID, job.CancelledBy)
+	}
+	job.CancelledBy = user
+	close(job.cancelled)
+	running := job.RunningJob
+	var processes []*exec.Cmd
+	for cmd := range job.processes {
+		processes = append(processes, cmd)
+	}
+	c.mu.Unlock()
+
+	job.ctx.Log.Info("%s was cancelled by %s", job.Command, user)
+	if c.output != nil {
+		c.output.Send(job.ctx, fmt.Sprintf("Cancelled by %s, interrupting the running process.", user), false)
+	}
+	for _, cmd := range processes {
+		if err := signalProcessGroup(cmd, false); err != nil {
+			job.ctx.Log.Warn("unable to interrupt process %d: %s", cmd.Process.Pid, err)
+		}
+		go c.killAfterGracePeriod(job, cmd)
+	}
+	return running, nil
+}
+
+// Supersede records that a pull request was updated to headCommit by by, ex.
+// "the push of abc1234", and cancels its running plans of other commits. The
+// plans of other commits that begin afterwards are cancelled too so that
+// they don't race with the plans of headCommit. It returns the jobs that it
+// cancelled.
+func (c *JobCanceller) Supersede(repoFullName string, pullNum int, headCommit string, by string) []RunningJob {
+	c.mu.Lock()
+	now := time.Now()
+	for key, head := range c.heads {
+		if now.Sub(head.updatedAt) > pullHeadTTL {
+			delete(c.heads, key)
+		}
+	}
+	c.heads[pullKey(repoFullName, pullNum)] = pullHead{commit: headCommit, by: by, updatedAt: now}
+	var superseded []string
+	for id, job := range c.jobs {
+		if job.RepoFullName == repoFullName && job.PullNum == pullNum && job.Command == command.Plan.String() &&
+			job.HeadCommit != headCommit && job.CancelledBy == "" {
+			superseded = append(superseded, id)
+		}
+	}
+	c.mu.Unlock()
+
+	var cancelled []RunningJob
+	for _, id := range superseded {
+		// The job may have ended since it was superseded.
+		if job, err := c.Cancel(id, by); err == nil {
+			cancelled = append(cancelled, job)
+		}
+	}
+	return cancelled
+}
+
+// Wait waits up to timeout for the jobs with jobIDs to end. It returns false
+// if any of them were still running after timeout.
+func (c *JobCanceller) Wait(jobIDs []string, timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for _, id := range jobIDs {
+		c.mu.Lock()
+		job, ok := c.jobs[id]
+		c.mu.Unlock()
+		if !ok {
+			continue
+		}
+		select {
+		case <-job.ended:
+		case <-deadline.C:
+			return false
+		}
+	}
+	return true
+}
+
+// GracePeriod is how long the processes of cancelled jobs have to exit
+// before they're killed.
+func (c *JobCanceller) GracePeriod() time.Duration {
+	return c.gracePeriod
+}
+
+func pullKey(repoFullName string, pullNum int) string {
+	return fmt.Sprintf("%s#%d", repoFullName, pullNum)
+}
+
+// killAfterGracePeriod kills the process group of cmd if it hasn't exited
+// within the grace period.
+func (c *JobCanceller) killAfterGracePeriod(job *cancellableJob, cmd *exec.Cmd) {
+	time.Sleep(c.gracePeriod)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := job.processes[cmd]; !ok {
+		return
+	}
+	job.ctx.Log.Warn("killing process %d since it didn't exit within %s of being interrupted", cmd.Process.Pid, c.gracePeriod)
+	if err := signalProcessGroup(cmd, true); err != nil {
+		job.ctx.Log.Warn("unable to kill process %d: %s", cmd.Process.Pid, err)
+	}
+}
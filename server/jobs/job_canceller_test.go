@@ -0,0 +1,185 @@
+package jobs_test
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/jobs"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// waitForExit waits for cmd to exit and fails if it takes longer than
+// timeout.
+func waitForExit(t *testing.T, cmd *exec.Cmd, timeout time.Duration) error {
+	t.Helper()
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(timeout):
+		t.Fatalf("process didn't exit within %s", timeout)
+		return nil
+	}
+}
+
+func TestJobCanceller_Cancel(t *testing.T) {
+	ctx := createTestProjectCmdContext(t)
+	ctx.BaseRepo.FullName = "test-org/test-repo"
+	outputHandler := createProjectCommandOutputHandler(t)
+	canceller := jobs.NewJobCanceller(outputHandler, time.Minute)
+
+	end := canceller.Begin(ctx, command.Apply)
+	running := canceller.Running("test-org/test-repo", 1)
+	Equals(t, 1, len(running))
+	Equals(t, "1234", running[0].ID)
+	Equals(t, "apply", running[0].Command)
+	Equals(t, "test-user", running[0].User)
+	Equals(t, 0, len(canceller.Running("test-org/test-repo", 2)))
+
+	// The process and the processes it starts are interrupted.
+	cmd := exec.Command("sh", "-c", "sleep 30; echo done")
+	done, err := canceller.Start(ctx.JobID, cmd)
+	Ok(t, err)
+	job, err := canceller.Cancel(ctx.JobID, "@alice")
+	Ok(t, err)
+	Equals(t, "@alice", job.CancelledBy)
+	ErrContains(t, "signal: interrupt", waitForExit(t, cmd, 5*time.Second))
+	done()
+
+	_, err = canceller.Cancel(ctx.JobID, "@bob")
+	ErrEquals(t, "job 1234 was already cancelled by @alice", err)
+
+	// The job can't start any more processes.
+	_, err = canceller.Start(ctx.JobID, exec.Command("true"))
+	ErrEquals(t, "job was cancelled by @alice", err)
+
+	Equals(t, "@alice", end())
+	Equals(t, 0, len(canceller.Running("test-org/test-repo", 1)))
+	_, err = canceller.Cancel(ctx.JobID, "@alice")
+	ErrEquals(t, "job 1234 isn't running", err)
+
+	// The cancellation is recorded in the job's output.
+	time.Sleep(10 * time.Millisecond)
+	Equals(t, "Cancelled by @alice, interrupting the running process.", outputHandler.LastLine(ctx.JobID))
+}
+
+func TestJobCanceller_KillsAfterGracePeriod(t *testing.T) {
+	ctx := createTestProjectCmdContext(t)
+	canceller := jobs.NewJobCanceller(nil, 100*time.Millisecond)
+	end := canceller.Begin(ctx, command.Plan)
+	defer end()
+
+	// The process ignores interrupts so it has to be killed.
+	cmd := exec.Command("sh", "-c", "trap '' INT; sleep 30")
+	done, err := canceller.Start(ctx.JobID, cmd)
+	Ok(t, err)
+	defer done()
+	// Give the shell time to set up the trap.
+	time.Sleep(50 * time.Millisecond)
+	_, err = canceller.Cancel(ctx.JobID, "@alice")
+	Ok(t, err)
+	ErrContains(t, "signal: killed", waitForExit(t, cmd, 5*time.Second))
+}
+
+func TestJobCanceller_StartUntrackedJob(t *testing.T) {
+	canceller := jobs.NewJobCanceller(nil, time.Minute)
+	cmd := exec.Command("sh", "-c", "echo hi")
+	var out strings.Builder
+	cmd.Stdout = &out
+	done, err := canceller.Start("unknown", cmd)
+	Ok(t, err)
+	Ok(t, cmd.Wait())
+	done()
+	Equals(t, "hi\n", out.String())
+}
+
+func TestJobCanceller_Context(t *testing.T) {
+	ctx := createTestProjectCmdContext(t)
+	canceller := jobs.NewJobCanceller(nil, time.Minute)
+	end := canceller.Begin(ctx, command.Plan)
+	defer end()
+
+	jobCtx, cancel := canceller.Context(ctx.JobID)
+	defer cancel()
+	Ok(t, jobCtx.Err())
+	_, err := canceller.Cancel(ctx.JobID, "@alice")
+	Ok(t, err)
+	select {
+	case <-jobCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context wasn't cancelled with the job")
+	}
+
+	// Contexts of jobs that were already cancelled start cancelled.
+	cancelledCtx, cancel := canceller.Context(ctx.JobID)
+	defer cancel()
+	select {
+	case <-cancelledCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context of cancelled job wasn't cancelled")
+	}
+
+	untrackedCtx, cancel := canceller.Context("unknown")
+	Ok(t, untrackedCtx.Err())
+	cancel()
+	Assert(t, untrackedCtx.Err() != nil, "expected context to be cancelled by cancel")
+}
+
+func TestJobCanceller_Supersede(t *testing.T) {
+	canceller := jobs.NewJobCanceller(nil, time.Minute)
+	oldPlan := createTestProjectCmdContext(t)
+	oldPlan.Pull.HeadCommit = "old"
+	endOldPlan := canceller.Begin(oldPlan, command.Plan)
+	oldApply := createTestProjectCmdContext(t)
+	oldApply.Pull.HeadCommit = "old"
+	oldApply.JobID = "apply"
+	endOldApply := canceller.Begin(oldApply, command.Apply)
+	defer endOldApply()
+	otherPull := createTestProjectCmdContext(t)
+	otherPull.Pull.Num = 2
+	otherPull.JobID = "other"
+	endOtherPull := canceller.Begin(otherPull, command.Plan)
+	defer endOtherPull()
+
+	// Only the plans of the pull request's other commits are cancelled.
+	cancelled := canceller.Supersede("", 1, "new", "the push of new")
+	Equals(t, 1, len(cancelled))
+	Equals(t, "1234", cancelled[0].ID)
+	Equals(t, "the push of new", cancelled[0].CancelledBy)
+	for _, id := range []string{"apply", "other"} {
+		job, ok := canceller.Get(id)
+		Assert(t, ok, "exp %s to be running", id)
+		Equals(t, "", job.CancelledBy)
+	}
+
+	// Wait returns once the cancelled jobs end.
+	Assert(t, !canceller.Wait([]string{"1234"}, 10*time.Millisecond), "exp wait to time out")
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		endOldPlan()
+	}()
+	Assert(t, canceller.Wait([]string{"1234", "unknown"}, 5*time.Second), "exp wait to return once the job ends")
+
+	// Plans of the old commit that begin afterwards begin cancelled.
+	lateOldPlan := createTestProjectCmdContext(t)
+	lateOldPlan.Pull.HeadCommit = "old"
+	endLateOldPlan := canceller.Begin(lateOldPlan, command.Plan)
+	Equals(t, "the push of new", endLateOldPlan())
+	newPlan := createTestProjectCmdContext(t)
+	newPlan.Pull.HeadCommit = "new"
+	endNewPlan := canceller.Begin(newPlan, command.Plan)
+	Equals(t, "", endNewPlan())
+}
+
+func TestRunningJob_Matches(t *testing.T) {
+	job := jobs.RunningJob{ProjectName: "app", RepoRelDir: "apps/app", Workspace: "staging"}
+	Assert(t, job.Matches("", "", ""), "exp empty selector to match")
+	Assert(t, job.Matches("app", "", ""), "exp project to match")
+	Assert(t, job.Matches("", "apps/app", "staging"), "exp dir and workspace to match")
+	Assert(t, !job.Matches("other", "", ""), "exp other project not to match")
+	Assert(t, !job.Matches("", "apps/app", "default"), "exp other workspace not to match")
+}
@@ -0,0 +1,63 @@
+package jobs
+
+import "sync"
+
+// jobEventsBufferSize is how many events a subscriber can fall behind by
+// before it's unsubscribed.
+const jobEventsBufferSize = 1000
+
+// JobEvent is a line of output of a job or the job's completion.
+type JobEvent struct {
+	JobID string
+	JobInfo
+	// Line is the line of output. Empty if Complete is true.
+	Line string
+	// Complete is true if the job completed. It's the job's last event.
+	Complete bool
+}
+
+// JobEvents sends the events of all jobs to its subscribers, ex. to stream
+// them to integrations over the gRPC API.
+type JobEvents struct {
+	mu          sync.Mutex
+	subscribers map[chan JobEvent]bool
+}
+
+// NewJobEvents returns JobEvents without subscribers.
+func NewJobEvents() *JobEvents {
+	return &JobEvents{subscribers: make(map[chan JobEvent]bool)}
+}
+
+// Subscribe returns a channel that receives the events of all jobs from now
+// on. The channel is closed by Unsubscribe or if the subscriber falls too
+// far behind, like the receivers of a job's output.
+func (j *JobEvents) Subscribe() chan JobEvent {
+	ch := make(chan JobEvent, jobEventsBufferSize)
+	j.mu.Lock()
+	j.subscribers[ch] = true
+	j.mu.Unlock()
+	return ch
+}
+
+// Unsubscribe stops sending events to ch and closes it.
+func (j *JobEvents) Unsubscribe(ch chan JobEvent) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.subscribers[ch] {
+		delete(j.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (j *JobEvents) publish(event JobEvent) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for ch := range j.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(j.subscribers, ch)
+			close(ch)
+		}
+	}
+}
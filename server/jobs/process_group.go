@@ -0,0 +1,28 @@
+//go:build !windows
+// +build !windows
+
+package jobs
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes cmd start in its own process group so that the
+// processes it starts, ex. terraform under sh -c, are signalled with it.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// signalProcessGroup interrupts the process group of cmd, or kills it if
+// kill is true.
+func signalProcessGroup(cmd *exec.Cmd, kill bool) error {
+	sig := syscall.SIGINT
+	if kill {
+		sig = syscall.SIGKILL
+	}
+	return syscall.Kill(-cmd.Process.Pid, sig)
+}
@@ -0,0 +1,18 @@
+package jobs
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on Windows where only cmd's own process is
+// signalled.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalProcessGroup interrupts cmd, or kills it if kill is true.
+func signalProcessGroup(cmd *exec.Cmd, kill bool) error {
+	if kill {
+		return cmd.Process.Kill()
+	}
+	return cmd.Process.Signal(os.Interrupt)
+}
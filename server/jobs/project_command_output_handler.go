@@ -1,9 +1,13 @@
 package jobs
 
 import (
+	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
 )
 
@@ -22,6 +26,9 @@ type PullInfo struct {
 type JobInfo struct {
 	PullInfo
 	HeadCommit string
+	// RepoFullName is the owner and name of the repo, unlike PullInfo.Repo
+	// which is only its name.
+	RepoFullName string
 }
 
 type ProjectCmdOutputLine struct {
@@ -31,6 +38,19 @@ type ProjectCmdOutputLine struct {
 	OperationComplete bool
 }
 
+// JobStore persists the output of completed jobs so that it can be viewed
+// after Atlantis restarts or the pull request is closed.
+type JobStore interface {
+	// SaveJob creates or replaces the job with job's ID.
+	SaveJob(job models.Job) error
+	// GetJob returns the job with ID jobID. If there is no such job, it
+	// returns nil.
+	GetJob(jobID string) (*models.Job, error)
+	// DeleteJobsCompletedBefore deletes the jobs that completed before t and
+	// returns how many were deleted.
+	DeleteJobsCompletedBefore(t time.Time) (int, error)
+}
+
 // AsyncProjectCommandOutputHandler is a handler to transport terraform client
 // outputs to the front end.
 type AsyncProjectCommandOutputHandler struct {
@@ -46,6 +66,17 @@ type AsyncProjectCommandOutputHandler struct {
 
 	// Tracks all the jobs for a pull request which is used for clean up after a pull request is closed.
 	pullToJobMapping sync.Map
+
+	// jobStore persists the output of completed jobs. If nil, jobs are only
+	// kept in memory until their pull request is closed.
+	jobStore JobStore
+	// maxLogBytes is the maximum size of the output of persisted jobs. Lines
+	// are dropped from the start of larger outputs. If 0, the size isn't
+	// capped.
+	maxLogBytes int
+	// jobEvents receives the output and completion of every job. If nil,
+	// they aren't published.
+	jobEvents *JobEvents
 }
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_project_command_output_handler.go ProjectCommandOutputHandler
@@ -63,6 +94,10 @@ type ProjectCommandOutputHandler interface {
 
 	IsKeyExists(key string) bool
 
+	// LastLine returns the last line of the output of the job with jobID, or
+	// an empty string if it doesn't have any output.
+	LastLine(jobID string) string
+
 	// Listens for msg from channel
 	Handle()
 
@@ -70,9 +105,16 @@ type ProjectCommandOutputHandler interface {
 	CleanUp(pullInfo PullInfo)
 }
 
+// NewAsyncProjectCommandOutputHandler returns a handler that keeps the output
+// of jobs in memory and, if jobStore isn't nil, persists the output of each
+// job when it completes, capped at maxLogBytes. If jobEvents isn't nil, the
+// output and completion of every job are published to it.
 func NewAsyncProjectCommandOutputHandler(
 	projectCmdOutput chan *ProjectCmdOutputLine,
 	logger logging.SimpleLogging,
+	jobStore JobStore,
+	maxLogBytes int,
+	jobEvents *JobEvents,
 ) ProjectCommandOutputHandler {
 	return &AsyncProjectCommandOutputHandler{
 		projectCmdOutput:     projectCmdOutput,
@@ -80,21 +122,33 @@ func NewAsyncProjectCommandOutputHandler(
 		receiverBuffers:      map[string]map[chan string]bool{},
 		projectOutputBuffers: map[string]OutputBuffer{},
 		pullToJobMapping:     sync.Map{},
+		jobStore:             jobStore,
+		maxLogBytes:          maxLogBytes,
+		jobEvents:            jobEvents,
 	}
 }
 
 func (p *AsyncProjectCommandOutputHandler) IsKeyExists(key string) bool {
 	p.projectOutputBuffersLock.RLock()
-	defer p.projectOutputBuffersLock.RUnlock()
 	_, ok := p.projectOutputBuffers[key]
-	return ok
+	p.projectOutputBuffersLock.RUnlock()
+	if ok || p.jobStore == nil {
+		return ok
+	}
+	job, err := p.jobStore.GetJob(key)
+	if err != nil {
+		p.logger.Warn("unable to get persisted job %s: %s", key, err)
+		return false
+	}
+	return job != nil
 }
 
 func (p *AsyncProjectCommandOutputHandler) Send(ctx command.ProjectContext, msg string, operationComplete bool) {
 	p.projectCmdOutput <- &ProjectCmdOutputLine{
 		JobID: ctx.JobID,
 		JobInfo: JobInfo{
-			HeadCommit: ctx.Pull.HeadCommit,
+			HeadCommit:   ctx.Pull.HeadCommit,
+			RepoFullName: ctx.BaseRepo.FullName,
 			PullInfo: PullInfo{
 				PullNum:     ctx.Pull.Num,
 				Repo:        ctx.BaseRepo.Name,
@@ -113,8 +167,12 @@ func (p *AsyncProjectCommandOutputHandler) Register(jobID string, receiver chan
 
 func (p *AsyncProjectCommandOutputHandler) Handle() {
 	for msg := range p.projectCmdOutput {
+		if p.jobEvents != nil {
+			p.jobEvents.publish(JobEvent{JobID: msg.JobID, JobInfo: msg.JobInfo, Line: msg.Line, Complete: msg.OperationComplete})
+		}
 		if msg.OperationComplete {
 			p.completeJob(msg.JobID)
+			p.persistJob(msg.JobID, msg.JobInfo)
 			continue
 		}
 
@@ -154,11 +212,73 @@ func (p *AsyncProjectCommandOutputHandler) completeJob(jobID string) {
 
 }
 
+// persistJob saves the output of the completed job with ID jobID to the job
+// store. Failing to save it doesn't fail the job.
+func (p *AsyncProjectCommandOutputHandler) persistJob(jobID string, info JobInfo) {
+	if p.jobStore == nil {
+		return
+	}
+	p.projectOutputBuffersLock.RLock()
+	outputBuffer, ok := p.projectOutputBuffers[jobID]
+	p.projectOutputBuffersLock.RUnlock()
+	if !ok {
+		return
+	}
+
+	lines, truncated := capLines(outputBuffer.Buffer, p.maxLogBytes)
+	err := p.jobStore.SaveJob(models.Job{
+		ID:             jobID,
+		Repo:           info.Repo,
+		PullNum:        info.PullNum,
+		ProjectName:    info.ProjectName,
+		Workspace:      info.Workspace,
+		HeadCommit:     info.HeadCommit,
+		Lines:          lines,
+		TruncatedLines: truncated,
+		CompletedAt:    time.Now(),
+	})
+	if err != nil {
+		p.logger.Warn("unable to persist job %s: %s", jobID, err)
+	}
+}
+
+// capLines returns the last lines whose total size is at most maxBytes and
+// how many lines were dropped. If maxBytes is 0, all lines are returned.
+func capLines(lines []string, maxBytes int) ([]string, int) {
+	if maxBytes <= 0 {
+		return lines, 0
+	}
+	size := 0
+	start := len(lines)
+	for start > 0 && size+len(lines[start-1]) <= maxBytes {
+		size += len(lines[start-1])
+		start--
+	}
+	return lines[start:], start
+}
+
 func (p *AsyncProjectCommandOutputHandler) addChan(ch chan string, jobID string) {
 	p.projectOutputBuffersLock.RLock()
-	outputBuffer := p.projectOutputBuffers[jobID]
+	outputBuffer, ok := p.projectOutputBuffers[jobID]
 	p.projectOutputBuffersLock.RUnlock()
 
+	// Jobs that aren't in memory anymore, ex. because Atlantis restarted, are
+	// streamed from the job store.
+	if !ok && p.jobStore != nil {
+		if job, err := p.jobStore.GetJob(jobID); err != nil {
+			p.logger.Warn("unable to get persisted job %s: %s", jobID, err)
+		} else if job != nil {
+			if job.TruncatedLines > 0 {
+				ch <- fmt.Sprintf("[%d earlier lines were truncated]", job.TruncatedLines)
+			}
+			for _, line := range job.Lines {
+				ch <- line
+			}
+			close(ch)
+			return
+		}
+	}
+
 	for _, line := range outputBuffer.Buffer {
 		ch <- line
 	}
@@ -213,6 +333,18 @@ func (p *AsyncProjectCommandOutputHandler) Deregister(jobID string, ch chan stri
 	p.receiverBuffersLock.Unlock()
 }
 
+func (p *AsyncProjectCommandOutputHandler) LastLine(jobID string) string {
+	p.projectOutputBuffersLock.RLock()
+	defer p.projectOutputBuffersLock.RUnlock()
+	buffer := p.projectOutputBuffers[jobID].Buffer
+	for i := len(buffer) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(buffer[i]); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
 func (p *AsyncProjectCommandOutputHandler) GetReceiverBufferForPull(jobID string) map[chan string]bool {
 	return p.receiverBuffers[jobID]
 }
@@ -264,3 +396,7 @@ func (p *NoopProjectOutputHandler) CleanUp(pullInfo PullInfo) {
 func (p *NoopProjectOutputHandler) IsKeyExists(key string) bool {
 	return false
 }
+
+func (p *NoopProjectOutputHandler) LastLine(jobID string) string {
+	return ""
+}
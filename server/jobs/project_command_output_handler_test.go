@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/runatlantis/atlantis/server/core/db"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/jobs"
@@ -48,6 +49,9 @@ func createProjectCommandOutputHandler(t *testing.T) jobs.ProjectCommandOutputHa
 	prjCmdOutputHandler := jobs.NewAsyncProjectCommandOutputHandler(
 		prjCmdOutputChan,
 		logger,
+		nil,
+		0,
+		nil,
 	)
 
 	go func() {
@@ -249,3 +253,92 @@ func TestProjectCommandOutputHandler(t *testing.T) {
 		assert.True(t, <-opComplete)
 	})
 }
+
+func TestProjectCommandOutputHandler_LastLine(t *testing.T) {
+	ctx := createTestProjectCmdContext(t)
+	projectOutputHandler := createProjectCommandOutputHandler(t)
+	Equals(t, "", projectOutputHandler.LastLine(ctx.JobID))
+
+	projectOutputHandler.Send(ctx, "aws_instance.web: Creating...", false)
+	projectOutputHandler.Send(ctx, "aws_instance.web: Still creating... [10s elapsed]", false)
+	projectOutputHandler.Send(ctx, "  ", false)
+
+	// Wait for the handler to buffer the lines.
+	time.Sleep(10 * time.Millisecond)
+	Equals(t, "aws_instance.web: Still creating... [10s elapsed]", projectOutputHandler.LastLine(ctx.JobID))
+}
+
+func TestProjectCommandOutputHandler_JobStore(t *testing.T) {
+	ctx := createTestProjectCmdContext(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltDB, err := db.New(tmp)
+	Ok(t, err)
+
+	prjCmdOutputChan := make(chan *jobs.ProjectCmdOutputLine)
+	projectOutputHandler := jobs.NewAsyncProjectCommandOutputHandler(prjCmdOutputChan, logging.NewNoopLogger(t), boltDB, 5, nil)
+	go projectOutputHandler.Handle()
+
+	projectOutputHandler.Send(ctx, "a", false)
+	projectOutputHandler.Send(ctx, "bb", false)
+	projectOutputHandler.Send(ctx, "ccc", false)
+	projectOutputHandler.Send(ctx, "", true)
+
+	// Wait for the handler to persist the job.
+	time.Sleep(10 * time.Millisecond)
+
+	job, err := boltDB.GetJob(ctx.JobID)
+	Ok(t, err)
+	Assert(t, job != nil, "exp job to be persisted")
+	Equals(t, []string{"bb", "ccc"}, job.Lines)
+	Equals(t, 1, job.TruncatedLines)
+	Equals(t, "test-project", job.ProjectName)
+	Equals(t, "234r232432", job.HeadCommit)
+
+	// Once the pull request is closed the job is streamed from the store.
+	projectOutputHandler.CleanUp(jobs.PullInfo{
+		PullNum:     ctx.Pull.Num,
+		Repo:        ctx.BaseRepo.Name,
+		ProjectName: ctx.ProjectName,
+		Workspace:   ctx.Workspace,
+	})
+	Assert(t, projectOutputHandler.IsKeyExists(ctx.JobID), "exp persisted job to exist")
+	Assert(t, !projectOutputHandler.IsKeyExists("other"), "exp unknown job not to exist")
+
+	ch := make(chan string, 10)
+	projectOutputHandler.Register(ctx.JobID, ch)
+	var received []string
+	for line := range ch {
+		received = append(received, line)
+	}
+	Equals(t, []string{"[1 earlier lines were truncated]", "bb", "ccc"}, received)
+}
+
+func TestProjectCommandOutputHandler_JobEvents(t *testing.T) {
+	ctx := createTestProjectCmdContext(t)
+	ctx.BaseRepo.FullName = "test-org/test-repo"
+	jobEvents := jobs.NewJobEvents()
+	projectOutputHandler := jobs.NewAsyncProjectCommandOutputHandler(make(chan *jobs.ProjectCmdOutputLine), logging.NewNoopLogger(t), nil, 0, jobEvents)
+	go projectOutputHandler.Handle()
+
+	events := jobEvents.Subscribe()
+	projectOutputHandler.Send(ctx, "line", false)
+	projectOutputHandler.Send(ctx, "", true)
+
+	info := jobs.JobInfo{
+		PullInfo: jobs.PullInfo{
+			PullNum:     1,
+			Repo:        "test-repo",
+			ProjectName: "test-project",
+			Workspace:   "myworkspace",
+		},
+		HeadCommit:   "234r232432",
+		RepoFullName: "test-org/test-repo",
+	}
+	Equals(t, jobs.JobEvent{JobID: "1234", JobInfo: info, Line: "line"}, <-events)
+	Equals(t, jobs.JobEvent{JobID: "1234", JobInfo: info, Complete: true}, <-events)
+
+	jobEvents.Unsubscribe(events)
+	_, ok := <-events
+	Assert(t, !ok, "expected events to be closed")
+}
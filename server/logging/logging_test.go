@@ -32,3 +32,20 @@ func TestStructuredLoggerSavesHistory(t *testing.T) {
 
 	assert.Equal(t, expectedStr, historyLogger.GetHistory())
 }
+
+func TestStructuredLoggerKeepsRecentErrors(t *testing.T) {
+	recent := logging.NewRecentErrors(2)
+	logger, err := logging.NewStructuredLoggerWithRecentErrors(logging.Info, recent)
+	assert.NoError(t, err)
+
+	logger.Info("not kept")
+	logger.Warn("first %d", 1)
+	logger.Err("second")
+	logger.Err("third")
+
+	var messages []string
+	for _, e := range recent.Entries() {
+		messages = append(messages, e.Level+" "+e.Message)
+	}
+	assert.Equal(t, []string{"ERROR second", "ERROR third"}, messages)
+}
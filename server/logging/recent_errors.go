@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RecentErrors keeps the last warnings and errors logged so they can be
+// included in support bundles.
+type RecentErrors struct {
+	mu      sync.Mutex
+	size    int
+	entries []RecentError
+	// next is the index in entries to overwrite once it's full.
+	next int
+}
+
+// RecentError is a warning or error that was logged.
+type RecentError struct {
+	Time    time.Time
+	Level   string
+	Caller  string
+	Message string
+}
+
+// NewRecentErrors returns a RecentErrors that keeps the last size entries.
+func NewRecentErrors(size int) *RecentErrors {
+	return &RecentErrors{size: size}
+}
+
+// Entries returns the entries kept, oldest first.
+func (r *RecentErrors) Entries() []RecentError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]RecentError, 0, len(r.entries))
+	entries = append(entries, r.entries[r.next:]...)
+	return append(entries, r.entries[:r.next]...)
+}
+
+// hook is a zap hook that keeps entries at warn level or above.
+func (r *RecentErrors) hook(e zapcore.Entry) error {
+	if e.Level < zapcore.WarnLevel || r.size == 0 {
+		return nil
+	}
+	entry := RecentError{
+		Time:    e.Time,
+		Level:   e.Level.CapitalString(),
+		Caller:  e.Caller.TrimmedPath(),
+		Message: e.Message,
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) < r.size {
+		r.entries = append(r.entries, entry)
+		return nil
+	}
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % r.size
+	return nil
+}
@@ -81,14 +81,24 @@ func NewStructuredLoggerFromLevel(lvl LogLevel) (SimpleLogging, error) {
 	return newStructuredLogger(cfg)
 }
 
+// NewStructuredLoggerWithRecentErrors is like NewStructuredLoggerFromLevel but
+// also keeps the warnings and errors logged in recent.
+func NewStructuredLoggerWithRecentErrors(lvl LogLevel, recent *RecentErrors) (SimpleLogging, error) {
+	cfg := zap.NewProductionConfig()
+
+	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	cfg.Level = zap.NewAtomicLevelAt(lvl.zLevel)
+	return newStructuredLogger(cfg, zap.Hooks(recent.hook))
+}
+
 func NewStructuredLogger() (SimpleLogging, error) {
 	cfg := zap.NewProductionConfig()
 	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	return newStructuredLogger(cfg)
 }
 
-func newStructuredLogger(cfg zap.Config) (*StructuredLogger, error) {
-	baseLogger, err := cfg.Build()
+func newStructuredLogger(cfg zap.Config, opts ...zap.Option) (*StructuredLogger, error) {
+	baseLogger, err := cfg.Build(opts...)
 
 	baseLogger = baseLogger.
 		// ensures that the caller doesn't just say logging/simple_logger each time
@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/uber-go/tally"
+)
+
+// newCloudWatchReporter returns a tally reporter that writes metrics in
+// CloudWatch's embedded metric format to the CloudWatch agent at
+// cfg.Endpoint, or to stdout if it's empty. Tags are sent as dimensions.
+// See https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html
+func newCloudWatchReporter(cfg *valid.CloudWatch, logger logging.SimpleLogging) tally.StatsReporter {
+	r := &cloudWatchReporter{
+		namespace: cfg.Namespace,
+		log:       logger,
+		now:       time.Now,
+	}
+	if cfg.Endpoint == "" {
+		r.out = os.Stdout
+	} else {
+		// The endpoint was validated to be tcp:// or udp://.
+		parts := strings.SplitN(cfg.Endpoint, "://", 2)
+		r.network, r.addr = parts[0], parts[1]
+	}
+	return r
+}
+
+type cloudWatchReporter struct {
+	namespace string
+	// network and addr are where the CloudWatch agent listens. If empty,
+	// metrics are written to out.
+	network string
+	addr    string
+	out     io.Writer
+	log     logging.SimpleLogging
+	now     func() time.Time
+
+	mu sync.Mutex
+	// pending are the metrics reported since the last flush.
+	pending [][]byte
+	conn    net.Conn
+}
+
+// Capabilities interface.
+
+func (r *cloudWatchReporter) Reporting() bool {
+	return true
+}
+
+func (r *cloudWatchReporter) Tagging() bool {
+	return true
+}
+
+func (r *cloudWatchReporter) Capabilities() tally.Capabilities {
+	return r
+}
+
+// Reporter interface.
+
+// Flush writes the metrics reported since the last flush, one document per
+// line. If the agent can't be reached they're dropped and it's reconnected
+// to on the next flush.
+func (r *cloudWatchReporter) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending) == 0 {
+		return
+	}
+	pending := r.pending
+	r.pending = nil
+
+	out := r.out
+	if out == nil {
+		if r.conn == nil {
+			conn, err := net.DialTimeout(r.network, r.addr, 5*time.Second)
+			if err != nil {
+				r.log.Warn("connecting to CloudWatch agent at %s: %s", r.addr, err)
+				return
+			}
+			r.conn = conn
+		}
+		out = r.conn
+	}
+	for _, doc := range pending {
+		if _, err := out.Write(append(doc, '\n')); err != nil {
+			r.log.Warn("writing CloudWatch metrics: %s", err)
+			if r.conn != nil {
+				r.conn.Close() // nolint: errcheck
+				r.conn = nil
+			}
+			return
+		}
+	}
+}
+
+func (r *cloudWatchReporter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		return nil
+	}
+	return r.conn.Close()
+}
+
+func (r *cloudWatchReporter) ReportCounter(name string, tags map[string]string, value int64) {
+	r.add(name, tags, value, "Count")
+}
+
+func (r *cloudWatchReporter) ReportGauge(name string, tags map[string]string, value float64) {
+	r.add(name, tags, value, "None")
+}
+
+func (r *cloudWatchReporter) ReportTimer(name string, tags map[string]string, interval time.Duration) {
+	r.add(name, tags, float64(interval)/float64(time.Millisecond), "Milliseconds")
+}
+
+func (r *cloudWatchReporter) ReportHistogramValueSamples(
+	name string,
+	tags map[string]string,
+	buckets tally.Buckets,
+	bucketLowerBound,
+	bucketUpperBound float64,
+	samples int64,
+) {
+	bucket := fmt.Sprintf("%s-%s", valueBucketString(bucketLowerBound), valueBucketString(bucketUpperBound))
+	r.add(name, withTag(tags, "bucket", bucket), samples, "Count")
+}
+
+func (r *cloudWatchReporter) ReportHistogramDurationSamples(
+	name string,
+	tags map[string]string,
+	buckets tally.Buckets,
+	bucketLowerBound,
+	bucketUpperBound time.Duration,
+	samples int64,
+) {
+	bucket := fmt.Sprintf("%s-%s", durationBucketString(bucketLowerBound), durationBucketString(bucketUpperBound))
+	r.add(name, withTag(tags, "bucket", bucket), samples, "Count")
+}
+
+type emfMetric struct {
+	Name string
+	Unit string
+}
+
+type emfDirective struct {
+	Namespace  string
+	Dimensions [][]string
+	Metrics    []emfMetric
+}
+
+type emfMetadata struct {
+	Timestamp         int64
+	CloudWatchMetrics []emfDirective
+}
+
+// add queues a document with the metric's value, its tags as dimensions and
+// the metadata that tells CloudWatch to extract the metric from it.
+func (r *cloudWatchReporter) add(name string, tags map[string]string, value interface{}, unit string) {
+	dimensions := make([]string, 0, len(tags))
+	doc := make(map[string]interface{}, len(tags)+2)
+	for k, v := range tags {
+		dimensions = append(dimensions, k)
+		doc[k] = v
+	}
+	sort.Strings(dimensions)
+	doc[name] = value
+	doc["_aws"] = emfMetadata{
+		Timestamp: r.now().UnixNano() / int64(time.Millisecond),
+		CloudWatchMetrics: []emfDirective{{
+			Namespace:  r.namespace,
+			Dimensions: [][]string{dimensions},
+			Metrics:    []emfMetric{{Name: name, Unit: unit}},
+		}},
+	}
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		r.log.Warn("encoding CloudWatch metric %s: %s", name, err)
+		return
+	}
+	r.mu.Lock()
+	r.pending = append(r.pending, encoded)
+	r.mu.Unlock()
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCloudWatchReporter(t *testing.T) {
+	reporter := newCloudWatchReporter(&valid.CloudWatch{Namespace: "Atlantis"}, logging.NewNoopLogger(t)).(*cloudWatchReporter)
+	var out bytes.Buffer
+	reporter.out = &out
+	reporter.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	reporter.ReportCounter("atlantis.api.plan.execution_success", nil, 1)
+	reporter.ReportTimer("atlantis.api.plan.execution_time", map[string]string{"repo": "owner/repo"}, 1500*time.Millisecond)
+	Equals(t, "", out.String())
+
+	reporter.Flush()
+	Equals(t, `{"_aws":{"Timestamp":1700000000000,"CloudWatchMetrics":[{"Namespace":"Atlantis","Dimensions":[[]],"Metrics":[{"Name":"atlantis.api.plan.execution_success","Unit":"Count"}]}]},"atlantis.api.plan.execution_success":1}
+{"_aws":{"Timestamp":1700000000000,"CloudWatchMetrics":[{"Namespace":"Atlantis","Dimensions":[["repo"]],"Metrics":[{"Name":"atlantis.api.plan.execution_time","Unit":"Milliseconds"}]}]},"atlantis.api.plan.execution_time":1500,"repo":"owner/repo"}
+`, out.String())
+
+	// Metrics are only written once.
+	out.Reset()
+	reporter.Flush()
+	Equals(t, "", out.String())
+}
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+// newDogStatsdReporter returns a tally reporter that sends metrics to the
+// statsd server at addr with DogStatsD tags, ex. to the Datadog agent. tags
+// are added to every metric.
+func newDogStatsdReporter(addr string, tags map[string]string) (tally.StatsReporter, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &dogStatsdReporter{conn: conn, tags: tags}, nil
+}
+
+type dogStatsdReporter struct {
+	conn io.WriteCloser
+	tags map[string]string
+}
+
+// Capabilities interface.
+
+func (r *dogStatsdReporter) Reporting() bool {
+	return true
+}
+
+func (r *dogStatsdReporter) Tagging() bool {
+	return true
+}
+
+func (r *dogStatsdReporter) Capabilities() tally.Capabilities {
+	return r
+}
+
+// Reporter interface.
+
+func (r *dogStatsdReporter) Flush() {
+	// Metrics are sent as they're reported.
+}
+
+func (r *dogStatsdReporter) Close() error {
+	return r.conn.Close()
+}
+
+func (r *dogStatsdReporter) ReportCounter(name string, tags map[string]string, value int64) {
+	r.send(name, strconv.FormatInt(value, 10), "c", tags)
+}
+
+func (r *dogStatsdReporter) ReportGauge(name string, tags map[string]string, value float64) {
+	r.send(name, strconv.FormatFloat(value, 'f', -1, 64), "g", tags)
+}
+
+func (r *dogStatsdReporter) ReportTimer(name string, tags map[string]string, interval time.Duration) {
+	r.send(name, strconv.FormatFloat(float64(interval)/float64(time.Millisecond), 'f', -1, 64), "ms", tags)
+}
+
+// Histogram samples are counted with the bucket as a tag instead of in the
+// name like tally's statsd reporter does, so they can be graphed together.
+
+func (r *dogStatsdReporter) ReportHistogramValueSamples(
+	name string,
+	tags map[string]string,
+	buckets tally.Buckets,
+	bucketLowerBound,
+	bucketUpperBound float64,
+	samples int64,
+) {
+	bucket := fmt.Sprintf("%s-%s", valueBucketString(bucketLowerBound), valueBucketString(bucketUpperBound))
+	r.send(name, strconv.FormatInt(samples, 10), "c", withTag(tags, "bucket", bucket))
+}
+
+func (r *dogStatsdReporter) ReportHistogramDurationSamples(
+	name string,
+	tags map[string]string,
+	buckets tally.Buckets,
+	bucketLowerBound,
+	bucketUpperBound time.Duration,
+	samples int64,
+) {
+	bucket := fmt.Sprintf("%s-%s", durationBucketString(bucketLowerBound), durationBucketString(bucketUpperBound))
+	r.send(name, strconv.FormatInt(samples, 10), "c", withTag(tags, "bucket", bucket))
+}
+
+// send writes the metric in the DogStatsD format,
+// name:value|type|#tag:value,... with the tags sorted. Errors are ignored
+// like they are by statsd clients since metrics are sent over UDP.
+func (r *dogStatsdReporter) send(name string, value string, metricType string, tags map[string]string) {
+	line := name + ":" + value + "|" + metricType
+	all := make(map[string]string, len(r.tags)+len(tags))
+	for k, v := range r.tags {
+		all[k] = v
+	}
+	for k, v := range tags {
+		all[k] = v
+	}
+	if len(all) > 0 {
+		pairs := make([]string, 0, len(all))
+		for k, v := range all {
+			pairs = append(pairs, k+":"+v)
+		}
+		sort.Strings(pairs)
+		line += "|#" + strings.Join(pairs, ",")
+	}
+	r.conn.Write([]byte(line)) // nolint: errcheck
+}
+
+// withTag returns a copy of tags with key set to value.
+func withTag(tags map[string]string, key string, value string) map[string]string {
+	copied := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		copied[k] = v
+	}
+	copied[key] = value
+	return copied
+}
+
+func valueBucketString(bound float64) string {
+	if bound == math.MaxFloat64 {
+		return "infinity"
+	}
+	if bound == -math.MaxFloat64 {
+		return "-infinity"
+	}
+	return strconv.FormatFloat(bound, 'f', 6, 64)
+}
+
+func durationBucketString(bound time.Duration) string {
+	if bound == time.Duration(math.MaxInt64) {
+		return "infinity"
+	}
+	if bound == time.Duration(math.MinInt64) {
+		return "-infinity"
+	}
+	return bound.String()
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/runatlantis/atlantis/testing"
+	"github.com/uber-go/tally"
+)
+
+func TestDogStatsdReporter(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	Ok(t, err)
+	defer conn.Close() // nolint: errcheck
+
+	reporter, err := newDogStatsdReporter(conn.LocalAddr().String(), map[string]string{"env": "prod"})
+	Ok(t, err)
+	defer reporter.(*dogStatsdReporter).Close() // nolint: errcheck
+
+	reporter.ReportCounter("atlantis.api.plan.execution_success", nil, 1)
+	reporter.ReportGauge("atlantis.runtime.goroutines", map[string]string{"host": "a"}, 12.5)
+	reporter.ReportTimer("atlantis.api.plan.execution_time", nil, 1500*time.Microsecond)
+	reporter.ReportHistogramDurationSamples("atlantis.apply.duration", nil, tally.DurationBuckets{}, time.Second, 2*time.Second, 3)
+
+	var lines []string
+	buf := make([]byte, 1024)
+	for i := 0; i < 4; i++ {
+		Ok(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, _, err := conn.ReadFrom(buf)
+		Ok(t, err)
+		lines = append(lines, string(buf[:n]))
+	}
+	Equals(t, []string{
+		"atlantis.api.plan.execution_success:1|c|#env:prod",
+		"atlantis.runtime.goroutines:12.5|g|#env:prod,host:a",
+		"atlantis.api.plan.execution_time:1.5|ms|#env:prod",
+		"atlantis.apply.duration:3|c|#bucket:1s-2s,env:prod",
+	}, lines)
+}
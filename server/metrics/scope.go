@@ -53,6 +53,11 @@ func newReporter(cfg valid.Metrics, logger logging.SimpleLogging) (tally.BaseSta
 		return tallyprom.NewReporter(tallyprom.Options{}), nil
 	}
 
+	// return cloudwatch metrics if configured
+	if cfg.CloudWatch != nil {
+		return newCloudWatchReporter(cfg.CloudWatch, logger), nil
+	}
+
 	// return logging reporter and proceed
 	return newLoggingReporter(logger), nil
 
@@ -62,6 +67,11 @@ func newStatsReporter(cfg valid.Metrics, logger logging.SimpleLogging) (tally.St
 
 	statsdCfg := cfg.Statsd
 
+	if statsdCfg.TagFormat == valid.DogStatsdTagFormat {
+		reporter, err := newDogStatsdReporter(strings.Join([]string{statsdCfg.Host, statsdCfg.Port}, ":"), statsdCfg.Tags)
+		return reporter, errors.Wrap(err, "initializing dogstatsd client")
+	}
+
 	client, err := statsd.NewClientWithConfig(&statsd.ClientConfig{
 		Address: strings.Join([]string{statsdCfg.Host, statsdCfg.Port}, ":"),
 	})
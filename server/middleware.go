@@ -47,8 +47,10 @@ func (l *RequestLogger) ServeHTTP(rw http.ResponseWriter, r *http.Request, next
 	if !l.WebAuthentication ||
 		r.URL.Path == "/events" ||
 		r.URL.Path == "/healthz" ||
+		r.URL.Path == "/readyz" ||
 		r.URL.Path == "/status" ||
-		strings.HasPrefix(r.URL.Path, "/api/") {
+		strings.HasPrefix(r.URL.Path, "/api/") ||
+		strings.HasPrefix(r.URL.Path, "/.well-known/") {
 		allowed = true
 	} else {
 		user, pass, ok := r.BasicAuth()
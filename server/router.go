@@ -20,6 +20,9 @@ type Router struct {
 	LockViewRouteName string
 	// ProjectJobsViewRouteName is the named route for the projects active jobs
 	ProjectJobsViewRouteName string
+	// ProjectGraphRouteName is the named route for the dependency graphs of
+	// projects' plans.
+	ProjectGraphRouteName string
 	// LockViewRouteIDQueryParam is the query parameter needed to construct the
 	// lock view: underlying.Get(LockViewRouteName).URL(LockViewRouteIDQueryParam, "my id").
 	LockViewRouteIDQueryParam string
@@ -52,3 +55,16 @@ func (r *Router) GenerateProjectJobURL(ctx command.ProjectContext) (string, erro
 
 	return r.AtlantisURL.String() + jobURL.String(), nil
 }
+
+// GenerateProjectGraphURL returns a fully qualified URL to the dependency
+// graph of the project's plan that ran as ctx's job.
+func (r *Router) GenerateProjectGraphURL(ctx command.ProjectContext) (string, error) {
+	if ctx.JobID == "" {
+		return "", fmt.Errorf("no job id in ctx")
+	}
+	graphURL, err := r.Underlying.Get(r.ProjectGraphRouteName).URL("job-id", ctx.JobID)
+	if err != nil {
+		return "", errors.Wrapf(err, "creating graph url for %s", ctx.JobID)
+	}
+	return r.AtlantisURL.String() + graphURL.String(), nil
+}
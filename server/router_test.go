@@ -72,11 +72,13 @@ func setupJobsRouter(t *testing.T) *server.Router {
 
 	underlyingRouter := mux.NewRouter()
 	underlyingRouter.HandleFunc("/jobs/{job-id}", func(_ http.ResponseWriter, _ *http.Request) {}).Methods("GET").Name("project-jobs-detail")
+	underlyingRouter.HandleFunc("/jobs/{job-id}/graph.svg", func(_ http.ResponseWriter, _ *http.Request) {}).Methods("GET").Name("project-graph")
 
 	return &server.Router{
 		AtlantisURL:              atlantisURL,
 		Underlying:               underlyingRouter,
 		ProjectJobsViewRouteName: "project-jobs-detail",
+		ProjectGraphRouteName:    "project-graph",
 	}
 }
 
@@ -110,3 +112,14 @@ func TestGenerateProjectJobURL_ShouldReturnErrorWhenJobIDNotSpecified(t *testing
 	assert.EqualError(t, err, expectedErrString)
 	Equals(t, "", gotURL)
 }
+
+func TestGenerateProjectGraphURL(t *testing.T) {
+	router := setupJobsRouter(t)
+	jobID := uuid.New().String()
+	gotURL, err := router.GenerateProjectGraphURL(command.ProjectContext{JobID: jobID})
+	Ok(t, err)
+	Equals(t, fmt.Sprintf("http://localhost:4141/jobs/%s/graph.svg", jobID), gotURL)
+
+	_, err = router.GenerateProjectGraphURL(command.ProjectContext{})
+	ErrEquals(t, "no job id in ctx", err)
+}
@@ -0,0 +1,33 @@
+package scheduled
+
+import (
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// DependencyUpgrades opens a pull request in each of Repos that upgrades the
+// Terraform providers and modules to their latest versions. The pull
+// requests are autoplanned like any other so the plans of the upgrades can be
+// reviewed before they're merged.
+type DependencyUpgrades struct {
+	Upgrader events.DependencyUpgrader
+	Repos    []models.Repo
+	Log      logging.SimpleLogging
+}
+
+func (d *DependencyUpgrades) Run() {
+	for _, repo := range d.Repos {
+		upgrades, pull, err := d.Upgrader.Upgrade(d.Log, repo)
+		switch {
+		case err != nil:
+			d.Log.Err("upgrading the dependencies of %s: %s", repo.FullName, err)
+		case len(upgrades) == 0:
+			d.Log.Debug("dependencies of %s are up to date", repo.FullName)
+		case pull == nil:
+			d.Log.Debug("pull request with the %d dependency upgrades of %s is up to date", len(upgrades), repo.FullName)
+		default:
+			d.Log.Info("opened or updated %s with %d dependency upgrades of %s", pull.URL, len(upgrades), repo.FullName)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package scheduled
 
 import (
 	"context"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/uber-go/tally"
 	"os"
@@ -16,11 +17,41 @@ type ExecutorService struct {
 
 	// jobs
 	runtimeStatsPublisher JobDefinition
+	// jobRetention deletes expired persisted jobs. Nil if jobs aren't
+	// persisted.
+	jobRetention *JobDefinition
+	// repoConfigReload reloads the server-side repo config. Nil if it isn't
+	// reloaded.
+	repoConfigReload *JobDefinition
+	// gitlabWebhookSync adds webhooks to the projects in GitLab groups. Nil if
+	// no groups are configured.
+	gitlabWebhookSync *JobDefinition
+	// webhookProvisioning adds webhooks to the allowlisted repos. Nil if
+	// webhooks aren't provisioned.
+	webhookProvisioning *JobDefinition
+	// dependencyUpgrades opens pull requests that upgrade providers and
+	// modules. Nil if dependencies aren't upgraded.
+	dependencyUpgrades *JobDefinition
 }
 
+// NewExecutorService returns a service that runs the scheduled jobs. If
+// jobStore isn't nil, jobs in it that are older than jobRetention are deleted
+// every hour. If repoConfigReload isn't nil, it's run every
+// repoConfigReloadInterval to reload the server-side repo config. If
+// gitlabWebhookSync or webhookProvisioning isn't nil, it's run on start and
+// then every ten minutes. If dependencyUpgrades isn't nil, it's run every
+// dependencyUpgradeInterval.
 func NewExecutorService(
 	statsScope tally.Scope,
 	log logging.SimpleLogging,
+	jobStore jobs.JobStore,
+	jobRetention time.Duration,
+	repoConfigReload Job,
+	repoConfigReloadInterval time.Duration,
+	gitlabWebhookSync Job,
+	webhookProvisioning Job,
+	dependencyUpgrades Job,
+	dependencyUpgradeInterval time.Duration,
 ) *ExecutorService {
 
 	scheduledScope := statsScope.SubScope("scheduled")
@@ -31,15 +62,69 @@ func NewExecutorService(
 		Period: 10 * time.Second,
 	}
 
+	var jobRetentionJob *JobDefinition
+	if jobStore != nil {
+		jobRetentionJob = &JobDefinition{
+			Job: &JobRetention{
+				Store:     jobStore,
+				Retention: jobRetention,
+				Log:       log,
+			},
+			Period: time.Hour,
+		}
+	}
+
+	var repoConfigReloadJob *JobDefinition
+	if repoConfigReload != nil {
+		repoConfigReloadJob = &JobDefinition{
+			Job:    repoConfigReload,
+			Period: repoConfigReloadInterval,
+		}
+	}
+
+	var gitlabWebhookSyncJob *JobDefinition
+	if gitlabWebhookSync != nil {
+		gitlabWebhookSyncJob = &JobDefinition{
+			Job:        gitlabWebhookSync,
+			Period:     10 * time.Minute,
+			RunOnStart: true,
+		}
+	}
+
+	var webhookProvisioningJob *JobDefinition
+	if webhookProvisioning != nil {
+		webhookProvisioningJob = &JobDefinition{
+			Job:        webhookProvisioning,
+			Period:     10 * time.Minute,
+			RunOnStart: true,
+		}
+	}
+
+	var dependencyUpgradesJob *JobDefinition
+	if dependencyUpgrades != nil {
+		dependencyUpgradesJob = &JobDefinition{
+			Job:    dependencyUpgrades,
+			Period: dependencyUpgradeInterval,
+		}
+	}
+
 	return &ExecutorService{
 		log:                   log,
 		runtimeStatsPublisher: runtimeStatsPublisherJob,
+		jobRetention:          jobRetentionJob,
+		repoConfigReload:      repoConfigReloadJob,
+		gitlabWebhookSync:     gitlabWebhookSyncJob,
+		webhookProvisioning:   webhookProvisioningJob,
+		dependencyUpgrades:    dependencyUpgradesJob,
 	}
 }
 
 type JobDefinition struct {
 	Job    Job
 	Period time.Duration
+	// RunOnStart runs the job when the service starts instead of waiting
+	// for the first period to pass.
+	RunOnStart bool
 }
 
 func (s *ExecutorService) Run() {
@@ -50,6 +135,21 @@ func (s *ExecutorService) Run() {
 	var wg sync.WaitGroup
 
 	s.runScheduledJob(ctx, &wg, s.runtimeStatsPublisher)
+	if s.jobRetention != nil {
+		s.runScheduledJob(ctx, &wg, *s.jobRetention)
+	}
+	if s.repoConfigReload != nil {
+		s.runScheduledJob(ctx, &wg, *s.repoConfigReload)
+	}
+	if s.gitlabWebhookSync != nil {
+		s.runScheduledJob(ctx, &wg, *s.gitlabWebhookSync)
+	}
+	if s.webhookProvisioning != nil {
+		s.runScheduledJob(ctx, &wg, *s.webhookProvisioning)
+	}
+	if s.dependencyUpgrades != nil {
+		s.runScheduledJob(ctx, &wg, *s.dependencyUpgrades)
+	}
 
 	interrupt := make(chan os.Signal, 1)
 
@@ -82,6 +182,9 @@ func (s *ExecutorService) runScheduledJob(ctx context.Context, wg *sync.WaitGrou
 			}
 		}()
 
+		if jd.RunOnStart {
+			jd.Job.Run()
+		}
 		for {
 			select {
 			case <-ctx.Done():
@@ -0,0 +1,74 @@
+package scheduled
+
+import (
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// GitlabWebhookClient is the part of the GitLab client that
+// GitlabWebhookSync uses.
+type GitlabWebhookClient interface {
+	ListGroupProjects(group string) ([]string, error)
+	HasGroupHook(group string, hookURL string) (bool, error)
+	EnsureWebhook(project string, hook vcs.Webhook) (vcs.WebhookChange, error)
+}
+
+// GitlabWebhookSync adds a webhook to Atlantis to every project in Groups
+// that's allowlisted, or fixes it if it's misconfigured, so that new projects
+// don't need to be configured one by one. Groups that already have a group
+// webhook to Atlantis are skipped since their projects' events are already
+// sent to Atlantis.
+type GitlabWebhookSync struct {
+	Client GitlabWebhookClient
+	Groups []string
+	// HookURL is Atlantis's events endpoint.
+	HookURL string
+	// Token is the webhook secret.
+	Token string
+	// IsAllowlisted returns true if the project with the full name is in the
+	// repo allowlist.
+	IsAllowlisted func(project string) bool
+	Log           logging.SimpleLogging
+
+	// secretSynced are the projects whose webhook secret has been set since
+	// Atlantis started.
+	secretSynced map[string]bool
+}
+
+func (s *GitlabWebhookSync) Run() {
+	for _, group := range s.Groups {
+		hasGroupHook, err := s.Client.HasGroupHook(group, s.HookURL)
+		if err != nil {
+			s.Log.Err("checking webhooks of GitLab group %s: %s", group, err)
+			continue
+		}
+		if hasGroupHook {
+			s.Log.Debug("GitLab group %s has a webhook to Atlantis, not adding project webhooks", group)
+			continue
+		}
+
+		projects, err := s.Client.ListGroupProjects(group)
+		if err != nil {
+			s.Log.Err("listing projects of GitLab group %s: %s", group, err)
+			continue
+		}
+		for _, project := range projects {
+			if !s.IsAllowlisted(project) {
+				continue
+			}
+			hook := vcs.Webhook{URL: s.HookURL, Secret: s.Token, SyncSecret: s.Token != "" && !s.secretSynced[project]}
+			change, err := s.Client.EnsureWebhook(project, hook)
+			if err != nil {
+				s.Log.Err("ensuring webhook of GitLab project %s: %s", project, err)
+				continue
+			}
+			if s.secretSynced == nil {
+				s.secretSynced = make(map[string]bool)
+			}
+			s.secretSynced[project] = true
+			if change != vcs.WebhookUnchanged {
+				s.Log.Info("%s webhook of GitLab project %s", change, project)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package scheduled_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakeGitlabWebhookClient struct {
+	groupProjects map[string][]string
+	groupHooks    map[string]bool
+	// projectHooks are the secrets of the projects' webhooks.
+	projectHooks map[string]string
+}
+
+func (f *fakeGitlabWebhookClient) ListGroupProjects(group string) ([]string, error) {
+	projects, ok := f.groupProjects[group]
+	if !ok {
+		return nil, errors.New("404 Group Not Found")
+	}
+	return projects, nil
+}
+
+func (f *fakeGitlabWebhookClient) HasGroupHook(group string, hookURL string) (bool, error) {
+	return f.groupHooks[group], nil
+}
+
+func (f *fakeGitlabWebhookClient) EnsureWebhook(project string, hook vcs.Webhook) (vcs.WebhookChange, error) {
+	if _, ok := f.projectHooks[project]; !ok {
+		f.projectHooks[project] = hook.Secret
+		return vcs.WebhookCreated, nil
+	}
+	if hook.SyncSecret {
+		f.projectHooks[project] = hook.Secret
+		return vcs.WebhookUpdated, nil
+	}
+	return vcs.WebhookUnchanged, nil
+}
+
+func TestGitlabWebhookSync(t *testing.T) {
+	client := &fakeGitlabWebhookClient{
+		groupProjects: map[string][]string{
+			"infra":    {"infra/network", "infra/team/database", "infra/docs"},
+			"platform": {"platform/cluster"},
+		},
+		// Events from platform's projects already come from its group
+		// webhook.
+		groupHooks:   map[string]bool{"platform": true},
+		projectHooks: map[string]string{"infra/network": "old-secret"},
+	}
+	sync := &scheduled.GitlabWebhookSync{
+		Client:  client,
+		Groups:  []string{"missing", "infra", "platform"},
+		HookURL: "https://atlantis.com/events",
+		Token:   "secret",
+		IsAllowlisted: func(project string) bool {
+			return !strings.HasSuffix(project, "/docs")
+		},
+		Log: logging.NewNoopLogger(t),
+	}
+
+	sync.Run()
+	Equals(t, map[string]string{
+		"infra/network":       "secret",
+		"infra/team/database": "secret",
+	}, client.projectHooks)
+
+	// Running again doesn't change anything.
+	client.projectHooks["infra/network"] = "changed"
+	sync.Run()
+	Equals(t, map[string]string{
+		"infra/network":       "changed",
+		"infra/team/database": "secret",
+	}, client.projectHooks)
+}
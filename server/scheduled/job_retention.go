@@ -0,0 +1,27 @@
+package scheduled
+
+import (
+	"time"
+
+	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// JobRetention deletes persisted jobs that completed longer than Retention
+// ago.
+type JobRetention struct {
+	Store     jobs.JobStore
+	Retention time.Duration
+	Log       logging.SimpleLogging
+}
+
+func (j *JobRetention) Run() {
+	deleted, err := j.Store.DeleteJobsCompletedBefore(time.Now().Add(-j.Retention))
+	if err != nil {
+		j.Log.Err("deleting expired jobs: %s", err)
+		return
+	}
+	if deleted > 0 {
+		j.Log.Info("deleted %d jobs older than %s", deleted, j.Retention)
+	}
+}
@@ -0,0 +1,73 @@
+package scheduled
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// ParseRepoConfigGit splits the value of --repo-config-git, ex.
+// https://github.com/org/atlantis-config.git@main, into the repo's URL and
+// the ref to check out.
+func ParseRepoConfigGit(value string) (string, string, error) {
+	i := strings.LastIndex(value, "@")
+	// Refs can't contain ":" so if the part after the last "@" does, it's
+	// the host of an SSH URL like git@github.com:org/repo.git.
+	if i <= 0 || i == len(value)-1 || strings.Contains(value[i+1:], ":") {
+		return "", "", fmt.Errorf("%q must be in the form <url>@<ref>, ex. https://github.com/org/atlantis-config.git@main", value)
+	}
+	return value[:i], value[i+1:], nil
+}
+
+// RepoConfigGitSync checks out Ref of the config repo at URL into Dir and then
+// runs Reload so the server-side repo config files in Dir are reloaded if the
+// checkout changed them.
+type RepoConfigGitSync struct {
+	URL    string
+	Ref    string
+	Dir    string
+	Reload *RepoConfigReload
+	Log    logging.SimpleLogging
+}
+
+func (r *RepoConfigGitSync) Run() {
+	if err := r.Sync(); err != nil {
+		r.Log.Err("not syncing repo config from %s, keeping the current config: %s", r.URL, err)
+		return
+	}
+	r.Reload.Run()
+}
+
+// Sync fetches Ref and checks it out into Dir, initializing Dir if it isn't
+// a git repo yet.
+func (r *RepoConfigGitSync) Sync() error {
+	if _, err := os.Stat(filepath.Join(r.Dir, ".git")); os.IsNotExist(err) {
+		if err := os.MkdirAll(r.Dir, 0700); err != nil {
+			return errors.Wrapf(err, "creating %s", r.Dir)
+		}
+		if err := r.git("init", "--quiet"); err != nil {
+			return err
+		}
+		if err := r.git("remote", "add", "origin", r.URL); err != nil {
+			return err
+		}
+	}
+	if err := r.git("fetch", "--quiet", "--depth=1", "origin", r.Ref); err != nil {
+		return err
+	}
+	return r.git("checkout", "--quiet", "--force", "--detach", "FETCH_HEAD")
+}
+
+func (r *RepoConfigGitSync) git(args ...string) error {
+	cmd := exec.Command("git", args...) // nolint: gosec
+	cmd.Dir = r.Dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "running git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package scheduled_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestParseRepoConfigGit(t *testing.T) {
+	cases := []struct {
+		value  string
+		expURL string
+		expRef string
+		expErr bool
+	}{
+		{value: "https://github.com/org/cfg.git@main", expURL: "https://github.com/org/cfg.git", expRef: "main"},
+		{value: "git@github.com:org/cfg.git@release/v1", expURL: "git@github.com:org/cfg.git", expRef: "release/v1"},
+		{value: "git@github.com:org/cfg.git", expErr: true},
+		{value: "https://github.com/org/cfg.git@", expErr: true},
+		{value: "https://github.com/org/cfg.git", expErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			url, ref, err := scheduled.ParseRepoConfigGit(c.value)
+			if c.expErr {
+				Assert(t, err != nil, "exp err")
+				return
+			}
+			Ok(t, err)
+			Equals(t, c.expURL, url)
+			Equals(t, c.expRef, ref)
+		})
+	}
+}
+
+func TestRepoConfigGitSync_Sync(t *testing.T) {
+	src := t.TempDir()
+	runCmd(t, src, "git", "init", "--quiet")
+	Ok(t, os.WriteFile(filepath.Join(src, "repos.yaml"), []byte("v1"), 0600))
+	runCmd(t, src, "git", "add", "repos.yaml")
+	runCmd(t, src, "git", "-c", "user.name=atlantis", "-c", "user.email=atlantis@example.com", "commit", "--quiet", "-m", "v1")
+	runCmd(t, src, "git", "branch", "-M", "main")
+
+	sync := &scheduled.RepoConfigGitSync{
+		URL: "file://" + src,
+		Ref: "main",
+		Dir: filepath.Join(t.TempDir(), "repo-config"),
+		Log: logging.NewNoopLogger(t),
+	}
+	Ok(t, sync.Sync())
+	Equals(t, "v1", string(mustRead(t, filepath.Join(sync.Dir, "repos.yaml"))))
+
+	Ok(t, os.WriteFile(filepath.Join(src, "repos.yaml"), []byte("v2"), 0600))
+	runCmd(t, src, "git", "-c", "user.name=atlantis", "-c", "user.email=atlantis@example.com", "commit", "--quiet", "-am", "v2")
+	Ok(t, sync.Sync())
+	Equals(t, "v2", string(mustRead(t, filepath.Join(sync.Dir, "repos.yaml"))))
+
+	sync.Ref = "missing"
+	Assert(t, sync.Sync() != nil, "exp err fetching missing ref")
+}
+
+func runCmd(t *testing.T, dir string, name string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	Assert(t, err == nil, "err running %q: %s", strings.Join(append([]string{name}, args...), " "), out)
+	return string(out)
+}
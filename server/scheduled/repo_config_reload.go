@@ -0,0 +1,75 @@
+package scheduled
+
+import (
+	"os"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// RepoConfigReload reloads the server-side repo config into Store when any of
+// Files changes. If the new config doesn't load, ex. because it's invalid, the
+// current config is kept until the files change again.
+type RepoConfigReload struct {
+	Files []string
+	// Load parses and validates Files.
+	Load  func() (valid.GlobalCfg, error)
+	Store *valid.GlobalCfgStore
+	Log   logging.SimpleLogging
+
+	// modTimes are the modification times of Files when they were last
+	// loaded.
+	modTimes map[string]time.Time
+}
+
+// NewRepoConfigReload returns a job that reloads files once they change from
+// their current version, which should be the one already in store.
+func NewRepoConfigReload(files []string, load func() (valid.GlobalCfg, error), store *valid.GlobalCfgStore, log logging.SimpleLogging) *RepoConfigReload {
+	modTimes, _ := fileModTimes(files)
+	return &RepoConfigReload{
+		Files:    files,
+		Load:     load,
+		Store:    store,
+		Log:      log,
+		modTimes: modTimes,
+	}
+}
+
+func (r *RepoConfigReload) Run() {
+	modTimes, err := fileModTimes(r.Files)
+	if err != nil {
+		r.Log.Err("not reloading repo config, keeping the current config: %s", err)
+		return
+	}
+	changed := false
+	for file, modTime := range modTimes {
+		if !modTime.Equal(r.modTimes[file]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	r.modTimes = modTimes
+
+	globalCfg, err := r.Load()
+	if err != nil {
+		r.Log.Err("not reloading repo config, keeping the current config: %s", err)
+		return
+	}
+	r.Store.Set(globalCfg)
+	r.Log.Info("reloaded repo config from %v", r.Files)
+}
+
+func fileModTimes(files []string) (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[file] = info.ModTime()
+	}
+	return modTimes, nil
+}
@@ -0,0 +1,61 @@
+package scheduled_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestRepoConfigReload(t *testing.T) {
+	tmp := t.TempDir()
+	file := filepath.Join(tmp, "repos.yaml")
+	Ok(t, os.WriteFile(file, []byte("v1"), 0600))
+
+	var loadErr error
+	loads := 0
+	load := func() (valid.GlobalCfg, error) {
+		loads++
+		if loadErr != nil {
+			return valid.GlobalCfg{}, loadErr
+		}
+		return valid.GlobalCfg{Workflows: map[string]valid.Workflow{"v": {Name: string(mustRead(t, file))}}}, nil
+	}
+	store := valid.NewGlobalCfgStore(valid.GlobalCfg{})
+	reload := scheduled.NewRepoConfigReload([]string{file}, load, store, logging.NewNoopLogger(t))
+
+	// Unchanged files aren't reloaded.
+	reload.Run()
+	Equals(t, 0, loads)
+
+	// Invalid config is rejected and the current config is kept.
+	loadErr = errors.New("invalid")
+	touch(t, file, "v2", time.Now().Add(time.Minute))
+	reload.Run()
+	Equals(t, 1, loads)
+	Equals(t, 0, len(store.Get().Workflows))
+
+	// Once the file is fixed it's reloaded.
+	loadErr = nil
+	touch(t, file, "v3", time.Now().Add(2*time.Minute))
+	reload.Run()
+	Equals(t, 2, loads)
+	Equals(t, "v3", store.Get().Workflows["v"].Name)
+}
+
+func touch(t *testing.T, file string, contents string, modTime time.Time) {
+	Ok(t, os.WriteFile(file, []byte(contents), 0600))
+	Ok(t, os.Chtimes(file, modTime, modTime))
+}
+
+func mustRead(t *testing.T, file string) []byte {
+	contents, err := os.ReadFile(file)
+	Ok(t, err)
+	return contents
+}
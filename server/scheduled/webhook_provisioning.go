@@ -0,0 +1,86 @@
+package scheduled
+
+import (
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// WebhookProvisioningHost is a VCS host that WebhookProvisioning adds
+// webhooks on.
+type WebhookProvisioningHost struct {
+	// Name is used in logs, ex. GitHub.
+	Name     string
+	Client   vcs.WebhookProvisioner
+	Hostname string
+	// Secret is the webhook secret.
+	Secret string
+}
+
+// WebhookProvisioning adds a webhook to Atlantis to every allowlisted repo
+// on Hosts, or fixes it if it's misconfigured, so that repos don't need to be
+// onboarded by hand. Repos matched by an allowlist rule with a wildcard are
+// found by listing the repos of the rule's owner. Rules with a wildcard in
+// the owner can't be enumerated and are skipped.
+type WebhookProvisioning struct {
+	Hosts []WebhookProvisioningHost
+	// HookURL is Atlantis's events endpoint.
+	HookURL   string
+	Allowlist *events.RepoAllowlistChecker
+	Log       logging.SimpleLogging
+
+	// secretSynced are the repos whose webhook secret has been set since
+	// Atlantis started, keyed by hostname/repo.
+	secretSynced map[string]bool
+	// warned is true once the unenumerable rules have been logged.
+	warned bool
+}
+
+func (p *WebhookProvisioning) Run() {
+	for _, host := range p.Hosts {
+		targets := p.Allowlist.Targets(host.Hostname)
+		if !p.warned {
+			for _, rule := range targets.Unenumerable {
+				p.Log.Warn("not provisioning webhooks for repo allowlist rule %q on %s since its repos can't be listed", rule, host.Name)
+			}
+		}
+
+		repos := targets.Repos
+		for _, owner := range targets.Owners {
+			ownerRepos, err := host.Client.ListOwnerRepos(owner)
+			if err != nil {
+				p.Log.Err("listing repos of %s owner %s: %s", host.Name, owner, err)
+				continue
+			}
+			for _, repo := range ownerRepos {
+				if p.Allowlist.IsAllowlisted(repo, host.Hostname) {
+					repos = append(repos, repo)
+				}
+			}
+		}
+
+		seen := make(map[string]bool)
+		for _, repo := range repos {
+			key := host.Hostname + "/" + repo
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			hook := vcs.Webhook{URL: p.HookURL, Secret: host.Secret, SyncSecret: host.Secret != "" && !p.secretSynced[key]}
+			change, err := host.Client.EnsureWebhook(repo, hook)
+			if err != nil {
+				p.Log.Err("ensuring webhook of %s repo %s: %s", host.Name, repo, err)
+				continue
+			}
+			if p.secretSynced == nil {
+				p.secretSynced = make(map[string]bool)
+			}
+			p.secretSynced[key] = true
+			if change != vcs.WebhookUnchanged {
+				p.Log.Info("%s webhook of %s repo %s", change, host.Name, repo)
+			}
+		}
+	}
+	p.warned = true
+}
@@ -0,0 +1,80 @@
+package scheduled_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakeWebhookProvisioner struct {
+	ownerRepos map[string][]string
+	// hooks are the secrets of the repos' webhooks.
+	hooks map[string]string
+}
+
+func (f *fakeWebhookProvisioner) ListOwnerRepos(owner string) ([]string, error) {
+	repos, ok := f.ownerRepos[owner]
+	if !ok {
+		return nil, errors.New("404 Not Found")
+	}
+	return repos, nil
+}
+
+func (f *fakeWebhookProvisioner) EnsureWebhook(repo string, hook vcs.Webhook) (vcs.WebhookChange, error) {
+	if _, ok := f.hooks[repo]; !ok {
+		f.hooks[repo] = hook.Secret
+		return vcs.WebhookCreated, nil
+	}
+	if hook.SyncSecret {
+		f.hooks[repo] = hook.Secret
+		return vcs.WebhookUpdated, nil
+	}
+	return vcs.WebhookUnchanged, nil
+}
+
+func TestWebhookProvisioning(t *testing.T) {
+	allowlist, err := events.NewRepoAllowlistChecker("github.com/org/infra-*,github.com/missing/*,github.com/user/repo,gitlab.com/group/*")
+	Ok(t, err)
+	github := &fakeWebhookProvisioner{
+		ownerRepos: map[string][]string{
+			"org": {"org/infra-network", "org/website", "org/infra-database"},
+		},
+		hooks: map[string]string{"org/infra-network": "old-secret"},
+	}
+	gitlab := &fakeWebhookProvisioner{
+		ownerRepos: map[string][]string{
+			"group": {"group/project", "group/subgroup/project"},
+		},
+		hooks: map[string]string{},
+	}
+	provisioning := &scheduled.WebhookProvisioning{
+		Hosts: []scheduled.WebhookProvisioningHost{
+			{Name: "GitHub", Client: github, Hostname: "github.com", Secret: "github-secret"},
+			{Name: "GitLab", Client: gitlab, Hostname: "gitlab.com"},
+		},
+		HookURL:   "https://atlantis.com/events",
+		Allowlist: allowlist,
+		Log:       logging.NewNoopLogger(t),
+	}
+
+	provisioning.Run()
+	Equals(t, map[string]string{
+		"org/infra-network":  "github-secret",
+		"org/infra-database": "github-secret",
+		"user/repo":          "github-secret",
+	}, github.hooks)
+	Equals(t, map[string]string{
+		"group/project":          "",
+		"group/subgroup/project": "",
+	}, gitlab.hooks)
+
+	// Running again doesn't change anything.
+	github.hooks["org/infra-network"] = "changed"
+	provisioning.Run()
+	Equals(t, "changed", github.hooks["org/infra-network"])
+}
@@ -21,17 +21,20 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"syscall"
+	"text/template"
 	"time"
 
-	"github.com/mitchellh/go-homedir"
+	"github.com/runatlantis/atlantis/server/core/artifacts"
 	cfg "github.com/runatlantis/atlantis/server/core/config"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/db"
@@ -45,25 +48,37 @@ import (
 	assetfs "github.com/elazarl/go-bindata-assetfs"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/agents"
 	"github.com/runatlantis/atlantis/server/controllers"
 	events_controllers "github.com/runatlantis/atlantis/server/controllers/events"
 	"github.com/runatlantis/atlantis/server/controllers/templates"
 	"github.com/runatlantis/atlantis/server/controllers/websocket"
 	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/core/oidc"
 	"github.com/runatlantis/atlantis/server/core/runtime"
 	"github.com/runatlantis/atlantis/server/core/runtime/policy"
 	"github.com/runatlantis/atlantis/server/core/terraform"
 	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/alerts"
+	"github.com/runatlantis/atlantis/server/events/analytics"
+	"github.com/runatlantis/atlantis/server/events/annotations"
+	"github.com/runatlantis/atlantis/server/events/changetickets"
+	"github.com/runatlantis/atlantis/server/events/cloudevents"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketserver"
+	"github.com/runatlantis/atlantis/server/events/vcs/gerrit"
 	"github.com/runatlantis/atlantis/server/events/webhooks"
+	"github.com/runatlantis/atlantis/server/i18n"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/static"
 	"github.com/urfave/cli"
 	"github.com/urfave/negroni"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 const (
@@ -77,12 +92,21 @@ const (
 	LockViewRouteIDQueryParam = "id"
 	// ProjectJobsViewRouteName is the named route in mux.Router for the log stream view.
 	ProjectJobsViewRouteName = "project-jobs-detail"
+	// ProjectGraphRouteName is the named route in mux.Router for the
+	// dependency graphs rendered by graph steps.
+	ProjectGraphRouteName = "project-graph"
+	// ArtifactsDirName is the name of the dir inside our data dir where
+	// artifacts are stored if --artifact-store isn't set.
+	ArtifactsDirName = "artifacts"
 	// binDirName is the name of the directory inside our data dir where
 	// we download binaries.
 	BinDirName = "bin"
 	// terraformPluginCacheDir is the name of the dir inside our data dir
 	// where we tell terraform to cache plugins and modules.
 	TerraformPluginCacheDirName = "plugin-cache"
+	// stateLockRetryDelay is how long we wait before retrying an apply that
+	// failed because the state was locked. It doubles with each retry.
+	stateLockRetryDelay = 10 * time.Second
 )
 
 // Server runs the Atlantis web server.
@@ -100,12 +124,15 @@ type Server struct {
 	StatsCloser                    io.Closer
 	Locker                         locking.Locker
 	ApplyLocker                    locking.ApplyLocker
+	Freezer                        locking.Freezer
 	VCSEventsController            *events_controllers.VCSEventsController
 	GithubAppController            *controllers.GithubAppController
 	LocksController                *controllers.LocksController
 	StatusController               *controllers.StatusController
+	ReadinessController            *controllers.ReadinessController
 	JobsController                 *controllers.JobsController
 	APIController                  *controllers.APIController
+	OIDCController                 *controllers.OIDCController
 	IndexTemplate                  templates.TemplateWriter
 	LockDetailTemplate             templates.TemplateWriter
 	ProjectJobsTemplate            templates.TemplateWriter
@@ -118,6 +145,22 @@ type Server struct {
 	WebPassword                    string
 	ProjectCmdOutputHandler        jobs.ProjectCommandOutputHandler
 	ScheduledExecutorService       *scheduled.ExecutorService
+	// AgentServer serves the agents on AgentPort. Nil if agents are disabled.
+	AgentServer *grpc.Server
+	AgentPort   int
+	// APIGRPCServer serves the API over gRPC on APIGRPCPort. Nil if the gRPC
+	// API is disabled.
+	APIGRPCServer *grpc.Server
+	APIGRPCPort   int
+	// ProjectRuns are the projects' last runs, used to show the slowest
+	// projects on the index page. If nil, they aren't shown.
+	ProjectRuns events.ProjectRunStore
+	// LockContention is used to show the most contended locks on the index
+	// page. If nil, it isn't shown.
+	LockContention events.LockContentionStore
+	// AnalyticsSink loads the records of plans and applies into a data
+	// warehouse while the server runs. Nil if there's no warehouse.
+	AnalyticsSink *analytics.BatchSink
 }
 
 // Config holds config for server that isn't passed in by the user.
@@ -143,13 +186,23 @@ type WebhookConfig struct {
 	// Channel is the channel to send this webhook to. It only applies to
 	// slack webhooks. Should be without '#'.
 	Channel string `mapstructure:"channel"`
+	// Topic is the topic to publish this webhook to. It only applies to sns
+	// webhooks, where it's the topic's ARN, and pubsub webhooks, where it's
+	// the topic's full name, ex. projects/my-project/topics/atlantis.
+	Topic string `mapstructure:"topic"`
+	// QueueURL is the URL of the queue to send this webhook to. It only
+	// applies to sqs webhooks.
+	QueueURL string `mapstructure:"queue-url"`
 }
 
 // NewServer returns a new server. If there are issues starting the server or
 // its dependencies an error will be returned. This is like the main() function
 // for the server CLI command because it injects all the dependencies.
 func NewServer(userConfig UserConfig, config Config) (*Server, error) {
-	logger, err := logging.NewStructuredLoggerFromLevel(userConfig.ToLogLevel())
+	startTime := time.Now()
+	// recentErrors are included in support bundles.
+	recentErrors := logging.NewRecentErrors(100)
+	logger, err := logging.NewStructuredLoggerWithRecentErrors(userConfig.ToLogLevel(), recentErrors)
 
 	if err != nil {
 		return nil, err
@@ -160,10 +213,22 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	var githubAppEnabled bool
 	var githubConfig vcs.GithubConfig
 	var githubCredentials vcs.GithubCredentials
+	// githubDeployments is nil unless GitHub deployments are enabled.
+	var githubDeployments *vcs.GithubDeploymentsClientProxy
+	// githubBranchProtection is nil unless GitHub is configured.
+	var githubBranchProtection *vcs.GithubBranchProtectionClientProxy
+	// githubPullStacks is nil unless pull stacks are enabled.
+	var githubPullStacks *vcs.GithubPullStackClientProxy
 	var gitlabClient *vcs.GitlabClient
+	// webhookProvisioningHosts are the VCS hosts that Atlantis adds webhooks
+	// on with --provision-webhooks.
+	var webhookProvisioningHosts []scheduled.WebhookProvisioningHost
 	var bitbucketCloudClient *bitbucketcloud.Client
 	var bitbucketServerClient *bitbucketserver.Client
 	var azuredevopsClient *vcs.AzureDevopsClient
+	var gerritClient *gerrit.Client
+	// readinessChecks are run by the /readyz readiness probe.
+	var readinessChecks []controllers.ReadinessCheck
 
 	policyChecksEnabled := false
 	if userConfig.EnablePolicyChecksFlag {
@@ -171,27 +236,78 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		policyChecksEnabled = true
 	}
 
-	validator := &cfg.ParserValidator{}
+	validator := newRepoConfigValidator(userConfig)
 
-	globalCfg := valid.NewGlobalCfgFromArgs(
-		valid.GlobalCfgArgs{
-			AllowRepoCfg:       userConfig.AllowRepoConfig,
-			MergeableReq:       userConfig.RequireMergeable,
-			ApprovedReq:        userConfig.RequireApproval,
-			UnDivergedReq:      userConfig.RequireUnDiverged,
-			PolicyCheckEnabled: userConfig.EnablePolicyChecksFlag,
-		})
-	if userConfig.RepoConfig != "" {
-		globalCfg, err = validator.ParseGlobalCfg(userConfig.RepoConfig, globalCfg)
+	var oidcIssuer *oidc.Issuer
+	if userConfig.OIDCSigningKeyFile != "" {
+		keyPEM, err := os.ReadFile(userConfig.OIDCSigningKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading OIDC signing key")
+		}
+		if oidcIssuer, err = oidc.NewIssuer(userConfig.AtlantisURL, keyPEM); err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", userConfig.OIDCSigningKeyFile)
+		}
+	}
+
+	globalCfgArgs := newGlobalCfgArgs(userConfig)
+	// Multiple files are layered in order, ex. org-wide defaults, then
+	// team overrides, then repo-specific config.
+	repoConfigFiles := splitRepoConfigFiles(userConfig.RepoConfig)
+	// If the config is in a git repo, the files are relative to its root.
+	var repoConfigGitSync *scheduled.RepoConfigGitSync
+	if userConfig.RepoConfigGit != "" {
+		url, ref, err := scheduled.ParseRepoConfigGit(userConfig.RepoConfigGit)
 		if err != nil {
-			return nil, errors.Wrapf(err, "parsing %s file", userConfig.RepoConfig)
+			return nil, errors.Wrap(err, "parsing --repo-config-git")
 		}
-	} else if userConfig.RepoConfigJSON != "" {
-		globalCfg, err = validator.ParseGlobalCfgJSON(userConfig.RepoConfigJSON, globalCfg)
+		repoConfigGitSync = &scheduled.RepoConfigGitSync{
+			URL: url,
+			Ref: ref,
+			Dir: filepath.Join(userConfig.DataDir, "repo-config"),
+			Log: logger,
+		}
+		if err := repoConfigGitSync.Sync(); err != nil {
+			return nil, errors.Wrapf(err, "fetching repo config from %s", url)
+		}
+		if len(repoConfigFiles) == 0 {
+			repoConfigFiles = []string{"repos.yaml"}
+		}
+		for i, f := range repoConfigFiles {
+			repoConfigFiles[i] = filepath.Join(repoConfigGitSync.Dir, f)
+		}
+	}
+	loadGlobalCfg := func() (valid.GlobalCfg, error) {
+		// The default config is built each time because parsing modifies it
+		// if the default workflow is redefined.
+		globalCfg, err := validator.ParseGlobalCfgs(repoConfigFiles, valid.NewGlobalCfgFromArgs(globalCfgArgs))
+		if err != nil {
+			return valid.GlobalCfg{}, err
+		}
+		if err := checkCloudCredentials(globalCfg, oidcIssuer); err != nil {
+			return valid.GlobalCfg{}, err
+		}
+		return globalCfg, checkAgentPools(globalCfg, userConfig.AgentPort != 0)
+	}
+
+	var globalCfg valid.GlobalCfg
+	if userConfig.RepoConfigJSON != "" {
+		globalCfg, err = validator.ParseGlobalCfgJSON(userConfig.RepoConfigJSON, valid.NewGlobalCfgFromArgs(globalCfgArgs))
 		if err != nil {
 			return nil, errors.Wrapf(err, "parsing --%s", config.RepoConfigJSONFlag)
 		}
+		if err := checkCloudCredentials(globalCfg, oidcIssuer); err != nil {
+			return nil, err
+		}
+		if err := checkAgentPools(globalCfg, userConfig.AgentPort != 0); err != nil {
+			return nil, err
+		}
+	} else {
+		globalCfg, err = loadGlobalCfg()
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s file", strings.Join(repoConfigFiles, ","))
+		}
 	}
+	globalCfgStore := valid.NewGlobalCfgStore(globalCfg)
 
 	statsScope, statsReporter, closer, err := metrics.NewScope(globalCfg.Metrics, logger, userConfig.StatsNamespace)
 
@@ -199,17 +315,66 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		return nil, errors.Wrapf(err, "instantiating metrics scope")
 	}
 
+	// The commit status updater is created before the VCS clients because
+	// they need to know how it names the apply statuses. Its client is set
+	// once they're created.
+	commitStatusUpdater := &events.DefaultCommitStatusUpdater{
+		StatusName:  userConfig.VCSStatusName,
+		Granularity: userConfig.CommitStatusGranularity,
+	}
+	if userConfig.CommitStatusNameTemplate != "" {
+		commitStatusUpdater.StatusNameTemplate, err = template.New("").Parse(userConfig.CommitStatusNameTemplate)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing commit status name template")
+		}
+	}
+	if userConfig.ProjectStatusNameTemplate != "" {
+		commitStatusUpdater.ProjectStatusNameTemplate, err = template.New("").Parse(userConfig.ProjectStatusNameTemplate)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing project status name template")
+		}
+	}
+
+	// gitEnvs are the env vars that make git connect to each VCS host through
+	// the same proxy and CA bundle as its client.
+	gitEnvs := make(map[models.VCSHostType][]string)
+	vcsTransport := func(hostType models.VCSHostType, config vcs.TransportConfig) (http.RoundTripper, error) {
+		gitEnvs[hostType] = config.GitEnv()
+		tr, err := config.Transport()
+		return tr, errors.Wrapf(err, "configuring %s transport", hostType.String())
+	}
+
+	// Repos on additional GitHub hosts use their own clients so one
+	// Atlantis can serve, ex. both github.com and GitHub Enterprise.
+	githubAdditionalHosts, err := userConfig.ToGithubAdditionalHosts()
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing --gh-additional-hosts")
+	}
+	githubHostClients := make(map[string]vcs.IGithubClient)
+	// tenantTransports are the transports of the clients that authenticate
+	// with tenants' credentials.
+	tenantTransports := make(map[models.VCSHostType]http.RoundTripper)
 	if userConfig.GithubUser != "" || userConfig.GithubAppID != 0 {
+		githubTransport, err := vcsTransport(models.Github, vcs.TransportConfig{
+			CABundleFile: userConfig.GithubCABundle,
+			ProxyURL:     userConfig.GithubProxy,
+		})
+		if err != nil {
+			return nil, err
+		}
+		tenantTransports[models.Github] = githubTransport
 		if userConfig.GithubAllowMergeableBypassApply {
 			githubConfig = vcs.GithubConfig{
 				AllowMergeableBypassApply: true,
+				IsApplyStatus:             commitStatusUpdater.IsApplyStatus,
 			}
 		}
 		supportedVCSHosts = append(supportedVCSHosts, models.Github)
 		if userConfig.GithubUser != "" {
 			githubCredentials = &vcs.GithubUserCredentials{
-				User:  userConfig.GithubUser,
-				Token: userConfig.GithubToken,
+				User:      userConfig.GithubUser,
+				Token:     userConfig.GithubToken,
+				Transport: githubTransport,
 			}
 		} else if userConfig.GithubAppID != 0 && userConfig.GithubAppKeyFile != "" {
 			privateKey, err := os.ReadFile(userConfig.GithubAppKeyFile)
@@ -217,51 +382,145 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 				return nil, err
 			}
 			githubCredentials = &vcs.GithubAppCredentials{
-				AppID:    userConfig.GithubAppID,
-				Key:      privateKey,
-				Hostname: userConfig.GithubHostname,
-				AppSlug:  userConfig.GithubAppSlug,
+				AppID:     userConfig.GithubAppID,
+				Key:       privateKey,
+				Hostname:  userConfig.GithubHostname,
+				AppSlug:   userConfig.GithubAppSlug,
+				Transport: githubTransport,
 			}
 			githubAppEnabled = true
 		} else if userConfig.GithubAppID != 0 && userConfig.GithubAppKey != "" {
 			githubCredentials = &vcs.GithubAppCredentials{
-				AppID:    userConfig.GithubAppID,
-				Key:      []byte(userConfig.GithubAppKey),
-				Hostname: userConfig.GithubHostname,
-				AppSlug:  userConfig.GithubAppSlug,
+				AppID:     userConfig.GithubAppID,
+				Key:       []byte(userConfig.GithubAppKey),
+				Hostname:  userConfig.GithubHostname,
+				AppSlug:   userConfig.GithubAppSlug,
+				Transport: githubTransport,
 			}
 			githubAppEnabled = true
 		}
 
-		var err error
 		rawGithubClient, err := vcs.NewGithubClient(userConfig.GithubHostname, githubCredentials, githubConfig, logger)
 		if err != nil {
 			return nil, err
 		}
 
 		githubClient = vcs.NewInstrumentedGithubClient(rawGithubClient, statsScope, logger)
+		readinessChecks = append(readinessChecks, controllers.ReadinessCheck{Name: "github", Check: rawGithubClient.CheckHealth})
+		if githubAppEnabled {
+			credentials := githubCredentials
+			readinessChecks = append(readinessChecks, controllers.ReadinessCheck{Name: "github-app-token", Check: func() error {
+				_, err := credentials.GetToken()
+				return err
+			}})
+		}
+		githubBranchProtection = &vcs.GithubBranchProtectionClientProxy{
+			Default: rawGithubClient,
+			Hosts:   make(map[string]vcs.BranchProtectionClient),
+		}
+		if userConfig.EnablePullStacks {
+			githubPullStacks = &vcs.GithubPullStackClientProxy{
+				Default: rawGithubClient,
+				Hosts:   make(map[string]vcs.PullStackClient),
+			}
+		}
+		if userConfig.EnableGithubDeployments {
+			githubDeployments = &vcs.GithubDeploymentsClientProxy{
+				Default: rawGithubClient,
+				Hosts:   make(map[string]vcs.GithubDeploymentsClient),
+			}
+		}
+		// GitHub App webhooks are configured on the app instead.
+		if userConfig.ProvisionWebhooks && !githubAppEnabled {
+			webhookProvisioningHosts = append(webhookProvisioningHosts, scheduled.WebhookProvisioningHost{
+				Name:     "GitHub",
+				Client:   rawGithubClient,
+				Hostname: userConfig.GithubHostname,
+				Secret:   userConfig.GithubWebhookSecret,
+			})
+		}
+
+		for _, host := range githubAdditionalHosts {
+			rawHostClient, err := vcs.NewGithubClient(host.Hostname, &vcs.GithubUserCredentials{
+				User:      host.User,
+				Token:     host.Token,
+				Transport: githubTransport,
+			}, githubConfig, logger)
+			if err != nil {
+				return nil, errors.Wrapf(err, "creating client for %s", host.Hostname)
+			}
+			githubHostClients[host.Hostname] = vcs.NewInstrumentedGithubClient(rawHostClient, statsScope, logger)
+			readinessChecks = append(readinessChecks, controllers.ReadinessCheck{Name: "github-" + host.Hostname, Check: rawHostClient.CheckHealth})
+			if githubDeployments != nil {
+				githubDeployments.Hosts[host.Hostname] = rawHostClient
+			}
+			githubBranchProtection.Hosts[host.Hostname] = rawHostClient
+			if githubPullStacks != nil {
+				githubPullStacks.Hosts[host.Hostname] = rawHostClient
+			}
+		}
 	}
 	if userConfig.GitlabUser != "" {
 		supportedVCSHosts = append(supportedVCSHosts, models.Gitlab)
-		var err error
-		gitlabClient, err = vcs.NewGitlabClient(userConfig.GitlabHostname, userConfig.GitlabToken, logger)
+		gitlabTransport, err := vcsTransport(models.Gitlab, vcs.TransportConfig{
+			CABundleFile: userConfig.GitlabCABundle,
+			ProxyURL:     userConfig.GitlabProxy,
+		})
+		if err != nil {
+			return nil, err
+		}
+		tenantTransports[models.Gitlab] = gitlabTransport
+		gitlabClient, err = vcs.NewGitlabClient(userConfig.GitlabHostname, userConfig.GitlabToken, gitlabTransport, logger)
 		if err != nil {
 			return nil, err
 		}
+		if userConfig.ProvisionWebhooks {
+			webhookProvisioningHosts = append(webhookProvisioningHosts, scheduled.WebhookProvisioningHost{
+				Name:     "GitLab",
+				Client:   gitlabClient,
+				Hostname: userConfig.GitlabHostname,
+				Secret:   userConfig.GitlabWebhookSecret,
+			})
+		}
 	}
+	// bitbucketTokenSource is set if Atlantis authenticates to Bitbucket Cloud
+	// with access tokens instead of an app password.
+	var bitbucketTokenSource oauth2.TokenSource
 	if userConfig.BitbucketUser != "" {
+		bitbucketConfig := vcs.TransportConfig{
+			CABundleFile: userConfig.BitbucketCABundle,
+			ProxyURL:     userConfig.BitbucketProxy,
+		}
 		if userConfig.BitbucketBaseURL == bitbucketcloud.BaseURL {
 			supportedVCSHosts = append(supportedVCSHosts, models.BitbucketCloud)
-			bitbucketCloudClient = bitbucketcloud.NewClient(
-				http.DefaultClient,
-				userConfig.BitbucketUser,
-				userConfig.BitbucketToken,
-				userConfig.AtlantisURL)
+			bitbucketTransport, err := vcsTransport(models.BitbucketCloud, bitbucketConfig)
+			if err != nil {
+				return nil, err
+			}
+			bitbucketHTTPClient := &http.Client{Transport: bitbucketTransport}
+			switch {
+			case userConfig.BitbucketOAuthClientID != "":
+				bitbucketTokenSource = bitbucketcloud.NewOAuthTokenSource(bitbucketHTTPClient, userConfig.BitbucketOAuthClientID, userConfig.BitbucketOAuthClientSecret)
+			case userConfig.BitbucketWorkspaceToken != "":
+				bitbucketTokenSource = bitbucketcloud.NewWorkspaceTokenSource(userConfig.BitbucketWorkspaceToken)
+			}
+			if bitbucketTokenSource != nil {
+				bitbucketCloudClient = bitbucketcloud.NewClientWithTokenSource(bitbucketHTTPClient, bitbucketTokenSource, userConfig.AtlantisURL)
+			} else {
+				bitbucketCloudClient = bitbucketcloud.NewClient(
+					bitbucketHTTPClient,
+					userConfig.BitbucketUser,
+					userConfig.BitbucketToken,
+					userConfig.AtlantisURL)
+			}
 		} else {
 			supportedVCSHosts = append(supportedVCSHosts, models.BitbucketServer)
-			var err error
+			bitbucketTransport, err := vcsTransport(models.BitbucketServer, bitbucketConfig)
+			if err != nil {
+				return nil, err
+			}
 			bitbucketServerClient, err = bitbucketserver.NewClient(
-				http.DefaultClient,
+				&http.Client{Transport: bitbucketTransport},
 				userConfig.BitbucketUser,
 				userConfig.BitbucketToken,
 				userConfig.BitbucketBaseURL,
@@ -280,37 +539,96 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			return nil, err
 		}
 	}
-
-	if userConfig.WriteGitCreds {
-		home, err := homedir.Dir()
+	if userConfig.GerritUser != "" {
+		supportedVCSHosts = append(supportedVCSHosts, models.Gerrit)
+		gerritClient, err = gerrit.NewClient(nil, userConfig.GerritUser, userConfig.GerritToken, userConfig.GerritBaseURL)
 		if err != nil {
-			return nil, errors.Wrap(err, "getting home dir to write ~/.git-credentials file")
+			return nil, errors.Wrapf(err, "setting up Gerrit client")
 		}
-		if userConfig.GithubUser != "" {
-			if err := events.WriteGitCreds(userConfig.GithubUser, userConfig.GithubToken, userConfig.GithubHostname, home, logger, false); err != nil {
-				return nil, err
-			}
+	}
+
+	// gitCredentials are the credentials that git authenticates to each VCS
+	// host with. They're given to git through a credential helper so that
+	// they're never written to disk.
+	var gitCredentials []events.GitCredentials
+	if userConfig.GithubUser != "" {
+		gitCredentials = append(gitCredentials, events.GitCredentials{
+			URL:      "https://" + userConfig.GithubHostname,
+			Username: userConfig.GithubUser,
+			Token:    events.StaticToken(userConfig.GithubToken),
+		})
+	} else if githubAppEnabled {
+		// https://developer.github.com/apps/building-github-apps/authenticating-with-github-apps/#http-based-git-access-by-an-installation
+		gitCredentials = append(gitCredentials, events.GitCredentials{
+			URL:      "https://" + userConfig.GithubHostname,
+			Username: "x-access-token",
+			Token:    githubCredentials.GetToken,
+		})
+	}
+	for _, host := range githubAdditionalHosts {
+		gitCredentials = append(gitCredentials, events.GitCredentials{
+			URL:      "https://" + host.Hostname,
+			Username: host.User,
+			Token:    events.StaticToken(host.Token),
+		})
+	}
+	if userConfig.GitlabUser != "" {
+		gitCredentials = append(gitCredentials, events.GitCredentials{
+			URL:      "https://" + userConfig.GitlabHostname,
+			Username: userConfig.GitlabUser,
+			Token:    events.StaticToken(userConfig.GitlabToken),
+		})
+	}
+	if userConfig.BitbucketUser != "" {
+		// The default BitbucketBaseURL is https://api.bitbucket.org which can't actually be used for git
+		// so we override it here only if it's that to be bitbucket.org
+		bitbucketBaseURL := userConfig.BitbucketBaseURL
+		if bitbucketBaseURL == bitbucketcloud.BaseURL {
+			bitbucketBaseURL = "https://bitbucket.org"
 		}
-		if userConfig.GitlabUser != "" {
-			if err := events.WriteGitCreds(userConfig.GitlabUser, userConfig.GitlabToken, userConfig.GitlabHostname, home, logger, false); err != nil {
-				return nil, err
-			}
+		bitbucketGitCredentials := events.GitCredentials{
+			URL:      bitbucketBaseURL,
+			Username: userConfig.BitbucketUser,
+			Token:    events.StaticToken(userConfig.BitbucketToken),
 		}
-		if userConfig.BitbucketUser != "" {
-			// The default BitbucketBaseURL is https://api.bitbucket.org which can't actually be used for git
-			// so we override it here only if it's that to be bitbucket.org
-			bitbucketBaseURL := userConfig.BitbucketBaseURL
-			if bitbucketBaseURL == "https://api.bitbucket.org" {
-				bitbucketBaseURL = "bitbucket.org"
-			}
-			if err := events.WriteGitCreds(userConfig.BitbucketUser, userConfig.BitbucketToken, bitbucketBaseURL, home, logger, false); err != nil {
-				return nil, err
+		if bitbucketTokenSource != nil {
+			bitbucketGitCredentials.Username = bitbucketcloud.TokenAuthUser
+			bitbucketGitCredentials.Token = func() (string, error) {
+				token, err := bitbucketTokenSource.Token()
+				if err != nil {
+					return "", err
+				}
+				return token.AccessToken, nil
 			}
 		}
-		if userConfig.AzureDevopsUser != "" {
-			if err := events.WriteGitCreds(userConfig.AzureDevopsUser, userConfig.AzureDevopsToken, "dev.azure.com", home, logger, false); err != nil {
-				return nil, err
-			}
+		gitCredentials = append(gitCredentials, bitbucketGitCredentials)
+	}
+	if userConfig.AzureDevopsUser != "" {
+		gitCredentials = append(gitCredentials, events.GitCredentials{
+			URL:      "https://" + userConfig.AzureDevOpsHostname,
+			Username: userConfig.AzureDevopsUser,
+			Token:    events.StaticToken(userConfig.AzureDevopsToken),
+		})
+	}
+	if userConfig.GerritUser != "" {
+		gitCredentials = append(gitCredentials, events.GitCredentials{
+			URL:      userConfig.GerritBaseURL,
+			Username: userConfig.GerritUser,
+			Token:    events.StaticToken(userConfig.GerritToken),
+		})
+	}
+	// Steps only get the credentials if they're allowed to clone private
+	// modules with them.
+	var stepGitCredentials []events.GitCredentials
+	if userConfig.WriteGitCreds {
+		stepGitCredentials = gitCredentials
+	}
+
+	var sshCredentials *events.SSHCredentialsWriter
+	if userConfig.SSHClone {
+		sshCredentials, err = events.NewSSHCredentialsWriter(filepath.Join(userConfig.DataDir, "ssh"), userConfig.SSHKnownHostsFile, userConfig.SSHKeyFile, globalCfgStore, logger)
+		if err != nil {
+			return nil, errors.Wrap(err, "setting up ssh cloning")
 		}
 	}
 
@@ -321,6 +639,8 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			Event:          c.Event,
 			Kind:           c.Kind,
 			WorkspaceRegex: c.WorkspaceRegex,
+			Topic:          c.Topic,
+			QueueURL:       c.QueueURL,
 		}
 		webhooksConfig = append(webhooksConfig, config)
 	}
@@ -328,8 +648,50 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "initializing webhooks")
 	}
-	vcsClient := vcs.NewClientProxy(githubClient, gitlabClient, bitbucketCloudClient, bitbucketServerClient, azuredevopsClient)
-	commitStatusUpdater := &events.DefaultCommitStatusUpdater{Client: vcsClient, StatusName: userConfig.VCSStatusName}
+	vcsClient := vcs.NewClientProxy(githubClient, gitlabClient, bitbucketCloudClient, bitbucketServerClient, azuredevopsClient, gerritClient)
+	if gitlabClient != nil {
+		readinessChecks = append(readinessChecks, controllers.ReadinessCheck{Name: "gitlab", Check: gitlabClient.CheckHealth})
+	}
+	if bitbucketServerClient != nil {
+		readinessChecks = append(readinessChecks, controllers.ReadinessCheck{Name: "bitbucket-server", Check: bitbucketServerClient.CheckHealth})
+	}
+	if gerritClient != nil {
+		readinessChecks = append(readinessChecks, controllers.ReadinessCheck{Name: "gerrit", Check: gerritClient.CheckHealth})
+	}
+	// Tenants that set their own VCS credentials comment and update statuses
+	// as their own user.
+	tenantClients := &vcs.TenantClients{
+		GlobalCfg: globalCfgStore,
+		NewClient: func(repo models.Repo, user string, token string) (vcs.Client, error) {
+			switch repo.VCSHost.Type {
+			case models.Github:
+				rawClient, err := vcs.NewGithubClient(repo.VCSHost.Hostname, &vcs.GithubUserCredentials{
+					User:      user,
+					Token:     token,
+					Transport: tenantTransports[models.Github],
+				}, githubConfig, logger)
+				if err != nil {
+					return nil, err
+				}
+				return vcs.NewInstrumentedGithubClient(rawClient, statsScope, logger), nil
+			case models.Gitlab:
+				return vcs.NewGitlabClient(userConfig.GitlabHostname, token, tenantTransports[models.Gitlab], logger)
+			}
+			return nil, fmt.Errorf("tenant credentials aren't supported for %s", repo.VCSHost.Type.String())
+		},
+	}
+	vcsClient.SetRepoClients(tenantClients)
+	hostPullGetters := make(map[string]vcs.GithubPullRequestGetter)
+	for hostname, client := range githubHostClients {
+		vcsClient.AddHostClient(hostname, client)
+		hostPullGetters[hostname] = client
+	}
+	var githubPullGetter events.GithubPullGetter = &vcs.GithubPullRequestGetterProxy{
+		Default: githubClient,
+		Hosts:   hostPullGetters,
+		Repos:   tenantClients,
+	}
+	commitStatusUpdater.Client = vcsClient
 
 	binDir, err := mkSubDir(userConfig.DataDir, BinDirName)
 
@@ -355,22 +717,66 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		LockViewRouteIDQueryParam: LockViewRouteIDQueryParam,
 		LockViewRouteName:         LockViewRouteName,
 		ProjectJobsViewRouteName:  ProjectJobsViewRouteName,
+		ProjectGraphRouteName:     ProjectGraphRouteName,
 		Underlying:                underlyingRouter,
 	}
 
+	var backend locking.Backend
+
+	switch dbtype := userConfig.LockingDBType; dbtype {
+	case "redis":
+		logger.Info("Utilizing Redis DB")
+		backend, err = redis.New(userConfig.RedisHost, userConfig.RedisPort, userConfig.RedisPassword, userConfig.RedisTLSEnabled, userConfig.RedisInsecureSkipVerify, userConfig.RedisDB)
+		if err != nil {
+			return nil, err
+		}
+	case "boltdb":
+		logger.Info("Utilizing BoltDB")
+		backend, err = db.New(userConfig.DataDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Jobs are only persisted if they're kept for a limited time so that
+	// the locking database doesn't grow forever.
+	var jobStore jobs.JobStore
+	var jobRetention time.Duration
+	if userConfig.JobRetention != "" {
+		jobRetention, err = time.ParseDuration(userConfig.JobRetention)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing --job-retention")
+		}
+		jobStore = backend
+	}
+
 	var projectCmdOutputHandler jobs.ProjectCommandOutputHandler
+	// jobEvents are streamed by the gRPC API. Nil if the output of jobs isn't
+	// streamed.
+	var jobEvents *jobs.JobEvents
 
 	if userConfig.TFEToken != "" && !userConfig.TFELocalExecutionMode {
 		// When TFE is enabled and using remote execution mode log streaming is not necessary.
 		projectCmdOutputHandler = &jobs.NoopProjectOutputHandler{}
 	} else {
 		projectCmdOutput := make(chan *jobs.ProjectCmdOutputLine)
+		jobEvents = jobs.NewJobEvents()
 		projectCmdOutputHandler = jobs.NewAsyncProjectCommandOutputHandler(
 			projectCmdOutput,
 			logger,
+			jobStore,
+			userConfig.JobMaxLogBytes,
+			jobEvents,
 		)
 	}
+	// jobCanceller cancels running plans and applies for the cancel command,
+	// the API and the job page.
+	jobCanceller := jobs.NewJobCanceller(projectCmdOutputHandler, jobs.DefaultCancelGracePeriod)
 
+	tfDownloader, err := terraform.NewDownloader(userConfig.TFChecksumsFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading --tf-checksums-file")
+	}
 	terraformClient, err := terraform.NewClient(
 		logger,
 		binDir,
@@ -380,7 +786,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		userConfig.DefaultTFVersion,
 		config.DefaultTFVersionFlag,
 		userConfig.TFDownloadURL,
-		&terraform.DefaultDownloader{},
+		tfDownloader,
 		true,
 		projectCmdOutputHandler)
 	// The flag.Lookup call is to detect if we're running in a unit test. If we
@@ -389,6 +795,33 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	if err != nil && flag.Lookup("test.v") == nil {
 		return nil, errors.Wrap(err, "initializing terraform")
 	}
+
+	// tfExecutor runs the Terraform commands of projects, on an agent if
+	// their repo has an agent pool.
+	var tfExecutor agents.TerraformExec = terraformClient
+	var agentServer *grpc.Server
+	if userConfig.AgentPort != 0 {
+		agentDispatcher := agents.NewDispatcher(userConfig.AgentToken, logger)
+		tfExecutor = &agents.RemoteTerraformExecutor{
+			Local:         terraformClient,
+			Dispatcher:    agentDispatcher,
+			GlobalCfg:     globalCfgStore,
+			OutputHandler: projectCmdOutputHandler,
+			JobCanceller:  jobCanceller,
+		}
+		agentServer, err = newGRPCServer(userConfig, agents.ServerOptions()...)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading TLS certificate for agents")
+		}
+		agents.RegisterAgentsServer(agentServer, agentDispatcher)
+	}
+	var catalog *i18n.Catalog
+	if userConfig.Locale != "" {
+		catalog, err = i18n.NewCatalog(userConfig.Locale)
+		if err != nil {
+			return nil, err
+		}
+	}
 	markdownRenderer := &events.MarkdownRenderer{
 		GitlabSupportsCommonMark: gitlabClient.SupportsCommonMark(),
 		DisableApplyAll:          userConfig.DisableApplyAll,
@@ -396,57 +829,68 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		DisableApply:             userConfig.DisableApply,
 		DisableRepoLocking:       userConfig.DisableRepoLocking,
 		EnableDiffMarkdownFormat: userConfig.EnableDiffMarkdownFormat,
+		FilterPlanOutput:         userConfig.FilterPlanOutput,
+		JobURLGenerator:          router,
+		Catalog:                  catalog,
 	}
-
-	var lockingClient locking.Locker
-	var applyLockingClient locking.ApplyLocker
-	var backend locking.Backend
-
-	switch dbtype := userConfig.LockingDBType; dbtype {
-	case "redis":
-		logger.Info("Utilizing Redis DB")
-		backend, err = redis.New(userConfig.RedisHost, userConfig.RedisPort, userConfig.RedisPassword, userConfig.RedisTLSEnabled, userConfig.RedisInsecureSkipVerify, userConfig.RedisDB)
-		if err != nil {
-			return nil, err
-		}
-	case "boltdb":
-		logger.Info("Utilizing BoltDB")
-		backend, err = db.New(userConfig.DataDir)
+	if userConfig.MarkdownTemplateDir != "" {
+		markdownRenderer.TemplateOverrides, err = events.LoadMarkdownTemplateOverrides(userConfig.MarkdownTemplateDir)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	var lockingClient locking.Locker
+	var applyLockingClient locking.ApplyLocker
 	if userConfig.DisableRepoLocking {
 		logger.Info("Repo Locking is disabled")
 		lockingClient = locking.NewNoOpLocker()
 	} else {
 		lockingClient = locking.NewClient(backend)
 	}
+	var eventPublisher *cloudevents.Publisher
+	if userConfig.CloudEventsSinks != "" {
+		eventPublisher, err = cloudevents.NewPublisher(userConfig.AtlantisURL, strings.Split(userConfig.CloudEventsSinks, ","), logger)
+		if err != nil {
+			return nil, errors.Wrap(err, "initializing CloudEvents sinks")
+		}
+		lockingClient = &cloudevents.Locker{Locker: lockingClient, Emitter: eventPublisher}
+	}
 
 	applyLockingClient = locking.NewApplyClient(backend, userConfig.DisableApply)
+	freezeClient := locking.NewFreezeClient(backend)
 	workingDirLocker := events.NewDefaultWorkingDirLocker()
 
+	tenantCredentials := &events.TenantCredentials{GlobalCfg: globalCfgStore}
 	var workingDir events.WorkingDir = &events.FileWorkspace{
-		DataDir:          userConfig.DataDir,
-		CheckoutMerge:    userConfig.CheckoutStrategy == "merge",
-		GithubAppEnabled: githubAppEnabled,
+		DataDir:           userConfig.DataDir,
+		CheckoutMerge:     userConfig.CheckoutStrategy == "merge",
+		CheckoutDepth:     userConfig.CheckoutDepth,
+		CheckoutFilter:    userConfig.CheckoutFilter,
+		GithubAppEnabled:  githubAppEnabled,
+		GitEnvs:           gitEnvs,
+		SSHCredentials:    sshCredentials,
+		GitCredentials:    gitCredentials,
+		TenantCredentials: tenantCredentials,
+		GlobalCfg:         globalCfgStore,
 	}
-	// provide fresh tokens before clone from the GitHub Apps integration, proxy workingDir
-	if githubAppEnabled {
-		if !userConfig.WriteGitCreds {
-			return nil, errors.New("Github App requires --write-git-creds to support cloning")
-		}
+	// clone with the user that Github App tokens authenticate as, proxy workingDir
+	if githubAppEnabled && sshCredentials == nil {
 		workingDir = &events.GithubAppWorkingDir{
-			WorkingDir:     workingDir,
-			Credentials:    githubCredentials,
-			GithubHostname: userConfig.GithubHostname,
+			WorkingDir: workingDir,
 		}
 	}
 
+	var pullStacks *events.PullStacks
+	if githubPullStacks != nil {
+		pullStacks = &events.PullStacks{Client: githubPullStacks}
+	}
 	projectLocker := &events.DefaultProjectLocker{
-		Locker:    lockingClient,
-		VCSClient: vcsClient,
+		Locker:         lockingClient,
+		VCSClient:      vcsClient,
+		LockContention: backend,
+		PullStacks:     pullStacks,
+		Catalog:        catalog,
 	}
 	deleteLockCommand := &events.DefaultDeleteLockCommand{
 		Locker:           lockingClient,
@@ -467,26 +911,45 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			PullClosedTemplate:       &events.PullClosedEventTemplate{},
 			LogStreamResourceCleaner: projectCmdOutputHandler,
 			VCSClient:                vcsClient,
+			WorkspaceDeleter: &runtime.WorkspaceDeleter{
+				TerraformExecutor: terraformClient,
+			},
 		},
 	)
+	githubHostCredentials := make(map[string]vcs.GithubUserCredentials)
+	for _, host := range githubAdditionalHosts {
+		githubHostCredentials[host.Hostname] = vcs.GithubUserCredentials{User: host.User, Token: host.Token}
+	}
+	// When using access tokens, the clone URLs don't contain a token since
+	// tokens expire. git gets them from the credential helper instead.
+	bitbucketCloneUser, bitbucketCloneToken := userConfig.BitbucketUser, userConfig.BitbucketToken
+	if bitbucketTokenSource != nil {
+		bitbucketCloneUser, bitbucketCloneToken = bitbucketcloud.TokenAuthUser, ""
+	}
 	eventParser := &events.EventParser{
 		GithubUser:         userConfig.GithubUser,
 		GithubToken:        userConfig.GithubToken,
+		GithubHosts:        githubHostCredentials,
 		GitlabUser:         userConfig.GitlabUser,
 		GitlabToken:        userConfig.GitlabToken,
 		AllowDraftPRs:      userConfig.PlanDrafts,
-		BitbucketUser:      userConfig.BitbucketUser,
-		BitbucketToken:     userConfig.BitbucketToken,
+		BitbucketUser:      bitbucketCloneUser,
+		BitbucketToken:     bitbucketCloneToken,
 		BitbucketServerURL: userConfig.BitbucketBaseURL,
 		AzureDevopsUser:    userConfig.AzureDevopsUser,
 		AzureDevopsToken:   userConfig.AzureDevopsToken,
+		GerritUser:         userConfig.GerritUser,
+		GerritToken:        userConfig.GerritToken,
+		GerritURL:          userConfig.GerritBaseURL,
 	}
 	commentParser := &events.CommentParser{
 		GithubUser:      userConfig.GithubUser,
 		GitlabUser:      userConfig.GitlabUser,
 		BitbucketUser:   userConfig.BitbucketUser,
 		AzureDevopsUser: userConfig.AzureDevopsUser,
+		GerritUser:      userConfig.GerritUser,
 		ApplyDisabled:   userConfig.DisableApply,
+		Catalog:         catalog,
 	}
 	defaultTfVersion := terraformClient.DefaultVersion()
 	pendingPlanFinder := &events.DefaultPendingPlanFinder{}
@@ -496,34 +959,61 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		TerraformBinDir:         terraformClient.TerraformBinDir(),
 		ProjectCmdOutputHandler: projectCmdOutputHandler,
 	}
+	var oidcController *controllers.OIDCController
+	if oidcIssuer != nil {
+		oidcController = &controllers.OIDCController{Issuer: oidcIssuer}
+	}
 	drainer := &events.Drainer{}
 	statusController := &controllers.StatusController{
 		Logger:          logger,
 		Drainer:         drainer,
 		AtlantisVersion: config.AtlantisVersion,
 	}
+	readinessController := &controllers.ReadinessController{
+		Logger: logger,
+		Checks: append(readinessChecks,
+			controllers.ReadinessCheck{Name: "database", Check: backend.Ping},
+			controllers.ReadinessCheck{Name: "disk-space", Check: controllers.DiskSpaceCheck(userConfig.DataDir, uint64(userConfig.MinFreeDiskMB)<<20)},
+		),
+		Timeout: 10 * time.Second,
+	}
+	redactedConfig, secrets := userConfig.Redacted()
+	supportBundle := &controllers.SupportBundle{
+		AtlantisVersion: config.AtlantisVersion,
+		StartTime:       startTime,
+		Config:          redactedConfig,
+		Secrets:         secrets,
+		Readiness:       readinessController,
+		RecentErrors:    recentErrors,
+	}
 	preWorkflowHooksCommandRunner := &events.DefaultPreWorkflowHooksCommandRunner{
 		VCSClient:             vcsClient,
-		GlobalCfg:             globalCfg,
+		GlobalCfg:             globalCfgStore,
 		WorkingDirLocker:      workingDirLocker,
 		WorkingDir:            workingDir,
 		PreWorkflowHookRunner: runtime.DefaultPreWorkflowHookRunner{},
 	}
 	postWorkflowHooksCommandRunner := &events.DefaultPostWorkflowHooksCommandRunner{
 		VCSClient:              vcsClient,
-		GlobalCfg:              globalCfg,
+		GlobalCfg:              globalCfgStore,
 		WorkingDirLocker:       workingDirLocker,
 		WorkingDir:             workingDir,
 		PostWorkflowHookRunner: runtime.DefaultPostWorkflowHookRunner{},
 	}
+	// Stacked pull requests plan the projects modified anywhere in their
+	// stack.
+	var projectCmdBuilderVCSClient vcs.Client = vcsClient
+	if pullStacks != nil {
+		projectCmdBuilderVCSClient = &events.PullStackVCSClient{Client: vcsClient, Stacks: pullStacks}
+	}
 	projectCommandBuilder := events.NewInstrumentedProjectCommandBuilder(
 		policyChecksEnabled,
 		validator,
 		&events.DefaultProjectFinder{},
-		vcsClient,
+		projectCmdBuilderVCSClient,
 		workingDir,
 		workingDirLocker,
-		globalCfg,
+		globalCfgStore,
 		pendingPlanFinder,
 		commentParser,
 		userConfig.SkipCloneNoChanges,
@@ -533,45 +1023,132 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		logger,
 	)
 
-	showStepRunner, err := runtime.NewShowStepRunner(terraformClient, defaultTfVersion)
+	var commentOutputs []string
+	if userConfig.ApplyCommentOutputs != "" {
+		for _, name := range strings.Split(userConfig.ApplyCommentOutputs, ",") {
+			commentOutputs = append(commentOutputs, strings.TrimSpace(name))
+		}
+	}
+
+	showStepRunner, err := runtime.NewShowStepRunner(tfExecutor, defaultTfVersion)
 
 	if err != nil {
 		return nil, errors.Wrap(err, "initializing show step runner")
 	}
 
+	conftestExecutor := policy.NewConfTestExecutorWorkflow(logger, binDir, &terraform.DefaultDownloader{})
 	policyCheckRunner, err := runtime.NewPolicyCheckStepRunner(
 		defaultTfVersion,
-		policy.NewConfTestExecutorWorkflow(logger, binDir, &terraform.DefaultDownloader{}),
+		conftestExecutor,
 	)
 
 	if err != nil {
 		return nil, errors.Wrap(err, "initializing policy check runner")
 	}
 
+	var changeTickets changetickets.Client
+	if userConfig.JiraURL != "" {
+		changeTickets = changetickets.NewJira(userConfig.JiraURL, userConfig.JiraUser, userConfig.JiraToken, userConfig.JiraProject,
+			userConfig.JiraIssueType, userConfig.JiraApprovedStatus, userConfig.JiraCloseTransition)
+	}
+	if userConfig.ServiceNowURL != "" {
+		changeTickets = changetickets.NewServiceNow(userConfig.ServiceNowURL, userConfig.ServiceNowUser, userConfig.ServiceNowPassword)
+	}
 	applyRequirementHandler := &events.AggregateApplyRequirements{
-		WorkingDir: workingDir,
+		WorkingDir:    workingDir,
+		VCSClient:     vcsClient,
+		ChangeTickets: changeTickets,
+		Catalog:       catalog,
+	}
+
+	var maxPlanAge time.Duration
+	if userConfig.MaxPlanAge != "" {
+		maxPlanAge, err = time.ParseDuration(userConfig.MaxPlanAge)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing max plan age")
+		}
+	}
+
+	var secretsProvider events.SecretsProvider
+	if userConfig.SecretsDir != "" {
+		secretsProvider = &events.FileSecretsProvider{Dir: userConfig.SecretsDir}
+	}
+	var cloudCredentials events.CloudCredentialsProvider
+	if oidcIssuer != nil {
+		cloudCredentials = &events.OIDCCredentialsProvider{
+			Issuer:  oidcIssuer,
+			DataDir: userConfig.DataDir,
+		}
+	}
+
+	var planSigner events.PlanSigner
+	if userConfig.PlanSigningKey != "" {
+		planSigner = &events.HMACPlanSigner{Key: []byte(userConfig.PlanSigningKey)}
+	}
+
+	// Graphs are only linked from pull requests so unlike state snapshots
+	// they don't need an artifact store to be configured.
+	var artifactStore artifacts.Store = &artifacts.FileStore{Dir: filepath.Join(userConfig.DataDir, ArtifactsDirName)}
+	if userConfig.ArtifactStore != "" {
+		if artifactStore, err = artifacts.NewStore(userConfig.ArtifactStore); err != nil {
+			return nil, errors.Wrap(err, "initializing artifact store")
+		}
+	}
+	var snapshotStore artifacts.Store
+	var snapshotKey []byte
+	if userConfig.StateSnapshotKey != "" {
+		snapshotStore = artifactStore
+		if snapshotKey, err = artifacts.ParseKey(userConfig.StateSnapshotKey); err != nil {
+			return nil, errors.Wrap(err, "parsing state snapshot key")
+		}
+	}
+
+	// gitCloner clones repos for the commands that push to them.
+	gitCloner := events.GitCloner{
+		DataDir:           userConfig.DataDir,
+		GitEnvs:           gitEnvs,
+		SSHCredentials:    sshCredentials,
+		GitCredentials:    gitCredentials,
+		TenantCredentials: tenantCredentials,
+	}
+	promoter := &events.GitPromoter{
+		GitCloner:        gitCloner,
+		VCSClient:        vcsClient,
+		GithubAppEnabled: githubAppEnabled,
+	}
+
+	applyLimiter := events.NewApplyLimiter(userConfig.MaxConcurrentApplies)
+	applyLimiter.Reporter = &events.ApplyQueueCommenter{
+		VCSClient:   vcsClient,
+		ProjectRuns: backend,
 	}
 
 	projectCommandRunner := &events.DefaultProjectCommandRunner{
 		Locker:           projectLocker,
 		LockURLGenerator: router,
 		InitStepRunner: &runtime.InitStepRunner{
-			TerraformExecutor: terraformClient,
+			TerraformExecutor: tfExecutor,
+			DefaultTFVersion:  defaultTfVersion,
+		},
+		ValidateStepRunner: &runtime.ValidateStepRunner{
+			TerraformExecutor: tfExecutor,
 			DefaultTFVersion:  defaultTfVersion,
 		},
 		PlanStepRunner: &runtime.PlanStepRunner{
-			TerraformExecutor:   terraformClient,
+			TerraformExecutor:   tfExecutor,
 			DefaultTFVersion:    defaultTfVersion,
 			CommitStatusUpdater: commitStatusUpdater,
-			AsyncTFExec:         terraformClient,
+			AsyncTFExec:         tfExecutor,
 		},
 		ShowStepRunner:        showStepRunner,
 		PolicyCheckStepRunner: policyCheckRunner,
 		ApplyStepRunner: &runtime.ApplyStepRunner{
-			TerraformExecutor:   terraformClient,
+			TerraformExecutor:   tfExecutor,
 			DefaultTFVersion:    defaultTfVersion,
 			CommitStatusUpdater: commitStatusUpdater,
-			AsyncTFExec:         terraformClient,
+			AsyncTFExec:         tfExecutor,
+			StateLockRetries:    userConfig.StateLockRetries,
+			StateLockRetryDelay: stateLockRetryDelay,
 		},
 		RunStepRunner: runStepRunner,
 		EnvStepRunner: &runtime.EnvStepRunner{
@@ -581,13 +1158,58 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			RunStepRunner: runStepRunner,
 		},
 		VersionStepRunner: &runtime.VersionStepRunner{
-			TerraformExecutor: terraformClient,
+			TerraformExecutor:      tfExecutor,
+			DefaultTFVersion:       defaultTfVersion,
+			AtlantisVersion:        config.AtlantisVersion,
+			PolicyChecksEnabled:    policyChecksEnabled,
+			DefaultConftestVersion: conftestExecutor.DefaultConftestVersion,
+		},
+		ForceUnlockStateStepRunner: &runtime.ForceUnlockStateStepRunner{
+			TerraformExecutor: tfExecutor,
 			DefaultTFVersion:  defaultTfVersion,
 		},
 		WorkingDir:                 workingDir,
 		Webhooks:                   webhooksManager,
 		WorkingDirLocker:           workingDirLocker,
 		AggregateApplyRequirements: applyRequirementHandler,
+		VCSClient:                  vcsClient,
+		FreezeChecker:              freezeClient,
+		MaxPlanAge:                 maxPlanAge,
+		ApplyLimiter:               applyLimiter,
+		ChangeTickets:              changeTickets,
+		Promoter:                   promoter,
+		Reverter:                   &events.GitReverter{GitPromoter: promoter},
+		Inventory:                  backend,
+		ProjectRuns:                backend,
+		CheckRemoteStates:          userConfig.CheckRemoteStates,
+		OutputStepRunner: &runtime.OutputStepRunner{
+			TerraformExecutor: tfExecutor,
+			DefaultTFVersion:  defaultTfVersion,
+		},
+		CommentOutputs:    commentOutputs,
+		PlanSigner:        planSigner,
+		CloudCredentials:  cloudCredentials,
+		Secrets:           secretsProvider,
+		GitCredentials:    stepGitCredentials,
+		TenantCredentials: tenantCredentials,
+		SnapshotStore:     snapshotStore,
+		SnapshotKey:       snapshotKey,
+		StatePullStepRunner: &runtime.StatePullStepRunner{
+			TerraformExecutor: tfExecutor,
+			DefaultTFVersion:  defaultTfVersion,
+		},
+		GraphStore: artifactStore,
+		GraphStepRunner: &runtime.GraphStepRunner{
+			TerraformExecutor: tfExecutor,
+			DefaultTFVersion:  defaultTfVersion,
+		},
+		GraphURLGenerator: router,
+	}
+	if githubDeployments != nil {
+		projectCommandRunner.Deployer = &events.GithubDeployer{
+			Client:          githubDeployments,
+			JobURLGenerator: router,
+		}
 	}
 
 	dbUpdater := &events.DBUpdater{
@@ -596,8 +1218,14 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 
 	pullUpdater := &events.PullUpdater{
 		HidePrevPlanComments: userConfig.HidePrevPlanComments,
+		GlobalCfg:            globalCfgStore,
 		VCSClient:            vcsClient,
 		MarkdownRenderer:     markdownRenderer,
+		CommentReactions:     userConfig.EnableCommentReactions,
+		StatusComment:        userConfig.EnableStatusComment,
+		PlanSummaryComment:   userConfig.EnablePlanSummaryComment,
+		PullStatusFetcher:    backend,
+		JobURLGenerator:      router,
 	}
 
 	autoMerger := &events.AutoMerger{
@@ -605,13 +1233,84 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		GlobalAutomerge: userConfig.Automerge,
 	}
 
+	var applyHeartbeatInterval time.Duration
+	if userConfig.ApplyHeartbeatInterval != "" {
+		applyHeartbeatInterval, err = time.ParseDuration(userConfig.ApplyHeartbeatInterval)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing --apply-heartbeat-interval")
+		}
+	}
 	projectOutputWrapper := &events.ProjectOutputWrapper{
-		JobMessageSender:     projectCmdOutputHandler,
-		ProjectCommandRunner: projectCommandRunner,
-		JobURLSetter:         jobs.NewJobURLSetter(router, commitStatusUpdater),
+		JobMessageSender:       projectCmdOutputHandler,
+		ProjectCommandRunner:   projectCommandRunner,
+		JobURLSetter:           jobs.NewJobURLSetter(router, commitStatusUpdater),
+		ApplyHeartbeatInterval: applyHeartbeatInterval,
+		JobOutput:              projectCmdOutputHandler,
+		Canceller:              jobCanceller,
+	}
+	var outputProjectCmdRunner events.ProjectCommandRunner = projectOutputWrapper
+	if eventPublisher != nil {
+		outputProjectCmdRunner = &events.CloudEventsProjectCommandRunner{
+			ProjectCommandRunner: outputProjectCmdRunner,
+			Emitter:              eventPublisher,
+			JobURLGenerator:      router,
+		}
+	}
+	var analyticsSink *analytics.BatchSink
+	if userConfig.AnalyticsSink != "" {
+		warehouse, err := analytics.NewWarehouse(userConfig.AnalyticsSink)
+		if err != nil {
+			return nil, errors.Wrap(err, "initializing analytics sink")
+		}
+		flushInterval, err := time.ParseDuration(userConfig.AnalyticsFlushInterval)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing analytics flush interval")
+		}
+		analyticsSink = analytics.NewBatchSink(warehouse, userConfig.AnalyticsBatchSize, flushInterval, logger)
+		outputProjectCmdRunner = &events.AnalyticsProjectCommandRunner{
+			ProjectCommandRunner: outputProjectCmdRunner,
+			Recorder:             analyticsSink,
+		}
+	}
+	if userConfig.PagerDutyRoutingKey != "" || userConfig.OpsgenieAPIKey != "" {
+		notifier := &alerts.Notifier{Logger: logger}
+		if notifier.BranchRegex, err = regexp.Compile(userConfig.AlertApplyBranchRegex); err != nil {
+			return nil, errors.Wrap(err, "parsing alert apply branch regex")
+		}
+		if notifier.ProjectRegex, err = regexp.Compile(userConfig.AlertDriftProjectRegex); err != nil {
+			return nil, errors.Wrap(err, "parsing alert drift project regex")
+		}
+		if userConfig.PagerDutyRoutingKey != "" {
+			notifier.Alerters = append(notifier.Alerters, alerts.NewPagerDuty(userConfig.PagerDutyRoutingKey))
+		}
+		if userConfig.OpsgenieAPIKey != "" {
+			notifier.Alerters = append(notifier.Alerters, alerts.NewOpsgenie(userConfig.OpsgenieAPIKey, userConfig.OpsgenieURL))
+		}
+		outputProjectCmdRunner = &events.AlertingProjectCommandRunner{
+			ProjectCommandRunner: outputProjectCmdRunner,
+			Notifier:             notifier,
+		}
+	}
+	if userConfig.GrafanaURL != "" || userConfig.AnnotationWebhookURL != "" {
+		publisher := &annotations.Publisher{Logger: logger}
+		if userConfig.AnnotationEnvironmentRegex != "" {
+			if publisher.EnvironmentRegex, err = regexp.Compile(userConfig.AnnotationEnvironmentRegex); err != nil {
+				return nil, errors.Wrap(err, "parsing annotation environment regex")
+			}
+		}
+		if userConfig.GrafanaURL != "" {
+			publisher.Annotators = append(publisher.Annotators, annotations.NewGrafana(userConfig.GrafanaURL, userConfig.GrafanaAPIKey))
+		}
+		if userConfig.AnnotationWebhookURL != "" {
+			publisher.Annotators = append(publisher.Annotators, annotations.NewWebhook(userConfig.AnnotationWebhookURL))
+		}
+		outputProjectCmdRunner = &events.AnnotatingProjectCommandRunner{
+			ProjectCommandRunner: outputProjectCmdRunner,
+			Publisher:            publisher,
+		}
 	}
 	instrumentedProjectCmdRunner := &events.InstrumentedProjectCommandRunner{
-		ProjectCommandRunner: projectOutputWrapper,
+		ProjectCommandRunner: outputProjectCmdRunner,
 	}
 
 	policyCheckCommandRunner := events.NewPolicyCheckCommandRunner(
@@ -641,6 +1340,9 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		backend,
 		lockingClient,
 	)
+	if userConfig.ScanSecrets {
+		planCommandRunner.SecretScanner = &events.SecretScanner{GitPromoter: promoter}
+	}
 
 	pullReqStatusFetcher := vcs.NewPullReqStatusFetcher(vcsClient)
 	applyCommandRunner := events.NewApplyCommandRunner(
@@ -660,6 +1362,8 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		userConfig.VCSStatusName,
 		pullReqStatusFetcher,
 	)
+	applyCommandRunner.GlobalCfg = globalCfgStore
+	applyCommandRunner.PullStacks = pullStacks
 
 	approvePoliciesCommandRunner := events.NewApprovePoliciesCommandRunner(
 		commitStatusUpdater,
@@ -675,6 +1379,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		deleteLockCommand,
 		vcsClient,
 		userConfig.SilenceNoProjects,
+		catalog,
 	)
 
 	versionCommandRunner := events.NewVersionCommandRunner(
@@ -685,12 +1390,71 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		userConfig.SilenceNoProjects,
 	)
 
+	stateLockAdminTeams := splitList(userConfig.StateLockAdminTeams)
+	forceUnlockStateCommandRunner := events.NewForceUnlockStateCommandRunner(
+		vcsClient,
+		pullUpdater,
+		projectCommandBuilder,
+		instrumentedProjectCmdRunner,
+		stateLockAdminTeams,
+	)
+
+	initConfigCommandRunner := events.NewInitConfigCommandRunner(
+		vcsClient,
+		&events.GitConfigInitializer{
+			GitCloner: gitCloner,
+			VCSClient: vcsClient,
+		},
+	)
+
+	// The autoplanner is set once the command runner that plans pull
+	// requests is created below.
+	revertCommandRunner := events.NewRevertCommandRunner(
+		vcsClient,
+		&events.GitPullReverter{GitPromoter: promoter},
+		nil,
+	)
+
+	fmtCommandRunner := events.NewFmtCommandRunner(
+		vcsClient,
+		&events.GitFormatter{
+			GitCloner:         gitCloner,
+			TerraformExecutor: terraformClient,
+		},
+		userConfig.EnableFmtFix,
+		userConfig.AutoplanFmt == "fix",
+	)
+
+	providersLockCommandRunner := events.NewProvidersLockCommandRunner(
+		vcsClient,
+		&events.GitProvidersLocker{
+			GitCloner:         gitCloner,
+			VCSClient:         vcsClient,
+			TerraformExecutor: terraformClient,
+			Platforms:         splitList(userConfig.ProvidersLockPlatforms),
+		},
+	)
+
+	approveResourcesCommandRunner := events.NewApproveResourcesCommandRunner(
+		vcsClient,
+		workingDir,
+		pendingPlanFinder,
+		globalCfgStore,
+	)
+
 	commentCommandRunnerByCmd := map[command.Name]events.CommentCommandRunner{
-		command.Plan:            planCommandRunner,
-		command.Apply:           applyCommandRunner,
-		command.ApprovePolicies: approvePoliciesCommandRunner,
-		command.Unlock:          unlockCommandRunner,
-		command.Version:         versionCommandRunner,
+		command.Plan:             planCommandRunner,
+		command.Apply:            applyCommandRunner,
+		command.ApprovePolicies:  approvePoliciesCommandRunner,
+		command.Unlock:           unlockCommandRunner,
+		command.Version:          versionCommandRunner,
+		command.ForceUnlockState: forceUnlockStateCommandRunner,
+		command.InitConfig:       initConfigCommandRunner,
+		command.ApproveResources: approveResourcesCommandRunner,
+		command.Fmt:              fmtCommandRunner,
+		command.ProvidersLock:    providersLockCommandRunner,
+		command.Cancel:           events.NewCancelCommandRunner(vcsClient, jobCanceller),
+		command.Revert:           revertCommandRunner,
 	}
 
 	githubTeamAllowlistChecker, err := events.NewTeamAllowlistChecker(userConfig.GithubTeamAllowlist)
@@ -704,13 +1468,13 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 
 	commandRunner := &events.DefaultCommandRunner{
 		VCSClient:                      vcsClient,
-		GithubPullGetter:               githubClient,
+		GithubPullGetter:               githubPullGetter,
 		GitlabMergeRequestGetter:       gitlabClient,
 		AzureDevopsPullGetter:          azuredevopsClient,
 		CommentCommandRunnerByCmd:      commentCommandRunnerByCmd,
 		EventParser:                    eventParser,
 		Logger:                         logger,
-		GlobalCfg:                      globalCfg,
+		GlobalCfg:                      globalCfgStore,
 		StatsScope:                     statsScope.SubScope("cmd"),
 		AllowForkPRs:                   userConfig.AllowForkPRs,
 		AllowForkPRsFlag:               config.AllowForkPRsFlag,
@@ -723,16 +1487,35 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		PullStatusFetcher:              backend,
 		TeamAllowlistChecker:           githubTeamAllowlistChecker,
 		VarFileAllowlistChecker:        varFileAllowlistChecker,
+		CommentReactions:               userConfig.EnableCommentReactions,
+		DebugAdminTeams:                splitList(userConfig.DebugAdminTeams),
+		Catalog:                        catalog,
+		ForcePushInvalidator: &events.ForcePushInvalidator{
+			WorkingDir:        workingDir,
+			WorkingDirLocker:  workingDirLocker,
+			PendingPlanFinder: pendingPlanFinder,
+			Backend:           backend,
+			VCSClient:         vcsClient,
+		},
 	}
+	if userConfig.AutoplanFmt != "" {
+		commandRunner.AutoplanFmtChecker = fmtCommandRunner
+	}
+	if userConfig.CancelSupersededPlans {
+		commandRunner.SupersededPlanCanceller = jobCanceller
+	}
+	revertCommandRunner.Autoplanner = commandRunner
 	repoAllowlist, err := events.NewRepoAllowlistChecker(userConfig.RepoAllowlist)
 	if err != nil {
 		return nil, err
 	}
+	repoAllowlist.GlobalCfg = globalCfgStore
 	locksController := &controllers.LocksController{
 		AtlantisVersion:    config.AtlantisVersion,
 		AtlantisURL:        parsedURL,
 		Locker:             lockingClient,
 		ApplyLocker:        applyLockingClient,
+		Freezer:            freezeClient,
 		Logger:             logger,
 		VCSClient:          vcsClient,
 		LockDetailTemplate: templates.LockTemplate,
@@ -758,10 +1541,14 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		WsMux:                    wsMux,
 		KeyGenerator:             controllers.JobIDKeyGenerator{},
 		StatsScope:               statsScope.SubScope("api"),
+		LogRegistry:              projectCmdOutputHandler,
+		JobCanceller:             jobCanceller,
+		Graphs:                   artifactStore,
 	}
 	apiController := &controllers.APIController{
 		APISecret:                 []byte(userConfig.APISecret),
 		Locker:                    lockingClient,
+		Freezer:                   freezeClient,
 		Logger:                    logger,
 		Parser:                    eventParser,
 		ProjectCommandBuilder:     projectCommandBuilder,
@@ -770,13 +1557,50 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		RepoAllowlistChecker:      repoAllowlist,
 		Scope:                     statsScope.SubScope("api"),
 		VCSClient:                 vcsClient,
+		WorkingDir:                workingDir,
+		ShowStepRunner:            showStepRunner,
+		Inventory:                 backend,
+		ProjectRuns:               backend,
+		LockContention:            backend,
+		StatusBadges:              userConfig.EnableStatusBadges,
+		ParserValidator:           validator,
+		GlobalCfg:                 globalCfgStore,
+		AutoplanFileList:          userConfig.AutoplanFileList,
+		SupportBundle:             supportBundle,
+		WorkingDirLocker:          workingDirLocker,
+		Drainer:                   drainer,
+		JobCanceller:              jobCanceller,
+		CommitStatusUpdater:       commitStatusUpdater,
+		PolicyChecksEnabled:       userConfig.EnablePolicyChecksFlag,
+	}
+	// A nil proxy would be a non-nil interface.
+	if githubBranchProtection != nil {
+		apiController.BranchProtection = githubBranchProtection
+	}
+	var apiGRPCServer *grpc.Server
+	if userConfig.GRPCAPIPort != 0 {
+		apiGRPCServer, err = newGRPCServer(userConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading TLS certificate for the gRPC API")
+		}
+		controllers.RegisterAPIGRPCServer(apiGRPCServer, &controllers.APIGRPCServer{
+			API:       apiController,
+			JobEvents: jobEvents,
+		})
 	}
 
+	repoHelp := &events.RepoHelpBuilder{
+		GlobalCfg:        globalCfgStore,
+		WorkingDir:       workingDir,
+		WorkingDirLocker: workingDirLocker,
+		ParserValidator:  validator,
+	}
 	eventsController := &events_controllers.VCSEventsController{
 		CommandRunner:                   commandRunner,
 		PullCleaner:                     pullClosedExecutor,
 		Parser:                          eventParser,
 		CommentParser:                   commentParser,
+		RepoHelp:                        repoHelp,
 		Logger:                          logger,
 		Scope:                           statsScope,
 		ApplyDisabled:                   userConfig.DisableApply,
@@ -792,6 +1616,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		AzureDevopsWebhookBasicUser:     []byte(userConfig.AzureDevopsWebhookUser),
 		AzureDevopsWebhookBasicPassword: []byte(userConfig.AzureDevopsWebhookPassword),
 		AzureDevopsRequestValidator:     &events_controllers.DefaultAzureDevopsRequestValidator{},
+		GerritWebhookSecret:             []byte(userConfig.GerritWebhookSecret),
 	}
 	githubAppController := &controllers.GithubAppController{
 		AtlantisURL:         parsedURL,
@@ -800,9 +1625,85 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		GithubHostname:      userConfig.GithubHostname,
 		GithubOrg:           userConfig.GithubOrg,
 	}
+	// Only repo config files are reloaded since --repo-config-json can't
+	// change while Atlantis is running. Config in a git repo is synced every
+	// minute unless a different interval is set.
+	var repoConfigReload scheduled.Job
+	var repoConfigReloadInterval time.Duration
+	if userConfig.RepoConfigReloadInterval != "" && len(repoConfigFiles) > 0 {
+		repoConfigReloadInterval, err = time.ParseDuration(userConfig.RepoConfigReloadInterval)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing --repo-config-reload-interval")
+		}
+		repoConfigReload = scheduled.NewRepoConfigReload(repoConfigFiles, loadGlobalCfg, globalCfgStore, logger)
+	}
+	if repoConfigGitSync != nil {
+		if repoConfigReloadInterval == 0 {
+			repoConfigReloadInterval = time.Minute
+		}
+		repoConfigGitSync.Reload = scheduled.NewRepoConfigReload(repoConfigFiles, loadGlobalCfg, globalCfgStore, logger)
+		repoConfigReload = repoConfigGitSync
+	}
+
+	var gitlabWebhookSync scheduled.Job
+	if userConfig.GitlabWebhookGroups != "" {
+		gitlabWebhookSync = &scheduled.GitlabWebhookSync{
+			Client:  gitlabClient,
+			Groups:  strings.Split(userConfig.GitlabWebhookGroups, ","),
+			HookURL: parsedURL.String() + "/events",
+			Token:   userConfig.GitlabWebhookSecret,
+			IsAllowlisted: func(project string) bool {
+				return repoAllowlist.IsAllowlisted(project, userConfig.GitlabHostname)
+			},
+			Log: logger,
+		}
+	}
+
+	var webhookProvisioning scheduled.Job
+	if len(webhookProvisioningHosts) > 0 {
+		webhookProvisioning = &scheduled.WebhookProvisioning{
+			Hosts:     webhookProvisioningHosts,
+			HookURL:   parsedURL.String() + "/events",
+			Allowlist: repoAllowlist,
+			Log:       logger,
+		}
+	}
+
+	var dependencyUpgrades scheduled.Job
+	var dependencyUpgradeInterval time.Duration
+	if userConfig.DependencyUpgradeRepos != "" {
+		repos, err := dependencyUpgradeRepos(userConfig, githubAppEnabled)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing --dependency-upgrade-repos")
+		}
+		dependencyUpgradeInterval, err = time.ParseDuration(userConfig.DependencyUpgradeInterval)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing --dependency-upgrade-interval")
+		}
+		dependencyUpgrades = &scheduled.DependencyUpgrades{
+			Upgrader: &events.GitDependencyUpgrader{
+				GitCloner:         gitCloner,
+				VCSClient:         vcsClient,
+				TerraformExecutor: terraformClient,
+				Registry:          &events.HTTPTerraformRegistry{},
+				Platforms:         splitList(userConfig.ProvidersLockPlatforms),
+			},
+			Repos: repos,
+			Log:   logger,
+		}
+	}
+
 	scheduledExecutorService := scheduled.NewExecutorService(
 		statsScope,
 		logger,
+		jobStore,
+		jobRetention,
+		repoConfigReload,
+		repoConfigReloadInterval,
+		gitlabWebhookSync,
+		webhookProvisioning,
+		dependencyUpgrades,
+		dependencyUpgradeInterval,
 	)
 
 	return &Server{
@@ -819,12 +1720,15 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		StatsCloser:                    closer,
 		Locker:                         lockingClient,
 		ApplyLocker:                    applyLockingClient,
+		Freezer:                        freezeClient,
 		VCSEventsController:            eventsController,
 		GithubAppController:            githubAppController,
 		LocksController:                locksController,
 		JobsController:                 jobsController,
 		StatusController:               statusController,
+		ReadinessController:            readinessController,
 		APIController:                  apiController,
+		OIDCController:                 oidcController,
 		IndexTemplate:                  templates.IndexTemplate,
 		LockDetailTemplate:             templates.LockTemplate,
 		ProjectJobsTemplate:            templates.ProjectJobsTemplate,
@@ -837,6 +1741,13 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		WebUsername:                    userConfig.WebUsername,
 		WebPassword:                    userConfig.WebPassword,
 		ScheduledExecutorService:       scheduledExecutorService,
+		AgentServer:                    agentServer,
+		AgentPort:                      userConfig.AgentPort,
+		APIGRPCServer:                  apiGRPCServer,
+		APIGRPCPort:                    userConfig.GRPCAPIPort,
+		ProjectRuns:                    backend,
+		LockContention:                 backend,
+		AnalyticsSink:                  analyticsSink,
 	}, nil
 }
 
@@ -846,24 +1757,34 @@ func (s *Server) Start() error {
 		return r.URL.Path == "/" || r.URL.Path == "/index.html"
 	})
 	s.Router.HandleFunc("/healthz", s.Healthz).Methods("GET")
+	s.Router.HandleFunc("/readyz", s.ReadinessController.Get).Methods("GET")
 	s.Router.HandleFunc("/status", s.StatusController.Get).Methods("GET")
 	s.Router.PathPrefix("/static/").Handler(http.FileServer(&assetfs.AssetFS{Asset: static.Asset, AssetDir: static.AssetDir, AssetInfo: static.AssetInfo}))
 	s.Router.HandleFunc("/events", s.VCSEventsController.Post).Methods("POST")
-	s.Router.HandleFunc("/api/plan", s.APIController.Plan).Methods("POST")
-	s.Router.HandleFunc("/api/apply", s.APIController.Apply).Methods("POST")
+	s.APIController.RegisterRoutes(s.Router)
+	if s.OIDCController != nil {
+		s.Router.HandleFunc(oidc.DiscoveryPath, s.OIDCController.Discovery).Methods("GET")
+		s.Router.HandleFunc(oidc.JWKSPath, s.OIDCController.JWKS).Methods("GET")
+	}
 	s.Router.HandleFunc("/github-app/exchange-code", s.GithubAppController.ExchangeCode).Methods("GET")
 	s.Router.HandleFunc("/github-app/setup", s.GithubAppController.New).Methods("GET")
 	s.Router.HandleFunc("/apply/lock", s.LocksController.LockApply).Methods("POST").Queries()
 	s.Router.HandleFunc("/apply/unlock", s.LocksController.UnlockApply).Methods("DELETE").Queries()
+	s.Router.HandleFunc("/freeze", s.LocksController.Freeze).Methods("POST")
+	s.Router.HandleFunc("/unfreeze", s.LocksController.Unfreeze).Methods("DELETE")
 	s.Router.HandleFunc("/locks", s.LocksController.DeleteLock).Methods("DELETE").Queries("id", "{id:.*}")
 	s.Router.HandleFunc("/lock", s.LocksController.GetLock).Methods("GET").
 		Queries(LockViewRouteIDQueryParam, fmt.Sprintf("{%s}", LockViewRouteIDQueryParam)).Name(LockViewRouteName)
 	s.Router.HandleFunc("/jobs/{job-id}", s.JobsController.GetProjectJobs).Methods("GET").Name(ProjectJobsViewRouteName)
 	s.Router.HandleFunc("/jobs/{job-id}/ws", s.JobsController.GetProjectJobsWS).Methods("GET")
+	s.Router.HandleFunc("/jobs/{job-id}/logs", s.JobsController.GetProjectJobLogs).Methods("GET")
+	s.Router.HandleFunc("/jobs/{job-id}/graph.svg", s.JobsController.GetProjectGraph).Methods("GET").Name(ProjectGraphRouteName)
+	s.Router.HandleFunc("/jobs/{job-id}/logs/stream", s.JobsController.TailProjectJobLogs).Methods("GET")
+	s.Router.HandleFunc("/jobs/{job-id}/cancel", s.JobsController.CancelProjectJob).Methods("POST")
 
 	r, ok := s.StatsReporter.(prometheus.Reporter)
 	if ok {
-		s.Router.Handle(s.CommandRunner.GlobalCfg.Metrics.Prometheus.Endpoint, r.HTTPHandler())
+		s.Router.Handle(s.CommandRunner.GlobalCfg.Get().Metrics.Prometheus.Endpoint, r.HTTPHandler())
 	}
 
 	n := negroni.New(&negroni.Recovery{
@@ -882,6 +1803,9 @@ func (s *Server) Start() error {
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
 	go s.ScheduledExecutorService.Run()
+	if s.AnalyticsSink != nil {
+		s.AnalyticsSink.Start()
+	}
 
 	go func() {
 		s.ProjectCmdOutputHandler.Handle()
@@ -902,10 +1826,34 @@ func (s *Server) Start() error {
 			s.Logger.Err(err.Error())
 		}
 	}()
+	if s.AgentServer != nil {
+		if err := s.serveGRPC(s.AgentServer, s.AgentPort, "agents"); err != nil {
+			return err
+		}
+	}
+	if s.APIGRPCServer != nil {
+		if err := s.serveGRPC(s.APIGRPCServer, s.APIGRPCPort, "gRPC API requests"); err != nil {
+			return err
+		}
+	}
 	<-stop
 
 	s.Logger.Warn("Received interrupt. Waiting for in-progress operations to complete")
 	s.waitForDrain()
+	if s.AgentServer != nil {
+		// Agents are waiting for jobs, not running them, once all operations
+		// are complete so they don't need to be drained.
+		s.AgentServer.Stop()
+	}
+	if s.APIGRPCServer != nil {
+		// Stop waits for in-progress calls but WatchJobs streams only end when
+		// their clients disconnect.
+		s.APIGRPCServer.Stop()
+	}
+	if s.AnalyticsSink != nil {
+		// Load the records of the operations that just completed.
+		s.AnalyticsSink.Close()
+	}
 
 	// flush stats before shutdown
 	if err := s.StatsCloser.Close(); err != nil {
@@ -938,6 +1886,69 @@ func (s *Server) waitForDrain() {
 	}
 }
 
+// serveGRPC serves srv on port in the background. name is what srv serves,
+// for the logs.
+func (s *Server) serveGRPC(srv *grpc.Server, port int, name string) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return errors.Wrapf(err, "listening for %s", name)
+	}
+	go func() {
+		s.Logger.Info("listening for %s on port %v", name, port)
+		if err := srv.Serve(lis); err != nil {
+			s.Logger.Err(err.Error())
+		}
+	}()
+	return nil
+}
+
+// newGRPCServer returns a gRPC server with opts that uses the server's TLS
+// certificate if it has one.
+func newGRPCServer(userConfig UserConfig, opts ...grpc.ServerOption) (*grpc.Server, error) {
+	if userConfig.SSLCertFile != "" && userConfig.SSLKeyFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(userConfig.SSLCertFile, userConfig.SSLKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	return grpc.NewServer(opts...), nil
+}
+
+// Diagnose is run by `atlantis server --diagnose` instead of Start. It prints
+// the results of the readiness checks and writes a support bundle to the
+// current directory. It returns an error if any of the checks failed.
+func (s *Server) Diagnose() error {
+	now := time.Now()
+	filename := controllers.SupportBundleFilename(now)
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.Wrap(err, "creating support bundle")
+	}
+	checks, err := s.APIController.SupportBundle.Write(f, now)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "writing support bundle")
+	}
+
+	failed := 0
+	for _, c := range checks.Checks {
+		if c.Error != "" {
+			failed++
+			fmt.Printf("%-7s %s (%dms): %s\n", c.Status, c.Name, c.DurationMS, c.Error)
+		} else {
+			fmt.Printf("%-7s %s (%dms)\n", c.Status, c.Name, c.DurationMS)
+		}
+	}
+	fmt.Printf("Wrote support bundle to %s\n", filename)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks.Checks))
+	}
+	return nil
+}
+
 // Index is the / route.
 func (s *Server) Index(w http.ResponseWriter, _ *http.Request) {
 	locks, err := s.Locker.List()
@@ -979,9 +1990,42 @@ func (s *Server) Index(w http.ResponseWriter, _ *http.Request) {
 	//Sort by date - newest to oldest.
 	sort.SliceStable(lockResults, func(i, j int) bool { return lockResults[i].Time.After(lockResults[j].Time) })
 
+	freezes, err := s.Freezer.ListFreezes()
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "Could not retrieve freezes: %s", err)
+		return
+	}
+	var freezeResults []templates.FreezeIndexData
+	for _, f := range freezes {
+		freezeResults = append(freezeResults, templates.FreezeIndexData{
+			RepoFullName:  f.RepoFullName,
+			Project:       f.Project,
+			Message:       f.Message,
+			TimeFormatted: f.Time.Format("02-01-2006 15:04:05"),
+		})
+	}
+
+	slowestProjects, err := s.slowestProjects()
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "Could not retrieve project runs: %s", err)
+		return
+	}
+
+	lockContention, err := s.lockContention(time.Now())
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "Could not retrieve lock contention: %s", err)
+		return
+	}
+
 	err = s.IndexTemplate.Execute(w, templates.IndexData{
 		Locks:           lockResults,
 		ApplyLock:       applyLockData,
+		Freezes:         freezeResults,
+		SlowestProjects: slowestProjects,
+		LockContention:  lockContention,
 		AtlantisVersion: s.AtlantisVersion,
 		CleanedBasePath: s.AtlantisURL.Path,
 	})
@@ -990,6 +2034,108 @@ func (s *Server) Index(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
+// maxSlowestProjects is how many of the slowest projects the index page
+// shows.
+const maxSlowestProjects = 10
+
+// slowestProjects returns the projects whose plans or applies take the
+// longest for the index page.
+func (s *Server) slowestProjects() ([]templates.ProjectDurationsIndexData, error) {
+	if s.ProjectRuns == nil {
+		return nil, nil
+	}
+	runs, err := s.ProjectRuns.ListProjectRuns()
+	if err != nil {
+		return nil, err
+	}
+	formatDuration := func(d time.Duration) string {
+		if d == 0 {
+			return ""
+		}
+		return d.Round(time.Second).String()
+	}
+	var slowest []templates.ProjectDurationsIndexData
+	for _, status := range models.SortBySlowest(models.NewProjectRunStatuses(runs)) {
+		if len(slowest) == maxSlowestProjects {
+			break
+		}
+		project := status.ProjectName
+		if project == "" {
+			project = status.Path
+		}
+		plan, apply := status.PlanDurations(), status.ApplyDurations()
+		slowest = append(slowest, templates.ProjectDurationsIndexData{
+			RepoFullName:  status.RepoFullName,
+			Project:       project,
+			Workspace:     status.Workspace,
+			PlanP50:       formatDuration(plan.P50),
+			PlanP95:       formatDuration(plan.P95),
+			ApplyP50:      formatDuration(apply.P50),
+			ApplyP95:      formatDuration(apply.P95),
+			GettingSlower: plan.GettingSlower() || apply.GettingSlower(),
+		})
+	}
+	return slowest, nil
+}
+
+// maxContendedLocks is how many of the most contended locks the index page
+// shows.
+const maxContendedLocks = 10
+
+// lockContention returns the heatmap of the locks that blocked pull requests
+// the longest in the last two weeks for the index page.
+func (s *Server) lockContention(now time.Time) (templates.LockContentionIndexData, error) {
+	data := templates.LockContentionIndexData{}
+	if s.LockContention == nil {
+		return data, nil
+	}
+	contention, err := s.LockContention.ListLockContention()
+	if err != nil {
+		return data, err
+	}
+	days := controllers.DefaultLockContentionDays
+	for i := days - 1; i >= 0; i-- {
+		data.Days = append(data.Days, now.AddDate(0, 0, -i).Format("Jan 2"))
+	}
+	since := now.AddDate(0, 0, -days)
+	for _, c := range models.SortByContention(contention, since, now) {
+		if len(data.Locks) == maxContendedLocks {
+			break
+		}
+		blocked, waited := c.Waited(since, now)
+		lock := templates.ContendedLockIndexData{
+			RepoFullName: c.RepoFullName,
+			Path:         c.Path,
+			Workspace:    c.Workspace,
+			Blocked:      blocked,
+			Waited:       waited.Round(time.Minute).String(),
+			BlockedNow:   len(c.BlockedPulls()),
+		}
+		for i, n := range c.BlockedPerDay(now, days) {
+			lock.Days = append(lock.Days, templates.LockContentionDayIndexData{
+				Day:     data.Days[i],
+				Blocked: n,
+				Heat:    lockContentionHeat(n),
+			})
+		}
+		data.Locks = append(data.Locks, lock)
+	}
+	return data, nil
+}
+
+// lockContentionHeat returns the shade, from 0 to 4, of a heatmap cell where
+// blocked pull requests waited for a lock.
+func lockContentionHeat(blocked int) int {
+	switch {
+	case blocked >= 5:
+		return 4
+	case blocked >= 3:
+		return 3
+	default:
+		return blocked
+	}
+}
+
 func mkSubDir(parentDir string, subDir string) (string, error) {
 	fullDir := filepath.Join(parentDir, subDir)
 	if err := os.MkdirAll(fullDir, 0700); err != nil {
@@ -999,7 +2145,9 @@ func mkSubDir(parentDir string, subDir string) (string, error) {
 	return fullDir, nil
 }
 
-// Healthz returns the health check response. It always returns a 200 currently.
+// Healthz returns the liveness check response. It always returns a 200
+// currently, see ReadinessController for the checks of Atlantis's
+// dependencies.
 func (s *Server) Healthz(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(healthzData) // nolint: errcheck
@@ -1026,3 +2174,140 @@ func ParseAtlantisURL(u string) (*url.URL, error) {
 	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
 	return parsed, nil
 }
+
+// splitList splits a comma separated list, ex. the value of
+// --state-lock-admin-teams.
+func splitList(list string) []string {
+	var split []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			split = append(split, item)
+		}
+	}
+	return split
+}
+
+// dependencyUpgradeRepos returns the repos in --dependency-upgrade-repos.
+// Pull requests can only be opened on GitHub and GitLab. The clone URLs don't
+// have a token since git gets it from the credential helper.
+func dependencyUpgradeRepos(userConfig UserConfig, githubAppEnabled bool) ([]models.Repo, error) {
+	var repos []models.Repo
+	for _, entry := range splitList(userConfig.DependencyUpgradeRepos) {
+		i := strings.Index(entry, "/")
+		hostname, fullName := entry[:i], entry[i+1:]
+		var vcsHostType models.VCSHostType
+		var user string
+		switch {
+		case hostname == userConfig.GithubHostname && githubAppEnabled:
+			vcsHostType, user = models.Github, "x-access-token"
+		case hostname == userConfig.GithubHostname && userConfig.GithubUser != "":
+			vcsHostType, user = models.Github, userConfig.GithubUser
+		case hostname == userConfig.GitlabHostname && userConfig.GitlabUser != "":
+			vcsHostType, user = models.Gitlab, userConfig.GitlabUser
+		default:
+			return nil, fmt.Errorf("%s isn't on the configured GitHub or GitLab host", entry)
+		}
+		repo, err := models.NewRepo(vcsHostType, fullName, "https://"+entry, user, "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", entry)
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+// ValidateRepoConfig parses the server-side repo config like NewServer does
+// without starting the server, for `atlantis server --validate-config`. If
+// --repo-config-git is set, its files are read relative to the current
+// directory, ex. in the CI of the config repo. It returns warnings about
+// config that's valid but can't take effect.
+func ValidateRepoConfig(userConfig UserConfig, config Config) ([]string, error) {
+	validator := newRepoConfigValidator(userConfig)
+	defaultCfg := valid.NewGlobalCfgFromArgs(newGlobalCfgArgs(userConfig))
+	repoConfigFiles := splitRepoConfigFiles(userConfig.RepoConfig)
+	if len(repoConfigFiles) == 0 && userConfig.RepoConfigGit != "" {
+		repoConfigFiles = []string{"repos.yaml"}
+	}
+
+	var globalCfg valid.GlobalCfg
+	var err error
+	switch {
+	case userConfig.RepoConfigJSON != "":
+		globalCfg, err = validator.ParseGlobalCfgJSON(userConfig.RepoConfigJSON, defaultCfg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing --%s", config.RepoConfigJSONFlag)
+		}
+	case len(repoConfigFiles) > 0:
+		globalCfg, err = validator.ParseGlobalCfgs(repoConfigFiles, defaultCfg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s file", strings.Join(repoConfigFiles, ","))
+		}
+	default:
+		return nil, fmt.Errorf("there's no server-side repo config to validate, set --repo-config or --%s", config.RepoConfigJSONFlag)
+	}
+
+	var allowlist []string
+	for _, rule := range strings.Split(userConfig.RepoAllowlist, ",") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			allowlist = append(allowlist, rule)
+		}
+	}
+	return cfg.LintGlobalCfg(globalCfg, allowlist), nil
+}
+
+// newRepoConfigValidator returns the parser for the server-side repo config.
+func newRepoConfigValidator(userConfig UserConfig) *cfg.ParserValidator {
+	validator := &cfg.ParserValidator{}
+	if userConfig.RepoConfigEnvAllowlist != "" {
+		for _, name := range strings.Split(userConfig.RepoConfigEnvAllowlist, ",") {
+			validator.EnvAllowlist = append(validator.EnvAllowlist, strings.TrimSpace(name))
+		}
+	}
+	return validator
+}
+
+// newGlobalCfgArgs returns the args for the default server-side repo config,
+// which the config files are layered over.
+func newGlobalCfgArgs(userConfig UserConfig) valid.GlobalCfgArgs {
+	return valid.GlobalCfgArgs{
+		AllowRepoCfg:       userConfig.AllowRepoConfig,
+		MergeableReq:       userConfig.RequireMergeable,
+		ApprovedReq:        userConfig.RequireApproval,
+		UnDivergedReq:      userConfig.RequireUnDiverged,
+		PolicyCheckEnabled: userConfig.EnablePolicyChecksFlag,
+		ValidateEnabled:    userConfig.EnableValidateStep,
+	}
+}
+
+// splitRepoConfigFiles returns the files in --repo-config.
+func splitRepoConfigFiles(repoConfig string) []string {
+	var files []string
+	if repoConfig != "" {
+		for _, f := range strings.Split(repoConfig, ",") {
+			files = append(files, strings.TrimSpace(f))
+		}
+	}
+	return files
+}
+
+// checkAgentPools returns an error if a repo in globalCfg sets agent_pool but
+// agents are disabled.
+func checkAgentPools(globalCfg valid.GlobalCfg, agentsEnabled bool) error {
+	for _, repo := range globalCfg.Repos {
+		if repo.AgentPool != "" && !agentsEnabled {
+			return fmt.Errorf("repo %s sets agent_pool but --agent-port isn't set", repo.IDString())
+		}
+	}
+	return nil
+}
+
+// checkCloudCredentials returns an error if a repo in globalCfg sets
+// cloud_credentials but Atlantis can't issue OIDC tokens.
+func checkCloudCredentials(globalCfg valid.GlobalCfg, oidcIssuer *oidc.Issuer) error {
+	for _, repo := range globalCfg.Repos {
+		if repo.CloudCredentials != nil && oidcIssuer == nil {
+			return fmt.Errorf("repo %s sets cloud_credentials but --oidc-signing-key-file isn't set", repo.IDString())
+		}
+	}
+	return nil
+}
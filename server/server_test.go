@@ -60,6 +60,21 @@ func TestNewServer_InvalidAtlantisURL(t *testing.T) {
 	ErrEquals(t, "parsing --atlantis-url flag \"example.com\": http or https must be specified", err)
 }
 
+func TestValidateRepoConfig(t *testing.T) {
+	config := server.Config{RepoConfigJSONFlag: "repo-config-json"}
+	warnings, err := server.ValidateRepoConfig(server.UserConfig{
+		RepoConfigJSON: `{"repos":[{"id":"github.com/owner/repo"},{"id":"/gitlab.com/.*/"}]}`,
+		RepoAllowlist:  "github.com/owner/repo, github.com/owner/other",
+	}, config)
+	Ok(t, err)
+	Equals(t, []string{"repo id /gitlab.com/.*/ doesn't match any repo in --repo-allowlist"}, warnings)
+
+	_, err = server.ValidateRepoConfig(server.UserConfig{
+		RepoConfigJSON: `{"repos":[{"id":"github.com/owner/repo","workflow":"missing"}]}`,
+	}, config)
+	ErrContains(t, "parsing --repo-config-json", err)
+}
+
 func TestIndex_LockErr(t *testing.T) {
 	t.Log("index should return a 503 if unable to list locks")
 	RegisterMockTestingT(t)
@@ -104,6 +119,7 @@ func TestIndex_Success(t *testing.T) {
 	s := server.Server{
 		Locker:          l,
 		ApplyLocker:     al,
+		Freezer:         mocks.NewMockFreezer(),
 		IndexTemplate:   it,
 		Router:          r,
 		AtlantisVersion: atlantisVersion,
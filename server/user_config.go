@@ -1,6 +1,12 @@
 package server
 
 import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/controllers"
 	"github.com/runatlantis/atlantis/server/logging"
 )
 
@@ -8,32 +14,77 @@ import (
 // The mapstructure tags correspond to flags in cmd/server.go and are used when
 // the config is parsed from a YAML file.
 type UserConfig struct {
+	AgentPort                       int    `mapstructure:"agent-port"`
+	AgentToken                      string `mapstructure:"agent-token"`
+	AlertApplyBranchRegex           string `mapstructure:"alert-apply-branch-regex"`
+	AlertDriftProjectRegex          string `mapstructure:"alert-drift-project-regex"`
 	AllowForkPRs                    bool   `mapstructure:"allow-fork-prs"`
 	AllowRepoConfig                 bool   `mapstructure:"allow-repo-config"`
+	AnalyticsBatchSize              int    `mapstructure:"analytics-batch-size"`
+	AnalyticsFlushInterval          string `mapstructure:"analytics-flush-interval"`
+	AnalyticsSink                   string `mapstructure:"analytics-sink"`
+	AnnotationEnvironmentRegex      string `mapstructure:"annotation-environment-regex"`
+	AnnotationWebhookURL            string `mapstructure:"annotation-webhook-url"`
+	ApplyCommentOutputs             string `mapstructure:"apply-comment-outputs"`
+	ApplyHeartbeatInterval          string `mapstructure:"apply-heartbeat-interval"`
+	ArtifactStore                   string `mapstructure:"artifact-store"`
 	AtlantisURL                     string `mapstructure:"atlantis-url"`
 	Automerge                       bool   `mapstructure:"automerge"`
 	AutoplanFileList                string `mapstructure:"autoplan-file-list"`
+	AutoplanFmt                     string `mapstructure:"autoplan-fmt"`
 	AzureDevopsToken                string `mapstructure:"azuredevops-token"`
 	AzureDevopsUser                 string `mapstructure:"azuredevops-user"`
 	AzureDevopsWebhookPassword      string `mapstructure:"azuredevops-webhook-password"`
 	AzureDevopsWebhookUser          string `mapstructure:"azuredevops-webhook-user"`
 	AzureDevOpsHostname             string `mapstructure:"azuredevops-hostname"`
 	BitbucketBaseURL                string `mapstructure:"bitbucket-base-url"`
+	BitbucketCABundle               string `mapstructure:"bitbucket-ca-bundle"`
+	BitbucketOAuthClientID          string `mapstructure:"bitbucket-oauth-client-id"`
+	BitbucketOAuthClientSecret      string `mapstructure:"bitbucket-oauth-client-secret"`
+	BitbucketProxy                  string `mapstructure:"bitbucket-proxy"`
 	BitbucketToken                  string `mapstructure:"bitbucket-token"`
 	BitbucketUser                   string `mapstructure:"bitbucket-user"`
 	BitbucketWebhookSecret          string `mapstructure:"bitbucket-webhook-secret"`
+	BitbucketWorkspaceToken         string `mapstructure:"bitbucket-workspace-token"`
+	CancelSupersededPlans           bool   `mapstructure:"cancel-superseded-plans"`
+	CheckoutDepth                   int    `mapstructure:"checkout-depth"`
+	CheckoutFilter                  string `mapstructure:"checkout-filter"`
 	CheckoutStrategy                string `mapstructure:"checkout-strategy"`
+	CheckRemoteStates               bool   `mapstructure:"check-remote-states"`
+	CloudEventsSinks                string `mapstructure:"cloudevents-sinks"`
+	CommitStatusGranularity         string `mapstructure:"commit-status-granularity"`
+	CommitStatusNameTemplate        string `mapstructure:"commit-status-name-template"`
 	DataDir                         string `mapstructure:"data-dir"`
+	DebugAdminTeams                 string `mapstructure:"debug-admin-teams"`
+	DependencyUpgradeInterval       string `mapstructure:"dependency-upgrade-interval"`
+	DependencyUpgradeRepos          string `mapstructure:"dependency-upgrade-repos"`
+	Diagnose                        bool   `mapstructure:"diagnose"`
 	DisableApplyAll                 bool   `mapstructure:"disable-apply-all"`
 	DisableApply                    bool   `mapstructure:"disable-apply"`
 	DisableAutoplan                 bool   `mapstructure:"disable-autoplan"`
 	DisableMarkdownFolding          bool   `mapstructure:"disable-markdown-folding"`
 	DisableRepoLocking              bool   `mapstructure:"disable-repo-locking"`
+	EnableCommentReactions          bool   `mapstructure:"enable-comment-reactions"`
+	EnableFmtFix                    bool   `mapstructure:"enable-fmt-fix"`
 	EnablePolicyChecksFlag          bool   `mapstructure:"enable-policy-checks"`
 	EnableRegExpCmd                 bool   `mapstructure:"enable-regexp-cmd"`
+	EnableStatusBadges              bool   `mapstructure:"enable-status-badges"`
+	EnableGithubDeployments         bool   `mapstructure:"enable-github-deployments"`
+	EnablePullStacks                bool   `mapstructure:"enable-pull-stacks"`
+	EnablePlanSummaryComment        bool   `mapstructure:"enable-plan-summary-comment"`
+	EnableStatusComment             bool   `mapstructure:"enable-status-comment"`
+	EnableValidateStep              bool   `mapstructure:"enable-validate-step"`
 	EnableDiffMarkdownFormat        bool   `mapstructure:"enable-diff-markdown-format"`
+	FilterPlanOutput                bool   `mapstructure:"filter-plan-output"`
+	GerritBaseURL                   string `mapstructure:"gerrit-base-url"`
+	GerritToken                     string `mapstructure:"gerrit-token"`
+	GerritUser                      string `mapstructure:"gerrit-user"`
+	GerritWebhookSecret             string `mapstructure:"gerrit-webhook-secret"`
+	GithubAdditionalHosts           string `mapstructure:"gh-additional-hosts"`
 	GithubAllowMergeableBypassApply bool   `mapstructure:"gh-allow-mergeable-bypass-apply"`
+	GithubCABundle                  string `mapstructure:"gh-ca-bundle"`
 	GithubHostname                  string `mapstructure:"gh-hostname"`
+	GithubProxy                     string `mapstructure:"gh-proxy"`
 	GithubToken                     string `mapstructure:"gh-token"`
 	GithubUser                      string `mapstructure:"gh-user"`
 	GithubWebhookSecret             string `mapstructure:"gh-webhook-secret"`
@@ -43,18 +94,46 @@ type UserConfig struct {
 	GithubAppKeyFile                string `mapstructure:"gh-app-key-file"`
 	GithubAppSlug                   string `mapstructure:"gh-app-slug"`
 	GithubTeamAllowlist             string `mapstructure:"gh-team-allowlist"`
+	GitlabCABundle                  string `mapstructure:"gitlab-ca-bundle"`
 	GitlabHostname                  string `mapstructure:"gitlab-hostname"`
+	GitlabProxy                     string `mapstructure:"gitlab-proxy"`
 	GitlabToken                     string `mapstructure:"gitlab-token"`
 	GitlabUser                      string `mapstructure:"gitlab-user"`
+	GitlabWebhookGroups             string `mapstructure:"gitlab-webhook-groups"`
 	GitlabWebhookSecret             string `mapstructure:"gitlab-webhook-secret"`
+	GrafanaAPIKey                   string `mapstructure:"grafana-api-key"`
+	GrafanaURL                      string `mapstructure:"grafana-url"`
+	GRPCAPIPort                     int    `mapstructure:"grpc-api-port"`
 	APISecret                       string `mapstructure:"api-secret"`
 	HidePrevPlanComments            bool   `mapstructure:"hide-prev-plan-comments"`
+	JiraApprovedStatus              string `mapstructure:"jira-approved-status"`
+	JiraCloseTransition             string `mapstructure:"jira-close-transition"`
+	JiraIssueType                   string `mapstructure:"jira-issue-type"`
+	JiraProject                     string `mapstructure:"jira-project"`
+	JiraToken                       string `mapstructure:"jira-token"`
+	JiraURL                         string `mapstructure:"jira-url"`
+	JiraUser                        string `mapstructure:"jira-user"`
+	JobMaxLogBytes                  int    `mapstructure:"job-max-log-bytes"`
+	JobRetention                    string `mapstructure:"job-retention"`
+	Locale                          string `mapstructure:"locale"`
 	LockingDBType                   string `mapstructure:"locking-db-type"`
 	LogLevel                        string `mapstructure:"log-level"`
+	MarkdownTemplateDir             string `mapstructure:"markdown-template-dir"`
+	MaxConcurrentApplies            int    `mapstructure:"max-concurrent-applies"`
+	MaxPlanAge                      string `mapstructure:"max-plan-age"`
+	MinFreeDiskMB                   int    `mapstructure:"min-free-disk-mb"`
+	OIDCSigningKeyFile              string `mapstructure:"oidc-signing-key-file"`
+	OpsgenieAPIKey                  string `mapstructure:"opsgenie-api-key"`
+	OpsgenieURL                     string `mapstructure:"opsgenie-url"`
+	PagerDutyRoutingKey             string `mapstructure:"pagerduty-routing-key"`
 	ParallelPoolSize                int    `mapstructure:"parallel-pool-size"`
+	PlanSigningKey                  string `mapstructure:"plan-signing-key"`
 	StatsNamespace                  string `mapstructure:"stats-namespace"`
 	PlanDrafts                      bool   `mapstructure:"allow-draft-prs"`
 	Port                            int    `mapstructure:"port"`
+	ProjectStatusNameTemplate       string `mapstructure:"project-status-name-template"`
+	ProvidersLockPlatforms          string `mapstructure:"providers-lock-platforms"`
+	ProvisionWebhooks               bool   `mapstructure:"provision-webhooks"`
 	RedisDB                         int    `mapstructure:"redis-db"`
 	RedisHost                       string `mapstructure:"redis-host"`
 	RedisPassword                   string `mapstructure:"redis-password"`
@@ -62,6 +141,9 @@ type UserConfig struct {
 	RedisTLSEnabled                 bool   `mapstructure:"redis-tls-enabled"`
 	RedisInsecureSkipVerify         bool   `mapstructure:"redis-insecure-skip-verify"`
 	RepoConfig                      string `mapstructure:"repo-config"`
+	RepoConfigEnvAllowlist          string `mapstructure:"repo-config-env-allowlist"`
+	RepoConfigGit                   string `mapstructure:"repo-config-git"`
+	RepoConfigReloadInterval        string `mapstructure:"repo-config-reload-interval"`
 	RepoConfigJSON                  string `mapstructure:"repo-config-json"`
 	RepoAllowlist                   string `mapstructure:"repo-allowlist"`
 	// RepoWhitelist is deprecated in favour of RepoAllowlist.
@@ -77,8 +159,16 @@ type UserConfig struct {
 	SilenceNoProjects bool `mapstructure:"silence-no-projects"`
 	// RequireUnDiverged is whether to require pull requests to rebase default branch before
 	// allowing terraform apply's to run.
-	RequireUnDiverged   bool `mapstructure:"require-undiverged"`
-	SilenceForkPRErrors bool `mapstructure:"silence-fork-pr-errors"`
+	RequireUnDiverged bool `mapstructure:"require-undiverged"`
+	// ScanSecrets is whether to scan the files that pull requests change for
+	// committed credentials before planning them.
+	ScanSecrets bool `mapstructure:"scan-secrets"`
+	// SecretsDir is the dir with the secrets that step env vars refer to.
+	SecretsDir          string `mapstructure:"secrets-dir"`
+	ServiceNowPassword  string `mapstructure:"servicenow-password"`
+	ServiceNowURL       string `mapstructure:"servicenow-url"`
+	ServiceNowUser      string `mapstructure:"servicenow-user"`
+	SilenceForkPRErrors bool   `mapstructure:"silence-fork-pr-errors"`
 	// SilenceVCSStatusNoPlans is whether autoplan should set commit status if no plans
 	// are found.
 	SilenceVCSStatusNoPlans bool `mapstructure:"silence-vcs-status-no-plans"`
@@ -90,12 +180,20 @@ type UserConfig struct {
 	SilenceWhitelistErrors bool            `mapstructure:"silence-whitelist-errors"`
 	SkipCloneNoChanges     bool            `mapstructure:"skip-clone-no-changes"`
 	SlackToken             string          `mapstructure:"slack-token"`
+	SSHClone               bool            `mapstructure:"ssh-clone"`
+	SSHKeyFile             string          `mapstructure:"ssh-key-file"`
+	SSHKnownHostsFile      string          `mapstructure:"ssh-known-hosts-file"`
 	SSLCertFile            string          `mapstructure:"ssl-cert-file"`
 	SSLKeyFile             string          `mapstructure:"ssl-key-file"`
+	StateLockAdminTeams    string          `mapstructure:"state-lock-admin-teams"`
+	StateSnapshotKey       string          `mapstructure:"state-snapshot-key"`
+	StateLockRetries       int             `mapstructure:"state-lock-retries"`
+	TFChecksumsFile        string          `mapstructure:"tf-checksums-file"`
 	TFDownloadURL          string          `mapstructure:"tf-download-url"`
 	TFEHostname            string          `mapstructure:"tfe-hostname"`
 	TFELocalExecutionMode  bool            `mapstructure:"tfe-local-execution-mode"`
 	TFEToken               string          `mapstructure:"tfe-token"`
+	ValidateConfig         bool            `mapstructure:"validate-config"`
 	VarFileAllowlist       string          `mapstructure:"var-file-allowlist"`
 	VCSStatusName          string          `mapstructure:"vcs-status-name"`
 	DefaultTFVersion       string          `mapstructure:"default-tf-version"`
@@ -121,3 +219,92 @@ func (u UserConfig) ToLogLevel() logging.LogLevel {
 	}
 	return logging.Info
 }
+
+// GithubHost is a GitHub host that Atlantis runs on in addition to the one
+// set by --gh-hostname, ex. a GitHub Enterprise instance while migrating
+// from it to github.com.
+type GithubHost struct {
+	Hostname string `json:"hostname"`
+	User     string `json:"user"`
+	Token    string `json:"token"`
+}
+
+// ToGithubAdditionalHosts parses GithubAdditionalHosts.
+func (u UserConfig) ToGithubAdditionalHosts() ([]GithubHost, error) {
+	if u.GithubAdditionalHosts == "" {
+		return nil, nil
+	}
+	var hosts []GithubHost
+	if err := json.Unmarshal([]byte(u.GithubAdditionalHosts), &hosts); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{u.GithubHostname: true}
+	for _, host := range hosts {
+		if host.Hostname == "" || host.User == "" || host.Token == "" {
+			return nil, fmt.Errorf("hostname, user and token are required for each host")
+		}
+		if seen[host.Hostname] {
+			return nil, fmt.Errorf("host %q is configured more than once", host.Hostname)
+		}
+		seen[host.Hostname] = true
+	}
+	return hosts, nil
+}
+
+// Redacted returns the config keyed by flag name for support bundles, with
+// secrets like tokens and passwords replaced. It also returns the secrets so
+// they can be redacted wherever else they appear.
+func (u UserConfig) Redacted() (map[string]interface{}, []string) {
+	var secrets []string
+	config := redactStruct(reflect.ValueOf(u), &secrets)
+	// The additional hosts are JSON that includes their tokens.
+	if hosts, err := u.ToGithubAdditionalHosts(); err == nil {
+		for _, host := range hosts {
+			secrets = append(secrets, host.Token)
+		}
+	} else {
+		config["gh-additional-hosts"] = controllers.Redacted
+	}
+	return config, secrets
+}
+
+// redactStruct returns the fields of v keyed by their mapstructure tags with
+// the secrets replaced and appended to secrets.
+func redactStruct(v reflect.Value, secrets *[]string) map[string]interface{} {
+	config := make(map[string]interface{}, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Tag.Get("mapstructure")
+		if name == "" {
+			continue
+		}
+		field := v.Field(i)
+		switch {
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct:
+			items := make([]interface{}, 0, field.Len())
+			for j := 0; j < field.Len(); j++ {
+				items = append(items, redactStruct(field.Index(j), secrets))
+			}
+			config[name] = items
+		case field.Kind() == reflect.String && field.String() != "" && isSecretFlag(name):
+			*secrets = append(*secrets, field.String())
+			config[name] = controllers.Redacted
+		default:
+			config[name] = field.Interface()
+		}
+	}
+	return config
+}
+
+// isSecretFlag returns true if the flag's value is a secret. Flags with the
+// path to a secret, ex. --gh-app-key-file, aren't secrets themselves.
+func isSecretFlag(name string) bool {
+	if strings.HasSuffix(name, "-file") || strings.HasSuffix(name, "-dir") {
+		return false
+	}
+	for _, word := range []string{"token", "secret", "password", "key"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
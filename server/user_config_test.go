@@ -44,3 +44,30 @@ func TestUserConfig_ToLogLevel(t *testing.T) {
 		})
 	}
 }
+
+func TestUserConfig_Redacted(t *testing.T) {
+	u := server.UserConfig{
+		GithubUser:            "atlantis",
+		GithubToken:           "ghp_token",
+		GithubAppKeyFile:      "/keys/app.pem",
+		RedisPassword:         "hunter22",
+		GithubAdditionalHosts: `[{"hostname":"ghe.example.com","user":"atlantis","token":"ghe_token"}]`,
+		Port:                  4141,
+		Webhooks: []server.WebhookConfig{
+			{Event: "apply", Kind: "slack", Channel: "ops"},
+		},
+	}
+	config, secrets := u.Redacted()
+	Equals(t, "atlantis", config["gh-user"])
+	Equals(t, "<redacted>", config["gh-token"])
+	Equals(t, "/keys/app.pem", config["gh-app-key-file"])
+	Equals(t, "<redacted>", config["redis-password"])
+	Equals(t, "", config["gitlab-token"])
+	Equals(t, 4141, config["port"])
+	Equals(t, "ops", config["webhooks"].([]interface{})[0].(map[string]interface{})["channel"])
+	Equals(t, []string{"ghp_token", "hunter22", "ghe_token"}, secrets)
+
+	u.GithubAdditionalHosts = `[{"hostname":"ghe.example.com","token":"ghe_token"`
+	config, _ = u.Redacted()
+	Equals(t, "<redacted>", config["gh-additional-hosts"])
+}